@@ -2,23 +2,57 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/base64"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/openmeet-team/survey/internal/analytics"
 	"github.com/openmeet-team/survey/internal/api"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/archive"
+	"github.com/openmeet-team/survey/internal/attestation"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/captcha"
+	"github.com/openmeet-team/survey/internal/consumer"
 	"github.com/openmeet-team/survey/internal/db"
+	"github.com/openmeet-team/survey/internal/dedup"
+	"github.com/openmeet-team/survey/internal/draft"
+	"github.com/openmeet-team/survey/internal/export"
 	"github.com/openmeet-team/survey/internal/generator"
+	"github.com/openmeet-team/survey/internal/heatmap"
+	"github.com/openmeet-team/survey/internal/idempotency"
+	"github.com/openmeet-team/survey/internal/identity"
+	"github.com/openmeet-team/survey/internal/interop"
+	"github.com/openmeet-team/survey/internal/jobs"
+	"github.com/openmeet-team/survey/internal/moderation"
+	"github.com/openmeet-team/survey/internal/notify"
 	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/preview"
+	"github.com/openmeet-team/survey/internal/provenance"
+	"github.com/openmeet-team/survey/internal/receipt"
+	"github.com/openmeet-team/survey/internal/reminder"
+	"github.com/openmeet-team/survey/internal/report"
+	"github.com/openmeet-team/survey/internal/review"
+	"github.com/openmeet-team/survey/internal/serviceauth"
+	"github.com/openmeet-team/survey/internal/snapshot"
+	"github.com/openmeet-team/survey/internal/spam"
+	"github.com/openmeet-team/survey/internal/status"
+	"github.com/openmeet-team/survey/internal/storage"
 	"github.com/openmeet-team/survey/internal/telemetry"
 	"github.com/openmeet-team/survey/internal/templates"
+	"github.com/openmeet-team/survey/internal/trash"
+	"github.com/openmeet-team/survey/internal/trending"
+	"github.com/openmeet-team/survey/internal/vanity"
 	"github.com/tmc/langchaingo/llms/openai"
 )
 
@@ -54,16 +88,57 @@ func main() {
 
 	log.Println("Connected to database successfully")
 
+	// Apply pending migrations on startup (AUTO_MIGRATE=true)
+	if autoMigrate, _ := strconv.ParseBool(os.Getenv("AUTO_MIGRATE")); autoMigrate {
+		applied, err := db.Migrate(ctx, database)
+		if err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		log.Printf("Applied %d database migrations", applied)
+	}
+
 	// Create database queries instance
 	queries := db.NewQueries(database)
 
+	// Route survey, results, and stats reads to read replicas (DATABASE_REPLICAS)
+	var replicas *db.Replicas
+	var replicaDBs []*sql.DB
+	if len(dbConfig.Replicas) > 0 {
+		replicaDBs, err = db.ConnectReplicas(ctx, dbConfig)
+		if err != nil {
+			log.Fatalf("Failed to connect to database replicas: %v", err)
+		}
+		replicaQueriers := make([]db.SQLQuerier, len(replicaDBs))
+		for i, replica := range replicaDBs {
+			defer db.Close(replica)
+			replicaQueriers[i] = replica
+		}
+		replicas = db.NewReplicas(replicaQueriers...)
+		queries = queries.WithReplicas(replicas)
+		log.Printf("Reading from %d database replicas", len(replicaDBs))
+	}
+
 	// Create Echo instance
 	e := echo.New()
 
+	// Session cookie attributes and path prefix (PUBLIC_BASE_URL, SESSION_COOKIE_*)
+	cookieConfig := oauth.CookieConfigFromEnv()
+	oauth.SetCookieConfig(cookieConfig)
+	templates.SetBasePath(cookieConfig.BasePath)
+	templates.SetPublicURL(oauth.PublicBaseURL())
+	if cookieConfig.BasePath != "" {
+		e.Pre(api.BasePathMiddleware(cookieConfig.BasePath))
+		log.Printf("Serving under path prefix %s", cookieConfig.BasePath)
+	}
+	log.Printf("Session cookie %q (domain=%q, secure=%t)", cookieConfig.Name, cookieConfig.Domain, cookieConfig.Secure)
+
 	// Basic middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 
+	// Server timeouts and slow request logging (HTTP_*_TIMEOUT, SLOW_REQUEST_*)
+	api.ServerConfigFromEnv().Apply(e)
+
 	// Create OAuth storage for session management
 	oauthStorage := oauth.NewStorage(database)
 
@@ -71,6 +146,22 @@ func main() {
 	cleanupCtx, cancelCleanup := context.WithCancel(ctx)
 	go oauth.StartCleanupWorker(cleanupCtx, oauthStorage, 1*time.Hour)
 
+	// Check replica health, so failed replicas stop receiving reads and recovered ones resume
+	go replicas.Run(cleanupCtx, db.DefaultReplicaCheckInterval)
+
+	// Export connection pool stats (DATABASE_MAX_OPEN_CONNS and friends size the pools)
+	go db.RunPoolStats(cleanupCtx, db.Pools(database, replicaDBs...), db.DefaultPoolStatsInterval)
+
+	// A SQLite deployment is a single binary, so the API server also consumes
+	// Jetstream. A separate consumer process would wait on standby.
+	if dbConfig.Driver == db.DriverSQLite {
+		go func() {
+			if err := consumer.RunFromEnv(cleanupCtx, database, queries); err != nil {
+				log.Printf("Consumer error: %v", err)
+			}
+		}()
+	}
+
 	// Initialize AI survey generator if OpenAI API key is configured
 	var surveyGenerator *generator.SurveyGenerator
 	var generatorRateLimiter *generator.RateLimiter
@@ -99,11 +190,11 @@ func main() {
 	// Create generation logger
 	generationLogger := generator.NewGenerationLogger(queries)
 
-	// Create OAuth config (optional - requires OAUTH_SECRET_JWK_B64 and SERVER_HOST env vars)
+	// Create OAuth config (optional - requires OAUTH_SECRET_JWK_B64 and PUBLIC_BASE_URL or SERVER_HOST env vars)
 	var oauthConfig *oauth.Config
 	var oauthHandlers *oauth.Handlers
 	secretJWKB64 := os.Getenv("OAUTH_SECRET_JWK_B64")
-	host := os.Getenv("SERVER_HOST")
+	host := oauth.PublicBaseURL()
 	if secretJWKB64 != "" && host != "" {
 		// Decode base64 JWK
 		secretJWKBytes, err := base64.StdEncoding.DecodeString(secretJWKB64)
@@ -117,16 +208,297 @@ func main() {
 		oauthHandlers = oauth.NewHandlers(database, *oauthConfig)
 		log.Println("OAuth handlers initialized")
 	} else {
-		log.Println("OAuth disabled (OAUTH_SECRET_JWK_B64 and SERVER_HOST not configured)")
+		log.Println("OAuth disabled (OAUTH_SECRET_JWK_B64 and PUBLIC_BASE_URL/SERVER_HOST not configured)")
 	}
 
 	// Create handlers with OAuth storage, config, and optional AI generator
 	handlers := api.NewHandlersWithOAuth(queries, oauthStorage, oauthConfig)
+	handlers.SetSessions(oauthStorage)
+	handlers.SetAccountData(queries)
 	if surveyGenerator != nil && generatorRateLimiter != nil {
 		handlers.SetGenerator(surveyGenerator, generatorRateLimiter)
 		handlers.SetLogger(generationLogger)
 	}
-	healthHandlers := api.NewHealthHandlers(database)
+	healthHandlers := api.NewHealthHandlers(db.PoolDB{DB: database})
+
+	// Show handles and display names instead of DIDs (cached in the identities table)
+	handlers.SetIdentityResolver(identity.NewResolver(queries, identity.TTLFromEnv()))
+
+	// Show a verified badge for authors whose handle proves back to their DID
+	verifier := identity.NewVerifier(queries, identity.TTLFromEnv())
+	handlers.SetIdentityVerifier(verifier)
+
+	// Identify this AppView in published results and results page attribution
+	appView := provenance.ConfigFromEnv()
+	handlers.SetProvenance(appView)
+
+	// Authenticate XRPC calls with ATProto service auth tokens addressed to the AppView's DID
+	if appView.AppViewDID != "" {
+		handlers.SetServiceAuth(serviceauth.NewVerifier(appView.AppViewDID))
+		log.Printf("XRPC service auth enabled for %s", appView.AppViewDID)
+	}
+
+	// Signed vote receipts (requires RECEIPT_SECRET, shared by all replicas)
+	if receipts := receipt.NewFromConfig(receipt.ConfigFromEnv()); receipts.Enabled() {
+		handlers.SetReceiptSigner(receipts)
+		log.Println("Vote receipts enabled")
+	}
+
+	// Signed results records, with the public keys at /.well-known/results-signing-keys.json (RESULTS_SIGNING_KEY)
+	resultsSigner, err := attestation.NewFromConfig(attestation.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Failed to configure results signing: %v", err)
+	}
+	if resultsSigner.Enabled() {
+		handlers.SetResultsSigner(resultsSigner)
+		log.Printf("Results signing enabled with key %s", resultsSigner.KeyID())
+	}
+
+	// Sign reviewed answers of surveys with a review step (REVIEW_SECRET, shared by all replicas)
+	if reviewConfig := review.ConfigFromEnv(); reviewConfig.Secret != "" {
+		handlers.SetReviewSigner(review.NewFromConfig(reviewConfig))
+	}
+
+	// Also publish new single-question surveys in another app's poll lexicon
+	if collection := interop.CrossPublishCollectionFromEnv(); collection != "" {
+		handlers.SetCrossPublishCollection(collection)
+		log.Printf("Cross-publishing polls to %s", collection)
+	}
+
+	// Queue records whose PDS write failed so users can retry publishing them
+	handlers.SetOutbox(queries)
+
+	// API keys for machine clients (API_KEY_REQUIRED=true rejects anonymous API requests)
+	apiKeyConfig := apikey.ConfigFromEnv()
+	apiKeyUsage := apikey.NewUsageCounter(queries)
+	handlers.SetAPIKeys(queries, apiKeyConfig, apiKeyUsage)
+	handlers.SetGenerationUsageStore(queries)
+	go apiKeyUsage.Run(cleanupCtx, time.Minute)
+	if apiKeyConfig.Required {
+		log.Println("API keys required for /api/v1")
+	}
+
+	// Survey page views, counted per hour and referrer for the analytics of authors
+	surveyViews := analytics.NewViewCounter(queries)
+	handlers.SetAnalytics(queries, surveyViews)
+	go surveyViews.Run(cleanupCtx, time.Minute)
+
+	// CAPTCHAs for anonymous callers close to the generation and vote limits (requires CAPTCHA_SECRET)
+	if captchaConfig := captcha.ConfigFromEnv(); captchaConfig.Secret != "" {
+		verifier, err := captcha.New(captchaConfig)
+		if err != nil {
+			log.Fatalf("Failed to configure CAPTCHA: %v", err)
+		}
+		handlers.SetCaptcha(verifier)
+		log.Printf("CAPTCHA enabled (%s)", captchaConfig.Provider)
+	}
+
+	// Drafts autosaved by the create page and the voting form (DRAFT_SECRET signs guests' draft cookies, shared by all replicas)
+	draftGuests := draft.NewGuests(draft.ConfigFromEnv())
+	handlers.SetDrafts(queries, draftGuests)
+	handlers.SetResponseDrafts(queries, draftGuests)
+	go draft.StartCleanupWorker(cleanupCtx, queries, queries, time.Hour)
+
+	// Previews of survey definitions before publishing, deleted an hour after creation
+	handlers.SetPreviews(queries)
+	templates.SetPreviewsEnabled(true)
+	go preview.StartCleanupWorker(cleanupCtx, queries, 10*time.Minute)
+
+	// Many surveys created in one request, e.g. one per session of an event
+	handlers.SetBulkSurveys(queries)
+
+	// Questions users save to reuse in new surveys
+	handlers.SetQuestionBank(queries)
+	templates.SetQuestionBankEnabled(true)
+
+	// Voter cookies blocking second guest votes from a browser (VOTER_SECRET signs them, shared by all replicas;
+	// DEDUP_FINGERPRINT=true also flags guest votes with the same request headers)
+	dedupConfig := dedup.ConfigFromEnv()
+	handlers.SetDedup(queries, dedup.NewVoters(dedupConfig))
+	if dedupConfig.Fingerprint {
+		log.Println("Duplicate vote fingerprints enabled")
+	}
+
+	// Spam scores of guest votes, which authors can leave out of results above a threshold
+	// (SPAM_IP_RANGES lists disposable IP ranges)
+	spamScorer, err := spam.NewScorer(spam.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Failed to configure spam scoring: %v", err)
+	}
+	handlers.SetSpam(queries, spamScorer)
+
+	// Responses by country and hour of day for surveys with responseMetadata
+	// (GEOIP_DB is a CSV of IP ranges and countries; GEOIP_HEADER a CDN's country header)
+	heatmapConfig := heatmap.ConfigFromEnv()
+	locator, err := heatmap.NewLocator(heatmapConfig)
+	if err != nil {
+		log.Fatalf("Failed to configure GeoIP: %v", err)
+	}
+	handlers.SetHeatmap(queries, locator)
+	templates.SetHeatmapEnabled(true)
+	if heatmapConfig.DB == "" && heatmapConfig.Header == "" {
+		log.Println("GeoIP not configured; response heatmaps count every country as unknown")
+	}
+
+	// Cache hot surveys and results (CACHE_BACKEND=memory or redis)
+	cacheConfig := cache.ConfigFromEnv()
+	cacheStore, err := cache.NewFromConfig(cacheConfig)
+	if err != nil {
+		log.Fatalf("Failed to configure cache: %v", err)
+	}
+	if cacheStore.Enabled() {
+		handlers.SetCache(cacheStore, queries.Primary())
+		log.Printf("Survey cache enabled (%s backend, TTL %s)", cacheConfig.Backend, cacheConfig.TTL)
+	}
+
+	// Cache rendered social card images (CARD_CACHE_BACKEND=disk, redis, or memory)
+	cardCacheConfig := cache.CardConfigFromEnv()
+	cardCache, err := cache.NewFromConfig(cardCacheConfig)
+	if err != nil {
+		log.Fatalf("Failed to configure card cache: %v", err)
+	}
+	if cardCache.Enabled() {
+		handlers.SetCardCache(cardCache)
+		log.Printf("Card cache enabled (%s backend, TTL %s)", cardCacheConfig.Backend, cardCacheConfig.TTL)
+	}
+
+	// Start status sampler for the public status page (runs every 5 minutes)
+	handlers.SetStatusStore(queries)
+	statusSampler := status.NewSampler(queries, database, func(ctx context.Context) (int64, error) {
+		return consumer.GetEventTime(ctx, queries)
+	}, status.SamplerConfig{
+		AIEnabled: surveyGenerator != nil,
+	})
+	go status.StartSampler(cleanupCtx, statusSampler, 5*time.Minute)
+
+	// Text answer moderation (blocklist and optional OpenAI moderation API)
+	moderator := moderation.NewFromConfig(moderation.ConfigFromEnv())
+	var adminDIDs []string
+	if v := os.Getenv("ADMIN_DIDS"); v != "" {
+		for _, did := range strings.Split(v, ",") {
+			if did = strings.TrimSpace(did); did != "" {
+				adminDIDs = append(adminDIDs, did)
+			}
+		}
+	}
+	handlers.SetAdmins(adminDIDs)
+	if moderator.Enabled() {
+		handlers.SetModeration(moderator, queries)
+		log.Printf("Text answer moderation enabled (%d admin DIDs)", len(adminDIDs))
+	}
+
+	// Service statistics dashboard for the admin DIDs
+	handlers.SetAdminStats(queries, func(ctx context.Context) (int64, error) {
+		return consumer.GetEventTime(ctx, queries)
+	})
+
+	// Abuse reports of surveys, reviewed by the admin DIDs
+	reportConfig := report.ConfigFromEnv()
+	handlers.SetReports(queries, reportConfig)
+	log.Printf("Survey reports enabled (surveys hidden after %d reporters)", reportConfig.HideThreshold)
+
+	// Share tokens and one-time invite links opening surveys with token and invite visibility
+	handlers.SetShareTokens(queries)
+	handlers.SetInvites(queries)
+
+	// Response submissions with an Idempotency-Key are replayed instead of repeated
+	handlers.SetIdempotency(queries)
+	go idempotency.StartCleanup(cleanupCtx, queries, idempotency.CleanupInterval)
+
+	// Organizations sharing the ownership of surveys
+	handlers.SetOrgs(queries)
+	templates.SetOrgsEnabled(true)
+
+	// Co-authors who edit surveys and ask their authors to publish results
+	handlers.SetCoAuthors(queries)
+	templates.SetCoAuthorsEnabled(true)
+
+	// Series of surveys compared across waves
+	handlers.SetSeries(queries)
+
+	// Slugs reserved by admins for allow-listed (and optionally verified) authors
+	vanityConfig := vanity.ConfigFromEnv()
+	handlers.SetReservedSlugs(queries, vanity.NewPolicy(vanityConfig, verifier))
+	log.Printf("Reserved slugs enabled (%d allow-listed authors, verified authors: %t)", len(vanityConfig.Authors), vanityConfig.Verified)
+
+	// Partners serving surveys on their own domains, with their branding
+	handlers.SetTenants(queries)
+
+	// Hourly or daily results snapshots that authors opt surveys into
+	handlers.SetSnapshots(queries)
+	templates.SetSnapshotsEnabled(true)
+	go snapshot.StartWorker(cleanupCtx, queries, handlers.TakeSnapshot, time.Minute)
+
+	// Deleted surveys stay in their author's trash for 30 days before they are purged
+	handlers.SetTrash(queries)
+	templates.SetTrashEnabled(true)
+	go trash.StartPurgeWorker(cleanupCtx, queries, time.Hour)
+
+	// Authors' survey lists can be sorted and filtered, and browsed at /my-surveys
+	handlers.SetSurveyList(queries)
+	templates.SetSurveyListEnabled(true)
+
+	// Trending surveys are rescored every few minutes; admins feature others
+	handlers.SetTrending(queries)
+	go trending.StartRefreshWorker(cleanupCtx, queries, trending.RefreshInterval)
+
+	// Atom feeds of new public surveys, site-wide and per author
+	handlers.SetFeeds(queries)
+	templates.SetFeedsEnabled(true)
+
+	// Responses of surveys closed long ago move to archives that keep their results.
+	// Archives made before archival was disabled are still restored for their authors.
+	handlers.SetArchive(queries)
+	if archiveConfig := archive.ConfigFromEnv(); archiveConfig.Enabled() {
+		go archive.StartWorker(cleanupCtx, queries, queries.GetSurveyResults, archiveConfig, archive.Interval)
+		log.Printf("Survey archival enabled (%d days after closing)", int(archiveConfig.After.Hours()/24))
+	}
+
+	// Long-running work runs as jobs from a queue in Postgres, outside request handlers
+	queue := jobs.NewQueue(queries)
+
+	// Response exports are generated by jobs into blob storage, on disk or in an
+	// S3-compatible bucket, and downloaded through signed URLs
+	storageConfig := storage.ConfigFromEnv()
+	storageConfig.URLPrefix = templates.AbsoluteURL("/blobs/")
+	blobs, err := storage.New(storageConfig)
+	if err != nil {
+		log.Fatalf("Failed to configure blob storage: %v", err)
+	}
+	queue.Register(export.JobType, export.Handler(queries, blobs, handlers.BuildExport), jobs.Options{})
+	handlers.SetExports(queries, blobs, queue)
+	go export.StartCleanup(cleanupCtx, queries, blobs, export.CleanupInterval)
+	log.Printf("Blob storage: %s", storageConfig.Backend)
+
+	go queue.Start(cleanupCtx, jobs.PollInterval)
+
+	// Notifications of survey milestones, posted to the author's account or sent as direct
+	// messages from the NOTIFY_BSKY_IDENTIFIER account; links in them need PUBLIC_BASE_URL
+	if templates.PublicURL != "" {
+		var messenger *notify.Messenger
+		if chatConfig := notify.ChatConfigFromEnv(); chatConfig.Enabled() {
+			messenger, err = notify.NewMessenger(chatConfig)
+			if err != nil {
+				log.Fatalf("Failed to configure notification messages: %v", err)
+			}
+			log.Printf("Notification direct messages enabled (from %s)", chatConfig.Identifier)
+		}
+		handlers.SetNotifications(queries, messenger)
+		templates.SetNotificationsEnabled(true)
+		go notify.StartWorker(cleanupCtx, queries, handlers.DeliverNotification, time.Minute)
+
+		// Reminders to survey invitees who have not responded, sent as direct messages
+		if messenger != nil {
+			handlers.SetReminders(queries, messenger)
+			go reminder.StartWorker(cleanupCtx, queries, handlers.DeliverReminder, time.Minute)
+		}
+	}
+
+	// CAR exports of the records results are counted from, for independent recounts
+	handlers.SetAudit(queries)
+
+	// CORS origins and security headers from environment
+	handlers.SetSecurity(api.SecurityConfigFromEnv())
 
 	// Set support URL from environment
 	if supportURL := os.Getenv("SUPPORT_URL"); supportURL != "" {
@@ -173,7 +545,7 @@ func main() {
 
 	log.Println("Shutting down server...")
 
-	// Stop cleanup worker
+	// Stop cleanup worker and status sampler
 	cancelCleanup()
 
 	// Graceful shutdown with timeout
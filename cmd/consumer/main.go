@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -46,6 +47,15 @@ func main() {
 
 	log.Println("Connected to database")
 
+	// Apply pending migrations on startup (AUTO_MIGRATE=true)
+	if autoMigrate, _ := strconv.ParseBool(os.Getenv("AUTO_MIGRATE")); autoMigrate {
+		applied, err := db.Migrate(ctx, database)
+		if err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		log.Printf("Applied %d database migrations", applied)
+	}
+
 	// Create queries instance
 	queries := db.NewQueries(database)
 
@@ -66,15 +76,13 @@ func main() {
 		}
 	}()
 
-	// Build Jetstream URL
-	// Subscribe to survey, response, and results collections
-	// Note: Jetstream requires repeated query params, not comma-separated values
-	jetstreamURL := "wss://jetstream2.us-east.bsky.network/subscribe?wantedCollections=net.openmeet.survey&wantedCollections=net.openmeet.survey.response&wantedCollections=net.openmeet.survey.results"
-
 	// Create context with cancellation for graceful shutdown
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// Export connection pool stats on the metrics server
+	go db.RunPoolStats(ctx, db.Pools(database), db.DefaultPoolStatsInterval)
+
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -82,7 +90,7 @@ func main() {
 	// Run consumer in goroutine
 	errChan := make(chan error, 1)
 	go func() {
-		errChan <- consumer.RunWithReconnect(ctx, jetstreamURL, queries)
+		errChan <- consumer.RunFromEnv(ctx, database, queries)
 	}()
 
 	// Wait for shutdown signal or error
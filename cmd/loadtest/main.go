@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/api"
+	"github.com/openmeet-team/survey/internal/db"
+	"github.com/openmeet-team/survey/internal/loadtest"
+	"github.com/openmeet-team/survey/pkg/client"
+	testcontainers "github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// loadtest creates surveys, submits responses, and reads results at a steady
+// rate, then prints a latency report and exits 1 if an endpoint's p99 is over
+// its budget or requests failed. With -container it runs against a fresh
+// PostgreSQL container and an in-process API server (requires Docker);
+// otherwise against the service at -url, which must trust this machine's
+// X-Forwarded-For headers (see loadtest.Run).
+//
+//	go run ./cmd/loadtest -container
+//	go run ./cmd/loadtest -url http://localhost:8080 -rate 100 -duration 1m
+//	go run ./cmd/loadtest -container -budget submit=150ms -json > report.json
+func main() {
+	log.SetFlags(0)
+	cfg := loadtest.DefaultConfig
+	baseURL := flag.String("url", "http://localhost:8080", "URL of the survey service")
+	apiKey := flag.String("key", os.Getenv("SURVEY_API_KEY"), "API key (sk_...), if the service requires one")
+	container := flag.Bool("container", false, "run against a PostgreSQL container and an in-process API server instead of -url")
+	budget := flag.String("budget", "", "p99 budgets overriding the defaults, e.g. submit=250ms,results=200ms")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.IntVar(&cfg.Rate, "rate", cfg.Rate, "submissions started per second")
+	flag.DurationVar(&cfg.Duration, "duration", cfg.Duration, "how long submissions are started")
+	flag.IntVar(&cfg.Workers, "workers", cfg.Workers, "most requests in flight")
+	flag.IntVar(&cfg.Surveys, "surveys", cfg.Surveys, "surveys created and voted on in turn")
+	flag.IntVar(&cfg.ResultsEvery, "results-every", cfg.ResultsEvery, "one results request after every this many submissions")
+	flag.Parse()
+
+	budgets, err := loadtest.ParseBudgets(*budget)
+	if err != nil {
+		log.Fatalf("Invalid -budget: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid flags: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	passed, err := run(ctx, *baseURL, *apiKey, *container, cfg, budgets, *asJSON)
+	stop()
+	if err != nil {
+		log.Fatalf("Load test failed: %v", err)
+	}
+	if !passed {
+		os.Exit(1)
+	}
+}
+
+// run runs the load test and writes its report to stdout, stopping the test
+// stack (if it started one) before returning
+func run(ctx context.Context, baseURL, apiKey string, container bool, cfg loadtest.Config, budgets loadtest.Budgets, asJSON bool) (bool, error) {
+	if container {
+		url, cleanup, err := startStack(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to start test stack: %w", err)
+		}
+		defer cleanup()
+		baseURL = url
+	}
+
+	log.Printf("Load testing %s at %d submissions/s for %s", baseURL, cfg.Rate, cfg.Duration)
+	report, err := loadtest.Run(ctx, client.New(baseURL, apiKey), cfg, budgets)
+	if report == nil {
+		return false, err
+	}
+	if errors.Is(err, context.Canceled) {
+		log.Printf("Interrupted; reporting the requests so far")
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return false, fmt.Errorf("failed to write report: %w", err)
+		}
+	} else {
+		report.WriteText(os.Stdout)
+	}
+	return report.Passed(), nil
+}
+
+// startStack starts a migrated PostgreSQL container and an API server on a
+// loopback port, and returns the server's URL and a function that stops both
+func startStack(ctx context.Context) (string, func(), error) {
+	log.Printf("Starting PostgreSQL container")
+	postgresC, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("survey_loadtest"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(60*time.Second)),
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	terminate := func() {
+		if err := postgresC.Terminate(context.Background()); err != nil {
+			log.Printf("Failed to terminate container: %v", err)
+		}
+	}
+
+	connStr, err := postgresC.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		terminate()
+		return "", nil, err
+	}
+	dbConn, err := sql.Open("pgx", connStr)
+	if err != nil {
+		terminate()
+		return "", nil, err
+	}
+	if _, err := db.Migrate(ctx, dbConn); err != nil {
+		dbConn.Close()
+		terminate()
+		return "", nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	e := echo.New()
+	e.HideBanner = true
+	api.SetupRoutes(e, api.NewHandlers(db.NewQueries(dbConn)), api.NewHealthHandlers(dbConn), nil, dbConn)
+	server := httptest.NewServer(e)
+
+	return server.URL, func() {
+		server.Close()
+		dbConn.Close()
+		terminate()
+	}, nil
+}
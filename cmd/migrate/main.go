@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/openmeet-team/survey/internal/db"
+)
+
+// migrate applies and reverts the database migrations embedded in the binaries.
+// Uses the same DATABASE_* environment variables as the API server.
+//
+//	go run ./cmd/migrate up        # apply all pending migrations
+//	go run ./cmd/migrate down      # revert the last migration
+//	go run ./cmd/migrate down 3    # revert the last 3 migrations
+//	go run ./cmd/migrate status    # show the schema version and pending migrations
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate up | down [steps] | status")
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+
+	cfg, err := db.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load database config: %v", err)
+	}
+
+	database, err := db.Connect(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close(database)
+
+	switch flag.Arg(0) {
+	case "up":
+		applied, err := db.Migrate(ctx, database)
+		if err != nil {
+			log.Fatalf("Failed to migrate: %v", err)
+		}
+		log.Printf("Applied %d migrations", applied)
+
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			n, err := strconv.Atoi(flag.Arg(1))
+			if err != nil || n < 1 {
+				log.Fatalf("Invalid number of steps %q", flag.Arg(1))
+			}
+			steps = n
+		}
+		reverted, err := db.MigrateDown(ctx, database, steps)
+		if err != nil {
+			log.Fatalf("Failed to revert migrations: %v", err)
+		}
+		log.Printf("Reverted %d migrations", reverted)
+
+	case "status":
+		status, err := db.GetMigrationStatus(ctx, database)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		fmt.Printf("version: %d (latest %d)\n", status.Version, status.Latest)
+		if status.Dirty {
+			fmt.Println("dirty: a migration failed part-way; fix the schema before migrating again")
+		}
+		for _, m := range status.Pending {
+			fmt.Printf("pending: %03d_%s\n", m.Version, m.Name)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
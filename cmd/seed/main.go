@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/db"
+	"github.com/openmeet-team/survey/internal/seed"
+)
+
+// seed populates a development database with demo surveys and responses.
+// Uses the same DATABASE_* environment variables as the API server.
+//
+//	go run ./cmd/seed -scale medium
+//	go run ./cmd/seed -surveys 5 -max-responses 2000 -seed 42
+func main() {
+	scale := flag.String("scale", "small", "preset: small, medium, or large")
+	surveys := flag.Int("surveys", 0, "number of surveys (overrides the preset)")
+	maxResponses := flag.Int("max-responses", 0, "maximum responses per survey (overrides the preset)")
+	authors := flag.Int("authors", 0, "number of survey authors (overrides the preset)")
+	federated := flag.Float64("federated", -1, "share of ATProto surveys and DID voters, 0 to 1 (overrides the preset)")
+	randomSeed := flag.Int64("seed", time.Now().UnixNano(), "random seed; reuse only on an empty database, as IDs and URIs repeat")
+	flag.Parse()
+
+	config, ok := seed.Scales[*scale]
+	if !ok {
+		log.Fatalf("Unknown scale %q (want small, medium, or large)", *scale)
+	}
+	if *surveys > 0 {
+		config.Surveys = *surveys
+	}
+	if *maxResponses > 0 {
+		config.MaxResponses = *maxResponses
+	}
+	if *authors > 0 {
+		config.Authors = *authors
+	}
+	if *federated >= 0 {
+		config.FederatedRatio = *federated
+	}
+	config.Seed = *randomSeed
+
+	ctx := context.Background()
+
+	cfg, err := db.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load database config: %v", err)
+	}
+
+	database, err := db.Connect(ctx, cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close(database)
+
+	log.Printf("Seeding %d surveys with up to %d responses each (seed %d)", config.Surveys, config.MaxResponses, config.Seed)
+
+	g := seed.New(config, time.Now())
+
+	queries := db.NewQueries(database)
+
+	// Store author identities so pages show handles and display names
+	if err := queries.UpsertIdentities(ctx, g.Authors()); err != nil {
+		log.Fatalf("Failed to store authors: %v", err)
+	}
+
+	start := time.Now()
+	total := 0
+	for i := 0; i < config.Surveys; i++ {
+		survey := g.Survey()
+		responses := g.Responses(survey)
+
+		if err := queries.CreateSurvey(ctx, survey); err != nil {
+			log.Fatalf("Failed to create survey %s: %v", survey.Slug, err)
+		}
+
+		// COPY inserts a survey's responses in one round trip
+		if _, err := queries.CopyResponses(ctx, responses); err != nil {
+			log.Fatalf("Failed to create responses for survey %s: %v", survey.Slug, err)
+		}
+
+		total += len(responses)
+		log.Printf("  /surveys/%s: %d responses", survey.Slug, len(responses))
+	}
+
+	log.Printf("Seeded %d surveys and %d responses in %s", config.Surveys, total, time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/surveyctl"
+	"github.com/openmeet-team/survey/pkg/client"
+)
+
+const usage = `usage: surveyctl [-url URL] [-key KEY] <command> [arguments]
+
+commands:
+  create [-slug SLUG] FILE        create a survey from a YAML or JSON definition
+  list                            list the surveys of the API key's owner
+  results [-format json|csv] SLUG print the results of a survey
+  watch [-interval 5s] SLUG       print the results of a survey as they change
+  plan DIR                        show the changes that make surveys match DIR
+  apply [-auto-approve] DIR       make surveys match the definitions in DIR
+
+The URL and key default to $SURVEY_URL and $SURVEY_API_KEY.`
+
+// surveyctl manages surveys through the JSON API, for power users and scripts.
+//
+//	export SURVEY_URL=https://survey.openmeet.net SURVEY_API_KEY=sk_...
+//	go run ./cmd/surveyctl create surveys/team-lunch.yaml
+//	go run ./cmd/surveyctl results -format csv team-lunch > lunch.csv
+//	go run ./cmd/surveyctl watch team-lunch
+//	go run ./cmd/surveyctl apply surveys/
+func main() {
+	log.SetFlags(0)
+	baseURL := flag.String("url", envOr("SURVEY_URL", "http://localhost:8080"), "URL of the survey service")
+	apiKey := flag.String("key", os.Getenv("SURVEY_API_KEY"), "API key (sk_...)")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	c := client.New(*baseURL, *apiKey)
+
+	var err error
+	switch args := flag.Args()[1:]; flag.Arg(0) {
+	case "create":
+		err = create(ctx, c, args)
+	case "list":
+		err = list(ctx, c)
+	case "results":
+		err = results(ctx, c, args)
+	case "watch":
+		err = watch(ctx, c, args)
+	case "plan":
+		err = plan(ctx, c, args)
+	case "apply":
+		err = apply(ctx, c, args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("surveyctl %s: %v", flag.Arg(0), err)
+	}
+}
+
+// create creates a survey from a definition file, with the file's name as
+// slug unless -slug is given
+func create(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	slug := fs.String("slug", "", "slug of the survey (default: the file name without extension)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected one definition file")
+	}
+
+	path := fs.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if *slug == "" {
+		*slug = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	survey, err := c.CreateSurvey(ctx, *slug, string(data))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created %s: %s/surveys/%s\n", survey.Slug, c.BaseURL, survey.Slug)
+	return nil
+}
+
+// list prints the surveys of the key's owner as a table
+func list(ctx context.Context, c *client.Client) error {
+	surveys, err := c.ListSurveys(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SLUG\tTITLE\tCREATED\tCLOSES")
+	for _, s := range surveys {
+		closes := "-"
+		if s.EndsAt != nil {
+			closes = s.EndsAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Slug, s.Title, s.CreatedAt.Local().Format(time.DateOnly), closes)
+	}
+	return tw.Flush()
+}
+
+// results prints the results of a survey as JSON or CSV
+func results(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("results", flag.ExitOnError)
+	format := fs.String("format", "json", "json or csv")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected one survey slug")
+	}
+	slug := fs.Arg(0)
+
+	res, err := c.GetResults(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	case "csv":
+		survey, err := c.GetSurvey(ctx, slug)
+		if err != nil {
+			return err
+		}
+		def, err := surveyctl.Definition(survey)
+		if err != nil {
+			return err
+		}
+		counted, err := surveyctl.Results(res)
+		if err != nil {
+			return err
+		}
+		return surveyctl.WriteResultsCSV(os.Stdout, def, counted)
+	default:
+		return fmt.Errorf("unknown format %q (want json or csv)", *format)
+	}
+}
+
+// watch prints a summary of the results of a survey whenever they change,
+// until interrupted
+func watch(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := fs.Duration("interval", 5*time.Second, "how often to check for new responses")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected one survey slug")
+	}
+
+	return c.WatchResults(ctx, fs.Arg(0), *interval, func(res *client.Results) {
+		counted, err := surveyctl.Results(res)
+		if err != nil {
+			log.Printf("surveyctl watch: %v", err)
+			return
+		}
+		fmt.Printf("%s  %s\n", time.Now().Format(time.TimeOnly), surveyctl.Summary(counted))
+	})
+}
+
+// plan prints the changes apply would make, without making them
+func plan(ctx context.Context, c *client.Client, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected one definitions directory")
+	}
+	p, err := surveyctl.Plan(ctx, c, args[0])
+	if err != nil {
+		return err
+	}
+	surveyctl.WritePlan(os.Stdout, p)
+	return nil
+}
+
+// apply creates, updates, and archives surveys to match a directory of
+// definition files, after printing the plan and asking for confirmation
+func apply(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	autoApprove := fs.Bool("auto-approve", false, "apply without asking for confirmation")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected one definitions directory")
+	}
+
+	p, err := surveyctl.Plan(ctx, c, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	surveyctl.WritePlan(os.Stdout, p)
+	if p.Empty() {
+		return nil
+	}
+
+	if !*autoApprove {
+		fmt.Print("\nApply these changes? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	if err := surveyctl.Apply(ctx, c, p); err != nil {
+		return err
+	}
+	fmt.Printf("Applied %d changes.\n", len(p.Actions))
+	return nil
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,149 @@
+// Package adminstats reports how the whole service is used, for admins:
+// surveys, responses, and voters per day, AI generation spend, the surveys
+// with the most responses, how far the consumer lags behind the network, and
+// the size of the database and its largest tables.
+package adminstats
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Report periods in days
+const (
+	DefaultDays = 30
+	MaxDays     = 365
+)
+
+// Limits
+const (
+	TopSurveysLimit = 10 // Surveys listed by responses
+	TablesLimit     = 15 // Largest tables listed
+)
+
+// Day is the activity on a day (UTC)
+type Day struct {
+	Day         time.Time `json:"day"`
+	Surveys     int       `json:"surveys"`   // Surveys created, not counting deleted ones
+	Responses   int       `json:"responses"` // Responses submitted
+	Voters      int       `json:"voters"`    // Distinct voters, by DID or guest session
+	Generations int       `json:"generations"`
+	CostUSD     float64   `json:"costUsd"` // Estimated cost of the day's AI generations
+}
+
+// Totals are the counts of a report's period
+type Totals struct {
+	Surveys     int     `json:"surveys"`
+	Responses   int     `json:"responses"`
+	Generations int     `json:"generations"`
+	CostUSD     float64 `json:"costUsd"`
+}
+
+// TopSurvey is a survey and the responses it got in a report's period
+type TopSurvey struct {
+	ID        uuid.UUID `json:"id"`
+	Slug      string    `json:"slug"`
+	Title     string    `json:"title"`
+	Responses int       `json:"responses"`
+}
+
+// Table is the size of a database table, with its indexes
+type Table struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+	Rows  int64  `json:"rows"` // Estimated by the planner statistics (counted on SQLite)
+}
+
+// Database is the size of the database
+type Database struct {
+	Bytes  int64    `json:"bytes"`
+	Tables []*Table `json:"tables"` // Largest first, at most TablesLimit
+}
+
+// Report is the service's statistics since a day
+type Report struct {
+	Since      time.Time     `json:"since"`
+	Days       int           `json:"days"`
+	AllTime    *models.Stats `json:"allTime"` // The counts of the landing page
+	Totals     Totals        `json:"totals"`
+	Daily      []*Day        `json:"daily"`      // Every day since Since, oldest first
+	TopSurveys []*TopSurvey  `json:"topSurveys"` // Most responses in the period first
+	Database   *Database     `json:"database"`
+	// ConsumerLagSeconds is how long ago the newest indexed event happened;
+	// null if the consumer has not indexed any
+	ConsumerLagSeconds *float64  `json:"consumerLagSeconds"`
+	GeneratedAt        time.Time `json:"generatedAt"`
+}
+
+// Store reads the statistics of the service
+type Store interface {
+	GetStats(ctx context.Context) (*models.Stats, error)
+	// GetDailyActivity returns the days since a time with any activity
+	GetDailyActivity(ctx context.Context, since time.Time) ([]*Day, error)
+	// GetTopSurveys returns the surveys with the most responses since a time
+	GetTopSurveys(ctx context.Context, since time.Time, limit int) ([]*TopSurvey, error)
+	GetDatabaseSize(ctx context.Context, tables int) (*Database, error)
+}
+
+// Period returns the report period for a requested number of days: the
+// default if days is not positive, capped at MaxDays
+func Period(days int) int {
+	if days < 1 {
+		return DefaultDays
+	}
+	return min(days, MaxDays)
+}
+
+// Since returns the first day of a report covering days days up to now
+func Since(now time.Time, days int) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+}
+
+// Build fills the days without activity into a report of every day from since
+// up to now and totals them. eventTimeUs is the time of the newest indexed
+// event (microseconds since epoch), 0 if none was indexed.
+func Build(since, now time.Time, days int, allTime *models.Stats, activity []*Day, top []*TopSurvey, database *Database, eventTimeUs int64) *Report {
+	report := &Report{
+		Since:       since,
+		Days:        days,
+		AllTime:     allTime,
+		Daily:       []*Day{},
+		TopSurveys:  top,
+		Database:    database,
+		GeneratedAt: now,
+	}
+	if report.TopSurveys == nil {
+		report.TopSurveys = []*TopSurvey{}
+	}
+
+	byDay := make(map[time.Time]*Day, len(activity))
+	for _, d := range activity {
+		y, m, dd := d.Day.UTC().Date()
+		byDay[time.Date(y, m, dd, 0, 0, 0, 0, time.UTC)] = d
+	}
+
+	for day := since; !day.After(now); day = day.AddDate(0, 0, 1) {
+		d, ok := byDay[day]
+		if !ok {
+			d = &Day{}
+		}
+		d.Day = day
+		report.Daily = append(report.Daily, d)
+
+		report.Totals.Surveys += d.Surveys
+		report.Totals.Responses += d.Responses
+		report.Totals.Generations += d.Generations
+		report.Totals.CostUSD += d.CostUSD
+	}
+
+	if eventTimeUs > 0 {
+		lag := max(now.Sub(time.UnixMicro(eventTimeUs)), 0).Seconds()
+		report.ConsumerLagSeconds = &lag
+	}
+
+	return report
+}
@@ -0,0 +1,44 @@
+package adminstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriod(t *testing.T) {
+	assert.Equal(t, DefaultDays, Period(0))
+	assert.Equal(t, 7, Period(7))
+	assert.Equal(t, MaxDays, Period(1000))
+
+	now := time.Date(2026, 3, 31, 15, 20, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Since(now, 30))
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2026, 3, 3, 15, 20, 0, 0, time.UTC)
+	since := Since(now, 3)
+	allTime := &models.Stats{SurveyCount: 40, ResponseCount: 900, UniqueUserCount: 300}
+	activity := []*Day{
+		{Day: since, Surveys: 2, Responses: 10, Voters: 8, Generations: 3, CostUSD: 0.25},
+		{Day: since.AddDate(0, 0, 2), Responses: 5, Voters: 5, Generations: 1, CostUSD: 0.5},
+	}
+
+	report := Build(since, now, 3, allTime, activity, nil, &Database{Bytes: 1 << 20}, now.Add(-90*time.Second).UnixMicro())
+
+	require.Len(t, report.Daily, 3)
+	assert.Equal(t, 10, report.Daily[0].Responses)
+	assert.Equal(t, Day{Day: since.AddDate(0, 0, 1)}, *report.Daily[1], "days without activity are included")
+	assert.Equal(t, 5, report.Daily[2].Voters)
+	assert.Equal(t, Totals{Surveys: 2, Responses: 15, Generations: 4, CostUSD: 0.75}, report.Totals)
+	assert.Same(t, allTime, report.AllTime)
+	assert.NotNil(t, report.TopSurveys)
+	require.NotNil(t, report.ConsumerLagSeconds)
+	assert.Equal(t, 90.0, *report.ConsumerLagSeconds)
+
+	report = Build(since, now, 3, allTime, nil, nil, &Database{}, 0)
+	assert.Nil(t, report.ConsumerLagSeconds, "no lag before the first event")
+}
@@ -0,0 +1,173 @@
+// Package analytics reports how a survey collects responses over time for its
+// author: responses and page views per hour or day, the share of views that
+// led to a submission, and the sites that referred visitors. Views are counted
+// per survey, hour, and referring host only; no visitor data is stored.
+package analytics
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval is the bucket size of a time series
+type Interval string
+
+// Series intervals
+const (
+	IntervalHour Interval = "hour"
+	IntervalDay  Interval = "day"
+)
+
+// Report periods in days
+const (
+	DefaultHourDays = 2
+	MaxHourDays     = 7
+	DefaultDayDays  = 30
+	MaxDayDays      = 90
+)
+
+// maxReferrers is the number of referrers in a report
+const maxReferrers = 10
+
+// ParseInterval parses an interval, as given in an ?interval= query parameter
+func ParseInterval(s string) (Interval, bool) {
+	switch Interval(s) {
+	case IntervalHour, IntervalDay:
+		return Interval(s), true
+	case "":
+		return IntervalDay, true
+	}
+	return "", false
+}
+
+// Days returns the report period for a requested number of days: the default
+// of the interval if days is not positive, capped at the interval's maximum
+func (i Interval) Days(days int) int {
+	def, max := DefaultDayDays, MaxDayDays
+	if i == IntervalHour {
+		def, max = DefaultHourDays, MaxHourDays
+	}
+	if days < 1 {
+		return def
+	}
+	return min(days, max)
+}
+
+// Since returns the start of the first bucket of a report covering days days up to now
+func (i Interval) Since(now time.Time, days int) time.Time {
+	if i == IntervalHour {
+		return Hour(now).Add(-time.Duration(days*24-1) * time.Hour)
+	}
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+}
+
+// bucket returns the start of the interval containing t
+func (i Interval) bucket(t time.Time) time.Time {
+	if i == IntervalHour {
+		return Hour(t)
+	}
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// next returns the start of the interval after t
+func (i Interval) next(t time.Time) time.Time {
+	if i == IntervalHour {
+		return t.Add(time.Hour)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// Point is the number of views and responses in one interval
+type Point struct {
+	Time      time.Time `json:"time"` // Start of the interval (UTC)
+	Views     int       `json:"views"`
+	Responses int       `json:"responses"`
+}
+
+// Funnel compares the views of the survey page with the responses submitted
+type Funnel struct {
+	Views          int     `json:"views"`
+	Submissions    int     `json:"submissions"`
+	ConversionRate float64 `json:"conversionRate"` // Submissions per view, 0 without views
+}
+
+// Referrer is a site that linked visitors to the survey
+type Referrer struct {
+	Host  string `json:"host"` // Referring host, or Direct
+	Views int    `json:"views"`
+}
+
+// Report is a survey's responses and views since a day or hour
+type Report struct {
+	Interval  Interval    `json:"interval"`
+	Since     time.Time   `json:"since"`
+	Days      int         `json:"days"`
+	Series    []*Point    `json:"series"` // Every interval since Since, oldest first
+	Funnel    Funnel      `json:"funnel"`
+	Referrers []*Referrer `json:"referrers"` // Most views first, at most 10
+}
+
+// Build buckets hourly views and responses into a report of every interval
+// from since up to now. Counts before since are ignored.
+func Build(interval Interval, since, now time.Time, days int, views []*View, responses []*HourCount) *Report {
+	report := &Report{
+		Interval:  interval,
+		Since:     since,
+		Days:      days,
+		Series:    []*Point{},
+		Referrers: []*Referrer{},
+	}
+
+	points := make(map[time.Time]*Point)
+	for t := since; !t.After(now); t = interval.next(t) {
+		p := &Point{Time: t}
+		report.Series = append(report.Series, p)
+		points[t] = p
+	}
+
+	referrers := make(map[string]*Referrer)
+	for _, v := range views {
+		p, ok := points[interval.bucket(v.Hour)]
+		if !ok {
+			continue
+		}
+		p.Views += v.Views
+		report.Funnel.Views += v.Views
+
+		r, ok := referrers[v.Referrer]
+		if !ok {
+			r = &Referrer{Host: v.Referrer}
+			referrers[v.Referrer] = r
+			report.Referrers = append(report.Referrers, r)
+		}
+		r.Views += v.Views
+	}
+
+	for _, c := range responses {
+		p, ok := points[interval.bucket(c.Hour)]
+		if !ok {
+			continue
+		}
+		p.Responses += c.Count
+		report.Funnel.Submissions += c.Count
+	}
+
+	if report.Funnel.Views > 0 {
+		report.Funnel.ConversionRate = float64(report.Funnel.Submissions) / float64(report.Funnel.Views)
+	}
+
+	sort.Slice(report.Referrers, func(i, j int) bool {
+		a, b := report.Referrers[i], report.Referrers[j]
+		if a.Views != b.Views {
+			return a.Views > b.Views
+		}
+		return a.Host < b.Host
+	})
+	if len(report.Referrers) > maxReferrers {
+		report.Referrers = report.Referrers[:maxReferrers]
+	}
+
+	return report
+}
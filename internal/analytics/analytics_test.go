@@ -0,0 +1,187 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterval(t *testing.T) {
+	now := time.Date(2026, 3, 31, 15, 20, 0, 0, time.UTC)
+
+	interval, ok := ParseInterval("")
+	require.True(t, ok)
+	assert.Equal(t, IntervalDay, interval)
+	_, ok = ParseInterval("week")
+	assert.False(t, ok)
+
+	assert.Equal(t, DefaultDayDays, IntervalDay.Days(0))
+	assert.Equal(t, MaxDayDays, IntervalDay.Days(365))
+	assert.Equal(t, DefaultHourDays, IntervalHour.Days(-1))
+	assert.Equal(t, MaxHourDays, IntervalHour.Days(30))
+
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), IntervalDay.Since(now, 30))
+	assert.Equal(t, time.Date(2026, 3, 30, 16, 0, 0, 0, time.UTC), IntervalHour.Since(now, 1))
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2026, 3, 3, 15, 20, 0, 0, time.UTC)
+	since := IntervalDay.Since(now, 3)
+	day1, day3 := since, since.AddDate(0, 0, 2)
+	surveyID := uuid.New()
+
+	views := []*View{
+		{SurveyID: surveyID, Hour: day1.Add(9 * time.Hour), Referrer: "bsky.app", Views: 6},
+		{SurveyID: surveyID, Hour: day1.Add(10 * time.Hour), Referrer: Direct, Views: 2},
+		{SurveyID: surveyID, Hour: day3.Add(14 * time.Hour), Referrer: "bsky.app", Views: 2},
+		{SurveyID: surveyID, Hour: since.Add(-time.Hour), Referrer: "old.example", Views: 50},
+	}
+	responses := []*HourCount{
+		{Hour: day1.Add(9 * time.Hour), Count: 3},
+		{Hour: day3.Add(15 * time.Hour), Count: 2},
+	}
+
+	report := Build(IntervalDay, since, now, 3, views, responses)
+
+	require.Len(t, report.Series, 3)
+	assert.Equal(t, Point{Time: day1, Views: 8, Responses: 3}, *report.Series[0])
+	assert.Equal(t, Point{Time: day1.AddDate(0, 0, 1)}, *report.Series[1], "days without activity are included")
+	assert.Equal(t, Point{Time: day3, Views: 2, Responses: 2}, *report.Series[2])
+
+	assert.Equal(t, Funnel{Views: 10, Submissions: 5, ConversionRate: 0.5}, report.Funnel)
+	assert.Equal(t, []*Referrer{{Host: "bsky.app", Views: 8}, {Host: Direct, Views: 2}}, report.Referrers, "views before the period are ignored")
+
+	hourly := Build(IntervalHour, IntervalHour.Since(now, 1), now, 1, views, responses)
+	require.Len(t, hourly.Series, 24)
+	assert.Equal(t, 2, hourly.Series[22].Views)
+	assert.Equal(t, 2, hourly.Series[23].Responses)
+}
+
+func TestBuild_NoViews(t *testing.T) {
+	now := time.Now()
+	report := Build(IntervalDay, IntervalDay.Since(now, 1), now, 1, nil, []*HourCount{{Hour: Hour(now), Count: 4}})
+	assert.Equal(t, Funnel{Submissions: 4}, report.Funnel)
+	assert.NotNil(t, report.Referrers)
+}
+
+func TestReferrerHost(t *testing.T) {
+	tests := []struct {
+		referer string
+		want    string
+	}{
+		{"", Direct},
+		{"not a url\x7f", Direct},
+		{"https://bsky.app/profile/alice", "bsky.app"},
+		{"https://www.Example.com/post", "example.com"},
+		{"https://survey.example.org/surveys/lunch", Direct},
+		{"http://survey.example.org:8080/", Direct},
+		{"https://" + strings.Repeat("a", 300) + ".example/", Other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.referer, func(t *testing.T) {
+			assert.Equal(t, tt.want, ReferrerHost(tt.referer, "survey.example.org:8080"))
+		})
+	}
+}
+
+func TestIsBot(t *testing.T) {
+	assert.True(t, IsBot(""))
+	assert.True(t, IsBot("Mozilla/5.0 (compatible; Googlebot/2.1)"))
+	assert.True(t, IsBot("facebookexternalhit/1.1"))
+	assert.False(t, IsBot("Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 Safari/605.1.15"))
+}
+
+// fakeStore records the views added to it
+type fakeStore struct {
+	added []*View
+	err   error
+}
+
+func (s *fakeStore) AddSurveyViews(ctx context.Context, views []*View) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.added = append(s.added, views...)
+	return nil
+}
+
+func (s *fakeStore) ListSurveyViews(ctx context.Context, surveyID uuid.UUID, since time.Time) ([]*View, error) {
+	return s.added, nil
+}
+
+func (s *fakeStore) ListHourlyResponses(ctx context.Context, surveyID uuid.UUID, since time.Time) ([]*HourCount, error) {
+	return nil, nil
+}
+
+func TestViewCounter(t *testing.T) {
+	store := &fakeStore{err: errors.New("database down")}
+	counter := NewViewCounter(store)
+	surveyID := uuid.New()
+	now := time.Date(2026, 3, 3, 15, 20, 0, 0, time.UTC)
+
+	counter.Record(surveyID, "bsky.app", now)
+	counter.Record(surveyID, "bsky.app", now.Add(10*time.Minute))
+	counter.Record(surveyID, Direct, now)
+
+	// Views are kept when the store fails
+	assert.Error(t, counter.Flush(context.Background()))
+	counter.Record(surveyID, "bsky.app", now)
+
+	store.err = nil
+	require.NoError(t, counter.Flush(context.Background()))
+	require.Len(t, store.added, 2)
+	byReferrer := map[string]int{}
+	for _, v := range store.added {
+		assert.Equal(t, Hour(now), v.Hour)
+		byReferrer[v.Referrer] = v.Views
+	}
+	assert.Equal(t, map[string]int{"bsky.app": 3, Direct: 1}, byReferrer)
+
+	require.NoError(t, counter.Flush(context.Background()))
+	assert.Len(t, store.added, 2, "nothing left to flush")
+
+	var nilCounter *ViewCounter
+	nilCounter.Record(surveyID, Direct, now)
+}
+
+func TestViewCounter_CapsReferrers(t *testing.T) {
+	store := &fakeStore{}
+	counter := NewViewCounter(store)
+	surveyID := uuid.New()
+	now := time.Date(2026, 3, 3, 15, 20, 0, 0, time.UTC)
+
+	for i := 0; i < maxReferrersPerHour+5; i++ {
+		counter.Record(surveyID, fmt.Sprintf("site%d.example", i), now)
+	}
+	counter.Record(surveyID, Direct, now)
+	require.NoError(t, counter.Flush(context.Background()))
+
+	// The cap holds across flushes within the hour
+	counter.Record(surveyID, "late.example", now.Add(30*time.Minute))
+	counter.Record(surveyID, "site0.example", now.Add(30*time.Minute))
+	require.NoError(t, counter.Flush(context.Background()))
+
+	byReferrer := map[string]int{}
+	for _, v := range store.added {
+		byReferrer[v.Referrer] += v.Views
+	}
+	assert.Len(t, byReferrer, maxReferrersPerHour+2, "the first referrers, direct and other")
+	assert.Equal(t, 6, byReferrer[Other])
+	assert.Equal(t, 1, byReferrer[Direct])
+	assert.Equal(t, 2, byReferrer["site0.example"])
+
+	// A new hour admits new referrers
+	store.added = nil
+	counter.Record(surveyID, "late.example", now.Add(time.Hour))
+	require.NoError(t, counter.Flush(context.Background()))
+	require.Len(t, store.added, 1)
+	assert.Equal(t, "late.example", store.added[0].Referrer)
+}
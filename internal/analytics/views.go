@@ -0,0 +1,241 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Direct is the referrer of views without a Referer from another site
+	Direct = "direct"
+
+	// Other is the referrer of views from hosts beyond a survey's first
+	// maxReferrersPerHour in an hour, and from overlong hosts
+	Other = "other"
+
+	// maxReferrersPerHour bounds the referring hosts counted per survey and hour,
+	// as the Referer header is set by the client
+	maxReferrersPerHour = 20
+
+	// maxCounters bounds the counters and referrer sets held between flushes
+	maxCounters = 10000
+
+	// maxHostLength is the longest valid host name
+	maxHostLength = 253
+)
+
+// View counts a survey's page views in an hour (UTC) from a referring host
+type View struct {
+	SurveyID uuid.UUID `json:"surveyId"`
+	Hour     time.Time `json:"hour"`
+	Referrer string    `json:"referrer"` // Referring host, or Direct
+	Views    int       `json:"views"`
+}
+
+// HourCount is a number of responses in an hour (UTC)
+type HourCount struct {
+	Hour  time.Time `json:"hour"`
+	Count int       `json:"count"`
+}
+
+// Store persists survey views and reads the counts of a survey
+type Store interface {
+	AddSurveyViews(ctx context.Context, views []*View) error
+	ListSurveyViews(ctx context.Context, surveyID uuid.UUID, since time.Time) ([]*View, error)
+	ListHourlyResponses(ctx context.Context, surveyID uuid.UUID, since time.Time) ([]*HourCount, error)
+}
+
+// viewKey identifies a counter
+type viewKey struct {
+	surveyID uuid.UUID
+	hour     time.Time
+	referrer string
+}
+
+// surveyHour identifies the referrers of a survey in an hour
+type surveyHour struct {
+	surveyID uuid.UUID
+	hour     time.Time
+}
+
+// ViewCounter counts survey page views in memory and periodically adds them to
+// the store, so rendering a survey does not cost a database write
+type ViewCounter struct {
+	store     Store
+	mu        sync.Mutex
+	counts    map[viewKey]*View
+	referrers map[surveyHour]map[string]bool // Referrers counted this hour, across flushes
+	hour      time.Time                      // Latest hour recorded
+}
+
+// NewViewCounter creates a view counter flushing to store
+func NewViewCounter(store Store) *ViewCounter {
+	return &ViewCounter{
+		store:     store,
+		counts:    make(map[viewKey]*View),
+		referrers: make(map[surveyHour]map[string]bool),
+	}
+}
+
+// Record counts a view of a survey. Views from referrers beyond the survey's
+// first maxReferrersPerHour in the hour are counted as Other, and views that
+// would need a counter beyond maxCounters are dropped until the next flush.
+// A nil counter records nothing.
+func (v *ViewCounter) Record(surveyID uuid.UUID, referrer string, now time.Time) {
+	if v == nil {
+		return
+	}
+
+	hour := Hour(now)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if hour.After(v.hour) {
+		v.hour = hour
+		for sh := range v.referrers {
+			if sh.hour.Before(hour) {
+				delete(v.referrers, sh)
+			}
+		}
+	}
+	referrer = v.admit(surveyHour{surveyID, hour}, referrer)
+
+	key := viewKey{surveyID, hour, referrer}
+	view, ok := v.counts[key]
+	if !ok {
+		if len(v.counts) >= maxCounters {
+			return
+		}
+		view = &View{SurveyID: surveyID, Hour: hour, Referrer: referrer}
+		v.counts[key] = view
+	}
+	view.Views++
+}
+
+// admit returns the referrer a view is counted under: the referrer itself if
+// it is already counted for the survey's hour or there is room for it, or Other
+func (v *ViewCounter) admit(sh surveyHour, referrer string) string {
+	if referrer == Direct || referrer == Other {
+		return referrer
+	}
+
+	known := v.referrers[sh]
+	switch {
+	case known[referrer]:
+		return referrer
+	case len(known) >= maxReferrersPerHour:
+		return Other
+	case known == nil:
+		if len(v.referrers) >= maxCounters {
+			return Other
+		}
+		known = make(map[string]bool)
+		v.referrers[sh] = known
+	}
+	known[referrer] = true
+	return referrer
+}
+
+// Flush adds the views recorded since the last flush to the store. Views are
+// kept for the next flush if the store fails.
+func (v *ViewCounter) Flush(ctx context.Context) error {
+	v.mu.Lock()
+	counts := v.counts
+	v.counts = make(map[viewKey]*View)
+	v.mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	views := make([]*View, 0, len(counts))
+	for _, c := range counts {
+		views = append(views, c)
+	}
+
+	if err := v.store.AddSurveyViews(ctx, views); err != nil {
+		v.mu.Lock()
+		for k, c := range counts {
+			if current, ok := v.counts[k]; ok {
+				current.Views += c.Views
+			} else {
+				v.counts[k] = c
+			}
+		}
+		v.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Run flushes views every interval until ctx is cancelled, then flushes once more
+func (v *ViewCounter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := v.Flush(ctx); err != nil {
+				log.Printf("Failed to store survey views: %v", err)
+			}
+		case <-ctx.Done():
+			// Final flush with a fresh context, as ctx is already cancelled
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := v.Flush(flushCtx); err != nil {
+				log.Printf("Failed to store survey views: %v", err)
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// ReferrerHost returns the host of a Referer header as views are counted: Direct
+// for missing or unparsable referrers and for links within this site (host),
+// and Other for hosts longer than a valid host name
+func ReferrerHost(referer, host string) string {
+	u, err := url.Parse(referer)
+	if err != nil || u.Hostname() == "" {
+		return Direct
+	}
+	if len(u.Hostname()) > maxHostLength {
+		return Other
+	}
+	referrer := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	own, _, _ := strings.Cut(host, ":")
+	if strings.TrimPrefix(strings.ToLower(own), "www.") == referrer {
+		return Direct
+	}
+	return referrer
+}
+
+// botMarkers are User-Agent substrings of crawlers and link preview fetchers,
+// whose requests are not counted as views
+var botMarkers = []string{"bot", "crawler", "spider", "preview", "facebookexternalhit", "embedly", "curl", "wget"}
+
+// IsBot reports whether a User-Agent looks like an automated client
+func IsBot(userAgent string) bool {
+	if userAgent == "" {
+		return true
+	}
+	ua := strings.ToLower(userAgent)
+	for _, marker := range botMarkers {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hour returns the UTC hour of t, as views and responses are counted
+func Hour(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
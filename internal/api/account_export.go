@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+)
+
+// maxExportRecords bounds the PDS records exported per collection
+const maxExportRecords = 10000
+
+// AccountArchive is the export of everything the service holds for a user:
+// the local index of their surveys, results, and responses, and their records
+// on their PDS
+type AccountArchive struct {
+	DID        string                       `json:"did"`
+	ExportedAt time.Time                    `json:"exportedAt"`
+	Surveys    []*models.Survey             `json:"surveys"`
+	Results    []*models.SurveyResults      `json:"results"`   // Of the user's surveys
+	Responses  []*models.Response           `json:"responses"` // Submitted by the user
+	Records    map[string][]oauth.PDSRecord `json:"records"`   // PDS records by collection
+}
+
+// ExportAccountData downloads all of the logged-in user's data as a JSON
+// archive, or with format=car their whole PDS repository as a CAR file
+// GET /my-data/export?format=json|car
+func (h *Handlers) ExportAccountData(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "car" {
+		return c.String(http.StatusBadRequest, "Format must be 'json' or 'car'")
+	}
+
+	pdsURL, err := h.resolvePDS(user.DID)
+	if err != nil {
+		c.Logger().Errorf("Failed to resolve PDS of %s for export: %v", user.DID, err)
+		return c.String(http.StatusBadGateway, "Failed to find your PDS")
+	}
+
+	if format == "car" {
+		repo, err := h.fetchRepo(c.Request().Context(), pdsURL, user.DID)
+		if err != nil {
+			c.Logger().Errorf("Failed to export repository of %s: %v", user.DID, err)
+			return c.String(http.StatusBadGateway, "Failed to export your PDS repository")
+		}
+		defer repo.Close()
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "repo.car"))
+		c.Response().Header().Set(echo.HeaderContentType, "application/vnd.ipld.car")
+		c.Response().WriteHeader(http.StatusOK)
+		_, err = io.Copy(c.Response(), repo)
+		return err
+	}
+
+	archive, err := h.accountArchive(c, user.DID, pdsURL)
+	if err != nil {
+		c.Logger().Errorf("Failed to export data of %s: %v", user.DID, err)
+		return c.String(http.StatusInternalServerError, "Failed to export your data")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "survey-data.json"))
+	return c.JSONPretty(http.StatusOK, archive, "  ")
+}
+
+// accountArchive collects a user's local and PDS data
+func (h *Handlers) accountArchive(c echo.Context, did, pdsURL string) (*AccountArchive, error) {
+	ctx := c.Request().Context()
+	archive := &AccountArchive{
+		DID:        did,
+		ExportedAt: time.Now().UTC(),
+		Surveys:    []*models.Survey{},
+		Results:    []*models.SurveyResults{},
+		Responses:  []*models.Response{},
+		Records:    map[string][]oauth.PDSRecord{},
+	}
+
+	surveys, err := h.accountData.ListSurveysByAuthor(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	for _, survey := range surveys {
+		results, err := h.queries.GetSurveyResults(ctx, survey.ID)
+		if err != nil {
+			return nil, err
+		}
+		archive.Surveys = append(archive.Surveys, survey)
+		archive.Results = append(archive.Results, results)
+	}
+
+	responses, err := h.accountData.ListResponsesByVoter(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	archive.Responses = append(archive.Responses, responses...)
+
+	collections := []string{"net.openmeet.survey", "net.openmeet.survey.response", "net.openmeet.survey.results", "net.openmeet.survey.comment"}
+	if h.crossPublish != "" {
+		collections = append(collections, h.crossPublish)
+	}
+	for _, collection := range collections {
+		records, err := listAllRecords(c.Request().Context(), pdsURL, did, collection)
+		if err != nil {
+			return nil, err
+		}
+		archive.Records[collection] = records
+	}
+
+	return archive, nil
+}
+
+// listAllRecords pages through a collection of a PDS repository
+func listAllRecords(ctx context.Context, pdsURL, did, collection string) ([]oauth.PDSRecord, error) {
+	records := []oauth.PDSRecord{}
+	cursor := ""
+	for len(records) < maxExportRecords {
+		page, err := oauth.ListRecords(ctx, pdsURL, did, collection, cursor, 100)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", collection, err)
+		}
+		records = append(records, page.Records...)
+		if page.Cursor == "" || len(page.Records) == 0 {
+			break
+		}
+		cursor = page.Cursor
+	}
+	return records, nil
+}
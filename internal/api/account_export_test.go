@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAccountData holds a user's surveys and responses
+type mockAccountData struct {
+	surveys   []*models.Survey
+	responses []*models.Response
+}
+
+func (m *mockAccountData) ListSurveysByAuthor(ctx context.Context, authorDID string) ([]*models.Survey, error) {
+	return m.surveys, nil
+}
+
+func (m *mockAccountData) ListResponsesByVoter(ctx context.Context, voterDID string) ([]*models.Response, error) {
+	return m.responses, nil
+}
+
+// fakeRepoPDS serves two pages of survey records of did:plc:alice
+func fakeRepoPDS(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.repo.listRecords":
+			assert.Equal(t, "did:plc:alice", r.URL.Query().Get("repo"))
+			var page oauth.ListRecordsResponse
+			switch {
+			case r.URL.Query().Get("collection") != "net.openmeet.survey":
+				page.Records = []oauth.PDSRecord{}
+			case r.URL.Query().Get("cursor") == "":
+				page.Records = []oauth.PDSRecord{{URI: "at://did:plc:alice/net.openmeet.survey/1", CID: "cid1"}}
+				page.Cursor = "next"
+			default:
+				page.Records = []oauth.PDSRecord{{URI: "at://did:plc:alice/net.openmeet.survey/2", CID: "cid2"}}
+			}
+			json.NewEncoder(w).Encode(page)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func setupAccountExportTest(t *testing.T) (*echo.Echo, *Handlers) {
+	e, mq, h := setupTest()
+	author := "did:plc:alice"
+	survey := &models.Survey{ID: uuid.New(), Slug: "lunch", Title: "Lunch", AuthorDID: &author, CreatedAt: time.Now()}
+	mq.CreateSurvey(context.Background(), survey)
+	h.SetAccountData(&mockAccountData{
+		surveys:   []*models.Survey{survey},
+		responses: []*models.Response{{ID: uuid.New(), SurveyID: uuid.New(), VoterDID: &author, Answers: map[string]models.Answer{"q1": {Text: "hi"}}}},
+	})
+	server := fakeRepoPDS(t)
+	h.resolvePDS = func(did string) (string, error) { return server.URL, nil }
+	h.fetchRepo = func(ctx context.Context, pdsURL, did string) (io.ReadCloser, error) {
+		assert.Equal(t, server.URL, pdsURL)
+		assert.Equal(t, "did:plc:alice", did)
+		return io.NopCloser(strings.NewReader("car bytes")), nil
+	}
+	return e, h
+}
+
+func exportRequest(e *echo.Echo, query string, user *oauth.User) (echo.Context, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/my-data/export"+query, nil), rec)
+	if user != nil {
+		c.Set("user", user)
+	}
+	return c, rec
+}
+
+func TestExportAccountData_JSON(t *testing.T) {
+	e, h := setupAccountExportTest(t)
+
+	c, rec := exportRequest(e, "", &oauth.User{DID: "did:plc:alice"})
+	require.NoError(t, h.ExportAccountData(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), "survey-data.json")
+
+	var archive AccountArchive
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &archive))
+	assert.Equal(t, "did:plc:alice", archive.DID)
+	require.Len(t, archive.Surveys, 1)
+	require.Len(t, archive.Results, 1)
+	assert.Equal(t, archive.Surveys[0].ID, archive.Results[0].SurveyID)
+	assert.Len(t, archive.Responses, 1)
+	assert.Len(t, archive.Records["net.openmeet.survey"], 2, "all pages are exported")
+	assert.Empty(t, archive.Records["net.openmeet.survey.response"])
+}
+
+func TestExportAccountData_CAR(t *testing.T) {
+	e, h := setupAccountExportTest(t)
+
+	c, rec := exportRequest(e, "?format=car", &oauth.User{DID: "did:plc:alice"})
+	require.NoError(t, h.ExportAccountData(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/vnd.ipld.car", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "car bytes", rec.Body.String())
+}
+
+func TestExportAccountData_Errors(t *testing.T) {
+	e, h := setupAccountExportTest(t)
+
+	c, rec := exportRequest(e, "", nil)
+	require.NoError(t, h.ExportAccountData(c))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	c, rec = exportRequest(e, "?format=zip", &oauth.User{DID: "did:plc:alice"})
+	require.NoError(t, h.ExportAccountData(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/adminstats"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/status"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// SetAdminStats enables the admin statistics dashboard. eventTime returns the
+// time of the newest event the consumer indexed, for its lag.
+func (h *Handlers) SetAdminStats(store adminstats.Store, eventTime status.CursorFunc) {
+	h.adminStats = store
+	h.eventTime = eventTime
+}
+
+// adminStatsReport builds the statistics of the service over the ?days=
+// period (default 30, max 365)
+func (h *Handlers) adminStatsReport(c echo.Context) (*adminstats.Report, error) {
+	days, _ := strconv.Atoi(c.QueryParam("days"))
+	days = adminstats.Period(days)
+
+	ctx := c.Request().Context()
+	now := time.Now()
+	since := adminstats.Since(now, days)
+
+	allTime, err := h.adminStats.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := h.adminStats.GetDailyActivity(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	top, err := h.adminStats.GetTopSurveys(ctx, since, adminstats.TopSurveysLimit)
+	if err != nil {
+		return nil, err
+	}
+	database, err := h.adminStats.GetDatabaseSize(ctx, adminstats.TablesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// A consumer that can't be read shows as not having indexed anything
+	var eventTime int64
+	if h.eventTime != nil {
+		if eventTime, err = h.eventTime(ctx); err != nil {
+			c.Logger().Warnf("Failed to get consumer event time: %v", err)
+			eventTime = 0
+		}
+	}
+
+	return adminstats.Build(since, now, days, allTime, activity, top, database, eventTime), nil
+}
+
+// GetAdminStats handles GET /api/v1/admin/stats?days=30
+// Returns surveys, responses, and voters per day, AI generation spend, the
+// surveys with the most responses, consumer lag, and database sizes, for admins
+func (h *Handlers) GetAdminStats(c echo.Context) error {
+	did, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+	if !h.adminDIDs[did] {
+		return Problem(c, problem.Forbidden, "Only admins can view service statistics")
+	}
+
+	report, err := h.adminStatsReport(c)
+	if err != nil {
+		return InternalServerError(c, "Failed to load statistics", err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// AdminStatsHTML renders the statistics dashboard, for admins
+// GET /admin/stats?days=30
+func (h *Handlers) AdminStatsHTML(c echo.Context) error {
+	user, profile := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.adminDIDs[user.DID] {
+		return c.String(http.StatusForbidden, "Only admins can view service statistics")
+	}
+
+	report, err := h.adminStatsReport(c)
+	if err != nil {
+		c.Logger().Errorf("Failed to load statistics: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load statistics")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.AdminStatsPage(report, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
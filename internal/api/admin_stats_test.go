@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/adminstats"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAdminStats returns fixed statistics, recording the period asked for
+type mockAdminStats struct {
+	since time.Time
+}
+
+func (m *mockAdminStats) GetStats(ctx context.Context) (*models.Stats, error) {
+	return &models.Stats{SurveyCount: 2, ResponseCount: 7, UniqueUserCount: 5}, nil
+}
+
+func (m *mockAdminStats) GetDailyActivity(ctx context.Context, since time.Time) ([]*adminstats.Day, error) {
+	m.since = since
+	return []*adminstats.Day{{Day: since, Surveys: 2, Responses: 7, Voters: 5, Generations: 1, CostUSD: 0.01}}, nil
+}
+
+func (m *mockAdminStats) GetTopSurveys(ctx context.Context, since time.Time, limit int) ([]*adminstats.TopSurvey, error) {
+	return []*adminstats.TopSurvey{{ID: uuid.New(), Slug: "lunch", Title: "Lunch", Responses: 7}}, nil
+}
+
+func (m *mockAdminStats) GetDatabaseSize(ctx context.Context, tables int) (*adminstats.Database, error) {
+	return &adminstats.Database{Bytes: 8 << 20, Tables: []*adminstats.Table{{Name: "responses", Bytes: 4 << 20, Rows: 7}}}, nil
+}
+
+func TestAdminStats(t *testing.T) {
+	e, _, h := setupTest()
+	store := &mockAdminStats{}
+	h.SetAdminStats(store, func(ctx context.Context) (int64, error) {
+		return 0, errors.New("cursor unavailable")
+	})
+	h.SetAdmins([]string{"did:plc:admin"})
+
+	call := func(user *oauth.User, days string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats?days="+days, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, h.GetAdminStats(c))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call(nil, "").Code)
+	assert.Equal(t, http.StatusForbidden, call(&oauth.User{DID: "did:plc:alice"}, "").Code)
+
+	rec := call(&oauth.User{DID: "did:plc:admin"}, "7")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var report adminstats.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, 7, report.Days)
+	assert.Len(t, report.Daily, 7, "every day of the period is reported")
+	assert.True(t, report.Since.Equal(store.since))
+	assert.Equal(t, 7, report.Totals.Responses)
+	assert.Equal(t, 2, report.AllTime.SurveyCount)
+	require.Len(t, report.TopSurveys, 1)
+	assert.Equal(t, "lunch", report.TopSurveys[0].Slug)
+	assert.Equal(t, int64(8<<20), report.Database.Bytes)
+	assert.Nil(t, report.ConsumerLagSeconds, "an unreadable cursor reports no lag")
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/analytics"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// recordView counts a view of a survey page, unless it comes from a crawler
+func (h *Handlers) recordView(c echo.Context, survey *models.Survey) {
+	req := c.Request()
+	if analytics.IsBot(req.UserAgent()) {
+		return
+	}
+	h.views.Record(survey.ID, analytics.ReferrerHost(req.Referer(), req.Host), time.Now())
+}
+
+// analyticsReport builds the analytics report of a survey by interval, over the
+// ?days= period (default: 2 for hours and 30 for days; max 7 and 90)
+func (h *Handlers) analyticsReport(c echo.Context, survey *models.Survey, interval analytics.Interval) (*analytics.Report, error) {
+	days, _ := strconv.Atoi(c.QueryParam("days"))
+	days = interval.Days(days)
+
+	ctx := c.Request().Context()
+	now := time.Now()
+	since := interval.Since(now, days)
+
+	views, err := h.analytics.ListSurveyViews(ctx, survey.ID, since)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := h.analytics.ListHourlyResponses(ctx, survey.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return analytics.Build(interval, since, now, days, views, responses), nil
+}
+
+// GetSurveyAnalytics returns responses and views over time, the view to
+// submission funnel, and the top referrers of a survey, for its author
+// GET /api/v1/surveys/:slug/analytics?interval=day&days=30
+func (h *Handlers) GetSurveyAnalytics(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	if h.analytics == nil {
+		return Problem(c, problem.ServiceUnavailable, "Analytics unavailable: Survey analytics are not enabled on this server")
+	}
+
+	survey, err := h.surveyBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.SurveyNotFound, fmt.Sprintf("No survey found with slug '%s'", slug))
+		}
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+
+	caller, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key of the survey author")
+	}
+	if !h.canReadSurveyAs(c.Request().Context(), caller, survey) {
+		return Problem(c, problem.Forbidden, "Only the survey author can view analytics")
+	}
+
+	interval, ok := analytics.ParseInterval(c.QueryParam("interval"))
+	if !ok {
+		return Problem(c, problem.ValidationFailed, "Invalid interval: Use 'hour' or 'day'")
+	}
+	report, err := h.analyticsReport(c, survey, interval)
+	if err != nil {
+		return InternalServerError(c, "Failed to load analytics", err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// AnalyticsPageHTML renders the response rate charts, funnel, and referrers of a survey
+// GET /surveys/:slug/analytics?interval=day&days=30
+func (h *Handlers) AnalyticsPageHTML(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if h.analytics == nil {
+		return c.String(http.StatusServiceUnavailable, "Survey analytics are not enabled")
+	}
+
+	survey, err := h.surveyBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user, profile := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canReadSurvey(ctx, user, survey) {
+		return c.String(http.StatusForbidden, "Only the survey author can view analytics")
+	}
+
+	interval, ok := analytics.ParseInterval(c.QueryParam("interval"))
+	if !ok {
+		return c.String(http.StatusBadRequest, "Unknown interval; use 'hour' or 'day'")
+	}
+	report, err := h.analyticsReport(c, survey, interval)
+	if err != nil {
+		c.Logger().Errorf("Failed to load analytics of survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to load analytics")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.AnalyticsPage(survey, report, user, profile, h.posthogKey)
+	return component.Render(ctx, c.Response().Writer)
+}
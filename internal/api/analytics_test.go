@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/analytics"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAnalytics stores survey views and returns fixed hourly responses
+type mockAnalytics struct {
+	views     []*analytics.View
+	responses []*analytics.HourCount
+}
+
+func (m *mockAnalytics) AddSurveyViews(ctx context.Context, views []*analytics.View) error {
+	m.views = append(m.views, views...)
+	return nil
+}
+
+func (m *mockAnalytics) ListSurveyViews(ctx context.Context, surveyID uuid.UUID, since time.Time) ([]*analytics.View, error) {
+	return m.views, nil
+}
+
+func (m *mockAnalytics) ListHourlyResponses(ctx context.Context, surveyID uuid.UUID, since time.Time) ([]*analytics.HourCount, error) {
+	return m.responses, nil
+}
+
+func TestGetSurveyAnalytics(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+	hour := analytics.Hour(time.Now())
+	store := &mockAnalytics{
+		views: []*analytics.View{
+			{SurveyID: survey.ID, Hour: hour, Referrer: "bsky.app", Views: 6},
+			{SurveyID: survey.ID, Hour: hour, Referrer: analytics.Direct, Views: 2},
+		},
+		responses: []*analytics.HourCount{{Hour: hour, Count: 2}},
+	}
+	h.SetAnalytics(store, nil)
+
+	c, rec := newResponsesContext(e, "/api/v1/surveys/export-survey/analytics", survey.Slug, url.Values{"interval": {"hour"}}, &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.GetSurveyAnalytics(c))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var report analytics.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, analytics.IntervalHour, report.Interval)
+	assert.Equal(t, analytics.DefaultHourDays, report.Days)
+	assert.Equal(t, analytics.Funnel{Views: 8, Submissions: 2, ConversionRate: 0.25}, report.Funnel)
+	require.Len(t, report.Referrers, 2)
+	assert.Equal(t, "bsky.app", report.Referrers[0].Host)
+
+	last := report.Series[len(report.Series)-1]
+	assert.Equal(t, 8, last.Views)
+	assert.Equal(t, 2, last.Responses)
+}
+
+func TestGetSurveyAnalytics_Access(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  *oauth.User
+		query url.Values
+		want  int
+	}{
+		{"not logged in", nil, url.Values{}, http.StatusUnauthorized},
+		{"not the author", &oauth.User{DID: "did:plc:someone"}, url.Values{}, http.StatusForbidden},
+		{"invalid interval", &oauth.User{DID: "did:plc:author"}, url.Values{"interval": {"week"}}, http.StatusBadRequest},
+		{"author", &oauth.User{DID: "did:plc:author"}, url.Values{}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _, h, survey := setupExportTest(t)
+			h.SetAnalytics(&mockAnalytics{}, nil)
+
+			c, rec := newResponsesContext(e, "/api/v1/surveys/export-survey/analytics", survey.Slug, tt.query, tt.user)
+			require.NoError(t, h.GetSurveyAnalytics(c))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestGetSurveyAnalytics_Disabled(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+
+	c, rec := newResponsesContext(e, "/api/v1/surveys/export-survey/analytics", survey.Slug, url.Values{}, &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.GetSurveyAnalytics(c))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestRecordView(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+	store := &mockAnalytics{}
+	views := analytics.NewViewCounter(store)
+	h.SetAnalytics(store, views)
+
+	for _, ua := range []string{"Mozilla/5.0 (X11; Linux x86_64)", "Googlebot/2.1 (+http://www.google.com/bot.html)"} {
+		req := httptest.NewRequest(http.MethodGet, "/surveys/export-survey", nil)
+		req.Header.Set("User-Agent", ua)
+		req.Header.Set("Referer", "https://bsky.app/profile/alice.bsky.social")
+		h.recordView(e.NewContext(req, httptest.NewRecorder()), survey)
+	}
+	require.NoError(t, views.Flush(context.Background()))
+
+	require.Len(t, store.views, 1, "crawlers are not counted")
+	assert.Equal(t, "bsky.app", store.views[0].Referrer)
+	assert.Equal(t, 1, store.views[0].Views)
+}
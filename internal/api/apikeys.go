@@ -0,0 +1,190 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+// apiKeyTouchInterval limits how often a key's last use is written
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyFromContext returns the API key that authenticated the request, or nil
+func APIKeyFromContext(c echo.Context) *apikey.Key {
+	key, _ := c.Get("api_key").(*apikey.Key)
+	return key
+}
+
+// APIKeyMiddleware authenticates requests carrying "Authorization: Bearer sk_..."
+// and enforces the key's rate limit. Requests without an Authorization header
+// pass through anonymously unless config.Required is set.
+func APIKeyMiddleware(store apikey.Store, config apikey.Config, limiter *KeyRateLimiter, usage *apikey.UsageCounter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if header == "" {
+				if config.Required {
+					return unauthorizedAPIKey(c, "An API key is required")
+				}
+				return next(c)
+			}
+
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok || !strings.HasPrefix(token, apikey.TokenPrefix) {
+				return unauthorizedAPIKey(c, "Expected Authorization: Bearer sk_...")
+			}
+
+			ctx := c.Request().Context()
+			key, err := store.GetAPIKeyByHash(ctx, apikey.Hash(token))
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return unauthorizedAPIKey(c, "Unknown API key")
+				}
+				return InternalServerError(c, "Failed to verify API key", err)
+			}
+			if !key.Active() {
+				return unauthorizedAPIKey(c, "API key has been revoked")
+			}
+
+			now := time.Now()
+			allowed, status := limiter.Allow(key)
+			usage.Record(key.ID, !allowed, now)
+			if !allowed {
+				telemetry.APIKeyRequestsTotal.WithLabelValues("rate_limited").Inc()
+				return rateLimitExceeded(c, status, "Too many requests for this API key. Please try again later.")
+			}
+			setRateLimitHeaders(c, status)
+
+			// Record use at most once per interval to avoid a write per request
+			if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+				if err := store.TouchAPIKey(ctx, key.ID, now); err != nil {
+					c.Logger().Errorf("Failed to record API key use: %v", err)
+				}
+			}
+
+			telemetry.APIKeyRequestsTotal.WithLabelValues("allowed").Inc()
+			c.Set("api_key", key)
+			return next(c)
+		}
+	}
+}
+
+// unauthorizedAPIKey returns a 401 response asking for a bearer token
+func unauthorizedAPIKey(c echo.Context, details string) error {
+	telemetry.APIKeyRequestsTotal.WithLabelValues("unauthorized").Inc()
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+	return Problem(c, problem.InvalidAPIKey, details)
+}
+
+// RequireScope rejects requests whose API key lacks the scope. Anonymous
+// requests are not affected; APIKeyMiddleware decides whether they are allowed.
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if key := APIKeyFromContext(c); key != nil && !key.Allows(scope) {
+				return Problem(c, problem.InsufficientScope, "This API key needs the '"+scope+"' scope")
+			}
+			return next(c)
+		}
+	}
+}
+
+// callerDID returns the DID the request acts as, in order of precedence: the
+// caller of an XRPC query with a service auth token, the owner of the API key
+// the request is authenticated with, or the logged-in user
+func callerDID(c echo.Context) (string, bool) {
+	if did := serviceAuthCaller(c); did != "" {
+		return did, true
+	}
+	if key := APIKeyFromContext(c); key != nil {
+		return key.OwnerDID, true
+	}
+	if user := oauth.GetUser(c); user != nil {
+		return user.DID, true
+	}
+	return "", false
+}
+
+// ListAPIKeys handles GET /api/v1/keys
+// Lists the caller's keys, including revoked ones
+func (h *Handlers) ListAPIKeys(c echo.Context) error {
+	owner, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key with the 'admin' scope")
+	}
+
+	keys, err := h.apiKeys.ListAPIKeysByOwner(c.Request().Context(), owner)
+	if err != nil {
+		return InternalServerError(c, "Failed to list API keys", err)
+	}
+	if keys == nil {
+		keys = []*apikey.Key{}
+	}
+
+	return c.JSON(http.StatusOK, ListAPIKeysResponse{Keys: keys})
+}
+
+// CreateAPIKey handles POST /api/v1/keys
+// The token is only returned in this response
+func (h *Handlers) CreateAPIKey(c echo.Context) error {
+	owner, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key with the 'admin' scope")
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+
+	key, token, err := apikey.New(owner, req.Name, req.Scopes, req.RateLimit)
+	if err != nil {
+		return Problem(c, problem.ValidationFailed, "Invalid API key: "+err.Error())
+	}
+
+	ctx := c.Request().Context()
+	count, err := h.apiKeys.CountActiveAPIKeys(ctx, owner)
+	if err != nil {
+		return InternalServerError(c, "Failed to create API key", err)
+	}
+	if count >= apikey.MaxKeysPerUser {
+		return Problem(c, problem.LimitReached, "Too many API keys: Revoke an existing key before creating another")
+	}
+
+	if err := h.apiKeys.CreateAPIKey(ctx, key); err != nil {
+		return InternalServerError(c, "Failed to create API key", err)
+	}
+
+	return c.JSON(http.StatusCreated, CreateAPIKeyResponse{Key: key, Token: token})
+}
+
+// RevokeAPIKey handles DELETE /api/v1/keys/:id
+func (h *Handlers) RevokeAPIKey(c echo.Context) error {
+	owner, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key with the 'admin' scope")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return Problem(c, problem.ValidationFailed, "Invalid key ID: "+err.Error())
+	}
+
+	if err := h.apiKeys.RevokeAPIKey(c.Request().Context(), id, owner); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.NotFound, "API key not found: No active key with this ID belongs to you")
+		}
+		return InternalServerError(c, "Failed to revoke API key", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,345 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAPIKeys is an in-memory apikey.Store
+type mockAPIKeys struct {
+	keys    map[uuid.UUID]*apikey.Key
+	usage   []*apikey.Usage
+	touches int
+}
+
+func newMockAPIKeys() *mockAPIKeys {
+	return &mockAPIKeys{keys: make(map[uuid.UUID]*apikey.Key)}
+}
+
+func (m *mockAPIKeys) CreateAPIKey(ctx context.Context, k *apikey.Key) error {
+	m.keys[k.ID] = k
+	return nil
+}
+
+func (m *mockAPIKeys) GetAPIKeyByHash(ctx context.Context, hash string) (*apikey.Key, error) {
+	for _, k := range m.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockAPIKeys) ListAPIKeysByOwner(ctx context.Context, ownerDID string) ([]*apikey.Key, error) {
+	var keys []*apikey.Key
+	for _, k := range m.keys {
+		if k.OwnerDID == ownerDID {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockAPIKeys) CountActiveAPIKeys(ctx context.Context, ownerDID string) (int, error) {
+	count := 0
+	for _, k := range m.keys {
+		if k.OwnerDID == ownerDID && k.Active() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockAPIKeys) RevokeAPIKey(ctx context.Context, id uuid.UUID, ownerDID string) error {
+	k, ok := m.keys[id]
+	if !ok || k.OwnerDID != ownerDID || !k.Active() {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	k.RevokedAt = &now
+	return nil
+}
+
+func (m *mockAPIKeys) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	m.touches++
+	m.keys[id].LastUsedAt = &usedAt
+	return nil
+}
+
+func (m *mockAPIKeys) AddAPIKeyUsage(ctx context.Context, usage []*apikey.Usage) error {
+	m.usage = append(m.usage, usage...)
+	return nil
+}
+
+func (m *mockAPIKeys) ListAPIKeyUsage(ctx context.Context, ownerDID string, since time.Time) ([]*apikey.Usage, error) {
+	var usage []*apikey.Usage
+	for _, u := range m.usage {
+		if k, ok := m.keys[u.KeyID]; ok && k.OwnerDID == ownerDID && !u.Day.Before(since) {
+			usage = append(usage, u)
+		}
+	}
+	return usage, nil
+}
+
+// addKey stores a new key and returns its token
+func (m *mockAPIKeys) addKey(t *testing.T, scopes []string, rateLimit int) (*apikey.Key, string) {
+	t.Helper()
+	key, token, err := apikey.New("did:plc:owner", "test", scopes, rateLimit)
+	require.NoError(t, err)
+	m.keys[key.ID] = key
+	return key, token
+}
+
+// serveWithKey runs handler behind the API key middleware and RequireScope
+func serveWithKey(store apikey.Store, config apikey.Config, limiter *KeyRateLimiter, scope, authorization string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/test", nil)
+	if authorization != "" {
+		req.Header.Set(echo.HeaderAuthorization, authorization)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	}
+	_ = APIKeyMiddleware(store, config, limiter, nil)(RequireScope(scope)(handler))(c)
+	return rec
+}
+
+func TestAPIKeyMiddleware_Anonymous(t *testing.T) {
+	store := newMockAPIKeys()
+	limiter := NewKeyRateLimiter()
+
+	rec := serveWithKey(store, apikey.Config{}, limiter, apikey.ScopeWrite, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveWithKey(store, apikey.Config{Required: true}, limiter, apikey.ScopeRead, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+}
+
+func TestAPIKeyMiddleware_Authenticates(t *testing.T) {
+	store := newMockAPIKeys()
+	limiter := NewKeyRateLimiter()
+	key, token := store.addKey(t, []string{apikey.ScopeRead}, 0)
+
+	rec := serveWithKey(store, apikey.Config{Required: true}, limiter, apikey.ScopeRead, "Bearer "+token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotNil(t, key.LastUsedAt)
+
+	// Use within the touch interval is not written again
+	rec = serveWithKey(store, apikey.Config{}, limiter, apikey.ScopeRead, "Bearer "+token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, store.touches)
+}
+
+func TestAPIKeyMiddleware_Rejects(t *testing.T) {
+	store := newMockAPIKeys()
+	limiter := NewKeyRateLimiter()
+	key, token := store.addKey(t, []string{apikey.ScopeRead}, 0)
+
+	tests := []struct {
+		name          string
+		authorization string
+		scope         string
+		want          int
+	}{
+		{"not a bearer token", "Basic abc", apikey.ScopeRead, http.StatusUnauthorized},
+		{"not an API key", "Bearer eyJhbGciOi", apikey.ScopeRead, http.StatusUnauthorized},
+		{"unknown key", "Bearer sk_unknown", apikey.ScopeRead, http.StatusUnauthorized},
+		{"missing scope", "Bearer " + token, apikey.ScopeWrite, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveWithKey(store, apikey.Config{}, limiter, tt.scope, tt.authorization)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	rec := serveWithKey(store, apikey.Config{}, limiter, apikey.ScopeRead, "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "revoked")
+}
+
+func TestAPIKeyMiddleware_RateLimit(t *testing.T) {
+	store := newMockAPIKeys()
+	limiter := NewKeyRateLimiter()
+	_, token := store.addKey(t, nil, 3)
+	_, other := store.addKey(t, nil, 3)
+
+	for i := 0; i < 3; i++ {
+		rec := serveWithKey(store, apikey.Config{}, limiter, apikey.ScopeRead, "Bearer "+token)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "3", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, strconv.Itoa(2-i), rec.Header().Get("RateLimit-Remaining"))
+	}
+
+	rec := serveWithKey(store, apikey.Config{}, limiter, apikey.ScopeRead, "Bearer "+token)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// Each key has its own budget
+	rec = serveWithKey(store, apikey.Config{}, limiter, apikey.ScopeRead, "Bearer "+other)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestIPRateLimiter_SkipsAPIKeys(t *testing.T) {
+	e := echo.New()
+	limiter := NewIPRateLimiter(1, time.Minute)
+	handler := limiter.Middleware()(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "192.168.1.100:12345"
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("api_key", &apikey.Key{ID: uuid.New(), RateLimit: 1})
+
+		require.NoError(t, handler(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+}
+
+func TestVoteSubmissionLimit_AppliesToAPIKeys(t *testing.T) {
+	e := echo.New()
+	limiter := NewRateLimiterConfig().VoteSubmission
+	handler := limiter.Middleware()(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	key := &apikey.Key{ID: uuid.New(), RateLimit: apikey.MaxRateLimit}
+
+	// The key is held to the vote limit even when it rotates IPs
+	var rec *httptest.ResponseRecorder
+	for i := 0; i < 11; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/test", nil)
+		req.RemoteAddr = fmt.Sprintf("192.168.1.%d:12345", i+1)
+		rec = httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("api_key", key)
+
+		require.NoError(t, handler(c))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Regexp(t, `^[0-9]+$`, rec.Header().Get("Retry-After"))
+}
+
+func TestAPIKeyHandlers(t *testing.T) {
+	store := newMockAPIKeys()
+	h := NewHandlers(&MockQueries{})
+	h.SetAPIKeys(store, apikey.Config{}, nil)
+	e := echo.New()
+
+	newContext := func(method, target, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("user", &oauth.User{DID: "did:plc:owner"})
+		return c, rec
+	}
+
+	// Create
+	c, rec := newContext(http.MethodPost, "/api/v1/keys", `{"name":"CI","scopes":["write"],"rateLimit":30}`)
+	require.NoError(t, h.CreateAPIKey(c))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	token, _ := created["token"].(string)
+	assert.True(t, strings.HasPrefix(token, apikey.TokenPrefix))
+	assert.Equal(t, "CI", created["name"])
+	assert.Equal(t, float64(30), created["rateLimit"])
+	assert.NotContains(t, rec.Body.String(), apikey.Hash(token))
+
+	// List never includes tokens
+	c, rec = newContext(http.MethodGet, "/api/v1/keys", "")
+	require.NoError(t, h.ListAPIKeys(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), token)
+	assert.Contains(t, rec.Body.String(), `"scopes":["write"]`)
+
+	// Invalid scope
+	c, rec = newContext(http.MethodPost, "/api/v1/keys", `{"name":"CI","scopes":["root"]}`)
+	require.NoError(t, h.CreateAPIKey(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Revoke
+	id := created["id"].(string)
+	c, rec = newContext(http.MethodDelete, "/api/v1/keys/"+id, "")
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+	require.NoError(t, h.RevokeAPIKey(c))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	c, rec = newContext(http.MethodDelete, "/api/v1/keys/"+id, "")
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+	require.NoError(t, h.RevokeAPIKey(c))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Anonymous callers cannot manage keys
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/keys", nil)
+	rec = httptest.NewRecorder()
+	require.NoError(t, h.ListAPIKeys(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAPIKeyHandlers_AdminKeyManagesOwnersKeys(t *testing.T) {
+	store := newMockAPIKeys()
+	h := NewHandlers(&MockQueries{})
+	h.SetAPIKeys(store, apikey.Config{}, nil)
+	admin, _ := store.addKey(t, []string{apikey.ScopeAdmin}, 0)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/keys", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("api_key", admin)
+
+	require.NoError(t, h.ListAPIKeys(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), admin.ID.String())
+}
+
+func TestAPIKeyMiddleware_RecordsUsage(t *testing.T) {
+	store := newMockAPIKeys()
+	limiter := NewKeyRateLimiter()
+	counter := apikey.NewUsageCounter(store)
+	key, token := store.addKey(t, nil, 1)
+
+	e := echo.New()
+	handler := APIKeyMiddleware(store, apikey.Config{}, limiter, counter)(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/test", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		require.NoError(t, handler(e.NewContext(req, httptest.NewRecorder())))
+	}
+
+	require.NoError(t, counter.Flush(context.Background()))
+	require.Len(t, store.usage, 1)
+	assert.Equal(t, key.ID, store.usage[0].KeyID)
+	assert.Equal(t, 2, store.usage[0].Requests)
+	assert.Equal(t, 1, store.usage[0].RateLimited)
+}
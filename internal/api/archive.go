@@ -0,0 +1,24 @@
+package api
+
+import (
+	"context"
+
+	"github.com/openmeet-team/survey/internal/archive"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// SetArchive restores the archived responses of surveys when their author
+// reads them
+func (h *Handlers) SetArchive(store archive.Store) {
+	h.archive = store
+}
+
+// restoreArchive restores the responses of an archived survey before they are
+// read. The archival job archives them again if the survey stays closed.
+func (h *Handlers) restoreArchive(ctx context.Context, survey *models.Survey) error {
+	if h.archive == nil {
+		return nil
+	}
+	_, err := h.archive.RestoreArchivedSurvey(ctx, survey.ID)
+	return err
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockArchive holds archived responses, restored into the mock queries
+type mockArchive struct {
+	queries   *MockQueries
+	responses map[uuid.UUID][]*models.Response
+}
+
+func (m *mockArchive) ListArchivableSurveys(ctx context.Context, endedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (m *mockArchive) ArchiveSurvey(ctx context.Context, surveyID uuid.UUID, results *models.SurveyResults) (int, error) {
+	return 0, nil
+}
+
+func (m *mockArchive) RestoreArchivedSurvey(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	responses := m.responses[surveyID]
+	for _, r := range responses {
+		if err := m.queries.CreateResponse(ctx, r); err != nil {
+			return 0, err
+		}
+	}
+	delete(m.responses, surveyID)
+	return len(responses), nil
+}
+
+func (m *mockArchive) ListReopenedSurveys(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (m *mockArchive) GetArchivedResults(ctx context.Context, surveyID uuid.UUID) (*models.SurveyResults, error) {
+	return nil, sql.ErrNoRows
+}
+
+func TestExportResponses_RestoresArchive(t *testing.T) {
+	e, mq, h, survey := setupExportTest(t)
+	voterDID := "did:plc:archived"
+	archive := &mockArchive{queries: mq, responses: map[uuid.UUID][]*models.Response{
+		survey.ID: {{
+			ID:        uuid.New(),
+			SurveyID:  survey.ID,
+			VoterDID:  &voterDID,
+			Answers:   map[string]models.Answer{"color": {SelectedOptions: []string{"green"}}},
+			CreatedAt: time.Now().Add(-2 * time.Hour),
+		}},
+	}}
+	h.SetArchive(archive)
+
+	c, rec := newExportContext(e, survey.Slug, "csv", &oauth.User{DID: "did:plc:someone"})
+	require.NoError(t, h.ExportResponses(c))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Len(t, archive.responses, 1, "only the author's exports restore archives")
+
+	c, rec = newExportContext(e, survey.Slug, "csv", &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.ExportResponses(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "did:plc:archived", "archived responses are restored for the export")
+	assert.Empty(t, archive.responses)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/attestation"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+)
+
+// SetResultsSigner enables signing published results records
+func (h *Handlers) SetResultsSigner(s *attestation.Signer) {
+	h.attestations = s
+}
+
+// publishedResultsRecord builds the results record to publish, signed with
+// the results signing key if one is configured
+func (h *Handlers) publishedResultsRecord(survey *models.Survey, results *models.SurveyResults, aggregatedAt time.Time) (map[string]interface{}, error) {
+	record := h.resultsRecord(survey, results, aggregatedAt)
+	if !h.attestations.Enabled() {
+		return record, nil
+	}
+	a, err := h.attestations.Sign(record)
+	if err != nil {
+		return nil, err
+	}
+	record[attestation.Field] = a.Record()
+	return record, nil
+}
+
+// verifyAttestation checks the attestation of a results record in the ATProto
+// JSON data model
+func (h *Handlers) verifyAttestation(record map[string]any) *AttestationVerification {
+	v := &AttestationVerification{}
+	a, err := h.attestations.Verify(record)
+	if a != nil {
+		v.Signed = true
+		v.KeyID = a.KeyID
+	}
+	switch {
+	case err == nil:
+		v.Valid = true
+	case errors.Is(err, attestation.ErrUnsigned):
+		v.Error = "The record has no attestation"
+	case errors.Is(err, attestation.ErrUnknownKey):
+		v.Error = "The record is signed with a key this service does not publish"
+	case errors.Is(err, attestation.ErrInvalidSignature):
+		v.Error = "The signature does not match the record"
+	default:
+		v.Error = err.Error()
+	}
+	return v
+}
+
+// ResultsSigningKeys serves the public keys published results records are
+// signed with, as a JSON Web Key Set
+// GET /.well-known/results-signing-keys.json
+func (h *Handlers) ResultsSigningKeys(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+	return c.JSON(http.StatusOK, h.attestations.JWKS())
+}
+
+// VerifyAttestation checks the signature of a results record, e.g. one
+// fetched from an author's PDS or re-published elsewhere
+// POST /api/v1/results/verify
+func (h *Handlers) VerifyAttestation(c echo.Context) error {
+	var req VerifyAttestationRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+	if req.Record == nil {
+		return Problem(c, problem.ValidationFailed, "record is required")
+	}
+	return c.JSON(http.StatusOK, h.verifyAttestation(req.Record))
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/attestation"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResultsSigner(t *testing.T) *attestation.Signer {
+	seed := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	s, err := attestation.NewFromConfig(attestation.Config{PrivateKey: seed})
+	require.NoError(t, err)
+	return s
+}
+
+func TestPublishedResultsRecord(t *testing.T) {
+	_, mq, h := setupTest()
+	alice := "did:plc:alice"
+	survey := createTextSurvey(mq, "lunch", &alice)
+	uri, cid := "at://did:plc:alice/net.openmeet.survey/lunch", "bafysurvey"
+	survey.URI, survey.CID = &uri, &cid
+	results, err := mq.GetSurveyResults(context.Background(), survey.ID)
+	require.NoError(t, err)
+
+	// Without a signing key, results are published unsigned
+	record, err := h.publishedResultsRecord(survey, results, time.Now())
+	require.NoError(t, err)
+	assert.NotContains(t, record, attestation.Field)
+
+	h.SetResultsSigner(newResultsSigner(t))
+	record, err = h.publishedResultsRecord(survey, results, time.Now())
+	require.NoError(t, err)
+	require.Contains(t, record, attestation.Field)
+
+	value, err := jsonDataModel(record)
+	require.NoError(t, err)
+	a, err := h.attestations.Verify(value)
+	require.NoError(t, err)
+	assert.Equal(t, h.attestations.KeyID(), a.KeyID)
+}
+
+func TestResultsAttestationRoutes(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetResultsSigner(newResultsSigner(t))
+	SetupRoutes(e, h, &HealthHandlers{}, nil, nil)
+
+	alice := "did:plc:alice"
+	survey := createTextSurvey(mq, "lunch", &alice)
+	uri, cid := "at://did:plc:alice/net.openmeet.survey/lunch", "bafysurvey"
+	survey.URI, survey.CID = &uri, &cid
+	results, err := mq.GetSurveyResults(context.Background(), survey.ID)
+	require.NoError(t, err)
+	record, err := h.publishedResultsRecord(survey, results, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	published, err := jsonDataModel(record)
+	require.NoError(t, err)
+
+	t.Run("publishes the keys", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/results-signing-keys.json", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var jwks attestation.JWKSet
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jwks))
+		require.Len(t, jwks.Keys, 1)
+		assert.Equal(t, h.attestations.KeyID(), jwks.Keys[0].KeyID)
+	})
+
+	verify := func(record map[string]any) *AttestationVerification {
+		body, _ := json.Marshal(VerifyAttestationRequest{Record: record})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/results/verify", strings.NewReader(string(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var v AttestationVerification
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &v))
+		return &v
+	}
+
+	t.Run("verifies records", func(t *testing.T) {
+		v := verify(published)
+		assert.True(t, v.Signed)
+		assert.True(t, v.Valid)
+		assert.Equal(t, h.attestations.KeyID(), v.KeyID)
+
+		tampered := make(map[string]any, len(published))
+		for k, val := range published {
+			tampered[k] = val
+		}
+		tampered["totalVotes"] = float64(7)
+		v = verify(tampered)
+		assert.True(t, v.Signed)
+		assert.False(t, v.Valid)
+		assert.NotEmpty(t, v.Error)
+
+		delete(tampered, attestation.Field)
+		v = verify(tampered)
+		assert.False(t, v.Signed)
+		assert.False(t, v.Valid)
+	})
+
+	t.Run("checks the published record", func(t *testing.T) {
+		resultsURI, resultsCID := "at://did:plc:alice/net.openmeet.survey.results/lunch", "bafyresults"
+		survey.ResultsURI, survey.ResultsCID = &resultsURI, &resultsCID
+		h.fetchRecord = func(ctx context.Context, uri string) (*oauth.PDSRecord, error) {
+			return &oauth.PDSRecord{URI: uri, CID: resultsCID, Value: published}, nil
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/surveys/lunch/verify", nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var response ResultsVerificationResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		require.NotNil(t, response.Attestation)
+		assert.True(t, response.Attestation.Valid)
+		assert.True(t, response.Match)
+	})
+}
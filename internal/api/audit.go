@@ -0,0 +1,177 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/audit"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+)
+
+// SetAudit enables exports of the records survey results are counted from
+func (h *Handlers) SetAudit(store audit.Store) {
+	h.audit = store
+}
+
+// ExportRecords downloads a survey's record and its response records as a CAR
+// file. Its root is a manifest of every counted record, so anyone can check
+// the records against their CIDs and recount the results.
+// GET /api/v1/surveys/:slug/responses.car
+func (h *Handlers) ExportRecords(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return h.surveyNotFoundJSON(c, slug)
+		}
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+	if h.surveyHidden(c, survey) {
+		return surveyHiddenJSON(c)
+	}
+	if !h.canViewSurvey(c, survey) {
+		return surveyPrivateJSON(c)
+	}
+
+	// Response record URIs name their voters
+	if survey.Definition.Anonymous {
+		return Problem(c, problem.SurveyAnonymous, "Response records name their voters; use the results instead")
+	}
+	if survey.URI == nil || survey.CID == nil {
+		return Problem(c, problem.NotFound, "The survey is not published as a record, so it has no records to export")
+	}
+
+	surveyRecord, err := h.audit.GetIndexedRecord(ctx, *survey.URI, *survey.CID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve records", err)
+	}
+	if surveyRecord == nil {
+		surveyRecord = &audit.Record{URI: *survey.URI, CID: *survey.CID}
+	}
+	responses, err := h.audit.ListResponseRecords(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve records", err)
+	}
+	local, err := h.audit.CountLocalResponses(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve records", err)
+	}
+
+	export, err := audit.NewExport(surveyRecord, responses, local, time.Now())
+	if err != nil {
+		return InternalServerError(c, "Failed to export records", err)
+	}
+	var buf bytes.Buffer
+	if err := export.WriteCAR(&buf); err != nil {
+		return InternalServerError(c, "Failed to export records", err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", survey.Slug+"-responses.car"))
+	return c.Blob(http.StatusOK, audit.ContentType, buf.Bytes())
+}
+
+// VerifyResults checks a survey's published results: it fetches the results
+// record from the author's PDS, checks it against the CID it was published
+// at, and compares its tallies with a recount of the indexed responses. Votes
+// cast after publishing make counts differ, so they are reported too. If the
+// service signs results, the record's signature is checked as well.
+// GET /api/v1/surveys/:slug/verify
+func (h *Handlers) VerifyResults(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return h.surveyNotFoundJSON(c, slug)
+		}
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+	if h.surveyHidden(c, survey) {
+		return surveyHiddenJSON(c)
+	}
+	if !h.canViewSurvey(c, survey) {
+		return surveyPrivateJSON(c)
+	}
+	if survey.ResultsURI == nil || survey.ResultsCID == nil || survey.URI == nil || survey.CID == nil {
+		return Problem(c, problem.NotFound, "The survey has no published results to verify")
+	}
+
+	record, err := h.fetchRecord(ctx, *survey.ResultsURI)
+	if err != nil {
+		c.Logger().Errorf("Failed to fetch results record %s: %v", *survey.ResultsURI, err)
+		return Problem(c, problem.PDSUnavailable, "The published results record could not be fetched from the author's PDS")
+	}
+
+	// Recount without the cache, which may lag the indexed responses
+	results, err := h.queries.GetSurveyResults(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve results", err)
+	}
+	recomputed, err := jsonDataModel(h.resultsRecord(survey, results, time.Now()))
+	if err != nil {
+		return InternalServerError(c, "Failed to recount results", err)
+	}
+
+	response := ResultsVerificationResponse{
+		ResultsURI:     *survey.ResultsURI,
+		ResultsCID:     *survey.ResultsCID,
+		RecordVerified: record.CID == *survey.ResultsCID && audit.Matches(record.Value, *survey.ResultsCID),
+		VerifiedAt:     time.Now().UTC(),
+		Comparison:     audit.CompareResults(record.Value, recomputed),
+	}
+	if h.attestations.Enabled() {
+		response.Attestation = h.verifyAttestation(record.Value)
+	}
+	if finalizedAt, ok := record.Value["finalizedAt"].(string); ok {
+		response.PublishedAt = finalizedAt
+		if published, err := time.Parse(time.RFC3339, finalizedAt); err == nil {
+			since, err := h.queries.CountFilteredResponses(ctx, survey.ID, models.ResponseFilter{From: published})
+			if err != nil {
+				return InternalServerError(c, "Failed to count responses", err)
+			}
+			response.ResponsesSincePublished = since
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// jsonDataModel converts a record built in Go to the ATProto JSON data model
+// records are fetched in
+func jsonDataModel(record map[string]interface{}) (map[string]any, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	var value map[string]any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// fetchPDSRecord fetches the record at an AT URI from the PDS hosting its repo
+func fetchPDSRecord(ctx context.Context, uri string) (*oauth.PDSRecord, error) {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid record URI %q", uri)
+	}
+	pdsURL, err := oauth.DIDToPDS(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	return oauth.GetRecord(ctx, pdsURL, parts[0], parts[1], parts[2])
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/audit"
+	"github.com/openmeet-team/survey/internal/firehose"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAuditStore holds kept records by URI and the response records of one survey
+type mockAuditStore struct {
+	records   map[string]*audit.Record
+	responses []*audit.Record
+	local     int
+}
+
+func (m *mockAuditStore) GetIndexedRecord(ctx context.Context, uri, cid string) (*audit.Record, error) {
+	if r, ok := m.records[uri]; ok && r.CID == cid {
+		return r, nil
+	}
+	return nil, nil
+}
+
+func (m *mockAuditStore) ListResponseRecords(ctx context.Context, surveyID uuid.UUID) ([]*audit.Record, error) {
+	return m.responses, nil
+}
+
+func (m *mockAuditStore) CountLocalResponses(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	return m.local, nil
+}
+
+func TestExportRecords(t *testing.T) {
+	e, mq, h := setupTest()
+	alice := "did:plc:alice"
+	survey := createTextSurvey(mq, "lunch", &alice)
+	uri := "at://did:plc:alice/net.openmeet.survey/lunch"
+	value := map[string]any{"$type": "net.openmeet.survey", "name": "Lunch"}
+	_, cid, err := firehose.EncodeRecord(value)
+	require.NoError(t, err)
+	cidString := cid.String()
+	survey.URI, survey.CID = &uri, &cidString
+
+	vote := map[string]any{"$type": "net.openmeet.survey.response", "subject": map[string]any{"uri": uri}}
+	_, voteCID, err := firehose.EncodeRecord(vote)
+	require.NoError(t, err)
+	store := &mockAuditStore{
+		records: map[string]*audit.Record{uri: {URI: uri, CID: cidString, Value: value}},
+		responses: []*audit.Record{
+			{URI: "at://did:plc:bob/net.openmeet.survey.response/1", CID: voteCID.String(), Value: vote},
+		},
+		local: 2,
+	}
+	h.SetAudit(store)
+	SetupRoutes(e, h, &HealthHandlers{}, nil, nil)
+
+	get := func(slug string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/surveys/"+slug+"/responses.car", nil))
+		return rec
+	}
+
+	rec := get("lunch")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, audit.ContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "lunch-responses.car")
+
+	car, err := firehose.ReadCAR(rec.Body.Bytes())
+	require.NoError(t, err)
+	require.Len(t, car.Roots, 1)
+	v, err := car.Decode(car.Roots[0])
+	require.NoError(t, err)
+	manifest := v.(map[string]any)
+	assert.Equal(t, int64(2), manifest["localResponses"])
+	assert.Empty(t, manifest["omitted"])
+	assert.Len(t, manifest["responses"], 1)
+	_, err = car.Decode(cid)
+	assert.NoError(t, err, "survey record is included")
+	_, err = car.Decode(voteCID)
+	assert.NoError(t, err, "response record is included")
+
+	// Anonymous surveys don't disclose their voters
+	survey.Definition.Anonymous = true
+	rec = get("lunch")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, problem.SurveyAnonymous, p.Code)
+
+	// Surveys without a record have nothing to export
+	createTextSurvey(mq, "local", &alice)
+	rec = get("local")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, problem.NotFound, p.Code)
+
+	rec = get("missing")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestVerifyResults(t *testing.T) {
+	e, mq, h := setupTest()
+	alice := "did:plc:alice"
+	survey := createTextSurvey(mq, "lunch", &alice)
+	SetupRoutes(e, h, &HealthHandlers{}, nil, nil)
+
+	get := func() (*httptest.ResponseRecorder, *ResultsVerificationResponse) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/surveys/lunch/verify", nil))
+		var response ResultsVerificationResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		}
+		return rec, &response
+	}
+
+	// Surveys whose results aren't published have nothing to verify
+	rec, _ := get()
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	uri, cid := "at://did:plc:alice/net.openmeet.survey/lunch", "bafysurvey"
+	resultsURI := "at://did:plc:alice/net.openmeet.survey.results/lunch"
+	published := map[string]any{
+		"$type":           "net.openmeet.survey.results",
+		"subject":         map[string]any{"uri": uri, "cid": cid},
+		"totalVotes":      float64(0),
+		"questionResults": []any{},
+		"finalizedAt":     time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	}
+	_, resultsCID, err := firehose.EncodeRecord(published)
+	require.NoError(t, err)
+	resultsCIDString := resultsCID.String()
+	survey.URI, survey.CID = &uri, &cid
+	survey.ResultsURI, survey.ResultsCID = &resultsURI, &resultsCIDString
+
+	var fetched string
+	h.fetchRecord = func(ctx context.Context, uri string) (*oauth.PDSRecord, error) {
+		fetched = uri
+		return &oauth.PDSRecord{URI: uri, CID: resultsCIDString, Value: published}, nil
+	}
+	rec, response := get()
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, resultsURI, fetched)
+	assert.True(t, response.RecordVerified)
+	assert.True(t, response.Match)
+	assert.Zero(t, response.ResponsesSincePublished)
+	assert.Equal(t, published["finalizedAt"], response.PublishedAt)
+
+	// Votes cast after publishing are counted, and make the totals differ
+	voterSession := "late"
+	require.NoError(t, mq.CreateResponse(context.Background(), &models.Response{
+		ID: uuid.New(), SurveyID: survey.ID, VoterSession: &voterSession, CreatedAt: time.Now(),
+	}))
+	rec, response = get()
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.True(t, response.RecordVerified)
+	assert.False(t, response.Match)
+	assert.Equal(t, 1, response.ResponsesSincePublished)
+	assert.Equal(t, audit.Count{Published: 0, Recomputed: 1}, response.TotalVotes)
+
+	// Published counts that the indexed responses don't add up to are reported
+	published["totalVotes"] = float64(5)
+	published["questionResults"] = []any{map[string]any{
+		"questionId":        "q1",
+		"optionCounts":      []any{},
+		"textResponseCount": float64(5),
+	}}
+	rec, response = get()
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.False(t, response.RecordVerified, "record no longer matches its CID")
+	assert.False(t, response.Match)
+	assert.Equal(t, audit.Count{Published: 5, Recomputed: 1}, response.TotalVotes)
+	require.Len(t, response.Questions, 1)
+	assert.Equal(t, []audit.CountDiff{{Kind: audit.KindTextResponses, Count: audit.Count{Published: 5}}}, response.Questions[0].Diffs)
+
+	// An unreachable PDS is reported as such
+	h.fetchRecord = func(ctx context.Context, uri string) (*oauth.PDSRecord, error) {
+		return nil, errors.New("connection refused")
+	}
+	rec, _ = get()
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, problem.PDSUnavailable, p.Code)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasePathMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+		path     string
+		want     string
+	}{
+		{"no base path", "", "/surveys/abc", "/surveys/abc"},
+		{"prefixed route", "/survey", "/survey/surveys/abc", "/surveys/abc"},
+		{"prefix root", "/survey", "/survey", "/"},
+		{"prefix root with slash", "/survey", "/survey/", "/"},
+		{"unprefixed route", "/survey", "/surveys/abc", "/surveys/abc"},
+		{"similar prefix", "/survey", "/surveyfoo/bar", "/surveyfoo/bar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Pre(BasePathMiddleware(tt.basePath))
+
+			var got string
+			e.Any("/*", func(c echo.Context) error {
+				got = c.Request().URL.Path
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBasePathMiddleware_RawPath(t *testing.T) {
+	e := echo.New()
+	e.Pre(BasePathMiddleware("/survey"))
+
+	var path, rawPath string
+	e.Any("/*", func(c echo.Context) error {
+		path = c.Request().URL.Path
+		rawPath = c.Request().URL.RawPath
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/survey/my-data/a%2Fb", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, "/my-data/a/b", path)
+	assert.Equal(t, "/my-data/a%2Fb", rawPath)
+}
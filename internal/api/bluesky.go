@@ -0,0 +1,169 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/bsky"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// blueskyShareLink returns the path shared in a post about a survey, and the
+// page to go back to: its results once published, or the survey
+func blueskyShareLink(survey *models.Survey) (link, back string) {
+	if survey.ResultsURI != nil {
+		return "/surveys/" + survey.Slug + "/results", "/surveys/" + survey.Slug + "/results"
+	}
+	return "/s/" + survey.Slug, "/surveys/" + survey.Slug
+}
+
+// blueskyText returns the suggested text of a post about a survey, shortening
+// the title to fit the link
+func blueskyText(survey *models.Survey, link string) string {
+	format := "Vote in my survey “%s”: %s"
+	if survey.ResultsURI != nil {
+		format = "The results of “%s” are in: %s"
+	}
+
+	title := []rune(survey.Title)
+	room := bsky.MaxTextLength - utf8.RuneCountInString(fmt.Sprintf(format, "", link))
+	if len(title) > room {
+		title = append(title[:max(room-1, 0)], '…')
+	}
+	return fmt.Sprintf(format, string(title), link)
+}
+
+// blueskySurvey loads the survey of a Bluesky share request and checks that
+// the caller manages it. On failure it returns nil and the error response written.
+func (h *Handlers) blueskySurvey(c echo.Context) (*models.Survey, error) {
+	survey, err := h.surveyBySlug(c.Request().Context(), c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.String(http.StatusNotFound, "Survey not found")
+		}
+		return nil, c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user := oauth.GetUser(c)
+	if user == nil {
+		return nil, c.String(http.StatusUnauthorized, "Log in to share to Bluesky")
+	}
+	if !h.canManageSurvey(c.Request().Context(), user, survey) {
+		return nil, c.String(http.StatusForbidden, "Only the survey author and editors of its organization can share it from here")
+	}
+	if survey.Definition.IsPrivate() {
+		return nil, c.String(http.StatusBadRequest, "Private surveys are shared with share and invite links")
+	}
+	if !templates.BlueskyShareable(survey) {
+		return nil, c.String(http.StatusNotFound, "Sharing to Bluesky needs PUBLIC_BASE_URL")
+	}
+	return survey, nil
+}
+
+// BlueskyComposeHTML shows the post sharing a survey to Bluesky, for the user
+// to edit and confirm. Publishing a survey or its results leads here.
+// GET /surveys/:slug/bluesky
+func (h *Handlers) BlueskyComposeHTML(c echo.Context) error {
+	survey, err := h.blueskySurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	link, back := blueskyShareLink(survey)
+	return h.renderBlueskyCompose(c, survey, blueskyText(survey, templates.AbsoluteURL(link)), back, "", "")
+}
+
+// ShareToBlueskyHTML posts a survey's link with its preview card to the
+// user's Bluesky account
+// POST /surveys/:slug/bluesky
+func (h *Handlers) ShareToBlueskyHTML(c echo.Context) error {
+	ctx := c.Request().Context()
+	survey, err := h.blueskySurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	text := c.FormValue("text")
+	link, back := blueskyShareLink(survey)
+	if err := bsky.ValidateText(text); err != nil {
+		return h.renderBlueskyCompose(c, survey, text, back, "", "Invalid post: "+err.Error())
+	}
+
+	var session *oauth.OAuthSession
+	if h.oauthStorage != nil {
+		session, _ = oauth.GetSession(c, h.oauthStorage)
+	}
+	if session == nil || session.AccessToken == "" || session.PDSUrl == "" {
+		return h.renderBlueskyCompose(c, survey, text, back, "", "Your session has expired. Please log in again.")
+	}
+	if err := h.ensureValidToken(ctx, session); err != nil {
+		c.Logger().Errorf("Failed to refresh token to share to Bluesky: %v", err)
+		return h.renderBlueskyCompose(c, survey, text, back, "", "Your session has expired. Please log in again.")
+	}
+
+	results, err := h.surveyResults(ctx, survey.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load results")
+	}
+	card := &bsky.External{
+		URI:         templates.AbsoluteURL(link),
+		Title:       survey.Title,
+		Description: fmt.Sprintf("%d questions · %d responses", len(survey.Definition.Questions), results.TotalVotes),
+	}
+
+	// Posts without a preview image still link to the survey
+	if image, err := h.surveyCard(ctx, survey, results.TotalVotes); err != nil {
+		c.Logger().Errorf("Failed to render card of survey %s: %v", survey.Slug, err)
+	} else {
+		blob, err := oauth.UploadBlob(ctx, session, image, "image/png")
+		recordPDSWrite("upload_blob", err)
+		if err != nil {
+			c.Logger().Errorf("Failed to upload card of survey %s: %v", survey.Slug, err)
+		} else {
+			card.Thumb = blob
+		}
+	}
+
+	var langs []string
+	if survey.Definition.Language != "" {
+		langs = []string{survey.Definition.Language}
+	}
+	record, err := bsky.NewPost(text, card, langs, time.Now())
+	if err != nil {
+		return h.renderBlueskyCompose(c, survey, text, back, "", "Invalid post: "+err.Error())
+	}
+
+	uri, _, err := oauth.CreateRecord(ctx, session, bsky.Collection, oauth.GenerateTID(), record)
+	recordPDSWrite("create", err)
+	if err != nil {
+		c.Logger().Errorf("Failed to post survey %s to Bluesky: %v", survey.Slug, err)
+		return h.renderBlueskyCompose(c, survey, text, back, "", "Failed to post to your Bluesky account")
+	}
+
+	return h.renderBlueskyCompose(c, survey, text, back, bsky.PostURL(uri), "")
+}
+
+// renderBlueskyCompose renders the Bluesky share page, with the post just made
+// or the error of a failed one
+func (h *Handlers) renderBlueskyCompose(c echo.Context, survey *models.Survey, text, back, postURL, formError string) error {
+	user, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.BlueskyCompose(survey, text, back, postURL, formError, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
+
+// blueskyRedirect returns the page to show after publishing a survey or its
+// results: the offer to share it to Bluesky, or path if it cannot be shared
+func blueskyRedirect(survey *models.Survey, path string) string {
+	if !templates.BlueskyShareable(survey) {
+		return path
+	}
+	return "/surveys/" + survey.Slug + "/bluesky"
+}
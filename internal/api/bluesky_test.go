@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/bsky"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlueskyCompose(t *testing.T) {
+	e, mq, h := setupTest()
+	alice := "did:plc:alice"
+	survey := createTextSurvey(mq, "lunch", &alice)
+
+	templates.SetPublicURL("https://survey.example")
+	t.Cleanup(func() { templates.SetPublicURL("") })
+
+	call := func(handler echo.HandlerFunc, method, user string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/surveys/lunch/bluesky", strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("lunch")
+		if user != "" {
+			c.Set("user", &oauth.User{DID: user})
+		}
+		require.NoError(t, handler(c))
+		return rec
+	}
+
+	t.Run("only those managing the survey", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, call(h.BlueskyComposeHTML, http.MethodGet, "", nil).Code)
+		assert.Equal(t, http.StatusForbidden, call(h.BlueskyComposeHTML, http.MethodGet, "did:plc:mallory", nil).Code)
+		assert.Equal(t, http.StatusForbidden, call(h.ShareToBlueskyHTML, http.MethodPost, "did:plc:mallory", url.Values{"text": {"hi"}}).Code)
+	})
+
+	t.Run("suggests a post linking to the survey", func(t *testing.T) {
+		rec := call(h.BlueskyComposeHTML, http.MethodGet, alice, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "https://survey.example/s/lunch")
+		assert.Contains(t, rec.Body.String(), "Vote in my survey")
+	})
+
+	t.Run("links to published results", func(t *testing.T) {
+		resultsURI := "at://did:plc:alice/net.openmeet.survey.results/3kabc"
+		survey.ResultsURI = &resultsURI
+		defer func() { survey.ResultsURI = nil }()
+
+		rec := call(h.BlueskyComposeHTML, http.MethodGet, alice, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "https://survey.example/surveys/lunch/results")
+		assert.Contains(t, rec.Body.String(), "are in")
+	})
+
+	t.Run("checks the text before posting", func(t *testing.T) {
+		rec := call(h.ShareToBlueskyHTML, http.MethodPost, alice, url.Values{"text": {strings.Repeat("a", bsky.MaxTextLength+1)}})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "at most 300 characters")
+	})
+
+	t.Run("posts need a session", func(t *testing.T) {
+		rec := call(h.ShareToBlueskyHTML, http.MethodPost, alice, url.Values{"text": {"Vote!"}})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "log in again")
+	})
+
+	t.Run("private surveys", func(t *testing.T) {
+		survey.Definition.Visibility = models.VisibilityToken
+		defer func() { survey.Definition.Visibility = "" }()
+		assert.Equal(t, http.StatusBadRequest, call(h.BlueskyComposeHTML, http.MethodGet, alice, nil).Code)
+	})
+
+	t.Run("without a public URL", func(t *testing.T) {
+		templates.SetPublicURL("")
+		defer templates.SetPublicURL("https://survey.example")
+		assert.Equal(t, http.StatusNotFound, call(h.BlueskyComposeHTML, http.MethodGet, alice, nil).Code)
+		assert.Equal(t, "/surveys/lunch", blueskyRedirect(survey, "/surveys/lunch"))
+	})
+
+	assert.Equal(t, "/surveys/lunch/bluesky", blueskyRedirect(survey, "/surveys/lunch"))
+}
+
+func TestBlueskyText(t *testing.T) {
+	link := "https://survey.example/s/long"
+	survey := &models.Survey{Slug: "long", Title: strings.Repeat("word ", 100)}
+
+	text := blueskyText(survey, link)
+	assert.Equal(t, bsky.MaxTextLength, utf8.RuneCountInString(text))
+	assert.True(t, strings.HasSuffix(text, "…”: "+link), "the title is shortened, not the link")
+	assert.NoError(t, bsky.ValidateText(text))
+}
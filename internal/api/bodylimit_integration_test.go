@@ -142,3 +142,27 @@ func TestBodyLimitErrorMessage(t *testing.T) {
 	// Echo's default error handler should provide a clear message
 	assert.Contains(t, rec.Body.String(), "Request Entity Too Large")
 }
+
+// TestBodyLimitRoutes verifies the routes of SetupRoutes limit their bodies
+func TestBodyLimitRoutes(t *testing.T) {
+	e, _, h := setupTest()
+	SetupRoutes(e, h, &HealthHandlers{}, nil, nil)
+
+	tests := []struct {
+		route string
+		size  int
+	}{
+		{"/api/v1/surveys/generate", 129 * 1024},
+		{"/api/v1/surveys/test-slug/archive", 1025 * 1024},
+		{"/surveys/test-slug/publish-results", 1025 * 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.route, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.route, bytes.NewReader(bytes.Repeat([]byte("a"), tt.size)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		})
+	}
+}
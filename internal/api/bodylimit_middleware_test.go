@@ -12,6 +12,7 @@ func TestDefaultBodyLimitConfig(t *testing.T) {
 
 	assert.Equal(t, "100KB", config.SurveyCreation, "Survey creation limit should be 100KB")
 	assert.Equal(t, "10KB", config.ResponseSubmission, "Response submission limit should be 10KB")
+	assert.Equal(t, "128KB", config.Generation, "Generation limit should be 128KB")
 	assert.Equal(t, "1MB", config.GeneralAPI, "General API limit should be 1MB")
 }
 
@@ -30,6 +31,10 @@ func TestBodyLimitConfigDocumentation(t *testing.T) {
 	t.Log("  - Typical response: <1KB")
 	t.Log("  - 10KB allows for surveys with many questions and text responses")
 	t.Log("")
+	t.Log("AI generation (128KB):")
+	t.Log("  - Descriptions are at most 2000 characters")
+	t.Log("  - Refinements include an existing survey definition (up to 100KB)")
+	t.Log("")
 	t.Log("General API (1MB):")
 	t.Log("  - Default fallback for other endpoints")
 	t.Log("  - Provides reasonable protection without being too restrictive")
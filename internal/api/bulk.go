@@ -0,0 +1,221 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/outbox"
+	"github.com/openmeet-team/survey/internal/problem"
+)
+
+// maxBulkSurveys limits the surveys of one bulk creation
+const maxBulkSurveys = 100
+
+// SetBulkSurveys enables creating many surveys in one request
+func (h *Handlers) SetBulkSurveys(store BulkSurveyStoreInterface) {
+	h.bulkSurveys = store
+}
+
+// CreateSurveysBulk creates up to maxBulkSurveys surveys, e.g. one per
+// session of an event. Every survey is validated first: if any is invalid,
+// the problem lists the error of each invalid one and none is created.
+// Otherwise all are created in one transaction. With "publish", the surveys
+// are also written to the logged-in caller's PDS; failed writes leave the
+// survey local-only with its record queued for retry, like single surveys.
+// Every survey counts against the bulk creation limit.
+// POST /api/v1/surveys/bulk
+func (h *Handlers) CreateSurveysBulk(c echo.Context) error {
+	var req BulkCreateSurveysRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+	if len(req.Surveys) == 0 {
+		return Problem(c, problem.ValidationFailed, "At least one survey is required")
+	}
+	if len(req.Surveys) > maxBulkSurveys {
+		return Problem(c, problem.ValidationFailed, fmt.Sprintf("At most %d surveys can be created at once", maxBulkSurveys))
+	}
+	if h.bulkLimiter != nil {
+		// Each survey counts against the limit, not the request
+		if allowed, err := h.bulkLimiter.AllowN(c, len(req.Surveys)); !allowed {
+			return err
+		}
+	}
+
+	var session *oauth.OAuthSession
+	if req.Publish {
+		if h.oauthStorage != nil {
+			session, _ = oauth.GetSession(c, h.oauthStorage)
+		}
+		if session == nil || session.AccessToken == "" || session.PDSUrl == "" {
+			return Problem(c, problem.AuthenticationRequired, "Log in to publish surveys to your PDS")
+		}
+	}
+
+	// Reserved slugs are kept for allow-listed authors: the key's owner, or
+	// the caller publishing to their PDS
+	var claimant string
+	if key := APIKeyFromContext(c); key != nil {
+		claimant = key.OwnerDID
+	} else if session != nil {
+		claimant = session.DID
+	}
+
+	surveys, itemErrors, err := h.prepareBulkSurveys(c, req.Surveys, claimant)
+	if err != nil {
+		return InternalServerError(c, "Failed to check slug availability", err)
+	}
+	if len(itemErrors) > 0 {
+		p := problem.New(problem.ValidationFailed, fmt.Sprintf("%d of %d surveys are invalid; none was created", len(itemErrors), len(req.Surveys)))
+		p.Errors = itemErrors
+		return WriteProblem(c, p)
+	}
+
+	results := make([]BulkSurveyResult, len(surveys))
+	unpublished := make([]*outbox.Entry, len(surveys)) // Records to queue for surveys whose PDS write failed
+	for i, survey := range surveys {
+		results[i].Index = i
+		if session != nil {
+			unpublished[i] = h.publishBulkSurvey(c, session, survey, &results[i])
+		}
+		// Images are blobs on the author's PDS, so local-only surveys cannot show them
+		if survey.URI == nil {
+			survey.Definition.StripImages()
+		}
+	}
+
+	// Surveys already written to the PDS are indexed from the firehose even if
+	// this fails, like records published from other apps
+	if err := h.bulkSurveys.CreateSurveys(c.Request().Context(), surveys); err != nil {
+		return InternalServerError(c, "Failed to create surveys", err)
+	}
+
+	for i, survey := range surveys {
+		if unpublished[i] != nil {
+			unpublished[i].SurveyID = survey.ID
+			h.queueRecord(c, unpublished[i])
+		}
+		results[i].Survey = ToSurveyResponse(survey, true)
+	}
+
+	return c.JSON(http.StatusCreated, BulkCreateSurveysResponse{Surveys: results})
+}
+
+// prepareBulkSurveys validates the requested surveys and builds them, or
+// returns the errors of the invalid ones. Generated slugs that are taken get
+// a random suffix, since surveys of a batch often share their first question.
+// Reserved slugs are refused unless the claimant may claim them.
+func (h *Handlers) prepareBulkSurveys(c echo.Context, reqs []CreateSurveyRequest, claimant string) ([]*models.Survey, []problem.ItemError, error) {
+	reservations, err := h.listReservedSlugs(c.Request().Context())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	surveys := make([]*models.Survey, 0, len(reqs))
+	var itemErrors []problem.ItemError
+	slugs := make(map[string]bool, len(reqs)) // Slugs of the batch so far
+
+	for i, req := range reqs {
+		def, err := parseNewDefinition(req.Definition)
+		if err != nil {
+			itemErrors = append(itemErrors, problem.ItemError{Index: i, Code: problem.InvalidDefinition, Detail: err.Error()})
+			continue
+		}
+
+		slug := req.Slug
+		if slug != "" {
+			if err := models.ValidateSlug(slug); err != nil {
+				itemErrors = append(itemErrors, problem.ItemError{Index: i, Code: problem.ValidationFailed, Detail: "Invalid slug: " + err.Error()})
+				continue
+			}
+		} else {
+			slug = generateSlug(def.Questions[0].Text)
+		}
+
+		taken, err := h.bulkSlugTaken(c, slugs, slug)
+		if err != nil {
+			return nil, nil, err
+		}
+		if taken && req.Slug == "" {
+			slug = fmt.Sprintf("%s-%s", trimSlug(slug, 41), uuid.New().String()[:8])
+			if taken, err = h.bulkSlugTaken(c, slugs, slug); err != nil {
+				return nil, nil, err
+			}
+		}
+		if taken {
+			itemErrors = append(itemErrors, problem.ItemError{Index: i, Code: problem.SlugTaken, Detail: fmt.Sprintf("A survey with slug '%s' already exists", slug)})
+			continue
+		}
+		if r := h.reservedSlug(c.Request().Context(), reservations, slug, claimant); r != nil {
+			itemErrors = append(itemErrors, problem.ItemError{Index: i, Code: problem.SlugReserved, Detail: slugReservedMessage(slug, r)})
+			continue
+		}
+		slugs[slug] = true
+
+		now := time.Now()
+		survey := &models.Survey{
+			ID:         uuid.New(),
+			Slug:       slug,
+			Title:      def.Questions[0].Text,
+			Definition: *def,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		// Surveys created with a key belong to its owner, who can list and manage them
+		if key := APIKeyFromContext(c); key != nil {
+			survey.AuthorDID = &key.OwnerDID
+		}
+		surveys = append(surveys, survey)
+	}
+
+	return surveys, itemErrors, nil
+}
+
+// bulkSlugTaken reports whether a slug is taken by an earlier survey of the
+// batch or an existing survey
+func (h *Handlers) bulkSlugTaken(c echo.Context, batch map[string]bool, slug string) (bool, error) {
+	if batch[slug] {
+		return true, nil
+	}
+	return h.queries.SlugExists(c.Request().Context(), slug)
+}
+
+// trimSlug shortens a slug to at most n characters, without a trailing hyphen
+func trimSlug(slug string, n int) string {
+	if len(slug) <= n {
+		return slug
+	}
+	return strings.TrimRight(slug[:n], "-")
+}
+
+// publishBulkSurvey writes a survey of a bulk creation to the caller's PDS
+// and records the outcome. Returns the record to queue if the write failed.
+func (h *Handlers) publishBulkSurvey(c echo.Context, session *oauth.OAuthSession, survey *models.Survey, result *BulkSurveyResult) *outbox.Entry {
+	rkey := oauth.GenerateTID()
+	record := surveyRecord(survey.Title, &survey.Definition, survey.CreatedAt)
+
+	uri, cid, err := h.writeRecord(c.Request().Context(), session, "net.openmeet.survey", rkey, record)
+	if err != nil {
+		c.Logger().Errorf("Failed to write survey %s to PDS: %v", survey.Slug, err)
+		recordPDSWriteFallback(outbox.KindSurvey, err)
+		result.PublishError = "Failed to write the survey to your PDS"
+		entry, err := outbox.NewEntry(outbox.KindSurvey, session.DID, "net.openmeet.survey", rkey, record)
+		if err != nil {
+			c.Logger().Errorf("Failed to build outbox entry: %v", err)
+		}
+		return entry
+	}
+
+	survey.URI = &uri
+	survey.CID = &cid
+	survey.AuthorDID = &session.DID
+	result.Published = true
+	h.crossPublishPoll(c, session, uri, cid, &survey.Definition)
+	return nil
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sessionFeedbackDefinition = "questions:\n  - id: rating\n    text: How was this session?\n    type: single\n    options:\n      - id: good\n        text: Good\n      - id: bad\n        text: Bad\n"
+
+func TestCreateSurveysBulk(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetBulkSurveys(mq)
+
+	create := func(req BulkCreateSurveysRequest, key *apikey.Key) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/bulk", strings.NewReader(string(body)))
+		httpReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httpReq, rec)
+		if key != nil {
+			c.Set("api_key", key)
+		}
+		require.NoError(t, h.CreateSurveysBulk(c))
+		return rec
+	}
+
+	t.Run("creates all surveys", func(t *testing.T) {
+		key := &apikey.Key{ID: uuid.New(), OwnerDID: "did:plc:organizer"}
+		rec := create(BulkCreateSurveysRequest{Surveys: []CreateSurveyRequest{
+			{Slug: "keynote-feedback", Definition: sessionFeedbackDefinition},
+			{Definition: sessionFeedbackDefinition},
+			{Definition: sessionFeedbackDefinition},
+		}}, key)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		var resp BulkCreateSurveysResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Surveys, 3)
+		assert.Equal(t, "keynote-feedback", resp.Surveys[0].Survey.Slug)
+		assert.Equal(t, "how-was-this-session", resp.Surveys[1].Survey.Slug)
+		assert.True(t, strings.HasPrefix(resp.Surveys[2].Survey.Slug, "how-was-this-session-"), "generated slugs taken in the batch get a suffix")
+		for i, result := range resp.Surveys {
+			assert.Equal(t, i, result.Index)
+			assert.False(t, result.Published)
+			assert.NoError(t, models.ValidateSlug(result.Survey.Slug))
+			require.Contains(t, mq.Surveys, result.Survey.Slug)
+			assert.Equal(t, "did:plc:organizer", *mq.Surveys[result.Survey.Slug].AuthorDID)
+		}
+	})
+
+	t.Run("creates none if any is invalid", func(t *testing.T) {
+		before := len(mq.Surveys)
+		rec := create(BulkCreateSurveysRequest{Surveys: []CreateSurveyRequest{
+			{Slug: "workshop-feedback", Definition: sessionFeedbackDefinition},
+			{Definition: "questions: []"},
+			{Slug: "keynote-feedback", Definition: sessionFeedbackDefinition},
+			{Slug: "No Spaces", Definition: sessionFeedbackDefinition},
+		}}, nil)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var p problem.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		assert.Equal(t, problem.ValidationFailed, p.Code)
+		require.Len(t, p.Errors, 3)
+		assert.Equal(t, problem.ItemError{Index: 1, Code: problem.InvalidDefinition, Detail: p.Errors[0].Detail}, p.Errors[0])
+		assert.Equal(t, 2, p.Errors[1].Index)
+		assert.Equal(t, problem.SlugTaken, p.Errors[1].Code)
+		assert.Equal(t, 3, p.Errors[2].Index)
+		assert.Equal(t, problem.ValidationFailed, p.Errors[2].Code)
+		assert.Len(t, mq.Surveys, before)
+		assert.NotContains(t, mq.Surveys, "workshop-feedback")
+	})
+
+	t.Run("rejects duplicate slugs of the batch", func(t *testing.T) {
+		rec := create(BulkCreateSurveysRequest{Surveys: []CreateSurveyRequest{
+			{Slug: "panel-feedback", Definition: sessionFeedbackDefinition},
+			{Slug: "panel-feedback", Definition: sessionFeedbackDefinition},
+		}}, nil)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		var p problem.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		require.Len(t, p.Errors, 1)
+		assert.Equal(t, 1, p.Errors[0].Index)
+		assert.NotContains(t, mq.Surveys, "panel-feedback")
+	})
+
+	t.Run("limits the batch size", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, create(BulkCreateSurveysRequest{}, nil).Code)
+
+		surveys := make([]CreateSurveyRequest, maxBulkSurveys+1)
+		for i := range surveys {
+			surveys[i].Definition = sessionFeedbackDefinition
+		}
+		assert.Equal(t, http.StatusBadRequest, create(BulkCreateSurveysRequest{Surveys: surveys}, nil).Code)
+	})
+
+	t.Run("publishing requires login", func(t *testing.T) {
+		rec := create(BulkCreateSurveysRequest{Surveys: []CreateSurveyRequest{{Definition: sessionFeedbackDefinition}}, Publish: true}, nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("charges the limit per survey", func(t *testing.T) {
+		h.bulkLimiter = NewIPRateLimiter(5, time.Hour)
+		h.bulkLimiter.limitAPIKeys = true
+		defer func() { h.bulkLimiter = nil }()
+
+		batch := func(n int) BulkCreateSurveysRequest {
+			surveys := make([]CreateSurveyRequest, n)
+			for i := range surveys {
+				surveys[i].Definition = sessionFeedbackDefinition
+			}
+			return BulkCreateSurveysRequest{Surveys: surveys}
+		}
+		key := &apikey.Key{ID: uuid.New(), OwnerDID: "did:plc:organizer"}
+		created := len(mq.Surveys)
+
+		rec := create(batch(3), key)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Remaining"))
+
+		rec = create(batch(3), key)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code, "one request cannot take more surveys than are left")
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+		assert.Len(t, mq.Surveys, created+3)
+
+		assert.Equal(t, http.StatusCreated, create(batch(2), key).Code)
+	})
+}
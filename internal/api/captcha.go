@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/captcha"
+	"github.com/openmeet-team/survey/internal/problem"
+)
+
+// captchaThreshold is the share of an anonymous rate limit left below which
+// callers must solve a CAPTCHA
+const captchaThreshold = 0.5
+
+// captchaHeader carries the CAPTCHA token of JSON API requests
+const captchaHeader = "X-Captcha-Token"
+
+// SetCaptcha requires anonymous callers close to the rate limit of AI
+// generation or vote submission to solve a CAPTCHA, verified by verifier
+func (h *Handlers) SetCaptcha(verifier *captcha.Verifier) {
+	h.captcha = verifier
+}
+
+// captchaWidget returns the CAPTCHA anonymous callers solve, or nil if
+// CAPTCHAs are disabled or the caller is logged in or uses an API key
+func (h *Handlers) captchaWidget(c echo.Context) *captcha.Widget {
+	if h.captcha == nil {
+		return nil
+	}
+	if _, ok := callerDID(c); ok {
+		return nil
+	}
+	return h.captcha.Widget()
+}
+
+// nearLimit reports whether a caller with remaining of limit requests left
+// must solve a CAPTCHA
+func nearLimit(remaining, limit int) bool {
+	return float64(remaining) < float64(limit)*captchaThreshold
+}
+
+// generationCaptcha returns the CAPTCHA an anonymous caller must solve to
+// generate a survey after counting pending more generations, or nil if none
+func (h *Handlers) generationCaptcha(c echo.Context, pending int) *captcha.Widget {
+	widget := h.captchaWidget(c)
+	if widget == nil || h.generatorRL == nil {
+		return nil
+	}
+	quota := h.generatorRL.AnonymousQuota(getClientIP(c))
+	if !nearLimit(quota.Remaining-pending, quota.Limit) {
+		return nil
+	}
+	return widget
+}
+
+// voteCaptcha returns the CAPTCHA an anonymous voter must solve to submit a
+// response after pending more submissions are counted by the vote limiter, or
+// nil if none. Submissions are counted before their handler runs.
+func (h *Handlers) voteCaptcha(c echo.Context, pending int) *captcha.Widget {
+	widget := h.captchaWidget(c)
+	if widget == nil || h.voteLimiter == nil {
+		return nil
+	}
+	remaining, limit := h.voteLimiter.Remaining(getClientIP(c))
+	if !nearLimit(remaining-pending, limit) {
+		return nil
+	}
+	return widget
+}
+
+// verifyCaptcha checks the caller's CAPTCHA token, sent in the X-Captcha-Token
+// header or the form field of the provider's widget
+func (h *Handlers) verifyCaptcha(c echo.Context) error {
+	token := c.Request().Header.Get(captchaHeader)
+	if token == "" {
+		token = c.FormValue(h.captcha.Widget().Provider.FormField())
+	}
+
+	err := h.captcha.Verify(c.Request().Context(), token, getClientIP(c))
+	if err != nil && !errors.Is(err, captcha.ErrMissingToken) && !errors.Is(err, captcha.ErrInvalidToken) {
+		c.Logger().Errorf("Failed to verify CAPTCHA: %v", err)
+	}
+	return err
+}
+
+// captchaRequired responds to a JSON API request whose CAPTCHA is missing or invalid
+func captchaRequired(c echo.Context, err error) error {
+	details := "Solve the CAPTCHA and send its token in the " + captchaHeader + " header"
+	if errors.Is(err, captcha.ErrInvalidToken) {
+		details = "The CAPTCHA token is invalid or was already used"
+	}
+	p := problem.New(problem.CaptchaRequired, details)
+	p.NeedsCaptcha = true
+	return WriteProblem(c, p)
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/captcha"
+	"github.com/openmeet-team/survey/internal/generator"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCaptcha returns a Turnstile verifier accepting only the token "good"
+func newTestCaptcha(t *testing.T) *captcha.Verifier {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]bool{"success": r.FormValue("response") == "good"})
+	}))
+	t.Cleanup(server.Close)
+
+	verifier, err := captcha.New(captcha.Config{Provider: captcha.ProviderTurnstile, SiteKey: "site", Secret: "secret", VerifyURL: server.URL})
+	require.NoError(t, err)
+	return verifier
+}
+
+func generateRequest(e *echo.Echo, token string, user *oauth.User) (echo.Context, *httptest.ResponseRecorder) {
+	body, _ := json.Marshal(GenerateSurveyRequest{Description: "A poll about coffee", Consent: true})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/generate", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		req.Header.Set(captchaHeader, token)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if user != nil {
+		c.Set("user", user)
+	}
+	return c, rec
+}
+
+func TestGenerateSurvey_Captcha(t *testing.T) {
+	e := echo.New()
+	result := &generator.GenerateResult{
+		Definition: &models.SurveyDefinition{
+			Questions: []models.Question{{ID: "q1", Text: "Coffee?", Type: models.QuestionTypeText}},
+		},
+	}
+	newHandlers := func(remaining int) *Handlers {
+		h := &Handlers{
+			queries:     NewMockQueries(),
+			generator:   NewMockSurveyGenerator(result, nil),
+			generatorRL: &MockRateLimiter{allowAnon: true, allowAuth: true, anonRemaining: remaining, anonLimit: 5},
+		}
+		h.SetCaptcha(newTestCaptcha(t))
+		return h
+	}
+
+	t.Run("far from the limit", func(t *testing.T) {
+		c, rec := generateRequest(e, "", nil)
+		require.NoError(t, newHandlers(4).GenerateSurvey(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp GenerateSurveyResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.False(t, resp.NeedsCaptcha)
+	})
+
+	t.Run("near the limit without a token", func(t *testing.T) {
+		c, rec := generateRequest(e, "", nil)
+		require.NoError(t, newHandlers(2).GenerateSurvey(c))
+		require.Equal(t, http.StatusForbidden, rec.Code)
+
+		var resp problem.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.True(t, resp.NeedsCaptcha)
+	})
+
+	t.Run("near the limit with an invalid token", func(t *testing.T) {
+		c, rec := generateRequest(e, "bad", nil)
+		require.NoError(t, newHandlers(2).GenerateSurvey(c))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("near the limit with a valid token", func(t *testing.T) {
+		c, rec := generateRequest(e, "good", nil)
+		require.NoError(t, newHandlers(2).GenerateSurvey(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp GenerateSurveyResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.True(t, resp.NeedsCaptcha, "the next generation needs a new token")
+	})
+
+	t.Run("authenticated users never solve one", func(t *testing.T) {
+		c, rec := generateRequest(e, "", &oauth.User{DID: "did:plc:test123"})
+		require.NoError(t, newHandlers(0).GenerateSurvey(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp GenerateSurveyResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.False(t, resp.NeedsCaptcha)
+	})
+}
+
+// setupCaptchaVoteTest returns handlers whose caller has used up the vote limit
+func setupCaptchaVoteTest(t *testing.T) (*echo.Echo, *Handlers) {
+	e, mq, h := setupTest()
+	h.SetCaptcha(newTestCaptcha(t))
+	h.voteLimiter = NewIPRateLimiter(2, time.Minute)
+	createTextSurvey(mq, "feedback", nil)
+	return e, h
+}
+
+func TestSubmitResponse_Captcha(t *testing.T) {
+	e, h := setupCaptchaVoteTest(t)
+
+	// Two submissions left: no CAPTCHA yet
+	assert.Nil(t, h.voteCaptcha(e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder()), 0))
+
+	// Used up by the rate limit middleware
+	ip := getClientIP(e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder()))
+	h.voteLimiter.getLimiter(ip).Allow()
+	h.voteLimiter.getLimiter(ip).Allow()
+
+	rec := submitText(t, e, h, "feedback", "hello")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	var resp problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.NeedsCaptcha)
+
+	body, _ := json.Marshal(SubmitResponseRequest{Answers: map[string]models.Answer{"q1": {Text: "hello"}}})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/feedback/responses", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(captchaHeader, "good")
+	rec = httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("feedback")
+	require.NoError(t, h.SubmitResponse(c))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestSubmitResponseHTML_Captcha(t *testing.T) {
+	e, h := setupCaptchaVoteTest(t)
+	ip := getClientIP(e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder()))
+	h.voteLimiter.getLimiter(ip).Allow()
+	h.voteLimiter.getLimiter(ip).Allow()
+
+	submit := func(form string, user *oauth.User) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/surveys/feedback/responses", strings.NewReader(form))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("feedback")
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, h.SubmitResponseHTML(c))
+		return rec
+	}
+
+	// Without a token, the form comes back with the answers and the widget
+	rec := submit("q1=Great+survey", nil)
+	assert.Contains(t, rec.Body.String(), `class="cf-turnstile"`)
+	assert.Contains(t, rec.Body.String(), "Great survey")
+
+	// Logged-in voters are not asked
+	rec = submit("q1=Great+survey", &oauth.User{DID: "did:plc:voter"})
+	assert.NotContains(t, rec.Body.String(), "cf-turnstile")
+
+	// Guests with a solved CAPTCHA vote
+	rec = submit("q1=Great+survey&cf-turnstile-response=good", nil)
+	assert.NotContains(t, rec.Body.String(), "cf-turnstile")
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/ogcard"
+)
+
+// SurveyCard renders the Open Graph card image of a survey, shown when its link is shared
+// GET /surveys/:slug/card.png
+func (h *Handlers) SurveyCard(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	survey, err := h.surveyBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	if h.surveyHidden(c, survey) {
+		return c.String(http.StatusNotFound, hiddenSurveyMessage)
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		return c.String(http.StatusForbidden, privateSurveyMessage)
+	}
+
+	results, err := h.surveyResults(ctx, survey.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load results")
+	}
+
+	data, err := h.surveyCard(ctx, survey, results.TotalVotes)
+	if err != nil {
+		c.Logger().Errorf("Failed to render card of survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to render card")
+	}
+
+	// Crawlers fetch cards once per share; a few minutes keeps vote counts fresh enough
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.Blob(http.StatusOK, "image/png", data)
+}
+
+// surveyCard renders the card image of a survey with its vote count, or returns it from the cache
+func (h *Handlers) surveyCard(ctx context.Context, survey *models.Survey, votes int) ([]byte, error) {
+	card := &ogcard.Card{
+		Title:     survey.Title,
+		Questions: len(survey.Definition.Questions),
+		Votes:     votes,
+	}
+	return h.cards.Card(ctx, card.Key(), func() ([]byte, error) {
+		return ogcard.Render(card)
+	})
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/ogcard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveSurveyCard(h *Handlers, slug string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/surveys/"+slug+"/card.png", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	_ = h.SurveyCard(c)
+	return rec
+}
+
+func TestSurveyCard(t *testing.T) {
+	mq := NewMockQueries()
+	mq.Surveys["offsite"] = &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "offsite",
+		Title: "Team offsite",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{{ID: "q1", Text: "Where?", Type: models.QuestionTypeText}},
+		},
+	}
+	h := NewHandlers(mq)
+	backend := cache.NewMemory(10)
+	h.SetCardCache(cache.New(backend, time.Minute))
+
+	rec := serveSurveyCard(h, "offsite")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Header().Get("Cache-Control"), "public")
+
+	img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, ogcard.Width, img.Bounds().Dx())
+
+	// The rendered card is cached by its content
+	key := (&ogcard.Card{Title: "Team offsite", Questions: 1}).Key()
+	cached, ok, err := backend.Get(context.Background(), cache.CardKey(key))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, rec.Body.Bytes(), cached)
+
+	rec = serveSurveyCard(h, "missing")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
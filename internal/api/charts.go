@@ -0,0 +1,75 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/charts"
+	"github.com/openmeet-team/survey/internal/i18n"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// GetResultsChart renders the results of a choice question as an SVG chart, for sharing and embedding
+// GET /surveys/:slug/results/chart.svg?question=q1&type=pie
+// type is "bar" or "pie"; it defaults to pie for single-choice and bar for multiple-choice questions
+func (h *Handlers) GetResultsChart(c echo.Context) error {
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		return c.String(http.StatusForbidden, privateSurveyMessage)
+	}
+
+	questionID := c.QueryParam("question")
+	var question *models.Question
+	for i := range survey.Definition.Questions {
+		if survey.Definition.Questions[i].ID == questionID {
+			question = &survey.Definition.Questions[i]
+			break
+		}
+	}
+	if question == nil {
+		return c.String(http.StatusNotFound, "Question not found")
+	}
+	if question.Type != models.QuestionTypeSingle && question.Type != models.QuestionTypeMulti {
+		return c.String(http.StatusBadRequest, "Charts are only available for choice questions")
+	}
+
+	kind := c.QueryParam("type")
+	if _, ok := charts.ParseKind(kind); kind != "" && !ok {
+		return c.String(http.StatusBadRequest, "Chart type must be 'bar' or 'pie'")
+	}
+
+	results, err := h.surveyResults(c.Request().Context(), survey.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load results")
+	}
+
+	// Charts are mirrored for RTL locales
+	locale := i18n.Resolve(survey.Definition.Language, c.Request().Header.Get("Accept-Language"))
+	c.Response().Header().Add("Vary", "Accept-Language")
+	if checkNotModified(c, resultsETag(survey, results, "chart", question.ID, kind, locale.Tag)) {
+		return notModified(c)
+	}
+
+	chart := charts.ForQuestion(question, results.QuestionResults[question.ID], results.TotalVotes)
+	if kind != "" {
+		chart.Kind = charts.Kind(kind)
+	}
+	chart.Caption = true
+	chart.RTL = locale.IsRTL()
+
+	// Served as a standalone document: its Content-Security-Policy (no scripts,
+	// only the chart's inline styles) is set by the route's embed headers
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+	return c.Blob(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(chart.SVG()))
+}
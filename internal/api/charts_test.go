@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveResultsChart(h *Handlers, slug, query string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/surveys/"+slug+"/results/chart.svg?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	_ = h.GetResultsChart(c)
+	return rec
+}
+
+func TestGetResultsChart(t *testing.T) {
+	mq := NewMockQueries()
+	mq.Surveys["lunch"] = &models.Survey{
+		ID:   uuid.New(),
+		Slug: "lunch",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "q1", Text: "Where to?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "a", Text: "Tacos"}, {ID: "b", Text: "Sushi"}}},
+				{ID: "q2", Text: "Anything else?", Type: models.QuestionTypeText},
+			},
+		},
+	}
+	h := NewHandlers(mq)
+
+	rec := serveResultsChart(h, "lunch", "question=q1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/svg+xml; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.NotEmpty(t, rec.Header().Get("ETag"))
+	assert.Contains(t, rec.Body.String(), "<svg")
+	assert.Contains(t, rec.Body.String(), "Where to?")
+	assert.Contains(t, rec.Body.String(), "No responses yet")
+
+	rec = serveResultsChart(h, "lunch", "question=q1&type=bar")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveResultsChart(h, "lunch", "question=q1&type=line")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveResultsChart(h, "lunch", "question=q2")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveResultsChart(h, "lunch", "question=q9")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveResultsChart(h, "missing", "question=q1")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,500 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/coauthor"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// SetCoAuthors enables survey co-authors, who edit a survey, see its
+// responses and private results, and ask its author to publish its results
+func (h *Handlers) SetCoAuthors(store coauthor.Store) {
+	h.coAuthors = store
+}
+
+// Errors of the co-author helpers caused by the request
+var (
+	errInvalidCoAuthorRequest = errors.New("invalid co-author request")
+	errCoAuthorForbidden      = errors.New("forbidden")
+)
+
+// isCoAuthor reports whether a DID is a co-author of a survey. A failed
+// lookup grants nothing.
+func (h *Handlers) isCoAuthor(ctx context.Context, did string, survey *models.Survey) bool {
+	if h.coAuthors == nil || did == "" {
+		return false
+	}
+	ok, err := h.coAuthors.IsCoAuthor(ctx, survey.ID, did)
+	return err == nil && ok
+}
+
+// canEditSurveyAs reports whether a DID may edit a local survey: those who
+// manage it and its co-authors
+func (h *Handlers) canEditSurveyAs(ctx context.Context, did string, survey *models.Survey) bool {
+	return h.canManageSurveyAs(ctx, did, survey) || h.isCoAuthor(ctx, did, survey)
+}
+
+// isSurveyAuthor reports whether a DID is the author of a survey, whose PDS
+// holds its records
+func isSurveyAuthor(survey *models.Survey, did string) bool {
+	return survey.AuthorDID != nil && *survey.AuthorDID == did
+}
+
+// addCoAuthor adds a co-author, by DID or handle, to a survey. Only its
+// author and admins add co-authors.
+func (h *Handlers) addCoAuthor(ctx context.Context, survey *models.Survey, caller, coAuthor string) (*coauthor.CoAuthor, error) {
+	if !isSurveyAuthor(survey, caller) && !h.adminDIDs[caller] {
+		return nil, fmt.Errorf("%w: only the survey author can add co-authors", errCoAuthorForbidden)
+	}
+
+	did := strings.TrimPrefix(strings.TrimSpace(coAuthor), "@")
+	if did == "" {
+		return nil, fmt.Errorf("%w: enter the DID or handle of the co-author", errInvalidCoAuthorRequest)
+	}
+	if strings.HasPrefix(did, "did:") {
+		normalized, err := coauthor.NormalizeDID(did)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidCoAuthorRequest, err)
+		}
+		did = normalized
+	} else {
+		resolved, err := h.resolveHandle(did)
+		if err != nil {
+			return nil, fmt.Errorf("%w: could not resolve handle %s", errInvalidCoAuthorRequest, did)
+		}
+		did = resolved
+	}
+	if isSurveyAuthor(survey, did) {
+		return nil, fmt.Errorf("%w: %s is the survey's author", errInvalidCoAuthorRequest, did)
+	}
+
+	coAuthors, err := h.coAuthors.ListCoAuthors(ctx, survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(coAuthors) >= coauthor.MaxCoAuthors {
+		return nil, fmt.Errorf("%w: a survey can have at most %d co-authors", errInvalidCoAuthorRequest, coauthor.MaxCoAuthors)
+	}
+
+	added := &coauthor.CoAuthor{
+		SurveyID:  survey.ID,
+		DID:       did,
+		AddedBy:   caller,
+		CreatedAt: time.Now(),
+	}
+	if err := h.coAuthors.AddCoAuthor(ctx, added); err != nil {
+		if errors.Is(err, coauthor.ErrAlreadyCoAuthor) {
+			return nil, fmt.Errorf("%w: %s is %v", errInvalidCoAuthorRequest, did, err)
+		}
+		return nil, err
+	}
+	return added, nil
+}
+
+// removeCoAuthor removes a co-author of a survey. Its author and admins
+// remove anyone; co-authors can leave.
+func (h *Handlers) removeCoAuthor(ctx context.Context, survey *models.Survey, caller, did string) error {
+	if !isSurveyAuthor(survey, caller) && !h.adminDIDs[caller] && did != caller {
+		return fmt.Errorf("%w: only the survey author can remove co-authors", errCoAuthorForbidden)
+	}
+	return h.coAuthors.RemoveCoAuthor(ctx, survey.ID, did, time.Now())
+}
+
+// requestPublish asks the author of a survey to publish its results, for a
+// co-author. Authors publish results themselves.
+func (h *Handlers) requestPublish(ctx context.Context, survey *models.Survey, caller string) (*coauthor.PublishRequest, error) {
+	if !h.isCoAuthor(ctx, caller, survey) {
+		return nil, fmt.Errorf("%w: only co-authors ask the author to publish results", errCoAuthorForbidden)
+	}
+	if survey.URI == nil {
+		return nil, fmt.Errorf("%w: cannot publish results for local-only surveys", errInvalidCoAuthorRequest)
+	}
+
+	request := coauthor.NewPublishRequest(survey.ID, caller, time.Now())
+	if err := h.coAuthors.CreatePublishRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// resolvePublish approves or rejects the pending publish request of a
+// survey. Only its author approves, which publishes the results to their PDS
+// with their latest login session; the author rejects it, and the co-author
+// who asked can withdraw it.
+func (h *Handlers) resolvePublish(ctx context.Context, survey *models.Survey, caller string, id uuid.UUID, status string) (*coauthor.PublishRequest, error) {
+	if status != coauthor.StatusApproved && status != coauthor.StatusRejected {
+		return nil, fmt.Errorf("%w: status must be approved or rejected", errInvalidCoAuthorRequest)
+	}
+
+	request, err := h.coAuthors.GetPendingPublishRequest(ctx, survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	if request.ID != id {
+		return nil, sql.ErrNoRows
+	}
+
+	author := isSurveyAuthor(survey, caller)
+	if status == coauthor.StatusApproved && !author {
+		return nil, fmt.Errorf("%w: only the survey author can publish results to their PDS", errCoAuthorForbidden)
+	}
+	if status == coauthor.StatusRejected && !author && request.RequestedBy != caller {
+		return nil, fmt.Errorf("%w: only the survey author can reject the request", errCoAuthorForbidden)
+	}
+
+	now := time.Now()
+	if status == coauthor.StatusApproved {
+		if err := h.publishResultsAs(ctx, caller, survey, now); err != nil {
+			if errors.Is(err, errNoSession) {
+				return nil, fmt.Errorf("%w: log in to publish results to your PDS", errInvalidCoAuthorRequest)
+			}
+			return nil, err
+		}
+	}
+	if err := h.coAuthors.ResolvePublishRequest(ctx, request.ID, survey.ID, status, caller, now); err != nil {
+		return nil, err
+	}
+	request.Status, request.ResolvedBy, request.ResolvedAt = status, &caller, &now
+	return request, nil
+}
+
+// publishResultsAs publishes the results of a survey to the PDS of a DID
+// with its latest login session, returning errNoSession if it has none
+func (h *Handlers) publishResultsAs(ctx context.Context, did string, survey *models.Survey, now time.Time) error {
+	session, err := h.latestSession(ctx, did)
+	if err != nil {
+		return err
+	}
+	results, err := h.queries.GetSurveyResults(ctx, survey.ID)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate results: %w", err)
+	}
+
+	record, err := h.publishedResultsRecord(survey, results, now)
+	if err != nil {
+		return fmt.Errorf("failed to sign results: %w", err)
+	}
+	uri, cid, err := h.writeRecord(ctx, session, "net.openmeet.survey.results", oauth.GenerateTID(), record)
+	if err != nil {
+		return fmt.Errorf("failed to write results to PDS: %w", err)
+	}
+	if err := h.queries.UpdateSurveyResults(ctx, survey.ID, uri, cid); err != nil {
+		return fmt.Errorf("failed to save results reference: %w", err)
+	}
+	h.cache.Invalidate(ctx, cache.SurveyKey(survey.Slug))
+	return nil
+}
+
+// coAuthorErrorJSON responds to an API request whose co-author helper failed
+func coAuthorErrorJSON(c echo.Context, err error, action string) error {
+	switch {
+	case errors.Is(err, errCoAuthorForbidden):
+		return Problem(c, problem.Forbidden, coAuthorErrorMessage(err))
+	case errors.Is(err, errInvalidCoAuthorRequest):
+		return ValidationError(c, "Invalid request", coAuthorErrorMessage(err))
+	case errors.Is(err, coauthor.ErrRequestPending):
+		return Problem(c, problem.PublishPending, coAuthorErrorMessage(err))
+	case errors.Is(err, sql.ErrNoRows):
+		return Problem(c, problem.NotFound, coAuthorErrorMessage(err))
+	}
+	return InternalServerError(c, "Failed to "+action, err)
+}
+
+// coAuthorErrorMessage is the message shown on a page whose co-author helper
+// failed because of the request, or "" for internal errors
+func coAuthorErrorMessage(err error) string {
+	for _, sentinel := range []error{errCoAuthorForbidden, errInvalidCoAuthorRequest} {
+		if errors.Is(err, sentinel) {
+			return strings.TrimPrefix(err.Error(), sentinel.Error()+": ")
+		}
+	}
+	switch {
+	case errors.Is(err, coauthor.ErrRequestPending):
+		return "The author has yet to answer the last request to publish the results"
+	case errors.Is(err, sql.ErrNoRows):
+		return "The survey has no such co-author or pending publish request"
+	}
+	return ""
+}
+
+// coAuthorSurvey loads the survey of an API request for a caller who edits
+// it: those who manage it and its co-authors. On failure it returns a nil
+// survey and the error response written.
+func (h *Handlers) coAuthorSurvey(c echo.Context) (*models.Survey, string, error) {
+	did, err := requireCaller(c)
+	if did == "" {
+		return nil, "", err
+	}
+
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", Problem(c, problem.SurveyNotFound, fmt.Sprintf("No survey found with slug '%s'", slug))
+		}
+		return nil, "", InternalServerError(c, "Failed to retrieve survey", err)
+	}
+	if !h.canEditSurveyAs(c.Request().Context(), did, survey) {
+		return nil, "", Problem(c, problem.Forbidden, "Only the survey author and its co-authors can do this")
+	}
+	return survey, did, nil
+}
+
+// ListCoAuthors handles GET /api/v1/surveys/:slug/coauthors
+// Lists the co-authors of a survey and its pending publish request
+func (h *Handlers) ListCoAuthors(c echo.Context) error {
+	survey, _, err := h.coAuthorSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	coAuthors, err := h.coAuthors.ListCoAuthors(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list co-authors", err)
+	}
+	if coAuthors == nil {
+		coAuthors = []*coauthor.CoAuthor{}
+	}
+	request, err := h.coAuthors.GetPendingPublishRequest(ctx, survey.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return InternalServerError(c, "Failed to load publish request", err)
+	}
+
+	return c.JSON(http.StatusOK, CoAuthorsResponse{AuthorDID: survey.AuthorDID, CoAuthors: coAuthors, PublishRequest: request})
+}
+
+// AddCoAuthor handles POST /api/v1/surveys/:slug/coauthors
+// Adds a co-author by DID or handle (the author only)
+func (h *Handlers) AddCoAuthor(c echo.Context) error {
+	survey, did, err := h.coAuthorSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	var req AddCoAuthorRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+
+	added, err := h.addCoAuthor(c.Request().Context(), survey, did, req.CoAuthor)
+	if err != nil {
+		return coAuthorErrorJSON(c, err, "add co-author")
+	}
+
+	return c.JSON(http.StatusCreated, added)
+}
+
+// RemoveCoAuthor handles DELETE /api/v1/surveys/:slug/coauthors/:did
+// Removes a co-author; co-authors can remove themselves to leave
+func (h *Handlers) RemoveCoAuthor(c echo.Context) error {
+	survey, did, err := h.coAuthorSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	if err := h.removeCoAuthor(c.Request().Context(), survey, did, memberParam(c)); err != nil {
+		return coAuthorErrorJSON(c, err, "remove co-author")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RequestPublish handles POST /api/v1/surveys/:slug/publish-requests
+// Asks the author to publish the survey's results to their PDS (co-authors only)
+func (h *Handlers) RequestPublish(c echo.Context) error {
+	survey, did, err := h.coAuthorSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	request, err := h.requestPublish(c.Request().Context(), survey, did)
+	if err != nil {
+		return coAuthorErrorJSON(c, err, "request publication")
+	}
+
+	return c.JSON(http.StatusCreated, request)
+}
+
+// ResolvePublish handles PUT /api/v1/surveys/:slug/publish-requests/:id
+// Approves the request, publishing the results, or rejects it
+func (h *Handlers) ResolvePublish(c echo.Context) error {
+	survey, did, err := h.coAuthorSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return ValidationError(c, "Invalid request", "Invalid publish request ID")
+	}
+	var req ResolvePublishRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+
+	request, err := h.resolvePublish(c.Request().Context(), survey, did, id, req.Status)
+	if err != nil {
+		return coAuthorErrorJSON(c, err, "resolve publish request")
+	}
+
+	return c.JSON(http.StatusOK, request)
+}
+
+// coAuthorSurveyHTML is coAuthorSurvey for pages, which need a logged-in user
+func (h *Handlers) coAuthorSurveyHTML(c echo.Context) (*models.Survey, *oauth.User, error) {
+	survey, err := h.surveyBySlug(c.Request().Context(), c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, c.String(http.StatusNotFound, "Survey not found")
+		}
+		return nil, nil, c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user := oauth.GetUser(c)
+	if user == nil {
+		return nil, nil, c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canEditSurveyAs(c.Request().Context(), user.DID, survey) {
+		return nil, nil, c.String(http.StatusForbidden, "Only the survey author and its co-authors can see its co-authors")
+	}
+	return survey, user, nil
+}
+
+// CoAuthorsPageHTML shows the co-authors of a survey and its pending publish
+// request, which its author approves or rejects
+// GET /surveys/:slug/coauthors
+func (h *Handlers) CoAuthorsPageHTML(c echo.Context) error {
+	survey, user, err := h.coAuthorSurveyHTML(c)
+	if survey == nil {
+		return err
+	}
+	return h.renderCoAuthorsPage(c, survey, user, "")
+}
+
+// AddCoAuthorHTML adds a co-author from the co-authors page
+// POST /surveys/:slug/coauthors
+func (h *Handlers) AddCoAuthorHTML(c echo.Context) error {
+	survey, user, err := h.coAuthorSurveyHTML(c)
+	if survey == nil {
+		return err
+	}
+
+	if _, err := h.addCoAuthor(c.Request().Context(), survey, user.DID, c.FormValue("coauthor")); err != nil {
+		return h.coAuthorErrorHTML(c, survey, user, err, "add co-author")
+	}
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug+"/coauthors"))
+}
+
+// RemoveCoAuthorHTML removes a co-author, or the user leaving
+// POST /surveys/:slug/coauthors/:did
+func (h *Handlers) RemoveCoAuthorHTML(c echo.Context) error {
+	survey, user, err := h.coAuthorSurveyHTML(c)
+	if survey == nil {
+		return err
+	}
+
+	did := memberParam(c)
+	if err := h.removeCoAuthor(c.Request().Context(), survey, user.DID, did); err != nil {
+		return h.coAuthorErrorHTML(c, survey, user, err, "remove co-author")
+	}
+
+	if did == user.DID {
+		return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug))
+	}
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug+"/coauthors"))
+}
+
+// RequestPublishHTML asks the author to publish the survey's results
+// POST /surveys/:slug/publish-requests
+func (h *Handlers) RequestPublishHTML(c echo.Context) error {
+	survey, user, err := h.coAuthorSurveyHTML(c)
+	if survey == nil {
+		return err
+	}
+
+	if _, err := h.requestPublish(c.Request().Context(), survey, user.DID); err != nil {
+		return h.coAuthorErrorHTML(c, survey, user, err, "request publication")
+	}
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug+"/coauthors"))
+}
+
+// ResolvePublishHTML approves or rejects the pending publish request with
+// the form's status
+// POST /surveys/:slug/publish-requests/:id
+func (h *Handlers) ResolvePublishHTML(c echo.Context) error {
+	survey, user, err := h.coAuthorSurveyHTML(c)
+	if survey == nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return h.renderCoAuthorsPage(c, survey, user, "Invalid publish request")
+	}
+	status := c.FormValue("status")
+	if _, err := h.resolvePublish(c.Request().Context(), survey, user.DID, id, status); err != nil {
+		return h.coAuthorErrorHTML(c, survey, user, err, "resolve publish request")
+	}
+
+	if status == coauthor.StatusApproved {
+		return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug+"/results"))
+	}
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug+"/coauthors"))
+}
+
+// coAuthorErrorHTML shows the error of a failed co-author change on the
+// co-authors page, or a 500 for internal errors
+func (h *Handlers) coAuthorErrorHTML(c echo.Context, survey *models.Survey, user *oauth.User, err error, action string) error {
+	if msg := coAuthorErrorMessage(err); msg != "" {
+		return h.renderCoAuthorsPage(c, survey, user, msg)
+	}
+	c.Logger().Errorf("Failed to %s of survey %s: %v", action, survey.Slug, err)
+	return c.String(http.StatusInternalServerError, "Failed to "+action)
+}
+
+// renderCoAuthorsPage renders the co-authors page of a survey, with the
+// error of a failed change
+func (h *Handlers) renderCoAuthorsPage(c echo.Context, survey *models.Survey, user *oauth.User, formError string) error {
+	ctx := c.Request().Context()
+	coAuthors, err := h.coAuthors.ListCoAuthors(ctx, survey.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to list co-authors of survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to load co-authors")
+	}
+	request, err := h.coAuthors.GetPendingPublishRequest(ctx, survey.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.Logger().Errorf("Failed to load publish request of survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to load co-authors")
+	}
+
+	dids := make([]string, 0, len(coAuthors)+1)
+	if survey.AuthorDID != nil {
+		dids = append(dids, *survey.AuthorDID)
+	}
+	for _, ca := range coAuthors {
+		dids = append(dids, ca.DID)
+	}
+	names := h.identities.Resolve(ctx, dids)
+
+	canAdd := isSurveyAuthor(survey, user.DID) || h.adminDIDs[user.DID]
+	_, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.CoAuthorsPage(survey, coAuthors, names, request, canAdd, formError, user, profile, h.posthogKey)
+	return component.Render(ctx, c.Response().Writer)
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/coauthor"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCoAuthorStore keeps co-authors and publish requests in memory
+type mockCoAuthorStore struct {
+	coAuthors []*coauthor.CoAuthor
+	requests  []*coauthor.PublishRequest
+}
+
+func (m *mockCoAuthorStore) AddCoAuthor(ctx context.Context, c *coauthor.CoAuthor) error {
+	if ok, _ := m.IsCoAuthor(ctx, c.SurveyID, c.DID); ok {
+		return coauthor.ErrAlreadyCoAuthor
+	}
+	m.coAuthors = append(m.coAuthors, c)
+	return nil
+}
+
+func (m *mockCoAuthorStore) ListCoAuthors(ctx context.Context, surveyID uuid.UUID) ([]*coauthor.CoAuthor, error) {
+	var coAuthors []*coauthor.CoAuthor
+	for _, c := range m.coAuthors {
+		if c.SurveyID == surveyID {
+			coAuthors = append(coAuthors, c)
+		}
+	}
+	return coAuthors, nil
+}
+
+func (m *mockCoAuthorStore) IsCoAuthor(ctx context.Context, surveyID uuid.UUID, did string) (bool, error) {
+	for _, c := range m.coAuthors {
+		if c.SurveyID == surveyID && c.DID == did {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockCoAuthorStore) RemoveCoAuthor(ctx context.Context, surveyID uuid.UUID, did string, now time.Time) error {
+	for i, c := range m.coAuthors {
+		if c.SurveyID == surveyID && c.DID == did {
+			m.coAuthors = append(m.coAuthors[:i], m.coAuthors[i+1:]...)
+			if r, err := m.GetPendingPublishRequest(ctx, surveyID); err == nil && r.RequestedBy == did {
+				r.Status = coauthor.StatusRejected
+			}
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *mockCoAuthorStore) CreatePublishRequest(ctx context.Context, r *coauthor.PublishRequest) error {
+	if _, err := m.GetPendingPublishRequest(ctx, r.SurveyID); err == nil {
+		return coauthor.ErrRequestPending
+	}
+	m.requests = append(m.requests, r)
+	return nil
+}
+
+func (m *mockCoAuthorStore) GetPendingPublishRequest(ctx context.Context, surveyID uuid.UUID) (*coauthor.PublishRequest, error) {
+	for _, r := range m.requests {
+		if r.SurveyID == surveyID && r.Status == coauthor.StatusPending {
+			return r, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockCoAuthorStore) ResolvePublishRequest(ctx context.Context, id, surveyID uuid.UUID, status, resolvedBy string, now time.Time) error {
+	r, err := m.GetPendingPublishRequest(ctx, surveyID)
+	if err != nil || r.ID != id {
+		return sql.ErrNoRows
+	}
+	r.Status, r.ResolvedBy, r.ResolvedAt = status, &resolvedBy, &now
+	return nil
+}
+
+func TestCoAuthors(t *testing.T) {
+	e, mq, h, _ := setupOrgTest()
+	store := &mockCoAuthorStore{}
+	h.SetCoAuthors(store)
+	ctx := context.Background()
+	alice, bob := "did:plc:alice", "did:plc:bob"
+	survey := createTextSurvey(mq, "lunch", &alice)
+
+	add := func(user, coAuthor string) int {
+		return callOrgAPI(t, e, h.AddCoAuthor, http.MethodPost, `{"coAuthor": "`+coAuthor+`"}`, user, "slug", "lunch").Code
+	}
+
+	t.Run("only the author adds co-authors", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, add("did:plc:mallory", "did:plc:mallory"))
+		require.Equal(t, http.StatusCreated, add(alice, "@bob.test"))
+		assert.Equal(t, http.StatusBadRequest, add(alice, "did:plc:bob"), "already a co-author")
+		assert.Equal(t, http.StatusBadRequest, add(alice, alice), "the author is no co-author")
+		assert.Equal(t, http.StatusBadRequest, add(alice, "@nobody.example"))
+		assert.Equal(t, http.StatusForbidden, add(bob, "did:plc:carol"), "co-authors add no one")
+	})
+
+	t.Run("co-authors edit and read the survey but do not manage it", func(t *testing.T) {
+		assert.True(t, h.canEditSurveyAs(ctx, bob, survey))
+		assert.True(t, h.canReadSurveyAs(ctx, bob, survey))
+		assert.False(t, h.canManageSurveyAs(ctx, bob, survey))
+		assert.False(t, h.canEditSurveyAs(ctx, "did:plc:mallory", survey))
+
+		body, _ := json.Marshal(UpdateSurveyRequest{Definition: `{"questions": [{"id": "q1", "text": "Where for lunch?", "type": "text"}]}`})
+		rec := callOrgAPI(t, e, h.UpdateSurvey, http.MethodPut, string(body), bob, "slug", "lunch")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "Where for lunch?", survey.Title)
+	})
+
+	t.Run("lists co-authors to them and the author", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.ListCoAuthors, http.MethodGet, "", "did:plc:mallory", "slug", "lunch")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = callOrgAPI(t, e, h.ListCoAuthors, http.MethodGet, "", bob, "slug", "lunch")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp CoAuthorsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.CoAuthors, 1)
+		assert.Equal(t, bob, resp.CoAuthors[0].DID)
+		assert.Equal(t, alice, resp.CoAuthors[0].AddedBy)
+		assert.Nil(t, resp.PublishRequest)
+	})
+
+	t.Run("co-authors leave", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, add(alice, "did:plc:carol"))
+		rec := callOrgAPI(t, e, h.RemoveCoAuthor, http.MethodDelete, "", bob, "slug", "did", "lunch", "did:plc:carol")
+		assert.Equal(t, http.StatusForbidden, rec.Code, "co-authors only remove themselves")
+		rec = callOrgAPI(t, e, h.RemoveCoAuthor, http.MethodDelete, "", bob, "slug", "did", "lunch", bob)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.False(t, h.canEditSurveyAs(ctx, bob, survey))
+		rec = callOrgAPI(t, e, h.RemoveCoAuthor, http.MethodDelete, "", alice, "slug", "did", "lunch", bob)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestPublishRequests(t *testing.T) {
+	e, mq, h, _ := setupOrgTest()
+	store := &mockCoAuthorStore{}
+	h.SetCoAuthors(store)
+	ctx := context.Background()
+	alice, bob, carol := "did:plc:alice", "did:plc:bob", "did:plc:carol"
+	survey := createTextSurvey(mq, "lunch", &alice)
+	uri, cid := "at://did:plc:alice/net.openmeet.survey/lunch", "bafylunch"
+	survey.URI, survey.CID = &uri, &cid
+	for _, did := range []string{bob, carol} {
+		_, err := h.addCoAuthor(ctx, survey, alice, did)
+		require.NoError(t, err)
+	}
+
+	request := func(user string) *coauthor.PublishRequest {
+		rec := callOrgAPI(t, e, h.RequestPublish, http.MethodPost, "", user, "slug", "lunch")
+		if rec.Code != http.StatusCreated {
+			return nil
+		}
+		var r coauthor.PublishRequest
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &r))
+		return &r
+	}
+	resolve := func(user string, id uuid.UUID, status string) int {
+		return callOrgAPI(t, e, h.ResolvePublish, http.MethodPut, `{"status": "`+status+`"}`, user, "slug", "id", "lunch", id.String()).Code
+	}
+
+	assert.Nil(t, request(alice), "authors publish results themselves")
+	r := request(bob)
+	require.NotNil(t, r)
+	assert.Equal(t, coauthor.StatusPending, r.Status)
+
+	rec := callOrgAPI(t, e, h.RequestPublish, http.MethodPost, "", carol, "slug", "lunch")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(problem.PublishPending))
+
+	t.Run("only the author approves", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, resolve(bob, r.ID, coauthor.StatusApproved))
+		assert.Equal(t, http.StatusForbidden, resolve(carol, r.ID, coauthor.StatusRejected), "co-authors only withdraw their own requests")
+		assert.Equal(t, http.StatusNotFound, resolve(alice, uuid.New(), coauthor.StatusApproved))
+		assert.Equal(t, http.StatusBadRequest, resolve(alice, r.ID, "maybe"))
+	})
+
+	t.Run("approving needs the author's login session", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, resolve(alice, r.ID, coauthor.StatusApproved))
+		assert.Equal(t, coauthor.StatusPending, r.Status)
+		assert.Nil(t, survey.ResultsURI)
+	})
+
+	t.Run("the author rejects", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, resolve(alice, store.requests[0].ID, coauthor.StatusRejected))
+		assert.Equal(t, coauthor.StatusRejected, store.requests[0].Status)
+		assert.Equal(t, alice, *store.requests[0].ResolvedBy)
+	})
+
+	t.Run("co-authors withdraw their requests", func(t *testing.T) {
+		r := request(carol)
+		require.NotNil(t, r)
+		assert.Equal(t, http.StatusOK, resolve(carol, r.ID, coauthor.StatusRejected))
+		_, err := store.GetPendingPublishRequest(ctx, survey.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("local-only surveys have no results to publish", func(t *testing.T) {
+		local := createTextSurvey(mq, "local", &alice)
+		_, err := h.addCoAuthor(ctx, local, alice, bob)
+		require.NoError(t, err)
+		rec := callOrgAPI(t, e, h.RequestPublish, http.MethodPost, "", bob, "slug", "local")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Page sizes of a survey's comments
+const (
+	defaultCommentsPageSize = 50
+	maxCommentsPageSize     = 200
+)
+
+// ListComments handles GET /api/v1/surveys/:slug/comments?limit=&offset=
+// Lists the net.openmeet.survey.comment records indexed for a survey, oldest
+// first, to anyone who can see the survey
+func (h *Handlers) ListComments(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return h.surveyNotFoundJSON(c, slug)
+		}
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+	if h.surveyHidden(c, survey) {
+		return surveyHiddenJSON(c)
+	}
+	if !h.canViewSurvey(c, survey) {
+		return surveyPrivateJSON(c)
+	}
+
+	page := CommentsPage{SurveyID: survey.ID, Slug: survey.Slug, Limit: defaultCommentsPageSize}
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+		page.Limit = min(l, maxCommentsPageSize)
+	}
+	if o, err := strconv.Atoi(c.QueryParam("offset")); err == nil && o >= 0 {
+		page.Offset = o
+	}
+
+	page.Comments, err = h.queries.ListSurveyComments(ctx, survey.ID, page.Limit, page.Offset)
+	if err != nil {
+		return InternalServerError(c, "Failed to list comments", err)
+	}
+	if page.Comments == nil {
+		page.Comments = []*models.Comment{}
+	}
+	page.Total, err = h.queries.CountSurveyComments(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list comments", err)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
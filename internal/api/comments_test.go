@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListComments(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := createTextSurvey(mq, "feedback", nil)
+	for i := range 3 {
+		mq.Comments = append(mq.Comments, &models.Comment{
+			ID:        uuid.New(),
+			SurveyID:  survey.ID,
+			AuthorDID: fmt.Sprintf("did:plc:commenter%d", i),
+			RecordURI: fmt.Sprintf("at://did:plc:commenter%d/net.openmeet.survey.comment/%d", i, i),
+			Text:      fmt.Sprintf("Comment %d", i),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	list := func(slug, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/surveys/"+slug+"/comments"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		require.NoError(t, h.ListComments(c))
+		return rec
+	}
+
+	rec := list("feedback", "?limit=2&offset=1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page CommentsPage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Equal(t, 3, page.Total)
+	assert.Equal(t, 2, page.Limit)
+	require.Len(t, page.Comments, 2)
+	assert.Equal(t, "Comment 1", page.Comments[0].Text)
+
+	rec = list("feedback", "?offset=5")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"comments":[]`)
+
+	assert.Equal(t, http.StatusNotFound, list("missing", "").Code)
+
+	// Comments of private surveys need a share token
+	survey.Definition.Visibility = models.VisibilityToken
+	assert.Equal(t, http.StatusForbidden, list("feedback", "").Code)
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/openmeet-team/survey/internal/idempotency"
+)
+
+// CORSMiddleware lets browsers on the allowed origins of a config call the
+// JSON API. Requests from other origins get no CORS headers, so browsers
+// refuse them.
+func CORSMiddleware(config SecurityConfig) echo.MiddlewareFunc {
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: config.AllowedOrigins,
+		AllowMethods: []string{
+			echo.GET,
+			echo.POST,
+			echo.PUT,
+			echo.DELETE,
+			echo.OPTIONS,
+		},
+		AllowHeaders: []string{
+			echo.HeaderContentType,
+			echo.HeaderAuthorization,
+			echo.HeaderAccept,
+			shareTokenHeader,
+			idempotency.Header,
+		},
+	})
+}
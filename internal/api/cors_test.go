@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	config := DefaultSecurityConfig()
+	config.AllowedOrigins = []string{"https://app.example"}
+
+	tests := []struct {
+		name   string
+		origin string
+		want   string
+	}{
+		{"allowed origin", "https://app.example", "https://app.example"},
+		{"other origin", "https://evil.example", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodOptions, "/api/v1/surveys", nil)
+			req.Header.Set(echo.HeaderOrigin, tt.origin)
+			req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodPost)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := CORSMiddleware(config)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			require.NoError(t, h(c))
+
+			assert.Equal(t, tt.want, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+			if tt.want != "" {
+				assert.Contains(t, rec.Header().Get(echo.HeaderAccessControlAllowHeaders), shareTokenHeader)
+			}
+		})
+	}
+}
+
+func TestSecurityRoutes(t *testing.T) {
+	e, mq, h := setupTest()
+	createTestSurvey(mq, "lunch")
+	config := DefaultSecurityConfig()
+	config.AllowedOrigins = []string{"https://app.example"}
+	config.FrameAncestors = []string{"https://blog.example"}
+	h.SetSecurity(config)
+	SetupRoutes(e, h, &HealthHandlers{}, nil, nil)
+
+	// The JSON API answers browsers on allowed origins only
+	for origin, want := range map[string]string{"https://app.example": "https://app.example", "https://evil.example": ""} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/surveys/lunch", nil)
+		req.Header.Set(echo.HeaderOrigin, origin)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, want, rec.Header().Get(echo.HeaderAccessControlAllowOrigin), origin)
+		assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+	}
+
+	// Results charts may be framed by the configured ancestors
+	req := httptest.NewRequest(http.MethodGet, "/surveys/lunch/results/chart.svg?question=q1", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, rec.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors https://blog.example", rec.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", rec.Header().Get("Referrer-Policy"))
+}
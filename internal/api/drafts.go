@@ -0,0 +1,253 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/draft"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// draftOwner returns the owner of the caller's drafts: the logged-in user or
+// key owner, or the guest of a valid draft cookie
+func (h *Handlers) draftOwner(c echo.Context) (string, bool) {
+	if did, ok := callerDID(c); ok {
+		return did, true
+	}
+
+	cookie, err := c.Cookie(draft.CookieName)
+	if err != nil {
+		return "", false
+	}
+	guestID, ok := h.draftGuests.Verify(cookie.Value)
+	if !ok {
+		return "", false
+	}
+	return draft.GuestOwner(guestID), true
+}
+
+// newDraftOwner returns the owner of the caller's drafts, identifying a new
+// guest with a draft cookie if the caller has none
+func (h *Handlers) newDraftOwner(c echo.Context) (string, error) {
+	if owner, ok := h.draftOwner(c); ok {
+		return owner, nil
+	}
+
+	guestID, value, err := h.draftGuests.New()
+	if err != nil {
+		return "", err
+	}
+	c.SetCookie(oauth.AppCookie(draft.CookieName, value, int(draft.TTL.Seconds())))
+	return draft.GuestOwner(guestID), nil
+}
+
+// invalidDraftID responds to an unparsable :id path parameter
+func invalidDraftID(c echo.Context, err error) error {
+	return Problem(c, problem.ValidationFailed, "Invalid draft ID: "+err.Error())
+}
+
+// draftNotFound responds that the caller has no such draft
+func draftNotFound(c echo.Context) error {
+	return Problem(c, problem.NotFound, "Draft not found: No draft with this ID belongs to you")
+}
+
+// invalidDraft responds to content rejected by draft.New or Update
+func invalidDraft(c echo.Context, err error) error {
+	code := problem.ValidationFailed
+	if errors.Is(err, draft.ErrTooLarge) {
+		code = problem.PayloadTooLarge
+	}
+	return Problem(c, code, "Invalid draft: "+err.Error())
+}
+
+// ListDrafts handles GET /api/v1/drafts
+// Guests without a draft cookie have no drafts
+func (h *Handlers) ListDrafts(c echo.Context) error {
+	drafts := []*draft.Draft{}
+	if owner, ok := h.draftOwner(c); ok {
+		listed, err := h.drafts.ListDrafts(c.Request().Context(), owner)
+		if err != nil {
+			return InternalServerError(c, "Failed to list drafts", err)
+		}
+		if listed != nil {
+			drafts = listed
+		}
+	}
+
+	return c.JSON(http.StatusOK, ListDraftsResponse{Drafts: drafts})
+}
+
+// CreateDraft handles POST /api/v1/drafts
+// Guests are identified by a new draft cookie
+func (h *Handlers) CreateDraft(c echo.Context) error {
+	var req SaveDraftRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+
+	owner, err := h.newDraftOwner(c)
+	if err != nil {
+		return InternalServerError(c, "Failed to create draft", err)
+	}
+
+	d, err := draft.New(owner, req.Format, req.Content, req.Slug, time.Now())
+	if err != nil {
+		return invalidDraft(c, err)
+	}
+
+	ctx := c.Request().Context()
+	count, err := h.drafts.CountDrafts(ctx, owner)
+	if err != nil {
+		return InternalServerError(c, "Failed to create draft", err)
+	}
+	if count >= draft.MaxDrafts {
+		return Problem(c, problem.LimitReached, "Too many drafts: Delete a draft before starting another")
+	}
+
+	if err := h.drafts.CreateDraft(ctx, d); err != nil {
+		return InternalServerError(c, "Failed to create draft", err)
+	}
+
+	return c.JSON(http.StatusCreated, d)
+}
+
+// GetDraft handles GET /api/v1/drafts/:id
+func (h *Handlers) GetDraft(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return invalidDraftID(c, err)
+	}
+	owner, ok := h.draftOwner(c)
+	if !ok {
+		return draftNotFound(c)
+	}
+
+	d, err := h.drafts.GetDraft(c.Request().Context(), id, owner)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return draftNotFound(c)
+		}
+		return InternalServerError(c, "Failed to get draft", err)
+	}
+
+	return c.JSON(http.StatusOK, d)
+}
+
+// SaveDraft handles PUT /api/v1/drafts/:id
+// The create page autosaves the editor content here as it changes
+func (h *Handlers) SaveDraft(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return invalidDraftID(c, err)
+	}
+	owner, ok := h.draftOwner(c)
+	if !ok {
+		return draftNotFound(c)
+	}
+
+	var req SaveDraftRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	d, err := h.drafts.GetDraft(ctx, id, owner)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return draftNotFound(c)
+		}
+		return InternalServerError(c, "Failed to save draft", err)
+	}
+
+	if err := d.Update(req.Format, req.Content, req.Slug, time.Now()); err != nil {
+		return invalidDraft(c, err)
+	}
+	if err := h.drafts.UpdateDraft(ctx, d); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return draftNotFound(c) // Deleted since it was read
+		}
+		return InternalServerError(c, "Failed to save draft", err)
+	}
+
+	return c.JSON(http.StatusOK, d)
+}
+
+// DeleteDraft handles DELETE /api/v1/drafts/:id
+func (h *Handlers) DeleteDraft(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return invalidDraftID(c, err)
+	}
+	owner, ok := h.draftOwner(c)
+	if !ok {
+		return draftNotFound(c)
+	}
+
+	if err := h.drafts.DeleteDraft(c.Request().Context(), id, owner); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return draftNotFound(c)
+		}
+		return InternalServerError(c, "Failed to delete draft", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// createPageDrafts returns the drafts of the create page: the draft given by
+// ?draft= to resume, or else the caller's drafts for the resume banner
+func (h *Handlers) createPageDrafts(c echo.Context) templates.DraftState {
+	if h.drafts == nil {
+		return templates.DraftState{}
+	}
+	state := templates.DraftState{Enabled: true}
+
+	owner, ok := h.draftOwner(c)
+	if !ok {
+		return state
+	}
+
+	ctx := c.Request().Context()
+	if id, err := uuid.Parse(c.QueryParam("draft")); err == nil {
+		d, err := h.drafts.GetDraft(ctx, id, owner)
+		if err == nil {
+			state.Resumed = d
+			return state
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			c.Logger().Errorf("Failed to get draft %s: %v", id, err)
+		}
+	}
+
+	drafts, err := h.drafts.ListDrafts(ctx, owner)
+	if err != nil {
+		c.Logger().Errorf("Failed to list drafts: %v", err)
+	}
+	state.Saved = drafts
+	return state
+}
+
+// deletePublishedDraft deletes the draft a survey was created from, given in
+// the draft_id field of the create form
+func (h *Handlers) deletePublishedDraft(c echo.Context) {
+	if h.drafts == nil {
+		return
+	}
+	id, err := uuid.Parse(c.FormValue("draft_id"))
+	if err != nil {
+		return
+	}
+	owner, ok := h.draftOwner(c)
+	if !ok {
+		return
+	}
+
+	if err := h.drafts.DeleteDraft(c.Request().Context(), id, owner); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.Logger().Errorf("Failed to delete published draft %s: %v", id, err)
+	}
+}
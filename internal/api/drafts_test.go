@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/draft"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockDrafts stores drafts in memory
+type mockDrafts struct {
+	drafts map[uuid.UUID]*draft.Draft
+}
+
+func newMockDrafts() *mockDrafts {
+	return &mockDrafts{drafts: make(map[uuid.UUID]*draft.Draft)}
+}
+
+func (m *mockDrafts) CreateDraft(ctx context.Context, d *draft.Draft) error {
+	copied := *d
+	m.drafts[d.ID] = &copied
+	return nil
+}
+
+func (m *mockDrafts) GetDraft(ctx context.Context, id uuid.UUID, owner string) (*draft.Draft, error) {
+	d, ok := m.drafts[id]
+	if !ok || d.Owner != owner {
+		return nil, sql.ErrNoRows
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (m *mockDrafts) ListDrafts(ctx context.Context, owner string) ([]*draft.Draft, error) {
+	var drafts []*draft.Draft
+	for _, d := range m.drafts {
+		if d.Owner == owner {
+			drafts = append(drafts, d)
+		}
+	}
+	return drafts, nil
+}
+
+func (m *mockDrafts) CountDrafts(ctx context.Context, owner string) (int, error) {
+	drafts, _ := m.ListDrafts(ctx, owner)
+	return len(drafts), nil
+}
+
+func (m *mockDrafts) UpdateDraft(ctx context.Context, d *draft.Draft) error {
+	if _, err := m.GetDraft(ctx, d.ID, d.Owner); err != nil {
+		return err
+	}
+	return m.CreateDraft(ctx, d)
+}
+
+func (m *mockDrafts) DeleteDraft(ctx context.Context, id uuid.UUID, owner string) error {
+	if _, err := m.GetDraft(ctx, id, owner); err != nil {
+		return err
+	}
+	delete(m.drafts, id)
+	return nil
+}
+
+func (m *mockDrafts) DeleteExpiredDrafts(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// draftRequest builds a draft API request with an optional user and cookies
+func draftRequest(e *echo.Echo, method, body, id string, user *oauth.User, cookies []*http.Cookie) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, "/api/v1/drafts", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if id != "" {
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+	}
+	if user != nil {
+		c.Set("user", user)
+	}
+	return c, rec
+}
+
+func setupDraftsTest() (*echo.Echo, *Handlers, *mockDrafts) {
+	store := newMockDrafts()
+	h := NewHandlers(&MockQueries{})
+	h.SetDrafts(store, draft.NewGuests(draft.Config{Secret: "secret"}))
+	return echo.New(), h, store
+}
+
+func TestDrafts_Guest(t *testing.T) {
+	e, h, store := setupDraftsTest()
+
+	// Saving a first draft identifies the guest with a cookie
+	c, rec := draftRequest(e, http.MethodPost, `{"content": "questions:\n  - text: Lunch?\n", "format": "yaml"}`, "", nil, nil)
+	require.NoError(t, h.CreateDraft(c))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, draft.CookieName, cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+
+	var created draft.Draft
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "Lunch?", created.Title)
+	assert.Equal(t, draft.FormatYAML, created.Format)
+	assert.True(t, strings.HasPrefix(store.drafts[created.ID].Owner, "guest:"))
+
+	// Autosave
+	c, rec = draftRequest(e, http.MethodPut, `{"content": "{\"questions\": [{\"text\": \"Dinner?\"}]}", "slug": "dinner"}`, created.ID.String(), nil, cookies)
+	require.NoError(t, h.SaveDraft(c))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "Dinner?", store.drafts[created.ID].Title)
+	assert.Equal(t, "dinner", store.drafts[created.ID].Slug)
+	assert.Equal(t, draft.FormatJSON, store.drafts[created.ID].Format)
+
+	c, rec = draftRequest(e, http.MethodGet, "", "", nil, cookies)
+	require.NoError(t, h.ListDrafts(c))
+	var list ListDraftsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Drafts, 1)
+	assert.Equal(t, created.ID, list.Drafts[0].ID)
+
+	c, rec = draftRequest(e, http.MethodDelete, "", created.ID.String(), nil, cookies)
+	require.NoError(t, h.DeleteDraft(c))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	c, rec = draftRequest(e, http.MethodGet, "", created.ID.String(), nil, cookies)
+	require.NoError(t, h.GetDraft(c))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDrafts_Owner(t *testing.T) {
+	e, h, _ := setupDraftsTest()
+	alice := &oauth.User{DID: "did:plc:alice"}
+
+	c, rec := draftRequest(e, http.MethodPost, `{"content": "{}"}`, "", alice, nil)
+	require.NoError(t, h.CreateDraft(c))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Result().Cookies(), "logged-in users need no draft cookie")
+
+	var created draft.Draft
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+
+	c, rec = draftRequest(e, http.MethodGet, "", created.ID.String(), alice, nil)
+	require.NoError(t, h.GetDraft(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	forged := []*http.Cookie{{Name: draft.CookieName, Value: "did:plc:alice.forged"}}
+	tests := []struct {
+		name    string
+		user    *oauth.User
+		cookies []*http.Cookie
+	}{
+		{"another user", &oauth.User{DID: "did:plc:bob"}, nil},
+		{"no identity", nil, nil},
+		{"forged cookie", nil, forged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := draftRequest(e, http.MethodGet, "", created.ID.String(), tt.user, tt.cookies)
+			require.NoError(t, h.GetDraft(c))
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+
+			c, rec = draftRequest(e, http.MethodPut, `{"content": "{}"}`, created.ID.String(), tt.user, tt.cookies)
+			require.NoError(t, h.SaveDraft(c))
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+
+			c, rec = draftRequest(e, http.MethodDelete, "", created.ID.String(), tt.user, tt.cookies)
+			require.NoError(t, h.DeleteDraft(c))
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+	}
+}
+
+func TestCreateDraft_Rejected(t *testing.T) {
+	alice := &oauth.User{DID: "did:plc:alice"}
+
+	t.Run("invalid format", func(t *testing.T) {
+		e, h, _ := setupDraftsTest()
+		c, rec := draftRequest(e, http.MethodPost, `{"content": "{}", "format": "xml"}`, "", alice, nil)
+		require.NoError(t, h.CreateDraft(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		e, h, _ := setupDraftsTest()
+		body, _ := json.Marshal(SaveDraftRequest{Content: strings.Repeat("x", draft.MaxContentBytes+1)})
+		c, rec := draftRequest(e, http.MethodPost, string(body), "", alice, nil)
+		require.NoError(t, h.CreateDraft(c))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("too many drafts", func(t *testing.T) {
+		e, h, store := setupDraftsTest()
+		for range draft.MaxDrafts {
+			d, err := draft.New(alice.DID, "", "{}", "", time.Now())
+			require.NoError(t, err)
+			require.NoError(t, store.CreateDraft(context.Background(), d))
+		}
+		c, rec := draftRequest(e, http.MethodPost, `{"content": "{}"}`, "", alice, nil)
+		require.NoError(t, h.CreateDraft(c))
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+}
+
+func TestListDrafts_NoIdentity(t *testing.T) {
+	e, h, _ := setupDraftsTest()
+
+	c, rec := draftRequest(e, http.MethodGet, "", "", nil, nil)
+	require.NoError(t, h.ListDrafts(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"drafts": []}`, rec.Body.String())
+	assert.Empty(t, rec.Result().Cookies(), "listing does not create a guest")
+}
+
+func TestCreatePageDrafts(t *testing.T) {
+	e, h, store := setupDraftsTest()
+	alice := &oauth.User{DID: "did:plc:alice"}
+	d, err := draft.New(alice.DID, "", "{}", "", time.Now())
+	require.NoError(t, err)
+	require.NoError(t, store.CreateDraft(context.Background(), d))
+
+	c, _ := draftRequest(e, http.MethodGet, "", "", alice, nil)
+	state := h.createPageDrafts(c)
+	assert.True(t, state.Enabled)
+	assert.Nil(t, state.Resumed)
+	require.Len(t, state.Saved, 1)
+
+	req := httptest.NewRequest(http.MethodGet, "/surveys/new?draft="+d.ID.String(), nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+	c.Set("user", alice)
+	state = h.createPageDrafts(c)
+	require.NotNil(t, state.Resumed)
+	assert.Equal(t, d.ID, state.Resumed.ID)
+
+	c.Set("user", &oauth.User{DID: "did:plc:bob"})
+	state = h.createPageDrafts(c)
+	assert.Nil(t, state.Resumed, "drafts of others are not resumed")
+	assert.Empty(t, state.Saved)
+}
@@ -1,10 +1,27 @@
 package api
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/audit"
+	"github.com/openmeet-team/survey/internal/coauthor"
+	"github.com/openmeet-team/survey/internal/dedup"
+	"github.com/openmeet-team/survey/internal/draft"
+	"github.com/openmeet-team/survey/internal/identity"
+	"github.com/openmeet-team/survey/internal/invite"
 	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/notify"
+	"github.com/openmeet-team/survey/internal/org"
+	"github.com/openmeet-team/survey/internal/questionbank"
+	"github.com/openmeet-team/survey/internal/reminder"
+	"github.com/openmeet-team/survey/internal/series"
+	"github.com/openmeet-team/survey/internal/sharetoken"
+	"github.com/openmeet-team/survey/internal/tenant"
+	"github.com/openmeet-team/survey/internal/vanity"
+	"github.com/openmeet-team/survey/internal/weighting"
 )
 
 // CreateSurveyRequest represents the request body for creating a survey
@@ -13,16 +30,59 @@ type CreateSurveyRequest struct {
 	Definition string `json:"definition"` // YAML or JSON string
 }
 
+// BulkCreateSurveysRequest represents the request body for creating surveys in bulk
+type BulkCreateSurveysRequest struct {
+	Surveys []CreateSurveyRequest `json:"surveys"`
+	Publish bool                  `json:"publish"` // optional, also write the surveys to the logged-in caller's PDS
+}
+
+// BulkCreateSurveysResponse lists the surveys created in bulk, in the order requested
+type BulkCreateSurveysResponse struct {
+	Surveys []BulkSurveyResult `json:"surveys"`
+}
+
+// BulkSurveyResult is the outcome of one survey of a bulk creation
+type BulkSurveyResult struct {
+	Index        int             `json:"index"` // Position of the survey in the request
+	Survey       *SurveyResponse `json:"survey"`
+	Published    bool            `json:"published,omitempty"`    // Written to the caller's PDS
+	PublishError string          `json:"publishError,omitempty"` // Why the PDS write failed; the survey is local-only until its queued record is retried
+}
+
+// UpdateSurveyRequest represents the request body for replacing a survey's definition
+type UpdateSurveyRequest struct {
+	Definition string `json:"definition"` // YAML or JSON string
+}
+
+// PreviewSurveyRequest represents the request body for previewing a survey definition
+type PreviewSurveyRequest struct {
+	Definition string `json:"definition"` // YAML or JSON string
+}
+
+// PreviewSurveyResponse is the short-lived link to a survey preview
+type PreviewSurveyResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ValidateSurveyRequest represents the request body for validating a survey definition
+type ValidateSurveyRequest struct {
+	Definition string `json:"definition"` // YAML or JSON string
+}
+
 // SurveyResponse represents a survey in API responses
 type SurveyResponse struct {
 	ID          uuid.UUID                `json:"id"`
 	URI         *string                  `json:"uri,omitempty"`
 	CID         *string                  `json:"cid,omitempty"`
 	AuthorDID   *string                  `json:"authorDid,omitempty"`
+	Author      *identity.Identity       `json:"author,omitempty"` // resolved handle and profile of the author
 	Slug        string                   `json:"slug"`
 	Title       string                   `json:"title"`
 	Description *string                  `json:"description,omitempty"`
 	Definition  *models.SurveyDefinition `json:"definition,omitempty"` // omitted in list view
+	Version     int                      `json:"version,omitempty"`    // definition version, bumped when questions are reordered
 	StartsAt    *time.Time               `json:"startsAt,omitempty"`
 	EndsAt      *time.Time               `json:"endsAt,omitempty"`
 	CreatedAt   time.Time                `json:"createdAt"`
@@ -32,6 +92,7 @@ type SurveyResponse struct {
 // SurveyListResponse represents a survey in list responses (without full definition)
 type SurveyListResponse struct {
 	ID          uuid.UUID  `json:"id"`
+	URI         *string    `json:"uri,omitempty"` // set once published to the author's PDS
 	Slug        string     `json:"slug"`
 	Title       string     `json:"title"`
 	Description *string    `json:"description,omitempty"`
@@ -41,6 +102,21 @@ type SurveyListResponse struct {
 	UpdatedAt   time.Time  `json:"updatedAt"`
 }
 
+// ListedSurveyResponse is a survey of a sorted and filtered survey list
+type ListedSurveyResponse struct {
+	SurveyListResponse
+	Status        string `json:"status"` // open, closed, or upcoming
+	ResponseCount int    `json:"responseCount"`
+	HasResults    bool   `json:"hasResults"` // Whether the results were published
+}
+
+// TrashedSurveyResponse is a survey in the trash
+type TrashedSurveyResponse struct {
+	SurveyListResponse
+	DeletedAt time.Time `json:"deletedAt"`
+	ExpiresAt time.Time `json:"expiresAt"` // When it is deleted for good
+}
+
 // SubmitResponseRequest represents the request body for submitting a survey response
 type SubmitResponseRequest struct {
 	Answers map[string]models.Answer `json:"answers"`
@@ -51,17 +127,481 @@ type ResponseSubmittedResponse struct {
 	ID        uuid.UUID `json:"id"`
 	SurveyID  uuid.UUID `json:"surveyId"`
 	CreatedAt time.Time `json:"createdAt"`
+	Receipt   string    `json:"receipt,omitempty"` // Signed receipt token, verifiable at /surveys/:slug/receipt/:token
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Details string `json:"details,omitempty"`
+// ReportSurveyRequest represents the request body for reporting a survey
+type ReportSurveyRequest struct {
+	Reason  string `json:"reason"`            // spam, harassment, illegal, or other
+	Details string `json:"details,omitempty"` // optional, up to 1000 characters
 }
 
 // SurveyResultsResponse wraps the models.SurveyResults for API response
 type SurveyResultsResponse struct {
 	*models.SurveyResults
+	Weighting *weighting.Results `json:"weighting,omitempty"` // with ?weightBy=, next to the raw counts
+}
+
+// MarshalJSON adds the weighting to the results, which marshal themselves to
+// keep questions in display order and would otherwise leave it out
+func (r SurveyResultsResponse) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(r.SurveyResults)
+	if err != nil || r.Weighting == nil {
+		return data, err
+	}
+	weighted, err := json.Marshal(r.Weighting)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data[:len(data)-1], `,"weighting":`...)
+	data = append(data, weighted...)
+	return append(data, '}'), nil
+}
+
+// ExportResponse is the JSON export of a survey's responses
+type ExportResponse struct {
+	SurveyID  uuid.UUID        `json:"surveyId"`
+	Slug      string           `json:"slug"`
+	Version   int              `json:"version"`
+	Questions []ExportQuestion `json:"questions"` // in current display order
+	Responses []ExportRecord   `json:"responses"` // oldest first
+}
+
+// ExportQuestion describes a question column of an export
+type ExportQuestion struct {
+	QuestionID string   `json:"questionId"`
+	Ordinal    int      `json:"ordinal"` // 1-based position in the current definition, 0 if removed
+	Text       string   `json:"text,omitempty"`
+	Rows       []string `json:"rows,omitempty"` // row IDs of a matrix question, each a CSV column
+}
+
+// ExportRecord is a single response in a JSON export
+type ExportRecord struct {
+	ID            uuid.UUID      `json:"id"`
+	SubmittedAt   time.Time      `json:"submittedAt"`
+	VoterType     string         `json:"voterType"` // "did" or "anonymous"
+	VoterDID      *string        `json:"voterDid,omitempty"`
+	SurveyVersion int            `json:"surveyVersion"`
+	Answers       []ExportAnswer `json:"answers"` // in current display order
+}
+
+// ExportAnswer is a single answer in a JSON export
+type ExportAnswer struct {
+	QuestionID      string            `json:"questionId"`
+	Ordinal         int               `json:"ordinal"`                  // position in the current definition, 0 if removed
+	VersionOrdinal  int               `json:"versionOrdinal,omitempty"` // position in the version the voter answered
+	SelectedOptions []string          `json:"selectedOptions,omitempty"`
+	Text            string            `json:"text,omitempty"`
+	Rows            map[string]string `json:"rows,omitempty"` // option chosen per row of a matrix question
+	File            *models.Blob      `json:"file,omitempty"` // blob of a file question, on the voter's PDS
+}
+
+// ExportJobResponse is the status of an export generated in the background
+type ExportJobResponse struct {
+	ID                   uuid.UUID  `json:"id"`
+	Status               string     `json:"status"` // pending, running, done, or failed
+	Format               string     `json:"format"`
+	CreatedAt            time.Time  `json:"createdAt"`
+	CompletedAt          *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt            *time.Time `json:"expiresAt,omitempty"` // when the export is deleted
+	Error                *string    `json:"error,omitempty"`
+	DownloadURL          string     `json:"downloadUrl,omitempty"` // signed URL of the file, once done
+	DownloadURLExpiresAt *time.Time `json:"downloadUrlExpiresAt,omitempty"`
+}
+
+// VoterResponsesPage is a page of a non-anonymous survey's responses and who gave them
+type VoterResponsesPage struct {
+	SurveyID  uuid.UUID        `json:"surveyId"`
+	Slug      string           `json:"slug"`
+	Total     int              `json:"total"` // responses matching the filter, across all pages
+	Limit     int              `json:"limit"`
+	Offset    int              `json:"offset"`
+	Questions []ExportQuestion `json:"questions"` // in current display order
+	Responses []VoterResponse  `json:"responses"` // oldest first
+}
+
+// XRPCError is the body of an XRPC error
+type XRPCError struct {
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
+// XRPCSurveyOutput is the output of net.openmeet.survey.getSurvey
+type XRPCSurveyOutput struct {
+	Survey *SurveyResponse `json:"survey"`
+}
+
+// XRPCResultsOutput is the output of net.openmeet.survey.getResults
+type XRPCResultsOutput struct {
+	URI        string                `json:"uri"`
+	ResultsURI *string               `json:"resultsUri,omitempty"` // published by the author, if any
+	Results    *models.SurveyResults `json:"results"`
+}
+
+// XRPCCommentsOutput is the output of net.openmeet.survey.getComments
+type XRPCCommentsOutput struct {
+	URI      string            `json:"uri"`
+	Cursor   string            `json:"cursor,omitempty"` // set while more comments follow
+	Comments []XRPCCommentView `json:"comments"`
+}
+
+// XRPCResponsesOutput is the output of net.openmeet.survey.getResponses
+type XRPCResponsesOutput struct {
+	URI       string           `json:"uri"`
+	Cursor    string           `json:"cursor,omitempty"` // set while more responses follow
+	Total     int              `json:"total"`
+	Questions []ExportQuestion `json:"questions"` // in current display order
+	Responses []VoterResponse  `json:"responses"` // oldest first
+}
+
+// XRPCCommentView is net.openmeet.survey.getComments#commentView
+type XRPCCommentView struct {
+	URI       string    `json:"uri"`
+	CID       string    `json:"cid"`
+	Author    string    `json:"author"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+	IndexedAt time.Time `json:"indexedAt"`
+}
+
+// LexiconsResponse lists the published lexicon schemas
+type LexiconsResponse struct {
+	Lexicons []LexiconLink `json:"lexicons"`
+}
+
+// LexiconLink is the NSID of a published lexicon schema and where to fetch it
+type LexiconLink struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// CommentsPage is a page of the comments indexed for a survey
+type CommentsPage struct {
+	SurveyID uuid.UUID         `json:"surveyId"`
+	Slug     string            `json:"slug"`
+	Total    int               `json:"total"` // comments across all pages
+	Limit    int               `json:"limit"`
+	Offset   int               `json:"offset"`
+	Comments []*models.Comment `json:"comments"` // oldest first
+}
+
+// VoterResponse is a single response with its voter
+type VoterResponse struct {
+	ID            uuid.UUID          `json:"id"`
+	SubmittedAt   time.Time          `json:"submittedAt"`
+	Voter         *identity.Identity `json:"voter,omitempty"` // omitted for voters who were not logged in
+	SurveyVersion int                `json:"surveyVersion"`
+	Answers       []ExportAnswer     `json:"answers"` // in current display order
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`    // read, write, admin; defaults to read
+	RateLimit int      `json:"rateLimit"` // requests per minute; 0 for the default
+}
+
+// CreateAPIKeyResponse returns a new key with its token, which is not shown again
+type CreateAPIKeyResponse struct {
+	*apikey.Key
+	Token string `json:"token"`
+}
+
+// ListAPIKeysResponse lists a user's API keys
+type ListAPIKeysResponse struct {
+	Keys []*apikey.Key `json:"keys"`
+}
+
+// CreateOrgRequest represents the request body for creating an organization
+type CreateOrgRequest struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// InviteOrgMemberRequest represents the request body for inviting an organization member
+type InviteOrgMemberRequest struct {
+	Member string `json:"member"` // DID or handle
+	Role   string `json:"role"`   // owner, editor, or viewer
+}
+
+// UpdateOrgMemberRequest represents the request body for changing a member's role
+type UpdateOrgMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// SetSurveyOrgRequest represents the request body for moving a survey to an organization
+type SetSurveyOrgRequest struct {
+	Org string `json:"org"` // Slug of the organization, or "" to return the survey to its author
+}
+
+// ListOrgsResponse lists the caller's organizations and pending invites
+type ListOrgsResponse struct {
+	Orgs []*org.Membership `json:"orgs"`
+}
+
+// OrgResponse is an organization with its members and surveys
+type OrgResponse struct {
+	Org     *org.Org      `json:"org"`
+	Members []*org.Member `json:"members"`
+	Surveys []OrgSurvey   `json:"surveys"`
+}
+
+// OrgSurvey is a survey owned by an organization
+type OrgSurvey struct {
+	Slug      string  `json:"slug"`
+	Title     string  `json:"title"`
+	AuthorDID *string `json:"authorDid,omitempty"`
+}
+
+// SurveyOrgResponse is the organization owning a survey after it was moved
+type SurveyOrgResponse struct {
+	Slug string   `json:"slug"`
+	Org  *org.Org `json:"org"` // nil once the survey is returned to its author
+}
+
+// AddCoAuthorRequest represents the request body for adding a co-author to a survey
+type AddCoAuthorRequest struct {
+	CoAuthor string `json:"coAuthor"` // DID or handle
+}
+
+// ResolvePublishRequest represents the request body for answering a co-author's publish request
+type ResolvePublishRequest struct {
+	Status string `json:"status"` // approved or rejected
+}
+
+// CoAuthorsResponse lists the co-authors of a survey and its pending publish request
+type CoAuthorsResponse struct {
+	AuthorDID      *string                  `json:"authorDid,omitempty"`
+	CoAuthors      []*coauthor.CoAuthor     `json:"coAuthors"`
+	PublishRequest *coauthor.PublishRequest `json:"publishRequest,omitempty"`
+}
+
+// CreateSeriesRequest represents the request body for creating a survey series
+type CreateSeriesRequest struct {
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+}
+
+// AddSeriesSurveyRequest represents the request body for adding a survey to a series
+type AddSeriesSurveyRequest struct {
+	Survey string `json:"survey"` // Slug of the survey, which becomes the last wave
+}
+
+// ListSeriesResponse lists the caller's survey series
+type ListSeriesResponse struct {
+	Series []*series.Series `json:"series"`
+}
+
+// SeriesResponse is a survey series with its surveys, in wave order
+type SeriesResponse struct {
+	Series  *series.Series `json:"series"`
+	Surveys []SeriesSurvey `json:"surveys"`
+}
+
+// SeriesSurvey is a survey of a series
+type SeriesSurvey struct {
+	Slug      string `json:"slug"`
+	Title     string `json:"title"`
+	Anonymous bool   `json:"anonymous,omitempty"` // Its voters are not tracked across waves
+}
+
+// SeriesResultsResponse is the combined results of a survey series
+type SeriesResultsResponse struct {
+	Series  *series.Series  `json:"series"`
+	Results *series.Results `json:"results"`
+}
+
+// SeriesRespondentsPage is a page of the logged-in voters of a series with
+// their response to each wave
+type SeriesRespondentsPage struct {
+	Waves       []string             `json:"waves"` // Slugs of the waves, in the order of each respondent's responses
+	Total       int                  `json:"total"` // Respondents matching minWaves, across all pages
+	Limit       int                  `json:"limit"`
+	Offset      int                  `json:"offset"`
+	Respondents []*series.Respondent `json:"respondents"`
+}
+
+// ReserveSlugRequest represents the request body for reserving a slug
+type ReserveSlugRequest struct {
+	Pattern string `json:"pattern"` // A slug, or "prefix-*" for a namespace
+	Reason  string `json:"reason"`
+}
+
+// ListReservedSlugsResponse lists the reserved slugs and namespaces
+type ListReservedSlugsResponse struct {
+	ReservedSlugs []*vanity.Reservation `json:"reservedSlugs"`
+}
+
+// TenantRequest represents the request body for creating or updating a tenant
+type TenantRequest struct {
+	Slug          string `json:"slug"` // Only when creating
+	Name          string `json:"name"`
+	Tagline       string `json:"tagline"`
+	LogoURL       string `json:"logoUrl"`
+	AccentColor   string `json:"accentColor"`
+	DefaultAuthor string `json:"defaultAuthor"` // DID, or "" for none
+}
+
+// AddDomainRequest represents the request body for adding a hostname to a tenant
+type AddDomainRequest struct {
+	Hostname string `json:"hostname"`
+}
+
+// TenantResponse is a tenant with its hostnames
+type TenantResponse struct {
+	*tenant.Tenant
+	Domains []string `json:"domains"`
+}
+
+// CreateShareTokenRequest represents the request body for creating a share token
+type CreateShareTokenRequest struct {
+	Label string `json:"label"` // optional, who the link is for
+}
+
+// CreateShareTokenResponse returns a new share token with the token and the
+// link opening the survey with it, which are not shown again
+type CreateShareTokenResponse struct {
+	*sharetoken.Token
+	Secret string `json:"token"`
+	URL    string `json:"url"`
+}
+
+// ListShareTokensResponse lists a survey's share tokens
+type ListShareTokensResponse struct {
+	ShareTokens []*sharetoken.Token `json:"shareTokens"`
+}
+
+// CreateInvitesRequest represents the JSON request body for inviting respondents to a survey
+type CreateInvitesRequest struct {
+	Invitees []string `json:"invitees"` // Email addresses or DIDs
+}
+
+// CreatedInvite is a new invite with the link opening the survey with it,
+// which is not shown again
+type CreatedInvite struct {
+	*invite.Invite
+	URL string `json:"url"`
+}
+
+// CreateInvitesResponse returns the new invites of a survey and the invitees
+// skipped as already invited
+type CreateInvitesResponse struct {
+	Invites []CreatedInvite `json:"invites"`
+	Skipped []string        `json:"skipped"`
+}
+
+// ListInvitesResponse lists a survey's invites with its response rate
+type ListInvitesResponse struct {
+	invite.Rate
+	Invites []*invite.Invite `json:"invites"`
+}
+
+// SaveDraftRequest represents the request body for creating or autosaving a draft
+type SaveDraftRequest struct {
+	Content string `json:"content"` // Editor text; need not be a valid definition yet
+	Format  string `json:"format"`  // json or yaml; defaults to json
+	Slug    string `json:"slug"`
+}
+
+// ListDraftsResponse lists the caller's drafts, most recently saved first
+type ListDraftsResponse struct {
+	Drafts []*draft.Draft `json:"drafts"`
+}
+
+// ResultsVerificationResponse compares a survey's published results record,
+// fetched from the author's PDS, with a recount of the indexed responses
+type ResultsVerificationResponse struct {
+	ResultsURI              string                   `json:"resultsUri"`
+	ResultsCID              string                   `json:"resultsCid"`
+	RecordVerified          bool                     `json:"recordVerified"`        // The fetched record is the one published, unaltered
+	PublishedAt             string                   `json:"publishedAt,omitempty"` // The record's finalizedAt
+	VerifiedAt              time.Time                `json:"verifiedAt"`
+	ResponsesSincePublished int                      `json:"responsesSincePublished"` // Votes after publishing, which the recount includes
+	Attestation             *AttestationVerification `json:"attestation,omitempty"`   // If the service signs results
+	*audit.Comparison
+}
+
+// AttestationVerification is the outcome of checking the signature of a
+// results record against the service's results signing keys
+type AttestationVerification struct {
+	Signed bool   `json:"signed"`
+	Valid  bool   `json:"valid"`
+	KeyID  string `json:"keyId,omitempty"`
+	Error  string `json:"error,omitempty"` // Why the record does not verify
+}
+
+// VerifyAttestationRequest is the request to check the signature of a results record
+type VerifyAttestationRequest struct {
+	Record map[string]any `json:"record"` // The record value, as fetched from a PDS
+}
+
+// DuplicatesResponse lists the suspected duplicate responses of a survey
+type DuplicatesResponse struct {
+	Groups   []dedup.Group `json:"groups"`
+	Excluded int           `json:"excluded"` // Responses excluded from results
+}
+
+// ExcludeResponsesRequest represents the request body for excluding responses
+// from results, or including them again
+type ExcludeResponsesRequest struct {
+	ResponseIDs []uuid.UUID `json:"responseIds"`
+}
+
+// ExcludeResponsesResponse returns the number of responses changed
+type ExcludeResponsesResponse struct {
+	Changed int64 `json:"changed"`
+}
+
+// SpamThresholdRequest represents the request body for setting a survey's
+// spam threshold
+type SpamThresholdRequest struct {
+	Threshold *int `json:"threshold"` // 1-100, or 0 to count all responses
+}
+
+// AddInvitationsRequest represents the request body for inviting users to a survey
+type AddInvitationsRequest struct {
+	Invitees []string `json:"invitees"` // DIDs or handles
+	Org      bool     `json:"org"`      // Also invite the members of the survey's organization
+}
+
+// ReminderScheduleRequest represents the request body for setting when a
+// survey's invitees are reminded
+type ReminderScheduleRequest struct {
+	HoursBefore *int `json:"hoursBefore"` // 1-168, or 0 to stop reminders
+}
+
+// InvitationsResponse lists a survey's invitations and its reminder schedule
+type InvitationsResponse struct {
+	HoursBefore int                    `json:"hoursBefore"` // 0 if invitees are not reminded
+	EndsAt      *time.Time             `json:"endsAt,omitempty"`
+	Invited     int                    `json:"invited"`
+	Responded   int                    `json:"responded"`
+	Reminded    int                    `json:"reminded"`
+	Invitations []*reminder.Invitation `json:"invitations,omitempty"` // Not listed for anonymous surveys
+}
+
+// SaveBankQuestionRequest represents the request body for saving a question to the caller's bank
+type SaveBankQuestionRequest struct {
+	Question models.Question `json:"question"`
+}
+
+// ListBankQuestionsResponse lists the caller's saved questions, most recently saved first
+type ListBankQuestionsResponse struct {
+	Questions []*questionbank.Question `json:"questions"`
+}
+
+// NotificationPreferencesRequest opts the caller into milestone notifications
+type NotificationPreferencesRequest struct {
+	Channel    notify.Channel     `json:"channel"`    // "post" or "dm"
+	Milestones []notify.Milestone `json:"milestones"` // first_response, responses_100, closed
+}
+
+// NotificationsResponse is the caller's notification preferences and latest
+// notifications, newest first
+type NotificationsResponse struct {
+	Preferences    *notify.Preferences    `json:"preferences"` // null if not opted in
+	Notifications  []*notify.Notification `json:"notifications"`
+	DirectMessages bool                   `json:"directMessages"` // Whether the dm channel is available
 }
 
 // ToSurveyResponse converts a models.Survey to a SurveyResponse
@@ -74,6 +614,7 @@ func ToSurveyResponse(s *models.Survey, includeDefinition bool) *SurveyResponse
 		Slug:        s.Slug,
 		Title:       s.Title,
 		Description: s.Description,
+		Version:     s.Version,
 		StartsAt:    s.StartsAt,
 		EndsAt:      s.EndsAt,
 		CreatedAt:   s.CreatedAt,
@@ -91,6 +632,7 @@ func ToSurveyResponse(s *models.Survey, includeDefinition bool) *SurveyResponse
 func ToSurveyListResponse(s *models.Survey) *SurveyListResponse {
 	return &SurveyListResponse{
 		ID:          s.ID,
+		URI:         s.URI,
 		Slug:        s.Slug,
 		Title:       s.Title,
 		Description: s.Description,
@@ -113,5 +655,5 @@ type GenerateSurveyResponse struct {
 	Definition   *models.SurveyDefinition `json:"definition"`
 	TokensUsed   int                      `json:"tokens_used"`
 	Cost         float64                  `json:"cost"`
-	NeedsCaptcha bool                     `json:"needs_captcha,omitempty"`
+	NeedsCaptcha bool                     `json:"needs_captcha,omitempty"` // The next generation requires a CAPTCHA token
 }
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/dedup"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+)
+
+// SetDedup enables the duplicate signals of guest votes: voter cookies signed
+// by voters, which block a second vote from a browser, and fingerprints if
+// voters has them enabled. Authors get a report of suspected duplicates.
+func (h *Handlers) SetDedup(store dedup.Store, voters *dedup.Voters) {
+	h.dedup = store
+	h.voters = voters
+}
+
+// guestSignals returns the duplicate signals of a guest vote, issuing a voter
+// cookie if the browser has none, or nil if dedup is disabled. It returns
+// errAlreadyVoted if the browser's cookie has already voted.
+func (h *Handlers) guestSignals(c echo.Context, survey *models.Survey) (*dedup.Signals, error) {
+	if h.dedup == nil {
+		return nil, nil
+	}
+
+	signals := &dedup.Signals{
+		SurveyID:    survey.ID,
+		Fingerprint: h.voters.Fingerprint(survey.ID, c.Request().Header),
+	}
+	if cookie, err := c.Cookie(dedup.CookieName); err == nil {
+		if voterID, ok := h.voters.Verify(cookie.Value); ok {
+			signals.VoterToken = dedup.Token(survey.ID, voterID)
+			voted, err := h.dedup.HasVoterToken(c.Request().Context(), survey.ID, signals.VoterToken)
+			if err != nil {
+				return nil, err
+			}
+			if voted {
+				return nil, errAlreadyVoted
+			}
+			return signals, nil
+		}
+	}
+
+	voterID, value, err := h.voters.New()
+	if err != nil {
+		return nil, err
+	}
+	c.SetCookie(oauth.AppCookie(dedup.CookieName, value, int(dedup.CookieMaxAge.Seconds())))
+	signals.VoterToken = dedup.Token(survey.ID, voterID)
+	return signals, nil
+}
+
+// errAlreadyVoted is returned by guestSignals when the browser already voted
+var errAlreadyVoted = errors.New("already voted from this browser")
+
+// saveSignals keeps the duplicate signals of a saved guest response. A vote
+// is not failed for them, so errors are only logged.
+func (h *Handlers) saveSignals(c echo.Context, signals *dedup.Signals, response *models.Response) {
+	if signals == nil {
+		return
+	}
+	signals.ResponseID, signals.CreatedAt = response.ID, response.CreatedAt
+	if err := h.dedup.SaveResponseSignals(c.Request().Context(), signals); err != nil {
+		c.Logger().Errorf("Failed to save duplicate signals of response %s: %v", response.ID, err)
+	}
+}
+
+// ListDuplicates handles GET /api/v1/surveys/:slug/duplicates
+// Lists the groups of guest responses sharing a voter cookie or a fingerprint,
+// for the survey author to review
+func (h *Handlers) ListDuplicates(c echo.Context) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	signals, err := h.dedup.ListResponseSignals(c.Request().Context(), survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list suspected duplicates", err)
+	}
+
+	response := DuplicatesResponse{Groups: dedup.Groups(signals)}
+	if response.Groups == nil {
+		response.Groups = []dedup.Group{}
+	}
+	for _, s := range signals {
+		if s.Excluded {
+			response.Excluded++
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// ExcludeResponses handles POST /api/v1/surveys/:slug/duplicates/exclude
+// Leaves guest responses out of the survey's results
+func (h *Handlers) ExcludeResponses(c echo.Context) error {
+	return h.setResponsesExcluded(c, true)
+}
+
+// IncludeResponses handles POST /api/v1/surveys/:slug/duplicates/include
+// Counts excluded responses in the survey's results again
+func (h *Handlers) IncludeResponses(c echo.Context) error {
+	return h.setResponsesExcluded(c, false)
+}
+
+// setResponsesExcluded excludes the responses of the request from results,
+// or includes them again
+func (h *Handlers) setResponsesExcluded(c echo.Context, excluded bool) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	var req ExcludeResponsesRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+	if len(req.ResponseIDs) == 0 {
+		return ValidationError(c, "Invalid request", "responseIds must list at least one response")
+	}
+
+	changed, err := h.dedup.SetResponsesExcluded(c.Request().Context(), survey.ID, req.ResponseIDs, excluded)
+	if err != nil {
+		return InternalServerError(c, "Failed to update responses", err)
+	}
+	if changed > 0 {
+		h.cache.Invalidate(c.Request().Context(), cache.ResultsKey(survey.ID))
+	}
+
+	return c.JSON(http.StatusOK, ExcludeResponsesResponse{Changed: changed})
+}
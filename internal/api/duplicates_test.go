@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/dedup"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockDedupStore keeps response signals in memory
+type mockDedupStore struct {
+	signals []*dedup.Signals
+}
+
+func (m *mockDedupStore) SaveResponseSignals(ctx context.Context, s *dedup.Signals) error {
+	m.signals = append(m.signals, s)
+	return nil
+}
+
+func (m *mockDedupStore) HasVoterToken(ctx context.Context, surveyID uuid.UUID, token string) (bool, error) {
+	for _, s := range m.signals {
+		if s.SurveyID == surveyID && s.VoterToken == token {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockDedupStore) ListResponseSignals(ctx context.Context, surveyID uuid.UUID) ([]*dedup.Signals, error) {
+	var signals []*dedup.Signals
+	for _, s := range m.signals {
+		if s.SurveyID == surveyID {
+			signals = append(signals, s)
+		}
+	}
+	return signals, nil
+}
+
+func (m *mockDedupStore) SetResponsesExcluded(ctx context.Context, surveyID uuid.UUID, responseIDs []uuid.UUID, excluded bool) (int64, error) {
+	var changed int64
+	for _, s := range m.signals {
+		for _, id := range responseIDs {
+			if s.SurveyID == surveyID && s.ResponseID == id && s.Excluded != excluded {
+				s.Excluded = excluded
+				changed++
+			}
+		}
+	}
+	return changed, nil
+}
+
+func TestSubmitResponse_VoterCookie(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockDedupStore{}
+	h.SetDedup(store, dedup.NewVoters(dedup.Config{Secret: "secret", Fingerprint: true}))
+	createTextSurvey(mq, "feedback", nil)
+
+	vote := func(ip string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(SubmitResponseRequest{Answers: map[string]models.Answer{"q1": {Text: "Great"}}})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/feedback/responses", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("User-Agent", "Mozilla/5.0")
+		req.RemoteAddr = ip + ":12345"
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("feedback")
+		require.NoError(t, h.SubmitResponse(c))
+		return rec
+	}
+
+	rec := vote("192.168.1.1", nil)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, dedup.CookieName, cookies[0].Name)
+	require.Len(t, store.signals, 1)
+	assert.NotEmpty(t, store.signals[0].VoterToken)
+	assert.NotEmpty(t, store.signals[0].Fingerprint)
+
+	// The same browser on another network has already voted
+	rec = vote("10.0.0.1", cookies[0])
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Len(t, store.signals, 1)
+
+	// Without the cookie the vote counts, but shares the fingerprint
+	rec = vote("10.0.0.2", nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Len(t, store.signals, 2)
+	assert.Equal(t, store.signals[0].Fingerprint, store.signals[1].Fingerprint)
+	assert.NotEqual(t, store.signals[0].VoterToken, store.signals[1].VoterToken)
+}
+
+func TestDuplicatesReport(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockDedupStore{}
+	h.SetDedup(store, dedup.NewVoters(dedup.Config{Secret: "secret"}))
+	author := "did:plc:author"
+	survey := createTextSurvey(mq, "feedback", &author)
+	first, second, other := uuid.New(), uuid.New(), uuid.New()
+	store.signals = []*dedup.Signals{
+		{ResponseID: first, SurveyID: survey.ID, Fingerprint: "same"},
+		{ResponseID: second, SurveyID: survey.ID, Fingerprint: "same"},
+		{ResponseID: other, SurveyID: survey.ID, Fingerprint: "different"},
+	}
+
+	call := func(method, body string, user *oauth.User, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/surveys/feedback/duplicates", bytes.NewReader([]byte(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("feedback")
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, handler(c))
+		return rec
+	}
+
+	rec := call(http.MethodGet, "", &oauth.User{DID: "did:plc:other"}, h.ListDuplicates)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = call(http.MethodGet, "", &oauth.User{DID: author}, h.ListDuplicates)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var report DuplicatesResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	require.Len(t, report.Groups, 1)
+	assert.Equal(t, dedup.SignalFingerprint, report.Groups[0].Signal)
+	assert.Len(t, report.Groups[0].Responses, 2)
+	assert.Zero(t, report.Excluded)
+
+	rec = call(http.MethodPost, `{"responseIds": ["`+second.String()+`"]}`, &oauth.User{DID: author}, h.ExcludeResponses)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"changed": 1}`, rec.Body.String())
+	assert.True(t, store.signals[1].Excluded)
+
+	rec = call(http.MethodPost, `{"responseIds": []}`, &oauth.User{DID: author}, h.ExcludeResponses)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = call(http.MethodPost, `{"responseIds": ["`+second.String()+`"]}`, &oauth.User{DID: author}, h.IncludeResponses)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, store.signals[1].Excluded)
+}
@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
 
@@ -17,6 +16,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/openmeet-team/survey/internal/db"
 	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	testcontainers "github.com/testcontainers/testcontainers-go"
@@ -54,10 +54,7 @@ func setupTestServer(t *testing.T) (*echo.Echo, *Handlers, func()) {
 	require.NoError(t, err, "Failed to ping database")
 
 	// Run migrations
-	migrationSQL, err := os.ReadFile("../../internal/db/migrations/001_initial.up.sql")
-	require.NoError(t, err, "Failed to read migration file")
-
-	_, err = dbConn.ExecContext(ctx, string(migrationSQL))
+	_, err = db.Migrate(ctx, dbConn)
 	require.NoError(t, err, "Failed to run migrations")
 
 	// Create queries and handlers
@@ -299,10 +296,10 @@ func TestE2E_DuplicateVoteRejected(t *testing.T) {
 
 	assert.Equal(t, http.StatusConflict, rec.Code)
 
-	var errResp ErrorResponse
+	var errResp problem.Problem
 	err = json.Unmarshal(rec.Body.Bytes(), &errResp)
 	require.NoError(t, err)
-	assert.Contains(t, errResp.Error, "Already voted")
+	assert.Equal(t, problem.AlreadyVoted, errResp.Code)
 }
 
 // TestE2E_InvalidAnswersRejected tests validation of survey responses
@@ -364,11 +361,11 @@ func TestE2E_InvalidAnswersRejected(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-	var errResp ErrorResponse
+	var errResp problem.Problem
 	err = json.Unmarshal(rec.Body.Bytes(), &errResp)
 	require.NoError(t, err)
-	assert.Contains(t, errResp.Error, "Invalid answers")
-	assert.Contains(t, errResp.Details, "required")
+	assert.Equal(t, problem.InvalidAnswers, errResp.Code)
+	assert.Contains(t, errResp.Detail, "required")
 
 	// Test 2: Submit response with invalid option ID
 	submitReq2 := SubmitResponseRequest{
@@ -389,7 +386,7 @@ func TestE2E_InvalidAnswersRejected(t *testing.T) {
 
 	err = json.Unmarshal(rec.Body.Bytes(), &errResp)
 	require.NoError(t, err)
-	assert.Contains(t, errResp.Error, "Invalid answers")
+	assert.Equal(t, problem.InvalidAnswers, errResp.Code)
 }
 
 // TestE2E_SlugValidation tests slug validation and auto-generation
@@ -428,10 +425,10 @@ func TestE2E_SlugValidation(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-	var errResp ErrorResponse
+	var errResp problem.Problem
 	err = json.Unmarshal(rec.Body.Bytes(), &errResp)
 	require.NoError(t, err)
-	assert.Contains(t, errResp.Error, "Invalid slug")
+	assert.Contains(t, errResp.Detail, "Invalid slug")
 
 	// Test 2: Try to create survey with invalid slug (special chars)
 	createReq.Slug = "invalid@slug#123"
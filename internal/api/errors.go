@@ -2,10 +2,12 @@ package api
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -19,6 +21,35 @@ func getTraceID(ctx context.Context) string {
 	return span.SpanContext().TraceID().String()
 }
 
+// WriteProblem writes a problem as application/problem+json, filling in its
+// type URI, the request path, and the trace ID
+func WriteProblem(c echo.Context, p *problem.Problem) error {
+	if p.Type == "" {
+		p.Type = templates.AbsoluteURL("/problems/" + string(p.Code))
+	}
+	if p.Instance == "" {
+		p.Instance = c.Request().URL.Path
+	}
+	if p.TraceID == "" {
+		p.TraceID = getTraceID(c.Request().Context())
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, problem.ContentType)
+	return c.JSON(p.Status, p)
+}
+
+// Problem responds with a problem of a code and a detail specific to this
+// request (may be empty)
+//
+// Example:
+//
+//	if survey == nil {
+//	    return Problem(c, problem.SurveyNotFound, "No survey found with slug 'lunch'")
+//	}
+func Problem(c echo.Context, code problem.Code, detail string) error {
+	return WriteProblem(c, problem.New(code, detail))
+}
+
 // InternalServerError returns a sanitized 500 error response to the client
 // and logs the full error details server-side with the trace ID for debugging
 //
@@ -33,7 +64,7 @@ func getTraceID(ctx context.Context) string {
 //	    return InternalServerError(c, "Failed to retrieve surveys", err)
 //	}
 //
-// Client sees: {"error": "Failed to retrieve surveys", "details": "Reference: abc123..."}
+// Client sees: {"code": "internal_error", "detail": "Failed to retrieve surveys", "traceId": "abc123...", ...}
 // Server logs: [abc123...] Failed to retrieve surveys: pq: connection refused
 func InternalServerError(c echo.Context, userMessage string, err error) error {
 	traceID := getTraceID(c.Request().Context())
@@ -45,17 +76,8 @@ func InternalServerError(c echo.Context, userMessage string, err error) error {
 		c.Logger().Errorf("%s: %v", userMessage, err)
 	}
 
-	// Build sanitized response for client
-	response := ErrorResponse{
-		Error: userMessage,
-	}
-
-	// Include trace ID if available (safe to show - it's just a reference)
-	if traceID != "" {
-		response.Details = fmt.Sprintf("Reference: %s", traceID)
-	}
-
-	return c.JSON(http.StatusInternalServerError, response)
+	// The trace ID is safe to show - it's just a reference
+	return Problem(c, problem.InternalError, userMessage)
 }
 
 // ValidationError returns a 400 error response with full details
@@ -72,8 +94,61 @@ func InternalServerError(c echo.Context, userMessage string, err error) error {
 //	    return ValidationError(c, "Invalid slug", err.Error())
 //	}
 func ValidationError(c echo.Context, message string, details string) error {
-	return c.JSON(http.StatusBadRequest, ErrorResponse{
-		Error:   message,
-		Details: details,
-	})
+	if details != "" {
+		message += ": " + details
+	}
+	return Problem(c, problem.ValidationFailed, message)
+}
+
+// HTTPErrorHandler is the Echo error handler. It writes the errors returned by
+// handlers and middleware as problem details: problems as they are, Echo's
+// HTTP errors (unknown routes, body limits, ...) with the code of their
+// status, and anything else as a logged internal error.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var p *problem.Problem
+	var he *echo.HTTPError
+	switch {
+	case errors.As(err, &p):
+	case errors.As(err, &he):
+		p = problem.New(problem.ForStatus(he.Code), "")
+		p.Status = he.Code
+		if msg, ok := he.Message.(string); ok && msg != http.StatusText(he.Code) {
+			p.Detail = msg
+		}
+		if he.Code >= http.StatusInternalServerError {
+			c.Logger().Error(err)
+		}
+	default:
+		c.Logger().Errorf("[%s] %v", getTraceID(c.Request().Context()), err)
+		p = problem.New(problem.InternalError, "")
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(p.Status)
+	} else {
+		err = WriteProblem(c, p)
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// ListProblemTypes handles GET /problems
+// Documents the error codes of the API
+func (h *Handlers) ListProblemTypes(c echo.Context) error {
+	return c.JSON(http.StatusOK, problem.Definitions())
+}
+
+// GetProblemType handles GET /problems/:code
+// The type URI of every problem resolves here
+func (h *Handlers) GetProblemType(c echo.Context) error {
+	def, ok := problem.Lookup(problem.Code(c.Param("code")))
+	if !ok {
+		return Problem(c, problem.NotFound, "No error has this code")
+	}
+	return c.JSON(http.StatusOK, def)
 }
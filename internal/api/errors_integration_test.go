@@ -45,8 +45,9 @@ func TestErrorSanitization_EndToEnd(t *testing.T) {
 			mockError:  errors.New("pq: relation \"surveys\" does not exist"),
 			wantStatus: http.StatusInternalServerError,
 			wantInBody: []string{
-				`"error":"Failed to retrieve survey"`,
-				`"details":"Reference:`,
+				`"code":"internal_error"`,
+				`"detail":"Failed to retrieve survey"`,
+				`"traceId":"`,
 			},
 			wantNotInBody: []string{
 				"pq:",
@@ -167,7 +168,7 @@ func TestTraceIDInRealHandler(t *testing.T) {
 	assert.Contains(t, body, "Failed to retrieve survey")
 
 	// Should have trace ID reference
-	assert.Contains(t, body, "Reference:")
+	assert.Contains(t, body, `"traceId":"`)
 
 	// Should NOT leak internal details
 	assert.NotContains(t, body, "pq:")
@@ -2,12 +2,15 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/problem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel"
@@ -118,8 +121,8 @@ func TestInternalServerError(t *testing.T) {
 				return c
 			},
 			wantStatus:    http.StatusInternalServerError,
-			wantInBody:    []string{`"error":"Failed to create survey"`, `"details":"Reference:`},
-			wantNotInBody: []string{"pq:", "relation", "surveys", "does not exist"},
+			wantInBody:    []string{`"detail":"Failed to create survey"`, `"traceId":"`},
+			wantNotInBody: []string{"pq:", "relation", `\"surveys\"`, "does not exist"},
 		},
 		{
 			name:        "sanitizes file path error",
@@ -138,7 +141,7 @@ func TestInternalServerError(t *testing.T) {
 				return c
 			},
 			wantStatus:    http.StatusInternalServerError,
-			wantInBody:    []string{`"error":"Failed to retrieve survey"`, `"details":"Reference:`},
+			wantInBody:    []string{`"detail":"Failed to retrieve survey"`, `"traceId":"`},
 			wantNotInBody: []string{"/var/lib/app", "config.json", "permission denied"},
 		},
 		{
@@ -152,7 +155,7 @@ func TestInternalServerError(t *testing.T) {
 				return e.NewContext(req, rec)
 			},
 			wantStatus:    http.StatusInternalServerError,
-			wantInBody:    []string{`"error":"Failed to retrieve results"`},
+			wantInBody:    []string{`"detail":"Failed to retrieve results"`},
 			wantNotInBody: []string{"internal error"},
 		},
 		{
@@ -165,7 +168,7 @@ func TestInternalServerError(t *testing.T) {
 				return e.NewContext(req, rec)
 			},
 			wantStatus:    http.StatusInternalServerError,
-			wantInBody:    []string{`"error":"Failed to check slug availability"`},
+			wantInBody:    []string{`"detail":"Failed to check slug availability"`},
 			wantNotInBody: []string{"sql:", "connection refused"},
 		},
 	}
@@ -262,3 +265,80 @@ func TestValidationError(t *testing.T) {
 	assert.Contains(t, body, "Invalid survey definition")
 	assert.Contains(t, body, "slug must be 3-50 alphanumeric characters")
 }
+
+// TestHTTPErrorHandler tests that errors returned to Echo become problem details
+func TestHTTPErrorHandler(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.GET("/problem", func(c echo.Context) error {
+		return problem.New(problem.SurveyClosed, "Voting has closed")
+	})
+	e.GET("/failure", func(c echo.Context) error {
+		return errors.New("pq: connection refused")
+	})
+	e.POST("/limited", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, NewBodyLimitMiddleware("1B"))
+
+	serve := func(method, path, body string) (*httptest.ResponseRecorder, problem.Problem) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var p problem.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		assert.Equal(t, problem.ContentType, rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, rec.Code, p.Status)
+		assert.Equal(t, path, p.Instance)
+		assert.Equal(t, "/problems/"+string(p.Code), p.Type)
+		return rec, p
+	}
+
+	rec, p := serve(http.MethodGet, "/problem", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, problem.SurveyClosed, p.Code)
+	assert.Equal(t, "Voting has closed", p.Detail)
+
+	rec, p = serve(http.MethodGet, "/failure", "")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, problem.InternalError, p.Code)
+	assert.NotContains(t, rec.Body.String(), "pq:")
+
+	rec, p = serve(http.MethodGet, "/missing", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, problem.NotFound, p.Code)
+
+	rec, p = serve(http.MethodPost, "/problem", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, problem.MethodNotAllowed, p.Code)
+
+	rec, p = serve(http.MethodPost, "/limited", "too large")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, problem.PayloadTooLarge, p.Code)
+}
+
+// TestProblemTypes tests the documentation of error codes
+func TestProblemTypes(t *testing.T) {
+	e := echo.New()
+	h := &Handlers{}
+
+	req := httptest.NewRequest(http.MethodGet, "/problems/slug_taken", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("code")
+	c.SetParamValues("slug_taken")
+	require.NoError(t, h.GetProblemType(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var def problem.Definition
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &def))
+	assert.Equal(t, problem.SlugTaken, def.Code)
+	assert.Equal(t, http.StatusConflict, def.Status)
+
+	req = httptest.NewRequest(http.MethodGet, "/problems/nope", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("code")
+	c.SetParamValues("nope")
+	require.NoError(t, h.GetProblemType(c))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/identity"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// surveyETag identifies a survey's content by its update time, record CID,
+// and definition version. The resolved author is included because GetSurvey
+// returns the author's profile alongside the survey.
+func surveyETag(survey *models.Survey, author *identity.Identity) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%d|%d", survey.ID, survey.Version, survey.UpdatedAt.UnixNano())
+	if survey.CID != nil {
+		fmt.Fprintf(h, "|%s", *survey.CID)
+	}
+	if author != nil {
+		fmt.Fprintf(h, "|%s|%s|%s", author.Handle, author.DisplayName, author.Avatar)
+	}
+	return etag(h.Sum(nil))
+}
+
+// resultsETag identifies a survey's results. Results have no stored version, so
+// the aggregated counts are hashed along with the survey they are rendered for
+// and any other inputs of the representation (e.g. the locale of rendered HTML).
+func resultsETag(survey *models.Survey, results *models.SurveyResults, extra ...string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%d|%d", survey.ID, survey.Version, survey.UpdatedAt.UnixNano())
+	// Map keys are sorted by encoding/json, so equal results hash equally
+	if err := json.NewEncoder(h).Encode(results); err != nil {
+		return ""
+	}
+	for _, e := range extra {
+		fmt.Fprintf(h, "|%s", e)
+	}
+	return etag(h.Sum(nil))
+}
+
+// etag formats a hash as a weak ETag. Weak because representations differing
+// only in encoding (e.g. JSON whitespace or compression) are interchangeable.
+func etag(sum []byte) string {
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// checkNotModified sets the ETag header and reports whether the request's
+// If-None-Match matches it, in which case the caller responds with 304.
+// Responses are marked no-cache so clients revalidate on every poll.
+func checkNotModified(c echo.Context, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	header := c.Response().Header()
+	header.Set("ETag", tag)
+	header.Set("Cache-Control", "no-cache")
+
+	return etagMatches(c.Request().Header.Get("If-None-Match"), tag)
+}
+
+// etagMatches compares an If-None-Match header against an ETag using the weak
+// comparison required for If-None-Match (RFC 9110 section 13.1.2)
+func etagMatches(ifNoneMatch, tag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	want := strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified responds with 304 Not Modified
+func notModified(c echo.Context) error {
+	return c.NoContent(http.StatusNotModified)
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tag := `W/"abc123"`
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"no header", "", false},
+		{"exact", `W/"abc123"`, true},
+		{"strong form", `"abc123"`, true},
+		{"wildcard", "*", true},
+		{"in list", `"other", W/"abc123"`, true},
+		{"different", `W/"def456"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, etagMatches(tt.ifNoneMatch, tag))
+		})
+	}
+}
+
+func TestResultsETag_ChangesWithResults(t *testing.T) {
+	survey := &models.Survey{ID: uuid.New(), UpdatedAt: time.Now()}
+	results := &models.SurveyResults{
+		SurveyID:   survey.ID,
+		TotalVotes: 1,
+		QuestionResults: map[string]*models.QuestionResult{
+			"q1": {QuestionID: "q1", OptionCounts: map[string]int{"a": 1}},
+		},
+	}
+
+	before := resultsETag(survey, results)
+	assert.Equal(t, before, resultsETag(survey, results), "ETag must be stable")
+	assert.NotEqual(t, before, resultsETag(survey, results, "ar"), "locale is part of the representation")
+
+	results.TotalVotes = 2
+	results.QuestionResults["q1"].OptionCounts["a"] = 2
+	assert.NotEqual(t, before, resultsETag(survey, results))
+}
+
+func TestGetSurvey_NotModified(t *testing.T) {
+	e, mq, h := setupTest()
+
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		Slug:      "etag-survey",
+		Title:     "ETag Survey",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	mq.CreateSurvey(context.Background(), survey)
+
+	getSurvey := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/surveys/etag-survey", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("etag-survey")
+		require.NoError(t, h.GetSurvey(c))
+		return rec
+	}
+
+	rec := getSurvey("")
+	require.Equal(t, http.StatusOK, rec.Code)
+	tag := rec.Header().Get("ETag")
+	require.NotEmpty(t, tag)
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+
+	rec = getSurvey(tag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, tag, rec.Header().Get("ETag"))
+
+	// Editing the survey changes its ETag
+	survey.UpdatedAt = survey.UpdatedAt.Add(time.Second)
+	rec = getSurvey(tag)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, tag, rec.Header().Get("ETag"))
+}
+
+func TestGetResults_NotModified(t *testing.T) {
+	e, mq, h := setupTest()
+
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		Slug:      "etag-results",
+		Title:     "ETag Results",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	mq.CreateSurvey(context.Background(), survey)
+
+	getResults := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/surveys/etag-results/results", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("etag-results")
+		require.NoError(t, h.GetResults(c))
+		return rec
+	}
+
+	rec := getResults("")
+	require.Equal(t, http.StatusOK, rec.Code)
+	tag := rec.Header().Get("ETag")
+	require.NotEmpty(t, tag)
+
+	rec = getResults(tag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = getResults(`W/"stale"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, tag, rec.Header().Get("ETag"))
+}
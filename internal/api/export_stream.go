@@ -0,0 +1,105 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+)
+
+// streamBatchSize is how many responses a streamed export reads from the
+// database and writes to the client at a time
+const streamBatchSize = 500
+
+// streamWriteTimeout is how long the client of a streamed export has to read
+// each batch. Streams outlast the server's write timeout, so it is extended per batch.
+const streamWriteTimeout = time.Minute
+
+// StreamResponses streams the responses of a survey as newline-delimited JSON,
+// one export record per line, for surveys too large to export at once.
+// Responses are read in batches through a database cursor and flushed as they
+// are written, so memory use doesn't grow with the survey. It takes the
+// filters of the file exports (see parseExportFilter).
+// GET /api/v1/surveys/:slug/responses/export?format=ndjson&from=&to=&voter=&questions=
+func (h *Handlers) StreamResponses(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.SurveyNotFound, fmt.Sprintf("No survey found with slug '%s'", slug))
+		}
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+
+	caller, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key of the survey author")
+	}
+	if !h.canReadSurveyAs(ctx, caller, survey) {
+		return Problem(c, problem.Forbidden, "Only the survey author can export responses")
+	}
+
+	if format := c.QueryParam("format"); format != "ndjson" {
+		return Problem(c, problem.ValidationFailed, "Format must be 'ndjson'")
+	}
+
+	ordinals, err := h.queries.ListQuestionOrdinals(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to load responses", err)
+	}
+	filter, err := parseExportFilter(c.QueryParams(), survey, ordinals)
+	if err != nil {
+		return Problem(c, problem.ValidationFailed, err.Error())
+	}
+	if err := h.restoreArchive(ctx, survey); err != nil {
+		return InternalServerError(c, "Failed to load responses", err)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", survey.Slug+"-responses.ndjson"))
+	controller := http.NewResponseController(res)
+	enc := json.NewEncoder(res)
+	selected := selectedQuestions(filter.QuestionIDs)
+	current := currentExportQuestions(survey, selected)
+
+	err = h.queries.ListResponsesBySurveyStream(ctx, survey.ID, filter, streamBatchSize, func(batch []*models.Response) error {
+		// Unsupported by some writers, such as test recorders
+		_ = controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if !res.Committed {
+			res.WriteHeader(http.StatusOK)
+		}
+		for _, r := range batch {
+			// Questions removed from the definition follow, as in the file exports
+			questions := slices.Concat(current, removedExportQuestions(survey, []*models.Response{r}, selected))
+			if err := enc.Encode(buildExportRecord(survey, r, questions, ordinals)); err != nil {
+				return err
+			}
+		}
+		res.Flush()
+		return nil
+	})
+	if err != nil {
+		if res.Committed {
+			// The stream is cut short; all that's left is to log why
+			c.Logger().Errorf("Failed to stream responses of %s: %v", survey.Slug, err)
+			return nil
+		}
+		return InternalServerError(c, "Failed to load responses", err)
+	}
+
+	if !res.Committed {
+		// No responses: an empty stream
+		return c.NoContent(http.StatusOK)
+	}
+	return nil
+}
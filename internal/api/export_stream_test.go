@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeStream decodes the export records of an NDJSON stream
+func decodeStream(t *testing.T, body string) []ExportRecord {
+	t.Helper()
+	var records []ExportRecord
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		var record ExportRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record), scanner.Text())
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestStreamResponses(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+
+	c, rec := newResponsesContext(e, "/api/v1/surveys/export-survey/responses/export", survey.Slug, url.Values{"format": {"ndjson"}}, &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.StreamResponses(c))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), `filename="export-survey-responses.ndjson"`)
+
+	records := decodeStream(t, rec.Body.String())
+	require.Len(t, records, 2)
+	first := records[0]
+	assert.Equal(t, "did:plc:voter", *first.VoterDID)
+	assert.Equal(t, 1, first.SurveyVersion)
+	require.Len(t, first.Answers, 3)
+	assert.Equal(t, ExportAnswer{QuestionID: "color", Ordinal: 1, VersionOrdinal: 2, SelectedOptions: []string{"blue"}}, first.Answers[0])
+	assert.Equal(t, ExportAnswer{QuestionID: "why", Ordinal: 2, VersionOrdinal: 1, Text: "Calm"}, first.Answers[1])
+	assert.Equal(t, ExportAnswer{QuestionID: "old", Text: "gone"}, first.Answers[2], "removed questions come last")
+	assert.Equal(t, "anonymous", records[1].VoterType)
+}
+
+func TestStreamResponses_Batches(t *testing.T) {
+	e, mq, h, survey := setupExportTest(t)
+	for i := range streamBatchSize {
+		require.NoError(t, mq.CreateResponse(context.Background(), &models.Response{
+			ID:        uuid.New(),
+			SurveyID:  survey.ID,
+			Answers:   map[string]models.Answer{"color": {SelectedOptions: []string{"red"}}},
+			CreatedAt: time.Now().Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	c, rec := newResponsesContext(e, "/api/v1/surveys/export-survey/responses/export", survey.Slug, url.Values{"format": {"ndjson"}, "voter": {"anonymous"}}, &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.StreamResponses(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, decodeStream(t, rec.Body.String()), streamBatchSize+1)
+}
+
+func TestStreamResponses_Errors(t *testing.T) {
+	author := &oauth.User{DID: "did:plc:author"}
+	tests := []struct {
+		name  string
+		user  *oauth.User
+		query url.Values
+		want  int
+	}{
+		{"not logged in", nil, url.Values{"format": {"ndjson"}}, http.StatusUnauthorized},
+		{"not the author", &oauth.User{DID: "did:plc:someone"}, url.Values{"format": {"ndjson"}}, http.StatusForbidden},
+		{"no format", author, url.Values{}, http.StatusBadRequest},
+		{"other format", author, url.Values{"format": {"csv"}}, http.StatusBadRequest},
+		{"invalid filter", author, url.Values{"format": {"ndjson"}, "voter": {"robot"}}, http.StatusBadRequest},
+		{"no matching responses", author, url.Values{"format": {"ndjson"}, "from": {"2999-01-01"}}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _, h, survey := setupExportTest(t)
+			c, rec := newResponsesContext(e, "/api/v1/surveys/export-survey/responses/export", survey.Slug, tt.query, tt.user)
+			require.NoError(t, h.StreamResponses(c))
+			assert.Equal(t, tt.want, rec.Code, rec.Body.String())
+			if tt.want == http.StatusOK {
+				assert.Empty(t, rec.Body.String())
+			}
+		})
+	}
+}
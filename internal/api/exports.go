@@ -0,0 +1,353 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+)
+
+// ExportResponses downloads the responses of a survey as CSV or JSON.
+// Answers are ordered by question position and carry both the question ID and
+// ordinal, so exports line up with the survey as shown even after reordering.
+// Responses can be filtered by date range, voter type, and question (see parseExportFilter).
+// GET /surveys/:slug/export?format=csv|json&from=&to=&voter=&questions=
+func (h *Handlers) ExportResponses(c echo.Context) error {
+	survey, err := h.surveyBySlug(c.Request().Context(), c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canReadSurvey(c.Request().Context(), user, survey) {
+		return c.String(http.StatusForbidden, "Only the survey author can export responses")
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return c.String(http.StatusBadRequest, "Format must be 'csv' or 'json'")
+	}
+
+	ordinals, err := h.queries.ListQuestionOrdinals(c.Request().Context(), survey.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to list question ordinals for export: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load responses")
+	}
+
+	filter, err := parseExportFilter(c.QueryParams(), survey, ordinals)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.restoreArchive(c.Request().Context(), survey); err != nil {
+		c.Logger().Errorf("Failed to restore archived responses for export: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load responses")
+	}
+
+	responses, err := h.queries.ListFilteredResponses(c.Request().Context(), survey.ID, filter)
+	if err != nil {
+		c.Logger().Errorf("Failed to list responses for export: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load responses")
+	}
+
+	export := buildExport(survey, responses, ordinals, filter.QuestionIDs)
+	filename := survey.Slug + "-responses." + format
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		return c.JSON(http.StatusOK, export)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	return writeExportCSV(c.Response().Writer, export, !survey.Definition.Anonymous)
+}
+
+// parseExportFilter parses the export query filters:
+//   - from, to: submission time bounds, as YYYY-MM-DD or RFC 3339. "from" is
+//     inclusive; "to" is inclusive for dates (the whole day) and exclusive for times
+//   - voter: "did" or "anonymous"
+//   - questions: comma-separated question IDs from any version of the survey
+func parseExportFilter(query url.Values, survey *models.Survey, ordinals map[int]map[string]int) (models.ResponseFilter, error) {
+	var filter models.ResponseFilter
+
+	if from := query.Get("from"); from != "" {
+		t, _, err := parseExportTime(from)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid 'from': %v", err)
+		}
+		filter.From = t
+	}
+	if to := query.Get("to"); to != "" {
+		t, isDate, err := parseExportTime(to)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid 'to': %v", err)
+		}
+		if isDate {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.To = t
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("'from' must be before 'to'")
+	}
+
+	switch voter := query.Get("voter"); voter {
+	case "", models.VoterTypeDID, models.VoterTypeAnonymous:
+		filter.VoterType = voter
+	default:
+		return filter, fmt.Errorf("Voter must be '%s' or '%s'", models.VoterTypeDID, models.VoterTypeAnonymous)
+	}
+
+	if questions := query.Get("questions"); questions != "" {
+		known := survey.Definition.QuestionOrdinals()
+		for _, versionOrdinals := range ordinals {
+			for questionID, ordinal := range versionOrdinals {
+				known[questionID] = ordinal
+			}
+		}
+		for _, questionID := range strings.Split(questions, ",") {
+			questionID = strings.TrimSpace(questionID)
+			if questionID == "" {
+				continue
+			}
+			if _, ok := known[questionID]; !ok {
+				return filter, fmt.Errorf("Unknown question '%s'", questionID)
+			}
+			filter.QuestionIDs = append(filter.QuestionIDs, questionID)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseExportTime parses a YYYY-MM-DD date (UTC) or an RFC 3339 time, and
+// reports whether it was a date
+func parseExportTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected YYYY-MM-DD or RFC 3339 time")
+	}
+	return t, false, nil
+}
+
+// buildExport assembles the export of a survey's responses. Questions follow the
+// current definition order; questions only found in older answers come last.
+// If questionIDs is not empty, only those questions are exported.
+// Responses recorded before versions were tracked are treated as version 1.
+func buildExport(survey *models.Survey, responses []*models.Response, ordinals map[int]map[string]int, questionIDs []string) *ExportResponse {
+	selected := selectedQuestions(questionIDs)
+	export := &ExportResponse{
+		SurveyID:  survey.ID,
+		Slug:      survey.Slug,
+		Version:   survey.Version,
+		Questions: currentExportQuestions(survey, selected),
+		Responses: make([]ExportRecord, 0, len(responses)),
+	}
+	export.Questions = append(export.Questions, removedExportQuestions(survey, responses, selected)...)
+
+	for _, r := range responses {
+		export.Responses = append(export.Responses, buildExportRecord(survey, r, export.Questions, ordinals))
+	}
+
+	return export
+}
+
+// selectedQuestions returns the set of the given question IDs
+func selectedQuestions(questionIDs []string) map[string]bool {
+	selected := make(map[string]bool, len(questionIDs))
+	for _, questionID := range questionIDs {
+		selected[questionID] = true
+	}
+	return selected
+}
+
+// currentExportQuestions returns the questions of the current definition in
+// order, only the selected ones if any are
+func currentExportQuestions(survey *models.Survey, selected map[string]bool) []ExportQuestion {
+	questions := make([]ExportQuestion, 0, len(survey.Definition.Questions))
+	for i, q := range survey.Definition.Questions {
+		if len(selected) > 0 && !selected[q.ID] {
+			continue
+		}
+		question := ExportQuestion{
+			QuestionID: q.ID,
+			Ordinal:    i + 1,
+			Text:       q.Text,
+		}
+		if q.Type == models.QuestionTypeMatrix {
+			for _, row := range q.Rows {
+				question.Rows = append(question.Rows, row.ID)
+			}
+		}
+		questions = append(questions, question)
+	}
+	return questions
+}
+
+// removedExportQuestions returns the questions answered in the responses that
+// are no longer in the definition, sorted by ID
+func removedExportQuestions(survey *models.Survey, responses []*models.Response, selected map[string]bool) []ExportQuestion {
+	current := survey.Definition.QuestionOrdinals()
+	var removed []string
+	seen := make(map[string]bool)
+	for _, r := range responses {
+		for questionID := range r.Answers {
+			if _, ok := current[questionID]; !ok && !seen[questionID] && (len(selected) == 0 || selected[questionID]) {
+				seen[questionID] = true
+				removed = append(removed, questionID)
+			}
+		}
+	}
+	sort.Strings(removed)
+
+	questions := make([]ExportQuestion, 0, len(removed))
+	for _, questionID := range removed {
+		questions = append(questions, ExportQuestion{QuestionID: questionID})
+	}
+	return questions
+}
+
+// buildExportRecord converts a response to an export record with its answers
+// to the questions, in their order
+func buildExportRecord(survey *models.Survey, r *models.Response, questions []ExportQuestion, ordinals map[int]map[string]int) ExportRecord {
+	version := 1
+	if r.SurveyVersion != nil {
+		version = *r.SurveyVersion
+	}
+
+	record := ExportRecord{
+		ID:            r.ID,
+		SubmittedAt:   r.CreatedAt,
+		VoterType:     "anonymous",
+		SurveyVersion: version,
+		Answers:       make([]ExportAnswer, 0, len(r.Answers)),
+	}
+	if r.VoterDID != nil {
+		record.VoterType = "did"
+		if !survey.Definition.Anonymous {
+			record.VoterDID = r.VoterDID
+		}
+	}
+
+	for _, q := range questions {
+		answer, ok := r.Answers[q.QuestionID]
+		if !ok {
+			continue
+		}
+		record.Answers = append(record.Answers, ExportAnswer{
+			QuestionID:      q.QuestionID,
+			Ordinal:         q.Ordinal,
+			VersionOrdinal:  ordinals[version][q.QuestionID],
+			SelectedOptions: answer.SelectedOptions,
+			Text:            answer.Text,
+			Rows:            answer.Rows,
+			File:            answer.File,
+		})
+	}
+	return record
+}
+
+// writeExportCSV writes an export as CSV with one column per question, and one
+// per row of matrix questions. Question columns are headed "Q<ordinal> <question ID>",
+// and row columns "Q<ordinal> <question ID>.<row ID>", or "removed <question ID>"
+// for questions no longer in the definition. Files are given by their blob CID.
+// Selected options are joined with ";",
+// as are the "<row ID>=<option ID>" ratings of removed matrix questions.
+func writeExportCSV(w io.Writer, export *ExportResponse, includeVoterDID bool) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"response_id", "submitted_at", "voter_type"}
+	if includeVoterDID {
+		header = append(header, "voter_did")
+	}
+	header = append(header, "survey_version")
+	for _, q := range export.Questions {
+		if len(q.Rows) > 0 {
+			for _, rowID := range q.Rows {
+				header = append(header, fmt.Sprintf("Q%d %s", q.Ordinal, models.MatrixFieldName(q.QuestionID, rowID)))
+			}
+		} else if q.Ordinal > 0 {
+			header = append(header, fmt.Sprintf("Q%d %s", q.Ordinal, q.QuestionID))
+		} else {
+			header = append(header, "removed "+q.QuestionID)
+		}
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range export.Responses {
+		row := []string{r.ID.String(), r.SubmittedAt.UTC().Format(time.RFC3339), r.VoterType}
+		if includeVoterDID {
+			voterDID := ""
+			if r.VoterDID != nil {
+				voterDID = *r.VoterDID
+			}
+			row = append(row, voterDID)
+		}
+		row = append(row, strconv.Itoa(r.SurveyVersion))
+
+		answers := make(map[string]ExportAnswer, len(r.Answers))
+		for _, a := range r.Answers {
+			answers[a.QuestionID] = a
+		}
+		for _, q := range export.Questions {
+			a := answers[q.QuestionID]
+			if len(q.Rows) > 0 {
+				for _, rowID := range q.Rows {
+					row = append(row, a.Rows[rowID])
+				}
+			} else if len(a.Rows) > 0 {
+				row = append(row, joinRowAnswers(a.Rows))
+			} else if a.Text != "" {
+				row = append(row, a.Text)
+			} else if a.File != nil {
+				row = append(row, a.File.CID())
+			} else {
+				row = append(row, strings.Join(a.SelectedOptions, ";"))
+			}
+		}
+
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// joinRowAnswers joins the ratings of a matrix answer as "<row ID>=<option ID>",
+// sorted by row ID
+func joinRowAnswers(rows map[string]string) string {
+	ratings := make([]string, 0, len(rows))
+	for rowID, optionID := range rows {
+		ratings = append(ratings, rowID+"="+optionID)
+	}
+	sort.Strings(ratings)
+	return strings.Join(ratings, ";")
+}
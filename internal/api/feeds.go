@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/feed"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// SetFeeds enables the Atom feeds of new surveys
+func (h *Handlers) SetFeeds(store feed.Store) {
+	h.feeds = store
+}
+
+// SiteFeed handles GET /feeds/surveys.atom
+// Lists the newest public surveys of everyone
+func (h *Handlers) SiteFeed(c echo.Context) error {
+	return h.renderFeed(c, "", feed.Meta{
+		Title:   "New surveys - OpenMeet Survey",
+		SelfURL: templates.AbsoluteURL("/feeds/surveys.atom"),
+		PageURL: templates.AbsoluteURL("/"),
+	})
+}
+
+// AuthorFeed handles GET /feeds/authors/:did.atom
+// Lists the newest public surveys of an author
+func (h *Handlers) AuthorFeed(c echo.Context) error {
+	did, ok := strings.CutSuffix(c.Param("file"), ".atom")
+	if !ok || !strings.HasPrefix(did, "did:") {
+		return c.String(http.StatusNotFound, "Feed not found")
+	}
+
+	author := &feed.Person{Name: did}
+	if i := h.identities.ResolveOne(c.Request().Context(), did); i != nil {
+		author.Name = i.Name()
+	}
+	return h.renderFeed(c, did, feed.Meta{
+		Title:   "Surveys by " + author.Name + " - OpenMeet Survey",
+		SelfURL: templates.AbsoluteURL("/feeds/authors/" + did + ".atom"),
+		PageURL: templates.AbsoluteURL("/"),
+		Author:  author,
+	})
+}
+
+// renderFeed writes the feed of an author's surveys, or everyone's if authorDID is empty
+func (h *Handlers) renderFeed(c echo.Context, authorDID string, meta feed.Meta) error {
+	ctx := c.Request().Context()
+	surveys, err := h.feeds.ListFeedSurveys(ctx, authorDID, feed.Limit)
+	if err != nil {
+		c.Logger().Errorf("Failed to list feed surveys: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load feed")
+	}
+
+	var dids []string
+	for _, s := range surveys {
+		if s.AuthorDID != nil {
+			dids = append(dids, *s.AuthorDID)
+		}
+	}
+	names := h.identities.Resolve(ctx, dids)
+
+	body, err := feed.Marshal(feed.New(meta, surveys,
+		func(s *models.Survey) string { return templates.AbsoluteURL("/surveys/" + s.Slug) },
+		func(s *models.Survey) *feed.Person {
+			if s.AuthorDID == nil {
+				return nil
+			}
+			person := &feed.Person{Name: *s.AuthorDID, URI: templates.AbsoluteURL("/feeds/authors/" + *s.AuthorDID + ".atom")}
+			if i, ok := names[*s.AuthorDID]; ok {
+				person.Name = i.Name()
+			}
+			return person
+		},
+		time.Now(),
+	))
+	if err != nil {
+		c.Logger().Errorf("Failed to encode feed: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load feed")
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.Blob(http.StatusOK, feed.ContentType, body)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/feed"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockFeeds lists fixed surveys, recording the author asked for
+type mockFeeds struct {
+	surveys   []*models.Survey
+	authorDID string
+}
+
+func (m *mockFeeds) ListFeedSurveys(ctx context.Context, authorDID string, limit int) ([]*models.Survey, error) {
+	m.authorDID = authorDID
+	return m.surveys, nil
+}
+
+func TestSiteFeed(t *testing.T) {
+	e, _, h := setupTest()
+	alice := "did:plc:alice"
+	uri := "at://did:plc:alice/net.openmeet.survey/3k2"
+	store := &mockFeeds{surveys: []*models.Survey{
+		{ID: uuid.New(), URI: &uri, AuthorDID: &alice, Slug: "lunch", Title: "Lunch?", CreatedAt: time.Now(), UpdatedAt: time.Now()},
+	}}
+	h.SetFeeds(store)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/feeds/surveys.atom", nil), rec)
+	require.NoError(t, h.SiteFeed(c))
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, feed.ContentType, rec.Header().Get("Content-Type"))
+	assert.Empty(t, store.authorDID, "the site feed lists everyone's surveys")
+	body := rec.Body.String()
+	assert.Contains(t, body, "<title>Lunch?</title>")
+	assert.Contains(t, body, `<link href="/surveys/lunch" rel="alternate" type="text/html"></link>`)
+	assert.Contains(t, body, "<id>"+uri+"</id>")
+	assert.Contains(t, body, "<uri>/feeds/authors/did:plc:alice.atom</uri>", "entries link to their author's feed")
+}
+
+func TestAuthorFeed(t *testing.T) {
+	e, _, h := setupTest()
+	store := &mockFeeds{}
+	h.SetFeeds(store)
+
+	call := func(file string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/feeds/authors/"+file, nil), rec)
+		c.SetParamNames("file")
+		c.SetParamValues(file)
+		require.NoError(t, h.AuthorFeed(c))
+		return rec
+	}
+
+	rec := call("did:plc:alice.atom")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "did:plc:alice", store.authorDID)
+	assert.Contains(t, rec.Body.String(), "<title>Surveys by did:plc:alice - OpenMeet Survey</title>", "unresolved authors are named by their DID")
+
+	assert.Equal(t, http.StatusNotFound, call("did:plc:alice.rss").Code)
+	assert.Equal(t, http.StatusNotFound, call("alice.atom").Code)
+}
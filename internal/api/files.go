@@ -0,0 +1,197 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// fileAnswersMessage explains why answers with files were not submitted
+func fileAnswersMessage(survey *models.Survey) string {
+	if survey.URI == nil {
+		return "Files can only be uploaded to surveys published to their author's PDS"
+	}
+	return "Log in to answer with files; they are stored on your PDS"
+}
+
+// UploadFileHTML uploads a file answering a file question to the logged-in
+// voter's PDS
+// POST /surveys/:slug/files/:question (multipart form with a "<question>.file" file)
+// Renders the blob reference that the survey form submits as the answer
+func (h *Handlers) UploadFileHTML(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	survey, err := h.surveyBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			component := templates.Error("Survey not found")
+			return component.Render(ctx, c.Response().Writer)
+		}
+		component := templates.Error("Failed to load survey")
+		return component.Render(ctx, c.Response().Writer)
+	}
+	if h.surveyHidden(c, survey) {
+		component := templates.Error("Survey not found")
+		return component.Render(ctx, c.Response().Writer)
+	}
+	if !h.canViewSurvey(c, survey) {
+		component := templates.Error(privateSurveyMessage)
+		return component.Render(ctx, c.Response().Writer)
+	}
+	if survey.IsForeign() {
+		component := templates.Error("This poll was created in another app; votes must be cast there")
+		return component.Render(ctx, c.Response().Writer)
+	}
+	if survey.IsClosed(time.Now()) {
+		component := templates.Error(closedSurveyMessage)
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	questionID := c.Param("question")
+	question := surveyQuestion(survey, questionID)
+	if question.Type != models.QuestionTypeFile {
+		component := templates.Error("Question not found")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	var session *oauth.OAuthSession
+	if h.oauthStorage != nil {
+		session, _ = oauth.GetSession(c, h.oauthStorage)
+	}
+	if survey.URI == nil || session == nil || session.AccessToken == "" || session.PDSUrl == "" {
+		component := templates.Error(fileAnswersMessage(survey))
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	file, err := c.FormFile(models.FileFieldName(questionID))
+	if err != nil {
+		component := templates.Error("Choose a file to upload")
+		return component.Render(ctx, c.Response().Writer)
+	}
+	if file.Size > models.MaxFileSize {
+		component := templates.Error("Files must be at most 1 MB")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		component := templates.Error("Failed to read file")
+		return component.Render(ctx, c.Response().Writer)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, models.MaxFileSize+1))
+	if err != nil || len(data) > models.MaxFileSize {
+		component := templates.Error("Files must be at most 1 MB")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	// Trust the content, not the client's declared type
+	mimeType := http.DetectContentType(data)
+	if !models.AllowedFileType(mimeType) {
+		component := templates.Error("Files must be PDFs or PNG, JPEG, GIF, or WebP images")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	if err := h.ensureValidToken(ctx, session); err != nil {
+		c.Logger().Errorf("Failed to refresh token for file upload: %v", err)
+		component := templates.Error("Your session has expired. Please log in again.")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	raw, err := oauth.UploadBlob(ctx, session, data, mimeType)
+	recordPDSWrite("upload_blob", err)
+	if err != nil {
+		c.Logger().Errorf("Failed to upload file to PDS: %v", err)
+		component := templates.Error("Failed to upload file to your PDS")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	blob := &models.Blob{}
+	if err := json.Unmarshal(raw, blob); err != nil {
+		c.Logger().Errorf("Failed to parse uploaded blob %s: %v", raw, err)
+		component := templates.Error("Failed to upload file to your PDS")
+		return component.Render(ctx, c.Response().Writer)
+	}
+	if err := blob.ValidateFile(); err != nil {
+		component := templates.Error("Invalid file: " + err.Error())
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	component := templates.UploadedFile(questionID, blob)
+	return component.Render(ctx, c.Response().Writer)
+}
+
+// SurveyFile serves a file answering a file question from the voter's PDS, to
+// those who can read the survey's responses
+// GET /surveys/:slug/responses/:id/files/:question
+func (h *Handlers) SurveyFile(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	survey, err := h.surveyBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user, _ := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canReadSurvey(ctx, user, survey) {
+		return c.String(http.StatusForbidden, "Only the survey author can view responses")
+	}
+	if survey.Definition.Anonymous {
+		return c.String(http.StatusForbidden, "Voters of anonymous surveys are not disclosed")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusNotFound, "File not found")
+	}
+	response, err := h.queries.GetResponseByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "File not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load response")
+	}
+
+	// Only files answering the survey are proxied, so this cannot fetch arbitrary blobs
+	answer := response.Answers[c.Param("question")]
+	if response.SurveyID != survey.ID || response.VoterDID == nil || answer.File == nil {
+		return c.String(http.StatusNotFound, "File not found")
+	}
+
+	data, err := h.fetchBlob(ctx, *response.VoterDID, answer.File.CID())
+	if err != nil {
+		c.Logger().Errorf("Failed to fetch file %s of response %s: %v", answer.File.CID(), response.ID, err)
+		return c.String(http.StatusBadGateway, "Failed to fetch file from the voter's PDS")
+	}
+
+	contentType := http.DetectContentType(data)
+	if !models.AllowedFileType(contentType) {
+		return c.String(http.StatusBadGateway, "The voter's PDS returned an unsupported file")
+	}
+
+	// Blobs never change, but answers are only shown to the survey's readers
+	c.Response().Header().Set("Cache-Control", "private, max-age=3600")
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+	c.Response().Header().Set("Content-Security-Policy", "default-src 'none'")
+	if contentType == "application/pdf" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", answer.File.CID()+".pdf"))
+	}
+	return c.Blob(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pdfHeader is enough of a PDF for content sniffing
+var pdfHeader = []byte("%PDF-1.7\n")
+
+var testFile = &models.Blob{Type: "blob", Ref: models.BlobRef{Link: testImageCID}, MimeType: "application/pdf", Size: 120000}
+
+func fileSurvey(t *testing.T, mq *MockQueries) *models.Survey {
+	uri := "at://did:plc:author/net.openmeet.survey/3kfiles"
+	author := "did:plc:author"
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		Slug:      "talk-proposals",
+		URI:       &uri,
+		AuthorDID: &author,
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "title", Text: "Title of your talk", Type: models.QuestionTypeText},
+				{ID: "slides", Text: "Your slides", Type: models.QuestionTypeFile},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, mq.CreateSurvey(context.Background(), survey))
+	return survey
+}
+
+func TestFormAnswers_File(t *testing.T) {
+	def := &models.SurveyDefinition{Questions: []models.Question{{ID: "slides", Type: models.QuestionTypeFile}}}
+	reference, err := json.Marshal(testFile)
+	require.NoError(t, err)
+
+	answers := formAnswers(def, url.Values{"slides": {string(reference)}})
+	require.NotNil(t, answers["slides"].File)
+	assert.Equal(t, *testFile, *answers["slides"].File)
+
+	// A reference that does not parse fails validation instead of being dropped
+	answers = formAnswers(def, url.Values{"slides": {"not json"}})
+	require.NotNil(t, answers["slides"].File)
+	assert.Error(t, answers["slides"].File.ValidateFile())
+
+	assert.Empty(t, formAnswers(def, url.Values{}))
+}
+
+func TestSubmitResponseHTML_FileAnswersRequireLogin(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := fileSurvey(t, mq)
+	reference, err := json.Marshal(testFile)
+	require.NoError(t, err)
+
+	form := url.Values{"title": {"Go at scale"}, "slides": {string(reference)}}
+	req := httptest.NewRequest(http.MethodPost, "/surveys/"+survey.Slug+"/responses", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(survey.Slug)
+
+	require.NoError(t, h.SubmitResponseHTML(c))
+	assert.Contains(t, rec.Body.String(), "Log in to answer with files")
+	assert.Empty(t, mq.Responses, "guests cannot keep files on a PDS")
+}
+
+func TestSubmitResponse_RejectsFileAnswers(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := fileSurvey(t, mq)
+
+	body, _ := json.Marshal(SubmitResponseRequest{Answers: map[string]models.Answer{"slides": {File: testFile}}})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/"+survey.Slug+"/responses", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(survey.Slug)
+
+	require.NoError(t, h.SubmitResponse(c))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, problem.InvalidAnswers, p.Code)
+	assert.Empty(t, mq.Responses)
+}
+
+func TestUploadFileHTML_RequiresLogin(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := fileSurvey(t, mq)
+
+	upload := func(questionID string) string {
+		req := httptest.NewRequest(http.MethodPost, "/surveys/"+survey.Slug+"/files/"+questionID, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug", "question")
+		c.SetParamValues(survey.Slug, questionID)
+		require.NoError(t, h.UploadFileHTML(c))
+		return rec.Body.String()
+	}
+
+	assert.Contains(t, upload("slides"), "Log in to answer with files")
+	assert.Contains(t, upload("title"), "Question not found", "only file questions take uploads")
+}
+
+func TestSurveyFile(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := fileSurvey(t, mq)
+	voter := "did:plc:speaker"
+	response := &models.Response{
+		ID:        uuid.New(),
+		SurveyID:  survey.ID,
+		VoterDID:  &voter,
+		Answers:   map[string]models.Answer{"title": {Text: "Go at scale"}, "slides": {File: testFile}},
+		CreatedAt: time.Now(),
+	}
+	mq.Responses[response.ID] = response
+
+	var fetched []string
+	h.fetchBlob = func(ctx context.Context, did, cid string) ([]byte, error) {
+		fetched = append(fetched, did+" "+cid)
+		return pdfHeader, nil
+	}
+
+	serve := func(responseID, questionID string, user *oauth.User) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/surveys/"+survey.Slug+"/responses/"+responseID+"/files/"+questionID, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug", "id", "question")
+		c.SetParamValues(survey.Slug, responseID, questionID)
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, h.SurveyFile(c))
+		return rec
+	}
+	author := &oauth.User{DID: "did:plc:author"}
+
+	rec := serve(response.ID.String(), "slides", author)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/pdf", rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), "attachment")
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, []string{voter + " " + testImageCID}, fetched)
+
+	assert.Equal(t, http.StatusUnauthorized, serve(response.ID.String(), "slides", nil).Code)
+	assert.Equal(t, http.StatusForbidden, serve(response.ID.String(), "slides", &oauth.User{DID: voter}).Code)
+	assert.Equal(t, http.StatusNotFound, serve(response.ID.String(), "title", author).Code)
+	assert.Equal(t, http.StatusNotFound, serve(uuid.NewString(), "slides", author).Code)
+	assert.Len(t, fetched, 1, "only files answering the survey are fetched")
+}
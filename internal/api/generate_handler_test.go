@@ -7,11 +7,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/openmeet-team/survey/internal/generator"
 	"github.com/openmeet-team/survey/internal/models"
 	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -41,8 +43,10 @@ func NewMockSurveyGenerator(result *generator.GenerateResult, err error) *MockSu
 
 // MockRateLimiter implements a mock version of generator.RateLimiter for testing
 type MockRateLimiter struct {
-	allowAnon bool
-	allowAuth bool
+	allowAnon     bool
+	allowAuth     bool
+	anonRemaining int
+	anonLimit     int
 }
 
 func (m *MockRateLimiter) AllowAnonymous(ip string) bool {
@@ -53,6 +57,14 @@ func (m *MockRateLimiter) AllowAuthenticated(did string) bool {
 	return m.allowAuth
 }
 
+func (m *MockRateLimiter) AnonymousQuota(ip string) generator.Quota {
+	return generator.Quota{Limit: m.anonLimit, Remaining: m.anonRemaining, Reset: time.Now().Add(time.Hour)}
+}
+
+func (m *MockRateLimiter) AuthenticatedQuota(did string) generator.Quota {
+	return generator.Quota{Limit: 20, Remaining: 0, Reset: time.Now().Add(24 * time.Hour)}
+}
+
 func NewMockRateLimiter(allowAnon, allowAuth bool) *MockRateLimiter {
 	return &MockRateLimiter{allowAnon: allowAnon, allowAuth: allowAuth}
 }
@@ -82,10 +94,10 @@ func TestGenerateSurvey_MissingConsent(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-	var resp ErrorResponse
+	var resp problem.Problem
 	err = json.Unmarshal(rec.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	assert.Contains(t, resp.Error, "consent")
+	assert.Contains(t, resp.Detail, "consent")
 }
 
 func TestGenerateSurvey_EmptyDescription(t *testing.T) {
@@ -111,10 +123,10 @@ func TestGenerateSurvey_EmptyDescription(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-	var resp ErrorResponse
+	var resp problem.Problem
 	err = json.Unmarshal(rec.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	assert.Contains(t, resp.Error, "empty")
+	assert.Contains(t, resp.Detail, "empty")
 }
 
 func TestGenerateSurvey_Success_Anonymous(t *testing.T) {
@@ -239,10 +251,12 @@ func TestGenerateSurvey_RateLimitExceeded_Anonymous(t *testing.T) {
 	e := echo.New()
 
 	// Mock rate limit - set to false so it's exceeded
+	rl := NewMockRateLimiter(false, true) // Anonymous blocked
+	rl.anonLimit = 5
 	h := &Handlers{
 		queries:     NewMockQueries(),
 		generator:   NewMockSurveyGenerator(nil, nil),
-		generatorRL: NewMockRateLimiter(false, true), // Anonymous blocked
+		generatorRL: rl,
 	}
 
 	reqBody := GenerateSurveyRequest{
@@ -260,10 +274,17 @@ func TestGenerateSurvey_RateLimitExceeded_Anonymous(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
 
-	var resp ErrorResponse
+	assert.Equal(t, "5", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "3600", rec.Header().Get("Retry-After"))
+
+	var resp problem.Problem
 	err = json.Unmarshal(rec.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	assert.Contains(t, resp.Error, "Rate limit")
+	assert.Equal(t, problem.RateLimited, resp.Code)
+	assert.Contains(t, resp.Detail, "AI generation")
+	assert.Equal(t, 5, resp.Limit)
+	assert.Equal(t, 3600, resp.RetryAfter)
 }
 
 func TestGenerateSurvey_CostLimitExceeded(t *testing.T) {
@@ -291,10 +312,10 @@ func TestGenerateSurvey_CostLimitExceeded(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
 
-	var resp ErrorResponse
+	var resp problem.Problem
 	err = json.Unmarshal(rec.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	assert.Contains(t, resp.Error, "budget")
+	assert.Contains(t, resp.Detail, "budget")
 }
 
 func TestGenerateSurvey_InvalidInput(t *testing.T) {
@@ -322,10 +343,10 @@ func TestGenerateSurvey_InvalidInput(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
-	var resp ErrorResponse
+	var resp problem.Problem
 	err = json.Unmarshal(rec.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	assert.Contains(t, resp.Error, "too long")
+	assert.Contains(t, resp.Detail, "too long")
 }
 
 func TestGenerateSurvey_WithExistingJSON(t *testing.T) {
@@ -1,24 +1,72 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/adminstats"
+	"github.com/openmeet-team/survey/internal/analytics"
+	"github.com/openmeet-team/survey/internal/archive"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/attestation"
+	"github.com/openmeet-team/survey/internal/audit"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/captcha"
+	"github.com/openmeet-team/survey/internal/coauthor"
+	"github.com/openmeet-team/survey/internal/dedup"
+	"github.com/openmeet-team/survey/internal/draft"
+	"github.com/openmeet-team/survey/internal/export"
+	"github.com/openmeet-team/survey/internal/feed"
 	"github.com/openmeet-team/survey/internal/generator"
+	"github.com/openmeet-team/survey/internal/heatmap"
+	"github.com/openmeet-team/survey/internal/i18n"
+	"github.com/openmeet-team/survey/internal/idempotency"
+	"github.com/openmeet-team/survey/internal/invite"
+	"github.com/openmeet-team/survey/internal/identity"
+	"github.com/openmeet-team/survey/internal/interop"
+	"github.com/openmeet-team/survey/internal/jobs"
 	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/moderation"
+	"github.com/openmeet-team/survey/internal/notify"
 	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/org"
+	"github.com/openmeet-team/survey/internal/outbox"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/provenance"
+	"github.com/openmeet-team/survey/internal/preview"
+	"github.com/openmeet-team/survey/internal/questionbank"
+	"github.com/openmeet-team/survey/internal/receipt"
+	"github.com/openmeet-team/survey/internal/reminder"
+	"github.com/openmeet-team/survey/internal/report"
+	"github.com/openmeet-team/survey/internal/review"
+	"github.com/openmeet-team/survey/internal/series"
+	"github.com/openmeet-team/survey/internal/sharetoken"
+	"github.com/openmeet-team/survey/internal/spam"
+	"github.com/openmeet-team/survey/internal/snapshot"
+	"github.com/openmeet-team/survey/internal/status"
+	"github.com/openmeet-team/survey/internal/storage"
+	"github.com/openmeet-team/survey/internal/surveylist"
 	"github.com/openmeet-team/survey/internal/telemetry"
+	"github.com/openmeet-team/survey/internal/tenant"
 	"github.com/openmeet-team/survey/internal/templates"
+	"github.com/openmeet-team/survey/internal/trash"
+	"github.com/openmeet-team/survey/internal/trending"
+	"github.com/openmeet-team/survey/internal/vanity"
+	"github.com/openmeet-team/survey/internal/weighting"
 )
 
 // QueriesInterface defines the interface for database queries
@@ -27,13 +75,29 @@ type QueriesInterface interface {
 	CreateSurvey(ctx context.Context, s *models.Survey) error
 	GetSurveyBySlug(ctx context.Context, slug string) (*models.Survey, error)
 	GetSurveyByURI(ctx context.Context, uri string) (*models.Survey, error)
+	GetSurveyByID(ctx context.Context, id uuid.UUID) (*models.Survey, error)
 	ListSurveys(ctx context.Context, limit, offset int) ([]*models.Survey, error)
 	SlugExists(ctx context.Context, slug string) (bool, error)
 	CreateResponse(ctx context.Context, r *models.Response) error
 	GetResponseBySurveyAndVoter(ctx context.Context, surveyID uuid.UUID, voterDID, voterSession string) (*models.Response, error)
+	GetResponseByID(ctx context.Context, id uuid.UUID) (*models.Response, error)
 	GetSurveyResults(ctx context.Context, surveyID uuid.UUID) (*models.SurveyResults, error)
+	UpdateSurvey(ctx context.Context, s *models.Survey) error
 	UpdateSurveyResults(ctx context.Context, surveyID uuid.UUID, resultsURI, resultsCID string) error
 	GetStats(ctx context.Context) (*models.Stats, error)
+	ListResponsesBySurvey(ctx context.Context, surveyID uuid.UUID) ([]*models.Response, error)
+	ListRespondentDIDs(ctx context.Context, surveyID uuid.UUID, limit int) ([]string, int, error)
+	ListFilteredResponses(ctx context.Context, surveyID uuid.UUID, filter models.ResponseFilter) ([]*models.Response, error)
+	ListResponsesBySurveyStream(ctx context.Context, surveyID uuid.UUID, filter models.ResponseFilter, batchSize int, fn func([]*models.Response) error) error
+	CountFilteredResponses(ctx context.Context, surveyID uuid.UUID, filter models.ResponseFilter) (int, error)
+	ListQuestionOrdinals(ctx context.Context, surveyID uuid.UUID) (map[int]map[string]int, error)
+	ListSurveyRevisions(ctx context.Context, surveyID uuid.UUID, limit int) ([]*models.SurveyRevision, error)
+	CreateSurveyRevision(ctx context.Context, r *models.SurveyRevision) error
+	ListSurveyComments(ctx context.Context, surveyID uuid.UUID, limit, offset int) ([]*models.Comment, error)
+	CountSurveyComments(ctx context.Context, surveyID uuid.UUID) (int, error)
+	ListSurveyCommentsAfter(ctx context.Context, surveyID uuid.UUID, after *models.CommentCursor, limit int) ([]*models.Comment, error)
+	GetSurveyTombstoneBySlug(ctx context.Context, slug string) (*models.SurveyTombstone, error)
+	GetSurveyTombstoneByURI(ctx context.Context, uri string) (*models.SurveyTombstone, error)
 }
 
 // GeneratorInterface defines the interface for AI survey generation
@@ -47,6 +111,8 @@ type GeneratorInterface interface {
 type RateLimiterInterface interface {
 	AllowAnonymous(ip string) bool
 	AllowAuthenticated(did string) bool
+	AnonymousQuota(ip string) generator.Quota
+	AuthenticatedQuota(did string) generator.Quota
 }
 
 // GenerationLoggerInterface defines the interface for logging AI generation attempts
@@ -55,34 +121,155 @@ type GenerationLoggerInterface interface {
 	LogError(ctx context.Context, userID, userType, inputPrompt, systemPrompt, rawResponse, status, errorMessage string, inputTokens, outputTokens int, costUSD float64, durationMS int) error
 }
 
+// StatusStoreInterface defines the interface for reading status page history
+type StatusStoreInterface interface {
+	status.HistoryReader
+}
+
+// ModerationStoreInterface defines the interface for storing and reviewing flagged answers
+type ModerationStoreInterface interface {
+	moderation.Store
+	CreateModeratedResponse(ctx context.Context, r *models.Response, flags []*moderation.FlaggedResponse) error
+	ListFlaggedResponsesBySurvey(ctx context.Context, surveyID uuid.UUID) ([]*moderation.FlaggedResponse, error)
+	ReviewFlaggedResponse(ctx context.Context, id, surveyID uuid.UUID, status, reviewerDID string) error
+}
+
+// SessionManagerInterface lists and revokes users' login sessions
+type SessionManagerInterface interface {
+	ListSessionsByDID(ctx context.Context, did string) ([]oauth.OAuthSession, error)
+	DeleteSessionByDID(ctx context.Context, did, id string) error
+	DeleteSessionsByDID(ctx context.Context, did string) (int64, error)
+}
+
+// AccountDataStoreInterface lists a user's surveys and responses, for exporting their data
+type AccountDataStoreInterface interface {
+	ListSurveysByAuthor(ctx context.Context, authorDID string) ([]*models.Survey, error)
+	ListResponsesByVoter(ctx context.Context, voterDID string) ([]*models.Response, error)
+}
+
+// BulkSurveyStoreInterface creates many surveys at once
+type BulkSurveyStoreInterface interface {
+	CreateSurveys(ctx context.Context, surveys []*models.Survey) error // All or none
+}
+
+// ServiceAuthInterface verifies the ATProto service auth tokens of XRPC calls
+type ServiceAuthInterface interface {
+	Verify(ctx context.Context, token, method string) (string, error)
+}
+
 // Handlers holds the HTTP handlers and dependencies
 type Handlers struct {
-	queries        QueriesInterface
-	oauthStorage   *oauth.Storage
-	oauthConfig    *oauth.Config // OAuth config (needed for token refresh)
-	supportURL     string
-	posthogKey     string
-	generator      GeneratorInterface
-	generatorRL    RateLimiterInterface
-	generationLog  GenerationLoggerInterface
+	queries         QueriesInterface
+	oauthStorage    *oauth.Storage
+	oauthConfig     *oauth.Config // OAuth config (needed for token refresh)
+	sessions        SessionManagerInterface
+	accountData     AccountDataStoreInterface
+	supportURL      string
+	security        *SecurityConfig // CORS policy and security headers; defaults if nil
+	posthogKey      string
+	generator       GeneratorInterface
+	generatorRL     RateLimiterInterface
+	generationLog   GenerationLoggerInterface
+	statusStore     StatusStoreInterface
+	statusReports   statusReportCache
+	moderator       *moderation.Moderator
+	moderationStore ModerationStoreInterface
+	adminDIDs       map[string]bool
+	reports         report.Store
+	reportConfig    report.Config
+	shareTokens     sharetoken.Store
+	invites         invite.Store // One-time links of surveys with invite visibility
+	orgs            org.Store
+	coAuthors       coauthor.Store
+	series          series.Store // Surveys linked into series, compared across waves
+	reservedSlugs   vanity.Store
+	vanityPolicy    *vanity.Policy // Who may claim reserved slugs
+	tenants         tenant.Store
+	tenantResolver  *tenant.Resolver
+	identities      *identity.Resolver
+	verifier        *identity.Verifier
+	serviceAuth     ServiceAuthInterface // Authenticates XRPC calls of native clients
+	provenance      provenance.Config
+	receipts        *receipt.Signer
+	attestations    *attestation.Signer // Signs published results records
+	crossPublish    string // Foreign poll collection new surveys are also published to
+	cache           *cache.Store
+	cacheFill       QueriesInterface // Fills the cache, reading the primary
+	cards           *cache.Store // Social card images
+	outbox          outbox.Store
+	snapshots       snapshot.Store
+	trash           trash.Store
+	surveyList      surveylist.Store
+	trending        trending.Store
+	feeds           feed.Store
+	archive         archive.Store
+	exports         export.Store
+	blobs           storage.Store // Files of exports
+	jobs            *jobs.Queue   // Runs exports in the background
+	idempotency     idempotency.Store // Responses of submissions, replayed for repeated Idempotency-Keys
+	audit           audit.Store
+	dedup           dedup.Store
+	questionBank    questionbank.Store
+	previews        preview.Store
+	bulkSurveys     BulkSurveyStoreInterface
+	notifications   notify.Store
+	messenger       *notify.Messenger // Sends direct message notifications, if configured
+	adminStats      adminstats.Store
+	eventTime       status.CursorFunc // Time of the newest event the consumer indexed, for the admin stats
+	apiKeys         apikey.Store
+	apiKeyConfig    apikey.Config
+	apiKeyUsage     *apikey.UsageCounter
+	generationUsage generator.UsageStore
+	analytics       analytics.Store
+	views           *analytics.ViewCounter // Survey page views, flushed to analytics
+	drafts          draft.Store
+	draftGuests     *draft.Guests // Signs the cookies identifying guests' drafts
+	voters          *dedup.Voters // Signs the cookies identifying guest voters' browsers
+	spam            spam.Store
+	spamScorer      *spam.Scorer // Scores guest votes, counting the recent votes of each IP address
+	heatmap         heatmap.Store
+	locator         *heatmap.Locator // Looks up the countries of voters for the response heatmap
+	reminders       reminder.Store // Invitations to surveys, reminded by messenger
+	responseDrafts  draft.ResponseStore
+	reviews         *review.Signer
+	captcha         *captcha.Verifier
+	voteLimiter     *IPRateLimiter // Vote submission limit that anonymous voters solve CAPTCHAs near
+	bulkLimiter     *IPRateLimiter // Surveys that can be created in bulk, charged per survey
+	fetchBlob       func(ctx context.Context, did, cid string) ([]byte, error) // Fetches survey images and answer files from PDSes
+	fetchRecord     func(ctx context.Context, uri string) (*oauth.PDSRecord, error) // Fetches published records from their PDS
+	resolvePDS      func(did string) (string, error)      // Resolves the PDS of a user whose data is exported
+	fetchRepo       func(ctx context.Context, pdsURL, did string) (io.ReadCloser, error) // Fetches the CAR export of a user's repository
+	resolveHandle   func(handle string) (string, error)   // Resolves the handles of invited organization members
 }
 
 // NewHandlers creates a new Handlers instance
 func NewHandlers(q QueriesInterface) *Handlers {
 	return &Handlers{
-		queries:      q,
-		oauthStorage: nil, // Optional: can be nil if OAuth not configured
-		supportURL:   "",
+		queries:       q,
+		oauthStorage:  nil, // Optional: can be nil if OAuth not configured
+		supportURL:    "",
+		reviews:       review.NewFromConfig(review.Config{}),
+		fetchBlob:     fetchAuthorBlob,
+		fetchRecord:   fetchPDSRecord,
+		resolvePDS:    oauth.DIDToPDS,
+		fetchRepo:     oauth.GetRepo,
+		resolveHandle: oauth.HandleToDID,
 	}
 }
 
 // NewHandlersWithOAuth creates a new Handlers instance with OAuth support
 func NewHandlersWithOAuth(q QueriesInterface, oauthStorage *oauth.Storage, oauthConfig *oauth.Config) *Handlers {
 	return &Handlers{
-		queries:      q,
-		oauthStorage: oauthStorage,
-		oauthConfig:  oauthConfig,
-		supportURL:   "",
+		queries:       q,
+		oauthStorage:  oauthStorage,
+		oauthConfig:   oauthConfig,
+		supportURL:    "",
+		reviews:       review.NewFromConfig(review.Config{}),
+		fetchBlob:     fetchAuthorBlob,
+		fetchRecord:   fetchPDSRecord,
+		resolvePDS:    oauth.DIDToPDS,
+		fetchRepo:     oauth.GetRepo,
+		resolveHandle: oauth.HandleToDID,
 	}
 }
 
@@ -91,6 +278,11 @@ func (h *Handlers) SetSupportURL(url string) {
 	h.supportURL = url
 }
 
+// SetSecurity sets the CORS policy and security headers applied by SetupRoutes
+func (h *Handlers) SetSecurity(config SecurityConfig) {
+	h.security = &config
+}
+
 // SetPostHogKey sets the PostHog API key for analytics
 func (h *Handlers) SetPostHogKey(key string) {
 	h.posthogKey = key
@@ -107,6 +299,362 @@ func (h *Handlers) SetLogger(logger GenerationLoggerInterface) {
 	h.generationLog = logger
 }
 
+// SetStatusStore sets the store backing the public status page
+func (h *Handlers) SetStatusStore(store StatusStoreInterface) {
+	h.statusStore = store
+}
+
+// SetModeration enables text answer moderation. Flagged answers can be
+// reviewed by the survey author or any of the admin DIDs.
+func (h *Handlers) SetModeration(m *moderation.Moderator, store ModerationStoreInterface) {
+	h.moderator = m
+	h.moderationStore = store
+}
+
+// SetAdmins sets the DIDs that may manage any survey and review abuse reports
+func (h *Handlers) SetAdmins(dids []string) {
+	h.adminDIDs = make(map[string]bool, len(dids))
+	for _, did := range dids {
+		h.adminDIDs[did] = true
+	}
+}
+
+// SetReports enables abuse reports of surveys, reviewed by the admin DIDs
+func (h *Handlers) SetReports(store report.Store, config report.Config) {
+	h.reports = store
+	h.reportConfig = config
+}
+
+// SetIdentityResolver sets the resolver used to show handles and display names instead of DIDs
+func (h *Handlers) SetIdentityResolver(r *identity.Resolver) {
+	h.identities = r
+}
+
+// SetIdentityVerifier sets the verifier used to show a verified badge for survey authors
+func (h *Handlers) SetIdentityVerifier(v *identity.Verifier) {
+	h.verifier = v
+}
+
+// SetProvenance sets the AppView identity recorded in published results
+func (h *Handlers) SetProvenance(config provenance.Config) {
+	h.provenance = config
+}
+
+// SetReceiptSigner enables signed vote receipts
+func (h *Handlers) SetReceiptSigner(s *receipt.Signer) {
+	h.receipts = s
+}
+
+// SetCache enables caching of surveys and results, filled with reads from
+// fill. Writes invalidate cached entries and the next read fills them again;
+// a lagging replica would answer it with the data from before the write,
+// which would be served until the entry expires, so fill should read the
+// primary.
+func (h *Handlers) SetCache(store *cache.Store, fill QueriesInterface) {
+	h.cache = store
+	h.cacheFill = fill
+}
+
+// SetCardCache enables caching of rendered social card images
+func (h *Handlers) SetCardCache(store *cache.Store) {
+	h.cards = store
+}
+
+// SetOutbox enables queueing records whose PDS write failed, so users can retry publishing them
+func (h *Handlers) SetOutbox(store outbox.Store) {
+	h.outbox = store
+}
+
+// SetReviewSigner sets the signer of reviewed answers, replacing the default
+// per-process key so reviews can be submitted to any replica
+func (h *Handlers) SetReviewSigner(s *review.Signer) {
+	h.reviews = s
+}
+
+// SetAPIKeys enables API key authentication of the JSON API and the key
+// management endpoints. Requests per key are counted in usage, if not nil.
+func (h *Handlers) SetAPIKeys(store apikey.Store, config apikey.Config, usage *apikey.UsageCounter) {
+	h.apiKeys = store
+	h.apiKeyConfig = config
+	h.apiKeyUsage = usage
+}
+
+// SetGenerationUsageStore sets the store the usage dashboard reads AI generations from
+func (h *Handlers) SetGenerationUsageStore(store generator.UsageStore) {
+	h.generationUsage = store
+}
+
+// SetAnalytics sets the store of survey views and response counts, and the
+// counter recording survey page views
+func (h *Handlers) SetAnalytics(store analytics.Store, views *analytics.ViewCounter) {
+	h.analytics = store
+	h.views = views
+}
+
+// SetDrafts enables saving drafts of the create page, for logged-in users and
+// for guests identified by cookies signed by guests
+func (h *Handlers) SetDrafts(store draft.Store, guests *draft.Guests) {
+	h.drafts = store
+	h.draftGuests = guests
+}
+
+// SetResponseDrafts enables autosaving the answers of the voting form, for
+// logged-in users and for guests identified by cookies signed by guests
+func (h *Handlers) SetResponseDrafts(store draft.ResponseStore, guests *draft.Guests) {
+	h.responseDrafts = store
+	h.draftGuests = guests
+}
+
+// SetSessions enables the page on which users list and revoke their login sessions
+func (h *Handlers) SetSessions(store SessionManagerInterface) {
+	h.sessions = store
+}
+
+// SetAccountData enables exporting all of a user's data from /my-data/export
+func (h *Handlers) SetAccountData(store AccountDataStoreInterface) {
+	h.accountData = store
+}
+
+// surveyBySlug returns a survey, from the cache if enabled
+func (h *Handlers) surveyBySlug(ctx context.Context, slug string) (*models.Survey, error) {
+	return h.cache.Survey(ctx, slug, func() (*models.Survey, error) {
+		return h.cacheQueries().GetSurveyBySlug(ctx, slug)
+	})
+}
+
+// surveyResults returns a survey's results, from the cache if enabled
+func (h *Handlers) surveyResults(ctx context.Context, surveyID uuid.UUID) (*models.SurveyResults, error) {
+	return h.cache.Results(ctx, surveyID, func() (*models.SurveyResults, error) {
+		return h.cacheQueries().GetSurveyResults(ctx, surveyID)
+	})
+}
+
+// cacheQueries returns the queries that fill the cache
+func (h *Handlers) cacheQueries() QueriesInterface {
+	if h.cacheFill != nil {
+		return h.cacheFill
+	}
+	return h.queries
+}
+
+// SetCrossPublishCollection enables cross-publishing new single-question
+// surveys as simplified records in another app's poll lexicon
+func (h *Handlers) SetCrossPublishCollection(collection string) {
+	h.crossPublish = collection
+}
+
+// crossPublishPoll writes the simplified poll record for a survey just published
+// to the user's PDS. Failures are logged; the survey itself is already saved.
+func (h *Handlers) crossPublishPoll(c echo.Context, session *oauth.OAuthSession, surveyURI, surveyCID string, def *models.SurveyDefinition) {
+	if h.crossPublish == "" {
+		return
+	}
+
+	record, ok := interop.PollRecord(h.crossPublish, surveyURI, surveyCID, def, time.Now().Format(time.RFC3339))
+	if !ok {
+		return // Not expressible as a poll
+	}
+
+	pollURI, _, err := oauth.CreateRecord(c.Request().Context(), session, h.crossPublish, oauth.GenerateTID(), record)
+	recordPDSWrite("create", err)
+	if err != nil {
+		c.Logger().Errorf("Failed to cross-publish survey %s to %s: %v", surveyURI, h.crossPublish, err)
+		return
+	}
+	c.Logger().Infof("Cross-published survey %s as %s", surveyURI, pollURI)
+}
+
+// maxRespondentsShown limits how many respondents are resolved and listed on results pages
+const maxRespondentsShown = 100
+
+// surveyAuthor returns the cached identity of the author of a survey, or nil
+// if unknown. Pages don't wait for the author to be resolved: it is resolved
+// in the background and shown from a later request.
+func (h *Handlers) surveyAuthor(ctx context.Context, survey *models.Survey) *identity.Identity {
+	if survey.AuthorDID == nil {
+		return nil
+	}
+	return h.identities.ResolveCached(ctx, []string{*survey.AuthorDID})[*survey.AuthorDID]
+}
+
+// authorVerification verifies the handle of a survey's author, or returns nil if unknown
+func (h *Handlers) authorVerification(ctx context.Context, survey *models.Survey) *identity.Verification {
+	if survey.AuthorDID == nil {
+		return nil
+	}
+	return h.verifier.Verify(ctx, *survey.AuthorDID)
+}
+
+// surveyRespondents returns the logged-in respondents of a non-anonymous survey in
+// order of their first response, up to maxRespondentsShown. Also returns how many
+// more respondents were left out. Respondents are shown as cached, and those
+// not cached yet are listed by DID until resolved in the background.
+func (h *Handlers) surveyRespondents(ctx context.Context, survey *models.Survey) ([]*identity.Identity, int, error) {
+	if survey.Definition.Anonymous || h.identities == nil {
+		return nil, 0, nil
+	}
+
+	dids, total, err := h.queries.ListRespondentDIDs(ctx, survey.ID, maxRespondentsShown)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resolved := h.identities.ResolveCached(ctx, dids)
+	respondents := make([]*identity.Identity, 0, len(dids))
+	for _, did := range dids {
+		if i, ok := resolved[did]; ok {
+			respondents = append(respondents, i)
+		} else {
+			respondents = append(respondents, &identity.Identity{DID: did})
+		}
+	}
+
+	return respondents, total - len(dids), nil
+}
+
+// canManageSurvey reports whether the user may review flagged answers and export responses of the survey
+func (h *Handlers) canManageSurvey(ctx context.Context, user *oauth.User, survey *models.Survey) bool {
+	if user == nil {
+		return false
+	}
+	return h.canManageSurveyAs(ctx, user.DID, survey)
+}
+
+// canManageSurveyAs reports whether a DID, such as the owner of an API key, may
+// manage a survey: its author, admins, and owners and editors of its organization
+func (h *Handlers) canManageSurveyAs(ctx context.Context, did string, survey *models.Survey) bool {
+	if survey.AuthorDID != nil && *survey.AuthorDID == did {
+		return true
+	}
+	if h.adminDIDs[did] {
+		return true
+	}
+	return h.surveyOrgMember(ctx, did, survey).CanEdit()
+}
+
+// canReadSurveyAs reports whether a DID may see the responses, exports, and
+// analytics of a survey: those who manage it, its co-authors, and viewers of
+// its organization
+func (h *Handlers) canReadSurveyAs(ctx context.Context, did string, survey *models.Survey) bool {
+	if h.canManageSurveyAs(ctx, did, survey) || h.isCoAuthor(ctx, did, survey) {
+		return true
+	}
+	return h.surveyOrgMember(ctx, did, survey).CanView()
+}
+
+// orderedOptionIDs returns the counted option IDs of a question result in the order
+// the options appear in the survey definition, followed by unknown options sorted by ID
+func orderedOptionIDs(survey *models.Survey, qResult *models.QuestionResult) []string {
+	return orderedIDs(surveyQuestion(survey, qResult.QuestionID).Options, qResult.OptionCounts)
+}
+
+// orderedRowIDs is orderedOptionIDs for the rows of a matrix question result
+func orderedRowIDs(survey *models.Survey, qResult *models.QuestionResult) []string {
+	rows := make(map[string]int, len(qResult.RowCounts))
+	for rowID := range qResult.RowCounts {
+		rows[rowID] = 0
+	}
+	return orderedIDs(surveyQuestion(survey, qResult.QuestionID).Rows, rows)
+}
+
+// surveyQuestion returns a question of the survey, or an empty question if it has none with the ID
+func surveyQuestion(survey *models.Survey, questionID string) models.Question {
+	for _, q := range survey.Definition.Questions {
+		if q.ID == questionID {
+			return q
+		}
+	}
+	return models.Question{}
+}
+
+// orderedIDs returns the keys of counts in the order of options, followed by
+// IDs not among the options sorted by ID
+func orderedIDs(options []models.Option, counts map[string]int) []string {
+	position := make(map[string]int, len(options))
+	for i, opt := range options {
+		position[opt.ID] = i + 1
+	}
+
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := position[ids[i]], position[ids[j]]
+		if a != b {
+			if a == 0 || b == 0 {
+				return b == 0
+			}
+			return a < b
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// recordPDSWrite records the outcome of a PDS write for metrics and the status page
+func recordPDSWrite(operation string, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	telemetry.PDSWritesTotal.WithLabelValues(operation, status).Inc()
+}
+
+// errTokenRefresh marks PDS writes that failed because the session could not be refreshed
+var errTokenRefresh = errors.New("failed to refresh access token")
+
+// writeRecord refreshes the session's access token if needed and creates a record on the user's PDS
+func (h *Handlers) writeRecord(ctx context.Context, session *oauth.OAuthSession, collection, rkey string, record map[string]interface{}) (string, string, error) {
+	if err := h.ensureValidToken(ctx, session); err != nil {
+		return "", "", fmt.Errorf("%w: %v", errTokenRefresh, err)
+	}
+
+	uri, cid, err := oauth.CreateRecord(ctx, session, collection, rkey, record)
+	recordPDSWrite("create", err)
+	return uri, cid, err
+}
+
+// surveyRecord builds the net.openmeet.survey record of a survey definition
+func surveyRecord(title string, def *models.SurveyDefinition, createdAt time.Time) map[string]interface{} {
+	record := map[string]interface{}{
+		"$type":     "net.openmeet.survey",
+		"name":      title,
+		"questions": def.Questions,
+		"createdAt": createdAt.Format(time.RFC3339),
+	}
+
+	// Add optional fields if present
+	if def.Anonymous {
+		record["anonymous"] = def.Anonymous
+	}
+	if def.Language != "" {
+		record["langs"] = []string{def.Language}
+	}
+	if def.ConfirmBeforeSubmit {
+		record["confirmBeforeSubmit"] = true
+	}
+	if !def.IsListed() {
+		record["visibility"] = def.Visibility
+	}
+	if def.ResponseMetadata {
+		record["responseMetadata"] = true
+	}
+	if def.Redaction.Enabled() {
+		record["redaction"] = def.Redaction
+	}
+	return record
+}
+
+// recordPDSWriteFallback counts a survey or response saved locally only because its PDS write failed
+func recordPDSWriteFallback(kind string, err error) {
+	reason := "write_error"
+	if errors.Is(err, errTokenRefresh) {
+		reason = "token_refresh"
+	}
+	telemetry.PDSWriteFallbacksTotal.WithLabelValues(kind, reason).Inc()
+}
+
 // ensureValidToken checks if the session's access token is valid and refreshes if needed.
 // Returns error if refresh is needed but fails (caller should invalidate session).
 // Returns nil if OAuth is not configured (config is nil).
@@ -125,27 +673,12 @@ func (h *Handlers) ensureValidToken(ctx context.Context, session *oauth.OAuthSes
 func (h *Handlers) CreateSurvey(c echo.Context) error {
 	var req CreateSurveyRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Details: err.Error(),
-		})
+		return Problem(c, problem.InvalidRequestBody, err.Error())
 	}
 
-	// Parse the definition (JSON or YAML)
-	def, err := models.ParseSurveyDefinition([]byte(req.Definition))
+	def, err := parseNewDefinition(req.Definition)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid survey definition",
-			Details: err.Error(),
-		})
-	}
-
-	// Validate the definition
-	if err := def.ValidateDefinition(); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid survey definition",
-			Details: err.Error(),
-		})
+		return Problem(c, problem.InvalidDefinition, err.Error())
 	}
 
 	// Generate or validate slug
@@ -160,10 +693,7 @@ func (h *Handlers) CreateSurvey(c echo.Context) error {
 	} else {
 		// Validate provided slug
 		if err := models.ValidateSlug(slug); err != nil {
-			return c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error:   "Invalid slug",
-				Details: err.Error(),
-			})
+			return Problem(c, problem.ValidationFailed, "Invalid slug: "+err.Error())
 		}
 	}
 
@@ -173,10 +703,20 @@ func (h *Handlers) CreateSurvey(c echo.Context) error {
 		return InternalServerError(c, "Failed to check slug availability", err)
 	}
 	if exists {
-		return c.JSON(http.StatusConflict, ErrorResponse{
-			Error:   "Survey slug already exists",
-			Details: fmt.Sprintf("A survey with slug '%s' already exists", slug),
-		})
+		return Problem(c, problem.SlugTaken, fmt.Sprintf("A survey with slug '%s' already exists", slug))
+	}
+
+	// Reserved slugs are kept for the allow-listed authors the key belongs to
+	var authorDID string
+	if key := APIKeyFromContext(c); key != nil {
+		authorDID = key.OwnerDID
+	}
+	reserved, err := h.checkSlugReserved(c.Request().Context(), slug, authorDID)
+	if err != nil {
+		return InternalServerError(c, "Failed to check slug availability", err)
+	}
+	if reserved != nil {
+		return Problem(c, problem.SlugReserved, slugReservedMessage(slug, reserved))
 	}
 
 	// Extract title from definition (use first question text if no explicit title)
@@ -185,6 +725,9 @@ func (h *Handlers) CreateSurvey(c echo.Context) error {
 		title = def.Questions[0].Text
 	}
 
+	// API surveys are local-only, so they cannot show images stored as PDS blobs
+	def.StripImages()
+
 	// Create survey model
 	now := time.Now()
 	survey := &models.Survey{
@@ -196,6 +739,11 @@ func (h *Handlers) CreateSurvey(c echo.Context) error {
 		UpdatedAt:  now,
 	}
 
+	// Surveys created with a key belong to its owner, who can list and manage them
+	if key := APIKeyFromContext(c); key != nil {
+		survey.AuthorDID = &key.OwnerDID
+	}
+
 	// Save to database
 	if err := h.queries.CreateSurvey(c.Request().Context(), survey); err != nil {
 		return InternalServerError(c, "Failed to create survey", err)
@@ -205,23 +753,78 @@ func (h *Handlers) CreateSurvey(c echo.Context) error {
 	return c.JSON(http.StatusCreated, ToSurveyResponse(survey, true))
 }
 
+// parseNewDefinition parses a definition (JSON or YAML) of a new survey and
+// validates it, first against the published schema
+func parseNewDefinition(raw string) (*models.SurveyDefinition, error) {
+	def, err := models.ParseSurveyDefinition([]byte(raw))
+	if err != nil {
+		return nil, err
+	}
+	if errs := models.SchemaErrors([]byte(raw)); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	if err := def.ValidateDefinition(); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// ValidateSurvey lints a survey definition without creating the survey,
+// returning all its errors and warnings with the fields and lines they
+// concern. Used by the survey editor for feedback while typing.
+// POST /api/v1/surveys/validate
+func (h *Handlers) ValidateSurvey(c echo.Context) error {
+	var req ValidateSurveyRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+	if strings.TrimSpace(req.Definition) == "" {
+		return Problem(c, problem.ValidationFailed, "Definition is required")
+	}
+
+	return c.JSON(http.StatusOK, models.LintDefinition([]byte(req.Definition)))
+}
+
+// GetDefinitionSchema serves the JSON Schema of survey definitions, for
+// editors and CI pipelines authoring surveys
+// GET /api/v1/schema/survey-definition.json
+func (h *Handlers) GetDefinitionSchema(c echo.Context) error {
+	schema := *models.DefinitionSchema()
+	schema.ID = c.Scheme() + "://" + c.Request().Host + templates.AppPath(models.DefinitionSchemaID)
+	c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+	return c.JSON(http.StatusOK, &schema)
+}
+
 // GetSurvey retrieves a survey by slug
 // GET /api/v1/surveys/:slug
 func (h *Handlers) GetSurvey(c echo.Context) error {
 	slug := c.Param("slug")
 
-	survey, err := h.queries.GetSurveyBySlug(c.Request().Context(), slug)
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return c.JSON(http.StatusNotFound, ErrorResponse{
-				Error:   "Survey not found",
-				Details: fmt.Sprintf("No survey found with slug '%s'", slug),
-			})
+			return h.surveyNotFoundJSON(c, slug)
 		}
 		return InternalServerError(c, "Failed to retrieve survey", err)
 	}
 
-	return c.JSON(http.StatusOK, ToSurveyResponse(survey, true))
+	if h.surveyHidden(c, survey) {
+		return surveyHiddenJSON(c)
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		return surveyPrivateJSON(c)
+	}
+
+	author := h.surveyAuthor(c.Request().Context(), survey)
+	if checkNotModified(c, surveyETag(survey, author)) {
+		return notModified(c)
+	}
+
+	resp := ToSurveyResponse(survey, true)
+	resp.Author = author
+
+	return c.JSON(http.StatusOK, resp)
 }
 
 // ListSurveys retrieves a list of surveys with pagination
@@ -257,44 +860,107 @@ func (h *Handlers) ListSurveys(c echo.Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
+// ListOwnSurveys lists the surveys of the caller: the logged-in user or the
+// owner of an API key. There is no public listing, so surveys cannot be discovered.
+// With the survey list enabled, they can be sorted and filtered (see listSurveys).
+// GET /api/v1/surveys
+func (h *Handlers) ListOwnSurveys(c echo.Context) error {
+	owner, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+
+	if h.surveyList != nil {
+		return h.listSurveys(c, owner)
+	}
+
+	surveys, err := h.accountData.ListSurveysByAuthor(c.Request().Context(), owner)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve surveys", err)
+	}
+
+	result := make([]SurveyListResponse, len(surveys))
+	for i, s := range surveys {
+		result[i] = *ToSurveyListResponse(s)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
 // SubmitResponse submits a response to a survey
 // POST /api/v1/surveys/:slug/responses
 func (h *Handlers) SubmitResponse(c echo.Context) error {
 	slug := c.Param("slug")
 
 	// Get the survey
-	survey, err := h.queries.GetSurveyBySlug(c.Request().Context(), slug)
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return c.JSON(http.StatusNotFound, ErrorResponse{
-				Error:   "Survey not found",
-				Details: fmt.Sprintf("No survey found with slug '%s'", slug),
-			})
+			return h.surveyNotFoundJSON(c, slug)
 		}
 		return InternalServerError(c, "Failed to retrieve survey", err)
 	}
 
+	if h.surveyHidden(c, survey) {
+		return surveyHiddenJSON(c)
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		return surveyPrivateJSON(c)
+	}
+
+	// Polls indexed from other apps are read-only
+	if survey.IsForeign() {
+		return Problem(c, problem.SurveyReadOnly, "This poll was created in another app; votes must be cast there")
+	}
+
+	if survey.IsClosed(time.Now()) {
+		return Problem(c, problem.SurveyClosed, closedSurveyMessage)
+	}
+
 	// Parse request body
 	var req SubmitResponseRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Details: err.Error(),
-		})
+		return Problem(c, problem.InvalidRequestBody, err.Error())
 	}
 
 	// Validate answers
 	if err := models.ValidateAnswers(&survey.Definition, req.Answers); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid answers",
-			Details: err.Error(),
-		})
+		return Problem(c, problem.InvalidAnswers, err.Error())
+	}
+
+	// Files are uploaded to the voter's own PDS, which API submissions do not write to
+	if models.HasFileAnswers(req.Answers) {
+		return Problem(c, problem.InvalidAnswers, "File questions are answered in the survey's web form by logged-in voters")
 	}
 
+	// A retried submission gets the response of the first one
+	replay, claim, err := h.claimSubmission(c, survey, req.Answers)
+	if err != nil {
+		return idempotencyProblem(c, err)
+	}
+	if replay != nil {
+		return replaySubmission(c, replay)
+	}
+	defer claim.release(c)
+
+	// Anonymous voters close to the limit must solve a CAPTCHA
+	if h.voteCaptcha(c, 0) != nil {
+		if err := h.verifyCaptcha(c); err != nil {
+			return captchaRequired(c, err)
+		}
+	}
+
+	// Surveys with invite visibility take one response per invite link
+	used, err := h.useInvite(c, survey)
+	if err != nil {
+		return inviteProblem(c, err)
+	}
+	defer used.release(c)
+
 	// Generate voter session (guest identity)
 	ip := getClientIP(c)
 	userAgent := c.Request().UserAgent()
-	voterSession := models.GenerateVoterSession(survey.ID, ip, userAgent)
+	voterSession := used.voterSession(survey.ID, models.GenerateVoterSession(survey.ID, ip, userAgent))
 
 	// Check if already voted
 	existingResponse, err := h.queries.GetResponseBySurveyAndVoter(
@@ -308,12 +974,19 @@ func (h *Handlers) SubmitResponse(c echo.Context) error {
 	}
 
 	if existingResponse != nil {
-		return c.JSON(http.StatusConflict, ErrorResponse{
-			Error:   "Already voted",
-			Details: "You have already submitted a response to this survey",
-		})
+		return Problem(c, problem.AlreadyVoted, "You have already submitted a response to this survey")
 	}
 
+	// The voter cookie catches second votes from another network
+	signals, err := h.guestSignals(c, survey)
+	if errors.Is(err, errAlreadyVoted) {
+		return Problem(c, problem.AlreadyVoted, "You have already submitted a response to this survey")
+	}
+	if err != nil {
+		return InternalServerError(c, "Failed to check for existing response", err)
+	}
+	score := h.spamScore(c, survey, "")
+
 	// Create response
 	now := time.Now()
 	response := &models.Response{
@@ -324,46 +997,100 @@ func (h *Handlers) SubmitResponse(c echo.Context) error {
 		CreatedAt:    now,
 	}
 
-	// Save response
-	if err := h.queries.CreateResponse(c.Request().Context(), response); err != nil {
+	// Save response, with flags on text answers to review before they appear in results
+	if err := h.createResponse(c, response); err != nil {
 		return InternalServerError(c, "Failed to submit response", err)
 	}
+	used.complete(c, survey, response)
+	h.saveSignals(c, signals, response)
+	h.saveSpamScore(c, score, response)
+	h.recordResponseMetadata(c, survey, response)
+	h.cache.Invalidate(c.Request().Context(), cache.ResultsKey(survey.ID))
 
 	// Record metrics (no slug label to avoid cardinality explosion)
 	telemetry.SurveyResponsesTotal.WithLabelValues("web").Inc()
 
-	// Return success
-	return c.JSON(http.StatusCreated, ResponseSubmittedResponse{
+	// Return success, kept for retries with the same idempotency key
+	body, err := json.Marshal(ResponseSubmittedResponse{
 		ID:        response.ID,
 		SurveyID:  survey.ID,
 		CreatedAt: response.CreatedAt,
+		Receipt:   h.responseReceipt(response),
 	})
+	if err != nil {
+		return InternalServerError(c, "Failed to encode response", err)
+	}
+	claim.complete(c, response.ID, http.StatusCreated, echo.MIMEApplicationJSON, body)
+	return c.JSONBlob(http.StatusCreated, body)
 }
 
-// GetResults retrieves aggregated results for a survey
-// GET /api/v1/surveys/:slug/results
+// GetResults retrieves aggregated results for a survey, optionally with the
+// percentages and confidence intervals of choice questions (see parseStats).
+// Its author can also get them weighted by a single-choice question (see
+// parseWeighting).
+// GET /api/v1/surveys/:slug/results?stats=&confidence=&weightBy=&targets=
 func (h *Handlers) GetResults(c echo.Context) error {
 	slug := c.Param("slug")
 
 	// Get the survey
-	survey, err := h.queries.GetSurveyBySlug(c.Request().Context(), slug)
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return c.JSON(http.StatusNotFound, ErrorResponse{
-				Error:   "Survey not found",
-				Details: fmt.Sprintf("No survey found with slug '%s'", slug),
-			})
+			return h.surveyNotFoundJSON(c, slug)
 		}
 		return InternalServerError(c, "Failed to retrieve survey", err)
 	}
 
+	if h.surveyHidden(c, survey) {
+		return surveyHiddenJSON(c)
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		return surveyPrivateJSON(c)
+	}
+
+	confidence, err := parseStats(c)
+	if err != nil {
+		return ValidationError(c, "Invalid statistics", err.Error())
+	}
+
+	// Weighted results break votes down by group, so only the author gets them
+	spec, err := parseWeighting(c, survey)
+	if err != nil {
+		return ValidationError(c, "Invalid weighting", err.Error())
+	}
+	if spec != nil {
+		if caller, ok := callerDID(c); !ok || !h.canReadSurveyAs(c.Request().Context(), caller, survey) {
+			return Problem(c, problem.Forbidden, "Only the survey author can weight results")
+		}
+	}
+
 	// Get results
-	results, err := h.queries.GetSurveyResults(c.Request().Context(), survey.ID)
+	results, err := h.surveyResults(c.Request().Context(), survey.ID)
 	if err != nil {
 		return InternalServerError(c, "Failed to retrieve results", err)
 	}
 
-	return c.JSON(http.StatusOK, results)
+	if checkNotModified(c, resultsETag(survey, results, c.QueryParam("weightBy"), c.QueryParam("targets"), strconv.Itoa(confidence))) {
+		return notModified(c)
+	}
+
+	response := SurveyResultsResponse{SurveyResults: results}
+	if confidence > 0 {
+		response.SurveyResults = results.WithStats(&survey.Definition, confidence)
+	}
+	if spec != nil {
+		if err := h.restoreArchive(c.Request().Context(), survey); err != nil {
+			return InternalServerError(c, "Failed to restore archived responses", err)
+		}
+		responses, err := h.queries.ListResponsesBySurvey(c.Request().Context(), survey.ID)
+		if err != nil {
+			return InternalServerError(c, "Failed to weight results", err)
+		}
+		response.Weighting = weighting.Compute(&survey.Definition, responses, *spec)
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 // Helper Functions
@@ -401,30 +1128,61 @@ func generateSlug(title string) string {
 
 // HTML Handlers
 
+// maxRevisionsShown limits the change history on the survey page
+const maxRevisionsShown = 20
+
 // GetSurveyHTML renders the survey form page
 // GET /surveys/:slug
 func (h *Handlers) GetSurveyHTML(c echo.Context) error {
 	slug := c.Param("slug")
 
-	survey, err := h.queries.GetSurveyBySlug(c.Request().Context(), slug)
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return c.String(http.StatusNotFound, "Survey not found")
+			return h.surveyNotFoundHTML(c, slug)
 		}
 		return c.String(http.StatusInternalServerError, "Failed to load survey")
 	}
 
+	if h.surveyHidden(c, survey) {
+		return c.String(http.StatusNotFound, hiddenSurveyMessage)
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		return c.String(http.StatusForbidden, privateSurveyMessage)
+	}
+
+	h.recordView(c, survey)
+
 	// Get user and profile from context
 	user, profile := getUserAndProfile(c)
 
+	author := h.surveyAuthor(c.Request().Context(), survey)
+	verification := h.authorVerification(c.Request().Context(), survey)
+	pending := h.pendingRecords(c, survey, user)
+
+	// Show edits made by the author after the survey was published
+	revisions, err := h.queries.ListSurveyRevisions(c.Request().Context(), survey.ID, maxRevisionsShown)
+	if err != nil {
+		c.Logger().Errorf("Failed to list revisions of survey %s: %v", slug, err)
+	}
+
 	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
-	component := templates.SurveyForm(survey, user, profile, h.posthogKey)
+	draftAnswers := h.responseDraftAnswers(c, survey)
+
+	// Surveys with a review step show the CAPTCHA on the review page instead
+	var widget *captcha.Widget
+	if !survey.Definition.ConfirmBeforeSubmit {
+		widget = h.voteCaptcha(c, 1)
+	}
+	component := templates.SurveyForm(survey, author, verification, user, profile, h.posthogKey, pending, revisions, draftAnswers, h.autosaves(survey), widget, h.reports != nil)
 	return component.Render(c.Request().Context(), c.Response().Writer)
 }
 
 // CreateSurveyPageHTML renders the create survey form
 // GET /surveys/new
 // Optional query param: template=<slug> to pre-populate from existing survey
+// Optional query param: draft=<id> to resume a saved draft
 func (h *Handlers) CreateSurveyPageHTML(c echo.Context) error {
 	// Get user and profile from context
 	user, profile := getUserAndProfile(c)
@@ -432,8 +1190,8 @@ func (h *Handlers) CreateSurveyPageHTML(c echo.Context) error {
 	// Check for template query param
 	var templateJSON string
 	if templateSlug := c.QueryParam("template"); templateSlug != "" {
-		survey, err := h.queries.GetSurveyBySlug(c.Request().Context(), templateSlug)
-		if err == nil && survey != nil {
+		survey, err := h.surveyBySlug(c.Request().Context(), templateSlug)
+		if err == nil && survey != nil && !h.surveyHidden(c, survey) && h.canViewSurvey(c, survey) {
 			// Serialize the definition to JSON
 			defBytes, err := json.Marshal(survey.Definition)
 			if err == nil {
@@ -443,7 +1201,13 @@ func (h *Handlers) CreateSurveyPageHTML(c echo.Context) error {
 	}
 
 	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
-	component := templates.CreateSurvey(user, profile, h.posthogKey, templateJSON)
+	// Anonymous users may have to solve a CAPTCHA to generate surveys with AI
+	var widget *captcha.Widget
+	if h.generator != nil {
+		widget = h.captchaWidget(c)
+	}
+
+	component := templates.CreateSurvey(user, profile, h.posthogKey, templateJSON, h.createPageDrafts(c), widget)
 	return component.Render(c.Request().Context(), c.Response().Writer)
 }
 
@@ -460,7 +1224,11 @@ func (h *Handlers) CreateSurveyHTML(c echo.Context) error {
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 
-	// Validate the definition
+	// Validate the definition, first against the published schema
+	if errs := models.SchemaErrors([]byte(definition)); len(errs) > 0 {
+		component := templates.Error("Invalid survey definition: " + errs[0].Error())
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
 	if err := def.ValidateDefinition(); err != nil {
 		component := templates.Error("Invalid survey definition: " + err.Error())
 		return component.Render(c.Request().Context(), c.Response().Writer)
@@ -491,6 +1259,21 @@ func (h *Handlers) CreateSurveyHTML(c echo.Context) error {
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 
+	// Reserved slugs are kept for allow-listed authors
+	var claimant string
+	if user := oauth.GetUser(c); user != nil {
+		claimant = user.DID
+	}
+	reserved, err := h.checkSlugReserved(c.Request().Context(), slug, claimant)
+	if err != nil {
+		component := templates.Error("Failed to check slug availability")
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+	if reserved != nil {
+		component := templates.Error(slugReservedMessage(slug, reserved))
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
 	// Extract title from definition
 	title := ""
 	if len(def.Questions) > 0 {
@@ -501,54 +1284,42 @@ func (h *Handlers) CreateSurveyHTML(c echo.Context) error {
 	var uri *string
 	var cid *string
 	var authorDID *string
+	var unpublished *outbox.Entry // Record to queue if the PDS write fails
 
 	if h.oauthStorage != nil {
 		session, err := oauth.GetSession(c, h.oauthStorage)
 		if err == nil && session != nil && session.AccessToken != "" && session.PDSUrl != "" {
-			// User is logged in - ensure token is valid before PDS write
-			if err := h.ensureValidToken(c.Request().Context(), session); err != nil {
-				// Token refresh failed - log and continue with local-only survey
-				c.Logger().Errorf("Failed to refresh access token: %v", err)
-				// Optionally: delete the invalid session
-				// h.oauthStorage.DeleteSession(c.Request().Context(), session.ID)
+			// User is logged in - write to PDS
+			rkey := oauth.GenerateTID()
+
+			record := surveyRecord(title, def, time.Now())
+
+			// Write to PDS (refreshing the token first if needed)
+			pdsURI, pdsCID, err := h.writeRecord(c.Request().Context(), session, "net.openmeet.survey", rkey, record)
+			if err != nil {
+				// PDS write failed - continue with a local-only survey and queue the record for retry
+				c.Logger().Errorf("Failed to write survey to PDS: %v", err)
+				recordPDSWriteFallback(outbox.KindSurvey, err)
+				unpublished, err = outbox.NewEntry(outbox.KindSurvey, session.DID, "net.openmeet.survey", rkey, record)
+				if err != nil {
+					c.Logger().Errorf("Failed to build outbox entry: %v", err)
+				}
 			} else {
-				// Token is valid - write to PDS
-				rkey := oauth.GenerateTID()
-
-				// Build AT URI before PDS write (so we can store it locally)
-				atURI := fmt.Sprintf("at://%s/net.openmeet.survey/%s", session.DID, rkey)
-				uri = &atURI
+				// PDS write succeeded - store the record's URI and CID
+				uri = &pdsURI
+				cid = &pdsCID
 				authorDID = &session.DID
 
-				// Build ATProto record matching lexicon format
-				record := map[string]interface{}{
-					"$type":     "net.openmeet.survey",
-					"name":      title,
-					"questions": def.Questions,
-					"createdAt": time.Now().Format(time.RFC3339),
-				}
-
-				// Add optional fields if present
-				if def.Anonymous {
-					record["anonymous"] = def.Anonymous
-				}
-
-				// Write to PDS
-				pdsURI, pdsCID, err := oauth.CreateRecord(session, "net.openmeet.survey", rkey, record)
-				if err != nil {
-					// PDS write failed - log but continue with local-only survey
-					c.Logger().Errorf("Failed to write survey to PDS: %v", err)
-					uri = nil
-					authorDID = nil
-				} else {
-					// PDS write succeeded - update with actual CID
-					uri = &pdsURI
-					cid = &pdsCID
-				}
+				h.crossPublishPoll(c, session, pdsURI, pdsCID, def)
 			}
 		}
 	}
 
+	// Images are blobs on the author's PDS, so local-only surveys cannot show them
+	if uri == nil && def.StripImages() {
+		c.Logger().Infof("Removed images from local-only survey %s", slug)
+	}
+
 	// Create survey locally (either after PDS write or as local-only)
 	now := time.Now()
 	survey := &models.Survey{
@@ -568,8 +1339,21 @@ func (h *Handlers) CreateSurveyHTML(c echo.Context) error {
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 
+	// The survey page offers to retry publishing a queued record
+	if unpublished != nil {
+		unpublished.SurveyID = survey.ID
+		h.queueRecord(c, unpublished)
+	}
+
+	h.deletePublishedDraft(c)
+
+	// Surveys published to the author's PDS are offered to share to Bluesky
+	if uri != nil {
+		return c.Redirect(http.StatusSeeOther, templates.AppPath(blueskyRedirect(survey, "/surveys/"+slug)))
+	}
+
 	// Redirect to the new survey
-	return c.Redirect(http.StatusSeeOther, "/surveys/"+slug)
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+slug))
 }
 
 // SubmitResponseHTML handles survey response submission from HTML form
@@ -578,57 +1362,102 @@ func (h *Handlers) SubmitResponseHTML(c echo.Context) error {
 	slug := c.Param("slug")
 
 	// Get the survey
-	survey, err := h.queries.GetSurveyBySlug(c.Request().Context(), slug)
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			component := templates.Error("Survey not found")
+			component := templates.Error(h.surveyNotFoundMessage(c.Request().Context(), slug))
 			return component.Render(c.Request().Context(), c.Response().Writer)
 		}
 		component := templates.Error("Failed to load survey")
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 
+	if h.surveyHidden(c, survey) {
+		component := templates.Error(hiddenSurveyMessage)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		component := templates.Error(privateSurveyMessage)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	// Polls indexed from other apps are read-only
+	if survey.IsForeign() {
+		component := templates.Error("This poll was created in another app; votes must be cast there")
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	if survey.IsClosed(time.Now()) {
+		component := templates.Error(closedSurveyMessage)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
 	// Parse form data into answers
-	answers := make(map[string]models.Answer)
 	formValues, err := c.FormParams()
 	if err != nil {
 		component := templates.Error("Invalid form data")
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
+	answers := formAnswers(&survey.Definition, formValues)
 
-	for _, question := range survey.Definition.Questions {
-		if question.Type == models.QuestionTypeSingle {
-			if value := formValues.Get(question.ID); value != "" {
-				answers[question.ID] = models.Answer{
-					SelectedOptions: []string{value},
-				}
-			}
-		} else if question.Type == models.QuestionTypeMulti {
-			if values, ok := formValues[question.ID]; ok && len(values) > 0 {
-				answers[question.ID] = models.Answer{
-					SelectedOptions: values,
-				}
+	// Validate answers
+	if err := models.ValidateAnswers(&survey.Definition, answers); err != nil {
+		component := templates.Error("Invalid answers: " + err.Error())
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	// Surveys with a review step only accept the answers the voter confirmed
+	if survey.Definition.ConfirmBeforeSubmit {
+		if err := h.reviews.Verify(formValues.Get(reviewTokenField), survey, answers, time.Now()); err != nil {
+			if errors.Is(err, review.ErrExpiredToken) {
+				component := templates.Error("Your review has expired. Please review your answers again.")
+				return component.Render(c.Request().Context(), c.Response().Writer)
 			}
-		} else if question.Type == models.QuestionTypeText {
-			if value := formValues.Get(question.ID); value != "" {
-				answers[question.ID] = models.Answer{
-					Text: value,
-				}
+			component := templates.Error("Please review your answers before submitting")
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+	}
+
+	// A retried submission gets the page of the first one, before anything is
+	// written to the voter's PDS again
+	replay, claim, err := h.claimSubmission(c, survey, answers)
+	if err != nil {
+		component := templates.Error(idempotencyMessage(err))
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+	if replay != nil {
+		return replaySubmission(c, replay)
+	}
+	defer claim.release(c)
+
+	// Anonymous voters close to the limit must solve a CAPTCHA; show it with their answers
+	if widget := h.voteCaptcha(c, 0); widget != nil {
+		if err := h.verifyCaptcha(c); err != nil {
+			if survey.Definition.ConfirmBeforeSubmit {
+				component := templates.ReviewAnswers(survey, answers, formValues.Get(reviewTokenField), widget)
+				return component.Render(c.Request().Context(), c.Response().Writer)
 			}
+			component := templates.ResponseForm(survey, answers, h.autosaves(survey), widget)
+			return component.Render(c.Request().Context(), c.Response().Writer)
 		}
 	}
 
-	// Validate answers
-	if err := models.ValidateAnswers(&survey.Definition, answers); err != nil {
-		component := templates.Error("Invalid answers: " + err.Error())
+	// Surveys with invite visibility take one response per invite link, checked
+	// before anything is written to the voter's PDS
+	used, err := h.useInvite(c, survey)
+	if err != nil {
+		component := templates.Error(inviteMessage(err))
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
+	defer used.release(c)
 
 	// Initialize response fields
 	var uri *string
 	var cid *string
 	var voterDID *string
 	var voterSession *string
+	var unpublished *outbox.Entry // Record to queue if the PDS write fails
 
 	// Check if user is logged in and survey has a URI (ATProto record)
 	// If both conditions are met, write response to user's PDS
@@ -637,67 +1466,77 @@ func (h *Handlers) SubmitResponseHTML(c echo.Context) error {
 		session, err := oauth.GetSession(c, h.oauthStorage)
 		c.Logger().Infof("OAuth session lookup: session=%v, err=%v", session != nil, err)
 		if err == nil && session != nil {
-			// Ensure token is valid before PDS write
-			if err := h.ensureValidToken(c.Request().Context(), session); err != nil {
-				// Token refresh failed - log and continue with local-only response
-				c.Logger().Errorf("Failed to refresh access token: %v", err)
-			} else {
-				c.Logger().Infof("Attempting PDS write for user %s to survey %s", session.DID, *survey.URI)
-				// Generate TID for response rkey
-				rkey := oauth.GenerateTID()
-				atURI := fmt.Sprintf("at://%s/net.openmeet.survey.response/%s", session.DID, rkey)
-				uri = &atURI
-				voterDID = &session.DID
-
-				// Convert answers map to lexicon format
-				// The lexicon expects an array of {questionId, selectedOptions?, text?}
-				lexiconAnswers := make([]map[string]interface{}, 0, len(answers))
-				for qid, answer := range answers {
-					lexAnswer := map[string]interface{}{
-						"questionId": qid,
-					}
-					if len(answer.SelectedOptions) > 0 {
-						lexAnswer["selectedOptions"] = answer.SelectedOptions
-					}
-					if answer.Text != "" {
-						lexAnswer["text"] = answer.Text
-					}
-					lexiconAnswers = append(lexiconAnswers, lexAnswer)
+			c.Logger().Infof("Attempting PDS write for user %s to survey %s", session.DID, *survey.URI)
+			// Generate TID for response rkey
+			rkey := oauth.GenerateTID()
+
+			// Convert answers map to lexicon format
+			// The lexicon expects an array of {questionId, selectedOptions?, text?}
+			lexiconAnswers := make([]map[string]interface{}, 0, len(answers))
+			for qid, answer := range answers {
+				lexAnswer := map[string]interface{}{
+					"questionId": qid,
 				}
-
-				// Build ATProto record matching lexicon format
-				record := map[string]interface{}{
-					"$type": "net.openmeet.survey.response",
-					"subject": map[string]string{
-						"uri": *survey.URI,
-						"cid": *survey.CID,
-					},
-					"answers":   lexiconAnswers,
-					"createdAt": time.Now().Format(time.RFC3339),
+				if len(answer.SelectedOptions) > 0 {
+					lexAnswer["selectedOptions"] = answer.SelectedOptions
 				}
+				if answer.Text != "" {
+					lexAnswer["text"] = answer.Text
+				}
+				if len(answer.Rows) > 0 {
+					lexAnswer["rows"] = lexiconRowAnswers(answer.Rows)
+				}
+				if answer.File != nil {
+					lexAnswer["file"] = answer.File
+				}
+				lexiconAnswers = append(lexiconAnswers, lexAnswer)
+			}
 
-				// Write to PDS
-				pdsURI, pdsCID, err := oauth.CreateRecord(session, "net.openmeet.survey.response", rkey, record)
+			// Build ATProto record matching lexicon format
+			record := map[string]interface{}{
+				"$type": "net.openmeet.survey.response",
+				"subject": map[string]string{
+					"uri": *survey.URI,
+					"cid": *survey.CID,
+				},
+				"answers":   lexiconAnswers,
+				"createdAt": time.Now().Format(time.RFC3339),
+			}
+
+			// Write to PDS (refreshing the token first if needed)
+			pdsURI, pdsCID, err := h.writeRecord(c.Request().Context(), session, "net.openmeet.survey.response", rkey, record)
+			if err != nil {
+				// PDS write failed - continue with a local-only response and queue the record for retry
+				c.Logger().Errorf("Failed to write response to PDS: %v", err)
+				recordPDSWriteFallback(outbox.KindResponse, err)
+				unpublished, err = outbox.NewEntry(outbox.KindResponse, session.DID, "net.openmeet.survey.response", rkey, record)
 				if err != nil {
-					// PDS write failed - log but continue with local-only response
-					c.Logger().Errorf("Failed to write response to PDS: %v", err)
-					uri = nil
-					voterDID = nil
-				} else {
-					// PDS write succeeded - update with actual CID
-					c.Logger().Infof("PDS write succeeded: uri=%s, cid=%s", pdsURI, pdsCID)
-					uri = &pdsURI
-					cid = &pdsCID
+					c.Logger().Errorf("Failed to build outbox entry: %v", err)
 				}
+			} else {
+				// PDS write succeeded - store the record's URI and CID
+				c.Logger().Infof("PDS write succeeded: uri=%s, cid=%s", pdsURI, pdsCID)
+				uri = &pdsURI
+				cid = &pdsCID
+				voterDID = &session.DID
 			}
 		}
 	}
 
+	// Uploaded files are kept on the voter's PDS only if their response record
+	// references them, so responses with files cannot fall back to guest voting
+	if voterDID == nil && models.HasFileAnswers(answers) {
+		component := templates.Error(fileAnswersMessage(survey))
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
 	// If not logged in or PDS write failed, fall back to guest voting
+	var signals *dedup.Signals
+	var score *spam.Score
 	if voterDID == nil {
 		ip := getClientIP(c)
 		userAgent := c.Request().UserAgent()
-		session := models.GenerateVoterSession(survey.ID, ip, userAgent)
+		session := used.voterSession(survey.ID, models.GenerateVoterSession(survey.ID, ip, userAgent))
 		voterSession = &session
 
 		// Check if already voted using session
@@ -716,6 +1555,18 @@ func (h *Handlers) SubmitResponseHTML(c echo.Context) error {
 			component := templates.Error("You have already submitted a response to this survey")
 			return component.Render(c.Request().Context(), c.Response().Writer)
 		}
+
+		// The voter cookie catches second votes from another network
+		signals, err = h.guestSignals(c, survey)
+		if errors.Is(err, errAlreadyVoted) {
+			component := templates.Error("You have already submitted a response to this survey")
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+		if err != nil {
+			component := templates.Error("Failed to check for existing response")
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+		score = h.spamScore(c, survey, formValues.Get(spam.HoneypotField))
 	} else {
 		// Check if already voted using DID
 		existingResponse, err := h.queries.GetResponseBySurveyAndVoter(
@@ -748,16 +1599,171 @@ func (h *Handlers) SubmitResponseHTML(c echo.Context) error {
 		CreatedAt:    now,
 	}
 
-	if err := h.queries.CreateResponse(c.Request().Context(), response); err != nil {
+	// Save with flags on text answers to review before they appear in results
+	if err := h.createResponse(c, response); err != nil {
 		component := templates.Error("Failed to submit response")
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
+	used.complete(c, survey, response)
+	h.saveSignals(c, signals, response)
+	h.saveSpamScore(c, score, response)
+	h.recordResponseMetadata(c, survey, response)
+	h.cache.Invalidate(c.Request().Context(), cache.ResultsKey(survey.ID))
 
 	// Record metrics (no slug label to avoid cardinality explosion)
 	telemetry.SurveyResponsesTotal.WithLabelValues("web").Inc()
+	h.deleteResponseDraft(c, survey)
+
+	// Queue the record if the PDS write failed so the voter can retry publishing it
+	var pending *outbox.Entry
+	if unpublished != nil {
+		unpublished.SurveyID = survey.ID
+		unpublished.ResponseID = &response.ID
+		pending = h.queueRecord(c, unpublished)
+	}
+
+	// Return thank you message with the voter's receipt, kept for retries with
+	// the same idempotency key
+	var page bytes.Buffer
+	if err := templates.ThankYou(slug, h.responseReceipt(response), pending).Render(c.Request().Context(), &page); err != nil {
+		return err
+	}
+	claim.complete(c, response.ID, http.StatusOK, echo.MIMETextHTMLCharsetUTF8, page.Bytes())
+	return c.HTMLBlob(http.StatusOK, page.Bytes())
+}
+
+// reviewTokenField is the form field carrying the signed answers of the review step
+const reviewTokenField = "review_token"
+
+// formAnswers parses a survey form into answers. Unanswered questions are omitted.
+func formAnswers(def *models.SurveyDefinition, formValues url.Values) map[string]models.Answer {
+	answers := make(map[string]models.Answer)
+	for _, question := range def.Questions {
+		if question.Type == models.QuestionTypeSingle {
+			if value := formValues.Get(question.ID); value != "" {
+				answers[question.ID] = models.Answer{
+					SelectedOptions: []string{value},
+				}
+			}
+		} else if question.Type == models.QuestionTypeMulti {
+			if values, ok := formValues[question.ID]; ok && len(values) > 0 {
+				answers[question.ID] = models.Answer{
+					SelectedOptions: values,
+				}
+			}
+		} else if question.Type == models.QuestionTypeText || question.Type.HasRange() {
+			if value := formValues.Get(question.ID); value != "" {
+				answers[question.ID] = models.Answer{
+					Text: value,
+				}
+			}
+		} else if question.Type == models.QuestionTypeMatrix {
+			rows := make(map[string]string)
+			for _, row := range question.Rows {
+				if value := formValues.Get(models.MatrixFieldName(question.ID, row.ID)); value != "" {
+					rows[row.ID] = value
+				}
+			}
+			if len(rows) > 0 {
+				answers[question.ID] = models.Answer{
+					Rows: rows,
+				}
+			}
+		} else if question.Type == models.QuestionTypeFile {
+			// The blob reference rendered by UploadFileHTML; one that does not
+			// parse is left empty to fail validation
+			if value := formValues.Get(question.ID); value != "" {
+				file := &models.Blob{}
+				if err := json.Unmarshal([]byte(value), file); err != nil {
+					file = &models.Blob{}
+				}
+				answers[question.ID] = models.Answer{
+					File: file,
+				}
+			}
+		}
+	}
+	return answers
+}
+
+// lexiconRowAnswers converts the rows of a matrix answer to the lexicon's
+// array of {rowId, optionId}, sorted by row ID
+func lexiconRowAnswers(rows map[string]string) []map[string]string {
+	rowIDs := make([]string, 0, len(rows))
+	for rowID := range rows {
+		rowIDs = append(rowIDs, rowID)
+	}
+	sort.Strings(rowIDs)
+
+	answers := make([]map[string]string, 0, len(rows))
+	for _, rowID := range rowIDs {
+		answers = append(answers, map[string]string{"rowId": rowID, "optionId": rows[rowID]})
+	}
+	return answers
+}
+
+// ReviewResponseHTML shows a voter their answers for confirmation before they
+// are submitted, for surveys with confirmBeforeSubmit. With action=edit it
+// returns the form filled with the reviewed answers instead.
+// POST /surveys/:slug/review
+func (h *Handlers) ReviewResponseHTML(c echo.Context) error {
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			component := templates.Error(h.surveyNotFoundMessage(c.Request().Context(), slug))
+			return component.Render(c.Request().Context(), c.Response().Writer)
+		}
+		component := templates.Error("Failed to load survey")
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	if h.surveyHidden(c, survey) {
+		component := templates.Error(hiddenSurveyMessage)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		component := templates.Error(privateSurveyMessage)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	if survey.IsForeign() {
+		component := templates.Error("This poll was created in another app; votes must be cast there")
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	if survey.IsClosed(time.Now()) {
+		component := templates.Error(closedSurveyMessage)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	formValues, err := c.FormParams()
+	if err != nil {
+		component := templates.Error("Invalid form data")
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+	answers := formAnswers(&survey.Definition, formValues)
+
+	if formValues.Get("action") == "edit" {
+		component := templates.ResponseForm(survey, answers, h.autosaves(survey), nil)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	if err := models.ValidateAnswers(&survey.Definition, answers); err != nil {
+		component := templates.Error("Invalid answers: " + err.Error())
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	token, err := h.reviews.Sign(survey, answers, time.Now())
+	if err != nil {
+		c.Logger().Errorf("Failed to sign reviewed answers: %v", err)
+		component := templates.Error("Failed to review answers")
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
 
-	// Return thank you message
-	component := templates.ThankYou(slug)
+	component := templates.ReviewAnswers(survey, answers, token, h.voteCaptcha(c, 1))
 	return component.Render(c.Request().Context(), c.Response().Writer)
 }
 
@@ -766,15 +1772,23 @@ func (h *Handlers) SubmitResponseHTML(c echo.Context) error {
 func (h *Handlers) GetResultsHTML(c echo.Context) error {
 	slug := c.Param("slug")
 
-	survey, err := h.queries.GetSurveyBySlug(c.Request().Context(), slug)
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return c.String(http.StatusNotFound, "Survey not found")
+			return h.surveyNotFoundHTML(c, slug)
 		}
 		return c.String(http.StatusInternalServerError, "Failed to load survey")
 	}
 
-	results, err := h.queries.GetSurveyResults(c.Request().Context(), survey.ID)
+	if h.surveyHidden(c, survey) {
+		return c.String(http.StatusNotFound, hiddenSurveyMessage)
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		return c.String(http.StatusForbidden, privateSurveyMessage)
+	}
+
+	results, err := h.surveyResults(c.Request().Context(), survey.ID)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Failed to load results")
 	}
@@ -782,8 +1796,22 @@ func (h *Handlers) GetResultsHTML(c echo.Context) error {
 	// Get user and profile from context
 	user, profile := getUserAndProfile(c)
 
+	// Format numbers/dates in the survey's language (or the viewer's, if unset)
+	locale := i18n.Resolve(survey.Definition.Language, c.Request().Header.Get("Accept-Language"))
+
+	author := h.surveyAuthor(c.Request().Context(), survey)
+	verification := h.authorVerification(c.Request().Context(), survey)
+	respondents, moreRespondents, err := h.surveyRespondents(c.Request().Context(), survey)
+	if err != nil {
+		// Log error but don't fail - results are still shown without respondents
+		c.Logger().Errorf("Failed to list respondents: %v", err)
+	}
+
 	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
-	component := templates.SurveyResults(survey, results, user, profile, h.posthogKey)
+	attribution := resultsProvenance(h.provenance, results, time.Now())
+	canManage := h.canManageSurvey(c.Request().Context(), user, survey)
+	coAuthor := user != nil && h.isCoAuthor(c.Request().Context(), user.DID, survey)
+	component := templates.SurveyResults(survey, results, locale, author, verification, respondents, moreRespondents, attribution, canManage, coAuthor, user, profile, h.posthogKey)
 	return component.Render(c.Request().Context(), c.Response().Writer)
 }
 
@@ -792,7 +1820,7 @@ func (h *Handlers) GetResultsHTML(c echo.Context) error {
 func (h *Handlers) GetResultsPartialHTML(c echo.Context) error {
 	slug := c.Param("slug")
 
-	survey, err := h.queries.GetSurveyBySlug(c.Request().Context(), slug)
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return c.String(http.StatusNotFound, "Survey not found")
@@ -800,12 +1828,28 @@ func (h *Handlers) GetResultsPartialHTML(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, "Failed to load survey")
 	}
 
-	results, err := h.queries.GetSurveyResults(c.Request().Context(), survey.ID)
+	if h.surveyHidden(c, survey) {
+		return c.String(http.StatusNotFound, hiddenSurveyMessage)
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		return c.String(http.StatusForbidden, privateSurveyMessage)
+	}
+
+	results, err := h.surveyResults(c.Request().Context(), survey.ID)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Failed to load results")
 	}
 
-	component := templates.ResultsPartial(survey, results)
+	locale := i18n.Resolve(survey.Definition.Language, c.Request().Header.Get("Accept-Language"))
+
+	// HTMX polls this every few seconds; skip re-rendering unchanged results
+	c.Response().Header().Add("Vary", "Accept-Language")
+	if checkNotModified(c, resultsETag(survey, results, locale.Tag)) {
+		return notModified(c)
+	}
+
+	component := templates.ResultsPartial(survey, results, locale)
 	return component.Render(c.Request().Context(), c.Response().Writer)
 }
 
@@ -815,7 +1859,7 @@ func (h *Handlers) PublishResultsHTML(c echo.Context) error {
 	slug := c.Param("slug")
 
 	// Get the survey
-	survey, err := h.queries.GetSurveyBySlug(c.Request().Context(), slug)
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return c.String(http.StatusNotFound, "Survey not found")
@@ -841,48 +1885,33 @@ func (h *Handlers) PublishResultsHTML(c echo.Context) error {
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 
-	// Verify user is the survey author
-	if survey.AuthorDID == nil || *survey.AuthorDID != session.DID {
-		component := templates.Error("Only the survey author can publish results")
+	// Verify user manages the survey: its author, or an editor of its organization.
+	// Co-authors ask the author to publish the results to the author's PDS instead.
+	if !h.canManageSurveyAs(c.Request().Context(), session.DID, survey) {
+		if h.isCoAuthor(c.Request().Context(), session.DID, survey) {
+			if _, err := h.requestPublish(c.Request().Context(), survey, session.DID); err != nil && !errors.Is(err, coauthor.ErrRequestPending) {
+				c.Logger().Errorf("Failed to request publication of results: %v", err)
+				component := templates.Error("Failed to ask the author to publish results")
+				return component.Render(c.Request().Context(), c.Response().Writer)
+			}
+			return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+slug+"/coauthors"))
+		}
+		component := templates.Error("Only the survey author and editors of its organization can publish results")
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 
-	// Get aggregated results from database
+	// Get aggregated results from database (uncached, these are published)
 	results, err := h.queries.GetSurveyResults(c.Request().Context(), survey.ID)
 	if err != nil {
 		component := templates.Error("Failed to aggregate results")
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 
-	// Build question results for the lexicon format
-	lexiconQuestionResults := make([]map[string]interface{}, 0, len(results.QuestionResults))
-	for _, qResult := range results.QuestionResults {
-		// Build option counts array
-		optionCounts := make([]map[string]interface{}, 0, len(qResult.OptionCounts))
-		for optionID, count := range qResult.OptionCounts {
-			optionCounts = append(optionCounts, map[string]interface{}{
-				"optionId": optionID,
-				"count":    count,
-			})
-		}
-
-		lexiconQuestionResults = append(lexiconQuestionResults, map[string]interface{}{
-			"questionId":        qResult.QuestionID,
-			"optionCounts":      optionCounts,
-			"textResponseCount": len(qResult.TextAnswers),
-		})
-	}
-
-	// Build ATProto results record matching lexicon format
-	record := map[string]interface{}{
-		"$type": "net.openmeet.survey.results",
-		"subject": map[string]string{
-			"uri": *survey.URI,
-			"cid": *survey.CID,
-		},
-		"totalVotes":      results.TotalVotes,
-		"questionResults": lexiconQuestionResults,
-		"finalizedAt":     time.Now().Format(time.RFC3339),
+	record, err := h.publishedResultsRecord(survey, results, time.Now())
+	if err != nil {
+		c.Logger().Errorf("Failed to sign results: %v", err)
+		component := templates.Error("Failed to sign results")
+		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 
 	// Generate TID for results rkey
@@ -895,13 +1924,14 @@ func (h *Handlers) PublishResultsHTML(c echo.Context) error {
 		if h.oauthStorage != nil {
 			_ = h.oauthStorage.DeleteSession(c.Request().Context(), session.ID)
 		}
-		c.SetCookie(&http.Cookie{Name: "session", Value: "", MaxAge: -1, Path: "/"})
+		oauth.ClearSessionCookie(c)
 		component := templates.Error("Session expired. Please log in again.")
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
 
 	// Write to PDS
-	resultsURI, resultsCID, err := oauth.CreateRecord(session, "net.openmeet.survey.results", rkey, record)
+	resultsURI, resultsCID, err := oauth.CreateRecord(c.Request().Context(), session, "net.openmeet.survey.results", rkey, record)
+	recordPDSWrite("create", err)
 	if err != nil {
 		c.Logger().Errorf("Failed to write results to PDS: %v", err)
 		component := templates.Error("Failed to publish results to your PDS")
@@ -914,9 +1944,97 @@ func (h *Handlers) PublishResultsHTML(c echo.Context) error {
 		component := templates.Error("Failed to save results reference")
 		return component.Render(c.Request().Context(), c.Response().Writer)
 	}
+	h.cache.Invalidate(c.Request().Context(), cache.SurveyKey(survey.Slug))
+
+	// Offer to share the results to Bluesky, or redirect to the results page
+	return c.Redirect(http.StatusSeeOther, templates.AppPath(blueskyRedirect(survey, "/surveys/"+slug+"/results")))
+}
+
+// resultsRecord builds the net.openmeet.survey.results record of a survey's
+// results, aggregated at the given time
+func (h *Handlers) resultsRecord(survey *models.Survey, results *models.SurveyResults, aggregatedAt time.Time) map[string]interface{} {
+	// Build question results for the lexicon format, in question order
+	lexiconQuestionResults := make([]map[string]interface{}, 0, len(results.QuestionResults))
+	for _, qResult := range results.OrderedQuestionResults() {
+		// Build option counts array in option order
+		optionCounts := make([]map[string]interface{}, 0, len(qResult.OptionCounts))
+		for _, optionID := range orderedOptionIDs(survey, qResult) {
+			optionCounts = append(optionCounts, map[string]interface{}{
+				"optionId": optionID,
+				"count":    qResult.OptionCounts[optionID],
+			})
+		}
+
+		lexiconQuestionResult := map[string]interface{}{
+			"questionId":        qResult.QuestionID,
+			"optionCounts":      optionCounts,
+			"textResponseCount": len(qResult.TextAnswers),
+		}
+		if len(qResult.Histogram) > 0 {
+			buckets := make([]map[string]interface{}, 0, len(qResult.Histogram))
+			for _, bucket := range qResult.Histogram {
+				// Records have no floats, so bounds are decimal strings
+				buckets = append(buckets, map[string]interface{}{
+					"min":   strconv.FormatFloat(bucket.Min, 'f', -1, 64),
+					"max":   strconv.FormatFloat(bucket.Max, 'f', -1, 64),
+					"count": bucket.Count,
+				})
+			}
+			lexiconQuestionResult["histogram"] = buckets
+		}
+		if qResult.Dates != nil {
+			lexiconQuestionResult["dates"] = map[string]interface{}{
+				"earliest":        qResult.Dates.Earliest,
+				"latest":          qResult.Dates.Latest,
+				"mostCommon":      qResult.Dates.MostCommon,
+				"mostCommonCount": qResult.Dates.MostCommonCount,
+			}
+		}
+		if len(qResult.RowCounts) > 0 {
+			rowCounts := make([]map[string]interface{}, 0, len(qResult.RowCounts))
+			for _, rowID := range orderedRowIDs(survey, qResult) {
+				counts := make([]map[string]interface{}, 0, len(qResult.RowCounts[rowID]))
+				for _, optionID := range orderedIDs(surveyQuestion(survey, qResult.QuestionID).Options, qResult.RowCounts[rowID]) {
+					counts = append(counts, map[string]interface{}{
+						"optionId": optionID,
+						"count":    qResult.RowCounts[rowID][optionID],
+					})
+				}
+				rowCounts = append(rowCounts, map[string]interface{}{
+					"rowId":        rowID,
+					"optionCounts": counts,
+				})
+			}
+			lexiconQuestionResult["rowCounts"] = rowCounts
+		}
+		if qResult.Ordinal > 0 {
+			lexiconQuestionResult["ordinal"] = qResult.Ordinal
+		}
+		lexiconQuestionResults = append(lexiconQuestionResults, lexiconQuestionResult)
+	}
+
+	// Build ATProto results record matching lexicon format, with provenance so
+	// results from different AppViews aggregating the same survey can be told apart
+	return map[string]interface{}{
+		"$type": "net.openmeet.survey.results",
+		"subject": map[string]string{
+			"uri": *survey.URI,
+			"cid": *survey.CID,
+		},
+		"totalVotes":      results.TotalVotes,
+		"questionResults": lexiconQuestionResults,
+		"finalizedAt":     aggregatedAt.Format(time.RFC3339),
+		"provenance":      resultsProvenance(h.provenance, results, aggregatedAt).Record(),
+	}
+}
 
-	// Redirect to results page
-	return c.Redirect(http.StatusSeeOther, "/surveys/"+slug+"/results")
+// resultsProvenance describes how results were aggregated, including the
+// responses the survey's settings excluded from them
+func resultsProvenance(config provenance.Config, results *models.SurveyResults, aggregatedAt time.Time) *provenance.Provenance {
+	return provenance.New(config, aggregatedAt, provenance.Exclusions{
+		Duplicates: results.ExcludedDuplicates,
+		Spam:       results.ExcludedSpam,
+	})
 }
 
 // Health Check Handlers
@@ -927,6 +2045,12 @@ type DBChecker interface {
 	PingContext(ctx context.Context) error
 }
 
+// PoolStatser reports connection pool stats; *sql.DB implements it. A
+// DBChecker that also implements it has its pool saturation checked.
+type PoolStatser interface {
+	Stats() sql.DBStats
+}
+
 // HealthHandlers holds health check dependencies
 type HealthHandlers struct {
 	db DBChecker
@@ -963,12 +2087,28 @@ func (hh *HealthHandlers) Health(c echo.Context) error {
 	})
 }
 
-// Readiness returns a readiness check with DB connectivity
+// Readiness returns a readiness check with DB connectivity and pool saturation
 // GET /health/ready
 func (hh *HealthHandlers) Readiness(c echo.Context) error {
 	checks := make(map[string]string)
 	status := "ready"
 
+	// A saturated pool makes requests wait for connections, so load balancers
+	// should prefer other instances; a ping would wait too, so it is skipped
+	if pool, ok := hh.db.(PoolStatser); ok {
+		stats := pool.Stats()
+		if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+			checks["database_pool"] = fmt.Sprintf("saturated: %d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+			checks["database"] = "unchecked"
+			return c.JSON(http.StatusServiceUnavailable, ReadinessResponse{
+				Status:  "not_ready",
+				Service: "survey-api",
+				Checks:  checks,
+			})
+		}
+		checks["database_pool"] = fmt.Sprintf("healthy: %d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+	}
+
 	// Check database connection
 	if err := hh.db.PingContext(c.Request().Context()); err != nil {
 		checks["database"] = "unhealthy: " + err.Error()
@@ -1008,9 +2148,14 @@ func getUserAndProfile(c echo.Context) (*oauth.User, *oauth.Profile) {
 	return user, profile
 }
 
-// LandingPage renders the landing page with live statistics
+// LandingPage renders the landing page with live statistics, or the landing
+// page of the tenant served on the request's host
 // GET /
 func (h *Handlers) LandingPage(c echo.Context) error {
+	if t := tenant.FromContext(c.Request().Context()); t != nil {
+		return h.tenantLandingPage(c, t)
+	}
+
 	// Get statistics
 	stats, err := h.queries.GetStats(c.Request().Context())
 	if err != nil {
@@ -1020,8 +2165,10 @@ func (h *Handlers) LandingPage(c echo.Context) error {
 	// Get user and profile from context
 	user, profile := getUserAndProfile(c)
 
+	featured, trendingSurveys := h.landingSurveys(c)
+
 	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
-	component := templates.LandingPage(stats, user, profile, h.supportURL, h.posthogKey)
+	component := templates.LandingPage(stats, featured, trendingSurveys, user, profile, h.supportURL, h.posthogKey)
 	return component.Render(c.Request().Context(), c.Response().Writer)
 }
 
@@ -1092,7 +2239,7 @@ func (h *Handlers) MyDataCollectionHTML(c echo.Context) error {
 	limit := 50
 
 	// Fetch records from PDS
-	records, err := oauth.ListRecords(session.PDSUrl, session.DID, collection, cursor, limit)
+	records, err := oauth.ListRecords(c.Request().Context(), session.PDSUrl, session.DID, collection, cursor, limit)
 	if err != nil {
 		c.Logger().Errorf("Failed to list records from %s: %v", collection, err)
 		return c.String(http.StatusInternalServerError, "Failed to fetch records: "+err.Error())
@@ -1101,9 +2248,18 @@ func (h *Handlers) MyDataCollectionHTML(c echo.Context) error {
 	// Get profile
 	_, profile := getUserAndProfile(c)
 
+	// Resolve the authors of surveys referenced by records (e.g. responses)
+	var dids []string
+	for _, record := range records.Records {
+		if did := record.SubjectDID(); did != "" {
+			dids = append(dids, did)
+		}
+	}
+	identities := h.identities.Resolve(c.Request().Context(), dids)
+
 	// Render collection page
 	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
-	component := templates.MyDataCollectionPage(user, profile, collection, records.Records, records.Cursor, h.posthogKey)
+	component := templates.MyDataCollectionPage(user, profile, collection, records.Records, records.Cursor, identities, h.posthogKey)
 	return component.Render(c.Request().Context(), c.Response().Writer)
 }
 
@@ -1136,7 +2292,7 @@ func (h *Handlers) MyDataRecordHTML(c echo.Context) error {
 
 	// Fetch all records to find the specific one
 	// (ATProto doesn't have a getRecord endpoint for public repos)
-	records, err := oauth.ListRecords(session.PDSUrl, session.DID, collection, "", 100)
+	records, err := oauth.ListRecords(c.Request().Context(), session.PDSUrl, session.DID, collection, "", 100)
 	if err != nil {
 		c.Logger().Errorf("Failed to list records from %s: %v", collection, err)
 		return c.String(http.StatusInternalServerError, "Failed to fetch records: "+err.Error())
@@ -1205,19 +2361,20 @@ func (h *Handlers) UpdateRecordHTML(c echo.Context) error {
 		if h.oauthStorage != nil {
 			_ = h.oauthStorage.DeleteSession(c.Request().Context(), session.ID)
 		}
-		c.SetCookie(&http.Cookie{Name: "session", Value: "", MaxAge: -1, Path: "/"})
+		oauth.ClearSessionCookie(c)
 		return c.String(http.StatusUnauthorized, "Session expired. Please log in again.")
 	}
 
 	// Update record on PDS
-	_, _, err = oauth.UpdateRecord(session, collection, rkey, recordData)
+	_, _, err = oauth.UpdateRecord(c.Request().Context(), session, collection, rkey, recordData)
+	recordPDSWrite("update", err)
 	if err != nil {
 		c.Logger().Errorf("Failed to update record %s/%s: %v", collection, rkey, err)
 		return c.String(http.StatusInternalServerError, "Failed to update record: "+err.Error())
 	}
 
 	// Redirect back to collection view
-	return c.Redirect(http.StatusSeeOther, "/my-data/"+collection)
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/my-data/"+collection))
 }
 
 // ShortSlugURL provides a short URL redirect to survey by slug
@@ -1226,16 +2383,16 @@ func (h *Handlers) ShortSlugURL(c echo.Context) error {
 	slug := c.Param("slug")
 
 	// Verify survey exists
-	survey, err := h.queries.GetSurveyBySlug(c.Request().Context(), slug)
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return c.String(http.StatusNotFound, "Survey not found")
+			return h.surveyNotFoundHTML(c, slug)
 		}
 		return InternalServerError(c, "Failed to retrieve survey", err)
 	}
 
 	// Redirect to full survey URL
-	return c.Redirect(http.StatusSeeOther, "/surveys/"+survey.Slug)
+	return c.Redirect(http.StatusSeeOther, withShareToken(c, templates.AppPath("/surveys/"+survey.Slug)))
 }
 
 // ATProtoURL provides canonical AT Protocol URL redirect
@@ -1251,13 +2408,17 @@ func (h *Handlers) ATProtoURL(c echo.Context) error {
 	survey, err := h.queries.GetSurveyByURI(c.Request().Context(), uri)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			// The deleted survey's page explains what happened to it
+			if tombstone, err := h.queries.GetSurveyTombstoneByURI(c.Request().Context(), uri); err == nil {
+				return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+tombstone.Slug))
+			}
 			return c.String(http.StatusNotFound, "Survey not found")
 		}
 		return InternalServerError(c, "Failed to retrieve survey", err)
 	}
 
 	// Redirect to survey by slug
-	return c.Redirect(http.StatusSeeOther, "/surveys/"+survey.Slug)
+	return c.Redirect(http.StatusSeeOther, withShareToken(c, templates.AppPath("/surveys/"+survey.Slug)))
 }
 
 // DeleteRecordsHTML deletes multiple records via form submission
@@ -1298,13 +2459,14 @@ func (h *Handlers) DeleteRecordsHTML(c echo.Context) error {
 		if h.oauthStorage != nil {
 			_ = h.oauthStorage.DeleteSession(c.Request().Context(), session.ID)
 		}
-		c.SetCookie(&http.Cookie{Name: "session", Value: "", MaxAge: -1, Path: "/"})
+		oauth.ClearSessionCookie(c)
 		return c.String(http.StatusUnauthorized, "Session expired. Please log in again.")
 	}
 
 	// Delete each record
 	for _, rkey := range rkeys {
-		err := oauth.DeleteRecord(session, collection, rkey)
+		err := oauth.DeleteRecord(c.Request().Context(), session, collection, rkey)
+		recordPDSWrite("delete", err)
 		if err != nil {
 			// Continue with other deletions even if one fails
 			c.Logger().Errorf("Failed to delete record %s/%s: %v", collection, rkey, err)
@@ -1312,7 +2474,7 @@ func (h *Handlers) DeleteRecordsHTML(c echo.Context) error {
 	}
 
 	// Redirect back to collection view
-	return c.Redirect(http.StatusSeeOther, "/my-data/"+collection)
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/my-data/"+collection))
 }
 
 // GenerateSurvey handles AI survey generation requests
@@ -1321,58 +2483,65 @@ func (h *Handlers) GenerateSurvey(c echo.Context) error {
 	// Parse request
 	var req GenerateSurveyRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Details: err.Error(),
-		})
+		return Problem(c, problem.InvalidRequestBody, err.Error())
 	}
 
 	// Check consent
 	if !req.Consent {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "AI generation requires explicit consent for OpenAI processing",
-		})
+		return Problem(c, problem.ValidationFailed, "AI generation requires explicit consent for OpenAI processing")
 	}
 
 	// Validate description
 	if strings.TrimSpace(req.Description) == "" {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "Description cannot be empty",
-		})
+		return Problem(c, problem.ValidationFailed, "Description cannot be empty")
 	}
 
 	// Check if generator is configured
 	if h.generator == nil {
-		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error: "AI survey generation is not available",
-		})
+		return Problem(c, problem.ServiceUnavailable, "AI survey generation is not available")
 	}
 
 	// Check if rate limiter is configured
 	if h.generatorRL == nil {
-		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error: "AI survey generation is not available",
-		})
+		return Problem(c, problem.ServiceUnavailable, "AI survey generation is not available")
 	}
 
 	// Get user context (authenticated vs anonymous)
 	user := oauth.GetUser(c)
+
+	// Requests with an API key count as its owner, and are attributed to the key in the generation log
+	if key := APIKeyFromContext(c); key != nil {
+		user = &oauth.User{DID: key.OwnerDID}
+		c.SetRequest(c.Request().WithContext(generator.WithAPIKeyID(c.Request().Context(), key.ID)))
+	}
+
 	var allowed bool
+	var quota generator.Quota
 	var userID string
 	var userType string
 
 	if user != nil {
 		// Authenticated user - check DID-based rate limit
 		allowed = h.generatorRL.AllowAuthenticated(user.DID)
+		quota = h.generatorRL.AuthenticatedQuota(user.DID)
 		userID = user.DID
 		userType = "authenticated"
 	} else {
+		// Anonymous users close to the limit must solve a CAPTCHA first
+		if h.generationCaptcha(c, 0) != nil {
+			if err := h.verifyCaptcha(c); err != nil {
+				return captchaRequired(c, err)
+			}
+		}
+
 		// Anonymous user - check IP-based rate limit
 		ip := getClientIP(c)
 		allowed = h.generatorRL.AllowAnonymous(ip)
+		quota = h.generatorRL.AnonymousQuota(ip)
 		userID = ip
 		userType = "anonymous"
 	}
+	limitStatus := quotaStatus(quota, time.Now())
 
 	if !allowed {
 		// Record rate limit hit metric
@@ -1399,10 +2568,9 @@ func (h *Handlers) GenerateSurvey(c echo.Context) error {
 			)
 		}
 
-		return c.JSON(http.StatusTooManyRequests, ErrorResponse{
-			Error: "Rate limit exceeded for AI generation. Please try again later.",
-		})
+		return rateLimitExceeded(c, limitStatus, "Rate limit exceeded for AI generation. Please try again later.")
 	}
+	setRateLimitHeaders(c, limitStatus)
 
 	// Validate user input first (before building combined prompt)
 	if err := h.generator.ValidateInput(req.Description); err != nil {
@@ -1422,9 +2590,7 @@ func (h *Handlers) GenerateSurvey(c echo.Context) error {
 			)
 		}
 
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: err.Error(),
-		})
+		return Problem(c, problem.ValidationFailed, err.Error())
 	}
 
 	// Build prompt
@@ -1491,22 +2657,13 @@ func (h *Handlers) GenerateSurvey(c echo.Context) error {
 
 			// Return specific error response
 			if errors.Is(err, generator.ErrInputTooLong) {
-				return c.JSON(http.StatusBadRequest, ErrorResponse{
-					Error:   "Input too long",
-					Details: err.Error(),
-				})
+				return Problem(c, problem.ValidationFailed, "Input too long: "+err.Error())
 			}
 			if errors.Is(err, generator.ErrEmptyInput) {
-				return c.JSON(http.StatusBadRequest, ErrorResponse{
-					Error:   "Input cannot be empty",
-					Details: err.Error(),
-				})
+				return Problem(c, problem.ValidationFailed, "Input cannot be empty: "+err.Error())
 			}
 			if errors.Is(err, generator.ErrBlockedPattern) {
-				return c.JSON(http.StatusBadRequest, ErrorResponse{
-					Error:   "Input contains blocked pattern",
-					Details: "Your input was flagged for potentially unsafe content",
-				})
+				return Problem(c, problem.ValidationFailed, "Input contains blocked pattern: Your input was flagged for potentially unsafe content")
 			}
 		}
 
@@ -1531,9 +2688,7 @@ func (h *Handlers) GenerateSurvey(c echo.Context) error {
 				)
 			}
 
-			return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-				Error: "AI generation budget exceeded. Please try again later.",
-			})
+			return Problem(c, problem.ServiceUnavailable, "AI generation budget exceeded. Please try again later.")
 		}
 
 		// Generic error (includes "invalid LLM output" errors)
@@ -1558,10 +2713,7 @@ func (h *Handlers) GenerateSurvey(c echo.Context) error {
 		}
 
 		c.Logger().Errorf("AI generation failed: %v", err)
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "AI generation failed",
-			Details: err.Error(),
-		})
+		return Problem(c, problem.GenerationFailed, err.Error())
 	}
 
 	// Record success metrics
@@ -1591,6 +2743,6 @@ func (h *Handlers) GenerateSurvey(c echo.Context) error {
 		Definition:   result.Definition,
 		TokensUsed:   result.InputTokens + result.OutputTokens,
 		Cost:         result.EstimatedCost,
-		NeedsCaptcha: false, // MVP: no captcha implementation yet
+		NeedsCaptcha: user == nil && h.generationCaptcha(c, 0) != nil,
 	})
 }
@@ -3,152 +3,36 @@ package api
 import (
 	"bytes"
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/cache"
 	"github.com/openmeet-team/survey/internal/models"
 	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/receipt"
+	"github.com/openmeet-team/survey/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-// MockQueries implements a mock version of db.Queries for testing
-type MockQueries struct {
-	surveys         map[string]*models.Survey
-	surveysByURI    map[string]*models.Survey // URI -> survey
-	slugs           map[string]bool
-	responses       map[uuid.UUID]*models.Response
-	responsesBySurvey map[uuid.UUID]map[string]*models.Response // surveyID -> voterSession -> response
-}
-
-func NewMockQueries() *MockQueries {
-	return &MockQueries{
-		surveys:           make(map[string]*models.Survey),
-		surveysByURI:      make(map[string]*models.Survey),
-		slugs:             make(map[string]bool),
-		responses:         make(map[uuid.UUID]*models.Response),
-		responsesBySurvey: make(map[uuid.UUID]map[string]*models.Response),
-	}
-}
-
-func (m *MockQueries) CreateSurvey(ctx context.Context, s *models.Survey) error {
-	m.surveys[s.Slug] = s
-	m.slugs[s.Slug] = true
-	if s.URI != nil {
-		m.surveysByURI[*s.URI] = s
-	}
-	m.responsesBySurvey[s.ID] = make(map[string]*models.Response)
-	return nil
-}
-
-func (m *MockQueries) GetSurveyBySlug(ctx context.Context, slug string) (*models.Survey, error) {
-	if s, ok := m.surveys[slug]; ok {
-		return s, nil
-	}
-	return nil, sql.ErrNoRows
-}
-
-func (m *MockQueries) GetSurveyByURI(ctx context.Context, uri string) (*models.Survey, error) {
-	if s, ok := m.surveysByURI[uri]; ok {
-		return s, nil
-	}
-	return nil, sql.ErrNoRows
-}
-
-func (m *MockQueries) ListSurveys(ctx context.Context, limit, offset int) ([]*models.Survey, error) {
-	var surveys []*models.Survey
-	for _, s := range m.surveys {
-		surveys = append(surveys, s)
-	}
-	return surveys, nil
-}
-
-func (m *MockQueries) SlugExists(ctx context.Context, slug string) (bool, error) {
-	return m.slugs[slug], nil
-}
-
-func (m *MockQueries) CreateResponse(ctx context.Context, r *models.Response) error {
-	m.responses[r.ID] = r
-
-	// Track by voter session
-	if r.VoterSession != nil {
-		m.responsesBySurvey[r.SurveyID][*r.VoterSession] = r
-	}
-
-	return nil
-}
-
-func (m *MockQueries) GetResponseBySurveyAndVoter(ctx context.Context, surveyID uuid.UUID, voterDID, voterSession string) (*models.Response, error) {
-	if voterSession != "" {
-		if surveyResponses, ok := m.responsesBySurvey[surveyID]; ok {
-			if resp, exists := surveyResponses[voterSession]; exists {
-				return resp, nil
-			}
-		}
-	}
-	return nil, nil // No existing response
-}
-
-func (m *MockQueries) GetSurveyResults(ctx context.Context, surveyID uuid.UUID) (*models.SurveyResults, error) {
-	// Simple mock implementation
-	return &models.SurveyResults{
-		SurveyID:        surveyID,
-		TotalVotes:      0,
-		QuestionResults: make(map[string]*models.QuestionResult),
-	}, nil
-}
-
-func (m *MockQueries) UpdateSurveyResults(ctx context.Context, surveyID uuid.UUID, resultsURI, resultsCID string) error {
-	// Find and update the survey
-	for _, survey := range m.surveys {
-		if survey.ID == surveyID {
-			survey.ResultsURI = &resultsURI
-			survey.ResultsCID = &resultsCID
-			return nil
-		}
-	}
-	return fmt.Errorf("survey not found")
-}
-
-func (m *MockQueries) GetStats(ctx context.Context) (*models.Stats, error) {
-	// Count surveys
-	surveyCount := len(m.surveys)
+// MockQueries is the in-memory QueriesInterface shared with other packages' tests
+type MockQueries = testutil.MemoryQueries
 
-	// Count total responses
-	responseCount := len(m.responses)
+// Compile-time check that the in-memory queries keep up with QueriesInterface
+var _ QueriesInterface = (*MockQueries)(nil)
 
-	// Count unique authenticated users (DIDs)
-	uniqueDIDs := make(map[string]bool)
-	for _, response := range m.responses {
-		if response.VoterDID != nil && *response.VoterDID != "" {
-			uniqueDIDs[*response.VoterDID] = true
-		}
-	}
-
-	// Count unique anonymous users (sessions where voter_did is null)
-	uniqueSessions := make(map[string]bool)
-	for _, response := range m.responses {
-		if response.VoterDID == nil && response.VoterSession != nil && *response.VoterSession != "" {
-			uniqueSessions[*response.VoterSession] = true
-		}
-	}
-
-	// Total unique users = authenticated + anonymous
-	uniqueUserCount := len(uniqueDIDs) + len(uniqueSessions)
-
-	return &models.Stats{
-		SurveyCount:     surveyCount,
-		ResponseCount:   responseCount,
-		UniqueUserCount: uniqueUserCount,
-	}, nil
+func NewMockQueries() *MockQueries {
+	return testutil.NewMemoryQueries()
 }
 
 // Test Helpers
@@ -203,6 +87,147 @@ func TestCreateSurvey_WithJSONDefinition(t *testing.T) {
 	assert.Equal(t, "What is your favorite color?", resp.Definition.Questions[0].Text)
 }
 
+func TestCreateSurvey_StripsImages(t *testing.T) {
+	e, _, h := setupTest()
+
+	definition := `{
+		"questions": [
+			{
+				"id": "q1",
+				"text": "Which logo?",
+				"type": "text",
+				"image": {"image": {"$type": "blob", "ref": {"$link": "` + testImageCID + `"}, "mimeType": "image/png", "size": 1234}, "alt": "Logos"}
+			}
+		]
+	}`
+
+	body, _ := json.Marshal(CreateSurveyRequest{Slug: "logos", Definition: definition})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	require.NoError(t, h.CreateSurvey(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	// API surveys have no PDS record to hold the image blob
+	var resp SurveyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Nil(t, resp.Definition.Questions[0].Image)
+}
+
+func TestValidateSurvey(t *testing.T) {
+	e, mq, h := setupTest()
+
+	call := func(definition string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(ValidateSurveyRequest{Definition: definition})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/validate", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		require.NoError(t, h.ValidateSurvey(e.NewContext(req, rec)))
+		return rec
+	}
+
+	t.Run("reports errors and warnings", func(t *testing.T) {
+		rec := call(`{
+  "questions": [
+    {"id": "q1", "text": "Lunch?", "type": "single", "options": [{"id": "a", "text": "Pizza"}]}
+  ]
+}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var report models.LintReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.False(t, report.Valid)
+		require.Len(t, report.Errors, 1)
+		assert.Equal(t, "/questions/0/options", report.Errors[0].Path)
+		assert.Equal(t, 3, report.Errors[0].Line)
+		assert.Contains(t, report.Errors[0].Suggestion, "1 option")
+		assert.NotEmpty(t, report.Warnings)
+		assert.Empty(t, mq.Surveys, "nothing is created")
+	})
+
+	t.Run("requires a definition", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, call("  ").Code)
+	})
+}
+
+func TestCreateSurvey_WithAPIKeyIsOwned(t *testing.T) {
+	e, mq, h := setupTest()
+
+	body, _ := json.Marshal(CreateSurveyRequest{
+		Slug:       "owned",
+		Definition: `{"questions": [{"id": "q1", "text": "Why?", "type": "text"}]}`,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("api_key", &apikey.Key{ID: uuid.New(), OwnerDID: "did:plc:alice"})
+	require.NoError(t, h.CreateSurvey(c))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	require.Contains(t, mq.Surveys, "owned")
+	require.NotNil(t, mq.Surveys["owned"].AuthorDID)
+	assert.Equal(t, "did:plc:alice", *mq.Surveys["owned"].AuthorDID)
+}
+
+func TestListOwnSurveys(t *testing.T) {
+	e, _, h := setupTest()
+	alice := "did:plc:alice"
+	h.SetAccountData(&mockAccountData{surveys: []*models.Survey{{ID: uuid.New(), Slug: "lunch", Title: "Lunch?", AuthorDID: &alice}}})
+
+	call := func(key *apikey.Key) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/surveys", nil), rec)
+		if key != nil {
+			c.Set("api_key", key)
+		}
+		require.NoError(t, h.ListOwnSurveys(c))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call(nil).Code, "there is no public listing")
+
+	rec := call(&apikey.Key{ID: uuid.New(), OwnerDID: alice})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var surveys []SurveyListResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &surveys))
+	require.Len(t, surveys, 1)
+	assert.Equal(t, "lunch", surveys[0].Slug)
+}
+
+func TestGetDefinitionSchema(t *testing.T) {
+	e, _, h := setupTest()
+
+	req := httptest.NewRequest(http.MethodGet, "http://survey.example/api/v1/schema/survey-definition.json", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, h.GetDefinitionSchema(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &schema))
+	assert.Equal(t, "http://survey.example/api/v1/schema/survey-definition.json", schema["$id"])
+	assert.Contains(t, schema["$defs"], "Question")
+	assert.Equal(t, models.DefinitionSchemaID, models.DefinitionSchema().ID, "the shared schema is not modified")
+}
+
+func TestCreateSurvey_RejectsDefinitionsOutsideSchema(t *testing.T) {
+	e, mq, h := setupTest()
+
+	body, _ := json.Marshal(CreateSurveyRequest{
+		Slug:       "colors",
+		Definition: `{"questions": [{"id": "q1", "text": "Color?", "type": "text", "placeholder": "Red"}]}`,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	require.NoError(t, h.CreateSurvey(e.NewContext(req, rec)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `unknown property \"placeholder\"`)
+	assert.Empty(t, mq.Surveys)
+}
+
 func TestCreateSurvey_WithYAMLDefinition(t *testing.T) {
 	e, _, h := setupTest()
 
@@ -324,10 +349,10 @@ func TestCreateSurvey_DuplicateSlug(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusConflict, rec.Code)
 
-	var errResp ErrorResponse
+	var errResp problem.Problem
 	err = json.Unmarshal(rec.Body.Bytes(), &errResp)
 	require.NoError(t, err)
-	assert.Contains(t, errResp.Error, "slug already exists")
+	assert.Equal(t, problem.SlugTaken, errResp.Code)
 }
 
 func TestGetSurvey_Success(t *testing.T) {
@@ -522,10 +547,10 @@ func TestSubmitResponse_DuplicateVote(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusConflict, rec2.Code)
 
-	var errResp ErrorResponse
+	var errResp problem.Problem
 	err = json.Unmarshal(rec2.Body.Bytes(), &errResp)
 	require.NoError(t, err)
-	assert.Contains(t, errResp.Error, "Already voted")
+	assert.Equal(t, problem.AlreadyVoted, errResp.Code)
 }
 
 func TestSubmitResponse_InvalidAnswers(t *testing.T) {
@@ -1037,8 +1062,12 @@ func TestGetStats_DeduplicatesAnonymousSessions(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 	mq.CreateSurvey(context.Background(), survey)
+	other := *survey
+	other.ID = uuid.New()
+	other.Slug = "other-survey"
+	mq.CreateSurvey(context.Background(), &other)
 
-	// Create multiple responses from same anonymous session
+	// Create responses from same anonymous session to both surveys
 	session1 := "session_abc123"
 	response1 := &models.Response{
 		ID:           uuid.New(),
@@ -1051,7 +1080,7 @@ func TestGetStats_DeduplicatesAnonymousSessions(t *testing.T) {
 
 	response2 := &models.Response{
 		ID:           uuid.New(),
-		SurveyID:     survey.ID,
+		SurveyID:     other.ID,
 		VoterSession: &session1, // Same session
 		Answers:      map[string]models.Answer{"q1": {SelectedOptions: []string{"a"}}},
 		CreatedAt:    time.Now(),
@@ -1072,7 +1101,7 @@ func TestGetStats_DeduplicatesAnonymousSessions(t *testing.T) {
 	// Test GetStats - should count only 2 unique anonymous users despite 3 responses
 	stats, err := mq.GetStats(context.Background())
 	require.NoError(t, err)
-	assert.Equal(t, 1, stats.SurveyCount, "Should have 1 survey")
+	assert.Equal(t, 2, stats.SurveyCount, "Should have 2 surveys")
 	assert.Equal(t, 3, stats.ResponseCount, "Should have 3 responses")
 	assert.Equal(t, 2, stats.UniqueUserCount, "Should have 2 unique anonymous users")
 }
@@ -1504,3 +1533,461 @@ func TestATProtoURL_NotFound(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 }
+
+func setupExportTest(t *testing.T) (*echo.Echo, *MockQueries, *Handlers, *models.Survey) {
+	e, mq, h := setupTest()
+
+	authorDID := "did:plc:author"
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		AuthorDID: &authorDID,
+		Slug:      "export-survey",
+		Title:     "Export Survey",
+		Version:   2,
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "color", Text: "Favorite color?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "red", Text: "Red"}, {ID: "blue", Text: "Blue"}}},
+				{ID: "why", Text: "Why?", Type: models.QuestionTypeText},
+			},
+		},
+	}
+	require.NoError(t, mq.CreateSurvey(context.Background(), survey))
+
+	// Version 1 asked "why" before "color"
+	mq.QuestionOrdinals[survey.ID] = map[int]map[string]int{
+		1: {"why": 1, "color": 2},
+		2: {"color": 1, "why": 2},
+	}
+
+	voterDID := "did:plc:voter"
+	v1, v2 := 1, 2
+	now := time.Now()
+	require.NoError(t, mq.CreateResponse(context.Background(), &models.Response{
+		ID:            uuid.New(),
+		SurveyID:      survey.ID,
+		VoterDID:      &voterDID,
+		Answers:       map[string]models.Answer{"why": {Text: "Calm"}, "color": {SelectedOptions: []string{"blue"}}, "old": {Text: "gone"}},
+		SurveyVersion: &v1,
+		CreatedAt:     now.Add(-time.Hour),
+	}))
+	require.NoError(t, mq.CreateResponse(context.Background(), &models.Response{
+		ID:            uuid.New(),
+		SurveyID:      survey.ID,
+		Answers:       map[string]models.Answer{"color": {SelectedOptions: []string{"red"}}},
+		SurveyVersion: &v2,
+		CreatedAt:     now,
+	}))
+
+	return e, mq, h, survey
+}
+
+func newExportContext(e *echo.Echo, slug, format string, user *oauth.User) (echo.Context, *httptest.ResponseRecorder) {
+	return newExportContextWithQuery(e, slug, url.Values{"format": {format}}, user)
+}
+
+func newExportContextWithQuery(e *echo.Echo, slug string, query url.Values, user *oauth.User) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/surveys/"+slug+"/export?"+query.Encode(), nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/surveys/:slug/export")
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	if user != nil {
+		c.Set("user", user)
+	}
+	return c, rec
+}
+
+func TestExportResponses_RequiresAuthor(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+
+	c, rec := newExportContext(e, survey.Slug, "csv", nil)
+	require.NoError(t, h.ExportResponses(c))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	c, rec = newExportContext(e, survey.Slug, "csv", &oauth.User{DID: "did:plc:someone"})
+	require.NoError(t, h.ExportResponses(c))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestExportResponses_CSVOrderedByOrdinal(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+
+	c, rec := newExportContext(e, survey.Slug, "csv", &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.ExportResponses(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), "export-survey-responses.csv")
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "response_id,submitted_at,voter_type,voter_did,survey_version,Q1 color,Q2 why,removed old", lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], ",did,did:plc:voter,1,blue,Calm,gone"), lines[1])
+	assert.True(t, strings.HasSuffix(lines[2], ",anonymous,,2,red,,"), lines[2])
+}
+
+func TestExportResponses_JSONIncludesOrdinals(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+	survey.Definition.Anonymous = true
+
+	c, rec := newExportContext(e, survey.Slug, "json", &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.ExportResponses(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var export ExportResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &export))
+	assert.Equal(t, 2, export.Version)
+	require.Len(t, export.Responses, 2)
+
+	first := export.Responses[0]
+	assert.Equal(t, "did", first.VoterType)
+	assert.Nil(t, first.VoterDID, "voter DID is omitted for anonymous surveys")
+	require.Len(t, first.Answers, 3)
+	assert.Equal(t, ExportAnswer{QuestionID: "color", Ordinal: 1, VersionOrdinal: 2, SelectedOptions: []string{"blue"}}, first.Answers[0])
+	assert.Equal(t, ExportAnswer{QuestionID: "why", Ordinal: 2, VersionOrdinal: 1, Text: "Calm"}, first.Answers[1])
+	assert.Equal(t, ExportAnswer{QuestionID: "old", Text: "gone"}, first.Answers[2])
+}
+
+func TestExportResponses_MatrixColumnPerRow(t *testing.T) {
+	e, mq, h, survey := setupExportTest(t)
+	survey.Definition.Questions = append(survey.Definition.Questions, models.Question{
+		ID:      "rate",
+		Text:    "Rate the event",
+		Type:    models.QuestionTypeMatrix,
+		Options: []models.Option{{ID: "agree", Text: "Agree"}, {ID: "disagree", Text: "Disagree"}},
+		Rows:    []models.Option{{ID: "venue", Text: "Venue"}, {ID: "food", Text: "Food"}},
+	})
+	for _, r := range mq.Responses {
+		if r.VoterDID == nil {
+			r.Answers["rate"] = models.Answer{Rows: map[string]string{"venue": "agree", "food": "disagree"}}
+		}
+	}
+
+	c, rec := newExportContext(e, survey.Slug, "csv", &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.ExportResponses(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "response_id,submitted_at,voter_type,voter_did,survey_version,Q1 color,Q2 why,Q3 rate.venue,Q3 rate.food,removed old", lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], ",blue,Calm,,,gone"), lines[1])
+	assert.True(t, strings.HasSuffix(lines[2], ",red,,agree,disagree,"), lines[2])
+}
+
+func TestExportResponses_InvalidFormat(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+
+	c, rec := newExportContext(e, survey.Slug, "xml", &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.ExportResponses(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestExportResponses_Filters(t *testing.T) {
+	author := &oauth.User{DID: "did:plc:author"}
+	today := time.Now().UTC().Format(time.DateOnly)
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	halfHourAgo := time.Now().Add(-30 * time.Minute).Format(time.RFC3339)
+
+	tests := []struct {
+		name      string
+		query     url.Values
+		responses int
+		questions []string
+	}{
+		{"no filters", url.Values{}, 2, []string{"color", "why", "old"}},
+		{"did voters", url.Values{"voter": {"did"}}, 1, []string{"color", "why", "old"}},
+		{"anonymous voters", url.Values{"voter": {"anonymous"}}, 1, []string{"color", "why"}},
+		{"date range including today", url.Values{"from": {yesterday}, "to": {today}}, 2, []string{"color", "why", "old"}},
+		{"before a time", url.Values{"to": {halfHourAgo}}, 1, []string{"color", "why", "old"}},
+		{"after a time", url.Values{"from": {halfHourAgo}}, 1, []string{"color", "why"}},
+		{"question subset", url.Values{"questions": {"why"}}, 2, []string{"why"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _, h, survey := setupExportTest(t)
+
+			tt.query.Set("format", "json")
+			c, rec := newExportContextWithQuery(e, survey.Slug, tt.query, author)
+			require.NoError(t, h.ExportResponses(c))
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var export ExportResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &export))
+			assert.Len(t, export.Responses, tt.responses)
+
+			var questions []string
+			for _, q := range export.Questions {
+				questions = append(questions, q.QuestionID)
+			}
+			assert.Equal(t, tt.questions, questions)
+		})
+	}
+}
+
+func TestExportResponses_InvalidFilters(t *testing.T) {
+	author := &oauth.User{DID: "did:plc:author"}
+
+	for _, query := range []url.Values{
+		{"from": {"last week"}},
+		{"from": {"2026-03-02"}, "to": {"2026-03-01"}},
+		{"voter": {"kiosk"}},
+		{"questions": {"color,missing"}},
+	} {
+		e, _, h, survey := setupExportTest(t)
+
+		c, rec := newExportContextWithQuery(e, survey.Slug, query, author)
+		require.NoError(t, h.ExportResponses(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query.Encode())
+	}
+}
+
+func setupReceiptTest(t *testing.T) (*echo.Echo, *MockQueries, *Handlers, *models.Survey) {
+	e, mq, h := setupTest()
+	h.SetReceiptSigner(receipt.NewFromConfig(receipt.Config{Secret: "test-secret"}))
+
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "receipt-survey",
+		Title: "Receipt Survey",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "q1", Text: "Pick one", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "a", Text: "A"}}},
+			},
+		},
+	}
+	require.NoError(t, mq.CreateSurvey(context.Background(), survey))
+
+	return e, mq, h, survey
+}
+
+func submitForReceipt(t *testing.T, e *echo.Echo, h *Handlers, slug string) ResponseSubmittedResponse {
+	body, _ := json.Marshal(SubmitResponseRequest{
+		Answers: map[string]models.Answer{"q1": {SelectedOptions: []string{"a"}}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/"+slug+"/responses", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+
+	require.NoError(t, h.SubmitResponse(c))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var resp ResponseSubmittedResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+func newReceiptContext(e *echo.Echo, slug, token string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/surveys/"+slug+"/receipt/"+token, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug", "token")
+	c.SetParamValues(slug, token)
+	return c, rec
+}
+
+func TestSubmitResponse_ReturnsReceipt(t *testing.T) {
+	e, _, h, survey := setupReceiptTest(t)
+
+	resp := submitForReceipt(t, e, h, survey.Slug)
+	require.NotEmpty(t, resp.Receipt)
+
+	rcpt, err := h.receipts.Verify(resp.Receipt)
+	require.NoError(t, err)
+	assert.Equal(t, resp.ID, rcpt.ResponseID)
+	assert.Equal(t, survey.ID, rcpt.SurveyID)
+	assert.Equal(t, resp.CreatedAt.Unix(), rcpt.SubmittedAt.Unix())
+}
+
+func TestSubmitResponse_NoReceiptWhenDisabled(t *testing.T) {
+	e, _, h, survey := setupReceiptTest(t)
+	h.SetReceiptSigner(nil)
+
+	resp := submitForReceipt(t, e, h, survey.Slug)
+	assert.Empty(t, resp.Receipt)
+}
+
+func TestReceiptPage(t *testing.T) {
+	e, mq, h, survey := setupReceiptTest(t)
+	resp := submitForReceipt(t, e, h, survey.Slug)
+
+	t.Run("valid receipt", func(t *testing.T) {
+		c, rec := newReceiptContext(e, survey.Slug, resp.Receipt)
+		require.NoError(t, h.ReceiptPageHTML(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), resp.ID.String())
+		assert.Contains(t, rec.Body.String(), "Counted in the survey results")
+	})
+
+	t.Run("tampered receipt", func(t *testing.T) {
+		tampered := "A" + resp.Receipt[1:]
+		if tampered == resp.Receipt {
+			tampered = "B" + resp.Receipt[1:]
+		}
+		c, rec := newReceiptContext(e, survey.Slug, tampered)
+		require.NoError(t, h.ReceiptPageHTML(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("receipt for another survey", func(t *testing.T) {
+		other := &models.Survey{ID: uuid.New(), Slug: "other-survey", Title: "Other"}
+		require.NoError(t, mq.CreateSurvey(context.Background(), other))
+
+		c, rec := newReceiptContext(e, other.Slug, resp.Receipt)
+		require.NoError(t, h.ReceiptPageHTML(c))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("deleted response", func(t *testing.T) {
+		delete(mq.Responses, resp.ID)
+
+		c, rec := newReceiptContext(e, survey.Slug, resp.Receipt)
+		require.NoError(t, h.ReceiptPageHTML(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "No longer recorded")
+	})
+
+	t.Run("receipts disabled", func(t *testing.T) {
+		h.SetReceiptSigner(nil)
+
+		c, rec := newReceiptContext(e, survey.Slug, resp.Receipt)
+		require.NoError(t, h.ReceiptPageHTML(c))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestSubmitResponse_ForeignPollIsReadOnly(t *testing.T) {
+	e, mq, h := setupTest()
+
+	uri := "at://did:plc:other/com.example.poll/3kabc"
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		URI:   &uri,
+		Slug:  "foreign-poll",
+		Title: "Foreign Poll",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{
+					ID:       "q1",
+					Text:     "Foreign Poll",
+					Type:     models.QuestionTypeSingle,
+					Required: true,
+					Options: []models.Option{
+						{ID: "opt-0", Text: "A"},
+						{ID: "opt-1", Text: "B"},
+					},
+				},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	mq.CreateSurvey(context.Background(), survey)
+
+	body, _ := json.Marshal(SubmitResponseRequest{
+		Answers: map[string]models.Answer{"q1": {SelectedOptions: []string{"opt-0"}}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/foreign-poll/responses", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("foreign-poll")
+
+	err := h.SubmitResponse(c)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	assert.Empty(t, mq.Responses)
+}
+
+func TestGetResults_CachedUntilResponseSubmitted(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetCache(cache.New(cache.NewMemory(10), time.Minute), mq)
+
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "hot-survey",
+		Title: "Hot Survey",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{
+					ID:       "q1",
+					Text:     "Test Question",
+					Type:     models.QuestionTypeSingle,
+					Required: true,
+					Options: []models.Option{
+						{ID: "a", Text: "A"},
+						{ID: "b", Text: "B"},
+					},
+				},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	mq.CreateSurvey(context.Background(), survey)
+
+	getResults := func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/surveys/hot-survey/results", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("hot-survey")
+		require.NoError(t, h.GetResults(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	getResults()
+	getResults()
+	assert.Equal(t, 1, mq.ResultsQueries, "second request should be served from the cache")
+
+	body, _ := json.Marshal(SubmitResponseRequest{
+		Answers: map[string]models.Answer{"q1": {SelectedOptions: []string{"a"}}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/hot-survey/responses", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("hot-survey")
+	require.NoError(t, h.SubmitResponse(c))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	getResults()
+	assert.Equal(t, 2, mq.ResultsQueries, "submitting a response should invalidate cached results")
+}
+
+func TestGetResults_CacheFilledFromPrimary(t *testing.T) {
+	// The handlers' queries stand in for a replica that has not seen the survey
+	e, replica, h := setupTest()
+	primary := NewMockQueries()
+	h.SetCache(cache.New(cache.NewMemory(10), time.Minute), primary)
+
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "new-survey",
+		Title: "New Survey",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "q1", Text: "Test Question", Type: models.QuestionTypeText},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	primary.CreateSurvey(context.Background(), survey)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/surveys/new-survey/results", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("new-survey")
+	require.NoError(t, h.GetResults(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, 1, primary.ResultsQueries)
+	assert.Equal(t, 0, replica.ResultsQueries, "cache fills should not read replicas")
+}
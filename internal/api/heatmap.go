@@ -0,0 +1,113 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/heatmap"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// SetHeatmap enables counting the responses of surveys with responseMetadata
+// by country, looked up by locator, and hour of day
+func (h *Handlers) SetHeatmap(store heatmap.Store, locator *heatmap.Locator) {
+	h.heatmap = store
+	h.locator = locator
+}
+
+// recordResponseMetadata counts a saved response by its country and hour of
+// day, if its survey collects them. A vote is not failed for it, so errors
+// are only logged.
+func (h *Handlers) recordResponseMetadata(c echo.Context, survey *models.Survey, response *models.Response) {
+	if h.heatmap == nil || !survey.Definition.CollectsResponseMetadata() {
+		return
+	}
+	country := h.locator.Country(getClientIP(c), c.Request().Header)
+	if err := h.heatmap.RecordResponseMetadata(c.Request().Context(), survey.ID, country, heatmap.Hour(response.CreatedAt)); err != nil {
+		c.Logger().Errorf("Failed to record metadata of response %s: %v", response.ID, err)
+	}
+}
+
+// heatmapReport builds the heatmap of a survey's responses
+func (h *Handlers) heatmapReport(c echo.Context, survey *models.Survey) (*heatmap.Report, error) {
+	counts, err := h.heatmap.ListResponseMetadata(c.Request().Context(), survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	return heatmap.Build(counts, survey.Definition.CollectsResponseMetadata()), nil
+}
+
+// GetResponseHeatmap returns a survey's responses by country and hour of day,
+// for its author. Anonymous surveys have none.
+// GET /api/v1/surveys/:slug/results/heatmap
+func (h *Handlers) GetResponseHeatmap(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.SurveyNotFound, fmt.Sprintf("No survey found with slug '%s'", slug))
+		}
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+
+	caller, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key of the survey author")
+	}
+	if !h.canReadSurveyAs(ctx, caller, survey) {
+		return Problem(c, problem.Forbidden, "Only the survey author can view the response heatmap")
+	}
+	if survey.Definition.Anonymous {
+		return Problem(c, problem.SurveyAnonymous, "Anonymous surveys do not collect response metadata")
+	}
+
+	report, err := h.heatmapReport(c, survey)
+	if err != nil {
+		return InternalServerError(c, "Failed to load response heatmap", err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// HeatmapPageHTML renders the heatmap of a survey's responses by country and
+// hour of day, with the responses of each country
+// GET /surveys/:slug/results/heatmap
+func (h *Handlers) HeatmapPageHTML(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	survey, err := h.surveyBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user, profile := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canReadSurvey(ctx, user, survey) {
+		return c.String(http.StatusForbidden, "Only the survey author can view the response heatmap")
+	}
+	if survey.Definition.Anonymous {
+		return c.String(http.StatusForbidden, "Anonymous surveys do not collect response metadata")
+	}
+
+	report, err := h.heatmapReport(c, survey)
+	if err != nil {
+		c.Logger().Errorf("Failed to load response heatmap of survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to load response heatmap")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.HeatmapPage(survey, report, user, profile, h.posthogKey)
+	return component.Render(ctx, c.Response().Writer)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/heatmap"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHeatmapStore keeps response metadata counts in memory
+type mockHeatmapStore struct {
+	counts map[uuid.UUID][]*heatmap.Count
+}
+
+func (m *mockHeatmapStore) RecordResponseMetadata(ctx context.Context, surveyID uuid.UUID, country string, hour int) error {
+	if m.counts == nil {
+		m.counts = make(map[uuid.UUID][]*heatmap.Count)
+	}
+	for _, c := range m.counts[surveyID] {
+		if c.Country == country && c.Hour == hour {
+			c.Responses++
+			return nil
+		}
+	}
+	m.counts[surveyID] = append(m.counts[surveyID], &heatmap.Count{Country: country, Hour: hour, Responses: 1})
+	return nil
+}
+
+func (m *mockHeatmapStore) ListResponseMetadata(ctx context.Context, surveyID uuid.UUID) ([]*heatmap.Count, error) {
+	return m.counts[surveyID], nil
+}
+
+func TestResponseHeatmap(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockHeatmapStore{}
+	locator, err := heatmap.NewLocator(heatmap.Config{Header: "CF-IPCountry"})
+	require.NoError(t, err)
+	h.SetHeatmap(store, locator)
+	author := "did:plc:author"
+	survey := createTextSurvey(mq, "feedback", &author)
+	survey.Definition.ResponseMetadata = true
+	plain := createTextSurvey(mq, "plain", &author)
+
+	submit := func(slug, ip string) int {
+		body, _ := json.Marshal(SubmitResponseRequest{Answers: map[string]models.Answer{"q1": {Text: "Great"}}})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/"+slug+"/responses", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("CF-IPCountry", "NZ")
+		req.RemoteAddr = ip + ":12345"
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		require.NoError(t, h.SubmitResponse(c))
+		return rec.Code
+	}
+	require.Equal(t, http.StatusCreated, submit("feedback", "192.168.1.1"))
+	require.Equal(t, http.StatusCreated, submit("plain", "192.168.1.1"))
+	require.Len(t, store.counts[survey.ID], 1)
+	assert.Equal(t, "NZ", store.counts[survey.ID][0].Country)
+	assert.Empty(t, store.counts[plain.ID], "surveys count nothing unless they opt in")
+
+	call := func(slug string, user *oauth.User) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/surveys/"+slug+"/results/heatmap", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, h.GetResponseHeatmap(c))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call("feedback", nil).Code)
+	assert.Equal(t, http.StatusForbidden, call("feedback", &oauth.User{DID: "did:plc:other"}).Code)
+
+	rec := call("feedback", &oauth.User{DID: author})
+	require.Equal(t, http.StatusOK, rec.Code)
+	var report heatmap.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.True(t, report.Collecting)
+	assert.Equal(t, 1, report.Total)
+	require.Len(t, report.Countries, 1)
+	assert.Equal(t, "NZ", report.Countries[0].Country)
+
+	// Anonymous surveys neither count responses nor report them
+	survey.Definition.Anonymous = true
+	require.Equal(t, http.StatusCreated, submit("feedback", "192.168.1.2"))
+	assert.Equal(t, 1, store.counts[survey.ID][0].Responses)
+	rec = call("feedback", &oauth.User{DID: author})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "survey_anonymous")
+}
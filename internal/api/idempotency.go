@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/idempotency"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+)
+
+// replayedHeader marks a response replayed for a repeated idempotency key
+const replayedHeader = "Idempotent-Replayed"
+
+// SetIdempotency enables the Idempotency-Key header on response submissions.
+// Without it, the header is ignored.
+func (h *Handlers) SetIdempotency(store idempotency.Store) {
+	h.idempotency = store
+}
+
+// submissionClaim is the claimed idempotency key of a response submission.
+// Its methods do nothing on a nil claim, i.e. when the request has no key.
+type submissionClaim struct {
+	store     idempotency.Store
+	key       string
+	completed bool
+}
+
+// claimSubmission claims the Idempotency-Key of a submission of answers to a
+// survey, scoped to the caller (see submissionCaller). It returns the response to replay if the key's
+// submission succeeded before, or the claim to complete once this one does
+// (nil without a key). The claim must be released if the submission fails.
+func (h *Handlers) claimSubmission(c echo.Context, survey *models.Survey, answers map[string]models.Answer) (*idempotency.Record, *submissionClaim, error) {
+	key := c.Request().Header.Get(idempotency.Header)
+	if h.idempotency == nil || key == "" {
+		return nil, nil, nil
+	}
+
+	scope := "responses:" + survey.ID.String() + ":" + h.submissionCaller(c, survey)
+	fingerprint, err := idempotency.Fingerprint(answers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record, err := idempotency.Claim(c.Request().Context(), h.idempotency, scope, key, fingerprint, time.Now())
+	if err != nil || record != nil {
+		return record, nil, err
+	}
+	return nil, &submissionClaim{store: h.idempotency, key: idempotency.ScopedKey(scope, key)}, nil
+}
+
+// submissionCaller returns who a submission of answers to a survey is from:
+// the DID of the OAuth session its response is written with, the logged-in
+// user or API key owner, else the guest's voter session
+func (h *Handlers) submissionCaller(c echo.Context, survey *models.Survey) string {
+	if h.oauthStorage != nil {
+		if session, err := oauth.GetSession(c, h.oauthStorage); err == nil && session != nil {
+			return session.DID
+		}
+	}
+	if did, ok := callerDID(c); ok {
+		return did
+	}
+	return models.GenerateVoterSession(survey.ID, getClientIP(c), c.Request().UserAgent())
+}
+
+// complete stores the response of the submission for replays
+func (s *submissionClaim) complete(c echo.Context, responseID uuid.UUID, status int, contentType string, body []byte) {
+	if s == nil {
+		return
+	}
+	s.completed = true
+	if err := s.store.CompleteIdempotencyKey(c.Request().Context(), s.key, responseID, status, contentType, body, time.Now()); err != nil {
+		c.Logger().Errorf("Failed to store response of idempotency key: %v", err)
+	}
+}
+
+// release frees the key of a submission that did not complete, so it can be retried
+func (s *submissionClaim) release(c echo.Context) {
+	if s == nil || s.completed {
+		return
+	}
+	if err := s.store.ReleaseIdempotencyKey(c.Request().Context(), s.key); err != nil {
+		c.Logger().Errorf("Failed to release idempotency key: %v", err)
+	}
+}
+
+// replaySubmission sends the stored response of a repeated idempotency key
+func replaySubmission(c echo.Context, record *idempotency.Record) error {
+	c.Response().Header().Set(replayedHeader, "true")
+	return c.Blob(record.Status, record.ContentType, record.Body)
+}
+
+// idempotencyProblem returns the problem of an error claiming an idempotency key
+func idempotencyProblem(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, idempotency.ErrInvalidKey):
+		return Problem(c, problem.ValidationFailed, "Idempotency-Key must be 1 to 255 printable ASCII characters")
+	case errors.Is(err, idempotency.ErrInProgress):
+		c.Response().Header().Set("Retry-After", "1")
+		return Problem(c, problem.IdempotencyKeyInUse, "A submission with this Idempotency-Key is still being processed")
+	case errors.Is(err, idempotency.ErrMismatch):
+		return Problem(c, problem.IdempotencyKeyReused, "This Idempotency-Key was used for a submission with different answers")
+	}
+	return InternalServerError(c, "Failed to check Idempotency-Key", err)
+}
+
+// idempotencyMessage returns the error message shown on pages for an error
+// claiming an idempotency key
+func idempotencyMessage(err error) string {
+	switch {
+	case errors.Is(err, idempotency.ErrInProgress):
+		return "Your response is still being submitted. Please wait a moment."
+	case errors.Is(err, idempotency.ErrMismatch), errors.Is(err, idempotency.ErrInvalidKey):
+		return "This form was already submitted. Please reload the page."
+	}
+	return "Failed to submit response"
+}
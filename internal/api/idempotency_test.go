@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/idempotency"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIdempotencyStore keeps idempotency records in memory
+type mockIdempotencyStore struct {
+	records map[string]*idempotency.Record
+}
+
+func (m *mockIdempotencyStore) ClaimIdempotencyKey(ctx context.Context, r *idempotency.Record, now time.Time, claimTimeout time.Duration) (*idempotency.Record, error) {
+	if existing, ok := m.records[r.Key]; ok && existing.ExpiresAt.After(now) {
+		return existing, nil
+	}
+	m.records[r.Key] = r
+	return nil, nil
+}
+
+func (m *mockIdempotencyStore) CompleteIdempotencyKey(ctx context.Context, key string, responseID uuid.UUID, status int, contentType string, body []byte, now time.Time) error {
+	r := m.records[key]
+	r.ResponseID, r.Status, r.ContentType, r.Body, r.CompletedAt = &responseID, status, contentType, body, &now
+	return nil
+}
+
+func (m *mockIdempotencyStore) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	if r, ok := m.records[key]; ok && !r.Completed() {
+		delete(m.records, key)
+	}
+	return nil
+}
+
+func (m *mockIdempotencyStore) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestSubmitResponse_IdempotencyKey(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockIdempotencyStore{records: map[string]*idempotency.Record{}}
+	h.SetIdempotency(store)
+	createTextSurvey(mq, "feedback", nil)
+
+	submit := func(key, text string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(SubmitResponseRequest{Answers: map[string]models.Answer{"q1": {Text: text}}})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/feedback/responses", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(idempotency.Header, key)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("feedback")
+		require.NoError(t, h.SubmitResponse(c))
+		return rec
+	}
+
+	rec := submit(strings.Repeat("k", idempotency.MaxKeyLength+1), "Great")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Empty(t, mq.Responses)
+
+	first := submit("retry-1", "Great")
+	require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+	require.Len(t, mq.Responses, 1)
+
+	// A retry gets the original response, without voting again
+	replay := submit("retry-1", "Great")
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get(replayedHeader))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Len(t, mq.Responses, 1)
+
+	// The key can't be reused for other answers
+	rec = submit("retry-1", "Changed my mind")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(problem.IdempotencyKeyReused))
+
+	// Without the key, the second vote is a duplicate; a new key's failed
+	// submission frees the key
+	rec = submit("", "Great")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = submit("retry-2", "Great")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Len(t, store.records, 1, "only the completed key is kept")
+}
+
+func TestSubmitResponse_IdempotencyKeyInProgress(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockIdempotencyStore{records: map[string]*idempotency.Record{}}
+	h.SetIdempotency(store)
+	survey := createTextSurvey(mq, "feedback", nil)
+
+	body, _ := json.Marshal(SubmitResponseRequest{Answers: map[string]models.Answer{"q1": {Text: "Great"}}})
+	fingerprint, err := idempotency.Fingerprint(map[string]models.Answer{"q1": {Text: "Great"}})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/feedback/responses", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(idempotency.Header, "slow")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("feedback")
+
+	// The first request with the key is still running
+	scope := "responses:" + survey.ID.String() + ":" + models.GenerateVoterSession(survey.ID, getClientIP(c), req.UserAgent())
+	store.records[idempotency.ScopedKey(scope, "slow")] = &idempotency.Record{Fingerprint: fingerprint, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	require.NoError(t, h.SubmitResponse(c))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(problem.IdempotencyKeyInUse))
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Empty(t, mq.Responses)
+}
+
+func TestSubmitResponseHTML_IdempotencyKey(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetIdempotency(&mockIdempotencyStore{records: map[string]*idempotency.Record{}})
+	createTextSurvey(mq, "feedback", nil)
+
+	submit := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/surveys/feedback/responses", strings.NewReader("q1=Great+survey"))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.Header.Set(idempotency.Header, "form-1")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("feedback")
+		require.NoError(t, h.SubmitResponseHTML(c))
+		return rec
+	}
+
+	first := submit()
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Len(t, mq.Responses, 1)
+
+	// A form sent twice shows the same thank you page
+	replay := submit()
+	assert.Equal(t, http.StatusOK, replay.Code)
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.NotContains(t, replay.Body.String(), "already submitted")
+	assert.Len(t, mq.Responses, 1)
+}
+
+func TestSubmissionCaller(t *testing.T) {
+	e, _, h := setupTest()
+	survey := &models.Survey{ID: uuid.New()}
+
+	newContext := func(ip string) echo.Context {
+		req := httptest.NewRequest(http.MethodPost, "/surveys/feedback/responses", nil)
+		req.Header.Set("User-Agent", "test-agent")
+		req.RemoteAddr = ip + ":1234"
+		return e.NewContext(req, httptest.NewRecorder())
+	}
+
+	// Logged-in voters are the same caller from any network
+	home, office := newContext("192.0.2.1"), newContext("198.51.100.1")
+	home.Set("user", &oauth.User{DID: "did:plc:voter"})
+	office.Set("user", &oauth.User{DID: "did:plc:voter"})
+	assert.Equal(t, "did:plc:voter", h.submissionCaller(home, survey))
+	assert.Equal(t, "did:plc:voter", h.submissionCaller(office, survey))
+
+	// Guests are told apart by their voter session
+	guest := h.submissionCaller(newContext("192.0.2.1"), survey)
+	assert.Equal(t, models.GenerateVoterSession(survey.ID, "192.0.2.1", "test-agent"), guest)
+	assert.NotEqual(t, guest, h.submissionCaller(newContext("198.51.100.1"), survey))
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// UploadImageHTML uploads an image to the logged-in user's PDS
+// POST /images (multipart form with an "image" file and optional "alt" text)
+// Renders the image reference to add to a question or option of a survey definition
+func (h *Handlers) UploadImageHTML(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var session *oauth.OAuthSession
+	if h.oauthStorage != nil {
+		session, _ = oauth.GetSession(c, h.oauthStorage)
+	}
+	if session == nil || session.AccessToken == "" || session.PDSUrl == "" {
+		component := templates.Error("Log in to upload images; they are stored on your PDS")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		component := templates.Error("Choose an image to upload")
+		return component.Render(ctx, c.Response().Writer)
+	}
+	if file.Size > models.MaxImageSize {
+		component := templates.Error("Images must be at most 1 MB")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		component := templates.Error("Failed to read image")
+		return component.Render(ctx, c.Response().Writer)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, models.MaxImageSize+1))
+	if err != nil || len(data) > models.MaxImageSize {
+		component := templates.Error("Images must be at most 1 MB")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	// Trust the content, not the client's declared type
+	mimeType := http.DetectContentType(data)
+	if !models.AllowedImageType(mimeType) {
+		component := templates.Error("Images must be PNG, JPEG, GIF, or WebP")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	if err := h.ensureValidToken(ctx, session); err != nil {
+		c.Logger().Errorf("Failed to refresh token for image upload: %v", err)
+		component := templates.Error("Your session has expired. Please log in again.")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	blob, err := oauth.UploadBlob(c.Request().Context(), session, data, mimeType)
+	recordPDSWrite("upload_blob", err)
+	if err != nil {
+		c.Logger().Errorf("Failed to upload image to PDS: %v", err)
+		component := templates.Error("Failed to upload image to your PDS")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	image := &models.Image{Alt: c.FormValue("alt")}
+	if err := json.Unmarshal(blob, &image.Image); err != nil {
+		c.Logger().Errorf("Failed to parse uploaded blob %s: %v", blob, err)
+		component := templates.Error("Failed to upload image to your PDS")
+		return component.Render(ctx, c.Response().Writer)
+	}
+	if err := image.Validate(); err != nil {
+		component := templates.Error("Invalid image: " + err.Error())
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	// JSON is also valid YAML, so the reference can be pasted into either format
+	reference, err := json.Marshal(image)
+	if err != nil {
+		component := templates.Error("Failed to upload image to your PDS")
+		return component.Render(ctx, c.Response().Writer)
+	}
+
+	component := templates.UploadedImage(string(reference))
+	return component.Render(ctx, c.Response().Writer)
+}
+
+// SurveyImage serves an image of a survey's question or option from the author's PDS
+// GET /surveys/:slug/images/:cid
+func (h *Handlers) SurveyImage(c echo.Context) error {
+	survey, err := h.surveyBySlug(c.Request().Context(), c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		return c.String(http.StatusForbidden, privateSurveyMessage)
+	}
+
+	// Only the survey's own images are proxied, so this cannot fetch arbitrary blobs
+	cid := c.Param("cid")
+	if survey.AuthorDID == nil || !survey.Definition.HasImage(cid) {
+		return c.String(http.StatusNotFound, "Image not found")
+	}
+
+	data, err := h.fetchBlob(c.Request().Context(), *survey.AuthorDID, cid)
+	if err != nil {
+		c.Logger().Errorf("Failed to fetch image %s of survey %s: %v", cid, survey.Slug, err)
+		return c.String(http.StatusBadGateway, "Failed to fetch image from the author's PDS")
+	}
+
+	contentType := http.DetectContentType(data)
+	if !models.AllowedImageType(contentType) {
+		return c.String(http.StatusBadGateway, "The author's PDS returned an unsupported image")
+	}
+
+	// Blobs are addressed by content, so they never change
+	c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+	c.Response().Header().Set("Content-Security-Policy", "default-src 'none'")
+	return c.Blob(http.StatusOK, contentType, data)
+}
+
+// fetchAuthorBlob fetches a blob from the PDS hosting a DID's repo
+func fetchAuthorBlob(ctx context.Context, did, cid string) ([]byte, error) {
+	pdsURL, err := oauth.DIDToPDS(did)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := oauth.GetBlob(ctx, pdsURL, did, cid, models.MaxImageSize)
+	return data, err
+}
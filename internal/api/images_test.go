@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testImageCID = "bafkreibme22gw2h7y2h7tg2fhqotaqjucnbc24deqo72b6mkl2egezxhvy"
+
+// pngHeader is enough of a PNG for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func imageSurvey(authorDID *string) *models.Survey {
+	return &models.Survey{
+		ID:        uuid.New(),
+		Slug:      "logos",
+		AuthorDID: authorDID,
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{{
+				ID:   "q1",
+				Text: "Which logo?",
+				Type: models.QuestionTypeSingle,
+				Options: []models.Option{
+					{ID: "a", Text: "A", Image: &models.Image{
+						Image: models.Blob{Type: "blob", Ref: models.BlobRef{Link: testImageCID}, MimeType: "image/png", Size: 16},
+					}},
+					{ID: "b", Text: "B"},
+				},
+			}},
+		},
+	}
+}
+
+func serveSurveyImage(h *Handlers, slug, cid string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/surveys/"+slug+"/images/"+cid, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug", "cid")
+	c.SetParamValues(slug, cid)
+	_ = h.SurveyImage(c)
+	return rec
+}
+
+func TestSurveyImage(t *testing.T) {
+	author := "did:plc:author"
+	mq := NewMockQueries()
+	mq.Surveys["logos"] = imageSurvey(&author)
+	h := NewHandlers(mq)
+
+	var fetched []string
+	h.fetchBlob = func(ctx context.Context, did, cid string) ([]byte, error) {
+		fetched = append(fetched, did+" "+cid)
+		return pngHeader, nil
+	}
+
+	rec := serveSurveyImage(h, "logos", testImageCID)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Header().Get("Cache-Control"), "immutable")
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, []string{author + " " + testImageCID}, fetched)
+
+	// Blobs that are not images of the survey are not proxied
+	rec = serveSurveyImage(h, "logos", "bafkreiother")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = serveSurveyImage(h, "missing", testImageCID)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Len(t, fetched, 1)
+}
+
+func TestSurveyImage_RejectsNonImages(t *testing.T) {
+	author := "did:plc:author"
+	mq := NewMockQueries()
+	mq.Surveys["logos"] = imageSurvey(&author)
+	h := NewHandlers(mq)
+
+	h.fetchBlob = func(ctx context.Context, did, cid string) ([]byte, error) {
+		return []byte("<html><script>alert(1)</script></html>"), nil
+	}
+	rec := serveSurveyImage(h, "logos", testImageCID)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+
+	h.fetchBlob = func(ctx context.Context, did, cid string) ([]byte, error) {
+		return nil, errors.New("PDS unreachable")
+	}
+	rec = serveSurveyImage(h, "logos", testImageCID)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+}
+
+func TestSurveyImage_LocalOnlySurvey(t *testing.T) {
+	mq := NewMockQueries()
+	mq.Surveys["logos"] = imageSurvey(nil)
+	h := NewHandlers(mq)
+
+	rec := serveSurveyImage(h, "logos", testImageCID)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
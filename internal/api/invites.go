@@ -0,0 +1,265 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/invite"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+)
+
+// SetInvites enables one-time invite links, which open surveys with invite
+// visibility and submit one response each. Without them, such surveys are
+// only shown to their author and admins.
+func (h *Handlers) SetInvites(store invite.Store) {
+	h.invites = store
+}
+
+// Errors of useInvite caused by the request
+var (
+	errInviteRequired = errors.New("this survey is only open to invitees: respond with your invite link")
+	errInviteUsed     = errors.New("your invite link has already been used to respond")
+)
+
+// usedInvite is the invite link a response is being submitted with
+type usedInvite struct {
+	store     invite.Store
+	invite    *invite.Invite
+	completed bool
+}
+
+// useInvite marks the invite link of a response to a survey with invite
+// visibility used, so no other response uses it, or returns nil for other
+// surveys. The invite must be released if the submission fails.
+func (h *Handlers) useInvite(c echo.Context, survey *models.Survey) (*usedInvite, error) {
+	if survey.Definition.Visibility != models.VisibilityInvite {
+		return nil, nil
+	}
+	token, _ := accessToken(c, survey)
+	if h.invites == nil || token == "" {
+		return nil, errInviteRequired
+	}
+
+	ctx := c.Request().Context()
+	inv, err := h.invites.GetInviteByHash(ctx, survey.ID, invite.Hash(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errInviteRequired
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := h.invites.UseInvite(ctx, inv.ID, time.Now()); err != nil {
+		if errors.Is(err, invite.ErrUsed) {
+			return nil, errInviteUsed
+		}
+		return nil, err
+	}
+	return &usedInvite{store: h.invites, invite: inv}, nil
+}
+
+// inviteProblem responds to a JSON submission whose invite could not be used
+func inviteProblem(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, errInviteRequired):
+		return Problem(c, problem.SurveyPrivate, err.Error())
+	case errors.Is(err, errInviteUsed):
+		return Problem(c, problem.AlreadyVoted, err.Error())
+	}
+	return InternalServerError(c, "Failed to check invite link", err)
+}
+
+// inviteMessage is the page message of a submission whose invite could not be used
+func inviteMessage(err error) string {
+	if errors.Is(err, errInviteRequired) || errors.Is(err, errInviteUsed) {
+		return err.Error()
+	}
+	return "Failed to check invite link"
+}
+
+// complete links the invite to its response, unless the survey is anonymous
+func (u *usedInvite) complete(c echo.Context, survey *models.Survey, response *models.Response) {
+	if u == nil {
+		return
+	}
+	u.completed = true
+	if survey.Definition.Anonymous {
+		return
+	}
+	if err := u.store.SetInviteResponse(c.Request().Context(), u.invite.ID, response.ID); err != nil {
+		c.Logger().Errorf("Failed to link invite %s to its response: %v", u.invite.ID, err)
+	}
+}
+
+// voterSession returns the voter session of a guest response: one of its
+// invite, so invitees sharing a network and browser each respond, or session
+// without an invite
+func (u *usedInvite) voterSession(surveyID uuid.UUID, session string) string {
+	if u == nil {
+		return session
+	}
+	return models.GenerateVoterSession(surveyID, "invite", u.invite.ID.String())
+}
+
+// release frees the invite of a submission that did not complete, so it can be retried
+func (u *usedInvite) release(c echo.Context) {
+	if u == nil || u.completed {
+		return
+	}
+	if err := u.store.ReleaseInvite(c.Request().Context(), u.invite.ID); err != nil {
+		c.Logger().Errorf("Failed to release invite %s: %v", u.invite.ID, err)
+	}
+}
+
+// ListInvites handles GET /api/v1/surveys/:slug/invites
+// Lists a survey's invites, without their tokens, and its response rate.
+// Responses are only linked to invites of surveys that are not anonymous.
+func (h *Handlers) ListInvites(c echo.Context) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	invites, err := h.invites.ListInvites(c.Request().Context(), survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list invites", err)
+	}
+	if invites == nil {
+		invites = []*invite.Invite{}
+	}
+
+	return c.JSON(http.StatusOK, ListInvitesResponse{Rate: invite.ResponseRate(invites), Invites: invites})
+}
+
+// CreateInvites handles POST /api/v1/surveys/:slug/invites
+// Invites a list of email addresses and DIDs, sent as JSON or as CSV or plain
+// text with one invitee per line. Each gets a one-time link, only returned now;
+// invitees already invited are skipped.
+func (h *Handlers) CreateInvites(c echo.Context) error {
+	survey, caller, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+	if survey.Definition.Visibility != models.VisibilityInvite {
+		return ValidationError(c, "Invalid request", "Set the survey's visibility to invite before inviting respondents")
+	}
+
+	invitees, err := readInvitees(c)
+	if err != nil {
+		return ValidationError(c, "Invalid request", err.Error())
+	}
+	if len(invitees) == 0 {
+		return ValidationError(c, "Invalid request", "Enter the email addresses or DIDs of the invitees")
+	}
+	if len(invitees) > invite.MaxInvitesPerSurvey {
+		return ValidationError(c, "Invalid request", fmt.Sprintf("A survey can have at most %d invitees", invite.MaxInvitesPerSurvey))
+	}
+
+	ctx := c.Request().Context()
+	existing, err := h.invites.ListInvites(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list invites", err)
+	}
+	invited := make(map[string]bool, len(existing))
+	for _, inv := range existing {
+		invited[inv.Invitee] = true
+	}
+
+	resp := CreateInvitesResponse{Invites: []CreatedInvite{}, Skipped: []string{}}
+	var invites []*invite.Invite
+	for _, invitee := range invitees {
+		inv, token, err := invite.New(survey.ID, invitee, caller)
+		if err != nil {
+			return ValidationError(c, "Invalid invitee", err.Error())
+		}
+		if invited[inv.Invitee] {
+			resp.Skipped = append(resp.Skipped, inv.Invitee)
+			continue
+		}
+		invited[inv.Invitee] = true
+		invites = append(invites, inv)
+		resp.Invites = append(resp.Invites, CreatedInvite{Invite: inv, URL: shareLink(survey, token)})
+	}
+	if len(existing)+len(invites) > invite.MaxInvitesPerSurvey {
+		return ValidationError(c, "Invalid request", fmt.Sprintf("A survey can have at most %d invitees", invite.MaxInvitesPerSurvey))
+	}
+
+	if len(invites) > 0 {
+		if err := h.invites.CreateInvites(ctx, invites); err != nil {
+			return InternalServerError(c, "Failed to create invites", err)
+		}
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// readInvitees reads the invitees of a CreateInvites request: the invitees of
+// a JSON body, or the first column of each line of a CSV or plain text body,
+// skipping a header named invitee, email, or did
+func readInvitees(c echo.Context) ([]string, error) {
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+	if !strings.HasPrefix(contentType, "text/csv") && !strings.HasPrefix(contentType, echo.MIMETextPlain) {
+		var req CreateInvitesRequest
+		if err := c.Bind(&req); err != nil {
+			return nil, errors.New("request body must be JSON, CSV, or plain text")
+		}
+		return req.Invitees, nil
+	}
+
+	reader := csv.NewReader(c.Request().Body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var invitees []string
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := strings.TrimSpace(record[0])
+		if value == "" {
+			continue
+		}
+		if line == 1 {
+			switch strings.ToLower(value) {
+			case "invitee", "email", "did":
+				continue
+			}
+		}
+		invitees = append(invitees, value)
+	}
+	return invitees, nil
+}
+
+// DeleteInvite handles DELETE /api/v1/surveys/:slug/invites/:id
+// The invite's link stops opening the survey; its response, if any, is kept
+func (h *Handlers) DeleteInvite(c echo.Context) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return ValidationError(c, "Invalid invite ID", err.Error())
+	}
+
+	if err := h.invites.DeleteInvite(c.Request().Context(), id, survey.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.NotFound, "Invite not found: The survey has no invite with this ID")
+		}
+		return InternalServerError(c, "Failed to delete invite", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
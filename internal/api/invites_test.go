@@ -0,0 +1,233 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/invite"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockInviteStore keeps invites in memory
+type mockInviteStore struct {
+	invites []*invite.Invite
+}
+
+func (m *mockInviteStore) CreateInvites(ctx context.Context, invites []*invite.Invite) error {
+	m.invites = append(m.invites, invites...)
+	return nil
+}
+
+func (m *mockInviteStore) ListInvites(ctx context.Context, surveyID uuid.UUID) ([]*invite.Invite, error) {
+	var invites []*invite.Invite
+	for _, inv := range m.invites {
+		if inv.SurveyID == surveyID {
+			invites = append(invites, inv)
+		}
+	}
+	return invites, nil
+}
+
+func (m *mockInviteStore) GetInviteByHash(ctx context.Context, surveyID uuid.UUID, hash string) (*invite.Invite, error) {
+	for _, inv := range m.invites {
+		if inv.SurveyID == surveyID && inv.Hash == hash {
+			return inv, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockInviteStore) find(id uuid.UUID) *invite.Invite {
+	for _, inv := range m.invites {
+		if inv.ID == id {
+			return inv
+		}
+	}
+	return nil
+}
+
+func (m *mockInviteStore) UseInvite(ctx context.Context, id uuid.UUID, now time.Time) error {
+	inv := m.find(id)
+	if inv == nil || inv.UsedAt != nil {
+		return invite.ErrUsed
+	}
+	inv.UsedAt = &now
+	return nil
+}
+
+func (m *mockInviteStore) SetInviteResponse(ctx context.Context, id, responseID uuid.UUID) error {
+	if inv := m.find(id); inv != nil {
+		inv.ResponseID = &responseID
+	}
+	return nil
+}
+
+func (m *mockInviteStore) ReleaseInvite(ctx context.Context, id uuid.UUID) error {
+	if inv := m.find(id); inv != nil {
+		inv.UsedAt, inv.ResponseID = nil, nil
+	}
+	return nil
+}
+
+func (m *mockInviteStore) DeleteInvite(ctx context.Context, id, surveyID uuid.UUID) error {
+	for i, inv := range m.invites {
+		if inv.ID == id && inv.SurveyID == surveyID {
+			m.invites = append(m.invites[:i], m.invites[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// submitWithInvite submits a text response with an invite token in the share token header
+func submitWithInvite(t *testing.T, e *echo.Echo, h *Handlers, slug, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(SubmitResponseRequest{
+		Answers: map[string]models.Answer{"q1": {Text: "hello"}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/"+slug+"/responses", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(shareTokenHeader, token)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	require.NoError(t, h.SubmitResponse(c))
+	return rec
+}
+
+// inviteToken returns the token of the link of a created invite
+func inviteToken(t *testing.T, created CreatedInvite) string {
+	t.Helper()
+	u, err := url.Parse(created.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "/surveys/private", u.Path)
+	return u.Query().Get("token")
+}
+
+func TestInvites(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockInviteStore{}
+	h.SetInvites(store)
+	author := "did:plc:author"
+	survey := createTextSurvey(mq, "private", &author)
+
+	create := func(contentType, body string) (int, CreateInvitesResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/private/invites", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, contentType)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("private")
+		c.Set("user", &oauth.User{DID: author})
+		require.NoError(t, h.CreateInvites(c))
+		var resp CreateInvitesResponse
+		if rec.Code == http.StatusCreated {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec.Code, resp
+	}
+
+	t.Run("only surveys with invite visibility", func(t *testing.T) {
+		code, _ := create(echo.MIMEApplicationJSON, `{"invitees": ["bob@example.com"]}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	survey.Definition.Visibility = models.VisibilityInvite
+	var tokens []string
+
+	t.Run("invite from JSON", func(t *testing.T) {
+		code, _ := create(echo.MIMEApplicationJSON, `{"invitees": ["bob@example.com", "not an invitee"]}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Empty(t, store.invites, "none are saved")
+
+		code, resp := create(echo.MIMEApplicationJSON, `{"invitees": ["Bob@Example.com", "did:plc:carol"]}`)
+		require.Equal(t, http.StatusCreated, code)
+		require.Len(t, resp.Invites, 2)
+		assert.Equal(t, "bob@example.com", resp.Invites[0].Invitee)
+		for _, created := range resp.Invites {
+			tokens = append(tokens, inviteToken(t, created))
+		}
+	})
+
+	t.Run("invite from CSV, skipping those already invited", func(t *testing.T) {
+		code, resp := create("text/csv", "email,name\nbob@example.com,Bob\ndave@example.com,Dave\n\n")
+		require.Equal(t, http.StatusCreated, code)
+		require.Len(t, resp.Invites, 1)
+		assert.Equal(t, "dave@example.com", resp.Invites[0].Invitee)
+		tokens = append(tokens, inviteToken(t, resp.Invites[0]))
+		assert.Equal(t, []string{"bob@example.com"}, resp.Skipped)
+		assert.Len(t, store.invites, 3)
+	})
+
+	t.Run("an invite link opens the survey", func(t *testing.T) {
+		rec := getPrivateSurvey(t, e, h, "/surveys/private", nil, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec = getPrivateSurvey(t, e, h, "/surveys/private?token="+tokens[0], nil, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("an invite link submits one response", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, submitText(t, e, h, "private", "hello").Code)
+
+		require.Equal(t, http.StatusCreated, submitWithInvite(t, e, h, "private", tokens[0]).Code)
+		require.Equal(t, http.StatusCreated, submitWithInvite(t, e, h, "private", tokens[1]).Code, "invitees on the same network each respond")
+
+		rec := submitWithInvite(t, e, h, "private", tokens[0])
+		require.Equal(t, http.StatusConflict, rec.Code)
+		var resp problem.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, problem.AlreadyVoted, resp.Code)
+
+		require.Len(t, mq.Responses, 2)
+		require.NotNil(t, store.invites[0].ResponseID)
+		assert.Contains(t, mq.Responses, *store.invites[0].ResponseID)
+	})
+
+	t.Run("list with the response rate", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.ListInvites, http.MethodGet, "", author, "slug", "private")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), store.invites[0].Hash)
+		var resp ListInvitesResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 3, resp.Invited)
+		assert.Equal(t, 2, resp.Responded)
+		assert.Len(t, resp.Invites, 3)
+
+		rec = callOrgAPI(t, e, h.ListInvites, http.MethodGet, "", "did:plc:mallory", "slug", "private")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("anonymous surveys don't link invites to responses", func(t *testing.T) {
+		survey.Definition.Anonymous = true
+		defer func() { survey.Definition.Anonymous = false }()
+
+		require.Equal(t, http.StatusCreated, submitWithInvite(t, e, h, "private", tokens[2]).Code)
+		assert.NotNil(t, store.invites[2].UsedAt)
+		assert.Nil(t, store.invites[2].ResponseID)
+	})
+
+	t.Run("delete an invite", func(t *testing.T) {
+		remove := func() int {
+			return callOrgAPI(t, e, h.DeleteInvite, http.MethodDelete, "", author, "slug", "id", "private", store.invites[0].ID.String()).Code
+		}
+		id := store.invites[0].ID
+		assert.Equal(t, http.StatusNoContent, remove())
+		assert.Nil(t, store.find(id))
+		assert.Equal(t, http.StatusForbidden, getPrivateSurvey(t, e, h, "/surveys/private?token="+tokens[0], nil, nil).Code)
+	})
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+	"github.com/openmeet-team/survey/lexicon"
+)
+
+// lexiconsPath is where the lexicon schemas of the survey records are published
+const lexiconsPath = "/.well-known/atproto-lexicons/"
+
+// ListLexicons handles GET /.well-known/atproto-lexicons/
+// Lists the published lexicon schemas, so ATProto developers can discover them
+func (h *Handlers) ListLexicons(c echo.Context) error {
+	resp := LexiconsResponse{Lexicons: []LexiconLink{}}
+	for _, id := range lexicon.IDs() {
+		resp.Lexicons = append(resp.Lexicons, LexiconLink{ID: id, URL: templates.AbsoluteURL(lexiconsPath + id)})
+	}
+	c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetLexicon handles GET /.well-known/atproto-lexicons/:nsid
+// Serves a lexicon schema as in the lexicon directory, with or without a .json suffix
+func (h *Handlers) GetLexicon(c echo.Context) error {
+	id := strings.TrimSuffix(c.Param("nsid"), ".json")
+	data, ok := lexicon.Document(id)
+	if !ok {
+		return Problem(c, problem.NotFound, "No lexicon has this NSID")
+	}
+	c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+	return c.JSONBlob(http.StatusOK, data)
+}
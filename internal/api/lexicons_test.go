@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexicons(t *testing.T) {
+	e, _, h := setupTest()
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/atproto-lexicons/", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, h.ListLexicons(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list LexiconsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.NotEmpty(t, list.Lexicons)
+	assert.Equal(t, "net.openmeet.survey", list.Lexicons[0].ID)
+	assert.Equal(t, "/.well-known/atproto-lexicons/net.openmeet.survey", list.Lexicons[0].URL)
+
+	get := func(nsid string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/.well-known/atproto-lexicons/"+nsid, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("nsid")
+		c.SetParamValues(nsid)
+		require.NoError(t, h.GetLexicon(c))
+		return rec
+	}
+
+	for _, nsid := range []string{"net.openmeet.survey.response", "net.openmeet.survey.response.json"} {
+		rec := get(nsid)
+		require.Equal(t, http.StatusOK, rec.Code, nsid)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "application/json")
+		assert.Equal(t, "public, max-age=3600", rec.Header().Get("Cache-Control"))
+		var schema map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &schema))
+		assert.Equal(t, "net.openmeet.survey.response", schema["id"])
+	}
+
+	assert.Equal(t, http.StatusNotFound, get("app.bsky.feed.post").Code)
+}
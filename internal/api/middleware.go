@@ -2,6 +2,7 @@ package api
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -64,7 +65,10 @@ func MetricsMiddleware() echo.MiddlewareFunc {
 // BodyLimitConfig defines body size limits for different route types
 type BodyLimitConfig struct {
 	SurveyCreation   string
+	BulkSurveyCreation string
 	ResponseSubmission string
+	ImageUpload      string
+	Generation       string
 	GeneralAPI       string
 }
 
@@ -72,7 +76,10 @@ type BodyLimitConfig struct {
 func DefaultBodyLimitConfig() BodyLimitConfig {
 	return BodyLimitConfig{
 		SurveyCreation:     "100KB", // Survey YAML definitions
+		BulkSurveyCreation: "2MB",   // Up to maxBulkSurveys definitions
 		ResponseSubmission: "10KB",  // Survey responses
+		ImageUpload:        "2MB",   // Question and option images (1 MB) with multipart overhead
+		Generation:         "128KB", // AI generation descriptions with an existing survey definition to refine
 		GeneralAPI:         "1MB",   // Default for other endpoints
 	}
 }
@@ -81,3 +88,47 @@ func DefaultBodyLimitConfig() BodyLimitConfig {
 func NewBodyLimitMiddleware(limit string) echo.MiddlewareFunc {
 	return middleware.BodyLimit(limit)
 }
+
+// BasePathMiddleware strips the path prefix the app is served under, for
+// reverse proxies that forward the prefix. Use it with e.Pre so routes match
+// the stripped path. Requests without the prefix are passed through unchanged.
+func BasePathMiddleware(basePath string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if basePath == "" {
+				return next(c)
+			}
+
+			req := c.Request()
+			if path, ok := stripBasePath(req.URL.Path, basePath); ok {
+				req.URL.Path = path
+				if req.URL.RawPath != "" {
+					if rawPath, ok := stripBasePath(req.URL.RawPath, basePath); ok {
+						req.URL.RawPath = rawPath
+					} else {
+						req.URL.RawPath = ""
+					}
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// stripBasePath removes the prefix from a path, mapping the prefix itself to "/"
+func stripBasePath(path, basePath string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, basePath)
+	if !ok {
+		return path, false
+	}
+	switch {
+	case rest == "":
+		return "/", true
+	case strings.HasPrefix(rest, "/"):
+		return rest, true
+	default:
+		// "/surveyfoo" is not under "/survey"
+		return path, false
+	}
+}
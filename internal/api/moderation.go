@@ -0,0 +1,121 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/moderation"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// createResponse saves a new response. With moderation enabled, its text
+// answers are checked first, outside the transaction, and the flags of
+// flagged answers are saved with the response.
+func (h *Handlers) createResponse(c echo.Context, response *models.Response) error {
+	ctx := c.Request().Context()
+	if h.moderationStore == nil {
+		return h.queries.CreateResponse(ctx, response)
+	}
+
+	flags := h.moderator.CheckAnswers(ctx, response.Answers).Flags(response.SurveyID, response.ID, response.Answers)
+	if err := h.moderationStore.CreateModeratedResponse(ctx, response, flags); err != nil {
+		return err
+	}
+	if len(flags) > 0 {
+		c.Logger().Infof("Flagged %d text answers of response %s for review", len(flags), response.ID)
+	}
+	return nil
+}
+
+// ModerationPageHTML lists flagged text answers of a survey for review
+// GET /surveys/:slug/moderation
+func (h *Handlers) ModerationPageHTML(c echo.Context) error {
+	if h.moderationStore == nil {
+		return c.String(http.StatusNotFound, "Moderation is not enabled")
+	}
+
+	survey, err := h.surveyBySlug(c.Request().Context(), c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user, profile := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canManageSurvey(c.Request().Context(), user, survey) {
+		return c.String(http.StatusForbidden, "Only the survey author can review flagged answers")
+	}
+
+	flagged, err := h.moderationStore.ListFlaggedResponsesBySurvey(c.Request().Context(), survey.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to list flagged responses: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load flagged answers")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.ModerationPage(survey, flagged, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
+
+// ReviewFlaggedResponseHTML approves or rejects a flagged text answer
+// POST /surveys/:slug/moderation/:id
+func (h *Handlers) ReviewFlaggedResponseHTML(c echo.Context) error {
+	if h.moderationStore == nil {
+		return c.String(http.StatusNotFound, "Moderation is not enabled")
+	}
+
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canManageSurvey(c.Request().Context(), user, survey) {
+		return c.String(http.StatusForbidden, "Only the survey author can review flagged answers")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid flag ID")
+	}
+
+	var reviewStatus string
+	switch c.FormValue("action") {
+	case "approve":
+		reviewStatus = moderation.StatusApproved
+	case "reject":
+		reviewStatus = moderation.StatusRejected
+	default:
+		return c.String(http.StatusBadRequest, "Action must be 'approve' or 'reject'")
+	}
+
+	if err := h.moderationStore.ReviewFlaggedResponse(c.Request().Context(), id, survey.ID, reviewStatus, user.DID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Flagged answer not found")
+		}
+		c.Logger().Errorf("Failed to review flagged response %s: %v", id, err)
+		return c.String(http.StatusInternalServerError, "Failed to save review")
+	}
+
+	// Approved answers appear in results, rejected ones stay hidden
+	h.cache.Invalidate(c.Request().Context(), cache.ResultsKey(survey.ID))
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+slug+"/moderation"))
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/moderation"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/org"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockModerationStore saves moderated responses to the mock queries, failing
+// the whole save when err is set, like a rolled back transaction
+type mockModerationStore struct {
+	queries *MockQueries
+	flags   []*moderation.FlaggedResponse
+	err     error
+}
+
+func (m *mockModerationStore) CreateModeratedResponse(ctx context.Context, r *models.Response, flags []*moderation.FlaggedResponse) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.flags = append(m.flags, flags...)
+	return m.queries.CreateResponse(ctx, r)
+}
+
+func (m *mockModerationStore) CreateFlaggedResponse(ctx context.Context, f *moderation.FlaggedResponse) error {
+	m.flags = append(m.flags, f)
+	return nil
+}
+
+func (m *mockModerationStore) DeleteFlaggedResponse(ctx context.Context, responseID uuid.UUID, questionID string) error {
+	return nil
+}
+
+func (m *mockModerationStore) ListFlaggedResponsesBySurvey(ctx context.Context, surveyID uuid.UUID) ([]*moderation.FlaggedResponse, error) {
+	return m.flags, nil
+}
+
+func (m *mockModerationStore) ReviewFlaggedResponse(ctx context.Context, id, surveyID uuid.UUID, status, reviewerDID string) error {
+	return nil
+}
+
+func createTextSurvey(mq *MockQueries, slug string, authorDID *string) *models.Survey {
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		Slug:      slug,
+		Title:     "Feedback",
+		AuthorDID: authorDID,
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "q1", Text: "Comments?", Type: models.QuestionTypeText},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	mq.CreateSurvey(context.Background(), survey)
+	return survey
+}
+
+func submitText(t *testing.T, e *echo.Echo, h *Handlers, slug, text string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(SubmitResponseRequest{
+		Answers: map[string]models.Answer{"q1": {Text: text}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/"+slug+"/responses", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	require.NoError(t, h.SubmitResponse(c))
+	return rec
+}
+
+func TestSubmitResponse_SavesFlagsWithResponse(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockModerationStore{queries: mq}
+	h.SetModeration(moderation.NewModerator(moderation.NewBlocklist([]string{"spam"})), store)
+	createTextSurvey(mq, "feedback", nil)
+
+	rec := submitText(t, e, h, "feedback", "buy spam now")
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	require.Len(t, mq.Responses, 1)
+	require.Len(t, store.flags, 1)
+	for id := range mq.Responses {
+		assert.Equal(t, id, store.flags[0].ResponseID)
+	}
+	assert.Equal(t, "q1", store.flags[0].QuestionID)
+}
+
+func TestSubmitResponse_FailedFlagSaveKeepsNoResponse(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockModerationStore{queries: mq, err: errors.New("connection reset")}
+	h.SetModeration(moderation.NewModerator(moderation.NewBlocklist([]string{"spam"})), store)
+	createTextSurvey(mq, "feedback", nil)
+
+	rec := submitText(t, e, h, "feedback", "buy spam now")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, mq.Responses, "an unmoderated response must not be saved")
+}
+
+func TestGetResultsHTML_ManagementLinksForAdmins(t *testing.T) {
+	author := "did:plc:author"
+	orgID := uuid.New()
+	accepted := time.Now()
+	tests := []struct {
+		name      string
+		did       string
+		wantLinks bool
+	}{
+		{"author", author, true},
+		{"admin", "did:plc:admin", true},
+		{"org editor", "did:plc:editor", true},
+		{"org viewer", "did:plc:viewer", false},
+		{"other user", "did:plc:other", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, mq, h := setupTest()
+			h.SetModeration(moderation.NewModerator(), &mockModerationStore{queries: mq})
+			h.SetAdmins([]string{"did:plc:admin"})
+			h.SetOrgs(&mockOrgStore{mq: mq, members: []*org.Member{
+				{OrgID: orgID, DID: "did:plc:editor", Role: org.RoleEditor, AcceptedAt: &accepted},
+				{OrgID: orgID, DID: "did:plc:viewer", Role: org.RoleViewer, AcceptedAt: &accepted},
+			}})
+			survey := createTextSurvey(mq, "feedback", &author)
+			survey.OrgID = &orgID
+
+			req := httptest.NewRequest(http.MethodGet, "/surveys/feedback/results", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("slug")
+			c.SetParamValues("feedback")
+			c.Set("user", &oauth.User{DID: tt.did})
+
+			require.NoError(t, h.GetResultsHTML(c))
+			assert.Equal(t, tt.wantLinks, bytes.Contains(rec.Body.Bytes(), []byte("Review Flagged Answers")))
+			assert.Equal(t, tt.wantLinks, bytes.Contains(rec.Body.Bytes(), []byte("Export CSV")))
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/bsky"
+	"github.com/openmeet-team/survey/internal/notify"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// SetNotifications enables milestone notifications of authors. Direct
+// messages are sent by messenger; without one only posts are offered. Call
+// notify.StartWorker with DeliverNotification to deliver them.
+func (h *Handlers) SetNotifications(store notify.Store, messenger *notify.Messenger) {
+	h.notifications = store
+	h.messenger = messenger
+}
+
+// DeliverNotification posts a milestone of a survey to its author's account
+// with their latest login session, or sends it to them as a direct message
+func (h *Handlers) DeliverNotification(ctx context.Context, n *notify.Notification) (string, error) {
+	survey, err := h.queries.GetSurveyByID(ctx, n.SurveyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load survey: %w", err)
+	}
+
+	link := "/s/" + survey.Slug
+	if n.Milestone == notify.Closed {
+		link = "/surveys/" + survey.Slug + "/results"
+	}
+	text := notify.Text(n.Milestone, survey.Title, templates.AbsoluteURL(link))
+
+	if n.Channel == notify.ChannelDM {
+		if h.messenger == nil {
+			return "", fmt.Errorf("direct messages are not enabled")
+		}
+		return "", h.messenger.Send(ctx, n.DID, text)
+	}
+
+	session, err := h.latestSession(ctx, n.DID)
+	if err != nil {
+		return "", err
+	}
+	var langs []string
+	if survey.Definition.Language != "" {
+		langs = []string{survey.Definition.Language}
+	}
+	record, err := bsky.NewPost(text, &bsky.External{URI: templates.AbsoluteURL(link), Title: survey.Title}, langs, time.Now())
+	if err != nil {
+		return "", err
+	}
+	uri, _, err := h.writeRecord(ctx, session, bsky.Collection, oauth.GenerateTID(), record)
+	return uri, err
+}
+
+// notificationsOwner returns the DID of the author whose notifications are
+// managed: the logged-in user or key owner
+func notificationsOwner(c echo.Context) (string, error) {
+	if did, ok := callerDID(c); ok {
+		return did, nil
+	}
+	return "", Problem(c, problem.AuthenticationRequired, "Log in or use an API key to manage notifications")
+}
+
+// GetNotifications handles GET /api/v1/me/notifications
+// Returns the caller's preferences (null if not opted in) and latest notifications
+func (h *Handlers) GetNotifications(c echo.Context) error {
+	did, err := notificationsOwner(c)
+	if did == "" {
+		return err
+	}
+
+	prefs, notifications, err := h.loadNotifications(c.Request().Context(), did)
+	if err != nil {
+		return InternalServerError(c, "Failed to load notifications", err)
+	}
+
+	return c.JSON(http.StatusOK, NotificationsResponse{
+		Preferences:    prefs,
+		Notifications:  notifications,
+		DirectMessages: h.messenger != nil,
+	})
+}
+
+// SaveNotificationPreferences handles PUT /api/v1/me/notifications/preferences
+// Opts the caller into notifications of the milestones of their surveys.
+// Milestones reached before are not notified.
+func (h *Handlers) SaveNotificationPreferences(c echo.Context) error {
+	did, err := notificationsOwner(c)
+	if did == "" {
+		return err
+	}
+
+	var req NotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+
+	prefs, err := h.newNotificationPreferences(did, req.Channel, req.Milestones)
+	if err != nil {
+		return Problem(c, problem.ValidationFailed, "Invalid preferences: "+err.Error())
+	}
+	if err := h.notifications.SaveNotificationPreferences(c.Request().Context(), prefs); err != nil {
+		return InternalServerError(c, "Failed to save preferences", err)
+	}
+
+	return c.JSON(http.StatusOK, prefs)
+}
+
+// DeleteNotificationPreferences handles DELETE /api/v1/me/notifications/preferences
+// Opts the caller out of notifications; queued ones are still delivered
+func (h *Handlers) DeleteNotificationPreferences(c echo.Context) error {
+	did, err := notificationsOwner(c)
+	if did == "" {
+		return err
+	}
+
+	if err := h.notifications.DeleteNotificationPreferences(c.Request().Context(), did); err != nil {
+		return InternalServerError(c, "Failed to delete preferences", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// NotificationsPageHTML shows the logged-in user's notification preferences
+// and latest notifications
+// GET /settings/notifications
+func (h *Handlers) NotificationsPageHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	return h.renderNotificationsPage(c, user, "")
+}
+
+// SaveNotificationPreferencesHTML saves the preferences of the notifications
+// page, opting out when no milestone is chosen
+// POST /settings/notifications
+func (h *Handlers) SaveNotificationPreferencesHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	ctx := c.Request().Context()
+
+	form, err := c.FormParams()
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid form")
+	}
+	var milestones []notify.Milestone
+	for _, m := range form["milestones"] {
+		milestones = append(milestones, notify.Milestone(m))
+	}
+
+	if len(milestones) == 0 {
+		if err := h.notifications.DeleteNotificationPreferences(ctx, user.DID); err != nil {
+			c.Logger().Errorf("Failed to delete notification preferences: %v", err)
+			return c.String(http.StatusInternalServerError, "Failed to save preferences")
+		}
+		return c.Redirect(http.StatusSeeOther, templates.AppPath("/settings/notifications"))
+	}
+
+	prefs, err := h.newNotificationPreferences(user.DID, notify.Channel(form.Get("channel")), milestones)
+	if err != nil {
+		return h.renderNotificationsPage(c, user, "Invalid preferences: "+err.Error())
+	}
+	if err := h.notifications.SaveNotificationPreferences(ctx, prefs); err != nil {
+		c.Logger().Errorf("Failed to save notification preferences: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to save preferences")
+	}
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/settings/notifications"))
+}
+
+// newNotificationPreferences validates preferences, rejecting direct
+// messages when they are not enabled
+func (h *Handlers) newNotificationPreferences(did string, channel notify.Channel, milestones []notify.Milestone) (*notify.Preferences, error) {
+	if channel == notify.ChannelDM && h.messenger == nil {
+		return nil, fmt.Errorf("direct messages are not enabled on this server")
+	}
+	return notify.NewPreferences(did, channel, milestones, time.Now())
+}
+
+// loadNotifications returns an author's preferences and latest notifications
+func (h *Handlers) loadNotifications(ctx context.Context, did string) (*notify.Preferences, []*notify.Notification, error) {
+	prefs, err := h.notifications.GetNotificationPreferences(ctx, did)
+	if err != nil {
+		return nil, nil, err
+	}
+	notifications, err := h.notifications.ListNotifications(ctx, did, notify.HistoryLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	if notifications == nil {
+		notifications = []*notify.Notification{}
+	}
+	return prefs, notifications, nil
+}
+
+// renderNotificationsPage renders the notifications page, with the error of
+// a failed preferences change
+func (h *Handlers) renderNotificationsPage(c echo.Context, user *oauth.User, formError string) error {
+	prefs, notifications, err := h.loadNotifications(c.Request().Context(), user.DID)
+	if err != nil {
+		c.Logger().Errorf("Failed to load notifications: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load notifications")
+	}
+
+	_, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.NotificationsPage(prefs, notifications, h.messenger != nil, formError, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/notify"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockNotifications keeps preferences and notifications in memory
+type mockNotifications struct {
+	prefs         map[string]*notify.Preferences
+	notifications []*notify.Notification
+}
+
+func (m *mockNotifications) GetNotificationPreferences(ctx context.Context, did string) (*notify.Preferences, error) {
+	return m.prefs[did], nil
+}
+
+func (m *mockNotifications) SaveNotificationPreferences(ctx context.Context, p *notify.Preferences) error {
+	m.prefs[p.DID] = p
+	return nil
+}
+
+func (m *mockNotifications) DeleteNotificationPreferences(ctx context.Context, did string) error {
+	delete(m.prefs, did)
+	return nil
+}
+
+func (m *mockNotifications) QueueReachedMilestones(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockNotifications) ClaimDueNotifications(ctx context.Context, now time.Time, limit int) ([]*notify.Notification, error) {
+	return nil, nil
+}
+
+func (m *mockNotifications) UpdateNotification(ctx context.Context, n *notify.Notification) error {
+	return nil
+}
+
+func (m *mockNotifications) ListNotifications(ctx context.Context, did string, limit int) ([]*notify.Notification, error) {
+	var notifications []*notify.Notification
+	for _, n := range m.notifications {
+		if n.DID == did {
+			notifications = append(notifications, n)
+		}
+	}
+	return notifications, nil
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	e, _, h := setupTest()
+	store := &mockNotifications{prefs: map[string]*notify.Preferences{}}
+	h.SetNotifications(store, nil)
+	alice := &oauth.User{DID: "did:plc:alice"}
+	store.notifications = []*notify.Notification{{DID: alice.DID, SurveyTitle: "Lunch", Milestone: notify.FirstResponse, Status: notify.StatusSent}}
+
+	call := func(method, body string, user *oauth.User, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/me/notifications/preferences", bytes.NewReader([]byte(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, handler(c))
+		return rec
+	}
+
+	rec := call(http.MethodGet, "", nil, h.GetNotifications)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = call(http.MethodPut, `{"channel": "post", "milestones": ["first_response", "closed", "closed"]}`, alice, h.SaveNotificationPreferences)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotNil(t, store.prefs[alice.DID])
+	assert.Equal(t, []notify.Milestone{notify.FirstResponse, notify.Closed}, store.prefs[alice.DID].Milestones)
+
+	rec = call(http.MethodPut, `{"channel": "dm", "milestones": ["closed"]}`, alice, h.SaveNotificationPreferences)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "direct messages need a messenger")
+	assert.Contains(t, rec.Body.String(), "Invalid preferences")
+
+	rec = call(http.MethodPut, `{"channel": "post", "milestones": ["responses_1000"]}`, alice, h.SaveNotificationPreferences)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, notify.ChannelPost, store.prefs[alice.DID].Channel)
+
+	rec = call(http.MethodGet, "", alice, h.GetNotifications)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp NotificationsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Preferences)
+	assert.Len(t, resp.Preferences.Milestones, 2)
+	require.Len(t, resp.Notifications, 1)
+	assert.Equal(t, "Lunch", resp.Notifications[0].SurveyTitle)
+	assert.False(t, resp.DirectMessages)
+
+	rec = call(http.MethodGet, "", &oauth.User{DID: "did:plc:bob"}, h.GetNotifications)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"preferences": null, "notifications": [], "directMessages": false}`, rec.Body.String())
+
+	rec = call(http.MethodDelete, "", alice, h.DeleteNotificationPreferences)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, store.prefs)
+}
@@ -0,0 +1,694 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/org"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// SetOrgs enables organizations, whose members share the ownership of
+// surveys moved to them
+func (h *Handlers) SetOrgs(store org.Store) {
+	h.orgs = store
+}
+
+// Errors of the organization helpers caused by the request
+var (
+	errInvalidOrgRequest = errors.New("invalid organization request")
+	errOrgForbidden      = errors.New("forbidden")
+)
+
+// canReadSurvey is canReadSurveyAs for a logged-in user
+func (h *Handlers) canReadSurvey(ctx context.Context, user *oauth.User, survey *models.Survey) bool {
+	if user == nil {
+		return false
+	}
+	return h.canReadSurveyAs(ctx, user.DID, survey)
+}
+
+// surveyOrgMember returns the membership of a DID in the organization owning a
+// survey, or nil. A failed lookup grants nothing.
+func (h *Handlers) surveyOrgMember(ctx context.Context, did string, survey *models.Survey) *org.Member {
+	if h.orgs == nil || survey.OrgID == nil || did == "" {
+		return nil
+	}
+	m, err := h.orgs.GetMember(ctx, *survey.OrgID, did)
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+// loadOrg returns an organization with the caller's membership of it, nil if
+// none. It returns sql.ErrNoRows if there is no such organization.
+func (h *Handlers) loadOrg(ctx context.Context, slug, did string) (*org.Org, *org.Member, error) {
+	o, err := h.orgs.GetOrgBySlug(ctx, slug)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := h.orgs.GetMember(ctx, o.ID, did)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, err
+	}
+	return o, m, nil
+}
+
+// createOrg creates an organization owned by its creator
+func (h *Handlers) createOrg(ctx context.Context, slug, name, did string) (*org.Org, error) {
+	count, err := h.orgs.CountOrgsByCreator(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if count >= org.MaxOrgsPerCreator && !h.adminDIDs[did] {
+		return nil, fmt.Errorf("%w: you can create at most %d organizations", errInvalidOrgRequest, org.MaxOrgsPerCreator)
+	}
+
+	o, err := org.New(slug, name, did)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidOrgRequest, err)
+	}
+	if err := h.orgs.CreateOrg(ctx, o); err != nil {
+		if errors.Is(err, org.ErrSlugTaken) {
+			return nil, fmt.Errorf("%w: %v", errInvalidOrgRequest, err)
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+// inviteOrgMember invites a user, by DID or handle, to an organization the
+// inviter owns
+func (h *Handlers) inviteOrgMember(ctx context.Context, o *org.Org, inviter *org.Member, invitee, role string) (*org.Member, error) {
+	if !inviter.CanAdminister() {
+		return nil, fmt.Errorf("%w: only owners can invite members", errOrgForbidden)
+	}
+	if !org.ValidRole(role) {
+		return nil, fmt.Errorf("%w: role must be owner, editor, or viewer", errInvalidOrgRequest)
+	}
+
+	did := strings.TrimPrefix(strings.TrimSpace(invitee), "@")
+	if did == "" {
+		return nil, fmt.Errorf("%w: enter the DID or handle of the member to invite", errInvalidOrgRequest)
+	}
+	if !strings.HasPrefix(did, "did:") {
+		resolved, err := h.resolveHandle(did)
+		if err != nil {
+			return nil, fmt.Errorf("%w: could not resolve handle %s", errInvalidOrgRequest, did)
+		}
+		did = resolved
+	}
+
+	members, err := h.orgs.ListMembers(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) >= org.MaxMembers {
+		return nil, fmt.Errorf("%w: an organization can have at most %d members and invites", errInvalidOrgRequest, org.MaxMembers)
+	}
+
+	m := &org.Member{
+		OrgID:     o.ID,
+		DID:       did,
+		Role:      role,
+		InvitedBy: inviter.DID,
+		CreatedAt: time.Now(),
+	}
+	if err := h.orgs.InviteMember(ctx, m); err != nil {
+		if errors.Is(err, org.ErrAlreadyMember) {
+			return nil, fmt.Errorf("%w: %s is %v", errInvalidOrgRequest, did, err)
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// changeOrgMember changes the role of a member, or removes them with an empty
+// role. Owners change anyone; other members can only leave or decline an
+// invite. The last owner can neither leave nor be demoted.
+func (h *Handlers) changeOrgMember(ctx context.Context, o *org.Org, caller *org.Member, callerDID, did, role string) error {
+	leaving := role == "" && did == callerDID
+	if !caller.CanAdminister() && !(leaving && caller != nil) {
+		return fmt.Errorf("%w: only owners can change members", errOrgForbidden)
+	}
+	if role != "" && !org.ValidRole(role) {
+		return fmt.Errorf("%w: role must be owner, editor, or viewer", errInvalidOrgRequest)
+	}
+
+	members, err := h.orgs.ListMembers(ctx, o.ID)
+	if err != nil {
+		return err
+	}
+	owners, target := 0, (*org.Member)(nil)
+	for _, m := range members {
+		if m.CanAdminister() {
+			owners++
+		}
+		if m.DID == did {
+			target = m
+		}
+	}
+	if target == nil {
+		return sql.ErrNoRows
+	}
+	if target.CanAdminister() && role != org.RoleOwner && owners == 1 {
+		return fmt.Errorf("%w: an organization needs an owner; make another member owner first", errInvalidOrgRequest)
+	}
+
+	if role == "" {
+		return h.orgs.RemoveMember(ctx, o.ID, did)
+	}
+	return h.orgs.UpdateMemberRole(ctx, o.ID, did, role)
+}
+
+// setSurveyOrg moves a survey to the organization with the slug, or back to
+// its author with an empty slug. Its author and admins move it into
+// organizations they can edit; they and the owners of its organization move
+// it out.
+func (h *Handlers) setSurveyOrg(ctx context.Context, survey *models.Survey, did, slug string) (*org.Org, error) {
+	isAuthor := survey.AuthorDID != nil && *survey.AuthorDID == did
+	mayMove := isAuthor || h.adminDIDs[did]
+
+	if slug == "" {
+		if !mayMove && !h.surveyOrgMember(ctx, did, survey).CanAdminister() {
+			return nil, fmt.Errorf("%w: only the survey author and owners of its organization can remove it", errOrgForbidden)
+		}
+		if err := h.orgs.SetSurveyOrg(ctx, survey.ID, nil); err != nil {
+			return nil, err
+		}
+		h.cache.Invalidate(ctx, cache.SurveyKey(survey.Slug))
+		return nil, nil
+	}
+
+	if !mayMove {
+		return nil, fmt.Errorf("%w: only the survey author can move it to an organization", errOrgForbidden)
+	}
+	o, m, err := h.loadOrg(ctx, slug, did)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: no organization with slug '%s'", errInvalidOrgRequest, slug)
+		}
+		return nil, err
+	}
+	if !m.CanEdit() && !h.adminDIDs[did] {
+		return nil, fmt.Errorf("%w: you must be an owner or editor of the organization", errOrgForbidden)
+	}
+	if err := h.orgs.SetSurveyOrg(ctx, survey.ID, &o.ID); err != nil {
+		return nil, err
+	}
+	h.cache.Invalidate(ctx, cache.SurveyKey(survey.Slug))
+	return o, nil
+}
+
+// orgErrorJSON responds to an API request whose organization helper failed
+func orgErrorJSON(c echo.Context, err error, action string) error {
+	switch {
+	case errors.Is(err, errOrgForbidden):
+		return Problem(c, problem.Forbidden, orgErrorMessage(err))
+	case errors.Is(err, errInvalidOrgRequest):
+		return ValidationError(c, "Invalid request", orgErrorMessage(err))
+	case errors.Is(err, sql.ErrNoRows):
+		return Problem(c, problem.NotFound, "Member not found: "+orgErrorMessage(err))
+	}
+	return InternalServerError(c, "Failed to "+action, err)
+}
+
+// orgErrorMessage is the message shown on a page whose organization helper
+// failed because of the request, or "" for internal errors
+func orgErrorMessage(err error) string {
+	for _, sentinel := range []error{errOrgForbidden, errInvalidOrgRequest} {
+		if errors.Is(err, sentinel) {
+			return strings.TrimPrefix(err.Error(), sentinel.Error()+": ")
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return "The organization has no such member or invite"
+	}
+	return ""
+}
+
+// requireCaller returns the DID of the logged-in user or API key owner, or
+// writes a 401 response
+func requireCaller(c echo.Context) (string, error) {
+	did, ok := callerDID(c)
+	if !ok {
+		return "", Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+	return did, nil
+}
+
+// orgForRequest loads the organization of an API request with the caller's
+// membership. On failure it returns a nil organization and the error response
+// written; organizations are hidden from those who are neither members nor invited.
+func (h *Handlers) orgForRequest(c echo.Context) (*org.Org, *org.Member, string, error) {
+	did, err := requireCaller(c)
+	if did == "" {
+		return nil, nil, "", err
+	}
+	o, m, err := h.loadOrg(c.Request().Context(), c.Param("org"), did)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, "", InternalServerError(c, "Failed to retrieve organization", err)
+	}
+	if o == nil || m == nil {
+		return nil, nil, "", Problem(c, problem.NotFound, fmt.Sprintf("Organization not found: No organization found with slug '%s'", c.Param("org")))
+	}
+	return o, m, did, nil
+}
+
+// ListOrgs handles GET /api/v1/orgs
+// Lists the caller's organizations and pending invites
+func (h *Handlers) ListOrgs(c echo.Context) error {
+	did, err := requireCaller(c)
+	if did == "" {
+		return err
+	}
+
+	memberships, err := h.orgs.ListMemberships(c.Request().Context(), did)
+	if err != nil {
+		return InternalServerError(c, "Failed to list organizations", err)
+	}
+	if memberships == nil {
+		memberships = []*org.Membership{}
+	}
+
+	return c.JSON(http.StatusOK, ListOrgsResponse{Orgs: memberships})
+}
+
+// CreateOrg handles POST /api/v1/orgs
+func (h *Handlers) CreateOrg(c echo.Context) error {
+	did, err := requireCaller(c)
+	if did == "" {
+		return err
+	}
+
+	var req CreateOrgRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+
+	o, err := h.createOrg(c.Request().Context(), req.Slug, req.Name, did)
+	if err != nil {
+		return orgErrorJSON(c, err, "create organization")
+	}
+
+	return c.JSON(http.StatusCreated, o)
+}
+
+// GetOrg handles GET /api/v1/orgs/:org
+// Returns an organization with its members and surveys, to its members
+func (h *Handlers) GetOrg(c echo.Context) error {
+	o, m, _, err := h.orgForRequest(c)
+	if o == nil {
+		return err
+	}
+	if !m.CanView() {
+		return Problem(c, problem.Forbidden, "Accept the invite to see the organization")
+	}
+
+	ctx := c.Request().Context()
+	members, err := h.orgs.ListMembers(ctx, o.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list members", err)
+	}
+	surveys, err := h.orgs.ListOrgSurveys(ctx, o.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list surveys", err)
+	}
+
+	resp := OrgResponse{Org: o, Members: members, Surveys: make([]OrgSurvey, 0, len(surveys))}
+	for _, s := range surveys {
+		resp.Surveys = append(resp.Surveys, OrgSurvey{Slug: s.Slug, Title: s.Title, AuthorDID: s.AuthorDID})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// InviteOrgMember handles POST /api/v1/orgs/:org/members
+// Invites a user by DID or handle; they join once they accept
+func (h *Handlers) InviteOrgMember(c echo.Context) error {
+	o, m, _, err := h.orgForRequest(c)
+	if o == nil {
+		return err
+	}
+
+	var req InviteOrgMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+
+	invited, err := h.inviteOrgMember(c.Request().Context(), o, m, req.Member, req.Role)
+	if err != nil {
+		return orgErrorJSON(c, err, "invite member")
+	}
+
+	return c.JSON(http.StatusCreated, invited)
+}
+
+// AcceptOrgInvite handles POST /api/v1/orgs/:org/accept
+func (h *Handlers) AcceptOrgInvite(c echo.Context) error {
+	o, _, did, err := h.orgForRequest(c)
+	if o == nil {
+		return err
+	}
+
+	if err := h.orgs.AcceptInvite(c.Request().Context(), o.ID, did); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ValidationError(c, "No pending invite", "You are already a member of the organization")
+		}
+		return InternalServerError(c, "Failed to accept invite", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UpdateOrgMember handles PUT /api/v1/orgs/:org/members/:did
+// Changes a member's role (owners only)
+func (h *Handlers) UpdateOrgMember(c echo.Context) error {
+	o, m, did, err := h.orgForRequest(c)
+	if o == nil {
+		return err
+	}
+
+	var req UpdateOrgMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+	if req.Role == "" {
+		return ValidationError(c, "Invalid request", "role is required")
+	}
+
+	if err := h.changeOrgMember(c.Request().Context(), o, m, did, memberParam(c), req.Role); err != nil {
+		return orgErrorJSON(c, err, "update member")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RemoveOrgMember handles DELETE /api/v1/orgs/:org/members/:did
+// Removes a member or invite; members can remove themselves to leave
+func (h *Handlers) RemoveOrgMember(c echo.Context) error {
+	o, m, did, err := h.orgForRequest(c)
+	if o == nil {
+		return err
+	}
+
+	if err := h.changeOrgMember(c.Request().Context(), o, m, did, memberParam(c), ""); err != nil {
+		return orgErrorJSON(c, err, "remove member")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// memberParam returns the DID of the :did path parameter, whose colons
+// clients may have escaped
+func memberParam(c echo.Context) string {
+	did, err := url.PathUnescape(c.Param("did"))
+	if err != nil {
+		return c.Param("did")
+	}
+	return did
+}
+
+// SetSurveyOrg handles PUT /api/v1/surveys/:slug/org
+// Moves a survey to an organization, or back to its author with an empty org
+func (h *Handlers) SetSurveyOrg(c echo.Context) error {
+	did, err := requireCaller(c)
+	if did == "" {
+		return err
+	}
+
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.SurveyNotFound, fmt.Sprintf("No survey found with slug '%s'", slug))
+		}
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+
+	var req SetSurveyOrgRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+
+	o, err := h.setSurveyOrg(c.Request().Context(), survey, did, req.Org)
+	if err != nil {
+		return orgErrorJSON(c, err, "move survey")
+	}
+
+	return c.JSON(http.StatusOK, SurveyOrgResponse{Slug: survey.Slug, Org: o})
+}
+
+// OrgsPageHTML lists the user's organizations and invites
+// GET /orgs
+func (h *Handlers) OrgsPageHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	return h.renderOrgsPage(c, user, "")
+}
+
+// CreateOrgHTML creates an organization from the form on the organizations page
+// POST /orgs
+func (h *Handlers) CreateOrgHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+
+	o, err := h.createOrg(c.Request().Context(), c.FormValue("slug"), c.FormValue("name"), user.DID)
+	if err != nil {
+		if msg := orgErrorMessage(err); msg != "" {
+			return h.renderOrgsPage(c, user, msg)
+		}
+		c.Logger().Errorf("Failed to create organization: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to create organization")
+	}
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/orgs/"+o.Slug))
+}
+
+// renderOrgsPage renders the organizations page, with the error of a failed creation
+func (h *Handlers) renderOrgsPage(c echo.Context, user *oauth.User, formError string) error {
+	memberships, err := h.orgs.ListMemberships(c.Request().Context(), user.DID)
+	if err != nil {
+		c.Logger().Errorf("Failed to list organizations: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load organizations")
+	}
+
+	_, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.OrgsPage(memberships, formError, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
+
+// orgForRequestHTML is orgForRequest for pages, which need a logged-in user
+func (h *Handlers) orgForRequestHTML(c echo.Context) (*org.Org, *org.Member, *oauth.User, error) {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return nil, nil, nil, c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	o, m, err := h.loadOrg(c.Request().Context(), c.Param("org"), user.DID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.Logger().Errorf("Failed to load organization: %v", err)
+		return nil, nil, nil, c.String(http.StatusInternalServerError, "Failed to load organization")
+	}
+	if o == nil || m == nil {
+		return nil, nil, nil, c.String(http.StatusNotFound, "Organization not found")
+	}
+	return o, m, user, nil
+}
+
+// OrgPageHTML shows an organization's members and surveys; invitees see their invite
+// GET /orgs/:org
+func (h *Handlers) OrgPageHTML(c echo.Context) error {
+	o, m, user, err := h.orgForRequestHTML(c)
+	if o == nil {
+		return err
+	}
+	return h.renderOrgPage(c, o, m, user, "")
+}
+
+// renderOrgPage renders an organization's page, with the error of a failed change
+func (h *Handlers) renderOrgPage(c echo.Context, o *org.Org, m *org.Member, user *oauth.User, formError string) error {
+	ctx := c.Request().Context()
+	var members []*org.Member
+	var surveys []*models.Survey
+	if m.CanView() {
+		var err error
+		if members, err = h.orgs.ListMembers(ctx, o.ID); err == nil {
+			surveys, err = h.orgs.ListOrgSurveys(ctx, o.ID)
+		}
+		if err != nil {
+			c.Logger().Errorf("Failed to load organization %s: %v", o.Slug, err)
+			return c.String(http.StatusInternalServerError, "Failed to load organization")
+		}
+	}
+
+	dids := make([]string, 0, len(members))
+	for _, member := range members {
+		dids = append(dids, member.DID)
+	}
+	names := h.identities.Resolve(ctx, dids)
+
+	_, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.OrgPage(o, m, members, names, surveys, formError, user, profile, h.posthogKey)
+	return component.Render(ctx, c.Response().Writer)
+}
+
+// InviteOrgMemberHTML invites a member from the organization page
+// POST /orgs/:org/invites
+func (h *Handlers) InviteOrgMemberHTML(c echo.Context) error {
+	o, m, user, err := h.orgForRequestHTML(c)
+	if o == nil {
+		return err
+	}
+
+	if _, err := h.inviteOrgMember(c.Request().Context(), o, m, c.FormValue("member"), c.FormValue("role")); err != nil {
+		if msg := orgErrorMessage(err); msg != "" {
+			return h.renderOrgPage(c, o, m, user, msg)
+		}
+		c.Logger().Errorf("Failed to invite member to %s: %v", o.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to invite member")
+	}
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/orgs/"+o.Slug))
+}
+
+// AcceptOrgInviteHTML accepts the user's invite to an organization
+// POST /orgs/:org/accept
+func (h *Handlers) AcceptOrgInviteHTML(c echo.Context) error {
+	o, _, user, err := h.orgForRequestHTML(c)
+	if o == nil {
+		return err
+	}
+
+	if err := h.orgs.AcceptInvite(c.Request().Context(), o.ID, user.DID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.Logger().Errorf("Failed to accept invite to %s: %v", o.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to accept invite")
+	}
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/orgs/"+o.Slug))
+}
+
+// UpdateOrgMemberHTML changes a member's role, or removes them when the form's
+// action is "remove"; members remove themselves to leave or decline an invite
+// POST /orgs/:org/members/:did
+func (h *Handlers) UpdateOrgMemberHTML(c echo.Context) error {
+	o, m, user, err := h.orgForRequestHTML(c)
+	if o == nil {
+		return err
+	}
+
+	did, role := memberParam(c), c.FormValue("role")
+	if c.FormValue("action") == "remove" {
+		role = ""
+	} else if role == "" {
+		return h.renderOrgPage(c, o, m, user, "Choose a role")
+	}
+
+	if err := h.changeOrgMember(c.Request().Context(), o, m, user.DID, did, role); err != nil {
+		if msg := orgErrorMessage(err); msg != "" {
+			return h.renderOrgPage(c, o, m, user, msg)
+		}
+		c.Logger().Errorf("Failed to change member of %s: %v", o.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to change member")
+	}
+
+	if role == "" && did == user.DID {
+		return c.Redirect(http.StatusSeeOther, templates.AppPath("/orgs"))
+	}
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/orgs/"+o.Slug))
+}
+
+// SurveyOrgPageHTML lets a survey's author move it to one of their organizations
+// GET /surveys/:slug/org
+func (h *Handlers) SurveyOrgPageHTML(c echo.Context) error {
+	survey, user, err := h.surveyOrgRequestHTML(c)
+	if survey == nil {
+		return err
+	}
+	return h.renderSurveyOrgPage(c, survey, user, "")
+}
+
+// SetSurveyOrgHTML moves a survey to the organization chosen on its page
+// POST /surveys/:slug/org
+func (h *Handlers) SetSurveyOrgHTML(c echo.Context) error {
+	survey, user, err := h.surveyOrgRequestHTML(c)
+	if survey == nil {
+		return err
+	}
+
+	if _, err := h.setSurveyOrg(c.Request().Context(), survey, user.DID, c.FormValue("org")); err != nil {
+		if msg := orgErrorMessage(err); msg != "" {
+			return h.renderSurveyOrgPage(c, survey, user, msg)
+		}
+		c.Logger().Errorf("Failed to move survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to move survey")
+	}
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug+"/results"))
+}
+
+// surveyOrgRequestHTML loads the survey of an ownership page for a user who manages it
+func (h *Handlers) surveyOrgRequestHTML(c echo.Context) (*models.Survey, *oauth.User, error) {
+	survey, err := h.surveyBySlug(c.Request().Context(), c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, c.String(http.StatusNotFound, "Survey not found")
+		}
+		return nil, nil, c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user := oauth.GetUser(c)
+	if user == nil {
+		return nil, nil, c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canManageSurvey(c.Request().Context(), user, survey) {
+		return nil, nil, c.String(http.StatusForbidden, "Only those who manage the survey can change its owner")
+	}
+	return survey, user, nil
+}
+
+// renderSurveyOrgPage renders the ownership page of a survey, listing the
+// organizations the user can move it to
+func (h *Handlers) renderSurveyOrgPage(c echo.Context, survey *models.Survey, user *oauth.User, formError string) error {
+	ctx := c.Request().Context()
+	memberships, err := h.orgs.ListMemberships(ctx, user.DID)
+	if err != nil {
+		c.Logger().Errorf("Failed to list organizations: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load organizations")
+	}
+	var choices []*org.Org
+	for _, ms := range memberships {
+		if ms.Member.CanEdit() {
+			choices = append(choices, ms.Org)
+		}
+	}
+
+	var current *org.Org
+	if survey.OrgID != nil {
+		if current, err = h.orgs.GetOrgByID(ctx, *survey.OrgID); err != nil {
+			c.Logger().Errorf("Failed to load organization of survey %s: %v", survey.Slug, err)
+			return c.String(http.StatusInternalServerError, "Failed to load organization")
+		}
+	}
+
+	_, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.SurveyOrgPage(survey, current, choices, formError, user, profile, h.posthogKey)
+	return component.Render(ctx, c.Response().Writer)
+}
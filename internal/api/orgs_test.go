@@ -0,0 +1,313 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/org"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOrgStore keeps organizations in memory, moving the surveys of mq
+type mockOrgStore struct {
+	mq      *MockQueries
+	orgs    []*org.Org
+	members []*org.Member
+}
+
+func (m *mockOrgStore) CreateOrg(ctx context.Context, o *org.Org) error {
+	if _, err := m.GetOrgBySlug(ctx, o.Slug); err == nil {
+		return org.ErrSlugTaken
+	}
+	m.orgs = append(m.orgs, o)
+	now := o.CreatedAt
+	m.members = append(m.members, &org.Member{OrgID: o.ID, DID: o.CreatedBy, Role: org.RoleOwner, InvitedBy: o.CreatedBy, AcceptedAt: &now, CreatedAt: now})
+	return nil
+}
+
+func (m *mockOrgStore) GetOrgBySlug(ctx context.Context, slug string) (*org.Org, error) {
+	for _, o := range m.orgs {
+		if o.Slug == slug {
+			return o, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockOrgStore) GetOrgByID(ctx context.Context, id uuid.UUID) (*org.Org, error) {
+	for _, o := range m.orgs {
+		if o.ID == id {
+			return o, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockOrgStore) CountOrgsByCreator(ctx context.Context, did string) (int, error) {
+	count := 0
+	for _, o := range m.orgs {
+		if o.CreatedBy == did {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockOrgStore) ListMemberships(ctx context.Context, did string) ([]*org.Membership, error) {
+	var memberships []*org.Membership
+	for _, member := range m.members {
+		if member.DID == did {
+			o, _ := m.GetOrgByID(ctx, member.OrgID)
+			memberships = append(memberships, &org.Membership{Org: o, Member: member})
+		}
+	}
+	return memberships, nil
+}
+
+func (m *mockOrgStore) ListMembers(ctx context.Context, orgID uuid.UUID) ([]*org.Member, error) {
+	var members []*org.Member
+	for _, member := range m.members {
+		if member.OrgID == orgID {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+func (m *mockOrgStore) GetMember(ctx context.Context, orgID uuid.UUID, did string) (*org.Member, error) {
+	for _, member := range m.members {
+		if member.OrgID == orgID && member.DID == did {
+			return member, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockOrgStore) InviteMember(ctx context.Context, member *org.Member) error {
+	if _, err := m.GetMember(ctx, member.OrgID, member.DID); err == nil {
+		return org.ErrAlreadyMember
+	}
+	m.members = append(m.members, member)
+	return nil
+}
+
+func (m *mockOrgStore) AcceptInvite(ctx context.Context, orgID uuid.UUID, did string) error {
+	member, err := m.GetMember(ctx, orgID, did)
+	if err != nil || !member.Pending() {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	member.AcceptedAt = &now
+	return nil
+}
+
+func (m *mockOrgStore) UpdateMemberRole(ctx context.Context, orgID uuid.UUID, did, role string) error {
+	member, err := m.GetMember(ctx, orgID, did)
+	if err != nil {
+		return err
+	}
+	member.Role = role
+	return nil
+}
+
+func (m *mockOrgStore) RemoveMember(ctx context.Context, orgID uuid.UUID, did string) error {
+	for i, member := range m.members {
+		if member.OrgID == orgID && member.DID == did {
+			m.members = append(m.members[:i], m.members[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *mockOrgStore) ListOrgSurveys(ctx context.Context, orgID uuid.UUID) ([]*models.Survey, error) {
+	var surveys []*models.Survey
+	for _, s := range m.mq.Surveys {
+		if s.OrgID != nil && *s.OrgID == orgID {
+			surveys = append(surveys, s)
+		}
+	}
+	return surveys, nil
+}
+
+func (m *mockOrgStore) SetSurveyOrg(ctx context.Context, surveyID uuid.UUID, orgID *uuid.UUID) error {
+	for _, s := range m.mq.Surveys {
+		if s.ID == surveyID {
+			s.OrgID = orgID
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// setupOrgTest returns handlers with organizations enabled, resolving handles
+// of the form name.test to did:plc:name
+func setupOrgTest() (*echo.Echo, *MockQueries, *Handlers, *mockOrgStore) {
+	e, mq, h := setupTest()
+	store := &mockOrgStore{mq: mq}
+	h.SetOrgs(store)
+	h.resolveHandle = func(handle string) (string, error) {
+		if name, ok := strings.CutSuffix(handle, ".test"); ok {
+			return "did:plc:" + name, nil
+		}
+		return "", fmt.Errorf("unknown handle %s", handle)
+	}
+	return e, mq, h, store
+}
+
+// callOrgAPI calls an organization handler as a user with a JSON body
+func callOrgAPI(t *testing.T, e *echo.Echo, handler echo.HandlerFunc, method, body string, user string, params ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, "/api/v1/orgs", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames(params[:len(params)/2]...)
+	c.SetParamValues(params[len(params)/2:]...)
+	if user != "" {
+		c.Set("user", &oauth.User{DID: user})
+	}
+	require.NoError(t, handler(c))
+	return rec
+}
+
+func TestOrgMembership(t *testing.T) {
+	e, _, h, _ := setupOrgTest()
+	alice, bob := "did:plc:alice", "did:plc:bob"
+
+	rec := callOrgAPI(t, e, h.CreateOrg, http.MethodPost, `{"slug": "acme", "name": "Acme"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = callOrgAPI(t, e, h.CreateOrg, http.MethodPost, `{"slug": "acme", "name": "Acme"}`, alice)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec = callOrgAPI(t, e, h.CreateOrg, http.MethodPost, `{"slug": "acme", "name": "Other"}`, bob)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "slugs are unique")
+
+	t.Run("invite by handle", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.InviteOrgMember, http.MethodPost, `{"member": "@nobody.example", "role": "editor"}`, alice, "org", "acme")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = callOrgAPI(t, e, h.InviteOrgMember, http.MethodPost, `{"member": "@bob.test", "role": "editor"}`, alice, "org", "acme")
+		require.Equal(t, http.StatusCreated, rec.Code)
+		var invited org.Member
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &invited))
+		assert.Equal(t, bob, invited.DID)
+		assert.Nil(t, invited.AcceptedAt)
+
+		rec = callOrgAPI(t, e, h.InviteOrgMember, http.MethodPost, `{"member": "did:plc:bob", "role": "viewer"}`, alice, "org", "acme")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "already invited")
+	})
+
+	t.Run("invitees accept before seeing the organization", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.GetOrg, http.MethodGet, "", bob, "org", "acme")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = callOrgAPI(t, e, h.AcceptOrgInvite, http.MethodPost, "", bob, "org", "acme")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec = callOrgAPI(t, e, h.GetOrg, http.MethodGet, "", bob, "org", "acme")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp OrgResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Len(t, resp.Members, 2)
+	})
+
+	t.Run("strangers do not see the organization", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.GetOrg, http.MethodGet, "", "did:plc:mallory", "org", "acme")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("only owners change members", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.InviteOrgMember, http.MethodPost, `{"member": "did:plc:carol", "role": "viewer"}`, bob, "org", "acme")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec = callOrgAPI(t, e, h.UpdateOrgMember, http.MethodPut, `{"role": "owner"}`, bob, "org", "did", "acme", bob)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("the last owner stays", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.UpdateOrgMember, http.MethodPut, `{"role": "editor"}`, alice, "org", "did", "acme", alice)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		rec = callOrgAPI(t, e, h.RemoveOrgMember, http.MethodDelete, "", alice, "org", "did", "acme", alice)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = callOrgAPI(t, e, h.UpdateOrgMember, http.MethodPut, `{"role": "owner"}`, alice, "org", "did", "acme", bob)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		rec = callOrgAPI(t, e, h.RemoveOrgMember, http.MethodDelete, "", alice, "org", "did", "acme", alice)
+		assert.Equal(t, http.StatusNoContent, rec.Code, "alice leaves once bob owns the organization")
+	})
+
+	rec = callOrgAPI(t, e, h.ListOrgs, http.MethodGet, "", bob)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list ListOrgsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Orgs, 1)
+	assert.Equal(t, org.RoleOwner, list.Orgs[0].Member.Role)
+}
+
+func TestOrgSurveyPermissions(t *testing.T) {
+	e, mq, h, store := setupOrgTest()
+	ctx := context.Background()
+	alice := "did:plc:alice"
+	survey := createTextSurvey(mq, "team-survey", &alice)
+
+	acme, err := h.createOrg(ctx, "acme", "Acme", alice)
+	require.NoError(t, err)
+	other, err := h.createOrg(ctx, "other", "Other", "did:plc:zed")
+	require.NoError(t, err)
+	now := time.Now()
+	store.members = append(store.members,
+		&org.Member{OrgID: acme.ID, DID: "did:plc:editor", Role: org.RoleEditor, AcceptedAt: &now},
+		&org.Member{OrgID: acme.ID, DID: "did:plc:viewer", Role: org.RoleViewer, AcceptedAt: &now},
+		&org.Member{OrgID: acme.ID, DID: "did:plc:invitee", Role: org.RoleEditor},
+	)
+
+	move := func(user, slug string) *httptest.ResponseRecorder {
+		return callOrgAPI(t, e, h.SetSurveyOrg, http.MethodPut, `{"org": "`+slug+`"}`, user, "slug", "team-survey")
+	}
+
+	assert.Equal(t, http.StatusForbidden, move(alice, other.Slug).Code, "authors only move surveys to their organizations")
+	assert.Equal(t, http.StatusForbidden, move("did:plc:editor", acme.Slug).Code, "only the author moves the survey in")
+	require.Equal(t, http.StatusOK, move(alice, acme.Slug).Code)
+	require.NotNil(t, survey.OrgID)
+
+	tests := []struct {
+		did          string
+		manage, read bool
+	}{
+		{alice, true, true},
+		{"did:plc:editor", true, true},
+		{"did:plc:viewer", false, true},
+		{"did:plc:invitee", false, false},
+		{"did:plc:mallory", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.did, func(t *testing.T) {
+			assert.Equal(t, tt.manage, h.canManageSurveyAs(ctx, tt.did, survey))
+			assert.Equal(t, tt.read, h.canReadSurveyAs(ctx, tt.did, survey))
+		})
+	}
+
+	t.Run("viewers list responses", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.ListVoterResponses, http.MethodGet, "", "did:plc:viewer", "slug", "team-survey")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("editors cannot take the survey away from the organization", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, move("did:plc:editor", "").Code)
+		require.Equal(t, http.StatusOK, move(acme.CreatedBy, "").Code)
+		assert.Nil(t, survey.OrgID)
+		assert.False(t, h.canManageSurveyAs(ctx, "did:plc:editor", survey))
+	})
+}
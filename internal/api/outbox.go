@@ -0,0 +1,127 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/outbox"
+	"github.com/openmeet-team/survey/internal/telemetry"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// queueRecord stores a record whose PDS write failed so the user can retry it.
+// Returns nil if the outbox is disabled or the entry could not be stored.
+func (h *Handlers) queueRecord(c echo.Context, entry *outbox.Entry) *outbox.Entry {
+	if h.outbox == nil || entry == nil {
+		return nil
+	}
+	if err := h.outbox.CreateOutboxEntry(c.Request().Context(), entry); err != nil {
+		c.Logger().Errorf("Failed to queue %s record %s: %v", entry.Kind, entry.URI(), err)
+		return nil
+	}
+	return entry
+}
+
+// pendingRecords returns the user's records of a survey that are not yet on their PDS
+func (h *Handlers) pendingRecords(c echo.Context, survey *models.Survey, user *oauth.User) []*outbox.Entry {
+	if h.outbox == nil || user == nil {
+		return nil
+	}
+	entries, err := h.outbox.ListPendingOutboxEntries(c.Request().Context(), survey.ID, user.DID)
+	if err != nil {
+		c.Logger().Errorf("Failed to list pending records for survey %s: %v", survey.Slug, err)
+		return nil
+	}
+	return entries
+}
+
+// RetryRecordHTML retries writing a queued survey or response record to the user's PDS
+// POST /surveys/:slug/outbox/:id/retry
+func (h *Handlers) RetryRecordHTML(c echo.Context) error {
+	if h.outbox == nil {
+		return c.String(http.StatusNotFound, "Publishing retries are not enabled")
+	}
+
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid record ID")
+	}
+
+	entry, err := h.outbox.GetOutboxEntry(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Record not found")
+		}
+		c.Logger().Errorf("Failed to load outbox entry %s: %v", id, err)
+		return c.String(http.StatusInternalServerError, "Failed to load record")
+	}
+	if entry.SurveyID != survey.ID {
+		return c.String(http.StatusNotFound, "Record not found")
+	}
+
+	// Only the owner of the repo can write the record
+	var session *oauth.OAuthSession
+	if h.oauthStorage != nil {
+		session, _ = oauth.GetSession(c, h.oauthStorage)
+	}
+	if session == nil {
+		component := templates.Error("You must log in to publish to your PDS")
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+	if session.DID != entry.DID {
+		return c.String(http.StatusForbidden, "This record belongs to another account")
+	}
+
+	if !entry.Pending() {
+		component := templates.RecordPublished(entry)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	record, err := entry.RecordValue()
+	if err != nil {
+		c.Logger().Errorf("Failed to decode outbox entry %s: %v", id, err)
+		return c.String(http.StatusInternalServerError, "Failed to load record")
+	}
+
+	pdsURI, pdsCID, err := h.writeRecord(c.Request().Context(), session, entry.Collection, entry.RKey, record)
+	if err != nil {
+		c.Logger().Errorf("Failed to retry writing %s to PDS: %v", entry.URI(), err)
+		telemetry.PDSOutboxRetriesTotal.WithLabelValues(entry.Kind, "error").Inc()
+		if err := h.outbox.RecordOutboxAttempt(c.Request().Context(), id, err.Error()); err != nil {
+			c.Logger().Errorf("Failed to record outbox attempt %s: %v", id, err)
+		}
+		entry.Attempts++
+		component := templates.PendingRecord(slug, entry, "Publishing to your PDS failed again. Please try again later.")
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	if err := h.outbox.MarkOutboxPublished(c.Request().Context(), entry, pdsURI, pdsCID); err != nil {
+		// The record is on the PDS; the consumer indexes it from the firehose
+		c.Logger().Errorf("Failed to link published record %s: %v", pdsURI, err)
+	}
+	telemetry.PDSOutboxRetriesTotal.WithLabelValues(entry.Kind, "success").Inc()
+	entry.Status = outbox.StatusPublished
+
+	if entry.Kind == outbox.KindSurvey {
+		h.crossPublishPoll(c, session, pdsURI, pdsCID, &survey.Definition)
+	}
+	h.cache.Invalidate(c.Request().Context(), cache.SurveyKey(slug), cache.ResultsKey(survey.ID))
+
+	component := templates.RecordPublished(entry)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
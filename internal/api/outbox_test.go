@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOutbox is an in-memory outbox.Store
+type mockOutbox struct {
+	entries map[uuid.UUID]*outbox.Entry
+}
+
+func newMockOutbox() *mockOutbox {
+	return &mockOutbox{entries: make(map[uuid.UUID]*outbox.Entry)}
+}
+
+func (m *mockOutbox) CreateOutboxEntry(ctx context.Context, e *outbox.Entry) error {
+	m.entries[e.ID] = e
+	return nil
+}
+
+func (m *mockOutbox) GetOutboxEntry(ctx context.Context, id uuid.UUID) (*outbox.Entry, error) {
+	if e, ok := m.entries[id]; ok {
+		return e, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockOutbox) ListPendingOutboxEntries(ctx context.Context, surveyID uuid.UUID, did string) ([]*outbox.Entry, error) {
+	var entries []*outbox.Entry
+	for _, e := range m.entries {
+		if e.SurveyID == surveyID && e.DID == did && e.Pending() {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (m *mockOutbox) RecordOutboxAttempt(ctx context.Context, id uuid.UUID, lastError string) error {
+	m.entries[id].Attempts++
+	m.entries[id].LastError = &lastError
+	return nil
+}
+
+func (m *mockOutbox) MarkOutboxPublished(ctx context.Context, e *outbox.Entry, uri, cid string) error {
+	m.entries[e.ID].Status = outbox.StatusPublished
+	return nil
+}
+
+func TestRetryRecordHTML(t *testing.T) {
+	e, mq, h := setupTest()
+
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		Slug:      "local-survey",
+		Title:     "Local Survey",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	mq.CreateSurvey(context.Background(), survey)
+
+	entry, err := outbox.NewEntry(outbox.KindSurvey, "did:plc:author", "net.openmeet.survey", "3kxyz", map[string]interface{}{"name": "Local Survey"})
+	require.NoError(t, err)
+	entry.SurveyID = survey.ID
+
+	retry := func(slug, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/surveys/"+slug+"/outbox/"+id+"/retry", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug", "id")
+		c.SetParamValues(slug, id)
+		require.NoError(t, h.RetryRecordHTML(c))
+		return rec
+	}
+
+	t.Run("outbox disabled", func(t *testing.T) {
+		rec := retry("local-survey", entry.ID.String())
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	store := newMockOutbox()
+	require.NoError(t, store.CreateOutboxEntry(context.Background(), entry))
+	h.SetOutbox(store)
+
+	t.Run("invalid ID", func(t *testing.T) {
+		rec := retry("local-survey", "not-a-uuid")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("entry of another survey", func(t *testing.T) {
+		other := &models.Survey{ID: uuid.New(), Slug: "other-survey", Title: "Other"}
+		mq.CreateSurvey(context.Background(), other)
+
+		rec := retry("other-survey", entry.ID.String())
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("not logged in", func(t *testing.T) {
+		rec := retry("local-survey", entry.ID.String())
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "log in")
+		assert.True(t, store.entries[entry.ID].Pending(), "entry stays pending")
+		assert.Equal(t, 0, store.entries[entry.ID].Attempts)
+	})
+}
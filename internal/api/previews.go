@@ -0,0 +1,73 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/preview"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// SetPreviews enables previews of survey definitions before publishing
+func (h *Handlers) SetPreviews(store preview.Store) {
+	h.previews = store
+}
+
+// CreatePreview handles POST /api/v1/surveys/preview
+// Validates a definition like survey creation and returns a short-lived link
+// to its voting form. No survey is created.
+func (h *Handlers) CreatePreview(c echo.Context) error {
+	var req PreviewSurveyRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+	if strings.TrimSpace(req.Definition) == "" {
+		return Problem(c, problem.ValidationFailed, "Definition is required")
+	}
+
+	def, err := parseNewDefinition(req.Definition)
+	if err != nil {
+		return Problem(c, problem.InvalidDefinition, err.Error())
+	}
+
+	// Like API surveys, previews cannot show images stored as PDS blobs
+	def.StripImages()
+
+	p, token, err := preview.New(*def, time.Now())
+	if err != nil {
+		return InternalServerError(c, "Failed to create preview", err)
+	}
+	if err := h.previews.CreatePreview(c.Request().Context(), p); err != nil {
+		return InternalServerError(c, "Failed to create preview", err)
+	}
+
+	return c.JSON(http.StatusCreated, PreviewSurveyResponse{
+		Token:     token,
+		URL:       templates.AbsoluteURL("/surveys/preview/" + token),
+		ExpiresAt: p.ExpiresAt,
+	})
+}
+
+// PreviewHTML handles GET /surveys/preview/:token
+// Renders the voting form of a preview, which submits nothing
+func (h *Handlers) PreviewHTML(c echo.Context) error {
+	p, err := h.previews.GetPreview(c.Request().Context(), preview.Hash(c.Param("token")), time.Now())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Preview not found or expired")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load preview")
+	}
+
+	user, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().Header().Set("X-Robots-Tag", "noindex")
+	c.Response().Header().Set("Cache-Control", "no-store")
+	component := templates.SurveyPreview(p.Survey(), p.ExpiresAt, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
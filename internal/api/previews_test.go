@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/preview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPreviews keeps previews in memory, by hash
+type mockPreviews struct {
+	previews map[string]*preview.Preview
+}
+
+func (m *mockPreviews) CreatePreview(ctx context.Context, p *preview.Preview) error {
+	m.previews[p.Hash] = p
+	return nil
+}
+
+func (m *mockPreviews) GetPreview(ctx context.Context, hash string, now time.Time) (*preview.Preview, error) {
+	p, ok := m.previews[hash]
+	if !ok || !p.ExpiresAt.After(now) {
+		return nil, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (m *mockPreviews) DeleteExpiredPreviews(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestPreview(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockPreviews{previews: map[string]*preview.Preview{}}
+	h.SetPreviews(store)
+
+	create := func(definition string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(PreviewSurveyRequest{Definition: definition})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/preview", strings.NewReader(string(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		require.NoError(t, h.CreatePreview(e.NewContext(req, rec)))
+		return rec
+	}
+	view := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/surveys/preview/"+token, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("token")
+		c.SetParamValues(token)
+		require.NoError(t, h.PreviewHTML(c))
+		return rec
+	}
+
+	rec := create("questions:\n  - id: lunch\n    text: Where to lunch?\n    type: single\n    required: true\n    options:\n      - id: a\n        text: Pizza place\n      - id: b\n        text: Salad bar\n")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created PreviewSurveyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.True(t, strings.HasPrefix(created.Token, preview.TokenPrefix))
+	assert.True(t, strings.HasSuffix(created.URL, "/surveys/preview/"+created.Token))
+	assert.Len(t, store.previews, 1)
+	assert.Empty(t, mq.Surveys, "previews create no survey")
+
+	rec = view(created.Token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	html := rec.Body.String()
+	assert.Contains(t, html, "Where to lunch?")
+	assert.Contains(t, html, "Salad bar")
+	assert.Contains(t, html, "Submit Response")
+	assert.NotContains(t, html, "hx-post", "previews submit nothing")
+	assert.Equal(t, "noindex", rec.Header().Get("X-Robots-Tag"))
+
+	// Previews validate definitions like survey creation
+	rec = create(`{"questions": [{"id": "q1", "text": "Which?", "type": "single"}]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = create("  ")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Len(t, store.previews, 1)
+
+	assert.Equal(t, http.StatusNotFound, view("pv_unknown").Code)
+	for _, p := range store.previews {
+		p.ExpiresAt = time.Now().Add(-time.Minute)
+	}
+	assert.Equal(t, http.StatusNotFound, view(created.Token).Code, "expired previews are gone")
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/questionbank"
+)
+
+// SetQuestionBank enables the question banks of logged-in users
+func (h *Handlers) SetQuestionBank(store questionbank.Store) {
+	h.questionBank = store
+}
+
+// bankOwner returns the DID owning the caller's question bank: the logged-in
+// user or key owner. Guests have no bank.
+func bankOwner(c echo.Context) (string, error) {
+	if did, ok := callerDID(c); ok {
+		return did, nil
+	}
+	return "", Problem(c, problem.AuthenticationRequired, "Log in or use an API key to use your question bank")
+}
+
+// ListBankQuestions handles GET /api/v1/me/questions?q=&limit=
+// Lists the caller's saved questions, most recently saved first. q searches
+// the text of questions and their options.
+func (h *Handlers) ListBankQuestions(c echo.Context) error {
+	owner, err := bankOwner(c)
+	if owner == "" {
+		return err
+	}
+
+	search := c.QueryParam("q")
+	if len(search) > questionbank.MaxSearchLength {
+		return Problem(c, problem.ValidationFailed, "Search too long: at most 100 bytes")
+	}
+	limit := questionbank.DefaultLimit
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+		limit = min(l, questionbank.MaxLimit)
+	}
+
+	questions, err := h.questionBank.ListBankQuestions(c.Request().Context(), owner, search, limit)
+	if err != nil {
+		return InternalServerError(c, "Failed to list questions", err)
+	}
+	if questions == nil {
+		questions = []*questionbank.Question{}
+	}
+
+	return c.JSON(http.StatusOK, ListBankQuestionsResponse{Questions: questions})
+}
+
+// SaveBankQuestion handles POST /api/v1/me/questions
+// Saves a question, validated as in survey definitions, to the caller's bank
+func (h *Handlers) SaveBankQuestion(c echo.Context) error {
+	owner, err := bankOwner(c)
+	if owner == "" {
+		return err
+	}
+
+	var req SaveBankQuestionRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+
+	q, err := questionbank.New(owner, req.Question, time.Now())
+	if err != nil {
+		return Problem(c, problem.ValidationFailed, "Invalid question: "+err.Error())
+	}
+
+	ctx := c.Request().Context()
+	count, err := h.questionBank.CountBankQuestions(ctx, owner)
+	if err != nil {
+		return InternalServerError(c, "Failed to save question", err)
+	}
+	if count >= questionbank.MaxQuestions {
+		return Problem(c, problem.LimitReached, questionbank.ErrFull.Error())
+	}
+
+	if err := h.questionBank.CreateBankQuestion(ctx, q); err != nil {
+		return InternalServerError(c, "Failed to save question", err)
+	}
+
+	return c.JSON(http.StatusCreated, q)
+}
+
+// DeleteBankQuestion handles DELETE /api/v1/me/questions/:id
+func (h *Handlers) DeleteBankQuestion(c echo.Context) error {
+	owner, err := bankOwner(c)
+	if owner == "" {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return Problem(c, problem.ValidationFailed, "Invalid question ID: "+err.Error())
+	}
+
+	if err := h.questionBank.DeleteBankQuestion(c.Request().Context(), id, owner); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.NotFound, "Question not found: No saved question with this ID belongs to you")
+		}
+		return InternalServerError(c, "Failed to delete question", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/questionbank"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockQuestionBank keeps saved questions in memory, most recent last
+type mockQuestionBank struct {
+	questions []*questionbank.Question
+}
+
+func (m *mockQuestionBank) CreateBankQuestion(ctx context.Context, q *questionbank.Question) error {
+	m.questions = append(m.questions, q)
+	return nil
+}
+
+func (m *mockQuestionBank) ListBankQuestions(ctx context.Context, ownerDID, search string, limit int) ([]*questionbank.Question, error) {
+	var questions []*questionbank.Question
+	for i := len(m.questions) - 1; i >= 0 && len(questions) < limit; i-- {
+		q := m.questions[i]
+		text := q.Question.Text
+		for _, o := range q.Question.Options {
+			text += " " + o.Text
+		}
+		if q.OwnerDID == ownerDID && strings.Contains(strings.ToLower(text), strings.ToLower(search)) {
+			questions = append(questions, q)
+		}
+	}
+	return questions, nil
+}
+
+func (m *mockQuestionBank) CountBankQuestions(ctx context.Context, ownerDID string) (int, error) {
+	count := 0
+	for _, q := range m.questions {
+		if q.OwnerDID == ownerDID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockQuestionBank) DeleteBankQuestion(ctx context.Context, id uuid.UUID, ownerDID string) error {
+	for i, q := range m.questions {
+		if q.ID == id && q.OwnerDID == ownerDID {
+			m.questions = append(m.questions[:i], m.questions[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func TestQuestionBank(t *testing.T) {
+	e, _, h := setupTest()
+	store := &mockQuestionBank{}
+	h.SetQuestionBank(store)
+	alice := &oauth.User{DID: "did:plc:alice"}
+
+	call := func(method, target, body, id string, user *oauth.User, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewReader([]byte(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if id != "" {
+			c.SetParamNames("id")
+			c.SetParamValues(id)
+		}
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, handler(c))
+		return rec
+	}
+
+	rec := call(http.MethodGet, "/api/v1/me/questions", "", "", nil, h.ListBankQuestions)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "guests have no bank")
+
+	rec = call(http.MethodPost, "/api/v1/me/questions", `{"question": {"id": "lunch", "text": "Lunch?", "type": "single", "options": [{"id": "a", "text": "Pizza"}, {"id": "b", "text": "Salad"}]}}`, "", alice, h.SaveBankQuestion)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var saved questionbank.Question
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &saved))
+	assert.Equal(t, "Lunch?", saved.Question.Text)
+	assert.NotContains(t, rec.Body.String(), "did:plc:alice", "the owner is not part of the response")
+
+	rec = call(http.MethodPost, "/api/v1/me/questions", `{"question": {"id": "mood", "text": "Mood?", "type": "text"}}`, "", alice, h.SaveBankQuestion)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = call(http.MethodPost, "/api/v1/me/questions", `{"question": {"id": "empty", "text": "Which?", "type": "single"}}`, "", alice, h.SaveBankQuestion)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Invalid question")
+	assert.Len(t, store.questions, 2)
+
+	// Searches match options as well as question text
+	rec = call(http.MethodGet, "/api/v1/me/questions?q=salad", "", "", alice, h.ListBankQuestions)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list ListBankQuestionsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Questions, 1)
+	assert.Equal(t, saved.ID, list.Questions[0].ID)
+
+	rec = call(http.MethodGet, "/api/v1/me/questions", "", "", &oauth.User{DID: "did:plc:bob"}, h.ListBankQuestions)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"questions": []}`, rec.Body.String())
+
+	rec = call(http.MethodDelete, "/api/v1/me/questions/"+saved.ID.String(), "", saved.ID.String(), &oauth.User{DID: "did:plc:bob"}, h.DeleteBankQuestion)
+	assert.Equal(t, http.StatusNotFound, rec.Code, "questions can only be deleted by their owner")
+
+	rec = call(http.MethodDelete, "/api/v1/me/questions/"+saved.ID.String(), "", saved.ID.String(), alice, h.DeleteBankQuestion)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Len(t, store.questions, 1)
+}
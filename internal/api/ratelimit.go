@@ -1,11 +1,16 @@
 package api
 
 import (
-	"net/http"
+	"math"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/generator"
+	"github.com/openmeet-team/survey/internal/problem"
 	"golang.org/x/time/rate"
 )
 
@@ -16,6 +21,10 @@ type IPRateLimiter struct {
 	rate     rate.Limit
 	burst    int
 	duration time.Duration
+
+	// limitAPIKeys keeps the limit in force for requests authenticated with an
+	// API key, applying it per key as well as per IP
+	limitAPIKeys bool
 }
 
 // rateLimiterEntry holds a rate limiter and its last access time for cleanup
@@ -84,6 +93,12 @@ func (rl *IPRateLimiter) cleanup() {
 	}
 }
 
+// Remaining returns how many requests an IP can make right now, and the
+// burst it can make at most, without counting a request
+func (rl *IPRateLimiter) Remaining(ip string) (int, int) {
+	return int(rl.getLimiter(ip).Tokens()), rl.burst
+}
+
 // getIP extracts the IP address from the request
 // Uses the secure getClientIP function from ip_extraction.go
 func getIP(c echo.Context) string {
@@ -94,42 +109,208 @@ func getIP(c echo.Context) string {
 func (rl *IPRateLimiter) Middleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			ip := getIP(c)
-			limiter := rl.getLimiter(ip)
+			if allowed, err := rl.AllowN(c, 1); !allowed {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// AllowN charges a request as n requests, e.g. one per item of a batch, and
+// reports whether they were allowed. If not, it has responded 429 Too Many
+// Requests and returns the error of writing the response.
+func (rl *IPRateLimiter) AllowN(c echo.Context, n int) (bool, error) {
+	// Requests authenticated with an API key are limited per key instead,
+	// unless this limit must also hold for keys (e.g. vote submission)
+	key := APIKeyFromContext(c)
+	if key != nil && !rl.limitAPIKeys {
+		return true, nil
+	}
 
-			if !limiter.Allow() {
-				// Calculate retry after duration
-				reservation := limiter.Reserve()
-				delay := reservation.Delay()
-				reservation.Cancel()
+	limiters := []*rate.Limiter{rl.getLimiter(getIP(c))}
+	if key != nil {
+		// Cap each key at this limit too, whatever its own rate limit
+		limiters = append(limiters, rl.getLimiter("apikey:"+key.ID.String()))
+	}
 
-				c.Response().Header().Set("Retry-After", delay.String())
+	// Report the limit closest to running out
+	var status rateLimitStatus
+	for i, limiter := range limiters {
+		allowed := limiter.AllowN(time.Now(), n)
+		s := bucketStatus(limiter)
+		if !allowed {
+			return false, rateLimitExceeded(c, s, "Too many requests. Please try again later.")
+		}
+		if i == 0 || s.remaining < status.remaining {
+			status = s
+		}
+	}
+	setRateLimitHeaders(c, status)
+	return true, nil
+}
 
-				return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
-					"error":   "Rate limit exceeded",
-					"message": "Too many requests. Please try again later.",
-				})
-			}
+// rateLimitStatus is what a caller has left of a rate limit
+type rateLimitStatus struct {
+	limit      int           // Requests per window, or the burst of a token bucket
+	remaining  int           // Requests that can be made now
+	reset      time.Duration // Until the full limit is available again
+	retryAfter time.Duration // Until the next request is allowed, if none remain
+}
 
-			return next(c)
+// bucketStatus returns the status of a token bucket limiter, whose burst is
+// the limit and whose tokens are the remaining requests
+func bucketStatus(limiter *rate.Limiter) rateLimitStatus {
+	tokens := limiter.Tokens()
+	perToken := time.Duration(float64(time.Second) / float64(limiter.Limit()))
+	status := rateLimitStatus{
+		limit:     limiter.Burst(),
+		remaining: max(int(tokens), 0),
+		reset:     time.Duration((float64(limiter.Burst()) - tokens) * float64(perToken)),
+	}
+	if tokens < 1 {
+		status.retryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return status
+}
+
+// quotaStatus returns the status of a fixed window quota at now
+func quotaStatus(q generator.Quota, now time.Time) rateLimitStatus {
+	status := rateLimitStatus{
+		limit:     q.Limit,
+		remaining: q.Remaining,
+		reset:     max(q.Reset.Sub(now), 0),
+	}
+	if q.Remaining == 0 {
+		status.retryAfter = status.reset
+	}
+	return status
+}
+
+// setRateLimitHeaders sets the RateLimit-Limit, RateLimit-Remaining, and
+// RateLimit-Reset headers (IETF httpapi-ratelimit-headers draft). When
+// several limits apply to a request, the one with fewest remaining requests wins.
+func setRateLimitHeaders(c echo.Context, status rateLimitStatus) {
+	header := c.Response().Header()
+	if current, err := strconv.Atoi(header.Get("RateLimit-Remaining")); err == nil && current <= status.remaining {
+		return
+	}
+	header.Set("RateLimit-Limit", strconv.Itoa(status.limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(status.remaining))
+	header.Set("RateLimit-Reset", retryAfter(status.reset))
+}
+
+// rateLimitExceeded responds 429 Too Many Requests with the rate limit
+// headers, Retry-After, and a rate_limited problem
+func rateLimitExceeded(c echo.Context, status rateLimitStatus, details string) error {
+	status.remaining = 0
+	c.Response().Header().Del("RateLimit-Remaining")
+	setRateLimitHeaders(c, status)
+	c.Response().Header().Set("Retry-After", retryAfter(status.retryAfter))
+
+	p := problem.New(problem.RateLimited, details)
+	p.Limit = status.limit
+	p.RetryAfter = int(math.Ceil(status.retryAfter.Seconds()))
+	return WriteProblem(c, p)
+}
+
+// retryAfter formats a delay as a Retry-After value: whole seconds, rounded up
+func retryAfter(delay time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(delay.Seconds())))
+}
+
+// KeyRateLimiter manages rate limiters per API key, each at its key's own limit
+type KeyRateLimiter struct {
+	limiters map[uuid.UUID]*rateLimiterEntry
+	mu       sync.Mutex
+}
+
+// NewKeyRateLimiter creates a new API key rate limiter
+func NewKeyRateLimiter() *KeyRateLimiter {
+	limiter := &KeyRateLimiter{
+		limiters: make(map[uuid.UUID]*rateLimiterEntry),
+	}
+
+	// Start background cleanup goroutine
+	go limiter.cleanupLoop()
+
+	return limiter
+}
+
+// getLimiter retrieves or creates the rate limiter of a key. The limiter is
+// replaced if the key's limit changed.
+func (rl *KeyRateLimiter) getLimiter(key *apikey.Key) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	limit := rate.Limit(float64(key.RateLimit) / time.Minute.Seconds())
+	entry, exists := rl.limiters[key.ID]
+	if !exists || entry.limiter.Burst() != key.RateLimit {
+		entry = &rateLimiterEntry{limiter: rate.NewLimiter(limit, key.RateLimit)}
+		rl.limiters[key.ID] = entry
+	}
+
+	entry.lastAccess = time.Now()
+	return entry.limiter
+}
+
+// cleanupLoop removes rate limiters of keys that haven't been used recently
+func (rl *KeyRateLimiter) cleanupLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		rl.cleanup()
+	}
+}
+
+// cleanup removes stale rate limiters
+func (rl *KeyRateLimiter) cleanup() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	for id, entry := range rl.limiters {
+		if now.Sub(entry.lastAccess) > 2*time.Minute {
+			delete(rl.limiters, id)
 		}
 	}
 }
 
+// Allow reports whether the key may make a request now, and what is left of
+// its limit
+func (rl *KeyRateLimiter) Allow(key *apikey.Key) (bool, rateLimitStatus) {
+	limiter := rl.getLimiter(key)
+	allowed := limiter.Allow()
+	return allowed, bucketStatus(limiter)
+}
+
 // RateLimiterConfig holds different rate limiters for different endpoint types
 type RateLimiterConfig struct {
 	SurveyCreation *IPRateLimiter
+	BulkSurveys    *IPRateLimiter // Charged per survey of a bulk creation
 	VoteSubmission *IPRateLimiter
 	GeneralAPI     *IPRateLimiter
 	OAuth          *IPRateLimiter
+	SurveyReport   *IPRateLimiter
 }
 
 // NewRateLimiterConfig creates rate limiters with the specified limits
 func NewRateLimiterConfig() *RateLimiterConfig {
-	return &RateLimiterConfig{
-		SurveyCreation: NewIPRateLimiter(5, time.Minute),   // 5 requests per minute
-		VoteSubmission: NewIPRateLimiter(10, time.Minute),  // 10 requests per minute
-		GeneralAPI:     NewIPRateLimiter(60, time.Minute),  // 60 requests per minute
-		OAuth:          NewIPRateLimiter(10, time.Minute),  // 10 requests per minute
+	config := &RateLimiterConfig{
+		SurveyCreation: NewIPRateLimiter(5, time.Minute),            // 5 requests per minute
+		BulkSurveys:    NewIPRateLimiter(maxBulkSurveys, time.Hour), // 100 surveys per hour
+		VoteSubmission: NewIPRateLimiter(10, time.Minute),           // 10 requests per minute
+		GeneralAPI:     NewIPRateLimiter(60, time.Minute),           // 60 requests per minute
+		OAuth:          NewIPRateLimiter(10, time.Minute),           // 10 requests per minute
+		SurveyReport:   NewIPRateLimiter(5, time.Hour),              // 5 requests per hour
 	}
+
+	// API keys must not raise how fast ballots can be cast
+	config.VoteSubmission.limitAPIKeys = true
+
+	// A key's limit counts requests, so it cannot bound surveys created in bulk
+	config.BulkSurveys.limitAPIKeys = true
+
+	return config
 }
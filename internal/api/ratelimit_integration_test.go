@@ -40,9 +40,9 @@ func createTestSurvey(mq *MockQueries, slug string) *models.Survey {
 			},
 		},
 	}
-	mq.surveys[slug] = survey
-	mq.slugs[slug] = true
-	mq.responsesBySurvey[survey.ID] = make(map[string]*models.Response)
+	mq.Surveys[slug] = survey
+	mq.Slugs[slug] = true
+	mq.ResponsesBySurvey[survey.ID] = make(map[string]*models.Response)
 	return survey
 }
 
@@ -88,7 +88,7 @@ func TestRateLimiting_SurveyCreationEndpoint(t *testing.T) {
 	var response map[string]interface{}
 	err := json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Contains(t, response["error"], "Rate limit")
+	assert.Equal(t, "rate_limited", response["code"])
 
 	// Verify only 3 requests succeeded
 	assert.Equal(t, 3, successCount, "Should have processed 3 successful requests")
@@ -1,13 +1,16 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/problem"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestRateLimiter_WithinLimit tests that requests within rate limit succeed
@@ -74,7 +77,7 @@ func TestRateLimiter_ExceedsLimit(t *testing.T) {
 	err := rateLimitedHandler(c)
 	assert.NoError(t, err) // Middleware handles the error
 	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
-	assert.Contains(t, rec.Header().Get("Retry-After"), "")
+	assert.Regexp(t, `^[0-9]+$`, rec.Header().Get("Retry-After"))
 }
 
 // TestRateLimiter_DifferentIPs tests that different IPs have separate limits
@@ -243,3 +246,41 @@ func TestRateLimiterConfig_OAuth(t *testing.T) {
 	// OAuth should be 10 req/min
 	assert.NotNil(t, config.OAuth)
 }
+
+// TestRateLimiter_Headers tests the RateLimit headers and the 429 body
+func TestRateLimiter_Headers(t *testing.T) {
+	e := echo.New()
+	limiter := NewIPRateLimiter(2, time.Minute)
+	handler := limiter.Middleware()(func(c echo.Context) error {
+		return c.String(http.StatusOK, "success")
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "192.168.1.50:12345"
+		rec := httptest.NewRecorder()
+		require.NoError(t, handler(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := serve()
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", rec.Header().Get("RateLimit-Reset"))
+
+	rec = serve()
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+
+	rec = serve()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+	var resp problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, problem.RateLimited, resp.Code)
+	assert.NotEmpty(t, resp.Detail)
+	assert.Equal(t, 2, resp.Limit)
+	assert.Equal(t, 30, resp.RetryAfter)
+}
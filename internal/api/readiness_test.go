@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePool is a database that answers pings and reports fixed pool stats
+type fakePool struct {
+	stats sql.DBStats
+	pings int
+}
+
+func (p *fakePool) PingContext(ctx context.Context) error {
+	p.pings++
+	return nil
+}
+
+func (p *fakePool) Stats() sql.DBStats {
+	return p.stats
+}
+
+func TestReadiness_PoolSaturation(t *testing.T) {
+	e := echo.New()
+	pool := &fakePool{stats: sql.DBStats{MaxOpenConnections: 25, InUse: 3}}
+	hh := NewHealthHandlers(pool)
+
+	ready := func() (int, ReadinessResponse) {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/health/ready", nil), rec)
+		require.NoError(t, hh.Readiness(c))
+		var resp ReadinessResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return rec.Code, resp
+	}
+
+	code, resp := ready()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy: 3 of 25 connections in use", resp.Checks["database_pool"])
+	assert.Equal(t, "healthy", resp.Checks["database"])
+	assert.Equal(t, 1, pool.pings)
+
+	pool.stats.InUse = 25
+	code, resp = ready()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", resp.Status)
+	assert.Contains(t, resp.Checks["database_pool"], "saturated")
+	assert.Equal(t, 1, pool.pings, "a saturated pool is not pinged")
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/receipt"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// responseReceipt returns the receipt token for a response, or "" if receipts are disabled
+func (h *Handlers) responseReceipt(response *models.Response) string {
+	if !h.receipts.Enabled() {
+		return ""
+	}
+	return h.receipts.Sign(receipt.Receipt{
+		ResponseID:  response.ID,
+		SurveyID:    response.SurveyID,
+		SubmittedAt: response.CreatedAt,
+	})
+}
+
+// ReceiptPageHTML verifies a vote receipt and shows what it proves
+// GET /surveys/:slug/receipt/:token
+func (h *Handlers) ReceiptPageHTML(c echo.Context) error {
+	if !h.receipts.Enabled() {
+		return c.String(http.StatusNotFound, "Receipts are not enabled")
+	}
+
+	survey, err := h.surveyBySlug(c.Request().Context(), c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+
+	rcpt, err := h.receipts.Verify(c.Param("token"))
+	if err != nil || rcpt.SurveyID != survey.ID {
+		c.Response().WriteHeader(http.StatusBadRequest)
+		component := templates.ReceiptPage(survey, nil, false, user, profile, h.posthogKey)
+		return component.Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	// The signature proves the response was accepted; check it is still counted
+	response, err := h.queries.GetResponseByID(c.Request().Context(), rcpt.ResponseID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.Logger().Errorf("Failed to look up receipt response: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to verify receipt")
+	}
+	recorded := response != nil && response.SurveyID == survey.ID
+
+	component := templates.ReceiptPage(survey, rcpt, recorded, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/coauthor"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/notify"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/reminder"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// errInvalidInvitation is wrapped by invitation errors caused by the request
+var errInvalidInvitation = errors.New("invalid invitation")
+
+// SetReminders enables invitations to surveys and reminders to invitees who
+// have not responded, sent as direct messages by messenger. Call
+// reminder.StartWorker with DeliverReminder to send them.
+func (h *Handlers) SetReminders(store reminder.Store, messenger *notify.Messenger) {
+	h.reminders = store
+	h.messenger = messenger
+}
+
+// DeliverReminder sends an invitee a direct message reminding them to respond
+// to a survey before it closes
+func (h *Handlers) DeliverReminder(ctx context.Context, inv *reminder.Invitation) error {
+	survey, err := h.queries.GetSurveyByID(ctx, inv.SurveyID)
+	if err != nil {
+		return fmt.Errorf("failed to load survey: %w", err)
+	}
+	if survey.EndsAt == nil {
+		return fmt.Errorf("survey has no end")
+	}
+
+	text := reminder.Text(survey.Title, templates.AbsoluteURL("/s/"+survey.Slug), *survey.EndsAt, time.Now())
+	return h.messenger.Send(ctx, inv.DID, text)
+}
+
+// GetInvitations handles GET /api/v1/surveys/:slug/invitations
+// Returns the survey's reminder schedule and invitations. Anonymous surveys
+// only count who responded.
+func (h *Handlers) GetInvitations(c echo.Context) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+	return h.invitationsResponse(c, survey, http.StatusOK)
+}
+
+// AddInvitations handles POST /api/v1/surveys/:slug/invitations
+// Invites users by DID or handle, and with org the members of the survey's organization
+func (h *Handlers) AddInvitations(c echo.Context) error {
+	survey, caller, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	var req AddInvitationsRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	dids, err := h.invitees(ctx, survey, req)
+	if err != nil {
+		if errors.Is(err, errInvalidInvitation) {
+			return ValidationError(c, "Invalid request", strings.TrimPrefix(err.Error(), errInvalidInvitation.Error()+": "))
+		}
+		return InternalServerError(c, "Failed to list invitees", err)
+	}
+
+	invitations, err := h.reminders.ListInvitations(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list invitations", err)
+	}
+	invited := make(map[string]bool, len(invitations))
+	for _, inv := range invitations {
+		invited[inv.DID] = true
+	}
+	var added []string
+	for _, did := range dids {
+		if !invited[did] {
+			invited[did] = true
+			added = append(added, did)
+		}
+	}
+	if len(invitations)+len(added) > reminder.MaxInvitations {
+		return ValidationError(c, "Invalid request", fmt.Sprintf("a survey can have at most %d invitees", reminder.MaxInvitations))
+	}
+
+	if len(added) > 0 {
+		if _, err := h.reminders.AddInvitations(ctx, survey.ID, added, caller); err != nil {
+			return InternalServerError(c, "Failed to add invitations", err)
+		}
+	}
+
+	return h.invitationsResponse(c, survey, http.StatusCreated)
+}
+
+// invitees resolves the DIDs invited by a request
+func (h *Handlers) invitees(ctx context.Context, survey *models.Survey, req AddInvitationsRequest) ([]string, error) {
+	if len(req.Invitees) == 0 && !req.Org {
+		return nil, fmt.Errorf("%w: enter the DIDs or handles of invitees, or invite the survey's organization", errInvalidInvitation)
+	}
+	if len(req.Invitees) > reminder.MaxInvitations {
+		return nil, fmt.Errorf("%w: a survey can have at most %d invitees", errInvalidInvitation, reminder.MaxInvitations)
+	}
+
+	var dids []string
+	for _, invitee := range req.Invitees {
+		did := strings.TrimPrefix(strings.TrimSpace(invitee), "@")
+		if did == "" {
+			continue
+		}
+		if strings.HasPrefix(did, "did:") {
+			normalized, err := coauthor.NormalizeDID(did)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", errInvalidInvitation, err)
+			}
+			did = normalized
+		} else {
+			resolved, err := h.resolveHandle(did)
+			if err != nil {
+				return nil, fmt.Errorf("%w: could not resolve handle %s", errInvalidInvitation, did)
+			}
+			did = resolved
+		}
+		dids = append(dids, did)
+	}
+
+	if req.Org {
+		if survey.OrgID == nil || h.orgs == nil {
+			return nil, fmt.Errorf("%w: the survey does not belong to an organization", errInvalidInvitation)
+		}
+		members, err := h.orgs.ListMembers(ctx, *survey.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if m.AcceptedAt != nil { // Not those only invited to the organization
+				dids = append(dids, m.DID)
+			}
+		}
+	}
+
+	// The author is not reminded of their own survey
+	var invitees []string
+	for _, did := range dids {
+		if !isSurveyAuthor(survey, did) {
+			invitees = append(invitees, did)
+		}
+	}
+	return invitees, nil
+}
+
+// RemoveInvitation handles DELETE /api/v1/surveys/:slug/invitations/:did
+func (h *Handlers) RemoveInvitation(c echo.Context) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	if err := h.reminders.RemoveInvitation(c.Request().Context(), survey.ID, memberParam(c)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.NotFound, "The user is not invited to this survey")
+		}
+		return InternalServerError(c, "Failed to remove invitation", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetReminderSchedule handles PUT /api/v1/surveys/:slug/reminders
+// Sets how many hours before the survey ends invitees who have not responded
+// are reminded, or stops reminders with 0
+func (h *Handlers) SetReminderSchedule(c echo.Context) error {
+	survey, caller, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	var req ReminderScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+	if req.HoursBefore == nil {
+		return ValidationError(c, "Invalid request", "hoursBefore is required")
+	}
+	if err := reminder.ValidateHoursBefore(*req.HoursBefore); err != nil {
+		return ValidationError(c, "Invalid request", err.Error())
+	}
+	if *req.HoursBefore > 0 && survey.EndsAt == nil {
+		return ValidationError(c, "Invalid request", "the survey has no end date to remind invitees before")
+	}
+
+	if err := h.reminders.SetReminderSchedule(c.Request().Context(), survey.ID, *req.HoursBefore, caller); err != nil {
+		return InternalServerError(c, "Failed to set reminder schedule", err)
+	}
+
+	return h.invitationsResponse(c, survey, http.StatusOK)
+}
+
+// invitationsResponse responds with a survey's reminder schedule and invitations
+func (h *Handlers) invitationsResponse(c echo.Context, survey *models.Survey, status int) error {
+	ctx := c.Request().Context()
+	hours, err := h.reminders.GetReminderSchedule(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to get reminder schedule", err)
+	}
+	invitations, err := h.reminders.ListInvitations(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list invitations", err)
+	}
+
+	resp := InvitationsResponse{HoursBefore: hours, EndsAt: survey.EndsAt, Invited: len(invitations)}
+	for _, inv := range invitations {
+		if inv.RespondedAt != nil {
+			resp.Responded++
+		}
+		if inv.Status == reminder.StatusReminded {
+			resp.Reminded++
+		}
+	}
+	if !survey.Definition.Anonymous {
+		resp.Invitations = invitations
+		if resp.Invitations == nil {
+			resp.Invitations = []*reminder.Invitation{}
+		}
+	}
+
+	return c.JSON(status, resp)
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/org"
+	"github.com/openmeet-team/survey/internal/reminder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockReminderStore keeps invitations and reminder schedules in memory
+type mockReminderStore struct {
+	invitations []*reminder.Invitation
+	schedules   map[uuid.UUID]int
+}
+
+func (m *mockReminderStore) AddInvitations(ctx context.Context, surveyID uuid.UUID, dids []string, by string) (int64, error) {
+	for _, did := range dids {
+		m.invitations = append(m.invitations, &reminder.Invitation{
+			SurveyID: surveyID, DID: did, InvitedBy: by, Status: reminder.StatusInvited, CreatedAt: time.Now(),
+		})
+	}
+	return int64(len(dids)), nil
+}
+
+func (m *mockReminderStore) RemoveInvitation(ctx context.Context, surveyID uuid.UUID, did string) error {
+	for i, inv := range m.invitations {
+		if inv.SurveyID == surveyID && inv.DID == did {
+			m.invitations = append(m.invitations[:i], m.invitations[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *mockReminderStore) ListInvitations(ctx context.Context, surveyID uuid.UUID) ([]*reminder.Invitation, error) {
+	var invitations []*reminder.Invitation
+	for _, inv := range m.invitations {
+		if inv.SurveyID == surveyID {
+			invitations = append(invitations, inv)
+		}
+	}
+	return invitations, nil
+}
+
+func (m *mockReminderStore) GetReminderSchedule(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	return m.schedules[surveyID], nil
+}
+
+func (m *mockReminderStore) SetReminderSchedule(ctx context.Context, surveyID uuid.UUID, hoursBefore int, by string) error {
+	if m.schedules == nil {
+		m.schedules = make(map[uuid.UUID]int)
+	}
+	m.schedules[surveyID] = hoursBefore
+	return nil
+}
+
+func (m *mockReminderStore) MarkRespondedInvitations(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockReminderStore) ClaimDueReminders(ctx context.Context, now time.Time, limit int) ([]*reminder.Invitation, error) {
+	return nil, nil
+}
+
+func (m *mockReminderStore) UpdateInvitation(ctx context.Context, inv *reminder.Invitation) error {
+	return nil
+}
+
+func TestInvitations(t *testing.T) {
+	e, mq, h, orgs := setupOrgTest()
+	store := &mockReminderStore{}
+	h.SetReminders(store, nil)
+	alice := "did:plc:alice"
+	survey := createTextSurvey(mq, "offsite", &alice)
+
+	call := func(handler echo.HandlerFunc, method, body, user string) (int, InvitationsResponse) {
+		rec := callOrgAPI(t, e, handler, method, body, user, "slug", "offsite")
+		var resp InvitationsResponse
+		if rec.Code < http.StatusMultipleChoices && rec.Body.Len() > 0 {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec.Code, resp
+	}
+
+	t.Run("only those who manage the survey invite", func(t *testing.T) {
+		code, _ := call(h.AddInvitations, http.MethodPost, `{"invitees": ["did:plc:bob"]}`, "did:plc:mallory")
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("invite by DID or handle", func(t *testing.T) {
+		code, _ := call(h.AddInvitations, http.MethodPost, `{}`, alice)
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = call(h.AddInvitations, http.MethodPost, `{"invitees": ["@nobody.example"]}`, alice)
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, resp := call(h.AddInvitations, http.MethodPost, `{"invitees": ["did:plc:bob", "@carol.test", "@bob.test", "did:plc:alice"]}`, alice)
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, 2, resp.Invited, "invited once, and not the author")
+		require.Len(t, resp.Invitations, 2)
+		assert.Equal(t, "did:plc:carol", resp.Invitations[1].DID)
+		assert.Equal(t, alice, resp.Invitations[1].InvitedBy)
+	})
+
+	t.Run("invite the survey's organization", func(t *testing.T) {
+		code, _ := call(h.AddInvitations, http.MethodPost, `{"org": true}`, alice)
+		assert.Equal(t, http.StatusBadRequest, code, "the survey has no organization")
+
+		orgID := uuid.New()
+		survey.OrgID = &orgID
+		accepted := time.Now()
+		orgs.members = append(orgs.members,
+			&org.Member{OrgID: orgID, DID: alice, Role: org.RoleOwner, AcceptedAt: &accepted},
+			&org.Member{OrgID: orgID, DID: "did:plc:dave", Role: org.RoleViewer, AcceptedAt: &accepted},
+			&org.Member{OrgID: orgID, DID: "did:plc:erin", Role: org.RoleViewer})
+
+		code, resp := call(h.AddInvitations, http.MethodPost, `{"org": true}`, alice)
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, 3, resp.Invited, "members who accepted, except the author")
+	})
+
+	t.Run("remove an invitation", func(t *testing.T) {
+		remove := func() int {
+			return callOrgAPI(t, e, h.RemoveInvitation, http.MethodDelete, "", alice, "slug", "did", "offsite", "did:plc:dave").Code
+		}
+		assert.Equal(t, http.StatusNoContent, remove())
+		assert.Equal(t, http.StatusNotFound, remove())
+		assert.Len(t, store.invitations, 2)
+	})
+
+	t.Run("schedule reminders", func(t *testing.T) {
+		code, _ := call(h.SetReminderSchedule, http.MethodPut, `{"hoursBefore": 24}`, alice)
+		assert.Equal(t, http.StatusBadRequest, code, "the survey has no end")
+
+		endsAt := time.Now().Add(48 * time.Hour)
+		survey.EndsAt = &endsAt
+		code, _ = call(h.SetReminderSchedule, http.MethodPut, `{}`, alice)
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = call(h.SetReminderSchedule, http.MethodPut, `{"hoursBefore": 200}`, alice)
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, resp := call(h.SetReminderSchedule, http.MethodPut, `{"hoursBefore": 24}`, alice)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 24, resp.HoursBefore)
+	})
+
+	t.Run("anonymous surveys only count who responded", func(t *testing.T) {
+		responded := time.Now()
+		store.invitations[0].RespondedAt = &responded
+		survey.Definition.Anonymous = true
+		defer func() { survey.Definition.Anonymous = false }()
+
+		code, resp := call(h.GetInvitations, http.MethodGet, "", alice)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, resp.Invited)
+		assert.Equal(t, 1, resp.Responded)
+		assert.Nil(t, resp.Invitations)
+	})
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/report"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// hiddenSurveyMessage explains why a reported survey cannot be seen
+const hiddenSurveyMessage = "This survey is hidden while reports about it are reviewed"
+
+// surveyHidden reports whether a survey is hidden from the caller. Hidden
+// surveys are still shown to their author and admins.
+func (h *Handlers) surveyHidden(c echo.Context, survey *models.Survey) bool {
+	if survey.HiddenAt == nil {
+		return false
+	}
+	did, ok := callerDID(c)
+	return !ok || !h.canReadSurveyAs(c.Request().Context(), did, survey)
+}
+
+// surveyHiddenJSON responds to an API request for a hidden survey
+func surveyHiddenJSON(c echo.Context) error {
+	return Problem(c, problem.SurveyHidden, hiddenSurveyMessage)
+}
+
+// newReport validates a visitor's report of a survey. Logged-in visitors are
+// counted once however many addresses they report from.
+func newReport(c echo.Context, survey *models.Survey, reason, details string) (*report.Report, error) {
+	var did string
+	if user := oauth.GetUser(c); user != nil {
+		did = user.DID
+	}
+	return report.New(survey.ID, reason, details, report.ReporterKey(did, getClientIP(c)))
+}
+
+// saveReport saves a report of a survey, hiding the survey if enough distinct
+// visitors reported it
+func (h *Handlers) saveReport(c echo.Context, survey *models.Survey, r *report.Report) error {
+	ctx := c.Request().Context()
+	created, hid, err := h.reports.CreateReport(ctx, r, h.reportConfig.HideThreshold)
+	if err != nil {
+		return err
+	}
+	if created {
+		c.Logger().Infof("Survey %s reported for %s", survey.Slug, r.Reason)
+	}
+	if hid {
+		c.Logger().Warnf("Survey %s hidden pending review of its reports", survey.Slug)
+		h.cache.Invalidate(ctx, cache.SurveyKey(survey.Slug))
+	}
+	return nil
+}
+
+// ReportSurvey reports a survey as abusive. Repeated reports of a survey by
+// the same visitor are accepted but not counted again.
+// POST /api/v1/surveys/:slug/report
+func (h *Handlers) ReportSurvey(c echo.Context) error {
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return h.surveyNotFoundJSON(c, slug)
+		}
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+
+	var req ReportSurveyRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+
+	r, err := newReport(c, survey, req.Reason, req.Details)
+	if err != nil {
+		return Problem(c, problem.ValidationFailed, "Invalid report: "+err.Error())
+	}
+
+	if err := h.saveReport(c, survey, r); err != nil {
+		return InternalServerError(c, "Failed to save report", err)
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+// ReportSurveyHTML handles the report form of the survey page
+// POST /surveys/:slug/report
+func (h *Handlers) ReportSurveyHTML(c echo.Context) error {
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return h.surveyNotFoundHTML(c, slug)
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	r, err := newReport(c, survey, c.FormValue("reason"), c.FormValue("details"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.saveReport(c, survey, r); err != nil {
+		c.Logger().Errorf("Failed to save report of survey %s: %v", slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to save report")
+	}
+
+	user, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.ReportReceived(slug, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
+
+// ReportsPageHTML lists the surveys with pending reports, for admins
+// GET /admin/reports
+func (h *Handlers) ReportsPageHTML(c echo.Context) error {
+	user, profile := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.adminDIDs[user.DID] {
+		return c.String(http.StatusForbidden, "Only admins can review reports")
+	}
+
+	surveys, err := h.reports.ListReportedSurveys(c.Request().Context())
+	if err != nil {
+		c.Logger().Errorf("Failed to list reported surveys: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load reports")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.ReportsPage(surveys, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
+
+// ResolveReportsHTML dismisses the pending reports of a survey, showing it
+// again, or upholds them, keeping it hidden
+// POST /admin/reports/:id
+func (h *Handlers) ResolveReportsHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.adminDIDs[user.DID] {
+		return c.String(http.StatusForbidden, "Only admins can review reports")
+	}
+
+	surveyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid survey ID")
+	}
+
+	var status string
+	switch c.FormValue("action") {
+	case "dismiss":
+		status = report.StatusDismissed
+	case "uphold":
+		status = report.StatusUpheld
+	default:
+		return c.String(http.StatusBadRequest, "Action must be 'dismiss' or 'uphold'")
+	}
+
+	ctx := c.Request().Context()
+	slug, err := h.reports.ResolveReports(ctx, surveyID, status, user.DID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "No pending reports of this survey")
+		}
+		c.Logger().Errorf("Failed to resolve reports of survey %s: %v", surveyID, err)
+		return c.String(http.StatusInternalServerError, "Failed to save review")
+	}
+	h.cache.Invalidate(ctx, cache.SurveyKey(slug))
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/admin/reports"))
+}
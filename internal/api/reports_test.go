@@ -0,0 +1,216 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockReportStore keeps reports in memory, hiding surveys of MockQueries
+type mockReportStore struct {
+	queries *MockQueries
+	reports []*report.Report
+}
+
+func (m *mockReportStore) CreateReport(ctx context.Context, r *report.Report, threshold int) (bool, bool, error) {
+	pending := 0
+	for _, existing := range m.reports {
+		if existing.SurveyID != r.SurveyID {
+			continue
+		}
+		if existing.Reporter == r.Reporter {
+			return false, false, nil
+		}
+		if existing.Status == report.StatusPending {
+			pending++
+		}
+	}
+	m.reports = append(m.reports, r)
+
+	for _, s := range m.queries.Surveys {
+		if s.ID == r.SurveyID && s.HiddenAt == nil && pending+1 >= threshold {
+			now := time.Now()
+			s.HiddenAt = &now
+			return true, true, nil
+		}
+	}
+	return true, false, nil
+}
+
+func (m *mockReportStore) ListReportedSurveys(ctx context.Context) ([]*report.ReportedSurvey, error) {
+	var surveys []*report.ReportedSurvey
+	for _, s := range m.queries.Surveys {
+		reported := &report.ReportedSurvey{SurveyID: s.ID, Slug: s.Slug, Title: s.Title, HiddenAt: s.HiddenAt}
+		for _, r := range m.reports {
+			if r.SurveyID == s.ID && r.Status == report.StatusPending {
+				reported.Reports = append(reported.Reports, r)
+			}
+		}
+		if len(reported.Reports) > 0 {
+			surveys = append(surveys, reported)
+		}
+	}
+	return surveys, nil
+}
+
+func (m *mockReportStore) ResolveReports(ctx context.Context, surveyID uuid.UUID, status, reviewerDID string) (string, error) {
+	resolved := 0
+	for _, r := range m.reports {
+		if r.SurveyID == surveyID && r.Status == report.StatusPending {
+			r.Status = status
+			r.ReviewedBy = &reviewerDID
+			resolved++
+		}
+	}
+	if resolved == 0 {
+		return "", sql.ErrNoRows
+	}
+	for _, s := range m.queries.Surveys {
+		if s.ID == surveyID {
+			if status == report.StatusDismissed {
+				s.HiddenAt = nil
+			}
+			return s.Slug, nil
+		}
+	}
+	return "", sql.ErrNoRows
+}
+
+func setupReportTest() (*echo.Echo, *MockQueries, *Handlers, *mockReportStore) {
+	e, mq, h := setupTest()
+	store := &mockReportStore{queries: mq}
+	h.SetReports(store, report.Config{HideThreshold: 2})
+	h.SetAdmins([]string{"did:plc:admin"})
+	return e, mq, h, store
+}
+
+func reportSurvey(t *testing.T, e *echo.Echo, h *Handlers, slug, ip string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/"+slug+"/report", bytes.NewReader(data))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.RemoteAddr = ip + ":1234"
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	require.NoError(t, h.ReportSurvey(c))
+	return rec
+}
+
+func getSurveyAs(t *testing.T, e *echo.Echo, h *Handlers, slug string, user *oauth.User) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/surveys/"+slug, nil), rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	if user != nil {
+		c.Set("user", user)
+	}
+	require.NoError(t, h.GetSurvey(c))
+	return rec
+}
+
+func TestReportSurvey(t *testing.T) {
+	e, mq, h, store := setupReportTest()
+	author := "did:plc:author"
+	createTextSurvey(mq, "feedback", &author)
+
+	t.Run("invalid reason", func(t *testing.T) {
+		rec := reportSurvey(t, e, h, "feedback", "10.0.0.1", ReportSurveyRequest{Reason: "boring"})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("unknown survey", func(t *testing.T) {
+		rec := reportSurvey(t, e, h, "missing", "10.0.0.1", ReportSurveyRequest{Reason: report.ReasonSpam})
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	// One reporter does not hide the survey, however often they report it
+	for i := 0; i < 2; i++ {
+		rec := reportSurvey(t, e, h, "feedback", "10.0.0.1", ReportSurveyRequest{Reason: report.ReasonSpam, Details: "ads"})
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	}
+	assert.Len(t, store.reports, 1)
+	assert.Equal(t, http.StatusOK, getSurveyAs(t, e, h, "feedback", nil).Code)
+
+	// A second reporter does
+	rec := reportSurvey(t, e, h, "feedback", "10.0.0.2", ReportSurveyRequest{Reason: report.ReasonHarassment})
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	rec = getSurveyAs(t, e, h, "feedback", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	var resp problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, problem.SurveyHidden, resp.Code)
+
+	// The author and admins still see it
+	assert.Equal(t, http.StatusOK, getSurveyAs(t, e, h, "feedback", &oauth.User{DID: author}).Code)
+	assert.Equal(t, http.StatusOK, getSurveyAs(t, e, h, "feedback", &oauth.User{DID: "did:plc:admin"}).Code)
+}
+
+func TestReviewReports(t *testing.T) {
+	e, mq, h, _ := setupReportTest()
+	survey := createTextSurvey(mq, "feedback", nil)
+	reportSurvey(t, e, h, "feedback", "10.0.0.1", ReportSurveyRequest{Reason: report.ReasonSpam})
+	reportSurvey(t, e, h, "feedback", "10.0.0.2", ReportSurveyRequest{Reason: report.ReasonSpam})
+	require.NotNil(t, survey.HiddenAt)
+
+	resolve := func(user *oauth.User, action string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/reports/"+survey.ID.String(), strings.NewReader("action="+action))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(survey.ID.String())
+		c.Set("user", user)
+		require.NoError(t, h.ResolveReportsHTML(c))
+		return rec
+	}
+
+	t.Run("queue is for admins", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/admin/reports", nil), rec)
+		c.Set("user", &oauth.User{DID: "did:plc:someone"})
+		require.NoError(t, h.ReportsPageHTML(c))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		assert.Equal(t, http.StatusForbidden, resolve(&oauth.User{DID: "did:plc:someone"}, "dismiss").Code)
+	})
+
+	t.Run("queue lists the reported survey", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/admin/reports", nil), rec)
+		c.Set("user", &oauth.User{DID: "did:plc:admin"})
+		require.NoError(t, h.ReportsPageHTML(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Feedback")
+		assert.Contains(t, rec.Body.String(), "Hidden since")
+	})
+
+	t.Run("dismissing shows the survey again", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, resolve(&oauth.User{DID: "did:plc:admin"}, "ignore").Code)
+
+		rec := resolve(&oauth.User{DID: "did:plc:admin"}, "dismiss")
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Nil(t, survey.HiddenAt)
+		assert.Equal(t, http.StatusOK, getSurveyAs(t, e, h, "feedback", nil).Code)
+
+		// Nothing is left to review
+		assert.Equal(t, http.StatusNotFound, resolve(&oauth.User{DID: "did:plc:admin"}, "uphold").Code)
+	})
+}
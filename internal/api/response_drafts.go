@@ -0,0 +1,99 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/draft"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// autosaves reports whether the voting form of a survey autosaves its answers
+func (h *Handlers) autosaves(survey *models.Survey) bool {
+	return h.responseDrafts != nil && !survey.IsForeign()
+}
+
+// AutosaveResponseHTML saves the answers of a voting form that has not been
+// submitted yet, so the voter can resume after closing the tab. The form posts
+// here every draft.AutosaveInterval; guests get a draft cookie on the first save.
+// POST /surveys/:slug/autosave
+func (h *Handlers) AutosaveResponseHTML(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	survey, err := h.surveyBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+	if !h.autosaves(survey) {
+		return c.String(http.StatusNotFound, "Autosave is not enabled")
+	}
+	if !h.canViewSurvey(c, survey) {
+		return c.String(http.StatusForbidden, privateSurveyMessage)
+	}
+
+	formValues, err := c.FormParams()
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid form data")
+	}
+	answers := formAnswers(&survey.Definition, formValues)
+	if len(answers) == 0 {
+		// Nothing to resume yet; don't give guests a cookie for it
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	owner, err := h.newDraftOwner(c)
+	if err != nil {
+		return InternalServerError(c, "Failed to save draft", err)
+	}
+
+	d := &draft.ResponseDraft{SurveyID: survey.ID, Owner: owner, Answers: answers, UpdatedAt: time.Now()}
+	if err := h.responseDrafts.SaveResponseDraft(ctx, d); err != nil {
+		c.Logger().Errorf("Failed to save response draft for survey %s: %v", survey.Slug, err)
+		return templates.AutosaveStatus(false).Render(ctx, c.Response().Writer)
+	}
+
+	return templates.AutosaveStatus(true).Render(ctx, c.Response().Writer)
+}
+
+// responseDraftAnswers returns the caller's autosaved answers to a survey, or
+// nil if there are none
+func (h *Handlers) responseDraftAnswers(c echo.Context, survey *models.Survey) map[string]models.Answer {
+	if !h.autosaves(survey) {
+		return nil
+	}
+	owner, ok := h.draftOwner(c)
+	if !ok {
+		return nil
+	}
+
+	d, err := h.responseDrafts.GetResponseDraft(c.Request().Context(), survey.ID, owner)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			c.Logger().Errorf("Failed to get response draft for survey %s: %v", survey.Slug, err)
+		}
+		return nil
+	}
+	return d.Answers
+}
+
+// deleteResponseDraft deletes the caller's autosaved answers once their response is submitted
+func (h *Handlers) deleteResponseDraft(c echo.Context, survey *models.Survey) {
+	if !h.autosaves(survey) {
+		return
+	}
+	owner, ok := h.draftOwner(c)
+	if !ok {
+		return
+	}
+
+	if err := h.responseDrafts.DeleteResponseDraft(c.Request().Context(), survey.ID, owner); err != nil {
+		c.Logger().Errorf("Failed to delete response draft for survey %s: %v", survey.Slug, err)
+	}
+}
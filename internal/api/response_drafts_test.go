@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/draft"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockResponseDrafts stores response drafts in memory
+type mockResponseDrafts struct {
+	drafts map[string]*draft.ResponseDraft
+}
+
+func newMockResponseDrafts() *mockResponseDrafts {
+	return &mockResponseDrafts{drafts: make(map[string]*draft.ResponseDraft)}
+}
+
+func responseDraftKey(surveyID uuid.UUID, owner string) string {
+	return surveyID.String() + "/" + owner
+}
+
+func (m *mockResponseDrafts) SaveResponseDraft(ctx context.Context, d *draft.ResponseDraft) error {
+	copied := *d
+	m.drafts[responseDraftKey(d.SurveyID, d.Owner)] = &copied
+	return nil
+}
+
+func (m *mockResponseDrafts) GetResponseDraft(ctx context.Context, surveyID uuid.UUID, owner string) (*draft.ResponseDraft, error) {
+	d, ok := m.drafts[responseDraftKey(surveyID, owner)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (m *mockResponseDrafts) DeleteResponseDraft(ctx context.Context, surveyID uuid.UUID, owner string) error {
+	delete(m.drafts, responseDraftKey(surveyID, owner))
+	return nil
+}
+
+func (m *mockResponseDrafts) DeleteExpiredResponseDrafts(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func setupResponseDraftsTest() (*echo.Echo, *MockQueries, *Handlers, *mockResponseDrafts) {
+	e, mq, h := setupTest()
+	store := newMockResponseDrafts()
+	h.SetResponseDrafts(store, draft.NewGuests(draft.Config{Secret: "secret"}))
+
+	mq.CreateSurvey(context.Background(), &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "lunch",
+		Title: "Lunch",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "q1", Text: "Where?", Type: models.QuestionTypeSingle, Required: true, Options: []models.Option{
+					{ID: "a", Text: "Cafe"},
+					{ID: "b", Text: "Park"},
+				}},
+				{ID: "q2", Text: "Anything else?", Type: models.QuestionTypeText},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	return e, mq, h, store
+}
+
+// formRequest builds a request to a survey page with a form body and cookies
+func formRequest(e *echo.Echo, method, path, form string, cookies []*http.Cookie) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, path, strings.NewReader(form))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.RemoteAddr = "192.168.1.1:12345"
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("lunch")
+	return c, rec
+}
+
+func TestAutosaveResponseHTML_GuestResumes(t *testing.T) {
+	e, _, h, store := setupResponseDraftsTest()
+
+	// The first autosave identifies the guest with a cookie
+	c, rec := formRequest(e, http.MethodPost, "/surveys/lunch/autosave", "q1=b&q2=No+onions", nil)
+	require.NoError(t, h.AutosaveResponseHTML(c))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "saved as a draft")
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, draft.CookieName, cookies[0].Name)
+	require.Len(t, store.drafts, 1)
+	for _, d := range store.drafts {
+		assert.True(t, strings.HasPrefix(d.Owner, "guest:"))
+		assert.Equal(t, []string{"b"}, d.Answers["q1"].SelectedOptions)
+		assert.Equal(t, "No onions", d.Answers["q2"].Text)
+	}
+
+	// Reloading the form restores the answers
+	c, rec = formRequest(e, http.MethodGet, "/surveys/lunch", "", cookies)
+	require.NoError(t, h.GetSurveyHTML(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Welcome back!")
+	assert.Contains(t, rec.Body.String(), "No onions")
+
+	// Other voters start from an empty form
+	c, rec = formRequest(e, http.MethodGet, "/surveys/lunch", "", nil)
+	require.NoError(t, h.GetSurveyHTML(c))
+	assert.NotContains(t, rec.Body.String(), "Welcome back!")
+
+	// Submitting deletes the draft
+	c, rec = formRequest(e, http.MethodPost, "/surveys/lunch/responses", "q1=b&q2=No+onions", cookies)
+	require.NoError(t, h.SubmitResponseHTML(c))
+	assert.Empty(t, store.drafts)
+}
+
+func TestAutosaveResponseHTML_EmptyFormSavesNothing(t *testing.T) {
+	e, _, h, store := setupResponseDraftsTest()
+
+	c, rec := formRequest(e, http.MethodPost, "/surveys/lunch/autosave", "q2=", nil)
+	require.NoError(t, h.AutosaveResponseHTML(c))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Result().Cookies())
+	assert.Empty(t, store.drafts)
+}
+
+func TestAutosaveResponseHTML_Disabled(t *testing.T) {
+	e, mq, h := setupTest()
+	mq.CreateSurvey(context.Background(), &models.Survey{ID: uuid.New(), Slug: "lunch", Title: "Lunch"})
+
+	c, rec := formRequest(e, http.MethodPost, "/surveys/lunch/autosave", "q1=a", nil)
+	require.NoError(t, h.AutosaveResponseHTML(c))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/export"
+	"github.com/openmeet-team/survey/internal/jobs"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/storage"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// SetExports enables exports of responses generated by queued jobs and
+// downloaded from blob storage
+func (h *Handlers) SetExports(store export.Store, blobs storage.Store, queue *jobs.Queue) {
+	h.exports = store
+	h.blobs = blobs
+	h.jobs = queue
+}
+
+// RequestExport handles POST /api/v1/surveys/:slug/exports
+// Queues an export of a survey's responses, with the format and filters of
+// GET /surveys/:slug/export as query parameters. Responds 202 with the
+// export, whose Location is polled until it is done.
+func (h *Handlers) RequestExport(c echo.Context) error {
+	did, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return h.surveyNotFoundJSON(c, slug)
+	}
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+	if !h.canReadSurveyAs(ctx, did, survey) {
+		return Problem(c, problem.Forbidden, "Only the survey author can export responses")
+	}
+
+	query := c.QueryParams()
+	format := query.Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if !export.ValidFormat(format) {
+		return Problem(c, problem.ValidationFailed, "Format must be 'csv' or 'json'")
+	}
+
+	// Filters are checked now, so the export doesn't fail on them later
+	ordinals, err := h.queries.ListQuestionOrdinals(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to load questions", err)
+	}
+	if _, err := parseExportFilter(query, survey, ordinals); err != nil {
+		return Problem(c, problem.ValidationFailed, err.Error())
+	}
+	query.Del("format")
+
+	queued := &export.Export{
+		ID:          uuid.New(),
+		SurveyID:    survey.ID,
+		RequestedBy: did,
+		Format:      format,
+		Query:       query.Encode(),
+		Status:      export.StatusPending,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := h.exports.CreateExport(ctx, queued); err != nil {
+		return InternalServerError(c, "Failed to queue export", err)
+	}
+	if _, err := h.jobs.Enqueue(ctx, export.JobType, export.Job{ExportID: queued.ID}, time.Time{}); err != nil {
+		if err := h.exports.FailExport(ctx, queued.ID, "Failed to queue the export", time.Now().Add(export.TTL)); err != nil {
+			c.Logger().Errorf("Failed to mark export as failed: %v", err)
+		}
+		return InternalServerError(c, "Failed to queue export", err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, templates.AppPath("/api/v1/surveys/"+survey.Slug+"/exports/"+queued.ID.String()))
+	return c.JSON(http.StatusAccepted, toExportJobResponse(queued))
+}
+
+// GetExport handles GET /api/v1/surveys/:slug/exports/:id
+// Returns the status of an export and, once done, a signed URL to download
+// it that is valid for export.URLExpiry
+func (h *Handlers) GetExport(c echo.Context) error {
+	did, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return h.surveyNotFoundJSON(c, slug)
+	}
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+	if !h.canReadSurveyAs(ctx, did, survey) {
+		return Problem(c, problem.Forbidden, "Only the survey author can export responses")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return Problem(c, problem.NotFound, "Export not found")
+	}
+	found, err := h.exports.GetExport(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && found.SurveyID != survey.ID) {
+		return Problem(c, problem.NotFound, "Export not found")
+	}
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve export", err)
+	}
+
+	response := toExportJobResponse(found)
+	if found.Status == export.StatusDone && found.BlobKey != nil {
+		signed, err := h.blobs.SignedURL(ctx, *found.BlobKey, export.URLExpiry)
+		if err != nil {
+			return InternalServerError(c, "Failed to sign download URL", err)
+		}
+		expires := time.Now().Add(export.URLExpiry).UTC()
+		response.DownloadURL = signed
+		response.DownloadURLExpiresAt = &expires
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// BuildExport builds the file of a queued export, like GET /surveys/:slug/export.
+// It is the export.BuildFunc of export jobs.
+func (h *Handlers) BuildExport(ctx context.Context, queued *export.Export) (string, []byte, error) {
+	survey, err := h.queries.GetSurveyByID(ctx, queued.SurveyID)
+	if err != nil {
+		return "", nil, err
+	}
+	ordinals, err := h.queries.ListQuestionOrdinals(ctx, survey.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	query, err := url.ParseQuery(queued.Query)
+	if err != nil {
+		return "", nil, err
+	}
+	filter, err := parseExportFilter(query, survey, ordinals)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := h.restoreArchive(ctx, survey); err != nil {
+		return "", nil, err
+	}
+	responses, err := h.queries.ListFilteredResponses(ctx, survey.ID, filter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	built := buildExport(survey, responses, ordinals, filter.QuestionIDs)
+	filename := survey.Slug + "-responses." + queued.Format
+	if queued.Format == export.FormatJSON {
+		body, err := json.Marshal(built)
+		return filename, body, err
+	}
+	var body bytes.Buffer
+	if err := writeExportCSV(&body, built, !survey.Definition.Anonymous); err != nil {
+		return "", nil, err
+	}
+	return filename, body.Bytes(), nil
+}
+
+// servesBlobs reports whether blobs are stored on disk, so their signed URLs
+// are served by ServeBlob
+func (h *Handlers) servesBlobs() bool {
+	_, ok := h.blobs.(*storage.Disk)
+	return ok
+}
+
+// ServeBlob downloads a blob of the disk blob store through a signed URL
+// GET /blobs/*?expires=&signature=
+func (h *Handlers) ServeBlob(c echo.Context) error {
+	disk, ok := h.blobs.(*storage.Disk)
+	if !ok {
+		return c.String(http.StatusNotFound, "Not found")
+	}
+
+	key := c.Param("*")
+	if !disk.Verify(key, c.QueryParam("expires"), c.QueryParam("signature"), time.Now()) {
+		return c.String(http.StatusForbidden, "This link has expired or is invalid")
+	}
+
+	blob, err := disk.Open(c.Request().Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.String(http.StatusNotFound, "Not found")
+	}
+	if err != nil {
+		c.Logger().Errorf("Failed to open blob: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load file")
+	}
+	defer blob.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)}))
+	c.Response().Header().Set("Cache-Control", "private, no-store")
+	return c.Stream(http.StatusOK, contentType, blob)
+}
+
+// toExportJobResponse converts an export to its status response
+func toExportJobResponse(e *export.Export) *ExportJobResponse {
+	return &ExportJobResponse{
+		ID:          e.ID,
+		Status:      e.Status,
+		Format:      e.Format,
+		CreatedAt:   e.CreatedAt,
+		CompletedAt: e.CompletedAt,
+		ExpiresAt:   e.ExpiresAt,
+		Error:       e.Error,
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/export"
+	"github.com/openmeet-team/survey/internal/jobs"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockExports keeps exports by ID
+type mockExports struct {
+	exports map[uuid.UUID]*export.Export
+}
+
+func (m *mockExports) CreateExport(ctx context.Context, e *export.Export) error {
+	m.exports[e.ID] = e
+	return nil
+}
+
+func (m *mockExports) GetExport(ctx context.Context, id uuid.UUID) (*export.Export, error) {
+	e, ok := m.exports[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (m *mockExports) StartExport(ctx context.Context, id uuid.UUID) error {
+	m.exports[id].Status = export.StatusRunning
+	return nil
+}
+
+func (m *mockExports) CompleteExport(ctx context.Context, id uuid.UUID, blobKey string, expiresAt time.Time) error {
+	e := m.exports[id]
+	e.Status, e.BlobKey, e.ExpiresAt = export.StatusDone, &blobKey, &expiresAt
+	return nil
+}
+
+func (m *mockExports) FailExport(ctx context.Context, id uuid.UUID, reason string, expiresAt time.Time) error {
+	e := m.exports[id]
+	e.Status, e.Error, e.ExpiresAt = export.StatusFailed, &reason, &expiresAt
+	return nil
+}
+
+func (m *mockExports) DeleteExpiredExports(ctx context.Context, now time.Time) ([]*export.Export, error) {
+	return nil, nil
+}
+
+// mockJobs records enqueued jobs
+type mockJobs struct {
+	jobs []*jobs.Job
+}
+
+func (m *mockJobs) EnqueueJob(ctx context.Context, job *jobs.Job) error {
+	m.jobs = append(m.jobs, job)
+	return nil
+}
+
+func (m *mockJobs) ClaimJobs(ctx context.Context, jobType string, now time.Time, lease time.Duration, limit int) ([]*jobs.Job, error) {
+	return nil, nil
+}
+
+func (m *mockJobs) CompleteJob(ctx context.Context, id uuid.UUID, now time.Time) error { return nil }
+
+func (m *mockJobs) RetryJob(ctx context.Context, id uuid.UUID, runAt time.Time, reason string) error {
+	return nil
+}
+
+func (m *mockJobs) FailJob(ctx context.Context, id uuid.UUID, reason string, now time.Time) error {
+	return nil
+}
+
+func (m *mockJobs) DeleteFinishedJobs(ctx context.Context, before time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *mockJobs) CountJobs(ctx context.Context) ([]jobs.Count, error) { return nil, nil }
+
+// setExports enables exports with an export job handler, returning the queued jobs
+func setExports(t *testing.T, h *Handlers, store export.Store) (*storage.Disk, *mockJobs) {
+	blobs, err := storage.NewDisk(t.TempDir(), []byte("secret"), "https://example.com/blobs/")
+	require.NoError(t, err)
+	queued := &mockJobs{}
+	queue := jobs.NewQueue(queued)
+	queue.Register(export.JobType, export.Handler(store, blobs, h.BuildExport), jobs.Options{})
+	h.SetExports(store, blobs, queue)
+	return blobs, queued
+}
+
+// newExportJobContext creates a request to the export job endpoints as a user
+func newExportJobContext(e *echo.Echo, method, target string, names, values []string, user *oauth.User) (echo.Context, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(method, target, nil), rec)
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+	if user != nil {
+		c.Set("user", user)
+	}
+	return c, rec
+}
+
+func TestRequestExport(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+	store := &mockExports{exports: map[uuid.UUID]*export.Export{}}
+	_, queue := setExports(t, h, store)
+	author := &oauth.User{DID: "did:plc:author"}
+
+	c, rec := newExportJobContext(e, http.MethodPost, "/api/v1/surveys/"+survey.Slug+"/exports?format=csv", []string{"slug"}, []string{survey.Slug}, &oauth.User{DID: "did:plc:someone"})
+	require.NoError(t, h.RequestExport(c))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	c, rec = newExportJobContext(e, http.MethodPost, "/api/v1/surveys/"+survey.Slug+"/exports?format=xml", []string{"slug"}, []string{survey.Slug}, author)
+	require.NoError(t, h.RequestExport(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	c, rec = newExportJobContext(e, http.MethodPost, "/api/v1/surveys/"+survey.Slug+"/exports?questions=nope", []string{"slug"}, []string{survey.Slug}, author)
+	require.NoError(t, h.RequestExport(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "filters are checked when queuing")
+
+	c, rec = newExportJobContext(e, http.MethodPost, "/api/v1/surveys/"+survey.Slug+"/exports?format=csv&voter=did", []string{"slug"}, []string{survey.Slug}, author)
+	require.NoError(t, h.RequestExport(c))
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+	var queued ExportJobResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &queued))
+	assert.Equal(t, export.StatusPending, queued.Status)
+	assert.Equal(t, "/api/v1/surveys/"+survey.Slug+"/exports/"+queued.ID.String(), rec.Header().Get(echo.HeaderLocation))
+	require.Contains(t, store.exports, queued.ID)
+	assert.Equal(t, "voter=did", store.exports[queued.ID].Query, "filters are kept without the format")
+	assert.Equal(t, "did:plc:author", store.exports[queued.ID].RequestedBy)
+	require.Len(t, queue.jobs, 1)
+	assert.Equal(t, export.JobType, queue.jobs[0].Type)
+	assert.JSONEq(t, `{"exportId":"`+queued.ID.String()+`"}`, string(queue.jobs[0].Payload))
+}
+
+func TestGetExport(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+	store := &mockExports{exports: map[uuid.UUID]*export.Export{}}
+	blobs, _ := setExports(t, h, store)
+	author := &oauth.User{DID: "did:plc:author"}
+
+	queued := &export.Export{ID: uuid.New(), SurveyID: survey.ID, RequestedBy: author.DID, Format: export.FormatCSV, Status: export.StatusPending, CreatedAt: time.Now()}
+	require.NoError(t, store.CreateExport(context.Background(), queued))
+	other := &export.Export{ID: uuid.New(), SurveyID: uuid.New(), Format: export.FormatCSV, Status: export.StatusPending}
+	require.NoError(t, store.CreateExport(context.Background(), other))
+
+	get := func(id uuid.UUID, user *oauth.User) (*httptest.ResponseRecorder, ExportJobResponse) {
+		c, rec := newExportJobContext(e, http.MethodGet, "/api/v1/surveys/"+survey.Slug+"/exports/"+id.String(), []string{"slug", "id"}, []string{survey.Slug, id.String()}, user)
+		require.NoError(t, h.GetExport(c))
+		var response ExportJobResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+
+	rec, _ := get(queued.ID, &oauth.User{DID: "did:plc:someone"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec, _ = get(other.ID, author)
+	assert.Equal(t, http.StatusNotFound, rec.Code, "exports of other surveys are not found")
+
+	rec, response := get(queued.ID, author)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, export.StatusPending, response.Status)
+	assert.Empty(t, response.DownloadURL)
+
+	// The export job builds the export from the survey's responses
+	require.NoError(t, export.Run(context.Background(), store, blobs, h.BuildExport, queued.ID, false, time.Now()))
+
+	rec, response = get(queued.ID, author)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, export.StatusDone, response.Status)
+	require.True(t, strings.HasPrefix(response.DownloadURL, "https://example.com/blobs/exports/"+queued.ID.String()+"/export-survey-responses.csv?"), response.DownloadURL)
+	assert.NotNil(t, response.DownloadURLExpiresAt)
+
+	// The signed URL downloads the file
+	signed, err := url.Parse(response.DownloadURL)
+	require.NoError(t, err)
+	key := strings.TrimPrefix(signed.Path, "/blobs/")
+	rec = httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, signed.RequestURI(), nil), rec)
+	c.SetParamNames("*")
+	c.SetParamValues(key)
+	require.NoError(t, h.ServeBlob(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), `attachment; filename=export-survey-responses.csv`)
+	assert.Contains(t, rec.Body.String(), "response_id,submitted_at,voter_type,voter_did,survey_version")
+
+	// Tampered URLs are refused
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, signed.Path+"?expires=9999999999&signature="+signed.Query().Get("signature"), nil), rec)
+	c.SetParamNames("*")
+	c.SetParamValues(key)
+	require.NoError(t, h.ServeBlob(c))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/identity"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// Page sizes of the per-voter responses list
+const (
+	defaultResponsesPageSize = 50
+	maxResponsesPageSize     = 200
+)
+
+// parseResponsesFilter parses the per-voter responses query:
+//   - limit, offset: pagination, defaulting to the first 50 responses
+//   - question, option: only responses that selected the option of a choice question
+func parseResponsesFilter(c echo.Context, survey *models.Survey) (models.ResponseFilter, error) {
+	filter := models.ResponseFilter{Limit: defaultResponsesPageSize}
+
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+		filter.Limit = min(l, maxResponsesPageSize)
+	}
+	if o, err := strconv.Atoi(c.QueryParam("offset")); err == nil && o >= 0 {
+		filter.Offset = o
+	}
+
+	questionID, optionID := c.QueryParam("question"), c.QueryParam("option")
+	if questionID == "" && optionID == "" {
+		return filter, nil
+	}
+	if questionID == "" || optionID == "" {
+		return filter, fmt.Errorf("Filter needs both 'question' and 'option'")
+	}
+	for _, q := range survey.Definition.Questions {
+		if q.ID != questionID {
+			continue
+		}
+		for _, opt := range q.Options {
+			if opt.ID == optionID {
+				filter.Question, filter.Option = questionID, optionID
+				return filter, nil
+			}
+		}
+		return filter, fmt.Errorf("Unknown option '%s' of question '%s'", optionID, questionID)
+	}
+	return filter, fmt.Errorf("Unknown question '%s'", questionID)
+}
+
+// voterResponses loads a page of a survey's responses, the number of responses
+// matching the filter, and the identities of their logged-in voters.
+// Unresolved DIDs are listed as-is.
+func (h *Handlers) voterResponses(ctx context.Context, survey *models.Survey, filter models.ResponseFilter) ([]*models.Response, int, map[string]*identity.Identity, error) {
+	if err := h.restoreArchive(ctx, survey); err != nil {
+		return nil, 0, nil, err
+	}
+	responses, err := h.queries.ListFilteredResponses(ctx, survey.ID, filter)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	total, err := h.queries.CountFilteredResponses(ctx, survey.ID, filter)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	var dids []string
+	for _, r := range responses {
+		if r.VoterDID != nil {
+			dids = append(dids, *r.VoterDID)
+		}
+	}
+	voters := h.identities.Resolve(ctx, dids)
+	for _, did := range dids {
+		if _, ok := voters[did]; !ok {
+			voters[did] = &identity.Identity{DID: did}
+		}
+	}
+
+	return responses, total, voters, nil
+}
+
+// ListVoterResponses lists who answered what in a non-anonymous survey, for its author.
+// Responses are paginated oldest first and can be filtered by a selected option
+// (see parseResponsesFilter).
+// GET /api/v1/surveys/:slug/responses?limit=&offset=&question=&option=
+func (h *Handlers) ListVoterResponses(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.SurveyNotFound, fmt.Sprintf("No survey found with slug '%s'", slug))
+		}
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+
+	caller, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key of the survey author")
+	}
+	if !h.canReadSurveyAs(c.Request().Context(), caller, survey) {
+		return Problem(c, problem.Forbidden, "Only the survey author can list responses")
+	}
+	if survey.Definition.Anonymous {
+		return Problem(c, problem.SurveyAnonymous, "Voters of anonymous surveys are not disclosed; use the results instead")
+	}
+
+	filter, err := parseResponsesFilter(c, survey)
+	if err != nil {
+		return Problem(c, problem.ValidationFailed, "Invalid filter: "+err.Error())
+	}
+
+	responses, total, voters, err := h.voterResponses(ctx, survey, filter)
+	if err != nil {
+		return InternalServerError(c, "Failed to list responses", err)
+	}
+	ordinals, err := h.queries.ListQuestionOrdinals(ctx, survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list responses", err)
+	}
+
+	export := buildExport(survey, responses, ordinals, nil)
+	page := &VoterResponsesPage{
+		SurveyID:  survey.ID,
+		Slug:      survey.Slug,
+		Total:     total,
+		Limit:     filter.Limit,
+		Offset:    filter.Offset,
+		Questions: export.Questions,
+		Responses: voterResponseViews(export, voters),
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// voterResponseViews returns the responses of an export with their voters
+func voterResponseViews(export *ExportResponse, voters map[string]*identity.Identity) []VoterResponse {
+	views := make([]VoterResponse, len(export.Responses))
+	for i, record := range export.Responses {
+		views[i] = VoterResponse{
+			ID:            record.ID,
+			SubmittedAt:   record.SubmittedAt,
+			SurveyVersion: record.SurveyVersion,
+			Answers:       record.Answers,
+		}
+		if record.VoterDID != nil {
+			views[i].Voter = voters[*record.VoterDID]
+		}
+	}
+	return views
+}
+
+// ResponsesPageHTML renders the per-voter responses table of a non-anonymous survey
+// GET /surveys/:slug/responses?offset=&question=&option=
+func (h *Handlers) ResponsesPageHTML(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	survey, err := h.surveyBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user, profile := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canReadSurvey(ctx, user, survey) {
+		return c.String(http.StatusForbidden, "Only the survey author can view responses")
+	}
+	if survey.Definition.Anonymous {
+		return c.String(http.StatusForbidden, "Voters of anonymous surveys are not disclosed")
+	}
+
+	filter, err := parseResponsesFilter(c, survey)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	responses, total, voters, err := h.voterResponses(ctx, survey, filter)
+	if err != nil {
+		c.Logger().Errorf("Failed to list responses of survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to load responses")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.ResponsesPage(survey, responses, voters, total, filter, user, profile, h.posthogKey)
+	return component.Render(ctx, c.Response().Writer)
+}
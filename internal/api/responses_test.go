@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResponsesContext(e *echo.Echo, path, slug string, query url.Values, user *oauth.User) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, path+"?"+query.Encode(), nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/surveys/:slug/responses")
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	if user != nil {
+		c.Set("user", user)
+	}
+	return c, rec
+}
+
+func TestListVoterResponses(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+
+	c, rec := newResponsesContext(e, "/api/v1/surveys/export-survey/responses", survey.Slug, url.Values{}, &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.ListVoterResponses(c))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var page VoterResponsesPage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, defaultResponsesPageSize, page.Limit)
+	require.Len(t, page.Responses, 2)
+
+	first := page.Responses[0]
+	require.NotNil(t, first.Voter)
+	assert.Equal(t, "did:plc:voter", first.Voter.DID)
+	assert.Equal(t, ExportAnswer{QuestionID: "color", Ordinal: 1, VersionOrdinal: 2, SelectedOptions: []string{"blue"}}, first.Answers[0])
+	assert.Nil(t, page.Responses[1].Voter, "voters who were not logged in have no identity")
+}
+
+func TestListVoterResponses_Access(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      *oauth.User
+		anonymous bool
+		want      int
+	}{
+		{"not logged in", nil, false, http.StatusUnauthorized},
+		{"not the author", &oauth.User{DID: "did:plc:someone"}, false, http.StatusForbidden},
+		{"anonymous survey", &oauth.User{DID: "did:plc:author"}, true, http.StatusForbidden},
+		{"author", &oauth.User{DID: "did:plc:author"}, false, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _, h, survey := setupExportTest(t)
+			survey.Definition.Anonymous = tt.anonymous
+
+			c, rec := newResponsesContext(e, "/api/v1/surveys/export-survey/responses", survey.Slug, url.Values{}, tt.user)
+			require.NoError(t, h.ListVoterResponses(c))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestListVoterResponses_Filters(t *testing.T) {
+	author := &oauth.User{DID: "did:plc:author"}
+
+	tests := []struct {
+		name   string
+		query  url.Values
+		status int
+		total  int
+		shown  int
+	}{
+		{"first page", url.Values{"limit": {"1"}}, http.StatusOK, 2, 1},
+		{"second page", url.Values{"limit": {"1"}, "offset": {"1"}}, http.StatusOK, 2, 1},
+		{"past the end", url.Values{"offset": {"5"}}, http.StatusOK, 2, 0},
+		{"selected option", url.Values{"question": {"color"}, "option": {"blue"}}, http.StatusOK, 1, 1},
+		{"unselected option", url.Values{"question": {"color"}, "option": {"red"}, "offset": {"1"}}, http.StatusOK, 1, 0},
+		{"option without question", url.Values{"option": {"blue"}}, http.StatusBadRequest, 0, 0},
+		{"unknown question", url.Values{"question": {"size"}, "option": {"blue"}}, http.StatusBadRequest, 0, 0},
+		{"unknown option", url.Values{"question": {"color"}, "option": {"green"}}, http.StatusBadRequest, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _, h, survey := setupExportTest(t)
+
+			c, rec := newResponsesContext(e, "/api/v1/surveys/export-survey/responses", survey.Slug, tt.query, author)
+			require.NoError(t, h.ListVoterResponses(c))
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var page VoterResponsesPage
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+			assert.Equal(t, tt.total, page.Total)
+			assert.Len(t, page.Responses, tt.shown)
+		})
+	}
+}
+
+func TestResponsesPageHTML(t *testing.T) {
+	e, _, h, survey := setupExportTest(t)
+
+	c, rec := newResponsesContext(e, "/surveys/export-survey/responses", survey.Slug, url.Values{}, nil)
+	require.NoError(t, h.ResponsesPageHTML(c))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	c, rec = newResponsesContext(e, "/surveys/export-survey/responses", survey.Slug, url.Values{"question": {"color"}, "option": {"blue"}}, &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.ResponsesPageHTML(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "did:plc:voter")
+	assert.Contains(t, rec.Body.String(), "1–1 of 1 responses")
+
+	survey.Definition.Anonymous = true
+	c, rec = newResponsesContext(e, "/surveys/export-survey/responses", survey.Slug, url.Values{}, &oauth.User{DID: "did:plc:author"})
+	require.NoError(t, h.ResponsesPageHTML(c))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createReviewSurvey stores a survey with a review step
+func createReviewSurvey(mq *MockQueries) *models.Survey {
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "review-survey",
+		Title: "Review Survey",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{
+					ID:       "q1",
+					Text:     "Pick one",
+					Type:     models.QuestionTypeSingle,
+					Required: true,
+					Options:  []models.Option{{ID: "a", Text: "Apples"}, {ID: "b", Text: "Bananas"}},
+				},
+				{ID: "q2", Text: "Why?", Type: models.QuestionTypeText},
+			},
+			ConfirmBeforeSubmit: true,
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	mq.CreateSurvey(context.Background(), survey)
+	return survey
+}
+
+// postReviewForm posts a form to a review or submit handler
+func postReviewForm(t *testing.T, e *echo.Echo, handler echo.HandlerFunc, path string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.RemoteAddr = "192.168.1.1:12345"
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("review-survey")
+	require.NoError(t, handler(c))
+	return rec
+}
+
+var reviewTokenPattern = regexp.MustCompile(`name="review_token" value="([^"]+)"`)
+
+func TestFormAnswers(t *testing.T) {
+	def := &models.SurveyDefinition{Questions: []models.Question{
+		{ID: "q1", Type: models.QuestionTypeSingle},
+		{ID: "q2", Type: models.QuestionTypeMulti},
+		{ID: "q3", Type: models.QuestionTypeText},
+		{ID: "q4", Type: models.QuestionTypeText},
+	}}
+
+	answers := formAnswers(def, url.Values{
+		"q1":    {"a"},
+		"q2":    {"x", "y"},
+		"q3":    {"hello"},
+		"other": {"ignored"},
+	})
+
+	assert.Equal(t, map[string]models.Answer{
+		"q1": {SelectedOptions: []string{"a"}},
+		"q2": {SelectedOptions: []string{"x", "y"}},
+		"q3": {Text: "hello"},
+	}, answers)
+}
+
+func TestReviewResponseHTML(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := createReviewSurvey(mq)
+
+	rec := postReviewForm(t, e, h.ReviewResponseHTML, "/surveys/review-survey/review", url.Values{"q1": {"b"}, "q2": {"Tasty"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "Review your answers")
+	assert.Contains(t, body, "Bananas")
+	assert.Contains(t, body, "Tasty")
+	assert.Regexp(t, reviewTokenPattern, body)
+
+	// Nothing is submitted until the voter confirms
+	assert.Empty(t, mq.ResponsesBySurvey[survey.ID])
+}
+
+func TestReviewResponseHTML_Edit(t *testing.T) {
+	e, mq, h := setupTest()
+	createReviewSurvey(mq)
+
+	rec := postReviewForm(t, e, h.ReviewResponseHTML, "/surveys/review-survey/review", url.Values{"q1": {"b"}, "q2": {"Tasty"}, "action": {"edit"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "Review Answers")
+	assert.Regexp(t, `value="b"\s+checked`, body)
+	assert.Contains(t, body, ">Tasty</textarea>")
+}
+
+func TestSubmitResponseHTML_RequiresReview(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := createReviewSurvey(mq)
+
+	// Without a review token
+	rec := postReviewForm(t, e, h.SubmitResponseHTML, "/surveys/review-survey/responses", url.Values{"q1": {"a"}})
+	assert.Contains(t, rec.Body.String(), "Please review your answers")
+	assert.Empty(t, mq.ResponsesBySurvey[survey.ID])
+
+	// With a token for other answers
+	rec = postReviewForm(t, e, h.ReviewResponseHTML, "/surveys/review-survey/review", url.Values{"q1": {"a"}})
+	token := reviewTokenPattern.FindStringSubmatch(rec.Body.String())[1]
+	rec = postReviewForm(t, e, h.SubmitResponseHTML, "/surveys/review-survey/responses", url.Values{"q1": {"b"}, "review_token": {token}})
+	assert.Contains(t, rec.Body.String(), "Please review your answers")
+	assert.Empty(t, mq.ResponsesBySurvey[survey.ID])
+
+	// With the token of the reviewed answers
+	rec = postReviewForm(t, e, h.SubmitResponseHTML, "/surveys/review-survey/responses", url.Values{"q1": {"a"}, "review_token": {token}})
+	assert.Contains(t, rec.Body.String(), "Thank You")
+	assert.Len(t, mq.ResponsesBySurvey[survey.ID], 1)
+}
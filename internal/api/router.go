@@ -4,7 +4,7 @@ import (
 	"database/sql"
 
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+	"github.com/openmeet-team/survey/internal/apikey"
 	"github.com/openmeet-team/survey/internal/oauth"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
@@ -12,6 +12,9 @@ import (
 
 // SetupRoutes configures all API routes
 func SetupRoutes(e *echo.Echo, h *Handlers, hh *HealthHandlers, oh *oauth.Handlers, db *sql.DB) {
+	// Errors returned by handlers and middleware are written as problem details
+	e.HTTPErrorHandler = HTTPErrorHandler
+
 	// Health check and metrics endpoints (no middleware)
 	e.GET("/health", hh.Health)
 	e.GET("/health/ready", hh.Readiness)
@@ -21,12 +24,22 @@ func SetupRoutes(e *echo.Echo, h *Handlers, hh *HealthHandlers, oh *oauth.Handle
 	e.Static("/static", "static")      // Committed assets (og-image, etc.)
 	e.Static("/assets", "web/dist")    // Built assets (survey-editor.js)
 
+	security := DefaultSecurityConfig()
+	if h.security != nil {
+		security = *h.security
+	}
+
 	// Apply middleware to all other routes
 	e.Use(RequestIDMiddleware())
 	e.Use(MetricsMiddleware())
-	e.Use(SecurityHeadersMiddleware())
+	e.Use(SecurityHeadersWithConfig(security))
 	e.Use(otelecho.Middleware("survey-api"))
 
+	// Serve tenants on their own domains
+	if h.tenantResolver != nil {
+		e.Use(TenantMiddleware(h.tenantResolver))
+	}
+
 	// Create session middleware
 	storage := oauth.NewStorage(db)
 	sessionMiddleware := oauth.SessionMiddleware(storage)
@@ -34,6 +47,12 @@ func SetupRoutes(e *echo.Echo, h *Handlers, hh *HealthHandlers, oh *oauth.Handle
 	// Create rate limiters
 	rateLimiters := NewRateLimiterConfig()
 
+	// Anonymous voters close to the vote submission limit must solve a CAPTCHA
+	h.voteLimiter = rateLimiters.VoteSubmission
+
+	// Bulk creation is charged per survey once the request is read
+	h.bulkLimiter = rateLimiters.BulkSurveys
+
 	// Create body limit config
 	bodyLimits := DefaultBodyLimitConfig()
 
@@ -41,33 +60,255 @@ func SetupRoutes(e *echo.Echo, h *Handlers, hh *HealthHandlers, oh *oauth.Handle
 	api := e.Group("/api/v1")
 
 	// CORS configuration for API routes
-	api.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"}, // Allow all origins for MVP
-		AllowMethods: []string{
-			echo.GET,
-			echo.POST,
-			echo.PUT,
-			echo.DELETE,
-			echo.OPTIONS,
-		},
-		AllowHeaders: []string{
-			echo.HeaderContentType,
-			echo.HeaderAuthorization,
-			echo.HeaderAccept,
-		},
-	}))
+	cors := CORSMiddleware(security)
+	api.Use(cors)
+
+	// API key authentication with per-key rate limits (keys replace the per-IP limits)
+	keyLimiter := NewKeyRateLimiter()
+	if h.apiKeys != nil {
+		api.Use(APIKeyMiddleware(h.apiKeys, h.apiKeyConfig, keyLimiter, h.apiKeyUsage))
+	}
 
 	// Survey management with rate limiting and body limits
-	api.POST("/surveys", h.CreateSurvey, rateLimiters.SurveyCreation.Middleware(), NewBodyLimitMiddleware(bodyLimits.SurveyCreation))
-	api.GET("/surveys/:slug", h.GetSurvey, rateLimiters.GeneralAPI.Middleware())
-	api.POST("/surveys/generate", h.GenerateSurvey, rateLimiters.SurveyCreation.Middleware())
+	api.POST("/surveys", h.CreateSurvey, RequireScope(apikey.ScopeWrite), rateLimiters.SurveyCreation.Middleware(), NewBodyLimitMiddleware(bodyLimits.SurveyCreation))
+	if h.bulkSurveys != nil {
+		api.POST("/surveys/bulk", h.CreateSurveysBulk, sessionMiddleware, RequireScope(apikey.ScopeWrite), NewBodyLimitMiddleware(bodyLimits.BulkSurveyCreation))
+	}
+	api.POST("/surveys/validate", h.ValidateSurvey, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.SurveyCreation))
+	if h.previews != nil {
+		api.POST("/surveys/preview", h.CreatePreview, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.SurveyCreation))
+	}
+	if h.accountData != nil {
+		api.GET("/surveys", h.ListOwnSurveys, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+	}
+	api.GET("/surveys/:slug", h.GetSurvey, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+	api.PUT("/surveys/:slug", h.UpdateSurvey, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.SurveyCreation.Middleware(), NewBodyLimitMiddleware(bodyLimits.SurveyCreation))
+	api.POST("/surveys/:slug/archive", h.ArchiveSurvey, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	if h.trash != nil {
+		api.DELETE("/surveys/:slug", h.DeleteSurvey, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+		api.GET("/trash", h.ListTrash, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/trash/:slug/restore", h.RestoreSurvey, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+	api.POST("/surveys/generate", h.GenerateSurvey, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.SurveyCreation.Middleware(), NewBodyLimitMiddleware(bodyLimits.Generation))
 
 	// Response submission and results with rate limiting and body limits
-	api.POST("/surveys/:slug/responses", h.SubmitResponse, rateLimiters.VoteSubmission.Middleware(), NewBodyLimitMiddleware(bodyLimits.ResponseSubmission))
-	api.GET("/surveys/:slug/results", h.GetResults, rateLimiters.GeneralAPI.Middleware())
+	api.POST("/surveys/:slug/responses", h.SubmitResponse, RequireScope(apikey.ScopeWrite), rateLimiters.VoteSubmission.Middleware(), NewBodyLimitMiddleware(bodyLimits.ResponseSubmission))
+	api.GET("/surveys/:slug/results", h.GetResults, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+
+	// Comments published from commenters' own repositories
+	api.GET("/surveys/:slug/comments", h.ListComments, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+
+	// Published results checked against a recount of the indexed responses
+	api.GET("/surveys/:slug/verify", h.VerifyResults, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+	if h.attestations.Enabled() {
+		api.POST("/results/verify", h.VerifyAttestation, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Survey and response records as a CAR file, for anyone to verify and recount
+	if h.audit != nil {
+		api.GET("/surveys/:slug/responses.car", h.ExportRecords, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Response exports generated in the background, downloaded through signed URLs
+	if h.exports != nil {
+		api.POST("/surveys/:slug/exports", h.RequestExport, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.GET("/surveys/:slug/exports/:id", h.GetExport, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Abuse reports of surveys, by any visitor
+	if h.reports != nil {
+		api.POST("/surveys/:slug/report", h.ReportSurvey, sessionMiddleware, rateLimiters.SurveyReport.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Per-voter responses of non-anonymous surveys, for their authors (logged in or with a key)
+	api.GET("/surveys/:slug/responses", h.ListVoterResponses, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+
+	// Responses streamed as NDJSON, for surveys too large for file exports (survey author, logged in or with a key)
+	api.GET("/surveys/:slug/responses/export", h.StreamResponses, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+
+	// Response rate over time, view funnel, and referrers, for survey authors
+	api.GET("/surveys/:slug/analytics", h.GetSurveyAnalytics, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+
+	// Responses by country and hour of day, for authors of surveys with responseMetadata
+	if h.heatmap != nil {
+		api.GET("/surveys/:slug/results/heatmap", h.GetResponseHeatmap, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Questions saved to reuse in new surveys (logged in or with a key)
+	if h.questionBank != nil {
+		api.GET("/me/questions", h.ListBankQuestions, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/me/questions", h.SaveBankQuestion, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/me/questions/:id", h.DeleteBankQuestion, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Milestone notification preferences and history (logged in or with a key)
+	if h.notifications != nil {
+		api.GET("/me/notifications", h.GetNotifications, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.PUT("/me/notifications/preferences", h.SaveNotificationPreferences, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/me/notifications/preferences", h.DeleteNotificationPreferences, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Suspected duplicate guest votes, which authors can exclude from results (logged in or with a key)
+	if h.dedup != nil {
+		api.GET("/surveys/:slug/duplicates", h.ListDuplicates, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/surveys/:slug/duplicates/exclude", h.ExcludeResponses, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.POST("/surveys/:slug/duplicates/include", h.IncludeResponses, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Spam scores of guest votes and the threshold leaving them out of results (logged in or with a key)
+	if h.spam != nil {
+		api.GET("/surveys/:slug/spam", h.GetSpamReport, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.PUT("/surveys/:slug/spam", h.SetSpamThreshold, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// One-time invite links of surveys with invite visibility, for their authors (logged in or with a key)
+	if h.invites != nil {
+		api.GET("/surveys/:slug/invites", h.ListInvites, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/surveys/:slug/invites", h.CreateInvites, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/surveys/:slug/invites/:id", h.DeleteInvite, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Invitees of surveys and reminders to those who have not responded before they close (logged in or with a key)
+	if h.reminders != nil {
+		api.GET("/surveys/:slug/invitations", h.GetInvitations, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/surveys/:slug/invitations", h.AddInvitations, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/surveys/:slug/invitations/:did", h.RemoveInvitation, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+		api.PUT("/surveys/:slug/reminders", h.SetReminderSchedule, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Share tokens of surveys with token visibility, for their authors (logged in or with a key)
+	if h.shareTokens != nil {
+		api.GET("/surveys/:slug/share-tokens", h.ListShareTokens, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/surveys/:slug/share-tokens", h.CreateShareToken, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/surveys/:slug/share-tokens/:id", h.RevokeShareToken, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Organizations sharing the ownership of surveys (logged in or with a key)
+	if h.orgs != nil {
+		api.GET("/orgs", h.ListOrgs, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/orgs", h.CreateOrg, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.GET("/orgs/:org", h.GetOrg, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/orgs/:org/members", h.InviteOrgMember, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.POST("/orgs/:org/accept", h.AcceptOrgInvite, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.PUT("/orgs/:org/members/:did", h.UpdateOrgMember, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/orgs/:org/members/:did", h.RemoveOrgMember, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+		api.PUT("/surveys/:slug/org", h.SetSurveyOrg, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Co-authors of surveys and their requests to publish results (logged in or with a key)
+	if h.coAuthors != nil {
+		api.GET("/surveys/:slug/coauthors", h.ListCoAuthors, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/surveys/:slug/coauthors", h.AddCoAuthor, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/surveys/:slug/coauthors/:did", h.RemoveCoAuthor, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/surveys/:slug/publish-requests", h.RequestPublish, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.PUT("/surveys/:slug/publish-requests/:id", h.ResolvePublish, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Survey series, combining results across surveys (logged in or with a key)
+	if h.series != nil {
+		api.GET("/series", h.ListSeries, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/series", h.CreateSeries, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.GET("/series/:series", h.GetSeries, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.DELETE("/series/:series", h.DeleteSeries, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/series/:series/surveys", h.AddSeriesSurvey, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/series/:series/surveys/:slug", h.RemoveSeriesSurvey, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+		api.GET("/series/:series/results", h.GetSeriesResults, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.GET("/series/:series/respondents", h.ListSeriesRespondents, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Service statistics dashboard (admin)
+	if h.adminStats != nil {
+		api.GET("/admin/stats", h.GetAdminStats, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Surveys featured on the landing page (admin)
+	if h.trending != nil {
+		api.GET("/admin/featured", h.ListFeaturedSurveys, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.PUT("/admin/featured/:slug", h.FeatureSurvey, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/admin/featured/:slug", h.UnfeatureSurvey, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Slugs reserved for allow-listed authors (admin)
+	if h.reservedSlugs != nil {
+		api.GET("/admin/reserved-slugs", h.ListReservedSlugs, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/admin/reserved-slugs", h.ReserveSlug, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/admin/reserved-slugs/:pattern", h.UnreserveSlug, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Tenants serving surveys on their own domains (admin), and the surveys
+	// listed on them
+	if h.tenants != nil {
+		api.GET("/admin/tenants", h.ListTenants, sessionMiddleware, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		api.POST("/admin/tenants", h.CreateTenant, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.PUT("/admin/tenants/:tenant", h.UpdateTenant, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.POST("/admin/tenants/:tenant/domains", h.AddTenantDomain, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/admin/tenants/:tenant/domains/:hostname", h.RemoveTenantDomain, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+		api.PUT("/tenants/:tenant/surveys/:slug", h.CrossPostSurvey, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		api.DELETE("/tenants/:tenant/surveys/:slug", h.RemoveCrossPost, sessionMiddleware, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+		api.GET("/tenant/surveys", h.ListTenantSurveys, rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Public service status
+	api.GET("/status", h.GetStatus, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+
+	// JSON Schema of survey definitions, public so editors and CI pipelines can fetch it without a key
+	e.GET("/api/v1/schema/survey-definition.json", h.GetDefinitionSchema, cors, rateLimiters.GeneralAPI.Middleware())
 
-	// HTML routes (Templ handlers) - with session middleware
-	web := e.Group("", sessionMiddleware)
+	// Error codes; the type URI of every problem resolves here
+	e.GET("/problems", h.ListProblemTypes, cors, rateLimiters.GeneralAPI.Middleware())
+	e.GET("/problems/:code", h.GetProblemType, cors, rateLimiters.GeneralAPI.Middleware())
+
+	// XRPC queries of the index, for ATProto clients, declared by the query lexicons below
+	xrpc := e.Group("/xrpc", cors, rateLimiters.GeneralAPI.Middleware())
+	// Service auth tokens let native clients call the queries as a user, e.g. authors listing responses
+	if h.serviceAuth != nil {
+		xrpc.Use(h.ServiceAuthMiddleware())
+		xrpc.GET("/net.openmeet.survey.getResponses", h.XRPCGetResponses)
+	}
+	xrpc.GET("/net.openmeet.survey.getSurvey", h.XRPCGetSurvey)
+	xrpc.GET("/net.openmeet.survey.getResults", h.XRPCGetResults)
+	xrpc.GET("/net.openmeet.survey.getComments", h.XRPCGetComments)
+	xrpc.Any("/*", h.XRPCNotImplemented)
+
+	// Lexicon schemas of the survey records and queries, for third-party ATProto clients to validate against
+	e.GET("/.well-known/atproto-lexicons", h.ListLexicons, cors, rateLimiters.GeneralAPI.Middleware())
+	e.GET("/.well-known/atproto-lexicons/", h.ListLexicons, cors, rateLimiters.GeneralAPI.Middleware())
+	e.GET("/.well-known/atproto-lexicons/:nsid", h.GetLexicon, cors, rateLimiters.GeneralAPI.Middleware())
+
+	// Public keys published results are signed with, for anyone to check their attestations
+	if h.attestations.Enabled() {
+		e.GET("/.well-known/results-signing-keys.json", h.ResultsSigningKeys, cors, rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// API key management, for logged-in users or keys with the admin scope.
+	// A separate group so users can create their first key even when keys are required.
+	if h.apiKeys != nil {
+		account := APIKeyMiddleware(h.apiKeys, apikey.Config{}, keyLimiter, h.apiKeyUsage)
+		keys := e.Group("/api/v1/keys", cors, sessionMiddleware, account, RequireScope(apikey.ScopeAdmin))
+		keys.GET("", h.ListAPIKeys, rateLimiters.GeneralAPI.Middleware())
+		keys.POST("", h.CreateAPIKey, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		keys.DELETE("/:id", h.RevokeAPIKey, rateLimiters.GeneralAPI.Middleware())
+
+		// Usage of the caller's keys and AI generations
+		e.GET("/api/v1/usage", h.GetUsage, cors, sessionMiddleware, account, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Drafts autosaved by the create page, for logged-in users, keys, and guests with a
+	// draft cookie. A separate group so guests can save drafts even when keys are required.
+	if h.drafts != nil {
+		drafts := e.Group("/api/v1/drafts", cors, sessionMiddleware)
+		if h.apiKeys != nil {
+			drafts.Use(APIKeyMiddleware(h.apiKeys, apikey.Config{}, keyLimiter, h.apiKeyUsage))
+		}
+		drafts.GET("", h.ListDrafts, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		drafts.POST("", h.CreateDraft, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		drafts.GET("/:id", h.GetDraft, RequireScope(apikey.ScopeRead), rateLimiters.GeneralAPI.Middleware())
+		drafts.PUT("/:id", h.SaveDraft, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		drafts.DELETE("/:id", h.DeleteDraft, RequireScope(apikey.ScopeWrite), rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// HTML routes (Templ handlers) - with session middleware, recording when sessions were last used
+	web := e.Group("", sessionMiddleware, oauth.SessionActivityMiddleware(storage))
 
 	// Short URL routes with rate limiting
 	web.GET("/s/:slug", h.ShortSlugURL, rateLimiters.GeneralAPI.Middleware())
@@ -76,38 +317,190 @@ func SetupRoutes(e *echo.Echo, h *Handlers, hh *HealthHandlers, oh *oauth.Handle
 	// Survey creation with rate limiting and body limits
 	web.GET("/surveys/new", h.CreateSurveyPageHTML, rateLimiters.GeneralAPI.Middleware())
 	web.POST("/surveys", h.CreateSurveyHTML, rateLimiters.SurveyCreation.Middleware(), NewBodyLimitMiddleware(bodyLimits.SurveyCreation))
+	if h.previews != nil {
+		web.GET("/surveys/preview/:token", h.PreviewHTML, rateLimiters.GeneralAPI.Middleware())
+	}
 
 	// Survey viewing and voting with rate limiting and body limits
 	web.GET("/surveys/:slug", h.GetSurveyHTML, rateLimiters.GeneralAPI.Middleware())
 	web.POST("/surveys/:slug/responses", h.SubmitResponseHTML, rateLimiters.VoteSubmission.Middleware(), NewBodyLimitMiddleware(bodyLimits.ResponseSubmission))
+	web.POST("/surveys/:slug/review", h.ReviewResponseHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.ResponseSubmission))
+	web.GET("/surveys/:slug/status", h.SurveyStatusHTML, rateLimiters.GeneralAPI.Middleware())
+	if h.responseDrafts != nil {
+		web.POST("/surveys/:slug/autosave", h.AutosaveResponseHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.ResponseSubmission))
+	}
+
+	// Question and option images: uploads to the author's PDS, and a proxy serving them
+	web.POST("/images", h.UploadImageHTML, rateLimiters.SurveyCreation.Middleware(), NewBodyLimitMiddleware(bodyLimits.ImageUpload))
+	web.GET("/surveys/:slug/images/:cid", h.SurveyImage, rateLimiters.GeneralAPI.Middleware())
+
+	// Files answering file questions: uploads to the voter's PDS, and a proxy
+	// serving them to those who can read the responses
+	web.POST("/surveys/:slug/files/:question", h.UploadFileHTML, rateLimiters.VoteSubmission.Middleware(), NewBodyLimitMiddleware(bodyLimits.ImageUpload))
+	web.GET("/surveys/:slug/responses/:id/files/:question", h.SurveyFile, rateLimiters.GeneralAPI.Middleware())
+
+	// Open Graph card image of a survey, for link previews
+	web.GET("/surveys/:slug/card.png", h.SurveyCard, rateLimiters.GeneralAPI.Middleware())
+
+	// Posts sharing a survey or its results to the user's Bluesky account
+	web.GET("/surveys/:slug/bluesky", h.BlueskyComposeHTML, rateLimiters.GeneralAPI.Middleware())
+	web.POST("/surveys/:slug/bluesky", h.ShareToBlueskyHTML, rateLimiters.SurveyCreation.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+
+	// Retry of survey and response records whose PDS write failed
+	web.POST("/surveys/:slug/outbox/:id/retry", h.RetryRecordHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
 
 	// Results with rate limiting
 	web.GET("/surveys/:slug/results", h.GetResultsHTML, rateLimiters.GeneralAPI.Middleware())
 	web.GET("/surveys/:slug/results-partial", h.GetResultsPartialHTML, rateLimiters.GeneralAPI.Middleware())
-	web.POST("/surveys/:slug/publish-results", h.PublishResultsHTML, rateLimiters.GeneralAPI.Middleware())
+	// Charts are embeddable: framing is allowed from the configured ancestors
+	web.GET("/surveys/:slug/results/chart.svg", h.GetResultsChart, SecurityHeadersOverride(security.EmbedHeaders()), rateLimiters.GeneralAPI.Middleware())
+	web.POST("/surveys/:slug/publish-results", h.PublishResultsHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+
+	// Verification of vote receipts
+	web.GET("/surveys/:slug/receipt/:token", h.ReceiptPageHTML, rateLimiters.GeneralAPI.Middleware())
+
+	// Review of flagged text answers (survey author or admin)
+	web.GET("/surveys/:slug/moderation", h.ModerationPageHTML, rateLimiters.GeneralAPI.Middleware())
+	web.POST("/surveys/:slug/moderation/:id", h.ReviewFlaggedResponseHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+
+	// Abuse reports of surveys, and their review queue (admin)
+	if h.reports != nil {
+		web.POST("/surveys/:slug/report", h.ReportSurveyHTML, rateLimiters.SurveyReport.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.GET("/admin/reports", h.ReportsPageHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/admin/reports/:id", h.ResolveReportsHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Service statistics dashboard (admin)
+	if h.adminStats != nil {
+		web.GET("/admin/stats", h.AdminStatsHTML, rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Surveys featured on the landing page (admin)
+	if h.trending != nil {
+		web.GET("/admin/featured", h.FeaturedPageHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/admin/featured", h.FeatureSurveyHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.POST("/admin/featured/:slug/remove", h.UnfeatureSurveyHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Share links of surveys with token visibility (survey author or admin)
+	if h.shareTokens != nil {
+		web.GET("/surveys/:slug/share-tokens", h.ShareTokensPageHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/surveys/:slug/share-tokens", h.CreateShareTokenHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.POST("/surveys/:slug/share-tokens/:id/revoke", h.RevokeShareTokenHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Results snapshot history and schedule (survey author)
+	if h.snapshots != nil {
+		web.GET("/surveys/:slug/snapshots", h.SnapshotsPageHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/surveys/:slug/snapshots", h.SetSnapshotScheduleHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Organizations and the ownership of surveys (login)
+	if h.orgs != nil {
+		web.GET("/orgs", h.OrgsPageHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/orgs", h.CreateOrgHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.GET("/orgs/:org", h.OrgPageHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/orgs/:org/invites", h.InviteOrgMemberHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.POST("/orgs/:org/accept", h.AcceptOrgInviteHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.POST("/orgs/:org/members/:did", h.UpdateOrgMemberHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.GET("/surveys/:slug/org", h.SurveyOrgPageHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/surveys/:slug/org", h.SetSurveyOrgHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Co-authors of surveys and their requests to publish results (login)
+	if h.coAuthors != nil {
+		web.GET("/surveys/:slug/coauthors", h.CoAuthorsPageHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/surveys/:slug/coauthors", h.AddCoAuthorHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.POST("/surveys/:slug/coauthors/:did", h.RemoveCoAuthorHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.POST("/surveys/:slug/publish-requests", h.RequestPublishHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.POST("/surveys/:slug/publish-requests/:id", h.ResolvePublishHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Combined results of survey series (owner)
+	if h.series != nil {
+		web.GET("/series/:series", h.SeriesPageHTML, rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Response export and per-voter responses (survey author or admin)
+	web.GET("/surveys/:slug/export", h.ExportResponses, rateLimiters.GeneralAPI.Middleware())
+	web.GET("/surveys/:slug/responses", h.ResponsesPageHTML, rateLimiters.GeneralAPI.Middleware())
+
+	// Response rate over time, view funnel, and referrers (survey author)
+	web.GET("/surveys/:slug/analytics", h.AnalyticsPageHTML, rateLimiters.GeneralAPI.Middleware())
+
+	// Responses by country and hour of day (survey author)
+	if h.heatmap != nil {
+		web.GET("/surveys/:slug/results/heatmap", h.HeatmapPageHTML, rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// API usage dashboard (requires login)
+	if h.apiKeys != nil {
+		web.GET("/usage", h.UsageHTML, rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Browse page of the user's surveys (requires login) with rate limiting
+	if h.surveyList != nil {
+		web.GET("/my-surveys", h.MySurveysHTML, rateLimiters.GeneralAPI.Middleware())
+	}
 
 	// My Data routes (requires login) with rate limiting
 	web.GET("/my-data", h.MyDataHTML, rateLimiters.GeneralAPI.Middleware())
+	if h.accountData != nil {
+		web.GET("/my-data/export", h.ExportAccountData, rateLimiters.GeneralAPI.Middleware())
+	}
 	web.GET("/my-data/:collection", h.MyDataCollectionHTML, rateLimiters.GeneralAPI.Middleware())
 	web.GET("/my-data/:collection/:rkey", h.MyDataRecordHTML, rateLimiters.GeneralAPI.Middleware())
-	web.POST("/my-data/:collection/:rkey", h.UpdateRecordHTML, rateLimiters.GeneralAPI.Middleware())
-	web.POST("/my-data/delete", h.DeleteRecordsHTML, rateLimiters.GeneralAPI.Middleware())
+	web.POST("/my-data/:collection/:rkey", h.UpdateRecordHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	web.POST("/my-data/delete", h.DeleteRecordsHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+
+	// Deleted surveys, restorable until purged (requires login)
+	if h.trash != nil {
+		web.GET("/trash", h.TrashPageHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/trash/:slug/restore", h.RestoreSurveyHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Session management (requires login)
+	if h.sessions != nil {
+		web.GET("/settings/sessions", h.SessionsHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/settings/sessions/revoke-all", h.RevokeAllSessionsHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+		web.POST("/settings/sessions/:handle/revoke", h.RevokeSessionHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
+
+	// Milestone notification settings (requires login)
+	if h.notifications != nil {
+		web.GET("/settings/notifications", h.NotificationsPageHTML, rateLimiters.GeneralAPI.Middleware())
+		web.POST("/settings/notifications", h.SaveNotificationPreferencesHTML, rateLimiters.GeneralAPI.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
+	}
 
 	// OAuth routes with rate limiting
 	if oh != nil {
 		oauthGroup := e.Group("/oauth")
 		oauthGroup.GET("/login", oh.LoginPage, rateLimiters.OAuth.Middleware())
-		oauthGroup.POST("/login", oh.Login, rateLimiters.OAuth.Middleware())
+		oauthGroup.POST("/login", oh.Login, rateLimiters.OAuth.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
 		oauthGroup.GET("/callback", oh.Callback, rateLimiters.OAuth.Middleware())
 		oauthGroup.GET("/client-metadata.json", oh.ClientMetadata, rateLimiters.OAuth.Middleware())
 		oauthGroup.GET("/jwks.json", oh.JWKS, rateLimiters.OAuth.Middleware())
-		oauthGroup.POST("/logout", oh.Logout, rateLimiters.OAuth.Middleware())
+		oauthGroup.POST("/logout", oh.Logout, rateLimiters.OAuth.Middleware(), NewBodyLimitMiddleware(bodyLimits.GeneralAPI))
 	}
 
 	// Landing page with statistics
 	web.GET("/", h.LandingPage, rateLimiters.GeneralAPI.Middleware())
 
+	// Files of the disk blob store, such as exports, behind signed URLs
+	if h.servesBlobs() {
+		web.GET("/blobs/*", h.ServeBlob, rateLimiters.GeneralAPI.Middleware())
+	}
+
+	// Atom feeds of new public surveys, site-wide and per author
+	if h.feeds != nil {
+		web.GET("/feeds/surveys.atom", h.SiteFeed, rateLimiters.GeneralAPI.Middleware())
+		web.GET("/feeds/authors/:file", h.AuthorFeed, rateLimiters.GeneralAPI.Middleware())
+	}
+
 	// Legal pages
 	web.GET("/privacy", h.PrivacyPage, rateLimiters.GeneralAPI.Middleware())
 	web.GET("/terms", h.TermsPage, rateLimiters.GeneralAPI.Middleware())
+
+	// Public status page
+	web.GET("/status", h.StatusPageHTML, rateLimiters.GeneralAPI.Middleware())
 }
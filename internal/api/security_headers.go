@@ -1,12 +1,117 @@
 package api
 
 import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
 	"github.com/labstack/echo/v4"
 )
 
+// defaultCSP is a balanced Content-Security-Policy that allows common use
+// cases while protecting against XSS and injection attacks
+const defaultCSP = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline' https://unpkg.com https://cdnjs.cloudflare.com https://*.posthog.com https://*.i.posthog.com https://challenges.cloudflare.com https://hcaptcha.com https://*.hcaptcha.com; " + // Allow HTMX, Monaco, PostHog, and CAPTCHA widgets
+	"style-src 'self' 'unsafe-inline' https://cdnjs.cloudflare.com https://hcaptcha.com https://*.hcaptcha.com; " + // unsafe-inline needed for inline styles, Monaco CSS from CDN, hCaptcha styles
+	"img-src 'self' data: https:; " + // Allow images from same origin, data URIs, and HTTPS
+	"font-src 'self' data: https://cdnjs.cloudflare.com; " + // Allow fonts from same origin, data URIs, and Monaco fonts
+	"connect-src 'self' https://*.posthog.com https://*.i.posthog.com https://hcaptcha.com https://*.hcaptcha.com; " + // Allow PostHog analytics and hCaptcha
+	"frame-src https://challenges.cloudflare.com https://hcaptcha.com https://*.hcaptcha.com; " + // Allow CAPTCHA challenge frames
+	"worker-src 'self' blob: https://cdnjs.cloudflare.com;" // Allow PostHog web workers and Monaco workers
+
+// DefaultHSTSMaxAge is how long browsers keep to HTTPS (one year)
+const DefaultHSTSMaxAge = 31536000
+
+// SecurityConfig is the CORS policy of the JSON API and the security headers
+// of all responses
+type SecurityConfig struct {
+	AllowedOrigins        []string // Origins browsers may call the API from; "*" allows any
+	ContentSecurityPolicy string   // Policy of all routes but embeddable ones
+	HSTSMaxAge            int      // Seconds browsers keep to HTTPS after an HTTPS response; 0 turns HSTS off
+	FrameAncestors        []string // Origins that may frame embeddable routes; "*" allows any, empty none
+}
+
+// DefaultSecurityConfig allows API calls from any origin and embedding
+// anywhere, with the default Content-Security-Policy and a year of HSTS
+func DefaultSecurityConfig() SecurityConfig {
+	return SecurityConfig{
+		AllowedOrigins:        []string{"*"},
+		ContentSecurityPolicy: defaultCSP,
+		HSTSMaxAge:            DefaultHSTSMaxAge,
+		FrameAncestors:        []string{"*"},
+	}
+}
+
+// SecurityConfigFromEnv creates a SecurityConfig from environment variables
+// Environment variables:
+//   - CORS_ALLOWED_ORIGINS: comma-separated origins allowed to call the API (default "*")
+//   - CONTENT_SECURITY_POLICY: replaces the default Content-Security-Policy
+//   - HSTS_MAX_AGE: seconds of HSTS (default one year, 0 to turn it off)
+//   - EMBED_FRAME_ANCESTORS: comma-separated origins allowed to frame results charts (default "*", "none" for none)
+func SecurityConfigFromEnv() SecurityConfig {
+	config := DefaultSecurityConfig()
+	// An empty list would allow any origin, so it keeps the default
+	if origins := splitList(os.Getenv("CORS_ALLOWED_ORIGINS")); len(origins) > 0 {
+		config.AllowedOrigins = origins
+	}
+	if v := os.Getenv("CONTENT_SECURITY_POLICY"); v != "" {
+		config.ContentSecurityPolicy = v
+	}
+	if v := os.Getenv("HSTS_MAX_AGE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			config.HSTSMaxAge = n
+		} else {
+			log.Printf("Warning: Invalid HSTS_MAX_AGE %q, using %d", v, DefaultHSTSMaxAge)
+		}
+	}
+	if v := os.Getenv("EMBED_FRAME_ANCESTORS"); v == "none" {
+		config.FrameAncestors = nil
+	} else if v != "" {
+		config.FrameAncestors = splitList(v)
+	}
+	return config
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(v string) []string {
+	var list []string
+	for _, entry := range strings.Split(v, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// EmbedHeaders are the security headers of embeddable routes, for
+// SecurityHeadersOverride: framing is allowed from FrameAncestors, and the
+// embedded document may only use inline styles
+func (s SecurityConfig) EmbedHeaders() map[string]string {
+	ancestors := "'none'"
+	if len(s.FrameAncestors) > 0 {
+		ancestors = strings.Join(s.FrameAncestors, " ")
+	}
+	return map[string]string{
+		"X-Frame-Options":         "",
+		"Content-Security-Policy": "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors " + ancestors,
+	}
+}
+
 // SecurityHeadersMiddleware adds security headers to all responses
 // to protect against common web vulnerabilities
 func SecurityHeadersMiddleware() echo.MiddlewareFunc {
+	return SecurityHeadersWithConfig(DefaultSecurityConfig())
+}
+
+// SecurityHeadersWithConfig adds the security headers of a config to all responses
+func SecurityHeadersWithConfig(config SecurityConfig) echo.MiddlewareFunc {
+	hsts := ""
+	if config.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d; includeSubDomains", config.HSTSMaxAge)
+	}
+
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			res := c.Response()
@@ -39,23 +144,16 @@ func SecurityHeadersMiddleware() echo.MiddlewareFunc {
 			}
 
 			// Strict-Transport-Security (HSTS): Enforce HTTPS
-			// Only set for HTTPS requests (setting on HTTP can cause issues)
-			if c.Request().URL.Scheme == "https" && res.Header().Get("Strict-Transport-Security") == "" {
-				res.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+			// Only set for HTTPS requests, including those a proxy terminated TLS for
+			// (setting on HTTP can cause issues)
+			https := c.Request().URL.Scheme == "https" || c.Scheme() == "https"
+			if hsts != "" && https && res.Header().Get("Strict-Transport-Security") == "" {
+				res.Header().Set("Strict-Transport-Security", hsts)
 			}
 
 			// Content-Security-Policy: Protect against XSS and injection attacks
-			// This is a balanced policy that allows common use cases while maintaining security
-			if res.Header().Get("Content-Security-Policy") == "" {
-				csp := "default-src 'self'; " +
-					"script-src 'self' 'unsafe-inline' https://unpkg.com https://cdnjs.cloudflare.com https://*.posthog.com https://*.i.posthog.com; " + // Allow HTMX, Monaco, and PostHog
-					"style-src 'self' 'unsafe-inline' https://cdnjs.cloudflare.com; " + // unsafe-inline needed for inline styles, Monaco CSS from CDN
-					"img-src 'self' data: https:; " + // Allow images from same origin, data URIs, and HTTPS
-					"font-src 'self' data: https://cdnjs.cloudflare.com; " + // Allow fonts from same origin, data URIs, and Monaco fonts
-					"connect-src 'self' https://*.posthog.com https://*.i.posthog.com; " + // Allow PostHog analytics
-					"worker-src 'self' blob: https://cdnjs.cloudflare.com;" // Allow PostHog web workers and Monaco workers
-
-				res.Header().Set("Content-Security-Policy", csp)
+			if config.ContentSecurityPolicy != "" && res.Header().Get("Content-Security-Policy") == "" {
+				res.Header().Set("Content-Security-Policy", config.ContentSecurityPolicy)
 			}
 
 			// Call next handler
@@ -63,3 +161,21 @@ func SecurityHeadersMiddleware() echo.MiddlewareFunc {
 		}
 	}
 }
+
+// SecurityHeadersOverride replaces the security headers of the routes it is
+// applied to: each header is set to its value, or removed if the value is
+// empty. Handlers may still set headers of their own.
+func SecurityHeadersOverride(headers map[string]string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for name, value := range headers {
+				if value == "" {
+					c.Response().Header().Del(name)
+				} else {
+					c.Response().Header().Set(name, value)
+				}
+			}
+			return next(c)
+		}
+	}
+}
@@ -239,3 +239,87 @@ func TestSecurityHeadersMiddleware_AllMethods(t *testing.T) {
 		})
 	}
 }
+
+// TestSecurityConfigFromEnv verifies the environment overrides the defaults
+func TestSecurityConfigFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+	t.Setenv("CONTENT_SECURITY_POLICY", "")
+	t.Setenv("HSTS_MAX_AGE", "")
+	t.Setenv("EMBED_FRAME_ANCESTORS", "")
+	assert.Equal(t, DefaultSecurityConfig(), SecurityConfigFromEnv())
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example, https://other.example,")
+	t.Setenv("CONTENT_SECURITY_POLICY", "default-src 'self'")
+	t.Setenv("HSTS_MAX_AGE", "0")
+	t.Setenv("EMBED_FRAME_ANCESTORS", "https://blog.example")
+	config := SecurityConfigFromEnv()
+	assert.Equal(t, []string{"https://app.example", "https://other.example"}, config.AllowedOrigins)
+	assert.Equal(t, "default-src 'self'", config.ContentSecurityPolicy)
+	assert.Zero(t, config.HSTSMaxAge)
+	assert.Equal(t, []string{"https://blog.example"}, config.FrameAncestors)
+
+	// Invalid values keep the defaults
+	t.Setenv("CORS_ALLOWED_ORIGINS", ",")
+	t.Setenv("HSTS_MAX_AGE", "-1")
+	t.Setenv("EMBED_FRAME_ANCESTORS", "none")
+	config = SecurityConfigFromEnv()
+	assert.Equal(t, []string{"*"}, config.AllowedOrigins)
+	assert.Equal(t, DefaultHSTSMaxAge, config.HSTSMaxAge)
+	assert.Empty(t, config.FrameAncestors)
+}
+
+// TestSecurityHeadersWithConfig verifies the configured CSP and HSTS are sent
+func TestSecurityHeadersWithConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SecurityConfig
+		proto  string
+		csp    string
+		hsts   string
+	}{
+		{"custom policy", SecurityConfig{ContentSecurityPolicy: "default-src 'self'", HSTSMaxAge: 60}, "https", "default-src 'self'", "max-age=60; includeSubDomains"},
+		{"HSTS turned off", SecurityConfig{ContentSecurityPolicy: "default-src 'self'"}, "https", "default-src 'self'", ""},
+		{"plain HTTP behind a proxy", DefaultSecurityConfig(), "http", defaultCSP, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set(echo.HeaderXForwardedProto, tt.proto)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			h := SecurityHeadersWithConfig(tt.config)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			require.NoError(t, h(c))
+
+			assert.Equal(t, tt.csp, rec.Header().Get("Content-Security-Policy"))
+			assert.Equal(t, tt.hsts, rec.Header().Get("Strict-Transport-Security"))
+		})
+	}
+}
+
+// TestSecurityHeadersOverride verifies per-route overrides replace and remove headers
+func TestSecurityHeadersOverride(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/chart.svg", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	config := DefaultSecurityConfig()
+	config.FrameAncestors = []string{"https://blog.example", "https://news.example"}
+	handler := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	h := SecurityHeadersMiddleware()(SecurityHeadersOverride(config.EmbedHeaders())(handler))
+	require.NoError(t, h(c))
+
+	assert.Empty(t, rec.Header().Values("X-Frame-Options"), "embeds may be framed")
+	assert.Equal(t, "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors https://blog.example https://news.example",
+		rec.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"), "other headers are kept")
+
+	config.FrameAncestors = nil
+	assert.Contains(t, config.EmbedHeaders()["Content-Security-Policy"], "frame-ancestors 'none'")
+}
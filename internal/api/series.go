@@ -0,0 +1,411 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/series"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// Page sizes of the respondents of a series
+const (
+	defaultRespondentsPageSize = 50
+	maxRespondentsPageSize     = 200
+)
+
+// SetSeries enables survey series, whose results are combined and whose
+// logged-in voters are tracked across surveys
+func (h *Handlers) SetSeries(store series.Store) {
+	h.series = store
+}
+
+// Errors of the series helpers caused by the request
+var (
+	errInvalidSeriesRequest = errors.New("invalid series request")
+	errSeriesForbidden      = errors.New("forbidden")
+)
+
+// loadSeries returns the series with the slug for its owner and admins. It
+// returns sql.ErrNoRows if there is no such series or the DID may not see it.
+func (h *Handlers) loadSeries(ctx context.Context, slug, did string) (*series.Series, error) {
+	s, err := h.series.GetSeriesBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if s.OwnerDID != did && !h.adminDIDs[did] {
+		return nil, sql.ErrNoRows
+	}
+	return s, nil
+}
+
+// seriesSurveys returns the surveys of a series in wave order, leaving out
+// deleted surveys and those the DID can no longer read
+func (h *Handlers) seriesSurveys(ctx context.Context, s *series.Series, did string) ([]*models.Survey, error) {
+	ids, err := h.series.ListSeriesSurveyIDs(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	surveys := make([]*models.Survey, 0, len(ids))
+	for _, id := range ids {
+		survey, err := h.queries.GetSurveyByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return nil, err
+		}
+		if h.canReadSurveyAs(ctx, did, survey) {
+			surveys = append(surveys, survey)
+		}
+	}
+	return surveys, nil
+}
+
+// seriesWaves loads the results of the surveys of a series and the responses
+// of the logged-in voters of its surveys that are not anonymous
+func (h *Handlers) seriesWaves(ctx context.Context, s *series.Series, did string) ([]*series.Wave, error) {
+	surveys, err := h.seriesSurveys(ctx, s, did)
+	if err != nil {
+		return nil, err
+	}
+	waves := make([]*series.Wave, 0, len(surveys))
+	for _, survey := range surveys {
+		results, err := h.surveyResults(ctx, survey.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to aggregate results of %s: %w", survey.Slug, err)
+		}
+		w := &series.Wave{Survey: survey, Results: results}
+		if w.Tracked() {
+			if err := h.restoreArchive(ctx, survey); err != nil {
+				return nil, err
+			}
+			w.Responses, err = h.queries.ListFilteredResponses(ctx, survey.ID, models.ResponseFilter{VoterType: models.VoterTypeDID})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list responses of %s: %w", survey.Slug, err)
+			}
+		}
+		waves = append(waves, w)
+	}
+	return waves, nil
+}
+
+// createSeries creates a series owned by its creator
+func (h *Handlers) createSeries(ctx context.Context, slug, title, did string) (*series.Series, error) {
+	owned, err := h.series.ListSeriesByOwner(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if len(owned) >= series.MaxSeriesPerDID && !h.adminDIDs[did] {
+		return nil, fmt.Errorf("%w: you can create at most %d series", errInvalidSeriesRequest, series.MaxSeriesPerDID)
+	}
+
+	s, err := series.New(slug, title, did)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidSeriesRequest, err)
+	}
+	if err := h.series.CreateSeries(ctx, s); err != nil {
+		if errors.Is(err, series.ErrSlugTaken) {
+			return nil, fmt.Errorf("%w: %v", errInvalidSeriesRequest, err)
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// addSeriesSurvey makes a survey the last wave of a series. Only those who
+// manage the survey add it.
+func (h *Handlers) addSeriesSurvey(ctx context.Context, s *series.Series, did, slug string) (*models.Survey, error) {
+	slug = strings.TrimSpace(slug)
+	if slug == "" {
+		return nil, fmt.Errorf("%w: survey is required", errInvalidSeriesRequest)
+	}
+	survey, err := h.surveyBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: no survey with slug '%s'", errInvalidSeriesRequest, slug)
+		}
+		return nil, err
+	}
+	if !h.canManageSurveyAs(ctx, did, survey) {
+		return nil, fmt.Errorf("%w: only those who manage a survey can add it to a series", errSeriesForbidden)
+	}
+
+	ids, err := h.series.ListSeriesSurveyIDs(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) >= series.MaxSurveys {
+		return nil, fmt.Errorf("%w: a series can have at most %d surveys", errInvalidSeriesRequest, series.MaxSurveys)
+	}
+	if err := h.series.AddSeriesSurvey(ctx, s.ID, survey.ID, time.Now()); err != nil {
+		if errors.Is(err, series.ErrAlreadyInSeries) {
+			return nil, fmt.Errorf("%w: %v", errInvalidSeriesRequest, err)
+		}
+		return nil, err
+	}
+	return survey, nil
+}
+
+// removeSeriesSurvey removes a survey from a series, returning sql.ErrNoRows
+// if it is not in the series
+func (h *Handlers) removeSeriesSurvey(ctx context.Context, s *series.Series, slug string) error {
+	survey, err := h.surveyBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+	return h.series.RemoveSeriesSurvey(ctx, s.ID, survey.ID)
+}
+
+// seriesErrorJSON responds to an API request whose series helper failed
+func seriesErrorJSON(c echo.Context, err error, action string) error {
+	switch {
+	case errors.Is(err, errSeriesForbidden):
+		return Problem(c, problem.Forbidden, seriesErrorMessage(err))
+	case errors.Is(err, errInvalidSeriesRequest):
+		return ValidationError(c, "Invalid request", seriesErrorMessage(err))
+	case errors.Is(err, sql.ErrNoRows):
+		return Problem(c, problem.NotFound, "The survey is not in the series")
+	}
+	return InternalServerError(c, "Failed to "+action, err)
+}
+
+// seriesErrorMessage is the message of a series helper that failed because
+// of the request, or "" for internal errors
+func seriesErrorMessage(err error) string {
+	for _, sentinel := range []error{errSeriesForbidden, errInvalidSeriesRequest} {
+		if errors.Is(err, sentinel) {
+			return strings.TrimPrefix(err.Error(), sentinel.Error()+": ")
+		}
+	}
+	return ""
+}
+
+// seriesForRequest loads the series of an API request for its owner. On
+// failure it returns a nil series and the error response written; series
+// are hidden from everyone else.
+func (h *Handlers) seriesForRequest(c echo.Context) (*series.Series, string, error) {
+	did, err := requireCaller(c)
+	if did == "" {
+		return nil, "", err
+	}
+	s, err := h.loadSeries(c.Request().Context(), c.Param("series"), did)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", Problem(c, problem.NotFound, fmt.Sprintf("Series not found: No series found with slug '%s'", c.Param("series")))
+		}
+		return nil, "", InternalServerError(c, "Failed to retrieve series", err)
+	}
+	return s, did, nil
+}
+
+// ListSeries handles GET /api/v1/series
+// Lists the caller's series, newest first
+func (h *Handlers) ListSeries(c echo.Context) error {
+	did, err := requireCaller(c)
+	if did == "" {
+		return err
+	}
+
+	list, err := h.series.ListSeriesByOwner(c.Request().Context(), did)
+	if err != nil {
+		return InternalServerError(c, "Failed to list series", err)
+	}
+	if list == nil {
+		list = []*series.Series{}
+	}
+
+	return c.JSON(http.StatusOK, ListSeriesResponse{Series: list})
+}
+
+// CreateSeries handles POST /api/v1/series
+func (h *Handlers) CreateSeries(c echo.Context) error {
+	did, err := requireCaller(c)
+	if did == "" {
+		return err
+	}
+
+	var req CreateSeriesRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+
+	s, err := h.createSeries(c.Request().Context(), req.Slug, req.Title, did)
+	if err != nil {
+		return seriesErrorJSON(c, err, "create series")
+	}
+
+	return c.JSON(http.StatusCreated, s)
+}
+
+// GetSeries handles GET /api/v1/series/:series
+// Returns a series with its surveys, in wave order
+func (h *Handlers) GetSeries(c echo.Context) error {
+	s, did, err := h.seriesForRequest(c)
+	if s == nil {
+		return err
+	}
+
+	surveys, err := h.seriesSurveys(c.Request().Context(), s, did)
+	if err != nil {
+		return InternalServerError(c, "Failed to list surveys", err)
+	}
+
+	resp := SeriesResponse{Series: s, Surveys: make([]SeriesSurvey, 0, len(surveys))}
+	for _, survey := range surveys {
+		resp.Surveys = append(resp.Surveys, SeriesSurvey{Slug: survey.Slug, Title: survey.Title, Anonymous: survey.Definition.Anonymous})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeleteSeries handles DELETE /api/v1/series/:series
+// Deletes a series; its surveys are kept
+func (h *Handlers) DeleteSeries(c echo.Context) error {
+	s, _, err := h.seriesForRequest(c)
+	if s == nil {
+		return err
+	}
+
+	if err := h.series.DeleteSeries(c.Request().Context(), s.ID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return InternalServerError(c, "Failed to delete series", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AddSeriesSurvey handles POST /api/v1/series/:series/surveys
+// Adds a survey the caller manages as the last wave of the series
+func (h *Handlers) AddSeriesSurvey(c echo.Context) error {
+	s, did, err := h.seriesForRequest(c)
+	if s == nil {
+		return err
+	}
+
+	var req AddSeriesSurveyRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+
+	survey, err := h.addSeriesSurvey(c.Request().Context(), s, did, req.Survey)
+	if err != nil {
+		return seriesErrorJSON(c, err, "add survey")
+	}
+
+	return c.JSON(http.StatusCreated, SeriesSurvey{Slug: survey.Slug, Title: survey.Title, Anonymous: survey.Definition.Anonymous})
+}
+
+// RemoveSeriesSurvey handles DELETE /api/v1/series/:series/surveys/:slug
+func (h *Handlers) RemoveSeriesSurvey(c echo.Context) error {
+	s, _, err := h.seriesForRequest(c)
+	if s == nil {
+		return err
+	}
+
+	if err := h.removeSeriesSurvey(c.Request().Context(), s, c.Param("slug")); err != nil {
+		return seriesErrorJSON(c, err, "remove survey")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetSeriesResults handles GET /api/v1/series/:series/results
+// Returns the combined results of the series' surveys, with the answer
+// changes of voters between consecutive waves
+func (h *Handlers) GetSeriesResults(c echo.Context) error {
+	s, did, err := h.seriesForRequest(c)
+	if s == nil {
+		return err
+	}
+
+	waves, err := h.seriesWaves(c.Request().Context(), s, did)
+	if err != nil {
+		return InternalServerError(c, "Failed to combine results", err)
+	}
+
+	return c.JSON(http.StatusOK, SeriesResultsResponse{Series: s, Results: series.Combine(waves)})
+}
+
+// ListSeriesRespondents handles GET /api/v1/series/:series/respondents?minWaves=&limit=&offset=
+// Lists the logged-in voters of the series with their response to each wave,
+// those who answered the most waves first. minWaves (default 2) leaves out
+// voters who answered fewer waves.
+func (h *Handlers) ListSeriesRespondents(c echo.Context) error {
+	s, did, err := h.seriesForRequest(c)
+	if s == nil {
+		return err
+	}
+
+	minWaves, limit, offset := 2, defaultRespondentsPageSize, 0
+	if m, err := strconv.Atoi(c.QueryParam("minWaves")); err == nil && m > 0 {
+		minWaves = m
+	}
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+		limit = min(l, maxRespondentsPageSize)
+	}
+	if o, err := strconv.Atoi(c.QueryParam("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	waves, err := h.seriesWaves(c.Request().Context(), s, did)
+	if err != nil {
+		return InternalServerError(c, "Failed to list respondents", err)
+	}
+
+	// Respondents come sorted by waves answered, so those matching are a prefix
+	respondents := series.Track(waves)
+	total := 0
+	for total < len(respondents) && respondents[total].Answered() >= minWaves {
+		total++
+	}
+
+	page := SeriesRespondentsPage{
+		Waves:       make([]string, len(waves)),
+		Total:       total,
+		Limit:       limit,
+		Offset:      offset,
+		Respondents: respondents[min(offset, total):min(offset+limit, total)],
+	}
+	for i, w := range waves {
+		page.Waves[i] = w.Survey.Slug
+	}
+	return c.JSON(http.StatusOK, page)
+}
+
+// SeriesPageHTML shows the combined results of a series to its owner
+// GET /series/:series
+func (h *Handlers) SeriesPageHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+
+	ctx := c.Request().Context()
+	s, err := h.loadSeries(ctx, c.Param("series"), user.DID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Series not found")
+		}
+		c.Logger().Errorf("Failed to load series: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load series")
+	}
+	waves, err := h.seriesWaves(ctx, s, user.DID)
+	if err != nil {
+		c.Logger().Errorf("Failed to combine series results: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load series results")
+	}
+
+	_, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.SeriesPage(s, series.Combine(waves), user, profile, h.posthogKey)
+	return component.Render(ctx, c.Response().Writer)
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/series"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSeriesStore keeps series in memory
+type mockSeriesStore struct {
+	series  []*series.Series
+	surveys map[uuid.UUID][]uuid.UUID
+}
+
+func (m *mockSeriesStore) CreateSeries(ctx context.Context, s *series.Series) error {
+	if _, err := m.GetSeriesBySlug(ctx, s.Slug); err == nil {
+		return series.ErrSlugTaken
+	}
+	m.series = append(m.series, s)
+	return nil
+}
+
+func (m *mockSeriesStore) GetSeriesBySlug(ctx context.Context, slug string) (*series.Series, error) {
+	for _, s := range m.series {
+		if s.Slug == slug {
+			return s, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockSeriesStore) ListSeriesByOwner(ctx context.Context, did string) ([]*series.Series, error) {
+	var list []*series.Series
+	for i := len(m.series) - 1; i >= 0; i-- {
+		if m.series[i].OwnerDID == did {
+			list = append(list, m.series[i])
+		}
+	}
+	return list, nil
+}
+
+func (m *mockSeriesStore) DeleteSeries(ctx context.Context, id uuid.UUID) error {
+	for i, s := range m.series {
+		if s.ID == id {
+			m.series = append(m.series[:i], m.series[i+1:]...)
+			delete(m.surveys, id)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *mockSeriesStore) AddSeriesSurvey(ctx context.Context, seriesID, surveyID uuid.UUID, now time.Time) error {
+	for _, ids := range m.surveys {
+		for _, id := range ids {
+			if id == surveyID {
+				return series.ErrAlreadyInSeries
+			}
+		}
+	}
+	m.surveys[seriesID] = append(m.surveys[seriesID], surveyID)
+	return nil
+}
+
+func (m *mockSeriesStore) RemoveSeriesSurvey(ctx context.Context, seriesID, surveyID uuid.UUID) error {
+	for i, id := range m.surveys[seriesID] {
+		if id == surveyID {
+			m.surveys[seriesID] = append(m.surveys[seriesID][:i], m.surveys[seriesID][i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *mockSeriesStore) ListSeriesSurveyIDs(ctx context.Context, seriesID uuid.UUID) ([]uuid.UUID, error) {
+	return m.surveys[seriesID], nil
+}
+
+// createMoodSurvey creates a survey of an author asking for a mood, answered
+// by logged-in voters
+func createMoodSurvey(t *testing.T, mq *MockQueries, slug, author string, moods map[string]string) *models.Survey {
+	t.Helper()
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		Slug:      slug,
+		Title:     "Pulse " + slug,
+		AuthorDID: &author,
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "mood", Text: "How are you?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "good", Text: "Good"}, {ID: "bad", Text: "Bad"}}},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, mq.CreateSurvey(context.Background(), survey))
+	for did, mood := range moods {
+		voter := did
+		require.NoError(t, mq.CreateResponse(context.Background(), &models.Response{
+			ID:        uuid.New(),
+			SurveyID:  survey.ID,
+			VoterDID:  &voter,
+			Answers:   map[string]models.Answer{"mood": {SelectedOptions: []string{mood}}},
+			CreatedAt: time.Now(),
+		}))
+	}
+	return survey
+}
+
+func TestSeries(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetSeries(&mockSeriesStore{surveys: make(map[uuid.UUID][]uuid.UUID)})
+	alice, bob := "did:plc:alice", "did:plc:bob"
+
+	createMoodSurvey(t, mq, "pulse-q1", alice, map[string]string{"did:plc:v1": "good", "did:plc:v2": "bad"})
+	createMoodSurvey(t, mq, "pulse-q2", alice, map[string]string{"did:plc:v1": "bad", "did:plc:v2": "bad", "did:plc:v3": "good"})
+	createMoodSurvey(t, mq, "bobs-survey", bob, nil)
+
+	rec := callOrgAPI(t, e, h.CreateSeries, http.MethodPost, `{"slug": "pulse", "title": "Team pulse"}`, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = callOrgAPI(t, e, h.CreateSeries, http.MethodPost, `{"slug": "pulse", "title": "Team pulse"}`, alice)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec = callOrgAPI(t, e, h.CreateSeries, http.MethodPost, `{"slug": "pulse", "title": "Other"}`, bob)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "slugs are unique")
+
+	t.Run("owners add the surveys they manage", func(t *testing.T) {
+		for _, slug := range []string{"pulse-q1", "pulse-q2"} {
+			rec := callOrgAPI(t, e, h.AddSeriesSurvey, http.MethodPost, `{"survey": "`+slug+`"}`, alice, "series", "pulse")
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		rec := callOrgAPI(t, e, h.AddSeriesSurvey, http.MethodPost, `{"survey": "pulse-q1"}`, alice, "series", "pulse")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "a survey is in one series")
+		rec = callOrgAPI(t, e, h.AddSeriesSurvey, http.MethodPost, `{"survey": "bobs-survey"}`, alice, "series", "pulse")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec = callOrgAPI(t, e, h.AddSeriesSurvey, http.MethodPost, `{"survey": "nothing"}`, alice, "series", "pulse")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = callOrgAPI(t, e, h.GetSeries, http.MethodGet, "", alice, "series", "pulse")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp SeriesResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Surveys, 2)
+		assert.Equal(t, "pulse-q1", resp.Surveys[0].Slug)
+	})
+
+	t.Run("series are hidden from others", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.GetSeriesResults, http.MethodGet, "", bob, "series", "pulse")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		rec = callOrgAPI(t, e, h.AddSeriesSurvey, http.MethodPost, `{"survey": "bobs-survey"}`, bob, "series", "pulse")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("combined results", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.GetSeriesResults, http.MethodGet, "", alice, "series", "pulse")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp SeriesResultsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		results := resp.Results
+		assert.Equal(t, 5, results.TotalVotes)
+		assert.Equal(t, 3, results.Respondents, "voters of both waves count once")
+		assert.Equal(t, 2, results.Returning)
+		require.Len(t, results.Questions, 1)
+		assert.Equal(t, map[string]int{"good": 2, "bad": 3}, results.Questions[0].OptionCounts)
+		require.Len(t, results.Transitions, 1)
+		assert.Equal(t, 2, results.Transitions[0].Voters)
+		assert.Equal(t, 1, results.Transitions[0].Changed)
+	})
+
+	t.Run("respondents across waves", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.ListSeriesRespondents, http.MethodGet, "", alice, "series", "pulse")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var page SeriesRespondentsPage
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		assert.Equal(t, []string{"pulse-q1", "pulse-q2"}, page.Waves)
+		assert.Equal(t, 2, page.Total, "only returning voters by default")
+		require.Len(t, page.Respondents, 2)
+		v1 := page.Respondents[0]
+		assert.Equal(t, "did:plc:v1", v1.DID)
+		assert.Equal(t, []string{"good"}, v1.Waves[0].Answers["mood"].SelectedOptions)
+		assert.Equal(t, []string{"bad"}, v1.Waves[1].Answers["mood"].SelectedOptions)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/series/pulse/respondents?minWaves=1&limit=1&offset=2", nil)
+		rec = httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("series")
+		c.SetParamValues("pulse")
+		c.Set("user", &oauth.User{DID: alice})
+		require.NoError(t, h.ListSeriesRespondents(c))
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		assert.Equal(t, 3, page.Total)
+		require.Len(t, page.Respondents, 1)
+		assert.Equal(t, "did:plc:v3", page.Respondents[0].DID)
+		assert.Nil(t, page.Respondents[0].Waves[0])
+	})
+
+	t.Run("series page", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/series/pulse", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("series")
+		c.SetParamValues("pulse")
+		c.Set("user", &oauth.User{DID: alice})
+		require.NoError(t, h.SeriesPageHTML(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Team pulse")
+		assert.Contains(t, rec.Body.String(), "1 of 2 voters changed their answer")
+	})
+
+	t.Run("removing surveys and series", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.RemoveSeriesSurvey, http.MethodDelete, "", alice, "series", "slug", "pulse", "pulse-q1")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		rec = callOrgAPI(t, e, h.RemoveSeriesSurvey, http.MethodDelete, "", alice, "series", "slug", "pulse", "pulse-q1")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = callOrgAPI(t, e, h.DeleteSeries, http.MethodDelete, "", alice, "series", "pulse")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		rec = callOrgAPI(t, e, h.ListSeries, http.MethodGet, "", alice)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var list ListSeriesResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+		assert.Empty(t, list.Series)
+	})
+}
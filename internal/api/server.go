@@ -0,0 +1,127 @@
+package api
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Default HTTP server timeouts
+const (
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultWriteTimeout      = 2 * time.Minute // AI generation and large exports take a while
+	DefaultIdleTimeout       = 2 * time.Minute
+
+	// DefaultSlowRequestThreshold is how long a request takes before it is logged as slow
+	DefaultSlowRequestThreshold = time.Second
+)
+
+// ServerConfig holds the timeouts of the HTTP server and when requests are
+// logged as slow
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration // Reading the whole request, body included
+	WriteTimeout      time.Duration // From the end of the request headers to the end of the response
+	IdleTimeout       time.Duration // Keep-alive connections between requests
+
+	SlowRequestThreshold time.Duration            // 0 turns slow request logging off
+	SlowRouteThresholds  map[string]time.Duration // Thresholds of routes that are expected to be slow, by route pattern
+}
+
+// DefaultServerConfig returns the default timeouts, and logs requests slower
+// than a second, or than 30 seconds for AI generation
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		ReadHeaderTimeout:    DefaultReadHeaderTimeout,
+		ReadTimeout:          DefaultReadTimeout,
+		WriteTimeout:         DefaultWriteTimeout,
+		IdleTimeout:          DefaultIdleTimeout,
+		SlowRequestThreshold: DefaultSlowRequestThreshold,
+		SlowRouteThresholds: map[string]time.Duration{
+			"/api/v1/surveys/generate": 30 * time.Second,
+		},
+	}
+}
+
+// ServerConfigFromEnv creates a ServerConfig from environment variables
+// Environment variables:
+//   - HTTP_READ_HEADER_TIMEOUT: e.g. "5s" (default: 10s)
+//   - HTTP_READ_TIMEOUT: default 30s
+//   - HTTP_WRITE_TIMEOUT: default 2m
+//   - HTTP_IDLE_TIMEOUT: default 2m
+//   - SLOW_REQUEST_THRESHOLD: default 1s, 0 to turn slow request logging off
+//   - SLOW_REQUEST_ROUTE_THRESHOLDS: comma-separated route=duration pairs, e.g.
+//     "/api/v1/surveys/:slug/exports=5s", added to the defaults
+func ServerConfigFromEnv() ServerConfig {
+	config := DefaultServerConfig()
+	config.ReadHeaderTimeout = durationFromEnv("HTTP_READ_HEADER_TIMEOUT", config.ReadHeaderTimeout)
+	config.ReadTimeout = durationFromEnv("HTTP_READ_TIMEOUT", config.ReadTimeout)
+	config.WriteTimeout = durationFromEnv("HTTP_WRITE_TIMEOUT", config.WriteTimeout)
+	config.IdleTimeout = durationFromEnv("HTTP_IDLE_TIMEOUT", config.IdleTimeout)
+	if v := os.Getenv("SLOW_REQUEST_THRESHOLD"); v == "0" {
+		config.SlowRequestThreshold = 0
+	} else {
+		config.SlowRequestThreshold = durationFromEnv("SLOW_REQUEST_THRESHOLD", config.SlowRequestThreshold)
+	}
+	for _, pair := range splitList(os.Getenv("SLOW_REQUEST_ROUTE_THRESHOLDS")) {
+		route, v, _ := strings.Cut(pair, "=")
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d <= 0 {
+			log.Printf("Warning: Invalid SLOW_REQUEST_ROUTE_THRESHOLDS entry %q, ignoring it", pair)
+			continue
+		}
+		config.SlowRouteThresholds[strings.TrimSpace(route)] = d
+	}
+	return config
+}
+
+// durationFromEnv parses a positive duration, falling back to def
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: Invalid %s %q, using %s", name, v, def)
+		return def
+	}
+	return d
+}
+
+// Apply sets the timeouts of the Echo server and logs slow requests
+func (s ServerConfig) Apply(e *echo.Echo) {
+	e.Server.ReadHeaderTimeout = s.ReadHeaderTimeout
+	e.Server.ReadTimeout = s.ReadTimeout
+	e.Server.WriteTimeout = s.WriteTimeout
+	e.Server.IdleTimeout = s.IdleTimeout
+	if s.SlowRequestThreshold > 0 {
+		e.Use(SlowRequestMiddleware(s.SlowRequestThreshold, s.SlowRouteThresholds))
+	}
+}
+
+// SlowRequestMiddleware logs requests that take longer than the threshold of
+// their route, or the default threshold
+func SlowRequestMiddleware(threshold time.Duration, routes map[string]time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			limit := threshold
+			if d, ok := routes[c.Path()]; ok {
+				limit = d
+			}
+			if took := time.Since(start); took > limit {
+				rid, _ := c.Get("request_id").(string)
+				log.Printf("Slow request: %s %s (route %s) took %s, over %s (status %d, request %s)",
+					c.Request().Method, c.Request().URL.Path, c.Path(), took.Round(time.Millisecond), limit, c.Response().Status, rid)
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerConfigFromEnv(t *testing.T) {
+	for _, name := range []string{"HTTP_READ_HEADER_TIMEOUT", "HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT", "HTTP_IDLE_TIMEOUT", "SLOW_REQUEST_THRESHOLD", "SLOW_REQUEST_ROUTE_THRESHOLDS"} {
+		t.Setenv(name, "")
+	}
+	assert.Equal(t, DefaultServerConfig(), ServerConfigFromEnv())
+
+	t.Setenv("HTTP_READ_TIMEOUT", "5s")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "-1s")
+	t.Setenv("SLOW_REQUEST_THRESHOLD", "500ms")
+	t.Setenv("SLOW_REQUEST_ROUTE_THRESHOLDS", "/api/v1/surveys/:slug/exports=5s, /broken=soon")
+	config := ServerConfigFromEnv()
+	assert.Equal(t, 5*time.Second, config.ReadTimeout)
+	assert.Equal(t, DefaultWriteTimeout, config.WriteTimeout, "invalid values keep the default")
+	assert.Equal(t, 500*time.Millisecond, config.SlowRequestThreshold)
+	assert.Equal(t, map[string]time.Duration{
+		"/api/v1/surveys/generate":      30 * time.Second,
+		"/api/v1/surveys/:slug/exports": 5 * time.Second,
+	}, config.SlowRouteThresholds)
+
+	t.Setenv("SLOW_REQUEST_THRESHOLD", "0")
+	assert.Zero(t, ServerConfigFromEnv().SlowRequestThreshold)
+}
+
+func TestServerConfigApply(t *testing.T) {
+	e := echo.New()
+	config := DefaultServerConfig()
+	config.Apply(e)
+	assert.Equal(t, DefaultReadHeaderTimeout, e.Server.ReadHeaderTimeout)
+	assert.Equal(t, DefaultReadTimeout, e.Server.ReadTimeout)
+	assert.Equal(t, DefaultWriteTimeout, e.Server.WriteTimeout)
+	assert.Equal(t, DefaultIdleTimeout, e.Server.IdleTimeout)
+}
+
+func TestSlowRequestMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logs)
+
+	e := echo.New()
+	e.Use(RequestIDMiddleware())
+	e.Use(SlowRequestMiddleware(10*time.Millisecond, map[string]time.Duration{"/generate": time.Minute}))
+	slow := func(c echo.Context) error {
+		time.Sleep(20 * time.Millisecond)
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/fast", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/slow/:id", slow)
+	e.GET("/generate", slow)
+
+	for _, path := range []string{"/fast", "/generate"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Empty(t, logs.String(), "requests within their threshold are not logged")
+
+	req := httptest.NewRequest(http.MethodGet, "/slow/1", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Contains(t, logs.String(), "Slow request: GET /slow/1 (route /slow/:id)")
+	assert.Contains(t, logs.String(), "over 10ms (status 200, request req-1)")
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// SessionsHTML lists the logged-in user's active sessions
+func (h *Handlers) SessionsHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+
+	sessions, err := h.sessions.ListSessionsByDID(c.Request().Context(), user.DID)
+	if err != nil {
+		c.Logger().Errorf("Failed to list sessions: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load sessions")
+	}
+
+	currentID, _ := oauth.SessionCookieValue(c)
+	_, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.SessionsPage(sessions, currentID, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
+
+// RevokeSessionHTML logs one of the user's sessions out, identified by its
+// handle. Revoking the current session logs the user out.
+func (h *Handlers) RevokeSessionHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+
+	ctx := c.Request().Context()
+	sessions, err := h.sessions.ListSessionsByDID(ctx, user.DID)
+	if err != nil {
+		c.Logger().Errorf("Failed to list sessions: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to revoke session")
+	}
+
+	handle := c.Param("handle")
+	for _, session := range sessions {
+		if oauth.SessionHandle(session.ID) != handle {
+			continue
+		}
+		if err := h.sessions.DeleteSessionByDID(ctx, user.DID, session.ID); err != nil {
+			c.Logger().Errorf("Failed to revoke session: %v", err)
+			return c.String(http.StatusInternalServerError, "Failed to revoke session")
+		}
+		if currentID, _ := oauth.SessionCookieValue(c); currentID == session.ID {
+			oauth.ClearSessionCookie(c)
+			return c.Redirect(http.StatusSeeOther, templates.AppPath("/"))
+		}
+		return c.Redirect(http.StatusSeeOther, templates.AppPath("/settings/sessions"))
+	}
+
+	return c.String(http.StatusNotFound, "Session not found")
+}
+
+// RevokeAllSessionsHTML logs the user out everywhere, including here
+func (h *Handlers) RevokeAllSessionsHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+
+	if _, err := h.sessions.DeleteSessionsByDID(c.Request().Context(), user.DID); err != nil {
+		c.Logger().Errorf("Failed to revoke sessions: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to log out everywhere")
+	}
+
+	oauth.ClearSessionCookie(c)
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/"))
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSessions stores login sessions in memory
+type mockSessions struct {
+	sessions []oauth.OAuthSession
+}
+
+func (m *mockSessions) ListSessionsByDID(ctx context.Context, did string) ([]oauth.OAuthSession, error) {
+	var sessions []oauth.OAuthSession
+	for _, s := range m.sessions {
+		if s.DID == did {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *mockSessions) DeleteSessionByDID(ctx context.Context, did, id string) error {
+	for i, s := range m.sessions {
+		if s.DID == did && s.ID == id {
+			m.sessions = append(m.sessions[:i], m.sessions[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *mockSessions) DeleteSessionsByDID(ctx context.Context, did string) (int64, error) {
+	var kept []oauth.OAuthSession
+	for _, s := range m.sessions {
+		if s.DID != did {
+			kept = append(kept, s)
+		}
+	}
+	count := int64(len(m.sessions) - len(kept))
+	m.sessions = kept
+	return count, nil
+}
+
+func setupSessionsTest() (*echo.Echo, *Handlers, *mockSessions) {
+	e, _, h := setupTest()
+	lastUsed := time.Now()
+	store := &mockSessions{sessions: []oauth.OAuthSession{
+		{ID: "laptop-secret", DID: "did:plc:alice", UserAgent: "Firefox on Linux", LastUsedAt: &lastUsed, CreatedAt: time.Now()},
+		{ID: "phone-secret", DID: "did:plc:alice", UserAgent: "Safari on iOS", CreatedAt: time.Now()},
+		{ID: "bob-secret", DID: "did:plc:bob", UserAgent: "Chrome", CreatedAt: time.Now()},
+	}}
+	h.SetSessions(store)
+	return e, h, store
+}
+
+// sessionsRequest makes a request from alice's laptop session
+func sessionsRequest(e *echo.Echo, method, path string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, path, nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "laptop-secret"})
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user", &oauth.User{DID: "did:plc:alice"})
+	return c, rec
+}
+
+func TestSessionsHTML(t *testing.T) {
+	e, h, _ := setupSessionsTest()
+
+	c, rec := sessionsRequest(e, http.MethodGet, "/settings/sessions")
+	require.NoError(t, h.SessionsHTML(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "Firefox on Linux")
+	assert.Contains(t, body, "(this browser)")
+	assert.Contains(t, body, "Safari on iOS")
+	assert.NotContains(t, body, "Chrome", "other users' sessions are not listed")
+	assert.NotContains(t, body, "phone-secret", "session IDs are not revealed")
+	assert.Contains(t, body, "/settings/sessions/"+oauth.SessionHandle("phone-secret")+"/revoke")
+}
+
+func TestSessionsHTML_RequiresLogin(t *testing.T) {
+	e, h, _ := setupSessionsTest()
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/settings/sessions", nil), rec)
+	require.NoError(t, h.SessionsHTML(c))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRevokeSessionHTML(t *testing.T) {
+	t.Run("another session", func(t *testing.T) {
+		e, h, store := setupSessionsTest()
+		c, rec := sessionsRequest(e, http.MethodPost, "/")
+		c.SetParamNames("handle")
+		c.SetParamValues(oauth.SessionHandle("phone-secret"))
+
+		require.NoError(t, h.RevokeSessionHTML(c))
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, "/settings/sessions", rec.Header().Get("Location"))
+		assert.Len(t, store.sessions, 2)
+		assert.Empty(t, rec.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("this session logs out", func(t *testing.T) {
+		e, h, store := setupSessionsTest()
+		c, rec := sessionsRequest(e, http.MethodPost, "/")
+		c.SetParamNames("handle")
+		c.SetParamValues(oauth.SessionHandle("laptop-secret"))
+
+		require.NoError(t, h.RevokeSessionHTML(c))
+		assert.Equal(t, "/", rec.Header().Get("Location"))
+		assert.Len(t, store.sessions, 2)
+		assert.Contains(t, rec.Header().Get("Set-Cookie"), "Max-Age=0")
+	})
+
+	t.Run("other users' sessions are not found", func(t *testing.T) {
+		e, h, store := setupSessionsTest()
+		c, rec := sessionsRequest(e, http.MethodPost, "/")
+		c.SetParamNames("handle")
+		c.SetParamValues(oauth.SessionHandle("bob-secret"))
+
+		require.NoError(t, h.RevokeSessionHTML(c))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Len(t, store.sessions, 3)
+	})
+}
+
+func TestRevokeAllSessionsHTML(t *testing.T) {
+	e, h, store := setupSessionsTest()
+	c, rec := sessionsRequest(e, http.MethodPost, "/settings/sessions/revoke-all")
+
+	require.NoError(t, h.RevokeAllSessionsHTML(c))
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	require.Len(t, store.sessions, 1)
+	assert.Equal(t, "did:plc:bob", store.sessions[0].DID)
+	assert.Contains(t, rec.Header().Get("Set-Cookie"), "Max-Age=0")
+}
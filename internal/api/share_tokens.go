@@ -0,0 +1,338 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/invite"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/sharetoken"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// shareTokenHeader carries the share token of JSON API requests
+const shareTokenHeader = "X-Share-Token"
+
+// privateSurveyMessage explains why a survey with token or invite visibility cannot be seen
+const privateSurveyMessage = "This survey is private. Open it with the link its author shared with you."
+
+// SetShareTokens enables share tokens, which open surveys with token
+// visibility. Without them, such surveys are only shown to their author and admins.
+func (h *Handlers) SetShareTokens(store sharetoken.Store) {
+	h.shareTokens = store
+}
+
+// shareCookieName is the cookie keeping a visitor's share token of a survey,
+// so the form, results, and polling of the page work after the first visit
+func shareCookieName(slug string) string {
+	return "survey_share_" + slug
+}
+
+// canViewSurvey reports whether the caller may see a survey. Surveys with
+// token visibility need a valid share token, and surveys with invite
+// visibility an invite token, sent in the ?token= query parameter, the
+// X-Share-Token header, or the cookie set by a valid ?token=; their author and
+// admins need none.
+func (h *Handlers) canViewSurvey(c echo.Context, survey *models.Survey) bool {
+	if !survey.Definition.IsPrivate() {
+		return true
+	}
+	if did, ok := callerDID(c); ok && h.canReadSurveyAs(c.Request().Context(), did, survey) {
+		return true
+	}
+
+	token, fromQuery := accessToken(c, survey)
+	if token == "" {
+		return false
+	}
+
+	valid, err := h.validAccessToken(c, survey, token)
+	if err != nil {
+		c.Logger().Errorf("Failed to check access token of survey %s: %v", survey.Slug, err)
+		return false
+	}
+	if valid && fromQuery {
+		c.SetCookie(&http.Cookie{
+			Name:     shareCookieName(survey.Slug),
+			Value:    token,
+			Path:     templates.AppPath("/surveys/" + survey.Slug),
+			HttpOnly: true,
+			Secure:   c.Scheme() == "https",
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return valid
+}
+
+// accessToken returns the share or invite token of a request for a survey,
+// and whether it came from the ?token= query parameter
+func accessToken(c echo.Context, survey *models.Survey) (string, bool) {
+	if token := c.QueryParam("token"); token != "" {
+		return token, true
+	}
+	if token := c.Request().Header.Get(shareTokenHeader); token != "" {
+		return token, false
+	}
+	if cookie, err := c.Cookie(shareCookieName(survey.Slug)); err == nil {
+		return cookie.Value, false
+	}
+	return "", false
+}
+
+// validAccessToken reports whether a token opens a private survey: an active
+// share token of a survey with token visibility, or an invite token, used or
+// not, of a survey with invite visibility
+func (h *Handlers) validAccessToken(c echo.Context, survey *models.Survey, token string) (bool, error) {
+	ctx := c.Request().Context()
+	switch survey.Definition.Visibility {
+	case models.VisibilityToken:
+		if h.shareTokens == nil {
+			return false, nil
+		}
+		return h.shareTokens.ValidShareToken(ctx, survey.ID, sharetoken.Hash(token))
+	case models.VisibilityInvite:
+		if h.invites == nil {
+			return false, nil
+		}
+		_, err := h.invites.GetInviteByHash(ctx, survey.ID, invite.Hash(token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return false, nil
+}
+
+// withShareToken keeps the share token of a request in a link it redirects to
+func withShareToken(c echo.Context, path string) string {
+	if token := c.QueryParam("token"); token != "" {
+		return path + "?token=" + url.QueryEscape(token)
+	}
+	return path
+}
+
+// surveyPrivateJSON responds to an API request for a survey the caller may not see
+func surveyPrivateJSON(c echo.Context) error {
+	return Problem(c, problem.SurveyPrivate, "Send a share or invite token of the survey in the ?token= query parameter or the "+shareTokenHeader+" header")
+}
+
+// shareLink returns the link opening a survey with a share token
+func shareLink(survey *models.Survey, token string) string {
+	return templates.AbsoluteURL("/surveys/" + survey.Slug + "?token=" + url.QueryEscape(token))
+}
+
+// manageableSurvey loads the survey of a JSON API request and checks that the
+// caller manages it. On failure it returns nil and the error response written.
+func (h *Handlers) manageableSurvey(c echo.Context) (*models.Survey, string, error) {
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", Problem(c, problem.SurveyNotFound, fmt.Sprintf("No survey found with slug '%s'", slug))
+		}
+		return nil, "", InternalServerError(c, "Failed to retrieve survey", err)
+	}
+
+	caller, ok := callerDID(c)
+	if !ok {
+		return nil, "", Problem(c, problem.AuthenticationRequired, "Log in or use an API key of the survey author")
+	}
+	if !h.canManageSurveyAs(c.Request().Context(), caller, survey) {
+		return nil, "", Problem(c, problem.Forbidden, "Only the survey author can manage this survey")
+	}
+	return survey, caller, nil
+}
+
+// createShareToken creates a share token of a survey, if it has room for one
+func (h *Handlers) createShareToken(c echo.Context, survey *models.Survey, label, createdBy string) (*sharetoken.Token, string, error) {
+	ctx := c.Request().Context()
+	count, err := h.shareTokens.CountActiveShareTokens(ctx, survey.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= sharetoken.MaxTokensPerSurvey {
+		return nil, "", fmt.Errorf("%w: a survey can have at most %d active share tokens", errTooManyShareTokens, sharetoken.MaxTokensPerSurvey)
+	}
+
+	st, token, err := sharetoken.New(survey.ID, label, createdBy)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", errInvalidShareToken, err)
+	}
+	if err := h.shareTokens.CreateShareToken(ctx, st); err != nil {
+		return nil, "", err
+	}
+	return st, token, nil
+}
+
+// Errors of createShareToken caused by the request
+var (
+	errTooManyShareTokens = errors.New("too many share tokens")
+	errInvalidShareToken  = errors.New("invalid share token")
+)
+
+// CreateShareToken handles POST /api/v1/surveys/:slug/share-tokens
+// Creates a share token; the token and its link are only returned now
+func (h *Handlers) CreateShareToken(c echo.Context) error {
+	survey, caller, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	var req CreateShareTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+
+	st, token, err := h.createShareToken(c, survey, req.Label, caller)
+	if err != nil {
+		if errors.Is(err, errTooManyShareTokens) || errors.Is(err, errInvalidShareToken) {
+			return ValidationError(c, "Invalid share token", err.Error())
+		}
+		return InternalServerError(c, "Failed to create share token", err)
+	}
+
+	return c.JSON(http.StatusCreated, CreateShareTokenResponse{
+		Token:  st,
+		Secret: token,
+		URL:    shareLink(survey, token),
+	})
+}
+
+// ListShareTokens handles GET /api/v1/surveys/:slug/share-tokens
+// Lists a survey's share tokens, including revoked ones, without the tokens themselves
+func (h *Handlers) ListShareTokens(c echo.Context) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	tokens, err := h.shareTokens.ListShareTokens(c.Request().Context(), survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list share tokens", err)
+	}
+	if tokens == nil {
+		tokens = []*sharetoken.Token{}
+	}
+
+	return c.JSON(http.StatusOK, ListShareTokensResponse{ShareTokens: tokens})
+}
+
+// RevokeShareToken handles DELETE /api/v1/surveys/:slug/share-tokens/:id
+// Links with a revoked token stop opening the survey, including for visitors
+// who already opened it
+func (h *Handlers) RevokeShareToken(c echo.Context) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return ValidationError(c, "Invalid share token ID", err.Error())
+	}
+
+	if err := h.shareTokens.RevokeShareToken(c.Request().Context(), id, survey.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.NotFound, "Share token not found: The survey has no active share token with this ID")
+		}
+		return InternalServerError(c, "Failed to revoke share token", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ShareTokensPageHTML lists the share tokens of a survey for its author
+// GET /surveys/:slug/share-tokens
+func (h *Handlers) ShareTokensPageHTML(c echo.Context) error {
+	survey, _, err := h.manageableSurveyHTML(c)
+	if survey == nil {
+		return err
+	}
+	return h.renderShareTokensPage(c, survey, "", "")
+}
+
+// CreateShareTokenHTML creates a share token and shows its link, once
+// POST /surveys/:slug/share-tokens
+func (h *Handlers) CreateShareTokenHTML(c echo.Context) error {
+	survey, user, err := h.manageableSurveyHTML(c)
+	if survey == nil {
+		return err
+	}
+
+	_, token, err := h.createShareToken(c, survey, c.FormValue("label"), user.DID)
+	if err != nil {
+		if errors.Is(err, errTooManyShareTokens) || errors.Is(err, errInvalidShareToken) {
+			return h.renderShareTokensPage(c, survey, "", err.Error())
+		}
+		c.Logger().Errorf("Failed to create share token of survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to create share token")
+	}
+
+	return h.renderShareTokensPage(c, survey, shareLink(survey, token), "")
+}
+
+// RevokeShareTokenHTML revokes a share token
+// POST /surveys/:slug/share-tokens/:id/revoke
+func (h *Handlers) RevokeShareTokenHTML(c echo.Context) error {
+	survey, _, err := h.manageableSurveyHTML(c)
+	if survey == nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid share token ID")
+	}
+
+	if err := h.shareTokens.RevokeShareToken(c.Request().Context(), id, survey.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Share token not found")
+		}
+		c.Logger().Errorf("Failed to revoke share token %s: %v", id, err)
+		return c.String(http.StatusInternalServerError, "Failed to revoke share token")
+	}
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug+"/share-tokens"))
+}
+
+// manageableSurveyHTML is manageableSurvey for pages, which need a logged-in author
+func (h *Handlers) manageableSurveyHTML(c echo.Context) (*models.Survey, *oauth.User, error) {
+	survey, err := h.surveyBySlug(c.Request().Context(), c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, c.String(http.StatusNotFound, "Survey not found")
+		}
+		return nil, nil, c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user := oauth.GetUser(c)
+	if user == nil {
+		return nil, nil, c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canManageSurvey(c.Request().Context(), user, survey) {
+		return nil, nil, c.String(http.StatusForbidden, "Only the survey author can manage share links")
+	}
+	return survey, user, nil
+}
+
+// renderShareTokensPage renders the share links page, with the link of a
+// token just created or the error of a failed creation
+func (h *Handlers) renderShareTokensPage(c echo.Context, survey *models.Survey, newLink, formError string) error {
+	tokens, err := h.shareTokens.ListShareTokens(c.Request().Context(), survey.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to list share tokens: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load share links")
+	}
+
+	user, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.ShareTokensPage(survey, tokens, newLink, formError, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/sharetoken"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockShareTokenStore keeps share tokens in memory
+type mockShareTokenStore struct {
+	tokens []*sharetoken.Token
+}
+
+func (m *mockShareTokenStore) CreateShareToken(ctx context.Context, t *sharetoken.Token) error {
+	m.tokens = append(m.tokens, t)
+	return nil
+}
+
+func (m *mockShareTokenStore) ListShareTokens(ctx context.Context, surveyID uuid.UUID) ([]*sharetoken.Token, error) {
+	var tokens []*sharetoken.Token
+	for _, t := range m.tokens {
+		if t.SurveyID == surveyID {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *mockShareTokenStore) CountActiveShareTokens(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	count := 0
+	for _, t := range m.tokens {
+		if t.SurveyID == surveyID && t.RevokedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockShareTokenStore) ValidShareToken(ctx context.Context, surveyID uuid.UUID, hash string) (bool, error) {
+	for _, t := range m.tokens {
+		if t.SurveyID == surveyID && t.Hash == hash && t.RevokedAt == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockShareTokenStore) RevokeShareToken(ctx context.Context, id, surveyID uuid.UUID) error {
+	for _, t := range m.tokens {
+		if t.ID == id && t.SurveyID == surveyID && t.RevokedAt == nil {
+			now := time.Now()
+			t.RevokedAt = &now
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// setupShareTokenTest returns handlers with a private survey of did:plc:author
+// and a valid share token of it
+func setupShareTokenTest(t *testing.T) (*echo.Echo, *Handlers, *models.Survey, *mockShareTokenStore, string) {
+	t.Helper()
+	e, mq, h := setupTest()
+	store := &mockShareTokenStore{}
+	h.SetShareTokens(store)
+
+	author := "did:plc:author"
+	survey := createTextSurvey(mq, "private", &author)
+	survey.Definition.Visibility = models.VisibilityToken
+
+	st, token, err := sharetoken.New(survey.ID, "Board", author)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateShareToken(context.Background(), st))
+	return e, h, survey, store, token
+}
+
+func getPrivateSurvey(t *testing.T, e *echo.Echo, h *Handlers, target string, setup func(*http.Request), user *oauth.User) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if setup != nil {
+		setup(req)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("private")
+	if user != nil {
+		c.Set("user", user)
+	}
+	require.NoError(t, h.GetSurveyHTML(c))
+	return rec
+}
+
+func TestPrivateSurvey_HTML(t *testing.T) {
+	e, h, _, store, token := setupShareTokenTest(t)
+
+	t.Run("without a token", func(t *testing.T) {
+		rec := getPrivateSurvey(t, e, h, "/surveys/private", nil, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "private")
+	})
+
+	t.Run("with an invalid token", func(t *testing.T) {
+		rec := getPrivateSurvey(t, e, h, "/surveys/private?token=st_nope", nil, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	var cookie *http.Cookie
+	t.Run("with a valid token", func(t *testing.T) {
+		rec := getPrivateSurvey(t, e, h, "/surveys/private?token="+token, nil, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `name="robots" content="noindex`)
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		cookie = cookies[0]
+		assert.Equal(t, shareCookieName("private"), cookie.Name)
+		assert.Equal(t, "/surveys/private", cookie.Path)
+		assert.True(t, cookie.HttpOnly)
+	})
+
+	t.Run("with the cookie", func(t *testing.T) {
+		rec := getPrivateSurvey(t, e, h, "/surveys/private", func(r *http.Request) { r.AddCookie(cookie) }, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Result().Cookies(), "the cookie is only set from ?token=")
+	})
+
+	t.Run("as the author", func(t *testing.T) {
+		rec := getPrivateSurvey(t, e, h, "/surveys/private", nil, &oauth.User{DID: "did:plc:author"})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("after the token is revoked", func(t *testing.T) {
+		store.tokens[0].RevokedAt = new(time.Time)
+		rec := getPrivateSurvey(t, e, h, "/surveys/private", func(r *http.Request) { r.AddCookie(cookie) }, nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+func TestPrivateSurvey_JSON(t *testing.T) {
+	e, h, _, _, token := setupShareTokenTest(t)
+
+	get := func(header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/surveys/private", nil)
+		if header != "" {
+			req.Header.Set(shareTokenHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("private")
+		require.NoError(t, h.GetSurvey(c))
+		return rec
+	}
+
+	rec := get("")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	var resp problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, problem.SurveyPrivate, resp.Code)
+
+	assert.Equal(t, http.StatusOK, get(token).Code)
+
+	assert.Equal(t, http.StatusForbidden, submitText(t, e, h, "private", "hello").Code)
+}
+
+func TestUnlistedSurvey_OpenWithoutToken(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetShareTokens(&mockShareTokenStore{})
+	survey := createTextSurvey(mq, "private", nil)
+	survey.Definition.Visibility = models.VisibilityUnlisted
+
+	rec := getPrivateSurvey(t, e, h, "/surveys/private", nil, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `name="robots" content="noindex`)
+}
+
+func TestShortSlugURL_KeepsShareToken(t *testing.T) {
+	e, h, _, _, token := setupShareTokenTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/s/private?token="+token, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("private")
+	require.NoError(t, h.ShortSlugURL(c))
+
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/surveys/private?token="+token, rec.Header().Get("Location"))
+}
+
+func TestCreateSurveyPage_PrivateTemplate(t *testing.T) {
+	e, h, _, _, _ := setupShareTokenTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/surveys/new?template=private", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, h.CreateSurveyPageHTML(e.NewContext(req, rec)))
+	assert.NotContains(t, rec.Body.String(), "Comments?", "private surveys are not copied by strangers")
+}
+
+func TestShareTokenManagement(t *testing.T) {
+	e, h, survey, store, _ := setupShareTokenTest(t)
+	author := &oauth.User{DID: "did:plc:author"}
+
+	call := func(method, target string, body string, user *oauth.User, handler echo.HandlerFunc, params ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewReader([]byte(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames(append([]string{"slug"}, params[:len(params)/2]...)...)
+		c.SetParamValues(append([]string{"private"}, params[len(params)/2:]...)...)
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, handler(c))
+		return rec
+	}
+
+	t.Run("only the author manages tokens", func(t *testing.T) {
+		rec := call(http.MethodPost, "/api/v1/surveys/private/share-tokens", `{}`, nil, h.CreateShareToken)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		rec = call(http.MethodGet, "/api/v1/surveys/private/share-tokens", "", &oauth.User{DID: "did:plc:other"}, h.ListShareTokens)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	var created CreateShareTokenResponse
+	t.Run("create", func(t *testing.T) {
+		rec := call(http.MethodPost, "/api/v1/surveys/private/share-tokens", `{"label": "Staff"}`, author, h.CreateShareToken)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+		assert.Equal(t, "Staff", created.Label)
+		assert.True(t, strings.HasPrefix(created.Secret, sharetoken.TokenPrefix))
+		assert.Contains(t, created.URL, "/surveys/private?token="+created.Secret)
+		assert.NotContains(t, rec.Body.String(), store.tokens[1].Hash)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		rec := call(http.MethodGet, "/api/v1/surveys/private/share-tokens", "", author, h.ListShareTokens)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp ListShareTokensResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Len(t, resp.ShareTokens, 2)
+		assert.NotContains(t, rec.Body.String(), created.Secret, "tokens are only shown at creation")
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		target := "/api/v1/surveys/private/share-tokens/" + created.ID.String()
+		rec := call(http.MethodDelete, target, "", author, h.RevokeShareToken, "id", created.ID.String())
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		valid, _ := store.ValidShareToken(context.Background(), survey.ID, sharetoken.Hash(created.Secret))
+		assert.False(t, valid)
+
+		rec = call(http.MethodDelete, target, "", author, h.RevokeShareToken, "id", created.ID.String())
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/snapshot"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// errNoSession is returned when a snapshot cannot be published because the
+// account that opted in has no login session to write to its PDS with
+var errNoSession = errors.New("no login session")
+
+// SetSnapshots enables scheduled results snapshots. Call snapshot.StartWorker
+// with TakeSnapshot to take them.
+func (h *Handlers) SetSnapshots(store snapshot.Store) {
+	h.snapshots = store
+}
+
+// TakeSnapshot takes the results snapshot of a schedule's survey, keeping it
+// locally and publishing it to the PDS of the account that opted in. The
+// schedule ends after the snapshot of a closed survey, and when the account
+// no longer manages the survey.
+func (h *Handlers) TakeSnapshot(ctx context.Context, schedule *snapshot.Schedule, now time.Time) error {
+	survey, err := h.queries.GetSurveyByID(ctx, schedule.SurveyID)
+	if err != nil {
+		return fmt.Errorf("failed to load survey: %w", err)
+	}
+	if survey.URI == nil || survey.CID == nil || !h.canManageSurveyAs(ctx, schedule.DID, survey) {
+		return h.snapshots.DeleteSnapshotSchedule(ctx, survey.ID)
+	}
+
+	results, err := h.queries.GetSurveyResults(ctx, survey.ID)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate results: %w", err)
+	}
+	record, err := h.publishedResultsRecord(survey, results, now)
+	if err != nil {
+		return fmt.Errorf("failed to sign results: %w", err)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode results record: %w", err)
+	}
+
+	snap := &snapshot.Snapshot{
+		ID:         uuid.New(),
+		SurveyID:   survey.ID,
+		TotalVotes: results.TotalVotes,
+		Record:     data,
+		CreatedAt:  now,
+	}
+	uri, cid, publishErr := h.publishSnapshot(ctx, schedule.DID, record)
+	if publishErr == nil {
+		snap.URI, snap.CID = &uri, &cid
+	}
+	if err := h.snapshots.CreateSnapshot(ctx, snap); err != nil {
+		return err
+	}
+
+	if snap.Published() {
+		// The latest snapshot is the survey's published results
+		if err := h.queries.UpdateSurveyResults(ctx, survey.ID, uri, cid); err != nil {
+			return fmt.Errorf("failed to save results reference: %w", err)
+		}
+		h.cache.Invalidate(ctx, cache.SurveyKey(survey.Slug))
+	}
+	if survey.EndsAt != nil && !now.Before(*survey.EndsAt) {
+		if err := h.snapshots.DeleteSnapshotSchedule(ctx, survey.ID); err != nil {
+			return err
+		}
+	}
+
+	if publishErr != nil && !errors.Is(publishErr, errNoSession) {
+		return fmt.Errorf("snapshot kept locally, not published: %w", publishErr)
+	}
+	return nil
+}
+
+// publishSnapshot writes a results record to the PDS of did with its most
+// recently used session, returning errNoSession if it has none
+func (h *Handlers) publishSnapshot(ctx context.Context, did string, record map[string]interface{}) (string, string, error) {
+	session, err := h.latestSession(ctx, did)
+	if err != nil {
+		return "", "", err
+	}
+	return h.writeRecord(ctx, session, "net.openmeet.survey.results", oauth.GenerateTID(), record)
+}
+
+// latestSession returns the most recently used login session of did, to
+// write to its PDS without a request, or errNoSession if it has none
+func (h *Handlers) latestSession(ctx context.Context, did string) (*oauth.OAuthSession, error) {
+	if h.sessions == nil || h.oauthStorage == nil {
+		return nil, errNoSession
+	}
+	sessions, err := h.sessions.ListSessionsByDID(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, errNoSession
+	}
+
+	session, err := h.oauthStorage.GetSessionByID(ctx, sessions[0].ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	return session, nil
+}
+
+// SnapshotsPageHTML shows the results snapshot history of a survey, and lets
+// those managing it choose how often snapshots are taken
+// GET /surveys/:slug/snapshots
+func (h *Handlers) SnapshotsPageHTML(c echo.Context) error {
+	survey, user, err := h.snapshotsSurvey(c)
+	if survey == nil {
+		return err
+	}
+	return h.renderSnapshotsPage(c, survey, user, "")
+}
+
+// SetSnapshotScheduleHTML opts a survey into hourly or daily results
+// snapshots, published to the caller's PDS, or out with an empty frequency
+// POST /surveys/:slug/snapshots
+func (h *Handlers) SetSnapshotScheduleHTML(c echo.Context) error {
+	survey, user, err := h.snapshotsSurvey(c)
+	if survey == nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	if !h.canManageSurvey(ctx, user, survey) {
+		return c.String(http.StatusForbidden, "Only the survey author and editors of its organization can schedule snapshots")
+	}
+
+	value := c.FormValue("frequency")
+	if value == "" {
+		if err := h.snapshots.DeleteSnapshotSchedule(ctx, survey.ID); err != nil {
+			c.Logger().Errorf("Failed to delete snapshot schedule of survey %s: %v", survey.Slug, err)
+			return c.String(http.StatusInternalServerError, "Failed to save schedule")
+		}
+		return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug+"/snapshots"))
+	}
+
+	frequency, ok := snapshot.ParseFrequency(value)
+	if !ok {
+		return h.renderSnapshotsPage(c, survey, user, "Choose hourly or daily snapshots")
+	}
+	if survey.URI == nil || survey.CID == nil {
+		return h.renderSnapshotsPage(c, survey, user, "Snapshots are published as records of the survey, so local-only surveys cannot have them")
+	}
+
+	if err := h.snapshots.SaveSnapshotSchedule(ctx, snapshot.NewSchedule(survey.ID, frequency, user.DID, time.Now())); err != nil {
+		c.Logger().Errorf("Failed to save snapshot schedule of survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to save schedule")
+	}
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug+"/snapshots"))
+}
+
+// snapshotsSurvey loads the survey of a snapshots request and checks that the
+// caller may read its results history. On failure it returns nil and the
+// error response written.
+func (h *Handlers) snapshotsSurvey(c echo.Context) (*models.Survey, *oauth.User, error) {
+	ctx := c.Request().Context()
+	survey, err := h.surveyBySlug(ctx, c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, c.String(http.StatusNotFound, "Survey not found")
+		}
+		return nil, nil, c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	user := oauth.GetUser(c)
+	if user == nil {
+		return nil, nil, c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.canReadSurvey(ctx, user, survey) {
+		return nil, nil, c.String(http.StatusForbidden, "Only the survey author can view results snapshots")
+	}
+	return survey, user, nil
+}
+
+// renderSnapshotsPage renders the snapshots page, with the error of a failed schedule change
+func (h *Handlers) renderSnapshotsPage(c echo.Context, survey *models.Survey, user *oauth.User, formError string) error {
+	ctx := c.Request().Context()
+	schedule, err := h.snapshots.GetSnapshotSchedule(ctx, survey.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to load snapshot schedule of survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to load snapshots")
+	}
+	snapshots, err := h.snapshots.ListSnapshots(ctx, survey.ID, snapshot.HistoryLimit)
+	if err != nil {
+		c.Logger().Errorf("Failed to list snapshots of survey %s: %v", survey.Slug, err)
+		return c.String(http.StatusInternalServerError, "Failed to load snapshots")
+	}
+
+	_, profile := getUserAndProfile(c)
+	canManage := h.canManageSurvey(ctx, user, survey)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.SnapshotsPage(survey, schedule, snapshots, canManage, formError, user, profile, h.posthogKey)
+	return component.Render(ctx, c.Response().Writer)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSnapshotStore keeps schedules and snapshots in memory
+type mockSnapshotStore struct {
+	schedules map[uuid.UUID]*snapshot.Schedule
+	snapshots []*snapshot.Snapshot
+}
+
+func newMockSnapshotStore() *mockSnapshotStore {
+	return &mockSnapshotStore{schedules: make(map[uuid.UUID]*snapshot.Schedule)}
+}
+
+func (m *mockSnapshotStore) GetSnapshotSchedule(ctx context.Context, surveyID uuid.UUID) (*snapshot.Schedule, error) {
+	return m.schedules[surveyID], nil
+}
+
+func (m *mockSnapshotStore) SaveSnapshotSchedule(ctx context.Context, s *snapshot.Schedule) error {
+	m.schedules[s.SurveyID] = s
+	return nil
+}
+
+func (m *mockSnapshotStore) DeleteSnapshotSchedule(ctx context.Context, surveyID uuid.UUID) error {
+	delete(m.schedules, surveyID)
+	return nil
+}
+
+func (m *mockSnapshotStore) ClaimDueSnapshotSchedules(ctx context.Context, now time.Time, limit int) ([]*snapshot.Schedule, error) {
+	var due []*snapshot.Schedule
+	for _, s := range m.schedules {
+		if !s.NextRunAt.After(now) && len(due) < limit {
+			s.NextRunAt = s.Frequency.Next(now)
+			due = append(due, s)
+		}
+	}
+	return due, nil
+}
+
+func (m *mockSnapshotStore) CreateSnapshot(ctx context.Context, s *snapshot.Snapshot) error {
+	m.snapshots = append(m.snapshots, s)
+	return nil
+}
+
+func (m *mockSnapshotStore) ListSnapshots(ctx context.Context, surveyID uuid.UUID, limit int) ([]*snapshot.Snapshot, error) {
+	var snapshots []*snapshot.Snapshot
+	for _, s := range m.snapshots {
+		if s.SurveyID == surveyID {
+			snapshots = append(snapshots, s)
+		}
+	}
+	return snapshots, nil
+}
+
+func TestSnapshotSchedule(t *testing.T) {
+	e, mq, h := setupTest()
+	store := newMockSnapshotStore()
+	h.SetSnapshots(store)
+
+	alice := "did:plc:alice"
+	local := createTextSurvey(mq, "local", &alice)
+	survey := createTextSurvey(mq, "lunch", &alice)
+	uri, cid := "at://did:plc:alice/net.openmeet.survey/lunch", "bafylunch"
+	survey.URI, survey.CID = &uri, &cid
+
+	call := func(handler echo.HandlerFunc, method, slug, user string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/surveys/"+slug+"/snapshots", strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		if user != "" {
+			c.Set("user", &oauth.User{DID: user})
+		}
+		require.NoError(t, handler(c))
+		return rec
+	}
+
+	t.Run("only those managing the survey", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, call(h.SnapshotsPageHTML, http.MethodGet, "lunch", "", nil).Code)
+		assert.Equal(t, http.StatusForbidden, call(h.SnapshotsPageHTML, http.MethodGet, "lunch", "did:plc:mallory", nil).Code)
+		rec := call(h.SetSnapshotScheduleHTML, http.MethodPost, "lunch", "did:plc:mallory", url.Values{"frequency": {"daily"}})
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, store.schedules)
+	})
+
+	t.Run("opts in and out", func(t *testing.T) {
+		rec := call(h.SetSnapshotScheduleHTML, http.MethodPost, "lunch", alice, url.Values{"frequency": {"hourly"}})
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		require.Contains(t, store.schedules, survey.ID)
+		assert.Equal(t, snapshot.Hourly, store.schedules[survey.ID].Frequency)
+		assert.Equal(t, alice, store.schedules[survey.ID].DID)
+
+		rec = call(h.SnapshotsPageHTML, http.MethodGet, "lunch", alice, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Hourly snapshots")
+
+		call(h.SetSnapshotScheduleHTML, http.MethodPost, "lunch", alice, url.Values{"frequency": {""}})
+		assert.Empty(t, store.schedules)
+	})
+
+	t.Run("rejects bad schedules", func(t *testing.T) {
+		rec := call(h.SetSnapshotScheduleHTML, http.MethodPost, "lunch", alice, url.Values{"frequency": {"weekly"}})
+		assert.Contains(t, rec.Body.String(), "Choose hourly or daily")
+
+		rec = call(h.SetSnapshotScheduleHTML, http.MethodPost, local.Slug, alice, url.Values{"frequency": {"daily"}})
+		assert.Contains(t, rec.Body.String(), "local-only surveys")
+		assert.Empty(t, store.schedules)
+	})
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	_, mq, h := setupTest()
+	store := newMockSnapshotStore()
+	h.SetSnapshots(store)
+	ctx := context.Background()
+
+	alice := "did:plc:alice"
+	survey := createTextSurvey(mq, "lunch", &alice)
+	uri, cid := "at://did:plc:alice/net.openmeet.survey/lunch", "bafylunch"
+	survey.URI, survey.CID = &uri, &cid
+
+	now := time.Date(2026, 3, 14, 16, 0, 0, 0, time.UTC)
+	require.NoError(t, store.SaveSnapshotSchedule(ctx, snapshot.NewSchedule(survey.ID, snapshot.Hourly, alice, now.Add(-time.Hour))))
+
+	t.Run("kept locally without a session", func(t *testing.T) {
+		snapshot.Run(ctx, store, h.TakeSnapshot, now)
+		require.Len(t, store.snapshots, 1)
+		snap := store.snapshots[0]
+		assert.False(t, snap.Published())
+		assert.Equal(t, now, snap.CreatedAt)
+
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(snap.Record, &record))
+		assert.Equal(t, "net.openmeet.survey.results", record["$type"])
+		assert.Equal(t, now.Add(time.Hour), store.schedules[survey.ID].NextRunAt, "the schedule moves on")
+	})
+
+	t.Run("ends with the survey", func(t *testing.T) {
+		survey.EndsAt = &now
+		defer func() { survey.EndsAt = nil }()
+		require.NoError(t, h.TakeSnapshot(ctx, store.schedules[survey.ID], now))
+		assert.Len(t, store.snapshots, 2, "a last snapshot is taken")
+		assert.Empty(t, store.schedules)
+	})
+
+	t.Run("ends when the account no longer manages the survey", func(t *testing.T) {
+		schedule := snapshot.NewSchedule(survey.ID, snapshot.Daily, "did:plc:former-editor", now)
+		require.NoError(t, store.SaveSnapshotSchedule(ctx, schedule))
+		require.NoError(t, h.TakeSnapshot(ctx, schedule, now))
+		assert.Len(t, store.snapshots, 2)
+		assert.Empty(t, store.schedules)
+	})
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/spam"
+)
+
+// SetSpam enables spam scores of guest votes. Authors set a threshold per
+// survey to leave responses scoring at or above it out of results.
+func (h *Handlers) SetSpam(store spam.Store, scorer *spam.Scorer) {
+	h.spam = store
+	h.spamScorer = scorer
+}
+
+// spamScore scores a guest vote, or returns nil if spam scoring is disabled.
+// honeypot is the value of the voting form's hidden field.
+func (h *Handlers) spamScore(c echo.Context, survey *models.Survey, honeypot string) *spam.Score {
+	if h.spam == nil {
+		return nil
+	}
+	return h.spamScorer.Score(survey.ID, getClientIP(c), c.Request().UserAgent(), honeypot)
+}
+
+// saveSpamScore keeps the spam score of a saved guest response. A vote is not
+// failed for it, so errors are only logged.
+func (h *Handlers) saveSpamScore(c echo.Context, score *spam.Score, response *models.Response) {
+	if score == nil {
+		return
+	}
+	score.ResponseID, score.CreatedAt = response.ID, response.CreatedAt
+	if err := h.spam.SaveSpamScore(c.Request().Context(), score); err != nil {
+		c.Logger().Errorf("Failed to save spam score of response %s: %v", response.ID, err)
+	}
+}
+
+// GetSpamReport handles GET /api/v1/surveys/:slug/spam
+// Returns the survey's spam threshold and how its guest responses scored
+func (h *Handlers) GetSpamReport(c echo.Context) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	threshold, err := h.spam.GetSpamThreshold(c.Request().Context(), survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to get spam threshold", err)
+	}
+	return h.spamReport(c, survey, threshold)
+}
+
+// SetSpamThreshold handles PUT /api/v1/surveys/:slug/spam
+// Sets the score at or above which guest responses are left out of the
+// survey's results, or counts them all again with 0
+func (h *Handlers) SetSpamThreshold(c echo.Context) error {
+	survey, caller, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	var req SpamThresholdRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+	if req.Threshold == nil {
+		return ValidationError(c, "Invalid request", "threshold is required")
+	}
+	if err := spam.ValidateThreshold(*req.Threshold); err != nil {
+		return ValidationError(c, "Invalid request", err.Error())
+	}
+
+	if err := h.spam.SetSpamThreshold(c.Request().Context(), survey.ID, *req.Threshold, caller); err != nil {
+		return InternalServerError(c, "Failed to set spam threshold", err)
+	}
+	h.cache.Invalidate(c.Request().Context(), cache.ResultsKey(survey.ID))
+
+	return h.spamReport(c, survey, *req.Threshold)
+}
+
+// spamReport responds with the report of a survey's spam scores under a threshold
+func (h *Handlers) spamReport(c echo.Context, survey *models.Survey, threshold int) error {
+	scores, err := h.spam.ListSpamScores(c.Request().Context(), survey.ID)
+	if err != nil {
+		return InternalServerError(c, "Failed to list spam scores", err)
+	}
+	return c.JSON(http.StatusOK, spam.NewReport(scores, threshold))
+}
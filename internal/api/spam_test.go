@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/spam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSpamStore keeps spam scores and thresholds in memory
+type mockSpamStore struct {
+	scores     []*spam.Score
+	thresholds map[uuid.UUID]int
+}
+
+func (m *mockSpamStore) SaveSpamScore(ctx context.Context, s *spam.Score) error {
+	m.scores = append(m.scores, s)
+	return nil
+}
+
+func (m *mockSpamStore) ListSpamScores(ctx context.Context, surveyID uuid.UUID) ([]*spam.Score, error) {
+	var scores []*spam.Score
+	for _, s := range m.scores {
+		if s.SurveyID == surveyID {
+			scores = append(scores, s)
+		}
+	}
+	return scores, nil
+}
+
+func (m *mockSpamStore) GetSpamThreshold(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	return m.thresholds[surveyID], nil
+}
+
+func (m *mockSpamStore) SetSpamThreshold(ctx context.Context, surveyID uuid.UUID, threshold int, by string) error {
+	if m.thresholds == nil {
+		m.thresholds = make(map[uuid.UUID]int)
+	}
+	m.thresholds[surveyID] = threshold
+	return nil
+}
+
+func TestSubmitResponse_SpamScore(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockSpamStore{}
+	scorer, err := spam.NewScorer(spam.Config{})
+	require.NoError(t, err)
+	h.SetSpam(store, scorer)
+	createTextSurvey(mq, "feedback", nil)
+
+	const browser = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+	submit := func(ip, form string, user *oauth.User) {
+		req := httptest.NewRequest(http.MethodPost, "/surveys/feedback/responses", strings.NewReader(form))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.Header.Set("User-Agent", browser)
+		req.RemoteAddr = ip + ":12345"
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("feedback")
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, h.SubmitResponseHTML(c))
+	}
+
+	submit("192.168.1.1", "q1=Great", nil)
+	require.Len(t, store.scores, 1)
+	assert.Zero(t, store.scores[0].Score)
+	assert.NotEqual(t, uuid.Nil, store.scores[0].ResponseID)
+
+	// Bots fill in the hidden field
+	submit("192.168.1.2", "q1=Great&"+spam.HoneypotField+"=https://spam.example", nil)
+	require.Len(t, store.scores, 2)
+	assert.Equal(t, spam.MaxScore, store.scores[1].Score)
+	assert.Equal(t, []string{spam.ReasonHoneypot}, store.scores[1].Reasons)
+
+	// Scripts posting to the JSON API have no honeypot, but a scripted user agent
+	body, _ := json.Marshal(SubmitResponseRequest{Answers: map[string]models.Answer{"q1": {Text: "Great"}}})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/feedback/responses", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("User-Agent", "python-requests/2.32")
+	req.RemoteAddr = "192.168.1.3:12345"
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("feedback")
+	require.NoError(t, h.SubmitResponse(c))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.Len(t, store.scores, 3)
+	assert.Equal(t, []string{spam.ReasonUserAgent}, store.scores[2].Reasons)
+}
+
+func TestSpamThreshold(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockSpamStore{}
+	scorer, err := spam.NewScorer(spam.Config{})
+	require.NoError(t, err)
+	h.SetSpam(store, scorer)
+	author := "did:plc:author"
+	survey := createTextSurvey(mq, "feedback", &author)
+	store.scores = []*spam.Score{
+		{ResponseID: uuid.New(), SurveyID: survey.ID, Score: 0, Reasons: []string{}},
+		{ResponseID: uuid.New(), SurveyID: survey.ID, Score: 40, Reasons: []string{spam.ReasonUserAgent}},
+		{ResponseID: uuid.New(), SurveyID: survey.ID, Score: 100, Reasons: []string{spam.ReasonHoneypot, spam.ReasonUserAgent}},
+	}
+
+	call := func(method, body string, user *oauth.User, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/surveys/feedback/spam", bytes.NewReader([]byte(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("feedback")
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, handler(c))
+		return rec
+	}
+
+	rec := call(http.MethodGet, "", &oauth.User{DID: "did:plc:other"}, h.GetSpamReport)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = call(http.MethodPut, `{"threshold": 50}`, &oauth.User{DID: "did:plc:other"}, h.SetSpamThreshold)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = call(http.MethodGet, "", &oauth.User{DID: author}, h.GetSpamReport)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var report spam.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Zero(t, report.Threshold)
+	assert.Equal(t, 3, report.Scored)
+	assert.Zero(t, report.Excluded, "no threshold counts all responses")
+	assert.Equal(t, map[string]int{spam.ReasonHoneypot: 1, spam.ReasonUserAgent: 2}, report.Reasons)
+
+	rec = call(http.MethodPut, `{"threshold": 40}`, &oauth.User{DID: author}, h.SetSpamThreshold)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, 40, report.Threshold)
+	assert.Equal(t, 2, report.Excluded)
+	assert.Equal(t, 40, store.thresholds[survey.ID])
+
+	for _, body := range []string{`{}`, `{"threshold": -1}`, `{"threshold": 101}`} {
+		rec = call(http.MethodPut, body, &oauth.User{DID: author}, h.SetSpamThreshold)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	assert.Equal(t, 40, store.thresholds[survey.ID])
+
+	rec = call(http.MethodPut, `{"threshold": 0}`, &oauth.User{DID: author}, h.SetSpamThreshold)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Zero(t, report.Excluded)
+}
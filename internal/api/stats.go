@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// parseStats parses the statistics of a results query, returning their
+// confidence level, or 0 if there are none:
+//   - stats: true to add the percentages and confidence intervals of choice questions
+//   - confidence: the confidence level of the intervals, 90, 95, or 99 (default 95)
+func parseStats(c echo.Context) (int, error) {
+	stats, confidence := c.QueryParam("stats"), c.QueryParam("confidence")
+	if stats == "" {
+		if confidence != "" {
+			return 0, errors.New("'confidence' needs 'stats=true'")
+		}
+		return 0, nil
+	}
+	if on, err := strconv.ParseBool(stats); err != nil {
+		return 0, errors.New("'stats' must be true or false")
+	} else if !on {
+		return 0, nil
+	}
+
+	if confidence == "" {
+		return models.DefaultConfidence, nil
+	}
+	level, err := strconv.Atoi(confidence)
+	if err != nil {
+		return 0, errors.New("'confidence' must be 90, 95, or 99")
+	}
+	return level, models.ValidateConfidence(level)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetResults_Stats(t *testing.T) {
+	e, mq, h := setupTest()
+	createWeightingSurvey(mq, "did:plc:author")
+
+	rec := getWeightedResults(t, e, h, "stats=true", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp models.SurveyResults
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	tea := resp.QuestionResults["tea"]
+	assert.Equal(t, 4, tea.Respondents)
+	require.NotNil(t, tea.Stats)
+	assert.Equal(t, models.DefaultConfidence, tea.Stats.Confidence)
+	assert.Equal(t, 75.0, tea.Stats.Options["yes"].Percent)
+	assert.Less(t, tea.Stats.Options["yes"].Low, 75.0)
+	assert.Greater(t, tea.Stats.Options["yes"].High, 75.0)
+	assert.Equal(t, 49.0, tea.Stats.MarginOfError)
+
+	// Wider intervals at a higher confidence level
+	rec = getWeightedResults(t, e, h, "stats=true&confidence=99", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 99, resp.QuestionResults["tea"].Stats.Confidence)
+	assert.Greater(t, resp.QuestionResults["tea"].Stats.MarginOfError, 49.0)
+
+	// Statistics are only added when asked for
+	rec = getWeightedResults(t, e, h, "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), `"stats"`)
+	rec = getWeightedResults(t, e, h, "stats=false", nil)
+	assert.NotContains(t, rec.Body.String(), `"stats"`)
+}
+
+func TestGetResults_StatsErrors(t *testing.T) {
+	e, mq, h := setupTest()
+	createWeightingSurvey(mq, "did:plc:author")
+
+	for _, query := range []string{"stats=maybe", "stats=true&confidence=80", "stats=true&confidence=high", "confidence=95"} {
+		t.Run(query, func(t *testing.T) {
+			rec := getWeightedResults(t, e, h, query, nil)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
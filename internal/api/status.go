@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/status"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// statusReportTTL is how long a status report is served before it is built
+// again. Samples are taken every 5 minutes, so a report is at most a minute
+// behind them.
+const statusReportTTL = time.Minute
+
+// statusReportCache holds the last status report built
+type statusReportCache struct {
+	mu      sync.Mutex
+	report  *status.Report
+	builtAt time.Time
+}
+
+// statusReport summarizes the status history of the retention period. The
+// report is cached for statusReportTTL; concurrent requests wait for one
+// build instead of each querying the history.
+func (h *Handlers) statusReport(ctx context.Context) (*status.Report, error) {
+	reports := &h.statusReports
+	reports.mu.Lock()
+	defer reports.mu.Unlock()
+
+	now := time.Now()
+	if reports.report != nil && now.Sub(reports.builtAt) < statusReportTTL {
+		return reports.report, nil
+	}
+
+	var histories map[status.Component]*status.History
+	if h.statusStore != nil {
+		var err error
+		histories, err = h.statusStore.StatusHistory(ctx, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+	reports.report = status.Summarize(histories, now)
+	reports.builtAt = now
+	return reports.report, nil
+}
+
+// GetStatus returns the service status as JSON
+// GET /api/v1/status
+func (h *Handlers) GetStatus(c echo.Context) error {
+	report, err := h.statusReport(c.Request().Context())
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve status", err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// StatusPageHTML displays the public status page
+// GET /status
+func (h *Handlers) StatusPageHTML(c echo.Context) error {
+	user, profile := getUserAndProfile(c)
+
+	report, err := h.statusReport(c.Request().Context())
+	if err != nil {
+		c.Logger().Errorf("Failed to retrieve status: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to retrieve status")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.StatusPage(report, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
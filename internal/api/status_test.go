@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStatusStore counts history reads
+type mockStatusStore struct {
+	reads int
+}
+
+func (m *mockStatusStore) StatusHistory(ctx context.Context, now time.Time) (map[status.Component]*status.History, error) {
+	m.reads++
+	return map[status.Component]*status.History{
+		status.ComponentAPI: {Latest: []*status.Sample{{Component: status.ComponentAPI, Healthy: true, SampledAt: now}}},
+	}, nil
+}
+
+func TestGetStatus_CachesReport(t *testing.T) {
+	e, _, h := setupTest()
+	store := &mockStatusStore{}
+	h.SetStatusStore(store)
+
+	getStatus := func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+		rec := httptest.NewRecorder()
+		require.NoError(t, h.GetStatus(e.NewContext(req, rec)))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"status":"operational"`)
+	}
+
+	getStatus()
+	getStatus()
+	assert.Equal(t, 1, store.reads, "the second request should be served the cached report")
+
+	h.statusReports.builtAt = time.Now().Add(-statusReportTTL)
+	getStatus()
+	assert.Equal(t, 2, store.reads, "an expired report should be built again")
+}
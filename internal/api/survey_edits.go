@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+)
+
+// UpdateSurvey replaces the definition of a local-only survey, for those who
+// manage it and its co-authors. Surveys published to a PDS are edited through
+// their record instead. Changes are recorded in the survey's change history
+// like edits of records.
+// PUT /api/v1/surveys/:slug
+func (h *Handlers) UpdateSurvey(c echo.Context) error {
+	survey, _, err := h.coAuthorSurvey(c)
+	if survey == nil {
+		return err
+	}
+	if survey.URI != nil {
+		return Problem(c, problem.SurveyPublished, "Edit the survey record on its author's PDS")
+	}
+
+	var req UpdateSurveyRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+
+	def, err := models.ParseSurveyDefinition([]byte(req.Definition))
+	if err != nil {
+		return Problem(c, problem.InvalidDefinition, err.Error())
+	}
+	if errs := models.SchemaErrors([]byte(req.Definition)); len(errs) > 0 {
+		return Problem(c, problem.InvalidDefinition, errs[0].Error())
+	}
+	if err := def.ValidateDefinition(); err != nil {
+		return Problem(c, problem.InvalidDefinition, err.Error())
+	}
+	def.StripImages()
+
+	ctx := c.Request().Context()
+	changes := models.DiffDefinitions(&survey.Definition, def)
+	survey.Definition = *def
+	survey.Title = def.Questions[0].Text
+	if err := h.queries.UpdateSurvey(ctx, survey); err != nil {
+		return InternalServerError(c, "Failed to update survey", err)
+	}
+	if len(changes) > 0 {
+		revision := &models.SurveyRevision{
+			ID:        uuid.New(),
+			SurveyID:  survey.ID,
+			Changes:   changes,
+			CreatedAt: time.Now(),
+		}
+		if err := h.queries.CreateSurveyRevision(ctx, revision); err != nil {
+			return InternalServerError(c, "Failed to record survey revision", err)
+		}
+	}
+	h.cache.Invalidate(ctx, cache.SurveyKey(survey.Slug))
+
+	return c.JSON(http.StatusOK, ToSurveyResponse(survey, true))
+}
+
+// ArchiveSurvey closes voting on a survey now, keeping it and its results.
+// Closed surveys are left as they are.
+// POST /api/v1/surveys/:slug/archive
+func (h *Handlers) ArchiveSurvey(c echo.Context) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	now := time.Now()
+	if !survey.IsClosed(now) {
+		ctx := c.Request().Context()
+		survey.EndsAt = &now
+		if err := h.queries.UpdateSurvey(ctx, survey); err != nil {
+			return InternalServerError(c, "Failed to archive survey", err)
+		}
+		h.cache.Invalidate(ctx, cache.SurveyKey(survey.Slug))
+	}
+
+	return c.JSON(http.StatusOK, ToSurveyResponse(survey, true))
+}
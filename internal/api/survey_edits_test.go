@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateSurvey(t *testing.T) {
+	e, mq, h := setupTest()
+	alice := "did:plc:alice"
+	survey := createTextSurvey(mq, "lunch", &alice)
+
+	call := func(slug, owner, definition string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(UpdateSurveyRequest{Definition: definition})
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/surveys/"+slug, bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		if owner != "" {
+			c.Set("api_key", &apikey.Key{ID: uuid.New(), OwnerDID: owner})
+		}
+		require.NoError(t, h.UpdateSurvey(c))
+		return rec
+	}
+	definition := `{"questions": [{"id": "q1", "text": "Where for lunch?", "type": "text"}, {"id": "q2", "text": "When?", "type": "date"}]}`
+
+	t.Run("only by those managing the survey", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, call("lunch", "", definition).Code)
+		assert.Equal(t, http.StatusForbidden, call("lunch", "did:plc:mallory", definition).Code)
+	})
+
+	t.Run("replaces the definition and records the changes", func(t *testing.T) {
+		rec := call("lunch", alice, definition)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "Where for lunch?", survey.Title)
+		require.Len(t, survey.Definition.Questions, 2)
+		require.Len(t, mq.Revisions, 1)
+		assert.Equal(t, survey.ID, mq.Revisions[0].SurveyID)
+	})
+
+	t.Run("rejects invalid definitions", func(t *testing.T) {
+		rec := call("lunch", alice, `{"questions": []}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Len(t, survey.Definition.Questions, 2)
+	})
+
+	t.Run("published surveys are edited on their PDS", func(t *testing.T) {
+		published := createTextSurvey(mq, "published", &alice)
+		uri := "at://did:plc:alice/net.openmeet.survey/published"
+		published.URI = &uri
+		assert.Equal(t, http.StatusConflict, call("published", alice, definition).Code)
+	})
+}
+
+func TestArchiveSurvey(t *testing.T) {
+	e, mq, h := setupTest()
+	alice := "did:plc:alice"
+	survey := createTextSurvey(mq, "lunch", &alice)
+
+	call := func(owner string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/v1/surveys/lunch/archive", nil), rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("lunch")
+		c.Set("api_key", &apikey.Key{ID: uuid.New(), OwnerDID: owner})
+		require.NoError(t, h.ArchiveSurvey(c))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusForbidden, call("did:plc:mallory").Code)
+	assert.Nil(t, survey.EndsAt)
+
+	assert.Equal(t, http.StatusOK, call(alice).Code)
+	require.NotNil(t, survey.EndsAt)
+	assert.True(t, survey.IsClosed(time.Now()))
+
+	closedAt := *survey.EndsAt
+	call(alice)
+	assert.Equal(t, closedAt, *survey.EndsAt, "archiving a closed survey keeps its end")
+
+	var resp SurveyResponse
+	require.NoError(t, json.Unmarshal(call(alice).Body.Bytes(), &resp))
+	assert.Equal(t, models.QuestionTypeText, resp.Definition.Questions[0].Type)
+}
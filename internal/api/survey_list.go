@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/surveylist"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// SetSurveyList enables sorting and filtering survey lists, and the browse
+// page of the logged-in user's surveys
+func (h *Handlers) SetSurveyList(store surveylist.Store) {
+	h.surveyList = store
+}
+
+// errOtherAuthor is returned when a user who is not an admin lists another
+// author's surveys
+var errOtherAuthor = errors.New("only admins can list other authors' surveys")
+
+// surveyListFilter parses the survey list filter of a request. Users list
+// their own surveys; admins can list any author's with ?author=. The
+// returned filter keeps the author as given, for links; author is whose
+// surveys to list.
+func (h *Handlers) surveyListFilter(c echo.Context, viewerDID string) (filter surveylist.Filter, author string, err error) {
+	filter, err = surveylist.Parse(c.QueryParams())
+	if err != nil {
+		return filter, "", err
+	}
+
+	author = viewerDID
+	if filter.AuthorDID != "" && filter.AuthorDID != viewerDID {
+		if !h.adminDIDs[viewerDID] {
+			return filter, "", errOtherAuthor
+		}
+		author = filter.AuthorDID
+	}
+	return filter, author, nil
+}
+
+// listSurveys handles GET /api/v1/surveys?sort=&order=&status=&has_results=&author=
+// for the caller, with the survey list enabled
+func (h *Handlers) listSurveys(c echo.Context, owner string) error {
+	filter, author, err := h.surveyListFilter(c, owner)
+	if errors.Is(err, errOtherAuthor) {
+		return Problem(c, problem.Forbidden, "Only admins can list other authors' surveys")
+	}
+	if err != nil {
+		return Problem(c, problem.ValidationFailed, err.Error())
+	}
+
+	now := time.Now()
+	filter.AuthorDID = author
+	items, err := h.surveyList.ListAuthorSurveys(c.Request().Context(), filter, now)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve surveys", err)
+	}
+
+	result := make([]ListedSurveyResponse, len(items))
+	for i, item := range items {
+		result[i] = ListedSurveyResponse{
+			SurveyListResponse: *ToSurveyListResponse(item.Survey),
+			Status:             surveylist.Status(item.Survey, now),
+			ResponseCount:      item.Responses,
+			HasResults:         item.Survey.ResultsURI != nil,
+		}
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// MySurveysHTML renders the browse page of the logged-in user's surveys,
+// with facets by status and published results
+// GET /my-surveys?sort=&order=&status=&has_results=&author=
+func (h *Handlers) MySurveysHTML(c echo.Context) error {
+	user, profile := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+
+	filter, author, err := h.surveyListFilter(c, user.DID)
+	if errors.Is(err, errOtherAuthor) {
+		return c.String(http.StatusForbidden, "Only admins can list other authors' surveys")
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	now := time.Now()
+	listed := filter
+	listed.AuthorDID = author
+	items, err := h.surveyList.ListAuthorSurveys(ctx, listed, now)
+	if err != nil {
+		c.Logger().Errorf("Failed to list surveys: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load surveys")
+	}
+	facets, err := h.surveyList.GetSurveyFacets(ctx, author, now)
+	if err != nil {
+		c.Logger().Errorf("Failed to count surveys: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load surveys")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.MySurveysPage(filter, items, facets, now, user, profile, h.posthogKey)
+	return component.Render(ctx, c.Response().Writer)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/surveylist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSurveyList lists fixed surveys, recording the filter asked for
+type mockSurveyList struct {
+	items  []*surveylist.Item
+	filter surveylist.Filter
+}
+
+func (m *mockSurveyList) ListAuthorSurveys(ctx context.Context, filter surveylist.Filter, now time.Time) ([]*surveylist.Item, error) {
+	m.filter = filter
+	return m.items, nil
+}
+
+func (m *mockSurveyList) GetSurveyFacets(ctx context.Context, authorDID string, now time.Time) (*surveylist.Facets, error) {
+	return &surveylist.Facets{Total: len(m.items), Closed: 1, WithResults: 1}, nil
+}
+
+func TestListOwnSurveys_Filtered(t *testing.T) {
+	e, _, h := setupTest()
+	h.SetAccountData(&mockAccountData{})
+	h.SetAdmins([]string{"did:plc:admin"})
+	ended := time.Now().Add(-time.Hour)
+	resultsURI := "at://did:plc:alice/net.openmeet.survey.results/3k2"
+	store := &mockSurveyList{items: []*surveylist.Item{
+		{Survey: &models.Survey{ID: uuid.New(), Slug: "lunch", Title: "Lunch?", EndsAt: &ended, ResultsURI: &resultsURI}, Responses: 12},
+	}}
+	h.SetSurveyList(store)
+
+	call := func(owner, query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/surveys?"+query, nil), rec)
+		c.Set("api_key", &apikey.Key{ID: uuid.New(), OwnerDID: owner})
+		require.NoError(t, h.ListOwnSurveys(c))
+		return rec
+	}
+
+	rec := call("did:plc:alice", "sort=response_count&status=closed&has_results=true")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "did:plc:alice", store.filter.AuthorDID, "users list their own surveys")
+	assert.Equal(t, surveylist.SortResponseCount, store.filter.Sort)
+	assert.True(t, store.filter.Descending)
+	assert.Equal(t, surveylist.StatusClosed, store.filter.Status)
+
+	var surveys []ListedSurveyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &surveys))
+	require.Len(t, surveys, 1)
+	assert.Equal(t, "lunch", surveys[0].Slug)
+	assert.Equal(t, 12, surveys[0].ResponseCount)
+	assert.Equal(t, surveylist.StatusClosed, surveys[0].Status)
+	assert.True(t, surveys[0].HasResults)
+
+	assert.Equal(t, http.StatusBadRequest, call("did:plc:alice", "sort=slug").Code)
+	assert.Equal(t, http.StatusForbidden, call("did:plc:alice", "author=did:plc:bob").Code, "surveys can't be discovered")
+
+	require.Equal(t, http.StatusOK, call("did:plc:admin", "author=did:plc:bob").Code)
+	assert.Equal(t, "did:plc:bob", store.filter.AuthorDID, "admins can list any author's surveys")
+}
+
+func TestMySurveysHTML(t *testing.T) {
+	e, _, h := setupTest()
+	store := &mockSurveyList{items: []*surveylist.Item{
+		{Survey: &models.Survey{ID: uuid.New(), Slug: "lunch", Title: "Lunch?"}, Responses: 3},
+	}}
+	h.SetSurveyList(store)
+
+	call := func(user *oauth.User, query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/my-surveys?"+query, nil), rec)
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, h.MySurveysHTML(c))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call(nil, "").Code)
+
+	rec := call(&oauth.User{DID: "did:plc:alice"}, "status=open")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, surveylist.StatusOpen, store.filter.Status)
+	body := rec.Body.String()
+	assert.Contains(t, body, "Lunch?")
+	assert.Contains(t, body, "<strong>Open (0)</strong>", "the selected facet is shown in bold")
+	assert.Contains(t, body, `href="/my-surveys?has_results=true&amp;status=open"`, "facet links keep the other facets")
+}
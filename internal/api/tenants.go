@@ -0,0 +1,385 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+	"github.com/openmeet-team/survey/internal/tenant"
+)
+
+// SetTenants enables serving surveys on the domains of tenants, with their
+// branding and landing pages
+func (h *Handlers) SetTenants(store tenant.Store) {
+	h.tenants = store
+	h.tenantResolver = tenant.NewResolver(store, tenant.CacheTTL)
+}
+
+// TenantMiddleware adds the tenant of the request's host to its context, for
+// handlers and templates to read with tenant.FromContext. Requests whose
+// tenant can't be looked up are served without one.
+func TenantMiddleware(resolver *tenant.Resolver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			t, err := resolver.Resolve(req.Context(), req.Host)
+			if err != nil {
+				c.Logger().Warnf("Failed to resolve tenant of %s: %v", req.Host, err)
+			}
+			if t != nil {
+				c.SetRequest(req.WithContext(tenant.NewContext(req.Context(), t)))
+			}
+			return next(c)
+		}
+	}
+}
+
+// tenantLandingPage renders the landing page of a tenant, listing its
+// surveys. They are left out if they can't be loaded, rather than failing the page.
+func (h *Handlers) tenantLandingPage(c echo.Context, t *tenant.Tenant) error {
+	ctx := c.Request().Context()
+	surveys, err := h.tenants.ListTenantSurveys(ctx, t.ID, tenant.LandingLimit, 0)
+	if err != nil {
+		c.Logger().Warnf("Failed to load surveys of tenant %s: %v", t.Slug, err)
+	}
+
+	user, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.TenantLandingPage(t, surveys, user, profile, h.posthogKey)
+	return component.Render(ctx, c.Response().Writer)
+}
+
+// errInvalidTenantRequest is returned by the tenant helpers when the request is invalid
+var errInvalidTenantRequest = errors.New("invalid tenant request")
+
+// requireAdmin returns the DID of the caller if they are an admin, or writes
+// a 401 or 403 response
+func (h *Handlers) requireAdmin(c echo.Context, action string) (string, error) {
+	did, err := requireCaller(c)
+	if did == "" {
+		return "", err
+	}
+	if !h.adminDIDs[did] {
+		return "", Problem(c, problem.Forbidden, "Only admins can "+action)
+	}
+	return did, nil
+}
+
+// tenantForRequest loads the tenant of the :tenant parameter, or writes a 404
+// response and returns nil
+func (h *Handlers) tenantForRequest(c echo.Context) (*tenant.Tenant, error) {
+	slug := c.Param("tenant")
+	t, err := h.tenants.GetTenantBySlug(c.Request().Context(), slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, Problem(c, problem.NotFound, fmt.Sprintf("Tenant not found: No tenant found with slug '%s'", slug))
+	}
+	if err != nil {
+		return nil, InternalServerError(c, "Failed to retrieve tenant", err)
+	}
+	return t, nil
+}
+
+// tenantResponse returns a tenant with its hostnames
+func (h *Handlers) tenantResponse(ctx context.Context, t *tenant.Tenant) (*TenantResponse, error) {
+	domains, err := h.tenants.ListDomains(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &TenantResponse{Tenant: t, Domains: make([]string, len(domains))}
+	for i, d := range domains {
+		resp.Domains[i] = d.Hostname
+	}
+	return resp, nil
+}
+
+// applyTenantRequest sets the branding and default author of a tenant from a request
+func applyTenantRequest(t *tenant.Tenant, req TenantRequest) error {
+	t.Name = req.Name
+	t.Tagline = req.Tagline
+	t.LogoURL = req.LogoURL
+	t.AccentColor = req.AccentColor
+	t.DefaultAuthorDID = &req.DefaultAuthor
+	if err := t.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidTenantRequest, err)
+	}
+	return nil
+}
+
+// addTenantDomain adds a hostname to a tenant, up to tenant.MaxDomains
+func (h *Handlers) addTenantDomain(ctx context.Context, t *tenant.Tenant, host string) (*tenant.Domain, error) {
+	hostname, err := tenant.NormalizeHostname(host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidTenantRequest, err)
+	}
+
+	domains, err := h.tenants.ListDomains(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(domains) >= tenant.MaxDomains {
+		return nil, fmt.Errorf("%w: a tenant can have at most %d hostnames", errInvalidTenantRequest, tenant.MaxDomains)
+	}
+
+	d := &tenant.Domain{Hostname: hostname, TenantID: t.ID, CreatedAt: time.Now()}
+	if err := h.tenants.AddDomain(ctx, d); err != nil {
+		if errors.Is(err, tenant.ErrDomainTaken) {
+			return nil, fmt.Errorf("%w: %v", errInvalidTenantRequest, err)
+		}
+		return nil, err
+	}
+	h.tenantResolver.Invalidate()
+	return d, nil
+}
+
+// canCrossPost reports whether a DID lists surveys on a tenant: admins and
+// the tenant's default author
+func (h *Handlers) canCrossPost(t *tenant.Tenant, did string) bool {
+	return h.adminDIDs[did] || (t.DefaultAuthorDID != nil && *t.DefaultAuthorDID == did)
+}
+
+// tenantErrorJSON responds to an API request whose tenant helper failed
+func tenantErrorJSON(c echo.Context, err error, action string) error {
+	if errors.Is(err, errInvalidTenantRequest) {
+		return ValidationError(c, "Invalid request", strings.TrimPrefix(err.Error(), errInvalidTenantRequest.Error()+": "))
+	}
+	return InternalServerError(c, "Failed to "+action, err)
+}
+
+// ListTenants handles GET /api/v1/admin/tenants
+// Lists the tenants with their hostnames, for admins
+func (h *Handlers) ListTenants(c echo.Context) error {
+	if did, err := h.requireAdmin(c, "manage tenants"); did == "" {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	tenants, err := h.tenants.ListTenants(ctx)
+	if err != nil {
+		return InternalServerError(c, "Failed to list tenants", err)
+	}
+
+	result := make([]*TenantResponse, len(tenants))
+	for i, t := range tenants {
+		if result[i], err = h.tenantResponse(ctx, t); err != nil {
+			return InternalServerError(c, "Failed to list domains", err)
+		}
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// CreateTenant handles POST /api/v1/admin/tenants
+// Creates a tenant without hostnames, for admins
+func (h *Handlers) CreateTenant(c echo.Context) error {
+	if did, err := h.requireAdmin(c, "manage tenants"); did == "" {
+		return err
+	}
+
+	var req TenantRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+
+	t, err := tenant.New(req.Slug, req.Name)
+	if err != nil {
+		return ValidationError(c, "Invalid request", err.Error())
+	}
+	if err := applyTenantRequest(t, req); err != nil {
+		return tenantErrorJSON(c, err, "create tenant")
+	}
+
+	if err := h.tenants.CreateTenant(c.Request().Context(), t); err != nil {
+		if errors.Is(err, tenant.ErrSlugTaken) {
+			return ValidationError(c, "Invalid request", err.Error())
+		}
+		return InternalServerError(c, "Failed to create tenant", err)
+	}
+
+	return c.JSON(http.StatusCreated, &TenantResponse{Tenant: t, Domains: []string{}})
+}
+
+// UpdateTenant handles PUT /api/v1/admin/tenants/:tenant
+// Replaces the branding and default author of a tenant, for admins
+func (h *Handlers) UpdateTenant(c echo.Context) error {
+	if did, err := h.requireAdmin(c, "manage tenants"); did == "" {
+		return err
+	}
+	t, err := h.tenantForRequest(c)
+	if t == nil {
+		return err
+	}
+
+	var req TenantRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+	if err := applyTenantRequest(t, req); err != nil {
+		return tenantErrorJSON(c, err, "update tenant")
+	}
+
+	ctx := c.Request().Context()
+	if err := h.tenants.UpdateTenant(ctx, t); err != nil {
+		return InternalServerError(c, "Failed to update tenant", err)
+	}
+	h.tenantResolver.Invalidate()
+
+	resp, err := h.tenantResponse(ctx, t)
+	if err != nil {
+		return InternalServerError(c, "Failed to list domains", err)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// AddTenantDomain handles POST /api/v1/admin/tenants/:tenant/domains
+// Serves a tenant on a hostname, for admins
+func (h *Handlers) AddTenantDomain(c echo.Context) error {
+	if did, err := h.requireAdmin(c, "manage tenants"); did == "" {
+		return err
+	}
+	t, err := h.tenantForRequest(c)
+	if t == nil {
+		return err
+	}
+
+	var req AddDomainRequest
+	if err := c.Bind(&req); err != nil {
+		return ValidationError(c, "Invalid request", "Request body must be JSON")
+	}
+
+	d, err := h.addTenantDomain(c.Request().Context(), t, req.Hostname)
+	if err != nil {
+		return tenantErrorJSON(c, err, "add domain")
+	}
+
+	return c.JSON(http.StatusCreated, d)
+}
+
+// RemoveTenantDomain handles DELETE /api/v1/admin/tenants/:tenant/domains/:hostname
+// Stops serving a tenant on a hostname, for admins
+func (h *Handlers) RemoveTenantDomain(c echo.Context) error {
+	if did, err := h.requireAdmin(c, "manage tenants"); did == "" {
+		return err
+	}
+	t, err := h.tenantForRequest(c)
+	if t == nil {
+		return err
+	}
+
+	if err := h.tenants.RemoveDomain(c.Request().Context(), t.ID, tenant.Hostname(c.Param("hostname"))); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.NotFound, "The tenant has no such hostname")
+		}
+		return InternalServerError(c, "Failed to remove domain", err)
+	}
+	h.tenantResolver.Invalidate()
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CrossPostSurvey handles PUT /api/v1/tenants/:tenant/surveys/:slug
+// Lists a public survey on a tenant's landing page, for admins and the
+// tenant's default author
+func (h *Handlers) CrossPostSurvey(c echo.Context) error {
+	did, err := requireCaller(c)
+	if did == "" {
+		return err
+	}
+	t, err := h.tenantForRequest(c)
+	if t == nil {
+		return err
+	}
+	if !h.canCrossPost(t, did) {
+		return Problem(c, problem.Forbidden, "Only admins and the tenant's default author can cross-post surveys")
+	}
+
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return h.surveyNotFoundJSON(c, slug)
+	}
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+	if !survey.Definition.IsListed() || survey.HiddenAt != nil {
+		return Problem(c, problem.ValidationFailed, "Only public surveys that are not hidden can be cross-posted")
+	}
+
+	if err := h.tenants.CrossPostSurvey(ctx, t.ID, survey.ID, did); err != nil {
+		return InternalServerError(c, "Failed to cross-post survey", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RemoveCrossPost handles DELETE /api/v1/tenants/:tenant/surveys/:slug
+// Stops listing a cross-posted survey on a tenant, for admins and the
+// tenant's default author
+func (h *Handlers) RemoveCrossPost(c echo.Context) error {
+	did, err := requireCaller(c)
+	if did == "" {
+		return err
+	}
+	t, err := h.tenantForRequest(c)
+	if t == nil {
+		return err
+	}
+	if !h.canCrossPost(t, did) {
+		return Problem(c, problem.Forbidden, "Only admins and the tenant's default author can cross-post surveys")
+	}
+
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return h.surveyNotFoundJSON(c, slug)
+	}
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+
+	if err := h.tenants.RemoveCrossPost(ctx, t.ID, survey.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.NotFound, "The survey is not cross-posted to the tenant")
+		}
+		return InternalServerError(c, "Failed to remove cross-post", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListTenantSurveys handles GET /api/v1/tenant/surveys?limit=20&offset=0
+// Lists the public surveys of the tenant served on the request's host, newest
+// first. Other hosts have no listing.
+func (h *Handlers) ListTenantSurveys(c echo.Context) error {
+	t := tenant.FromContext(c.Request().Context())
+	if t == nil {
+		return Problem(c, problem.NotFound, "Surveys are only listed on the domains of tenants")
+	}
+
+	limit, offset := 20, 0
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(c.QueryParam("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	surveys, err := h.tenants.ListTenantSurveys(c.Request().Context(), t.ID, limit, offset)
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve surveys", err)
+	}
+
+	result := make([]SurveyListResponse, len(surveys))
+	for i, s := range surveys {
+		result[i] = *ToSurveyListResponse(s)
+	}
+	return c.JSON(http.StatusOK, result)
+}
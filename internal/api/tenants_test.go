@@ -0,0 +1,295 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTenantStore keeps tenants, their domains, and cross-posts in memory
+type mockTenantStore struct {
+	mq          *MockQueries
+	tenants     []*tenant.Tenant
+	domains     []*tenant.Domain
+	crossPosted map[uuid.UUID][]uuid.UUID // tenant ID -> survey IDs
+}
+
+func (m *mockTenantStore) CreateTenant(ctx context.Context, t *tenant.Tenant) error {
+	if _, err := m.GetTenantBySlug(ctx, t.Slug); err == nil {
+		return tenant.ErrSlugTaken
+	}
+	m.tenants = append(m.tenants, t)
+	return nil
+}
+
+func (m *mockTenantStore) UpdateTenant(ctx context.Context, t *tenant.Tenant) error {
+	for i, existing := range m.tenants {
+		if existing.ID == t.ID {
+			m.tenants[i] = t
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *mockTenantStore) GetTenantBySlug(ctx context.Context, slug string) (*tenant.Tenant, error) {
+	for _, t := range m.tenants {
+		if t.Slug == slug {
+			return t, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockTenantStore) GetTenantByHostname(ctx context.Context, hostname string) (*tenant.Tenant, error) {
+	for _, d := range m.domains {
+		if d.Hostname == hostname {
+			for _, t := range m.tenants {
+				if t.ID == d.TenantID {
+					return t, nil
+				}
+			}
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockTenantStore) ListTenants(ctx context.Context) ([]*tenant.Tenant, error) {
+	return m.tenants, nil
+}
+
+func (m *mockTenantStore) ListDomains(ctx context.Context, tenantID uuid.UUID) ([]*tenant.Domain, error) {
+	var domains []*tenant.Domain
+	for _, d := range m.domains {
+		if d.TenantID == tenantID {
+			domains = append(domains, d)
+		}
+	}
+	return domains, nil
+}
+
+func (m *mockTenantStore) AddDomain(ctx context.Context, d *tenant.Domain) error {
+	if _, err := m.GetTenantByHostname(ctx, d.Hostname); err == nil {
+		return tenant.ErrDomainTaken
+	}
+	m.domains = append(m.domains, d)
+	return nil
+}
+
+func (m *mockTenantStore) RemoveDomain(ctx context.Context, tenantID uuid.UUID, hostname string) error {
+	for i, d := range m.domains {
+		if d.TenantID == tenantID && d.Hostname == hostname {
+			m.domains = append(m.domains[:i], m.domains[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *mockTenantStore) CrossPostSurvey(ctx context.Context, tenantID, surveyID uuid.UUID, by string) error {
+	for _, id := range m.crossPosted[tenantID] {
+		if id == surveyID {
+			return nil
+		}
+	}
+	m.crossPosted[tenantID] = append(m.crossPosted[tenantID], surveyID)
+	return nil
+}
+
+func (m *mockTenantStore) RemoveCrossPost(ctx context.Context, tenantID, surveyID uuid.UUID) error {
+	for i, id := range m.crossPosted[tenantID] {
+		if id == surveyID {
+			m.crossPosted[tenantID] = append(m.crossPosted[tenantID][:i], m.crossPosted[tenantID][i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *mockTenantStore) ListTenantSurveys(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*models.Survey, error) {
+	var t *tenant.Tenant
+	for _, candidate := range m.tenants {
+		if candidate.ID == tenantID {
+			t = candidate
+		}
+	}
+	var surveys []*models.Survey
+	for _, s := range m.mq.Surveys {
+		own := t != nil && t.DefaultAuthorDID != nil && s.AuthorDID != nil && *s.AuthorDID == *t.DefaultAuthorDID
+		crossPosted := false
+		for _, id := range m.crossPosted[tenantID] {
+			crossPosted = crossPosted || id == s.ID
+		}
+		if (own || crossPosted) && s.Definition.IsListed() && s.HiddenAt == nil {
+			surveys = append(surveys, s)
+		}
+	}
+	return surveys, nil
+}
+
+func setupTenantTest() (*echo.Echo, *MockQueries, *Handlers, *mockTenantStore) {
+	e, mq, h := setupTest()
+	store := &mockTenantStore{mq: mq, crossPosted: make(map[uuid.UUID][]uuid.UUID)}
+	h.SetTenants(store)
+	h.SetAdmins([]string{"did:plc:admin"})
+	return e, mq, h, store
+}
+
+func TestTenantAdmin(t *testing.T) {
+	e, _, h, store := setupTenantTest()
+	admin := "did:plc:admin"
+
+	rec := callOrgAPI(t, e, h.CreateTenant, http.MethodPost, `{"slug": "acme", "name": "Acme Polls"}`, "did:plc:alice")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callOrgAPI(t, e, h.CreateTenant, http.MethodPost, `{"slug": "acme", "name": "Acme Polls", "accentColor": "#112233", "defaultAuthor": "did:plc:acme"}`, admin)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created TenantResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "acme", created.Slug)
+	assert.Equal(t, "did:plc:acme", *created.DefaultAuthorDID)
+	assert.Empty(t, created.Domains)
+
+	rec = callOrgAPI(t, e, h.CreateTenant, http.MethodPost, `{"slug": "acme", "name": "Other"}`, admin)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "slugs are unique")
+	rec = callOrgAPI(t, e, h.CreateTenant, http.MethodPost, `{"slug": "other", "name": "Other", "accentColor": "red"}`, admin)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	t.Run("domains", func(t *testing.T) {
+		add := func(hostname string) int {
+			return callOrgAPI(t, e, h.AddTenantDomain, http.MethodPost, `{"hostname": "`+hostname+`"}`, admin, "tenant", "acme").Code
+		}
+		assert.Equal(t, http.StatusCreated, add("Surveys.Acme.Example"))
+		assert.Equal(t, http.StatusBadRequest, add("surveys.acme.example"), "hostnames belong to one tenant")
+		assert.Equal(t, http.StatusBadRequest, add("localhost"))
+		assert.Equal(t, "surveys.acme.example", store.domains[0].Hostname)
+
+		rec := callOrgAPI(t, e, h.AddTenantDomain, http.MethodPost, `{"hostname": "polls.acme.example"}`, admin, "tenant", "nobody")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = callOrgAPI(t, e, h.ListTenants, http.MethodGet, "", admin)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var tenants []TenantResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tenants))
+		require.Len(t, tenants, 1)
+		assert.Equal(t, []string{"surveys.acme.example"}, tenants[0].Domains)
+	})
+
+	t.Run("update branding", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.UpdateTenant, http.MethodPut, `{"name": "Acme", "tagline": "Polls by Acme", "logoUrl": "http://acme.example/logo.png"}`, admin, "tenant", "acme")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "logos are served over https")
+
+		rec = callOrgAPI(t, e, h.UpdateTenant, http.MethodPut, `{"name": "Acme", "tagline": "Polls by Acme"}`, admin, "tenant", "acme")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		acme, err := store.GetTenantBySlug(context.Background(), "acme")
+		require.NoError(t, err)
+		assert.Equal(t, "Polls by Acme", acme.Tagline)
+		assert.Nil(t, acme.DefaultAuthorDID, "the update replaces the default author")
+	})
+
+	rec = callOrgAPI(t, e, h.RemoveTenantDomain, http.MethodDelete, "", admin, "tenant", "hostname", "acme", "surveys.acme.example")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = callOrgAPI(t, e, h.RemoveTenantDomain, http.MethodDelete, "", admin, "tenant", "hostname", "acme", "surveys.acme.example")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTenantHosts(t *testing.T) {
+	e, mq, h, store := setupTenantTest()
+	SetupRoutes(e, h, &HealthHandlers{}, nil, nil)
+	ctx := context.Background()
+
+	partner := "did:plc:acme"
+	acme, err := tenant.New("acme", "Acme Polls")
+	require.NoError(t, err)
+	acme.DefaultAuthorDID = &partner
+	acme.AccentColor = "#112233"
+	acme.Tagline = "Polls by Acme"
+	require.NoError(t, store.CreateTenant(ctx, acme))
+	_, err = h.addTenantDomain(ctx, acme, "surveys.acme.example")
+	require.NoError(t, err)
+
+	own := createTextSurvey(mq, "acme-own", &partner)
+	own.Title = "Acme survey"
+	other := "did:plc:other"
+	crossPosted := createTextSurvey(mq, "cross-posted", &other)
+	crossPosted.Title = "Cross-posted survey"
+	elsewhere := createTextSurvey(mq, "elsewhere", &other)
+	elsewhere.Title = "Survey elsewhere"
+	unlisted := createTextSurvey(mq, "unlisted", &other)
+	unlisted.Definition.Visibility = models.VisibilityUnlisted
+
+	get := func(host, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("cross-posting", func(t *testing.T) {
+		crossPost := func(user, slug string) int {
+			return callOrgAPI(t, e, h.CrossPostSurvey, http.MethodPut, "", user, "tenant", "slug", "acme", slug).Code
+		}
+		assert.Equal(t, http.StatusForbidden, crossPost(other, "cross-posted"))
+		assert.Equal(t, http.StatusBadRequest, crossPost(partner, "unlisted"), "only listed surveys are cross-posted")
+		assert.Equal(t, http.StatusNotFound, crossPost(partner, "missing"))
+		assert.Equal(t, http.StatusNoContent, crossPost(partner, "cross-posted"))
+		assert.Equal(t, http.StatusNoContent, crossPost("did:plc:admin", "elsewhere"))
+
+		rec := callOrgAPI(t, e, h.RemoveCrossPost, http.MethodDelete, "", partner, "tenant", "slug", "acme", "elsewhere")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		rec = callOrgAPI(t, e, h.RemoveCrossPost, http.MethodDelete, "", partner, "tenant", "slug", "acme", "elsewhere")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("tenant landing page", func(t *testing.T) {
+		rec := get("Surveys.Acme.Example:443", "/")
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "<title>Acme Polls - Acme Polls</title>")
+		assert.Contains(t, body, "Polls by Acme")
+		assert.Contains(t, body, "nav { background: #112233; }")
+		assert.Contains(t, body, own.Title)
+		assert.Contains(t, body, crossPosted.Title)
+		assert.NotContains(t, body, elsewhere.Title)
+		assert.NotContains(t, body, "OpenMeet Survey")
+	})
+
+	t.Run("other hosts are served as usual", func(t *testing.T) {
+		rec := get("survey.openmeet.example", "/")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Welcome to OpenMeet Survey")
+		assert.NotContains(t, rec.Body.String(), "Acme Polls")
+	})
+
+	t.Run("surveys are listed on tenant hosts only", func(t *testing.T) {
+		rec := get("surveys.acme.example", "/api/v1/tenant/surveys")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var surveys []SurveyListResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &surveys))
+		var slugs []string
+		for _, s := range surveys {
+			slugs = append(slugs, s.Slug)
+		}
+		assert.ElementsMatch(t, []string{"acme-own", "cross-posted"}, slugs)
+
+		rec = get("survey.openmeet.example", "/api/v1/tenant/surveys")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("survey pages carry the tenant's branding", func(t *testing.T) {
+		rec := get("surveys.acme.example", "/surveys/elsewhere")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Survey elsewhere - Acme Polls")
+	})
+}
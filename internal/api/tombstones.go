@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// deletedSurveyMessage explains why a deleted survey's form does not accept answers
+const deletedSurveyMessage = "This survey was deleted by its author"
+
+// deletedSurvey returns the tombstone of a deleted survey, or nil if no survey
+// with the slug was ever deleted
+func (h *Handlers) deletedSurvey(ctx context.Context, slug string) *models.SurveyTombstone {
+	tombstone, err := h.queries.GetSurveyTombstoneBySlug(ctx, slug)
+	if err != nil {
+		return nil
+	}
+	return tombstone
+}
+
+// surveyNotFoundHTML responds to a page request for a survey that does not
+// exist, with the "survey deleted" page if it was deleted
+func (h *Handlers) surveyNotFoundHTML(c echo.Context, slug string) error {
+	tombstone := h.deletedSurvey(c.Request().Context(), slug)
+	if tombstone == nil {
+		return c.String(http.StatusNotFound, "Survey not found")
+	}
+
+	user, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(http.StatusGone)
+	component := templates.SurveyDeleted(tombstone, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
+
+// surveyNotFoundJSON responds to an API request for a survey that does not
+// exist, with 410 Gone if it was deleted
+func (h *Handlers) surveyNotFoundJSON(c echo.Context, slug string) error {
+	if tombstone := h.deletedSurvey(c.Request().Context(), slug); tombstone != nil {
+		return Problem(c, problem.SurveyDeleted, fmt.Sprintf("The survey '%s' was deleted by its author on %s", slug, tombstone.DeletedAt.Format("2006-01-02")))
+	}
+	return Problem(c, problem.SurveyNotFound, fmt.Sprintf("No survey found with slug '%s'", slug))
+}
+
+// surveyNotFoundMessage is the error shown in place of the form of a survey
+// that does not exist
+func (h *Handlers) surveyNotFoundMessage(ctx context.Context, slug string) string {
+	if h.deletedSurvey(ctx, slug) != nil {
+		return deletedSurveyMessage
+	}
+	return "Survey not found"
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func slugRequest(e *echo.Echo, method, path, slug string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, path, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues(slug)
+	return c, rec
+}
+
+func TestDeletedSurvey(t *testing.T) {
+	e, mq, h := setupTest()
+	uri := "at://did:plc:author/net.openmeet.survey/gone1"
+	mq.Tombstones["gone"] = &models.SurveyTombstone{
+		Slug:      "gone",
+		URI:       &uri,
+		DeletedAt: time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("survey page", func(t *testing.T) {
+		c, rec := slugRequest(e, http.MethodGet, "/surveys/gone", "gone")
+		require.NoError(t, h.GetSurveyHTML(c))
+		assert.Equal(t, http.StatusGone, rec.Code)
+		assert.Contains(t, rec.Body.String(), "This survey was deleted")
+		assert.Contains(t, rec.Body.String(), "Mar 4, 2026")
+	})
+
+	t.Run("results page", func(t *testing.T) {
+		c, rec := slugRequest(e, http.MethodGet, "/surveys/gone/results", "gone")
+		require.NoError(t, h.GetResultsHTML(c))
+		assert.Equal(t, http.StatusGone, rec.Code)
+	})
+
+	t.Run("API", func(t *testing.T) {
+		c, rec := slugRequest(e, http.MethodGet, "/api/v1/surveys/gone", "gone")
+		require.NoError(t, h.GetSurvey(c))
+		require.Equal(t, http.StatusGone, rec.Code)
+
+		var resp problem.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, problem.SurveyDeleted, resp.Code)
+	})
+
+	t.Run("form submission", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/surveys/gone/responses", strings.NewReader("q1=hello"))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("slug")
+		c.SetParamValues("gone")
+		require.NoError(t, h.SubmitResponseHTML(c))
+		assert.Contains(t, rec.Body.String(), deletedSurveyMessage)
+	})
+
+	t.Run("AT URI redirects to the deleted page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/at/did:plc:author/gone1", nil), rec)
+		c.SetParamNames("did", "rkey")
+		c.SetParamValues("did:plc:author", "gone1")
+		require.NoError(t, h.ATProtoURL(c))
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, "/surveys/gone", rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("never-known slugs are still not found", func(t *testing.T) {
+		c, rec := slugRequest(e, http.MethodGet, "/surveys/unknown", "unknown")
+		require.NoError(t, h.GetSurveyHTML(c))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		c, rec = slugRequest(e, http.MethodGet, "/api/v1/surveys/unknown", "unknown")
+		require.NoError(t, h.GetSurvey(c))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
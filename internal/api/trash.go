@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+	"github.com/openmeet-team/survey/internal/trash"
+)
+
+// SetTrash enables the trash: deleted surveys are kept for trash.TTL, and
+// their authors can list and restore them
+func (h *Handlers) SetTrash(store trash.Store) {
+	h.trash = store
+}
+
+// Errors of restoreSurvey that the caller can fix
+var (
+	errSurveyNotInTrash = errors.New("survey not in trash")
+	errRestoreNeedsPDS  = errors.New("restoring a published survey needs a login session to publish its record again")
+	errForeignSurvey    = errors.New("surveys of other apps are restored in the app that created them")
+)
+
+// trashedSurvey finds a survey of an author in the trash by slug
+func (h *Handlers) trashedSurvey(ctx context.Context, authorDID, slug string) (*models.Survey, error) {
+	surveys, err := h.trash.ListDeletedSurveys(ctx, authorDID)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range surveys {
+		if s.Slug == slug {
+			return s, nil
+		}
+	}
+	return nil, errSurveyNotInTrash
+}
+
+// restoreSurvey takes a survey out of the trash. A published survey's record
+// was deleted from its author's PDS, so it is written again with the same
+// record key, which needs the author's login session.
+func (h *Handlers) restoreSurvey(c echo.Context, survey *models.Survey) error {
+	ctx := c.Request().Context()
+	var cid *string
+	if survey.URI != nil {
+		if survey.IsForeign() {
+			return errForeignSurvey
+		}
+		if h.oauthStorage == nil {
+			return errRestoreNeedsPDS
+		}
+		session, err := oauth.GetSession(c, h.oauthStorage)
+		if err != nil || session == nil || session.AccessToken == "" || session.DID != *survey.AuthorDID {
+			return errRestoreNeedsPDS
+		}
+
+		record := surveyRecord(survey.Title, &survey.Definition, survey.CreatedAt)
+		_, recordCID, err := h.writeRecord(ctx, session, "net.openmeet.survey", path.Base(*survey.URI), record)
+		if err != nil {
+			return err
+		}
+		cid = &recordCID
+	}
+
+	if err := h.trash.RestoreSurvey(ctx, survey.ID, cid); err != nil {
+		return err
+	}
+	survey.DeletedAt = nil
+	h.cache.Invalidate(ctx, cache.SurveyKey(survey.Slug))
+	return nil
+}
+
+// DeleteSurvey handles DELETE /api/v1/surveys/:slug
+// Moves a local survey to the trash. Published surveys are deleted by
+// deleting their record, which moves them to the trash when indexed.
+func (h *Handlers) DeleteSurvey(c echo.Context) error {
+	survey, _, err := h.manageableSurvey(c)
+	if survey == nil {
+		return err
+	}
+	if survey.URI != nil {
+		return Problem(c, problem.SurveyPublished, "Delete the survey record on its author's PDS")
+	}
+
+	ctx := c.Request().Context()
+	if err := h.trash.DeleteSurvey(ctx, survey.ID); err != nil {
+		return InternalServerError(c, "Failed to delete survey", err)
+	}
+	h.cache.Invalidate(ctx, cache.SurveyKey(survey.Slug))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListTrash handles GET /api/v1/trash
+// Lists the caller's surveys in the trash, most recently deleted first
+func (h *Handlers) ListTrash(c echo.Context) error {
+	owner, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+
+	surveys, err := h.trash.ListDeletedSurveys(c.Request().Context(), owner)
+	if err != nil {
+		return InternalServerError(c, "Failed to list deleted surveys", err)
+	}
+
+	result := make([]TrashedSurveyResponse, len(surveys))
+	for i, s := range surveys {
+		result[i] = TrashedSurveyResponse{
+			SurveyListResponse: *ToSurveyListResponse(s),
+			DeletedAt:          *s.DeletedAt,
+			ExpiresAt:          trash.ExpiresAt(s),
+		}
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// RestoreSurvey handles POST /api/v1/trash/:slug/restore
+// Takes a survey of the caller out of the trash. Published surveys need a
+// login session rather than an API key, to publish their record again.
+func (h *Handlers) RestoreSurvey(c echo.Context) error {
+	owner, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+
+	survey, err := h.trashedSurvey(c.Request().Context(), owner, c.Param("slug"))
+	if err == nil {
+		err = h.restoreSurvey(c, survey)
+	}
+	switch {
+	case errors.Is(err, errSurveyNotInTrash):
+		return Problem(c, problem.SurveyNotFound, "None of your surveys in the trash has this slug")
+	case errors.Is(err, errRestoreNeedsPDS), errors.Is(err, errForeignSurvey):
+		return Problem(c, problem.SurveyPublished, err.Error())
+	case err != nil:
+		return InternalServerError(c, "Failed to restore survey", err)
+	}
+
+	return c.JSON(http.StatusOK, ToSurveyResponse(survey, true))
+}
+
+// TrashPageHTML lists the logged-in user's surveys in the trash
+// GET /trash
+func (h *Handlers) TrashPageHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	return h.renderTrashPage(c, user.DID, "")
+}
+
+// RestoreSurveyHTML restores a survey from the trash and opens it
+// POST /trash/:slug/restore
+func (h *Handlers) RestoreSurveyHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+
+	survey, err := h.trashedSurvey(c.Request().Context(), user.DID, c.Param("slug"))
+	if err == nil {
+		err = h.restoreSurvey(c, survey)
+	}
+	switch {
+	case errors.Is(err, errSurveyNotInTrash):
+		return c.String(http.StatusNotFound, "Survey not found in your trash")
+	case errors.Is(err, errRestoreNeedsPDS), errors.Is(err, errForeignSurvey):
+		return h.renderTrashPage(c, user.DID, err.Error())
+	case err != nil:
+		c.Logger().Errorf("Failed to restore survey %s: %v", c.Param("slug"), err)
+		return h.renderTrashPage(c, user.DID, "Failed to restore the survey. Please try again.")
+	}
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/surveys/"+survey.Slug))
+}
+
+// renderTrashPage renders the trash of a user, with the error of a failed restore
+func (h *Handlers) renderTrashPage(c echo.Context, did, formError string) error {
+	surveys, err := h.trash.ListDeletedSurveys(c.Request().Context(), did)
+	if err != nil {
+		c.Logger().Errorf("Failed to list deleted surveys: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load the trash")
+	}
+
+	user, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.TrashPage(surveys, time.Now(), formError, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTrashStore moves surveys between MockQueries and the trash
+type mockTrashStore struct {
+	mq      *MockQueries
+	deleted []*models.Survey
+}
+
+func (m *mockTrashStore) DeleteSurvey(ctx context.Context, id uuid.UUID) error {
+	for slug, s := range m.mq.Surveys {
+		if s.ID == id {
+			now := time.Now()
+			s.DeletedAt = &now
+			delete(m.mq.Surveys, slug)
+			m.mq.Tombstones[slug] = &models.SurveyTombstone{Slug: slug, URI: s.URI, AuthorDID: s.AuthorDID, DeletedAt: now}
+			m.deleted = append([]*models.Survey{s}, m.deleted...)
+		}
+	}
+	return nil
+}
+
+func (m *mockTrashStore) ListDeletedSurveys(ctx context.Context, authorDID string) ([]*models.Survey, error) {
+	var surveys []*models.Survey
+	for _, s := range m.deleted {
+		if s.AuthorDID != nil && *s.AuthorDID == authorDID {
+			surveys = append(surveys, s)
+		}
+	}
+	return surveys, nil
+}
+
+func (m *mockTrashStore) RestoreSurvey(ctx context.Context, id uuid.UUID, cid *string) error {
+	for i, s := range m.deleted {
+		if s.ID == id {
+			s.DeletedAt = nil
+			m.mq.Surveys[s.Slug] = s
+			delete(m.mq.Tombstones, s.Slug)
+			m.deleted = append(m.deleted[:i], m.deleted[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *mockTrashStore) PurgeDeletedSurveys(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestSurveyTrash(t *testing.T) {
+	e, mq, h := setupTest()
+	store := &mockTrashStore{mq: mq}
+	h.SetTrash(store)
+	alice := "did:plc:alice"
+	createTextSurvey(mq, "lunch", &alice)
+	published := createTextSurvey(mq, "published", &alice)
+	uri := "at://did:plc:alice/net.openmeet.survey/published"
+	published.URI = &uri
+
+	call := func(handler func(echo.Context) error, method, path, slug, owner string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(method, path, nil), rec)
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		c.Set("api_key", &apikey.Key{ID: uuid.New(), OwnerDID: owner})
+		require.NoError(t, handler(c))
+		return rec
+	}
+
+	t.Run("authors move local surveys to the trash", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, call(h.DeleteSurvey, http.MethodDelete, "/api/v1/surveys/lunch", "lunch", "did:plc:mallory").Code)
+		assert.Equal(t, http.StatusConflict, call(h.DeleteSurvey, http.MethodDelete, "/api/v1/surveys/published", "published", alice).Code)
+
+		assert.Equal(t, http.StatusNoContent, call(h.DeleteSurvey, http.MethodDelete, "/api/v1/surveys/lunch", "lunch", alice).Code)
+		rec := call(h.GetSurvey, http.MethodGet, "/api/v1/surveys/lunch", "lunch", alice)
+		assert.Equal(t, http.StatusGone, rec.Code, "links to it say it was deleted")
+	})
+
+	t.Run("the trash lists the caller's deleted surveys", func(t *testing.T) {
+		var trashed []TrashedSurveyResponse
+		rec := call(h.ListTrash, http.MethodGet, "/api/v1/trash", "", alice)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &trashed))
+		require.Len(t, trashed, 1)
+		assert.Equal(t, "lunch", trashed[0].Slug)
+		assert.WithinDuration(t, trashed[0].DeletedAt.Add(30*24*time.Hour), trashed[0].ExpiresAt, time.Second)
+
+		rec = call(h.ListTrash, http.MethodGet, "/api/v1/trash", "", "did:plc:mallory")
+		assert.JSONEq(t, `[]`, rec.Body.String())
+	})
+
+	t.Run("only authors restore their surveys", func(t *testing.T) {
+		rec := call(h.RestoreSurvey, http.MethodPost, "/api/v1/trash/lunch/restore", "lunch", "did:plc:mallory")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = call(h.RestoreSurvey, http.MethodPost, "/api/v1/trash/lunch/restore", "lunch", alice)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, http.StatusOK, call(h.GetSurvey, http.MethodGet, "/api/v1/surveys/lunch", "lunch", alice).Code)
+	})
+
+	t.Run("published surveys need a login to publish their record again", func(t *testing.T) {
+		require.NoError(t, store.DeleteSurvey(context.Background(), published.ID))
+		rec := call(h.RestoreSurvey, http.MethodPost, "/api/v1/trash/published/restore", "published", alice)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.NotNil(t, published.DeletedAt, "still in the trash")
+	})
+}
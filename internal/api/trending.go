@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+	"github.com/openmeet-team/survey/internal/trending"
+)
+
+// SetTrending enables the trending and featured surveys of the landing page,
+// and the admin pages to feature surveys
+func (h *Handlers) SetTrending(store trending.Store) {
+	h.trending = store
+}
+
+// landingSurveys returns the featured and trending surveys of the landing
+// page. They are left out if they can't be loaded, rather than failing the page.
+func (h *Handlers) landingSurveys(c echo.Context) ([]*models.Survey, []*trending.Entry) {
+	if h.trending == nil {
+		return nil, nil
+	}
+	ctx := c.Request().Context()
+
+	featured, err := h.trending.ListFeaturedSurveys(ctx)
+	if err != nil {
+		c.Logger().Warnf("Failed to load featured surveys: %v", err)
+	}
+	entries, err := h.trending.GetTrendingSurveys(ctx, trending.Limit)
+	if err != nil {
+		c.Logger().Warnf("Failed to load trending surveys: %v", err)
+	}
+	return trending.Listed(featured), entries
+}
+
+// errNotListed is returned when featuring a survey that can't be listed
+var errNotListed = errors.New("only public surveys that are not hidden can be featured")
+
+// featureSurvey features a survey, if it can be listed
+func (h *Handlers) featureSurvey(ctx context.Context, survey *models.Survey, adminDID string) error {
+	if len(trending.Listed([]*models.Survey{survey})) == 0 {
+		return errNotListed
+	}
+	return h.trending.FeatureSurvey(ctx, survey.ID, adminDID)
+}
+
+// ListFeaturedSurveys handles GET /api/v1/admin/featured
+// Lists the featured surveys, most recently featured first, for admins
+func (h *Handlers) ListFeaturedSurveys(c echo.Context) error {
+	did, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+	if !h.adminDIDs[did] {
+		return Problem(c, problem.Forbidden, "Only admins can feature surveys")
+	}
+
+	surveys, err := h.trending.ListFeaturedSurveys(c.Request().Context())
+	if err != nil {
+		return InternalServerError(c, "Failed to list featured surveys", err)
+	}
+
+	result := make([]SurveyListResponse, len(surveys))
+	for i, s := range surveys {
+		result[i] = *ToSurveyListResponse(s)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// FeatureSurvey handles PUT /api/v1/admin/featured/:slug
+// Features a public survey on the landing page, for admins
+func (h *Handlers) FeatureSurvey(c echo.Context) error {
+	did, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+	if !h.adminDIDs[did] {
+		return Problem(c, problem.Forbidden, "Only admins can feature surveys")
+	}
+
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return h.surveyNotFoundJSON(c, slug)
+	}
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+
+	err = h.featureSurvey(ctx, survey, did)
+	switch {
+	case errors.Is(err, errNotListed):
+		return Problem(c, problem.ValidationFailed, "Only public surveys that are not hidden can be featured")
+	case errors.Is(err, trending.ErrTooManyFeatured):
+		return Problem(c, problem.LimitReached, fmt.Sprintf("At most %d surveys can be featured; unfeature one first", trending.MaxFeatured))
+	case err != nil:
+		return InternalServerError(c, "Failed to feature survey", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UnfeatureSurvey handles DELETE /api/v1/admin/featured/:slug
+// Stops featuring a survey, for admins
+func (h *Handlers) UnfeatureSurvey(c echo.Context) error {
+	did, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+	if !h.adminDIDs[did] {
+		return Problem(c, problem.Forbidden, "Only admins can feature surveys")
+	}
+
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+	survey, err := h.surveyBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return h.surveyNotFoundJSON(c, slug)
+	}
+	if err != nil {
+		return InternalServerError(c, "Failed to retrieve survey", err)
+	}
+
+	if err := h.trending.UnfeatureSurvey(ctx, survey.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.NotFound, "The survey is not featured")
+		}
+		return InternalServerError(c, "Failed to unfeature survey", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// FeaturedPageHTML renders the featured surveys with a form to feature more,
+// for admins
+// GET /admin/featured
+func (h *Handlers) FeaturedPageHTML(c echo.Context) error {
+	user, _ := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.adminDIDs[user.DID] {
+		return c.String(http.StatusForbidden, "Only admins can feature surveys")
+	}
+	return h.renderFeaturedPage(c, "")
+}
+
+// renderFeaturedPage renders the featured surveys, with the error of a failed feature
+func (h *Handlers) renderFeaturedPage(c echo.Context, formError string) error {
+	surveys, err := h.trending.ListFeaturedSurveys(c.Request().Context())
+	if err != nil {
+		c.Logger().Errorf("Failed to list featured surveys: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load featured surveys")
+	}
+
+	user, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.FeaturedPage(surveys, formError, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
+
+// FeatureSurveyHTML features the survey of the form's slug, for admins
+// POST /admin/featured
+func (h *Handlers) FeatureSurveyHTML(c echo.Context) error {
+	user, _ := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.adminDIDs[user.DID] {
+		return c.String(http.StatusForbidden, "Only admins can feature surveys")
+	}
+
+	ctx := c.Request().Context()
+	slug := c.FormValue("slug")
+	survey, err := h.surveyBySlug(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return h.renderFeaturedPage(c, fmt.Sprintf("No survey found with slug '%s'", slug))
+	}
+	if err != nil {
+		c.Logger().Errorf("Failed to load survey: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	err = h.featureSurvey(ctx, survey, user.DID)
+	switch {
+	case errors.Is(err, errNotListed):
+		return h.renderFeaturedPage(c, "Only public surveys that are not hidden can be featured")
+	case errors.Is(err, trending.ErrTooManyFeatured):
+		return h.renderFeaturedPage(c, fmt.Sprintf("At most %d surveys can be featured; unfeature one first", trending.MaxFeatured))
+	case err != nil:
+		c.Logger().Errorf("Failed to feature survey: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to feature survey")
+	}
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/admin/featured"))
+}
+
+// UnfeatureSurveyHTML stops featuring a survey, for admins
+// POST /admin/featured/:slug/remove
+func (h *Handlers) UnfeatureSurveyHTML(c echo.Context) error {
+	user, _ := getUserAndProfile(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+	if !h.adminDIDs[user.DID] {
+		return c.String(http.StatusForbidden, "Only admins can feature surveys")
+	}
+
+	ctx := c.Request().Context()
+	survey, err := h.surveyBySlug(ctx, c.Param("slug"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, "Survey not found")
+	}
+	if err != nil {
+		c.Logger().Errorf("Failed to load survey: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	if err := h.trending.UnfeatureSurvey(ctx, survey.ID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.Logger().Errorf("Failed to unfeature survey: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to unfeature survey")
+	}
+
+	return c.Redirect(http.StatusSeeOther, templates.AppPath("/admin/featured"))
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/trending"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTrending keeps featured surveys in memory, next to fixed trending ones
+type mockTrending struct {
+	trending []*trending.Entry
+	featured []*models.Survey
+}
+
+func (m *mockTrending) RefreshTrendingSurveys(ctx context.Context) error { return nil }
+
+func (m *mockTrending) GetTrendingSurveys(ctx context.Context, limit int) ([]*trending.Entry, error) {
+	return m.trending, nil
+}
+
+func (m *mockTrending) ListFeaturedSurveys(ctx context.Context) ([]*models.Survey, error) {
+	return m.featured, nil
+}
+
+func (m *mockTrending) FeatureSurvey(ctx context.Context, surveyID uuid.UUID, by string) error {
+	for _, s := range m.featured {
+		if s.ID == surveyID {
+			return nil
+		}
+	}
+	if len(m.featured) >= trending.MaxFeatured {
+		return trending.ErrTooManyFeatured
+	}
+	m.featured = append(m.featured, &models.Survey{ID: surveyID})
+	return nil
+}
+
+func (m *mockTrending) UnfeatureSurvey(ctx context.Context, surveyID uuid.UUID) error {
+	for i, s := range m.featured {
+		if s.ID == surveyID {
+			m.featured = append(m.featured[:i], m.featured[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func TestLandingPage_TrendingAndFeatured(t *testing.T) {
+	e, _, h := setupTest()
+	now := time.Now()
+	h.SetTrending(&mockTrending{
+		featured: []*models.Survey{
+			{ID: uuid.New(), Slug: "picked", Title: "Picked by us"},
+			{ID: uuid.New(), Slug: "hidden", Title: "Hidden since", HiddenAt: &now},
+		},
+		trending: []*trending.Entry{
+			{Survey: &models.Survey{ID: uuid.New(), Slug: "hot", Title: "Hot topic"}, Responses: 42, Score: 30},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	require.NoError(t, h.LandingPage(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "Featured Surveys")
+	assert.Contains(t, body, `href="/surveys/picked"`)
+	assert.NotContains(t, body, "Hidden since", "featured surveys that are no longer listed are left out")
+	assert.Contains(t, body, `href="/surveys/hot"`)
+	assert.Contains(t, body, "42 responses today")
+}
+
+func TestLandingPage_NoTrendingSurveys(t *testing.T) {
+	e, _, h := setupTest()
+	h.SetTrending(&mockTrending{})
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	require.NoError(t, h.LandingPage(c))
+	body := rec.Body.String()
+	assert.NotContains(t, body, "<h2>Featured Surveys</h2>")
+	assert.NotContains(t, body, "<h2>Trending</h2>", "empty sections are not shown")
+}
+
+func TestFeatureSurvey(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetAdmins([]string{"did:plc:admin"})
+	store := &mockTrending{}
+	h.SetTrending(store)
+
+	public := &models.Survey{ID: uuid.New(), Slug: "public", Title: "Public"}
+	unlisted := &models.Survey{ID: uuid.New(), Slug: "unlisted", Title: "Unlisted", Definition: models.SurveyDefinition{Visibility: models.VisibilityUnlisted}}
+	require.NoError(t, mq.CreateSurvey(context.Background(), public))
+	require.NoError(t, mq.CreateSurvey(context.Background(), unlisted))
+
+	call := func(method, owner, slug string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(method, "/api/v1/admin/featured/"+slug, nil), rec)
+		if owner != "" {
+			c.Set("api_key", &apikey.Key{ID: uuid.New(), OwnerDID: owner})
+		}
+		c.SetParamNames("slug")
+		c.SetParamValues(slug)
+		if method == http.MethodPut {
+			require.NoError(t, h.FeatureSurvey(c))
+		} else {
+			require.NoError(t, h.UnfeatureSurvey(c))
+		}
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodPut, "", "public").Code)
+	assert.Equal(t, http.StatusForbidden, call(http.MethodPut, "did:plc:alice", "public").Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPut, "did:plc:admin", "unlisted").Code, "only listed surveys can be featured")
+	assert.Equal(t, http.StatusNotFound, call(http.MethodPut, "did:plc:admin", "missing").Code)
+
+	assert.Equal(t, http.StatusNoContent, call(http.MethodPut, "did:plc:admin", "public").Code)
+	require.Len(t, store.featured, 1)
+	assert.Equal(t, public.ID, store.featured[0].ID)
+
+	assert.Equal(t, http.StatusNoContent, call(http.MethodDelete, "did:plc:admin", "public").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, "did:plc:admin", "public").Code, "the survey is no longer featured")
+}
+
+func TestFeatureSurvey_LimitReached(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetAdmins([]string{"did:plc:admin"})
+	store := &mockTrending{}
+	for i := 0; i < trending.MaxFeatured; i++ {
+		store.featured = append(store.featured, &models.Survey{ID: uuid.New()})
+	}
+	h.SetTrending(store)
+	require.NoError(t, mq.CreateSurvey(context.Background(), &models.Survey{ID: uuid.New(), Slug: "one-more", Title: "One more"}))
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPut, "/api/v1/admin/featured/one-more", nil), rec)
+	c.Set("api_key", &apikey.Key{ID: uuid.New(), OwnerDID: "did:plc:admin"})
+	c.SetParamNames("slug")
+	c.SetParamValues("one-more")
+	require.NoError(t, h.FeatureSurvey(c))
+	assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+}
+
+func TestFeatureSurveyHTML(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetAdmins([]string{"did:plc:admin"})
+	store := &mockTrending{}
+	h.SetTrending(store)
+	require.NoError(t, mq.CreateSurvey(context.Background(), &models.Survey{ID: uuid.New(), Slug: "public", Title: "Public"}))
+
+	call := func(user *oauth.User, slug string) *httptest.ResponseRecorder {
+		form := url.Values{"slug": {slug}}
+		req := httptest.NewRequest(http.MethodPost, "/admin/featured", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if user != nil {
+			c.Set("user", user)
+		}
+		require.NoError(t, h.FeatureSurveyHTML(c))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call(nil, "public").Code)
+	assert.Equal(t, http.StatusForbidden, call(&oauth.User{DID: "did:plc:alice"}, "public").Code)
+
+	rec := call(&oauth.User{DID: "did:plc:admin"}, "missing")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "No survey found with slug &#39;missing&#39;")
+
+	rec = call(&oauth.User{DID: "did:plc:admin"}, "public")
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Len(t, store.featured, 1)
+}
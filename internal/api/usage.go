@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/generator"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/templates"
+	"github.com/openmeet-team/survey/internal/usage"
+)
+
+// usageDays parses the ?days= period of a usage report
+func usageDays(c echo.Context) int {
+	days, err := strconv.Atoi(c.QueryParam("days"))
+	if err != nil || days < 1 {
+		return usage.DefaultDays
+	}
+	if days > usage.MaxDays {
+		return usage.MaxDays
+	}
+	return days
+}
+
+// usageReport builds the usage report of an author's keys and AI generations
+func (h *Handlers) usageReport(c echo.Context, ownerDID string, days int) (*usage.Report, error) {
+	ctx := c.Request().Context()
+	since := usage.Since(time.Now(), days)
+
+	keys, err := h.apiKeys.ListAPIKeysByOwner(ctx, ownerDID)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := h.apiKeys.ListAPIKeyUsage(ctx, ownerDID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var generations []*generator.DailyUsage
+	if h.generationUsage != nil {
+		generations, err = h.generationUsage.GetGenerationUsage(ctx, ownerDID, since)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return usage.Build(since, days, keys, requests, generations), nil
+}
+
+// GetUsage handles GET /api/v1/usage?days=30
+// Returns the caller's API requests, rate-limit hits, and AI generations per key and day
+func (h *Handlers) GetUsage(c echo.Context) error {
+	owner, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+
+	report, err := h.usageReport(c, owner, usageDays(c))
+	if err != nil {
+		return InternalServerError(c, "Failed to load usage", err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// UsageHTML renders the usage dashboard of the logged-in user
+// GET /usage?days=30
+func (h *Handlers) UsageHTML(c echo.Context) error {
+	user := oauth.GetUser(c)
+	if user == nil {
+		return c.String(http.StatusUnauthorized, "Authentication required")
+	}
+
+	report, err := h.usageReport(c, user.DID, usageDays(c))
+	if err != nil {
+		c.Logger().Errorf("Failed to load usage: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load usage")
+	}
+
+	_, profile := getUserAndProfile(c)
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	component := templates.UsagePage(report, user, profile, h.posthogKey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/generator"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockGenerationUsage returns fixed generation usage
+type mockGenerationUsage struct {
+	usage  []*generator.DailyUsage
+	userID string
+}
+
+func (m *mockGenerationUsage) GetGenerationUsage(ctx context.Context, userID string, since time.Time) ([]*generator.DailyUsage, error) {
+	m.userID = userID
+	return m.usage, nil
+}
+
+func TestGetUsage(t *testing.T) {
+	store := newMockAPIKeys()
+	key, _ := store.addKey(t, nil, 0)
+	today := apikey.Day(time.Now())
+	store.usage = []*apikey.Usage{{KeyID: key.ID, Day: today, Requests: 7, RateLimited: 1}}
+
+	generations := &mockGenerationUsage{usage: []*generator.DailyUsage{
+		{Day: today, APIKeyID: &key.ID, Generations: 2, Succeeded: 2, CostUSD: 0.5},
+	}}
+
+	h := NewHandlers(&MockQueries{})
+	h.SetAPIKeys(store, apikey.Config{}, nil)
+	h.SetGenerationUsageStore(generations)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/usage?days=7", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("user", &oauth.User{DID: "did:plc:owner"})
+
+	require.NoError(t, h.GetUsage(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "did:plc:owner", generations.userID)
+
+	var report usage.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, 7, report.Days)
+	require.Len(t, report.Keys, 1)
+	assert.Equal(t, 7, report.Keys[0].Requests)
+	assert.Equal(t, 1, report.Keys[0].RateLimited)
+	assert.Equal(t, 2, report.Keys[0].Generations)
+	assert.InDelta(t, 0.5, report.Totals.CostUSD, 1e-9)
+}
+
+func TestGetUsage_Unauthenticated(t *testing.T) {
+	h := NewHandlers(&MockQueries{})
+	h.SetAPIKeys(newMockAPIKeys(), apikey.Config{}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/usage", nil)
+	rec := httptest.NewRecorder()
+
+	require.NoError(t, h.GetUsage(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestUsageDays(t *testing.T) {
+	e := echo.New()
+	for query, want := range map[string]int{
+		"":          usage.DefaultDays,
+		"?days=7":   7,
+		"?days=0":   usage.DefaultDays,
+		"?days=abc": usage.DefaultDays,
+		"?days=365": usage.MaxDays,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/usage"+query, nil)
+		assert.Equal(t, want, usageDays(e.NewContext(req, httptest.NewRecorder())), query)
+	}
+}
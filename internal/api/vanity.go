@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/vanity"
+)
+
+// SetReservedSlugs enables reserved slugs, managed by admins. Only the
+// authors policy lets claim them can create surveys with them.
+func (h *Handlers) SetReservedSlugs(store vanity.Store, policy *vanity.Policy) {
+	h.reservedSlugs = store
+	h.vanityPolicy = policy
+}
+
+// listReservedSlugs returns the reservations, or none if reserved slugs are
+// not enabled
+func (h *Handlers) listReservedSlugs(ctx context.Context) ([]*vanity.Reservation, error) {
+	if h.reservedSlugs == nil {
+		return nil, nil
+	}
+	return h.reservedSlugs.ListReservedSlugs(ctx)
+}
+
+// reservedSlug returns the reservation of a slug the author may not claim,
+// or nil if the author may create a survey with it
+func (h *Handlers) reservedSlug(ctx context.Context, reservations []*vanity.Reservation, slug, did string) *vanity.Reservation {
+	r := vanity.Match(reservations, slug)
+	if r == nil || h.vanityPolicy.MayClaim(ctx, did) {
+		return nil
+	}
+	return r
+}
+
+// checkSlugReserved returns the reservation of a slug the author may not
+// claim, or nil
+func (h *Handlers) checkSlugReserved(ctx context.Context, slug, did string) (*vanity.Reservation, error) {
+	reservations, err := h.listReservedSlugs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return h.reservedSlug(ctx, reservations, slug, did), nil
+}
+
+// slugReservedMessage explains why a slug can't be used
+func slugReservedMessage(slug string, r *vanity.Reservation) string {
+	msg := fmt.Sprintf("The slug '%s' is reserved", slug)
+	if r.Reason != "" {
+		msg += ": " + r.Reason
+	}
+	return msg
+}
+
+// ListReservedSlugs handles GET /api/v1/admin/reserved-slugs
+// Lists the reserved slugs and namespaces, for admins
+func (h *Handlers) ListReservedSlugs(c echo.Context) error {
+	did, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+	if !h.adminDIDs[did] {
+		return Problem(c, problem.Forbidden, "Only admins can reserve slugs")
+	}
+
+	reservations, err := h.reservedSlugs.ListReservedSlugs(c.Request().Context())
+	if err != nil {
+		return InternalServerError(c, "Failed to list reserved slugs", err)
+	}
+	if reservations == nil {
+		reservations = []*vanity.Reservation{}
+	}
+
+	return c.JSON(http.StatusOK, ListReservedSlugsResponse{ReservedSlugs: reservations})
+}
+
+// ReserveSlug handles POST /api/v1/admin/reserved-slugs
+// Reserves a slug, or a namespace of slugs written "prefix-*", for admins.
+// Surveys that already have the slugs keep them.
+func (h *Handlers) ReserveSlug(c echo.Context) error {
+	did, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+	if !h.adminDIDs[did] {
+		return Problem(c, problem.Forbidden, "Only admins can reserve slugs")
+	}
+
+	var req ReserveSlugRequest
+	if err := c.Bind(&req); err != nil {
+		return Problem(c, problem.InvalidRequestBody, err.Error())
+	}
+
+	r, err := vanity.New(req.Pattern, req.Reason, did)
+	if err != nil {
+		return ValidationError(c, "Invalid request", err.Error())
+	}
+
+	if err := h.reservedSlugs.ReserveSlug(c.Request().Context(), r); err != nil {
+		if errors.Is(err, vanity.ErrAlreadyReserved) {
+			return ValidationError(c, "Invalid request", fmt.Sprintf("'%s' is already reserved", r.Pattern))
+		}
+		return InternalServerError(c, "Failed to reserve slug", err)
+	}
+
+	return c.JSON(http.StatusCreated, r)
+}
+
+// UnreserveSlug handles DELETE /api/v1/admin/reserved-slugs/:pattern
+// Releases a reserved slug or namespace, for admins
+func (h *Handlers) UnreserveSlug(c echo.Context) error {
+	did, ok := callerDID(c)
+	if !ok {
+		return Problem(c, problem.AuthenticationRequired, "Log in or use an API key")
+	}
+	if !h.adminDIDs[did] {
+		return Problem(c, problem.Forbidden, "Only admins can reserve slugs")
+	}
+
+	pattern, err := url.PathUnescape(c.Param("pattern"))
+	if err != nil {
+		return ValidationError(c, "Invalid request", "Invalid pattern")
+	}
+	pattern, err = vanity.NormalizePattern(pattern)
+	if err != nil {
+		return ValidationError(c, "Invalid request", err.Error())
+	}
+
+	if err := h.reservedSlugs.UnreserveSlug(c.Request().Context(), pattern); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Problem(c, problem.NotFound, fmt.Sprintf("'%s' is not reserved", pattern))
+		}
+		return InternalServerError(c, "Failed to unreserve slug", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/identity"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/openmeet-team/survey/internal/vanity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockReservedSlugStore keeps reservations in memory
+type mockReservedSlugStore struct {
+	reservations []*vanity.Reservation
+}
+
+func (m *mockReservedSlugStore) ListReservedSlugs(ctx context.Context) ([]*vanity.Reservation, error) {
+	return m.reservations, nil
+}
+
+func (m *mockReservedSlugStore) ReserveSlug(ctx context.Context, r *vanity.Reservation) error {
+	for _, existing := range m.reservations {
+		if existing.Pattern == r.Pattern {
+			return vanity.ErrAlreadyReserved
+		}
+	}
+	m.reservations = append(m.reservations, r)
+	return nil
+}
+
+func (m *mockReservedSlugStore) UnreserveSlug(ctx context.Context, pattern string) error {
+	for i, r := range m.reservations {
+		if r.Pattern == pattern {
+			m.reservations = append(m.reservations[:i], m.reservations[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// mockVerificationStore holds fresh verifications, so nothing is fetched
+type mockVerificationStore map[string]*identity.Verification
+
+func (m mockVerificationStore) GetVerification(ctx context.Context, did string) (*identity.Verification, error) {
+	return m[did], nil
+}
+
+func (m mockVerificationStore) UpsertVerification(ctx context.Context, v *identity.Verification) error {
+	m[v.DID] = v
+	return nil
+}
+
+func TestReservedSlugs(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetAdmins([]string{"did:plc:admin"})
+	h.SetBulkSurveys(mq)
+	store := &mockReservedSlugStore{}
+	policy := vanity.NewPolicy(vanity.Config{Admins: []string{"did:plc:admin"}, Authors: []string{"did:plc:brand"}}, nil)
+	h.SetReservedSlugs(store, policy)
+
+	t.Run("admins reserve slugs", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.ReserveSlug, http.MethodPost, `{"pattern": "official-*", "reason": "Kept for official surveys"}`, "did:plc:alice")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		for _, body := range []string{`{"pattern": "official-*", "reason": "Kept for official surveys"}`, `{"pattern": "News"}`} {
+			rec := callOrgAPI(t, e, h.ReserveSlug, http.MethodPost, body, "did:plc:admin")
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		rec = callOrgAPI(t, e, h.ReserveSlug, http.MethodPost, `{"pattern": "news"}`, "did:plc:admin")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "already reserved")
+		rec = callOrgAPI(t, e, h.ReserveSlug, http.MethodPost, `{"pattern": "official*"}`, "did:plc:admin")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = callOrgAPI(t, e, h.ListReservedSlugs, http.MethodGet, "", "did:plc:admin")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var list ListReservedSlugsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+		require.Len(t, list.ReservedSlugs, 2)
+		assert.Equal(t, "news", list.ReservedSlugs[1].Pattern)
+		assert.Equal(t, "did:plc:admin", list.ReservedSlugs[1].CreatedBy)
+	})
+
+	create := func(slug, owner string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(CreateSurveyRequest{Slug: slug, Definition: sessionFeedbackDefinition})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys", strings.NewReader(string(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if owner != "" {
+			c.Set("api_key", &apikey.Key{ID: uuid.New(), OwnerDID: owner})
+		}
+		require.NoError(t, h.CreateSurvey(c))
+		return rec
+	}
+
+	t.Run("others are refused reserved slugs", func(t *testing.T) {
+		for _, owner := range []string{"", "did:plc:alice"} {
+			rec := create("official-townhall", owner)
+			require.Equal(t, http.StatusConflict, rec.Code)
+			var p problem.Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+			assert.Equal(t, problem.SlugReserved, p.Code)
+			assert.Contains(t, p.Detail, "Kept for official surveys")
+		}
+		assert.Equal(t, http.StatusCreated, create("news-digest", "did:plc:alice").Code, "single slugs only reserve themselves")
+	})
+
+	t.Run("allow-listed authors and admins claim them", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, create("official-townhall", "did:plc:brand").Code)
+		assert.Equal(t, http.StatusCreated, create("news", "did:plc:admin").Code)
+	})
+
+	t.Run("verified authors claim them if configured", func(t *testing.T) {
+		now := time.Now()
+		verifier := identity.NewVerifier(mockVerificationStore{
+			"did:plc:verified":   {DID: "did:plc:verified", Handle: "verified.example.com", Verified: true, CheckedAt: now},
+			"did:plc:unverified": {DID: "did:plc:unverified", Handle: "unverified.example.com", CheckedAt: now},
+		}, time.Hour)
+		h.SetReservedSlugs(store, vanity.NewPolicy(vanity.Config{Authors: []string{"did:plc:brand"}}, verifier))
+		defer h.SetReservedSlugs(store, policy)
+
+		assert.Equal(t, http.StatusConflict, create("official-verified", "did:plc:verified").Code)
+		h.SetReservedSlugs(store, vanity.NewPolicy(vanity.Config{Authors: []string{"did:plc:brand"}, Verified: true}, verifier))
+		assert.Equal(t, http.StatusCreated, create("official-verified", "did:plc:verified").Code)
+		assert.Equal(t, http.StatusConflict, create("official-unverified", "did:plc:unverified").Code)
+	})
+
+	t.Run("bulk creation refuses reserved slugs", func(t *testing.T) {
+		body, _ := json.Marshal(BulkCreateSurveysRequest{Surveys: []CreateSurveyRequest{
+			{Slug: "bulk-feedback", Definition: sessionFeedbackDefinition},
+			{Slug: "official-bulk", Definition: sessionFeedbackDefinition},
+		}})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/surveys/bulk", strings.NewReader(string(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("api_key", &apikey.Key{ID: uuid.New(), OwnerDID: "did:plc:alice"})
+		require.NoError(t, h.CreateSurveysBulk(c))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var p problem.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		require.Len(t, p.Errors, 1)
+		assert.Equal(t, problem.ItemError{Index: 1, Code: problem.SlugReserved, Detail: p.Errors[0].Detail}, p.Errors[0])
+	})
+
+	t.Run("admins release slugs", func(t *testing.T) {
+		rec := callOrgAPI(t, e, h.UnreserveSlug, http.MethodDelete, "", "did:plc:admin", "pattern", "official-%2A")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		rec = callOrgAPI(t, e, h.UnreserveSlug, http.MethodDelete, "", "did:plc:admin", "pattern", "official-*")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, http.StatusCreated, create("official-lunch", "did:plc:alice").Code)
+	})
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/templates"
+)
+
+// closedSurveyMessage is shown to voters of a survey whose voting window has ended
+const closedSurveyMessage = "Voting on this survey has closed"
+
+// SurveyStatusHTML renders the countdown to the end of voting, polled by the
+// survey page so changes to the end time reach open pages. Once voting has
+// closed, HTMX is sent to the results instead.
+// GET /surveys/:slug/status
+func (h *Handlers) SurveyStatusHTML(c echo.Context) error {
+	slug := c.Param("slug")
+
+	survey, err := h.surveyBySlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "Survey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to load survey")
+	}
+
+	if h.surveyHidden(c, survey) {
+		return c.String(http.StatusNotFound, hiddenSurveyMessage)
+	}
+
+	if !h.canViewSurvey(c, survey) {
+		return c.String(http.StatusForbidden, privateSurveyMessage)
+	}
+
+	if survey.IsClosed(time.Now()) {
+		results := templates.AppPath("/surveys/" + survey.Slug + "/results")
+		if c.Request().Header.Get("HX-Request") == "" {
+			return c.Redirect(http.StatusSeeOther, results)
+		}
+		// A plain redirect would be followed by the XHR and swapped into the page
+		c.Response().Header().Set("HX-Redirect", results)
+		return c.NoContent(http.StatusOK)
+	}
+
+	component := templates.VotingCountdown(survey)
+	return component.Render(c.Request().Context(), c.Response().Writer)
+}
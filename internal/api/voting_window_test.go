@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func surveyPage(t *testing.T, e *echo.Echo, h *Handlers, method, path string, handler echo.HandlerFunc, hx bool) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader("q1=hello"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	if hx {
+		req.Header.Set("HX-Request", "true")
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("feedback")
+	require.NoError(t, handler(c))
+	return rec
+}
+
+func TestVotingWindow_Open(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := createTextSurvey(mq, "feedback", nil)
+	endsAt := time.Now().Add(2 * time.Hour)
+	survey.EndsAt = &endsAt
+
+	rec := surveyPage(t, e, h, http.MethodGet, "/surveys/feedback", h.GetSurveyHTML, false)
+	assert.Contains(t, rec.Body.String(), `id="voting-window"`)
+	assert.Contains(t, rec.Body.String(), `id="survey-form"`)
+
+	rec = surveyPage(t, e, h, http.MethodGet, "/surveys/feedback/status", h.SurveyStatusHTML, true)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("HX-Redirect"))
+	assert.Contains(t, rec.Body.String(), "Voting closes in")
+	assert.Contains(t, rec.Body.String(), "1h 59m")
+
+	assert.Equal(t, http.StatusCreated, submitText(t, e, h, "feedback", "hello").Code)
+}
+
+func TestVotingWindow_Closed(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := createTextSurvey(mq, "feedback", nil)
+	endsAt := time.Now().Add(-time.Minute)
+	survey.EndsAt = &endsAt
+
+	t.Run("the page shows no form", func(t *testing.T) {
+		rec := surveyPage(t, e, h, http.MethodGet, "/surveys/feedback", h.GetSurveyHTML, false)
+		assert.Contains(t, rec.Body.String(), "Voting closed on")
+		assert.NotContains(t, rec.Body.String(), `id="survey-form"`)
+		assert.NotContains(t, rec.Body.String(), `id="voting-window"`)
+	})
+
+	t.Run("the status sends HTMX to the results", func(t *testing.T) {
+		rec := surveyPage(t, e, h, http.MethodGet, "/surveys/feedback/status", h.SurveyStatusHTML, true)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "/surveys/feedback/results", rec.Header().Get("HX-Redirect"))
+		assert.Empty(t, rec.Body.String())
+	})
+
+	t.Run("the status redirects other clients", func(t *testing.T) {
+		rec := surveyPage(t, e, h, http.MethodGet, "/surveys/feedback/status", h.SurveyStatusHTML, false)
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, "/surveys/feedback/results", rec.Header().Get("Location"))
+	})
+
+	t.Run("the API rejects responses", func(t *testing.T) {
+		rec := submitText(t, e, h, "feedback", "hello")
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		var resp problem.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, problem.SurveyClosed, resp.Code)
+	})
+
+	t.Run("the form rejects responses", func(t *testing.T) {
+		rec := surveyPage(t, e, h, http.MethodPost, "/surveys/feedback/responses", h.SubmitResponseHTML, true)
+		assert.Contains(t, rec.Body.String(), closedSurveyMessage)
+
+		rec = surveyPage(t, e, h, http.MethodPost, "/surveys/feedback/review", h.ReviewResponseHTML, true)
+		assert.Contains(t, rec.Body.String(), closedSurveyMessage)
+	})
+
+	assert.Empty(t, mq.Responses, "no response was saved")
+}
+
+func TestVotingWindow_UnknownSurvey(t *testing.T) {
+	e, _, h := setupTest()
+	rec := surveyPage(t, e, h, http.MethodGet, "/surveys/feedback/status", h.SurveyStatusHTML, true)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
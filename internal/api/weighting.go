@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/weighting"
+)
+
+// parseWeighting parses the weighting of a results query, or returns nil if
+// there is none:
+//   - weightBy: the single-choice question whose answers group the responses
+//   - targets: the share each group should have, as option:share pairs, e.g. a:60,b:40
+func parseWeighting(c echo.Context, survey *models.Survey) (*weighting.Spec, error) {
+	weightBy, targets := c.QueryParam("weightBy"), c.QueryParam("targets")
+	if weightBy == "" && targets == "" {
+		return nil, nil
+	}
+	if weightBy == "" || targets == "" {
+		return nil, errors.New("Weighting needs both 'weightBy' and 'targets'")
+	}
+
+	spec := &weighting.Spec{WeightBy: weightBy}
+	var err error
+	if spec.Targets, err = weighting.ParseTargets(targets); err != nil {
+		return nil, err
+	}
+	if err := spec.Validate(&survey.Definition); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createWeightingSurvey creates a survey of the author's asking for an age
+// group and a yes/no question, answered by three young voters saying yes and
+// one old voter saying no
+func createWeightingSurvey(mq *MockQueries, author string) {
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		Slug:      "tea",
+		Title:     "Tea",
+		AuthorDID: &author,
+		Definition: models.SurveyDefinition{Questions: []models.Question{
+			{ID: "age", Text: "Age?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "young", Text: "Young"}, {ID: "old", Text: "Old"}}},
+			{ID: "tea", Text: "Tea?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "yes", Text: "Yes"}, {ID: "no", Text: "No"}}},
+		}},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	mq.CreateSurvey(context.Background(), survey)
+
+	for i, answers := range [][2]string{{"young", "yes"}, {"young", "yes"}, {"young", "yes"}, {"old", "no"}} {
+		mq.CreateResponse(context.Background(), &models.Response{
+			ID:       uuid.New(),
+			SurveyID: survey.ID,
+			Answers: map[string]models.Answer{
+				"age": {SelectedOptions: []string{answers[0]}},
+				"tea": {SelectedOptions: []string{answers[1]}},
+			},
+			CreatedAt: time.Now().Add(time.Duration(i) * time.Second),
+		})
+	}
+}
+
+func getWeightedResults(t *testing.T, e *echo.Echo, h *Handlers, query string, user *oauth.User) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/surveys/tea/results?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("slug")
+	c.SetParamValues("tea")
+	if user != nil {
+		c.Set("user", user)
+	}
+	require.NoError(t, h.GetResults(c))
+	return rec
+}
+
+func TestGetResults_Weighted(t *testing.T) {
+	e, mq, h := setupTest()
+	author := &oauth.User{DID: "did:plc:author"}
+	createWeightingSurvey(mq, author.DID)
+
+	rec := getWeightedResults(t, e, h, "weightBy=age&targets=young:50,old:50", author)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp SurveyResultsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.SurveyResults, "raw results are kept")
+	require.NotNil(t, resp.Weighting)
+	assert.Equal(t, 4, resp.Weighting.WeightedResponses)
+	assert.Equal(t, 50.0, resp.Weighting.QuestionResults["tea"].OptionPercentages["yes"])
+	assert.Equal(t, 2.0, resp.Weighting.QuestionResults["tea"].OptionCounts["no"])
+}
+
+func TestGetResults_Unweighted(t *testing.T) {
+	e, mq, h := setupTest()
+	createWeightingSurvey(mq, "did:plc:author")
+
+	rec := getWeightedResults(t, e, h, "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "weighting")
+}
+
+func TestGetResults_WeightingErrors(t *testing.T) {
+	e, mq, h := setupTest()
+	author := &oauth.User{DID: "did:plc:author"}
+	createWeightingSurvey(mq, author.DID)
+
+	tests := []struct {
+		name   string
+		query  string
+		user   *oauth.User
+		status int
+	}{
+		{"anonymous caller", "weightBy=age&targets=young:1,old:1", nil, http.StatusForbidden},
+		{"other user", "weightBy=age&targets=young:1,old:1", &oauth.User{DID: "did:plc:other"}, http.StatusForbidden},
+		{"missing targets", "weightBy=age", author, http.StatusBadRequest},
+		{"unknown question", "weightBy=nope&targets=young:1", author, http.StatusBadRequest},
+		{"unknown option", "weightBy=age&targets=teen:1", author, http.StatusBadRequest},
+		{"malformed targets", "weightBy=age&targets=young", author, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getWeightedResults(t, e, h, tt.query, tt.user)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/serviceauth"
+)
+
+// Page sizes of XRPC queries, as in their lexicons
+const (
+	defaultXRPCLimit = 50
+	maxXRPCLimit     = 100
+)
+
+// serviceAuthErrors are the XRPC error names of rejected service auth tokens,
+// as the reference implementation names them
+var serviceAuthErrors = []struct {
+	err  error
+	name string
+}{
+	{serviceauth.ErrExpired, "JwtExpired"},
+	{serviceauth.ErrBadSignature, "BadJwtSignature"},
+	{serviceauth.ErrBadAudience, "BadJwtAudience"},
+	{serviceauth.ErrBadMethod, "BadJwtLexiconMethod"},
+}
+
+// SetServiceAuth enables ATProto service auth on the XRPC queries: clients
+// call them as a user with a token the user's PDS signed for this AppView,
+// which also opens the author-only queries
+func (h *Handlers) SetServiceAuth(verifier ServiceAuthInterface) {
+	h.serviceAuth = verifier
+}
+
+// ServiceAuthMiddleware authenticates XRPC calls carrying a service auth token
+// ("Authorization: Bearer <JWT>") for the called method. Calls without one
+// pass through anonymously.
+func (h *Handlers) ServiceAuthMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if header == "" {
+				return next(c)
+			}
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				return xrpcError(c, http.StatusUnauthorized, "AuthenticationRequired", "Expected Authorization: Bearer <service auth token>")
+			}
+
+			method := path.Base(c.Request().URL.Path)
+			did, err := h.serviceAuth.Verify(c.Request().Context(), token, method)
+			if err != nil {
+				name := "BadJwt"
+				for _, e := range serviceAuthErrors {
+					if errors.Is(err, e.err) {
+						name = e.name
+						break
+					}
+				}
+				return xrpcError(c, http.StatusUnauthorized, name, err.Error())
+			}
+
+			c.Set("service_auth_did", did)
+			return next(c)
+		}
+	}
+}
+
+// serviceAuthCaller returns the DID of the caller of an XRPC query with a
+// service auth token, or ""
+func serviceAuthCaller(c echo.Context) string {
+	did, _ := c.Get("service_auth_did").(string)
+	return did
+}
+
+// xrpcError writes an XRPC error. Errors declared by a query's lexicon are
+// returned with status 400, as ATProto clients expect.
+func xrpcError(c echo.Context, status int, name, message string) error {
+	return c.JSON(status, XRPCError{Error: name, Message: message})
+}
+
+// xrpcSurvey loads the survey of an XRPC query's uri parameter and checks that
+// the caller can see it. On failure it returns nil and the error response written.
+func (h *Handlers) xrpcSurvey(c echo.Context) (*models.Survey, error) {
+	uri := c.QueryParam("uri")
+	if !strings.HasPrefix(uri, "at://") {
+		return nil, xrpcError(c, http.StatusBadRequest, "InvalidRequest", "uri must be the AT URI of a net.openmeet.survey record")
+	}
+
+	survey, err := h.queries.GetSurveyByURI(c.Request().Context(), uri)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, xrpcError(c, http.StatusBadRequest, "SurveyNotFound", "No survey is indexed with this URI")
+		}
+		c.Logger().Errorf("Failed to get survey %s: %v", uri, err)
+		return nil, xrpcError(c, http.StatusInternalServerError, "InternalServerError", "Failed to retrieve survey")
+	}
+	if h.surveyHidden(c, survey) {
+		return nil, xrpcError(c, http.StatusBadRequest, "SurveyHidden", hiddenSurveyMessage)
+	}
+	if !h.canViewSurvey(c, survey) {
+		return nil, xrpcError(c, http.StatusBadRequest, "SurveyPrivate", privateSurveyMessage)
+	}
+	return survey, nil
+}
+
+// XRPCGetSurvey handles GET /xrpc/net.openmeet.survey.getSurvey?uri=
+func (h *Handlers) XRPCGetSurvey(c echo.Context) error {
+	survey, err := h.xrpcSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	resp := ToSurveyResponse(survey, true)
+	resp.Author = h.surveyAuthor(c.Request().Context(), survey)
+	return c.JSON(http.StatusOK, XRPCSurveyOutput{Survey: resp})
+}
+
+// XRPCGetResults handles GET /xrpc/net.openmeet.survey.getResults?uri=
+// The results are counted live from the indexed responses
+func (h *Handlers) XRPCGetResults(c echo.Context) error {
+	survey, err := h.xrpcSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	results, err := h.surveyResults(c.Request().Context(), survey.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to get results of survey %s: %v", survey.Slug, err)
+		return xrpcError(c, http.StatusInternalServerError, "InternalServerError", "Failed to retrieve results")
+	}
+	return c.JSON(http.StatusOK, XRPCResultsOutput{URI: *survey.URI, ResultsURI: survey.ResultsURI, Results: results})
+}
+
+// XRPCGetComments handles GET /xrpc/net.openmeet.survey.getComments?uri=&limit=&cursor=
+// Comments are paginated oldest first; the cursor is returned while more follow
+func (h *Handlers) XRPCGetComments(c echo.Context) error {
+	survey, err := h.xrpcSurvey(c)
+	if survey == nil {
+		return err
+	}
+
+	limit, ok := xrpcLimit(c)
+	if !ok {
+		return xrpcError(c, http.StatusBadRequest, "InvalidRequest", fmt.Sprintf("limit must be between 1 and %d", maxXRPCLimit))
+	}
+	var after *models.CommentCursor
+	if s := c.QueryParam("cursor"); s != "" {
+		if after, err = parseCommentCursor(s); err != nil {
+			return xrpcError(c, http.StatusBadRequest, "InvalidRequest", "Invalid cursor")
+		}
+	}
+
+	// One more than the page tells whether another page follows
+	comments, err := h.queries.ListSurveyCommentsAfter(c.Request().Context(), survey.ID, after, limit+1)
+	if err != nil {
+		c.Logger().Errorf("Failed to list comments of survey %s: %v", survey.Slug, err)
+		return xrpcError(c, http.StatusInternalServerError, "InternalServerError", "Failed to list comments")
+	}
+
+	out := XRPCCommentsOutput{URI: *survey.URI, Comments: []XRPCCommentView{}}
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[limit-1]
+		out.Cursor = formatCommentCursor(&models.CommentCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	for _, comment := range comments {
+		out.Comments = append(out.Comments, XRPCCommentView{
+			URI:       comment.RecordURI,
+			CID:       comment.RecordCID,
+			Author:    comment.AuthorDID,
+			Text:      comment.Text,
+			CreatedAt: comment.CreatedAt,
+			IndexedAt: comment.IndexedAt,
+		})
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+// XRPCGetResponses handles GET /xrpc/net.openmeet.survey.getResponses?uri=&limit=&cursor=
+// Lists who answered what in a non-anonymous survey, for its author calling
+// with a service auth token. Responses are paginated oldest first; the cursor
+// is returned while more follow.
+func (h *Handlers) XRPCGetResponses(c echo.Context) error {
+	caller := serviceAuthCaller(c)
+	if caller == "" {
+		return xrpcError(c, http.StatusUnauthorized, "AuthenticationRequired", "Call with a service auth token of the survey author")
+	}
+	survey, err := h.xrpcSurvey(c)
+	if survey == nil {
+		return err
+	}
+	ctx := c.Request().Context()
+	if !h.canReadSurveyAs(ctx, caller, survey) {
+		return xrpcError(c, http.StatusBadRequest, "NotSurveyAuthor", "Only the survey author can list responses")
+	}
+	if survey.Definition.Anonymous {
+		return xrpcError(c, http.StatusBadRequest, "SurveyAnonymous", "Voters of anonymous surveys are not disclosed; use the results instead")
+	}
+
+	limit, ok := xrpcLimit(c)
+	if !ok {
+		return xrpcError(c, http.StatusBadRequest, "InvalidRequest", fmt.Sprintf("limit must be between 1 and %d", maxXRPCLimit))
+	}
+	// The cursor is the offset of the next page
+	offset := 0
+	if s := c.QueryParam("cursor"); s != "" {
+		if offset, err = strconv.Atoi(s); err != nil || offset < 0 {
+			return xrpcError(c, http.StatusBadRequest, "InvalidRequest", "Invalid cursor")
+		}
+	}
+
+	responses, total, voters, err := h.voterResponses(ctx, survey, models.ResponseFilter{Limit: limit, Offset: offset})
+	if err != nil {
+		c.Logger().Errorf("Failed to list responses of survey %s: %v", survey.Slug, err)
+		return xrpcError(c, http.StatusInternalServerError, "InternalServerError", "Failed to list responses")
+	}
+	ordinals, err := h.queries.ListQuestionOrdinals(ctx, survey.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to list question ordinals of survey %s: %v", survey.Slug, err)
+		return xrpcError(c, http.StatusInternalServerError, "InternalServerError", "Failed to list responses")
+	}
+
+	export := buildExport(survey, responses, ordinals, nil)
+	out := XRPCResponsesOutput{
+		URI:       *survey.URI,
+		Total:     total,
+		Questions: export.Questions,
+		Responses: voterResponseViews(export, voters),
+	}
+	if next := offset + len(responses); len(responses) > 0 && next < total {
+		out.Cursor = strconv.Itoa(next)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+// xrpcLimit returns the page size of an XRPC query's limit parameter, or false
+// if it is out of range
+func xrpcLimit(c echo.Context) (int, bool) {
+	s := c.QueryParam("limit")
+	if s == "" {
+		return defaultXRPCLimit, true
+	}
+	limit, err := strconv.Atoi(s)
+	return limit, err == nil && limit >= 1 && limit <= maxXRPCLimit
+}
+
+// XRPCNotImplemented handles XRPC methods this AppView does not serve
+func (h *Handlers) XRPCNotImplemented(c echo.Context) error {
+	return xrpcError(c, http.StatusNotImplemented, "MethodNotImplemented", "Method not implemented")
+}
+
+// formatCommentCursor encodes a comment cursor as "<createdAt>::<id>", like
+// the AppView's timestamp cursors
+func formatCommentCursor(cursor *models.CommentCursor) string {
+	return cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "::" + cursor.ID.String()
+}
+
+// parseCommentCursor decodes a cursor of formatCommentCursor
+func parseCommentCursor(s string) (*models.CommentCursor, error) {
+	ts, id, ok := strings.Cut(s, "::")
+	if !ok {
+		return nil, errors.New("missing separator")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CommentCursor{CreatedAt: createdAt, ID: parsed}, nil
+}
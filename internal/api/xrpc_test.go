@@ -0,0 +1,260 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/serviceauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callXRPC calls an XRPC query handler with query parameters
+func callXRPC(t *testing.T, e *echo.Echo, handler echo.HandlerFunc, params url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/xrpc/net.openmeet.survey.query?"+params.Encode(), nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler(e.NewContext(req, rec)))
+	return rec
+}
+
+// createIndexedSurvey creates a text survey published as a record
+func createIndexedSurvey(mq *MockQueries, slug string) *models.Survey {
+	author := "did:plc:author"
+	survey := createTextSurvey(mq, slug, &author)
+	uri := "at://did:plc:author/net.openmeet.survey/" + slug
+	survey.URI = &uri
+	mq.SurveysByURI[uri] = survey
+	return survey
+}
+
+func TestXRPCGetSurvey(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := createIndexedSurvey(mq, "feedback")
+
+	rec := callXRPC(t, e, h.XRPCGetSurvey, url.Values{"uri": {*survey.URI}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	var out XRPCSurveyOutput
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Equal(t, survey.URI, out.Survey.URI)
+	assert.Equal(t, "feedback", out.Survey.Slug)
+	require.NotNil(t, out.Survey.Definition)
+
+	xrpcErr := func(rec *httptest.ResponseRecorder) string {
+		var body XRPCError
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body.Error
+	}
+
+	rec = callXRPC(t, e, h.XRPCGetSurvey, url.Values{})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "InvalidRequest", xrpcErr(rec))
+
+	rec = callXRPC(t, e, h.XRPCGetSurvey, url.Values{"uri": {"at://did:plc:author/net.openmeet.survey/missing"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "SurveyNotFound", xrpcErr(rec))
+
+	survey.Definition.Visibility = models.VisibilityToken
+	rec = callXRPC(t, e, h.XRPCGetResults, url.Values{"uri": {*survey.URI}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "SurveyPrivate", xrpcErr(rec))
+
+	rec = httptest.NewRecorder()
+	require.NoError(t, h.XRPCNotImplemented(e.NewContext(httptest.NewRequest(http.MethodGet, "/xrpc/app.bsky.feed.getTimeline", nil), rec)))
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+	assert.Equal(t, "MethodNotImplemented", xrpcErr(rec))
+}
+
+func TestXRPCGetResults(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := createIndexedSurvey(mq, "feedback")
+
+	rec := callXRPC(t, e, h.XRPCGetResults, url.Values{"uri": {*survey.URI}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	var out XRPCResultsOutput
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Equal(t, *survey.URI, out.URI)
+	require.NotNil(t, out.Results)
+	assert.Equal(t, survey.ID, out.Results.SurveyID)
+}
+
+func TestXRPCGetComments(t *testing.T) {
+	e, mq, h := setupTest()
+	survey := createIndexedSurvey(mq, "feedback")
+	start := time.Now().Add(-time.Hour).UTC()
+	for i := range 5 {
+		mq.Comments = append(mq.Comments, &models.Comment{
+			ID:        uuid.New(),
+			SurveyID:  survey.ID,
+			AuthorDID: "did:plc:commenter",
+			RecordURI: fmt.Sprintf("at://did:plc:commenter/net.openmeet.survey.comment/%d", i),
+			RecordCID: fmt.Sprintf("bafy%d", i),
+			Text:      fmt.Sprintf("Comment %d", i),
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	// Pages follow the cursor until none is returned
+	var texts []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		params := url.Values{"uri": {*survey.URI}, "limit": {"2"}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		rec := callXRPC(t, e, h.XRPCGetComments, params)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var out XRPCCommentsOutput
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+		for _, comment := range out.Comments {
+			assert.Equal(t, "did:plc:commenter", comment.Author)
+			texts = append(texts, comment.Text)
+		}
+		if cursor = out.Cursor; cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"Comment 0", "Comment 1", "Comment 2", "Comment 3", "Comment 4"}, texts)
+
+	for _, params := range []url.Values{
+		{"uri": {*survey.URI}, "limit": {"0"}},
+		{"uri": {*survey.URI}, "limit": {"101"}},
+		{"uri": {*survey.URI}, "cursor": {"nope"}},
+	} {
+		assert.Equal(t, http.StatusBadRequest, callXRPC(t, e, h.XRPCGetComments, params).Code, params.Encode())
+	}
+}
+
+func TestCommentCursor(t *testing.T) {
+	cursor := &models.CommentCursor{CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC), ID: uuid.New()}
+	parsed, err := parseCommentCursor(formatCommentCursor(cursor))
+	require.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(parsed.CreatedAt))
+	assert.Equal(t, cursor.ID, parsed.ID)
+}
+
+// fakeServiceAuth accepts tokens of the form "<did> <method>"
+type fakeServiceAuth struct{}
+
+func (fakeServiceAuth) Verify(ctx context.Context, token, method string) (string, error) {
+	did, lxm, _ := strings.Cut(token, " ")
+	if lxm != method {
+		return "", serviceauth.ErrBadMethod
+	}
+	return did, nil
+}
+
+// callXRPCAs calls an XRPC method through the service auth middleware, with
+// an Authorization header unless it is ""
+func callXRPCAs(t *testing.T, e *echo.Echo, h *Handlers, method string, handler echo.HandlerFunc, params url.Values, authorization string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/xrpc/"+method+"?"+params.Encode(), nil)
+	if authorization != "" {
+		req.Header.Set(echo.HeaderAuthorization, authorization)
+	}
+	rec := httptest.NewRecorder()
+	require.NoError(t, h.ServiceAuthMiddleware()(handler)(e.NewContext(req, rec)))
+	return rec
+}
+
+func TestServiceAuthMiddleware(t *testing.T) {
+	e, mq, h := setupTest()
+	h.SetServiceAuth(fakeServiceAuth{})
+	survey := createIndexedSurvey(mq, "private")
+	survey.Definition.Visibility = models.VisibilityToken
+	params := url.Values{"uri": {*survey.URI}}
+	const method = "net.openmeet.survey.getSurvey"
+
+	rec := callXRPCAs(t, e, h, method, h.XRPCGetSurvey, params, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "anonymous callers can't see private surveys")
+
+	rec = callXRPCAs(t, e, h, method, h.XRPCGetSurvey, params, "Bearer did:plc:author "+method)
+	assert.Equal(t, http.StatusOK, rec.Code, "the author can")
+
+	rec = callXRPCAs(t, e, h, method, h.XRPCGetSurvey, params, "Bearer did:plc:author net.openmeet.survey.getResults")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	var body XRPCError
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "BadJwtLexiconMethod", body.Error)
+
+	rec = callXRPCAs(t, e, h, method, h.XRPCGetSurvey, params, "Basic abc")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestXRPCGetResponses(t *testing.T) {
+	e, mq, h, survey := setupExportTest(t)
+	h.SetServiceAuth(fakeServiceAuth{})
+	uri := "at://did:plc:author/net.openmeet.survey/" + survey.Slug
+	survey.URI = &uri
+	mq.SurveysByURI[uri] = survey
+	const method = "net.openmeet.survey.getResponses"
+
+	get := func(params url.Values, caller string) *httptest.ResponseRecorder {
+		params.Set("uri", uri)
+		authorization := ""
+		if caller != "" {
+			authorization = "Bearer " + caller + " " + method
+		}
+		return callXRPCAs(t, e, h, method, h.XRPCGetResponses, params, authorization)
+	}
+
+	rec := get(url.Values{"limit": {"1"}}, "did:plc:author")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var out XRPCResponsesOutput
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Equal(t, uri, out.URI)
+	assert.Equal(t, 2, out.Total)
+	require.Len(t, out.Responses, 1)
+	require.NotNil(t, out.Responses[0].Voter)
+	assert.Equal(t, "did:plc:voter", out.Responses[0].Voter.DID)
+	assert.Equal(t, "1", out.Cursor)
+
+	rec = get(url.Values{"limit": {"1"}, "cursor": {out.Cursor}}, "did:plc:author")
+	require.Equal(t, http.StatusOK, rec.Code)
+	out = XRPCResponsesOutput{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	require.Len(t, out.Responses, 1)
+	assert.Nil(t, out.Responses[0].Voter)
+	assert.Empty(t, out.Cursor, "no more pages")
+
+	tests := []struct {
+		name   string
+		params url.Values
+		caller string
+		status int
+		error  string
+	}{
+		{"not authenticated", url.Values{}, "", http.StatusUnauthorized, "AuthenticationRequired"},
+		{"not the author", url.Values{}, "did:plc:someone", http.StatusBadRequest, "NotSurveyAuthor"},
+		{"invalid cursor", url.Values{"cursor": {"-1"}}, "did:plc:author", http.StatusBadRequest, "InvalidRequest"},
+		{"invalid limit", url.Values{"limit": {"101"}}, "did:plc:author", http.StatusBadRequest, "InvalidRequest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(tt.params, tt.caller)
+			assert.Equal(t, tt.status, rec.Code)
+			var body XRPCError
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.error, body.Error)
+		})
+	}
+
+	t.Run("anonymous survey", func(t *testing.T) {
+		survey.Definition.Anonymous = true
+		defer func() { survey.Definition.Anonymous = false }()
+		rec := get(url.Values{}, "did:plc:author")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "SurveyAnonymous")
+	})
+}
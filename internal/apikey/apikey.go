@@ -0,0 +1,180 @@
+// Package apikey authenticates machine clients of the JSON API. A key is a
+// random "sk_" token shown once at creation; only its SHA-256 hash is stored.
+// Keys belong to a user DID and carry scopes and their own rate limit.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenPrefix starts every API key token
+const TokenPrefix = "sk_"
+
+// Scopes of a key. Each scope includes the ones below it: admin keys can
+// write, and write keys can read.
+const (
+	ScopeRead  = "read"  // Read surveys, results, and status
+	ScopeWrite = "write" // Create surveys and submit responses
+	ScopeAdmin = "admin" // Manage the owner's API keys
+)
+
+// scopeLevels orders scopes for Allows
+var scopeLevels = map[string]int{
+	ScopeRead:  1,
+	ScopeWrite: 2,
+	ScopeAdmin: 3,
+}
+
+// Defaults
+const (
+	DefaultRateLimit = 120  // Requests per minute of a key created without a limit
+	MaxRateLimit     = 6000 // Highest rate limit a user can request
+	MaxKeysPerUser   = 20   // Active keys a user can hold
+	prefixLength     = 12   // Characters of the token kept for display, including "sk_"
+)
+
+// Key is an API key. The token itself is never stored.
+type Key struct {
+	ID         uuid.UUID  `json:"id"`
+	OwnerDID   string     `json:"ownerDid"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Start of the token, to tell keys apart
+	Hash       string     `json:"-"`      // Hex SHA-256 of the token
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rateLimit"` // Requests per minute
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Store persists API keys
+type Store interface {
+	CreateAPIKey(ctx context.Context, k *Key) error
+	// GetAPIKeyByHash returns sql.ErrNoRows if no key has the hash
+	GetAPIKeyByHash(ctx context.Context, hash string) (*Key, error)
+	ListAPIKeysByOwner(ctx context.Context, ownerDID string) ([]*Key, error)
+	CountActiveAPIKeys(ctx context.Context, ownerDID string) (int, error)
+	// RevokeAPIKey returns sql.ErrNoRows if the owner has no such active key
+	RevokeAPIKey(ctx context.Context, id uuid.UUID, ownerDID string) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+	// AddAPIKeyUsage adds request counts to the stored daily usage
+	AddAPIKeyUsage(ctx context.Context, usage []*Usage) error
+	// ListAPIKeyUsage returns the daily usage of an owner's keys since a day
+	ListAPIKeyUsage(ctx context.Context, ownerDID string, since time.Time) ([]*Usage, error)
+}
+
+// New creates a key and returns it with its token, which must be shown to
+// the user now as it cannot be recovered. A rateLimit of 0 uses the default.
+func New(ownerDID, name string, scopes []string, rateLimit int) (*Key, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("name is required")
+	}
+	if len(name) > 100 {
+		return nil, "", fmt.Errorf("name must be at most 100 characters")
+	}
+
+	scopes, err := NormalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if rateLimit == 0 {
+		rateLimit = DefaultRateLimit
+	}
+	if rateLimit < 1 || rateLimit > MaxRateLimit {
+		return nil, "", fmt.Errorf("rate limit must be between 1 and %d requests per minute", MaxRateLimit)
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &Key{
+		ID:        uuid.New(),
+		OwnerDID:  ownerDID,
+		Name:      name,
+		Prefix:    token[:prefixLength],
+		Hash:      Hash(token),
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+		CreatedAt: time.Now(),
+	}, token, nil
+}
+
+// generateToken returns a new random token
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return TokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns the hex SHA-256 of a token, as stored
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeScopes validates scopes and removes duplicates. No scopes means read only.
+func NormalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return []string{ScopeRead}, nil
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	var normalized []string
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if _, ok := scopeLevels[scope]; !ok {
+			return nil, fmt.Errorf("unknown scope %q (want read, write, or admin)", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// Allows reports whether the key grants scope, directly or through a higher scope
+func (k *Key) Allows(scope string) bool {
+	want := scopeLevels[scope]
+	for _, s := range k.Scopes {
+		if scopeLevels[s] >= want {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key has not been revoked
+func (k *Key) Active() bool {
+	return k.RevokedAt == nil
+}
+
+// Config controls how the JSON API treats requests without a key
+type Config struct {
+	Required bool // Reject /api/v1 requests without a valid key
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - API_KEY_REQUIRED: "true" to require an API key on /api/v1 (default: false, keys are optional)
+func ConfigFromEnv() Config {
+	required, _ := strconv.ParseBool(os.Getenv("API_KEY_REQUIRED"))
+	return Config{Required: required}
+}
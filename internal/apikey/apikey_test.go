@@ -0,0 +1,77 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	k, token, err := New("did:plc:alice", "  CI bot ", []string{"write"}, 0)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(token, TokenPrefix))
+	assert.Equal(t, "CI bot", k.Name)
+	assert.Equal(t, "did:plc:alice", k.OwnerDID)
+	assert.Equal(t, Hash(token), k.Hash)
+	assert.NotContains(t, k.Hash, token)
+	assert.True(t, strings.HasPrefix(token, k.Prefix))
+	assert.Len(t, k.Prefix, prefixLength)
+	assert.Equal(t, DefaultRateLimit, k.RateLimit)
+	assert.True(t, k.Active())
+
+	_, other, err := New("did:plc:alice", "CI bot", nil, 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, _, err := New("did:plc:alice", " ", nil, 0)
+	assert.Error(t, err)
+
+	_, _, err = New("did:plc:alice", "bot", []string{"delete"}, 0)
+	assert.Error(t, err)
+
+	_, _, err = New("did:plc:alice", "bot", nil, MaxRateLimit+1)
+	assert.Error(t, err)
+
+	_, _, err = New("did:plc:alice", "bot", nil, -1)
+	assert.Error(t, err)
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	scopes, err := NormalizeScopes(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ScopeRead}, scopes)
+
+	scopes, err = NormalizeScopes([]string{"Write", "read", "write"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ScopeWrite, ScopeRead}, scopes)
+}
+
+func TestAllows(t *testing.T) {
+	read := &Key{Scopes: []string{ScopeRead}}
+	assert.True(t, read.Allows(ScopeRead))
+	assert.False(t, read.Allows(ScopeWrite))
+	assert.False(t, read.Allows(ScopeAdmin))
+
+	write := &Key{Scopes: []string{ScopeWrite}}
+	assert.True(t, write.Allows(ScopeRead))
+	assert.True(t, write.Allows(ScopeWrite))
+	assert.False(t, write.Allows(ScopeAdmin))
+
+	admin := &Key{Scopes: []string{ScopeAdmin}}
+	assert.True(t, admin.Allows(ScopeRead))
+	assert.True(t, admin.Allows(ScopeWrite))
+	assert.True(t, admin.Allows(ScopeAdmin))
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("API_KEY_REQUIRED", "")
+	assert.False(t, ConfigFromEnv().Required)
+
+	t.Setenv("API_KEY_REQUIRED", "true")
+	assert.True(t, ConfigFromEnv().Required)
+}
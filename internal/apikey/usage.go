@@ -0,0 +1,124 @@
+package apikey
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Usage counts a key's requests on a day (UTC)
+type Usage struct {
+	KeyID       uuid.UUID `json:"keyId"`
+	Day         time.Time `json:"day"`
+	Requests    int       `json:"requests"`    // Authenticated requests, including rate-limited ones
+	RateLimited int       `json:"rateLimited"` // Requests rejected by the key's rate limit
+}
+
+// usageKey identifies a counter
+type usageKey struct {
+	keyID uuid.UUID
+	day   time.Time
+}
+
+// UsageCounter counts requests per key in memory and periodically adds them
+// to the store, so authenticating a request does not cost a database write
+type UsageCounter struct {
+	store  Store
+	mu     sync.Mutex
+	counts map[usageKey]*Usage
+}
+
+// NewUsageCounter creates a usage counter flushing to store
+func NewUsageCounter(store Store) *UsageCounter {
+	return &UsageCounter{
+		store:  store,
+		counts: make(map[usageKey]*Usage),
+	}
+}
+
+// Record counts a request of a key. A nil counter records nothing.
+func (u *UsageCounter) Record(keyID uuid.UUID, rateLimited bool, now time.Time) {
+	if u == nil {
+		return
+	}
+
+	day := Day(now)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usage, ok := u.counts[usageKey{keyID, day}]
+	if !ok {
+		usage = &Usage{KeyID: keyID, Day: day}
+		u.counts[usageKey{keyID, day}] = usage
+	}
+	usage.Requests++
+	if rateLimited {
+		usage.RateLimited++
+	}
+}
+
+// Flush adds the counts recorded since the last flush to the store. Counts are
+// kept for the next flush if the store fails.
+func (u *UsageCounter) Flush(ctx context.Context) error {
+	u.mu.Lock()
+	counts := u.counts
+	u.counts = make(map[usageKey]*Usage)
+	u.mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	usage := make([]*Usage, 0, len(counts))
+	for _, c := range counts {
+		usage = append(usage, c)
+	}
+
+	if err := u.store.AddAPIKeyUsage(ctx, usage); err != nil {
+		u.mu.Lock()
+		for k, c := range counts {
+			if current, ok := u.counts[k]; ok {
+				current.Requests += c.Requests
+				current.RateLimited += c.RateLimited
+			} else {
+				u.counts[k] = c
+			}
+		}
+		u.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Run flushes counts every interval until ctx is cancelled, then flushes once more
+func (u *UsageCounter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := u.Flush(ctx); err != nil {
+				log.Printf("Failed to store API key usage: %v", err)
+			}
+		case <-ctx.Done():
+			// Final flush with a fresh context, as ctx is already cancelled
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := u.Flush(flushCtx); err != nil {
+				log.Printf("Failed to store API key usage: %v", err)
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// Day returns the UTC day of t, as usage is counted
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,79 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usageStore records added usage; other Store methods are unused
+type usageStore struct {
+	Store
+	added []*Usage
+	err   error
+}
+
+func (s *usageStore) AddAPIKeyUsage(ctx context.Context, usage []*Usage) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.added = append(s.added, usage...)
+	return nil
+}
+
+func TestUsageCounter(t *testing.T) {
+	store := &usageStore{}
+	u := NewUsageCounter(store)
+	key := uuid.New()
+	now := time.Date(2026, 3, 2, 23, 59, 0, 0, time.UTC)
+
+	u.Record(key, false, now)
+	u.Record(key, true, now)
+	u.Record(key, false, now.Add(2*time.Minute)) // Next day
+
+	require.NoError(t, u.Flush(context.Background()))
+	require.Len(t, store.added, 2)
+
+	byDay := make(map[time.Time]*Usage)
+	for _, usage := range store.added {
+		byDay[usage.Day] = usage
+	}
+	assert.Equal(t, &Usage{KeyID: key, Day: Day(now), Requests: 2, RateLimited: 1}, byDay[Day(now)])
+	assert.Equal(t, 1, byDay[time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)].Requests)
+
+	// Flushed counts are not added again
+	require.NoError(t, u.Flush(context.Background()))
+	assert.Len(t, store.added, 2)
+}
+
+func TestUsageCounter_KeepsCountsOnError(t *testing.T) {
+	store := &usageStore{err: errors.New("db down")}
+	u := NewUsageCounter(store)
+	key := uuid.New()
+	now := time.Now()
+
+	u.Record(key, false, now)
+	assert.Error(t, u.Flush(context.Background()))
+
+	u.Record(key, true, now)
+	store.err = nil
+	require.NoError(t, u.Flush(context.Background()))
+	require.Len(t, store.added, 1)
+	assert.Equal(t, 2, store.added[0].Requests)
+	assert.Equal(t, 1, store.added[0].RateLimited)
+}
+
+func TestUsageCounter_Nil(t *testing.T) {
+	var u *UsageCounter
+	u.Record(uuid.New(), false, time.Now())
+}
+
+func TestDay(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Day(time.Date(2026, 3, 2, 8, 0, 0, 0, loc)))
+}
@@ -0,0 +1,133 @@
+// Package archive moves the responses of surveys closed long ago out of the
+// responses table, into one archive row per survey that keeps their results.
+// Results of archived surveys are read from the archive; their responses are
+// restored when the author exports them, or when the survey is reopened.
+package archive
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+const (
+	// DefaultAfterDays is how many days after a survey closes its responses are archived
+	DefaultAfterDays = 180
+	// BatchSize is how many surveys are archived per run
+	BatchSize = 20
+	// Interval is how often surveys are archived
+	Interval = time.Hour
+)
+
+// Store persists archives
+type Store interface {
+	// ListArchivableSurveys returns up to limit surveys not in the trash that
+	// ended before a time and have responses, none of them pending a PDS write
+	ListArchivableSurveys(ctx context.Context, endedBefore time.Time, limit int) ([]uuid.UUID, error)
+	// ArchiveSurvey moves the responses of a survey into its archive with its
+	// results, and returns how many were archived
+	ArchiveSurvey(ctx context.Context, surveyID uuid.UUID, results *models.SurveyResults) (int, error)
+	// RestoreArchivedSurvey moves the responses of an archived survey back and
+	// deletes its archive, returning how many were restored (0 if the survey
+	// is not archived)
+	RestoreArchivedSurvey(ctx context.Context, surveyID uuid.UUID) (int, error)
+	// ListReopenedSurveys returns the archived surveys that are no longer closed
+	ListReopenedSurveys(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	// GetArchivedResults returns the results of an archived survey as
+	// archived, or sql.ErrNoRows if the survey is not archived
+	GetArchivedResults(ctx context.Context, surveyID uuid.UUID) (*models.SurveyResults, error)
+}
+
+// ResultsFunc computes the results of a survey from its responses
+type ResultsFunc func(ctx context.Context, surveyID uuid.UUID) (*models.SurveyResults, error)
+
+// Config configures archival
+type Config struct {
+	After time.Duration // Time after a survey closes its responses are archived, 0 to disable
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - ARCHIVE_AFTER_DAYS: days after a survey closes its responses are archived,
+//     0 to disable archival (default: 180)
+func ConfigFromEnv() Config {
+	config := Config{After: DefaultAfterDays * 24 * time.Hour}
+
+	if v := os.Getenv("ARCHIVE_AFTER_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			config.After = time.Duration(n) * 24 * time.Hour
+		} else {
+			log.Printf("Warning: Invalid ARCHIVE_AFTER_DAYS %q, using %d", v, DefaultAfterDays)
+		}
+	}
+
+	return config
+}
+
+// Enabled reports whether archival is enabled
+func (c Config) Enabled() bool {
+	return c.After > 0
+}
+
+// Run restores the archives of reopened surveys, then archives up to
+// BatchSize surveys that closed more than after ago. A survey that fails is
+// logged and skipped.
+func Run(ctx context.Context, store Store, results ResultsFunc, after time.Duration, now time.Time) (archived, restored int, err error) {
+	reopened, err := store.ListReopenedSurveys(ctx, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, id := range reopened {
+		if _, err := store.RestoreArchivedSurvey(ctx, id); err != nil {
+			log.Printf("Error restoring archived survey %s: %v", id, err)
+			continue
+		}
+		restored++
+	}
+
+	ids, err := store.ListArchivableSurveys(ctx, now.Add(-after), BatchSize)
+	if err != nil {
+		return 0, restored, err
+	}
+	for _, id := range ids {
+		r, err := results(ctx, id)
+		if err != nil {
+			log.Printf("Error computing results of survey %s to archive: %v", id, err)
+			continue
+		}
+		if _, err := store.ArchiveSurvey(ctx, id, r); err != nil {
+			log.Printf("Error archiving survey %s: %v", id, err)
+			continue
+		}
+		archived++
+	}
+
+	return archived, restored, nil
+}
+
+// StartWorker archives surveys every interval until ctx is cancelled
+func StartWorker(ctx context.Context, store Store, results ResultsFunc, config Config, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		archived, restored, err := Run(ctx, store, results, config.After, time.Now())
+		if err != nil {
+			log.Printf("Error archiving surveys: %v", err)
+		}
+		if archived > 0 || restored > 0 {
+			log.Printf("Archived %d surveys, restored %d reopened surveys", archived, restored)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStore records archived and restored surveys
+type mockStore struct {
+	archivable  []uuid.UUID
+	reopened    []uuid.UUID
+	endedBefore time.Time
+	archived    map[uuid.UUID]*models.SurveyResults
+	restored    []uuid.UUID
+}
+
+func (m *mockStore) ListArchivableSurveys(ctx context.Context, endedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	m.endedBefore = endedBefore
+	return m.archivable, nil
+}
+
+func (m *mockStore) ArchiveSurvey(ctx context.Context, surveyID uuid.UUID, results *models.SurveyResults) (int, error) {
+	m.archived[surveyID] = results
+	return results.TotalVotes, nil
+}
+
+func (m *mockStore) RestoreArchivedSurvey(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	m.restored = append(m.restored, surveyID)
+	return 1, nil
+}
+
+func (m *mockStore) ListReopenedSurveys(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	return m.reopened, nil
+}
+
+func (m *mockStore) GetArchivedResults(ctx context.Context, surveyID uuid.UUID) (*models.SurveyResults, error) {
+	return m.archived[surveyID], nil
+}
+
+func TestRun(t *testing.T) {
+	closed, failing, reopened := uuid.New(), uuid.New(), uuid.New()
+	store := &mockStore{
+		archivable: []uuid.UUID{closed, failing},
+		reopened:   []uuid.UUID{reopened},
+		archived:   make(map[uuid.UUID]*models.SurveyResults),
+	}
+	results := func(ctx context.Context, id uuid.UUID) (*models.SurveyResults, error) {
+		if id == failing {
+			return nil, errors.New("boom")
+		}
+		return &models.SurveyResults{SurveyID: id, TotalVotes: 7}, nil
+	}
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	archived, restored, err := Run(context.Background(), store, results, 30*24*time.Hour, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived, "surveys whose results fail are skipped")
+	assert.Equal(t, 1, restored)
+	assert.Equal(t, now.Add(-30*24*time.Hour), store.endedBefore)
+	assert.Equal(t, 7, store.archived[closed].TotalVotes, "results are archived with the responses")
+	assert.Equal(t, []uuid.UUID{reopened}, store.restored)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("ARCHIVE_AFTER_DAYS", "")
+	assert.Equal(t, DefaultAfterDays*24*time.Hour, ConfigFromEnv().After)
+
+	t.Setenv("ARCHIVE_AFTER_DAYS", "30")
+	assert.Equal(t, 30*24*time.Hour, ConfigFromEnv().After)
+
+	t.Setenv("ARCHIVE_AFTER_DAYS", "0")
+	assert.False(t, ConfigFromEnv().Enabled())
+
+	t.Setenv("ARCHIVE_AFTER_DAYS", "soon")
+	assert.Equal(t, DefaultAfterDays*24*time.Hour, ConfigFromEnv().After)
+}
@@ -0,0 +1,261 @@
+// Package attestation signs published survey results with an Ed25519 key held
+// by the service, separate from its OAuth client key. The signature covers the
+// DAG-CBOR block of a net.openmeet.survey.results record without its
+// attestation, so anyone with the public key can check that the results were
+// aggregated by this AppView and not edited since, even on a copy of the
+// record that was re-published elsewhere.
+package attestation
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openmeet-team/survey/internal/firehose"
+)
+
+const (
+	// Field is the field of a results record holding its attestation
+	Field = "attestation"
+
+	// Algorithm is the JWS name of the signature algorithm, Ed25519
+	Algorithm = "EdDSA"
+)
+
+var (
+	// ErrUnsigned is returned for records without an attestation
+	ErrUnsigned = errors.New("record is not signed")
+
+	// ErrUnknownKey is returned for attestations of a key this service does not publish
+	ErrUnknownKey = errors.New("unknown signing key")
+
+	// ErrInvalidSignature is returned for signatures that do not match the record
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Attestation is the signature of a results record, in the
+// net.openmeet.survey.results#attestation format
+type Attestation struct {
+	KeyID     string `json:"keyId"` // JWK thumbprint of the public key
+	Algorithm string `json:"alg"`
+	Signature string `json:"sig"` // Base64url, without padding
+}
+
+// Record returns the attestation in the record format
+func (a *Attestation) Record() map[string]interface{} {
+	return map[string]interface{}{
+		"keyId": a.KeyID,
+		"alg":   a.Algorithm,
+		"sig":   a.Signature,
+	}
+}
+
+// Config holds the results signing key
+type Config struct {
+	PrivateKey   string   // Base64 Ed25519 seed
+	PreviousKeys []string // Base64 public keys of retired signing keys
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - RESULTS_SIGNING_KEY: base64 32-byte Ed25519 seed results are signed with, e.g. from
+//     `openssl rand -base64 32` (results are not signed if empty)
+//   - RESULTS_SIGNING_PREVIOUS_KEYS: comma-separated base64 public keys of retired signing
+//     keys, still published so results they signed keep verifying
+func ConfigFromEnv() Config {
+	config := Config{PrivateKey: os.Getenv("RESULTS_SIGNING_KEY")}
+	for _, key := range strings.Split(os.Getenv("RESULTS_SIGNING_PREVIOUS_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			config.PreviousKeys = append(config.PreviousKeys, key)
+		}
+	}
+	return config
+}
+
+// Signer signs results records and verifies their attestations
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+	keys  map[string]ed25519.PublicKey // Published keys by key ID
+	order []string                     // Key IDs, the current one first
+}
+
+// NewFromConfig creates a signer, or returns nil if no key is configured
+func NewFromConfig(config Config) (*Signer, error) {
+	if config.PrivateKey == "" {
+		return nil, nil
+	}
+	seed, err := decodeKey(config.PrivateKey, ed25519.SeedSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESULTS_SIGNING_KEY: %w", err)
+	}
+
+	s := &Signer{key: ed25519.NewKeyFromSeed(seed), keys: make(map[string]ed25519.PublicKey)}
+	s.keyID = s.addKey(s.key.Public().(ed25519.PublicKey))
+	for _, previous := range config.PreviousKeys {
+		key, err := decodeKey(previous, ed25519.PublicKeySize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RESULTS_SIGNING_PREVIOUS_KEYS: %w", err)
+		}
+		s.addKey(key)
+	}
+	return s, nil
+}
+
+// decodeKey decodes a key in standard or URL-safe base64, with or without padding
+func decodeKey(s string, size int) ([]byte, error) {
+	s = strings.TrimRight(strings.NewReplacer("-", "+", "_", "/").Replace(s), "=")
+	key, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("not base64")
+	}
+	if len(key) != size {
+		return nil, fmt.Errorf("got %d bytes, want %d", len(key), size)
+	}
+	return key, nil
+}
+
+func (s *Signer) addKey(key ed25519.PublicKey) string {
+	keyID := KeyID(key)
+	if _, ok := s.keys[keyID]; !ok {
+		s.keys[keyID] = key
+		s.order = append(s.order, keyID)
+	}
+	return keyID
+}
+
+// Enabled reports whether results are signed. A nil signer is disabled.
+func (s *Signer) Enabled() bool {
+	return s != nil
+}
+
+// KeyID returns the ID of the current signing key
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// Sign signs a results record, built in Go or in the ATProto JSON data model.
+// Any attestation it has is not signed.
+func (s *Signer) Sign(record map[string]interface{}) (*Attestation, error) {
+	payload, err := Payload(record)
+	if err != nil {
+		return nil, err
+	}
+	return &Attestation{
+		KeyID:     s.keyID,
+		Algorithm: Algorithm,
+		Signature: base64.RawURLEncoding.EncodeToString(ed25519.Sign(s.key, payload)),
+	}, nil
+}
+
+// Verify checks the attestation of a results record against the published
+// keys, and returns it
+func (s *Signer) Verify(record map[string]interface{}) (*Attestation, error) {
+	a, err := Parse(record)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := s.keys[a.KeyID]
+	if !ok || a.Algorithm != Algorithm {
+		return a, ErrUnknownKey
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return a, ErrInvalidSignature
+	}
+	payload, err := Payload(record)
+	if err != nil {
+		return a, err
+	}
+	if !ed25519.Verify(key, payload, sig) {
+		return a, ErrInvalidSignature
+	}
+	return a, nil
+}
+
+// Parse returns the attestation of a results record, or ErrUnsigned
+func Parse(record map[string]interface{}) (*Attestation, error) {
+	value, ok := record[Field]
+	if !ok {
+		return nil, ErrUnsigned
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, ErrUnsigned
+	}
+	var a Attestation
+	if err := json.Unmarshal(data, &a); err != nil || a.KeyID == "" || a.Signature == "" {
+		return nil, ErrUnsigned
+	}
+	return &a, nil
+}
+
+// Payload returns the signed bytes of a results record: the DAG-CBOR block of
+// the record without its attestation
+func Payload(record map[string]interface{}) ([]byte, error) {
+	unsigned := make(map[string]interface{}, len(record))
+	for k, v := range record {
+		if k != Field {
+			unsigned[k] = v
+		}
+	}
+
+	// Round-trip through JSON to get the JSON data model the block is encoded from
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+	var value map[string]any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+	block, _, err := firehose.EncodeRecord(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+	return block, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 8037)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JWKSet is a set of public keys, as served at the well-known endpoint
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the published keys, the current signing key first
+func (s *Signer) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(s.order))}
+	for _, keyID := range s.order {
+		set.Keys = append(set.Keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(s.keys[keyID]),
+			KeyID:     keyID,
+			Use:       "sig",
+			Algorithm: Algorithm,
+		})
+	}
+	return set
+}
+
+// KeyID returns the JWK thumbprint (RFC 7638) of an Ed25519 public key
+func KeyID(key ed25519.PublicKey) string {
+	// The thumbprint hashes the required members in lexicographic order
+	thumbprint := `{"crv":"Ed25519","kty":"OKP","x":"` + base64.RawURLEncoding.EncodeToString(key) + `"}`
+	sum := sha256.Sum256([]byte(thumbprint))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
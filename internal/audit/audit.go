@@ -0,0 +1,143 @@
+// Package audit lets anyone check survey results against the records they
+// were counted from. An export packs the survey record and the indexed
+// response records into a CAR file. Each record is re-encoded from its kept
+// value and included only if it hashes to the CID its repository lists, so a
+// third party can recount the tallies from records it can verify. Published
+// results records are checked the same way: CompareResults compares their
+// counts with a recount of the indexed responses.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/firehose"
+)
+
+const (
+	// ContentType is the media type of CAR files
+	ContentType = "application/vnd.ipld.car"
+
+	// ManifestType is the $type of an export's root block
+	ManifestType = "net.openmeet.survey.export"
+)
+
+// Reasons records are omitted from an export
+const (
+	ReasonNotKept  = "record not kept"               // Indexed before record values were kept
+	ReasonBadCID   = "malformed CID"                 // The indexed CID does not parse
+	ReasonMismatch = "record does not match its CID" // The kept value encodes to another CID
+)
+
+// Record is an indexed survey or response record
+type Record struct {
+	URI   string
+	CID   string
+	Value map[string]any // In the ATProto JSON data model; nil if not kept
+}
+
+// Store is the storage of indexed records
+type Store interface {
+	// GetIndexedRecord returns the record at uri if its value was kept at
+	// cid, or nil
+	GetIndexedRecord(ctx context.Context, uri, cid string) (*Record, error)
+	// ListResponseRecords returns the records of a survey's responses,
+	// ordered by URI, with the values kept at the CIDs they were counted at
+	ListResponseRecords(ctx context.Context, surveyID uuid.UUID) ([]*Record, error)
+	// CountLocalResponses counts the responses of a survey that have no
+	// record, such as guest votes
+	CountLocalResponses(ctx context.Context, surveyID uuid.UUID) (int, error)
+}
+
+// Omission is a record counted in the results but missing from an export
+type Omission struct {
+	URI    string
+	Reason string
+}
+
+// Export is the verifiable records of a survey's responses
+type Export struct {
+	Root           firehose.CID // The manifest block
+	Responses      int          // Response records included
+	Omitted        []Omission
+	LocalResponses int // Responses without records, which can't be verified
+	blocks         []firehose.Block
+}
+
+// NewExport builds the export of a survey record and its response records.
+// The root block is a manifest listing every record with its URI and CID,
+// the records left out and why, and the number of local responses.
+func NewExport(survey *Record, responses []*Record, localResponses int, exportedAt time.Time) (*Export, error) {
+	e := &Export{LocalResponses: localResponses}
+	seen := make(map[firehose.CID]bool)
+
+	// add returns the manifest entry of a record, or nil if its CID does not
+	// parse, and includes its block if it matches the CID
+	add := func(r *Record) (map[string]any, bool) {
+		cid, err := firehose.ParseCIDString(r.CID)
+		if err != nil {
+			e.Omitted = append(e.Omitted, Omission{URI: r.URI, Reason: ReasonBadCID})
+			return nil, false
+		}
+		entry := map[string]any{"uri": r.URI, "cid": cid}
+
+		if r.Value == nil {
+			e.Omitted = append(e.Omitted, Omission{URI: r.URI, Reason: ReasonNotKept})
+			return entry, false
+		}
+		block, encoded, err := firehose.EncodeRecord(r.Value)
+		if err != nil || encoded != cid {
+			e.Omitted = append(e.Omitted, Omission{URI: r.URI, Reason: ReasonMismatch})
+			return entry, false
+		}
+		if !seen[cid] {
+			seen[cid] = true
+			e.blocks = append(e.blocks, firehose.Block{CID: cid, Data: block})
+		}
+		return entry, true
+	}
+
+	manifest := map[string]any{
+		"$type":          ManifestType,
+		"localResponses": int64(localResponses),
+		"exportedAt":     exportedAt.UTC().Format(time.RFC3339),
+	}
+	if entry, _ := add(survey); entry != nil {
+		manifest["survey"] = entry
+	}
+
+	entries := make([]any, 0, len(responses))
+	for _, r := range responses {
+		entry, included := add(r)
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+		if included {
+			e.Responses++
+		}
+	}
+	manifest["responses"] = entries
+
+	omitted := make([]any, len(e.Omitted))
+	for i, o := range e.Omitted {
+		omitted[i] = map[string]any{"uri": o.URI, "reason": o.Reason}
+	}
+	manifest["omitted"] = omitted
+
+	root, err := firehose.EncodeCBOR(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	e.Root = firehose.NewCID(root)
+	e.blocks = append([]firehose.Block{{CID: e.Root, Data: root}}, e.blocks...)
+
+	return e, nil
+}
+
+// WriteCAR writes the export as a CAR file rooted at its manifest
+func (e *Export) WriteCAR(w io.Writer) error {
+	return firehose.WriteCAR(w, []firehose.CID{e.Root}, e.blocks)
+}
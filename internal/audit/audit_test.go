@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/firehose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// record returns an indexed record with the CID of its value
+func record(t *testing.T, uri string, value map[string]any) *Record {
+	t.Helper()
+	_, cid, err := firehose.EncodeRecord(value)
+	require.NoError(t, err)
+	return &Record{URI: uri, CID: cid.String(), Value: value}
+}
+
+func TestNewExport(t *testing.T) {
+	surveyURI := "at://did:plc:author/net.openmeet.survey/1"
+	survey := record(t, surveyURI, map[string]any{"$type": "net.openmeet.survey", "name": "Lunch"})
+	vote := func(voter, option string) *Record {
+		return record(t, "at://"+voter+"/net.openmeet.survey.response/1", map[string]any{
+			"$type":     "net.openmeet.survey.response",
+			"subject":   map[string]any{"uri": surveyURI, "cid": map[string]any{"$link": survey.CID}},
+			"answers":   []any{map[string]any{"questionId": "q1", "selected": []any{option}}},
+			"createdAt": "2026-03-01T11:00:00Z",
+		})
+	}
+
+	alice, bob := vote("did:plc:alice", "pizza"), vote("did:plc:bob", "salad")
+	tampered := vote("did:plc:carol", "soup")
+	tampered.Value["answers"] = []any{map[string]any{"questionId": "q1", "selected": []any{"salad"}}}
+	notKept := &Record{URI: "at://did:plc:dave/net.openmeet.survey.response/1", CID: alice.CID}
+	badCID := &Record{URI: "at://did:plc:erin/net.openmeet.survey.response/1", CID: "bafy123"}
+
+	exportedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	export, err := NewExport(survey, []*Record{alice, bob, tampered, notKept, badCID}, 3, exportedAt)
+	require.NoError(t, err)
+	assert.Equal(t, 2, export.Responses)
+	assert.Equal(t, 3, export.LocalResponses)
+	assert.Equal(t, []Omission{
+		{URI: tampered.URI, Reason: ReasonMismatch},
+		{URI: notKept.URI, Reason: ReasonNotKept},
+		{URI: badCID.URI, Reason: ReasonBadCID},
+	}, export.Omitted)
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteCAR(&buf))
+	car, err := firehose.ReadCAR(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, []firehose.CID{export.Root}, car.Roots)
+
+	v, err := car.Decode(export.Root)
+	require.NoError(t, err)
+	manifest := v.(map[string]any)
+	assert.Equal(t, ManifestType, manifest["$type"])
+	assert.Equal(t, int64(3), manifest["localResponses"])
+	assert.Equal(t, "2026-03-01T12:00:00Z", manifest["exportedAt"])
+	assert.Len(t, manifest["omitted"], 3)
+
+	// Every counted record with a CID is listed; only the verified ones have blocks
+	responses := manifest["responses"].([]any)
+	require.Len(t, responses, 4)
+	for i, r := range []*Record{alice, bob, tampered, notKept} {
+		entry := responses[i].(map[string]any)
+		assert.Equal(t, r.URI, entry["uri"])
+		assert.Equal(t, r.CID, entry["cid"].(firehose.CID).String())
+	}
+
+	surveyCID := manifest["survey"].(map[string]any)["cid"].(firehose.CID)
+	surveyBlock, err := car.Decode(surveyCID)
+	require.NoError(t, err)
+	assert.Equal(t, "Lunch", surveyBlock.(map[string]any)["name"])
+
+	bobBlock, err := car.Decode(responses[1].(map[string]any)["cid"].(firehose.CID))
+	require.NoError(t, err)
+	answer := bobBlock.(map[string]any)["answers"].([]any)[0].(map[string]any)
+	assert.Equal(t, []any{"salad"}, answer["selected"])
+
+	_, err = car.Decode(responses[2].(map[string]any)["cid"].(firehose.CID))
+	assert.Error(t, err, "tampered record is not included")
+}
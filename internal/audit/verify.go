@@ -0,0 +1,174 @@
+package audit
+
+import "github.com/openmeet-team/survey/internal/firehose"
+
+// Kinds of counts in results records
+const (
+	KindOption        = "option"        // Responses selecting an option
+	KindRow           = "row"           // Responses rating a matrix row with an option
+	KindTextResponses = "textResponses" // Text answers counted
+)
+
+// Count is a tally as published and as recounted from the indexed responses
+type Count struct {
+	Published  int `json:"published"`
+	Recomputed int `json:"recomputed"`
+}
+
+// CountDiff is a count of a question that differs between the published and
+// the recounted results
+type CountDiff struct {
+	Kind     string `json:"kind"`
+	RowID    string `json:"rowId,omitempty"`
+	OptionID string `json:"optionId,omitempty"`
+	Count
+}
+
+// QuestionComparison compares the counts of one question
+type QuestionComparison struct {
+	QuestionID string      `json:"questionId"`
+	Match      bool        `json:"match"`
+	Diffs      []CountDiff `json:"diffs,omitempty"`
+}
+
+// Comparison compares published results with a recount
+type Comparison struct {
+	Match      bool                 `json:"match"`
+	TotalVotes Count                `json:"totalVotes"`
+	Questions  []QuestionComparison `json:"questions"`
+}
+
+// Matches reports whether a record in the ATProto JSON data model encodes to
+// the block a CID addresses, so it is the record its repository committed
+func Matches(value map[string]any, cid string) bool {
+	want, err := firehose.ParseCIDString(cid)
+	if err != nil || value == nil {
+		return false
+	}
+	_, got, err := firehose.EncodeRecord(value)
+	return err == nil && got == want
+}
+
+// countKey identifies a count of a question
+type countKey struct {
+	kind, rowID, optionID string
+}
+
+// tally is the counts of a results record's questions, in record order
+type tally struct {
+	total     int
+	questions []string
+	counts    map[string]map[countKey]int
+	keys      map[string][]countKey
+}
+
+// CompareResults compares the tallies of a published net.openmeet.survey.results
+// record with a recounted one: the total votes and, for each question, the
+// count of every option, of every option in each matrix row, and of text
+// responses. A count missing from one record is zero there. Both records are
+// in the ATProto JSON data model.
+func CompareResults(published, recomputed map[string]any) *Comparison {
+	p, r := newTally(published), newTally(recomputed)
+	c := &Comparison{
+		TotalVotes: Count{Published: p.total, Recomputed: r.total},
+		Questions:  []QuestionComparison{},
+	}
+	c.Match = p.total == r.total
+
+	for _, questionID := range union(p.questions, r.questions) {
+		q := QuestionComparison{QuestionID: questionID, Match: true}
+		for _, key := range union(p.keys[questionID], r.keys[questionID]) {
+			count := Count{Published: p.counts[questionID][key], Recomputed: r.counts[questionID][key]}
+			if count.Published != count.Recomputed {
+				q.Match = false
+				q.Diffs = append(q.Diffs, CountDiff{Kind: key.kind, RowID: key.rowID, OptionID: key.optionID, Count: count})
+			}
+		}
+		c.Match = c.Match && q.Match
+		c.Questions = append(c.Questions, q)
+	}
+
+	return c
+}
+
+// newTally reads the counts of a results record
+func newTally(record map[string]any) *tally {
+	t := &tally{
+		total:  number(record["totalVotes"]),
+		counts: make(map[string]map[countKey]int),
+		keys:   make(map[string][]countKey),
+	}
+	add := func(questionID string, key countKey, count int) {
+		if _, ok := t.counts[questionID][key]; !ok {
+			t.keys[questionID] = append(t.keys[questionID], key)
+		}
+		t.counts[questionID][key] += count
+	}
+
+	questions, _ := record["questionResults"].([]any)
+	for _, q := range questions {
+		result, _ := q.(map[string]any)
+		questionID, _ := result["questionId"].(string)
+		if questionID == "" {
+			continue
+		}
+		if _, ok := t.counts[questionID]; !ok {
+			t.questions = append(t.questions, questionID)
+			t.counts[questionID] = make(map[countKey]int)
+		}
+
+		for _, oc := range optionCounts(result["optionCounts"]) {
+			add(questionID, countKey{kind: KindOption, optionID: oc.optionID}, oc.count)
+		}
+		rows, _ := result["rowCounts"].([]any)
+		for _, row := range rows {
+			rowCounts, _ := row.(map[string]any)
+			rowID, _ := rowCounts["rowId"].(string)
+			for _, oc := range optionCounts(rowCounts["optionCounts"]) {
+				add(questionID, countKey{kind: KindRow, rowID: rowID, optionID: oc.optionID}, oc.count)
+			}
+		}
+		add(questionID, countKey{kind: KindTextResponses}, number(result["textResponseCount"]))
+	}
+
+	return t
+}
+
+// optionCount is an entry of an optionCounts array
+type optionCount struct {
+	optionID string
+	count    int
+}
+
+// optionCounts reads an optionCounts array of {optionId, count} entries
+func optionCounts(v any) []optionCount {
+	entries, _ := v.([]any)
+	counts := make([]optionCount, 0, len(entries))
+	for _, e := range entries {
+		entry, _ := e.(map[string]any)
+		optionID, _ := entry["optionId"].(string)
+		counts = append(counts, optionCount{optionID, number(entry["count"])})
+	}
+	return counts
+}
+
+// number reads an integer of the JSON data model, or 0
+func number(v any) int {
+	f, _ := v.(float64)
+	return int(f)
+}
+
+// union returns the elements of a followed by those of b not in a
+func union[T comparable](a, b []T) []T {
+	seen := make(map[T]bool, len(a))
+	out := make([]T, 0, len(a)+len(b))
+	for _, list := range [][]T{a, b} {
+		for _, e := range list {
+			if !seen[e] {
+				seen[e] = true
+				out = append(out, e)
+			}
+		}
+	}
+	return out
+}
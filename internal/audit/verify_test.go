@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// results returns a results record in the JSON data model with one choice
+// question and one matrix question
+func results(total, pizza, salad, rowPizza float64) map[string]any {
+	return map[string]any{
+		"$type":      "net.openmeet.survey.results",
+		"totalVotes": total,
+		"questionResults": []any{
+			map[string]any{
+				"questionId": "lunch",
+				"optionCounts": []any{
+					map[string]any{"optionId": "pizza", "count": pizza},
+					map[string]any{"optionId": "salad", "count": salad},
+				},
+				"textResponseCount": float64(0),
+			},
+			map[string]any{
+				"questionId":   "days",
+				"optionCounts": []any{},
+				"rowCounts": []any{
+					map[string]any{"rowId": "mon", "optionCounts": []any{
+						map[string]any{"optionId": "pizza", "count": rowPizza},
+					}},
+				},
+				"textResponseCount": float64(0),
+			},
+		},
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	c := CompareResults(results(3, 2, 1, 1), results(3, 2, 1, 1))
+	assert.True(t, c.Match)
+	assert.Equal(t, Count{Published: 3, Recomputed: 3}, c.TotalVotes)
+	require.Len(t, c.Questions, 2)
+	assert.True(t, c.Questions[0].Match)
+	assert.Empty(t, c.Questions[0].Diffs)
+
+	c = CompareResults(results(3, 2, 1, 1), results(4, 2, 2, 0))
+	assert.False(t, c.Match)
+	assert.Equal(t, Count{Published: 3, Recomputed: 4}, c.TotalVotes)
+	require.Len(t, c.Questions, 2)
+	assert.Equal(t, QuestionComparison{
+		QuestionID: "lunch",
+		Diffs:      []CountDiff{{Kind: KindOption, OptionID: "salad", Count: Count{Published: 1, Recomputed: 2}}},
+	}, c.Questions[0])
+	assert.Equal(t, QuestionComparison{
+		QuestionID: "days",
+		Diffs:      []CountDiff{{Kind: KindRow, RowID: "mon", OptionID: "pizza", Count: Count{Published: 1, Recomputed: 0}}},
+	}, c.Questions[1])
+
+	// Options and questions missing from one record count as zero there
+	recount := results(3, 2, 1, 1)
+	recount["questionResults"] = recount["questionResults"].([]any)[:1]
+	c = CompareResults(results(3, 2, 1, 1), recount)
+	assert.False(t, c.Match)
+	require.Len(t, c.Questions, 2)
+	assert.True(t, c.Questions[0].Match)
+	assert.Equal(t, []CountDiff{{Kind: KindRow, RowID: "mon", OptionID: "pizza", Count: Count{Published: 1}}}, c.Questions[1].Diffs)
+}
+
+func TestMatches(t *testing.T) {
+	r := record(t, "at://did:plc:author/net.openmeet.survey.results/1", results(3, 2, 1, 1))
+	assert.True(t, Matches(r.Value, r.CID))
+	assert.False(t, Matches(results(3, 2, 2, 1), r.CID), "altered record")
+	assert.False(t, Matches(r.Value, "bafy123"))
+	assert.False(t, Matches(nil, r.CID))
+}
@@ -0,0 +1,113 @@
+// Package bsky builds Bluesky posts sharing surveys. A post links to the survey
+// in its text and embeds a link card with the survey's preview image, so it
+// shows like a link pasted into the Bluesky composer.
+package bsky
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Collection is the collection of Bluesky posts
+const Collection = "app.bsky.feed.post"
+
+// MaxTextLength is the most characters of a post's text. Bluesky counts
+// graphemes; characters are counted here, which never allows a longer text.
+const MaxTextLength = 300
+
+// External is the link card embedded in a post
+type External struct {
+	URI         string
+	Title       string
+	Description string
+	Thumb       json.RawMessage // Blob reference of the preview image, if uploaded
+}
+
+// NewPost builds the record of a post. Every occurrence of the card's link in
+// the text is marked as a link, so clients show it as one.
+func NewPost(text string, card *External, langs []string, now time.Time) (map[string]interface{}, error) {
+	if err := ValidateText(text); err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+
+	record := map[string]interface{}{
+		"$type":     Collection,
+		"text":      text,
+		"createdAt": now.UTC().Format(time.RFC3339),
+	}
+	if len(langs) > 0 {
+		record["langs"] = langs
+	}
+	if card == nil {
+		return record, nil
+	}
+
+	if facets := linkFacets(text, card.URI); len(facets) > 0 {
+		record["facets"] = facets
+	}
+	external := map[string]interface{}{
+		"uri":         card.URI,
+		"title":       card.Title,
+		"description": card.Description,
+	}
+	if len(card.Thumb) > 0 {
+		external["thumb"] = card.Thumb
+	}
+	record["embed"] = map[string]interface{}{
+		"$type":    "app.bsky.embed.external",
+		"external": external,
+	}
+	return record, nil
+}
+
+// ValidateText checks the text of a post, ignoring surrounding whitespace
+func ValidateText(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("text is required")
+	}
+	if utf8.RuneCountInString(text) > MaxTextLength {
+		return fmt.Errorf("text must be at most %d characters", MaxTextLength)
+	}
+	return nil
+}
+
+// linkFacets marks the occurrences of a link in a text. Facets index UTF-8 bytes.
+func linkFacets(text, link string) []map[string]interface{} {
+	if link == "" {
+		return nil
+	}
+	var facets []map[string]interface{}
+	for start := 0; ; {
+		i := strings.Index(text[start:], link)
+		if i < 0 {
+			return facets
+		}
+		byteStart := start + i
+		start = byteStart + len(link)
+		facets = append(facets, map[string]interface{}{
+			"index": map[string]int{"byteStart": byteStart, "byteEnd": start},
+			"features": []map[string]string{{
+				"$type": "app.bsky.richtext.facet#link",
+				"uri":   link,
+			}},
+		})
+	}
+}
+
+// PostURL returns the bsky.app URL of a post, or "" if uri is not a post's AT URI
+func PostURL(uri string) string {
+	did, rest, ok := strings.Cut(strings.TrimPrefix(uri, "at://"), "/")
+	if !ok || !strings.HasPrefix(uri, "at://") {
+		return ""
+	}
+	rkey, ok := strings.CutPrefix(rest, Collection+"/")
+	if !ok || rkey == "" || strings.Contains(rkey, "/") {
+		return ""
+	}
+	return "https://bsky.app/profile/" + did + "/post/" + rkey
+}
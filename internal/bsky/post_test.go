@@ -0,0 +1,69 @@
+package bsky
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPost(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	link := "https://example.com/s/lunch"
+
+	t.Run("link card", func(t *testing.T) {
+		card := &External{URI: link, Title: "Lunch?", Description: "1 question · 3 votes", Thumb: json.RawMessage(`{"$type":"blob"}`)}
+		record, err := NewPost("  Où déjeuner ? "+link+"  ", card, []string{"fr"}, now)
+		require.NoError(t, err)
+
+		assert.Equal(t, Collection, record["$type"])
+		assert.Equal(t, "Où déjeuner ? "+link, record["text"])
+		assert.Equal(t, "2026-03-01T12:00:00Z", record["createdAt"])
+		assert.Equal(t, []string{"fr"}, record["langs"])
+
+		facets := record["facets"].([]map[string]interface{})
+		require.Len(t, facets, 1)
+		index := facets[0]["index"].(map[string]int)
+		text := record["text"].(string)
+		assert.Equal(t, link, text[index["byteStart"]:index["byteEnd"]], "facets index bytes, not characters")
+
+		embed := record["embed"].(map[string]interface{})
+		assert.Equal(t, "app.bsky.embed.external", embed["$type"])
+		external := embed["external"].(map[string]interface{})
+		assert.Equal(t, link, external["uri"])
+		assert.Equal(t, card.Thumb, external["thumb"])
+	})
+
+	t.Run("text without the link", func(t *testing.T) {
+		record, err := NewPost("Vote!", &External{URI: link, Title: "Lunch?"}, nil, now)
+		require.NoError(t, err)
+		assert.NotContains(t, record, "facets")
+		assert.NotContains(t, record, "langs")
+		assert.NotContains(t, record["embed"].(map[string]interface{})["external"], "thumb")
+	})
+
+	t.Run("link twice", func(t *testing.T) {
+		record, err := NewPost(link+" "+link, &External{URI: link}, nil, now)
+		require.NoError(t, err)
+		assert.Len(t, record["facets"], 2)
+	})
+
+	t.Run("invalid text", func(t *testing.T) {
+		_, err := NewPost("   ", nil, nil, now)
+		assert.Error(t, err)
+		_, err = NewPost(strings.Repeat("é", MaxTextLength+1), nil, nil, now)
+		assert.Error(t, err)
+		_, err = NewPost(strings.Repeat("é", MaxTextLength), nil, nil, now)
+		assert.NoError(t, err)
+	})
+}
+
+func TestPostURL(t *testing.T) {
+	assert.Equal(t, "https://bsky.app/profile/did:plc:abc/post/3kabc", PostURL("at://did:plc:abc/app.bsky.feed.post/3kabc"))
+	assert.Empty(t, PostURL("at://did:plc:abc/net.openmeet.survey/3kabc"))
+	assert.Empty(t, PostURL("https://did:plc:abc/app.bsky.feed.post/3kabc"))
+	assert.Empty(t, PostURL("at://did:plc:abc/app.bsky.feed.post/"))
+}
@@ -0,0 +1,280 @@
+// Package cache caches hot surveys and results in front of the database.
+// Entries are stored as JSON in Redis or an in-process LRU and invalidated by
+// the API and the consumer when responses or surveys change. The in-process
+// backend only sees its own process's invalidations, so other replicas and the
+// consumer's writes become visible after the TTL; use Redis to share the cache.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+const (
+	// DefaultTTL is how long entries are cached
+	DefaultTTL = 30 * time.Second
+
+	// DefaultSize is the maximum number of entries of the in-process cache
+	DefaultSize = 1000
+
+	// DefaultCardTTL is how long social card images are cached
+	DefaultCardTTL = time.Hour
+
+	// keyPrefix namespaces keys in a shared Redis
+	keyPrefix = "survey:cache:"
+)
+
+// Backend stores cache entries
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Config holds cache configuration
+type Config struct {
+	Backend  string // "memory", "redis", "disk", or "" (disabled)
+	RedisURL string
+	Dir      string // Directory of the disk backend
+	Size     int
+	TTL      time.Duration
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - CACHE_BACKEND: "memory", "redis", or "none" (default: none)
+//   - REDIS_URL: Redis connection URL, e.g. redis://localhost:6379/0 (required for redis)
+//   - CACHE_SIZE: maximum entries of the memory backend (default: 1000)
+//   - CACHE_TTL: how long entries are cached, e.g. "30s" (default: 30s)
+func ConfigFromEnv() Config {
+	config := Config{
+		Backend:  os.Getenv("CACHE_BACKEND"),
+		RedisURL: os.Getenv("REDIS_URL"),
+		Size:     DefaultSize,
+		TTL:      DefaultTTL,
+	}
+	if config.Backend == "none" {
+		config.Backend = ""
+	}
+
+	if v := os.Getenv("CACHE_SIZE"); v != "" {
+		if size, err := strconv.Atoi(v); err == nil && size > 0 {
+			config.Size = size
+		} else {
+			log.Printf("Warning: Invalid CACHE_SIZE %q, using %d", v, DefaultSize)
+		}
+	}
+	if v := os.Getenv("CACHE_TTL"); v != "" {
+		if ttl, err := time.ParseDuration(v); err == nil && ttl > 0 {
+			config.TTL = ttl
+		} else {
+			log.Printf("Warning: Invalid CACHE_TTL %q, using %s", v, DefaultTTL)
+		}
+	}
+
+	return config
+}
+
+// CardConfigFromEnv creates the Config of the social card image cache.
+// Cards are keyed by their content, so they are never stale and can be kept longer than surveys.
+// Environment variables:
+//   - CARD_CACHE_BACKEND: "disk", "redis", "memory", or "none" (default: disk)
+//   - CARD_CACHE_DIR: directory of the disk backend (default: survey-cards in the OS temp directory)
+//   - CARD_CACHE_TTL: how long cards are cached, e.g. "1h" (default: 1h)
+//   - REDIS_URL: Redis connection URL (required for redis)
+//   - CACHE_SIZE: maximum entries of the memory backend (default: 1000)
+func CardConfigFromEnv() Config {
+	config := Config{
+		Backend:  os.Getenv("CARD_CACHE_BACKEND"),
+		RedisURL: os.Getenv("REDIS_URL"),
+		Dir:      os.Getenv("CARD_CACHE_DIR"),
+		Size:     DefaultSize,
+		TTL:      DefaultCardTTL,
+	}
+	switch config.Backend {
+	case "":
+		config.Backend = "disk"
+	case "none":
+		config.Backend = ""
+	}
+	if config.Dir == "" {
+		config.Dir = filepath.Join(os.TempDir(), "survey-cards")
+	}
+
+	if v := os.Getenv("CACHE_SIZE"); v != "" {
+		if size, err := strconv.Atoi(v); err == nil && size > 0 {
+			config.Size = size
+		}
+	}
+	if v := os.Getenv("CARD_CACHE_TTL"); v != "" {
+		if ttl, err := time.ParseDuration(v); err == nil && ttl > 0 {
+			config.TTL = ttl
+		} else {
+			log.Printf("Warning: Invalid CARD_CACHE_TTL %q, using %s", v, DefaultCardTTL)
+		}
+	}
+
+	return config
+}
+
+// Store caches surveys and results. A nil store caches nothing.
+type Store struct {
+	backend Backend
+	ttl     time.Duration
+}
+
+// NewFromConfig creates a store for the configured backend, or returns nil if caching is disabled
+func NewFromConfig(config Config) (*Store, error) {
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	switch config.Backend {
+	case "":
+		return nil, nil
+	case "memory":
+		return New(NewMemory(config.Size), ttl), nil
+	case "redis":
+		backend, err := NewRedis(config.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		return New(backend, ttl), nil
+	case "disk":
+		backend, err := NewDisk(config.Dir)
+		if err != nil {
+			return nil, err
+		}
+		return New(backend, ttl), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend: %s", config.Backend)
+	}
+}
+
+// New creates a store on the given backend
+func New(backend Backend, ttl time.Duration) *Store {
+	return &Store{backend: backend, ttl: ttl}
+}
+
+// Enabled reports whether caching is enabled. A nil store is disabled.
+func (s *Store) Enabled() bool {
+	return s != nil
+}
+
+// SurveyKey is the cache key of a survey by slug
+func SurveyKey(slug string) string {
+	return keyPrefix + "survey:" + slug
+}
+
+// ResultsKey is the cache key of a survey's results
+func ResultsKey(surveyID uuid.UUID) string {
+	return keyPrefix + "results:" + surveyID.String()
+}
+
+// CardKey is the cache key of a social card image by its content key
+func CardKey(contentKey string) string {
+	return keyPrefix + "card:" + contentKey
+}
+
+// Survey returns the survey with the given slug, calling load on a miss.
+// Load errors (including not found) are returned and not cached.
+func (s *Store) Survey(ctx context.Context, slug string, load func() (*models.Survey, error)) (*models.Survey, error) {
+	return cached(ctx, s, "survey", SurveyKey(slug), load)
+}
+
+// Results returns a survey's results, calling load on a miss
+func (s *Store) Results(ctx context.Context, surveyID uuid.UUID, load func() (*models.SurveyResults, error)) (*models.SurveyResults, error) {
+	return cached(ctx, s, "results", ResultsKey(surveyID), load)
+}
+
+// Card returns a social card image, calling render on a miss.
+// Unlike surveys and results, cards are stored as raw bytes.
+func (s *Store) Card(ctx context.Context, contentKey string, render func() ([]byte, error)) ([]byte, error) {
+	if s == nil {
+		return render()
+	}
+
+	key := CardKey(contentKey)
+	data, ok, err := s.backend.Get(ctx, key)
+	switch {
+	case err != nil:
+		telemetry.CacheRequestsTotal.WithLabelValues("card", "error").Inc()
+		log.Printf("Failed to read cache key %s: %v", key, err)
+	case ok:
+		telemetry.CacheRequestsTotal.WithLabelValues("card", "hit").Inc()
+		return data, nil
+	default:
+		telemetry.CacheRequestsTotal.WithLabelValues("card", "miss").Inc()
+	}
+
+	data, err = render()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.backend.Set(ctx, key, data, s.ttl); err != nil {
+		log.Printf("Failed to write cache key %s: %v", key, err)
+	}
+	return data, nil
+}
+
+// Invalidate removes entries after the data they were loaded from changed
+func (s *Store) Invalidate(ctx context.Context, keys ...string) {
+	if s == nil || len(keys) == 0 {
+		return
+	}
+	if err := s.backend.Delete(ctx, keys...); err != nil {
+		log.Printf("Failed to invalidate cache keys %v: %v", keys, err)
+	}
+}
+
+// cached looks up a JSON-encoded value, loading and storing it on a miss.
+// Cache errors are logged and fall back to load, so the cache never fails a request.
+func cached[T any](ctx context.Context, s *Store, kind, key string, load func() (*T, error)) (*T, error) {
+	if s == nil {
+		return load()
+	}
+
+	data, ok, err := s.backend.Get(ctx, key)
+	switch {
+	case err != nil:
+		telemetry.CacheRequestsTotal.WithLabelValues(kind, "error").Inc()
+		log.Printf("Failed to read cache key %s: %v", key, err)
+	case ok:
+		var value T
+		decodeErr := json.Unmarshal(data, &value)
+		if decodeErr == nil {
+			telemetry.CacheRequestsTotal.WithLabelValues(kind, "hit").Inc()
+			return &value, nil
+		}
+		telemetry.CacheRequestsTotal.WithLabelValues(kind, "error").Inc()
+		log.Printf("Failed to decode cache key %s: %v", key, decodeErr)
+	default:
+		telemetry.CacheRequestsTotal.WithLabelValues(kind, "miss").Inc()
+	}
+
+	value, err := load()
+	if err != nil || value == nil {
+		return value, err
+	}
+
+	data, err = json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to encode cache key %s: %v", key, err)
+		return value, nil
+	}
+	if err := s.backend.Set(ctx, key, data, s.ttl); err != nil {
+		log.Printf("Failed to write cache key %s: %v", key, err)
+	}
+	return value, nil
+}
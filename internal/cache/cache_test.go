@@ -0,0 +1,266 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+
+	require.NoError(t, m.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, m.Set(ctx, "b", []byte("2"), time.Minute))
+
+	// Touch "a" so "b" is the least recently used
+	_, ok, _ := m.Get(ctx, "a")
+	require.True(t, ok)
+
+	require.NoError(t, m.Set(ctx, "c", []byte("3"), time.Minute))
+	assert.Equal(t, 2, m.Len())
+
+	_, ok, _ = m.Get(ctx, "b")
+	assert.False(t, ok, "b should have been evicted")
+	value, ok, _ := m.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+}
+
+func TestMemory_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	m := NewMemory(10)
+	m.now = func() time.Time { return now }
+
+	require.NoError(t, m.Set(ctx, "a", []byte("1"), time.Minute))
+	_, ok, _ := m.Get(ctx, "a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok, _ = m.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestDisk_SetGetDelete(t *testing.T) {
+	ctx := context.Background()
+	d, err := NewDisk(t.TempDir())
+	require.NoError(t, err)
+
+	_, ok, err := d.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, d.Set(ctx, "a", []byte("png bytes"), time.Minute))
+	value, ok, err := d.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("png bytes"), value)
+
+	// Keys are hashed, so keys with path separators are safe
+	require.NoError(t, d.Set(ctx, "../card:x/y", []byte("1"), time.Minute))
+	_, ok, _ = d.Get(ctx, "../card:x/y")
+	assert.True(t, ok)
+
+	require.NoError(t, d.Delete(ctx, "a", "missing"))
+	_, ok, _ = d.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestDisk_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	d, err := NewDisk(t.TempDir())
+	require.NoError(t, err)
+	d.now = func() time.Time { return now }
+	d.lastPruned = now // No background pruning
+
+	require.NoError(t, d.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, d.Set(ctx, "b", []byte("2"), time.Hour))
+
+	now = now.Add(time.Minute)
+	_, ok, _ := d.Get(ctx, "a")
+	assert.False(t, ok)
+	_, ok, _ = d.Get(ctx, "b")
+	assert.True(t, ok)
+
+	now = now.Add(time.Hour)
+	removed, err := d.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed, "b expired; a was already removed by Get")
+	_, ok, _ = d.Get(ctx, "b")
+	assert.False(t, ok)
+}
+
+func TestStore_Card(t *testing.T) {
+	ctx := context.Background()
+	store := New(NewMemory(10), time.Minute)
+
+	renders := 0
+	render := func() ([]byte, error) {
+		renders++
+		return []byte("\x89PNG"), nil
+	}
+
+	first, err := store.Card(ctx, "abc", render)
+	require.NoError(t, err)
+	second, err := store.Card(ctx, "abc", render)
+	require.NoError(t, err)
+	assert.Equal(t, 1, renders)
+	assert.Equal(t, first, second)
+
+	_, err = store.Card(ctx, "def", render)
+	require.NoError(t, err)
+	assert.Equal(t, 2, renders)
+
+	failed := errors.New("render failed")
+	_, err = store.Card(ctx, "ghi", func() ([]byte, error) { return nil, failed })
+	assert.ErrorIs(t, err, failed)
+
+	var nilStore *Store
+	_, err = nilStore.Card(ctx, "abc", render)
+	require.NoError(t, err)
+	assert.Equal(t, 3, renders)
+}
+
+func TestStore_Survey(t *testing.T) {
+	ctx := context.Background()
+	store := New(NewMemory(10), time.Minute)
+
+	loads := 0
+	load := func() (*models.Survey, error) {
+		loads++
+		return &models.Survey{ID: uuid.New(), Slug: "hot", Title: "Hot survey"}, nil
+	}
+
+	first, err := store.Survey(ctx, "hot", load)
+	require.NoError(t, err)
+	second, err := store.Survey(ctx, "hot", load)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, loads)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, "Hot survey", second.Title)
+
+	store.Invalidate(ctx, SurveyKey("hot"))
+	_, err = store.Survey(ctx, "hot", load)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loads)
+}
+
+func TestStore_DoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	store := New(NewMemory(10), time.Minute)
+	notFound := errors.New("not found")
+
+	loads := 0
+	load := func() (*models.SurveyResults, error) {
+		loads++
+		return nil, notFound
+	}
+
+	id := uuid.New()
+	_, err := store.Results(ctx, id, load)
+	assert.ErrorIs(t, err, notFound)
+	_, err = store.Results(ctx, id, load)
+	assert.ErrorIs(t, err, notFound)
+	assert.Equal(t, 2, loads)
+}
+
+func TestStore_NilStoreLoadsDirectly(t *testing.T) {
+	var store *Store
+	assert.False(t, store.Enabled())
+
+	loads := 0
+	load := func() (*models.Survey, error) {
+		loads++
+		return &models.Survey{Slug: "s"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := store.Survey(context.Background(), "s", load)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, loads)
+
+	store.Invalidate(context.Background(), SurveyKey("s")) // Must not panic
+}
+
+func TestNewFromConfig(t *testing.T) {
+	store, err := NewFromConfig(Config{})
+	require.NoError(t, err)
+	assert.False(t, store.Enabled())
+
+	store, err = NewFromConfig(Config{Backend: "memory", Size: 5})
+	require.NoError(t, err)
+	assert.True(t, store.Enabled())
+
+	_, err = NewFromConfig(Config{Backend: "redis"})
+	assert.Error(t, err, "redis requires REDIS_URL")
+
+	store, err = NewFromConfig(Config{Backend: "disk", Dir: t.TempDir()})
+	require.NoError(t, err)
+	assert.True(t, store.Enabled())
+
+	_, err = NewFromConfig(Config{Backend: "disk"})
+	assert.Error(t, err, "disk requires a directory")
+
+	_, err = NewFromConfig(Config{Backend: "memcached"})
+	assert.Error(t, err)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CACHE_BACKEND", "redis")
+	t.Setenv("REDIS_URL", "redis://localhost:6379/1")
+	t.Setenv("CACHE_SIZE", "50")
+	t.Setenv("CACHE_TTL", "1m")
+
+	config := ConfigFromEnv()
+	assert.Equal(t, "redis", config.Backend)
+	assert.Equal(t, "redis://localhost:6379/1", config.RedisURL)
+	assert.Equal(t, 50, config.Size)
+	assert.Equal(t, time.Minute, config.TTL)
+
+	t.Setenv("CACHE_BACKEND", "none")
+	t.Setenv("CACHE_SIZE", "lots")
+	t.Setenv("CACHE_TTL", "")
+
+	config = ConfigFromEnv()
+	assert.Equal(t, "", config.Backend)
+	assert.Equal(t, DefaultSize, config.Size)
+	assert.Equal(t, DefaultTTL, config.TTL)
+}
+
+func TestCardConfigFromEnv(t *testing.T) {
+	t.Setenv("CARD_CACHE_BACKEND", "")
+	t.Setenv("CARD_CACHE_DIR", "")
+	t.Setenv("CARD_CACHE_TTL", "")
+
+	config := CardConfigFromEnv()
+	assert.Equal(t, "disk", config.Backend)
+	assert.NotEmpty(t, config.Dir)
+	assert.Equal(t, DefaultCardTTL, config.TTL)
+
+	t.Setenv("CARD_CACHE_BACKEND", "redis")
+	t.Setenv("CARD_CACHE_DIR", "/var/cache/cards")
+	t.Setenv("CARD_CACHE_TTL", "24h")
+
+	config = CardConfigFromEnv()
+	assert.Equal(t, "redis", config.Backend)
+	assert.Equal(t, "/var/cache/cards", config.Dir)
+	assert.Equal(t, 24*time.Hour, config.TTL)
+
+	t.Setenv("CARD_CACHE_BACKEND", "none")
+	t.Setenv("CARD_CACHE_TTL", "forever")
+
+	config = CardConfigFromEnv()
+	assert.Equal(t, "", config.Backend)
+	assert.Equal(t, DefaultCardTTL, config.TTL)
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often Set removes expired files
+const pruneInterval = time.Hour
+
+// Disk is a backend storing entries as files in a directory, for large values
+// such as images that should survive restarts without a Redis
+type Disk struct {
+	dir string
+	now func() time.Time
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// NewDisk creates a backend storing files in dir, creating it if needed
+func NewDisk(dir string) (*Disk, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("disk cache directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &Disk{dir: dir, now: time.Now}, nil
+}
+
+// path returns the file of a key. Keys are hashed so any key is a safe file name.
+func (d *Disk) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get returns an unexpired entry. Files start with their expiry time in Unix nanoseconds.
+func (d *Disk) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	path := d.path(key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	expiresAt, value, ok := decodeDiskEntry(data)
+	if !ok || !d.now().Before(expiresAt) {
+		_ = os.Remove(path)
+		return nil, false, nil
+	}
+	return value, true, nil
+}
+
+// Set writes an entry atomically, so concurrent readers never see a partial file
+func (d *Disk) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	d.maybePrune()
+
+	data := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(data, uint64(d.now().Add(ttl).UnixNano()))
+	copy(data[8:], value)
+
+	tmp, err := os.CreateTemp(d.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Delete removes entries
+func (d *Disk) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := os.Remove(d.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prune removes expired entries and returns how many were removed
+func (d *Disk) Prune() (int, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	now := d.now()
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".tmp-") {
+			continue // Temporary files are being written by Set
+		}
+		path := filepath.Join(d.dir, entry.Name())
+		if expiresAt, ok := readDiskExpiry(path); ok && now.Before(expiresAt) {
+			continue
+		}
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// maybePrune prunes expired entries at most once per pruneInterval, in the background
+func (d *Disk) maybePrune() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if now.Sub(d.lastPruned) < pruneInterval {
+		return
+	}
+	d.lastPruned = now
+	go func() {
+		_, _ = d.Prune()
+	}()
+}
+
+// decodeDiskEntry splits a file into its expiry time and value
+func decodeDiskEntry(data []byte) (time.Time, []byte, bool) {
+	if len(data) < 8 {
+		return time.Time{}, nil, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data))), data[8:], true
+}
+
+// readDiskExpiry reads the expiry time of a file without reading its value
+func readDiskExpiry(path string) (time.Time, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer f.Close()
+
+	var header [8]byte
+	if _, err := io.ReadFull(f, header[:]); err != nil {
+		return time.Time{}, false
+	}
+	expiresAt, _, ok := decodeDiskEntry(header[:])
+	return expiresAt, ok
+}
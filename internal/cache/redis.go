@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a backend shared by all API replicas and the consumer
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis creates a Redis backend from a connection URL
+func NewRedis(url string) (*Redis, error) {
+	if url == "" {
+		return nil, fmt.Errorf("REDIS_URL is required for the redis cache backend")
+	}
+
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse REDIS_URL: %w", err)
+	}
+
+	// Keep timeouts short so an unreachable Redis degrades to database reads
+	// instead of stalling page renders (URL parameters override these)
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = time.Second
+	}
+	if opts.ReadTimeout == 0 {
+		opts.ReadTimeout = 500 * time.Millisecond
+	}
+	if opts.WriteTimeout == 0 {
+		opts.WriteTimeout = 500 * time.Millisecond
+	}
+
+	return &Redis{client: redis.NewClient(opts)}, nil
+}
+
+// Get returns an entry
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get key: %w", err)
+	}
+	return value, true, nil
+}
+
+// Set stores an entry with an expiry
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := r.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set key: %w", err)
+	}
+	return nil
+}
+
+// Delete removes entries
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete keys: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection pool
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
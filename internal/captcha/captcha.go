@@ -0,0 +1,179 @@
+// Package captcha verifies the tokens of Cloudflare Turnstile and hCaptcha
+// widgets, which anonymous callers close to a rate limit must solve before
+// generating surveys or submitting responses.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Provider is a CAPTCHA service
+type Provider string
+
+// Supported providers
+const (
+	ProviderTurnstile Provider = "turnstile"
+	ProviderHCaptcha  Provider = "hcaptcha"
+)
+
+// maxVerifyResponseSize bounds the siteverify responses read
+const maxVerifyResponseSize = 64 << 10
+
+// Errors returned by Verify
+var (
+	ErrMissingToken = errors.New("CAPTCHA token missing")
+	ErrInvalidToken = errors.New("CAPTCHA token invalid")
+)
+
+// ScriptURL returns the provider's widget script
+func (p Provider) ScriptURL() string {
+	if p == ProviderHCaptcha {
+		return "https://js.hcaptcha.com/1/api.js"
+	}
+	return "https://challenges.cloudflare.com/turnstile/v0/api.js"
+}
+
+// WidgetClass returns the class of the element the provider's script renders the widget in
+func (p Provider) WidgetClass() string {
+	if p == ProviderHCaptcha {
+		return "h-captcha"
+	}
+	return "cf-turnstile"
+}
+
+// FormField returns the form field in which the widget posts its token
+func (p Provider) FormField() string {
+	if p == ProviderHCaptcha {
+		return "h-captcha-response"
+	}
+	return "cf-turnstile-response"
+}
+
+// verifyURL returns the provider's server-side token verification endpoint
+func (p Provider) verifyURL() string {
+	if p == ProviderHCaptcha {
+		return "https://api.hcaptcha.com/siteverify"
+	}
+	return "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+}
+
+// Config holds the CAPTCHA provider and keys
+type Config struct {
+	Provider  Provider
+	SiteKey   string
+	Secret    string
+	VerifyURL string // Overrides the provider's verification endpoint
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - CAPTCHA_PROVIDER: "turnstile" (default) or "hcaptcha"
+//   - CAPTCHA_SITE_KEY: public key the widget is rendered with
+//   - CAPTCHA_SECRET: secret key tokens are verified with (CAPTCHAs are disabled if empty)
+func ConfigFromEnv() Config {
+	config := Config{
+		Provider: ProviderTurnstile,
+		SiteKey:  os.Getenv("CAPTCHA_SITE_KEY"),
+		Secret:   os.Getenv("CAPTCHA_SECRET"),
+	}
+	if v := os.Getenv("CAPTCHA_PROVIDER"); v != "" {
+		config.Provider = Provider(strings.ToLower(v))
+	}
+	return config
+}
+
+// Widget is what a page needs to render the CAPTCHA
+type Widget struct {
+	Provider Provider
+	SiteKey  string
+}
+
+// Verifier checks CAPTCHA tokens with the provider
+type Verifier struct {
+	widget    Widget
+	secret    string
+	verifyURL string
+	client    *http.Client
+}
+
+// New creates a verifier, failing if the provider is unknown or a key is missing
+func New(config Config) (*Verifier, error) {
+	if config.Provider != ProviderTurnstile && config.Provider != ProviderHCaptcha {
+		return nil, fmt.Errorf("unsupported CAPTCHA provider: %s", config.Provider)
+	}
+	if config.SiteKey == "" || config.Secret == "" {
+		return nil, errors.New("CAPTCHA site key and secret are required")
+	}
+
+	verifyURL := config.VerifyURL
+	if verifyURL == "" {
+		verifyURL = config.Provider.verifyURL()
+	}
+	return &Verifier{
+		widget:    Widget{Provider: config.Provider, SiteKey: config.SiteKey},
+		secret:    config.Secret,
+		verifyURL: verifyURL,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Widget returns the widget tokens are verified for
+func (v *Verifier) Widget() *Widget {
+	widget := v.widget
+	return &widget
+}
+
+// verifyResponse is the part of a siteverify response the service reads
+type verifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify checks a token with the provider. Tokens can only be verified once.
+func (v *Verifier) Verify(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return ErrMissingToken
+	}
+
+	form := url.Values{"secret": {v.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	if v.widget.Provider == ProviderHCaptcha {
+		form.Set("sitekey", v.widget.SiteKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to verify CAPTCHA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var result verifyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxVerifyResponseSize)).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode verification: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("%w: %s", ErrInvalidToken, strings.Join(result.ErrorCodes, ", "))
+	}
+	return nil
+}
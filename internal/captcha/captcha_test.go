@@ -0,0 +1,75 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// siteverify serves a provider's verification endpoint accepting only "good" tokens
+func siteverify(t *testing.T, wantSiteKey string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "203.0.113.7", r.PostForm.Get("remoteip"))
+		assert.Equal(t, wantSiteKey, r.PostForm.Get("sitekey"))
+		if r.PostForm.Get("response") == "good" {
+			w.Write([]byte(`{"success": true}`))
+			return
+		}
+		w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+	}))
+}
+
+func TestVerify(t *testing.T) {
+	for _, provider := range []Provider{ProviderTurnstile, ProviderHCaptcha} {
+		t.Run(string(provider), func(t *testing.T) {
+			wantSiteKey := ""
+			if provider == ProviderHCaptcha {
+				wantSiteKey = "site"
+			}
+			server := siteverify(t, wantSiteKey)
+			defer server.Close()
+
+			v, err := New(Config{Provider: provider, SiteKey: "site", Secret: "secret", VerifyURL: server.URL})
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			assert.NoError(t, v.Verify(ctx, "good", "203.0.113.7"))
+			assert.ErrorIs(t, v.Verify(ctx, "bad", "203.0.113.7"), ErrInvalidToken)
+			assert.ErrorIs(t, v.Verify(ctx, "", "203.0.113.7"), ErrMissingToken)
+		})
+	}
+}
+
+func TestVerify_ProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	v, err := New(Config{Provider: ProviderTurnstile, SiteKey: "site", Secret: "secret", VerifyURL: server.URL})
+	require.NoError(t, err)
+
+	err = v.Verify(context.Background(), "good", "")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{Provider: "recaptcha", SiteKey: "site", Secret: "secret"})
+	assert.ErrorContains(t, err, "unsupported CAPTCHA provider")
+
+	_, err = New(Config{Provider: ProviderTurnstile, SiteKey: "site"})
+	assert.Error(t, err)
+
+	v, err := New(Config{Provider: ProviderHCaptcha, SiteKey: "site", Secret: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, &Widget{Provider: ProviderHCaptcha, SiteKey: "site"}, v.Widget())
+	assert.Equal(t, "h-captcha-response", v.Widget().Provider.FormField())
+}
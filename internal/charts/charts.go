@@ -0,0 +1,248 @@
+// Package charts renders survey results as SVG bar and pie charts. Charts are
+// rendered server-side, so they show without JavaScript, can be embedded in
+// the results page, and can be shared or embedded elsewhere as images.
+package charts
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Kind is the type of a chart
+type Kind string
+
+// Chart kinds
+const (
+	KindBar Kind = "bar"
+	KindPie Kind = "pie"
+)
+
+// ParseKind parses a chart kind, as given in a ?type= query parameter
+func ParseKind(s string) (Kind, bool) {
+	switch Kind(s) {
+	case KindBar, KindPie:
+		return Kind(s), true
+	}
+	return "", false
+}
+
+// Layout of the charts, in SVG user units
+const (
+	width           = 600
+	padding         = 16
+	captionHeight   = 32
+	barRowHeight    = 44
+	barHeight       = 20
+	pieRadius       = 110
+	legendRowHeight = 24
+	maxLabelRunes   = 48
+)
+
+// palette colors slices in order, repeating for questions with more options
+var palette = []string{"#3498db", "#e67e22", "#2ecc71", "#9b59b6", "#e74c3c", "#1abc9c", "#f1c40f", "#34495e"}
+
+// Slice is one option of a chart and its number of votes
+type Slice struct {
+	Label string
+	Value int
+}
+
+// Chart is a chart of a question's results
+type Chart struct {
+	Kind    Kind
+	Title   string  // Used as the accessible name, and drawn when Caption is set
+	Caption bool    // Draw the title above the chart, for charts shown on their own
+	Total   int     // Total responses; bar percentages are relative to it
+	Slices  []Slice // In the question's option order
+	RTL     bool    // Mirror the layout for right-to-left languages
+}
+
+// ForQuestion builds the chart of a choice question. Single-choice questions
+// default to a pie chart; multiple-choice questions, whose options can add up
+// to more than the number of responses, to a bar chart. Shares are of the
+// responses that answered the question. result may be nil if the question has
+// no responses yet.
+func ForQuestion(q *models.Question, result *models.QuestionResult, totalVotes int) *Chart {
+	kind := KindBar
+	if q.Type == models.QuestionTypeSingle {
+		kind = KindPie
+	}
+
+	chart := &Chart{
+		Kind:   kind,
+		Title:  q.Text,
+		Total:  totalVotes,
+		Slices: make([]Slice, 0, len(q.Options)),
+	}
+	if result != nil {
+		chart.Total = result.Answered(totalVotes)
+	}
+	for _, o := range q.Options {
+		value := 0
+		if result != nil {
+			value = result.OptionCounts[o.ID]
+		}
+		chart.Slices = append(chart.Slices, Slice{Label: o.Text, Value: value})
+	}
+	return chart
+}
+
+// sum returns the number of votes across all slices
+func (c *Chart) sum() int {
+	total := 0
+	for _, s := range c.Slices {
+		total += s.Value
+	}
+	return total
+}
+
+// SVG renders the chart as a standalone SVG document
+func (c *Chart) SVG() string {
+	var body strings.Builder
+	top := padding
+	if c.Caption {
+		fmt.Fprintf(&body, `<text x="%.1f" y="%d" font-size="18" font-weight="bold" fill="#2c3e50">%s</text>`,
+			mirror(c.RTL, padding, 0), padding+18, escape(truncate(c.Title, maxLabelRunes+20)))
+		top += captionHeight
+	}
+
+	var height int
+	switch {
+	case c.sum() == 0:
+		fmt.Fprintf(&body, `<text x="%.1f" y="%d" font-size="14" font-style="italic" fill="#7f8c8d">No responses yet</text>`,
+			mirror(c.RTL, padding, 0), top+16)
+		height = top + 24 + padding
+	case c.Kind == KindPie:
+		height = c.pie(&body, top)
+	default:
+		height = c.bar(&body, top)
+	}
+
+	return document(c.Title, height, c.RTL, body.String())
+}
+
+// bar draws one labelled horizontal bar per slice and returns the chart height.
+// Bars grow from the start side: the left, or the right in RTL charts.
+func (c *Chart) bar(b *strings.Builder, top int) int {
+	trackWidth := width - 2*padding
+	for i, s := range c.Slices {
+		y := top + i*barRowHeight
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="14" fill="#2c3e50">%s</text>`,
+			mirror(c.RTL, padding, 0), y+14, escape(truncate(s.Label, maxLabelRunes)))
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="14" fill="#7f8c8d" text-anchor="end">%s</text>`,
+			mirror(c.RTL, width-padding, 0), y+14, voteLabel(s.Value, c.Total))
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="#ecf0f1"/>`,
+			padding, y+20, trackWidth, barHeight)
+		if w := float64(trackWidth) * fraction(s.Value, c.Total); w > 0 {
+			fmt.Fprintf(b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" rx="4" fill="%s"/>`,
+				mirror(c.RTL, padding, w), y+20, w, barHeight, color(i))
+		}
+	}
+	return top + len(c.Slices)*barRowHeight + padding
+}
+
+// pie draws a pie of the slices with a legend on its right and returns the
+// chart height. RTL charts are mirrored: the legend is on the left of the pie,
+// and slices run counterclockwise.
+func (c *Chart) pie(b *strings.Builder, top int) int {
+	sum := c.sum()
+	cx, cy := mirror(c.RTL, padding+pieRadius, 0), float64(top+pieRadius)
+	direction, sweepFlag := 1.0, 1
+	if c.RTL {
+		direction, sweepFlag = -1, 0
+	}
+
+	angle := -math.Pi / 2 // Start at 12 o'clock
+	for i, s := range c.Slices {
+		if s.Value == 0 {
+			continue
+		}
+		if s.Value == sum {
+			// An arc from a point back to itself draws nothing
+			fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%d" fill="%s"/>`, cx, cy, pieRadius, color(i))
+			break
+		}
+		sweep := 2 * math.Pi * float64(s.Value) / float64(sum)
+		x1, y1 := cx+direction*pieRadius*math.Cos(angle), cy+pieRadius*math.Sin(angle)
+		angle += sweep
+		x2, y2 := cx+direction*pieRadius*math.Cos(angle), cy+pieRadius*math.Sin(angle)
+		largeArc := 0
+		if sweep > math.Pi {
+			largeArc = 1
+		}
+		fmt.Fprintf(b, `<path d="M%.1f,%.1f L%.1f,%.1f A%d,%d 0 %d,%d %.1f,%.1f Z" fill="%s" stroke="#fff" stroke-width="1"/>`,
+			cx, cy, x1, y1, pieRadius, pieRadius, largeArc, sweepFlag, x2, y2, color(i))
+	}
+
+	legendX := float64(padding + 2*pieRadius + 2*padding)
+	for i, s := range c.Slices {
+		y := top + i*legendRowHeight
+		fmt.Fprintf(b, `<rect x="%.1f" y="%d" width="14" height="14" rx="2" fill="%s"/>`,
+			mirror(c.RTL, legendX, 14), y+2, color(i))
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="14" fill="#2c3e50">%s <tspan fill="#7f8c8d">%s</tspan></text>`,
+			mirror(c.RTL, legendX+22, 0), y+14, escape(truncate(s.Label, maxLabelRunes/2)), voteLabel(s.Value, sum))
+	}
+
+	return top + max(2*pieRadius, len(c.Slices)*legendRowHeight) + padding
+}
+
+// document wraps a chart body in a standalone SVG document. RTL documents set
+// the SVG direction, so text anchored at "start" extends leftwards.
+func document(title string, height int, rtl bool, body string) string {
+	dir := ""
+	if rtl {
+		dir = ` direction="rtl"`
+	}
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="100%%" style="max-width: %dpx;" role="img" aria-label="%s"%s font-family="-apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif">`,
+		width, height, width, escape(title), dir)
+	fmt.Fprintf(&svg, `<title>%s</title>`, escape(title))
+	svg.WriteString(body)
+	svg.WriteString(`</svg>`)
+	return svg.String()
+}
+
+// mirror returns the left edge of an element of width w whose left edge is at
+// x in a left-to-right chart, mirrored across the chart if rtl is set
+func mirror(rtl bool, x, w float64) float64 {
+	if rtl {
+		return width - x - w
+	}
+	return x
+}
+
+// fraction returns value/total, or 0 if there are no votes
+func fraction(value, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(value) / float64(total)
+}
+
+// voteLabel formats a count and its percentage of total, e.g. "3 (25%)"
+func voteLabel(value, total int) string {
+	return fmt.Sprintf("%d (%.0f%%)", value, fraction(value, total)*100)
+}
+
+// color returns the fill color of the i-th slice
+func color(i int) string {
+	return palette[i%len(palette)]
+}
+
+// truncate shortens s to at most n runes, ending in an ellipsis if shortened
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// escape escapes text for SVG element content and attribute values
+func escape(s string) string {
+	return html.EscapeString(s)
+}
@@ -0,0 +1,210 @@
+package charts
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertWellFormed fails if svg is not well-formed XML
+func assertWellFormed(t *testing.T, svg string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := d.Token()
+		if err != nil {
+			require.Equal(t, "EOF", err.Error(), "invalid SVG: %s", svg)
+			return
+		}
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	kind, ok := ParseKind("pie")
+	assert.True(t, ok)
+	assert.Equal(t, KindPie, kind)
+
+	kind, ok = ParseKind("bar")
+	assert.True(t, ok)
+	assert.Equal(t, KindBar, kind)
+
+	_, ok = ParseKind("line")
+	assert.False(t, ok)
+	_, ok = ParseKind("")
+	assert.False(t, ok)
+}
+
+func TestForQuestion(t *testing.T) {
+	q := &models.Question{
+		ID:   "q1",
+		Text: "Favorite color?",
+		Type: models.QuestionTypeSingle,
+		Options: []models.Option{
+			{ID: "red", Text: "Red"},
+			{ID: "blue", Text: "Blue"},
+		},
+	}
+	result := &models.QuestionResult{QuestionID: "q1", OptionCounts: map[string]int{"blue": 3}}
+
+	chart := ForQuestion(q, result, 3)
+	assert.Equal(t, KindPie, chart.Kind)
+	assert.Equal(t, "Favorite color?", chart.Title)
+	assert.Equal(t, 3, chart.Total)
+	assert.Equal(t, []Slice{{Label: "Red", Value: 0}, {Label: "Blue", Value: 3}}, chart.Slices)
+
+	q.Type = models.QuestionTypeMulti
+	chart = ForQuestion(q, nil, 0)
+	assert.Equal(t, KindBar, chart.Kind)
+	assert.Equal(t, []Slice{{Label: "Red"}, {Label: "Blue"}}, chart.Slices)
+}
+
+func TestSVG_Bar(t *testing.T) {
+	chart := &Chart{
+		Kind:   KindBar,
+		Title:  "Which languages?",
+		Total:  4,
+		Slices: []Slice{{Label: "Go", Value: 3}, {Label: "Rust", Value: 1}, {Label: "Zig", Value: 0}},
+	}
+	svg := chart.SVG()
+
+	assertWellFormed(t, svg)
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	assert.Contains(t, svg, `<title>Which languages?</title>`)
+	assert.Contains(t, svg, "3 (75%)")
+	assert.Contains(t, svg, "1 (25%)")
+	assert.Contains(t, svg, "0 (0%)")
+	// One track per option, one filled bar per option with votes
+	assert.Equal(t, 5, strings.Count(svg, "<rect"))
+	// The caption is only drawn for standalone charts
+	assert.NotContains(t, svg, `font-weight="bold"`)
+}
+
+func TestSVG_Pie(t *testing.T) {
+	chart := &Chart{
+		Kind:   KindPie,
+		Title:  "Favorite color?",
+		Slices: []Slice{{Label: "Red", Value: 1}, {Label: "Blue", Value: 3}, {Label: "Green", Value: 0}},
+	}
+	svg := chart.SVG()
+
+	assertWellFormed(t, svg)
+	assert.Equal(t, 2, strings.Count(svg, "<path"))
+	assert.Contains(t, svg, "1 (25%)")
+	assert.Contains(t, svg, "3 (75%)")
+	// The larger slice sweeps over half the circle
+	assert.Contains(t, svg, " 0 1,1 ")
+}
+
+func TestSVG_PieSingleSlice(t *testing.T) {
+	chart := &Chart{Kind: KindPie, Slices: []Slice{{Label: "Yes", Value: 2}, {Label: "No", Value: 0}}}
+	svg := chart.SVG()
+
+	assertWellFormed(t, svg)
+	assert.Contains(t, svg, "<circle")
+	assert.NotContains(t, svg, "<path")
+}
+
+func TestSVG_NoResponses(t *testing.T) {
+	for _, kind := range []Kind{KindBar, KindPie} {
+		chart := &Chart{Kind: kind, Slices: []Slice{{Label: "Yes"}, {Label: "No"}}}
+		svg := chart.SVG()
+		assertWellFormed(t, svg)
+		assert.Contains(t, svg, "No responses yet")
+		assert.NotContains(t, svg, "<rect")
+	}
+}
+
+func TestSVG_Caption(t *testing.T) {
+	chart := &Chart{Kind: KindBar, Title: "Lunch?", Caption: true, Total: 1, Slices: []Slice{{Label: "Pizza", Value: 1}}}
+	svg := chart.SVG()
+	assertWellFormed(t, svg)
+	assert.Contains(t, svg, `font-weight="bold" fill="#2c3e50">Lunch?</text>`)
+}
+
+func TestSVG_EscapesText(t *testing.T) {
+	chart := &Chart{
+		Kind:    KindBar,
+		Title:   `"><script>alert(1)</script>`,
+		Caption: true,
+		Total:   1,
+		Slices:  []Slice{{Label: "<b>Tom & Jerry</b>", Value: 1}},
+	}
+	svg := chart.SVG()
+
+	assertWellFormed(t, svg)
+	assert.NotContains(t, svg, "<script>")
+	assert.NotContains(t, svg, "<b>")
+	assert.Contains(t, svg, "&lt;b&gt;Tom &amp; Jerry&lt;/b&gt;")
+}
+
+func TestSVG_TruncatesLongLabels(t *testing.T) {
+	label := strings.Repeat("é", 100)
+	chart := &Chart{Kind: KindBar, Total: 1, Slices: []Slice{{Label: label, Value: 1}}}
+	svg := chart.SVG()
+
+	assert.NotContains(t, svg, label)
+	assert.Contains(t, svg, strings.Repeat("é", maxLabelRunes-1)+"…")
+}
+
+func TestSeriesSVG(t *testing.T) {
+	series := &Series{Title: "Responses per day", Points: []Point{
+		{Label: "Mar 1", Value: 4},
+		{Label: "Mar 2", Value: 0},
+		{Label: "Mar 3", Value: 2},
+	}}
+
+	svg := series.SVG()
+	assertWellFormed(t, svg)
+	assert.Contains(t, svg, `aria-label="Responses per day"`)
+	assert.Equal(t, 2, strings.Count(svg, "<rect"), "one column per point with a value")
+	assert.Contains(t, svg, "<title>Mar 1: 4</title>")
+	assert.Contains(t, svg, ">Mar 1</text>")
+	assert.Contains(t, svg, ">Mar 2</text>")
+	assert.Contains(t, svg, ">Mar 3</text>")
+
+	empty := &Series{Title: "Views <per> day", Points: []Point{{Label: "Mar 1"}}}
+	svg = empty.SVG()
+	assertWellFormed(t, svg)
+	assert.Contains(t, svg, "No data yet")
+	assert.Contains(t, svg, "Views &lt;per&gt; day")
+}
+
+func TestChart_RTLMirrorsBars(t *testing.T) {
+	chart := &Chart{Kind: KindBar, Title: "Q", Total: 4, Slices: []Slice{{Label: "نعم", Value: 1}}, RTL: true}
+	svg := chart.SVG()
+	assertWellFormed(t, svg)
+
+	assert.Contains(t, svg, `direction="rtl"`)
+	// The label starts at the right edge and the count ends at the left edge
+	assert.Contains(t, svg, `<text x="584.0" y="30" font-size="14" fill="#2c3e50">نعم</text>`)
+	assert.Contains(t, svg, `<text x="16.0" y="30" font-size="14" fill="#7f8c8d" text-anchor="end">1 (25%)</text>`)
+	// The bar grows from the right: a quarter of the 568-unit track, ending at 584
+	assert.Contains(t, svg, `<rect x="442.0" y="36" width="142.0"`)
+}
+
+func TestChart_RTLMirrorsPie(t *testing.T) {
+	chart := &Chart{Kind: KindPie, Title: "Q", Total: 4, Slices: []Slice{{Label: "a", Value: 1}, {Label: "b", Value: 3}}, RTL: true}
+	svg := chart.SVG()
+	assertWellFormed(t, svg)
+
+	// The pie sits on the right, with slices running counterclockwise
+	assert.Contains(t, svg, `<path d="M474.0,126.0 L474.0,16.0`)
+	assert.Contains(t, svg, " 0 0,0 ")
+	// The legend is on the left
+	assert.Contains(t, svg, `<rect x="318.0" y="18" width="14"`)
+}
+
+func TestSeries_RTLRunsRightToLeft(t *testing.T) {
+	series := &Series{Title: "Responses per day", RTL: true, Points: []Point{{Label: "Mar 1", Value: 4}, {Label: "Mar 2", Value: 2}}}
+	svg := series.SVG()
+	assertWellFormed(t, svg)
+
+	// The oldest column is on the right
+	assert.Contains(t, svg, `<rect x="301.0" y="32.0" width="282.0"`)
+	assert.Contains(t, svg, `<text x="584.0" y="194" font-size="12" fill="#7f8c8d">Mar 1</text>`)
+	assert.Contains(t, svg, `<text x="16.0" y="194" font-size="12" fill="#7f8c8d" text-anchor="end">Mar 2</text>`)
+}
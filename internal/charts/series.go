@@ -0,0 +1,87 @@
+package charts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layout of series charts, in SVG user units
+const (
+	seriesPlotHeight = 160
+	seriesAxisHeight = 24
+	seriesGap        = 2
+)
+
+// Point is one column of a series chart
+type Point struct {
+	Label string // Shown below the first, middle, and last columns and in tooltips
+	Value int
+}
+
+// Series is a column chart of counts over time, e.g. responses per day
+type Series struct {
+	Title  string
+	Color  string  // Column fill; defaults to the first palette color
+	Points []Point // Oldest first
+	RTL    bool    // Run time right to left, for right-to-left languages
+}
+
+// SVG renders the series as a standalone SVG document
+func (s *Series) SVG() string {
+	var body strings.Builder
+	top := padding
+
+	peak := 0
+	for _, p := range s.Points {
+		peak = max(peak, p.Value)
+	}
+
+	height := top + seriesPlotHeight + seriesAxisHeight + padding
+	if peak == 0 {
+		fmt.Fprintf(&body, `<text x="%.1f" y="%d" font-size="14" font-style="italic" fill="#7f8c8d">No data yet</text>`,
+			mirror(s.RTL, padding, 0), top+16)
+		height = top + 24 + padding
+	} else {
+		s.columns(&body, top, peak)
+	}
+
+	return document(s.Title, height, s.RTL, body.String())
+}
+
+// columns draws one column per point scaled to peak, a baseline, the peak
+// value, and the labels of the first, middle, and last points
+func (s *Series) columns(b *strings.Builder, top, peak int) {
+	fill := s.Color
+	if fill == "" {
+		fill = color(0)
+	}
+	plotWidth := float64(width - 2*padding)
+	step := plotWidth / float64(len(s.Points))
+	baseline := top + seriesPlotHeight
+
+	fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="12" fill="#7f8c8d">%d</text>`, mirror(s.RTL, padding, 0), top+10, peak)
+	for i, p := range s.Points {
+		if p.Value == 0 {
+			continue
+		}
+		h := float64(seriesPlotHeight-16) * fraction(p.Value, peak)
+		w := max(step-seriesGap, 1)
+		x := float64(padding) + float64(i)*step + seriesGap/2
+		fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="1" fill="%s"><title>%s: %d</title></rect>`,
+			mirror(s.RTL, x, w), float64(baseline)-h, w, h, fill, escape(p.Label), p.Value)
+	}
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#bdc3c7" stroke-width="1"/>`,
+		padding, baseline, width-padding, baseline)
+
+	last := len(s.Points) - 1
+	fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="12" fill="#7f8c8d">%s</text>`,
+		mirror(s.RTL, padding, 0), baseline+18, escape(s.Points[0].Label))
+	if last >= 2 {
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="12" fill="#7f8c8d" text-anchor="middle">%s</text>`,
+			mirror(s.RTL, float64(padding)+(float64(last/2)+0.5)*step, 0), baseline+18, escape(s.Points[last/2].Label))
+	}
+	if last >= 1 {
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="12" fill="#7f8c8d" text-anchor="end">%s</text>`,
+			mirror(s.RTL, width-padding, 0), baseline+18, escape(s.Points[last].Label))
+	}
+}
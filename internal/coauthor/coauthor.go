@@ -0,0 +1,94 @@
+// Package coauthor lets a survey's author share it with co-authors. Co-authors
+// edit the local survey, see its responses, exports, analytics, and private
+// results, and can ask for its results to be published. Results records are
+// written to the author's PDS, so a co-author's request waits until the
+// author approves it; results records in a co-author's own PDS are not
+// accepted.
+package coauthor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxCoAuthors bounds the co-authors of a survey
+const MaxCoAuthors = 20
+
+// Statuses of publish requests
+const (
+	StatusPending  = "pending"  // Waiting for the author
+	StatusApproved = "approved" // The author published the results
+	StatusRejected = "rejected" // The author declined, or the requester withdrew it
+)
+
+// Errors of Store methods caused by the request
+var (
+	ErrAlreadyCoAuthor = errors.New("already a co-author")
+	ErrRequestPending  = errors.New("a publish request is already pending")
+)
+
+// CoAuthor is a co-author of a survey
+type CoAuthor struct {
+	SurveyID  uuid.UUID `json:"surveyId"`
+	DID       string    `json:"did"`
+	AddedBy   string    `json:"addedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PublishRequest is a co-author's request for the author to publish a
+// survey's results to their PDS
+type PublishRequest struct {
+	ID          uuid.UUID  `json:"id"`
+	SurveyID    uuid.UUID  `json:"surveyId"`
+	RequestedBy string     `json:"requestedBy"`
+	Status      string     `json:"status"`
+	ResolvedBy  *string    `json:"resolvedBy,omitempty"`
+	ResolvedAt  *time.Time `json:"resolvedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// Store persists co-authors and publish requests
+type Store interface {
+	// AddCoAuthor returns ErrAlreadyCoAuthor if the DID is a co-author of the survey
+	AddCoAuthor(ctx context.Context, c *CoAuthor) error
+	// ListCoAuthors returns the co-authors of a survey, oldest first
+	ListCoAuthors(ctx context.Context, surveyID uuid.UUID) ([]*CoAuthor, error)
+	IsCoAuthor(ctx context.Context, surveyID uuid.UUID, did string) (bool, error)
+	// RemoveCoAuthor returns sql.ErrNoRows if the DID is not a co-author.
+	// Their pending publish request is rejected.
+	RemoveCoAuthor(ctx context.Context, surveyID uuid.UUID, did string, now time.Time) error
+	// CreatePublishRequest returns ErrRequestPending if the survey has a pending request
+	CreatePublishRequest(ctx context.Context, r *PublishRequest) error
+	// GetPendingPublishRequest returns sql.ErrNoRows if the survey has no pending request
+	GetPendingPublishRequest(ctx context.Context, surveyID uuid.UUID) (*PublishRequest, error)
+	// ResolvePublishRequest approves or rejects a pending request, returning
+	// sql.ErrNoRows if the survey has no such pending request
+	ResolvePublishRequest(ctx context.Context, id, surveyID uuid.UUID, status, resolvedBy string, now time.Time) error
+}
+
+// NormalizeDID trims a DID entered in a form, returning an error if it is
+// not a DID. Handles are resolved by callers.
+func NormalizeDID(did string) (string, error) {
+	did = strings.TrimSpace(did)
+	parts := strings.SplitN(did, ":", 3)
+	if len(parts) != 3 || parts[0] != "did" || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("%q is not a DID", did)
+	}
+	return did, nil
+}
+
+// NewPublishRequest creates a pending publish request of a co-author
+func NewPublishRequest(surveyID uuid.UUID, requestedBy string, now time.Time) *PublishRequest {
+	return &PublishRequest{
+		ID:          uuid.New(),
+		SurveyID:    surveyID,
+		RequestedBy: requestedBy,
+		Status:      StatusPending,
+		CreatedAt:   now,
+	}
+}
@@ -0,0 +1,36 @@
+package coauthor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDID(t *testing.T) {
+	did, err := NormalizeDID("  did:plc:alice ")
+	require.NoError(t, err)
+	assert.Equal(t, "did:plc:alice", did)
+
+	did, err = NormalizeDID("did:web:example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "did:web:example.com", did)
+
+	for _, invalid := range []string{"", "alice.bsky.social", "did:plc", "did::alice", "did:plc:", "dad:plc:alice"} {
+		_, err := NormalizeDID(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestNewPublishRequest(t *testing.T) {
+	surveyID, now := uuid.New(), time.Now()
+	r := NewPublishRequest(surveyID, "did:plc:bob", now)
+	assert.NotEqual(t, uuid.Nil, r.ID)
+	assert.Equal(t, surveyID, r.SurveyID)
+	assert.Equal(t, "did:plc:bob", r.RequestedBy)
+	assert.Equal(t, StatusPending, r.Status)
+	assert.Equal(t, now, r.CreatedAt)
+	assert.Nil(t, r.ResolvedAt)
+}
@@ -1,9 +1,11 @@
 package consumer
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/openmeet-team/survey/internal/models"
 )
@@ -29,6 +31,35 @@ func ParseSurveyRecord(record map[string]interface{}) (*models.SurveyDefinition,
 		anonymous = anonVal
 	}
 
+	// Extract primary language (optional, first entry of "langs")
+	var language string
+	if langs, hasLangs := record["langs"].([]interface{}); hasLangs && len(langs) > 0 {
+		if lang, ok := langs[0].(string); ok {
+			language = lang
+		}
+	}
+
+	// Extract confirm-before-submit flag (optional, default false)
+	confirmBeforeSubmit, _ := record["confirmBeforeSubmit"].(bool)
+
+	// Extract visibility (optional, default public); validated with the definition
+	visibility, _ := record["visibility"].(string)
+
+	// Extract response metadata flag (optional, default false)
+	responseMetadata, _ := record["responseMetadata"].(bool)
+
+	// Extract redaction settings (optional, default none)
+	var redaction *models.Redaction
+	if r, ok := record["redaction"].(map[string]interface{}); ok {
+		redaction = &models.Redaction{}
+		redaction.Emails, _ = r["emails"].(bool)
+		redaction.Phones, _ = r["phones"].(bool)
+		redaction.Profanity, _ = r["profanity"].(bool)
+		if !redaction.Enabled() {
+			redaction = nil
+		}
+	}
+
 	// Parse questions array
 	questionsRaw, ok := record["questions"].([]interface{})
 	if !ok || len(questionsRaw) == 0 {
@@ -51,8 +82,13 @@ func ParseSurveyRecord(record map[string]interface{}) (*models.SurveyDefinition,
 	}
 
 	def := &models.SurveyDefinition{
-		Questions: questions,
-		Anonymous: anonymous,
+		Questions:           questions,
+		Anonymous:           anonymous,
+		Language:            language,
+		ConfirmBeforeSubmit: confirmBeforeSubmit,
+		Visibility:          visibility,
+		ResponseMetadata:    responseMetadata,
+		Redaction:           redaction,
 	}
 
 	return def, name, description, nil
@@ -105,12 +141,44 @@ func parseQuestion(qObj map[string]interface{}, index int) (*models.Question, er
 		}
 	}
 
+	// Parse rows array (for matrix questions); rows have the shape of options
+	var rows []models.Option
+	if rowsRaw, hasRows := qObj["rows"].([]interface{}); hasRows {
+		for j, rowRaw := range rowsRaw {
+			rowObj, ok := rowRaw.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("question %d, row %d: not an object", index, j)
+			}
+
+			row, err := parseOption(rowObj, index, j)
+			if err != nil {
+				return nil, err
+			}
+
+			rows = append(rows, *row)
+		}
+	}
+
+	// Extract bounds of number and date questions (optional)
+	minValue, _ := qObj["min"].(string)
+	maxValue, _ := qObj["max"].(string)
+
+	// Extract answer lengths of text questions (optional); JSON numbers decode as float64
+	minLength, _ := qObj["minLength"].(float64)
+	maxLength, _ := qObj["maxLength"].(float64)
+
 	return &models.Question{
-		ID:       id,
-		Text:     text,
-		Type:     models.QuestionType(questionType),
-		Required: required,
-		Options:  options,
+		ID:        id,
+		Text:      text,
+		Type:      models.QuestionType(questionType),
+		Required:  required,
+		Options:   options,
+		Rows:      rows,
+		Min:       minValue,
+		Max:       maxValue,
+		MinLength: int(minLength),
+		MaxLength: int(maxLength),
+		Image:     parseImage(qObj["image"]),
 	}, nil
 }
 
@@ -127,11 +195,33 @@ func parseOption(optObj map[string]interface{}, qIndex, optIndex int) (*models.O
 	}
 
 	return &models.Option{
-		ID:   id,
-		Text: text,
+		ID:    id,
+		Text:  text,
+		Image: parseImage(optObj["image"]),
 	}, nil
 }
 
+// parseImage parses an optional image ({image: blob, alt}). Invalid images are
+// dropped rather than rejecting the survey, as they are not needed to vote.
+func parseImage(raw interface{}) *models.Image {
+	if raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var image models.Image
+	if err := json.Unmarshal(data, &image); err != nil {
+		return nil
+	}
+	if err := image.Validate(); err != nil {
+		return nil
+	}
+	return &image
+}
+
 // stripTokenPrefix converts "net.openmeet.survey#single" -> "single"
 func stripTokenPrefix(tokenType string) string {
 	// Split on '#' and take the last part
@@ -201,6 +291,40 @@ func ParseResponseRecord(record map[string]interface{}) (string, map[string]mode
 			answer.Text = textStr
 		}
 
+		// Parse rows array (for matrix questions)
+		if rowsRaw, hasRows := ansObj["rows"]; hasRows {
+			rowsArr, ok := rowsRaw.([]interface{})
+			if !ok {
+				return "", nil, fmt.Errorf("answer %d: rows must be an array", i)
+			}
+
+			answer.Rows = make(map[string]string, len(rowsArr))
+			for j, rowRaw := range rowsArr {
+				rowObj, ok := rowRaw.(map[string]interface{})
+				if !ok {
+					return "", nil, fmt.Errorf("answer %d, row %d: not an object", i, j)
+				}
+				rowID, _ := rowObj["rowId"].(string)
+				optionID, _ := rowObj["optionId"].(string)
+				if rowID == "" || optionID == "" {
+					return "", nil, fmt.Errorf("answer %d, row %d: rowId and optionId are required", i, j)
+				}
+				answer.Rows[rowID] = optionID
+			}
+		}
+
+		// Parse file blob reference (for file questions)
+		if fileRaw, hasFile := ansObj["file"]; hasFile {
+			data, err := json.Marshal(fileRaw)
+			if err != nil {
+				return "", nil, fmt.Errorf("answer %d: invalid file: %w", i, err)
+			}
+			answer.File = &models.Blob{}
+			if err := json.Unmarshal(data, answer.File); err != nil {
+				return "", nil, fmt.Errorf("answer %d: file must be a blob reference", i)
+			}
+		}
+
 		answers[questionID] = answer
 	}
 
@@ -236,6 +360,16 @@ func GenerateSlugFromTitle(title string) string {
 	return slug
 }
 
+// ResponseSubjectCID returns the CID of the survey record a response record
+// references in its subject, or nil if it has none
+func ResponseSubjectCID(record map[string]interface{}) *string {
+	subject, _ := record["subject"].(map[string]interface{})
+	if cid, ok := subject["cid"].(string); ok && cid != "" {
+		return &cid
+	}
+	return nil
+}
+
 // ParseResultsRecord parses an ATProto survey results record
 // Returns: surveyURI, resultsCID
 func ParseResultsRecord(record map[string]interface{}) (string, error) {
@@ -253,3 +387,37 @@ func ParseResultsRecord(record map[string]interface{}) (string, error) {
 	// We don't need to parse the actual results data - just track that results were published
 	return surveyURI, nil
 }
+
+// ParseCommentRecord parses an ATProto survey comment record
+// Returns: surveyURI, text, and createdAt, or now if the record declares a
+// later or no time
+func ParseCommentRecord(record map[string]interface{}, now time.Time) (string, string, time.Time, error) {
+	subject, ok := record["subject"].(map[string]interface{})
+	if !ok {
+		return "", "", time.Time{}, fmt.Errorf("subject is required")
+	}
+
+	// The subject must be a survey record
+	surveyURI, ok := subject["uri"].(string)
+	if !ok || surveyURI == "" {
+		return "", "", time.Time{}, fmt.Errorf("subject.uri is required")
+	}
+	parts := strings.Split(strings.TrimPrefix(surveyURI, "at://"), "/")
+	if !strings.HasPrefix(surveyURI, "at://") || len(parts) != 3 || parts[0] == "" || parts[1] != "net.openmeet.survey" || parts[2] == "" {
+		return "", "", time.Time{}, fmt.Errorf("subject.uri must be a net.openmeet.survey record, got %q", surveyURI)
+	}
+
+	text, _ := record["text"].(string)
+	if err := models.ValidateCommentText(text); err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	createdAt := now
+	if s, ok := record["createdAt"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil && t.Before(now) {
+			createdAt = t
+		}
+	}
+
+	return surveyURI, text, createdAt, nil
+}
@@ -0,0 +1,255 @@
+package consumer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+func TestParseSurveyRecord_Images(t *testing.T) {
+	var record map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"name": "Logos",
+		"questions": [{
+			"id": "q1",
+			"text": "Which logo?",
+			"type": "net.openmeet.survey#single",
+			"image": {"image": {"$type": "blob", "ref": {"$link": "bafkreibme22gw2h7y2h7tg2fhqotaqjucnbc24deqo72b6mkl2egezxhvy"}, "mimeType": "image/png", "size": 1234}, "alt": "Both logos"},
+			"options": [
+				{"id": "a", "text": "A", "image": {"image": {"$type": "blob", "ref": {"$link": "bafkreibme22gw2h7y2h7tg2fhqotaqjucnbc24deqo72b6mkl2egezxhvy"}, "mimeType": "image/svg+xml", "size": 10}, "alt": ""}},
+				{"id": "b", "text": "B", "image": "not an image"}
+			]
+		}]
+	}`), &record)
+	if err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+
+	def, _, _, err := ParseSurveyRecord(record)
+	if err != nil {
+		t.Fatalf("ParseSurveyRecord failed: %v", err)
+	}
+
+	q := def.Questions[0]
+	if q.Image == nil || q.Image.Alt != "Both logos" || q.Image.Image.Size != 1234 {
+		t.Errorf("question image = %+v, want the parsed blob", q.Image)
+	}
+
+	// Invalid images are dropped without rejecting the survey
+	for _, o := range q.Options {
+		if o.Image != nil {
+			t.Errorf("option %s image = %+v, want nil", o.ID, o.Image)
+		}
+	}
+}
+
+func TestParseRecords_Matrix(t *testing.T) {
+	var survey map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"name": "Event",
+		"questions": [{
+			"id": "q1",
+			"text": "Rate the event",
+			"type": "net.openmeet.survey#matrix",
+			"options": [{"id": "agree", "text": "Agree"}, {"id": "disagree", "text": "Disagree"}],
+			"rows": [{"id": "venue", "text": "Venue"}, {"id": "food", "text": "Food"}]
+		}]
+	}`), &survey)
+	if err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+
+	def, _, _, err := ParseSurveyRecord(survey)
+	if err != nil {
+		t.Fatalf("ParseSurveyRecord failed: %v", err)
+	}
+	if q := def.Questions[0]; len(q.Rows) != 2 || q.Rows[1].ID != "food" {
+		t.Errorf("rows = %+v, want venue and food", q.Rows)
+	}
+
+	var response map[string]interface{}
+	err = json.Unmarshal([]byte(`{
+		"subject": {"uri": "at://did:plc:author/net.openmeet.survey/3k2a"},
+		"answers": [{"questionId": "q1", "rows": [{"rowId": "venue", "optionId": "agree"}, {"rowId": "food", "optionId": "disagree"}]}]
+	}`), &response)
+	if err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+
+	_, answers, err := ParseResponseRecord(response)
+	if err != nil {
+		t.Fatalf("ParseResponseRecord failed: %v", err)
+	}
+	if rows := answers["q1"].Rows; rows["venue"] != "agree" || rows["food"] != "disagree" {
+		t.Errorf("rows = %v, want venue=agree and food=disagree", rows)
+	}
+}
+
+func TestParseResponseRecord_File(t *testing.T) {
+	var response map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"subject": {"uri": "at://did:plc:author/net.openmeet.survey/3k2a"},
+		"answers": [{"questionId": "receipt", "file": {"$type": "blob", "ref": {"$link": "bafkreibme22gw2h7y2h7tg2fhqotaqjucnbc24deqo72b6mkl2egezxhvy"}, "mimeType": "application/pdf", "size": 5120}}]
+	}`), &response)
+	if err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+
+	_, answers, err := ParseResponseRecord(response)
+	if err != nil {
+		t.Fatalf("ParseResponseRecord failed: %v", err)
+	}
+	file := answers["receipt"].File
+	if file == nil || file.MimeType != "application/pdf" || file.Size != 5120 || file.CID() != "bafkreibme22gw2h7y2h7tg2fhqotaqjucnbc24deqo72b6mkl2egezxhvy" {
+		t.Errorf("file = %+v, want the parsed blob", file)
+	}
+
+	response["answers"] = []interface{}{map[string]interface{}{"questionId": "receipt", "file": "not a blob"}}
+	if _, _, err := ParseResponseRecord(response); err == nil {
+		t.Error("ParseResponseRecord accepted a file that is not a blob reference")
+	}
+}
+
+func TestResponseSubjectCID(t *testing.T) {
+	tests := []struct {
+		name   string
+		record map[string]interface{}
+		want   string
+	}{
+		{"strong ref", map[string]interface{}{"subject": map[string]interface{}{"uri": "at://did:plc:a/net.openmeet.survey/1", "cid": "bafy1"}}, "bafy1"},
+		{"no cid", map[string]interface{}{"subject": map[string]interface{}{"uri": "at://did:plc:a/net.openmeet.survey/1"}}, ""},
+		{"empty cid", map[string]interface{}{"subject": map[string]interface{}{"cid": ""}}, ""},
+		{"no subject", map[string]interface{}{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResponseSubjectCID(tt.record)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("ResponseSubjectCID() = %q, want nil", *got)
+				}
+				return
+			}
+			if got == nil || *got != tt.want {
+				t.Errorf("ResponseSubjectCID() = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSurveyRecord_Visibility(t *testing.T) {
+	record := map[string]interface{}{
+		"name":       "Team retro",
+		"visibility": "token",
+		"questions": []interface{}{
+			map[string]interface{}{"id": "q1", "text": "What went well?", "type": "net.openmeet.survey#text"},
+		},
+	}
+
+	def, _, _, err := ParseSurveyRecord(record)
+	if err != nil {
+		t.Fatalf("ParseSurveyRecord failed: %v", err)
+	}
+	if def.Visibility != "token" {
+		t.Errorf("visibility = %q, want token", def.Visibility)
+	}
+}
+
+func TestParseSurveyRecord_TextLengths(t *testing.T) {
+	var record map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"name": "Feedback",
+		"questions": [{"id": "q1", "text": "Why?", "type": "net.openmeet.survey#text", "minLength": 10, "maxLength": 500}]
+	}`), &record)
+	if err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+
+	def, _, _, err := ParseSurveyRecord(record)
+	if err != nil {
+		t.Fatalf("ParseSurveyRecord failed: %v", err)
+	}
+	if q := def.Questions[0]; q.MinLength != 10 || q.MaxLength != 500 {
+		t.Errorf("lengths = %d to %d, want 10 to 500", q.MinLength, q.MaxLength)
+	}
+}
+
+func TestParseSurveyRecord_Redaction(t *testing.T) {
+	var record map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"name": "Feedback",
+		"questions": [{"id": "q1", "text": "Why?", "type": "net.openmeet.survey#text"}],
+		"redaction": {"emails": true, "profanity": true}
+	}`), &record)
+	if err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+
+	def, _, _, err := ParseSurveyRecord(record)
+	if err != nil {
+		t.Fatalf("ParseSurveyRecord failed: %v", err)
+	}
+	if want := (models.Redaction{Emails: true, Profanity: true}); def.Redaction == nil || *def.Redaction != want {
+		t.Errorf("redaction = %+v, want %+v", def.Redaction, want)
+	}
+
+	// Settings that redact nothing are dropped
+	record["redaction"] = map[string]interface{}{"phones": false}
+	def, _, _, err = ParseSurveyRecord(record)
+	if err != nil {
+		t.Fatalf("ParseSurveyRecord failed: %v", err)
+	}
+	if def.Redaction != nil {
+		t.Errorf("redaction = %+v, want nil", def.Redaction)
+	}
+}
+
+func TestParseCommentRecord(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	subject := map[string]interface{}{"uri": "at://did:plc:a/net.openmeet.survey/1", "cid": "bafy1"}
+
+	surveyURI, text, createdAt, err := ParseCommentRecord(map[string]interface{}{
+		"subject": subject, "text": "Great survey", "createdAt": "2026-03-01T10:00:00Z",
+	}, now)
+	if err != nil {
+		t.Fatalf("ParseCommentRecord() error = %v", err)
+	}
+	if surveyURI != "at://did:plc:a/net.openmeet.survey/1" || text != "Great survey" {
+		t.Errorf("ParseCommentRecord() = %q, %q", surveyURI, text)
+	}
+	if want := now.Add(-2 * time.Hour); !createdAt.Equal(want) {
+		t.Errorf("createdAt = %v, want %v", createdAt, want)
+	}
+
+	// Times in the future are not trusted
+	_, _, createdAt, err = ParseCommentRecord(map[string]interface{}{
+		"subject": subject, "text": "Later", "createdAt": "2030-01-01T00:00:00Z",
+	}, now)
+	if err != nil || !createdAt.Equal(now) {
+		t.Errorf("ParseCommentRecord() createdAt = %v, %v, want %v", createdAt, err, now)
+	}
+
+	invalid := []struct {
+		name   string
+		record map[string]interface{}
+	}{
+		{"no subject", map[string]interface{}{"text": "Hi"}},
+		{"no subject uri", map[string]interface{}{"subject": map[string]interface{}{"cid": "bafy1"}, "text": "Hi"}},
+		{"subject not a survey", map[string]interface{}{"subject": map[string]interface{}{"uri": "at://did:plc:a/net.openmeet.survey.response/1"}, "text": "Hi"}},
+		{"subject not an at uri", map[string]interface{}{"subject": map[string]interface{}{"uri": "https://example.com/net.openmeet.survey/1"}, "text": "Hi"}},
+		{"empty text", map[string]interface{}{"subject": subject, "text": "  "}},
+		{"text too long", map[string]interface{}{"subject": subject, "text": strings.Repeat("a", 3001)}},
+		{"too many characters", map[string]interface{}{"subject": subject, "text": strings.Repeat("é", 1001)}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, err := ParseCommentRecord(tt.record, now); err == nil {
+				t.Error("ParseCommentRecord() expected an error")
+			}
+		})
+	}
+}
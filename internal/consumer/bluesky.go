@@ -0,0 +1,117 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/openmeet-team/survey/internal/interop"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// SetBlueskyPollAuthors sets the DIDs whose Bluesky posts are indexed as
+// read-only polls, voted on by replying. None disables Bluesky poll ingestion.
+func (p *Processor) SetBlueskyPollAuthors(dids []string) {
+	p.pollAuthors = make(map[string]bool, len(dids))
+	for _, did := range dids {
+		p.pollAuthors[did] = true
+	}
+}
+
+// skipsPost reports whether a message is a Bluesky post that can be neither
+// a poll of an opted-in author nor a reply to one. Subscribing to posts streams
+// all of Bluesky, so such posts are dropped before they are queued.
+// Deletes carry no record, so they are kept: they may withdraw votes.
+func (p *Processor) skipsPost(msg *JetstreamMessage) bool {
+	commit := msg.Commit
+	if commit == nil || commit.Collection != interop.PostCollection {
+		return false
+	}
+	if len(p.pollAuthors) == 0 {
+		return true
+	}
+	if commit.Record == nil {
+		return false
+	}
+	if parent := interop.ReplyParentURI(commit.Record); parent != "" {
+		did, _, _ := strings.Cut(strings.TrimPrefix(parent, "at://"), "/")
+		return !p.pollAuthors[did]
+	}
+	repo := commit.Repo
+	if repo == "" {
+		repo = msg.Did
+	}
+	return !p.pollAuthors[repo]
+}
+
+// processBlueskyPost indexes a poll post of an opted-in author as a survey, and
+// a reply voting on an indexed poll as a response. Both keep their post URI.
+// Returns false if the message is not a Bluesky post.
+func (p *Processor) processBlueskyPost(ctx context.Context, msg *JetstreamMessage) (bool, error) {
+	commit := msg.Commit
+	if commit.Collection != interop.PostCollection {
+		return false, nil
+	}
+	if p.skipsPost(msg) {
+		return true, nil
+	}
+
+	if commit.Operation == "delete" {
+		// A deleted post may be a poll of an opted-in author or a vote; both deletes are idempotent
+		if p.pollAuthors[commit.Repo] {
+			if err := p.deleteSurvey(ctx, commit); err != nil {
+				return true, err
+			}
+		}
+		return true, p.deleteResponse(ctx, commit)
+	}
+
+	if parent := interop.ReplyParentURI(commit.Record); parent != "" {
+		return true, p.processVoteReply(ctx, msg, parent)
+	}
+
+	record, err := interop.PostSurveyRecord(commit.Record)
+	if err != nil {
+		return true, nil // Most posts are not polls
+	}
+	uri := fmt.Sprintf("at://%s/%s/%s", commit.Repo, commit.Collection, commit.RKey)
+	def, _, _, err := ParseSurveyRecord(record)
+	if err == nil {
+		err = def.ValidateDefinition()
+	}
+	if err != nil {
+		log.Printf("Skipping unsupported Bluesky poll %s: %v", uri, err)
+		return true, nil
+	}
+
+	commit.published = commit.Record
+	commit.Record = record
+	return true, p.processSurveyCommit(ctx, msg)
+}
+
+// processVoteReply indexes a reply to pollURI as a vote, if it names an option
+// of an indexed poll. Other replies are skipped; the first vote of a voter counts.
+func (p *Processor) processVoteReply(ctx context.Context, msg *JetstreamMessage, pollURI string) error {
+	commit := msg.Commit
+	record, err := interop.ReplyResponseRecord(commit.Record)
+	if err != nil {
+		return nil // Not a vote
+	}
+
+	survey, err := p.queries.GetSurveyByURI(ctx, pollURI)
+	if err != nil {
+		return fmt.Errorf("failed to get survey by URI %s: %w", pollURI, err)
+	}
+	if survey == nil {
+		return nil // A reply to a post that is not a poll
+	}
+	_, answers, err := ParseResponseRecord(record)
+	if err != nil || models.ValidateAnswers(&survey.Definition, answers) != nil {
+		return nil // No such option
+	}
+
+	commit.published = commit.Record
+	commit.Record = record
+	return p.processResponseCommit(ctx, msg)
+}
@@ -0,0 +1,166 @@
+package consumer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/interop"
+)
+
+// postMessage builds a Jetstream message of a Bluesky post
+func postMessage(operation, repo, rkey string, record map[string]interface{}) *JetstreamMessage {
+	return &JetstreamMessage{
+		Did:  repo,
+		Kind: "commit",
+		Commit: &JetstreamCommit{
+			Operation:  operation,
+			Collection: interop.PostCollection,
+			RKey:       rkey,
+			CID:        "bafy" + rkey,
+			Record:     record,
+		},
+	}
+}
+
+// replyTo builds the record of a reply to a post
+func replyTo(uri, text string) map[string]interface{} {
+	ref := map[string]interface{}{"uri": uri, "cid": "bafyparent"}
+	return map[string]interface{}{
+		"text":  text,
+		"reply": map[string]interface{}{"root": ref, "parent": ref},
+	}
+}
+
+func TestSkipsPost(t *testing.T) {
+	p := NewProcessor(nil)
+	p.SetBlueskyPollAuthors([]string{"did:plc:pollster"})
+
+	tests := map[string]struct {
+		msg   *JetstreamMessage
+		skips bool
+	}{
+		"post of an opted-in author": {
+			msg: postMessage("create", "did:plc:pollster", "1", map[string]interface{}{"text": "Q\n1. A\n2. B"}),
+		},
+		"post of another author": {
+			msg:   postMessage("create", "did:plc:other", "1", map[string]interface{}{"text": "Q\n1. A\n2. B"}),
+			skips: true,
+		},
+		"reply to an opted-in author": {
+			msg: postMessage("create", "did:plc:voter", "2", replyTo("at://did:plc:pollster/app.bsky.feed.post/1", "2")),
+		},
+		"reply to another author": {
+			msg:   postMessage("create", "did:plc:voter", "2", replyTo("at://did:plc:other/app.bsky.feed.post/1", "2")),
+			skips: true,
+		},
+		"delete": {
+			msg: postMessage("delete", "did:plc:voter", "2", nil),
+		},
+		"other collection": {
+			msg: &JetstreamMessage{Kind: "commit", Commit: &JetstreamCommit{Operation: "create", Collection: "net.openmeet.survey"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := p.skipsPost(tt.msg); got != tt.skips {
+				t.Errorf("skipsPost() = %v, want %v", got, tt.skips)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		if !NewProcessor(nil).skipsPost(tests["post of an opted-in author"].msg) {
+			t.Error("posts are skipped without opted-in authors")
+		}
+	})
+}
+
+func TestProcessBlueskyPost_Skips(t *testing.T) {
+	// No database: every case must return before indexing
+	p := NewProcessor(nil)
+	p.SetBlueskyPollAuthors([]string{"did:plc:pollster"})
+	ctx := context.Background()
+
+	for name, msg := range map[string]*JetstreamMessage{
+		"not a poll":     postMessage("create", "did:plc:pollster", "1", map[string]interface{}{"text": "Good morning"}),
+		"too long":       postMessage("create", "did:plc:pollster", "1", map[string]interface{}{"text": "Q\n1. " + strings.Repeat("x", 600) + "\n2. B"}),
+		"reply, no vote": postMessage("create", "did:plc:voter", "2", replyTo("at://did:plc:pollster/app.bsky.feed.post/1", "Great question")),
+		"other author":   postMessage("create", "did:plc:other", "1", map[string]interface{}{"text": "Q\n1. A\n2. B"}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			msg.Commit.Repo = msg.Did
+			handled, err := p.processBlueskyPost(ctx, msg)
+			if err != nil {
+				t.Fatalf("processBlueskyPost failed: %v", err)
+			}
+			if !handled {
+				t.Error("posts are handled")
+			}
+		})
+	}
+}
+
+func TestProcessorOptionsCollections(t *testing.T) {
+	opts := ProcessorOptions{PollAuthors: []string{"did:plc:pollster"}}
+	want := append(WantedCollections(nil), interop.PostCollection)
+	if got := opts.Collections(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Collections() = %v, want %v", got, want)
+	}
+	if got := (ProcessorOptions{}).Collections(); !reflect.DeepEqual(got, WantedCollections(nil)) {
+		t.Errorf("Collections() = %v, want no posts", got)
+	}
+}
+
+func TestBlueskyPolls(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	pollster := "did:plc:pollster-" + uuid.NewString()[:8]
+	processor.SetBlueskyPollAuthors([]string{pollster})
+	ctx := context.Background()
+
+	process := func(msg *JetstreamMessage) {
+		t.Helper()
+		if err := processor.ProcessMessage(ctx, msg); err != nil {
+			t.Fatalf("ProcessMessage failed: %v", err)
+		}
+	}
+
+	process(postMessage("create", pollster, "poll", map[string]interface{}{"text": "Where should we meet?\n1. Online\n2. In person"}))
+	pollURI := "at://" + pollster + "/app.bsky.feed.post/poll"
+	survey, err := queries.GetSurveyByURI(ctx, pollURI)
+	if err != nil || survey == nil {
+		t.Fatalf("poll was not indexed: %v", err)
+	}
+	if survey.Title != "Where should we meet?" || !survey.IsForeign() {
+		t.Errorf("indexed poll = %q (foreign %v)", survey.Title, survey.IsForeign())
+	}
+
+	process(postMessage("create", "did:plc:voter1", "v1", replyTo(pollURI, "2")))
+	process(postMessage("create", "did:plc:voter1", "v2", replyTo(pollURI, "1, no wait")))
+	process(postMessage("create", "did:plc:voter2", "v3", replyTo(pollURI, "#1")))
+	process(postMessage("create", "did:plc:voter3", "v4", replyTo(pollURI, "7")))
+
+	count, err := queries.CountResponsesBySurvey(ctx, survey.ID)
+	if err != nil {
+		t.Fatalf("CountResponsesBySurvey failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("votes = %d, want 2 (first vote per voter, no unknown options)", count)
+	}
+
+	process(postMessage("delete", "did:plc:voter2", "v3", nil))
+	if count, _ := queries.CountResponsesBySurvey(ctx, survey.ID); count != 1 {
+		t.Errorf("votes after deleting a reply = %d, want 1", count)
+	}
+
+	process(postMessage("delete", pollster, "poll", nil))
+	if survey, err := queries.GetSurveyByURI(ctx, pollURI); err != nil || survey != nil {
+		t.Errorf("deleted poll is still indexed: %v", err)
+	}
+}
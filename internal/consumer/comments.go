@@ -0,0 +1,133 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// CommentCollection is the collection of comments on surveys
+const CommentCollection = "net.openmeet.survey.comment"
+
+// processCommentCommit handles create/update/delete operations for survey comments
+func (p *Processor) processCommentCommit(ctx context.Context, msg *JetstreamMessage) error {
+	commit := msg.Commit
+
+	switch commit.Operation {
+	case "create":
+		return p.createComment(ctx, commit)
+	case "update":
+		return p.updateComment(ctx, commit)
+	case "delete":
+		return p.deleteComment(ctx, commit)
+	default:
+		return nil // Skip unknown operations
+	}
+}
+
+// createComment indexes a new comment on a survey. A record already indexed
+// is updated instead, and the same comment by the same author on a survey is
+// indexed once.
+func (p *Processor) createComment(ctx context.Context, commit *JetstreamCommit) error {
+	if commit.Record == nil {
+		return fmt.Errorf("create operation missing record")
+	}
+	recordURI := commit.recordURI()
+
+	existing, err := p.queries.GetCommentByRecordURI(ctx, recordURI)
+	if err != nil {
+		return fmt.Errorf("failed to get comment by URI: %w", err)
+	}
+	if existing != nil {
+		return p.updateComment(ctx, commit)
+	}
+
+	surveyURI, text, createdAt, err := ParseCommentRecord(commit.Record, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to parse comment record: %w", err)
+	}
+
+	survey, err := p.subjectSurvey(ctx, surveyURI, recordURI)
+	if err != nil || survey == nil {
+		return err // Skipped if the survey was deleted
+	}
+
+	created, err := p.queries.CreateComment(ctx, &models.Comment{
+		ID:        uuid.New(),
+		SurveyID:  survey.ID,
+		AuthorDID: commit.Repo,
+		RecordURI: recordURI,
+		RecordCID: commit.CID,
+		Text:      text,
+		CreatedAt: createdAt,
+	})
+	if err != nil {
+		return err
+	}
+	if !created {
+		log.Printf("Skipping comment %s: %s already made the same comment on survey %s", recordURI, commit.Repo, surveyURI)
+	}
+
+	return nil
+}
+
+// updateComment updates the text of an indexed comment
+func (p *Processor) updateComment(ctx context.Context, commit *JetstreamCommit) error {
+	if commit.Record == nil {
+		return fmt.Errorf("update operation missing record")
+	}
+	recordURI := commit.recordURI()
+
+	comment, err := p.queries.GetCommentByRecordURI(ctx, recordURI)
+	if err != nil {
+		return fmt.Errorf("failed to get comment by URI: %w", err)
+	}
+	if comment == nil {
+		// Comment doesn't exist in our index - treat as create
+		return p.createComment(ctx, commit)
+	}
+
+	// Authorization check: verify the update comes from the comment's author
+	if comment.AuthorDID != commit.Repo {
+		return fmt.Errorf("unauthorized: DID %s cannot update comment owned by %s", commit.Repo, comment.AuthorDID)
+	}
+
+	surveyURI, text, _, err := ParseCommentRecord(commit.Record, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to parse comment record: %w", err)
+	}
+	survey, err := p.subjectSurvey(ctx, surveyURI, recordURI)
+	if err != nil || survey == nil {
+		return err // Skipped if the survey was deleted
+	}
+	if survey.ID != comment.SurveyID {
+		return fmt.Errorf("comment record cannot change survey reference")
+	}
+
+	return p.queries.UpdateComment(ctx, comment.ID, text, commit.CID)
+}
+
+// deleteComment removes a comment from the index
+func (p *Processor) deleteComment(ctx context.Context, commit *JetstreamCommit) error {
+	recordURI := commit.recordURI()
+
+	comment, err := p.queries.GetCommentByRecordURI(ctx, recordURI)
+	if err != nil {
+		return fmt.Errorf("failed to get comment by URI: %w", err)
+	}
+	if comment == nil {
+		// Comment doesn't exist - nothing to delete (idempotent)
+		return nil
+	}
+
+	// Authorization check: verify the delete comes from the comment's author
+	if comment.AuthorDID != commit.Repo {
+		return fmt.Errorf("unauthorized: DID %s cannot delete comment owned by %s", commit.Repo, comment.AuthorDID)
+	}
+
+	return p.queries.DeleteCommentByRecordURI(ctx, recordURI)
+}
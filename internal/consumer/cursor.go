@@ -3,6 +3,7 @@ package consumer
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/openmeet-team/survey/internal/db"
@@ -15,7 +16,7 @@ func GetCursor(ctx context.Context, q *db.Queries) (int64, error) {
 	var timeUs int64
 	err := q.GetDB().QueryRowContext(ctx, query).Scan(&timeUs)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return 0, fmt.Errorf("cursor row not found (id=1 should exist)")
 		}
 		return 0, fmt.Errorf("failed to get cursor: %w", err)
@@ -44,3 +45,89 @@ func UpdateCursor(ctx context.Context, q *db.Queries, timeUs int64) error {
 
 	return nil
 }
+
+// AdvanceCursor moves the Jetstream cursor forward to the given value, leaving
+// it unchanged if it is already past it. Workers commit out of order, so a
+// cursor computed earlier may be saved after a later one.
+func AdvanceCursor(ctx context.Context, q *db.Queries, timeUs int64) error {
+	query := `UPDATE jetstream_cursor SET time_us = GREATEST(time_us, $1), updated_at = NOW() WHERE id = 1`
+
+	result, err := q.GetDB().ExecContext(ctx, query, timeUs)
+	if err != nil {
+		return fmt.Errorf("failed to update cursor: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("cursor row not found (expected id=1)")
+	}
+
+	return nil
+}
+
+// GetFirehoseCursor retrieves the sequence number of the last indexed firehose event
+func GetFirehoseCursor(ctx context.Context, q *db.Queries) (int64, error) {
+	query := `SELECT seq FROM firehose_cursor WHERE id = 1`
+
+	var seq int64
+	err := q.GetDB().QueryRowContext(ctx, query).Scan(&seq)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("firehose cursor row not found (id=1 should exist)")
+		}
+		return 0, fmt.Errorf("failed to get firehose cursor: %w", err)
+	}
+
+	return seq, nil
+}
+
+// UpdateFirehoseCursor updates the firehose cursor to the given sequence number
+// and the time of its event (microseconds since epoch, 0 if unknown)
+func UpdateFirehoseCursor(ctx context.Context, q *db.Queries, seq, timeUs int64) error {
+	query := `
+		UPDATE firehose_cursor
+		SET seq = $1, time_us = GREATEST(time_us, $2), updated_at = NOW()
+		WHERE id = 1
+	`
+
+	result, err := q.GetDB().ExecContext(ctx, query, seq, timeUs)
+	if err != nil {
+		return fmt.Errorf("failed to update firehose cursor: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("firehose cursor row not found (expected id=1)")
+	}
+
+	return nil
+}
+
+// GetEventTime returns the time of the newest event indexed from either
+// Jetstream or the firehose (microseconds since epoch), for consumer lag
+func GetEventTime(ctx context.Context, q *db.Queries) (int64, error) {
+	query := `
+		SELECT GREATEST(
+			(SELECT time_us FROM jetstream_cursor WHERE id = 1),
+			(SELECT time_us FROM firehose_cursor WHERE id = 1)
+		)
+	`
+
+	var timeUs sql.NullInt64
+	if err := q.GetDB().QueryRowContext(ctx, query).Scan(&timeUs); err != nil {
+		return 0, fmt.Errorf("failed to get consumer event time: %w", err)
+	}
+	if !timeUs.Valid {
+		return 0, fmt.Errorf("cursor row not found (id=1 should exist)")
+	}
+
+	return timeUs.Int64, nil
+}
@@ -142,3 +142,42 @@ func TestGetCursorWithTransaction(t *testing.T) {
 		}
 	})
 }
+
+func TestFirehoseCursor(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	ctx := context.Background()
+	if _, err := database.Exec("UPDATE firehose_cursor SET seq = 0, time_us = 0 WHERE id = 1"); err != nil {
+		t.Skipf("Skipping test - firehose cursor table not initialized: %v", err)
+	}
+
+	if err := UpdateFirehoseCursor(ctx, queries, 987654321, 1772452800000000); err != nil {
+		t.Fatalf("UpdateFirehoseCursor failed: %v", err)
+	}
+	seq, err := GetFirehoseCursor(ctx, queries)
+	if err != nil {
+		t.Fatalf("GetFirehoseCursor failed: %v", err)
+	}
+	if seq != 987654321 {
+		t.Errorf("Expected seq to be 987654321, got %d", seq)
+	}
+
+	// The Jetstream cursor is separate
+	cursor, err := GetCursor(ctx, queries)
+	if err != nil {
+		t.Fatalf("GetCursor failed: %v", err)
+	}
+	if cursor != 0 {
+		t.Errorf("Expected the Jetstream cursor to be unchanged, got %d", cursor)
+	}
+
+	// Lag is reported from the newest event of either source
+	eventTime, err := GetEventTime(ctx, queries)
+	if err != nil {
+		t.Fatalf("GetEventTime failed: %v", err)
+	}
+	if eventTime != 1772452800000000 {
+		t.Errorf("Expected the firehose event time, got %d", eventTime)
+	}
+}
@@ -0,0 +1,333 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/openmeet-team/survey/internal/db"
+	"github.com/openmeet-team/survey/internal/firehose"
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+// Source is the event stream the consumer indexes
+type Source string
+
+const (
+	// SourceJetstream reads JSON events from a Jetstream instance
+	SourceJetstream Source = "jetstream"
+	// SourceFirehose reads the CBOR repository firehose of a relay
+	SourceFirehose Source = "firehose"
+)
+
+// DefaultFirehoseURL is the relay firehose read in firehose mode
+const DefaultFirehoseURL = "wss://bsky.network"
+
+// firehoseCursorInterval is how often the cursor is saved while the firehose
+// carries no records of wanted collections
+const firehoseCursorInterval = 5 * time.Second
+
+// SourceFromEnv returns the event stream the consumer indexes
+// Environment variables:
+//   - CONSUMER_SOURCE: "jetstream" or "firehose" (default: jetstream)
+func SourceFromEnv() Source {
+	switch source := Source(os.Getenv("CONSUMER_SOURCE")); source {
+	case SourceJetstream, SourceFirehose:
+		return source
+	case "":
+		return SourceJetstream
+	default:
+		log.Printf("Warning: Unknown CONSUMER_SOURCE %q, using %s", source, SourceJetstream)
+		return SourceJetstream
+	}
+}
+
+// FirehoseClient indexes records from a relay's com.atproto.sync.subscribeRepos
+// stream. Unlike Jetstream, the firehose carries every repository commit on the
+// network, so commits are filtered client-side, their signatures are checked
+// against the repository's key, and records are decoded from the commit's CAR
+// blocks before being handed to the Processor as Jetstream messages.
+type FirehoseClient struct {
+	url         string // Relay base URL, e.g. wss://bsky.network
+	queries     *db.Queries
+	processor   *Processor
+	keys        *signingKeys
+	collections map[string]bool
+	conn        *websocket.Conn
+	seq         int64     // Last event read
+	seqTimeUs   int64     // Time of the last commit read, for lag reporting
+	savedSeq    int64     // Last sequence number persisted
+	savedAt     time.Time // When the cursor was last persisted
+	retriedAt   time.Time // When failed messages were last retried
+}
+
+// NewFirehoseClient creates a client indexing the given collections
+func NewFirehoseClient(url string, queries *db.Queries, collections []string) *FirehoseClient {
+	wanted := make(map[string]bool, len(collections))
+	for _, c := range collections {
+		wanted[c] = true
+	}
+	return &FirehoseClient{
+		url:         strings.TrimSuffix(url, "/"),
+		queries:     queries,
+		processor:   NewProcessor(queries),
+		keys:        newSigningKeys(),
+		collections: wanted,
+	}
+}
+
+// Connect establishes the WebSocket connection with cursor resumption
+func (c *FirehoseClient) Connect(ctx context.Context) error {
+	cursor, err := GetFirehoseCursor(ctx, c.queries)
+	if err != nil {
+		return fmt.Errorf("failed to get cursor: %w", err)
+	}
+	c.seq, c.savedSeq, c.savedAt = cursor, cursor, time.Now()
+
+	url, err := withCursor(c.url+"/xrpc/com.atproto.sync.subscribeRepos", cursor)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Connecting to firehose: %s", url)
+
+	header := http.Header{}
+	header.Set("User-Agent", "survey-consumer/1.0")
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, header)
+	if err != nil {
+		return fmt.Errorf("failed to dial websocket: %w", err)
+	}
+
+	c.conn = conn
+	keepAlive(conn)
+	telemetry.JetstreamConnectionStatus.Set(1)
+	log.Printf("Connected to firehose (resuming from seq: %d)", cursor)
+
+	return nil
+}
+
+// Run reads and indexes events until ctx is cancelled or the stream fails.
+// Events are processed in order; there is no priority queue because the
+// relay's sequence numbers only allow resuming after a fully processed prefix.
+// Records that fail to index are queued for a retry, which Run attempts every
+// RetryInterval between events.
+func (c *FirehoseClient) Run(ctx context.Context) error {
+	// Unblock the read when shutting down
+	stop := context.AfterFunc(ctx, func() { c.conn.Close() })
+	defer stop()
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				log.Println("Shutting down firehose client...")
+				return nil
+			}
+			return readError(err)
+		}
+		extendReadDeadline(c.conn)
+
+		frame, err := firehose.DecodeFrame(data)
+		if err != nil {
+			log.Printf("ERROR: Failed to decode firehose frame: %v", err)
+			continue
+		}
+
+		switch {
+		case frame.Err != nil:
+			return frame.Err
+		case frame.Commit != nil:
+			if err := c.handleCommit(ctx, frame.Commit); err != nil {
+				return err
+			}
+		case frame.Info != "":
+			log.Printf("Firehose info: %s", frame.Info)
+		default:
+			c.advance(ctx, frame.Seq)
+		}
+
+		if time.Since(c.retriedAt) >= RetryInterval {
+			if err := c.processor.RetryDeferred(ctx); err != nil {
+				return fmt.Errorf("failed to retry messages: %w", err)
+			}
+			c.retriedAt = time.Now()
+		}
+	}
+}
+
+// handleCommit indexes the records of wanted collections in a commit.
+// Records that fail to index are queued for a retry in the transaction that
+// moves the cursor past them; if they cannot be queued, handleCommit returns
+// an error without moving the cursor, so the commit is read again.
+func (c *FirehoseClient) handleCommit(ctx context.Context, commit *firehose.Commit) error {
+	observeLag(commit.Time.UnixMicro())
+
+	msgs, skipped := commitMessages(commit, c.collections)
+	msgs = slices.DeleteFunc(msgs, c.processor.skipsPost)
+	if len(msgs) > 0 {
+		if err := c.keys.verify(ctx, commit); err != nil {
+			if errors.Is(err, errKeyUnavailable) || ctx.Err() != nil {
+				return err
+			}
+			// A commit that is not signed by its repository is not the author's
+			log.Printf("ERROR: Skipping firehose commit %d of %s: %v", commit.Seq, commit.Repo, err)
+			for _, msg := range msgs {
+				telemetry.JetstreamRecordsProcessed.WithLabelValues(msg.Commit.Collection, msg.Commit.Operation, "error").Inc()
+			}
+			msgs = nil
+		}
+	}
+	for _, s := range skipped {
+		log.Printf("ERROR: Skipping firehose record at://%s/%s: %v", commit.Repo, s.op.Path, s.err)
+		telemetry.JetstreamRecordsProcessed.WithLabelValues(s.op.Collection(), s.op.Action, "error").Inc()
+	}
+	if len(msgs) == 0 {
+		c.seqTimeUs = commit.Time.UnixMicro()
+		c.advance(ctx, commit.Seq)
+		return nil
+	}
+
+	for i, msg := range msgs {
+		// Resume before this commit until its last record is indexed
+		seq := commit.Seq - 1
+		if i == len(msgs)-1 {
+			seq = commit.Seq
+		}
+
+		startTime := time.Now()
+		if err := c.processor.ProcessMessageAtSeq(ctx, msg, seq); err != nil {
+			if isRejected(err) {
+				// Retrying would reject the record again
+				log.Printf("ERROR: Skipping rejected message: %v", err)
+				telemetry.JetstreamRecordsProcessed.WithLabelValues(msg.Commit.Collection, msg.Commit.Operation, "rejected").Inc()
+				if err := c.processor.SkipMessageAtSeq(ctx, msg, seq); err != nil {
+					return fmt.Errorf("failed to skip rejected message: %w", err)
+				}
+				continue
+			}
+			log.Printf("ERROR: Failed to process message, queueing it for a retry: %v", err)
+			telemetry.JetstreamRecordsProcessed.WithLabelValues(msg.Commit.Collection, msg.Commit.Operation, "error").Inc()
+			if err := c.processor.DeferMessageAtSeq(ctx, msg, err, seq); err != nil {
+				return fmt.Errorf("failed to queue message for retry: %w", err)
+			}
+			continue
+		}
+		telemetry.JetstreamRecordsProcessed.WithLabelValues(msg.Commit.Collection, msg.Commit.Operation, "success").Inc()
+		telemetry.JetstreamProcessingDuration.WithLabelValues(msg.Commit.Collection, msg.Commit.Operation).Observe(time.Since(startTime).Seconds())
+	}
+
+	c.seq, c.seqTimeUs, c.savedSeq, c.savedAt = commit.Seq, commit.Time.UnixMicro(), commit.Seq, time.Now()
+	return nil
+}
+
+// advance records an event without wanted records, saving the cursor at most
+// every firehoseCursorInterval
+func (c *FirehoseClient) advance(ctx context.Context, seq int64) {
+	if seq <= c.seq {
+		return
+	}
+	c.seq = seq
+	if time.Since(c.savedAt) >= firehoseCursorInterval {
+		c.saveCursor(ctx)
+	}
+}
+
+// saveCursor persists the sequence number of the last event read
+func (c *FirehoseClient) saveCursor(ctx context.Context) {
+	if c.seq == c.savedSeq {
+		return
+	}
+	if err := c.processor.SaveFirehoseCursor(ctx, c.seq, c.seqTimeUs); err != nil {
+		log.Printf("ERROR: Failed to save firehose cursor: %v", err)
+		return
+	}
+	c.savedSeq, c.savedAt = c.seq, time.Now()
+}
+
+// Close saves the cursor and closes the WebSocket connection
+func (c *FirehoseClient) Close() error {
+	telemetry.JetstreamConnectionStatus.Set(0)
+	if c.conn == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.saveCursor(ctx)
+
+	// The connection may already be closed by a shutdown
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return c.conn.Close()
+}
+
+// skippedOp is an operation whose record could not be read from its commit
+type skippedOp struct {
+	op  firehose.Op
+	err error
+}
+
+// commitMessages converts the operations of a commit on wanted collections into
+// Jetstream messages for the Processor. Operations whose record cannot be
+// decoded are returned as skipped.
+func commitMessages(commit *firehose.Commit, wanted map[string]bool) ([]*JetstreamMessage, []skippedOp) {
+	var msgs []*JetstreamMessage
+	var skipped []skippedOp
+
+	for _, op := range commit.Ops {
+		if !wanted[op.Collection()] {
+			continue
+		}
+		if op.Action != "create" && op.Action != "update" && op.Action != "delete" {
+			continue
+		}
+
+		msg := &JetstreamMessage{
+			Did:    commit.Repo,
+			TimeUs: commit.Time.UnixMicro(),
+			Kind:   "commit",
+			Commit: &JetstreamCommit{
+				Rev:        commit.Rev,
+				Operation:  op.Action,
+				Collection: op.Collection(),
+				RKey:       op.RKey(),
+				Repo:       commit.Repo,
+			},
+		}
+
+		if op.Action != "delete" {
+			if commit.TooBig {
+				skipped = append(skipped, skippedOp{op, fmt.Errorf("commit %d is too big to carry its records", commit.Seq)})
+				continue
+			}
+			record, cid, err := commit.Record(op)
+			if err != nil {
+				skipped = append(skipped, skippedOp{op, err})
+				continue
+			}
+			msg.Commit.Record = record
+			msg.Commit.CID = cid.String()
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, skipped
+}
+
+// RunFirehoseWithReconnect indexes the relay firehose at url like
+// RunWithReconnect indexes Jetstream
+func RunFirehoseWithReconnect(ctx context.Context, url string, queries *db.Queries, opts ProcessorOptions) error {
+	collections := opts.Collections()
+	return runWithReconnect(ctx, func() streamClient {
+		client := NewFirehoseClient(url, queries, collections)
+		opts.configure(client.processor)
+		return client
+	})
+}
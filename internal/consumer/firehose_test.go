@@ -0,0 +1,68 @@
+package consumer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/firehose"
+)
+
+func TestCommitMessages(t *testing.T) {
+	commit := &firehose.Commit{
+		Seq:  10,
+		Repo: "did:plc:voter",
+		Rev:  "3l2",
+		Time: time.UnixMicro(1772452800000000),
+		Ops: []firehose.Op{
+			{Action: "delete", Path: "app.bsky.feed.post/1"},
+			{Action: "delete", Path: "net.openmeet.survey.response/r1"},
+			{Action: "create", Path: "net.openmeet.survey.response/r2"},
+		},
+	}
+	wanted := map[string]bool{"net.openmeet.survey.response": true}
+
+	msgs, skipped := commitMessages(commit, wanted)
+
+	if len(msgs) != 1 {
+		t.Fatalf("Expected only the wanted delete to become a message, got %d", len(msgs))
+	}
+	msg := msgs[0]
+	if msg.Kind != "commit" || msg.Did != "did:plc:voter" || msg.TimeUs != 1772452800000000 {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+	if c := msg.Commit; c.Operation != "delete" || c.Collection != "net.openmeet.survey.response" || c.RKey != "r1" || c.Repo != "did:plc:voter" || c.Rev != "3l2" {
+		t.Errorf("Unexpected commit: %+v", c)
+	}
+
+	// The create carries no blocks, so its record cannot be read
+	if len(skipped) != 1 || skipped[0].op.Path != "net.openmeet.survey.response/r2" {
+		t.Errorf("Expected the create to be skipped, got: %+v", skipped)
+	}
+
+	commit.TooBig = true
+	_, skipped = commitMessages(commit, wanted)
+	if len(skipped) != 1 {
+		t.Errorf("Expected records of too-big commits to be skipped, got: %+v", skipped)
+	}
+}
+
+func TestSourceFromEnv(t *testing.T) {
+	tests := []struct {
+		env  string
+		want Source
+	}{
+		{"", SourceJetstream},
+		{"jetstream", SourceJetstream},
+		{"firehose", SourceFirehose},
+		{"relay", SourceJetstream},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("CONSUMER_SOURCE", tt.env)
+			if got := SourceFromEnv(); got != tt.want {
+				t.Errorf("SourceFromEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
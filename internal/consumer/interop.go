@@ -0,0 +1,76 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/openmeet-team/survey/internal/interop"
+)
+
+// SetPollLexicons sets the foreign poll lexicons indexed as read-only surveys
+func (p *Processor) SetPollLexicons(lexicons []interop.Lexicon) {
+	p.foreignPolls = make(map[string]bool)
+	p.foreignVotes = make(map[string]bool)
+	for _, l := range lexicons {
+		p.foreignPolls[l.Poll] = true
+		if l.Vote != "" {
+			p.foreignVotes[l.Vote] = true
+		}
+	}
+}
+
+// WantedCollections returns the collections to subscribe to: the survey
+// lexicon plus the configured foreign poll lexicons
+func WantedCollections(lexicons []interop.Lexicon) []string {
+	collections := []string{
+		"net.openmeet.survey",
+		"net.openmeet.survey.response",
+		"net.openmeet.survey.results",
+		CommentCollection,
+	}
+	for _, l := range lexicons {
+		collections = append(collections, l.Poll)
+		if l.Vote != "" {
+			collections = append(collections, l.Vote)
+		}
+	}
+	return collections
+}
+
+// processForeignCommit indexes a foreign poll or vote by converting its record
+// into the survey lexicon. The commit keeps its collection, so the indexed
+// survey or response is identified by the foreign record's URI.
+// Returns false if the collection is not a configured foreign lexicon.
+func (p *Processor) processForeignCommit(ctx context.Context, msg *JetstreamMessage) (bool, error) {
+	commit := msg.Commit
+	isPoll, isVote := p.foreignPolls[commit.Collection], p.foreignVotes[commit.Collection]
+	if !isPoll && !isVote {
+		return false, nil
+	}
+
+	if commit.Record != nil {
+		convert := interop.ResponseRecord
+		if isPoll {
+			convert = interop.SurveyRecord
+		}
+
+		record, err := convert(commit.Record)
+		if errors.Is(err, interop.ErrCrossPublished) {
+			return true, nil // Already indexed as the survey it was published from
+		}
+		if err != nil {
+			uri := fmt.Sprintf("at://%s/%s/%s", commit.Repo, commit.Collection, commit.RKey)
+			log.Printf("Skipping unsupported foreign record %s: %v", uri, err)
+			return true, nil
+		}
+		commit.published = commit.Record
+		commit.Record = record
+	}
+
+	if isPoll {
+		return true, p.processSurveyCommit(ctx, msg)
+	}
+	return true, p.processResponseCommit(ctx, msg)
+}
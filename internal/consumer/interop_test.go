@@ -0,0 +1,154 @@
+package consumer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/openmeet-team/survey/internal/interop"
+)
+
+func TestWantedCollections(t *testing.T) {
+	got := WantedCollections([]interop.Lexicon{
+		{Poll: "com.example.poll", Vote: "com.example.poll.vote"},
+		{Poll: "org.other.poll"},
+	})
+	want := []string{
+		"net.openmeet.survey",
+		"net.openmeet.survey.response",
+		"net.openmeet.survey.results",
+		"net.openmeet.survey.comment",
+		"com.example.poll",
+		"com.example.poll.vote",
+		"org.other.poll",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WantedCollections() = %v, want %v", got, want)
+	}
+}
+
+func TestProcessForeignCommit_Skips(t *testing.T) {
+	// No database: every case must return before indexing
+	p := NewProcessor(nil)
+	p.SetPollLexicons([]interop.Lexicon{{Poll: "com.example.poll", Vote: "com.example.poll.vote"}})
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		collection string
+		record     map[string]interface{}
+		handled    bool
+	}{
+		"unconfigured collection": {
+			collection: "app.bsky.feed.post",
+			record:     map[string]interface{}{"text": "hello"},
+		},
+		"unsupported poll": {
+			collection: "com.example.poll",
+			record:     map[string]interface{}{"question": "No options"},
+			handled:    true,
+		},
+		"unsupported vote": {
+			collection: "com.example.poll.vote",
+			record:     map[string]interface{}{"option": 0.0},
+			handled:    true,
+		},
+		"cross-published poll": {
+			collection: "com.example.poll",
+			record: map[string]interface{}{
+				"question": "Q",
+				"options":  []interface{}{"A", "B"},
+				"source":   map[string]interface{}{"uri": "at://did:plc:test123/net.openmeet.survey/abc123"},
+			},
+			handled: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			msg := &JetstreamMessage{
+				Kind: "commit",
+				Commit: &JetstreamCommit{
+					Operation:  "create",
+					Repo:       "did:plc:test123",
+					Collection: tt.collection,
+					RKey:       "abc123",
+					Record:     tt.record,
+				},
+			}
+			handled, err := p.processForeignCommit(ctx, msg)
+			if err != nil {
+				t.Fatalf("processForeignCommit failed: %v", err)
+			}
+			if handled != tt.handled {
+				t.Errorf("handled = %v, want %v", handled, tt.handled)
+			}
+		})
+	}
+}
+
+func TestProcessForeignPollAndVote(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	processor.SetPollLexicons([]interop.Lexicon{{Poll: "com.example.poll", Vote: "com.example.poll.vote"}})
+	ctx := context.Background()
+
+	pollURI := "at://did:plc:pollster/com.example.poll/poll123"
+	defer queries.DeleteSurveyByURI(ctx, pollURI)
+
+	err := processor.ProcessMessage(ctx, &JetstreamMessage{
+		Kind: "commit",
+		Commit: &JetstreamCommit{
+			Operation:  "create",
+			Repo:       "did:plc:pollster",
+			Collection: "com.example.poll",
+			RKey:       "poll123",
+			CID:        "bafypoll",
+			Record: map[string]interface{}{
+				"question": "Foreign poll question",
+				"options":  []interface{}{"Yes", "No"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to index foreign poll: %v", err)
+	}
+
+	survey, err := queries.GetSurveyByURI(ctx, pollURI)
+	if err != nil || survey == nil {
+		t.Fatalf("foreign poll not indexed: %v", err)
+	}
+	if !survey.IsForeign() {
+		t.Error("expected indexed poll to be foreign")
+	}
+	if survey.Title != "Foreign poll question" {
+		t.Errorf("expected title from poll question, got %s", survey.Title)
+	}
+
+	err = processor.ProcessMessage(ctx, &JetstreamMessage{
+		Kind: "commit",
+		Commit: &JetstreamCommit{
+			Operation:  "create",
+			Repo:       "did:plc:voter123",
+			Collection: "com.example.poll.vote",
+			RKey:       "vote123",
+			CID:        "bafyvote",
+			Record: map[string]interface{}{
+				"subject": map[string]interface{}{"uri": pollURI, "cid": "bafypoll"},
+				"option":  1.0,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to index foreign vote: %v", err)
+	}
+
+	response, err := queries.GetResponseByRecordURI(ctx, "at://did:plc:voter123/com.example.poll.vote/vote123")
+	if err != nil || response == nil {
+		t.Fatalf("foreign vote not indexed: %v", err)
+	}
+	if got := response.Answers["q1"].SelectedOptions; !reflect.DeepEqual(got, []string{"opt-1"}) {
+		t.Errorf("expected selected option opt-1, got %v", got)
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -13,11 +17,30 @@ import (
 	"github.com/openmeet-team/survey/internal/telemetry"
 )
 
+// DefaultWorkers is the number of Jetstream messages processed concurrently
+const DefaultWorkers = 4
+
+// WorkersFromEnv returns the number of Jetstream messages processed concurrently
+// Environment variables:
+//   - CONSUMER_WORKERS: number of workers (default: 4)
+func WorkersFromEnv() int {
+	if v := os.Getenv("CONSUMER_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("Warning: Invalid CONSUMER_WORKERS %q, using default %d", v, DefaultWorkers)
+	}
+	return DefaultWorkers
+}
+
 // JetstreamClient manages the WebSocket connection to Jetstream
 type JetstreamClient struct {
 	url       string
 	queries   *db.Queries
 	processor *Processor
+	queue     *PriorityQueue
+	known     *knownSurveys
+	workers   int
 	conn      *websocket.Conn
 	done      chan struct{}
 }
@@ -28,10 +51,19 @@ func NewJetstreamClient(url string, queries *db.Queries) *JetstreamClient {
 		url:       url,
 		queries:   queries,
 		processor: NewProcessor(queries),
+		queue:     NewPriorityQueue(DefaultQueueSize),
+		known:     newKnownSurveys(queries),
+		workers:   DefaultWorkers,
 		done:      make(chan struct{}),
 	}
 }
 
+// queuedWork is a popped message handed to a worker
+type queuedWork struct {
+	msg      *JetstreamMessage
+	priority Priority
+}
+
 // Connect establishes the WebSocket connection with cursor resumption
 func (c *JetstreamClient) Connect(ctx context.Context) error {
 	// Get current cursor
@@ -40,10 +72,10 @@ func (c *JetstreamClient) Connect(ctx context.Context) error {
 		return fmt.Errorf("failed to get cursor: %w", err)
 	}
 
-	// Build URL with cursor if > 0
-	url := c.url
-	if cursor > 0 {
-		url = fmt.Sprintf("%s&cursor=%d", c.url, cursor)
+	// Resume from the cursor, if any
+	url, err := withCursor(c.url, cursor)
+	if err != nil {
+		return err
 	}
 
 	log.Printf("Connecting to Jetstream: %s", url)
@@ -57,67 +89,198 @@ func (c *JetstreamClient) Connect(ctx context.Context) error {
 	}
 
 	c.conn = conn
+	keepAlive(conn)
 	telemetry.JetstreamConnectionStatus.Set(1)
 	log.Printf("Connected to Jetstream (resuming from cursor: %d)", cursor)
 
 	return nil
 }
 
-// Run starts the message processing loop
+// Run starts the message processing loop.
+// A reader goroutine classifies incoming messages into the priority queue while
+// workers drain it, so records for locally known surveys are indexed ahead of
+// unrelated network records when there is a backlog. Messages of a repository
+// always go to the same worker, so they are indexed in order.
 func (c *JetstreamClient) Run(ctx context.Context) error {
 	defer close(c.done)
 
+	parent := ctx
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	go func() {
+		cancel(c.readLoop(ctx))
+	}()
+
+	var wg sync.WaitGroup
+	workers := make([]chan queuedWork, c.workers)
+	for i := range workers {
+		workers[i] = make(chan queuedWork)
+		wg.Add(1)
+		go func(work <-chan queuedWork) {
+			defer wg.Done()
+			for w := range work {
+				if err := c.handleMessage(ctx, w.msg, w.priority); err != nil {
+					cancel(err)
+				}
+			}
+		}(workers[i])
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.retryLoop(ctx, cancel)
+	}()
+
 	for {
+		msg, priority, err := c.queue.Pop(ctx)
+		if err != nil {
+			break
+		}
 		select {
+		case workers[workerFor(msg, len(workers))] <- queuedWork{msg: msg, priority: priority}:
 		case <-ctx.Done():
-			log.Println("Shutting down Jetstream client...")
-			return nil
-		default:
-			// Read message from WebSocket
-			_, message, err := c.conn.ReadMessage()
-			if err != nil {
-				return fmt.Errorf("error reading message: %w", err)
-			}
+		}
+	}
+	for _, work := range workers {
+		close(work)
+	}
+	wg.Wait()
 
-			// Parse the message
-			var msg JetstreamMessage
-			if err := json.Unmarshal(message, &msg); err != nil {
-				log.Printf("ERROR: Failed to unmarshal message: %v", err)
-				continue
-			}
+	// Either we're shutting down or the reader or a worker failed
+	if parent.Err() == nil {
+		return context.Cause(ctx)
+	}
+	log.Println("Shutting down Jetstream client...")
+	return nil
+}
 
-			// Process the message with cursor update and metrics
-			collection := ""
-			operation := ""
-			if msg.Commit != nil {
-				collection = msg.Commit.Collection
-				operation = msg.Commit.Operation
-			}
+// workerFor returns the worker that processes a message, by its repository
+func workerFor(msg *JetstreamMessage, workers int) int {
+	repo := msg.Did
+	if msg.Commit != nil && msg.Commit.Repo != "" {
+		repo = msg.Commit.Repo
+	}
+	h := fnv.New32a()
+	h.Write([]byte(repo))
+	return int(h.Sum32() % uint32(workers))
+}
 
-			startTime := time.Now()
-			if err := c.processor.ProcessMessageWithCursor(ctx, &msg, c.queries.GetDB); err != nil {
-				log.Printf("ERROR: Failed to process message: %v", err)
-				telemetry.JetstreamRecordsProcessed.WithLabelValues(collection, operation, "error").Inc()
-				continue
-			}
+// retryLoop retries the failed messages that are due every RetryInterval
+func (c *JetstreamClient) retryLoop(ctx context.Context, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(RetryInterval)
+	defer ticker.Stop()
 
-			// Record success metrics
-			if collection != "" {
-				telemetry.JetstreamRecordsProcessed.WithLabelValues(collection, operation, "success").Inc()
-				telemetry.JetstreamProcessingDuration.WithLabelValues(collection, operation).Observe(time.Since(startTime).Seconds())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.processor.RetryDeferred(ctx); err != nil {
+				cancel(fmt.Errorf("failed to retry messages: %w", err))
+				return
 			}
+		}
+	}
+}
 
-			// Update cursor lag (time_us is microseconds since epoch)
-			if msg.TimeUs > 0 {
-				eventTime := time.UnixMicro(msg.TimeUs)
-				lagSeconds := time.Since(eventTime).Seconds()
-				if lagSeconds < 0 {
-					lagSeconds = 0 // Future events shouldn't happen but handle gracefully
-				}
-				telemetry.JetstreamCursorLag.Set(lagSeconds)
+// readLoop reads messages from the WebSocket and queues them by priority
+func (c *JetstreamClient) readLoop(ctx context.Context) error {
+	for {
+		// Read message from WebSocket
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return readError(err)
+		}
+		extendReadDeadline(c.conn)
+
+		// Parse the message
+		var msg JetstreamMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			log.Printf("ERROR: Failed to unmarshal message: %v", err)
+			continue
+		}
+
+		// Bluesky posts unrelated to polls of opted-in authors are not worth a transaction
+		if c.processor.skipsPost(&msg) {
+			continue
+		}
+
+		priority := c.known.Classify(ctx, &msg)
+		if err := c.queue.Push(ctx, &msg, priority); err != nil {
+			return nil // Context cancelled
+		}
+	}
+}
+
+// handleMessage processes a single queued message with cursor update and metrics.
+// A message that fails to process is queued for a retry in the transaction
+// that moves the cursor past it, unless its record was rejected by lexicon
+// validation, which is permanent. If it can be neither queued nor skipped, it
+// is dropped from the queue and handleMessage returns an error, so the client
+// reconnects from the last persisted cursor.
+func (c *JetstreamClient) handleMessage(ctx context.Context, msg *JetstreamMessage, priority Priority) error {
+	collection := ""
+	operation := ""
+	if msg.Commit != nil {
+		collection = msg.Commit.Collection
+		operation = msg.Commit.Operation
+	}
+
+	// The cursor only advances past messages that are no longer in flight
+	cursor := c.queue.Cursor(msg)
+
+	startTime := time.Now()
+	if err := c.processor.ProcessMessageAtCursor(ctx, msg, cursor); err != nil {
+		if isRejected(err) {
+			// Retrying would reject the record again
+			log.Printf("ERROR: Skipping rejected message: %v", err)
+			telemetry.JetstreamRecordsProcessed.WithLabelValues(collection, operation, "rejected").Inc()
+			telemetry.JetstreamTierRecordsProcessed.WithLabelValues(priority.String(), "rejected").Inc()
+			if err := c.processor.SkipMessageAtCursor(ctx, cursor); err != nil {
+				c.queue.Fail(msg)
+				return fmt.Errorf("failed to skip rejected message: %w", err)
 			}
+			c.queue.Complete(msg, cursor)
+			return nil
 		}
+		log.Printf("ERROR: Failed to process message, queueing it for a retry: %v", err)
+		telemetry.JetstreamRecordsProcessed.WithLabelValues(collection, operation, "error").Inc()
+		telemetry.JetstreamTierRecordsProcessed.WithLabelValues(priority.String(), "error").Inc()
+		if err := c.processor.DeferMessageAtCursor(ctx, msg, err, cursor); err != nil {
+			log.Printf("ERROR: Dropping message at time_us %d that could not be queued for a retry", msg.TimeUs)
+			c.queue.Fail(msg)
+			return fmt.Errorf("failed to queue message for retry: %w", err)
+		}
+		c.queue.Complete(msg, cursor)
+		return nil
+	}
+	c.queue.Complete(msg, cursor)
+	c.known.Observe(ctx, msg)
+
+	// Record success metrics
+	telemetry.JetstreamTierRecordsProcessed.WithLabelValues(priority.String(), "success").Inc()
+	if collection != "" {
+		telemetry.JetstreamRecordsProcessed.WithLabelValues(collection, operation, "success").Inc()
+		telemetry.JetstreamProcessingDuration.WithLabelValues(collection, operation).Observe(time.Since(startTime).Seconds())
 	}
+
+	// Update cursor lag
+	observeLag(msg.TimeUs)
+	return nil
+}
+
+// observeLag records the time since an event (time_us is microseconds since epoch)
+func observeLag(timeUs int64) {
+	if timeUs <= 0 {
+		return
+	}
+	lagSeconds := time.Since(time.UnixMicro(timeUs)).Seconds()
+	if lagSeconds < 0 {
+		lagSeconds = 0 // Future events shouldn't happen but handle gracefully
+	}
+	telemetry.JetstreamCursorLag.Set(lagSeconds)
 }
 
 // Close closes the WebSocket connection
@@ -133,47 +296,23 @@ func (c *JetstreamClient) Close() error {
 	return nil
 }
 
-// RunWithReconnect runs the client with exponential backoff on connection errors
-func RunWithReconnect(ctx context.Context, url string, queries *db.Queries) error {
-	backoff := time.Second
-	maxBackoff := 60 * time.Second
-
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-			client := NewJetstreamClient(url, queries)
-
-			// Try to connect
-			if err := client.Connect(ctx); err != nil {
-				log.Printf("Connection error: %v. Retrying in %v...", err, backoff)
-				telemetry.JetstreamReconnects.Inc()
-				time.Sleep(backoff)
-
-				// Exponential backoff
-				backoff = backoff * 2
-				if backoff > maxBackoff {
-					backoff = maxBackoff
-				}
-				continue
-			}
-
-			// Reset backoff on successful connection
-			backoff = time.Second
-
-			// Run the client
-			if err := client.Run(ctx); err != nil {
-				log.Printf("Runtime error: %v. Reconnecting...", err)
-				telemetry.JetstreamReconnects.Inc()
-				client.Close()
-				time.Sleep(backoff)
-				continue
-			}
+// streamClient is a connection to an event stream the consumer indexes
+type streamClient interface {
+	Connect(ctx context.Context) error
+	Run(ctx context.Context) error
+	Close() error
+}
 
-			// Clean shutdown
-			client.Close()
-			return nil
+// RunWithReconnect runs the client, reconnecting with jittered exponential
+// backoff on connection errors (see runWithReconnect)
+// Optional processing steps (moderation, lexicon validation, foreign polls, cache invalidation) are configured by opts.
+func RunWithReconnect(ctx context.Context, url string, queries *db.Queries, opts ProcessorOptions) error {
+	return runWithReconnect(ctx, func() streamClient {
+		client := NewJetstreamClient(url, queries)
+		opts.configure(client.processor)
+		if opts.Workers > 0 {
+			client.workers = opts.Workers
 		}
-	}
+		return client
+	})
 }
@@ -0,0 +1,114 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+// DefaultLeaderCheckInterval is how often standby instances try to take
+// leadership and the leader confirms it still holds it
+const DefaultLeaderCheckInterval = 5 * time.Second
+
+// LeaderLock is an exclusive lock held by the consumer instance that reads
+// Jetstream (see db.LeaderLock)
+type LeaderLock interface {
+	TryAcquire(ctx context.Context) (bool, error)
+	Check(ctx context.Context) error
+	Release(ctx context.Context) error
+}
+
+// errLeadershipLost is returned by lead when the lock is lost while consuming
+var errLeadershipLost = errors.New("consumer leadership lost")
+
+// RunAsLeader runs run only while holding lock, so a single instance of several
+// replicas consumes Jetstream. Standby instances try to take the lock every
+// interval and take over when the leader stops or loses its database
+// connection. A leader that loses the lock stops run and becomes a standby.
+// It returns when ctx is cancelled or run fails.
+func RunAsLeader(ctx context.Context, lock LeaderLock, interval time.Duration, run func(context.Context) error) error {
+	telemetry.ConsumerLeader.Set(0)
+	standbyLogged := false
+
+	for {
+		acquired, err := lock.TryAcquire(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("Failed to acquire consumer leadership: %v", err)
+		case acquired:
+			standbyLogged = false
+			err := lead(ctx, lock, interval, run)
+			if !errors.Is(err, errLeadershipLost) {
+				return err
+			}
+		case !standbyLogged:
+			log.Println("Another consumer instance is the leader, waiting on standby")
+			standbyLogged = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// lead runs run while checking every interval that lock is still held. A
+// check that takes longer than interval loses leadership. The lock is
+// released when run returns.
+func lead(ctx context.Context, lock LeaderLock, interval time.Duration, run func(context.Context) error) error {
+	log.Println("Acquired consumer leadership, consuming Jetstream")
+	telemetry.ConsumerLeader.Set(1)
+	telemetry.ConsumerLeadershipChanges.WithLabelValues("acquired").Inc()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- run(runCtx) }()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			releaseLeadership(lock)
+			return err
+		case <-ticker.C:
+			// A check that hangs, e.g. on a partitioned database, counts as lost
+			// leadership, as a standby may take over meanwhile
+			checkCtx, cancelCheck := context.WithTimeout(ctx, interval)
+			err := lock.Check(checkCtx)
+			cancelCheck()
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+			log.Printf("Lost consumer leadership, stopping consumption: %v", err)
+			telemetry.ConsumerLeader.Set(0)
+			telemetry.ConsumerLeadershipChanges.WithLabelValues("lost").Inc()
+			cancel()
+			<-done
+			lock.Release(context.Background())
+			return errLeadershipLost
+		}
+	}
+}
+
+// releaseLeadership releases the lock after consuming stopped, so a standby
+// instance can take over without waiting for the connection to time out
+func releaseLeadership(lock LeaderLock) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := lock.Release(ctx); err != nil {
+		log.Printf("Failed to release consumer leadership: %v", err)
+	} else {
+		log.Println("Released consumer leadership")
+	}
+	telemetry.ConsumerLeader.Set(0)
+	telemetry.ConsumerLeadershipChanges.WithLabelValues("released").Inc()
+}
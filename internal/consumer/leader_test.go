@@ -0,0 +1,258 @@
+package consumer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/db"
+)
+
+// fakeLeaderLock is a LeaderLock shared by simulated consumer instances
+type fakeLeaderLock struct {
+	mu       sync.Mutex
+	holder   *fakeLeaderLock // Shared lock state, nil for the shared lock itself
+	owner    *fakeLeaderLock
+	released int
+	lost     bool // Simulates a dropped database connection
+	hung     bool // Simulates a database that stopped answering
+}
+
+func (l *fakeLeaderLock) shared() *fakeLeaderLock {
+	if l.holder != nil {
+		return l.holder
+	}
+	return l
+}
+
+func (l *fakeLeaderLock) TryAcquire(ctx context.Context) (bool, error) {
+	s := l.shared()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l.lost {
+		return false, errors.New("connection lost")
+	}
+	if s.owner == nil || s.owner == l {
+		s.owner = l
+		return true, nil
+	}
+	return false, nil
+}
+
+func (l *fakeLeaderLock) Check(ctx context.Context) error {
+	s := l.shared()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l.hung {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if l.lost {
+		if s.owner == l {
+			s.owner = nil
+		}
+		return errors.New("connection lost")
+	}
+	return nil
+}
+
+func (l *fakeLeaderLock) Release(ctx context.Context) error {
+	s := l.shared()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owner == l {
+		s.owner = nil
+	}
+	l.released++
+	return nil
+}
+
+func (l *fakeLeaderLock) isOwner() bool {
+	s := l.shared()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.owner == l
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// consumingRun returns a run function that counts active runs until cancelled
+func consumingRun(mu *sync.Mutex, active *int) func(context.Context) error {
+	return func(ctx context.Context) error {
+		mu.Lock()
+		*active++
+		mu.Unlock()
+		<-ctx.Done()
+		mu.Lock()
+		*active--
+		mu.Unlock()
+		return nil
+	}
+}
+
+func TestRunAsLeader_Failover(t *testing.T) {
+	shared := &fakeLeaderLock{}
+	first := &fakeLeaderLock{holder: shared}
+	second := &fakeLeaderLock{holder: shared}
+
+	var mu sync.Mutex
+	var firstActive, secondActive int
+
+	ctx1, stop1 := context.WithCancel(context.Background())
+	done1 := make(chan error, 1)
+	go func() { done1 <- RunAsLeader(ctx1, first, time.Millisecond, consumingRun(&mu, &firstActive)) }()
+	waitFor(t, "the first instance to lead", first.isOwner)
+
+	ctx2, stop2 := context.WithCancel(context.Background())
+	defer stop2()
+	done2 := make(chan error, 1)
+	go func() { done2 <- RunAsLeader(ctx2, second, time.Millisecond, consumingRun(&mu, &secondActive)) }()
+
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	if secondActive != 0 {
+		t.Error("Expected the standby instance not to consume while another instance leads")
+	}
+	mu.Unlock()
+
+	// Stopping the leader releases the lock and the standby takes over
+	stop1()
+	if err := <-done1; err != nil {
+		t.Fatalf("Expected a clean shutdown, got: %v", err)
+	}
+	if first.released == 0 {
+		t.Error("Expected the lock to be released on shutdown")
+	}
+	waitFor(t, "the standby to take over", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return secondActive == 1
+	})
+
+	stop2()
+	if err := <-done2; err != nil {
+		t.Fatalf("Expected a clean shutdown, got: %v", err)
+	}
+}
+
+func TestRunAsLeader_LostLeadership(t *testing.T) {
+	lock := &fakeLeaderLock{}
+
+	var mu sync.Mutex
+	runs, active := 0, 0
+	run := func(ctx context.Context) error {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		return consumingRun(&mu, &active)(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- RunAsLeader(ctx, lock, time.Millisecond, run) }()
+	waitFor(t, "the instance to lead", lock.isOwner)
+
+	// A dropped connection stops consumption; the instance leads again once it can
+	lock.mu.Lock()
+	lock.lost = true
+	lock.mu.Unlock()
+	waitFor(t, "consumption to stop", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return active == 0
+	})
+
+	lock.mu.Lock()
+	lock.lost = false
+	lock.mu.Unlock()
+	waitFor(t, "the instance to lead again", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return runs >= 2 && active == 1
+	})
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected a clean shutdown, got: %v", err)
+	}
+}
+
+func TestRunAsLeader_HungCheck(t *testing.T) {
+	lock := &fakeLeaderLock{}
+
+	var mu sync.Mutex
+	runs, active := 0, 0
+	run := func(ctx context.Context) error {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		return consumingRun(&mu, &active)(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- RunAsLeader(ctx, lock, time.Millisecond, run) }()
+	waitFor(t, "the instance to lead", lock.isOwner)
+
+	// A check that never answers times out and stops consumption, which
+	// starts again once the instance takes the lock again
+	lock.mu.Lock()
+	lock.hung = true
+	lock.mu.Unlock()
+	waitFor(t, "consumption to restart", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return runs >= 2
+	})
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected a clean shutdown, got: %v", err)
+	}
+}
+
+func TestRunAsLeader_RunError(t *testing.T) {
+	lock := &fakeLeaderLock{}
+	want := errors.New("jetstream failed")
+
+	err := RunAsLeader(context.Background(), lock, time.Millisecond, func(ctx context.Context) error {
+		return want
+	})
+	if !errors.Is(err, want) {
+		t.Fatalf("Expected the run error, got: %v", err)
+	}
+	if lock.isOwner() {
+		t.Error("Expected the lock to be released when consuming fails")
+	}
+}
+
+func TestProcessWithCursor_Fenced(t *testing.T) {
+	// A transaction-scoped processor, so nothing reaches a database
+	processor := NewProcessor(db.NewQueries(&sql.Tx{}))
+	processor.fence = func(ctx context.Context, q db.Querier) error { return db.ErrNotLeader }
+
+	saved := false
+	err := processor.processWithCursor(context.Background(), &JetstreamMessage{Kind: "identity"}, func(*db.Queries) error {
+		saved = true
+		return nil
+	})
+	if !errors.Is(err, db.ErrNotLeader) {
+		t.Fatalf("Expected ErrNotLeader, got: %v", err)
+	}
+	if saved {
+		t.Error("Expected the cursor not to be saved after leadership was lost")
+	}
+}
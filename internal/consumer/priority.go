@@ -0,0 +1,306 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/db"
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+// Priority is the work queue tier a Jetstream message is processed in
+type Priority int
+
+const (
+	// PriorityHigh is for records whose subject is a survey already known to this AppView
+	PriorityHigh Priority = iota
+	// PriorityLow is for all other network records
+	PriorityLow
+)
+
+const (
+	// DefaultQueueSize is the per-tier buffer size of the consumer work queue
+	DefaultQueueSize = 1000
+
+	// lowTierShare is how often the low tier is served while the high tier has
+	// a backlog: one message in every lowTierShare, so it cannot starve
+	lowTierShare = 10
+
+	// knownSurveyCacheSize bounds the number of cached survey lookups
+	knownSurveyCacheSize = 10000
+
+	// knownSurveyTTL and unknownSurveyTTL are how long a survey lookup is cached
+	knownSurveyTTL   = time.Hour
+	unknownSurveyTTL = time.Minute
+)
+
+// String returns the metric label for the tier
+func (p Priority) String() string {
+	if p == PriorityHigh {
+		return "high"
+	}
+	return "low"
+}
+
+// queuedMessage is a message waiting in one of the queue tiers
+type queuedMessage struct {
+	msg        *JetstreamMessage
+	priority   Priority
+	enqueuedAt time.Time
+}
+
+// PriorityQueue is a two-tier work queue between the WebSocket reader and the
+// processing workers. The high tier is drained before the low tier, except
+// that every lowTierShare-th message comes from the low tier while both have
+// messages. Messages within a tier keep their firehose order.
+//
+// Because tiers reorder events, the queue also tracks which time_us values are
+// still in flight so the persisted cursor never skips past a message that has
+// not been processed yet.
+type PriorityQueue struct {
+	high chan queuedMessage
+	low  chan queuedMessage
+
+	mu       sync.Mutex
+	inFlight map[int64]int // time_us -> number of queued messages with that timestamp
+	maxSeen  int64         // highest time_us pushed so far
+	cursor   int64         // highest cursor persisted so far
+	served   int           // messages popped since the low tier was last served
+}
+
+// NewPriorityQueue creates a queue with the given buffer size per tier
+func NewPriorityQueue(size int) *PriorityQueue {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	return &PriorityQueue{
+		high:     make(chan queuedMessage, size),
+		low:      make(chan queuedMessage, size),
+		inFlight: make(map[int64]int),
+	}
+}
+
+// Push adds a message to the given tier, blocking while that tier is full
+func (q *PriorityQueue) Push(ctx context.Context, msg *JetstreamMessage, priority Priority) error {
+	q.mu.Lock()
+	q.inFlight[msg.TimeUs]++
+	if msg.TimeUs > q.maxSeen {
+		q.maxSeen = msg.TimeUs
+	}
+	q.mu.Unlock()
+
+	tier := q.low
+	if priority == PriorityHigh {
+		tier = q.high
+	}
+
+	select {
+	case tier <- queuedMessage{msg: msg, priority: priority, enqueuedAt: time.Now()}:
+		telemetry.JetstreamQueueDepth.WithLabelValues(priority.String()).Inc()
+		return nil
+	case <-ctx.Done():
+		q.release(msg.TimeUs)
+		return ctx.Err()
+	}
+}
+
+// Pop returns the next message, preferring the high tier.
+// Blocks until a message is available or ctx is cancelled.
+func (q *PriorityQueue) Pop(ctx context.Context) (*JetstreamMessage, Priority, error) {
+	q.mu.Lock()
+	q.served++
+	lowTurn := q.served >= lowTierShare
+	q.mu.Unlock()
+
+	// Give the low tier its share of a backlog first
+	if lowTurn {
+		select {
+		case qm := <-q.low:
+			return q.dequeued(qm), qm.priority, nil
+		default:
+		}
+	}
+
+	// Then drain the high tier without blocking
+	select {
+	case qm := <-q.high:
+		return q.dequeued(qm), qm.priority, nil
+	default:
+	}
+
+	select {
+	case qm := <-q.high:
+		return q.dequeued(qm), qm.priority, nil
+	case qm := <-q.low:
+		return q.dequeued(qm), qm.priority, nil
+	case <-ctx.Done():
+		return nil, PriorityLow, ctx.Err()
+	}
+}
+
+// dequeued records queue metrics for a message leaving its tier
+func (q *PriorityQueue) dequeued(qm queuedMessage) *JetstreamMessage {
+	if qm.priority == PriorityLow {
+		q.mu.Lock()
+		q.served = 0
+		q.mu.Unlock()
+	}
+
+	tier := qm.priority.String()
+	telemetry.JetstreamQueueDepth.WithLabelValues(tier).Dec()
+	telemetry.JetstreamQueueWait.WithLabelValues(tier).Observe(time.Since(qm.enqueuedAt).Seconds())
+	return qm.msg
+}
+
+// Cursor returns the cursor that is safe to persist once a popped message is
+// handled: everything at or before it has been processed, other than
+// messages still queued or being processed.
+func (q *PriorityQueue) Cursor(msg *JetstreamMessage) int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	safe := q.maxSeen
+	for timeUs, n := range q.inFlight {
+		if timeUs == msg.TimeUs && n == 1 {
+			continue // Only this message
+		}
+		// Resume just before the oldest message that is still in flight
+		if timeUs-1 < safe {
+			safe = timeUs - 1
+		}
+	}
+
+	// Never move the cursor backwards
+	return max(safe, q.cursor)
+}
+
+// Complete marks a popped message as handled once cursor, from Cursor, has
+// been persisted with it
+func (q *PriorityQueue) Complete(msg *JetstreamMessage, cursor int64) {
+	q.release(msg.TimeUs)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.cursor = max(q.cursor, cursor)
+}
+
+// Fail drops a popped message whose processing failed without it being queued
+// for a retry, so later cursors no longer wait for it. Nothing is persisted
+// with it; a client that resumes from the persisted cursor before the next
+// one passes it sees the message again.
+func (q *PriorityQueue) Fail(msg *JetstreamMessage) {
+	q.release(msg.TimeUs)
+}
+
+// Len returns the number of queued messages in each tier
+func (q *PriorityQueue) Len() (high, low int) {
+	return len(q.high), len(q.low)
+}
+
+// release removes one in-flight entry for the given timestamp
+func (q *PriorityQueue) release(timeUs int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.inFlight[timeUs]--
+	if q.inFlight[timeUs] <= 0 {
+		delete(q.inFlight, timeUs)
+	}
+}
+
+// knownSurveys remembers which survey URIs are indexed locally, so messages
+// are classified without a database query each
+type knownSurveys struct {
+	queries *db.Queries
+	cache   *cache.Memory
+}
+
+// newKnownSurveys creates a classifier caching survey lookups in memory
+func newKnownSurveys(queries *db.Queries) *knownSurveys {
+	return &knownSurveys{
+		queries: queries,
+		cache:   cache.NewMemory(knownSurveyCacheSize),
+	}
+}
+
+// Classify decides which tier a message belongs to.
+// Records referencing a survey already indexed locally (responses and results
+// for our surveys, and updates/deletes of the surveys themselves) go to the
+// high tier; everything else, including anything we fail to inspect, is low.
+func (k *knownSurveys) Classify(ctx context.Context, msg *JetstreamMessage) Priority {
+	surveyURI := subjectSurveyURI(msg)
+	if surveyURI == "" {
+		return PriorityLow
+	}
+
+	if known, ok, _ := k.cache.Get(ctx, surveyURI); ok {
+		if string(known) == "1" {
+			return PriorityHigh
+		}
+		return PriorityLow
+	}
+
+	known, err := k.queries.SurveyExistsByURI(ctx, surveyURI)
+	if err != nil {
+		return PriorityLow
+	}
+	k.set(ctx, surveyURI, known)
+	if !known {
+		return PriorityLow
+	}
+	return PriorityHigh
+}
+
+// Observe updates the cache after a survey record was indexed or deleted
+func (k *knownSurveys) Observe(ctx context.Context, msg *JetstreamMessage) {
+	if msg.Commit == nil || msg.Commit.Collection != "net.openmeet.survey" {
+		return
+	}
+	k.set(ctx, subjectSurveyURI(msg), msg.Commit.Operation != "delete")
+}
+
+// set caches whether a survey is known. Unknown surveys expire sooner, as
+// they may be indexed by the API before the consumer sees them.
+func (k *knownSurveys) set(ctx context.Context, surveyURI string, known bool) {
+	if known {
+		k.cache.Set(ctx, surveyURI, []byte("1"), knownSurveyTTL)
+		return
+	}
+	k.cache.Set(ctx, surveyURI, []byte("0"), unknownSurveyTTL)
+}
+
+// subjectSurveyURI returns the URI of the survey a message refers to, or "" if
+// it cannot be determined without processing the message
+func subjectSurveyURI(msg *JetstreamMessage) string {
+	if msg.Kind != "commit" || msg.Commit == nil {
+		return ""
+	}
+
+	commit := msg.Commit
+	repo := commit.Repo
+	if repo == "" {
+		repo = msg.Did
+	}
+
+	switch commit.Collection {
+	case "net.openmeet.survey":
+		// The survey record is its own subject
+		return fmt.Sprintf("at://%s/%s/%s", repo, commit.Collection, commit.RKey)
+	case "net.openmeet.survey.response", "net.openmeet.survey.results", CommentCollection:
+		// Deletes carry no record, so the subject is unknown
+		if commit.Record == nil {
+			return ""
+		}
+		subject, ok := commit.Record["subject"].(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		uri, _ := subject["uri"].(string)
+		return uri
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,280 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// complete marks a popped message as handled and returns the cursor persisted with it
+func complete(q *PriorityQueue, msg *JetstreamMessage) int64 {
+	cursor := q.Cursor(msg)
+	q.Complete(msg, cursor)
+	return cursor
+}
+
+func TestPriorityQueue_HighTierFirst(t *testing.T) {
+	q := NewPriorityQueue(10)
+	ctx := context.Background()
+
+	low1 := &JetstreamMessage{TimeUs: 100}
+	low2 := &JetstreamMessage{TimeUs: 200}
+	high := &JetstreamMessage{TimeUs: 300}
+
+	for _, item := range []struct {
+		msg      *JetstreamMessage
+		priority Priority
+	}{
+		{low1, PriorityLow},
+		{low2, PriorityLow},
+		{high, PriorityHigh},
+	} {
+		if err := q.Push(ctx, item.msg, item.priority); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+	}
+
+	expected := []*JetstreamMessage{high, low1, low2}
+	for i, want := range expected {
+		got, _, err := q.Pop(ctx)
+		if err != nil {
+			t.Fatalf("Pop %d failed: %v", i, err)
+		}
+		if got != want {
+			t.Errorf("Pop %d: expected time_us %d, got %d", i, want.TimeUs, got.TimeUs)
+		}
+	}
+}
+
+func TestPriorityQueue_PopReturnsOnCancel(t *testing.T) {
+	q := NewPriorityQueue(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, _, err := q.Pop(ctx); err == nil {
+		t.Error("expected error when context is cancelled")
+	}
+}
+
+func TestPriorityQueue_CursorWaitsForLowTier(t *testing.T) {
+	q := NewPriorityQueue(10)
+	ctx := context.Background()
+
+	low := &JetstreamMessage{TimeUs: 100}
+	high := &JetstreamMessage{TimeUs: 200}
+
+	_ = q.Push(ctx, low, PriorityLow)
+	_ = q.Push(ctx, high, PriorityHigh)
+
+	// High tier is processed first, but the older low-tier message is still queued
+	got, priority, _ := q.Pop(ctx)
+	if got != high || priority != PriorityHigh {
+		t.Fatalf("expected high-tier message first")
+	}
+	if cursor := complete(q, got); cursor != 99 {
+		t.Errorf("expected cursor to stop before queued message (99), got %d", cursor)
+	}
+
+	// Once the low-tier message is done the cursor catches up to the newest event
+	got, _, _ = q.Pop(ctx)
+	if cursor := complete(q, got); cursor != 200 {
+		t.Errorf("expected cursor 200 after draining queue, got %d", cursor)
+	}
+}
+
+func TestPriorityQueue_CursorNeverMovesBackwards(t *testing.T) {
+	q := NewPriorityQueue(10)
+	ctx := context.Background()
+
+	first := &JetstreamMessage{TimeUs: 500}
+	_ = q.Push(ctx, first, PriorityLow)
+	got, _, _ := q.Pop(ctx)
+	if cursor := complete(q, got); cursor != 500 {
+		t.Fatalf("expected cursor 500, got %d", cursor)
+	}
+
+	// An older event arriving late must not rewind the cursor
+	late := &JetstreamMessage{TimeUs: 300}
+	_ = q.Push(ctx, late, PriorityHigh)
+	got, _, _ = q.Pop(ctx)
+	if cursor := complete(q, got); cursor != 500 {
+		t.Errorf("expected cursor to stay at 500, got %d", cursor)
+	}
+}
+
+func TestPriorityQueue_LowTierShare(t *testing.T) {
+	q := NewPriorityQueue(2 * lowTierShare)
+	ctx := context.Background()
+
+	low := &JetstreamMessage{TimeUs: 1}
+	_ = q.Push(ctx, low, PriorityLow)
+	for i := 0; i < 2*lowTierShare; i++ {
+		_ = q.Push(ctx, &JetstreamMessage{TimeUs: int64(100 + i)}, PriorityHigh)
+	}
+
+	// A high tier backlog delays the low tier, but does not starve it
+	for i := 1; i <= lowTierShare; i++ {
+		got, priority, _ := q.Pop(ctx)
+		if i < lowTierShare && priority != PriorityHigh {
+			t.Fatalf("Pop %d: expected high-tier message", i)
+		}
+		if i == lowTierShare && got != low {
+			t.Fatalf("Pop %d: expected the low-tier message", i)
+		}
+	}
+}
+
+func TestPriorityQueue_CursorHoldsIncompleteMessage(t *testing.T) {
+	q := NewPriorityQueue(10)
+	ctx := context.Background()
+
+	failed := &JetstreamMessage{TimeUs: 100}
+	next := &JetstreamMessage{TimeUs: 200}
+	_ = q.Push(ctx, failed, PriorityLow)
+	_ = q.Push(ctx, next, PriorityLow)
+
+	// The first message is still being processed
+	got, _, _ := q.Pop(ctx)
+	if cursor := q.Cursor(got); cursor != 199 {
+		t.Fatalf("expected cursor 199 while the next message is queued, got %d", cursor)
+	}
+
+	got, _, _ = q.Pop(ctx)
+	if cursor := complete(q, got); cursor != 99 {
+		t.Errorf("expected cursor to stop before the incomplete message (99), got %d", cursor)
+	}
+}
+
+func TestPriorityQueue_FailedMessageReleasesCursor(t *testing.T) {
+	q := NewPriorityQueue(10)
+	ctx := context.Background()
+
+	failed := &JetstreamMessage{TimeUs: 100}
+	next := &JetstreamMessage{TimeUs: 200}
+	_ = q.Push(ctx, failed, PriorityLow)
+	_ = q.Push(ctx, next, PriorityLow)
+
+	// The first message fails without being queued for a retry
+	got, _, _ := q.Pop(ctx)
+	q.Fail(got)
+
+	got, _, _ = q.Pop(ctx)
+	if cursor := complete(q, got); cursor != 200 {
+		t.Errorf("expected cursor to move past the failed message (200), got %d", cursor)
+	}
+}
+
+func TestKnownSurveys_Observe(t *testing.T) {
+	k := newKnownSurveys(nil)
+	ctx := context.Background()
+
+	survey := &JetstreamMessage{
+		Kind: "commit",
+		Did:  "did:plc:author",
+		Commit: &JetstreamCommit{
+			Operation:  "create",
+			Collection: "net.openmeet.survey",
+			RKey:       "abc",
+		},
+	}
+	response := &JetstreamMessage{
+		Kind: "commit",
+		Commit: &JetstreamCommit{
+			Operation:  "create",
+			Collection: "net.openmeet.survey.response",
+			Repo:       "did:plc:voter",
+			RKey:       "r1",
+			Record: map[string]interface{}{
+				"subject": map[string]interface{}{
+					"uri": "at://did:plc:author/net.openmeet.survey/abc",
+				},
+			},
+		},
+	}
+
+	// Indexed surveys are classified from memory, without a query
+	k.Observe(ctx, survey)
+	if got := k.Classify(ctx, response); got != PriorityHigh {
+		t.Errorf("expected response to a known survey to be high priority, got %s", got)
+	}
+
+	survey.Commit.Operation = "delete"
+	k.Observe(ctx, survey)
+	if got := k.Classify(ctx, response); got != PriorityLow {
+		t.Errorf("expected response to a deleted survey to be low priority, got %s", got)
+	}
+}
+
+func TestWorkerFor_SameRepoSameWorker(t *testing.T) {
+	byDid := &JetstreamMessage{Did: "did:plc:voter"}
+	byRepo := &JetstreamMessage{Commit: &JetstreamCommit{Repo: "did:plc:voter"}}
+	if workerFor(byDid, DefaultWorkers) != workerFor(byRepo, DefaultWorkers) {
+		t.Error("expected messages of one repository to go to the same worker")
+	}
+}
+
+func TestSubjectSurveyURI(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *JetstreamMessage
+		want string
+	}{
+		{
+			name: "survey record is its own subject",
+			msg: &JetstreamMessage{
+				Kind: "commit",
+				Did:  "did:plc:author",
+				Commit: &JetstreamCommit{
+					Operation:  "update",
+					Collection: "net.openmeet.survey",
+					RKey:       "abc",
+				},
+			},
+			want: "at://did:plc:author/net.openmeet.survey/abc",
+		},
+		{
+			name: "response uses subject uri",
+			msg: &JetstreamMessage{
+				Kind: "commit",
+				Commit: &JetstreamCommit{
+					Operation:  "create",
+					Collection: "net.openmeet.survey.response",
+					Repo:       "did:plc:voter",
+					RKey:       "r1",
+					Record: map[string]interface{}{
+						"subject": map[string]interface{}{
+							"uri": "at://did:plc:author/net.openmeet.survey/abc",
+						},
+					},
+				},
+			},
+			want: "at://did:plc:author/net.openmeet.survey/abc",
+		},
+		{
+			name: "response delete has no subject",
+			msg: &JetstreamMessage{
+				Kind: "commit",
+				Commit: &JetstreamCommit{
+					Operation:  "delete",
+					Collection: "net.openmeet.survey.response",
+					Repo:       "did:plc:voter",
+					RKey:       "r1",
+				},
+			},
+			want: "",
+		},
+		{
+			name: "non-commit message",
+			msg:  &JetstreamMessage{Kind: "identity"},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subjectSurveyURI(tt.msg); got != tt.want {
+				t.Errorf("subjectSurveyURI() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
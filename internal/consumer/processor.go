@@ -3,13 +3,25 @@ package consumer
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/cache"
 	"github.com/openmeet-team/survey/internal/db"
+	"github.com/openmeet-team/survey/internal/interop"
+	"github.com/openmeet-team/survey/internal/invite"
 	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/moderation"
 	"github.com/openmeet-team/survey/internal/telemetry"
+	"github.com/openmeet-team/survey/internal/vanity"
+	"github.com/openmeet-team/survey/lexicon"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // JetstreamMessage represents a message from the Jetstream firehose
@@ -29,13 +41,44 @@ type JetstreamCommit struct {
 	Record     map[string]interface{} `json:"record,omitempty"` // Present for create/update
 	CID        string                 `json:"cid,omitempty"`    // Present for create/update
 	Repo       string                 `json:"repo"`             // DID of the repo owner
+
+	// published is the record as in the repository, when Record was
+	// converted from a foreign lexicon
+	published map[string]interface{}
+}
+
+// recordURI returns the AT URI of the commit's record
+func (c *JetstreamCommit) recordURI() string {
+	return fmt.Sprintf("at://%s/%s/%s", c.Repo, c.Collection, c.RKey)
+}
+
+// publishedRecord returns the record as in the repository, whose CID is CID
+func (c *JetstreamCommit) publishedRecord() map[string]interface{} {
+	if c.published != nil {
+		return c.published
+	}
+	return c.Record
 }
 
 // Processor handles processing of Jetstream messages
 type Processor struct {
-	queries *db.Queries
+	queries        *db.Queries
+	moderator      *moderation.Moderator
+	verdicts       moderation.Verdicts // Verdicts on the current message's text answers
+	validator      *lexicon.Validator
+	validationMode ValidationMode
+	foreignPolls   map[string]bool // Foreign poll collections indexed as read-only surveys
+	foreignVotes   map[string]bool // Foreign vote collections indexed as responses
+	pollAuthors    map[string]bool // DIDs whose Bluesky posts are indexed as polls
+	vanityPolicy   *vanity.Policy  // Who may get reserved slugs for indexed surveys
+	cache          *cache.Store
+	stale          []string // Cache keys to invalidate once the current message is committed
+	fence          func(ctx context.Context, q db.Querier) error
 }
 
+// tracer traces the processing of commits
+var tracer = otel.Tracer("github.com/openmeet-team/survey/internal/consumer")
+
 // NewProcessor creates a new Processor instance
 func NewProcessor(queries *db.Queries) *Processor {
 	return &Processor{
@@ -43,8 +86,52 @@ func NewProcessor(queries *db.Queries) *Processor {
 	}
 }
 
-// ProcessMessage processes a single Jetstream message
-func (p *Processor) ProcessMessage(ctx context.Context, msg *JetstreamMessage) error {
+// SetModerator sets the moderator used to flag text answers of indexed responses
+func (p *Processor) SetModerator(m *moderation.Moderator) {
+	p.moderator = m
+}
+
+// SetVanityPolicy sets who may get reserved slugs for their indexed surveys,
+// as when creating them with the API. Surveys of other authors get slugs
+// outside the reservations.
+func (p *Processor) SetVanityPolicy(policy *vanity.Policy) {
+	p.vanityPolicy = policy
+}
+
+// reservedSlugs returns the reservations of slugs an author may not get:
+// none for authors the vanity policy lets claim them
+func (p *Processor) reservedSlugs(ctx context.Context, did string) ([]*vanity.Reservation, error) {
+	reservations, err := p.queries.ListReservedSlugs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reserved slugs: %w", err)
+	}
+	if len(reservations) == 0 || p.vanityPolicy.MayClaim(ctx, did) {
+		return nil, nil
+	}
+	return reservations, nil
+}
+
+// SetCache sets the cache invalidated when indexed surveys and responses change
+func (p *Processor) SetCache(store *cache.Store) {
+	p.cache = store
+}
+
+// markStale records cache keys invalidated after the message is committed,
+// so concurrent readers cannot cache the data from before the commit
+func (p *Processor) markStale(keys ...string) {
+	if p.cache.Enabled() {
+		p.stale = append(p.stale, keys...)
+	}
+}
+
+// invalidateStale invalidates the cache keys recorded by markStale
+func (p *Processor) invalidateStale(ctx context.Context) {
+	p.cache.Invalidate(ctx, p.stale...)
+	p.stale = nil
+}
+
+// ProcessMessage processes a single Jetstream message, in a span per commit
+func (p *Processor) ProcessMessage(ctx context.Context, msg *JetstreamMessage) (err error) {
 	// Filter for commit messages only
 	if msg.Kind != "commit" || msg.Commit == nil {
 		return nil // Skip non-commit messages
@@ -55,6 +142,27 @@ func (p *Processor) ProcessMessage(ctx context.Context, msg *JetstreamMessage) e
 		msg.Commit.Repo = msg.Did
 	}
 
+	ctx, span := tracer.Start(ctx, "process "+msg.Commit.Collection, trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+		attribute.String("atproto.collection", msg.Commit.Collection),
+		attribute.String("atproto.operation", msg.Commit.Operation),
+		attribute.String("atproto.repo", msg.Commit.Repo),
+		attribute.String("atproto.rkey", msg.Commit.RKey),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	// Check created and updated records against their lexicon before indexing
+	if msg.Commit.Operation == "create" || msg.Commit.Operation == "update" {
+		if err := p.validateRecord(msg.Commit); err != nil {
+			return err
+		}
+	}
+
 	// Route to appropriate handler based on collection
 	switch msg.Commit.Collection {
 	case "net.openmeet.survey":
@@ -63,8 +171,14 @@ func (p *Processor) ProcessMessage(ctx context.Context, msg *JetstreamMessage) e
 		return p.processResponseCommit(ctx, msg)
 	case "net.openmeet.survey.results":
 		return p.processResultsCommit(ctx, msg)
+	case CommentCollection:
+		return p.processCommentCommit(ctx, msg)
+	case interop.PostCollection:
+		_, err := p.processBlueskyPost(ctx, msg)
+		return err
 	default:
-		return nil // Skip other collections
+		_, err := p.processForeignCommit(ctx, msg)
+		return err // Skips other collections
 	}
 }
 
@@ -111,18 +225,22 @@ func (p *Processor) createSurvey(ctx context.Context, commit *JetstreamCommit) e
 		return fmt.Errorf("invalid survey definition: %w", err)
 	}
 
-	// Generate slug from name
-	baseSlug := GenerateSlugFromTitle(name)
+	// Generate slug from name, outside the slugs reserved for others
+	reservations, err := p.reservedSlugs(ctx, commit.Repo)
+	if err != nil {
+		return err
+	}
+	baseSlug := vanity.Available(reservations, GenerateSlugFromTitle(name), 50)
 	slug := baseSlug
 
-	// Handle slug collisions by appending -2, -3, etc.
+	// Handle slug collisions and reserved slugs by appending -2, -3, etc.
 	suffix := 2
 	for {
 		exists, err := p.queries.SlugExists(ctx, slug)
 		if err != nil {
 			return fmt.Errorf("failed to check slug existence: %w", err)
 		}
-		if !exists {
+		if !exists && vanity.Match(reservations, slug) == nil {
 			break
 		}
 		slug = fmt.Sprintf("%s-%d", baseSlug, suffix)
@@ -153,6 +271,13 @@ func (p *Processor) createSurvey(ctx context.Context, commit *JetstreamCommit) e
 	if err := p.queries.CreateSurvey(ctx, survey); err != nil {
 		return fmt.Errorf("failed to create survey: %w", err)
 	}
+	if err := p.keepRecord(ctx, survey.ID, uri, commit); err != nil {
+		return err
+	}
+	// Responses and results that arrived first are retried now
+	if err := wakeRetries(ctx, p.queries, uri); err != nil {
+		return err
+	}
 
 	// Record business metrics
 	telemetry.SurveysIndexed.Inc()
@@ -175,16 +300,18 @@ func (p *Processor) updateSurvey(ctx context.Context, commit *JetstreamCommit) e
 
 	// Look up existing survey
 	survey, err := p.queries.GetSurveyByURI(ctx, uri)
-	if err != nil {
-		return fmt.Errorf("failed to get survey by URI: %w", err)
-	}
-	if survey == nil {
+	if errors.Is(err, sql.ErrNoRows) {
 		// Survey doesn't exist in our index - treat as create
 		return p.createSurvey(ctx, commit)
 	}
+	if err != nil {
+		return fmt.Errorf("failed to get survey by URI: %w", err)
+	}
 
-	// Authorization check: verify the update comes from the survey author
-	if survey.AuthorDID != nil && *survey.AuthorDID != commit.Repo {
+	// Authorization check: verify the update comes from the survey author or an editor of its organization
+	if ok, err := p.canWriteSurvey(ctx, survey, commit.Repo); err != nil {
+		return err
+	} else if !ok {
 		return fmt.Errorf("unauthorized: DID %s cannot update survey owned by %s", commit.Repo, *survey.AuthorDID)
 	}
 
@@ -199,6 +326,9 @@ func (p *Processor) updateSurvey(ctx context.Context, commit *JetstreamCommit) e
 		return fmt.Errorf("invalid survey definition: %w", err)
 	}
 
+	// Record what changed so voters can see edits made after they voted
+	changes := models.DiffDefinitions(&survey.Definition, def)
+
 	// Update the survey (keep existing slug, ID, etc.)
 	survey.CID = &commit.CID
 	survey.Title = name
@@ -208,38 +338,79 @@ func (p *Processor) updateSurvey(ctx context.Context, commit *JetstreamCommit) e
 	if err := p.queries.UpdateSurvey(ctx, survey); err != nil {
 		return fmt.Errorf("failed to update survey: %w", err)
 	}
+	if err := p.keepRecord(ctx, survey.ID, uri, commit); err != nil {
+		return err
+	}
+
+	if len(changes) > 0 {
+		revision := &models.SurveyRevision{
+			ID:        uuid.New(),
+			SurveyID:  survey.ID,
+			CID:       &commit.CID,
+			Changes:   changes,
+			CreatedAt: time.Now(),
+		}
+		if err := p.queries.CreateSurveyRevision(ctx, revision); err != nil {
+			return fmt.Errorf("failed to record survey revision: %w", err)
+		}
+	}
+	p.markStale(cache.SurveyKey(survey.Slug), cache.ResultsKey(survey.ID))
 
 	return nil
 }
 
-// deleteSurvey removes a survey from the index
+// deleteSurvey moves a survey to the trash, from where its author can restore
+// it for a while
 func (p *Processor) deleteSurvey(ctx context.Context, commit *JetstreamCommit) error {
 	// Construct record URI
 	uri := fmt.Sprintf("at://%s/%s/%s", commit.Repo, commit.Collection, commit.RKey)
 
 	// Look up existing survey for authorization check
 	survey, err := p.queries.GetSurveyByURI(ctx, uri)
-	if err != nil {
-		return fmt.Errorf("failed to get survey by URI: %w", err)
-	}
-	if survey == nil {
+	if errors.Is(err, sql.ErrNoRows) {
 		// Survey doesn't exist - nothing to delete (idempotent)
 		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("failed to get survey by URI: %w", err)
+	}
 
-	// Authorization check: verify the delete comes from the survey author
-	if survey.AuthorDID != nil && *survey.AuthorDID != commit.Repo {
+	// Authorization check: verify the delete comes from the survey author or an editor of its organization
+	if ok, err := p.canWriteSurvey(ctx, survey, commit.Repo); err != nil {
+		return err
+	} else if !ok {
 		return fmt.Errorf("unauthorized: DID %s cannot delete survey owned by %s", commit.Repo, *survey.AuthorDID)
 	}
 
-	// Delete the survey (cascades to responses due to ON DELETE CASCADE)
+	// Move the survey to the trash; its responses go when the trash is purged
 	if err := p.queries.DeleteSurveyByURI(ctx, uri); err != nil {
 		return fmt.Errorf("failed to delete survey: %w", err)
 	}
+	p.markStale(cache.SurveyKey(survey.Slug), cache.ResultsKey(survey.ID))
 
 	return nil
 }
 
+// canWriteSurvey reports whether a repository may change a survey and its
+// results: the survey's author, or an owner or editor of its organization.
+// Co-authors may not; the results they ask for are published by the author.
+func (p *Processor) canWriteSurvey(ctx context.Context, survey *models.Survey, did string) (bool, error) {
+	if survey.AuthorDID == nil || *survey.AuthorDID == did {
+		return true, nil
+	}
+	if survey.OrgID == nil {
+		return false, nil
+	}
+	member, err := p.queries.GetMember(ctx, *survey.OrgID, did)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check organization membership: %w", err)
+	}
+	return member.CanEdit(), nil
+}
+
 // processResponseCommit handles create/update/delete operations for survey responses
 func (p *Processor) processResponseCommit(ctx context.Context, msg *JetstreamMessage) error {
 	commit := msg.Commit
@@ -279,16 +450,17 @@ func (p *Processor) createResponse(ctx context.Context, commit *JetstreamCommit)
 	}
 
 	// Look up the survey by URI
-	survey, err := p.queries.GetSurveyByURI(ctx, surveyURI)
-	if err != nil {
-		return fmt.Errorf("failed to get survey by URI %s: %w", surveyURI, err)
-	}
-	if survey == nil {
-		return fmt.Errorf("survey not found: %s", surveyURI)
+	survey, err := p.subjectSurvey(ctx, surveyURI, recordURI)
+	if err != nil || survey == nil {
+		return err // Skipped if the survey was deleted
 	}
 
-	// Validate answers against survey definition
-	if err := models.ValidateAnswers(&survey.Definition, answers); err != nil {
+	// Validate answers against the version of the survey the voter answered
+	def, version, err := p.answeredVersion(ctx, survey, ResponseSubjectCID(commit.Record))
+	if err != nil {
+		return err
+	}
+	if err := models.ValidateAnswers(def, answers); err != nil {
 		return fmt.Errorf("answer validation failed: %w", err)
 	}
 
@@ -305,20 +477,46 @@ func (p *Processor) createResponse(ctx context.Context, commit *JetstreamCommit)
 		return nil
 	}
 
+	// Surveys with invite visibility only index responses of invitees whose
+	// invite is unused, which the response uses
+	inv, err := p.voterInvite(ctx, survey, voterDID)
+	if err != nil {
+		return err
+	}
+	if survey.Definition.Visibility == models.VisibilityInvite && inv == nil {
+		log.Printf("Skipping response %s: %s has no unused invite to survey %s", recordURI, voterDID, survey.ID)
+		return nil
+	}
+
 	// Create the response
 	response := &models.Response{
-		ID:        uuid.New(),
-		SurveyID:  survey.ID,
-		VoterDID:  &voterDID,
-		RecordURI: &recordURI,
-		RecordCID: &commit.CID,
-		Answers:   answers,
-		CreatedAt: time.Now(),
+		ID:            uuid.New(),
+		SurveyID:      survey.ID,
+		VoterDID:      &voterDID,
+		RecordURI:     &recordURI,
+		RecordCID:     &commit.CID,
+		Answers:       answers,
+		SurveyVersion: version,
+		CreatedAt:     time.Now(),
 	}
 
 	if err := p.queries.CreateResponse(ctx, response); err != nil {
 		return fmt.Errorf("failed to create response: %w", err)
 	}
+	if inv != nil && !survey.Definition.Anonymous {
+		if err := p.queries.SetInviteResponse(ctx, inv.ID, response.ID); err != nil {
+			return err
+		}
+	}
+	if err := p.keepRecord(ctx, survey.ID, recordURI, commit); err != nil {
+		return err
+	}
+
+	// Flag text answers for review before they appear in results
+	if _, err := p.verdicts.Save(ctx, p.queries, survey.ID, response.ID, answers); err != nil {
+		return fmt.Errorf("failed to moderate response: %w", err)
+	}
+	p.markStale(cache.ResultsKey(survey.ID))
 
 	// Record business metrics
 	telemetry.VotesIndexed.Inc()
@@ -332,6 +530,78 @@ func (p *Processor) createResponse(ctx context.Context, commit *JetstreamCommit)
 	return nil
 }
 
+// voterInvite uses and returns the invite of a voter to a survey with invite
+// visibility, or returns nil if the survey has another visibility or the
+// voter has no unused invite. Invitees are matched by DID: invites of email
+// addresses are only used with their links, through the API.
+func (p *Processor) voterInvite(ctx context.Context, survey *models.Survey, voterDID string) (*invite.Invite, error) {
+	if survey.Definition.Visibility != models.VisibilityInvite {
+		return nil, nil
+	}
+	inv, err := p.queries.GetInviteByInvitee(ctx, survey.ID, voterDID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+	if err := p.queries.UseInvite(ctx, inv.ID, time.Now()); err != nil {
+		if errors.Is(err, invite.ErrUsed) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return inv, nil
+}
+
+// missingSurveyError is returned for a response, results, or comment record
+// whose survey is not indexed (yet). Jetstream doesn't order the records of
+// different repositories, so a voter's response can arrive before the
+// author's survey; such records are retried as soon as the survey is indexed.
+type missingSurveyError struct {
+	uri string
+}
+
+func (e *missingSurveyError) Error() string {
+	return "survey not found: " + e.uri
+}
+
+// subjectSurvey returns the survey a response, results, or comment record refers to,
+// a *missingSurveyError if it is not indexed, or nil if it was deleted. Records
+// of deleted surveys are skipped rather than retried: the survey will not
+// come back.
+func (p *Processor) subjectSurvey(ctx context.Context, surveyURI, recordURI string) (*models.Survey, error) {
+	survey, err := p.queries.GetSurveyByURI(ctx, surveyURI)
+	if err == nil {
+		return survey, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get survey by URI %s: %w", surveyURI, err)
+	}
+	if _, err := p.queries.GetSurveyTombstoneByURI(ctx, surveyURI); err == nil {
+		log.Printf("Skipping record %s: survey %s was deleted", recordURI, surveyURI)
+		return nil, nil
+	}
+	return nil, &missingSurveyError{uri: surveyURI}
+}
+
+// answeredVersion returns the survey definition and version a response record
+// answered: the version of the survey record CID it references, or the current
+// version if it references none or an unknown CID
+func (p *Processor) answeredVersion(ctx context.Context, survey *models.Survey, surveyCID *string) (*models.SurveyDefinition, *int, error) {
+	if surveyCID == nil || (survey.CID != nil && *survey.CID == *surveyCID) {
+		return &survey.Definition, &survey.Version, nil
+	}
+	version, err := p.queries.GetSurveyVersionByCID(ctx, survey.ID, *surveyCID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get survey version of CID %s: %w", *surveyCID, err)
+	}
+	if version == nil {
+		return &survey.Definition, &survey.Version, nil
+	}
+	return &version.Definition, &version.Version, nil
+}
+
 // updateResponse updates an existing indexed response
 func (p *Processor) updateResponse(ctx context.Context, commit *JetstreamCommit) error {
 	if commit.Record == nil {
@@ -363,23 +633,33 @@ func (p *Processor) updateResponse(ctx context.Context, commit *JetstreamCommit)
 	}
 
 	// Get the survey to validate answers
-	survey, err := p.queries.GetSurveyByURI(ctx, surveyURI)
-	if err != nil {
-		return fmt.Errorf("failed to get survey by URI %s: %w", surveyURI, err)
-	}
-	if survey == nil {
-		return fmt.Errorf("survey not found: %s", surveyURI)
+	survey, err := p.subjectSurvey(ctx, surveyURI, recordURI)
+	if err != nil || survey == nil {
+		return err // Skipped if the survey was deleted
 	}
 
-	// Validate answers
-	if err := models.ValidateAnswers(&survey.Definition, answers); err != nil {
+	// Validate answers against the version of the survey the voter answered
+	def, version, err := p.answeredVersion(ctx, survey, ResponseSubjectCID(commit.Record))
+	if err != nil {
+		return err
+	}
+	if err := models.ValidateAnswers(def, answers); err != nil {
 		return fmt.Errorf("answer validation failed: %w", err)
 	}
 
 	// Update the response
-	if err := p.queries.UpdateResponseAnswers(ctx, response.ID, answers, commit.CID); err != nil {
+	if err := p.queries.UpdateResponseAnswers(ctx, response.ID, answers, commit.CID, version); err != nil {
 		return fmt.Errorf("failed to update response: %w", err)
 	}
+	if err := p.keepRecord(ctx, survey.ID, recordURI, commit); err != nil {
+		return err
+	}
+
+	// Flag the new answers again; unchanged flagged text keeps its review decision
+	if _, err := p.verdicts.Save(ctx, p.queries, survey.ID, response.ID, answers); err != nil {
+		return fmt.Errorf("failed to moderate response: %w", err)
+	}
+	p.markStale(cache.ResultsKey(survey.ID))
 
 	return nil
 }
@@ -408,10 +688,20 @@ func (p *Processor) deleteResponse(ctx context.Context, commit *JetstreamCommit)
 	if err := p.queries.DeleteResponseByRecordURI(ctx, recordURI); err != nil {
 		return fmt.Errorf("failed to delete response: %w", err)
 	}
+	if err := p.queries.DeleteIndexedRecord(ctx, recordURI); err != nil {
+		return err
+	}
+	p.markStale(cache.ResultsKey(response.SurveyID))
 
 	return nil
 }
 
+// keepRecord keeps the value of an indexed survey or response record, so
+// exports can show that it matches its CID
+func (p *Processor) keepRecord(ctx context.Context, surveyID uuid.UUID, uri string, commit *JetstreamCommit) error {
+	return p.queries.SaveIndexedRecord(ctx, surveyID, uri, commit.CID, commit.publishedRecord())
+}
+
 // processResultsCommit handles create/update/delete operations for survey results
 func (p *Processor) processResultsCommit(ctx context.Context, msg *JetstreamMessage) error {
 	commit := msg.Commit
@@ -451,16 +741,20 @@ func (p *Processor) createResults(ctx context.Context, commit *JetstreamCommit)
 	}
 
 	// Look up the survey
-	survey, err := p.queries.GetSurveyByURI(ctx, surveyURI)
-	if err != nil {
-		return fmt.Errorf("failed to get survey by URI %s: %w", surveyURI, err)
-	}
-	if survey == nil {
-		return fmt.Errorf("survey not found: %s", surveyURI)
-	}
-
-	// Authorization check: verify the results publish comes from the survey author
-	if survey.AuthorDID != nil && *survey.AuthorDID != commit.Repo {
+	survey, err := p.subjectSurvey(ctx, surveyURI, resultsURI)
+	if err != nil || survey == nil {
+		return err // Skipped if the survey was deleted
+	}
+
+	// Authorization check: verify the results publish comes from the survey author or an editor of its organization
+	if ok, err := p.canWriteSurvey(ctx, survey, commit.Repo); err != nil {
+		return err
+	} else if !ok {
+		if coAuthor, err := p.queries.IsCoAuthor(ctx, survey.ID, commit.Repo); err != nil {
+			return err
+		} else if coAuthor {
+			return fmt.Errorf("unauthorized: co-author %s cannot publish results for survey owned by %s; its author publishes them", commit.Repo, *survey.AuthorDID)
+		}
 		return fmt.Errorf("unauthorized: DID %s cannot publish results for survey owned by %s", commit.Repo, *survey.AuthorDID)
 	}
 
@@ -468,6 +762,7 @@ func (p *Processor) createResults(ctx context.Context, commit *JetstreamCommit)
 	if err := p.queries.UpdateSurveyResults(ctx, survey.ID, resultsURI, commit.CID); err != nil {
 		return fmt.Errorf("failed to update survey results: %w", err)
 	}
+	p.markStale(cache.SurveyKey(survey.Slug))
 
 	// Record business metric
 	telemetry.ResultsPublished.Inc()
@@ -494,8 +789,10 @@ func (p *Processor) updateResults(ctx context.Context, commit *JetstreamCommit)
 		return p.createResults(ctx, commit)
 	}
 
-	// Authorization check: verify the update comes from the survey author
-	if survey.AuthorDID != nil && *survey.AuthorDID != commit.Repo {
+	// Authorization check: verify the update comes from the survey author or an editor of its organization
+	if ok, err := p.canWriteSurvey(ctx, survey, commit.Repo); err != nil {
+		return err
+	} else if !ok {
 		return fmt.Errorf("unauthorized: DID %s cannot update results for survey owned by %s", commit.Repo, *survey.AuthorDID)
 	}
 
@@ -513,6 +810,7 @@ func (p *Processor) updateResults(ctx context.Context, commit *JetstreamCommit)
 	if err := p.queries.UpdateSurveyResults(ctx, survey.ID, resultsURI, commit.CID); err != nil {
 		return fmt.Errorf("failed to update survey results: %w", err)
 	}
+	p.markStale(cache.SurveyKey(survey.Slug))
 
 	return nil
 }
@@ -532,8 +830,10 @@ func (p *Processor) deleteResults(ctx context.Context, commit *JetstreamCommit)
 		return nil
 	}
 
-	// Authorization check: verify the delete comes from the survey author
-	if survey.AuthorDID != nil && *survey.AuthorDID != commit.Repo {
+	// Authorization check: verify the delete comes from the survey author or an editor of its organization
+	if ok, err := p.canWriteSurvey(ctx, survey, commit.Repo); err != nil {
+		return err
+	} else if !ok {
 		return fmt.Errorf("unauthorized: DID %s cannot delete results for survey owned by %s", commit.Repo, *survey.AuthorDID)
 	}
 
@@ -543,46 +843,125 @@ func (p *Processor) deleteResults(ctx context.Context, commit *JetstreamCommit)
 	if err != nil {
 		return fmt.Errorf("failed to clear survey results: %w", err)
 	}
+	p.markStale(cache.SurveyKey(survey.Slug))
 
 	return nil
 }
 
 // ProcessMessageWithCursor processes a message and updates the cursor atomically
 func (p *Processor) ProcessMessageWithCursor(ctx context.Context, msg *JetstreamMessage, getDB func() db.Querier) error {
-	// Start a transaction
-	dbConn, ok := p.queries.GetDB().(*sql.DB)
-	if !ok {
-		// If we're already in a transaction, just process the message
-		if err := p.ProcessMessage(ctx, msg); err != nil {
-			return fmt.Errorf("failed to process message: %w", err)
+	return p.ProcessMessageAtCursor(ctx, msg, msg.TimeUs)
+}
+
+// ProcessMessageAtCursor processes a message and atomically advances the
+// cursor to the given value. The priority queue uses this to persist a cursor
+// that lags behind messages still waiting in a lower tier.
+// Queued retries of the message's record are superseded by it.
+func (p *Processor) ProcessMessageAtCursor(ctx context.Context, msg *JetstreamMessage, cursor int64) error {
+	return p.processWithCursor(ctx, msg, func(q *db.Queries) error {
+		if err := supersedeRetries(ctx, q, msg, 0); err != nil {
+			return err
 		}
-		return UpdateCursor(ctx, p.queries, msg.TimeUs)
-	}
+		return AdvanceCursor(ctx, q, cursor)
+	})
+}
 
-	tx, err := dbConn.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+// ProcessMessageAtSeq processes a message and atomically sets the firehose
+// cursor to the given sequence number and the message's event time
+func (p *Processor) ProcessMessageAtSeq(ctx context.Context, msg *JetstreamMessage, seq int64) error {
+	return p.processWithCursor(ctx, msg, func(q *db.Queries) error {
+		if err := supersedeRetries(ctx, q, msg, 0); err != nil {
+			return err
+		}
+		return UpdateFirehoseCursor(ctx, q, seq, msg.TimeUs)
+	})
+}
+
+// checkFence verifies this instance may still write, if a fence is set
+func (p *Processor) checkFence(ctx context.Context, q db.Querier) error {
+	if p.fence == nil {
+		return nil
 	}
-	defer tx.Rollback()
+	if err := p.fence(ctx, q); err != nil {
+		return fmt.Errorf("consumer leadership check failed: %w", err)
+	}
+	return nil
+}
+
+// SaveFirehoseCursor sets the firehose cursor past events without wanted
+// records, fenced like message processing
+func (p *Processor) SaveFirehoseCursor(ctx context.Context, seq, timeUs int64) error {
+	return p.processWithCursor(ctx, nil, func(q *db.Queries) error {
+		return UpdateFirehoseCursor(ctx, q, seq, timeUs)
+	})
+}
 
-	// Create transaction-scoped processor
-	txQueries := db.NewQueries(tx)
-	txProcessor := NewProcessor(txQueries)
+// processWithCursor processes a message (if any) and saves the cursor in one transaction
+func (p *Processor) processWithCursor(ctx context.Context, msg *JetstreamMessage, saveCursor func(*db.Queries) error) error {
+	// Moderation may call external APIs, so it runs before the transaction
+	verdicts := p.checkAnswers(ctx, msg)
+
+	// Process in a transaction, unless already in one
+	var txProcessor *Processor
+	err := p.queries.InTx(ctx, func(txQueries *db.Queries) error {
+		// Check leadership first, so the fence holds until the transaction ends
+		if err := p.checkFence(ctx, txQueries.GetDB()); err != nil {
+			return err
+		}
 
-	// Process the message
-	if err := txProcessor.ProcessMessage(ctx, msg); err != nil {
-		return fmt.Errorf("failed to process message: %w", err)
-	}
+		// Create transaction-scoped processor
+		txProcessor = p.withQueries(txQueries, verdicts)
 
-	// Update cursor
-	if err := UpdateCursor(ctx, txQueries, msg.TimeUs); err != nil {
-		return fmt.Errorf("failed to update cursor: %w", err)
-	}
+		// Process the message
+		if msg != nil {
+			if err := txProcessor.ProcessMessage(ctx, msg); err != nil {
+				return fmt.Errorf("failed to process message: %w", err)
+			}
+		}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		// Update cursor
+		if err := saveCursor(txQueries); err != nil {
+			return fmt.Errorf("failed to update cursor: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	txProcessor.invalidateStale(ctx)
 
 	return nil
 }
+
+// withQueries returns a processor for one message, using queries (usually
+// in a transaction) and the verdicts on the message's text answers
+func (p *Processor) withQueries(queries *db.Queries, verdicts moderation.Verdicts) *Processor {
+	scoped := NewProcessor(queries)
+	scoped.moderator = p.moderator
+	scoped.verdicts = verdicts
+	scoped.validator = p.validator
+	scoped.validationMode = p.validationMode
+	scoped.foreignPolls = p.foreignPolls
+	scoped.foreignVotes = p.foreignVotes
+	scoped.pollAuthors = p.pollAuthors
+	scoped.vanityPolicy = p.vanityPolicy
+	scoped.cache = p.cache
+	return scoped
+}
+
+// checkAnswers checks the text answers of a created or updated response
+// record. Records that fail to parse are left to processing to reject.
+func (p *Processor) checkAnswers(ctx context.Context, msg *JetstreamMessage) moderation.Verdicts {
+	if !p.moderator.Enabled() || msg == nil || msg.Commit == nil || msg.Commit.Record == nil ||
+		msg.Commit.Collection != "net.openmeet.survey.response" {
+		return nil
+	}
+	if msg.Commit.Operation != "create" && msg.Commit.Operation != "update" {
+		return nil
+	}
+	_, answers, err := ParseResponseRecord(msg.Commit.Record)
+	if err != nil {
+		return nil
+	}
+	return p.moderator.CheckAnswers(ctx, answers)
+}
@@ -2,11 +2,23 @@ package consumer
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/coauthor"
+	"github.com/openmeet-team/survey/internal/invite"
 	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/moderation"
+	"github.com/openmeet-team/survey/internal/org"
+	"github.com/openmeet-team/survey/internal/vanity"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestProcessSurveyResponse(t *testing.T) {
@@ -363,6 +375,18 @@ func TestAuthorizationChecks(t *testing.T) {
 		if updated.Title != "Updated Title" {
 			t.Errorf("Expected title 'Updated Title', got: %s", updated.Title)
 		}
+
+		// Verify the definition change was recorded
+		revisions, err := queries.ListSurveyRevisions(ctx, survey.ID, 10)
+		if err != nil {
+			t.Fatalf("Failed to list survey revisions: %v", err)
+		}
+		if len(revisions) != 1 || len(revisions[0].Changes) != 1 {
+			t.Fatalf("Expected 1 revision with 1 change, got: %+v", revisions)
+		}
+		if got := revisions[0].Changes[0].Describe(); got != `changed question "Question 1?" to "Updated Question?"` {
+			t.Errorf("Unexpected change: %s", got)
+		}
 	})
 
 	t.Run("deleteSurvey rejects wrong author DID", func(t *testing.T) {
@@ -687,6 +711,156 @@ func TestAuthorizationChecks(t *testing.T) {
 	})
 }
 
+// TestOrganizationResultsAuthorization tests that editors of a survey's
+// organization may publish its results, and viewers may not
+func TestOrganizationResultsAuthorization(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		URI:       stringPtr("at://did:plc:orgauthor/net.openmeet.survey/orgsurvey"),
+		CID:       stringPtr("bafy700"),
+		AuthorDID: stringPtr("did:plc:orgauthor"),
+		Slug:      "test-survey-org-auth-" + uuid.NewString()[:8],
+		Title:     "Org Survey",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "q1", Text: "Question?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "a", Text: "Option A"}}},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := queries.CreateSurvey(ctx, survey); err != nil {
+		t.Fatalf("Failed to create survey: %v", err)
+	}
+
+	o, err := org.New("org-auth-"+uuid.NewString()[:8], "Org", "did:plc:orgauthor")
+	if err != nil {
+		t.Fatalf("Failed to build organization: %v", err)
+	}
+	if err := queries.CreateOrg(ctx, o); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	for did, role := range map[string]string{"did:plc:orgeditor": org.RoleEditor, "did:plc:orgviewer": org.RoleViewer} {
+		if err := queries.InviteMember(ctx, &org.Member{OrgID: o.ID, DID: did, Role: role, InvitedBy: o.CreatedBy, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Failed to invite %s: %v", did, err)
+		}
+		if err := queries.AcceptInvite(ctx, o.ID, did); err != nil {
+			t.Fatalf("Failed to accept invite of %s: %v", did, err)
+		}
+	}
+	if err := queries.SetSurveyOrg(ctx, survey.ID, &o.ID); err != nil {
+		t.Fatalf("Failed to move survey: %v", err)
+	}
+
+	publish := func(repo string, timeUs int64) error {
+		return processor.ProcessMessage(ctx, &JetstreamMessage{
+			Kind: "commit",
+			Commit: &JetstreamCommit{
+				Operation:  "create",
+				Repo:       repo,
+				Collection: "net.openmeet.survey.results",
+				RKey:       "orgresults",
+				CID:        "bafy701",
+				Record: map[string]interface{}{
+					"$type":     "net.openmeet.survey.results",
+					"subject":   map[string]interface{}{"uri": *survey.URI},
+					"createdAt": time.Now().Format(time.RFC3339),
+				},
+			},
+			TimeUs: timeUs,
+		})
+	}
+
+	err = publish("did:plc:orgviewer", 1234567900)
+	if err == nil || !contains(err.Error(), "unauthorized") {
+		t.Errorf("Expected 'unauthorized' error for a viewer, got: %v", err)
+	}
+
+	if err := publish("did:plc:orgeditor", 1234567901); err != nil {
+		t.Fatalf("Expected an editor to publish results, got: %v", err)
+	}
+	updated, err := queries.GetSurveyByURI(ctx, *survey.URI)
+	if err != nil {
+		t.Fatalf("Failed to get survey: %v", err)
+	}
+	if updated.ResultsURI == nil || *updated.ResultsURI != "at://did:plc:orgeditor/net.openmeet.survey.results/orgresults" {
+		t.Errorf("Expected the editor's results, got: %v", updated.ResultsURI)
+	}
+}
+
+// TestCoAuthorResultsAuthorization tests that results records in the
+// repositories of co-authors are refused; their author publishes them
+func TestCoAuthorResultsAuthorization(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		URI:       stringPtr("at://did:plc:coauthored/net.openmeet.survey/shared"),
+		CID:       stringPtr("bafy710"),
+		AuthorDID: stringPtr("did:plc:coauthored"),
+		Slug:      "test-survey-coauthor-auth-" + uuid.NewString()[:8],
+		Title:     "Shared Survey",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "q1", Text: "Question?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "a", Text: "Option A"}}},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := queries.CreateSurvey(ctx, survey); err != nil {
+		t.Fatalf("Failed to create survey: %v", err)
+	}
+	if err := queries.AddCoAuthor(ctx, &coauthor.CoAuthor{SurveyID: survey.ID, DID: "did:plc:coauthor", AddedBy: *survey.AuthorDID, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to add co-author: %v", err)
+	}
+
+	publish := func(repo string, timeUs int64) error {
+		return processor.ProcessMessage(ctx, &JetstreamMessage{
+			Kind: "commit",
+			Commit: &JetstreamCommit{
+				Operation:  "create",
+				Repo:       repo,
+				Collection: "net.openmeet.survey.results",
+				RKey:       "sharedresults",
+				CID:        "bafy711",
+				Record: map[string]interface{}{
+					"$type":     "net.openmeet.survey.results",
+					"subject":   map[string]interface{}{"uri": *survey.URI},
+					"createdAt": time.Now().Format(time.RFC3339),
+				},
+			},
+			TimeUs: timeUs,
+		})
+	}
+
+	err := publish("did:plc:coauthor", 1234567910)
+	if err == nil || !contains(err.Error(), "unauthorized: co-author") {
+		t.Errorf("Expected 'unauthorized' error for a co-author, got: %v", err)
+	}
+
+	if err := publish(*survey.AuthorDID, 1234567911); err != nil {
+		t.Fatalf("Expected the author to publish results, got: %v", err)
+	}
+	updated, err := queries.GetSurveyByURI(ctx, *survey.URI)
+	if err != nil {
+		t.Fatalf("Failed to get survey: %v", err)
+	}
+	if updated.ResultsURI == nil || *updated.ResultsURI != "at://did:plc:coauthored/net.openmeet.survey.results/sharedresults" {
+		t.Errorf("Expected the author's results, got: %v", updated.ResultsURI)
+	}
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -999,3 +1173,695 @@ func TestDeduplication(t *testing.T) {
 		}
 	})
 }
+
+// TestResponseVersionPinning tests that responses referencing an earlier survey
+// record are validated against and tagged with the version they answered
+func TestResponseVersionPinning(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		URI:       stringPtr("at://did:plc:pinauthor/net.openmeet.survey/pinned"),
+		CID:       stringPtr("bafy_v1"),
+		AuthorDID: stringPtr("did:plc:pinauthor"),
+		Slug:      "test-survey-pinned-" + uuid.NewString()[:8],
+		Title:     "Pinned Survey",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{
+					ID:   "q1",
+					Text: "Lunch?",
+					Type: models.QuestionTypeSingle,
+					Options: []models.Option{
+						{ID: "pizza", Text: "Pizza"},
+						{ID: "sushi", Text: "Sushi"},
+					},
+				},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := queries.CreateSurvey(ctx, survey); err != nil {
+		t.Fatalf("Failed to create test survey: %v", err)
+	}
+
+	// The author replaces "sushi" with "tacos"
+	err := processor.ProcessMessage(ctx, &JetstreamMessage{
+		Kind: "commit",
+		Commit: &JetstreamCommit{
+			Operation:  "update",
+			Repo:       "did:plc:pinauthor",
+			Collection: "net.openmeet.survey",
+			RKey:       "pinned",
+			CID:        "bafy_v2",
+			Record: map[string]interface{}{
+				"$type": "net.openmeet.survey",
+				"name":  "Pinned Survey",
+				"definition": map[string]interface{}{
+					"questions": []interface{}{
+						map[string]interface{}{
+							"id":   "q1",
+							"text": "Lunch?",
+							"type": "net.openmeet.survey#single",
+							"options": []interface{}{
+								map[string]interface{}{"id": "pizza", "text": "Pizza"},
+								map[string]interface{}{"id": "tacos", "text": "Tacos"},
+							},
+						},
+					},
+				},
+			},
+		},
+		TimeUs: 1234567893,
+	})
+	if err != nil {
+		t.Fatalf("Failed to update survey: %v", err)
+	}
+
+	updated, err := queries.GetSurveyByURI(ctx, *survey.URI)
+	if err != nil {
+		t.Fatalf("Failed to get updated survey: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Fatalf("Expected an option change to bump the version to 2, got: %d", updated.Version)
+	}
+
+	vote := func(voter, rkey, surveyCID, option string) error {
+		return processor.ProcessMessage(ctx, &JetstreamMessage{
+			Kind: "commit",
+			Commit: &JetstreamCommit{
+				Operation:  "create",
+				Repo:       voter,
+				Collection: "net.openmeet.survey.response",
+				RKey:       rkey,
+				CID:        "bafy_" + rkey,
+				Record: map[string]interface{}{
+					"$type":   "net.openmeet.survey.response",
+					"subject": map[string]interface{}{"uri": *survey.URI, "cid": surveyCID},
+					"answers": []interface{}{
+						map[string]interface{}{"questionId": "q1", "selectedOptions": []interface{}{option}},
+					},
+				},
+			},
+			TimeUs: 1234567894,
+		})
+	}
+
+	// A voter who loaded the first version can still pick the removed option
+	if err := vote("did:plc:pinvoter1", "pin1", "bafy_v1", "sushi"); err != nil {
+		t.Fatalf("Expected a vote on version 1 to be accepted, got: %v", err)
+	}
+	if err := vote("did:plc:pinvoter2", "pin2", "bafy_v2", "tacos"); err != nil {
+		t.Fatalf("Expected a vote on version 2 to be accepted, got: %v", err)
+	}
+	if err := vote("did:plc:pinvoter3", "pin3", "bafy_v2", "sushi"); err == nil {
+		t.Error("Expected a vote for an option removed before the referenced version to be rejected")
+	}
+
+	first, err := queries.GetResponseByRecordURI(ctx, "at://did:plc:pinvoter1/net.openmeet.survey.response/pin1")
+	if err != nil || first == nil {
+		t.Fatalf("Failed to get response: %v", err)
+	}
+	if first.SurveyVersion == nil || *first.SurveyVersion != 1 {
+		t.Errorf("Expected the response to be pinned to version 1, got: %v", first.SurveyVersion)
+	}
+
+	results, err := queries.GetSurveyResults(ctx, survey.ID)
+	if err != nil {
+		t.Fatalf("Failed to get results: %v", err)
+	}
+	if !results.MixedVersions || results.VersionVotes[1] != 1 || results.VersionVotes[2] != 1 {
+		t.Errorf("Expected one vote on each version, got: %v", results.VersionVotes)
+	}
+	if got := results.QuestionResults["q1"].RemovedOptions["sushi"]; got != "Sushi" {
+		t.Errorf("Expected the removed option to be labelled Sushi, got: %q", got)
+	}
+}
+
+func TestCheckAnswers(t *testing.T) {
+	p := NewProcessor(nil)
+	p.SetModerator(moderation.NewModerator(moderation.NewBlocklist([]string{"spam"})))
+
+	response := func(operation, collection string) *JetstreamMessage {
+		return &JetstreamMessage{
+			Kind: "commit",
+			Commit: &JetstreamCommit{
+				Operation:  operation,
+				Collection: collection,
+				Record: map[string]interface{}{
+					"subject": map[string]interface{}{"uri": "at://did:plc:a/net.openmeet.survey/1", "cid": "bafy1"},
+					"answers": []interface{}{
+						map[string]interface{}{"questionId": "q1", "text": "buy spam now"},
+					},
+				},
+			},
+		}
+	}
+
+	verdicts := p.checkAnswers(context.Background(), response("create", "net.openmeet.survey.response"))
+	if verdicts["q1"] == nil || !verdicts["q1"].Flagged {
+		t.Errorf("expected the text answer of a created response to be flagged, got %v", verdicts)
+	}
+	if verdicts := p.checkAnswers(context.Background(), response("update", "net.openmeet.survey.response")); len(verdicts) != 1 {
+		t.Errorf("expected updated responses to be checked, got %v", verdicts)
+	}
+	if verdicts := p.checkAnswers(context.Background(), response("create", "net.openmeet.survey")); verdicts != nil {
+		t.Errorf("expected other collections not to be checked, got %v", verdicts)
+	}
+	if verdicts := p.checkAnswers(context.Background(), nil); verdicts != nil {
+		t.Errorf("expected no verdicts without a message, got %v", verdicts)
+	}
+
+	p.SetModerator(nil)
+	if verdicts := p.checkAnswers(context.Background(), response("create", "net.openmeet.survey.response")); verdicts != nil {
+		t.Errorf("expected no verdicts with moderation disabled, got %v", verdicts)
+	}
+}
+
+func TestDeletedSurveyTombstone(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+
+	surveyURI := "at://did:plc:tombauthor/net.openmeet.survey/tomb1"
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		URI:       stringPtr(surveyURI),
+		CID:       stringPtr("bafytomb"),
+		AuthorDID: stringPtr("did:plc:tombauthor"),
+		Slug:      "test-survey-tombstone-" + uuid.NewString()[:8],
+		Title:     "Deleted Survey",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "q1", Text: "Comments?", Type: models.QuestionTypeText},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := queries.CreateSurvey(ctx, survey); err != nil {
+		t.Fatalf("Failed to create test survey: %v", err)
+	}
+	defer database.Exec("DELETE FROM survey_tombstones WHERE slug = $1", survey.Slug)
+
+	// The author deletes the survey
+	err := processor.ProcessMessage(ctx, &JetstreamMessage{
+		Kind: "commit",
+		Commit: &JetstreamCommit{
+			Operation:  "delete",
+			Repo:       "did:plc:tombauthor",
+			Collection: "net.openmeet.survey",
+			RKey:       "tomb1",
+		},
+	})
+	if err != nil {
+		t.Fatalf("ProcessMessage failed: %v", err)
+	}
+
+	tombstone, err := queries.GetSurveyTombstoneBySlug(ctx, survey.Slug)
+	if err != nil {
+		t.Fatalf("Expected a tombstone: %v", err)
+	}
+	if tombstone.URI == nil || *tombstone.URI != surveyURI {
+		t.Errorf("Expected tombstone of %s, got %v", surveyURI, tombstone.URI)
+	}
+	if exists, _ := queries.SlugExists(ctx, survey.Slug); !exists {
+		t.Error("Expected the deleted survey's slug to stay taken")
+	}
+
+	// A late response to it is skipped, not retried
+	err = processor.ProcessMessage(ctx, &JetstreamMessage{
+		Kind: "commit",
+		Commit: &JetstreamCommit{
+			Operation:  "create",
+			Repo:       "did:plc:latevoter",
+			Collection: "net.openmeet.survey.response",
+			RKey:       "late1",
+			CID:        "bafylate",
+			Record: map[string]interface{}{
+				"$type":     "net.openmeet.survey.response",
+				"subject":   map[string]interface{}{"uri": surveyURI},
+				"answers":   []interface{}{map[string]interface{}{"questionId": "q1", "text": "hello"}},
+				"createdAt": time.Now().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		t.Errorf("Expected response to deleted survey to be skipped, got: %v", err)
+	}
+}
+
+func TestProcessMessageSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	processor := NewProcessor(nil)
+	ctx := context.Background()
+
+	// Non-commit messages are not traced
+	if err := processor.ProcessMessage(ctx, &JetstreamMessage{Kind: "identity"}); err != nil {
+		t.Fatalf("ProcessMessage failed: %v", err)
+	}
+
+	err := processor.ProcessMessage(ctx, &JetstreamMessage{
+		Did:  "did:plc:author",
+		Kind: "commit",
+		Commit: &JetstreamCommit{
+			Operation:  "create",
+			Collection: "net.openmeet.survey",
+			RKey:       "abc",
+		},
+	})
+	if err == nil {
+		t.Fatal("Expected an error for a create without a record")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "process net.openmeet.survey" {
+		t.Errorf("Unexpected span name %q", span.Name)
+	}
+	for _, want := range []attribute.KeyValue{
+		attribute.String("atproto.collection", "net.openmeet.survey"),
+		attribute.String("atproto.operation", "create"),
+		attribute.String("atproto.repo", "did:plc:author"),
+	} {
+		found := false
+		for _, attr := range span.Attributes {
+			found = found || attr == want
+		}
+		if !found {
+			t.Errorf("Expected attribute %v", want)
+		}
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("Expected an error status, got %v", span.Status.Code)
+	}
+}
+
+func TestIndexedRecordsKept(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+
+	surveyURI := "at://did:plc:keepauthor/net.openmeet.survey/keep1"
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		URI:       stringPtr(surveyURI),
+		CID:       stringPtr("bafykeep"),
+		AuthorDID: stringPtr("did:plc:keepauthor"),
+		Slug:      "test-survey-keep-" + uuid.NewString()[:8],
+		Title:     "Kept Records",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "q1", Text: "Comments?", Type: models.QuestionTypeText},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := queries.CreateSurvey(ctx, survey); err != nil {
+		t.Fatalf("Failed to create test survey: %v", err)
+	}
+	defer database.Exec("DELETE FROM surveys WHERE id = $1", survey.ID)
+
+	recordURI := "at://did:plc:keepvoter/net.openmeet.survey.response/keep1"
+	commit := func(operation string) *JetstreamMessage {
+		msg := &JetstreamMessage{
+			Kind: "commit",
+			Commit: &JetstreamCommit{
+				Operation:  operation,
+				Repo:       "did:plc:keepvoter",
+				Collection: "net.openmeet.survey.response",
+				RKey:       "keep1",
+			},
+		}
+		if operation != "delete" {
+			msg.Commit.CID = "bafyvote"
+			msg.Commit.Record = map[string]interface{}{
+				"$type":     "net.openmeet.survey.response",
+				"subject":   map[string]interface{}{"uri": surveyURI},
+				"answers":   []interface{}{map[string]interface{}{"questionId": "q1", "text": "kept"}},
+				"createdAt": "2026-03-01T12:00:00Z",
+			}
+		}
+		return msg
+	}
+
+	if err := processor.ProcessMessage(ctx, commit("create")); err != nil {
+		t.Fatalf("ProcessMessage failed: %v", err)
+	}
+	record, err := queries.GetIndexedRecord(ctx, recordURI, "bafyvote")
+	if err != nil || record == nil {
+		t.Fatalf("Expected the response record to be kept, got %v, %v", record, err)
+	}
+	if record.Value["createdAt"] != "2026-03-01T12:00:00Z" {
+		t.Errorf("Expected the record value, got %v", record.Value)
+	}
+
+	records, err := queries.ListResponseRecords(ctx, survey.ID)
+	if err != nil {
+		t.Fatalf("ListResponseRecords failed: %v", err)
+	}
+	if len(records) != 1 || records[0].URI != recordURI || records[0].Value == nil {
+		t.Errorf("Expected the kept response record, got %v", records)
+	}
+
+	// A deleted record is no longer kept
+	if err := processor.ProcessMessage(ctx, commit("delete")); err != nil {
+		t.Fatalf("ProcessMessage failed: %v", err)
+	}
+	if record, err := queries.GetIndexedRecord(ctx, recordURI, "bafyvote"); err != nil || record != nil {
+		t.Errorf("Expected the deleted record to be gone, got %v, %v", record, err)
+	}
+}
+
+func TestOutOfOrderCommits(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+	rkey := uuid.NewString()[:8]
+	author := "did:plc:ooo" + rkey
+	surveyURI := "at://" + author + "/net.openmeet.survey/" + rkey
+	defer database.Exec("DELETE FROM surveys WHERE uri = $1", surveyURI)
+
+	results := func(operation string) *JetstreamMessage {
+		return &JetstreamMessage{
+			Kind: "commit",
+			Commit: &JetstreamCommit{
+				Operation:  operation,
+				Repo:       author,
+				Collection: "net.openmeet.survey.results",
+				RKey:       rkey,
+				CID:        "bafyresults" + operation,
+				Record: map[string]interface{}{
+					"$type":   "net.openmeet.survey.results",
+					"subject": map[string]interface{}{"uri": surveyURI},
+				},
+			},
+		}
+	}
+
+	t.Run("delete before create is a no-op", func(t *testing.T) {
+		msg := surveyMessage("delete", author, rkey)
+		msg.Commit.Record = nil
+		if err := processor.ProcessMessage(ctx, msg); err != nil {
+			t.Errorf("Expected delete of an unindexed survey to succeed, got: %v", err)
+		}
+	})
+
+	t.Run("results before their survey wait for it", func(t *testing.T) {
+		err := processor.ProcessMessage(ctx, results("update"))
+		var missing *missingSurveyError
+		if !errors.As(err, &missing) || missing.uri != surveyURI {
+			t.Errorf("Expected a missing survey error for %s, got: %v", surveyURI, err)
+		}
+	})
+
+	t.Run("survey update before create is a create", func(t *testing.T) {
+		if err := processor.ProcessMessage(ctx, surveyMessage("update", author, rkey)); err != nil {
+			t.Fatalf("Expected update before create to index the survey, got: %v", err)
+		}
+		if _, err := queries.GetSurveyByURI(ctx, surveyURI); err != nil {
+			t.Errorf("Expected the survey to be indexed: %v", err)
+		}
+	})
+
+	t.Run("results update before create is a create", func(t *testing.T) {
+		if err := processor.ProcessMessage(ctx, results("update")); err != nil {
+			t.Fatalf("Expected update before create to index the results, got: %v", err)
+		}
+		survey, err := queries.GetSurveyByURI(ctx, surveyURI)
+		if err != nil {
+			t.Fatalf("Failed to get survey: %v", err)
+		}
+		if survey.ResultsCID == nil || *survey.ResultsCID != "bafyresultsupdate" {
+			t.Errorf("Expected the results to be indexed, got %v", survey.ResultsCID)
+		}
+	})
+
+	t.Run("response update before create is a create", func(t *testing.T) {
+		msg := responseMessage("update", "did:plc:ooovoter", rkey, surveyURI)
+		if err := processor.ProcessMessage(ctx, msg); err != nil {
+			t.Fatalf("Expected update before create to index the response, got: %v", err)
+		}
+		response, err := queries.GetResponseByRecordURI(ctx, msg.Commit.recordURI())
+		if err != nil || response == nil {
+			t.Errorf("Expected the response to be indexed, got %v (%v)", response, err)
+		}
+	})
+}
+
+func TestProcessComments(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+	rkey := uuid.NewString()[:8]
+	surveyURI := "at://did:plc:commentauthor/net.openmeet.survey/" + rkey
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		URI:       stringPtr(surveyURI),
+		CID:       stringPtr("bafycomment"),
+		AuthorDID: stringPtr("did:plc:commentauthor"),
+		Slug:      "test-survey-comments-" + rkey,
+		Title:     "Comments",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "q1", Text: "Comments?", Type: models.QuestionTypeText},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := queries.CreateSurvey(ctx, survey); err != nil {
+		t.Fatalf("Failed to create test survey: %v", err)
+	}
+	defer database.Exec("DELETE FROM surveys WHERE id = $1", survey.ID)
+
+	comment := func(operation, repo, rkey, text string) *JetstreamMessage {
+		msg := &JetstreamMessage{
+			Kind: "commit",
+			Commit: &JetstreamCommit{
+				Operation:  operation,
+				Repo:       repo,
+				Collection: CommentCollection,
+				RKey:       rkey,
+			},
+		}
+		if operation != "delete" {
+			msg.Commit.CID = "bafy" + rkey + text
+			msg.Commit.Record = map[string]interface{}{
+				"$type":     CommentCollection,
+				"subject":   map[string]interface{}{"uri": surveyURI, "cid": "bafycomment"},
+				"text":      text,
+				"createdAt": "2026-03-01T12:00:00Z",
+			}
+		}
+		return msg
+	}
+	count := func() int {
+		n, err := queries.CountSurveyComments(ctx, survey.ID)
+		if err != nil {
+			t.Fatalf("CountSurveyComments failed: %v", err)
+		}
+		return n
+	}
+
+	// Redelivered records and repeated comments are indexed once
+	for _, msg := range []*JetstreamMessage{
+		comment("create", "did:plc:commenter", "c1", "Nice survey"),
+		comment("create", "did:plc:commenter", "c1", "Nice survey"),
+		comment("create", "did:plc:commenter", "c2", "Nice survey"),
+		comment("create", "did:plc:other", "c3", "Nice survey"),
+	} {
+		if err := processor.ProcessMessage(ctx, msg); err != nil {
+			t.Fatalf("ProcessMessage failed: %v", err)
+		}
+	}
+	if n := count(); n != 2 {
+		t.Errorf("Expected 2 comments, got %d", n)
+	}
+
+	// Oversized comments are rejected
+	if err := processor.ProcessMessage(ctx, comment("create", "did:plc:commenter", "c4", strings.Repeat("a", 3001))); err == nil {
+		t.Error("Expected an oversized comment to be rejected")
+	}
+
+	// Comments on surveys not indexed yet are retried
+	missing := comment("create", "did:plc:commenter", "c5", "Early")
+	missing.Commit.Record["subject"] = map[string]interface{}{"uri": "at://did:plc:commentauthor/net.openmeet.survey/missing" + rkey}
+	var missingErr *missingSurveyError
+	if err := processor.ProcessMessage(ctx, missing); !errors.As(err, &missingErr) {
+		t.Errorf("Expected a missing survey error, got %v", err)
+	}
+
+	// Authors edit and delete their comments
+	if err := processor.ProcessMessage(ctx, comment("update", "did:plc:commenter", "c1", "Nice survey, edited")); err != nil {
+		t.Fatalf("ProcessMessage failed: %v", err)
+	}
+	uri := "at://did:plc:commenter/" + CommentCollection + "/c1"
+	got, err := queries.GetCommentByRecordURI(ctx, uri)
+	if err != nil || got == nil || got.Text != "Nice survey, edited" {
+		t.Errorf("Expected the edited comment, got %v, %v", got, err)
+	}
+	if err := processor.ProcessMessage(ctx, comment("delete", "did:plc:commenter", "c1", "")); err != nil {
+		t.Fatalf("ProcessMessage failed: %v", err)
+	}
+	if n := count(); n != 1 {
+		t.Errorf("Expected 1 comment after the delete, got %d", n)
+	}
+}
+
+func TestCreateSurveyReservedSlugs(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	processor.SetVanityPolicy(vanity.NewPolicy(vanity.Config{Admins: []string{"did:plc:admin"}, Authors: []string{"did:plc:brand"}}, nil))
+	ctx := context.Background()
+
+	namespace := "vanity-" + uuid.NewString()[:8]
+	reservation, err := vanity.New(namespace+"-*", "", "did:plc:admin")
+	if err != nil {
+		t.Fatalf("Failed to build reservation: %v", err)
+	}
+	if err := queries.ReserveSlug(ctx, reservation); err != nil {
+		t.Fatalf("Failed to reserve slugs: %v", err)
+	}
+	defer queries.UnreserveSlug(ctx, reservation.Pattern)
+
+	create := func(repo, rkey string) string {
+		t.Helper()
+		err := processor.ProcessMessage(ctx, &JetstreamMessage{
+			Kind: "commit",
+			Commit: &JetstreamCommit{
+				Operation:  "create",
+				Repo:       repo,
+				Collection: "net.openmeet.survey",
+				RKey:       rkey,
+				CID:        "bafy_" + rkey,
+				Record: map[string]interface{}{
+					"$type": "net.openmeet.survey",
+					"name":  namespace + " lunch",
+					"definition": map[string]interface{}{
+						"questions": []interface{}{
+							map[string]interface{}{"id": "q1", "text": "Comments?", "type": "net.openmeet.survey#text"},
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create survey: %v", err)
+		}
+		survey, err := queries.GetSurveyByURI(ctx, "at://"+repo+"/net.openmeet.survey/"+rkey)
+		if err != nil {
+			t.Fatalf("Failed to get created survey: %v", err)
+		}
+		return survey.Slug
+	}
+
+	// Other authors get a slug outside the reserved namespace
+	if slug := create("did:plc:someone", namespace+"-a"); slug != "survey-"+namespace+"-lunch" {
+		t.Errorf("Expected a slug outside the namespace, got %s", slug)
+	}
+	if slug := create("did:plc:brand", namespace+"-b"); slug != namespace+"-lunch" {
+		t.Errorf("Expected the vanity author to get the reserved slug, got %s", slug)
+	}
+	if slug := create("did:plc:admin", namespace+"-c"); slug != namespace+"-lunch-2" {
+		t.Errorf("Expected the admin to get a reserved slug, got %s", slug)
+	}
+}
+
+func TestInviteOnlyResponses(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+	rkey := uuid.NewString()[:8]
+	surveyURI := "at://did:plc:inviteauthor/net.openmeet.survey/" + rkey
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		URI:       stringPtr(surveyURI),
+		CID:       stringPtr("bafyinvite"),
+		AuthorDID: stringPtr("did:plc:inviteauthor"),
+		Slug:      "test-survey-invite-" + rkey,
+		Title:     "Invite only",
+		Definition: models.SurveyDefinition{
+			Visibility: models.VisibilityInvite,
+			Questions: []models.Question{
+				{ID: "q1", Text: "Comments?", Type: models.QuestionTypeText},
+			},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := queries.CreateSurvey(ctx, survey); err != nil {
+		t.Fatalf("Failed to create test survey: %v", err)
+	}
+	defer database.Exec("DELETE FROM surveys WHERE id = $1", survey.ID)
+
+	inv, _, err := invite.New(survey.ID, "did:plc:invitee", "did:plc:inviteauthor")
+	if err != nil {
+		t.Fatalf("Failed to build invite: %v", err)
+	}
+	if err := queries.CreateInvites(ctx, []*invite.Invite{inv}); err != nil {
+		t.Fatalf("Failed to create invite: %v", err)
+	}
+
+	respond := func(repo string) {
+		t.Helper()
+		err := processor.ProcessMessage(ctx, &JetstreamMessage{
+			Kind: "commit",
+			Commit: &JetstreamCommit{
+				Operation:  "create",
+				Repo:       repo,
+				Collection: "net.openmeet.survey.response",
+				RKey:       rkey,
+				CID:        "bafy" + repo,
+				Record: map[string]interface{}{
+					"$type":   "net.openmeet.survey.response",
+					"subject": map[string]interface{}{"uri": surveyURI, "cid": "bafyinvite"},
+					"answers": []interface{}{
+						map[string]interface{}{"questionId": "q1", "text": "Hello"},
+					},
+					"createdAt": "2026-03-01T12:00:00Z",
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("ProcessMessage failed: %v", err)
+		}
+	}
+
+	// Only invitees' responses are indexed, and they use the invite
+	respond("did:plc:stranger")
+	respond("did:plc:invitee")
+	if n, err := queries.CountResponsesBySurvey(ctx, survey.ID); err != nil || n != 1 {
+		t.Errorf("Expected the invitee's response only, got %d, %v", n, err)
+	}
+	used, err := queries.GetInviteByInvitee(ctx, survey.ID, "did:plc:invitee")
+	if err != nil {
+		t.Fatalf("GetInviteByInvitee failed: %v", err)
+	}
+	if used.UsedAt == nil || used.ResponseID == nil {
+		t.Errorf("Expected the invite to be used by the response, got %+v", used)
+	}
+}
@@ -0,0 +1,191 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+const (
+	// reconnectMinDelay is the backoff before the first reconnect
+	reconnectMinDelay = time.Second
+	// reconnectMaxDelay caps the backoff between reconnects
+	reconnectMaxDelay = 60 * time.Second
+	// stableAfter is how long a connection must last for the backoff to reset
+	stableAfter = time.Minute
+	// pingInterval is how often the stream is pinged
+	pingInterval = 30 * time.Second
+	// pongWait is how long the stream can be silent, without messages or pongs,
+	// before the connection is considered stale
+	pongWait = 75 * time.Second
+)
+
+// Reconnect reasons, the label of survey_jetstream_reconnects_total
+const (
+	reasonDialError       = "dial_error"
+	reasonStale           = "stale"
+	reasonServerClosed    = "server_closed"
+	reasonReadError       = "read_error"
+	reasonProcessingError = "processing_error"
+)
+
+// streamError is an error reading the stream, with the reason to reconnect
+type streamError struct {
+	reason string
+	err    error
+}
+
+func (e *streamError) Error() string { return e.err.Error() }
+func (e *streamError) Unwrap() error { return e.err }
+
+// readError classifies an error reading a message from the stream
+func readError(err error) error {
+	var closeErr *websocket.CloseError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &closeErr):
+		return &streamError{reason: reasonServerClosed, err: fmt.Errorf("stream closed by server: %w", err)}
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return &streamError{reason: reasonStale, err: fmt.Errorf("no message or pong in %v: %w", pongWait, err)}
+	default:
+		return &streamError{reason: reasonReadError, err: fmt.Errorf("error reading message: %w", err)}
+	}
+}
+
+// reconnectReason returns why a client stopped with an error; errors that
+// are not read errors come from processing
+func reconnectReason(err error) string {
+	var streamErr *streamError
+	if errors.As(err, &streamErr) {
+		return streamErr.reason
+	}
+	return reasonProcessingError
+}
+
+// withCursor sets the cursor query parameter of a stream URL, replacing any
+// cursor it has, so the stream resumes after the saved position
+func withCursor(raw string, cursor int64) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid stream URL: %w", err)
+	}
+	query := u.Query()
+	query.Del("cursor")
+	if cursor > 0 {
+		query.Set("cursor", strconv.FormatInt(cursor, 10))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// keepAlive pings the stream every pingInterval and fails reads after pongWait
+// without a message or pong, so a connection that silently died is detected
+// instead of blocking forever. The pings stop once the connection is closed.
+func keepAlive(conn *websocket.Conn) {
+	extendReadDeadline(conn)
+	conn.SetPongHandler(func(string) error {
+		extendReadDeadline(conn)
+		return nil
+	})
+
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(pingInterval)); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// extendReadDeadline gives the stream another pongWait to send something
+func extendReadDeadline(conn *websocket.Conn) {
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+}
+
+// reconnectDelay is the backoff before reconnect attempt (starting at 1):
+// doubling from reconnectMinDelay up to reconnectMaxDelay, of which a random
+// half is jittered away, so consumers that lost the stream together don't
+// reconnect together. jitter is in [0, 1).
+func reconnectDelay(attempt int, jitter float64) time.Duration {
+	backoff := reconnectMinDelay
+	for i := 1; i < attempt && backoff < reconnectMaxDelay; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, reconnectMaxDelay)
+	return backoff/2 + time.Duration(jitter*float64(backoff/2))
+}
+
+// reconnector runs stream clients, reconnecting after errors
+type reconnector struct {
+	jitter func() float64                                  // Random number in [0, 1)
+	sleep  func(ctx context.Context, d time.Duration) bool // Returns false if ctx is cancelled
+	now    func() time.Time
+}
+
+// runWithReconnect runs clients from newClient until ctx is cancelled,
+// reconnecting with jittered exponential backoff on errors. Each client
+// resumes from the saved cursor when it connects.
+func runWithReconnect(ctx context.Context, newClient func() streamClient) error {
+	r := reconnector{jitter: rand.Float64, sleep: sleepContext, now: time.Now}
+	return r.run(ctx, newClient)
+}
+
+func (r reconnector) run(ctx context.Context, newClient func() streamClient) error {
+	attempt := 0
+	for ctx.Err() == nil {
+		client := newClient()
+
+		var reason string
+		var err error
+		if err = client.Connect(ctx); err != nil {
+			reason = reasonDialError
+		} else {
+			connectedAt := r.now()
+			err = client.Run(ctx)
+			client.Close()
+			if err == nil || ctx.Err() != nil {
+				return nil // Clean shutdown
+			}
+			reason = reconnectReason(err)
+			// A connection that lasted was healthy; back off from the start
+			if r.now().Sub(connectedAt) >= stableAfter {
+				attempt = 0
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		attempt++
+		delay := reconnectDelay(attempt, r.jitter())
+		log.Printf("Stream error (%s): %v. Reconnecting in %v...", reason, err, delay.Round(time.Millisecond))
+		telemetry.JetstreamReconnects.WithLabelValues(reason).Inc()
+		if !r.sleep(ctx, delay) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// sleepContext waits for d, returning false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
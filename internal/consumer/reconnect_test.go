@@ -0,0 +1,157 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		jitter   float64
+		expected time.Duration
+	}{
+		{1, 0, 500 * time.Millisecond},
+		{1, 0.999999, time.Second},
+		{2, 0, time.Second},
+		{3, 0.5, 3 * time.Second},
+		{7, 0, 30 * time.Second},
+		{100, 0.5, 45 * time.Second},
+	}
+	for _, tt := range tests {
+		got := reconnectDelay(tt.attempt, tt.jitter).Round(time.Millisecond)
+		if got != tt.expected {
+			t.Errorf("reconnectDelay(%d, %v) = %v, expected %v", tt.attempt, tt.jitter, got, tt.expected)
+		}
+	}
+	if got := reconnectDelay(100, 0.999999); got > reconnectMaxDelay {
+		t.Errorf("Expected the delay to be capped at %v, got %v", reconnectMaxDelay, got)
+	}
+}
+
+func TestWithCursor(t *testing.T) {
+	tests := []struct {
+		url      string
+		cursor   int64
+		expected string
+	}{
+		{"wss://jetstream.example/subscribe?wantedCollections=a&wantedCollections=b", 0, "wss://jetstream.example/subscribe?wantedCollections=a&wantedCollections=b"},
+		{"wss://jetstream.example/subscribe?wantedCollections=a", 1700000000000000, "wss://jetstream.example/subscribe?cursor=1700000000000000&wantedCollections=a"},
+		{"wss://jetstream.example/subscribe?", 42, "wss://jetstream.example/subscribe?cursor=42"},
+		{"wss://jetstream.example/subscribe?cursor=1", 42, "wss://jetstream.example/subscribe?cursor=42"},
+		{"wss://bsky.network/xrpc/com.atproto.sync.subscribeRepos", 7, "wss://bsky.network/xrpc/com.atproto.sync.subscribeRepos?cursor=7"},
+	}
+	for _, tt := range tests {
+		got, err := withCursor(tt.url, tt.cursor)
+		if err != nil {
+			t.Fatalf("withCursor(%q) failed: %v", tt.url, err)
+		}
+		if got != tt.expected {
+			t.Errorf("withCursor(%q, %d) = %q, expected %q", tt.url, tt.cursor, got, tt.expected)
+		}
+	}
+}
+
+func TestReconnectReason(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{readError(&websocket.CloseError{Code: websocket.CloseGoingAway}), reasonServerClosed},
+		{readError(fmt.Errorf("read: %w", os.ErrDeadlineExceeded)), reasonStale},
+		{readError(errors.New("connection reset by peer")), reasonReadError},
+		{fmt.Errorf("failed to retry messages: %w", errors.New("db down")), reasonProcessingError},
+	}
+	for _, tt := range tests {
+		if got := reconnectReason(tt.err); got != tt.expected {
+			t.Errorf("reconnectReason(%v) = %q, expected %q", tt.err, got, tt.expected)
+		}
+	}
+}
+
+// fakeStream is a stream client whose connects and runs fail as scripted
+type fakeStream struct {
+	connectErr error
+	runErr     error
+	run        func()
+	closed     bool
+}
+
+func (s *fakeStream) Connect(ctx context.Context) error { return s.connectErr }
+
+func (s *fakeStream) Run(ctx context.Context) error {
+	if s.run != nil {
+		s.run()
+	}
+	return s.runErr
+}
+
+func (s *fakeStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestReconnector(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	streamErr := readError(errors.New("connection reset"))
+	streams := []*fakeStream{
+		{connectErr: errors.New("dial failed")},
+		{connectErr: errors.New("dial failed")},
+		{runErr: streamErr},                                             // Fails at once; the backoff keeps growing
+		{runErr: streamErr, run: func() { now = now.Add(stableAfter) }}, // Lasted; the backoff resets
+		{run: cancel},
+	}
+	var delays []time.Duration
+	r := reconnector{
+		jitter: func() float64 { return 0 },
+		sleep: func(ctx context.Context, d time.Duration) bool {
+			delays = append(delays, d)
+			return true
+		},
+		now: func() time.Time { return now },
+	}
+
+	next := 0
+	err := r.run(ctx, func() streamClient {
+		s := streams[next]
+		next++
+		return s
+	})
+	if err != nil {
+		t.Fatalf("Expected a clean shutdown, got %v", err)
+	}
+	if next != len(streams) {
+		t.Fatalf("Expected %d connects, got %d", len(streams), next)
+	}
+
+	expected := []time.Duration{reconnectDelay(1, 0), reconnectDelay(2, 0), reconnectDelay(3, 0), reconnectDelay(1, 0)}
+	if fmt.Sprint(delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, delays)
+	}
+	for i, s := range streams[2:] {
+		if !s.closed {
+			t.Errorf("Expected client %d to be closed after running", i+2)
+		}
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	if !sleepContext(context.Background(), time.Millisecond) {
+		t.Error("Expected sleepContext to return true after the delay")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if sleepContext(ctx, time.Hour) {
+		t.Error("Expected sleepContext to return false when cancelled")
+	}
+}
@@ -0,0 +1,264 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/db"
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+const (
+	// MaxRetryAttempts is how many times a message is processed before it is dropped
+	MaxRetryAttempts = 8
+
+	// RetryInterval is how often stream clients retry the messages that are due
+	RetryInterval = 30 * time.Second
+
+	// retryBaseDelay is the delay before the first retry; it doubles with each
+	// failed attempt, up to retryMaxDelay
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 6 * time.Hour
+
+	// retryBatchSize bounds the messages retried at once
+	retryBatchSize = 100
+)
+
+// deferredMessage is a message in the retry queue
+type deferredMessage struct {
+	id       int64
+	msg      *JetstreamMessage
+	attempts int
+}
+
+// retryDelay returns how long to wait after a number of failed attempts
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
+
+// queueRetry stores a message whose processing failed for a later attempt,
+// replacing the queued messages of the same record. A message whose survey is
+// not indexed yet waits for it (see wakeRetries).
+func queueRetry(ctx context.Context, q *db.Queries, msg *JetstreamMessage, cause error) error {
+	message, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	if err := supersedeRetries(ctx, q, msg, 0); err != nil {
+		return err
+	}
+
+	var recordURI, waitingFor *string
+	if msg.Commit != nil {
+		uri := msg.Commit.recordURI()
+		recordURI = &uri
+	}
+	var missing *missingSurveyError
+	if errors.As(cause, &missing) {
+		waitingFor = &missing.uri
+	}
+
+	query := `
+		INSERT INTO consumer_retries (message, last_error, next_attempt_at, record_uri, waiting_for)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := q.GetDB().ExecContext(ctx, query, message, cause.Error(), time.Now().Add(retryDelay(1)), recordURI, waitingFor); err != nil {
+		return fmt.Errorf("failed to queue message for retry: %w", err)
+	}
+	return nil
+}
+
+// supersedeRetries removes the queued messages of msg's record up to the
+// queued message upTo, or all of them if upTo is 0. Records are published
+// whole, so a later message of a record replaces the earlier ones: a create
+// retried after its record was updated or deleted would undo that.
+func supersedeRetries(ctx context.Context, q *db.Queries, msg *JetstreamMessage, upTo int64) error {
+	if msg == nil || msg.Commit == nil {
+		return nil
+	}
+	query := `DELETE FROM consumer_retries WHERE record_uri = $1 AND ($2::bigint = 0 OR id <= $2::bigint)`
+	if _, err := q.GetDB().ExecContext(ctx, query, msg.Commit.recordURI(), upTo); err != nil {
+		return fmt.Errorf("failed to delete superseded retries: %w", err)
+	}
+	return nil
+}
+
+// wakeRetries makes the queued messages waiting for a survey due, now that it
+// is indexed, so they don't wait out their backoff
+func wakeRetries(ctx context.Context, q *db.Queries, surveyURI string) error {
+	query := `
+		UPDATE consumer_retries
+		SET next_attempt_at = NOW()
+		WHERE waiting_for = $1 AND next_attempt_at > NOW()
+	`
+	if _, err := q.GetDB().ExecContext(ctx, query, surveyURI); err != nil {
+		return fmt.Errorf("failed to wake retries: %w", err)
+	}
+	return nil
+}
+
+// listDueRetries returns the queued messages due for a retry, oldest first
+func listDueRetries(ctx context.Context, q *db.Queries, limit int) ([]deferredMessage, error) {
+	query := `
+		SELECT id, message, attempts
+		FROM consumer_retries
+		WHERE next_attempt_at <= NOW()
+		ORDER BY id
+		LIMIT $1
+	`
+
+	rows, err := q.GetDB().QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retries: %w", err)
+	}
+	defer rows.Close()
+
+	var due []deferredMessage
+	for rows.Next() {
+		var d deferredMessage
+		var message []byte
+		if err := rows.Scan(&d.id, &message, &d.attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan retry: %w", err)
+		}
+		if err := json.Unmarshal(message, &d.msg); err != nil {
+			log.Printf("ERROR: Dropping undecodable retry %d: %v", d.id, err)
+			d.msg = nil
+		}
+		due = append(due, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating retries: %w", err)
+	}
+
+	return due, nil
+}
+
+// deleteRetry removes a message from the retry queue
+func deleteRetry(ctx context.Context, q *db.Queries, id int64) error {
+	if _, err := q.GetDB().ExecContext(ctx, `DELETE FROM consumer_retries WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete retry: %w", err)
+	}
+	return nil
+}
+
+// rescheduleRetry counts a failed attempt and schedules the next one
+func rescheduleRetry(ctx context.Context, q *db.Queries, id int64, attempts int, cause error) error {
+	query := `
+		UPDATE consumer_retries
+		SET attempts = $2, last_error = $3, next_attempt_at = $4
+		WHERE id = $1
+	`
+	if _, err := q.GetDB().ExecContext(ctx, query, id, attempts, cause.Error(), time.Now().Add(retryDelay(attempts))); err != nil {
+		return fmt.Errorf("failed to reschedule retry: %w", err)
+	}
+	return nil
+}
+
+// DeferMessageAtSeq queues a message that failed to process for a retry and
+// sets the firehose cursor to seq in one transaction, so the cursor only
+// moves past the message once it is queued
+func (p *Processor) DeferMessageAtSeq(ctx context.Context, msg *JetstreamMessage, cause error, seq int64) error {
+	err := p.processWithCursor(ctx, nil, func(q *db.Queries) error {
+		if err := queueRetry(ctx, q, msg, cause); err != nil {
+			return err
+		}
+		return UpdateFirehoseCursor(ctx, q, seq, msg.TimeUs)
+	})
+	if err == nil {
+		telemetry.ConsumerRetries.WithLabelValues("queued").Inc()
+	}
+	return err
+}
+
+// DeferMessageAtCursor queues a message that failed to process for a retry
+// and advances the Jetstream cursor in one transaction
+func (p *Processor) DeferMessageAtCursor(ctx context.Context, msg *JetstreamMessage, cause error, cursor int64) error {
+	err := p.processWithCursor(ctx, nil, func(q *db.Queries) error {
+		if err := queueRetry(ctx, q, msg, cause); err != nil {
+			return err
+		}
+		return AdvanceCursor(ctx, q, cursor)
+	})
+	if err == nil {
+		telemetry.ConsumerRetries.WithLabelValues("queued").Inc()
+	}
+	return err
+}
+
+// SkipMessageAtSeq sets the firehose cursor to seq past a message that was
+// rejected, without queueing it for a retry
+func (p *Processor) SkipMessageAtSeq(ctx context.Context, msg *JetstreamMessage, seq int64) error {
+	return p.processWithCursor(ctx, nil, func(q *db.Queries) error {
+		return UpdateFirehoseCursor(ctx, q, seq, msg.TimeUs)
+	})
+}
+
+// SkipMessageAtCursor advances the Jetstream cursor past a message that was
+// rejected, without queueing it for a retry
+func (p *Processor) SkipMessageAtCursor(ctx context.Context, cursor int64) error {
+	return p.processWithCursor(ctx, nil, func(q *db.Queries) error {
+		return AdvanceCursor(ctx, q, cursor)
+	})
+}
+
+// RetryDeferred processes the queued messages that are due. A message that
+// fails again is rescheduled with exponential backoff, and dropped after
+// MaxRetryAttempts, or at once if its record is rejected. It stops at the first error writing the queue itself,
+// e.g. when leadership was lost.
+func (p *Processor) RetryDeferred(ctx context.Context) error {
+	due, err := listDueRetries(ctx, p.queries, retryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		if d.msg == nil {
+			if err := p.processWithCursor(ctx, nil, func(q *db.Queries) error { return deleteRetry(ctx, q, d.id) }); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Processing and dequeuing commit together, with the older messages of the record
+		procErr := p.processWithCursor(ctx, d.msg, func(q *db.Queries) error {
+			if err := deleteRetry(ctx, q, d.id); err != nil {
+				return err
+			}
+			return supersedeRetries(ctx, q, d.msg, d.id)
+		})
+		switch {
+		case procErr == nil:
+			telemetry.ConsumerRetries.WithLabelValues("succeeded").Inc()
+			continue
+		case errors.Is(procErr, db.ErrNotLeader) || ctx.Err() != nil:
+			return procErr
+		}
+
+		attempts := d.attempts + 1
+		update := func(q *db.Queries) error { return rescheduleRetry(ctx, q, d.id, attempts, procErr) }
+		event := "failed"
+		if isRejected(procErr) {
+			log.Printf("ERROR: Giving up on rejected message: %v", procErr)
+			update = func(q *db.Queries) error { return deleteRetry(ctx, q, d.id) }
+			event = "abandoned"
+		} else if attempts >= MaxRetryAttempts {
+			log.Printf("ERROR: Giving up on message after %d attempts: %v", attempts, procErr)
+			update = func(q *db.Queries) error { return deleteRetry(ctx, q, d.id) }
+			event = "abandoned"
+		}
+		if err := p.processWithCursor(ctx, nil, update); err != nil {
+			return err
+		}
+		telemetry.ConsumerRetries.WithLabelValues(event).Inc()
+	}
+
+	return nil
+}
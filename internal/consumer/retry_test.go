@@ -0,0 +1,253 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRetryDelay(t *testing.T) {
+	if got := retryDelay(1); got != retryBaseDelay {
+		t.Errorf("Expected %v before the first retry, got %v", retryBaseDelay, got)
+	}
+	if got := retryDelay(3); got != 4*retryBaseDelay {
+		t.Errorf("Expected %v after 3 attempts, got %v", 4*retryBaseDelay, got)
+	}
+	if got := retryDelay(100); got != retryMaxDelay {
+		t.Errorf("Expected the delay to be capped at %v, got %v", retryMaxDelay, got)
+	}
+}
+
+func TestDeferMessageAtSeq(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+	if _, err := database.Exec("DELETE FROM consumer_retries"); err != nil {
+		t.Skipf("Skipping test - retry table not initialized: %v", err)
+	}
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+
+	msg := &JetstreamMessage{
+		Kind:   "commit",
+		TimeUs: time.Now().UnixMicro(),
+		Commit: &JetstreamCommit{
+			Operation:  "create",
+			Repo:       "did:plc:voter",
+			Collection: "net.openmeet.survey.response",
+			RKey:       "retry1",
+			Record:     map[string]interface{}{"subject": map[string]interface{}{"uri": "at://did:plc:nobody/net.openmeet.survey/missing"}},
+		},
+	}
+	if err := processor.DeferMessageAtSeq(ctx, msg, errors.New("survey not found"), 77); err != nil {
+		t.Fatalf("Failed to defer message: %v", err)
+	}
+
+	seq, err := GetFirehoseCursor(ctx, queries)
+	if err != nil {
+		t.Fatalf("Failed to get cursor: %v", err)
+	}
+	if seq != 77 {
+		t.Errorf("Expected the cursor to move past the queued message, got %d", seq)
+	}
+
+	// Make the message due; it fails again and is rescheduled
+	if _, err := database.Exec("UPDATE consumer_retries SET next_attempt_at = NOW() - INTERVAL '1 second'"); err != nil {
+		t.Fatalf("Failed to make retry due: %v", err)
+	}
+	if err := processor.RetryDeferred(ctx); err != nil {
+		t.Fatalf("Failed to retry: %v", err)
+	}
+
+	var attempts int
+	var next time.Time
+	if err := database.QueryRow("SELECT attempts, next_attempt_at FROM consumer_retries").Scan(&attempts, &next); err != nil {
+		t.Fatalf("Expected the message to stay queued: %v", err)
+	}
+	if attempts != 2 || !next.After(time.Now()) {
+		t.Errorf("Expected a second attempt scheduled in the future, got attempts=%d next=%v", attempts, next)
+	}
+
+	// The last attempt drops it
+	if _, err := database.Exec("UPDATE consumer_retries SET attempts = $1, next_attempt_at = NOW() - INTERVAL '1 second'", MaxRetryAttempts-1); err != nil {
+		t.Fatalf("Failed to make retry due: %v", err)
+	}
+	if err := processor.RetryDeferred(ctx); err != nil {
+		t.Fatalf("Failed to retry: %v", err)
+	}
+	var count int
+	database.QueryRow("SELECT COUNT(*) FROM consumer_retries").Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected the message to be dropped after %d attempts, %d left", MaxRetryAttempts, count)
+	}
+}
+
+// surveyMessage returns a message creating or updating a survey record
+func surveyMessage(operation, repo, rkey string) *JetstreamMessage {
+	return &JetstreamMessage{
+		Kind:   "commit",
+		TimeUs: time.Now().UnixMicro(),
+		Commit: &JetstreamCommit{
+			Operation:  operation,
+			Repo:       repo,
+			Collection: "net.openmeet.survey",
+			RKey:       rkey,
+			CID:        "bafysurvey" + rkey,
+			Record: map[string]interface{}{
+				"$type": "net.openmeet.survey",
+				"name":  "Late Survey " + rkey,
+				"definition": map[string]interface{}{
+					"questions": []interface{}{
+						map[string]interface{}{
+							"id":       "q1",
+							"text":     "Ready?",
+							"type":     "net.openmeet.survey#single",
+							"required": true,
+							"options": []interface{}{
+								map[string]interface{}{"id": "yes", "text": "Yes"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// responseMessage returns a message of a response record answering a survey
+func responseMessage(operation, repo, rkey, surveyURI string) *JetstreamMessage {
+	msg := &JetstreamMessage{
+		Kind:   "commit",
+		TimeUs: time.Now().UnixMicro(),
+		Commit: &JetstreamCommit{
+			Operation:  operation,
+			Repo:       repo,
+			Collection: "net.openmeet.survey.response",
+			RKey:       rkey,
+		},
+	}
+	if operation != "delete" {
+		msg.Commit.CID = "bafyresponse" + rkey
+		msg.Commit.Record = map[string]interface{}{
+			"$type":     "net.openmeet.survey.response",
+			"subject":   map[string]interface{}{"uri": surveyURI},
+			"answers":   []interface{}{map[string]interface{}{"questionId": "q1", "selected": []interface{}{"yes"}}},
+			"createdAt": time.Now().Format(time.RFC3339),
+		}
+	}
+	return msg
+}
+
+func TestRetryWaitsForSurvey(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+	if _, err := database.Exec("DELETE FROM consumer_retries"); err != nil {
+		t.Skipf("Skipping test - retry table not initialized: %v", err)
+	}
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+	rkey := uuid.NewString()[:8]
+	author := "did:plc:lateauthor"
+	surveyURI := "at://" + author + "/net.openmeet.survey/" + rkey
+	defer database.Exec("DELETE FROM surveys WHERE uri = $1", surveyURI)
+
+	// The response arrives before its survey
+	response := responseMessage("create", "did:plc:earlyvoter", rkey, surveyURI)
+	err := processor.ProcessMessageAtCursor(ctx, response, response.TimeUs)
+	var missing *missingSurveyError
+	if !errors.As(err, &missing) {
+		t.Fatalf("Expected a missing survey error, got %v", err)
+	}
+	if err := processor.DeferMessageAtCursor(ctx, response, err, response.TimeUs); err != nil {
+		t.Fatalf("Failed to defer message: %v", err)
+	}
+
+	var waitingFor string
+	if err := database.QueryRow("SELECT waiting_for FROM consumer_retries").Scan(&waitingFor); err != nil {
+		t.Fatalf("Expected the message to be queued: %v", err)
+	}
+	if waitingFor != surveyURI {
+		t.Errorf("Expected the message to wait for %s, got %s", surveyURI, waitingFor)
+	}
+
+	// An update of the survey is its first message here; it is indexed as a create
+	update := surveyMessage("update", author, rkey)
+	if err := processor.ProcessMessageAtCursor(ctx, update, update.TimeUs); err != nil {
+		t.Fatalf("Expected an update before create to index the survey: %v", err)
+	}
+
+	// The waiting response is due at once
+	var due bool
+	if err := database.QueryRow("SELECT next_attempt_at <= NOW() FROM consumer_retries").Scan(&due); err != nil {
+		t.Fatalf("Expected the message to stay queued: %v", err)
+	}
+	if !due {
+		t.Error("Expected the waiting message to be due once its survey is indexed")
+	}
+
+	if err := processor.RetryDeferred(ctx); err != nil {
+		t.Fatalf("Failed to retry: %v", err)
+	}
+	indexed, err := queries.GetResponseByRecordURI(ctx, response.Commit.recordURI())
+	if err != nil || indexed == nil {
+		t.Fatalf("Expected the response to be indexed, got %v (%v)", indexed, err)
+	}
+	var count int
+	database.QueryRow("SELECT COUNT(*) FROM consumer_retries").Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected no queued messages, %d left", count)
+	}
+}
+
+func TestSupersededRetries(t *testing.T) {
+	database, queries := setupTestDB(t)
+	defer database.Close()
+	if _, err := database.Exec("DELETE FROM consumer_retries"); err != nil {
+		t.Skipf("Skipping test - retry table not initialized: %v", err)
+	}
+
+	processor := NewProcessor(queries)
+	ctx := context.Background()
+	rkey := uuid.NewString()[:8]
+	surveyURI := "at://did:plc:nobody/net.openmeet.survey/" + rkey
+
+	queued := func() []string {
+		rows, err := database.Query("SELECT message->'commit'->>'operation' FROM consumer_retries ORDER BY id")
+		if err != nil {
+			t.Fatalf("Failed to list retries: %v", err)
+		}
+		defer rows.Close()
+		var operations []string
+		for rows.Next() {
+			var operation string
+			rows.Scan(&operation)
+			operations = append(operations, operation)
+		}
+		return operations
+	}
+
+	create := responseMessage("create", "did:plc:voter", rkey, surveyURI)
+	if err := processor.DeferMessageAtCursor(ctx, create, &missingSurveyError{uri: surveyURI}, create.TimeUs); err != nil {
+		t.Fatalf("Failed to defer message: %v", err)
+	}
+	update := responseMessage("update", "did:plc:voter", rkey, surveyURI)
+	if err := processor.DeferMessageAtCursor(ctx, update, &missingSurveyError{uri: surveyURI}, update.TimeUs); err != nil {
+		t.Fatalf("Failed to defer message: %v", err)
+	}
+	if got := queued(); len(got) != 1 || got[0] != "update" {
+		t.Errorf("Expected the update to replace the queued create, got %v", got)
+	}
+
+	// The record is deleted; its queued update must not bring it back
+	del := responseMessage("delete", "did:plc:voter", rkey, "")
+	if err := processor.ProcessMessageAtCursor(ctx, del, del.TimeUs); err != nil {
+		t.Fatalf("Expected a delete of an unindexed record to succeed: %v", err)
+	}
+	if got := queued(); len(got) != 0 {
+		t.Errorf("Expected the delete to replace the queued update, got %v", got)
+	}
+}
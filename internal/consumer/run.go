@@ -0,0 +1,97 @@
+package consumer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/db"
+	"github.com/openmeet-team/survey/internal/identity"
+	"github.com/openmeet-team/survey/internal/interop"
+	"github.com/openmeet-team/survey/internal/moderation"
+	"github.com/openmeet-team/survey/internal/vanity"
+	"github.com/openmeet-team/survey/lexicon"
+)
+
+// RunFromEnv consumes Jetstream, or the relay firehose with
+// CONSUMER_SOURCE=firehose, into database with the options the environment
+// configures. Only the instance holding the consumer leader lock consumes;
+// others wait on standby. It returns when ctx is cancelled or consuming fails.
+func RunFromEnv(ctx context.Context, database *sql.DB, queries *db.Queries) error {
+	// Foreign poll lexicons indexed as read-only surveys
+	pollLexicons := interop.LexiconsFromEnv()
+	for _, l := range pollLexicons {
+		log.Printf("Indexing foreign poll lexicon: %s (votes: %q)", l.Poll, l.Vote)
+	}
+
+	// Bluesky posts of opted-in authors indexed as polls, voted on by replying
+	pollAuthors := interop.BlueskyPollAuthorsFromEnv()
+	if len(pollAuthors) > 0 {
+		log.Printf("Indexing Bluesky poll posts of %d opted-in authors", len(pollAuthors))
+	}
+
+	// Text answer moderation (blocklist and optional OpenAI moderation API)
+	moderator := moderation.NewFromConfig(moderation.ConfigFromEnv())
+	if moderator.Enabled() {
+		log.Println("Text answer moderation enabled")
+	}
+
+	// Lexicon validation of incoming records
+	validator, err := lexicon.NewValidator()
+	if err != nil {
+		return fmt.Errorf("failed to load lexicons: %w", err)
+	}
+	validationMode := ValidationModeFromEnv()
+	log.Printf("Lexicon validation mode: %s", validationMode)
+
+	// Invalidate cached surveys and results when indexed records change
+	// (only useful with a cache shared with the API, i.e. the redis backend)
+	cacheStore, err := cache.NewFromConfig(cache.ConfigFromEnv())
+	if err != nil {
+		return fmt.Errorf("failed to configure cache: %w", err)
+	}
+
+	opts := ProcessorOptions{
+		Moderator:      moderator,
+		Validator:      validator,
+		ValidationMode: validationMode,
+		PollLexicons:   pollLexicons,
+		PollAuthors:    pollAuthors,
+		VanityPolicy:   vanity.NewPolicy(vanity.ConfigFromEnv(), identity.NewVerifier(queries, identity.TTLFromEnv())),
+		Cache:          cacheStore,
+		Workers:        WorkersFromEnv(),
+	}
+
+	// Build Jetstream URL
+	// Subscribe to survey, response, and results collections plus foreign poll lexicons
+	// and, for Bluesky polls, posts
+	// Note: Jetstream requires repeated query params, not comma-separated values
+	params := url.Values{}
+	for _, collection := range opts.Collections() {
+		params.Add("wantedCollections", collection)
+	}
+	jetstreamURL := "wss://jetstream2.us-east.bsky.network/subscribe?" + params.Encode()
+
+	// Read Jetstream, or the raw relay firehose (CONSUMER_SOURCE=firehose)
+	source := SourceFromEnv()
+	firehoseURL := os.Getenv("FIREHOSE_URL")
+	if firehoseURL == "" {
+		firehoseURL = DefaultFirehoseURL
+	}
+	log.Printf("Consumer source: %s", source)
+
+	// Only the instance holding the leader lock consumes; other replicas wait on standby
+	leaderLock := db.NewLeaderLock(database, db.ConsumerLeaderLockID)
+	opts.Fence = leaderLock.Fence
+
+	return RunAsLeader(ctx, leaderLock, DefaultLeaderCheckInterval, func(ctx context.Context) error {
+		if source == SourceFirehose {
+			return RunFirehoseWithReconnect(ctx, firehoseURL, queries, opts)
+		}
+		return RunWithReconnect(ctx, jetstreamURL, queries, opts)
+	})
+}
@@ -0,0 +1,146 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/db"
+	"github.com/openmeet-team/survey/internal/interop"
+	"github.com/openmeet-team/survey/internal/moderation"
+	"github.com/openmeet-team/survey/internal/telemetry"
+	"github.com/openmeet-team/survey/internal/vanity"
+	"github.com/openmeet-team/survey/lexicon"
+)
+
+// ValidationMode controls what happens to records that violate their lexicon
+type ValidationMode string
+
+const (
+	// ValidationOff skips schema validation
+	ValidationOff ValidationMode = "off"
+	// ValidationPermissive logs violations and indexes the record anyway
+	ValidationPermissive ValidationMode = "permissive"
+	// ValidationStrict rejects records that violate their lexicon
+	ValidationStrict ValidationMode = "strict"
+)
+
+// ValidationModeFromEnv returns the lexicon validation mode
+// Environment variables:
+//   - LEXICON_VALIDATION: "strict", "permissive", or "off" (default: permissive)
+func ValidationModeFromEnv() ValidationMode {
+	switch mode := ValidationMode(os.Getenv("LEXICON_VALIDATION")); mode {
+	case ValidationOff, ValidationPermissive, ValidationStrict:
+		return mode
+	case "":
+		return ValidationPermissive
+	default:
+		log.Printf("Warning: Unknown LEXICON_VALIDATION %q, using %s", mode, ValidationPermissive)
+		return ValidationPermissive
+	}
+}
+
+// ProcessorOptions configures optional processing steps of the consumer
+type ProcessorOptions struct {
+	Moderator      *moderation.Moderator // Flags text answers of indexed responses (may be nil)
+	Validator      *lexicon.Validator    // Validates records against their lexicon (may be nil)
+	ValidationMode ValidationMode
+	PollLexicons   []interop.Lexicon // Foreign poll lexicons indexed read-only
+	PollAuthors    []string          // DIDs whose Bluesky poll posts are indexed read-only
+	VanityPolicy   *vanity.Policy    // Who may get reserved slugs for indexed surveys (may be nil)
+	Cache          *cache.Store      // Invalidated when indexed records change (may be nil)
+	Workers        int               // Jetstream messages processed concurrently (default DefaultWorkers)
+
+	// Fence is checked inside each processing transaction before anything is
+	// written, to stop an instance that lost consumer leadership (may be nil)
+	Fence func(ctx context.Context, q db.Querier) error
+}
+
+// configure applies the options to a processor
+func (o ProcessorOptions) configure(p *Processor) {
+	p.SetModerator(o.Moderator)
+	p.SetValidator(o.Validator, o.ValidationMode)
+	p.SetPollLexicons(o.PollLexicons)
+	p.SetBlueskyPollAuthors(o.PollAuthors)
+	p.SetVanityPolicy(o.VanityPolicy)
+	p.SetCache(o.Cache)
+	p.fence = o.Fence
+}
+
+// Collections returns the collections to subscribe to: those of
+// WantedCollections, plus Bluesky posts if polls are ingested from them
+func (o ProcessorOptions) Collections() []string {
+	collections := WantedCollections(o.PollLexicons)
+	if len(o.PollAuthors) > 0 {
+		collections = append(collections, interop.PostCollection)
+	}
+	return collections
+}
+
+// SetValidator sets the lexicon validator and what to do with invalid records
+func (p *Processor) SetValidator(v *lexicon.Validator, mode ValidationMode) {
+	p.validator = v
+	p.validationMode = mode
+}
+
+// rejectedRecordError is returned for records rejected by strict lexicon
+// validation. Rejections are permanent: the record would be rejected again,
+// so it is skipped rather than queued for a retry.
+type rejectedRecordError struct {
+	uri string
+	err error
+}
+
+func (e *rejectedRecordError) Error() string {
+	return fmt.Sprintf("record %s rejected by lexicon validation: %v", e.uri, e.err)
+}
+
+func (e *rejectedRecordError) Unwrap() error {
+	return e.err
+}
+
+// isRejected reports whether a message failed to process because its record
+// was rejected by lexicon validation
+func isRejected(err error) bool {
+	var rejected *rejectedRecordError
+	return errors.As(err, &rejected)
+}
+
+// validateRecord checks a created or updated record against its lexicon.
+// In strict mode violations are returned as a *rejectedRecordError so the
+// record is not indexed; in permissive mode they are logged and the record is
+// indexed anyway.
+func (p *Processor) validateRecord(commit *JetstreamCommit) error {
+	if p.validator == nil || p.validationMode == ValidationOff || commit.Record == nil {
+		return nil
+	}
+	if !p.validator.HasSchema(commit.Collection) {
+		return nil
+	}
+
+	err := p.validator.ValidateRecord(commit.Collection, commit.Record)
+	if err == nil {
+		return nil
+	}
+
+	uri := fmt.Sprintf("at://%s/%s/%s", commit.Repo, commit.Collection, commit.RKey)
+
+	var validationErr *lexicon.ValidationError
+	if !errors.As(err, &validationErr) {
+		// Problem with the schema itself, not the record
+		log.Printf("Lexicon validation of %s failed: %v", uri, err)
+		return nil
+	}
+
+	if p.validationMode == ValidationStrict {
+		telemetry.JetstreamSchemaViolations.WithLabelValues(commit.Collection, "rejected").Inc()
+		return &rejectedRecordError{uri: uri, err: err}
+	}
+
+	telemetry.JetstreamSchemaViolations.WithLabelValues(commit.Collection, "logged").Inc()
+	log.Printf("Lexicon violation in %s (indexed in permissive mode): %v", uri, err)
+	return nil
+}
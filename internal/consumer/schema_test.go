@@ -0,0 +1,108 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openmeet-team/survey/lexicon"
+)
+
+func TestValidationModeFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  ValidationMode
+	}{
+		{"", ValidationPermissive},
+		{"strict", ValidationStrict},
+		{"permissive", ValidationPermissive},
+		{"off", ValidationOff},
+		{"bogus", ValidationPermissive},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("LEXICON_VALIDATION", tt.value)
+		if got := ValidationModeFromEnv(); got != tt.want {
+			t.Errorf("LEXICON_VALIDATION=%q: got %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+// invalidSurveyCommit returns a survey create missing its required questions
+func invalidSurveyCommit() *JetstreamCommit {
+	return &JetstreamCommit{
+		Operation:  "create",
+		Collection: "net.openmeet.survey",
+		RKey:       "abc123",
+		Repo:       "did:plc:test123",
+		Record: map[string]interface{}{
+			"$type":     "net.openmeet.survey",
+			"name":      "No questions",
+			"createdAt": "2026-01-02T10:00:00Z",
+		},
+	}
+}
+
+func TestValidateRecord(t *testing.T) {
+	validator, err := lexicon.NewValidator()
+	if err != nil {
+		t.Fatalf("failed to load lexicons: %v", err)
+	}
+
+	t.Run("strict rejects violations", func(t *testing.T) {
+		p := NewProcessor(nil)
+		p.SetValidator(validator, ValidationStrict)
+
+		err := p.validateRecord(invalidSurveyCommit())
+		if !isRejected(err) {
+			t.Errorf("expected a permanent rejection, got %v", err)
+		}
+		var validationErr *lexicon.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("expected lexicon.ValidationError, got %v", err)
+		}
+		if validationErr.Path != "questions" {
+			t.Errorf("expected violation at questions, got %q", validationErr.Path)
+		}
+	})
+
+	t.Run("permissive allows violations", func(t *testing.T) {
+		p := NewProcessor(nil)
+		p.SetValidator(validator, ValidationPermissive)
+
+		if err := p.validateRecord(invalidSurveyCommit()); err != nil {
+			t.Errorf("expected no error in permissive mode, got %v", err)
+		}
+	})
+
+	t.Run("off skips validation", func(t *testing.T) {
+		p := NewProcessor(nil)
+		p.SetValidator(validator, ValidationOff)
+
+		if err := p.validateRecord(invalidSurveyCommit()); err != nil {
+			t.Errorf("expected no error with validation off, got %v", err)
+		}
+	})
+
+	t.Run("collections without lexicon are skipped", func(t *testing.T) {
+		p := NewProcessor(nil)
+		p.SetValidator(validator, ValidationStrict)
+
+		commit := invalidSurveyCommit()
+		commit.Collection = "app.bsky.feed.post"
+		if err := p.validateRecord(commit); err != nil {
+			t.Errorf("expected no error for unknown collection, got %v", err)
+		}
+	})
+
+	t.Run("strict rejects before indexing", func(t *testing.T) {
+		// Queries are nil, so reaching the database would panic
+		p := NewProcessor(nil)
+		p.SetValidator(validator, ValidationStrict)
+
+		msg := &JetstreamMessage{Did: "did:plc:test123", Kind: "commit", Commit: invalidSurveyCommit()}
+		if err := p.ProcessMessage(context.Background(), msg); err == nil {
+			t.Error("expected invalid record to be rejected")
+		}
+	})
+}
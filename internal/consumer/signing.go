@@ -0,0 +1,94 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/firehose"
+	"github.com/openmeet-team/survey/internal/identity"
+)
+
+const (
+	// signingKeyTTL is how long a repository's signing key is cached
+	signingKeyTTL = time.Hour
+
+	// signingKeyCacheSize bounds the number of cached signing keys
+	signingKeyCacheSize = 10000
+)
+
+// errKeyUnavailable means a repository's signing key could not be fetched
+// from the PLC directory. It is transient: the commit is read again later
+// instead of being skipped.
+var errKeyUnavailable = errors.New("signing key unavailable")
+
+// signingKeys resolves the keys repositories sign their commits with, from
+// their DID documents
+type signingKeys struct {
+	plcURL string
+	client *http.Client
+	cache  *cache.Memory
+}
+
+// newSigningKeys creates a resolver caching keys in memory
+func newSigningKeys() *signingKeys {
+	return &signingKeys{
+		plcURL: identity.DefaultPLCURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+		cache:  cache.NewMemory(signingKeyCacheSize),
+	}
+}
+
+// verify checks that a commit is signed by its repository. A cached key that
+// does not verify the commit is fetched again, as the repository may have
+// rotated its key.
+func (k *signingKeys) verify(ctx context.Context, commit *firehose.Commit) error {
+	key, cached, err := k.key(ctx, commit.Repo, false)
+	if err != nil {
+		return err
+	}
+	err = commit.VerifySignature(key)
+	if errors.Is(err, firehose.ErrBadSignature) && cached {
+		if key, _, err = k.key(ctx, commit.Repo, true); err != nil {
+			return err
+		}
+		err = commit.VerifySignature(key)
+	}
+	return err
+}
+
+// key returns the signing key of a DID and whether it came from the cache
+func (k *signingKeys) key(ctx context.Context, did string, refresh bool) (*firehose.PublicKey, bool, error) {
+	if !refresh {
+		if multibase, ok, _ := k.cache.Get(ctx, did); ok {
+			key, err := firehose.ParsePublicKey(string(multibase))
+			return key, true, err
+		}
+	}
+
+	doc, err := identity.FetchDocument(ctx, k.client, k.plcURL, did)
+	switch {
+	case err == nil:
+	case strings.HasPrefix(did, "did:plc:") && !errors.Is(err, identity.ErrDocumentNotFound):
+		// The directory is shared by most repositories; an outage must not drop their records
+		return nil, false, fmt.Errorf("%w for %s: %v", errKeyUnavailable, did, err)
+	default:
+		// A did:web host serves only its own repository
+		return nil, false, err
+	}
+
+	multibase := doc.SigningKey()
+	if multibase == "" {
+		return nil, false, fmt.Errorf("DID document of %s has no signing key", did)
+	}
+	key, err := firehose.ParsePublicKey(multibase)
+	if err != nil {
+		return nil, false, fmt.Errorf("signing key of %s: %w", did, err)
+	}
+	k.cache.Set(ctx, did, []byte(multibase), signingKeyTTL)
+	return key, false, nil
+}
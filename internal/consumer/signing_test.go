@@ -0,0 +1,52 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSigningKeys_Key(t *testing.T) {
+	const key = "zQ3shXjHeiBuRCKmM36cuYnm7YEMzhGnCmCyW92sRJ9pribSF"
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		switch r.URL.Path {
+		case "/did:plc:good":
+			fmt.Fprintf(w, `{"id": "did:plc:good", "verificationMethod": [{"id": "did:plc:good#atproto", "publicKeyMultibase": %q}]}`, key)
+		case "/did:plc:down":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	keys := newSigningKeys()
+	keys.plcURL = server.URL
+	ctx := context.Background()
+
+	_, cached, err := keys.key(ctx, "did:plc:good", false)
+	if err != nil || cached {
+		t.Fatalf("Expected a fetched key, got cached=%v err=%v", cached, err)
+	}
+	_, cached, err = keys.key(ctx, "did:plc:good", false)
+	if err != nil || !cached || fetches != 1 {
+		t.Fatalf("Expected the cached key, got cached=%v err=%v fetches=%d", cached, err, fetches)
+	}
+	if _, _, err := keys.key(ctx, "did:plc:good", true); err != nil || fetches != 2 {
+		t.Fatalf("Expected a refresh to refetch, got err=%v fetches=%d", err, fetches)
+	}
+
+	// A directory outage is transient; a missing DID is not
+	if _, _, err := keys.key(ctx, "did:plc:down", false); !errors.Is(err, errKeyUnavailable) {
+		t.Errorf("Expected errKeyUnavailable, got: %v", err)
+	}
+	_, _, err = keys.key(ctx, "did:plc:missing", false)
+	if err == nil || errors.Is(err, errKeyUnavailable) {
+		t.Errorf("Expected a permanent error, got: %v", err)
+	}
+}
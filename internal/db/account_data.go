@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// ListSurveysByAuthor retrieves all surveys created by a DID, oldest first
+func (q *Queries) ListSurveysByAuthor(ctx context.Context, authorDID string) ([]*models.Survey, error) {
+	query := `
+		SELECT id, uri, cid, author_did, slug, title, description, definition, version, starts_at, ends_at, results_uri, results_cid, created_at, updated_at, hidden_at, org_id
+		FROM surveys
+		WHERE author_did = $1 AND deleted_at IS NULL
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, authorDID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query surveys: %w", err)
+	}
+	defer rows.Close()
+
+	var surveys []*models.Survey
+	for rows.Next() {
+		survey := &models.Survey{}
+		var defJSON []byte
+
+		err := rows.Scan(
+			&survey.ID,
+			&survey.URI,
+			&survey.CID,
+			&survey.AuthorDID,
+			&survey.Slug,
+			&survey.Title,
+			&survey.Description,
+			&defJSON,
+			&survey.Version,
+			&survey.StartsAt,
+			&survey.EndsAt,
+			&survey.ResultsURI,
+			&survey.ResultsCID,
+			&survey.CreatedAt,
+			&survey.UpdatedAt,
+			&survey.HiddenAt,
+			&survey.OrgID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan survey: %w", err)
+		}
+
+		if err := json.Unmarshal(defJSON, &survey.Definition); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal survey definition: %w", err)
+		}
+
+		surveys = append(surveys, survey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating surveys: %w", err)
+	}
+
+	return surveys, nil
+}
+
+// ListResponsesByVoter retrieves all responses submitted by a DID, oldest first,
+// including those in survey archives
+func (q *Queries) ListResponsesByVoter(ctx context.Context, voterDID string) ([]*models.Response, error) {
+	query := `
+		SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, survey_version, created_at
+		FROM (
+			SELECT * FROM responses WHERE voter_did = $1
+			UNION ALL
+			SELECT r.* FROM survey_archives a CROSS JOIN LATERAL jsonb_populate_recordset(NULL::responses, a.responses) r
+			WHERE r.voter_did = $1
+		) responses
+		ORDER BY created_at ASC, id ASC
+	`
+	if q.dialect == dialectSQLite {
+		query = `
+			SELECT ` + strings.Join(responseColumns, ", ") + ` FROM responses WHERE voter_did = $1
+			UNION ALL
+			SELECT ` + sqliteRecordColumns("r", responseColumns) + `
+			FROM survey_archives a, json_each(a.responses) r
+			WHERE r.value->>'voter_did' = $1
+			ORDER BY created_at ASC, id ASC
+		`
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, voterDID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer rows.Close()
+
+	var responses []*models.Response
+	for rows.Next() {
+		response := &models.Response{}
+		var answersJSON []byte
+
+		err := rows.Scan(
+			&response.ID,
+			&response.SurveyID,
+			&response.VoterDID,
+			&response.VoterSession,
+			&response.RecordURI,
+			&response.RecordCID,
+			&answersJSON,
+			&response.SurveyVersion,
+			&response.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan response: %w", err)
+		}
+
+		if err := json.Unmarshal(answersJSON, &response.Answers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response answers: %w", err)
+		}
+
+		responses = append(responses, response)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating responses: %w", err)
+	}
+
+	return responses, nil
+}
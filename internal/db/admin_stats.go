@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/adminstats"
+)
+
+// GetDailyActivity implements the adminstats.Store interface
+// Counts surveys, responses, voters, and AI generations by UTC day
+func (q *Queries) GetDailyActivity(ctx context.Context, since time.Time) ([]*adminstats.Day, error) {
+	if q.replicas != nil {
+		return readFromReplica(ctx, q, func(r *Queries) ([]*adminstats.Day, error) { return r.GetDailyActivity(ctx, since) })
+	}
+
+	query := `
+		SELECT day, SUM(surveys), SUM(responses), SUM(voters), SUM(generations), SUM(cost_usd)
+		FROM (
+			SELECT (created_at AT TIME ZONE 'UTC')::date AS day,
+				COUNT(*) AS surveys, 0 AS responses, 0 AS voters, 0 AS generations, 0 AS cost_usd
+			FROM surveys
+			WHERE created_at >= $1 AND deleted_at IS NULL
+			GROUP BY 1
+			UNION ALL
+			SELECT (created_at AT TIME ZONE 'UTC')::date, 0, COUNT(*), COUNT(DISTINCT COALESCE(voter_did, voter_session)), 0, 0
+			FROM responses
+			WHERE created_at >= $1
+			GROUP BY 1
+			UNION ALL
+			SELECT (created_at AT TIME ZONE 'UTC')::date, 0, 0, 0, COUNT(*), COALESCE(SUM(cost_usd), 0)
+			FROM ai_generation_logs
+			WHERE created_at >= $1
+			GROUP BY 1
+		) activity
+		GROUP BY day
+		ORDER BY day
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily activity: %w", err)
+	}
+	defer rows.Close()
+
+	var days []*adminstats.Day
+	for rows.Next() {
+		d := &adminstats.Day{}
+		if err := rows.Scan(&d.Day, &d.Surveys, &d.Responses, &d.Voters, &d.Generations, &d.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan daily activity: %w", err)
+		}
+		d.Day = d.Day.UTC()
+		days = append(days, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating daily activity: %w", err)
+	}
+
+	return days, nil
+}
+
+// GetTopSurveys implements the adminstats.Store interface
+// Surveys in the trash are skipped
+func (q *Queries) GetTopSurveys(ctx context.Context, since time.Time, limit int) ([]*adminstats.TopSurvey, error) {
+	if q.replicas != nil {
+		return readFromReplica(ctx, q, func(r *Queries) ([]*adminstats.TopSurvey, error) { return r.GetTopSurveys(ctx, since, limit) })
+	}
+
+	query := `
+		SELECT s.id, s.slug, s.title, COUNT(*) AS responses
+		FROM responses r
+		JOIN surveys s ON s.id = r.survey_id AND s.deleted_at IS NULL
+		WHERE r.created_at >= $1
+		GROUP BY s.id
+		ORDER BY responses DESC, s.id
+		LIMIT $2
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top surveys: %w", err)
+	}
+	defer rows.Close()
+
+	var surveys []*adminstats.TopSurvey
+	for rows.Next() {
+		s := &adminstats.TopSurvey{}
+		if err := rows.Scan(&s.ID, &s.Slug, &s.Title, &s.Responses); err != nil {
+			return nil, fmt.Errorf("failed to scan top survey: %w", err)
+		}
+		surveys = append(surveys, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating top surveys: %w", err)
+	}
+
+	return surveys, nil
+}
+
+// GetDatabaseSize implements the adminstats.Store interface
+// Sizes are of the primary, and include indexes and TOAST data
+func (q *Queries) GetDatabaseSize(ctx context.Context, tables int) (*adminstats.Database, error) {
+	if q.dialect == dialectSQLite {
+		return q.getDatabaseSizeSQLite(ctx, tables)
+	}
+
+	database := &adminstats.Database{Tables: []*adminstats.Table{}}
+	if err := q.db.QueryRowContext(ctx, `SELECT pg_database_size(current_database())`).Scan(&database.Bytes); err != nil {
+		return nil, fmt.Errorf("failed to get database size: %w", err)
+	}
+
+	query := `
+		SELECT c.relname, pg_total_relation_size(c.oid), GREATEST(c.reltuples, 0)::bigint
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND n.nspname = current_schema()
+		ORDER BY 2 DESC, c.relname
+		LIMIT $1
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table sizes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t := &adminstats.Table{}
+		if err := rows.Scan(&t.Name, &t.Bytes, &t.Rows); err != nil {
+			return nil, fmt.Errorf("failed to scan table size: %w", err)
+		}
+		database.Tables = append(database.Tables, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating table sizes: %w", err)
+	}
+
+	return database, nil
+}
+
+// getDatabaseSizeSQLite sizes a SQLite database by its pages. SQLite keeps no
+// sizes of tables, so tables have exact row counts and are ordered by them.
+func (q *Queries) getDatabaseSizeSQLite(ctx context.Context, tables int) (*adminstats.Database, error) {
+	database := &adminstats.Database{Tables: []*adminstats.Table{}}
+	query := `SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`
+	if err := q.db.QueryRowContext(ctx, query).Scan(&database.Bytes); err != nil {
+		return nil, fmt.Errorf("failed to get database size: %w", err)
+	}
+
+	rows, err := q.db.QueryContext(ctx, `SELECT name FROM sqlite_schema WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t := &adminstats.Table{}
+		if err := rows.Scan(&t.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		database.Tables = append(database.Tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tables: %w", err)
+	}
+	rows.Close()
+
+	for _, t := range database.Tables {
+		if err := q.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM "`+t.Name+`"`).Scan(&t.Rows); err != nil {
+			return nil, fmt.Errorf("failed to count rows of %s: %w", t.Name, err)
+		}
+	}
+	sort.SliceStable(database.Tables, func(i, j int) bool { return database.Tables[i].Rows > database.Tables[j].Rows })
+	if len(database.Tables) > tables {
+		database.Tables = database.Tables[:tables]
+	}
+
+	return database, nil
+}
@@ -0,0 +1,74 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/adminstats"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminStats(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	since := adminstats.Since(now, 7)
+
+	newSurvey := func(slug string) *models.Survey {
+		survey := &models.Survey{
+			ID:         uuid.New(),
+			Slug:       slug,
+			Title:      slug,
+			Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}}},
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		require.NoError(t, queries.CreateSurvey(ctx, survey))
+		return survey
+	}
+	respond := func(survey *models.Survey, n int, at time.Time) {
+		for i := 0; i < n; i++ {
+			session := fmt.Sprintf("%s-%d-%d", survey.Slug, at.UnixNano(), i)
+			require.NoError(t, queries.CreateResponse(ctx, &models.Response{
+				ID:           uuid.New(),
+				SurveyID:     survey.ID,
+				VoterSession: &session,
+				Answers:      map[string]models.Answer{"q1": {Text: "Because"}},
+				CreatedAt:    at,
+			}))
+		}
+	}
+
+	popular := newSurvey("popular")
+	quiet := newSurvey("quiet")
+	respond(popular, 3, now)
+	respond(quiet, 1, now)
+	respond(quiet, 5, since.Add(-time.Hour)) // Before the period
+
+	days, err := queries.GetDailyActivity(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, days, 1)
+	assert.Equal(t, 2, days[0].Surveys)
+	assert.Equal(t, 4, days[0].Responses)
+	assert.Equal(t, 4, days[0].Voters)
+
+	top, err := queries.GetTopSurveys(ctx, since, 1)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Equal(t, popular.ID, top[0].ID)
+	assert.Equal(t, 3, top[0].Responses)
+
+	size, err := queries.GetDatabaseSize(ctx, adminstats.TablesLimit)
+	require.NoError(t, err)
+	assert.Positive(t, size.Bytes)
+	assert.NotEmpty(t, size.Tables)
+	assert.LessOrEqual(t, len(size.Tables), adminstats.TablesLimit)
+}
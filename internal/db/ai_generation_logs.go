@@ -3,7 +3,9 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/openmeet-team/survey/internal/generator"
@@ -15,8 +17,8 @@ func (q *Queries) LogGeneration(ctx context.Context, log *generator.AIGeneration
 	query := `
 		INSERT INTO ai_generation_logs (
 			id, user_id, user_type, input_prompt, system_prompt, raw_response,
-			status, error_message, input_tokens, output_tokens, cost_usd, duration_ms, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			status, error_message, input_tokens, output_tokens, cost_usd, duration_ms, api_key_id, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
 	_, err := q.db.ExecContext(
@@ -34,6 +36,7 @@ func (q *Queries) LogGeneration(ctx context.Context, log *generator.AIGeneration
 		log.OutputTokens,
 		log.CostUSD,
 		log.DurationMS,
+		log.APIKeyID,
 		log.CreatedAt,
 	)
 
@@ -71,7 +74,7 @@ func (q *Queries) GetGenerationLog(ctx context.Context, id uuid.UUID) (*generato
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return nil, err
 		}
 		return nil, fmt.Errorf("failed to get AI generation log: %w", err)
@@ -222,3 +225,52 @@ func (q *Queries) GetRecentGenerationLogs(ctx context.Context, limit, offset int
 
 	return logs, nil
 }
+
+// GetGenerationUsage implements the generator.UsageStore interface
+// Aggregates a user's generations by UTC day and API key
+func (q *Queries) GetGenerationUsage(ctx context.Context, userID string, since time.Time) ([]*generator.DailyUsage, error) {
+	query := `
+		SELECT (created_at AT TIME ZONE 'UTC')::date AS day, api_key_id,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'success'),
+			COUNT(*) FILTER (WHERE status = 'rate_limited'),
+			COALESCE(SUM(input_tokens), 0),
+			COALESCE(SUM(output_tokens), 0),
+			COALESCE(SUM(cost_usd), 0)
+		FROM ai_generation_logs
+		WHERE user_id = $1 AND created_at >= $2
+		GROUP BY day, api_key_id
+		ORDER BY day
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query AI generation usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []*generator.DailyUsage
+	for rows.Next() {
+		u := &generator.DailyUsage{}
+		if err := rows.Scan(
+			&u.Day,
+			&u.APIKeyID,
+			&u.Generations,
+			&u.Succeeded,
+			&u.RateLimited,
+			&u.InputTokens,
+			&u.OutputTokens,
+			&u.CostUSD,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan AI generation usage: %w", err)
+		}
+		u.Day = u.Day.UTC()
+		usage = append(usage, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating AI generation usage: %w", err)
+	}
+
+	return usage, nil
+}
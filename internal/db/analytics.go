@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/analytics"
+)
+
+// AddSurveyViews implements the analytics.Store interface
+// Adds view counts to the stored counts of their survey, hour, and referrer
+func (q *Queries) AddSurveyViews(ctx context.Context, views []*analytics.View) error {
+	query := `
+		INSERT INTO survey_views (survey_id, hour, referrer, views)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (survey_id, hour, referrer) DO UPDATE
+		SET views = survey_views.views + EXCLUDED.views
+	`
+
+	for _, v := range views {
+		if _, err := q.db.ExecContext(ctx, query, v.SurveyID, v.Hour, v.Referrer, v.Views); err != nil {
+			return fmt.Errorf("failed to add survey views: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListSurveyViews implements the analytics.Store interface
+// Returns the view counts of a survey since an hour, oldest first
+func (q *Queries) ListSurveyViews(ctx context.Context, surveyID uuid.UUID, since time.Time) ([]*analytics.View, error) {
+	query := `
+		SELECT survey_id, hour, referrer, views
+		FROM survey_views
+		WHERE survey_id = $1 AND hour >= $2
+		ORDER BY hour ASC, referrer ASC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list survey views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []*analytics.View
+	for rows.Next() {
+		v := &analytics.View{}
+		if err := rows.Scan(&v.SurveyID, &v.Hour, &v.Referrer, &v.Views); err != nil {
+			return nil, fmt.Errorf("failed to scan survey views: %w", err)
+		}
+		views = append(views, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating survey views: %w", err)
+	}
+
+	return views, nil
+}
+
+// ListHourlyResponses implements the analytics.Store interface
+// Returns the number of responses to a survey per hour (UTC) since an hour,
+// omitting hours without responses
+func (q *Queries) ListHourlyResponses(ctx context.Context, surveyID uuid.UUID, since time.Time) ([]*analytics.HourCount, error) {
+	query := `
+		SELECT date_trunc('hour', created_at, 'UTC') AS hour, COUNT(*)
+		FROM responses
+		WHERE survey_id = $1 AND created_at >= $2
+		GROUP BY hour
+		ORDER BY hour ASC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count hourly responses: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*analytics.HourCount
+	for rows.Next() {
+		c := &analytics.HourCount{}
+		if err := rows.Scan(&c.Hour, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan hourly responses: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating hourly responses: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/apikey"
+)
+
+// CreateAPIKey implements the apikey.Store interface
+func (q *Queries) CreateAPIKey(ctx context.Context, k *apikey.Key) error {
+	query := `
+		INSERT INTO api_keys (id, owner_did, name, prefix, key_hash, scopes, rate_limit, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := q.db.ExecContext(ctx, query,
+		k.ID,
+		k.OwnerDID,
+		k.Name,
+		k.Prefix,
+		k.Hash,
+		strings.Join(k.Scopes, " "),
+		k.RateLimit,
+		k.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert API key: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIKeyByHash implements the apikey.Store interface
+// Returns sql.ErrNoRows if no key has the hash; revoked keys are returned too
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, hash string) (*apikey.Key, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE key_hash = $1
+	`
+
+	k, err := scanAPIKey(q.db.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return k, nil
+}
+
+// ListAPIKeysByOwner implements the apikey.Store interface
+// Returns a user's keys, including revoked ones, newest first
+func (q *Queries) ListAPIKeysByOwner(ctx context.Context, ownerDID string) ([]*apikey.Key, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE owner_did = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, ownerDID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*apikey.Key
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// CountActiveAPIKeys implements the apikey.Store interface
+func (q *Queries) CountActiveAPIKeys(ctx context.Context, ownerDID string) (int, error) {
+	query := `SELECT COUNT(*) FROM api_keys WHERE owner_did = $1 AND revoked_at IS NULL`
+
+	var count int
+	if err := q.db.QueryRowContext(ctx, query, ownerDID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count API keys: %w", err)
+	}
+
+	return count, nil
+}
+
+// RevokeAPIKey implements the apikey.Store interface
+// Returns sql.ErrNoRows if the owner has no such active key
+func (q *Queries) RevokeAPIKey(ctx context.Context, id uuid.UUID, ownerDID string) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND owner_did = $2 AND revoked_at IS NULL
+	`
+
+	result, err := q.db.ExecContext(ctx, query, id, ownerDID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// TouchAPIKey implements the apikey.Store interface
+// Records when a key was last used
+func (q *Queries) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`
+
+	if _, err := q.db.ExecContext(ctx, query, id, usedAt); err != nil {
+		return fmt.Errorf("failed to update API key last use: %w", err)
+	}
+
+	return nil
+}
+
+// apiKeyColumns are the columns scanned by scanAPIKey
+const apiKeyColumns = `id, owner_did, name, prefix, key_hash, scopes, rate_limit, last_used_at, revoked_at, created_at`
+
+// scanAPIKey scans a row of apiKeyColumns
+func scanAPIKey(row rowScanner) (*apikey.Key, error) {
+	k := &apikey.Key{}
+	var scopes string
+	if err := row.Scan(
+		&k.ID,
+		&k.OwnerDID,
+		&k.Name,
+		&k.Prefix,
+		&k.Hash,
+		&scopes,
+		&k.RateLimit,
+		&k.LastUsedAt,
+		&k.RevokedAt,
+		&k.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	k.Scopes = strings.Fields(scopes)
+	return k, nil
+}
+
+// AddAPIKeyUsage implements the apikey.Store interface
+// Adds request counts to each key's daily usage
+func (q *Queries) AddAPIKeyUsage(ctx context.Context, usage []*apikey.Usage) error {
+	query := `
+		INSERT INTO api_key_usage (key_id, day, requests, rate_limited)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key_id, day) DO UPDATE
+		SET requests = api_key_usage.requests + EXCLUDED.requests,
+			rate_limited = api_key_usage.rate_limited + EXCLUDED.rate_limited
+	`
+
+	for _, u := range usage {
+		if _, err := q.db.ExecContext(ctx, query, u.KeyID, u.Day, u.Requests, u.RateLimited); err != nil {
+			return fmt.Errorf("failed to add API key usage: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListAPIKeyUsage implements the apikey.Store interface
+// Returns the daily usage of an owner's keys since a day, oldest first
+func (q *Queries) ListAPIKeyUsage(ctx context.Context, ownerDID string, since time.Time) ([]*apikey.Usage, error) {
+	query := `
+		SELECT u.key_id, u.day, u.requests, u.rate_limited
+		FROM api_key_usage u
+		JOIN api_keys k ON k.id = u.key_id
+		WHERE k.owner_did = $1 AND u.day >= $2
+		ORDER BY u.day, u.key_id
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, ownerDID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API key usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []*apikey.Usage
+	for rows.Next() {
+		u := &apikey.Usage{}
+		if err := rows.Scan(&u.KeyID, &u.Day, &u.Requests, &u.RateLimited); err != nil {
+			return nil, fmt.Errorf("failed to scan API key usage: %w", err)
+		}
+		u.Day = u.Day.UTC()
+		usage = append(usage, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API key usage: %w", err)
+	}
+
+	return usage, nil
+}
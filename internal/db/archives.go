@@ -0,0 +1,307 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// ListArchivableSurveys implements the archive.Store interface
+// Returns surveys that ended before a time, longest ago first
+func (q *Queries) ListArchivableSurveys(ctx context.Context, endedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT s.id
+		FROM surveys s
+		WHERE s.ends_at < $1 AND s.deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM survey_archives a WHERE a.survey_id = s.id)
+			AND EXISTS (SELECT 1 FROM responses r WHERE r.survey_id = s.id)
+			AND NOT EXISTS (SELECT 1 FROM pds_outbox o WHERE o.survey_id = s.id AND o.kind = 'response' AND o.status = 'pending')
+		ORDER BY s.ends_at
+		LIMIT $2
+	`
+
+	return q.queryIDs(ctx, query, endedBefore, limit)
+}
+
+// ArchiveSurvey implements the archive.Store interface
+// Copies the rows of a survey's responses, flags, signals, and spam scores
+// into its archive, then deletes the archived responses (and, by cascade,
+// their flags, signals, and spam scores) in one transaction
+func (q *Queries) ArchiveSurvey(ctx context.Context, surveyID uuid.UUID, results *models.SurveyResults) (int, error) {
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	var count int
+	err = q.InTx(ctx, func(tx *Queries) error {
+		query := `
+			INSERT INTO survey_archives (survey_id, response_count, responses, flags, signals, spam_scores, record_uris, results)
+			SELECT $1,
+				(SELECT COUNT(*) FROM responses WHERE survey_id = $1),
+				(SELECT COALESCE(jsonb_agg(to_jsonb(r)), '[]') FROM responses r WHERE r.survey_id = $1),
+				(SELECT COALESCE(jsonb_agg(to_jsonb(f)), '[]') FROM flagged_responses f WHERE f.survey_id = $1),
+				(SELECT COALESCE(jsonb_agg(to_jsonb(s)), '[]') FROM response_signals s WHERE s.survey_id = $1),
+				(SELECT COALESCE(jsonb_agg(to_jsonb(s)), '[]') FROM response_spam_scores s WHERE s.survey_id = $1),
+				(SELECT COALESCE(array_agg(record_uri) FILTER (WHERE record_uri IS NOT NULL), '{}') FROM responses WHERE survey_id = $1),
+				$2
+			RETURNING response_count
+		`
+		if tx.dialect == dialectSQLite {
+			if query, err = tx.archiveQuerySQLite(ctx); err != nil {
+				return err
+			}
+		}
+		if err := tx.db.QueryRowContext(ctx, query, surveyID, resultsJSON).Scan(&count); err != nil {
+			return fmt.Errorf("failed to archive responses: %w", err)
+		}
+
+		// Only the archived rows are deleted, should a response arrive meanwhile
+		query = `
+			DELETE FROM responses
+			WHERE survey_id = $1 AND id IN (
+				SELECT (jsonb_array_elements(responses)->>'id')::uuid FROM survey_archives WHERE survey_id = $1
+			)
+		`
+		if tx.dialect == dialectSQLite {
+			query = `
+				DELETE FROM responses
+				WHERE survey_id = $1 AND id IN (
+					SELECT r.value->>'id' FROM survey_archives a, json_each(a.responses) r WHERE a.survey_id = $1
+				)
+			`
+		}
+		if _, err := tx.db.ExecContext(ctx, query, surveyID); err != nil {
+			return fmt.Errorf("failed to delete archived responses: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// archiveQuerySQLite returns ArchiveSurvey's insert for SQLite, which has no
+// to_jsonb: rows are built into JSON objects column by column
+func (q *Queries) archiveQuerySQLite(ctx context.Context) (string, error) {
+	objects := map[string]string{}
+	for _, table := range []string{"responses", "flagged_responses", "response_signals", "response_spam_scores"} {
+		columns, err := sqliteColumns(ctx, q.db, table)
+		if err != nil {
+			return "", err
+		}
+		objects[table] = sqliteRowObject("t", columns)
+	}
+
+	return `
+		INSERT INTO survey_archives (survey_id, response_count, responses, flags, signals, spam_scores, record_uris, results)
+		SELECT $1,
+			(SELECT COUNT(*) FROM responses WHERE survey_id = $1),
+			(SELECT json_group_array(` + objects["responses"] + `) FROM responses t WHERE t.survey_id = $1),
+			(SELECT json_group_array(` + objects["flagged_responses"] + `) FROM flagged_responses t WHERE t.survey_id = $1),
+			(SELECT json_group_array(` + objects["response_signals"] + `) FROM response_signals t WHERE t.survey_id = $1),
+			(SELECT json_group_array(` + objects["response_spam_scores"] + `) FROM response_spam_scores t WHERE t.survey_id = $1),
+			(SELECT json_group_array(record_uri) FROM responses WHERE survey_id = $1 AND record_uri IS NOT NULL),
+			$2
+		RETURNING response_count
+	`, nil
+}
+
+// RestoreArchivedSurvey implements the archive.Store interface
+// Inserts the archived rows back and deletes the archive in one transaction.
+// Responses conflicting with one given since (by the same voter) are skipped,
+// with their flags, signals, and spam scores.
+func (q *Queries) RestoreArchivedSurvey(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	var restored int64
+	err := q.InTx(ctx, func(tx *Queries) error {
+		var locked int
+		err := tx.db.QueryRowContext(ctx, `SELECT 1 FROM survey_archives WHERE survey_id = $1 FOR UPDATE`, surveyID).Scan(&locked)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil // Not archived, or restored meanwhile
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock archive: %w", err)
+		}
+
+		if tx.dialect == dialectSQLite {
+			restored, err = tx.restoreArchiveSQLite(ctx, surveyID)
+			return err
+		}
+
+		result, err := tx.db.ExecContext(ctx, `
+			INSERT INTO responses
+			SELECT r.* FROM survey_archives a CROSS JOIN LATERAL jsonb_populate_recordset(NULL::responses, a.responses) r
+			WHERE a.survey_id = $1
+			ON CONFLICT DO NOTHING
+		`, surveyID)
+		if err != nil {
+			return fmt.Errorf("failed to restore responses: %w", err)
+		}
+		restored, _ = result.RowsAffected()
+
+		_, err = tx.db.ExecContext(ctx, `
+			INSERT INTO flagged_responses
+			SELECT f.* FROM survey_archives a CROSS JOIN LATERAL jsonb_populate_recordset(NULL::flagged_responses, a.flags) f
+			WHERE a.survey_id = $1 AND EXISTS (SELECT 1 FROM responses r WHERE r.id = f.response_id)
+			ON CONFLICT DO NOTHING
+		`, surveyID)
+		if err != nil {
+			return fmt.Errorf("failed to restore flagged answers: %w", err)
+		}
+
+		_, err = tx.db.ExecContext(ctx, `
+			INSERT INTO response_signals
+			SELECT s.* FROM survey_archives a CROSS JOIN LATERAL jsonb_populate_recordset(NULL::response_signals, a.signals) s
+			WHERE a.survey_id = $1 AND EXISTS (SELECT 1 FROM responses r WHERE r.id = s.response_id)
+			ON CONFLICT DO NOTHING
+		`, surveyID)
+		if err != nil {
+			return fmt.Errorf("failed to restore response signals: %w", err)
+		}
+
+		_, err = tx.db.ExecContext(ctx, `
+			INSERT INTO response_spam_scores
+			SELECT s.* FROM survey_archives a CROSS JOIN LATERAL jsonb_populate_recordset(NULL::response_spam_scores, a.spam_scores) s
+			WHERE a.survey_id = $1 AND EXISTS (SELECT 1 FROM responses r WHERE r.id = s.response_id)
+			ON CONFLICT DO NOTHING
+		`, surveyID)
+		if err != nil {
+			return fmt.Errorf("failed to restore spam scores: %w", err)
+		}
+
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM survey_archives WHERE survey_id = $1`, surveyID); err != nil {
+			return fmt.Errorf("failed to delete archive: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(restored), nil
+}
+
+// restoreArchiveSQLite runs the inserts and delete of RestoreArchivedSurvey on
+// SQLite, which has no jsonb_populate_recordset: rows are read from their JSON
+// objects column by column. It returns the number of restored responses.
+func (q *Queries) restoreArchiveSQLite(ctx context.Context, surveyID uuid.UUID) (int64, error) {
+	var restored int64
+	for _, archived := range []struct{ table, column, noun string }{
+		{"responses", "responses", "responses"},
+		{"flagged_responses", "flags", "flagged answers"},
+		{"response_signals", "signals", "response signals"},
+		{"response_spam_scores", "spam_scores", "spam scores"},
+	} {
+		columns, err := sqliteColumns(ctx, q.db, archived.table)
+		if err != nil {
+			return 0, err
+		}
+		condition := "true"
+		if archived.table != "responses" {
+			condition = "EXISTS (SELECT 1 FROM responses r WHERE r.id = t.response_id)"
+		}
+
+		query := `
+			INSERT INTO ` + archived.table + ` (` + strings.Join(columns, ", ") + `)
+			SELECT * FROM (
+				SELECT ` + sqliteRecordColumns("e", columns) + `
+				FROM survey_archives a, json_each(a.` + archived.column + `) e
+				WHERE a.survey_id = $1
+			) t
+			WHERE ` + condition + `
+			ON CONFLICT DO NOTHING
+		`
+		result, err := q.db.ExecContext(ctx, query, surveyID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to restore %s: %w", archived.noun, err)
+		}
+		if archived.table == "responses" {
+			restored, _ = result.RowsAffected()
+		}
+	}
+
+	if _, err := q.db.ExecContext(ctx, `DELETE FROM survey_archives WHERE survey_id = $1`, surveyID); err != nil {
+		return 0, fmt.Errorf("failed to delete archive: %w", err)
+	}
+	return restored, nil
+}
+
+// ListReopenedSurveys implements the archive.Store interface
+// Returns the archived surveys without an end time, or ending after now
+func (q *Queries) ListReopenedSurveys(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT a.survey_id
+		FROM survey_archives a
+		JOIN surveys s ON s.id = a.survey_id
+		WHERE s.ends_at IS NULL OR s.ends_at > $1
+	`
+
+	return q.queryIDs(ctx, query, now)
+}
+
+// GetArchivedResults implements the archive.Store interface
+// Returns sql.ErrNoRows if the survey is not archived
+func (q *Queries) GetArchivedResults(ctx context.Context, surveyID uuid.UUID) (*models.SurveyResults, error) {
+	var resultsJSON []byte
+	err := q.db.QueryRowContext(ctx, `SELECT results FROM survey_archives WHERE survey_id = $1`, surveyID).Scan(&resultsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var results models.SurveyResults
+	if err := json.Unmarshal(resultsJSON, &results); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal archived results: %w", err)
+	}
+	return &results, nil
+}
+
+// restoreArchiveOfRecord restores the archive holding the response of a record
+// URI, and reports whether there was one
+func (q *Queries) restoreArchiveOfRecord(ctx context.Context, recordURI string) (bool, error) {
+	var surveyID uuid.UUID
+	query := `SELECT survey_id FROM survey_archives WHERE record_uris @> ARRAY[$1::text]`
+	if q.dialect == dialectSQLite {
+		query = `SELECT survey_id FROM survey_archives a WHERE EXISTS (SELECT 1 FROM json_each(a.record_uris) WHERE value = $1)`
+	}
+	err := q.db.QueryRowContext(ctx, query, recordURI).Scan(&surveyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to find archive of record: %w", err)
+	}
+
+	if _, err := q.RestoreArchivedSurvey(ctx, surveyID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// queryIDs runs a query returning one UUID column
+func (q *Queries) queryIDs(ctx context.Context, query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query surveys: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan survey ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating surveys: %w", err)
+	}
+	return ids, nil
+}
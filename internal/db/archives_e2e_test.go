@@ -0,0 +1,103 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/archive"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/moderation"
+	"github.com/openmeet-team/survey/internal/surveylist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveSurvey(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	alice := "did:plc:alice"
+
+	ended := now.Add(-100 * 24 * time.Hour)
+	survey := &models.Survey{
+		ID:         uuid.New(),
+		AuthorDID:  &alice,
+		Slug:       "old",
+		Title:      "Old",
+		Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}}},
+		EndsAt:     &ended,
+		CreatedAt:  ended.Add(-time.Hour),
+		UpdatedAt:  ended.Add(-time.Hour),
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	voter, uri := "did:plc:voter", "at://did:plc:voter/net.openmeet.survey.response/1"
+	session := "guest"
+	voted := &models.Response{ID: uuid.New(), SurveyID: survey.ID, VoterDID: &voter, RecordURI: &uri, Answers: map[string]models.Answer{"q1": {Text: "Because"}}, CreatedAt: ended.Add(-time.Minute)}
+	guest := &models.Response{ID: uuid.New(), SurveyID: survey.ID, VoterSession: &session, Answers: map[string]models.Answer{"q1": {Text: "Rude"}}, CreatedAt: ended.Add(-time.Minute)}
+	require.NoError(t, queries.CreateResponse(ctx, voted))
+	require.NoError(t, queries.CreateResponse(ctx, guest))
+	require.NoError(t, queries.CreateFlaggedResponse(ctx, &moderation.FlaggedResponse{
+		ID: uuid.New(), ResponseID: guest.ID, SurveyID: survey.ID, QuestionID: "q1", Text: "Rude", Source: "blocklist", Reason: "rude", Status: moderation.StatusPending, CreatedAt: now,
+	}))
+
+	ids, err := queries.ListArchivableSurveys(ctx, now.Add(-90*24*time.Hour), archive.BatchSize)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{survey.ID}, ids)
+	ids, err = queries.ListArchivableSurveys(ctx, now.Add(-120*24*time.Hour), archive.BatchSize)
+	require.NoError(t, err)
+	assert.Empty(t, ids, "surveys closed more recently are not archived")
+
+	results, err := queries.GetSurveyResults(ctx, survey.ID)
+	require.NoError(t, err)
+	count, err := queries.ArchiveSurvey(ctx, survey.ID, results)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	responses, err := queries.ListResponsesBySurvey(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Empty(t, responses, "archived responses leave the responses table")
+
+	archived, err := queries.GetSurveyResults(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, archived.TotalVotes, "results are kept")
+	assert.Equal(t, []string{"Because"}, archived.QuestionResults["q1"].TextAnswers, "flagged answers stay hidden")
+
+	items, err := queries.ListAuthorSurveys(ctx, surveylist.Filter{AuthorDID: alice, Sort: surveylist.SortCreatedAt}, now)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, 2, items[0].Responses, "archived responses are counted")
+
+	// Deleting an archived response record restores the archive first
+	require.NoError(t, queries.DeleteResponseByRecordURI(ctx, uri))
+	responses, err = queries.ListResponsesBySurvey(ctx, survey.ID)
+	require.NoError(t, err)
+	require.Len(t, responses, 1)
+	assert.Equal(t, guest.ID, responses[0].ID)
+	flags, err := queries.ListFlaggedResponsesBySurvey(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Len(t, flags, 1, "flags are restored with their responses")
+	_, err = queries.GetArchivedResults(ctx, survey.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Reopened surveys are restored
+	_, err = queries.ArchiveSurvey(ctx, survey.ID, results)
+	require.NoError(t, err)
+	_, err = database.ExecContext(ctx, `UPDATE surveys SET ends_at = NULL WHERE id = $1`, survey.ID)
+	require.NoError(t, err)
+	reopened, err := queries.ListReopenedSurveys(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{survey.ID}, reopened)
+	restored, err := queries.RestoreArchivedSurvey(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	restored, err = queries.RestoreArchivedSurvey(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Zero(t, restored, "restoring twice does nothing")
+}
@@ -0,0 +1,21 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// CreateSurveys inserts surveys in one transaction: either all of them are
+// created or, if any fails (e.g. its slug was taken meanwhile), none is
+func (q *Queries) CreateSurveys(ctx context.Context, surveys []*models.Survey) error {
+	return q.InTx(ctx, func(tx *Queries) error {
+		for i, s := range surveys {
+			if err := tx.CreateSurvey(ctx, s); err != nil {
+				return fmt.Errorf("survey %d (%s): %w", i, s.Slug, err)
+			}
+		}
+		return nil
+	})
+}
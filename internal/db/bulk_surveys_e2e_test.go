@@ -0,0 +1,48 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSurveys(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	newSurvey := func(slug string) *models.Survey {
+		now := time.Now()
+		return &models.Survey{
+			ID:    uuid.New(),
+			Slug:  slug,
+			Title: "Session feedback",
+			Definition: models.SurveyDefinition{Questions: []models.Question{
+				{ID: "q1", Text: "Session feedback", Type: models.QuestionTypeText},
+			}},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	require.NoError(t, queries.CreateSurveys(ctx, []*models.Survey{newSurvey("session-1"), newSurvey("session-2")}))
+	for _, slug := range []string{"session-1", "session-2"} {
+		exists, err := queries.SlugExists(ctx, slug)
+		require.NoError(t, err)
+		assert.True(t, exists, slug)
+	}
+
+	// A taken slug rolls back the whole batch
+	err := queries.CreateSurveys(ctx, []*models.Survey{newSurvey("session-3"), newSurvey("session-1")})
+	require.Error(t, err)
+	exists, err := queries.SlugExists(ctx, "session-3")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
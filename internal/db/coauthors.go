@@ -0,0 +1,212 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/coauthor"
+)
+
+// AddCoAuthor implements the coauthor.Store interface
+// Returns coauthor.ErrAlreadyCoAuthor if the DID is a co-author of the survey
+func (q *Queries) AddCoAuthor(ctx context.Context, c *coauthor.CoAuthor) error {
+	query := `
+		INSERT INTO survey_coauthors (survey_id, did, added_by, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (survey_id, did) DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, c.SurveyID, c.DID, c.AddedBy, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert co-author: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return coauthor.ErrAlreadyCoAuthor
+	}
+
+	return nil
+}
+
+// ListCoAuthors implements the coauthor.Store interface
+// Returns the co-authors of a survey, oldest first
+func (q *Queries) ListCoAuthors(ctx context.Context, surveyID uuid.UUID) ([]*coauthor.CoAuthor, error) {
+	query := `
+		SELECT survey_id, did, added_by, created_at
+		FROM survey_coauthors
+		WHERE survey_id = $1
+		ORDER BY created_at, did
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list co-authors: %w", err)
+	}
+	defer rows.Close()
+
+	var coAuthors []*coauthor.CoAuthor
+	for rows.Next() {
+		c := &coauthor.CoAuthor{}
+		if err := rows.Scan(&c.SurveyID, &c.DID, &c.AddedBy, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan co-author: %w", err)
+		}
+		coAuthors = append(coAuthors, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating co-authors: %w", err)
+	}
+
+	return coAuthors, nil
+}
+
+// IsCoAuthor implements the coauthor.Store interface
+func (q *Queries) IsCoAuthor(ctx context.Context, surveyID uuid.UUID, did string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM survey_coauthors WHERE survey_id = $1 AND did = $2)`
+
+	var exists bool
+	if err := q.db.QueryRowContext(ctx, query, surveyID, did).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check co-author: %w", err)
+	}
+
+	return exists, nil
+}
+
+// RemoveCoAuthor implements the coauthor.Store interface
+// Returns sql.ErrNoRows if the DID is not a co-author; their pending publish
+// request is rejected with them
+func (q *Queries) RemoveCoAuthor(ctx context.Context, surveyID uuid.UUID, did string, now time.Time) error {
+	if q.dialect == dialectSQLite {
+		return q.removeCoAuthorSQLite(ctx, surveyID, did, now)
+	}
+
+	query := `
+		WITH removed AS (
+			DELETE FROM survey_coauthors WHERE survey_id = $1 AND did = $2
+			RETURNING did
+		), withdrawn AS (
+			UPDATE results_publish_requests
+			SET status = 'rejected', resolved_by = $2, resolved_at = $3
+			WHERE survey_id = $1 AND status = 'pending' AND requested_by IN (SELECT did FROM removed)
+		)
+		SELECT COUNT(*) FROM removed
+	`
+
+	var removed int
+	if err := q.db.QueryRowContext(ctx, query, surveyID, did, now).Scan(&removed); err != nil {
+		return fmt.Errorf("failed to remove co-author: %w", err)
+	}
+	if removed == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// removeCoAuthorSQLite runs RemoveCoAuthor's statements one by one on SQLite,
+// which has no data-modifying CTEs
+func (q *Queries) removeCoAuthorSQLite(ctx context.Context, surveyID uuid.UUID, did string, now time.Time) error {
+	return q.InTx(ctx, func(tx *Queries) error {
+		result, err := tx.db.ExecContext(ctx, `DELETE FROM survey_coauthors WHERE survey_id = $1 AND did = $2`, surveyID, did)
+		if err != nil {
+			return fmt.Errorf("failed to remove co-author: %w", err)
+		}
+		if removed, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		} else if removed == 0 {
+			return sql.ErrNoRows
+		}
+
+		query := `
+			UPDATE results_publish_requests
+			SET status = 'rejected', resolved_by = $2, resolved_at = $3
+			WHERE survey_id = $1 AND status = 'pending' AND requested_by = $2
+		`
+		if _, err := tx.db.ExecContext(ctx, query, surveyID, did, now); err != nil {
+			return fmt.Errorf("failed to remove co-author: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreatePublishRequest implements the coauthor.Store interface
+// Returns coauthor.ErrRequestPending if the survey has a pending request
+func (q *Queries) CreatePublishRequest(ctx context.Context, r *coauthor.PublishRequest) error {
+	query := `
+		INSERT INTO results_publish_requests (id, survey_id, requested_by, status, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (survey_id) WHERE status = 'pending' DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, r.ID, r.SurveyID, r.RequestedBy, r.Status, r.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert publish request: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return coauthor.ErrRequestPending
+	}
+
+	return nil
+}
+
+// GetPendingPublishRequest implements the coauthor.Store interface
+// Returns sql.ErrNoRows if the survey has no pending request
+func (q *Queries) GetPendingPublishRequest(ctx context.Context, surveyID uuid.UUID) (*coauthor.PublishRequest, error) {
+	query := `
+		SELECT id, survey_id, requested_by, status, resolved_by, resolved_at, created_at
+		FROM results_publish_requests
+		WHERE survey_id = $1 AND status = 'pending'
+	`
+
+	r := &coauthor.PublishRequest{}
+	err := q.db.QueryRowContext(ctx, query, surveyID).Scan(&r.ID, &r.SurveyID, &r.RequestedBy, &r.Status, &r.ResolvedBy, &r.ResolvedAt, &r.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get publish request: %w", err)
+	}
+
+	return r, nil
+}
+
+// ResolvePublishRequest implements the coauthor.Store interface
+// Returns sql.ErrNoRows if the survey has no such pending request
+func (q *Queries) ResolvePublishRequest(ctx context.Context, id, surveyID uuid.UUID, status, resolvedBy string, now time.Time) error {
+	query := `
+		UPDATE results_publish_requests
+		SET status = $3, resolved_by = $4, resolved_at = $5
+		WHERE id = $1 AND survey_id = $2 AND status = 'pending'
+	`
+
+	result, err := q.db.ExecContext(ctx, query, id, surveyID, status, resolvedBy, now)
+	if err != nil {
+		return fmt.Errorf("failed to resolve publish request: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
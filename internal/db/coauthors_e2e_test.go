@@ -0,0 +1,85 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/coauthor"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoAuthors(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	author := "did:plc:author"
+	survey := &models.Survey{
+		ID:        uuid.New(),
+		Slug:      "shared",
+		Title:     "shared",
+		AuthorDID: &author,
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	now := time.Now()
+	for _, did := range []string{"did:plc:bob", "did:plc:carol"} {
+		require.NoError(t, queries.AddCoAuthor(ctx, &coauthor.CoAuthor{SurveyID: survey.ID, DID: did, AddedBy: author, CreatedAt: now}))
+		now = now.Add(time.Second)
+	}
+	assert.ErrorIs(t, queries.AddCoAuthor(ctx, &coauthor.CoAuthor{SurveyID: survey.ID, DID: "did:plc:bob", AddedBy: author, CreatedAt: now}), coauthor.ErrAlreadyCoAuthor)
+
+	coAuthors, err := queries.ListCoAuthors(ctx, survey.ID)
+	require.NoError(t, err)
+	require.Len(t, coAuthors, 2)
+	assert.Equal(t, "did:plc:bob", coAuthors[0].DID)
+	assert.Equal(t, author, coAuthors[0].AddedBy)
+
+	is, err := queries.IsCoAuthor(ctx, survey.ID, "did:plc:carol")
+	require.NoError(t, err)
+	assert.True(t, is)
+	is, err = queries.IsCoAuthor(ctx, uuid.New(), "did:plc:carol")
+	require.NoError(t, err)
+	assert.False(t, is, "co-authors only share their own survey")
+
+	// One request is pending at a time
+	_, err = queries.GetPendingPublishRequest(ctx, survey.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	request := coauthor.NewPublishRequest(survey.ID, "did:plc:bob", now)
+	require.NoError(t, queries.CreatePublishRequest(ctx, request))
+	assert.ErrorIs(t, queries.CreatePublishRequest(ctx, coauthor.NewPublishRequest(survey.ID, "did:plc:carol", now)), coauthor.ErrRequestPending)
+
+	pending, err := queries.GetPendingPublishRequest(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, request.ID, pending.ID)
+	assert.Equal(t, "did:plc:bob", pending.RequestedBy)
+
+	assert.ErrorIs(t, queries.ResolvePublishRequest(ctx, request.ID, uuid.New(), coauthor.StatusApproved, author, now), sql.ErrNoRows, "requests are resolved for their own survey")
+	require.NoError(t, queries.ResolvePublishRequest(ctx, request.ID, survey.ID, coauthor.StatusApproved, author, now))
+	assert.ErrorIs(t, queries.ResolvePublishRequest(ctx, request.ID, survey.ID, coauthor.StatusRejected, author, now), sql.ErrNoRows, "resolved requests stay resolved")
+	_, err = queries.GetPendingPublishRequest(ctx, survey.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Removing a co-author withdraws their pending request
+	require.NoError(t, queries.CreatePublishRequest(ctx, coauthor.NewPublishRequest(survey.ID, "did:plc:carol", now)))
+	require.NoError(t, queries.RemoveCoAuthor(ctx, survey.ID, "did:plc:carol", now))
+	assert.ErrorIs(t, queries.RemoveCoAuthor(ctx, survey.ID, "did:plc:carol", now), sql.ErrNoRows)
+	_, err = queries.GetPendingPublishRequest(ctx, survey.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	coAuthors, err = queries.ListCoAuthors(ctx, survey.ID)
+	require.NoError(t, err)
+	require.Len(t, coAuthors, 1)
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// commentColumns are the columns scanned by scanComment
+const commentColumns = `id, survey_id, author_did, record_uri, record_cid, text, created_at, indexed_at`
+
+// scanComment scans a row of commentColumns
+func scanComment(row interface{ Scan(dest ...any) error }) (*models.Comment, error) {
+	c := &models.Comment{}
+	if err := row.Scan(&c.ID, &c.SurveyID, &c.AuthorDID, &c.RecordURI, &c.RecordCID, &c.Text, &c.CreatedAt, &c.IndexedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan comment: %w", err)
+	}
+	return c, nil
+}
+
+// CreateComment indexes a comment, unless its record is already indexed or
+// its author made the same comment on the survey before.
+// Returns whether the comment was indexed.
+func (q *Queries) CreateComment(ctx context.Context, c *models.Comment) (bool, error) {
+	query := `
+		INSERT INTO survey_comments (id, survey_id, author_did, record_uri, record_cid, text, created_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7
+		WHERE NOT EXISTS (
+			SELECT 1 FROM survey_comments
+			WHERE survey_id = $2 AND author_did = $3 AND md5(text) = md5($6) AND text = $6
+		)
+		ON CONFLICT (record_uri) DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, c.ID, c.SurveyID, c.AuthorDID, c.RecordURI, c.RecordCID, c.Text, c.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetCommentByRecordURI returns the comment indexed from a record, or nil if none is
+func (q *Queries) GetCommentByRecordURI(ctx context.Context, recordURI string) (*models.Comment, error) {
+	query := `SELECT ` + commentColumns + ` FROM survey_comments WHERE record_uri = $1`
+
+	c, err := scanComment(q.db.QueryRowContext(ctx, query, recordURI))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil // Not found is not an error for this query
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// UpdateComment updates the text and CID of an indexed comment
+func (q *Queries) UpdateComment(ctx context.Context, id uuid.UUID, text, cid string) error {
+	_, err := q.db.ExecContext(ctx, `UPDATE survey_comments SET text = $2, record_cid = $3 WHERE id = $1`, id, text, cid)
+	if err != nil {
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+	return nil
+}
+
+// DeleteCommentByRecordURI removes the comment indexed from a record
+func (q *Queries) DeleteCommentByRecordURI(ctx context.Context, recordURI string) error {
+	if _, err := q.db.ExecContext(ctx, `DELETE FROM survey_comments WHERE record_uri = $1`, recordURI); err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return nil
+}
+
+// ListSurveyComments returns a page of a survey's comments, oldest first
+func (q *Queries) ListSurveyComments(ctx context.Context, surveyID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM survey_comments
+		WHERE survey_id = $1
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*models.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// ListSurveyCommentsAfter returns a survey's comments after a cursor, or from
+// the first if it is nil, oldest first
+func (q *Queries) ListSurveyCommentsAfter(ctx context.Context, surveyID uuid.UUID, after *models.CommentCursor, limit int) ([]*models.Comment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM survey_comments
+		WHERE survey_id = $1
+	`
+	args := []interface{}{surveyID}
+	if after != nil {
+		query += ` AND (created_at, id) > ($2, $3)`
+		args = append(args, after.CreatedAt, after.ID)
+	}
+	query += fmt.Sprintf(` ORDER BY created_at, id LIMIT $%d`, len(args)+1)
+	args = append(args, limit)
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*models.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// CountSurveyComments returns the number of a survey's comments
+func (q *Queries) CountSurveyComments(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	var count int
+	if err := q.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM survey_comments WHERE survey_id = $1`, surveyID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+	return count, nil
+}
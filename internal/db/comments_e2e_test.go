@@ -0,0 +1,89 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComments(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	survey := &models.Survey{
+		ID:         uuid.New(),
+		Slug:       "commented",
+		Title:      "Commented",
+		Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Comments?", Type: models.QuestionTypeText}}},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	comment := func(i int, author, text string) *models.Comment {
+		return &models.Comment{
+			ID:        uuid.New(),
+			SurveyID:  survey.ID,
+			AuthorDID: author,
+			RecordURI: fmt.Sprintf("at://%s/net.openmeet.survey.comment/%d", author, i),
+			RecordCID: fmt.Sprintf("bafy%d", i),
+			Text:      text,
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		}
+	}
+
+	for i := range 3 {
+		created, err := queries.CreateComment(ctx, comment(i, "did:plc:alice", fmt.Sprintf("Comment %d", i)))
+		require.NoError(t, err)
+		assert.True(t, created)
+	}
+
+	// Redelivered records and repeated text are indexed once
+	created, err := queries.CreateComment(ctx, comment(0, "did:plc:alice", "Comment 0"))
+	require.NoError(t, err)
+	assert.False(t, created)
+	created, err = queries.CreateComment(ctx, comment(9, "did:plc:alice", "Comment 1"))
+	require.NoError(t, err)
+	assert.False(t, created)
+	created, err = queries.CreateComment(ctx, comment(3, "did:plc:bob", "Comment 1"))
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	count, err := queries.CountSurveyComments(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	page, err := queries.ListSurveyCommentsAfter(ctx, survey.ID, nil, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "Comment 0", page[0].Text)
+	page, err = queries.ListSurveyCommentsAfter(ctx, survey.ID, &models.CommentCursor{CreatedAt: page[1].CreatedAt, ID: page[1].ID}, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "Comment 2", page[0].Text)
+	assert.Equal(t, "did:plc:bob", page[1].AuthorDID)
+
+	got, err := queries.GetCommentByRecordURI(ctx, page[0].RecordURI)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.NoError(t, queries.UpdateComment(ctx, got.ID, "Edited", "bafyedited"))
+	require.NoError(t, queries.DeleteCommentByRecordURI(ctx, page[1].RecordURI))
+	got, err = queries.GetCommentByRecordURI(ctx, page[1].RecordURI)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	offset, err := queries.ListSurveyComments(ctx, survey.ID, 10, 1)
+	require.NoError(t, err)
+	require.Len(t, offset, 2)
+	assert.Equal(t, "Edited", offset[1].Text)
+}
@@ -0,0 +1,282 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Row is the result of Querier.QueryRowContext; *sql.Row and pgx.Row implement it
+type Row interface {
+	Scan(dest ...any) error
+}
+
+// Rows is the result of Querier.QueryContext; *sql.Rows implements it
+type Rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Close() error
+	Err() error
+}
+
+// Result is the result of Querier.ExecContext; sql.Result implements it
+type Result interface {
+	RowsAffected() (int64, error)
+}
+
+// SQLQuerier is a database/sql database, connection, or transaction
+type SQLQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// tx is a transaction of a pool or connection
+type tx interface {
+	Querier
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// txBeginner is a Querier that can begin transactions, i.e. one that is not
+// already in a transaction
+type txBeginner interface {
+	BeginTx(ctx context.Context) (tx, error)
+}
+
+// conn is one connection of a pool, held until closed, e.g. for a session lock
+type conn interface {
+	Querier
+	txBeginner
+	Close() error
+
+	// discard closes the connection instead of returning it to its pool, so
+	// its session ends with the locks it holds
+	discard()
+}
+
+// pgxPools holds the pgx pool behind each database Connect opened on Postgres.
+// Queries, migrations, and leader locks use the pool natively, while other
+// packages use the database/sql handle, which borrows its connections.
+var pgxPools sync.Map // *sql.DB -> *pgxpool.Pool
+
+// pgxPoolOf returns the pgx pool behind a database opened with Connect, or nil
+// for SQLite and databases opened elsewhere
+func pgxPoolOf(db SQLQuerier) *pgxpool.Pool {
+	if sqlDB, ok := db.(*sql.DB); ok {
+		if pool, ok := pgxPools.Load(sqlDB); ok {
+			return pool.(*pgxpool.Pool)
+		}
+	}
+	return nil
+}
+
+// querierOf returns a Querier running queries on db: natively on the pgx pool
+// of a database opened with Connect on Postgres, and through database/sql
+// otherwise
+func querierOf(db SQLQuerier) Querier {
+	if pool := pgxPoolOf(db); pool != nil {
+		return pgxPool{pgxQuerier{pool}, pool}
+	}
+	switch db := db.(type) {
+	case *sql.DB:
+		return sqlDB{sqlQuerier{db}, db}
+	case *sql.Conn:
+		return sqlConn{sqlQuerier{db}, db}
+	}
+	return sqlQuerier{db}
+}
+
+// acquire takes a connection of db out of its pool until it is closed
+func acquire(ctx context.Context, db *sql.DB) (conn, error) {
+	if pool := pgxPoolOf(db); pool != nil {
+		c, err := pool.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return pgxConn{pgxQuerier{c}, c}, nil
+	}
+	c, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sqlConn{sqlQuerier{c}, c}, nil
+}
+
+// pgxQueryer is a pgx pool, connection, or transaction
+type pgxQueryer interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error)
+}
+
+// pgxQuerier runs queries natively with pgx
+type pgxQuerier struct {
+	q pgxQueryer
+}
+
+func (p pgxQuerier) QueryRowContext(ctx context.Context, query string, args ...any) Row {
+	return p.q.QueryRow(ctx, query, args...)
+}
+
+func (p pgxQuerier) QueryContext(ctx context.Context, query string, args ...any) (Rows, error) {
+	rows, err := p.q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgxRows{rows}, nil
+}
+
+func (p pgxQuerier) ExecContext(ctx context.Context, query string, args ...any) (Result, error) {
+	tag, err := p.q.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgxResult{tag}, nil
+}
+
+// copyFrom copies rows into the columns of a table with COPY
+func (p pgxQuerier) copyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error) {
+	return p.q.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+}
+
+// pgxRows are the rows of a pgx query
+type pgxRows struct {
+	pgx.Rows
+}
+
+// Close closes the rows; errors are reported by Err
+func (r pgxRows) Close() error {
+	r.Rows.Close()
+	return nil
+}
+
+// pgxResult is the result of a pgx statement
+type pgxResult struct {
+	tag pgconn.CommandTag
+}
+
+func (r pgxResult) RowsAffected() (int64, error) {
+	return r.tag.RowsAffected(), nil
+}
+
+// pgxPool is a pgx pool
+type pgxPool struct {
+	pgxQuerier
+	pool *pgxpool.Pool
+}
+
+func (p pgxPool) BeginTx(ctx context.Context) (tx, error) {
+	t, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pgxTx{pgxQuerier{t}, t}, nil
+}
+
+// pgxConn is a connection acquired from a pgx pool
+type pgxConn struct {
+	pgxQuerier
+	conn *pgxpool.Conn
+}
+
+func (c pgxConn) BeginTx(ctx context.Context) (tx, error) {
+	t, err := c.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pgxTx{pgxQuerier{t}, t}, nil
+}
+
+func (c pgxConn) Close() error {
+	c.conn.Release()
+	return nil
+}
+
+func (c pgxConn) discard() {
+	c.conn.Hijack().Close(context.Background())
+}
+
+// pgxTx is a pgx transaction
+type pgxTx struct {
+	pgxQuerier
+	tx pgx.Tx
+}
+
+func (t pgxTx) Commit(ctx context.Context) error   { return t.tx.Commit(ctx) }
+func (t pgxTx) Rollback(ctx context.Context) error { return t.tx.Rollback(ctx) }
+
+// sqlQuerier runs queries through database/sql
+type sqlQuerier struct {
+	db SQLQuerier
+}
+
+func (s sqlQuerier) QueryRowContext(ctx context.Context, query string, args ...any) Row {
+	return s.db.QueryRowContext(ctx, query, args...)
+}
+
+func (s sqlQuerier) QueryContext(ctx context.Context, query string, args ...any) (Rows, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (s sqlQuerier) ExecContext(ctx context.Context, query string, args ...any) (Result, error) {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// sqlDB is a database/sql database
+type sqlDB struct {
+	sqlQuerier
+	db *sql.DB
+}
+
+func (d sqlDB) BeginTx(ctx context.Context) (tx, error) {
+	t, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return sqlTx{sqlQuerier{t}, t}, nil
+}
+
+// sqlConn is a database/sql connection
+type sqlConn struct {
+	sqlQuerier
+	conn *sql.Conn
+}
+
+func (c sqlConn) BeginTx(ctx context.Context) (tx, error) {
+	t, err := c.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return sqlTx{sqlQuerier{t}, t}, nil
+}
+
+func (c sqlConn) Close() error { return c.conn.Close() }
+
+// discard reports the connection as bad, so database/sql closes it
+func (c sqlConn) discard() {
+	c.conn.Raw(func(any) error { return driver.ErrBadConn })
+}
+
+// sqlTx is a database/sql transaction
+type sqlTx struct {
+	sqlQuerier
+	tx *sql.Tx
+}
+
+func (t sqlTx) Commit(ctx context.Context) error   { return t.tx.Commit() }
+func (t sqlTx) Rollback(ctx context.Context) error { return t.tx.Rollback() }
@@ -0,0 +1,67 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyResponses(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	survey := &models.Survey{
+		ID:         uuid.New(),
+		Slug:       "copied",
+		Title:      "Copied",
+		Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}}},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	pinned := 1
+	responses := make([]*models.Response, 500)
+	for i := range responses {
+		session := fmt.Sprintf("session-%d", i)
+		responses[i] = &models.Response{
+			ID:           uuid.New(),
+			SurveyID:     survey.ID,
+			VoterSession: &session,
+			Answers:      map[string]models.Answer{"q1": {Text: "Because"}},
+			CreatedAt:    now,
+		}
+	}
+	responses[0].SurveyVersion = &pinned
+
+	copied, err := queries.CopyResponses(ctx, responses)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(responses)), copied)
+
+	stored, err := queries.GetResponseByID(ctx, responses[len(responses)-1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Because", stored.Answers["q1"].Text)
+	require.NotNil(t, stored.SurveyVersion, "unversioned responses answer the current version")
+	assert.Equal(t, 1, *stored.SurveyVersion)
+
+	// A duplicate fails the whole batch
+	session := "session-new"
+	_, err = queries.CopyResponses(ctx, []*models.Response{
+		{ID: uuid.New(), SurveyID: survey.ID, VoterSession: &session, Answers: map[string]models.Answer{}, CreatedAt: now},
+		responses[1],
+	})
+	require.Error(t, err)
+	count, err := queries.CountResponsesBySurvey(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, len(responses), count)
+}
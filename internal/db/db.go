@@ -4,34 +4,96 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/XSAM/otelsql"
-	_ "github.com/jackc/pgx/v5/stdlib"
-	"go.opentelemetry.io/otel/attribute"
-	semconv "go.opentelemetry.io/otel/semconv/v1.28.0"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
 )
 
 // Config holds database connection configuration
 type Config struct {
+	Driver   string // DriverPostgres (the default when empty) or DriverSQLite
+	Path     string // SQLite database file
 	Host     string
 	Port     int
 	User     string
 	Password string
 	Database string
 	SSLMode  string
+	Replicas []string   // Read replica connection strings, or hosts with optional ports sharing the primary's credentials
+	Pool     PoolConfig // Applies to the primary and each replica
+}
+
+// Connection pool defaults
+const (
+	DefaultMaxOpenConns    = 25
+	DefaultMaxIdleConns    = 5
+	DefaultConnMaxLifetime = 30 * time.Minute
+	DefaultConnMaxIdleTime = 5 * time.Minute
+)
+
+// PoolConfig sizes a connection pool. Zero fields use the defaults.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration // Connections are closed and reopened after this long
+	ConnMaxIdleTime time.Duration // Idle connections are closed after this long
 }
 
-// ConfigFromEnv creates a Config from environment variables with sensible defaults
+// withDefaults returns the pool config with zero fields set to the defaults
+func (p PoolConfig) withDefaults() PoolConfig {
+	if p.MaxOpenConns == 0 {
+		p.MaxOpenConns = DefaultMaxOpenConns
+	}
+	if p.MaxIdleConns == 0 {
+		p.MaxIdleConns = min(DefaultMaxIdleConns, p.MaxOpenConns)
+	}
+	if p.ConnMaxLifetime == 0 {
+		p.ConnMaxLifetime = DefaultConnMaxLifetime
+	}
+	if p.ConnMaxIdleTime == 0 {
+		p.ConnMaxIdleTime = DefaultConnMaxIdleTime
+	}
+	return p
+}
+
+// ConfigFromEnv creates a Config from environment variables with sensible defaults.
+// DATABASE_REPLICAS is an optional comma-separated list of read replicas, each a
+// connection URL (postgres://...) or a host[:port] using the primary's credentials.
+// DATABASE_MAX_OPEN_CONNS, DATABASE_MAX_IDLE_CONNS, DATABASE_CONN_MAX_LIFETIME, and
+// DATABASE_CONN_MAX_IDLE_TIME size the connection pools.
+// DB_DRIVER=sqlite stores everything in the SQLite file DATABASE_PATH instead
+// of Postgres, for single-instance deployments.
 func ConfigFromEnv() (Config, error) {
 	cfg := Config{
+		Driver:   os.Getenv("DB_DRIVER"),
 		Host:     getEnvOrDefault("DATABASE_HOST", "localhost"),
 		User:     getEnvOrDefault("DATABASE_USER", "postgres"),
 		Password: os.Getenv("DATABASE_PASSWORD"),
 		Database: getEnvOrDefault("DATABASE_NAME", "survey"),
 		SSLMode:  getEnvOrDefault("DATABASE_SSLMODE", "disable"),
 	}
+	if cfg.Driver == DriverSQLite {
+		cfg.Path = getEnvOrDefault("DATABASE_PATH", "survey.db")
+	}
+	for _, replica := range strings.Split(os.Getenv("DATABASE_REPLICAS"), ",") {
+		if replica = strings.TrimSpace(replica); replica != "" {
+			cfg.Replicas = append(cfg.Replicas, replica)
+		}
+	}
 
 	// Parse port with default
 	portStr := getEnvOrDefault("DATABASE_PORT", "5432")
@@ -41,6 +103,34 @@ func ConfigFromEnv() (Config, error) {
 	}
 	cfg.Port = port
 
+	// Parse pool sizes and durations, leaving unset ones to the defaults
+	for _, v := range []struct {
+		key string
+		dst *int
+	}{
+		{"DATABASE_MAX_OPEN_CONNS", &cfg.Pool.MaxOpenConns},
+		{"DATABASE_MAX_IDLE_CONNS", &cfg.Pool.MaxIdleConns},
+	} {
+		if s := os.Getenv(v.key); s != "" {
+			if *v.dst, err = strconv.Atoi(s); err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %w", v.key, err)
+			}
+		}
+	}
+	for _, v := range []struct {
+		key string
+		dst *time.Duration
+	}{
+		{"DATABASE_CONN_MAX_LIFETIME", &cfg.Pool.ConnMaxLifetime},
+		{"DATABASE_CONN_MAX_IDLE_TIME", &cfg.Pool.ConnMaxIdleTime},
+	} {
+		if s := os.Getenv(v.key); s != "" {
+			if *v.dst, err = time.ParseDuration(s); err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %w", v.key, err)
+			}
+		}
+	}
+
 	// Validate the config
 	if err := cfg.Validate(); err != nil {
 		return Config{}, err
@@ -51,11 +141,29 @@ func ConfigFromEnv() (Config, error) {
 
 // Validate checks that required configuration fields are set
 func (c Config) Validate() error {
-	if c.Password == "" {
-		return fmt.Errorf("DATABASE_PASSWORD is required")
+	switch c.Driver {
+	case "", DriverPostgres:
+		if c.Password == "" {
+			return fmt.Errorf("DATABASE_PASSWORD is required")
+		}
+		if c.Port <= 0 {
+			return fmt.Errorf("port must be positive, got %d", c.Port)
+		}
+	case DriverSQLite:
+		if c.Path == "" {
+			return fmt.Errorf("DATABASE_PATH is required")
+		}
+		if len(c.Replicas) > 0 {
+			return fmt.Errorf("read replicas are not supported with SQLite")
+		}
+	default:
+		return fmt.Errorf("unknown DB_DRIVER %q: use %s or %s", c.Driver, DriverPostgres, DriverSQLite)
+	}
+	if c.Pool.MaxOpenConns < 0 || c.Pool.MaxIdleConns < 0 || c.Pool.ConnMaxLifetime < 0 || c.Pool.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("pool sizes and durations must not be negative")
 	}
-	if c.Port <= 0 {
-		return fmt.Errorf("port must be positive, got %d", c.Port)
+	if pool := c.Pool.withDefaults(); pool.MaxIdleConns > pool.MaxOpenConns {
+		return fmt.Errorf("max idle connections (%d) must not exceed max open connections (%d)", pool.MaxIdleConns, pool.MaxOpenConns)
 	}
 	return nil
 }
@@ -68,54 +176,132 @@ func (c Config) ConnectionString() string {
 	)
 }
 
-// Connect establishes a database connection with OpenTelemetry instrumentation
+// ReplicaConnectionStrings returns the connection strings of the read replicas.
+// Replicas given as host[:port] use the primary's user, password, database, and SSL mode.
+func (c Config) ReplicaConnectionStrings() []string {
+	dsns := make([]string, 0, len(c.Replicas))
+	for _, replica := range c.Replicas {
+		if strings.Contains(replica, "://") || strings.Contains(replica, "=") {
+			dsns = append(dsns, replica)
+			continue
+		}
+		replicaCfg := c
+		replicaCfg.Host = replica
+		if host, port, err := net.SplitHostPort(replica); err == nil {
+			if p, err := strconv.Atoi(port); err == nil {
+				replicaCfg.Host, replicaCfg.Port = host, p
+			}
+		}
+		dsns = append(dsns, replicaCfg.ConnectionString())
+	}
+	return dsns
+}
+
+// Connect establishes a database connection, with OpenTelemetry instrumentation
+// of Postgres queries
 func Connect(ctx context.Context, cfg Config) (*sql.DB, error) {
 	// Validate config before attempting connection
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
+	if cfg.Driver == DriverSQLite {
+		return openSQLite(ctx, cfg.Path, cfg.Pool)
+	}
+	return open(ctx, cfg.ConnectionString(), cfg.Database, "primary", cfg.Pool)
+}
 
-	// Register the pgx driver with OpenTelemetry instrumentation
-	driverName, err := otelsql.Register(
-		"pgx",
-		otelsql.WithAttributes(
-			semconv.DBSystemPostgreSQL,
-			attribute.String("db.name", cfg.Database),
-		),
-	)
+// ConnectReplicas connects to the configured read replicas. A replica that
+// cannot be reached fails startup, like the primary, so a typo is not silently
+// ignored; replicas failing later fall back to the primary.
+func ConnectReplicas(ctx context.Context, cfg Config) ([]*sql.DB, error) {
+	var replicas []*sql.DB
+	for i, dsn := range cfg.ReplicaConnectionStrings() {
+		db, err := open(ctx, dsn, cfg.Database, "replica", cfg.Pool)
+		if err != nil {
+			for _, r := range replicas {
+				r.Close()
+			}
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+		replicas = append(replicas, db)
+	}
+	return replicas, nil
+}
+
+// cancelDeadlineDelay is how long a cancelled query has to stop after the
+// server is asked to cancel it, before its connection is closed
+const cancelDeadlineDelay = 5 * time.Second
+
+// poolConfig parses a DSN into the config of a pgx pool of the given size,
+// tracing queries with the database name and role
+func poolConfig(dsn, database, role string, pool PoolConfig) (*pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database config: %w", err)
+	}
+
+	// Trace queries and COPYs with OpenTelemetry
+	config.ConnConfig.Tracer = newQueryTracer(database, role)
+
+	// Ask the server to cancel a query when its context is done, instead of
+	// only dropping the connection while the query keeps running
+	config.ConnConfig.BuildContextWatcherHandler = func(conn *pgconn.PgConn) ctxwatch.Handler {
+		return &pgconn.CancelRequestContextWatcherHandler{Conn: conn, DeadlineDelay: cancelDeadlineDelay}
+	}
+
+	// Statements are prepared once per connection and cached (pgx's default
+	// exec mode). Behind PgBouncer in transaction mode, set
+	// default_query_exec_mode=exec in the DSN instead.
+
+	pool = pool.withDefaults()
+	config.MaxConns = int32(pool.MaxOpenConns)
+	config.MaxConnLifetime = pool.ConnMaxLifetime
+	config.MaxConnIdleTime = pool.ConnMaxIdleTime
+	return config, nil
+}
+
+// open opens and pings a pgx pool of the given size, tracing queries with the
+// database name and role. Queries use the pool natively; the returned
+// database/sql handle borrows connections from it for other packages.
+func open(ctx context.Context, dsn, database, role string, pool PoolConfig) (*sql.DB, error) {
+	config, err := poolConfig(dsn, database, role, pool)
 	if err != nil {
-		return nil, fmt.Errorf("failed to register otelsql driver: %w", err)
+		return nil, err
+	}
+
+	// Close released connections beyond the idle limit
+	var pgxPool *pgxpool.Pool
+	maxIdle := int32(pool.withDefaults().MaxIdleConns)
+	config.AfterRelease = func(*pgx.Conn) bool {
+		return pgxPool.Stat().IdleConns() < maxIdle
 	}
 
-	// Open database connection
-	db, err := sql.Open(driverName, cfg.ConnectionString())
+	pgxPool, err = pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	// SetConnMaxIdleTime sets the maximum amount of time a connection may be idle
-	// db.SetConnMaxIdleTime(5 * time.Minute)
-	// SetConnMaxLifetime sets the maximum amount of time a connection may be reused
-	// db.SetConnMaxLifetime(30 * time.Minute)
-
 	// Verify connection
-	if err := db.PingContext(ctx); err != nil {
-		db.Close()
+	if err := pgxPool.Ping(ctx); err != nil {
+		pgxPool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
+	db := stdlib.OpenDBFromPool(pgxPool)
+	pgxPools.Store(db, pgxPool)
 	return db, nil
 }
 
-// Close closes the database connection
+// Close closes the database connection, and its pgx pool if it has one
 func Close(db *sql.DB) error {
 	if db == nil {
 		return nil
 	}
-	return db.Close()
+	err := db.Close()
+	if pool, ok := pgxPools.LoadAndDelete(db); ok {
+		pool.(*pgxpool.Pool).Close()
+	}
+	return err
 }
 
 // getEnvOrDefault returns environment variable value or default if not set
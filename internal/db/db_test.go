@@ -2,7 +2,12 @@ package db
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 func TestConfigFromEnv(t *testing.T) {
@@ -47,6 +52,23 @@ func TestConfigFromEnv(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "replicas are split and trimmed",
+			envVars: map[string]string{
+				"DATABASE_PASSWORD": "testpass",
+				"DATABASE_REPLICAS": "replica-1, replica-2:5433,",
+			},
+			want: Config{
+				Host:     "localhost",
+				Port:     5432,
+				User:     "postgres",
+				Password: "testpass",
+				Database: "survey",
+				SSLMode:  "disable",
+				Replicas: []string{"replica-1", "replica-2:5433"},
+			},
+			wantErr: false,
+		},
 		{
 			name:    "missing password returns error",
 			envVars: map[string]string{},
@@ -100,11 +122,55 @@ func TestConfigFromEnv(t *testing.T) {
 				if got.SSLMode != tt.want.SSLMode {
 					t.Errorf("ConfigFromEnv().SSLMode = %v, want %v", got.SSLMode, tt.want.SSLMode)
 				}
+				if strings.Join(got.Replicas, ",") != strings.Join(tt.want.Replicas, ",") {
+					t.Errorf("ConfigFromEnv().Replicas = %v, want %v", got.Replicas, tt.want.Replicas)
+				}
 			}
 		})
 	}
 }
 
+func TestConfigFromEnv_Pool(t *testing.T) {
+	clearDBEnv()
+	defer clearDBEnv()
+	os.Setenv("DATABASE_PASSWORD", "testpass")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+	want := PoolConfig{MaxOpenConns: DefaultMaxOpenConns, MaxIdleConns: DefaultMaxIdleConns, ConnMaxLifetime: DefaultConnMaxLifetime, ConnMaxIdleTime: DefaultConnMaxIdleTime}
+	if got := cfg.Pool.withDefaults(); got != want {
+		t.Errorf("default pool = %+v, want %+v", got, want)
+	}
+
+	os.Setenv("DATABASE_MAX_OPEN_CONNS", "50")
+	os.Setenv("DATABASE_MAX_IDLE_CONNS", "10")
+	os.Setenv("DATABASE_CONN_MAX_LIFETIME", "1h")
+	os.Setenv("DATABASE_CONN_MAX_IDLE_TIME", "90s")
+	cfg, err = ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+	want = PoolConfig{MaxOpenConns: 50, MaxIdleConns: 10, ConnMaxLifetime: time.Hour, ConnMaxIdleTime: 90 * time.Second}
+	if cfg.Pool != want {
+		t.Errorf("ConfigFromEnv().Pool = %+v, want %+v", cfg.Pool, want)
+	}
+
+	// Idle connections above the default maximum are rejected
+	os.Unsetenv("DATABASE_MAX_OPEN_CONNS")
+	os.Setenv("DATABASE_MAX_IDLE_CONNS", "30")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("ConfigFromEnv() with more idle than open connections should fail")
+	}
+
+	os.Setenv("DATABASE_MAX_IDLE_CONNS", "5")
+	os.Setenv("DATABASE_CONN_MAX_LIFETIME", "30")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("ConfigFromEnv() with a duration without unit should fail")
+	}
+}
+
 func TestConfigValidate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -159,6 +225,26 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:    "sqlite without password",
+			config:  Config{Driver: DriverSQLite, Path: "survey.db"},
+			wantErr: false,
+		},
+		{
+			name:    "sqlite missing path",
+			config:  Config{Driver: DriverSQLite},
+			wantErr: true,
+		},
+		{
+			name:    "sqlite with replicas",
+			config:  Config{Driver: DriverSQLite, Path: "survey.db", Replicas: []string{"replica-1"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown driver",
+			config:  Config{Driver: "mysql", Password: "secret", Port: 3306},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -189,6 +275,49 @@ func TestConfigConnectionString(t *testing.T) {
 	}
 }
 
+func TestPoolConfig(t *testing.T) {
+	config, err := poolConfig("host=db.example.com user=testuser dbname=testdb sslmode=disable", "testdb", "primary", PoolConfig{MaxOpenConns: 7})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+
+	if _, ok := config.ConnConfig.Tracer.(*queryTracer); !ok {
+		t.Errorf("poolConfig() Tracer = %T, want *queryTracer", config.ConnConfig.Tracer)
+	}
+	if config.ConnConfig.DefaultQueryExecMode != pgx.QueryExecModeCacheStatement {
+		t.Errorf("poolConfig() DefaultQueryExecMode = %v, want cached statements", config.ConnConfig.DefaultQueryExecMode)
+	}
+
+	handler, ok := config.ConnConfig.BuildContextWatcherHandler(nil).(*pgconn.CancelRequestContextWatcherHandler)
+	if !ok {
+		t.Fatalf("poolConfig() context watcher = %T, want *pgconn.CancelRequestContextWatcherHandler", handler)
+	}
+	if handler.DeadlineDelay != cancelDeadlineDelay {
+		t.Errorf("poolConfig() DeadlineDelay = %v, want %v", handler.DeadlineDelay, cancelDeadlineDelay)
+	}
+
+	// The pool is sized by the pool config, with defaults for unset fields
+	if config.MaxConns != 7 {
+		t.Errorf("poolConfig() MaxConns = %d, want 7", config.MaxConns)
+	}
+	if config.MaxConnLifetime != DefaultConnMaxLifetime || config.MaxConnIdleTime != DefaultConnMaxIdleTime {
+		t.Errorf("poolConfig() lifetimes = %v, %v, want the defaults", config.MaxConnLifetime, config.MaxConnIdleTime)
+	}
+
+	// The DSN can opt out of cached statements, e.g. behind PgBouncer
+	config, err = poolConfig("host=db.example.com default_query_exec_mode=exec", "testdb", "primary", PoolConfig{})
+	if err != nil {
+		t.Fatalf("poolConfig() error = %v", err)
+	}
+	if config.ConnConfig.DefaultQueryExecMode != pgx.QueryExecModeExec {
+		t.Errorf("poolConfig() DefaultQueryExecMode = %v, want exec", config.ConnConfig.DefaultQueryExecMode)
+	}
+
+	if _, err := poolConfig("host=db.example.com port=notaport", "testdb", "primary", PoolConfig{}); err == nil {
+		t.Error("poolConfig() with an invalid DSN succeeded, want error")
+	}
+}
+
 // clearDBEnv clears all database-related environment variables
 func clearDBEnv() {
 	os.Unsetenv("DATABASE_HOST")
@@ -197,4 +326,9 @@ func clearDBEnv() {
 	os.Unsetenv("DATABASE_PASSWORD")
 	os.Unsetenv("DATABASE_NAME")
 	os.Unsetenv("DATABASE_SSLMODE")
+	os.Unsetenv("DATABASE_REPLICAS")
+	os.Unsetenv("DATABASE_MAX_OPEN_CONNS")
+	os.Unsetenv("DATABASE_MAX_IDLE_CONNS")
+	os.Unsetenv("DATABASE_CONN_MAX_LIFETIME")
+	os.Unsetenv("DATABASE_CONN_MAX_IDLE_TIME")
 }
@@ -0,0 +1,260 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/draft"
+)
+
+// CreateDraft implements the draft.Store interface
+func (q *Queries) CreateDraft(ctx context.Context, d *draft.Draft) error {
+	query := `
+		INSERT INTO survey_drafts (id, owner, title, slug, format, content, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := q.db.ExecContext(ctx, query,
+		d.ID,
+		d.Owner,
+		d.Title,
+		d.Slug,
+		d.Format,
+		d.Content,
+		d.CreatedAt,
+		d.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert draft: %w", err)
+	}
+
+	return nil
+}
+
+// GetDraft implements the draft.Store interface
+// Returns sql.ErrNoRows if the owner has no such draft
+func (q *Queries) GetDraft(ctx context.Context, id uuid.UUID, owner string) (*draft.Draft, error) {
+	query := `
+		SELECT ` + draftColumns + `
+		FROM survey_drafts
+		WHERE id = $1 AND owner = $2
+	`
+
+	d, err := scanDraft(q.db.QueryRowContext(ctx, query, id, owner))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+
+	return d, nil
+}
+
+// ListDrafts implements the draft.Store interface
+// Returns an owner's drafts, most recently saved first
+func (q *Queries) ListDrafts(ctx context.Context, owner string) ([]*draft.Draft, error) {
+	query := `
+		SELECT ` + draftColumns + `
+		FROM survey_drafts
+		WHERE owner = $1
+		ORDER BY updated_at DESC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list drafts: %w", err)
+	}
+	defer rows.Close()
+
+	var drafts []*draft.Draft
+	for rows.Next() {
+		d, err := scanDraft(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan draft: %w", err)
+		}
+		drafts = append(drafts, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating drafts: %w", err)
+	}
+
+	return drafts, nil
+}
+
+// CountDrafts implements the draft.Store interface
+func (q *Queries) CountDrafts(ctx context.Context, owner string) (int, error) {
+	query := `SELECT COUNT(*) FROM survey_drafts WHERE owner = $1`
+
+	var count int
+	if err := q.db.QueryRowContext(ctx, query, owner).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count drafts: %w", err)
+	}
+
+	return count, nil
+}
+
+// UpdateDraft implements the draft.Store interface
+// Returns sql.ErrNoRows if the owner has no such draft
+func (q *Queries) UpdateDraft(ctx context.Context, d *draft.Draft) error {
+	query := `
+		UPDATE survey_drafts
+		SET title = $3, slug = $4, format = $5, content = $6, updated_at = $7
+		WHERE id = $1 AND owner = $2
+	`
+
+	result, err := q.db.ExecContext(ctx, query, d.ID, d.Owner, d.Title, d.Slug, d.Format, d.Content, d.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update draft: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteDraft implements the draft.Store interface
+// Returns sql.ErrNoRows if the owner has no such draft
+func (q *Queries) DeleteDraft(ctx context.Context, id uuid.UUID, owner string) error {
+	query := `DELETE FROM survey_drafts WHERE id = $1 AND owner = $2`
+
+	result, err := q.db.ExecContext(ctx, query, id, owner)
+	if err != nil {
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteExpiredDrafts implements the draft.Store interface
+// Deletes drafts last saved before a time and returns how many were deleted
+func (q *Queries) DeleteExpiredDrafts(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM survey_drafts WHERE updated_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired drafts: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// draftColumns are the columns scanned by scanDraft
+const draftColumns = `id, owner, title, slug, format, content, created_at, updated_at`
+
+// scanDraft scans a row of draftColumns
+func scanDraft(row rowScanner) (*draft.Draft, error) {
+	d := &draft.Draft{}
+	if err := row.Scan(
+		&d.ID,
+		&d.Owner,
+		&d.Title,
+		&d.Slug,
+		&d.Format,
+		&d.Content,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SaveResponseDraft implements the draft.ResponseStore interface
+func (q *Queries) SaveResponseDraft(ctx context.Context, d *draft.ResponseDraft) error {
+	answers, err := json.Marshal(d.Answers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal answers: %w", err)
+	}
+
+	query := `
+		INSERT INTO responses_draft (survey_id, owner, answers, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (survey_id, owner) DO UPDATE
+		SET answers = EXCLUDED.answers, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, d.SurveyID, d.Owner, answers, d.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save response draft: %w", err)
+	}
+
+	return nil
+}
+
+// GetResponseDraft implements the draft.ResponseStore interface
+// Returns sql.ErrNoRows if the owner has no draft for the survey
+func (q *Queries) GetResponseDraft(ctx context.Context, surveyID uuid.UUID, owner string) (*draft.ResponseDraft, error) {
+	query := `
+		SELECT survey_id, owner, answers, updated_at
+		FROM responses_draft
+		WHERE survey_id = $1 AND owner = $2
+	`
+
+	var d draft.ResponseDraft
+	var answers []byte
+	err := q.db.QueryRowContext(ctx, query, surveyID, owner).Scan(&d.SurveyID, &d.Owner, &answers, &d.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get response draft: %w", err)
+	}
+
+	if err := json.Unmarshal(answers, &d.Answers); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal answers: %w", err)
+	}
+
+	return &d, nil
+}
+
+// DeleteResponseDraft implements the draft.ResponseStore interface
+func (q *Queries) DeleteResponseDraft(ctx context.Context, surveyID uuid.UUID, owner string) error {
+	query := `DELETE FROM responses_draft WHERE survey_id = $1 AND owner = $2`
+
+	if _, err := q.db.ExecContext(ctx, query, surveyID, owner); err != nil {
+		return fmt.Errorf("failed to delete response draft: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredResponseDrafts implements the draft.ResponseStore interface
+// Deletes drafts last saved before a time and returns how many were deleted
+func (q *Queries) DeleteExpiredResponseDrafts(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM responses_draft WHERE updated_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired response drafts: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/export"
+)
+
+// exportColumns are the columns scanned by scanExport
+const exportColumns = `id, survey_id, requested_by, format, query, status, blob_key, error, created_at, started_at, completed_at, expires_at`
+
+// scanExport scans a row of exportColumns
+func scanExport(row rowScanner) (*export.Export, error) {
+	e := &export.Export{}
+	err := row.Scan(&e.ID, &e.SurveyID, &e.RequestedBy, &e.Format, &e.Query, &e.Status, &e.BlobKey, &e.Error,
+		&e.CreatedAt, &e.StartedAt, &e.CompletedAt, &e.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// CreateExport implements the export.Store interface
+func (q *Queries) CreateExport(ctx context.Context, e *export.Export) error {
+	query := `
+		INSERT INTO response_exports (id, survey_id, requested_by, format, query, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := q.db.ExecContext(ctx, query, e.ID, e.SurveyID, e.RequestedBy, e.Format, e.Query, e.Status, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create export: %w", err)
+	}
+	return nil
+}
+
+// GetExport implements the export.Store interface
+func (q *Queries) GetExport(ctx context.Context, id uuid.UUID) (*export.Export, error) {
+	query := `SELECT ` + exportColumns + ` FROM response_exports WHERE id = $1`
+
+	e, err := scanExport(q.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+	return e, nil
+}
+
+// StartExport implements the export.Store interface
+func (q *Queries) StartExport(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE response_exports SET status = 'running', started_at = NOW() WHERE id = $1`
+
+	if _, err := q.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to start export: %w", err)
+	}
+	return nil
+}
+
+// CompleteExport implements the export.Store interface
+func (q *Queries) CompleteExport(ctx context.Context, id uuid.UUID, blobKey string, expiresAt time.Time) error {
+	query := `
+		UPDATE response_exports
+		SET status = 'done', blob_key = $2, completed_at = NOW(), expires_at = $3
+		WHERE id = $1
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, id, blobKey, expiresAt); err != nil {
+		return fmt.Errorf("failed to complete export: %w", err)
+	}
+	return nil
+}
+
+// FailExport implements the export.Store interface
+func (q *Queries) FailExport(ctx context.Context, id uuid.UUID, reason string, expiresAt time.Time) error {
+	query := `
+		UPDATE response_exports
+		SET status = 'failed', error = $2, completed_at = NOW(), expires_at = $3
+		WHERE id = $1
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, id, reason, expiresAt); err != nil {
+		return fmt.Errorf("failed to mark export as failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredExports implements the export.Store interface
+func (q *Queries) DeleteExpiredExports(ctx context.Context, now time.Time) ([]*export.Export, error) {
+	query := `DELETE FROM response_exports WHERE expires_at < $1 RETURNING ` + exportColumns
+
+	rows, err := q.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired exports: %w", err)
+	}
+	defer rows.Close()
+
+	var expired []*export.Export
+	for rows.Next() {
+		e, err := scanExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan export: %w", err)
+		}
+		expired = append(expired, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired exports: %w", err)
+	}
+	return expired, nil
+}
@@ -0,0 +1,63 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/export"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseExports(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	survey := &models.Survey{
+		ID:         uuid.New(),
+		Slug:       "poll",
+		Title:      "Poll",
+		Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}}},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	first := &export.Export{ID: uuid.New(), SurveyID: survey.ID, RequestedBy: "did:plc:alice", Format: export.FormatCSV, Query: "voter=did", Status: export.StatusPending, CreatedAt: now.Add(-time.Minute)}
+	second := &export.Export{ID: uuid.New(), SurveyID: survey.ID, RequestedBy: "did:plc:alice", Format: export.FormatJSON, Status: export.StatusPending, CreatedAt: now}
+	require.NoError(t, queries.CreateExport(ctx, first))
+	require.NoError(t, queries.CreateExport(ctx, second))
+
+	require.NoError(t, queries.StartExport(ctx, first.ID))
+	running, err := queries.GetExport(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, export.StatusRunning, running.Status)
+	assert.NotNil(t, running.StartedAt)
+	assert.Equal(t, "voter=did", running.Query)
+
+	require.NoError(t, queries.CompleteExport(ctx, first.ID, "exports/first/poll-responses.csv", now.Add(export.TTL)))
+	require.NoError(t, queries.FailExport(ctx, second.ID, "Failed to build the export", now.Add(-time.Second)))
+
+	done, err := queries.GetExport(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, export.StatusDone, done.Status)
+	require.NotNil(t, done.BlobKey)
+	assert.Equal(t, "exports/first/poll-responses.csv", *done.BlobKey)
+	assert.NotNil(t, done.CompletedAt)
+
+	expired, err := queries.DeleteExpiredExports(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, second.ID, expired[0].ID)
+
+	_, err = queries.GetExport(ctx, second.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// ListFeedSurveys implements the feed.Store interface
+// Returns the newest listed surveys with an AT URI, of one author or of
+// everyone if authorDID is empty
+func (q *Queries) ListFeedSurveys(ctx context.Context, authorDID string, limit int) ([]*models.Survey, error) {
+	if q.replicas != nil {
+		return readFromReplica(ctx, q, func(r *Queries) ([]*models.Survey, error) { return r.ListFeedSurveys(ctx, authorDID, limit) })
+	}
+
+	query := `
+		SELECT s.id, s.uri, s.cid, s.author_did, s.slug, s.title, s.description, s.definition, s.version, s.starts_at, s.ends_at, s.results_uri, s.results_cid, s.created_at, s.updated_at, s.hidden_at, s.org_id
+		FROM surveys s
+		WHERE ` + listedSurvey + `
+			AND s.uri IS NOT NULL
+			AND ($1 = '' OR s.author_did = $1)
+		ORDER BY s.created_at DESC, s.id
+		LIMIT $2
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, authorDID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feed surveys: %w", err)
+	}
+	defer rows.Close()
+
+	var surveys []*models.Survey
+	for rows.Next() {
+		survey := &models.Survey{}
+		var defJSON []byte
+
+		err := rows.Scan(
+			&survey.ID,
+			&survey.URI,
+			&survey.CID,
+			&survey.AuthorDID,
+			&survey.Slug,
+			&survey.Title,
+			&survey.Description,
+			&defJSON,
+			&survey.Version,
+			&survey.StartsAt,
+			&survey.EndsAt,
+			&survey.ResultsURI,
+			&survey.ResultsCID,
+			&survey.CreatedAt,
+			&survey.UpdatedAt,
+			&survey.HiddenAt,
+			&survey.OrgID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed survey: %w", err)
+		}
+
+		if err := json.Unmarshal(defJSON, &survey.Definition); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal survey definition: %w", err)
+		}
+
+		surveys = append(surveys, survey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feed surveys: %w", err)
+	}
+
+	return surveys, nil
+}
@@ -0,0 +1,67 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListFeedSurveys(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	alice, bob := "did:plc:alice", "did:plc:bob"
+
+	newSurvey := func(slug string, author string, indexed bool, visibility string, created time.Time) *models.Survey {
+		survey := &models.Survey{
+			ID:        uuid.New(),
+			AuthorDID: &author,
+			Slug:      slug,
+			Title:     slug,
+			Definition: models.SurveyDefinition{
+				Visibility: visibility,
+				Questions:  []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}},
+			},
+			CreatedAt: created,
+			UpdatedAt: created,
+		}
+		if indexed {
+			uri := "at://" + author + "/net.openmeet.survey/" + slug
+			survey.URI = &uri
+		}
+		require.NoError(t, queries.CreateSurvey(ctx, survey))
+		return survey
+	}
+
+	newSurvey("old", alice, true, "", now.Add(-2*time.Hour))
+	newSurvey("new", alice, true, "", now.Add(-time.Hour))
+	newSurvey("bobs", bob, true, "", now)
+	newSurvey("local", alice, false, "", now)
+	newSurvey("unlisted", alice, true, models.VisibilityUnlisted, now)
+	hidden := newSurvey("hidden", alice, true, "", now)
+	_, err := database.ExecContext(ctx, `UPDATE surveys SET hidden_at = NOW() WHERE id = $1`, hidden.ID)
+	require.NoError(t, err)
+
+	slugs := func(authorDID string, limit int) []string {
+		surveys, err := queries.ListFeedSurveys(ctx, authorDID, limit)
+		require.NoError(t, err)
+		var slugs []string
+		for _, s := range surveys {
+			slugs = append(slugs, s.Slug)
+		}
+		return slugs
+	}
+
+	assert.Equal(t, []string{"bobs", "new", "old"}, slugs("", 10), "only listed surveys indexed from the network, newest first")
+	assert.Equal(t, []string{"new", "old"}, slugs(alice, 10))
+	assert.Equal(t, []string{"bobs"}, slugs("", 1))
+	assert.Empty(t, slugs("did:plc:nobody", 10))
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/moderation"
+)
+
+// CreateFlaggedResponse implements the moderation.Store interface
+// Stores a flagged text answer for review. Flagging an answer again keeps the
+// existing review decision if the text is unchanged, otherwise it is pending again.
+func (q *Queries) CreateFlaggedResponse(ctx context.Context, f *moderation.FlaggedResponse) error {
+	query := `
+		INSERT INTO flagged_responses (id, response_id, survey_id, question_id, text, source, reason, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (response_id, question_id) DO UPDATE
+		SET source = EXCLUDED.source,
+			reason = EXCLUDED.reason,
+			status = CASE WHEN flagged_responses.text = EXCLUDED.text THEN flagged_responses.status ELSE EXCLUDED.status END,
+			reviewed_by = CASE WHEN flagged_responses.text = EXCLUDED.text THEN flagged_responses.reviewed_by END,
+			reviewed_at = CASE WHEN flagged_responses.text = EXCLUDED.text THEN flagged_responses.reviewed_at END,
+			text = EXCLUDED.text
+	`
+
+	_, err := q.db.ExecContext(ctx, query,
+		f.ID,
+		f.ResponseID,
+		f.SurveyID,
+		f.QuestionID,
+		f.Text,
+		f.Source,
+		f.Reason,
+		f.Status,
+		f.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert flagged response: %w", err)
+	}
+
+	return nil
+}
+
+// ListFlaggedResponsesBySurvey returns the flagged answers of a survey, pending first
+func (q *Queries) ListFlaggedResponsesBySurvey(ctx context.Context, surveyID uuid.UUID) ([]*moderation.FlaggedResponse, error) {
+	query := `
+		SELECT id, response_id, survey_id, question_id, text, source, reason, status, reviewed_by, reviewed_at, created_at
+		FROM flagged_responses
+		WHERE survey_id = $1
+		ORDER BY (status = 'pending') DESC, created_at DESC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flagged responses: %w", err)
+	}
+	defer rows.Close()
+
+	var flagged []*moderation.FlaggedResponse
+	for rows.Next() {
+		f := &moderation.FlaggedResponse{}
+		if err := rows.Scan(
+			&f.ID,
+			&f.ResponseID,
+			&f.SurveyID,
+			&f.QuestionID,
+			&f.Text,
+			&f.Source,
+			&f.Reason,
+			&f.Status,
+			&f.ReviewedBy,
+			&f.ReviewedAt,
+			&f.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan flagged response: %w", err)
+		}
+		flagged = append(flagged, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating flagged responses: %w", err)
+	}
+
+	return flagged, nil
+}
+
+// ReviewFlaggedResponse records a review decision for a flagged answer of a survey.
+// Returns sql.ErrNoRows if the flag does not belong to the survey.
+func (q *Queries) ReviewFlaggedResponse(ctx context.Context, id, surveyID uuid.UUID, status, reviewerDID string) error {
+	query := `
+		UPDATE flagged_responses
+		SET status = $3, reviewed_by = $4, reviewed_at = NOW()
+		WHERE id = $1 AND survey_id = $2
+	`
+
+	result, err := q.db.ExecContext(ctx, query, id, surveyID, status, reviewerDID)
+	if err != nil {
+		return fmt.Errorf("failed to review flagged response: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteFlaggedResponse implements the moderation.Store interface
+// Removes the flag of a single answer, if any
+func (q *Queries) DeleteFlaggedResponse(ctx context.Context, responseID uuid.UUID, questionID string) error {
+	query := `DELETE FROM flagged_responses WHERE response_id = $1 AND question_id = $2`
+
+	if _, err := q.db.ExecContext(ctx, query, responseID, questionID); err != nil {
+		return fmt.Errorf("failed to delete flagged response: %w", err)
+	}
+
+	return nil
+}
+
+// CreateModeratedResponse creates a response and the flags of its flagged
+// answers in one transaction, so flagged answers never appear in results
+// before they are reviewed
+func (q *Queries) CreateModeratedResponse(ctx context.Context, r *models.Response, flags []*moderation.FlaggedResponse) error {
+	return q.InTx(ctx, func(tx *Queries) error {
+		if err := tx.CreateResponse(ctx, r); err != nil {
+			return err
+		}
+		for _, f := range flags {
+			if err := tx.CreateFlaggedResponse(ctx, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// getHiddenTextAnswers returns the flagged answers of a survey that are not approved,
+// keyed by response ID and question ID
+func (q *Queries) getHiddenTextAnswers(ctx context.Context, surveyID uuid.UUID) (map[uuid.UUID]map[string]bool, error) {
+	query := `
+		SELECT response_id, question_id
+		FROM flagged_responses
+		WHERE survey_id = $1 AND status != 'approved'
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hidden answers: %w", err)
+	}
+	defer rows.Close()
+
+	hidden := make(map[uuid.UUID]map[string]bool)
+	for rows.Next() {
+		var responseID uuid.UUID
+		var questionID string
+		if err := rows.Scan(&responseID, &questionID); err != nil {
+			return nil, fmt.Errorf("failed to scan hidden answer: %w", err)
+		}
+		if hidden[responseID] == nil {
+			hidden[responseID] = make(map[string]bool)
+		}
+		hidden[responseID][questionID] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating hidden answers: %w", err)
+	}
+
+	return hidden, nil
+}
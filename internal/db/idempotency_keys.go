@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/idempotency"
+)
+
+// ClaimIdempotencyKey implements the idempotency.Store interface
+// An expired key, or one whose request ran longer than claimTimeout, is
+// claimed again
+func (q *Queries) ClaimIdempotencyKey(ctx context.Context, r *idempotency.Record, now time.Time, claimTimeout time.Duration) (*idempotency.Record, error) {
+	query := `
+		INSERT INTO idempotency_keys (key, fingerprint, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, response_id = NULL, status = NULL, content_type = NULL, body = NULL,
+			created_at = EXCLUDED.created_at, completed_at = NULL, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= $5
+			OR (idempotency_keys.completed_at IS NULL AND idempotency_keys.created_at <= $6)
+		RETURNING key
+	`
+
+	var key string
+	err := q.db.QueryRowContext(ctx, query, r.Key, r.Fingerprint, r.CreatedAt, r.ExpiresAt, now, now.Add(-claimTimeout)).Scan(&key)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	// Held by another request
+	existing := &idempotency.Record{Key: r.Key}
+	query = `
+		SELECT fingerprint, response_id, COALESCE(status, 0), COALESCE(content_type, ''), body, created_at, completed_at, expires_at
+		FROM idempotency_keys
+		WHERE key = $1
+	`
+	err = q.db.QueryRowContext(ctx, query, r.Key).Scan(&existing.Fingerprint, &existing.ResponseID, &existing.Status,
+		&existing.ContentType, &existing.Body, &existing.CreatedAt, &existing.CompletedAt, &existing.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return existing, nil
+}
+
+// CompleteIdempotencyKey implements the idempotency.Store interface
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, key string, responseID uuid.UUID, status int, contentType string, body []byte, now time.Time) error {
+	query := `
+		UPDATE idempotency_keys
+		SET response_id = $2, status = $3, content_type = $4, body = $5, completed_at = $6
+		WHERE key = $1
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, key, responseID, status, contentType, body, now); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey implements the idempotency.Store interface
+func (q *Queries) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND completed_at IS NULL`
+
+	if _, err := q.db.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys implements the idempotency.Store interface
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
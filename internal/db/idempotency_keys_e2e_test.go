@@ -0,0 +1,72 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/idempotency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	record := func(key string, createdAt time.Time) *idempotency.Record {
+		return &idempotency.Record{Key: key, Fingerprint: "fp", CreatedAt: createdAt, ExpiresAt: createdAt.Add(idempotency.TTL)}
+	}
+
+	existing, err := queries.ClaimIdempotencyKey(ctx, record("a", now), now, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing, "a new key is claimed")
+
+	existing, err = queries.ClaimIdempotencyKey(ctx, record("a", now), now, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, existing, "a running key is held")
+	assert.False(t, existing.Completed())
+
+	responseID := uuid.New()
+	require.NoError(t, queries.CompleteIdempotencyKey(ctx, "a", responseID, 201, "application/json", []byte(`{"id":1}`), now))
+	existing, err = queries.ClaimIdempotencyKey(ctx, record("a", now), now, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.True(t, existing.Completed())
+	assert.Equal(t, &responseID, existing.ResponseID)
+	assert.Equal(t, 201, existing.Status)
+	assert.Equal(t, "application/json", existing.ContentType)
+	assert.Equal(t, `{"id":1}`, string(existing.Body))
+
+	// A key whose request died is claimed again after the claim timeout
+	_, err = queries.ClaimIdempotencyKey(ctx, record("b", now), now, time.Minute)
+	require.NoError(t, err)
+	later := now.Add(2 * time.Minute)
+	existing, err = queries.ClaimIdempotencyKey(ctx, record("b", later), later, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// Released keys can be claimed at once; completed keys are not released
+	require.NoError(t, queries.ReleaseIdempotencyKey(ctx, "b"))
+	existing, err = queries.ClaimIdempotencyKey(ctx, record("b", now), now, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	require.NoError(t, queries.ReleaseIdempotencyKey(ctx, "a"))
+	existing, err = queries.ClaimIdempotencyKey(ctx, record("a", now), now, time.Minute)
+	require.NoError(t, err)
+	assert.NotNil(t, existing)
+
+	// Expired keys are deleted, or claimed anew
+	expired := now.Add(idempotency.TTL + time.Second)
+	existing, err = queries.ClaimIdempotencyKey(ctx, record("a", expired), expired, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	n, err := queries.DeleteExpiredIdempotencyKeys(ctx, expired)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only b expired")
+}
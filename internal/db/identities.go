@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/openmeet-team/survey/internal/identity"
+)
+
+// GetIdentities implements the identity.Store interface
+// Returns the cached identities of the given DIDs; unknown DIDs are omitted
+func (q *Queries) GetIdentities(ctx context.Context, dids []string) ([]*identity.Identity, error) {
+	if len(dids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(dids))
+	args := make([]interface{}, len(dids))
+	for i, did := range dids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = did
+	}
+
+	query := `
+		SELECT did, handle, display_name, avatar, fetched_at
+		FROM identities
+		WHERE did IN (` + strings.Join(placeholders, ", ") + `)
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query identities: %w", err)
+	}
+	defer rows.Close()
+
+	var identities []*identity.Identity
+	for rows.Next() {
+		i := &identity.Identity{}
+		if err := rows.Scan(&i.DID, &i.Handle, &i.DisplayName, &i.Avatar, &i.FetchedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan identity: %w", err)
+		}
+		identities = append(identities, i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating identities: %w", err)
+	}
+
+	return identities, nil
+}
+
+// UpsertIdentities implements the identity.Store interface
+// Inserts or refreshes cached identities
+func (q *Queries) UpsertIdentities(ctx context.Context, identities []*identity.Identity) error {
+	query := `
+		INSERT INTO identities (did, handle, display_name, avatar, fetched_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (did) DO UPDATE
+		SET handle = EXCLUDED.handle,
+			display_name = EXCLUDED.display_name,
+			avatar = EXCLUDED.avatar,
+			fetched_at = EXCLUDED.fetched_at
+	`
+
+	for _, i := range identities {
+		if _, err := q.db.ExecContext(ctx, query, i.DID, i.Handle, i.DisplayName, i.Avatar, i.FetchedAt); err != nil {
+			return fmt.Errorf("failed to upsert identity %s: %w", i.DID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetVerification implements the identity.VerificationStore interface
+// Returns the cached verification of a DID, or nil if it was never verified
+func (q *Queries) GetVerification(ctx context.Context, did string) (*identity.Verification, error) {
+	query := `
+		SELECT did, handle, verified, checked_at
+		FROM identity_verifications
+		WHERE did = $1
+	`
+
+	v := &identity.Verification{}
+	err := q.db.QueryRowContext(ctx, query, did).Scan(&v.DID, &v.Handle, &v.Verified, &v.CheckedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get verification: %w", err)
+	}
+
+	return v, nil
+}
+
+// UpsertVerification implements the identity.VerificationStore interface
+// Inserts or refreshes a cached verification
+func (q *Queries) UpsertVerification(ctx context.Context, v *identity.Verification) error {
+	query := `
+		INSERT INTO identity_verifications (did, handle, verified, checked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (did) DO UPDATE
+		SET handle = EXCLUDED.handle,
+			verified = EXCLUDED.verified,
+			checked_at = EXCLUDED.checked_at
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, v.DID, v.Handle, v.Verified, v.CheckedAt); err != nil {
+		return fmt.Errorf("failed to upsert verification %s: %w", v.DID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/audit"
+)
+
+// SaveIndexedRecord keeps the value of an indexed survey or response record,
+// replacing the value of its earlier version
+func (q *Queries) SaveIndexedRecord(ctx context.Context, surveyID uuid.UUID, uri, cid string, record map[string]interface{}) error {
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	query := `
+		INSERT INTO indexed_records (uri, survey_id, cid, record)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (uri) DO UPDATE
+		SET survey_id = EXCLUDED.survey_id, cid = EXCLUDED.cid, record = EXCLUDED.record, indexed_at = NOW()
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, uri, surveyID, cid, recordJSON); err != nil {
+		return fmt.Errorf("failed to save indexed record: %w", err)
+	}
+	return nil
+}
+
+// DeleteIndexedRecord removes the value of a deleted record
+func (q *Queries) DeleteIndexedRecord(ctx context.Context, uri string) error {
+	query := `DELETE FROM indexed_records WHERE uri = $1`
+
+	if _, err := q.db.ExecContext(ctx, query, uri); err != nil {
+		return fmt.Errorf("failed to delete indexed record: %w", err)
+	}
+	return nil
+}
+
+// GetIndexedRecord implements the audit.Store interface
+func (q *Queries) GetIndexedRecord(ctx context.Context, uri, cid string) (*audit.Record, error) {
+	query := `SELECT record FROM indexed_records WHERE uri = $1 AND cid = $2`
+
+	var recordJSON []byte
+	err := q.db.QueryRowContext(ctx, query, uri, cid).Scan(&recordJSON)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get indexed record: %w", err)
+	}
+
+	record := &audit.Record{URI: uri, CID: cid}
+	if err := json.Unmarshal(recordJSON, &record.Value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal indexed record: %w", err)
+	}
+	return record, nil
+}
+
+// ListResponseRecords implements the audit.Store interface
+// The value of a response record is only joined if it was kept at the
+// CID the response was indexed with.
+func (q *Queries) ListResponseRecords(ctx context.Context, surveyID uuid.UUID) ([]*audit.Record, error) {
+	query := `
+		SELECT r.record_uri, COALESCE(r.record_cid, ''), ir.record
+		FROM responses r
+		LEFT JOIN indexed_records ir ON ir.uri = r.record_uri AND ir.cid = r.record_cid
+		WHERE r.survey_id = $1 AND r.record_uri IS NOT NULL
+		ORDER BY r.record_uri
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query response records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*audit.Record
+	for rows.Next() {
+		record := &audit.Record{}
+		var recordJSON []byte
+		if err := rows.Scan(&record.URI, &record.CID, &recordJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan response record: %w", err)
+		}
+		if recordJSON != nil {
+			if err := json.Unmarshal(recordJSON, &record.Value); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal response record: %w", err)
+			}
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating response records: %w", err)
+	}
+
+	return records, nil
+}
+
+// CountLocalResponses implements the audit.Store interface
+func (q *Queries) CountLocalResponses(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM responses WHERE survey_id = $1 AND record_uri IS NULL`
+
+	var count int
+	if err := q.db.QueryRowContext(ctx, query, surveyID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count local responses: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/reminder"
+)
+
+// AddInvitations implements the reminder.Store interface
+func (q *Queries) AddInvitations(ctx context.Context, surveyID uuid.UUID, dids []string, by string) (int64, error) {
+	query := `
+		INSERT INTO survey_invitations (survey_id, did, invited_by)
+		SELECT $1, did, $3 FROM unnest($2::text[]) AS did
+		ON CONFLICT (survey_id, did) DO NOTHING
+	`
+	if q.dialect == dialectSQLite {
+		query = `
+			INSERT INTO survey_invitations (survey_id, did, invited_by)
+			SELECT $1, value, $3 FROM json_each($2) WHERE true
+			ON CONFLICT (survey_id, did) DO NOTHING
+		`
+	}
+
+	result, err := q.db.ExecContext(ctx, query, surveyID, dids, by)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add invitations: %w", err)
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return added, nil
+}
+
+// RemoveInvitation implements the reminder.Store interface
+// Returns sql.ErrNoRows if the DID is not invited
+func (q *Queries) RemoveInvitation(ctx context.Context, surveyID uuid.UUID, did string) error {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM survey_invitations WHERE survey_id = $1 AND did = $2`, surveyID, did)
+	if err != nil {
+		return fmt.Errorf("failed to remove invitation: %w", err)
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if removed == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListInvitations implements the reminder.Store interface
+func (q *Queries) ListInvitations(ctx context.Context, surveyID uuid.UUID) ([]*reminder.Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM survey_invitations WHERE survey_id = $1 ORDER BY created_at, did`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	return scanInvitations(rows)
+}
+
+// GetReminderSchedule implements the reminder.Store interface
+func (q *Queries) GetReminderSchedule(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	var hours int
+	err := q.db.QueryRowContext(ctx, `SELECT hours_before FROM reminder_schedules WHERE survey_id = $1`, surveyID).Scan(&hours)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get reminder schedule: %w", err)
+	}
+
+	return hours, nil
+}
+
+// SetReminderSchedule implements the reminder.Store interface
+func (q *Queries) SetReminderSchedule(ctx context.Context, surveyID uuid.UUID, hoursBefore int, by string) error {
+	if hoursBefore == 0 {
+		if _, err := q.db.ExecContext(ctx, `DELETE FROM reminder_schedules WHERE survey_id = $1`, surveyID); err != nil {
+			return fmt.Errorf("failed to remove reminder schedule: %w", err)
+		}
+		return nil
+	}
+
+	query := `
+		INSERT INTO reminder_schedules (survey_id, hours_before, set_by, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (survey_id) DO UPDATE
+		SET hours_before = EXCLUDED.hours_before, set_by = EXCLUDED.set_by, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, surveyID, hoursBefore, by); err != nil {
+		return fmt.Errorf("failed to set reminder schedule: %w", err)
+	}
+
+	return nil
+}
+
+// MarkRespondedInvitations implements the reminder.Store interface
+// An invitee responded when a response of the survey has their DID
+func (q *Queries) MarkRespondedInvitations(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE survey_invitations AS i
+		SET responded_at = r.created_at
+		FROM responses r
+		WHERE i.responded_at IS NULL AND r.survey_id = i.survey_id AND r.voter_did = i.did
+	`
+
+	result, err := q.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark responded invitations: %w", err)
+	}
+
+	marked, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return marked, nil
+}
+
+// ClaimDueReminders implements the reminder.Store interface
+// A reminder is due from its survey's scheduled hours before it ends until it
+// ends. Responses are checked again, as invitees may have just responded, and
+// surveys in the trash are skipped. Due rows are locked, skipping rows
+// another replica is claiming.
+func (q *Queries) ClaimDueReminders(ctx context.Context, now time.Time, limit int) ([]*reminder.Invitation, error) {
+	// SQLite has no intervals, and compares Julian days instead
+	due := `s.ends_at - make_interval(hours => rs.hours_before) <= $1`
+	if q.dialect == dialectSQLite {
+		due = `julianday(s.ends_at) - rs.hours_before / 24.0 <= julianday($1)`
+	}
+
+	query := `
+		UPDATE survey_invitations
+		SET next_attempt_at = $3
+		WHERE (survey_id, did) IN (
+			SELECT i.survey_id, i.did
+			FROM survey_invitations i
+			JOIN reminder_schedules rs ON rs.survey_id = i.survey_id
+			JOIN surveys s ON s.id = i.survey_id AND s.deleted_at IS NULL
+			WHERE i.status = 'invited' AND i.responded_at IS NULL AND i.next_attempt_at <= $1
+				AND s.ends_at > $1 AND ` + due + `
+				AND NOT EXISTS (SELECT 1 FROM responses r WHERE r.survey_id = i.survey_id AND r.voter_did = i.did)
+			ORDER BY i.next_attempt_at
+			LIMIT $2
+			FOR UPDATE OF i SKIP LOCKED
+		)
+		RETURNING ` + invitationColumns
+
+	rows, err := q.db.QueryContext(ctx, query, now, limit, now.Add(reminder.ClaimLease))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due reminders: %w", err)
+	}
+	defer rows.Close()
+
+	return scanInvitations(rows)
+}
+
+// UpdateInvitation implements the reminder.Store interface
+func (q *Queries) UpdateInvitation(ctx context.Context, inv *reminder.Invitation) error {
+	query := `
+		UPDATE survey_invitations
+		SET status = $3, attempts = $4, error = $5, next_attempt_at = $6, reminded_at = $7
+		WHERE survey_id = $1 AND did = $2
+	`
+
+	_, err := q.db.ExecContext(ctx, query, inv.SurveyID, inv.DID, inv.Status, inv.Attempts, inv.Error, inv.NextAttemptAt, inv.RemindedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update invitation: %w", err)
+	}
+
+	return nil
+}
+
+// invitationColumns are the columns scanned by scanInvitations
+const invitationColumns = `survey_id, did, invited_by, status, attempts, error, next_attempt_at, responded_at, reminded_at, created_at`
+
+// scanInvitations scans rows of invitationColumns
+func scanInvitations(rows Rows) ([]*reminder.Invitation, error) {
+	var invitations []*reminder.Invitation
+	for rows.Next() {
+		inv := &reminder.Invitation{}
+		err := rows.Scan(&inv.SurveyID, &inv.DID, &inv.InvitedBy, &inv.Status, &inv.Attempts, &inv.Error,
+			&inv.NextAttemptAt, &inv.RespondedAt, &inv.RemindedAt, &inv.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, inv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating invitations: %w", err)
+	}
+
+	return invitations, nil
+}
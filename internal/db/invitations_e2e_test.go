@@ -0,0 +1,114 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/reminder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitations(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	now := time.Now()
+	endsAt := now.Add(12 * time.Hour)
+	survey := &models.Survey{
+		ID:         uuid.New(),
+		Slug:       "offsite",
+		Title:      "Offsite",
+		Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Where?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "a", Text: "A"}, {ID: "b", Text: "B"}}}}},
+		EndsAt:     &endsAt,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	added, err := queries.AddInvitations(ctx, survey.ID, []string{"did:plc:alice", "did:plc:bob", "did:plc:carol"}, "did:plc:author")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), added)
+	added, err = queries.AddInvitations(ctx, survey.ID, []string{"did:plc:alice", "did:plc:dave"}, "did:plc:author")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), added, "invitees are invited once")
+
+	require.NoError(t, queries.RemoveInvitation(ctx, survey.ID, "did:plc:dave"))
+	assert.ErrorIs(t, queries.RemoveInvitation(ctx, survey.ID, "did:plc:dave"), sql.ErrNoRows)
+
+	// Alice responded
+	alice := "did:plc:alice"
+	require.NoError(t, queries.CreateResponse(ctx, &models.Response{
+		ID:        uuid.New(),
+		SurveyID:  survey.ID,
+		VoterDID:  &alice,
+		Answers:   map[string]models.Answer{"q1": {SelectedOptions: []string{"a"}}},
+		CreatedAt: now,
+	}))
+	marked, err := queries.MarkRespondedInvitations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), marked)
+
+	// Nothing is due without a schedule, nor before its hours
+	due, err := queries.ClaimDueReminders(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	hours, err := queries.GetReminderSchedule(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Zero(t, hours)
+	require.NoError(t, queries.SetReminderSchedule(ctx, survey.ID, 6, "did:plc:author"))
+	hours, err = queries.GetReminderSchedule(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 6, hours)
+
+	due, err = queries.ClaimDueReminders(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due, "due from 6 hours before the survey ends")
+
+	// Due for those who have not responded, once per lease
+	later := now.Add(7 * time.Hour)
+	due, err = queries.ClaimDueReminders(ctx, later, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	dids := []string{due[0].DID, due[1].DID}
+	assert.ElementsMatch(t, []string{"did:plc:bob", "did:plc:carol"}, dids)
+	again, err := queries.ClaimDueReminders(ctx, later, 10)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	for _, inv := range due {
+		inv.Attempted(nil, later)
+		require.NoError(t, queries.UpdateInvitation(ctx, inv))
+	}
+
+	invitations, err := queries.ListInvitations(ctx, survey.ID)
+	require.NoError(t, err)
+	require.Len(t, invitations, 3)
+	for _, inv := range invitations {
+		if inv.DID == alice {
+			assert.NotNil(t, inv.RespondedAt)
+			assert.Equal(t, reminder.StatusInvited, inv.Status)
+		} else {
+			assert.Equal(t, reminder.StatusReminded, inv.Status)
+			assert.NotNil(t, inv.RemindedAt)
+		}
+	}
+
+	// Closed surveys are not reminded of
+	due, err = queries.ClaimDueReminders(ctx, endsAt.Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	require.NoError(t, queries.SetReminderSchedule(ctx, survey.ID, 0, "did:plc:author"))
+	hours, err = queries.GetReminderSchedule(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Zero(t, hours)
+}
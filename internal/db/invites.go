@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/invite"
+)
+
+// CreateInvites implements the invite.Store interface
+// All the invites are saved, or none
+func (q *Queries) CreateInvites(ctx context.Context, invites []*invite.Invite) error {
+	query := `
+		INSERT INTO survey_invites (id, survey_id, invitee, prefix, token_hash, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	return q.InTx(ctx, func(tx *Queries) error {
+		for _, inv := range invites {
+			_, err := tx.db.ExecContext(ctx, query, inv.ID, inv.SurveyID, inv.Invitee, inv.Prefix, inv.Hash, inv.CreatedBy, inv.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to insert invite: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListInvites implements the invite.Store interface
+func (q *Queries) ListInvites(ctx context.Context, surveyID uuid.UUID) ([]*invite.Invite, error) {
+	query := `SELECT ` + inviteColumns + ` FROM survey_invites WHERE survey_id = $1 ORDER BY created_at, invitee`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invites: %w", err)
+	}
+	defer rows.Close()
+
+	var invites []*invite.Invite
+	for rows.Next() {
+		inv, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, inv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating invites: %w", err)
+	}
+
+	return invites, nil
+}
+
+// GetInviteByHash implements the invite.Store interface
+func (q *Queries) GetInviteByHash(ctx context.Context, surveyID uuid.UUID, hash string) (*invite.Invite, error) {
+	query := `SELECT ` + inviteColumns + ` FROM survey_invites WHERE survey_id = $1 AND token_hash = $2`
+
+	inv, err := scanInvite(q.db.QueryRowContext(ctx, query, surveyID, hash))
+	if err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// GetInviteByInvitee returns the invite of an invitee of a survey, or
+// sql.ErrNoRows if they are not invited
+func (q *Queries) GetInviteByInvitee(ctx context.Context, surveyID uuid.UUID, invitee string) (*invite.Invite, error) {
+	query := `SELECT ` + inviteColumns + ` FROM survey_invites WHERE survey_id = $1 AND invitee = $2`
+
+	inv, err := scanInvite(q.db.QueryRowContext(ctx, query, surveyID, invitee))
+	if err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// UseInvite implements the invite.Store interface
+// The invite is only updated while unused, so two responses cannot use it
+func (q *Queries) UseInvite(ctx context.Context, id uuid.UUID, now time.Time) error {
+	result, err := q.db.ExecContext(ctx, `UPDATE survey_invites SET used_at = $2 WHERE id = $1 AND used_at IS NULL`, id, now)
+	if err != nil {
+		return fmt.Errorf("failed to use invite: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return invite.ErrUsed
+	}
+
+	return nil
+}
+
+// SetInviteResponse implements the invite.Store interface
+func (q *Queries) SetInviteResponse(ctx context.Context, id, responseID uuid.UUID) error {
+	if _, err := q.db.ExecContext(ctx, `UPDATE survey_invites SET response_id = $2 WHERE id = $1`, id, responseID); err != nil {
+		return fmt.Errorf("failed to set invite response: %w", err)
+	}
+	return nil
+}
+
+// ReleaseInvite implements the invite.Store interface
+func (q *Queries) ReleaseInvite(ctx context.Context, id uuid.UUID) error {
+	if _, err := q.db.ExecContext(ctx, `UPDATE survey_invites SET used_at = NULL, response_id = NULL WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to release invite: %w", err)
+	}
+	return nil
+}
+
+// DeleteInvite implements the invite.Store interface
+// Returns sql.ErrNoRows if the survey has no such invite
+func (q *Queries) DeleteInvite(ctx context.Context, id, surveyID uuid.UUID) error {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM survey_invites WHERE id = $1 AND survey_id = $2`, id, surveyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete invite: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// inviteColumns are the columns scanned by scanInvite
+const inviteColumns = `id, survey_id, invitee, prefix, token_hash, created_by, used_at, response_id, created_at`
+
+// scanInvite scans a row of inviteColumns
+func scanInvite(row interface{ Scan(dest ...any) error }) (*invite.Invite, error) {
+	inv := &invite.Invite{}
+	err := row.Scan(&inv.ID, &inv.SurveyID, &inv.Invitee, &inv.Prefix, &inv.Hash, &inv.CreatedBy, &inv.UsedAt, &inv.ResponseID, &inv.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan invite: %w", err)
+	}
+	return inv, nil
+}
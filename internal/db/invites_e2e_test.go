@@ -0,0 +1,89 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/invite"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvites(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	now := time.Now()
+	survey := &models.Survey{
+		ID:         uuid.New(),
+		Slug:       "private",
+		Title:      "Private",
+		Definition: models.SurveyDefinition{Visibility: models.VisibilityInvite, Questions: []models.Question{{ID: "q1", Text: "Comments?", Type: models.QuestionTypeText}}},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	bob, bobToken, err := invite.New(survey.ID, "bob@example.com", "did:plc:author")
+	require.NoError(t, err)
+	carol, _, err := invite.New(survey.ID, "did:plc:carol", "did:plc:author")
+	require.NoError(t, err)
+	require.NoError(t, queries.CreateInvites(ctx, []*invite.Invite{bob, carol}))
+
+	// Invitees are invited once, and a failed batch saves none
+	dave, _, err := invite.New(survey.ID, "dave@example.com", "did:plc:author")
+	require.NoError(t, err)
+	again, _, err := invite.New(survey.ID, "bob@example.com", "did:plc:author")
+	require.NoError(t, err)
+	assert.Error(t, queries.CreateInvites(ctx, []*invite.Invite{dave, again}))
+
+	invites, err := queries.ListInvites(ctx, survey.ID)
+	require.NoError(t, err)
+	require.Len(t, invites, 2)
+
+	got, err := queries.GetInviteByHash(ctx, survey.ID, invite.Hash(bobToken))
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, got.ID)
+	assert.Equal(t, "bob@example.com", got.Invitee)
+	_, err = queries.GetInviteByHash(ctx, uuid.New(), invite.Hash(bobToken))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	got, err = queries.GetInviteByInvitee(ctx, survey.ID, "did:plc:carol")
+	require.NoError(t, err)
+	assert.Equal(t, carol.ID, got.ID)
+	_, err = queries.GetInviteByInvitee(ctx, survey.ID, "did:plc:dave")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// An invite is used once, until released
+	require.NoError(t, queries.UseInvite(ctx, bob.ID, now))
+	assert.ErrorIs(t, queries.UseInvite(ctx, bob.ID, now), invite.ErrUsed)
+	require.NoError(t, queries.ReleaseInvite(ctx, bob.ID))
+	require.NoError(t, queries.UseInvite(ctx, bob.ID, now))
+
+	session := "invitee-session"
+	response := &models.Response{
+		ID:           uuid.New(),
+		SurveyID:     survey.ID,
+		VoterSession: &session,
+		Answers:      map[string]models.Answer{"q1": {Text: "hello"}},
+		CreatedAt:    now,
+	}
+	require.NoError(t, queries.CreateResponse(ctx, response))
+	require.NoError(t, queries.SetInviteResponse(ctx, bob.ID, response.ID))
+
+	got, err = queries.GetInviteByHash(ctx, survey.ID, bob.Hash)
+	require.NoError(t, err)
+	require.NotNil(t, got.UsedAt)
+	require.NotNil(t, got.ResponseID)
+	assert.Equal(t, response.ID, *got.ResponseID)
+
+	require.NoError(t, queries.DeleteInvite(ctx, carol.ID, survey.ID))
+	assert.ErrorIs(t, queries.DeleteInvite(ctx, carol.ID, survey.ID), sql.ErrNoRows)
+}
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/jobs"
+)
+
+// jobColumns are the columns scanned by scanJob
+const jobColumns = `id, type, payload, status, attempts, max_attempts, run_at, locked_until, last_error, created_at, finished_at`
+
+// scanJob scans a row of jobColumns
+func scanJob(row rowScanner) (*jobs.Job, error) {
+	j := &jobs.Job{}
+	var payload []byte
+	err := row.Scan(&j.ID, &j.Type, &payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.RunAt, &j.LockedUntil,
+		&j.LastError, &j.CreatedAt, &j.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	j.Payload = payload
+	return j, nil
+}
+
+// EnqueueJob implements the jobs.Store interface
+func (q *Queries) EnqueueJob(ctx context.Context, j *jobs.Job) error {
+	query := `
+		INSERT INTO jobs (id, type, payload, status, max_attempts, run_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := q.db.ExecContext(ctx, query, j.ID, j.Type, []byte(j.Payload), j.Status, j.MaxAttempts, j.RunAt, j.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// ClaimJobs implements the jobs.Store interface
+// Claimed rows are locked, skipping rows another instance is claiming
+func (q *Queries) ClaimJobs(ctx context.Context, jobType string, now time.Time, lease time.Duration, limit int) ([]*jobs.Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_until = $4
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE type = $1
+				AND ((status = 'pending' AND run_at <= $2) OR (status = 'running' AND locked_until < $2))
+			ORDER BY run_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+
+	rows, err := q.db.QueryContext(ctx, query, jobType, now, limit, now.Add(lease))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var claimed []*jobs.Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		claimed = append(claimed, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating claimed jobs: %w", err)
+	}
+	return claimed, nil
+}
+
+// CompleteJob implements the jobs.Store interface
+func (q *Queries) CompleteJob(ctx context.Context, id uuid.UUID, now time.Time) error {
+	query := `UPDATE jobs SET status = 'done', locked_until = NULL, finished_at = $2 WHERE id = $1`
+
+	if _, err := q.db.ExecContext(ctx, query, id, now); err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// RetryJob implements the jobs.Store interface
+func (q *Queries) RetryJob(ctx context.Context, id uuid.UUID, runAt time.Time, reason string) error {
+	query := `UPDATE jobs SET status = 'pending', locked_until = NULL, run_at = $2, last_error = $3 WHERE id = $1`
+
+	if _, err := q.db.ExecContext(ctx, query, id, runAt, reason); err != nil {
+		return fmt.Errorf("failed to retry job: %w", err)
+	}
+	return nil
+}
+
+// FailJob implements the jobs.Store interface
+func (q *Queries) FailJob(ctx context.Context, id uuid.UUID, reason string, now time.Time) error {
+	query := `UPDATE jobs SET status = 'failed', locked_until = NULL, last_error = $2, finished_at = $3 WHERE id = $1`
+
+	if _, err := q.db.ExecContext(ctx, query, id, reason, now); err != nil {
+		return fmt.Errorf("failed to mark job as failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteFinishedJobs implements the jobs.Store interface
+func (q *Queries) DeleteFinishedJobs(ctx context.Context, before time.Time) (int, error) {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM jobs WHERE finished_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(n), nil
+}
+
+// CountJobs implements the jobs.Store interface
+func (q *Queries) CountJobs(ctx context.Context) ([]jobs.Count, error) {
+	query := `
+		SELECT type, status, COUNT(*)
+		FROM jobs
+		WHERE status IN ('pending', 'running')
+		GROUP BY type, status
+		ORDER BY type, status
+	`
+
+	rows, err := q.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []jobs.Count
+	for rows.Next() {
+		var c jobs.Count
+		if err := rows.Scan(&c.Type, &c.Status, &c.Jobs); err != nil {
+			return nil, fmt.Errorf("failed to scan job count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating job counts: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,100 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobs(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	enqueue := func(jobType string, runAt time.Time) *jobs.Job {
+		job := &jobs.Job{ID: uuid.New(), Type: jobType, Payload: []byte(`{"n":1}`), Status: jobs.StatusPending, MaxAttempts: 3, RunAt: runAt, CreatedAt: now}
+		require.NoError(t, queries.EnqueueJob(ctx, job))
+		return job
+	}
+	due := enqueue("export", now.Add(-time.Minute))
+	enqueue("export", now.Add(time.Hour))
+	enqueue("other", now.Add(-time.Minute))
+
+	claimed, err := queries.ClaimJobs(ctx, "export", now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1, "only due jobs of the type are claimed")
+	assert.Equal(t, due.ID, claimed[0].ID)
+	assert.Equal(t, jobs.StatusRunning, claimed[0].Status)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	assert.JSONEq(t, `{"n":1}`, string(claimed[0].Payload))
+
+	claimed, err = queries.ClaimJobs(ctx, "export", now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "running jobs are not claimed again while leased")
+
+	claimed, err = queries.ClaimJobs(ctx, "export", now.Add(2*time.Minute), time.Minute, 1)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1, "jobs whose lease expired are claimed again")
+	assert.Equal(t, 2, claimed[0].Attempts)
+
+	require.NoError(t, queries.RetryJob(ctx, due.ID, now.Add(time.Minute), "boom"))
+	claimed, err = queries.ClaimJobs(ctx, "export", now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "retried jobs wait for their run time")
+
+	counts, err := queries.CountJobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []jobs.Count{{Type: "export", Status: jobs.StatusPending, Jobs: 2}, {Type: "other", Status: jobs.StatusPending, Jobs: 1}}, counts)
+
+	require.NoError(t, queries.CompleteJob(ctx, due.ID, now))
+	n, err := queries.DeleteFinishedJobs(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestClaimJobs_Concurrent(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, queries.EnqueueJob(ctx, &jobs.Job{ID: uuid.New(), Type: "export", Payload: []byte(`{}`), Status: jobs.StatusPending, MaxAttempts: 3, RunAt: now, CreatedAt: now}))
+	}
+
+	var mu sync.Mutex
+	seen := make(map[uuid.UUID]int)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				claimed, err := queries.ClaimJobs(ctx, "export", now, time.Minute, 3)
+				if err != nil || len(claimed) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, job := range claimed {
+					seen[job.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, 20)
+	for id, n := range seen {
+		assert.Equal(t, 1, n, "job %s was claimed %d times", id, n)
+	}
+}
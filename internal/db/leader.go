@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ConsumerLeaderLockID is the advisory lock held by the consumer instance that
+// reads Jetstream, so replicas do not process the same events twice
+const ConsumerLeaderLockID = 7_265_613_202
+
+// ErrNotLeader is returned by LeaderLock.Fence when another session has taken
+// the lock since this one acquired it
+var ErrNotLeader = errors.New("leader lock is held by another instance")
+
+// LeaderLock is a session-level advisory lock held on a dedicated connection.
+// Postgres releases the lock when that connection ends, so a crashed or
+// partitioned holder loses it without cleanup.
+//
+// Each acquisition bumps the lock's epoch in leader_epochs. Writers check the
+// epoch with Fence inside their transactions, so a holder whose connection
+// dropped cannot keep writing on other pool connections once a standby has
+// taken over.
+//
+// SQLite has no advisory locks, so there the lock is a lease on its epoch row:
+// the holder renews it with every Check, and another process can only take it
+// once it is released or has not been renewed for sqliteLeaseTTL.
+type LeaderLock struct {
+	db     *sql.DB
+	id     int64
+	sqlite bool         // The database is SQLite, where the lock is a lease
+	conn   conn         // Set while the lock is held
+	epoch  atomic.Int64 // Epoch of the current acquisition, 0 if not held
+}
+
+// sqliteLeaseTTL is how long a SQLite leader lock stays held without a Check
+const sqliteLeaseTTL = 30 * time.Second
+
+// NewLeaderLock creates an advisory lock with the given ID, initially not held
+func NewLeaderLock(db *sql.DB, id int64) *LeaderLock {
+	return &LeaderLock{db: db, id: id, sqlite: dialectOf(db) == dialectSQLite}
+}
+
+// TryAcquire takes the lock if no other session holds it, without waiting.
+// It reports whether the lock is held afterwards.
+func (l *LeaderLock) TryAcquire(ctx context.Context) (bool, error) {
+	if l.conn != nil {
+		return true, nil
+	}
+
+	conn, err := acquire(ctx, l.db)
+	if err != nil {
+		return false, fmt.Errorf("failed to open leader lock connection: %w", err)
+	}
+
+	if !l.sqlite {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.id).Scan(&acquired); err != nil {
+			conn.discard()
+			return false, fmt.Errorf("failed to try leader lock: %w", err)
+		}
+		if !acquired {
+			conn.Close()
+			return false, nil
+		}
+	}
+
+	// Bump the epoch, which waits for transactions of the previous holder that
+	// are still fenced on it
+	var epoch int64
+	if l.sqlite {
+		// Only take a lease that was released or has expired
+		err = conn.QueryRowContext(ctx, `
+			INSERT INTO leader_epochs (lock_id, epoch) VALUES ($1, 1)
+			ON CONFLICT (lock_id) DO UPDATE
+			SET epoch = leader_epochs.epoch + 1, updated_at = NOW()
+			WHERE leader_epochs.updated_at < $2
+			RETURNING epoch
+		`, l.id, time.Now().Add(-sqliteLeaseTTL)).Scan(&epoch)
+		if errors.Is(err, sql.ErrNoRows) {
+			conn.Close()
+			return false, nil
+		}
+	} else {
+		err = conn.QueryRowContext(ctx, `
+			INSERT INTO leader_epochs (lock_id, epoch) VALUES ($1, 1)
+			ON CONFLICT (lock_id) DO UPDATE
+			SET epoch = leader_epochs.epoch + 1, updated_at = NOW()
+			RETURNING epoch
+		`, l.id).Scan(&epoch)
+	}
+	if err != nil {
+		// Closing the session releases the lock
+		conn.discard()
+		return false, fmt.Errorf("failed to bump leader epoch: %w", err)
+	}
+
+	l.conn = conn
+	l.epoch.Store(epoch)
+	return true, nil
+}
+
+// Fence verifies within a transaction that no other session has acquired the
+// lock since this one did. The epoch row stays share-locked until the
+// transaction ends, so a new holder cannot take over while it commits.
+// It returns ErrNotLeader if leadership was lost.
+func (l *LeaderLock) Fence(ctx context.Context, q Querier) error {
+	held := l.epoch.Load()
+	if held == 0 {
+		return ErrNotLeader
+	}
+
+	var epoch int64
+	err := q.QueryRowContext(ctx, `SELECT epoch FROM leader_epochs WHERE lock_id = $1 FOR SHARE`, l.id).Scan(&epoch)
+	if err != nil {
+		return fmt.Errorf("failed to check leader epoch: %w", err)
+	}
+	if epoch != held {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+// Check verifies the lock is still held, i.e. its connection is alive, or on
+// SQLite renews the lease if no other process has taken it. If not, the
+// connection is dropped and the lock must be acquired again.
+func (l *LeaderLock) Check(ctx context.Context) error {
+	if l.conn == nil {
+		return fmt.Errorf("leader lock is not held")
+	}
+
+	if l.sqlite {
+		var epoch int64
+		err := l.conn.QueryRowContext(ctx, `
+			UPDATE leader_epochs SET updated_at = NOW()
+			WHERE lock_id = $1 AND epoch = $2
+			RETURNING epoch
+		`, l.id, l.epoch.Load()).Scan(&epoch)
+		if errors.Is(err, sql.ErrNoRows) {
+			l.drop(true)
+			return ErrNotLeader
+		}
+		if err != nil {
+			l.drop(true)
+			return fmt.Errorf("failed to renew leader lease: %w", err)
+		}
+		return nil
+	}
+
+	var one int
+	if err := l.conn.QueryRowContext(ctx, `SELECT 1`).Scan(&one); err != nil {
+		l.drop(true)
+		return fmt.Errorf("leader lock connection lost: %w", err)
+	}
+	return nil
+}
+
+// drop closes the connection of a lock that is no longer held. A connection
+// that failed a check is discarded, so a session lock it may still hold ends.
+func (l *LeaderLock) drop(discard bool) {
+	if discard {
+		l.conn.discard()
+	} else {
+		l.conn.Close()
+	}
+	l.conn = nil
+	l.epoch.Store(0)
+}
+
+// Release unlocks the lock and closes its connection. Releasing a lock that is
+// not held does nothing.
+func (l *LeaderLock) Release(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+
+	if l.sqlite {
+		defer l.drop(false)
+		// Expire the lease, unless another process has taken it
+		_, err := l.conn.ExecContext(ctx, `
+			UPDATE leader_epochs SET updated_at = $3
+			WHERE lock_id = $1 AND epoch = $2
+		`, l.id, l.epoch.Load(), time.Unix(0, 0))
+		if err != nil {
+			return fmt.Errorf("failed to release leader lease: %w", err)
+		}
+		return nil
+	}
+	if _, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.id); err != nil {
+		// Closing the session releases the lock instead
+		l.drop(true)
+		return fmt.Errorf("failed to release leader lock: %w", err)
+	}
+	l.drop(false)
+	return nil
+}
@@ -0,0 +1,301 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// migrationFiles holds the SQL migrations, so binaries can migrate without external tooling
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// sqliteMigrationFiles holds the migrations of SQLite databases. They start at
+// version 50 with the whole schema of the Postgres migrations up to it; each
+// later Postgres migration has a SQLite one with the same version.
+//
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrationFiles embed.FS
+
+// migrationLockID is the advisory lock held while migrating, so replicas
+// starting at once do not apply the same migration twice
+const migrationLockID = 7_265_613_201
+
+// migrationFileRegex matches migration file names, e.g. 001_initial.up.sql
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a numbered schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is the schema version of a database and the migrations not yet applied
+type MigrationStatus struct {
+	Version int         // 0 if no migration has been applied
+	Dirty   bool        // A migration failed part-way; fix the schema before migrating again
+	Latest  int         // Version of the newest embedded migration
+	Pending []Migration // Oldest first
+}
+
+// Migrations returns the embedded migrations, oldest first
+func Migrations() ([]Migration, error) {
+	return migrationsOf(dialectPostgres)
+}
+
+// SQLiteMigrations returns the embedded migrations of SQLite databases, oldest first
+func SQLiteMigrations() ([]Migration, error) {
+	return migrationsOf(dialectSQLite)
+}
+
+// migrationsOf returns the embedded migrations of a dialect, oldest first
+func migrationsOf(d dialect) ([]Migration, error) {
+	files, dir := fs.FS(migrationFiles), "migrations"
+	if d == dialectSQLite {
+		files, dir = sqliteMigrationFiles, "migrations/sqlite"
+	}
+	sub, err := fs.Sub(files, dir)
+	if err != nil {
+		return nil, err
+	}
+	return loadMigrations(sub)
+}
+
+// loadMigrations reads the up and down migrations of a directory.
+// Every version needs both files, and versions must be unique.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %03d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrate applies all pending migrations and returns how many were applied.
+// Each migration runs in a transaction. The version is tracked in the
+// schema_migrations table used by golang-migrate, so databases migrated with
+// either tool can be migrated with the other.
+func Migrate(ctx context.Context, db *sql.DB) (int, error) {
+	migrations, err := migrationsOf(dialectOf(db))
+	if err != nil {
+		return 0, err
+	}
+	return withMigrationLock(ctx, db, func(conn conn) (int, error) {
+		return migrateUp(ctx, conn, migrations)
+	})
+}
+
+// MigrateDown reverts the last steps migrations and returns how many were reverted
+func MigrateDown(ctx context.Context, db *sql.DB, steps int) (int, error) {
+	migrations, err := migrationsOf(dialectOf(db))
+	if err != nil {
+		return 0, err
+	}
+	return withMigrationLock(ctx, db, func(conn conn) (int, error) {
+		return migrateDown(ctx, conn, migrations, steps)
+	})
+}
+
+// GetMigrationStatus returns the schema version of the database and the pending migrations
+func GetMigrationStatus(ctx context.Context, db *sql.DB) (*MigrationStatus, error) {
+	migrations, err := migrationsOf(dialectOf(db))
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := acquire(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	version, dirty, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{Version: version, Dirty: dirty, Pending: []Migration{}}
+	for _, m := range migrations {
+		status.Latest = m.Version
+		if m.Version > version {
+			status.Pending = append(status.Pending, m)
+		}
+	}
+	return status, nil
+}
+
+// withMigrationLock runs fn on one connection holding the migration lock.
+// SQLite databases are used by one instance, which migrates before serving,
+// so they are not locked.
+func withMigrationLock(ctx context.Context, db *sql.DB, fn func(conn conn) (int, error)) (int, error) {
+	conn, err := acquire(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if dialectOf(db) == dialectPostgres {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+			return 0, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+	}
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return 0, err
+	}
+	return fn(conn)
+}
+
+// migrateUp applies the migrations newer than the database's version
+func migrateUp(ctx context.Context, conn conn, migrations []Migration) (int, error) {
+	version, dirty, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("database is dirty at version %d; fix the schema and reset schema_migrations before migrating", version)
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		if m.Version <= version {
+			continue
+		}
+		if err := runMigration(ctx, conn, m.Up, m.Version); err != nil {
+			return applied, fmt.Errorf("migration %03d_%s failed: %w", m.Version, m.Name, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// migrateDown reverts up to steps applied migrations, newest first
+func migrateDown(ctx context.Context, conn conn, migrations []Migration, steps int) (int, error) {
+	version, dirty, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("database is dirty at version %d; fix the schema and reset schema_migrations before migrating", version)
+	}
+	if len(migrations) > 0 && version > migrations[len(migrations)-1].Version {
+		return 0, fmt.Errorf("database version %d is newer than the migrations of this binary", version)
+	}
+
+	reverted := 0
+	for i := len(migrations) - 1; i >= 0 && reverted < steps; i-- {
+		m := migrations[i]
+		if m.Version > version {
+			continue
+		}
+		previous := 0
+		if i > 0 {
+			previous = migrations[i-1].Version
+		}
+		if err := runMigration(ctx, conn, m.Down, previous); err != nil {
+			return reverted, fmt.Errorf("reverting migration %03d_%s failed: %w", m.Version, m.Name, err)
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// runMigration runs migration SQL and records the resulting version in one transaction
+func runMigration(ctx context.Context, conn conn, query string, version int) error {
+	tx, err := conn.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ensureMigrationsTable creates the version table, in golang-migrate's format
+func ensureMigrationsTable(ctx context.Context, conn conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// schemaVersion returns the applied version, 0 if none
+func schemaVersion(ctx context.Context, conn conn) (int, bool, error) {
+	var version int
+	var dirty bool
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, dirty, nil
+}
@@ -0,0 +1,85 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	testcontainers "github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func TestMigrate_UpDownStatus(t *testing.T) {
+	ctx := context.Background()
+
+	postgresC, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("survey_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	require.NoError(t, err, "Failed to start PostgreSQL container")
+	defer func() {
+		if err := postgresC.Terminate(ctx); err != nil {
+			t.Logf("Failed to terminate container: %v", err)
+		}
+	}()
+
+	connStr, err := postgresC.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+	database, err := open(ctx, connStr, "survey_test", "primary", PoolConfig{})
+	require.NoError(t, err)
+	defer Close(database)
+
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	latest := migrations[len(migrations)-1].Version
+
+	status, err := GetMigrationStatus(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Version)
+	assert.Len(t, status.Pending, len(migrations))
+
+	applied, err := Migrate(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), applied)
+
+	// Migrating again is a no-op
+	applied, err = Migrate(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, 0, applied)
+
+	status, err = GetMigrationStatus(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, latest, status.Version)
+	assert.False(t, status.Dirty)
+	assert.Empty(t, status.Pending)
+
+	reverted, err := MigrateDown(ctx, database, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reverted)
+	status, err = GetMigrationStatus(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, migrations[len(migrations)-2].Version, status.Version)
+
+	// Every down migration reverts its up migration
+	reverted, err = MigrateDown(ctx, database, len(migrations))
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations)-1, reverted)
+	status, err = GetMigrationStatus(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Version)
+
+	applied, err = Migrate(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), applied)
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_Embedded(t *testing.T) {
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	// Versions are sequential from 1, and every migration can be reverted
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "migration %s", m.Name)
+		assert.NotEmpty(t, m.Up, "migration %s", m.Name)
+		assert.NotEmpty(t, m.Down, "migration %s", m.Name)
+	}
+	assert.Equal(t, "initial", migrations[0].Name)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_users.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+		"002_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"001_init.up.sql":    {Data: []byte("CREATE TABLE a ();")},
+		"001_init.down.sql":  {Data: []byte("DROP TABLE a;")},
+		"README.md":          {Data: []byte("not a migration")},
+	}
+
+	migrations, err := loadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, Migration{Version: 1, Name: "init", Up: "CREATE TABLE a ();", Down: "DROP TABLE a;"}, migrations[0])
+	assert.Equal(t, 2, migrations[1].Version)
+	assert.Equal(t, "users", migrations[1].Name)
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "missing down file",
+			fsys: fstest.MapFS{
+				"001_init.up.sql": {Data: []byte("CREATE TABLE a ();")},
+			},
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"001_init.up.sql":    {Data: []byte("CREATE TABLE a ();")},
+				"001_init.down.sql":  {Data: []byte("DROP TABLE a;")},
+				"001_other.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+				"001_other.down.sql": {Data: []byte("DROP TABLE b;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMigrations(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
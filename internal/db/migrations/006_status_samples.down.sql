@@ -0,0 +1,3 @@
+-- Rollback Status Samples
+
+DROP TABLE IF EXISTS status_samples;
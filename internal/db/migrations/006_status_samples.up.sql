@@ -0,0 +1,17 @@
+-- Status Samples
+-- Periodic availability samples backing the public /status page (90-day rolling window)
+
+CREATE TABLE status_samples (
+    id BIGSERIAL PRIMARY KEY,
+    component TEXT NOT NULL CHECK (component IN ('api', 'consumer', 'pds_writes', 'ai_generator')),
+    healthy BOOLEAN NOT NULL,
+    value DOUBLE PRECISION, -- component-specific measurement (success rate, cursor age in seconds)
+    detail TEXT,
+    sampled_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for per-component history queries
+CREATE INDEX idx_status_samples_component_sampled_at ON status_samples(component, sampled_at DESC);
+
+-- Index for pruning old samples
+CREATE INDEX idx_status_samples_sampled_at ON status_samples(sampled_at);
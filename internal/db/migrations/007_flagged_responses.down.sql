@@ -0,0 +1,3 @@
+-- Rollback Flagged Responses
+
+DROP TABLE IF EXISTS flagged_responses;
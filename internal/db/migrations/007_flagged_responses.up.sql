@@ -0,0 +1,22 @@
+-- Flagged Responses
+-- Free-text answers flagged by moderation, hidden from public results until approved
+
+CREATE TABLE flagged_responses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    response_id UUID NOT NULL REFERENCES responses(id) ON DELETE CASCADE,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    question_id TEXT NOT NULL,
+    text TEXT NOT NULL,
+    source TEXT NOT NULL, -- checker that flagged the answer (blocklist, openai)
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reviewed_by TEXT, -- DID of the author or admin who reviewed the flag
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- One flag per answer
+    UNIQUE (response_id, question_id)
+);
+
+-- Index for the review queue of a survey
+CREATE INDEX idx_flagged_responses_survey_status ON flagged_responses(survey_id, status);
@@ -0,0 +1,5 @@
+-- Rollback Question Ordinals
+
+ALTER TABLE responses DROP COLUMN IF EXISTS survey_version;
+DROP TABLE IF EXISTS survey_question_ordinals;
+ALTER TABLE surveys DROP COLUMN IF EXISTS version;
@@ -0,0 +1,24 @@
+-- Question Ordinals
+-- Records the display position of each question per definition version, so
+-- answers keyed by question ID can be lined up with the order voters saw
+
+-- Definition version, bumped when the question order or set changes
+ALTER TABLE surveys ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE survey_question_ordinals (
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    question_id TEXT NOT NULL,
+    ordinal INT NOT NULL, -- 1-based position in the definition
+    PRIMARY KEY (survey_id, version, question_id)
+);
+
+-- Version of the survey definition a response was submitted against
+-- NULL for responses recorded before versions were tracked (treated as version 1)
+ALTER TABLE responses ADD COLUMN survey_version INT;
+
+-- Backfill version 1 ordinals from existing definitions
+INSERT INTO survey_question_ordinals (survey_id, version, question_id, ordinal)
+SELECT s.id, 1, q.value->>'id', q.ordinality
+FROM surveys s, jsonb_array_elements(s.definition->'questions') WITH ORDINALITY AS q
+WHERE q.value->>'id' IS NOT NULL;
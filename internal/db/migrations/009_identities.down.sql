@@ -0,0 +1,3 @@
+-- Rollback Identities
+
+DROP TABLE IF EXISTS identities;
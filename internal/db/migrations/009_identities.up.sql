@@ -0,0 +1,10 @@
+-- Identities
+-- Cache of resolved Bluesky handles and profiles, refreshed after a TTL
+
+CREATE TABLE identities (
+    did TEXT PRIMARY KEY,
+    handle TEXT NOT NULL DEFAULT '',
+    display_name TEXT NOT NULL DEFAULT '',
+    avatar TEXT NOT NULL DEFAULT '',
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
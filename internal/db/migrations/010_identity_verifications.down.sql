@@ -0,0 +1,3 @@
+-- Rollback Identity verifications
+
+DROP TABLE IF EXISTS identity_verifications;
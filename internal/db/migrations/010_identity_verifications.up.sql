@@ -0,0 +1,9 @@
+-- Identity verifications
+-- Cache of whether a DID's handle proof (DNS TXT or HTTPS well-known) resolves back to the DID
+
+CREATE TABLE identity_verifications (
+    did TEXT PRIMARY KEY,
+    handle TEXT NOT NULL DEFAULT '',
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
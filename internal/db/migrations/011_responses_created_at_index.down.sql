@@ -0,0 +1,3 @@
+-- Rollback responses created_at index
+
+DROP INDEX IF EXISTS idx_responses_survey_created_at;
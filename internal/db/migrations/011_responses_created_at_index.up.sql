@@ -0,0 +1,4 @@
+-- Index responses by submission time within a survey
+-- Lets date-range exports read only the matching rows
+
+CREATE INDEX idx_responses_survey_created_at ON responses(survey_id, created_at);
@@ -0,0 +1,3 @@
+-- Rollback PDS Outbox
+
+DROP TABLE IF EXISTS pds_outbox;
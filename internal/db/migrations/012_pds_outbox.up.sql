@@ -0,0 +1,24 @@
+-- PDS Outbox
+-- Records whose write to the user's PDS failed. The survey or response is saved
+-- locally without a record until the user retries publishing it.
+
+CREATE TABLE pds_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind TEXT NOT NULL CHECK (kind IN ('survey', 'response')),
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    response_id UUID REFERENCES responses(id) ON DELETE CASCADE, -- Set for responses
+    did TEXT NOT NULL, -- Repo the record is written to
+    collection TEXT NOT NULL,
+    rkey TEXT NOT NULL,
+    record JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'published')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_pds_outbox_response CHECK ((kind = 'response') = (response_id IS NOT NULL))
+);
+
+-- Index for the pending records shown to a user on a survey page
+CREATE INDEX idx_pds_outbox_survey_did_status ON pds_outbox(survey_id, did, status);
@@ -0,0 +1,3 @@
+-- Rollback Survey Revisions
+
+DROP TABLE IF EXISTS survey_revisions;
@@ -0,0 +1,13 @@
+-- Survey Revisions
+-- Definition changes of surveys updated from the firehose, shown as a change history
+
+CREATE TABLE survey_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    cid TEXT, -- CID of the updated record
+    changes JSONB NOT NULL, -- [{"kind": "option_text", "questionId": "q1", "old": "Red", "new": "Green"}, ...]
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for the change history of a survey, newest first
+CREATE INDEX idx_survey_revisions_survey_created_at ON survey_revisions(survey_id, created_at DESC);
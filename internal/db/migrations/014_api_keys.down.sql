@@ -0,0 +1,3 @@
+-- Rollback API Keys
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,18 @@
+-- API Keys
+-- Keys authenticating machine clients of the JSON API; only the token's hash is stored
+
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_did TEXT NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL, -- Start of the token, shown to tell keys apart
+    key_hash TEXT NOT NULL UNIQUE, -- Hex SHA-256 of the token
+    scopes TEXT NOT NULL, -- Space-separated: read, write, admin
+    rate_limit INTEGER NOT NULL CHECK (rate_limit > 0), -- Requests per minute
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for listing a user's keys
+CREATE INDEX idx_api_keys_owner_did ON api_keys(owner_did, created_at DESC);
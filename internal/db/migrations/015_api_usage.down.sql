@@ -0,0 +1,5 @@
+-- Rollback API Usage
+
+DROP INDEX IF EXISTS idx_ai_generation_logs_user_created_at;
+ALTER TABLE ai_generation_logs DROP COLUMN IF EXISTS api_key_id;
+DROP TABLE IF EXISTS api_key_usage;
@@ -0,0 +1,16 @@
+-- API Usage
+-- Daily request counts per API key, and attribution of AI generations to keys
+
+CREATE TABLE api_key_usage (
+    key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    day DATE NOT NULL, -- UTC
+    requests INTEGER NOT NULL DEFAULT 0,
+    rate_limited INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day)
+);
+
+-- API key that requested a generation (NULL for web and anonymous requests)
+ALTER TABLE ai_generation_logs ADD COLUMN api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL;
+
+-- Index for usage of a user's generations by day
+CREATE INDEX idx_ai_generation_logs_user_created_at ON ai_generation_logs(user_id, created_at);
@@ -0,0 +1,3 @@
+-- Rollback Survey Versions
+
+DROP TABLE IF EXISTS survey_versions;
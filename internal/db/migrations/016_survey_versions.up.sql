@@ -0,0 +1,21 @@
+-- Survey Versions
+-- The definition of every survey version (surveys.version), so responses can be
+-- read against the definition they answered (responses.survey_version) even
+-- after options are changed over ATProto
+
+CREATE TABLE survey_versions (
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    cid TEXT, -- CID of the survey record defining this version (NULL for surveys created on the web)
+    definition JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (survey_id, version)
+);
+
+-- Index for pinning responses to the version of the record CID they reference
+CREATE INDEX idx_survey_versions_survey_cid ON survey_versions(survey_id, cid);
+
+-- Backfill the current definition of existing surveys; earlier definitions were not kept
+INSERT INTO survey_versions (survey_id, version, cid, definition, created_at)
+SELECT id, version, cid, definition, updated_at
+FROM surveys;
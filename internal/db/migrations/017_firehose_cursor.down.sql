@@ -0,0 +1,3 @@
+-- Rollback Firehose Cursor
+
+DROP TABLE IF EXISTS firehose_cursor;
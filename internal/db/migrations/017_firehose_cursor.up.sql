@@ -0,0 +1,13 @@
+-- Firehose Cursor
+-- Sequence number of the last relay firehose event indexed, for consumers
+-- reading com.atproto.sync.subscribeRepos instead of Jetstream
+
+CREATE TABLE firehose_cursor (
+    id INT PRIMARY KEY DEFAULT 1,
+    seq BIGINT NOT NULL,
+    time_us BIGINT NOT NULL DEFAULT 0,  -- Event time of seq, for consumer lag
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (id = 1)  -- Single row table
+);
+
+INSERT INTO firehose_cursor (id, seq) VALUES (1, 0);
@@ -0,0 +1,3 @@
+-- Rollback Survey Views
+
+DROP TABLE IF EXISTS survey_views;
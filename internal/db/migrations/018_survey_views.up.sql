@@ -0,0 +1,12 @@
+-- Survey Views
+-- Page views of surveys per hour (UTC) and referring host, for the response
+-- rate analytics of authors. Only counts are stored, no visitor data.
+
+CREATE TABLE survey_views (
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    hour TIMESTAMPTZ NOT NULL,
+    referrer TEXT NOT NULL, -- Referring host, or 'direct'
+    views INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (survey_id, hour, referrer)
+);
+
@@ -0,0 +1,3 @@
+-- Rollback Survey Drafts
+
+DROP TABLE IF EXISTS survey_drafts;
@@ -0,0 +1,20 @@
+-- Survey Drafts
+-- Editor content of surveys being created, autosaved so creators can resume.
+-- Owned by a DID, or by 'guest:' and the ID in a guest's signed cookie.
+
+CREATE TABLE survey_drafts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner TEXT NOT NULL,
+    title TEXT NOT NULL DEFAULT '', -- First question of the content, for listing
+    slug TEXT NOT NULL DEFAULT '',
+    format TEXT NOT NULL DEFAULT 'json' CHECK (format IN ('json', 'yaml')),
+    content TEXT NOT NULL, -- Editor text; need not be a valid definition
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for listing an owner's drafts, most recently saved first
+CREATE INDEX idx_survey_drafts_owner ON survey_drafts(owner, updated_at DESC);
+
+-- Index for deleting expired drafts
+CREATE INDEX idx_survey_drafts_updated_at ON survey_drafts(updated_at);
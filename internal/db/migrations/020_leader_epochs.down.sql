@@ -0,0 +1,3 @@
+-- Rollback Leader Epochs
+
+DROP TABLE IF EXISTS leader_epochs;
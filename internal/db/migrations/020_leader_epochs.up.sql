@@ -0,0 +1,10 @@
+-- Leader Epochs
+-- Fencing tokens of advisory-lock leaders. Each new holder of a lock bumps its
+-- epoch, and leaders check it inside every processing transaction, so a leader
+-- that lost its lock cannot write after a standby took over.
+
+CREATE TABLE leader_epochs (
+    lock_id BIGINT PRIMARY KEY,
+    epoch BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- Rollback Consumer Retries
+
+DROP TABLE IF EXISTS consumer_retries;
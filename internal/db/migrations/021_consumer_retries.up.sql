@@ -0,0 +1,15 @@
+-- Consumer Retries
+-- Indexed messages whose processing failed, kept for later attempts so the
+-- consumer cursor can move past them without losing the records.
+
+CREATE TABLE consumer_retries (
+    id BIGSERIAL PRIMARY KEY,
+    message JSONB NOT NULL, -- Jetstream message, as processed
+    attempts INTEGER NOT NULL DEFAULT 1,
+    last_error TEXT NOT NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for picking the messages due for a retry, oldest first
+CREATE INDEX idx_consumer_retries_due ON consumer_retries(next_attempt_at, id);
@@ -0,0 +1,4 @@
+-- Rollback Status Sample Instances
+
+DROP INDEX IF EXISTS idx_status_samples_component_instance;
+ALTER TABLE status_samples DROP COLUMN IF EXISTS instance;
@@ -0,0 +1,8 @@
+-- Status Sample Instances
+-- Every API instance samples its own metrics, so samples name the instance
+-- that took them and the current state is read from each instance's latest sample.
+
+ALTER TABLE status_samples ADD COLUMN instance TEXT NOT NULL DEFAULT '';
+
+-- Index for the latest sample of each component and instance
+CREATE INDEX idx_status_samples_component_instance ON status_samples(component, instance, sampled_at DESC);
@@ -0,0 +1,3 @@
+-- Rollback Response Drafts
+
+DROP TABLE IF EXISTS responses_draft;
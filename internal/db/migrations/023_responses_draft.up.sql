@@ -0,0 +1,15 @@
+-- Response Drafts
+-- Answers autosaved by the voting form, so voters can resume a long survey
+-- after closing the tab. Owned by a DID, or by 'guest:' and the ID in a
+-- guest's signed cookie; one draft per survey and owner.
+
+CREATE TABLE responses_draft (
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    owner TEXT NOT NULL,
+    answers JSONB NOT NULL, -- Partial answers; need not pass validation yet
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (survey_id, owner)
+);
+
+-- Index for deleting expired drafts
+CREATE INDEX idx_responses_draft_updated_at ON responses_draft(updated_at);
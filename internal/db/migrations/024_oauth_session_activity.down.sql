@@ -0,0 +1,4 @@
+-- Rollback OAuth Session Activity
+
+ALTER TABLE oauth_sessions DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE oauth_sessions DROP COLUMN IF EXISTS last_used_at;
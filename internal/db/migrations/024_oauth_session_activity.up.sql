@@ -0,0 +1,6 @@
+-- OAuth Session Activity
+-- When each session was last used, and the browser it was created in, so
+-- users can recognize and revoke their sessions on /settings/sessions.
+
+ALTER TABLE oauth_sessions ADD COLUMN last_used_at TIMESTAMPTZ;
+ALTER TABLE oauth_sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Rollback Account Data Indexes
+
+DROP INDEX IF EXISTS idx_responses_voter_did;
+DROP INDEX IF EXISTS idx_surveys_author_did;
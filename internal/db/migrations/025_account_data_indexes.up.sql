@@ -0,0 +1,6 @@
+-- Account Data Indexes
+-- Look up a user's surveys and responses, for exporting their data from
+-- /my-data/export.
+
+CREATE INDEX idx_surveys_author_did ON surveys(author_did) WHERE author_did IS NOT NULL;
+CREATE INDEX idx_responses_voter_did ON responses(voter_did) WHERE voter_did IS NOT NULL;
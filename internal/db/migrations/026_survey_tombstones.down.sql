@@ -0,0 +1,3 @@
+-- Rollback Survey Tombstones
+
+DROP TABLE IF EXISTS survey_tombstones;
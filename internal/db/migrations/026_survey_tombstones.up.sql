@@ -0,0 +1,11 @@
+-- Survey Tombstones
+-- A minimal record of each deleted survey, so links to it show that it was
+-- deleted instead of a 404, responses to it are rejected with a clear error,
+-- and its slug is never reused for another survey.
+
+CREATE TABLE survey_tombstones (
+    slug TEXT PRIMARY KEY,
+    uri TEXT UNIQUE,
+    author_did TEXT,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
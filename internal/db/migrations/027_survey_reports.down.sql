@@ -0,0 +1,4 @@
+-- Rollback Survey Reports
+
+DROP TABLE IF EXISTS survey_reports;
+ALTER TABLE surveys DROP COLUMN IF EXISTS hidden_at;
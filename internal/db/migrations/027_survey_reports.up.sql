@@ -0,0 +1,21 @@
+-- Survey Reports
+-- Abuse reports of surveys by visitors. A survey reported by enough distinct
+-- reporters is hidden until an admin reviews the reports.
+
+ALTER TABLE surveys ADD COLUMN hidden_at TIMESTAMPTZ; -- Set while hidden from the public
+
+CREATE TABLE survey_reports (
+    id UUID PRIMARY KEY,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL, -- spam, harassment, illegal, other
+    details TEXT NOT NULL DEFAULT '',
+    reporter TEXT NOT NULL, -- Hash of the reporter's DID or IP address
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, dismissed, upheld
+    reviewed_by TEXT,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (survey_id, reporter)
+);
+
+-- Index for the review queue
+CREATE INDEX idx_survey_reports_pending ON survey_reports(survey_id) WHERE status = 'pending';
@@ -0,0 +1,3 @@
+-- Rollback Share Tokens
+
+DROP TABLE IF EXISTS share_tokens;
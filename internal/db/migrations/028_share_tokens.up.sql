@@ -0,0 +1,17 @@
+-- Share Tokens
+-- Access tokens of surveys with token visibility, added to their links by the
+-- author. Only the SHA-256 hash of each token is stored.
+
+CREATE TABLE share_tokens (
+    id UUID PRIMARY KEY,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    label TEXT NOT NULL DEFAULT '', -- Who the link was given to
+    prefix TEXT NOT NULL, -- Start of the token, for display
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by TEXT NOT NULL, -- DID of the author or admin who created it
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for listing a survey's tokens
+CREATE INDEX idx_share_tokens_survey ON share_tokens(survey_id);
@@ -0,0 +1,6 @@
+-- Rollback Organizations
+
+DROP INDEX IF EXISTS idx_surveys_org;
+ALTER TABLE surveys DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
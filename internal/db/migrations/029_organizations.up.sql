@@ -0,0 +1,29 @@
+-- Organizations
+-- Teams owning surveys together. A survey moved to an organization is managed
+-- by its members according to their role, as well as by its author.
+
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_by TEXT NOT NULL, -- DID of the creator, its first owner
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE organization_members (
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    did TEXT NOT NULL,
+    role TEXT NOT NULL, -- owner, editor, viewer
+    invited_by TEXT NOT NULL,
+    accepted_at TIMESTAMPTZ, -- NULL while the invite is pending
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (org_id, did)
+);
+
+-- Index for listing a user's organizations
+CREATE INDEX idx_organization_members_did ON organization_members(did);
+
+ALTER TABLE surveys ADD COLUMN org_id UUID REFERENCES organizations(id) ON DELETE SET NULL;
+
+-- Index for listing an organization's surveys
+CREATE INDEX idx_surveys_org ON surveys(org_id) WHERE org_id IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Rollback Results Snapshots
+
+DROP TABLE IF EXISTS results_snapshots;
+DROP TABLE IF EXISTS snapshot_schedules;
@@ -0,0 +1,27 @@
+-- Results Snapshots
+-- Surveys opted into hourly or daily results snapshots, and the snapshots
+-- taken, each also published to the PDS of who opted in while they have a session.
+
+CREATE TABLE snapshot_schedules (
+    survey_id UUID PRIMARY KEY REFERENCES surveys(id) ON DELETE CASCADE,
+    frequency TEXT NOT NULL CHECK (frequency IN ('hourly', 'daily')),
+    did TEXT NOT NULL, -- DID of the author or editor who opted in
+    next_run_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for claiming due schedules
+CREATE INDEX idx_snapshot_schedules_next_run ON snapshot_schedules(next_run_at);
+
+CREATE TABLE results_snapshots (
+    id UUID PRIMARY KEY,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    total_votes INTEGER NOT NULL,
+    record JSONB NOT NULL, -- The net.openmeet.survey.results record
+    uri TEXT, -- Set once published to the PDS
+    cid TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for a survey's snapshot history
+CREATE INDEX idx_results_snapshots_survey ON results_snapshots(survey_id, created_at);
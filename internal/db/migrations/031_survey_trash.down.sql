@@ -0,0 +1,6 @@
+-- Rollback Survey Trash
+-- Surveys in the trash are deleted for good, as they were before
+
+DELETE FROM surveys WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_surveys_deleted;
+ALTER TABLE surveys DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,8 @@
+-- Survey Trash
+-- Deleted surveys keep their row, with deleted_at set, for 30 days so their
+-- authors can restore them; a cleanup job then deletes them with their responses.
+
+ALTER TABLE surveys ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Index for the trash of each author and for purging expired surveys
+CREATE INDEX idx_surveys_deleted ON surveys(author_did, deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Rollback Indexed Records
+
+DROP TABLE IF EXISTS indexed_records;
@@ -0,0 +1,14 @@
+-- Indexed Records
+-- The values of indexed survey and response records, as JSON in the ATProto
+-- data model, so exports can re-encode each record and show it matches its CID.
+
+CREATE TABLE indexed_records (
+    uri TEXT PRIMARY KEY,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE, -- The survey, or the survey a response answers
+    cid TEXT NOT NULL,
+    record JSONB NOT NULL,
+    indexed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for the records of a survey
+CREATE INDEX idx_indexed_records_survey ON indexed_records(survey_id);
@@ -0,0 +1,4 @@
+-- Rollback Response Signals
+-- Excluded responses count in results again
+
+DROP TABLE IF EXISTS response_signals;
@@ -0,0 +1,19 @@
+-- Response Signals
+-- Duplicate signals of guest responses: the hashed voter cookie, which blocks
+-- second votes from a browser, and an optional fingerprint of request headers.
+-- Authors can exclude suspected duplicates from results.
+
+CREATE TABLE response_signals (
+    response_id UUID PRIMARY KEY REFERENCES responses(id) ON DELETE CASCADE,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    voter_token TEXT, -- Per-survey hash of the voter cookie's ID
+    fingerprint TEXT, -- Per-survey hash of request headers, if enabled
+    excluded BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for blocking second votes from a browser
+CREATE INDEX idx_response_signals_token ON response_signals(survey_id, voter_token) WHERE voter_token IS NOT NULL;
+
+-- Index for leaving excluded responses out of results
+CREATE INDEX idx_response_signals_excluded ON response_signals(survey_id) WHERE excluded;
@@ -0,0 +1,3 @@
+-- Rollback Question Bank
+
+DROP TABLE IF EXISTS bank_questions;
@@ -0,0 +1,14 @@
+-- Question Bank
+-- Questions logged-in users saved, with their options, to insert into the
+-- definitions of new surveys.
+
+CREATE TABLE bank_questions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_did TEXT NOT NULL,
+    question JSONB NOT NULL, -- The question as in survey definitions
+    search_text TEXT NOT NULL, -- Question, option, and row text, for search
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for listing an owner's questions, most recently saved first
+CREATE INDEX idx_bank_questions_owner ON bank_questions(owner_did, created_at DESC);
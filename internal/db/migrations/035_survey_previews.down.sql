@@ -0,0 +1,3 @@
+-- Rollback Survey Previews
+
+DROP TABLE IF EXISTS survey_previews;
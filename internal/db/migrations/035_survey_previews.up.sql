@@ -0,0 +1,13 @@
+-- Survey Previews
+-- Definitions shown before publishing, viewed by a short-lived token. Only the
+-- token's hash is stored; expired previews are deleted by a cleanup worker.
+
+CREATE TABLE survey_previews (
+    token_hash TEXT PRIMARY KEY, -- Hex SHA-256 of the preview token
+    definition JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Index for deleting expired previews
+CREATE INDEX idx_survey_previews_expires_at ON survey_previews(expires_at);
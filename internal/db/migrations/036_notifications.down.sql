@@ -0,0 +1,4 @@
+-- Rollback Milestone Notifications
+
+DROP TABLE IF EXISTS survey_notifications;
+DROP TABLE IF EXISTS notification_preferences;
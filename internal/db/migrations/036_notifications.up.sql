@@ -0,0 +1,32 @@
+-- Milestone Notifications
+-- Authors opted into notifications of their surveys' milestones, and the
+-- notifications queued for and delivered to them.
+
+CREATE TABLE notification_preferences (
+    did TEXT PRIMARY KEY,
+    channel TEXT NOT NULL CHECK (channel IN ('post', 'dm')),
+    milestones JSONB NOT NULL, -- Array of first_response, responses_100, closed
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW() -- Milestones reached before are not notified
+);
+
+CREATE TABLE survey_notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    did TEXT NOT NULL, -- The author notified
+    milestone TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    uri TEXT, -- The post, for the post channel
+    error TEXT, -- Of the last failed attempt
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (survey_id, milestone) -- Each milestone is notified once
+);
+
+-- Index for claiming due notifications
+CREATE INDEX idx_survey_notifications_due ON survey_notifications(next_attempt_at) WHERE status = 'pending';
+
+-- Index for an author's notification history
+CREATE INDEX idx_survey_notifications_did ON survey_notifications(did, created_at DESC);
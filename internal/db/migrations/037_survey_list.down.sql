@@ -0,0 +1,5 @@
+-- Rollback Survey List Sorting and Filtering
+
+DROP INDEX IF EXISTS idx_surveys_author_ends_at;
+DROP INDEX IF EXISTS idx_surveys_author_title;
+DROP INDEX IF EXISTS idx_surveys_author_created_at;
@@ -0,0 +1,9 @@
+-- Survey List Sorting and Filtering
+-- Indexes for listing an author's surveys by creation time, title, and
+-- status. Response counts use idx_responses_survey_id.
+
+CREATE INDEX idx_surveys_author_created_at ON surveys(author_did, created_at) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_surveys_author_title ON surveys(author_did, lower(title)) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_surveys_author_ends_at ON surveys(author_did, ends_at) WHERE deleted_at IS NULL;
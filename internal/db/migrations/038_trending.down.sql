@@ -0,0 +1,4 @@
+-- Rollback Trending and Featured Surveys
+
+DROP TABLE IF EXISTS featured_surveys;
+DROP MATERIALIZED VIEW IF EXISTS trending_surveys;
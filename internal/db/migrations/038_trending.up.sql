@@ -0,0 +1,26 @@
+-- Trending and Featured Surveys
+-- Scores of surveys by their responses of the last 24 hours, each weighted
+-- by recency with a half-life of 6 hours (trending.Weight), recomputed by
+-- REFRESH MATERIALIZED VIEW. Visibility, moderation, and the trash are
+-- checked when reading, so changes show before the next refresh.
+
+CREATE MATERIALIZED VIEW trending_surveys AS
+SELECT
+    survey_id,
+    COUNT(*) AS responses,
+    SUM(POWER(0.5, GREATEST(EXTRACT(EPOCH FROM (NOW() - created_at)), 0) / 21600.0)) AS score
+FROM responses
+WHERE created_at > NOW() - INTERVAL '24 hours'
+GROUP BY survey_id;
+
+-- Unique index for REFRESH MATERIALIZED VIEW CONCURRENTLY
+CREATE UNIQUE INDEX idx_trending_surveys_survey_id ON trending_surveys(survey_id);
+
+CREATE INDEX idx_trending_surveys_score ON trending_surveys(score DESC);
+
+-- Surveys admins feature on the landing page
+CREATE TABLE featured_surveys (
+    survey_id UUID PRIMARY KEY REFERENCES surveys(id) ON DELETE CASCADE,
+    featured_by TEXT NOT NULL, -- The admin's DID
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
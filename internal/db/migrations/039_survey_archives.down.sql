@@ -0,0 +1,17 @@
+-- Rollback Survey Archives
+-- Archived responses are restored before the archives are dropped
+
+INSERT INTO responses
+SELECT r.* FROM survey_archives a CROSS JOIN LATERAL jsonb_populate_recordset(NULL::responses, a.responses) r
+ON CONFLICT DO NOTHING;
+
+INSERT INTO flagged_responses
+SELECT f.* FROM survey_archives a CROSS JOIN LATERAL jsonb_populate_recordset(NULL::flagged_responses, a.flags) f
+ON CONFLICT DO NOTHING;
+
+INSERT INTO response_signals
+SELECT s.* FROM survey_archives a CROSS JOIN LATERAL jsonb_populate_recordset(NULL::response_signals, a.signals) s
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS survey_archives;
+DROP INDEX IF EXISTS idx_surveys_ends_at;
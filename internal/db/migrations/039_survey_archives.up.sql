@@ -0,0 +1,22 @@
+-- Survey Archives
+-- Responses of surveys closed long ago, moved out of responses into one row
+-- per survey with their moderation flags and duplicate signals, as JSONB
+-- arrays of the rows (to_jsonb), which Postgres compresses. Results are kept
+-- as computed when archiving. Rows are restored with jsonb_populate_recordset.
+
+CREATE TABLE survey_archives (
+    survey_id UUID PRIMARY KEY REFERENCES surveys(id) ON DELETE CASCADE,
+    response_count INT NOT NULL,
+    responses JSONB NOT NULL, -- Rows of responses
+    flags JSONB NOT NULL, -- Rows of flagged_responses
+    signals JSONB NOT NULL, -- Rows of response_signals
+    record_uris TEXT[] NOT NULL, -- ATProto record URIs of the responses, for record deletions
+    results JSONB NOT NULL, -- models.SurveyResults
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for finding the archive of a deleted response record
+CREATE INDEX idx_survey_archives_record_uris ON survey_archives USING GIN (record_uris);
+
+-- Index for finding surveys to archive
+CREATE INDEX idx_surveys_ends_at ON surveys(ends_at) WHERE ends_at IS NOT NULL AND deleted_at IS NULL;
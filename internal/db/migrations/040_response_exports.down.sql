@@ -0,0 +1,4 @@
+-- Rollback Response Exports
+-- Files of the exports are left in blob storage
+
+DROP TABLE IF EXISTS response_exports;
@@ -0,0 +1,25 @@
+-- Response Exports
+-- Exports of survey responses queued by their readers. A worker writes each
+-- export's file to blob storage; finished and failed exports expire with
+-- their files.
+
+CREATE TABLE response_exports (
+    id UUID PRIMARY KEY,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    requested_by TEXT NOT NULL, -- DID of the requester
+    format TEXT NOT NULL CHECK (format IN ('csv', 'json')),
+    query TEXT NOT NULL DEFAULT '', -- URL-encoded response filters
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    blob_key TEXT,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+-- Index for the worker claiming queued and stale exports
+CREATE INDEX idx_response_exports_queued ON response_exports (created_at) WHERE status IN ('pending', 'running');
+
+-- Index for deleting expired exports
+CREATE INDEX idx_response_exports_expires_at ON response_exports (expires_at) WHERE expires_at IS NOT NULL;
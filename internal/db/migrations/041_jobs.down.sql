@@ -0,0 +1,6 @@
+-- Rollback Jobs
+-- Queued exports stay pending in response_exports
+
+CREATE INDEX idx_response_exports_queued ON response_exports (created_at) WHERE status IN ('pending', 'running');
+
+DROP TABLE IF EXISTS jobs;
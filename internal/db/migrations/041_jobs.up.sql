@@ -0,0 +1,36 @@
+-- Jobs
+-- Queue of long-running work, such as response exports, claimed by workers
+-- with FOR UPDATE SKIP LOCKED. Failed jobs are retried at a later run_at until
+-- max_attempts; running jobs whose lease (locked_until) expired are claimed
+-- again. Exports queued before the job queue are queued as jobs.
+
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY,
+    type TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+-- Index for claiming due jobs of a type
+CREATE INDEX idx_jobs_due ON jobs (type, run_at) WHERE status = 'pending';
+
+-- Index for claiming running jobs whose lease expired
+CREATE INDEX idx_jobs_running ON jobs (type, locked_until) WHERE status = 'running';
+
+-- Index for deleting finished jobs
+CREATE INDEX idx_jobs_finished_at ON jobs (finished_at) WHERE finished_at IS NOT NULL;
+
+INSERT INTO jobs (id, type, payload, max_attempts)
+SELECT gen_random_uuid(), 'export', jsonb_build_object('exportId', id), 5
+FROM response_exports
+WHERE status IN ('pending', 'running');
+
+-- Exports are no longer claimed from their own table
+DROP INDEX IF EXISTS idx_response_exports_queued;
@@ -0,0 +1,7 @@
+-- Rollback Consumer Retry Records
+
+DROP INDEX IF EXISTS idx_consumer_retries_waiting;
+DROP INDEX IF EXISTS idx_consumer_retries_record;
+
+ALTER TABLE consumer_retries DROP COLUMN IF EXISTS waiting_for;
+ALTER TABLE consumer_retries DROP COLUMN IF EXISTS record_uri;
@@ -0,0 +1,14 @@
+-- Consumer Retry Records
+-- The record each queued message is about, so a newer message of the record
+-- replaces its queued ones, and the survey a message waits for, so it is
+-- retried as soon as the survey is indexed.
+
+ALTER TABLE consumer_retries ADD COLUMN record_uri TEXT;
+ALTER TABLE consumer_retries ADD COLUMN waiting_for TEXT;
+
+UPDATE consumer_retries
+SET record_uri = 'at://' || (message->'commit'->>'repo') || '/' || (message->'commit'->>'collection') || '/' || (message->'commit'->>'rkey')
+WHERE message ? 'commit';
+
+CREATE INDEX idx_consumer_retries_record ON consumer_retries(record_uri) WHERE record_uri IS NOT NULL;
+CREATE INDEX idx_consumer_retries_waiting ON consumer_retries(waiting_for) WHERE waiting_for IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Rollback Idempotency Keys
+
+DROP TABLE IF EXISTS idempotency_keys;
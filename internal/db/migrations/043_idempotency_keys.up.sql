@@ -0,0 +1,18 @@
+-- Idempotency Keys
+-- Responses of submissions sent with an Idempotency-Key header, replayed when
+-- the submission is retried with the same key until they expire.
+
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY, -- SHA-256 of the caller's scope and key
+    fingerprint TEXT NOT NULL, -- SHA-256 of the request
+    response_id UUID,
+    status INTEGER,
+    content_type TEXT,
+    body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ, -- NULL while the request runs
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Index for deleting expired keys
+CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys(expires_at);
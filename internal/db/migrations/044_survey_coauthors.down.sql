@@ -0,0 +1,4 @@
+-- Rollback Survey Co-Authors
+
+DROP TABLE IF EXISTS results_publish_requests;
+DROP TABLE IF EXISTS survey_coauthors;
@@ -0,0 +1,27 @@
+-- Survey Co-Authors
+-- Users an author shares a survey with, and their requests for the author to
+-- publish the survey's results to the author's PDS.
+
+CREATE TABLE survey_coauthors (
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    did TEXT NOT NULL,
+    added_by TEXT NOT NULL, -- DID of the author or admin who added them
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (survey_id, did)
+);
+
+-- Index for checking a user's co-authorships
+CREATE INDEX idx_survey_coauthors_did ON survey_coauthors(did);
+
+CREATE TABLE results_publish_requests (
+    id UUID PRIMARY KEY,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    requested_by TEXT NOT NULL, -- DID of the co-author
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, approved, rejected
+    resolved_by TEXT,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A survey has at most one pending request
+CREATE UNIQUE INDEX idx_results_publish_requests_pending ON results_publish_requests(survey_id) WHERE status = 'pending';
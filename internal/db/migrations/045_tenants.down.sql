@@ -0,0 +1,5 @@
+-- Rollback Tenants
+
+DROP TABLE IF EXISTS tenant_surveys;
+DROP TABLE IF EXISTS domains;
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,31 @@
+-- Tenants
+-- Partners serving surveys on their own domains with their own branding, and
+-- the surveys cross-posted to them.
+
+CREATE TABLE tenants (
+    id UUID PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    tagline TEXT NOT NULL DEFAULT '',
+    logo_url TEXT NOT NULL DEFAULT '',
+    accent_color TEXT NOT NULL DEFAULT '', -- #rrggbb
+    default_author_did TEXT, -- Author whose surveys the tenant lists
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE domains (
+    hostname TEXT PRIMARY KEY, -- Lowercase, without port
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for listing a tenant's domains
+CREATE INDEX idx_domains_tenant ON domains(tenant_id);
+
+CREATE TABLE tenant_surveys (
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    added_by TEXT NOT NULL, -- DID of who cross-posted the survey
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, survey_id)
+);
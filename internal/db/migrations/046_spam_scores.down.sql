@@ -0,0 +1,5 @@
+-- Rollback Spam Scores
+
+ALTER TABLE survey_archives DROP COLUMN IF EXISTS spam_scores;
+DROP TABLE IF EXISTS spam_thresholds;
+DROP TABLE IF EXISTS response_spam_scores;
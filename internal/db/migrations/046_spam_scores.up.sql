@@ -0,0 +1,25 @@
+-- Spam Scores
+-- Spam scores of guest responses, from signals like a filled honeypot field,
+-- many votes from one IP address, and scripted user agents. Authors set a
+-- threshold per survey to leave responses scoring at or above it out of results.
+
+CREATE TABLE response_spam_scores (
+    response_id UUID PRIMARY KEY REFERENCES responses(id) ON DELETE CASCADE,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    score SMALLINT NOT NULL CHECK (score BETWEEN 0 AND 100),
+    reasons JSONB NOT NULL DEFAULT '[]', -- Reason codes of the score
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for leaving responses at or above a threshold out of results
+CREATE INDEX idx_response_spam_scores_survey ON response_spam_scores(survey_id, score);
+
+CREATE TABLE spam_thresholds (
+    survey_id UUID PRIMARY KEY REFERENCES surveys(id) ON DELETE CASCADE,
+    threshold SMALLINT NOT NULL CHECK (threshold BETWEEN 1 AND 100),
+    set_by TEXT NOT NULL, -- DID of the author who set it
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Archived surveys keep the spam scores of their responses
+ALTER TABLE survey_archives ADD COLUMN spam_scores JSONB NOT NULL DEFAULT '[]'; -- Rows of response_spam_scores
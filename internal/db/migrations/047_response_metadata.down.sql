@@ -0,0 +1,3 @@
+-- Rollback Response Metadata
+
+DROP TABLE IF EXISTS response_metadata;
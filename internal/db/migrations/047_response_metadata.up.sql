@@ -0,0 +1,12 @@
+-- Response Metadata
+-- Counts of a survey's responses by country and hour of day (UTC), for surveys
+-- whose definition sets responseMetadata. Counts are kept apart from responses
+-- so they can't be tied to answers or voters; IP addresses are not stored.
+
+CREATE TABLE response_metadata (
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    country TEXT NOT NULL, -- ISO 3166-1 alpha-2 code, or 'unknown'
+    hour SMALLINT NOT NULL CHECK (hour BETWEEN 0 AND 23),
+    responses INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (survey_id, country, hour)
+);
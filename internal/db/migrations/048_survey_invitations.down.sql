@@ -0,0 +1,4 @@
+-- Rollback Survey Invitations
+
+DROP TABLE IF EXISTS reminder_schedules;
+DROP TABLE IF EXISTS survey_invitations;
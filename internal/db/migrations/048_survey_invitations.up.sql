@@ -0,0 +1,29 @@
+-- Survey Invitations
+-- Invitees of surveys shared with a known audience, whether they responded,
+-- and the reminders sent to those who had not before the survey closed.
+
+CREATE TABLE survey_invitations (
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    did TEXT NOT NULL, -- The invitee
+    invited_by TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'invited' CHECK (status IN ('invited', 'reminded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT, -- Of the last failed reminder attempt
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    responded_at TIMESTAMPTZ,
+    reminded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (survey_id, did)
+);
+
+-- Index for claiming due reminders
+CREATE INDEX idx_survey_invitations_due ON survey_invitations(next_attempt_at)
+    WHERE status = 'invited' AND responded_at IS NULL;
+
+-- Hours before a survey ends its invitees are reminded
+CREATE TABLE reminder_schedules (
+    survey_id UUID PRIMARY KEY REFERENCES surveys(id) ON DELETE CASCADE,
+    hours_before INTEGER NOT NULL CHECK (hours_before BETWEEN 1 AND 168),
+    set_by TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- Rollback Survey Invites
+
+DROP TABLE IF EXISTS survey_invites;
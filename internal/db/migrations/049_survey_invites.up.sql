@@ -0,0 +1,16 @@
+-- Survey Invites
+-- One-time links of the invitees of surveys with invite visibility. Only the
+-- SHA-256 hash of each token is stored; a link is used by its response.
+
+CREATE TABLE survey_invites (
+    id UUID PRIMARY KEY,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    invitee TEXT NOT NULL, -- Lowercase email address, or DID
+    prefix TEXT NOT NULL, -- Start of the token, for display
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by TEXT NOT NULL, -- DID of the author or admin who invited them
+    used_at TIMESTAMPTZ,
+    response_id UUID REFERENCES responses(id) ON DELETE SET NULL, -- Not kept for anonymous surveys
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (survey_id, invitee)
+);
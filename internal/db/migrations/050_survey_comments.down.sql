@@ -0,0 +1,3 @@
+-- Rollback Survey Comments
+
+DROP TABLE IF EXISTS survey_comments;
@@ -0,0 +1,18 @@
+-- Survey Comments
+-- net.openmeet.survey.comment records indexed from their authors' repositories.
+-- A record is indexed once, and an author's identical comments on a survey
+-- are kept once.
+
+CREATE TABLE survey_comments (
+    id UUID PRIMARY KEY,
+    survey_id UUID NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    author_did TEXT NOT NULL,
+    record_uri TEXT NOT NULL UNIQUE,
+    record_cid TEXT NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL, -- Declared by the record
+    indexed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_survey_comments_survey ON survey_comments (survey_id, created_at, id);
+CREATE INDEX idx_survey_comments_author ON survey_comments (survey_id, author_did, md5(text));
@@ -0,0 +1,4 @@
+-- Rollback Survey Series
+
+DROP TABLE IF EXISTS survey_series_surveys;
+DROP TABLE IF EXISTS survey_series;
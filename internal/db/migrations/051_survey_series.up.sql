@@ -0,0 +1,23 @@
+-- Survey Series
+-- Surveys of one author linked into a series, whose results are combined and
+-- whose logged-in voters are tracked across its surveys (waves). A survey
+-- belongs to at most one series.
+
+CREATE TABLE survey_series (
+    id UUID PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
+    owner_did TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for listing a user's series
+CREATE INDEX idx_survey_series_owner ON survey_series(owner_did, created_at DESC);
+
+CREATE TABLE survey_series_surveys (
+    series_id UUID NOT NULL REFERENCES survey_series(id) ON DELETE CASCADE,
+    survey_id UUID NOT NULL UNIQUE REFERENCES surveys(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL, -- Order of the wave, from 1
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (series_id, survey_id)
+);
@@ -0,0 +1,11 @@
+-- Reserved Slugs
+-- Slugs and slug namespaces ("official-*") reserved by admins. Only
+-- allow-listed (and optionally verified) authors can claim them; the consumer
+-- picks other slugs for indexed surveys of everyone else.
+
+CREATE TABLE reserved_slugs (
+    pattern TEXT PRIMARY KEY,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,55 @@
+-- Rollback Initial SQLite schema
+
+DROP TABLE IF EXISTS survey_comments;
+DROP TABLE IF EXISTS survey_invites;
+DROP TABLE IF EXISTS reminder_schedules;
+DROP TABLE IF EXISTS survey_invitations;
+DROP TABLE IF EXISTS response_metadata;
+DROP TABLE IF EXISTS spam_thresholds;
+DROP TABLE IF EXISTS response_spam_scores;
+DROP TABLE IF EXISTS tenant_surveys;
+DROP TABLE IF EXISTS domains;
+DROP TABLE IF EXISTS tenants;
+DROP TABLE IF EXISTS results_publish_requests;
+DROP TABLE IF EXISTS survey_coauthors;
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS jobs;
+DROP TABLE IF EXISTS response_exports;
+DROP TABLE IF EXISTS survey_archives;
+DROP TABLE IF EXISTS featured_surveys;
+DROP TABLE IF EXISTS trending_surveys;
+DROP TABLE IF EXISTS survey_notifications;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS survey_previews;
+DROP TABLE IF EXISTS bank_questions;
+DROP TABLE IF EXISTS response_signals;
+DROP TABLE IF EXISTS indexed_records;
+DROP TABLE IF EXISTS results_snapshots;
+DROP TABLE IF EXISTS snapshot_schedules;
+DROP TABLE IF EXISTS share_tokens;
+DROP TABLE IF EXISTS survey_reports;
+DROP TABLE IF EXISTS survey_tombstones;
+DROP TABLE IF EXISTS responses_draft;
+DROP TABLE IF EXISTS consumer_retries;
+DROP TABLE IF EXISTS leader_epochs;
+DROP TABLE IF EXISTS survey_drafts;
+DROP TABLE IF EXISTS survey_views;
+DROP TABLE IF EXISTS survey_revisions;
+DROP TABLE IF EXISTS pds_outbox;
+DROP TABLE IF EXISTS identity_verifications;
+DROP TABLE IF EXISTS identities;
+DROP TABLE IF EXISTS survey_versions;
+DROP TABLE IF EXISTS survey_question_ordinals;
+DROP TABLE IF EXISTS flagged_responses;
+DROP TABLE IF EXISTS status_samples;
+DROP TABLE IF EXISTS ai_generation_logs;
+DROP TABLE IF EXISTS api_key_usage;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS oauth_sessions;
+DROP TABLE IF EXISTS oauth_requests;
+DROP TABLE IF EXISTS responses;
+DROP TABLE IF EXISTS surveys;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS firehose_cursor;
+DROP TABLE IF EXISTS jetstream_cursor;
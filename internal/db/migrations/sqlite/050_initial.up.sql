@@ -0,0 +1,651 @@
+-- Initial SQLite schema
+-- The schema of the Postgres migrations up to 050, for single-instance
+-- deployments without Postgres. UUIDs are text, timestamps are UTC text in
+-- the format the driver writes (sortable as text), JSONB is JSON text, and
+-- arrays are JSON arrays. IDs are generated by the application.
+
+CREATE TABLE jetstream_cursor (
+    id INTEGER PRIMARY KEY DEFAULT 1,
+    time_us INTEGER NOT NULL,
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    CHECK (id = 1) -- Single row table
+);
+
+INSERT INTO jetstream_cursor (id, time_us) VALUES (1, 0);
+
+CREATE TABLE firehose_cursor (
+    id INTEGER PRIMARY KEY DEFAULT 1,
+    seq INTEGER NOT NULL,
+    time_us INTEGER NOT NULL DEFAULT 0, -- Event time of seq, for consumer lag
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    CHECK (id = 1) -- Single row table
+);
+
+INSERT INTO firehose_cursor (id, seq) VALUES (1, 0);
+
+CREATE TABLE organizations (
+    id TEXT PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE TABLE organization_members (
+    org_id TEXT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    did TEXT NOT NULL,
+    role TEXT NOT NULL, -- owner, editor, viewer
+    invited_by TEXT NOT NULL,
+    accepted_at TIMESTAMP, -- NULL while the invite is pending
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    PRIMARY KEY (org_id, did)
+);
+
+CREATE INDEX idx_organization_members_did ON organization_members(did);
+
+CREATE TABLE surveys (
+    id TEXT PRIMARY KEY,
+    uri TEXT UNIQUE,
+    cid TEXT,
+    author_did TEXT,
+    slug TEXT UNIQUE NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    definition TEXT NOT NULL, -- JSON
+    version INTEGER NOT NULL DEFAULT 1,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    results_uri TEXT,
+    results_cid TEXT,
+    hidden_at TIMESTAMP,
+    org_id TEXT REFERENCES organizations(id) ON DELETE SET NULL,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX idx_surveys_created_at ON surveys(created_at DESC);
+CREATE INDEX idx_surveys_results_uri ON surveys(results_uri) WHERE results_uri IS NOT NULL;
+CREATE INDEX idx_surveys_author_did ON surveys(author_did) WHERE author_did IS NOT NULL;
+CREATE INDEX idx_surveys_org ON surveys(org_id) WHERE org_id IS NOT NULL;
+CREATE INDEX idx_surveys_deleted ON surveys(author_did, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_surveys_author_created_at ON surveys(author_did, created_at) WHERE deleted_at IS NULL;
+CREATE INDEX idx_surveys_author_title ON surveys(author_did, lower(title)) WHERE deleted_at IS NULL;
+CREATE INDEX idx_surveys_author_ends_at ON surveys(author_did, ends_at) WHERE deleted_at IS NULL;
+CREATE INDEX idx_surveys_ends_at ON surveys(ends_at) WHERE ends_at IS NOT NULL AND deleted_at IS NULL;
+
+CREATE TABLE responses (
+    id TEXT PRIMARY KEY,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    voter_did TEXT,
+    voter_session TEXT,
+    record_uri TEXT,
+    record_cid TEXT,
+    answers TEXT NOT NULL, -- JSON
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    survey_version INTEGER,
+    CONSTRAINT chk_voter_identity CHECK (
+        (voter_did IS NOT NULL AND voter_session IS NULL) OR
+        (voter_did IS NULL AND voter_session IS NOT NULL)
+    )
+);
+
+CREATE UNIQUE INDEX idx_responses_survey_voter_did ON responses(survey_id, voter_did) WHERE voter_did IS NOT NULL;
+CREATE UNIQUE INDEX idx_responses_survey_voter_session ON responses(survey_id, voter_session) WHERE voter_session IS NOT NULL;
+CREATE INDEX idx_responses_survey_id ON responses(survey_id);
+CREATE INDEX idx_responses_record_uri ON responses(record_uri) WHERE record_uri IS NOT NULL;
+CREATE INDEX idx_responses_survey_created_at ON responses(survey_id, created_at);
+CREATE INDEX idx_responses_voter_did ON responses(voter_did) WHERE voter_did IS NOT NULL;
+
+CREATE TABLE oauth_requests (
+    state TEXT PRIMARY KEY,
+    issuer TEXT NOT NULL,
+    pkce_verifier TEXT NOT NULL,
+    dpop_private_key TEXT NOT NULL,
+    destination TEXT,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_oauth_requests_expires_at ON oauth_requests(expires_at);
+
+CREATE TABLE oauth_sessions (
+    id TEXT PRIMARY KEY,
+    did TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    expires_at TIMESTAMP,
+    access_token TEXT,
+    refresh_token TEXT,
+    dpop_key TEXT,
+    pds_url TEXT,
+    token_expires_at TIMESTAMP,
+    issuer TEXT,
+    last_used_at TIMESTAMP,
+    user_agent TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_oauth_sessions_did ON oauth_sessions(did);
+CREATE INDEX idx_oauth_sessions_expires_at ON oauth_sessions(expires_at);
+CREATE INDEX idx_oauth_sessions_token_expires_at ON oauth_sessions(token_expires_at);
+
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY,
+    owner_did TEXT NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,
+    rate_limit INTEGER NOT NULL CHECK (rate_limit > 0),
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_api_keys_owner_did ON api_keys(owner_did, created_at DESC);
+
+CREATE TABLE api_key_usage (
+    key_id TEXT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    day DATE NOT NULL, -- UTC
+    requests INTEGER NOT NULL DEFAULT 0,
+    rate_limited INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day)
+);
+
+CREATE TABLE ai_generation_logs (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    user_type TEXT NOT NULL CHECK (user_type IN ('anonymous', 'authenticated')),
+    input_prompt TEXT NOT NULL,
+    system_prompt TEXT NOT NULL,
+    raw_response TEXT,
+    status TEXT NOT NULL CHECK (status IN ('success', 'error', 'rate_limited', 'validation_failed')),
+    error_message TEXT,
+    input_tokens INTEGER NOT NULL DEFAULT 0,
+    output_tokens INTEGER NOT NULL DEFAULT 0,
+    cost_usd REAL NOT NULL DEFAULT 0.0,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    api_key_id TEXT REFERENCES api_keys(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_ai_generation_logs_user_id ON ai_generation_logs(user_id);
+CREATE INDEX idx_ai_generation_logs_status ON ai_generation_logs(status);
+CREATE INDEX idx_ai_generation_logs_created_at ON ai_generation_logs(created_at DESC);
+CREATE INDEX idx_ai_generation_logs_user_created_at ON ai_generation_logs(user_id, created_at);
+
+CREATE TABLE status_samples (
+    id INTEGER PRIMARY KEY,
+    component TEXT NOT NULL CHECK (component IN ('api', 'consumer', 'pds_writes', 'ai_generator')),
+    healthy BOOLEAN NOT NULL,
+    value REAL,
+    detail TEXT,
+    sampled_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    instance TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_status_samples_component_sampled_at ON status_samples(component, sampled_at DESC);
+CREATE INDEX idx_status_samples_sampled_at ON status_samples(sampled_at);
+CREATE INDEX idx_status_samples_component_instance ON status_samples(component, instance, sampled_at DESC);
+
+CREATE TABLE flagged_responses (
+    id TEXT PRIMARY KEY,
+    response_id TEXT NOT NULL REFERENCES responses(id) ON DELETE CASCADE,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    question_id TEXT NOT NULL,
+    text TEXT NOT NULL,
+    source TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reviewed_by TEXT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    UNIQUE (response_id, question_id)
+);
+
+CREATE INDEX idx_flagged_responses_survey_status ON flagged_responses(survey_id, status);
+
+CREATE TABLE survey_question_ordinals (
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    question_id TEXT NOT NULL,
+    ordinal INTEGER NOT NULL,
+    PRIMARY KEY (survey_id, version, question_id)
+);
+
+CREATE TABLE survey_versions (
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    cid TEXT,
+    definition TEXT NOT NULL, -- JSON
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    PRIMARY KEY (survey_id, version)
+);
+
+CREATE INDEX idx_survey_versions_survey_cid ON survey_versions(survey_id, cid);
+
+CREATE TABLE identities (
+    did TEXT PRIMARY KEY,
+    handle TEXT NOT NULL DEFAULT '',
+    display_name TEXT NOT NULL DEFAULT '',
+    avatar TEXT NOT NULL DEFAULT '',
+    fetched_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE TABLE identity_verifications (
+    did TEXT PRIMARY KEY,
+    handle TEXT NOT NULL DEFAULT '',
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    checked_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE TABLE pds_outbox (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('survey', 'response')),
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    response_id TEXT REFERENCES responses(id) ON DELETE CASCADE,
+    did TEXT NOT NULL,
+    collection TEXT NOT NULL,
+    rkey TEXT NOT NULL,
+    record TEXT NOT NULL, -- JSON
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'published')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    CONSTRAINT chk_pds_outbox_response CHECK ((kind = 'response') = (response_id IS NOT NULL))
+);
+
+CREATE INDEX idx_pds_outbox_survey_did_status ON pds_outbox(survey_id, did, status);
+
+CREATE TABLE survey_revisions (
+    id TEXT PRIMARY KEY,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    cid TEXT,
+    changes TEXT NOT NULL, -- JSON
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_survey_revisions_survey_created_at ON survey_revisions(survey_id, created_at DESC);
+
+CREATE TABLE survey_views (
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    hour TIMESTAMP NOT NULL,
+    referrer TEXT NOT NULL,
+    views INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (survey_id, hour, referrer)
+);
+
+CREATE TABLE survey_drafts (
+    id TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    slug TEXT NOT NULL DEFAULT '',
+    format TEXT NOT NULL DEFAULT 'json' CHECK (format IN ('json', 'yaml')),
+    content TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_survey_drafts_owner ON survey_drafts(owner, updated_at DESC);
+CREATE INDEX idx_survey_drafts_updated_at ON survey_drafts(updated_at);
+
+CREATE TABLE leader_epochs (
+    lock_id INTEGER PRIMARY KEY,
+    epoch INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE TABLE consumer_retries (
+    id INTEGER PRIMARY KEY,
+    message TEXT NOT NULL, -- JSON
+    attempts INTEGER NOT NULL DEFAULT 1,
+    last_error TEXT NOT NULL,
+    next_attempt_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    record_uri TEXT,
+    waiting_for TEXT
+);
+
+CREATE INDEX idx_consumer_retries_due ON consumer_retries(next_attempt_at, id);
+CREATE INDEX idx_consumer_retries_record ON consumer_retries(record_uri) WHERE record_uri IS NOT NULL;
+CREATE INDEX idx_consumer_retries_waiting ON consumer_retries(waiting_for) WHERE waiting_for IS NOT NULL;
+
+CREATE TABLE responses_draft (
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    owner TEXT NOT NULL,
+    answers TEXT NOT NULL, -- JSON
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    PRIMARY KEY (survey_id, owner)
+);
+
+CREATE INDEX idx_responses_draft_updated_at ON responses_draft(updated_at);
+
+CREATE TABLE survey_tombstones (
+    slug TEXT PRIMARY KEY,
+    uri TEXT UNIQUE,
+    author_did TEXT,
+    deleted_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE TABLE survey_reports (
+    id TEXT PRIMARY KEY,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    reporter TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    reviewed_by TEXT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    UNIQUE (survey_id, reporter)
+);
+
+CREATE INDEX idx_survey_reports_pending ON survey_reports(survey_id) WHERE status = 'pending';
+
+CREATE TABLE share_tokens (
+    id TEXT PRIMARY KEY,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    label TEXT NOT NULL DEFAULT '',
+    prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by TEXT NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_share_tokens_survey ON share_tokens(survey_id);
+
+CREATE TABLE snapshot_schedules (
+    survey_id TEXT PRIMARY KEY REFERENCES surveys(id) ON DELETE CASCADE,
+    frequency TEXT NOT NULL CHECK (frequency IN ('hourly', 'daily')),
+    did TEXT NOT NULL,
+    next_run_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_snapshot_schedules_next_run ON snapshot_schedules(next_run_at);
+
+CREATE TABLE results_snapshots (
+    id TEXT PRIMARY KEY,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    total_votes INTEGER NOT NULL,
+    record TEXT NOT NULL, -- JSON
+    uri TEXT,
+    cid TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_results_snapshots_survey ON results_snapshots(survey_id, created_at);
+
+CREATE TABLE indexed_records (
+    uri TEXT PRIMARY KEY,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    cid TEXT NOT NULL,
+    record TEXT NOT NULL, -- JSON
+    indexed_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_indexed_records_survey ON indexed_records(survey_id);
+
+CREATE TABLE response_signals (
+    response_id TEXT PRIMARY KEY REFERENCES responses(id) ON DELETE CASCADE,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    voter_token TEXT,
+    fingerprint TEXT,
+    excluded BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_response_signals_token ON response_signals(survey_id, voter_token) WHERE voter_token IS NOT NULL;
+CREATE INDEX idx_response_signals_excluded ON response_signals(survey_id) WHERE excluded;
+
+CREATE TABLE bank_questions (
+    id TEXT PRIMARY KEY,
+    owner_did TEXT NOT NULL,
+    question TEXT NOT NULL, -- JSON
+    search_text TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_bank_questions_owner ON bank_questions(owner_did, created_at DESC);
+
+CREATE TABLE survey_previews (
+    token_hash TEXT PRIMARY KEY,
+    definition TEXT NOT NULL, -- JSON
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_survey_previews_expires_at ON survey_previews(expires_at);
+
+CREATE TABLE notification_preferences (
+    did TEXT PRIMARY KEY,
+    channel TEXT NOT NULL CHECK (channel IN ('post', 'dm')),
+    milestones TEXT NOT NULL, -- JSON array
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE TABLE survey_notifications (
+    -- Random UUID in text form
+    id TEXT PRIMARY KEY DEFAULT (lower(
+        hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+        substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))
+    )),
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    did TEXT NOT NULL,
+    milestone TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    uri TEXT,
+    error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    UNIQUE (survey_id, milestone)
+);
+
+CREATE INDEX idx_survey_notifications_due ON survey_notifications(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_survey_notifications_did ON survey_notifications(did, created_at DESC);
+
+-- A table refreshed by RefreshTrending, where Postgres has a materialized view
+CREATE TABLE trending_surveys (
+    survey_id TEXT PRIMARY KEY,
+    responses INTEGER NOT NULL,
+    score REAL NOT NULL
+);
+
+CREATE INDEX idx_trending_surveys_score ON trending_surveys(score DESC);
+
+CREATE TABLE featured_surveys (
+    survey_id TEXT PRIMARY KEY REFERENCES surveys(id) ON DELETE CASCADE,
+    featured_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE TABLE survey_archives (
+    survey_id TEXT PRIMARY KEY REFERENCES surveys(id) ON DELETE CASCADE,
+    response_count INTEGER NOT NULL,
+    responses TEXT NOT NULL, -- JSON array of rows of responses
+    flags TEXT NOT NULL, -- JSON array of rows of flagged_responses
+    signals TEXT NOT NULL, -- JSON array of rows of response_signals
+    record_uris TEXT NOT NULL, -- JSON array of the responses' ATProto record URIs
+    results TEXT NOT NULL, -- JSON
+    archived_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    spam_scores TEXT NOT NULL DEFAULT '[]' -- JSON array of rows of response_spam_scores
+);
+
+CREATE TABLE response_exports (
+    id TEXT PRIMARY KEY,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    requested_by TEXT NOT NULL,
+    format TEXT NOT NULL CHECK (format IN ('csv', 'json')),
+    query TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    blob_key TEXT,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX idx_response_exports_expires_at ON response_exports (expires_at) WHERE expires_at IS NOT NULL;
+
+CREATE TABLE jobs (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    payload TEXT NOT NULL DEFAULT '{}', -- JSON
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX idx_jobs_due ON jobs (type, run_at) WHERE status = 'pending';
+CREATE INDEX idx_jobs_running ON jobs (type, locked_until) WHERE status = 'running';
+CREATE INDEX idx_jobs_finished_at ON jobs (finished_at) WHERE finished_at IS NOT NULL;
+
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    response_id TEXT,
+    status INTEGER,
+    content_type TEXT,
+    body BLOB,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+
+CREATE TABLE survey_coauthors (
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    did TEXT NOT NULL,
+    added_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    PRIMARY KEY (survey_id, did)
+);
+
+CREATE INDEX idx_survey_coauthors_did ON survey_coauthors(did);
+
+CREATE TABLE results_publish_requests (
+    id TEXT PRIMARY KEY,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    requested_by TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    resolved_by TEXT,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE UNIQUE INDEX idx_results_publish_requests_pending ON results_publish_requests(survey_id) WHERE status = 'pending';
+
+CREATE TABLE tenants (
+    id TEXT PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    tagline TEXT NOT NULL DEFAULT '',
+    logo_url TEXT NOT NULL DEFAULT '',
+    accent_color TEXT NOT NULL DEFAULT '',
+    default_author_did TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE TABLE domains (
+    hostname TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_domains_tenant ON domains(tenant_id);
+
+CREATE TABLE tenant_surveys (
+    tenant_id TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    added_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    PRIMARY KEY (tenant_id, survey_id)
+);
+
+CREATE TABLE response_spam_scores (
+    response_id TEXT PRIMARY KEY REFERENCES responses(id) ON DELETE CASCADE,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    score INTEGER NOT NULL CHECK (score BETWEEN 0 AND 100),
+    reasons TEXT NOT NULL DEFAULT '[]', -- JSON array
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_response_spam_scores_survey ON response_spam_scores(survey_id, score);
+
+CREATE TABLE spam_thresholds (
+    survey_id TEXT PRIMARY KEY REFERENCES surveys(id) ON DELETE CASCADE,
+    threshold INTEGER NOT NULL CHECK (threshold BETWEEN 1 AND 100),
+    set_by TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE TABLE response_metadata (
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    country TEXT NOT NULL,
+    hour INTEGER NOT NULL CHECK (hour BETWEEN 0 AND 23),
+    responses INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (survey_id, country, hour)
+);
+
+CREATE TABLE survey_invitations (
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    did TEXT NOT NULL,
+    invited_by TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'invited' CHECK (status IN ('invited', 'reminded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    responded_at TIMESTAMP,
+    reminded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    PRIMARY KEY (survey_id, did)
+);
+
+CREATE INDEX idx_survey_invitations_due ON survey_invitations(next_attempt_at)
+    WHERE status = 'invited' AND responded_at IS NULL;
+
+CREATE TABLE reminder_schedules (
+    survey_id TEXT PRIMARY KEY REFERENCES surveys(id) ON DELETE CASCADE,
+    hours_before INTEGER NOT NULL CHECK (hours_before BETWEEN 1 AND 168),
+    set_by TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE TABLE survey_invites (
+    id TEXT PRIMARY KEY,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    invitee TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by TEXT NOT NULL,
+    used_at TIMESTAMP,
+    response_id TEXT REFERENCES responses(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    UNIQUE (survey_id, invitee)
+);
+
+CREATE TABLE survey_comments (
+    id TEXT PRIMARY KEY,
+    survey_id TEXT NOT NULL REFERENCES surveys(id) ON DELETE CASCADE,
+    author_did TEXT NOT NULL,
+    record_uri TEXT NOT NULL UNIQUE,
+    record_cid TEXT NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    indexed_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_survey_comments_survey ON survey_comments (survey_id, created_at, id);
+-- SQLite has no index entry size limit, so text is indexed without hashing it
+CREATE INDEX idx_survey_comments_author ON survey_comments (survey_id, author_did, text);
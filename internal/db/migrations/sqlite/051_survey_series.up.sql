@@ -0,0 +1,22 @@
+-- Survey Series
+-- Surveys of one author linked into a series, whose results are combined and
+-- whose logged-in voters are tracked across its surveys (waves). A survey
+-- belongs to at most one series.
+
+CREATE TABLE survey_series (
+    id TEXT PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
+    owner_did TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+
+CREATE INDEX idx_survey_series_owner ON survey_series(owner_did, created_at DESC);
+
+CREATE TABLE survey_series_surveys (
+    series_id TEXT NOT NULL REFERENCES survey_series(id) ON DELETE CASCADE,
+    survey_id TEXT NOT NULL UNIQUE REFERENCES surveys(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    added_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+    PRIMARY KEY (series_id, survey_id)
+);
@@ -0,0 +1,3 @@
+-- Rollback Reserved Slugs
+
+DROP TABLE IF EXISTS reserved_slugs;
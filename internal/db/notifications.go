@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/notify"
+)
+
+// GetNotificationPreferences implements the notify.Store interface
+// Returns nil if the author has not opted in
+func (q *Queries) GetNotificationPreferences(ctx context.Context, did string) (*notify.Preferences, error) {
+	query := `SELECT did, channel, milestones, updated_at FROM notification_preferences WHERE did = $1`
+
+	p := &notify.Preferences{}
+	var milestones []byte
+	err := q.db.QueryRowContext(ctx, query, did).Scan(&p.DID, &p.Channel, &milestones, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	if err := json.Unmarshal(milestones, &p.Milestones); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification milestones: %w", err)
+	}
+
+	return p, nil
+}
+
+// SaveNotificationPreferences implements the notify.Store interface
+func (q *Queries) SaveNotificationPreferences(ctx context.Context, p *notify.Preferences) error {
+	milestones, err := json.Marshal(p.Milestones)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification milestones: %w", err)
+	}
+
+	query := `
+		INSERT INTO notification_preferences (did, channel, milestones, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (did) DO UPDATE
+		SET channel = EXCLUDED.channel, milestones = EXCLUDED.milestones, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, p.DID, p.Channel, milestones, p.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteNotificationPreferences implements the notify.Store interface
+func (q *Queries) DeleteNotificationPreferences(ctx context.Context, did string) error {
+	if _, err := q.db.ExecContext(ctx, `DELETE FROM notification_preferences WHERE did = $1`, did); err != nil {
+		return fmt.Errorf("failed to delete notification preferences: %w", err)
+	}
+	return nil
+}
+
+// QueueReachedMilestones implements the notify.Store interface
+// A milestone is reached when its response was created or the survey closed.
+// Surveys in the trash are skipped, and private surveys unless notified by
+// direct message, as posts are public.
+func (q *Queries) QueueReachedMilestones(ctx context.Context, now time.Time) (int64, error) {
+	query := `
+		INSERT INTO survey_notifications (survey_id, did, milestone, channel, next_attempt_at, created_at)
+		SELECT s.id, p.did, m.milestone, p.channel, $1, $1
+		FROM notification_preferences p
+		JOIN surveys s ON s.author_did = p.did AND s.deleted_at IS NULL
+		CROSS JOIN LATERAL (
+			SELECT 'first_response' AS milestone,
+				(SELECT r.created_at FROM responses r WHERE r.survey_id = s.id ORDER BY r.created_at LIMIT 1) AS reached_at
+			UNION ALL
+			SELECT 'responses_100',
+				(SELECT r.created_at FROM responses r WHERE r.survey_id = s.id ORDER BY r.created_at OFFSET 99 LIMIT 1)
+			UNION ALL
+			SELECT 'closed', s.ends_at
+		) m
+		WHERE p.milestones @> jsonb_build_array(m.milestone)
+			AND (p.channel = 'dm' OR COALESCE(s.definition->>'visibility', '') NOT IN ('token', 'invite'))
+			AND m.reached_at > p.updated_at AND m.reached_at <= $1
+		ON CONFLICT (survey_id, milestone) DO NOTHING
+	`
+	if q.dialect == dialectSQLite {
+		// No lateral joins: each preferred milestone is reached at the time of its case
+		query = `
+			INSERT INTO survey_notifications (survey_id, did, milestone, channel, next_attempt_at, created_at)
+			SELECT survey_id, did, milestone, channel, $1, $1
+			FROM (
+				SELECT s.id AS survey_id, p.did, m.value AS milestone, p.channel, p.updated_at,
+					CASE m.value
+						WHEN 'first_response' THEN (SELECT r.created_at FROM responses r WHERE r.survey_id = s.id ORDER BY r.created_at LIMIT 1)
+						WHEN 'responses_100' THEN (SELECT r.created_at FROM responses r WHERE r.survey_id = s.id ORDER BY r.created_at LIMIT 1 OFFSET 99)
+						WHEN 'closed' THEN s.ends_at
+					END AS reached_at
+				FROM notification_preferences p
+				JOIN surveys s ON s.author_did = p.did AND s.deleted_at IS NULL
+				JOIN json_each(p.milestones) m
+				WHERE p.channel = 'dm' OR COALESCE(s.definition->>'visibility', '') NOT IN ('token', 'invite')
+			)
+			WHERE reached_at > updated_at AND reached_at <= $1
+			ON CONFLICT (survey_id, milestone) DO NOTHING
+		`
+	}
+
+	result, err := q.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue milestone notifications: %w", err)
+	}
+
+	queued, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return queued, nil
+}
+
+// ClaimDueNotifications implements the notify.Store interface
+// Due rows are locked, skipping rows another replica is claiming
+func (q *Queries) ClaimDueNotifications(ctx context.Context, now time.Time, limit int) ([]*notify.Notification, error) {
+	query := `
+		UPDATE survey_notifications
+		SET next_attempt_at = $3
+		WHERE id IN (
+			SELECT id FROM survey_notifications
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationColumns
+
+	rows, err := q.db.QueryContext(ctx, query, now, limit, now.Add(notify.ClaimLease))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due notifications: %w", err)
+	}
+	defer rows.Close()
+
+	return scanNotifications(rows)
+}
+
+// UpdateNotification implements the notify.Store interface
+func (q *Queries) UpdateNotification(ctx context.Context, n *notify.Notification) error {
+	query := `
+		UPDATE survey_notifications
+		SET status = $2, attempts = $3, uri = $4, error = $5, next_attempt_at = $6, sent_at = $7
+		WHERE id = $1
+	`
+
+	_, err := q.db.ExecContext(ctx, query, n.ID, n.Status, n.Attempts, n.URI, n.Error, n.NextAttemptAt, n.SentAt)
+	if err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+
+	return nil
+}
+
+// ListNotifications implements the notify.Store interface
+// Returns an author's latest notifications with their surveys, newest first
+func (q *Queries) ListNotifications(ctx context.Context, did string, limit int) ([]*notify.Notification, error) {
+	query := `
+		SELECT n.id, n.survey_id, s.slug, s.title, n.did, n.milestone, n.channel, n.status, n.attempts,
+			n.uri, n.error, n.next_attempt_at, n.sent_at, n.created_at
+		FROM survey_notifications n
+		JOIN surveys s ON s.id = n.survey_id
+		WHERE n.did = $1
+		ORDER BY n.created_at DESC, n.id
+		LIMIT $2
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, did, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []*notify.Notification
+	for rows.Next() {
+		n := &notify.Notification{}
+		err := rows.Scan(&n.ID, &n.SurveyID, &n.SurveySlug, &n.SurveyTitle, &n.DID, &n.Milestone, &n.Channel, &n.Status,
+			&n.Attempts, &n.URI, &n.Error, &n.NextAttemptAt, &n.SentAt, &n.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// notificationColumns are the columns scanned by scanNotifications
+const notificationColumns = `id, survey_id, did, milestone, channel, status, attempts, uri, error, next_attempt_at, sent_at, created_at`
+
+// scanNotifications scans rows of notificationColumns
+func scanNotifications(rows Rows) ([]*notify.Notification, error) {
+	var notifications []*notify.Notification
+	for rows.Next() {
+		n := &notify.Notification{}
+		err := rows.Scan(&n.ID, &n.SurveyID, &n.DID, &n.Milestone, &n.Channel, &n.Status, &n.Attempts,
+			&n.URI, &n.Error, &n.NextAttemptAt, &n.SentAt, &n.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notifications: %w", err)
+	}
+
+	return notifications, nil
+}
@@ -0,0 +1,104 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	author := "did:plc:alice"
+	now := time.Now().Truncate(time.Microsecond)
+
+	newSurvey := func(slug string, endsAt *time.Time) *models.Survey {
+		survey := &models.Survey{
+			ID:         uuid.New(),
+			Slug:       slug,
+			Title:      slug,
+			AuthorDID:  &author,
+			Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}}},
+			EndsAt:     endsAt,
+			CreatedAt:  now.Add(-time.Hour),
+			UpdatedAt:  now.Add(-time.Hour),
+		}
+		require.NoError(t, queries.CreateSurvey(ctx, survey))
+		return survey
+	}
+	respond := func(survey *models.Survey, n int, at time.Time) {
+		for i := 0; i < n; i++ {
+			session := fmt.Sprintf("%s-%d-%d", survey.Slug, at.UnixNano(), i)
+			require.NoError(t, queries.CreateResponse(ctx, &models.Response{
+				ID:           uuid.New(),
+				SurveyID:     survey.ID,
+				VoterSession: &session,
+				Answers:      map[string]models.Answer{"q1": {Text: "Because"}},
+				CreatedAt:    at,
+			}))
+		}
+	}
+
+	prefs, err := queries.GetNotificationPreferences(ctx, author)
+	require.NoError(t, err)
+	assert.Nil(t, prefs)
+
+	// Responses before opting in are not notified
+	old := newSurvey("before", nil)
+	respond(old, 1, now.Add(-time.Hour))
+
+	prefs, err = notify.NewPreferences(author, notify.ChannelPost, []notify.Milestone{notify.FirstResponse, notify.Responses100, notify.Closed}, now.Add(-30*time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, queries.SaveNotificationPreferences(ctx, prefs))
+
+	popular := newSurvey("popular", nil)
+	respond(popular, 100, now.Add(-10*time.Minute))
+	closed := newSurvey("closed", &[]time.Time{now.Add(-time.Minute)}[0])
+	newSurvey("running", &[]time.Time{now.Add(time.Hour)}[0])
+
+	queued, err := queries.QueueReachedMilestones(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), queued) // popular: first and 100th response; closed: closed
+	queued, err = queries.QueueReachedMilestones(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, queued, "milestones are queued once")
+
+	due, err := queries.ClaimDueNotifications(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 3)
+	again, err := queries.ClaimDueNotifications(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, again, "claimed notifications are leased")
+
+	for _, n := range due {
+		if n.SurveyID == closed.ID {
+			assert.Equal(t, notify.Closed, n.Milestone)
+		} else {
+			assert.Equal(t, popular.ID, n.SurveyID)
+		}
+		n.Attempted("at://did:plc:alice/app.bsky.feed.post/1", nil, now)
+		require.NoError(t, queries.UpdateNotification(ctx, n))
+	}
+
+	history, err := queries.ListNotifications(ctx, author, notify.HistoryLimit)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, notify.StatusSent, history[0].Status)
+	assert.NotEmpty(t, history[0].SurveyTitle)
+	require.NotNil(t, history[0].URI)
+
+	require.NoError(t, queries.DeleteNotificationPreferences(ctx, author))
+	prefs, err = queries.GetNotificationPreferences(ctx, author)
+	require.NoError(t, err)
+	assert.Nil(t, prefs)
+}
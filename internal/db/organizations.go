@@ -0,0 +1,316 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/org"
+)
+
+// CreateOrg implements the org.Store interface
+// Saves an organization with its creator as an accepted owner, or returns
+// org.ErrSlugTaken
+func (q *Queries) CreateOrg(ctx context.Context, o *org.Org) error {
+	return q.InTx(ctx, func(tx *Queries) error {
+		result, err := tx.db.ExecContext(ctx, `
+			INSERT INTO organizations (id, slug, name, created_by, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (slug) DO NOTHING
+		`, o.ID, o.Slug, o.Name, o.CreatedBy, o.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert organization: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return org.ErrSlugTaken
+		}
+
+		_, err = tx.db.ExecContext(ctx, `
+			INSERT INTO organization_members (org_id, did, role, invited_by, accepted_at, created_at)
+			VALUES ($1, $2, $3, $2, $4, $4)
+		`, o.ID, o.CreatedBy, org.RoleOwner, o.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert organization owner: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetOrgBySlug implements the org.Store interface
+func (q *Queries) GetOrgBySlug(ctx context.Context, slug string) (*org.Org, error) {
+	return q.getOrg(ctx, "slug", slug)
+}
+
+// GetOrgByID implements the org.Store interface
+func (q *Queries) GetOrgByID(ctx context.Context, id uuid.UUID) (*org.Org, error) {
+	return q.getOrg(ctx, "id", id)
+}
+
+// getOrg returns the organization whose column has the value, or sql.ErrNoRows
+func (q *Queries) getOrg(ctx context.Context, column string, value interface{}) (*org.Org, error) {
+	query := `SELECT id, slug, name, created_by, created_at FROM organizations WHERE ` + column + ` = $1`
+
+	o := &org.Org{}
+	err := q.db.QueryRowContext(ctx, query, value).Scan(&o.ID, &o.Slug, &o.Name, &o.CreatedBy, &o.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	return o, nil
+}
+
+// CountOrgsByCreator implements the org.Store interface
+func (q *Queries) CountOrgsByCreator(ctx context.Context, did string) (int, error) {
+	var count int
+	if err := q.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM organizations WHERE created_by = $1`, did).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count organizations: %w", err)
+	}
+	return count, nil
+}
+
+// ListMemberships implements the org.Store interface
+// Returns the organizations a user belongs to or is invited to, by name
+func (q *Queries) ListMemberships(ctx context.Context, did string) ([]*org.Membership, error) {
+	query := `
+		SELECT o.id, o.slug, o.name, o.created_by, o.created_at,
+			m.org_id, m.did, m.role, m.invited_by, m.accepted_at, m.created_at
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.org_id
+		WHERE m.did = $1
+		ORDER BY o.name, o.slug
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	defer rows.Close()
+
+	var memberships []*org.Membership
+	for rows.Next() {
+		o, m := &org.Org{}, &org.Member{}
+		if err := rows.Scan(&o.ID, &o.Slug, &o.Name, &o.CreatedBy, &o.CreatedAt,
+			&m.OrgID, &m.DID, &m.Role, &m.InvitedBy, &m.AcceptedAt, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan membership: %w", err)
+		}
+		memberships = append(memberships, &org.Membership{Org: o, Member: m})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating memberships: %w", err)
+	}
+
+	return memberships, nil
+}
+
+// ListMembers implements the org.Store interface
+// Returns the members and invitees of an organization, owners first
+func (q *Queries) ListMembers(ctx context.Context, orgID uuid.UUID) ([]*org.Member, error) {
+	query := `
+		SELECT org_id, did, role, invited_by, accepted_at, created_at
+		FROM organization_members
+		WHERE org_id = $1
+		ORDER BY CASE role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END, created_at
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*org.Member
+	for rows.Next() {
+		m := &org.Member{}
+		if err := rows.Scan(&m.OrgID, &m.DID, &m.Role, &m.InvitedBy, &m.AcceptedAt, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating members: %w", err)
+	}
+
+	return members, nil
+}
+
+// GetMember implements the org.Store interface
+// Returns sql.ErrNoRows if the DID is neither a member nor invited
+func (q *Queries) GetMember(ctx context.Context, orgID uuid.UUID, did string) (*org.Member, error) {
+	query := `
+		SELECT org_id, did, role, invited_by, accepted_at, created_at
+		FROM organization_members
+		WHERE org_id = $1 AND did = $2
+	`
+
+	m := &org.Member{}
+	err := q.db.QueryRowContext(ctx, query, orgID, did).Scan(&m.OrgID, &m.DID, &m.Role, &m.InvitedBy, &m.AcceptedAt, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+
+	return m, nil
+}
+
+// InviteMember implements the org.Store interface
+// Returns org.ErrAlreadyMember if the DID is a member or invited
+func (q *Queries) InviteMember(ctx context.Context, m *org.Member) error {
+	query := `
+		INSERT INTO organization_members (org_id, did, role, invited_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (org_id, did) DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, m.OrgID, m.DID, m.Role, m.InvitedBy, m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert invite: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return org.ErrAlreadyMember
+	}
+
+	return nil
+}
+
+// AcceptInvite implements the org.Store interface
+// Returns sql.ErrNoRows if the DID has no pending invite
+func (q *Queries) AcceptInvite(ctx context.Context, orgID uuid.UUID, did string) error {
+	query := `
+		UPDATE organization_members
+		SET accepted_at = NOW()
+		WHERE org_id = $1 AND did = $2 AND accepted_at IS NULL
+	`
+	return q.execOneMember(ctx, "accept invite", query, orgID, did)
+}
+
+// UpdateMemberRole implements the org.Store interface
+func (q *Queries) UpdateMemberRole(ctx context.Context, orgID uuid.UUID, did, role string) error {
+	query := `UPDATE organization_members SET role = $3 WHERE org_id = $1 AND did = $2`
+	return q.execOneMember(ctx, "update member role", query, orgID, did, role)
+}
+
+// RemoveMember implements the org.Store interface
+func (q *Queries) RemoveMember(ctx context.Context, orgID uuid.UUID, did string) error {
+	query := `DELETE FROM organization_members WHERE org_id = $1 AND did = $2`
+	return q.execOneMember(ctx, "remove member", query, orgID, did)
+}
+
+// execOneMember runs a statement changing one member, returning sql.ErrNoRows if it changed none
+func (q *Queries) execOneMember(ctx context.Context, action, query string, args ...interface{}) error {
+	result, err := q.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListOrgSurveys implements the org.Store interface
+// Returns the surveys owned by an organization, newest first
+func (q *Queries) ListOrgSurveys(ctx context.Context, orgID uuid.UUID) ([]*models.Survey, error) {
+	query := `
+		SELECT id, uri, cid, author_did, slug, title, description, definition, version, starts_at, ends_at, results_uri, results_cid, created_at, updated_at, hidden_at, org_id
+		FROM surveys
+		WHERE org_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query surveys: %w", err)
+	}
+	defer rows.Close()
+
+	var surveys []*models.Survey
+	for rows.Next() {
+		survey := &models.Survey{}
+		var defJSON []byte
+
+		err := rows.Scan(
+			&survey.ID,
+			&survey.URI,
+			&survey.CID,
+			&survey.AuthorDID,
+			&survey.Slug,
+			&survey.Title,
+			&survey.Description,
+			&defJSON,
+			&survey.Version,
+			&survey.StartsAt,
+			&survey.EndsAt,
+			&survey.ResultsURI,
+			&survey.ResultsCID,
+			&survey.CreatedAt,
+			&survey.UpdatedAt,
+			&survey.HiddenAt,
+			&survey.OrgID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan survey: %w", err)
+		}
+
+		if err := json.Unmarshal(defJSON, &survey.Definition); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal survey definition: %w", err)
+		}
+
+		surveys = append(surveys, survey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating surveys: %w", err)
+	}
+
+	return surveys, nil
+}
+
+// SetSurveyOrg implements the org.Store interface
+// Moves a survey to an organization, or back to its author with nil
+func (q *Queries) SetSurveyOrg(ctx context.Context, surveyID uuid.UUID, orgID *uuid.UUID) error {
+	query := `UPDATE surveys SET org_id = $2, updated_at = NOW() WHERE id = $1`
+
+	result, err := q.db.ExecContext(ctx, query, surveyID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to set survey organization: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
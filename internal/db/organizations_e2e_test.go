@@ -0,0 +1,85 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/org"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizations(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	o, err := org.New("acme", "Acme", "did:plc:alice")
+	require.NoError(t, err)
+	require.NoError(t, queries.CreateOrg(ctx, o))
+
+	dup, err := org.New("acme", "Other", "did:plc:bob")
+	require.NoError(t, err)
+	assert.ErrorIs(t, queries.CreateOrg(ctx, dup), org.ErrSlugTaken)
+
+	owner, err := queries.GetMember(ctx, o.ID, "did:plc:alice")
+	require.NoError(t, err)
+	assert.True(t, owner.CanAdminister(), "the creator is an accepted owner")
+
+	invite := &org.Member{OrgID: o.ID, DID: "did:plc:bob", Role: org.RoleEditor, InvitedBy: "did:plc:alice", CreatedAt: time.Now()}
+	require.NoError(t, queries.InviteMember(ctx, invite))
+	assert.ErrorIs(t, queries.InviteMember(ctx, invite), org.ErrAlreadyMember)
+
+	memberships, err := queries.ListMemberships(ctx, "did:plc:bob")
+	require.NoError(t, err)
+	require.Len(t, memberships, 1)
+	assert.Equal(t, "acme", memberships[0].Org.Slug)
+	assert.True(t, memberships[0].Member.Pending())
+
+	require.NoError(t, queries.AcceptInvite(ctx, o.ID, "did:plc:bob"))
+	assert.ErrorIs(t, queries.AcceptInvite(ctx, o.ID, "did:plc:bob"), sql.ErrNoRows)
+
+	require.NoError(t, queries.UpdateMemberRole(ctx, o.ID, "did:plc:bob", org.RoleViewer))
+	members, err := queries.ListMembers(ctx, o.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, "did:plc:alice", members[0].DID, "owners first")
+	assert.Equal(t, org.RoleViewer, members[1].Role)
+
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "org-survey",
+		Title: "Org Survey",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+	require.NoError(t, queries.SetSurveyOrg(ctx, survey.ID, &o.ID))
+
+	got, err := queries.GetSurveyBySlug(ctx, "org-survey")
+	require.NoError(t, err)
+	require.NotNil(t, got.OrgID)
+	assert.Equal(t, o.ID, *got.OrgID)
+
+	surveys, err := queries.ListOrgSurveys(ctx, o.ID)
+	require.NoError(t, err)
+	require.Len(t, surveys, 1)
+	assert.Equal(t, survey.ID, surveys[0].ID)
+
+	require.NoError(t, queries.RemoveMember(ctx, o.ID, "did:plc:bob"))
+	assert.ErrorIs(t, queries.RemoveMember(ctx, o.ID, "did:plc:bob"), sql.ErrNoRows)
+
+	require.NoError(t, queries.SetSurveyOrg(ctx, survey.ID, nil))
+	got, err = queries.GetSurveyBySlug(ctx, "org-survey")
+	require.NoError(t, err)
+	assert.Nil(t, got.OrgID)
+}
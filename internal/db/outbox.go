@@ -0,0 +1,302 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/outbox"
+)
+
+// CreateOutboxEntry implements the outbox.Store interface
+// Stores a record whose PDS write failed for a later retry
+func (q *Queries) CreateOutboxEntry(ctx context.Context, e *outbox.Entry) error {
+	query := `
+		INSERT INTO pds_outbox (id, kind, survey_id, response_id, did, collection, rkey, record, status, attempts, last_error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+
+	_, err := q.db.ExecContext(ctx, query,
+		e.ID,
+		e.Kind,
+		e.SurveyID,
+		e.ResponseID,
+		e.DID,
+		e.Collection,
+		e.RKey,
+		[]byte(e.Record),
+		e.Status,
+		e.Attempts,
+		e.LastError,
+		e.CreatedAt,
+		e.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert outbox entry: %w", err)
+	}
+
+	return nil
+}
+
+// GetOutboxEntry implements the outbox.Store interface
+// Returns sql.ErrNoRows if the entry does not exist
+func (q *Queries) GetOutboxEntry(ctx context.Context, id uuid.UUID) (*outbox.Entry, error) {
+	query := `
+		SELECT ` + outboxColumns + `
+		FROM pds_outbox
+		WHERE id = $1
+	`
+
+	e, err := scanOutboxEntry(q.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get outbox entry: %w", err)
+	}
+
+	return e, nil
+}
+
+// ListPendingOutboxEntries implements the outbox.Store interface
+// Returns a user's unpublished records for a survey, oldest first
+func (q *Queries) ListPendingOutboxEntries(ctx context.Context, surveyID uuid.UUID, did string) ([]*outbox.Entry, error) {
+	query := `
+		SELECT ` + outboxColumns + `
+		FROM pds_outbox
+		WHERE survey_id = $1 AND did = $2 AND status = 'pending'
+		ORDER BY created_at
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*outbox.Entry
+	for rows.Next() {
+		e, err := scanOutboxEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// RecordOutboxAttempt implements the outbox.Store interface
+// Counts a failed retry and keeps its error
+func (q *Queries) RecordOutboxAttempt(ctx context.Context, id uuid.UUID, lastError string) error {
+	query := `
+		UPDATE pds_outbox
+		SET attempts = attempts + 1, last_error = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, id, lastError); err != nil {
+		return fmt.Errorf("failed to record outbox attempt: %w", err)
+	}
+
+	return nil
+}
+
+// MarkOutboxPublished implements the outbox.Store interface
+// Links the local survey or response to the written record and marks the entry
+// published in a single transaction, so a failure leaves the entry pending.
+// A response becomes the voter's ATProto response, replacing its guest session.
+func (q *Queries) MarkOutboxPublished(ctx context.Context, e *outbox.Entry, uri, cid string) error {
+	switch e.Kind {
+	case outbox.KindSurvey:
+		if q.dialect == dialectSQLite {
+			return q.InTx(ctx, func(tx *Queries) error {
+				return tx.publishOutboxSurveySQLite(ctx, e, uri, cid)
+			})
+		}
+		query := `
+			WITH published AS (
+				UPDATE pds_outbox
+				SET status = 'published', attempts = attempts + 1, last_error = NULL, updated_at = NOW()
+				WHERE id = $1 AND status = 'pending'
+				RETURNING survey_id
+			)
+			UPDATE surveys
+			SET uri = $2, cid = $3, author_did = $4, updated_at = NOW()
+			WHERE id = (SELECT survey_id FROM published)
+		`
+		result, err := q.db.ExecContext(ctx, query, e.ID, uri, cid, e.DID)
+		if err != nil {
+			return fmt.Errorf("failed to mark outbox entry published: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	case outbox.KindResponse:
+		return q.InTx(ctx, func(tx *Queries) error {
+			return tx.publishOutboxResponse(ctx, e, uri, cid)
+		})
+	default:
+		return fmt.Errorf("unknown outbox entry kind: %s", e.Kind)
+	}
+}
+
+// publishOutboxSurveySQLite marks a survey entry published and links the
+// survey to the record on SQLite, which has no data-modifying CTEs
+func (q *Queries) publishOutboxSurveySQLite(ctx context.Context, e *outbox.Entry, uri, cid string) error {
+	var surveyID uuid.UUID
+	err := q.db.QueryRowContext(ctx, `
+		UPDATE pds_outbox
+		SET status = 'published', attempts = attempts + 1, last_error = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+		RETURNING survey_id
+	`, e.ID).Scan(&surveyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to mark outbox entry published: %w", err)
+	}
+
+	query := `UPDATE surveys SET uri = $2, cid = $3, author_did = $4, updated_at = NOW() WHERE id = $1`
+	result, err := q.db.ExecContext(ctx, query, surveyID, uri, cid, e.DID)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox entry published: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// publishOutboxResponse marks a response entry published and moves its guest
+// response to the voter's DID. The consumer may already have indexed the
+// published record, or the voter may have voted again while logged in; then
+// the voter's existing DID response takes the record and the guest row is
+// deleted, so the vote is not counted twice.
+func (q *Queries) publishOutboxResponse(ctx context.Context, e *outbox.Entry, uri, cid string) error {
+	var guestID, surveyID uuid.UUID
+	var err error
+	if q.dialect == dialectSQLite {
+		// No data-modifying CTEs: the entry is updated first
+		err = q.db.QueryRowContext(ctx, `
+			UPDATE pds_outbox
+			SET status = 'published', attempts = attempts + 1, last_error = NULL, updated_at = NOW()
+			WHERE id = $1 AND status = 'pending'
+			RETURNING response_id
+		`, e.ID).Scan(&guestID)
+		if err == nil {
+			err = q.db.QueryRowContext(ctx, `SELECT id, survey_id FROM responses WHERE id = $1`, guestID).Scan(&guestID, &surveyID)
+		}
+	} else {
+		err = q.db.QueryRowContext(ctx, `
+			WITH published AS (
+				UPDATE pds_outbox
+				SET status = 'published', attempts = attempts + 1, last_error = NULL, updated_at = NOW()
+				WHERE id = $1 AND status = 'pending'
+				RETURNING response_id
+			)
+			SELECT r.id, r.survey_id
+			FROM responses r
+			WHERE r.id = (SELECT response_id FROM published)
+			FOR UPDATE
+		`, e.ID).Scan(&guestID, &surveyID)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to mark outbox entry published: %w", err)
+	}
+
+	// The voter's DID response, by voter or by the published record
+	var existingID uuid.UUID
+	err = q.db.QueryRowContext(ctx, `
+		SELECT id FROM responses
+		WHERE survey_id = $1 AND id <> $2 AND (voter_did = $3 OR record_uri = $4)
+		ORDER BY (record_uri = $4) DESC NULLS LAST
+		LIMIT 1
+		FOR UPDATE
+	`, surveyID, guestID, e.DID, uri).Scan(&existingID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = q.db.ExecContext(ctx, `
+			UPDATE responses
+			SET record_uri = $2, record_cid = $3, voter_did = $4, voter_session = NULL
+			WHERE id = $1
+		`, guestID, uri, cid, e.DID)
+		if err != nil {
+			return fmt.Errorf("failed to link published response: %w", err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to find existing response: %w", err)
+	}
+
+	// The published record holds the guest's answers
+	_, err = q.db.ExecContext(ctx, `
+		UPDATE responses AS r
+		SET record_uri = $2, record_cid = $3, voter_did = $4, voter_session = NULL, answers = g.answers, survey_version = g.survey_version
+		FROM responses AS g
+		WHERE r.id = $1 AND g.id = $5
+	`, existingID, uri, cid, e.DID, guestID)
+	if err != nil {
+		return fmt.Errorf("failed to update existing response: %w", err)
+	}
+	// Keep the entry, which the guest row's deletion would cascade to
+	if _, err := q.db.ExecContext(ctx, `UPDATE pds_outbox SET response_id = $1 WHERE response_id = $2`, existingID, guestID); err != nil {
+		return fmt.Errorf("failed to move outbox entries: %w", err)
+	}
+	if _, err := q.db.ExecContext(ctx, `DELETE FROM responses WHERE id = $1`, guestID); err != nil {
+		return fmt.Errorf("failed to delete guest response: %w", err)
+	}
+	return nil
+}
+
+// outboxColumns are the columns scanned by scanOutboxEntry
+const outboxColumns = `id, kind, survey_id, response_id, did, collection, rkey, record, status, attempts, last_error, created_at, updated_at`
+
+// rowScanner is implemented by Row and Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOutboxEntry scans a row of outboxColumns
+func scanOutboxEntry(row rowScanner) (*outbox.Entry, error) {
+	e := &outbox.Entry{}
+	var record []byte
+	if err := row.Scan(
+		&e.ID,
+		&e.Kind,
+		&e.SurveyID,
+		&e.ResponseID,
+		&e.DID,
+		&e.Collection,
+		&e.RKey,
+		&record,
+		&e.Status,
+		&e.Attempts,
+		&e.LastError,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	e.Record = record
+	return e, nil
+}
@@ -0,0 +1,138 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	testcontainers "github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// startMigratedDB starts a PostgreSQL container with all migrations applied,
+// or opens a migrated SQLite database with TEST_DB_DRIVER=sqlite
+func startMigratedDB(t *testing.T) *sql.DB {
+	t.Helper()
+	if os.Getenv("TEST_DB_DRIVER") == DriverSQLite {
+		return openMigratedSQLite(t)
+	}
+	ctx := context.Background()
+
+	postgresC, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("survey_test"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second)),
+	)
+	require.NoError(t, err, "Failed to start PostgreSQL container")
+	t.Cleanup(func() {
+		if err := postgresC.Terminate(ctx); err != nil {
+			t.Logf("Failed to terminate container: %v", err)
+		}
+	})
+
+	connStr, err := postgresC.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+	// Opened like Connect does, so queries run natively on a pgx pool
+	database, err := open(ctx, connStr, "survey_test", "primary", PoolConfig{})
+	require.NoError(t, err)
+	t.Cleanup(func() { Close(database) })
+
+	_, err = Migrate(ctx, database)
+	require.NoError(t, err)
+	return database
+}
+
+func TestMarkOutboxPublished_Response(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	const did = "did:plc:voter"
+	uri := "at://" + did + "/net.openmeet.survey.response/3k2"
+	guestAnswers := map[string]models.Answer{"q1": {SelectedOptions: []string{"a"}}}
+
+	setup := func(t *testing.T, slug string) (*models.Survey, *models.Response, *outbox.Entry) {
+		t.Helper()
+		survey := &models.Survey{
+			ID:         uuid.New(),
+			Slug:       slug,
+			Title:      "Lunch",
+			Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Where?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "a", Text: "A"}, {ID: "b", Text: "B"}}}}},
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+		session := "guest-" + slug
+		guest := &models.Response{ID: uuid.New(), SurveyID: survey.ID, VoterSession: &session, Answers: guestAnswers, CreatedAt: time.Now()}
+		require.NoError(t, queries.CreateResponse(ctx, guest))
+
+		entry, err := outbox.NewEntry(outbox.KindResponse, did, "net.openmeet.survey.response", "3k2", map[string]interface{}{})
+		require.NoError(t, err)
+		entry.SurveyID, entry.ResponseID = survey.ID, &guest.ID
+		require.NoError(t, queries.CreateOutboxEntry(ctx, entry))
+		return survey, guest, entry
+	}
+
+	t.Run("moves the guest response to the DID", func(t *testing.T) {
+		_, guest, entry := setup(t, "moves")
+		require.NoError(t, queries.MarkOutboxPublished(ctx, entry, uri, "bafy1"))
+
+		response, err := queries.GetResponseByID(ctx, guest.ID)
+		require.NoError(t, err)
+		assert.Equal(t, did, *response.VoterDID)
+		assert.Nil(t, response.VoterSession)
+		assert.Equal(t, uri, *response.RecordURI)
+	})
+
+	t.Run("merges into the response already indexed from the record", func(t *testing.T) {
+		survey, guest, entry := setup(t, "merges")
+
+		// The consumer indexed the published record before it was linked
+		voterDID, recordURI, recordCID := did, uri, "bafy1"
+		indexed := &models.Response{
+			ID: uuid.New(), SurveyID: survey.ID, VoterDID: &voterDID, RecordURI: &recordURI, RecordCID: &recordCID,
+			Answers: map[string]models.Answer{"q1": {SelectedOptions: []string{"b"}}}, CreatedAt: time.Now(),
+		}
+		require.NoError(t, queries.CreateResponse(ctx, indexed))
+
+		require.NoError(t, queries.MarkOutboxPublished(ctx, entry, uri, "bafy1"))
+
+		_, err := queries.GetResponseByID(ctx, guest.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows, "the guest response is replaced")
+
+		response, err := queries.GetResponseByID(ctx, indexed.ID)
+		require.NoError(t, err)
+		assert.Equal(t, guestAnswers, response.Answers)
+
+		published, err := queries.GetOutboxEntry(ctx, entry.ID)
+		require.NoError(t, err)
+		assert.Equal(t, outbox.StatusPublished, published.Status)
+		assert.Equal(t, indexed.ID, *published.ResponseID)
+
+		var count int
+		require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM responses WHERE survey_id = $1`, survey.ID).Scan(&count))
+		assert.Equal(t, 1, count, "the vote is counted once")
+	})
+
+	t.Run("publishing twice reports no pending entry", func(t *testing.T) {
+		_, _, entry := setup(t, "twice")
+		require.NoError(t, queries.MarkOutboxPublished(ctx, entry, uri, "bafy1"))
+		assert.ErrorIs(t, queries.MarkOutboxPublished(ctx, entry, uri, "bafy1"), sql.ErrNoRows)
+	})
+}
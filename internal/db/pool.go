@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+// DefaultPoolStatsInterval is how often connection pool stats are exported
+const DefaultPoolStatsInterval = 15 * time.Second
+
+// Pool is a connection pool whose stats are exported under a name
+type Pool struct {
+	Name string // primary, or replica0, replica1, ...
+	DB   *sql.DB
+}
+
+// Pools names the primary and replica connection pools for their metrics
+func Pools(primary *sql.DB, replicas ...*sql.DB) []Pool {
+	pools := []Pool{{Name: "primary", DB: primary}}
+	for i, replica := range replicas {
+		pools = append(pools, Pool{Name: "replica" + strconv.Itoa(i), DB: replica})
+	}
+	return pools
+}
+
+// Stats returns the stats of a database's connection pool. For a database
+// opened with Connect on Postgres, these are the stats of its pgx pool, whose
+// connections the database/sql handle only borrows.
+func Stats(db *sql.DB) sql.DBStats {
+	pool := pgxPoolOf(db)
+	if pool == nil {
+		return db.Stats()
+	}
+	stat := pool.Stat()
+	return sql.DBStats{
+		MaxOpenConnections: int(stat.MaxConns()),
+		OpenConnections:    int(stat.TotalConns()),
+		InUse:              int(stat.AcquiredConns()),
+		Idle:               int(stat.IdleConns()),
+		WaitCount:          stat.EmptyAcquireCount(),
+		WaitDuration:       stat.EmptyAcquireWaitTime(),
+		MaxIdleTimeClosed:  stat.MaxIdleDestroyCount(),
+		MaxLifetimeClosed:  stat.MaxLifetimeDestroyCount(),
+	}
+}
+
+// PoolDB is a database whose Stats are those of its connection pool, for
+// health checks of databases opened with Connect
+type PoolDB struct {
+	*sql.DB
+}
+
+// Stats returns the stats of the database's connection pool
+func (d PoolDB) Stats() sql.DBStats {
+	return Stats(d.DB)
+}
+
+// Saturation returns the share of a pool's maximum open connections in use,
+// 0 for pools without a maximum
+func Saturation(stats sql.DBStats) float64 {
+	if stats.MaxOpenConnections <= 0 {
+		return 0
+	}
+	return float64(stats.InUse) / float64(stats.MaxOpenConnections)
+}
+
+// ExportPoolStats sets the pool metrics from the pools' current stats
+func ExportPoolStats(pools []Pool) {
+	for _, p := range pools {
+		stats := Stats(p.DB)
+		telemetry.DBPoolConnections.WithLabelValues(p.Name, "in_use").Set(float64(stats.InUse))
+		telemetry.DBPoolConnections.WithLabelValues(p.Name, "idle").Set(float64(stats.Idle))
+		telemetry.DBPoolConnections.WithLabelValues(p.Name, "max_open").Set(float64(stats.MaxOpenConnections))
+		telemetry.DBPoolWaitCount.WithLabelValues(p.Name).Set(float64(stats.WaitCount))
+		telemetry.DBPoolWaitDuration.WithLabelValues(p.Name).Set(stats.WaitDuration.Seconds())
+		telemetry.DBPoolSaturation.WithLabelValues(p.Name).Set(Saturation(stats))
+	}
+}
+
+// RunPoolStats exports the pools' stats every interval until ctx is cancelled
+func RunPoolStats(ctx context.Context, pools []Pool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ExportPoolStats(pools)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+func TestSaturation(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats sql.DBStats
+		want  float64
+	}{
+		{"idle pool", sql.DBStats{MaxOpenConnections: 25, Idle: 5}, 0},
+		{"partly used", sql.DBStats{MaxOpenConnections: 20, InUse: 5}, 0.25},
+		{"saturated", sql.DBStats{MaxOpenConnections: 25, InUse: 25}, 1},
+		{"unlimited pool", sql.DBStats{InUse: 100}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Saturation(tt.stats); got != tt.want {
+				t.Errorf("Saturation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStats_PgxPool(t *testing.T) {
+	config, err := poolConfig("host=db.example.com dbname=testdb", "testdb", "primary", PoolConfig{MaxOpenConns: 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The pool connects lazily, so no database is needed
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := stdlib.OpenDBFromPool(pool)
+	pgxPools.Store(database, pool)
+	defer Close(database)
+
+	// The database/sql handle has no limit of its own; the pool's is reported
+	if got := Stats(database).MaxOpenConnections; got != 9 {
+		t.Errorf("Stats() MaxOpenConnections = %d, want 9", got)
+	}
+	if got := (PoolDB{database}).Stats().MaxOpenConnections; got != 9 {
+		t.Errorf("PoolDB.Stats() MaxOpenConnections = %d, want 9", got)
+	}
+	if _, ok := NewQueries(database).GetDB().(pgxPool); !ok {
+		t.Errorf("NewQueries() queries %T, want the pgx pool", NewQueries(database).GetDB())
+	}
+}
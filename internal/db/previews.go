@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/preview"
+)
+
+// CreatePreview implements the preview.Store interface
+func (q *Queries) CreatePreview(ctx context.Context, p *preview.Preview) error {
+	definition, err := json.Marshal(p.Definition)
+	if err != nil {
+		return fmt.Errorf("failed to marshal definition: %w", err)
+	}
+
+	query := `
+		INSERT INTO survey_previews (token_hash, definition, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, p.Hash, definition, p.CreatedAt, p.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to insert preview: %w", err)
+	}
+
+	return nil
+}
+
+// GetPreview implements the preview.Store interface
+// Returns sql.ErrNoRows if no preview has the hash or it expired before now
+func (q *Queries) GetPreview(ctx context.Context, hash string, now time.Time) (*preview.Preview, error) {
+	query := `
+		SELECT token_hash, definition, created_at, expires_at
+		FROM survey_previews
+		WHERE token_hash = $1 AND expires_at > $2
+	`
+
+	p := &preview.Preview{}
+	var definition []byte
+	err := q.db.QueryRowContext(ctx, query, hash, now).Scan(&p.Hash, &definition, &p.CreatedAt, &p.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get preview: %w", err)
+	}
+	if err := json.Unmarshal(definition, &p.Definition); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal preview definition: %w", err)
+	}
+
+	return p, nil
+}
+
+// DeleteExpiredPreviews implements the preview.Store interface
+// Deletes previews that expired before a time and returns how many were deleted
+func (q *Queries) DeleteExpiredPreviews(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM survey_previews WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired previews: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
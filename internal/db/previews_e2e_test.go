@@ -0,0 +1,46 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/preview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviews(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	def := models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Lunch?", Type: models.QuestionTypeText}}}
+	now := time.Now()
+	p, token, err := preview.New(def, now)
+	require.NoError(t, err)
+	require.NoError(t, queries.CreatePreview(ctx, p))
+
+	got, err := queries.GetPreview(ctx, preview.Hash(token), now)
+	require.NoError(t, err)
+	assert.Equal(t, "Lunch?", got.Definition.Questions[0].Text)
+	assert.WithinDuration(t, p.ExpiresAt, got.ExpiresAt, time.Millisecond)
+
+	_, err = queries.GetPreview(ctx, preview.Hash("pv_other"), now)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Expired previews cannot be viewed, then are deleted
+	_, err = queries.GetPreview(ctx, p.Hash, now.Add(preview.TTL+time.Second))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleted, err := queries.DeleteExpiredPreviews(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	deleted, err = queries.DeleteExpiredPreviews(ctx, now.Add(preview.TTL+time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
@@ -6,26 +6,35 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/redact"
 )
 
-// Querier interface represents a database connection or transaction
+// Querier runs the queries of Queries on a database or in a transaction:
+// natively with pgx on databases opened with Connect on Postgres, and through
+// database/sql on SQLite and other databases
 type Querier interface {
-	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
-	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
-	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) Row
+	QueryContext(ctx context.Context, query string, args ...any) (Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (Result, error)
 }
 
 // Queries provides database query methods
 type Queries struct {
-	db Querier
+	db       Querier
+	dialect  dialect
+	replicas *Replicas // Read replicas for read-only queries, nil to use db
 }
 
-// NewQueries creates a new Queries instance
-func NewQueries(db Querier) *Queries {
-	return &Queries{db: db}
+// NewQueries creates a new Queries instance on a database, connection, or
+// transaction. Databases opened with Connect on Postgres are queried natively
+// with pgx.
+func NewQueries(db SQLQuerier) *Queries {
+	return &Queries{db: querierOf(db), dialect: dialectOf(db)}
 }
 
 // GetDB returns the underlying database connection
@@ -33,7 +42,31 @@ func (q *Queries) GetDB() Querier {
 	return q.db
 }
 
+// InTx runs fn with queries in a transaction, committed if fn returns nil.
+// If q is already in a transaction, fn runs in it.
+func (q *Queries) InTx(ctx context.Context, fn func(tx *Queries) error) error {
+	beginner, ok := q.db.(txBeginner)
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := beginner.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&Queries{db: tx, dialect: q.dialect}); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 // Survey Queries
+// Surveys in the trash (with deleted_at set) are left out of all but trash.go
 
 // CreateSurvey inserts a new survey into the database
 func (q *Queries) CreateSurvey(ctx context.Context, s *models.Survey) error {
@@ -43,9 +76,13 @@ func (q *Queries) CreateSurvey(ctx context.Context, s *models.Survey) error {
 		return fmt.Errorf("failed to marshal survey definition: %w", err)
 	}
 
+	if s.Version == 0 {
+		s.Version = 1
+	}
+
 	query := `
-		INSERT INTO surveys (id, uri, cid, author_did, slug, title, description, definition, starts_at, ends_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO surveys (id, uri, cid, author_did, slug, title, description, definition, version, starts_at, ends_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
 	_, err = q.db.ExecContext(
@@ -59,6 +96,7 @@ func (q *Queries) CreateSurvey(ctx context.Context, s *models.Survey) error {
 		s.Title,
 		s.Description,
 		defJSON,
+		s.Version,
 		s.StartsAt,
 		s.EndsAt,
 		s.CreatedAt,
@@ -69,15 +107,18 @@ func (q *Queries) CreateSurvey(ctx context.Context, s *models.Survey) error {
 		return fmt.Errorf("failed to insert survey: %w", err)
 	}
 
-	return nil
+	if err := q.insertSurveyVersion(ctx, s.ID, s.Version, s.CID, defJSON); err != nil {
+		return err
+	}
+	return q.insertQuestionOrdinals(ctx, s.ID, s.Version, defJSON)
 }
 
 // GetSurveyByURI retrieves a survey by its ATProto URI
 func (q *Queries) GetSurveyByURI(ctx context.Context, uri string) (*models.Survey, error) {
 	query := `
-		SELECT id, uri, cid, author_did, slug, title, description, definition, starts_at, ends_at, results_uri, results_cid, created_at, updated_at
+		SELECT id, uri, cid, author_did, slug, title, description, definition, version, starts_at, ends_at, results_uri, results_cid, created_at, updated_at, hidden_at, org_id
 		FROM surveys
-		WHERE uri = $1
+		WHERE uri = $1 AND deleted_at IS NULL
 	`
 
 	survey := &models.Survey{}
@@ -92,12 +133,15 @@ func (q *Queries) GetSurveyByURI(ctx context.Context, uri string) (*models.Surve
 		&survey.Title,
 		&survey.Description,
 		&defJSON,
+		&survey.Version,
 		&survey.StartsAt,
 		&survey.EndsAt,
 		&survey.ResultsURI,
 		&survey.ResultsCID,
 		&survey.CreatedAt,
 		&survey.UpdatedAt,
+		&survey.HiddenAt,
+		&survey.OrgID,
 	)
 
 	if err != nil {
@@ -117,10 +161,14 @@ func (q *Queries) GetSurveyByURI(ctx context.Context, uri string) (*models.Surve
 
 // GetSurveyBySlug retrieves a survey by its slug
 func (q *Queries) GetSurveyBySlug(ctx context.Context, slug string) (*models.Survey, error) {
+	if q.replicas != nil {
+		return readFromReplica(ctx, q, func(r *Queries) (*models.Survey, error) { return r.GetSurveyBySlug(ctx, slug) })
+	}
+
 	query := `
-		SELECT id, uri, cid, author_did, slug, title, description, definition, starts_at, ends_at, results_uri, results_cid, created_at, updated_at
+		SELECT id, uri, cid, author_did, slug, title, description, definition, version, starts_at, ends_at, results_uri, results_cid, created_at, updated_at, hidden_at, org_id
 		FROM surveys
-		WHERE slug = $1
+		WHERE slug = $1 AND deleted_at IS NULL
 	`
 
 	survey := &models.Survey{}
@@ -135,12 +183,15 @@ func (q *Queries) GetSurveyBySlug(ctx context.Context, slug string) (*models.Sur
 		&survey.Title,
 		&survey.Description,
 		&defJSON,
+		&survey.Version,
 		&survey.StartsAt,
 		&survey.EndsAt,
 		&survey.ResultsURI,
 		&survey.ResultsCID,
 		&survey.CreatedAt,
 		&survey.UpdatedAt,
+		&survey.HiddenAt,
+		&survey.OrgID,
 	)
 
 	if err != nil {
@@ -161,9 +212,9 @@ func (q *Queries) GetSurveyBySlug(ctx context.Context, slug string) (*models.Sur
 // GetSurveyByID retrieves a survey by its ID
 func (q *Queries) GetSurveyByID(ctx context.Context, id uuid.UUID) (*models.Survey, error) {
 	query := `
-		SELECT id, uri, cid, author_did, slug, title, description, definition, starts_at, ends_at, results_uri, results_cid, created_at, updated_at
+		SELECT id, uri, cid, author_did, slug, title, description, definition, version, starts_at, ends_at, results_uri, results_cid, created_at, updated_at, hidden_at, org_id
 		FROM surveys
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	survey := &models.Survey{}
@@ -178,12 +229,15 @@ func (q *Queries) GetSurveyByID(ctx context.Context, id uuid.UUID) (*models.Surv
 		&survey.Title,
 		&survey.Description,
 		&defJSON,
+		&survey.Version,
 		&survey.StartsAt,
 		&survey.EndsAt,
 		&survey.ResultsURI,
 		&survey.ResultsCID,
 		&survey.CreatedAt,
 		&survey.UpdatedAt,
+		&survey.HiddenAt,
+		&survey.OrgID,
 	)
 
 	if err != nil {
@@ -201,16 +255,37 @@ func (q *Queries) GetSurveyByID(ctx context.Context, id uuid.UUID) (*models.Surv
 	return survey, nil
 }
 
-// ListSurveys retrieves surveys with pagination
+// ListSurveys retrieves surveys with pagination, leaving out hidden ones and
+// those whose visibility keeps them out of listings
 func (q *Queries) ListSurveys(ctx context.Context, limit, offset int) ([]*models.Survey, error) {
+	return q.listSurveys(ctx, nil, limit, offset)
+}
+
+// ListTenantSurveys implements the tenant.Store interface
+// Lists like ListSurveys, scoped to the surveys of the tenant's default author
+// and those cross-posted to it
+func (q *Queries) ListTenantSurveys(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*models.Survey, error) {
+	return q.listSurveys(ctx, &tenantID, limit, offset)
+}
+
+// listSurveys lists the surveys of ListSurveys, of a tenant unless tenantID is nil
+func (q *Queries) listSurveys(ctx context.Context, tenantID *uuid.UUID, limit, offset int) ([]*models.Survey, error) {
+	if q.replicas != nil {
+		return readFromReplica(ctx, q, func(r *Queries) ([]*models.Survey, error) { return r.listSurveys(ctx, tenantID, limit, offset) })
+	}
+
 	query := `
-		SELECT id, uri, cid, author_did, slug, title, description, definition, starts_at, ends_at, results_uri, results_cid, created_at, updated_at
-		FROM surveys
-		ORDER BY created_at DESC
+		SELECT s.id, s.uri, s.cid, s.author_did, s.slug, s.title, s.description, s.definition, s.version, s.starts_at, s.ends_at, s.results_uri, s.results_cid, s.created_at, s.updated_at, s.hidden_at, s.org_id
+		FROM surveys s
+		WHERE ` + listedSurvey + `
+			AND ($3::uuid IS NULL
+				OR s.id IN (SELECT survey_id FROM tenant_surveys WHERE tenant_id = $3)
+				OR s.author_did = (SELECT default_author_did FROM tenants WHERE id = $3))
+		ORDER BY s.created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := q.db.QueryContext(ctx, query, limit, offset)
+	rows, err := q.db.QueryContext(ctx, query, limit, offset, tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query surveys: %w", err)
 	}
@@ -230,12 +305,15 @@ func (q *Queries) ListSurveys(ctx context.Context, limit, offset int) ([]*models
 			&survey.Title,
 			&survey.Description,
 			&defJSON,
+			&survey.Version,
 			&survey.StartsAt,
 			&survey.EndsAt,
 			&survey.ResultsURI,
 			&survey.ResultsCID,
 			&survey.CreatedAt,
 			&survey.UpdatedAt,
+			&survey.HiddenAt,
+			&survey.OrgID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan survey: %w", err)
@@ -258,7 +336,8 @@ func (q *Queries) ListSurveys(ctx context.Context, limit, offset int) ([]*models
 
 // SlugExists checks if a survey slug already exists
 func (q *Queries) SlugExists(ctx context.Context, slug string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM surveys WHERE slug = $1)`
+	// Slugs of deleted surveys stay taken, so their links never lead to another survey
+	query := `SELECT EXISTS(SELECT 1 FROM surveys WHERE slug = $1) OR EXISTS(SELECT 1 FROM survey_tombstones WHERE slug = $1)`
 
 	var exists bool
 	err := q.db.QueryRowContext(ctx, query, slug).Scan(&exists)
@@ -269,6 +348,19 @@ func (q *Queries) SlugExists(ctx context.Context, slug string) (bool, error) {
 	return exists, nil
 }
 
+// SurveyExistsByURI checks if a survey with the given ATProto URI is indexed
+func (q *Queries) SurveyExistsByURI(ctx context.Context, uri string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM surveys WHERE uri = $1)`
+
+	var exists bool
+	err := q.db.QueryRowContext(ctx, query, uri).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check survey existence: %w", err)
+	}
+
+	return exists, nil
+}
+
 // UpdateSurvey updates an existing survey and sets updated_at
 func (q *Queries) UpdateSurvey(ctx context.Context, s *models.Survey) error {
 	// Marshal definition to JSON for JSONB storage
@@ -277,45 +369,128 @@ func (q *Queries) UpdateSurvey(ctx context.Context, s *models.Survey) error {
 		return fmt.Errorf("failed to marshal survey definition: %w", err)
 	}
 
+	// The version is bumped in the same statement when the definition changes;
+	// the joined row holds the values from before the update
 	query := `
-		UPDATE surveys
+		UPDATE surveys s
 		SET uri = $2, cid = $3, author_did = $4, slug = $5, title = $6,
 		    description = $7, definition = $8, starts_at = $9, ends_at = $10,
+		    version = CASE WHEN old.definition = $8::jsonb THEN old.version ELSE old.version + 1 END,
 		    updated_at = NOW()
-		WHERE id = $1
+		FROM surveys old
+		WHERE s.id = $1 AND old.id = s.id
+		RETURNING s.version, s.version <> old.version
 	`
 
-	result, err := q.db.ExecContext(
-		ctx,
-		query,
-		s.ID,
-		s.URI,
-		s.CID,
-		s.AuthorDID,
-		s.Slug,
-		s.Title,
-		s.Description,
-		defJSON,
-		s.StartsAt,
-		s.EndsAt,
-	)
+	args := []any{s.ID, s.URI, s.CID, s.AuthorDID, s.Slug, s.Title, s.Description, defJSON, s.StartsAt, s.EndsAt}
 
+	var changed bool
+	if q.dialect == dialectSQLite {
+		err = q.updateSurveySQLite(ctx, args, &s.Version, &changed)
+	} else {
+		err = q.db.QueryRowContext(ctx, query, args...).Scan(&s.Version, &changed)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("survey not found")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update survey: %w", err)
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	// Only a new version needs its definition and question ordinals stored
+	if !changed {
+		return nil
+	}
+	if err := q.insertSurveyVersion(ctx, s.ID, s.Version, s.CID, defJSON); err != nil {
+		return err
 	}
+	return q.insertQuestionOrdinals(ctx, s.ID, s.Version, defJSON)
+}
 
-	if rows == 0 {
-		return fmt.Errorf("survey not found")
+// updateSurveySQLite runs UpdateSurvey's update on SQLite, whose RETURNING
+// can't see joined rows: the version is bumped by a statement of its own
+func (q *Queries) updateSurveySQLite(ctx context.Context, args []any, version *int, changed *bool) error {
+	return q.InTx(ctx, func(tx *Queries) error {
+		bump := `UPDATE surveys SET version = version + 1 WHERE id = $1 AND json(definition) IS NOT json($2) RETURNING version`
+		err := tx.db.QueryRowContext(ctx, bump, args[0], args[7]).Scan(version)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		*changed = err == nil
+
+		query := `
+			UPDATE surveys
+			SET uri = $2, cid = $3, author_did = $4, slug = $5, title = $6,
+			    description = $7, definition = $8, starts_at = $9, ends_at = $10,
+			    updated_at = NOW()
+			WHERE id = $1
+			RETURNING version
+		`
+		return tx.db.QueryRowContext(ctx, query, args...).Scan(version)
+	})
+}
+
+// insertQuestionOrdinals records the position of each question of a definition for a survey version
+func (q *Queries) insertQuestionOrdinals(ctx context.Context, surveyID uuid.UUID, version int, defJSON []byte) error {
+	query := `
+		INSERT INTO survey_question_ordinals (survey_id, version, question_id, ordinal)
+		SELECT $1, $2, q.value->>'id', q.ordinality
+		FROM jsonb_array_elements($3::jsonb->'questions') WITH ORDINALITY AS q
+		WHERE q.value->>'id' IS NOT NULL
+		ON CONFLICT (survey_id, version, question_id) DO UPDATE SET ordinal = EXCLUDED.ordinal
+	`
+	if q.dialect == dialectSQLite {
+		query = `
+			INSERT INTO survey_question_ordinals (survey_id, version, question_id, ordinal)
+			SELECT $1, $2, q.value->>'id', q.key + 1
+			FROM json_each($3, '$.questions') AS q
+			WHERE q.value->>'id' IS NOT NULL
+			ON CONFLICT (survey_id, version, question_id) DO UPDATE SET ordinal = EXCLUDED.ordinal
+		`
+	}
+
+	if _, err := q.db.ExecContext(ctx, query, surveyID, version, defJSON); err != nil {
+		return fmt.Errorf("failed to insert question ordinals: %w", err)
 	}
 
 	return nil
 }
 
+// ListQuestionOrdinals returns the recorded question ordinals of a survey,
+// keyed by version and then question ID
+func (q *Queries) ListQuestionOrdinals(ctx context.Context, surveyID uuid.UUID) (map[int]map[string]int, error) {
+	query := `
+		SELECT version, question_id, ordinal
+		FROM survey_question_ordinals
+		WHERE survey_id = $1
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query question ordinals: %w", err)
+	}
+	defer rows.Close()
+
+	ordinals := make(map[int]map[string]int)
+	for rows.Next() {
+		var version, ordinal int
+		var questionID string
+		if err := rows.Scan(&version, &questionID, &ordinal); err != nil {
+			return nil, fmt.Errorf("failed to scan question ordinal: %w", err)
+		}
+		if ordinals[version] == nil {
+			ordinals[version] = make(map[string]int)
+		}
+		ordinals[version][questionID] = ordinal
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating question ordinals: %w", err)
+	}
+
+	return ordinals, nil
+}
+
 // Response Queries
 
 // CreateResponse inserts a new response into the database
@@ -326,12 +501,14 @@ func (q *Queries) CreateResponse(ctx context.Context, r *models.Response) error
 		return fmt.Errorf("failed to marshal response answers: %w", err)
 	}
 
+	// Responses without a pinned version (web submissions) answer the current version
 	query := `
-		INSERT INTO responses (id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO responses (id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, survey_version, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($9::int, (SELECT version FROM surveys WHERE id = $2)), $8)
+		RETURNING survey_version
 	`
 
-	_, err = q.db.ExecContext(
+	err = q.db.QueryRowContext(
 		ctx,
 		query,
 		r.ID,
@@ -342,7 +519,8 @@ func (q *Queries) CreateResponse(ctx context.Context, r *models.Response) error
 		r.RecordCID,
 		answersJSON,
 		r.CreatedAt,
-	)
+		r.SurveyVersion,
+	).Scan(&r.SurveyVersion)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert response: %w", err)
@@ -351,10 +529,72 @@ func (q *Queries) CreateResponse(ctx context.Context, r *models.Response) error
 	return nil
 }
 
+// responseColumns are the columns CopyResponses copies, in row order
+var responseColumns = []string{"id", "survey_id", "voter_did", "voter_session", "record_uri", "record_cid", "answers", "survey_version", "created_at"}
+
+// CopyResponses bulk-inserts responses with COPY, for imports and backfills.
+// Responses without a pinned version answer their survey's current version.
+// A duplicate or invalid response fails the whole batch. On SQLite, which has
+// no COPY, and databases not opened with Connect, the responses are inserted
+// one by one in a transaction instead.
+func (q *Queries) CopyResponses(ctx context.Context, responses []*models.Response) (int64, error) {
+	copier, ok := q.db.(interface {
+		copyFrom(ctx context.Context, table string, columns []string, rows [][]any) (int64, error)
+	})
+	if !ok {
+		var copied int64
+		err := q.InTx(ctx, func(tx *Queries) error {
+			for _, r := range responses {
+				if err := tx.CreateResponse(ctx, r); err != nil {
+					return err
+				}
+				copied++
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		return copied, nil
+	}
+
+	// Pin unversioned responses to their survey's current version
+	versions := map[uuid.UUID]int{}
+	for _, r := range responses {
+		if r.SurveyVersion != nil {
+			continue
+		}
+		version, ok := versions[r.SurveyID]
+		if !ok {
+			if err := q.db.QueryRowContext(ctx, `SELECT version FROM surveys WHERE id = $1`, r.SurveyID).Scan(&version); err != nil {
+				return 0, fmt.Errorf("failed to get survey version: %w", err)
+			}
+			versions[r.SurveyID] = version
+		}
+		r.SurveyVersion = &version
+	}
+
+	rows := make([][]any, len(responses))
+	for i, r := range responses {
+		answersJSON, err := json.Marshal(r.Answers)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal response answers: %w", err)
+		}
+		rows[i] = []any{r.ID, r.SurveyID, r.VoterDID, r.VoterSession, r.RecordURI, r.RecordCID, answersJSON, r.SurveyVersion, r.CreatedAt}
+	}
+
+	copied, err := copier.copyFrom(ctx, "responses", responseColumns, rows)
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy responses: %w", err)
+	}
+
+	return copied, nil
+}
+
 // GetResponseByID retrieves a response by its ID
 func (q *Queries) GetResponseByID(ctx context.Context, id uuid.UUID) (*models.Response, error) {
 	query := `
-		SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, created_at
+		SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, survey_version, created_at
 		FROM responses
 		WHERE id = $1
 	`
@@ -370,6 +610,7 @@ func (q *Queries) GetResponseByID(ctx context.Context, id uuid.UUID) (*models.Re
 		&response.RecordURI,
 		&response.RecordCID,
 		&answersJSON,
+		&response.SurveyVersion,
 		&response.CreatedAt,
 	)
 
@@ -395,14 +636,14 @@ func (q *Queries) GetResponseBySurveyAndVoter(ctx context.Context, surveyID uuid
 
 	if voterDID != "" {
 		query = `
-			SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, created_at
+			SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, survey_version, created_at
 			FROM responses
 			WHERE survey_id = $1 AND voter_did = $2
 		`
 		args = []interface{}{surveyID, voterDID}
 	} else if voterSession != "" {
 		query = `
-			SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, created_at
+			SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, survey_version, created_at
 			FROM responses
 			WHERE survey_id = $1 AND voter_session = $2
 		`
@@ -422,6 +663,7 @@ func (q *Queries) GetResponseBySurveyAndVoter(ctx context.Context, surveyID uuid
 		&response.RecordURI,
 		&response.RecordCID,
 		&answersJSON,
+		&response.SurveyVersion,
 		&response.CreatedAt,
 	)
 
@@ -442,14 +684,54 @@ func (q *Queries) GetResponseBySurveyAndVoter(ctx context.Context, surveyID uuid
 
 // ListResponsesBySurvey retrieves all responses for a survey
 func (q *Queries) ListResponsesBySurvey(ctx context.Context, surveyID uuid.UUID) ([]*models.Response, error) {
+	return q.ListFilteredResponses(ctx, surveyID, models.ResponseFilter{})
+}
+
+// ListRespondentDIDs returns the DIDs of the logged-in respondents of a survey
+// in order of their first response, up to limit, and how many there are in all
+func (q *Queries) ListRespondentDIDs(ctx context.Context, surveyID uuid.UUID, limit int) ([]string, int, error) {
 	query := `
-		SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, created_at
+		SELECT voter_did, COUNT(*) OVER ()
 		FROM responses
-		WHERE survey_id = $1
-		ORDER BY created_at ASC
+		WHERE survey_id = $1 AND voter_did IS NOT NULL
+		GROUP BY voter_did
+		ORDER BY MIN(created_at), voter_did
+		LIMIT $2
 	`
 
-	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	rows, err := q.db.QueryContext(ctx, query, surveyID, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query respondents: %w", err)
+	}
+	defer rows.Close()
+
+	var dids []string
+	total := 0
+	for rows.Next() {
+		var did string
+		if err := rows.Scan(&did, &total); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan respondent: %w", err)
+		}
+		dids = append(dids, did)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating respondents: %w", err)
+	}
+
+	return dids, total, nil
+}
+
+// ListFilteredResponses retrieves the responses for a survey matching the filter,
+// oldest first. Filters are applied in SQL; with QuestionIDs set, answers to
+// other questions are dropped from the returned responses.
+func (q *Queries) ListFilteredResponses(ctx context.Context, surveyID uuid.UUID, filter models.ResponseFilter) ([]*models.Response, error) {
+	query, args, err := filteredResponsesQuery(q.dialect, surveyID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query responses: %w", err)
 	}
@@ -457,28 +739,10 @@ func (q *Queries) ListResponsesBySurvey(ctx context.Context, surveyID uuid.UUID)
 
 	var responses []*models.Response
 	for rows.Next() {
-		response := &models.Response{}
-		var answersJSON []byte
-
-		err := rows.Scan(
-			&response.ID,
-			&response.SurveyID,
-			&response.VoterDID,
-			&response.VoterSession,
-			&response.RecordURI,
-			&response.RecordCID,
-			&answersJSON,
-			&response.CreatedAt,
-		)
+		response, err := scanResponse(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan response: %w", err)
-		}
-
-		// Unmarshal JSONB answers
-		if err := json.Unmarshal(answersJSON, &response.Answers); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response answers: %w", err)
+			return nil, err
 		}
-
 		responses = append(responses, response)
 	}
 
@@ -489,6 +753,159 @@ func (q *Queries) ListResponsesBySurvey(ctx context.Context, surveyID uuid.UUID)
 	return responses, nil
 }
 
+// ListResponsesBySurveyStream streams the responses for a survey matching the
+// filter, oldest first, to fn in batches of up to batchSize. The responses are
+// read through the cursor of a single query as fn consumes them, so only one
+// batch is held in memory however many responses there are. An error from fn
+// stops the stream and is returned.
+func (q *Queries) ListResponsesBySurveyStream(ctx context.Context, surveyID uuid.UUID, filter models.ResponseFilter, batchSize int, fn func([]*models.Response) error) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch size must be positive")
+	}
+	query, args, err := filteredResponsesQuery(q.dialect, surveyID, filter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer rows.Close()
+
+	batch := make([]*models.Response, 0, batchSize)
+	for rows.Next() {
+		response, err := scanResponse(rows)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, response)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]*models.Response, 0, batchSize)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating responses: %w", err)
+	}
+
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// filteredResponsesQuery builds the query of the responses for a survey
+// matching a filter, oldest first
+func filteredResponsesQuery(d dialect, surveyID uuid.UUID, filter models.ResponseFilter) (string, []interface{}, error) {
+	conditions, args, err := responseFilterConditions(d, surveyID, filter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	answers := "answers"
+	if len(filter.QuestionIDs) > 0 {
+		args = append(args, filter.QuestionIDs)
+		answers = fmt.Sprintf(`(
+			SELECT COALESCE(jsonb_object_agg(key, value), '{}'::jsonb)
+			FROM jsonb_each(answers)
+			WHERE key = ANY($%d)
+		)`, len(args))
+	}
+
+	query := `
+		SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, ` + answers + `, survey_version, created_at
+		FROM responses
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at ASC, id ASC
+	`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit, filter.Offset)
+		query += fmt.Sprintf("LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+	return query, args, nil
+}
+
+// scanResponse scans a row of filteredResponsesQuery
+func scanResponse(rows Rows) (*models.Response, error) {
+	response := &models.Response{}
+	var answersJSON []byte
+
+	err := rows.Scan(
+		&response.ID,
+		&response.SurveyID,
+		&response.VoterDID,
+		&response.VoterSession,
+		&response.RecordURI,
+		&response.RecordCID,
+		&answersJSON,
+		&response.SurveyVersion,
+		&response.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan response: %w", err)
+	}
+
+	// Unmarshal JSONB answers
+	if err := json.Unmarshal(answersJSON, &response.Answers); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response answers: %w", err)
+	}
+	return response, nil
+}
+
+// CountFilteredResponses counts the responses for a survey matching the filter,
+// ignoring its Limit and Offset
+func (q *Queries) CountFilteredResponses(ctx context.Context, surveyID uuid.UUID, filter models.ResponseFilter) (int, error) {
+	conditions, args, err := responseFilterConditions(q.dialect, surveyID, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	query := `SELECT COUNT(*) FROM responses WHERE ` + strings.Join(conditions, " AND ")
+	if err := q.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count responses: %w", err)
+	}
+	return count, nil
+}
+
+// responseFilterConditions builds the SQL conditions and arguments selecting
+// the responses of a survey that match a filter
+func responseFilterConditions(d dialect, surveyID uuid.UUID, filter models.ResponseFilter) ([]string, []interface{}, error) {
+	args := []interface{}{surveyID}
+	conditions := []string{"survey_id = $1"}
+
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	switch filter.VoterType {
+	case models.VoterTypeDID:
+		conditions = append(conditions, "voter_did IS NOT NULL")
+	case models.VoterTypeAnonymous:
+		conditions = append(conditions, "voter_did IS NULL")
+	case "":
+	default:
+		return nil, nil, fmt.Errorf("unknown voter type %q", filter.VoterType)
+	}
+	if filter.Question != "" && filter.Option != "" {
+		args = append(args, filter.Question, filter.Option)
+		condition := "answers->($%d::text)->'selectedOptions' @> jsonb_build_array($%d::text)"
+		if d == dialectSQLite {
+			condition = "EXISTS (SELECT 1 FROM json_each(answers->($%d)->'selectedOptions') WHERE value = $%d)"
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)-1, len(args)))
+	}
+
+	return conditions, args, nil
+}
+
 // CountResponsesBySurvey counts the number of responses for a survey
 func (q *Queries) CountResponsesBySurvey(ctx context.Context, surveyID uuid.UUID) (int, error) {
 	query := `SELECT COUNT(*) FROM responses WHERE survey_id = $1`
@@ -505,7 +922,7 @@ func (q *Queries) CountResponsesBySurvey(ctx context.Context, surveyID uuid.UUID
 // GetResponseByRecordURI retrieves a response by its ATProto record URI
 func (q *Queries) GetResponseByRecordURI(ctx context.Context, recordURI string) (*models.Response, error) {
 	query := `
-		SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, created_at
+		SELECT id, survey_id, voter_did, voter_session, record_uri, record_cid, answers, survey_version, created_at
 		FROM responses
 		WHERE record_uri = $1
 	`
@@ -521,6 +938,7 @@ func (q *Queries) GetResponseByRecordURI(ctx context.Context, recordURI string)
 		&response.RecordURI,
 		&response.RecordCID,
 		&answersJSON,
+		&response.SurveyVersion,
 		&response.CreatedAt,
 	)
 
@@ -539,8 +957,9 @@ func (q *Queries) GetResponseByRecordURI(ctx context.Context, recordURI string)
 	return response, nil
 }
 
-// UpdateResponseAnswers updates the answers for an existing response
-func (q *Queries) UpdateResponseAnswers(ctx context.Context, id uuid.UUID, answers map[string]models.Answer, cid string) error {
+// UpdateResponseAnswers updates the answers for an existing response, pinning it
+// to surveyVersion, or to the survey's current version if nil
+func (q *Queries) UpdateResponseAnswers(ctx context.Context, id uuid.UUID, answers map[string]models.Answer, cid string, surveyVersion *int) error {
 	answersJSON, err := json.Marshal(answers)
 	if err != nil {
 		return fmt.Errorf("failed to marshal answers: %w", err)
@@ -548,11 +967,12 @@ func (q *Queries) UpdateResponseAnswers(ctx context.Context, id uuid.UUID, answe
 
 	query := `
 		UPDATE responses
-		SET answers = $2, record_cid = $3
+		SET answers = $2, record_cid = $3,
+		    survey_version = COALESCE($4::int, (SELECT version FROM surveys WHERE id = responses.survey_id))
 		WHERE id = $1
 	`
 
-	result, err := q.db.ExecContext(ctx, query, id, answersJSON, cid)
+	result, err := q.db.ExecContext(ctx, query, id, answersJSON, cid, surveyVersion)
 	if err != nil {
 		return fmt.Errorf("failed to update response: %w", err)
 	}
@@ -583,31 +1003,42 @@ func (q *Queries) DeleteResponseByRecordURI(ctx context.Context, recordURI strin
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	// Not an error if response doesn't exist
+	// The response may be archived: restore its survey's responses, then delete it
 	if rows == 0 {
-		return nil
+		restored, err := q.restoreArchiveOfRecord(ctx, recordURI)
+		if err != nil || !restored {
+			return err
+		}
+		if _, err := q.db.ExecContext(ctx, query, recordURI); err != nil {
+			return fmt.Errorf("failed to delete response: %w", err)
+		}
 	}
 
 	return nil
 }
 
-// DeleteSurveyByURI deletes a survey by its ATProto URI
+// DeleteSurveyByURI moves a survey to the trash by its ATProto URI
 func (q *Queries) DeleteSurveyByURI(ctx context.Context, uri string) error {
-	query := `DELETE FROM surveys WHERE uri = $1`
-
-	result, err := q.db.ExecContext(ctx, query, uri)
-	if err != nil {
-		return fmt.Errorf("failed to delete survey: %w", err)
+	if q.dialect == dialectSQLite {
+		return q.deleteSurveySQLite(ctx, "uri", uri)
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+	// Leave a tombstone, so links to the survey say it was deleted and its
+	// slug is not reused
+	query := `
+		WITH deleted AS (
+			UPDATE surveys SET deleted_at = NOW()
+			WHERE uri = $1 AND deleted_at IS NULL
+			RETURNING slug, uri, author_did
+		)
+		INSERT INTO survey_tombstones (slug, uri, author_did)
+		SELECT slug, uri, author_did FROM deleted
+		ON CONFLICT DO NOTHING
+	`
 
 	// Not an error if survey doesn't exist
-	if rows == 0 {
-		return nil
+	if _, err := q.db.ExecContext(ctx, query, uri); err != nil {
+		return fmt.Errorf("failed to delete survey: %w", err)
 	}
 
 	return nil
@@ -617,6 +1048,19 @@ func (q *Queries) DeleteSurveyByURI(ctx context.Context, uri string) error {
 
 // GetSurveyResults aggregates all responses for a survey into results
 func (q *Queries) GetSurveyResults(ctx context.Context, surveyID uuid.UUID) (*models.SurveyResults, error) {
+	if q.replicas != nil {
+		return readFromReplica(ctx, q, func(r *Queries) (*models.SurveyResults, error) { return r.GetSurveyResults(ctx, surveyID) })
+	}
+
+	// Archived surveys keep their results without their responses
+	archived, err := q.GetArchivedResults(ctx, surveyID)
+	if err == nil {
+		return archived, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get archived results: %w", err)
+	}
+
 	// First, get the survey to understand question structure
 	survey, err := q.GetSurveyByID(ctx, surveyID)
 	if err != nil {
@@ -629,45 +1073,152 @@ func (q *Queries) GetSurveyResults(ctx context.Context, surveyID uuid.UUID) (*mo
 		return nil, fmt.Errorf("failed to get responses: %w", err)
 	}
 
+	// Leave out responses the author excluded as suspected duplicates
+	excluded, err := q.getExcludedResponses(ctx, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get excluded responses: %w", err)
+	}
+	var excludedDuplicates, excludedSpam int
+	responses = slices.DeleteFunc(responses, func(r *models.Response) bool {
+		switch excluded[r.ID] {
+		case exclusionDuplicate:
+			excludedDuplicates++
+		case exclusionSpam:
+			excludedSpam++
+		default:
+			return false
+		}
+		return true
+	})
+
+	// Get text answers hidden by moderation
+	hidden, err := q.getHiddenTextAnswers(ctx, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hidden answers: %w", err)
+	}
+
+	// Get earlier definitions, to label options that have since been removed
+	versions, err := q.ListSurveyVersions(ctx, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get survey versions: %w", err)
+	}
+
 	// Initialize results structure
 	results := &models.SurveyResults{
-		SurveyID:        surveyID,
-		TotalVotes:      len(responses),
-		QuestionResults: make(map[string]*models.QuestionResult),
+		SurveyID:           surveyID,
+		TotalVotes:         len(responses),
+		ExcludedDuplicates: excludedDuplicates,
+		ExcludedSpam:       excludedSpam,
+		QuestionResults:    make(map[string]*models.QuestionResult),
+		Version:            survey.Version,
+		VersionVotes:       make(map[int]int),
 	}
 
+	// Mask what the author chose to redact from public text answers
+	redactor := redact.New(survey.Definition.Redaction, survey.Definition.Language)
+
 	// Initialize question results based on survey definition
-	for _, question := range survey.Definition.Questions {
+	valueQuestions := make(map[string]*models.Question) // number, date, and datetime questions
+	values := make(map[string][]string)
+	for i, question := range survey.Definition.Questions {
 		results.QuestionResults[question.ID] = &models.QuestionResult{
 			QuestionID:   question.ID,
+			Ordinal:      i + 1,
 			OptionCounts: make(map[string]int),
 			TextAnswers:  []string{},
 		}
+		if question.Type == models.QuestionTypeMatrix {
+			results.QuestionResults[question.ID].RowCounts = make(map[string]map[string]int)
+		}
+		if question.Type.HasRange() {
+			valueQuestions[question.ID] = &survey.Definition.Questions[i]
+		}
 	}
 
 	// Aggregate responses
 	for _, response := range responses {
+		version := 1 // Responses recorded before versions were tracked
+		if response.SurveyVersion != nil {
+			version = *response.SurveyVersion
+		}
+		results.VersionVotes[version]++
+
 		for questionID, answer := range response.Answers {
 			qResult, exists := results.QuestionResults[questionID]
 			if !exists {
 				continue // Skip answers for questions that no longer exist
 			}
+			if len(answer.SelectedOptions) > 0 || len(answer.Rows) > 0 || answer.Text != "" || answer.File != nil {
+				qResult.Respondents++
+			}
 
 			// Count selected options
 			for _, optionID := range answer.SelectedOptions {
 				qResult.OptionCounts[optionID]++
 			}
 
-			// Collect text answers
-			if answer.Text != "" {
-				qResult.TextAnswers = append(qResult.TextAnswers, answer.Text)
+			// Count the option rated for each row of matrix questions
+			if qResult.RowCounts != nil {
+				for rowID, optionID := range answer.Rows {
+					if qResult.RowCounts[rowID] == nil {
+						qResult.RowCounts[rowID] = make(map[string]int)
+					}
+					qResult.RowCounts[rowID][optionID]++
+				}
+			}
+
+			// Collect the values of number and date questions for their summaries
+			if _, ok := valueQuestions[questionID]; ok {
+				if answer.Text != "" {
+					values[questionID] = append(values[questionID], answer.Text)
+				}
+				continue
+			}
+
+			// Collect text answers, skipping those flagged by moderation and not approved
+			if answer.Text != "" && !hidden[response.ID][questionID] {
+				qResult.TextAnswers = append(qResult.TextAnswers, redactor.Redact(answer.Text))
 			}
 		}
 	}
+	for questionID, question := range valueQuestions {
+		results.QuestionResults[questionID].SummarizeValues(question, values[questionID])
+	}
+	results.MixedVersions = len(results.VersionVotes) > 1
+	labelRemovedOptions(results, survey, versions)
 
 	return results, nil
 }
 
+// labelRemovedOptions records the text of counted options that are no longer in
+// the current definition, from the newest earlier version that had them
+func labelRemovedOptions(results *models.SurveyResults, survey *models.Survey, versions []*models.SurveyVersion) {
+	current := make(map[string]map[string]bool)
+	for _, question := range survey.Definition.Questions {
+		current[question.ID] = make(map[string]bool)
+		for _, option := range question.Options {
+			current[question.ID][option.ID] = true
+		}
+	}
+
+	for questionID, qResult := range results.QuestionResults {
+		for optionID := range qResult.OptionCounts {
+			if current[questionID][optionID] {
+				continue
+			}
+			for i := len(versions) - 1; i >= 0; i-- {
+				if text, ok := versions[i].Definition.OptionText(questionID, optionID); ok {
+					if qResult.RemovedOptions == nil {
+						qResult.RemovedOptions = make(map[string]string)
+					}
+					qResult.RemovedOptions[optionID] = text
+					break
+				}
+			}
+		}
+	}
+}
+
 // UpdateSurveyResults updates the results URI and CID for a survey
 func (q *Queries) UpdateSurveyResults(ctx context.Context, surveyID uuid.UUID, resultsURI, resultsCID string) error {
 	query := `
@@ -696,9 +1247,9 @@ func (q *Queries) UpdateSurveyResults(ctx context.Context, surveyID uuid.UUID, r
 // GetSurveyByResultsURI retrieves a survey by its results URI
 func (q *Queries) GetSurveyByResultsURI(ctx context.Context, resultsURI string) (*models.Survey, error) {
 	query := `
-		SELECT id, uri, cid, author_did, slug, title, description, definition, starts_at, ends_at, results_uri, results_cid, created_at, updated_at
+		SELECT id, uri, cid, author_did, slug, title, description, definition, version, starts_at, ends_at, results_uri, results_cid, created_at, updated_at, hidden_at, org_id
 		FROM surveys
-		WHERE results_uri = $1
+		WHERE results_uri = $1 AND deleted_at IS NULL
 	`
 
 	survey := &models.Survey{}
@@ -713,12 +1264,15 @@ func (q *Queries) GetSurveyByResultsURI(ctx context.Context, resultsURI string)
 		&survey.Title,
 		&survey.Description,
 		&defJSON,
+		&survey.Version,
 		&survey.StartsAt,
 		&survey.EndsAt,
 		&survey.ResultsURI,
 		&survey.ResultsCID,
 		&survey.CreatedAt,
 		&survey.UpdatedAt,
+		&survey.HiddenAt,
+		&survey.OrgID,
 	)
 
 	if err != nil {
@@ -738,9 +1292,13 @@ func (q *Queries) GetSurveyByResultsURI(ctx context.Context, resultsURI string)
 
 // GetStats retrieves statistics about the survey service
 func (q *Queries) GetStats(ctx context.Context) (*models.Stats, error) {
+	if q.replicas != nil {
+		return readFromReplica(ctx, q, func(r *Queries) (*models.Stats, error) { return r.GetStats(ctx) })
+	}
+
 	query := `
 		SELECT
-			(SELECT COUNT(*) FROM surveys) as survey_count,
+			(SELECT COUNT(*) FROM surveys WHERE deleted_at IS NULL) as survey_count,
 			(SELECT COUNT(*) FROM responses) as response_count,
 			(
 				(SELECT COUNT(DISTINCT voter_did) FROM responses WHERE voter_did IS NOT NULL) +
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/questionbank"
+)
+
+// CreateBankQuestion implements the questionbank.Store interface
+func (q *Queries) CreateBankQuestion(ctx context.Context, bq *questionbank.Question) error {
+	question, err := json.Marshal(bq.Question)
+	if err != nil {
+		return fmt.Errorf("failed to marshal question: %w", err)
+	}
+
+	query := `
+		INSERT INTO bank_questions (id, owner_did, question, search_text, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, bq.ID, bq.OwnerDID, question, searchText(bq.Question), bq.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert bank question: %w", err)
+	}
+
+	return nil
+}
+
+// ListBankQuestions implements the questionbank.Store interface
+// Matches search case-insensitively anywhere in the question's text, options, and rows
+func (q *Queries) ListBankQuestions(ctx context.Context, ownerDID, search string, limit int) ([]*questionbank.Question, error) {
+	query := `
+		SELECT id, owner_did, question, created_at
+		FROM bank_questions
+		WHERE owner_did = $1 AND ($2 = '' OR strpos(lower(search_text), lower($2)) > 0)
+		ORDER BY created_at DESC, id
+		LIMIT $3
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, ownerDID, search, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bank questions: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []*questionbank.Question
+	for rows.Next() {
+		bq := &questionbank.Question{}
+		var question []byte
+		if err := rows.Scan(&bq.ID, &bq.OwnerDID, &question, &bq.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan bank question: %w", err)
+		}
+		if err := json.Unmarshal(question, &bq.Question); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal bank question: %w", err)
+		}
+		questions = append(questions, bq)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating bank questions: %w", err)
+	}
+
+	return questions, nil
+}
+
+// CountBankQuestions implements the questionbank.Store interface
+func (q *Queries) CountBankQuestions(ctx context.Context, ownerDID string) (int, error) {
+	query := `SELECT COUNT(*) FROM bank_questions WHERE owner_did = $1`
+
+	var count int
+	if err := q.db.QueryRowContext(ctx, query, ownerDID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count bank questions: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteBankQuestion implements the questionbank.Store interface
+// Returns sql.ErrNoRows if the owner has no such question
+func (q *Queries) DeleteBankQuestion(ctx context.Context, id uuid.UUID, ownerDID string) error {
+	query := `DELETE FROM bank_questions WHERE id = $1 AND owner_did = $2`
+
+	result, err := q.db.ExecContext(ctx, query, id, ownerDID)
+	if err != nil {
+		return fmt.Errorf("failed to delete bank question: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// searchText returns the text a bank question is found by
+func searchText(question models.Question) string {
+	texts := []string{question.Text}
+	for _, options := range [][]models.Option{question.Options, question.Rows} {
+		for _, o := range options {
+			texts = append(texts, o.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
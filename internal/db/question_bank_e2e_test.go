@@ -0,0 +1,59 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/questionbank"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuestionBank(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	alice, bob := "did:plc:alice", "did:plc:bob"
+	save := func(owner string, question models.Question, age time.Duration) *questionbank.Question {
+		bq, err := questionbank.New(owner, question, time.Now().Add(-age))
+		require.NoError(t, err)
+		require.NoError(t, queries.CreateBankQuestion(ctx, bq))
+		return bq
+	}
+	lunch := save(alice, models.Question{
+		ID:      "lunch",
+		Text:    "Where should we eat?",
+		Type:    models.QuestionTypeSingle,
+		Options: []models.Option{{ID: "pizza", Text: "Pizza Place"}, {ID: "salad", Text: "Salad Bar"}},
+	}, time.Hour)
+	feedback := save(alice, models.Question{ID: "feedback", Text: "Any comments?", Type: models.QuestionTypeText}, 0)
+	save(bob, models.Question{ID: "other", Text: "Where to?", Type: models.QuestionTypeText}, 0)
+
+	questions, err := queries.ListBankQuestions(ctx, alice, "", questionbank.DefaultLimit)
+	require.NoError(t, err)
+	require.Len(t, questions, 2)
+	assert.Equal(t, feedback.ID, questions[0].ID, "most recently saved first")
+	assert.Equal(t, lunch.Question, questions[1].Question)
+
+	// Search matches question and option text, case-insensitively
+	for _, search := range []string{"where", "PIZZA"} {
+		questions, err = queries.ListBankQuestions(ctx, alice, search, questionbank.DefaultLimit)
+		require.NoError(t, err)
+		require.Len(t, questions, 1, search)
+		assert.Equal(t, lunch.ID, questions[0].ID)
+	}
+
+	count, err := queries.CountBankQuestions(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.ErrorIs(t, queries.DeleteBankQuestion(ctx, lunch.ID, bob), sql.ErrNoRows, "only the owner deletes")
+	require.NoError(t, queries.DeleteBankQuestion(ctx, lunch.ID, alice))
+	assert.ErrorIs(t, queries.DeleteBankQuestion(ctx, lunch.ID, alice), sql.ErrNoRows)
+}
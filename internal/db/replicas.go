@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+// DefaultReplicaCheckInterval is how often replica health is checked
+const DefaultReplicaCheckInterval = 10 * time.Second
+
+// Replica is a read-only database connection and its health
+type Replica struct {
+	name    string // Index in the replica list, for logs and metrics
+	db      Querier
+	healthy atomic.Bool
+}
+
+// Replicas routes read-only queries to healthy read replicas in turn.
+// A nil *Replicas routes nothing, so queries go to the primary.
+type Replicas struct {
+	replicas []*Replica
+	next     atomic.Uint64
+	mu       sync.Mutex // Serializes health checks
+}
+
+// NewReplicas creates a router over replica connections, all initially healthy
+func NewReplicas(dbs ...SQLQuerier) *Replicas {
+	if len(dbs) == 0 {
+		return nil
+	}
+	r := &Replicas{}
+	for i, db := range dbs {
+		replica := &Replica{name: strconv.Itoa(i), db: querierOf(db)}
+		replica.healthy.Store(true)
+		telemetry.DBReplicaHealthy.WithLabelValues(replica.name).Set(1)
+		r.replicas = append(r.replicas, replica)
+	}
+	return r
+}
+
+// pick returns the next healthy replica, or nil if none is healthy
+func (r *Replicas) pick() *Replica {
+	if r == nil {
+		return nil
+	}
+	n := len(r.replicas)
+	start := int(r.next.Add(1) % uint64(n))
+	for i := 0; i < n; i++ {
+		replica := r.replicas[(start+i)%n]
+		if replica.healthy.Load() {
+			return replica
+		}
+	}
+	return nil
+}
+
+// Healthy returns the number of healthy replicas
+func (r *Replicas) Healthy() int {
+	if r == nil {
+		return 0
+	}
+	healthy := 0
+	for _, replica := range r.replicas {
+		if replica.healthy.Load() {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+// setHealthy records a replica's health, logging changes
+func (r *Replicas) setHealthy(replica *Replica, healthy bool, err error) {
+	if replica.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		log.Printf("Database replica %s is healthy again", replica.name)
+		telemetry.DBReplicaHealthy.WithLabelValues(replica.name).Set(1)
+	} else {
+		log.Printf("Database replica %s is unhealthy, reading from other replicas or the primary: %v", replica.name, err)
+		telemetry.DBReplicaHealthy.WithLabelValues(replica.name).Set(0)
+	}
+}
+
+// Check pings every replica and updates its health
+func (r *Replicas) Check(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		var one int
+		err := replica.db.QueryRowContext(pingCtx, `SELECT 1`).Scan(&one)
+		cancel()
+		r.setHealthy(replica, err == nil, err)
+	}
+}
+
+// Run checks replica health every interval until ctx is cancelled
+func (r *Replicas) Run(ctx context.Context, interval time.Duration) {
+	if r == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Check(ctx)
+		}
+	}
+}
+
+// WithReplicas returns Queries that route read-only survey, results, and stats
+// queries to the replicas. Writes and all other queries use the primary.
+func (q *Queries) WithReplicas(r *Replicas) *Queries {
+	return &Queries{db: q.db, dialect: q.dialect, replicas: r}
+}
+
+// Primary returns Queries that read from the primary only, for reads that
+// must see the latest writes
+func (q *Queries) Primary() *Queries {
+	return &Queries{db: q.db, dialect: q.dialect}
+}
+
+// readFromReplica runs a read-only query on a healthy replica. It falls back to
+// the primary when no replica is healthy, when the replica fails, and when a
+// row is not found, since a row just written to the primary may not have
+// replicated yet. A replica failing a query the primary answers is marked
+// unhealthy until the next check.
+func readFromReplica[T any](ctx context.Context, q *Queries, read func(*Queries) (T, error)) (T, error) {
+	primary := &Queries{db: q.db, dialect: q.dialect}
+
+	replica := q.replicas.pick()
+	if replica == nil {
+		telemetry.DBReadsTotal.WithLabelValues("primary").Inc()
+		return read(primary)
+	}
+
+	value, err := read(&Queries{db: replica.db})
+	switch {
+	case err == nil:
+		telemetry.DBReadsTotal.WithLabelValues("replica").Inc()
+		return value, nil
+	case ctx.Err() != nil:
+		return value, err
+	case errors.Is(err, sql.ErrNoRows):
+		telemetry.DBReadsTotal.WithLabelValues("fallback").Inc()
+		return read(primary)
+	default:
+		telemetry.DBReadsTotal.WithLabelValues("fallback").Inc()
+		value, primaryErr := read(primary)
+		if primaryErr == nil {
+			q.replicas.setHealthy(replica, false, err)
+		}
+		return value, primaryErr
+	}
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// fakeDriver answers every query with a single row holding 1, or fails to
+// connect when the DSN is "down", to exercise replica health checks
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	if name == "down" {
+		return nil, errors.New("connection refused")
+	}
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error) { return &fakeRows{}, nil }
+
+type fakeRows struct{ done bool }
+
+func (*fakeRows) Columns() []string { return []string{"?column?"} }
+func (*fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func init() {
+	sql.Register("replicatest", fakeDriver{})
+}
+
+func openFake(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("replicatest", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestReplicas_PickRoundRobin(t *testing.T) {
+	a, b := openFake(t, "a"), openFake(t, "b")
+	r := NewReplicas(a, b)
+
+	first, second, third := r.pick(), r.pick(), r.pick()
+	if first == second {
+		t.Error("expected picks to alternate between replicas")
+	}
+	if first != third {
+		t.Error("expected picks to cycle back to the first replica")
+	}
+
+	r.setHealthy(first, false, errors.New("down"))
+	for i := 0; i < 3; i++ {
+		if got := r.pick(); got != second {
+			t.Errorf("pick %d = replica %s, want the healthy replica %s", i, got.name, second.name)
+		}
+	}
+	if r.Healthy() != 1 {
+		t.Errorf("Healthy() = %d, want 1", r.Healthy())
+	}
+
+	r.setHealthy(second, false, errors.New("down"))
+	if got := r.pick(); got != nil {
+		t.Errorf("pick() = replica %s, want nil when no replica is healthy", got.name)
+	}
+
+	var none *Replicas
+	if none.pick() != nil || none.Healthy() != 0 {
+		t.Error("nil Replicas should route nothing")
+	}
+	if NewReplicas() != nil {
+		t.Error("NewReplicas() without replicas should return nil")
+	}
+}
+
+func TestReplicas_Check(t *testing.T) {
+	up, down := openFake(t, "up"), openFake(t, "down")
+	r := NewReplicas(up, down)
+
+	r.Check(context.Background())
+	if !r.replicas[0].healthy.Load() {
+		t.Error("expected the reachable replica to be healthy")
+	}
+	if r.replicas[1].healthy.Load() {
+		t.Error("expected the unreachable replica to be unhealthy")
+	}
+}
+
+func TestReadFromReplica(t *testing.T) {
+	ctx := context.Background()
+	primary, replica := openFake(t, "primary"), openFake(t, "replica")
+
+	// read records which database served the query and returns the given error from replicas
+	var servedBy []Querier
+	read := func(replicaErr, primaryErr error) func(*Queries) (*models.Stats, error) {
+		return func(q *Queries) (*models.Stats, error) {
+			servedBy = append(servedBy, q.db)
+			if q.replicas != nil {
+				t.Fatal("routed queries must not route again")
+			}
+			if q.db == querierOf(replica) {
+				return &models.Stats{SurveyCount: 1}, replicaErr
+			}
+			return &models.Stats{SurveyCount: 2}, primaryErr
+		}
+	}
+
+	tests := []struct {
+		name        string
+		replicaErr  error
+		primaryErr  error
+		wantCount   int
+		wantServed  []Querier
+		wantHealthy bool
+	}{
+		{"replica answers", nil, nil, 1, []Querier{querierOf(replica)}, true},
+		{"not found falls back", fmt.Errorf("survey not found: %w", sql.ErrNoRows), nil, 2, []Querier{querierOf(replica), querierOf(primary)}, true},
+		{"failure falls back and marks unhealthy", errors.New("connection reset"), nil, 2, []Querier{querierOf(replica), querierOf(primary)}, false},
+		{"failure on both keeps replica healthy", errors.New("bad query"), errors.New("bad query"), 2, []Querier{querierOf(replica), querierOf(primary)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQueries(primary).WithReplicas(NewReplicas(replica))
+			servedBy = nil
+
+			stats, err := readFromReplica(ctx, q, read(tt.replicaErr, tt.primaryErr))
+			if (err != nil) != (tt.primaryErr != nil) {
+				t.Fatalf("err = %v, want primary error %v", err, tt.primaryErr)
+			}
+			if stats.SurveyCount != tt.wantCount {
+				t.Errorf("served count %d, want %d", stats.SurveyCount, tt.wantCount)
+			}
+			if len(servedBy) != len(tt.wantServed) {
+				t.Fatalf("served by %d databases, want %d", len(servedBy), len(tt.wantServed))
+			}
+			for i := range servedBy {
+				if servedBy[i] != tt.wantServed[i] {
+					t.Errorf("query %d served by the wrong database", i)
+				}
+			}
+			if got := q.replicas.Healthy() == 1; got != tt.wantHealthy {
+				t.Errorf("replica healthy = %v, want %v", got, tt.wantHealthy)
+			}
+		})
+	}
+
+	t.Run("no healthy replica reads the primary", func(t *testing.T) {
+		replicas := NewReplicas(replica)
+		replicas.setHealthy(replicas.replicas[0], false, errors.New("down"))
+		q := NewQueries(primary).WithReplicas(replicas)
+		servedBy = nil
+
+		if _, err := readFromReplica(ctx, q, read(nil, nil)); err != nil {
+			t.Fatal(err)
+		}
+		if len(servedBy) != 1 || servedBy[0] != querierOf(primary) {
+			t.Error("expected the primary to serve the query")
+		}
+	})
+}
+
+func TestReplicaConnectionStrings(t *testing.T) {
+	cfg := Config{
+		Host:     "primary",
+		Port:     5432,
+		User:     "survey",
+		Password: "secret",
+		Database: "survey",
+		SSLMode:  "require",
+		Replicas: []string{
+			"replica-1",
+			"replica-2:6432",
+			"postgres://reader:pw@replica-3:5432/survey?sslmode=disable",
+			"host=replica-4 user=reader",
+		},
+	}
+
+	got := cfg.ReplicaConnectionStrings()
+	want := []string{
+		"host=replica-1 port=5432 user=survey password=secret dbname=survey sslmode=require",
+		"host=replica-2 port=6432 user=survey password=secret dbname=survey sslmode=require",
+		"postgres://reader:pw@replica-3:5432/survey?sslmode=disable",
+		"host=replica-4 user=reader",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d connection strings, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("replica %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/report"
+)
+
+// CreateReport implements the report.Store interface
+// Saves a report unless the reporter already reported the survey, and hides
+// the survey once threshold distinct reporters have pending reports of it.
+func (q *Queries) CreateReport(ctx context.Context, r *report.Report, threshold int) (created, hid bool, err error) {
+	err = q.InTx(ctx, func(tx *Queries) error {
+		result, err := tx.db.ExecContext(ctx, `
+			INSERT INTO survey_reports (id, survey_id, reason, details, reporter, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (survey_id, reporter) DO NOTHING
+		`, r.ID, r.SurveyID, r.Reason, r.Details, r.Reporter, r.Status, r.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert report: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return nil
+		}
+		created = true
+
+		// Reporters are unique per survey, so pending reports count distinct reporters
+		result, err = tx.db.ExecContext(ctx, `
+			UPDATE surveys SET hidden_at = NOW()
+			WHERE id = $1 AND hidden_at IS NULL
+				AND (SELECT COUNT(*) FROM survey_reports WHERE survey_id = $1 AND status = 'pending') >= $2
+		`, r.SurveyID, threshold)
+		if err != nil {
+			return fmt.Errorf("failed to hide survey: %w", err)
+		}
+		rows, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		hid = rows > 0
+		return nil
+	})
+	return created, hid, err
+}
+
+// ListReportedSurveys implements the report.Store interface
+// Returns the surveys with pending reports, hidden ones first
+func (q *Queries) ListReportedSurveys(ctx context.Context) ([]*report.ReportedSurvey, error) {
+	query := `
+		SELECT s.id, s.slug, s.title, s.hidden_at,
+			r.id, r.reason, r.details, r.status, r.created_at
+		FROM survey_reports r
+		JOIN surveys s ON s.id = r.survey_id
+		WHERE r.status = 'pending' AND s.deleted_at IS NULL
+		ORDER BY s.hidden_at IS NULL, s.id, r.created_at DESC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	defer rows.Close()
+
+	var surveys []*report.ReportedSurvey
+	for rows.Next() {
+		var s report.ReportedSurvey
+		r := &report.Report{}
+		if err := rows.Scan(&s.SurveyID, &s.Slug, &s.Title, &s.HiddenAt, &r.ID, &r.Reason, &r.Details, &r.Status, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan report: %w", err)
+		}
+		r.SurveyID = s.SurveyID
+
+		if n := len(surveys); n == 0 || surveys[n-1].SurveyID != s.SurveyID {
+			surveys = append(surveys, &s)
+		}
+		last := surveys[len(surveys)-1]
+		last.Reports = append(last.Reports, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reports: %w", err)
+	}
+
+	return surveys, nil
+}
+
+// ResolveReports implements the report.Store interface
+// Marks the pending reports of a survey dismissed (showing it again) or
+// upheld (keeping it hidden), returning its slug
+func (q *Queries) ResolveReports(ctx context.Context, surveyID uuid.UUID, status, reviewerDID string) (string, error) {
+	var slug string
+	err := q.InTx(ctx, func(tx *Queries) error {
+		result, err := tx.db.ExecContext(ctx, `
+			UPDATE survey_reports
+			SET status = $2, reviewed_by = $3, reviewed_at = NOW()
+			WHERE survey_id = $1 AND status = 'pending'
+		`, surveyID, status, reviewerDID)
+		if err != nil {
+			return fmt.Errorf("failed to resolve reports: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return sql.ErrNoRows
+		}
+
+		query := `UPDATE surveys SET hidden_at = NULL WHERE id = $1 RETURNING slug`
+		if status == report.StatusUpheld {
+			query = `UPDATE surveys SET hidden_at = COALESCE(hidden_at, NOW()) WHERE id = $1 RETURNING slug`
+		}
+		if err := tx.db.QueryRowContext(ctx, query, surveyID).Scan(&slug); err != nil {
+			return fmt.Errorf("failed to update survey visibility: %w", err)
+		}
+		return nil
+	})
+	return slug, err
+}
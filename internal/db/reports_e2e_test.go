@@ -0,0 +1,79 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSurveyReports(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "reported",
+		Title: "Reported",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	file := func(reporter string) (bool, bool) {
+		r, err := report.New(survey.ID, report.ReasonSpam, "", reporter)
+		require.NoError(t, err)
+		created, hid, err := queries.CreateReport(ctx, r, 2)
+		require.NoError(t, err)
+		return created, hid
+	}
+
+	created, hid := file("a")
+	assert.True(t, created)
+	assert.False(t, hid)
+
+	// The same reporter is not counted twice
+	created, hid = file("a")
+	assert.False(t, created)
+	assert.False(t, hid)
+
+	created, hid = file("b")
+	assert.True(t, created)
+	assert.True(t, hid)
+
+	got, err := queries.GetSurveyBySlug(ctx, survey.Slug)
+	require.NoError(t, err)
+	assert.NotNil(t, got.HiddenAt)
+
+	listed, err := queries.ListSurveys(ctx, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, listed, "hidden surveys are not listed")
+
+	reported, err := queries.ListReportedSurveys(ctx)
+	require.NoError(t, err)
+	require.Len(t, reported, 1)
+	assert.Len(t, reported[0].Reports, 2)
+
+	slug, err := queries.ResolveReports(ctx, survey.ID, report.StatusDismissed, "did:plc:admin")
+	require.NoError(t, err)
+	assert.Equal(t, survey.Slug, slug)
+
+	got, err = queries.GetSurveyBySlug(ctx, survey.Slug)
+	require.NoError(t, err)
+	assert.Nil(t, got.HiddenAt)
+
+	reported, err = queries.ListReportedSurveys(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reported)
+}
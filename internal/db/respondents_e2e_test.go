@@ -0,0 +1,57 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRespondentDIDs(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	survey := &models.Survey{
+		ID:         uuid.New(),
+		Slug:       "poll",
+		Title:      "Poll",
+		Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}}},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	dids, total, err := queries.ListRespondentDIDs(ctx, survey.ID, 2)
+	require.NoError(t, err)
+	assert.Empty(t, dids)
+	assert.Equal(t, 0, total)
+
+	respond := func(did, session string, at time.Time) {
+		r := &models.Response{ID: uuid.New(), SurveyID: survey.ID, Answers: map[string]models.Answer{"q1": {Text: "Because"}}, CreatedAt: at}
+		if did != "" {
+			r.VoterDID = &did
+		}
+		if session != "" {
+			r.VoterSession = &session
+		}
+		require.NoError(t, queries.CreateResponse(ctx, r))
+	}
+	respond("did:plc:carol", "", now.Add(-time.Minute))
+	respond("did:plc:alice", "", now.Add(-time.Hour))
+	respond("", "guest", now.Add(-2*time.Hour))
+	respond("did:plc:bob", "", now)
+
+	// Respondents are listed in order of their first response, without guests
+	dids, total, err = queries.ListRespondentDIDs(ctx, survey.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"did:plc:alice", "did:plc:carol"}, dids)
+	assert.Equal(t, 3, total)
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/heatmap"
+)
+
+// RecordResponseMetadata implements the heatmap.Store interface
+func (q *Queries) RecordResponseMetadata(ctx context.Context, surveyID uuid.UUID, country string, hour int) error {
+	query := `
+		INSERT INTO response_metadata (survey_id, country, hour, responses)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (survey_id, country, hour) DO UPDATE
+		SET responses = response_metadata.responses + 1
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, surveyID, country, hour); err != nil {
+		return fmt.Errorf("failed to record response metadata: %w", err)
+	}
+
+	return nil
+}
+
+// ListResponseMetadata implements the heatmap.Store interface
+func (q *Queries) ListResponseMetadata(ctx context.Context, surveyID uuid.UUID) ([]*heatmap.Count, error) {
+	query := `
+		SELECT country, hour, responses
+		FROM response_metadata
+		WHERE survey_id = $1
+		ORDER BY country, hour
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list response metadata: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*heatmap.Count
+	for rows.Next() {
+		c := &heatmap.Count{}
+		if err := rows.Scan(&c.Country, &c.Hour, &c.Responses); err != nil {
+			return nil, fmt.Errorf("failed to scan response metadata: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating response metadata: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,46 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/heatmap"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseMetadata(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	survey := &models.Survey{
+		ID:         uuid.New(),
+		Slug:       "lunch",
+		Title:      "Lunch",
+		Definition: models.SurveyDefinition{ResponseMetadata: true, Questions: []models.Question{{ID: "q1", Text: "Where?", Type: models.QuestionTypeText}}},
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	require.NoError(t, queries.RecordResponseMetadata(ctx, survey.ID, "DE", 9))
+	require.NoError(t, queries.RecordResponseMetadata(ctx, survey.ID, "DE", 9))
+	require.NoError(t, queries.RecordResponseMetadata(ctx, survey.ID, heatmap.Unknown, 23))
+
+	counts, err := queries.ListResponseMetadata(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []*heatmap.Count{
+		{Country: "DE", Hour: 9, Responses: 2},
+		{Country: heatmap.Unknown, Hour: 23, Responses: 1},
+	}, counts)
+
+	counts, err = queries.ListResponseMetadata(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
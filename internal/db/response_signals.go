@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/dedup"
+)
+
+// SaveResponseSignals implements the dedup.Store interface
+func (q *Queries) SaveResponseSignals(ctx context.Context, s *dedup.Signals) error {
+	query := `
+		INSERT INTO response_signals (response_id, survey_id, voter_token, fingerprint, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)
+		ON CONFLICT (response_id) DO UPDATE
+		SET voter_token = EXCLUDED.voter_token, fingerprint = EXCLUDED.fingerprint
+	`
+
+	_, err := q.db.ExecContext(ctx, query, s.ResponseID, s.SurveyID, s.VoterToken, s.Fingerprint, s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save response signals: %w", err)
+	}
+
+	return nil
+}
+
+// HasVoterToken implements the dedup.Store interface
+func (q *Queries) HasVoterToken(ctx context.Context, surveyID uuid.UUID, token string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM response_signals WHERE survey_id = $1 AND voter_token = $2)`
+
+	var exists bool
+	if err := q.db.QueryRowContext(ctx, query, surveyID, token).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check voter token: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ListResponseSignals implements the dedup.Store interface
+func (q *Queries) ListResponseSignals(ctx context.Context, surveyID uuid.UUID) ([]*dedup.Signals, error) {
+	query := `
+		SELECT response_id, survey_id, COALESCE(voter_token, ''), COALESCE(fingerprint, ''), excluded, created_at
+		FROM response_signals
+		WHERE survey_id = $1
+		ORDER BY created_at ASC, response_id ASC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list response signals: %w", err)
+	}
+	defer rows.Close()
+
+	var signals []*dedup.Signals
+	for rows.Next() {
+		s := &dedup.Signals{}
+		if err := rows.Scan(&s.ResponseID, &s.SurveyID, &s.VoterToken, &s.Fingerprint, &s.Excluded, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan response signals: %w", err)
+		}
+		signals = append(signals, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating response signals: %w", err)
+	}
+
+	return signals, nil
+}
+
+// SetResponsesExcluded implements the dedup.Store interface
+func (q *Queries) SetResponsesExcluded(ctx context.Context, surveyID uuid.UUID, responseIDs []uuid.UUID, excluded bool) (int64, error) {
+	ids := make([]string, len(responseIDs))
+	for i, id := range responseIDs {
+		ids[i] = id.String()
+	}
+
+	query := `
+		UPDATE response_signals SET excluded = $3
+		WHERE survey_id = $1 AND response_id = ANY($2::uuid[]) AND excluded != $3
+	`
+
+	result, err := q.db.ExecContext(ctx, query, surveyID, ids, excluded)
+	if err != nil {
+		return 0, fmt.Errorf("failed to exclude responses: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// Why a response is excluded from its survey's results
+const (
+	exclusionDuplicate = "duplicate" // excluded by the author as a suspected duplicate
+	exclusionSpam      = "spam"      // scored at or above the survey's spam threshold
+)
+
+// getExcludedResponses returns the IDs of a survey's responses excluded from
+// its results, with why they are excluded
+func (q *Queries) getExcludedResponses(ctx context.Context, surveyID uuid.UUID) (map[uuid.UUID]string, error) {
+	query := `
+		SELECT response_id, 'duplicate' FROM response_signals WHERE survey_id = $1 AND excluded
+		UNION ALL
+		SELECT s.response_id, 'spam'
+		FROM response_spam_scores s
+		JOIN spam_thresholds t ON t.survey_id = s.survey_id
+		WHERE s.survey_id = $1 AND s.score >= t.threshold
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query excluded responses: %w", err)
+	}
+	defer rows.Close()
+
+	excluded := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var responseID uuid.UUID
+		var reason string
+		if err := rows.Scan(&responseID, &reason); err != nil {
+			return nil, fmt.Errorf("failed to scan excluded response: %w", err)
+		}
+		// A response excluded for both reasons is counted as a duplicate
+		if excluded[responseID] != exclusionDuplicate {
+			excluded[responseID] = reason
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating excluded responses: %w", err)
+	}
+
+	return excluded, nil
+}
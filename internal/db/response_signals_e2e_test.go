@@ -0,0 +1,81 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/dedup"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseSignals(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	survey := &models.Survey{
+		ID:         uuid.New(),
+		Slug:       "lunch",
+		Title:      "Lunch",
+		Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Where?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "a", Text: "A"}, {ID: "b", Text: "B"}}}}},
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	vote := func(session, token, fingerprint string) *models.Response {
+		r := &models.Response{
+			ID:           uuid.New(),
+			SurveyID:     survey.ID,
+			VoterSession: &session,
+			Answers:      map[string]models.Answer{"q1": {SelectedOptions: []string{"a"}}},
+			CreatedAt:    time.Now(),
+		}
+		require.NoError(t, queries.CreateResponse(ctx, r))
+		require.NoError(t, queries.SaveResponseSignals(ctx, &dedup.Signals{
+			ResponseID: r.ID, SurveyID: survey.ID, VoterToken: token, Fingerprint: fingerprint, CreatedAt: r.CreatedAt,
+		}))
+		return r
+	}
+	first := vote("home", "token", "")
+	second := vote("office", "", "headers")
+	vote("cafe", "other", "headers")
+
+	has, err := queries.HasVoterToken(ctx, survey.ID, "token")
+	require.NoError(t, err)
+	assert.True(t, has)
+	has, err = queries.HasVoterToken(ctx, survey.ID, "")
+	require.NoError(t, err)
+	assert.False(t, has, "responses without a cookie share no token")
+
+	signals, err := queries.ListResponseSignals(ctx, survey.ID)
+	require.NoError(t, err)
+	require.Len(t, signals, 3)
+	assert.Equal(t, first.ID, signals[0].ResponseID)
+	assert.Equal(t, "token", signals[0].VoterToken)
+	assert.Empty(t, signals[0].Fingerprint)
+
+	// Excluded responses are left out of results
+	changed, err := queries.SetResponsesExcluded(ctx, survey.ID, []uuid.UUID{second.ID, uuid.New()}, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), changed)
+	results, err := queries.GetSurveyResults(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.TotalVotes)
+	assert.Equal(t, 1, results.ExcludedDuplicates)
+	assert.Equal(t, 2, results.QuestionResults["q1"].OptionCounts["a"])
+
+	changed, err = queries.SetResponsesExcluded(ctx, survey.ID, []uuid.UUID{second.ID}, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), changed)
+	results, err = queries.GetSurveyResults(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, results.TotalVotes)
+	assert.Zero(t, results.ExcludedDuplicates)
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// CreateSurveyRevision stores the definition changes of a survey update
+func (q *Queries) CreateSurveyRevision(ctx context.Context, r *models.SurveyRevision) error {
+	changesJSON, err := json.Marshal(r.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revision changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO survey_revisions (id, survey_id, cid, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, r.ID, r.SurveyID, r.CID, changesJSON, r.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert survey revision: %w", err)
+	}
+
+	return nil
+}
+
+// ListSurveyRevisions returns the most recent revisions of a survey, newest first
+func (q *Queries) ListSurveyRevisions(ctx context.Context, surveyID uuid.UUID, limit int) ([]*models.SurveyRevision, error) {
+	query := `
+		SELECT id, survey_id, cid, changes, created_at
+		FROM survey_revisions
+		WHERE survey_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list survey revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*models.SurveyRevision
+	for rows.Next() {
+		r := &models.SurveyRevision{}
+		var changesJSON []byte
+		if err := rows.Scan(&r.ID, &r.SurveyID, &r.CID, &changesJSON, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan survey revision: %w", err)
+		}
+		if err := json.Unmarshal(changesJSON, &r.Changes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal revision changes: %w", err)
+		}
+		revisions = append(revisions, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating survey revisions: %w", err)
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/series"
+)
+
+// CreateSeries implements the series.Store interface
+// Returns series.ErrSlugTaken if a series has the slug
+func (q *Queries) CreateSeries(ctx context.Context, s *series.Series) error {
+	query := `
+		INSERT INTO survey_series (id, slug, title, owner_did, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (slug) DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, s.ID, s.Slug, s.Title, s.OwnerDID, s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert series: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return series.ErrSlugTaken
+	}
+
+	return nil
+}
+
+// GetSeriesBySlug implements the series.Store interface
+// Returns sql.ErrNoRows if there is no such series
+func (q *Queries) GetSeriesBySlug(ctx context.Context, slug string) (*series.Series, error) {
+	query := `SELECT id, slug, title, owner_did, created_at FROM survey_series WHERE slug = $1`
+
+	s := &series.Series{}
+	err := q.db.QueryRowContext(ctx, query, slug).Scan(&s.ID, &s.Slug, &s.Title, &s.OwnerDID, &s.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get series: %w", err)
+	}
+
+	return s, nil
+}
+
+// ListSeriesByOwner implements the series.Store interface
+// Returns the series of a user, newest first
+func (q *Queries) ListSeriesByOwner(ctx context.Context, did string) ([]*series.Series, error) {
+	query := `
+		SELECT id, slug, title, owner_did, created_at
+		FROM survey_series
+		WHERE owner_did = $1
+		ORDER BY created_at DESC, slug
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list series: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*series.Series
+	for rows.Next() {
+		s := &series.Series{}
+		if err := rows.Scan(&s.ID, &s.Slug, &s.Title, &s.OwnerDID, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan series: %w", err)
+		}
+		list = append(list, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating series: %w", err)
+	}
+
+	return list, nil
+}
+
+// DeleteSeries implements the series.Store interface
+// Returns sql.ErrNoRows if there is no such series; its surveys are kept
+func (q *Queries) DeleteSeries(ctx context.Context, id uuid.UUID) error {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM survey_series WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete series: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AddSeriesSurvey implements the series.Store interface
+// Makes a survey the last wave of a series, returning series.ErrAlreadyInSeries
+// if it is in a series
+func (q *Queries) AddSeriesSurvey(ctx context.Context, seriesID, surveyID uuid.UUID, now time.Time) error {
+	query := `
+		INSERT INTO survey_series_surveys (series_id, survey_id, position, added_at)
+		SELECT $1::uuid, $2::uuid, COALESCE(MAX(position), 0) + 1, $3::timestamptz
+		FROM survey_series_surveys
+		WHERE series_id = $1
+		ON CONFLICT (survey_id) DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, seriesID, surveyID, now)
+	if err != nil {
+		return fmt.Errorf("failed to add survey to series: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return series.ErrAlreadyInSeries
+	}
+
+	return nil
+}
+
+// RemoveSeriesSurvey implements the series.Store interface
+// Returns sql.ErrNoRows if the survey is not in the series
+func (q *Queries) RemoveSeriesSurvey(ctx context.Context, seriesID, surveyID uuid.UUID) error {
+	query := `DELETE FROM survey_series_surveys WHERE series_id = $1 AND survey_id = $2`
+
+	result, err := q.db.ExecContext(ctx, query, seriesID, surveyID)
+	if err != nil {
+		return fmt.Errorf("failed to remove survey from series: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListSeriesSurveyIDs implements the series.Store interface
+// Returns the surveys of a series in the order they were added
+func (q *Queries) ListSeriesSurveyIDs(ctx context.Context, seriesID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT survey_id
+		FROM survey_series_surveys
+		WHERE series_id = $1
+		ORDER BY position, added_at
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list series surveys: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan series survey: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating series surveys: %w", err)
+	}
+
+	return ids, nil
+}
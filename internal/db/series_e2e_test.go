@@ -0,0 +1,85 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/series"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeries(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	author := "did:plc:author"
+	var surveys []*models.Survey
+	for _, slug := range []string{"pulse-q1", "pulse-q2", "pulse-q3"} {
+		survey := &models.Survey{
+			ID:        uuid.New(),
+			Slug:      slug,
+			Title:     slug,
+			AuthorDID: &author,
+			Definition: models.SurveyDefinition{
+				Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}},
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		require.NoError(t, queries.CreateSurvey(ctx, survey))
+		surveys = append(surveys, survey)
+	}
+
+	pulse, err := series.New("pulse", "Team pulse", author)
+	require.NoError(t, err)
+	require.NoError(t, queries.CreateSeries(ctx, pulse))
+	other, err := series.New("pulse", "Other", "did:plc:other")
+	require.NoError(t, err)
+	assert.ErrorIs(t, queries.CreateSeries(ctx, other), series.ErrSlugTaken)
+
+	got, err := queries.GetSeriesBySlug(ctx, "pulse")
+	require.NoError(t, err)
+	assert.Equal(t, pulse.ID, got.ID)
+	assert.Equal(t, "Team pulse", got.Title)
+	_, err = queries.GetSeriesBySlug(ctx, "nothing")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	list, err := queries.ListSeriesByOwner(ctx, author)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "pulse", list[0].Slug)
+
+	// Waves keep the order they were added in
+	now := time.Now()
+	for _, i := range []int{2, 0, 1} {
+		require.NoError(t, queries.AddSeriesSurvey(ctx, pulse.ID, surveys[i].ID, now))
+	}
+	assert.ErrorIs(t, queries.AddSeriesSurvey(ctx, pulse.ID, surveys[0].ID, now), series.ErrAlreadyInSeries)
+	ids, err := queries.ListSeriesSurveyIDs(ctx, pulse.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{surveys[2].ID, surveys[0].ID, surveys[1].ID}, ids)
+
+	require.NoError(t, queries.RemoveSeriesSurvey(ctx, pulse.ID, surveys[0].ID))
+	assert.ErrorIs(t, queries.RemoveSeriesSurvey(ctx, pulse.ID, surveys[0].ID), sql.ErrNoRows)
+	require.NoError(t, queries.AddSeriesSurvey(ctx, pulse.ID, surveys[0].ID, now))
+	ids, err = queries.ListSeriesSurveyIDs(ctx, pulse.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{surveys[2].ID, surveys[1].ID, surveys[0].ID}, ids, "re-added surveys come last")
+
+	// Deleting a series keeps its surveys
+	require.NoError(t, queries.DeleteSeries(ctx, pulse.ID))
+	assert.ErrorIs(t, queries.DeleteSeries(ctx, pulse.ID), sql.ErrNoRows)
+	ids, err = queries.ListSeriesSurveyIDs(ctx, pulse.ID)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+	_, err = queries.GetSurveyByID(ctx, surveys[0].ID)
+	assert.NoError(t, err)
+}
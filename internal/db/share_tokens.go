@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/sharetoken"
+)
+
+// CreateShareToken implements the sharetoken.Store interface
+func (q *Queries) CreateShareToken(ctx context.Context, t *sharetoken.Token) error {
+	query := `
+		INSERT INTO share_tokens (id, survey_id, label, prefix, token_hash, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := q.db.ExecContext(ctx, query, t.ID, t.SurveyID, t.Label, t.Prefix, t.Hash, t.CreatedBy, t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert share token: %w", err)
+	}
+
+	return nil
+}
+
+// ListShareTokens implements the sharetoken.Store interface
+// Returns a survey's tokens, including revoked ones, newest first
+func (q *Queries) ListShareTokens(ctx context.Context, surveyID uuid.UUID) ([]*sharetoken.Token, error) {
+	query := `
+		SELECT id, survey_id, label, prefix, token_hash, created_by, revoked_at, created_at
+		FROM share_tokens
+		WHERE survey_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*sharetoken.Token
+	for rows.Next() {
+		t := &sharetoken.Token{}
+		if err := rows.Scan(&t.ID, &t.SurveyID, &t.Label, &t.Prefix, &t.Hash, &t.CreatedBy, &t.RevokedAt, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan share token: %w", err)
+		}
+		tokens = append(tokens, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating share tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// CountActiveShareTokens implements the sharetoken.Store interface
+func (q *Queries) CountActiveShareTokens(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM share_tokens WHERE survey_id = $1 AND revoked_at IS NULL`
+
+	var count int
+	if err := q.db.QueryRowContext(ctx, query, surveyID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count share tokens: %w", err)
+	}
+
+	return count, nil
+}
+
+// ValidShareToken implements the sharetoken.Store interface
+func (q *Queries) ValidShareToken(ctx context.Context, surveyID uuid.UUID, hash string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM share_tokens
+			WHERE survey_id = $1 AND token_hash = $2 AND revoked_at IS NULL
+		)
+	`
+
+	var valid bool
+	if err := q.db.QueryRowContext(ctx, query, surveyID, hash).Scan(&valid); err != nil {
+		return false, fmt.Errorf("failed to check share token: %w", err)
+	}
+
+	return valid, nil
+}
+
+// RevokeShareToken implements the sharetoken.Store interface
+// Returns sql.ErrNoRows if the survey has no such active token
+func (q *Queries) RevokeShareToken(ctx context.Context, id, surveyID uuid.UUID) error {
+	query := `
+		UPDATE share_tokens
+		SET revoked_at = NOW()
+		WHERE id = $1 AND survey_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := q.db.ExecContext(ctx, query, id, surveyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke share token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
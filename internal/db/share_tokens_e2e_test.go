@@ -0,0 +1,80 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/sharetoken"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareTokens(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	create := func(slug, visibility string) *models.Survey {
+		survey := &models.Survey{
+			ID:    uuid.New(),
+			Slug:  slug,
+			Title: slug,
+			Definition: models.SurveyDefinition{
+				Questions:  []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}},
+				Visibility: visibility,
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		require.NoError(t, queries.CreateSurvey(ctx, survey))
+		return survey
+	}
+	create("listed", "")
+	create("public", models.VisibilityPublic)
+	create("unlisted", models.VisibilityUnlisted)
+	private := create("private", models.VisibilityToken)
+
+	// Only public surveys are listed
+	surveys, err := queries.ListSurveys(ctx, 10, 0)
+	require.NoError(t, err)
+	var slugs []string
+	for _, s := range surveys {
+		slugs = append(slugs, s.Slug)
+	}
+	assert.ElementsMatch(t, []string{"listed", "public"}, slugs)
+
+	token, secret, err := sharetoken.New(private.ID, "Board", "did:plc:author")
+	require.NoError(t, err)
+	require.NoError(t, queries.CreateShareToken(ctx, token))
+
+	valid, err := queries.ValidShareToken(ctx, private.ID, sharetoken.Hash(secret))
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = queries.ValidShareToken(ctx, uuid.New(), sharetoken.Hash(secret))
+	require.NoError(t, err)
+	assert.False(t, valid, "tokens only open their own survey")
+
+	count, err := queries.CountActiveShareTokens(ctx, private.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, queries.RevokeShareToken(ctx, token.ID, private.ID))
+	assert.ErrorIs(t, queries.RevokeShareToken(ctx, token.ID, private.ID), sql.ErrNoRows)
+
+	valid, err = queries.ValidShareToken(ctx, private.ID, sharetoken.Hash(secret))
+	require.NoError(t, err)
+	assert.False(t, valid, "revoked tokens no longer open the survey")
+
+	tokens, err := queries.ListShareTokens(ctx, private.ID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "Board", tokens[0].Label)
+	assert.NotNil(t, tokens[0].RevokedAt)
+}
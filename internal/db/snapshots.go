@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/snapshot"
+)
+
+// GetSnapshotSchedule implements the snapshot.Store interface
+func (q *Queries) GetSnapshotSchedule(ctx context.Context, surveyID uuid.UUID) (*snapshot.Schedule, error) {
+	query := `
+		SELECT survey_id, frequency, did, next_run_at, created_at
+		FROM snapshot_schedules
+		WHERE survey_id = $1
+	`
+
+	s := &snapshot.Schedule{}
+	err := q.db.QueryRowContext(ctx, query, surveyID).Scan(&s.SurveyID, &s.Frequency, &s.DID, &s.NextRunAt, &s.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get snapshot schedule: %w", err)
+	}
+
+	return s, nil
+}
+
+// SaveSnapshotSchedule implements the snapshot.Store interface
+func (q *Queries) SaveSnapshotSchedule(ctx context.Context, s *snapshot.Schedule) error {
+	query := `
+		INSERT INTO snapshot_schedules (survey_id, frequency, did, next_run_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (survey_id) DO UPDATE
+		SET frequency = EXCLUDED.frequency, did = EXCLUDED.did, next_run_at = EXCLUDED.next_run_at
+	`
+
+	_, err := q.db.ExecContext(ctx, query, s.SurveyID, s.Frequency, s.DID, s.NextRunAt, s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot schedule: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSnapshotSchedule implements the snapshot.Store interface
+func (q *Queries) DeleteSnapshotSchedule(ctx context.Context, surveyID uuid.UUID) error {
+	query := `DELETE FROM snapshot_schedules WHERE survey_id = $1`
+
+	if _, err := q.db.ExecContext(ctx, query, surveyID); err != nil {
+		return fmt.Errorf("failed to delete snapshot schedule: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDueSnapshotSchedules implements the snapshot.Store interface
+// Due rows are locked, skipping rows another replica is claiming, and moved to
+// their next run in the same transaction. Schedules of surveys in the trash wait.
+func (q *Queries) ClaimDueSnapshotSchedules(ctx context.Context, now time.Time, limit int) ([]*snapshot.Schedule, error) {
+	var schedules []*snapshot.Schedule
+	err := q.InTx(ctx, func(tx *Queries) error {
+		query := `
+			SELECT survey_id, frequency, did, next_run_at, created_at
+			FROM snapshot_schedules
+			WHERE next_run_at <= $1
+				AND NOT EXISTS (SELECT 1 FROM surveys s WHERE s.id = survey_id AND s.deleted_at IS NOT NULL)
+			ORDER BY next_run_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		`
+
+		rows, err := tx.db.QueryContext(ctx, query, now, limit)
+		if err != nil {
+			return fmt.Errorf("failed to query due snapshot schedules: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			s := &snapshot.Schedule{}
+			if err := rows.Scan(&s.SurveyID, &s.Frequency, &s.DID, &s.NextRunAt, &s.CreatedAt); err != nil {
+				return fmt.Errorf("failed to scan snapshot schedule: %w", err)
+			}
+			schedules = append(schedules, s)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating snapshot schedules: %w", err)
+		}
+
+		for _, s := range schedules {
+			// Runs missed while no replica was up are skipped, not caught up
+			s.NextRunAt = s.Frequency.Next(now)
+			_, err := tx.db.ExecContext(ctx, `UPDATE snapshot_schedules SET next_run_at = $2 WHERE survey_id = $1`, s.SurveyID, s.NextRunAt)
+			if err != nil {
+				return fmt.Errorf("failed to advance snapshot schedule: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// CreateSnapshot implements the snapshot.Store interface
+func (q *Queries) CreateSnapshot(ctx context.Context, s *snapshot.Snapshot) error {
+	query := `
+		INSERT INTO results_snapshots (id, survey_id, total_votes, record, uri, cid, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := q.db.ExecContext(ctx, query, s.ID, s.SurveyID, s.TotalVotes, []byte(s.Record), s.URI, s.CID, s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert results snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// ListSnapshots implements the snapshot.Store interface
+func (q *Queries) ListSnapshots(ctx context.Context, surveyID uuid.UUID, limit int) ([]*snapshot.Snapshot, error) {
+	query := `
+		SELECT id, survey_id, total_votes, record, uri, cid, created_at
+		FROM (
+			SELECT id, survey_id, total_votes, record, uri, cid, created_at
+			FROM results_snapshots
+			WHERE survey_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		) latest
+		ORDER BY created_at
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list results snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*snapshot.Snapshot
+	for rows.Next() {
+		s := &snapshot.Snapshot{}
+		var record []byte
+		if err := rows.Scan(&s.ID, &s.SurveyID, &s.TotalVotes, &record, &s.URI, &s.CID, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan results snapshot: %w", err)
+		}
+		s.Record = record
+		snapshots = append(snapshots, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating results snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
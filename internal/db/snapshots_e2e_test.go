@@ -0,0 +1,90 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultsSnapshots(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "snapshots",
+		Title: "Snapshots",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	schedule, err := queries.GetSnapshotSchedule(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Nil(t, schedule, "surveys are not opted in by default")
+
+	now := time.Date(2026, 3, 14, 15, 9, 0, 0, time.UTC)
+	require.NoError(t, queries.SaveSnapshotSchedule(ctx, snapshot.NewSchedule(survey.ID, snapshot.Daily, "did:plc:author", now)))
+	require.NoError(t, queries.SaveSnapshotSchedule(ctx, snapshot.NewSchedule(survey.ID, snapshot.Hourly, "did:plc:author", now)))
+
+	schedule, err = queries.GetSnapshotSchedule(ctx, survey.ID)
+	require.NoError(t, err)
+	require.NotNil(t, schedule)
+	assert.Equal(t, snapshot.Hourly, schedule.Frequency, "saving replaces the schedule")
+
+	due, err := queries.ClaimDueSnapshotSchedules(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due, "not due before the next hour")
+
+	later := now.Add(3 * time.Hour)
+	due, err = queries.ClaimDueSnapshotSchedules(ctx, later, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, time.Date(2026, 3, 14, 19, 0, 0, 0, time.UTC), due[0].NextRunAt.UTC(), "missed runs are skipped")
+
+	due, err = queries.ClaimDueSnapshotSchedules(ctx, later, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due, "claimed schedules are moved to their next run")
+
+	uri := "at://did:plc:author/net.openmeet.survey.results/3kabc"
+	for i, published := range []*string{nil, &uri} {
+		require.NoError(t, queries.CreateSnapshot(ctx, &snapshot.Snapshot{
+			ID:         uuid.New(),
+			SurveyID:   survey.ID,
+			TotalVotes: i + 1,
+			Record:     json.RawMessage(`{"$type": "net.openmeet.survey.results"}`),
+			URI:        published,
+			CreatedAt:  now.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+
+	snapshots, err := queries.ListSnapshots(ctx, survey.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, 1, snapshots[0].TotalVotes, "oldest first")
+	assert.False(t, snapshots[0].Published())
+	assert.True(t, snapshots[1].Published())
+
+	snapshots, err = queries.ListSnapshots(ctx, survey.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, 2, snapshots[0].TotalVotes, "the latest are kept")
+
+	require.NoError(t, queries.DeleteSnapshotSchedule(ctx, survey.ID))
+	schedule, err = queries.GetSnapshotSchedule(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Nil(t, schedule)
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/spam"
+)
+
+// SaveSpamScore implements the spam.Store interface
+func (q *Queries) SaveSpamScore(ctx context.Context, s *spam.Score) error {
+	reasons, err := json.Marshal(s.Reasons)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spam reasons: %w", err)
+	}
+
+	query := `
+		INSERT INTO response_spam_scores (response_id, survey_id, score, reasons, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (response_id) DO UPDATE
+		SET score = EXCLUDED.score, reasons = EXCLUDED.reasons
+	`
+
+	_, err = q.db.ExecContext(ctx, query, s.ResponseID, s.SurveyID, s.Score, reasons, s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save spam score: %w", err)
+	}
+
+	return nil
+}
+
+// ListSpamScores implements the spam.Store interface
+func (q *Queries) ListSpamScores(ctx context.Context, surveyID uuid.UUID) ([]*spam.Score, error) {
+	query := `
+		SELECT response_id, survey_id, score, reasons, created_at
+		FROM response_spam_scores
+		WHERE survey_id = $1
+		ORDER BY created_at ASC, response_id ASC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spam scores: %w", err)
+	}
+	defer rows.Close()
+
+	var scores []*spam.Score
+	for rows.Next() {
+		s := &spam.Score{}
+		var reasons []byte
+		if err := rows.Scan(&s.ResponseID, &s.SurveyID, &s.Score, &reasons, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan spam score: %w", err)
+		}
+		if err := json.Unmarshal(reasons, &s.Reasons); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal spam reasons: %w", err)
+		}
+		scores = append(scores, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating spam scores: %w", err)
+	}
+
+	return scores, nil
+}
+
+// GetSpamThreshold implements the spam.Store interface
+func (q *Queries) GetSpamThreshold(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	var threshold int
+	err := q.db.QueryRowContext(ctx, `SELECT threshold FROM spam_thresholds WHERE survey_id = $1`, surveyID).Scan(&threshold)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get spam threshold: %w", err)
+	}
+
+	return threshold, nil
+}
+
+// SetSpamThreshold implements the spam.Store interface
+func (q *Queries) SetSpamThreshold(ctx context.Context, surveyID uuid.UUID, threshold int, by string) error {
+	if threshold == 0 {
+		if _, err := q.db.ExecContext(ctx, `DELETE FROM spam_thresholds WHERE survey_id = $1`, surveyID); err != nil {
+			return fmt.Errorf("failed to remove spam threshold: %w", err)
+		}
+		return nil
+	}
+
+	query := `
+		INSERT INTO spam_thresholds (survey_id, threshold, set_by, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (survey_id) DO UPDATE
+		SET threshold = EXCLUDED.threshold, set_by = EXCLUDED.set_by, updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, surveyID, threshold, by); err != nil {
+		return fmt.Errorf("failed to set spam threshold: %w", err)
+	}
+
+	return nil
+}
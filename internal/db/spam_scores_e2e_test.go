@@ -0,0 +1,83 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/spam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpamScores(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	survey := &models.Survey{
+		ID:         uuid.New(),
+		Slug:       "lunch",
+		Title:      "Lunch",
+		Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Where?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "a", Text: "A"}, {ID: "b", Text: "B"}}}}},
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	vote := func(session string, score int, reasons ...string) *models.Response {
+		r := &models.Response{
+			ID:           uuid.New(),
+			SurveyID:     survey.ID,
+			VoterSession: &session,
+			Answers:      map[string]models.Answer{"q1": {SelectedOptions: []string{"a"}}},
+			CreatedAt:    time.Now(),
+		}
+		require.NoError(t, queries.CreateResponse(ctx, r))
+		require.NoError(t, queries.SaveSpamScore(ctx, &spam.Score{
+			ResponseID: r.ID, SurveyID: survey.ID, Score: score, Reasons: reasons, CreatedAt: r.CreatedAt,
+		}))
+		return r
+	}
+	vote("home", 0)
+	vote("office", 40, spam.ReasonUserAgent)
+	bot := vote("bot", 100, spam.ReasonHoneypot, spam.ReasonUserAgent)
+
+	scores, err := queries.ListSpamScores(ctx, survey.ID)
+	require.NoError(t, err)
+	require.Len(t, scores, 3)
+	assert.Equal(t, bot.ID, scores[2].ResponseID)
+	assert.Equal(t, 100, scores[2].Score)
+	assert.Equal(t, []string{spam.ReasonHoneypot, spam.ReasonUserAgent}, scores[2].Reasons)
+
+	threshold, err := queries.GetSpamThreshold(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Zero(t, threshold, "no threshold by default")
+
+	// Responses at or above the threshold are left out of results
+	require.NoError(t, queries.SetSpamThreshold(ctx, survey.ID, 40, "did:plc:author"))
+	threshold, err = queries.GetSpamThreshold(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 40, threshold)
+	results, err := queries.GetSurveyResults(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, results.TotalVotes)
+	assert.Equal(t, 2, results.ExcludedSpam)
+
+	require.NoError(t, queries.SetSpamThreshold(ctx, survey.ID, 90, "did:plc:author"))
+	results, err = queries.GetSurveyResults(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.TotalVotes)
+
+	require.NoError(t, queries.SetSpamThreshold(ctx, survey.ID, 0, "did:plc:author"))
+	threshold, err = queries.GetSpamThreshold(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Zero(t, threshold)
+	results, err = queries.GetSurveyResults(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, results.TotalVotes)
+}
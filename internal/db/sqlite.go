@@ -0,0 +1,409 @@
+package db
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriverName is the database/sql driver of SQLite databases: go-sqlite3
+// running the Postgres SQL of this package, translated by translateSQLite
+const sqliteDriverName = "sqlite3-survey"
+
+// sqliteTimeFormat is how timestamps are stored in SQLite: UTC with a fixed
+// number of fractional digits, so they compare correctly as text
+const sqliteTimeFormat = "2006-01-02 15:04:05.000000+00:00"
+
+// sqliteTimeRegex matches the text of timestamps stored in sqliteTimeFormat
+var sqliteTimeRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{6}\+00:00$`)
+
+func init() {
+	sql.Register(sqliteDriverName, &sqliteDriver{SQLiteDriver: &sqlite3.SQLiteDriver{ConnectHook: registerSQLiteFunctions}})
+}
+
+// dialect is the SQL dialect of a database
+type dialect int
+
+const (
+	dialectPostgres dialect = iota
+	dialectSQLite
+)
+
+// dialectOf returns the dialect of a connection pool; transactions can't tell
+// and are taken for Postgres, so Queries carries the dialect into InTx
+func dialectOf(db SQLQuerier) dialect {
+	if conn, ok := db.(*sql.DB); ok {
+		if _, ok := conn.Driver().(*sqliteDriver); ok {
+			return dialectSQLite
+		}
+	}
+	return dialectPostgres
+}
+
+// openSQLite opens and pings a SQLite database file, creating it if needed.
+// Foreign keys are enforced, the write-ahead log lets readers run alongside a
+// writer, and transactions take the write lock when they begin, so two
+// transactions never deadlock upgrading their locks.
+func openSQLite(ctx context.Context, path string, pool PoolConfig) (*sql.DB, error) {
+	params := url.Values{
+		"_loc":          {"UTC"},
+		"_foreign_keys": {"on"},
+		"_journal_mode": {"WAL"},
+		"_busy_timeout": {"10000"},
+		"_txlock":       {"immediate"},
+	}
+	db, err := sql.Open(sqliteDriverName, "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	pool = pool.withDefaults()
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
+
+// registerSQLiteFunctions defines the Postgres functions the queries of this
+// package call that SQLite lacks. Schemas don't use them, so the database
+// file can still be opened with other SQLite tools.
+func registerSQLiteFunctions(conn *sqlite3.SQLiteConn) error {
+	functions := []struct {
+		name string
+		impl any
+		pure bool
+	}{
+		{"now", func() string { return time.Now().UTC().Format(sqliteTimeFormat) }, false},
+		{"gen_random_uuid", func() string { return uuid.NewString() }, false},
+		{"md5", func(s string) string { sum := md5.Sum([]byte(s)); return hex.EncodeToString(sum[:]) }, true},
+		{"power", math.Pow, true},
+		{"date_trunc", sqliteDateTrunc, true},
+	}
+	for _, f := range functions {
+		if err := conn.RegisterFunc(f.name, f.impl, f.pure); err != nil {
+			return fmt.Errorf("failed to register %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// sqliteDateTrunc is date_trunc(unit, timestamp[, zone]) of timestamps stored
+// in UTC; the zone must be UTC
+func sqliteDateTrunc(unit, value string, zone ...string) (string, error) {
+	t, err := parseSQLiteTime(value)
+	if err != nil {
+		return "", err
+	}
+	switch unit {
+	case "hour":
+		t = t.Truncate(time.Hour)
+	case "day":
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	default:
+		return "", fmt.Errorf("date_trunc: unsupported unit %q", unit)
+	}
+	return t.Format(sqliteTimeFormat), nil
+}
+
+// sqliteTimeFormats are the formats of timestamps parseSQLiteTime accepts:
+// sqliteTimeFormat, then those other SQLite tools write
+var sqliteTimeFormats = []string{
+	sqliteTimeFormat,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.DateOnly,
+}
+
+// parseSQLiteTime parses a stored timestamp
+func parseSQLiteTime(value string) (time.Time, error) {
+	value = strings.TrimSuffix(value, "Z")
+	for _, format := range sqliteTimeFormats {
+		if t, err := time.ParseInLocation(format, value, time.UTC); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+// sqliteDriver is go-sqlite3 with translated queries and arguments. Binaries
+// built without cgo include a stub of go-sqlite3, whose connections fail.
+type sqliteDriver struct {
+	*sqlite3.SQLiteDriver
+}
+
+// Open opens a connection
+func (d *sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn}, nil
+}
+
+// sqliteConn translates the queries and arguments of a go-sqlite3 connection
+type sqliteConn struct {
+	driver.Conn
+}
+
+var (
+	_ driver.ExecerContext      = (*sqliteConn)(nil)
+	_ driver.QueryerContext     = (*sqliteConn)(nil)
+	_ driver.ConnPrepareContext = (*sqliteConn)(nil)
+	_ driver.ConnBeginTx        = (*sqliteConn)(nil)
+	_ driver.Pinger             = (*sqliteConn)(nil)
+	_ driver.NamedValueChecker  = (*sqliteConn)(nil)
+)
+
+// ExecContext runs a statement
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, translateSQLite(query), args)
+}
+
+// QueryContext runs a query
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.Conn.(driver.QueryerContext).QueryContext(ctx, translateSQLite(query), args)
+	if err != nil {
+		return nil, err
+	}
+	return newSQLiteRows(rows), nil
+}
+
+// PrepareContext prepares a statement
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, translateSQLite(query))
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteStmt{stmt}, nil
+}
+
+// Prepare prepares a statement
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// BeginTx begins a transaction
+func (c *sqliteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+// Ping checks the connection
+func (c *sqliteConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx)
+}
+
+// CheckNamedValue converts arguments to what SQLite stores: timestamps as
+// text in sqliteTimeFormat, JSON documents as text, and slices (arrays in
+// Postgres) as JSON arrays
+func (c *sqliteConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if v := reflect.ValueOf(nv.Value); v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		b, err := json.Marshal(nv.Value)
+		if err != nil {
+			return err
+		}
+		nv.Value = string(b)
+		return nil
+	}
+
+	value, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case time.Time:
+		value = v.UTC().Format(sqliteTimeFormat)
+	case []byte:
+		if utf8.Valid(v) {
+			value = string(v)
+		}
+	}
+	nv.Value = value
+	return nil
+}
+
+// sqliteStmt is a prepared statement whose rows are converted
+type sqliteStmt struct {
+	driver.Stmt
+}
+
+var (
+	_ driver.StmtExecContext  = (*sqliteStmt)(nil)
+	_ driver.StmtQueryContext = (*sqliteStmt)(nil)
+)
+
+// ExecContext runs the statement
+func (s *sqliteStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.Stmt.(driver.StmtExecContext).ExecContext(ctx, args)
+}
+
+// QueryContext runs the statement's query
+func (s *sqliteStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := s.Stmt.(driver.StmtQueryContext).QueryContext(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return newSQLiteRows(rows), nil
+}
+
+// sqliteRows parses timestamps computed by queries. go-sqlite3 only parses
+// those of columns declared as timestamps; expressions such as MAX(created_at)
+// or date_trunc() return text.
+type sqliteRows struct {
+	driver.Rows
+	computed []bool // Whether each column is an expression without a declared type
+}
+
+func newSQLiteRows(rows driver.Rows) *sqliteRows {
+	r := &sqliteRows{Rows: rows, computed: make([]bool, len(rows.Columns()))}
+	if types, ok := rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		for i := range r.computed {
+			r.computed[i] = types.ColumnTypeDatabaseTypeName(i) == ""
+		}
+	}
+	return r
+}
+
+// Next reads the next row
+func (r *sqliteRows) Next(dest []driver.Value) error {
+	if err := r.Rows.Next(dest); err != nil {
+		return err
+	}
+	for i, v := range dest {
+		if s, ok := v.(string); ok && r.computed[i] && sqliteTimeRegex.MatchString(s) {
+			if t, err := time.Parse(sqliteTimeFormat, s); err == nil {
+				dest[i] = t
+			}
+		}
+	}
+	return nil
+}
+
+// sqliteRewrites turn Postgres syntax into its SQLite equivalent, in order.
+// They only apply outside string literals.
+var sqliteRewrites = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	// Positional parameters; SQLite reads $1 as a parameter named "1"
+	{regexp.MustCompile(`\$(\d+)`), `?$1`},
+	// Type casts; values are stored as text, integers, and reals
+	{regexp.MustCompile(`::\w+(\[\])?`), ``},
+	// Arrays, which are passed as JSON arrays
+	{regexp.MustCompile(`=\s*ANY\s*\((\?\d+)\)`), `IN (SELECT value FROM json_each($1))`},
+	// Row locks; SQLite transactions take the database's write lock when they begin
+	{regexp.MustCompile(`(?i)\s+FOR\s+(UPDATE|SHARE)(\s+OF\s+\w+)?(\s+SKIP\s+LOCKED)?`), ``},
+	{regexp.MustCompile(`\bILIKE\b`), `LIKE`},
+	{regexp.MustCompile(`\bIS NOT DISTINCT FROM\b`), `IS`},
+	{regexp.MustCompile(`\bIS DISTINCT FROM\b`), `IS NOT`},
+	{regexp.MustCompile(`\bGREATEST\(`), `max(`},
+	{regexp.MustCompile(`\bLEAST\(`), `min(`},
+	// JSON functions of JSON1
+	{regexp.MustCompile(`\bjsonb_build_array\(`), `json_array(`},
+	{regexp.MustCompile(`\bjsonb_build_object\(`), `json_object(`},
+	{regexp.MustCompile(`\bjsonb_object_agg\(`), `json_group_object(`},
+	{regexp.MustCompile(`\bjsonb_agg\(`), `json_group_array(`},
+	{regexp.MustCompile(`\bjsonb_(each|array_elements)\(`), `json_each(`},
+	{regexp.MustCompile(`\bjsonb_array_length\(`), `json_array_length(`},
+	{regexp.MustCompile(`\bstring_agg\(`), `group_concat(`},
+	{regexp.MustCompile(`\bstrpos\(`), `instr(`},
+}
+
+// sqliteTimeZoneRewrites turn conversions of timestamps to UTC, which is how
+// they are stored, into the timestamps or their dates
+var sqliteTimeZoneRewrites = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`\(([\w.]+) AT TIME ZONE 'UTC'\)::date`), `date_trunc('day', $1)`},
+	{regexp.MustCompile(`\s+AT TIME ZONE 'UTC'`), ``},
+}
+
+// sqliteQueries caches translated queries, which are mostly constants
+var sqliteQueries sync.Map
+
+// translateSQLite translates a query of this package from Postgres to SQLite.
+// Queries using Postgres features without an equivalent have SQLite variants
+// chosen by the dialect of Queries instead.
+func translateSQLite(query string) string {
+	if translated, ok := sqliteQueries.Load(query); ok {
+		return translated.(string)
+	}
+
+	translated := query
+	for _, rw := range sqliteTimeZoneRewrites {
+		translated = rw.pattern.ReplaceAllString(translated, rw.replace)
+	}
+
+	// Split the query into string literals, at odd indexes, and the SQL between them
+	parts := strings.Split(translated, "'")
+	for i := 0; i < len(parts); i += 2 {
+		for _, rw := range sqliteRewrites {
+			parts[i] = rw.pattern.ReplaceAllString(parts[i], rw.replace)
+		}
+	}
+	translated = strings.Join(parts, "'")
+
+	sqliteQueries.Store(query, translated)
+	return translated
+}
+
+// sqliteColumns returns the columns of a SQLite table, in order
+func sqliteColumns(ctx context.Context, db Querier, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_table_info($1) ORDER BY cid`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// sqliteRowObject returns an expression of the columns of a row as a JSON
+// object, like to_jsonb of the row
+func sqliteRowObject(alias string, columns []string) string {
+	fields := make([]string, len(columns))
+	for i, column := range columns {
+		fields[i] = fmt.Sprintf(`'%s', %s."%s"`, column, alias, column)
+	}
+	return "json_object(" + strings.Join(fields, ", ") + ")"
+}
+
+// sqliteRecordColumns returns a select list of the columns of the JSON objects
+// of rows in the values of json_each alias, like jsonb_populate_recordset
+func sqliteRecordColumns(alias string, columns []string) string {
+	fields := make([]string, len(columns))
+	for i, column := range columns {
+		fields[i] = fmt.Sprintf(`%s.value->>'%s' AS "%s"`, alias, column, column)
+	}
+	return strings.Join(fields, ", ")
+}
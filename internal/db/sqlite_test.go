@@ -0,0 +1,281 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openSQLiteDB opens an empty SQLite database in a temporary directory,
+// skipping the test in builds without cgo
+func openSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := openSQLite(context.Background(), filepath.Join(t.TempDir(), "survey.db"), PoolConfig{})
+	if err != nil && strings.Contains(err.Error(), "CGO_ENABLED=0") {
+		t.Skip("SQLite requires cgo")
+	}
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+// openMigratedSQLite opens a SQLite database with all migrations applied
+func openMigratedSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	database := openSQLiteDB(t)
+	_, err := Migrate(context.Background(), database)
+	require.NoError(t, err)
+	return database
+}
+
+func TestTranslateSQLite(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"placeholders", `SELECT * FROM surveys WHERE id = $1 AND slug = $12`, `SELECT * FROM surveys WHERE id = ?1 AND slug = ?12`},
+		{"casts", `SELECT COALESCE($9::int, 1), '{}'::jsonb, $2::uuid[]`, `SELECT COALESCE(?9, 1), '{}', ?2`},
+		{"literals are kept", `SELECT 'cost: $1::int' WHERE x ILIKE 'FOR UPDATE'`, `SELECT 'cost: $1::int' WHERE x LIKE 'FOR UPDATE'`},
+		{"arrays", `WHERE response_id = ANY($2::uuid[])`, `WHERE response_id IN (SELECT value FROM json_each(?2))`},
+		{"row locks", "SELECT id FROM jobs\n\t\t\tFOR UPDATE OF j SKIP LOCKED\n", "SELECT id FROM jobs\n"},
+		{"dates", `SELECT (created_at AT TIME ZONE 'UTC')::date AS day`, `SELECT date_trunc('day', created_at) AS day`},
+		{"time zones", `date_trunc('day', sampled_at AT TIME ZONE 'UTC')`, `date_trunc('day', sampled_at)`},
+		{"functions", `SELECT GREATEST(a, $1), jsonb_agg(x), jsonb_build_array($2)`, `SELECT max(a, ?1), json_group_array(x), json_array(?2)`},
+		{"distinct", `WHERE a IS DISTINCT FROM b OR c IS NOT DISTINCT FROM d`, `WHERE a IS NOT b OR c IS d`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, translateSQLite(tt.query))
+		})
+	}
+}
+
+func TestSQLiteArguments(t *testing.T) {
+	database := openSQLiteDB(t)
+	ctx := context.Background()
+
+	// Timestamps are stored as UTC text, and read back as times
+	at := time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.FixedZone("CET", 3600))
+	var stored string
+	require.NoError(t, database.QueryRowContext(ctx, `SELECT typeof($1) || ' ' || $1`, at).Scan(&stored))
+	assert.Equal(t, "text 2024-03-01 11:30:00.123456+00:00", stored)
+	var got time.Time
+	require.NoError(t, database.QueryRowContext(ctx, `SELECT $1`, at).Scan(&got))
+	assert.True(t, at.Equal(got))
+	require.NoError(t, database.QueryRowContext(ctx, `SELECT date_trunc('hour', $1, 'UTC')`, at).Scan(&got))
+	assert.Equal(t, time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC), got)
+
+	// Slices are JSON arrays, and JSON documents text
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	var count int
+	require.NoError(t, database.QueryRowContext(ctx, `SELECT COUNT(*) FROM json_each($1) WHERE value = ANY($2)`, ids, []string{ids[1].String()}).Scan(&count))
+	assert.Equal(t, 1, count)
+	var kind string
+	require.NoError(t, database.QueryRowContext(ctx, `SELECT typeof($1) || ' ' || ($1->>'a')`, []byte(`{"a": "b"}`)).Scan(&kind))
+	assert.Equal(t, "text b", kind)
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	postgres, err := Migrations()
+	require.NoError(t, err)
+	sqlite, err := SQLiteMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, sqlite)
+
+	// The first SQLite migration has the schema up to its version, and each
+	// later Postgres migration has a SQLite one
+	first := sqlite[0].Version
+	var want []int
+	for _, m := range postgres {
+		if m.Version >= first {
+			want = append(want, m.Version)
+		}
+	}
+	var got []int
+	for _, m := range sqlite {
+		got = append(got, m.Version)
+	}
+	assert.Equal(t, want, got)
+
+	database := openSQLiteDB(t)
+	ctx := context.Background()
+	applied, err := Migrate(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, len(sqlite), applied)
+	status, err := GetMigrationStatus(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, sqlite[len(sqlite)-1].Version, status.Version)
+	assert.Empty(t, status.Pending)
+
+	// Every down migration reverts its up migration
+	reverted, err := MigrateDown(ctx, database, len(sqlite))
+	require.NoError(t, err)
+	assert.Equal(t, len(sqlite), reverted)
+	var tables int
+	require.NoError(t, database.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name != 'schema_migrations'`).Scan(&tables))
+	assert.Zero(t, tables)
+
+	applied, err = Migrate(ctx, database)
+	require.NoError(t, err)
+	assert.Equal(t, len(sqlite), applied)
+}
+
+func TestSQLiteQueries(t *testing.T) {
+	database := openMigratedSQLite(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "lunch",
+		Title: "Lunch",
+		Definition: models.SurveyDefinition{Questions: []models.Question{
+			{ID: "q1", Text: "Where?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "a", Text: "Here"}, {ID: "b", Text: "There"}}},
+		}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+
+	for i, option := range []string{"a", "b", "a"} {
+		did := "did:plc:voter" + string(rune('0'+i))
+		require.NoError(t, queries.CreateResponse(ctx, &models.Response{
+			ID:        uuid.New(),
+			SurveyID:  survey.ID,
+			VoterDID:  &did,
+			Answers:   map[string]models.Answer{"q1": {SelectedOptions: []string{option}}},
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	got, err := queries.GetSurveyBySlug(ctx, "lunch")
+	require.NoError(t, err)
+	assert.Equal(t, survey.ID, got.ID)
+	assert.Equal(t, survey.Definition, got.Definition)
+	assert.WithinDuration(t, now, got.CreatedAt, time.Microsecond)
+
+	// JSON containment is checked with JSON1
+	filtered, err := queries.ListFilteredResponses(ctx, survey.ID, models.ResponseFilter{Question: "q1", Option: "a", QuestionIDs: []string{"q1"}})
+	require.NoError(t, err)
+	assert.Len(t, filtered, 2)
+
+	results, err := queries.GetSurveyResults(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, results.QuestionResults["q1"].Respondents)
+	assert.Equal(t, map[string]int{"a": 2, "b": 1}, results.QuestionResults["q1"].OptionCounts)
+
+	// Streams come in batches, oldest first
+	var batches [][]string
+	err = queries.ListResponsesBySurveyStream(ctx, survey.ID, models.ResponseFilter{}, 2, func(batch []*models.Response) error {
+		var options []string
+		for _, r := range batch {
+			options = append(options, r.Answers["q1"].SelectedOptions[0])
+		}
+		batches = append(batches, options)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}, {"a"}}, batches)
+	stop := errors.New("stop")
+	err = queries.ListResponsesBySurveyStream(ctx, survey.ID, models.ResponseFilter{}, 1, func([]*models.Response) error { return stop })
+	assert.ErrorIs(t, err, stop)
+
+	// A definition change bumps the version in a transaction
+	survey.Definition.Questions[0].Text = "Where to?"
+	require.NoError(t, queries.UpdateSurvey(ctx, survey))
+	assert.Equal(t, 2, survey.Version)
+	require.NoError(t, queries.UpdateSurvey(ctx, survey))
+	assert.Equal(t, 2, survey.Version)
+	ordinals, err := queries.ListQuestionOrdinals(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[int]map[string]int{1: {"q1": 1}, 2: {"q1": 1}}, ordinals)
+
+	err = queries.InTx(ctx, func(tx *Queries) error {
+		assert.Equal(t, dialectSQLite, tx.dialect)
+		return tx.DeleteSurvey(ctx, survey.ID)
+	})
+	require.NoError(t, err)
+	_, err = queries.GetSurveyBySlug(ctx, "lunch")
+	assert.Error(t, err)
+}
+
+func TestSQLiteRedactedResults(t *testing.T) {
+	database := openMigratedSQLite(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "contact",
+		Title: "Contact",
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{{ID: "q1", Text: "Anything else?", Type: models.QuestionTypeText}},
+			Redaction: &models.Redaction{Emails: true},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	require.NoError(t, queries.CreateSurvey(ctx, survey))
+	session := "voter-session"
+	require.NoError(t, queries.CreateResponse(ctx, &models.Response{
+		ID:           uuid.New(),
+		SurveyID:     survey.ID,
+		VoterSession: &session,
+		Answers:      map[string]models.Answer{"q1": {Text: "Mail me at ann@example.com"}},
+		CreatedAt:    now,
+	}))
+
+	// Results are redacted, responses keep the original text for exports
+	results, err := queries.GetSurveyResults(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Mail me at [email]"}, results.QuestionResults["q1"].TextAnswers)
+	responses, err := queries.ListResponsesBySurvey(ctx, survey.ID)
+	require.NoError(t, err)
+	require.Len(t, responses, 1)
+	assert.Equal(t, "Mail me at ann@example.com", responses[0].Answers["q1"].Text)
+}
+
+func TestSQLiteLeaderLock(t *testing.T) {
+	database := openMigratedSQLite(t)
+	ctx := context.Background()
+	first := NewLeaderLock(database, ConsumerLeaderLockID)
+	second := NewLeaderLock(database, ConsumerLeaderLockID)
+
+	acquired, err := first.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.NoError(t, first.Check(ctx))
+
+	// Another process cannot take a held lease
+	acquired, err = second.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, acquired, "a second holder must be refused")
+
+	// A released lease can be taken at once
+	require.NoError(t, first.Release(ctx))
+	acquired, err = second.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// A lease that was not renewed expires, and the previous holder is fenced
+	_, err = database.ExecContext(ctx, `UPDATE leader_epochs SET updated_at = $1`, time.Now().Add(-2*sqliteLeaseTTL))
+	require.NoError(t, err)
+	acquired, err = first.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	assert.ErrorIs(t, second.Check(ctx), ErrNotLeader)
+	assert.ErrorIs(t, second.Fence(ctx, querierOf(database)), ErrNotLeader)
+	assert.NoError(t, first.Fence(ctx, querierOf(database)))
+}
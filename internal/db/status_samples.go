@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/status"
+)
+
+// InsertStatusSample implements the status.Store interface
+// Inserts a single availability sample
+func (q *Queries) InsertStatusSample(ctx context.Context, s *status.Sample) error {
+	query := `
+		INSERT INTO status_samples (component, instance, healthy, value, detail, sampled_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := q.db.QueryRowContext(ctx, query,
+		string(s.Component),
+		s.Instance,
+		s.Healthy,
+		s.Value,
+		s.Detail,
+		s.SampledAt,
+	).Scan(&s.ID)
+	if err != nil {
+		return fmt.Errorf("failed to insert status sample: %w", err)
+	}
+
+	return nil
+}
+
+// StatusHistory implements the status.HistoryReader interface
+// Counts samples per window and UTC day in SQL, and reads the latest sample of
+// each component and instance, so the status page does not load every sample
+func (q *Queries) StatusHistory(ctx context.Context, now time.Time) (map[status.Component]*status.History, error) {
+	histories := make(map[status.Component]*status.History)
+	history := func(component string) *status.History {
+		c := status.Component(component)
+		if histories[c] == nil {
+			histories[c] = &status.History{Days: make(map[string]status.Counts)}
+		}
+		return histories[c]
+	}
+
+	// Window counts, one pair of columns per window
+	var columns []string
+	var args []interface{}
+	for i, window := range status.Windows {
+		columns = append(columns, fmt.Sprintf(
+			"COUNT(*) FILTER (WHERE sampled_at >= $%d), COUNT(*) FILTER (WHERE sampled_at >= $%d AND healthy)", i+1, i+1))
+		args = append(args, now.Add(-window))
+	}
+	query := fmt.Sprintf(`
+		SELECT component, %s
+		FROM status_samples
+		WHERE sampled_at >= $%d
+		GROUP BY component
+	`, strings.Join(columns, ", "), len(args))
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count status samples: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var component string
+		var counts [len(status.Windows)]status.Counts
+		dest := []interface{}{&component}
+		for i := range counts {
+			dest = append(dest, &counts[i].Total, &counts[i].Healthy)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan status sample counts: %w", err)
+		}
+		history(component).Windows = counts
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status sample counts: %w", err)
+	}
+
+	// Daily counts
+	rows, err = q.db.QueryContext(ctx, `
+		SELECT component, date_trunc('day', sampled_at AT TIME ZONE 'UTC') AS day,
+			COUNT(*), COUNT(*) FILTER (WHERE healthy)
+		FROM status_samples
+		WHERE sampled_at >= $1
+		GROUP BY component, day
+	`, status.HistorySince(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count daily status samples: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var component string
+		var day time.Time
+		var counts status.Counts
+		if err := rows.Scan(&component, &day, &counts.Total, &counts.Healthy); err != nil {
+			return nil, fmt.Errorf("failed to scan daily status sample counts: %w", err)
+		}
+		history(component).Days[day.Format(time.DateOnly)] = counts
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating daily status sample counts: %w", err)
+	}
+
+	// Latest sample of each instance
+	query = `
+		SELECT DISTINCT ON (component, instance) id, component, instance, healthy, value, detail, sampled_at
+		FROM status_samples
+		WHERE sampled_at >= $1
+		ORDER BY component, instance, sampled_at DESC
+	`
+	if q.dialect == dialectSQLite {
+		query = `
+			SELECT id, component, instance, healthy, value, detail, sampled_at
+			FROM (
+				SELECT *, row_number() OVER (PARTITION BY component, instance ORDER BY sampled_at DESC) AS n
+				FROM status_samples
+				WHERE sampled_at >= $1
+			)
+			WHERE n = 1
+			ORDER BY component, instance
+		`
+	}
+	rows, err = q.db.QueryContext(ctx, query, now.Add(-status.RetentionPeriod))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list latest status samples: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s := &status.Sample{}
+		var component string
+		if err := rows.Scan(&s.ID, &component, &s.Instance, &s.Healthy, &s.Value, &s.Detail, &s.SampledAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status sample: %w", err)
+		}
+		s.Component = status.Component(component)
+		h := history(component)
+		h.Latest = append(h.Latest, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating latest status samples: %w", err)
+	}
+
+	return histories, nil
+}
+
+// DeleteStatusSamplesBefore implements the status.Store interface
+// Deletes samples older than before and returns the number removed
+func (q *Queries) DeleteStatusSamplesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM status_samples WHERE sampled_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete status samples: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return count, nil
+}
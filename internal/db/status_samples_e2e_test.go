@@ -0,0 +1,55 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusHistory(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	insert := func(instance string, healthy bool, at time.Time) {
+		t.Helper()
+		require.NoError(t, queries.InsertStatusSample(ctx, &status.Sample{
+			Component: status.ComponentAPI,
+			Instance:  instance,
+			Healthy:   healthy,
+			SampledAt: at,
+		}))
+	}
+	insert("api-1", true, now.Add(-10*24*time.Hour))
+	insert("api-1", false, now.Add(-2*time.Hour))
+	insert("api-1", true, now.Add(-5*time.Minute))
+	insert("api-2", true, now.Add(-time.Hour))
+	insert("api-2", false, now.Add(-4*time.Minute))
+	insert("api-1", true, now.Add(-100*24*time.Hour)) // Past the retention period
+
+	histories, err := queries.StatusHistory(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, histories, 1)
+	api := histories[status.ComponentAPI]
+
+	assert.Equal(t, status.Counts{Total: 4, Healthy: 2}, api.Windows[0])
+	assert.Equal(t, status.Counts{Total: 5, Healthy: 3}, api.Windows[3])
+	assert.Equal(t, status.Counts{Total: 4, Healthy: 2}, api.Days["2025-06-01"])
+	assert.Equal(t, status.Counts{Total: 1, Healthy: 1}, api.Days["2025-05-22"])
+
+	require.Len(t, api.Latest, 2)
+	latest := map[string]*status.Sample{}
+	for _, s := range api.Latest {
+		latest[s.Instance] = s
+	}
+	assert.True(t, latest["api-1"].Healthy)
+	assert.False(t, latest["api-2"].Healthy)
+	assert.True(t, latest["api-2"].SampledAt.Equal(now.Add(-4*time.Minute)))
+}
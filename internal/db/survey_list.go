@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/surveylist"
+)
+
+// surveyListOrders are the ORDER BY expressions of the survey list sorts
+var surveyListOrders = map[string]string{
+	surveylist.SortCreatedAt:     "s.created_at",
+	surveylist.SortResponseCount: "response_count",
+	surveylist.SortTitle:         "lower(s.title)",
+}
+
+// surveyStatusConditions are the conditions of the survey statuses, with
+// the current time as $2
+var surveyStatusConditions = map[string]string{
+	surveylist.StatusOpen:     "(s.starts_at IS NULL OR s.starts_at <= $2) AND (s.ends_at IS NULL OR s.ends_at > $2)",
+	surveylist.StatusClosed:   "s.ends_at <= $2",
+	surveylist.StatusUpcoming: "s.starts_at > $2 AND (s.ends_at IS NULL OR s.ends_at > $2)",
+}
+
+// ListAuthorSurveys implements the surveylist.Store interface
+// Returns an author's surveys with their response counts (archived ones
+// included), sorted and filtered
+func (q *Queries) ListAuthorSurveys(ctx context.Context, filter surveylist.Filter, now time.Time) ([]*surveylist.Item, error) {
+	order, ok := surveyListOrders[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown survey sort %q", filter.Sort)
+	}
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+
+	conditions := []string{"s.author_did = $1", "s.deleted_at IS NULL"}
+	if filter.Status != "" {
+		condition, ok := surveyStatusConditions[filter.Status]
+		if !ok {
+			return nil, fmt.Errorf("unknown survey status %q", filter.Status)
+		}
+		conditions = append(conditions, condition)
+	}
+	if filter.HasResults != nil {
+		if *filter.HasResults {
+			conditions = append(conditions, "s.results_uri IS NOT NULL")
+		} else {
+			conditions = append(conditions, "s.results_uri IS NULL")
+		}
+	}
+
+	query := fmt.Sprintf(`
+		SELECT s.id, s.uri, s.cid, s.author_did, s.slug, s.title, s.description, s.definition, s.version, s.starts_at, s.ends_at, s.results_uri, s.results_cid, s.created_at, s.updated_at, s.hidden_at, s.org_id,
+			(SELECT COUNT(*) FROM responses r WHERE r.survey_id = s.id)
+				+ COALESCE((SELECT a.response_count FROM survey_archives a WHERE a.survey_id = s.id), 0) AS response_count
+		FROM surveys s
+		WHERE %s
+		ORDER BY %s %s, s.id %s
+	`, strings.Join(conditions, " AND "), order, direction, direction)
+
+	// $2 is only referenced by status conditions
+	args := []interface{}{filter.AuthorDID}
+	if filter.Status != "" {
+		args = append(args, now)
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query surveys: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*surveylist.Item
+	for rows.Next() {
+		item := &surveylist.Item{Survey: &models.Survey{}}
+		survey := item.Survey
+		var defJSON []byte
+
+		err := rows.Scan(
+			&survey.ID,
+			&survey.URI,
+			&survey.CID,
+			&survey.AuthorDID,
+			&survey.Slug,
+			&survey.Title,
+			&survey.Description,
+			&defJSON,
+			&survey.Version,
+			&survey.StartsAt,
+			&survey.EndsAt,
+			&survey.ResultsURI,
+			&survey.ResultsCID,
+			&survey.CreatedAt,
+			&survey.UpdatedAt,
+			&survey.HiddenAt,
+			&survey.OrgID,
+			&item.Responses,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan survey: %w", err)
+		}
+
+		if err := json.Unmarshal(defJSON, &survey.Definition); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal survey definition: %w", err)
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating surveys: %w", err)
+	}
+
+	return items, nil
+}
+
+// GetSurveyFacets implements the surveylist.Store interface
+// Counts an author's surveys by status and published results
+func (q *Queries) GetSurveyFacets(ctx context.Context, authorDID string, now time.Time) (*surveylist.Facets, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE %s),
+			COUNT(*) FILTER (WHERE %s),
+			COUNT(*) FILTER (WHERE %s),
+			COUNT(*) FILTER (WHERE s.results_uri IS NOT NULL)
+		FROM surveys s
+		WHERE s.author_did = $1 AND s.deleted_at IS NULL
+	`, surveyStatusConditions[surveylist.StatusOpen], surveyStatusConditions[surveylist.StatusClosed], surveyStatusConditions[surveylist.StatusUpcoming])
+
+	facets := &surveylist.Facets{}
+	err := q.db.QueryRowContext(ctx, query, authorDID, now).Scan(
+		&facets.Total,
+		&facets.Open,
+		&facets.Closed,
+		&facets.Upcoming,
+		&facets.WithResults,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count surveys: %w", err)
+	}
+
+	return facets, nil
+}
@@ -0,0 +1,90 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/surveylist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAuthorSurveys(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	alice, bob := "did:plc:alice", "did:plc:bob"
+
+	newSurvey := func(slug, title string, author *string, created time.Time, startsAt, endsAt *time.Time, responses int) *models.Survey {
+		survey := &models.Survey{
+			ID:         uuid.New(),
+			AuthorDID:  author,
+			Slug:       slug,
+			Title:      title,
+			Definition: models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}}},
+			StartsAt:   startsAt,
+			EndsAt:     endsAt,
+			CreatedAt:  created,
+			UpdatedAt:  created,
+		}
+		require.NoError(t, queries.CreateSurvey(ctx, survey))
+		for i := 0; i < responses; i++ {
+			session := fmt.Sprintf("%s-%d", slug, i)
+			require.NoError(t, queries.CreateResponse(ctx, &models.Response{
+				ID:           uuid.New(),
+				SurveyID:     survey.ID,
+				VoterSession: &session,
+				Answers:      map[string]models.Answer{"q1": {Text: "Because"}},
+				CreatedAt:    now,
+			}))
+		}
+		return survey
+	}
+
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	open := newSurvey("open", "beta", &alice, now.Add(-3*time.Hour), nil, nil, 1)
+	closed := newSurvey("closed", "Alpha", &alice, now.Add(-2*time.Hour), nil, &past, 3)
+	upcoming := newSurvey("upcoming", "gamma", &alice, now.Add(-time.Hour), &future, nil, 0)
+	newSurvey("bobs", "Bob's", &bob, now, nil, nil, 5)
+	_, err := database.ExecContext(ctx, `UPDATE surveys SET results_uri = 'at://did:plc:alice/net.openmeet.survey.results/1' WHERE id = $1`, closed.ID)
+	require.NoError(t, err)
+
+	slugs := func(filter surveylist.Filter) []string {
+		filter.AuthorDID = alice
+		items, err := queries.ListAuthorSurveys(ctx, filter, now)
+		require.NoError(t, err)
+		var slugs []string
+		for _, item := range items {
+			slugs = append(slugs, item.Survey.Slug)
+		}
+		return slugs
+	}
+
+	assert.Equal(t, []string{"open", "closed", "upcoming"}, slugs(surveylist.Filter{Sort: surveylist.SortCreatedAt}))
+	assert.Equal(t, []string{"upcoming", "closed", "open"}, slugs(surveylist.Filter{Sort: surveylist.SortCreatedAt, Descending: true}))
+	assert.Equal(t, []string{"closed", "open", "upcoming"}, slugs(surveylist.Filter{Sort: surveylist.SortResponseCount, Descending: true}))
+	assert.Equal(t, []string{"closed", "open", "upcoming"}, slugs(surveylist.Filter{Sort: surveylist.SortTitle}), "titles sort case-insensitively")
+
+	assert.Equal(t, []string{open.Slug}, slugs(surveylist.Filter{Sort: surveylist.SortCreatedAt, Status: surveylist.StatusOpen}))
+	assert.Equal(t, []string{closed.Slug}, slugs(surveylist.Filter{Sort: surveylist.SortCreatedAt, Status: surveylist.StatusClosed}))
+	assert.Equal(t, []string{upcoming.Slug}, slugs(surveylist.Filter{Sort: surveylist.SortCreatedAt, Status: surveylist.StatusUpcoming}))
+
+	hasResults, noResults := true, false
+	assert.Equal(t, []string{closed.Slug}, slugs(surveylist.Filter{Sort: surveylist.SortCreatedAt, HasResults: &hasResults}))
+	assert.Equal(t, []string{"open", "upcoming"}, slugs(surveylist.Filter{Sort: surveylist.SortCreatedAt, HasResults: &noResults}))
+
+	items, err := queries.ListAuthorSurveys(ctx, surveylist.Filter{AuthorDID: alice, Sort: surveylist.SortResponseCount, Descending: true}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, items[0].Responses)
+
+	facets, err := queries.GetSurveyFacets(ctx, alice, now)
+	require.NoError(t, err)
+	assert.Equal(t, surveylist.Facets{Total: 3, Open: 1, Closed: 1, Upcoming: 1, WithResults: 1}, *facets)
+}
@@ -0,0 +1,248 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/tenant"
+)
+
+// tenantColumns are the columns scanned by scanTenant
+const tenantColumns = `t.id, t.slug, t.name, t.tagline, t.logo_url, t.accent_color, t.default_author_did, t.created_at`
+
+// scanTenant scans the tenantColumns of a row
+func scanTenant(row interface{ Scan(...any) error }) (*tenant.Tenant, error) {
+	t := &tenant.Tenant{}
+	err := row.Scan(&t.ID, &t.Slug, &t.Name, &t.Tagline, &t.LogoURL, &t.AccentColor, &t.DefaultAuthorDID, &t.CreatedAt)
+	return t, err
+}
+
+// CreateTenant implements the tenant.Store interface
+// Returns tenant.ErrSlugTaken if a tenant has the slug
+func (q *Queries) CreateTenant(ctx context.Context, t *tenant.Tenant) error {
+	query := `
+		INSERT INTO tenants (id, slug, name, tagline, logo_url, accent_color, default_author_did, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (slug) DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, t.ID, t.Slug, t.Name, t.Tagline, t.LogoURL, t.AccentColor, t.DefaultAuthorDID, t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert tenant: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return tenant.ErrSlugTaken
+	}
+
+	return nil
+}
+
+// UpdateTenant implements the tenant.Store interface
+// Returns sql.ErrNoRows if there is no such tenant
+func (q *Queries) UpdateTenant(ctx context.Context, t *tenant.Tenant) error {
+	query := `
+		UPDATE tenants
+		SET name = $2, tagline = $3, logo_url = $4, accent_color = $5, default_author_did = $6
+		WHERE id = $1
+	`
+
+	result, err := q.db.ExecContext(ctx, query, t.ID, t.Name, t.Tagline, t.LogoURL, t.AccentColor, t.DefaultAuthorDID)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetTenantBySlug implements the tenant.Store interface
+// Returns sql.ErrNoRows if there is no such tenant
+func (q *Queries) GetTenantBySlug(ctx context.Context, slug string) (*tenant.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants t WHERE t.slug = $1`
+
+	t, err := scanTenant(q.db.QueryRowContext(ctx, query, slug))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return t, nil
+}
+
+// GetTenantByHostname implements the tenant.Store interface
+// Returns sql.ErrNoRows if no tenant has the hostname
+func (q *Queries) GetTenantByHostname(ctx context.Context, hostname string) (*tenant.Tenant, error) {
+	query := `
+		SELECT ` + tenantColumns + `
+		FROM tenants t
+		JOIN domains d ON d.tenant_id = t.id
+		WHERE d.hostname = $1
+	`
+
+	t, err := scanTenant(q.db.QueryRowContext(ctx, query, hostname))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return t, nil
+}
+
+// ListTenants implements the tenant.Store interface
+func (q *Queries) ListTenants(ctx context.Context) ([]*tenant.Tenant, error) {
+	query := `SELECT ` + tenantColumns + ` FROM tenants t ORDER BY t.name, t.slug`
+
+	rows, err := q.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []*tenant.Tenant
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenants: %w", err)
+	}
+
+	return tenants, nil
+}
+
+// ListDomains implements the tenant.Store interface
+func (q *Queries) ListDomains(ctx context.Context, tenantID uuid.UUID) ([]*tenant.Domain, error) {
+	query := `
+		SELECT hostname, tenant_id, created_at
+		FROM domains
+		WHERE tenant_id = $1
+		ORDER BY hostname
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []*tenant.Domain
+	for rows.Next() {
+		d := &tenant.Domain{}
+		if err := rows.Scan(&d.Hostname, &d.TenantID, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan domain: %w", err)
+		}
+		domains = append(domains, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating domains: %w", err)
+	}
+
+	return domains, nil
+}
+
+// AddDomain implements the tenant.Store interface
+// Returns tenant.ErrDomainTaken if a tenant has the hostname
+func (q *Queries) AddDomain(ctx context.Context, d *tenant.Domain) error {
+	query := `
+		INSERT INTO domains (hostname, tenant_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (hostname) DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, d.Hostname, d.TenantID, d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert domain: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return tenant.ErrDomainTaken
+	}
+
+	return nil
+}
+
+// RemoveDomain implements the tenant.Store interface
+// Returns sql.ErrNoRows if the tenant does not have the hostname
+func (q *Queries) RemoveDomain(ctx context.Context, tenantID uuid.UUID, hostname string) error {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM domains WHERE tenant_id = $1 AND hostname = $2`, tenantID, hostname)
+	if err != nil {
+		return fmt.Errorf("failed to delete domain: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// CrossPostSurvey implements the tenant.Store interface
+func (q *Queries) CrossPostSurvey(ctx context.Context, tenantID, surveyID uuid.UUID, by string) error {
+	query := `
+		INSERT INTO tenant_surveys (tenant_id, survey_id, added_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_id, survey_id) DO NOTHING
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, tenantID, surveyID, by); err != nil {
+		return fmt.Errorf("failed to cross-post survey: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveCrossPost implements the tenant.Store interface
+// Returns sql.ErrNoRows if the survey is not cross-posted to the tenant
+func (q *Queries) RemoveCrossPost(ctx context.Context, tenantID, surveyID uuid.UUID) error {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM tenant_surveys WHERE tenant_id = $1 AND survey_id = $2`, tenantID, surveyID)
+	if err != nil {
+		return fmt.Errorf("failed to remove cross-post: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenants(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	acme, err := tenant.New("acme", "Acme Polls")
+	require.NoError(t, err)
+	partner := "did:plc:acme"
+	acme.DefaultAuthorDID = &partner
+	acme.AccentColor = "#112233"
+	require.NoError(t, queries.CreateTenant(ctx, acme))
+
+	dup, err := tenant.New("acme", "Other")
+	require.NoError(t, err)
+	assert.ErrorIs(t, queries.CreateTenant(ctx, dup), tenant.ErrSlugTaken)
+
+	acme.Tagline = "Polls by Acme"
+	require.NoError(t, queries.UpdateTenant(ctx, acme))
+	got, err := queries.GetTenantBySlug(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, "Polls by Acme", got.Tagline)
+	assert.Equal(t, "#112233", got.AccentColor)
+	assert.Equal(t, partner, *got.DefaultAuthorDID)
+	_, err = queries.GetTenantBySlug(ctx, "nobody")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Hostnames belong to one tenant
+	for _, hostname := range []string{"surveys.acme.example", "polls.acme.example"} {
+		require.NoError(t, queries.AddDomain(ctx, &tenant.Domain{Hostname: hostname, TenantID: acme.ID, CreatedAt: time.Now()}))
+	}
+	assert.ErrorIs(t, queries.AddDomain(ctx, &tenant.Domain{Hostname: "polls.acme.example", TenantID: acme.ID, CreatedAt: time.Now()}), tenant.ErrDomainTaken)
+	domains, err := queries.ListDomains(ctx, acme.ID)
+	require.NoError(t, err)
+	require.Len(t, domains, 2)
+	assert.Equal(t, "polls.acme.example", domains[0].Hostname)
+
+	got, err = queries.GetTenantByHostname(ctx, "surveys.acme.example")
+	require.NoError(t, err)
+	assert.Equal(t, acme.ID, got.ID)
+	require.NoError(t, queries.RemoveDomain(ctx, acme.ID, "surveys.acme.example"))
+	assert.ErrorIs(t, queries.RemoveDomain(ctx, acme.ID, "surveys.acme.example"), sql.ErrNoRows)
+	_, err = queries.GetTenantByHostname(ctx, "surveys.acme.example")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	tenants, err := queries.ListTenants(ctx)
+	require.NoError(t, err)
+	require.Len(t, tenants, 1)
+
+	// A tenant lists its default author's surveys and those cross-posted to it
+	other := "did:plc:other"
+	newSurvey := func(slug string, author *string, visibility string) *models.Survey {
+		s := &models.Survey{
+			ID:        uuid.New(),
+			Slug:      slug,
+			Title:     slug,
+			AuthorDID: author,
+			Definition: models.SurveyDefinition{
+				Visibility: visibility,
+				Questions:  []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}},
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		require.NoError(t, queries.CreateSurvey(ctx, s))
+		return s
+	}
+	own := newSurvey("acme-own", &partner, "")
+	crossPosted := newSurvey("cross-posted", &other, "")
+	newSurvey("elsewhere", &other, "")
+	unlisted := newSurvey("unlisted", &other, models.VisibilityUnlisted)
+
+	require.NoError(t, queries.CrossPostSurvey(ctx, acme.ID, crossPosted.ID, partner))
+	require.NoError(t, queries.CrossPostSurvey(ctx, acme.ID, crossPosted.ID, partner), "cross-posting again does nothing")
+	require.NoError(t, queries.CrossPostSurvey(ctx, acme.ID, unlisted.ID, partner))
+
+	listed, err := queries.ListTenantSurveys(ctx, acme.ID, 10, 0)
+	require.NoError(t, err)
+	var slugs []string
+	for _, s := range listed {
+		slugs = append(slugs, s.Slug)
+	}
+	assert.ElementsMatch(t, []string{own.Slug, crossPosted.Slug}, slugs, "unlisted surveys stay unlisted")
+
+	all, err := queries.ListSurveys(ctx, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 3, "ListSurveys is not scoped to a tenant")
+
+	require.NoError(t, queries.RemoveCrossPost(ctx, acme.ID, crossPosted.ID))
+	assert.ErrorIs(t, queries.RemoveCrossPost(ctx, acme.ID, crossPosted.ID), sql.ErrNoRows)
+	listed, err = queries.ListTenantSurveys(ctx, acme.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, own.ID, listed[0].ID)
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// GetSurveyTombstoneBySlug retrieves the tombstone of a deleted survey by its
+// slug, returning sql.ErrNoRows if no survey with the slug was deleted
+func (q *Queries) GetSurveyTombstoneBySlug(ctx context.Context, slug string) (*models.SurveyTombstone, error) {
+	return q.getSurveyTombstone(ctx, "slug", slug)
+}
+
+// GetSurveyTombstoneByURI retrieves the tombstone of a deleted survey by its
+// ATProto URI, returning sql.ErrNoRows if no survey with the URI was deleted
+func (q *Queries) GetSurveyTombstoneByURI(ctx context.Context, uri string) (*models.SurveyTombstone, error) {
+	return q.getSurveyTombstone(ctx, "uri", uri)
+}
+
+// getSurveyTombstone retrieves a tombstone by slug or uri (column)
+func (q *Queries) getSurveyTombstone(ctx context.Context, column, value string) (*models.SurveyTombstone, error) {
+	query := `
+		SELECT slug, uri, author_did, deleted_at
+		FROM survey_tombstones
+		WHERE ` + column + ` = $1
+	`
+
+	t := &models.SurveyTombstone{}
+	err := q.db.QueryRowContext(ctx, query, value).Scan(&t.Slug, &t.URI, &t.AuthorDID, &t.DeletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get survey tombstone: %w", err)
+	}
+
+	return t, nil
+}
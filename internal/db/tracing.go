@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.28.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer traces the queries pgx sends to Postgres
+var tracer = otel.Tracer("github.com/openmeet-team/survey/internal/db")
+
+// queryTracer starts a client span for every query and COPY of a connection
+type queryTracer struct {
+	attrs []attribute.KeyValue
+}
+
+var (
+	_ pgx.QueryTracer    = (*queryTracer)(nil)
+	_ pgx.CopyFromTracer = (*queryTracer)(nil)
+)
+
+// newQueryTracer traces the queries of connections to a database in a role
+// (primary or replica)
+func newQueryTracer(database, role string) *queryTracer {
+	return &queryTracer{attrs: []attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		attribute.String("db.name", database),
+		attribute.String("db.role", role),
+	}}
+}
+
+// TraceQueryStart starts the span of a query. Arguments are not recorded, as
+// they hold answers and DIDs.
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = tracer.Start(ctx, "postgres "+operation(data.SQL), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(t.attrs...), trace.WithAttributes(attribute.String("db.statement", data.SQL)))
+	return ctx
+}
+
+// TraceQueryEnd ends the span of a query
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.CommandTag.RowsAffected(), data.Err)
+}
+
+// TraceCopyFromStart starts the span of a COPY into a table
+func (t *queryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	ctx, _ = tracer.Start(ctx, "postgres COPY", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(t.attrs...), trace.WithAttributes(attribute.String("db.sql.table", data.TableName.Sanitize())))
+	return ctx
+}
+
+// TraceCopyFromEnd ends the span of a COPY
+func (t *queryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.CommandTag.RowsAffected(), data.Err)
+}
+
+// endSpan ends the span of a query, marking it failed if err is not nil
+func endSpan(span trace.Span, rows int64, err error) {
+	span.SetAttributes(attribute.Int64("db.rows_affected", rows))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// operation returns the first keyword of a query (SELECT, INSERT, WITH, ...)
+// for its span name
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
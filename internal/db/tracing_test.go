@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestQueryTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	qt := newQueryTracer("survey", "replica")
+	ctx := context.Background()
+
+	queryCtx := qt.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "\n\t\tselect id FROM surveys WHERE slug = $1", Args: []any{"lunch"}})
+	qt.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	copyCtx := qt.TraceCopyFromStart(ctx, nil, pgx.TraceCopyFromStartData{TableName: pgx.Identifier{"responses"}, ColumnNames: responseColumns})
+	qt.TraceCopyFromEnd(copyCtx, nil, pgx.TraceCopyFromEndData{Err: errors.New("duplicate key")})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	query := spans[0]
+	assert.Equal(t, "postgres SELECT", query.Name)
+	assert.Equal(t, trace.SpanKindClient, query.SpanKind)
+	assert.Contains(t, query.Attributes, attribute.String("db.system", "postgresql"))
+	assert.Contains(t, query.Attributes, attribute.String("db.role", "replica"))
+	assert.Contains(t, query.Attributes, attribute.Int64("db.rows_affected", 1))
+	for _, attr := range query.Attributes {
+		assert.NotEqual(t, "lunch", attr.Value.Emit(), "query arguments are not recorded")
+	}
+	assert.Equal(t, codes.Unset, query.Status.Code)
+
+	copySpan := spans[1]
+	assert.Equal(t, "postgres COPY", copySpan.Name)
+	assert.Contains(t, copySpan.Attributes, attribute.String("db.sql.table", `"responses"`))
+	assert.Equal(t, codes.Error, copySpan.Status.Code)
+}
+
+func TestOperation(t *testing.T) {
+	assert.Equal(t, "INSERT", operation("  insert INTO responses VALUES ($1)"))
+	assert.Equal(t, "WITH", operation("WITH recent AS (SELECT 1) SELECT * FROM recent"))
+	assert.Equal(t, "QUERY", operation(""))
+}
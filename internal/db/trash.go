@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// DeleteSurvey implements the trash.Store interface
+// Moves a survey to the trash, leaving a tombstone like DeleteSurveyByURI
+func (q *Queries) DeleteSurvey(ctx context.Context, id uuid.UUID) error {
+	if q.dialect == dialectSQLite {
+		return q.deleteSurveySQLite(ctx, "id", id)
+	}
+
+	query := `
+		WITH deleted AS (
+			UPDATE surveys SET deleted_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING slug, uri, author_did
+		)
+		INSERT INTO survey_tombstones (slug, uri, author_did)
+		SELECT slug, uri, author_did FROM deleted
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to delete survey: %w", err)
+	}
+	return nil
+}
+
+// deleteSurveySQLite moves the survey with a column value to the trash on
+// SQLite, which has no data-modifying CTEs: the tombstone is left first
+func (q *Queries) deleteSurveySQLite(ctx context.Context, column string, value any) error {
+	return q.InTx(ctx, func(tx *Queries) error {
+		query := `
+			INSERT INTO survey_tombstones (slug, uri, author_did)
+			SELECT slug, uri, author_did FROM surveys WHERE ` + column + ` = $1 AND deleted_at IS NULL
+			ON CONFLICT DO NOTHING
+		`
+		if _, err := tx.db.ExecContext(ctx, query, value); err != nil {
+			return fmt.Errorf("failed to delete survey: %w", err)
+		}
+
+		query = `UPDATE surveys SET deleted_at = NOW() WHERE ` + column + ` = $1 AND deleted_at IS NULL`
+		if _, err := tx.db.ExecContext(ctx, query, value); err != nil {
+			return fmt.Errorf("failed to delete survey: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListDeletedSurveys implements the trash.Store interface
+// Returns an author's surveys in the trash, most recently deleted first
+func (q *Queries) ListDeletedSurveys(ctx context.Context, authorDID string) ([]*models.Survey, error) {
+	query := `
+		SELECT id, uri, cid, author_did, slug, title, description, definition, version, starts_at, ends_at, results_uri, results_cid, created_at, updated_at, hidden_at, org_id, deleted_at
+		FROM surveys
+		WHERE author_did = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, authorDID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted surveys: %w", err)
+	}
+	defer rows.Close()
+
+	var surveys []*models.Survey
+	for rows.Next() {
+		survey := &models.Survey{}
+		var defJSON []byte
+
+		err := rows.Scan(
+			&survey.ID,
+			&survey.URI,
+			&survey.CID,
+			&survey.AuthorDID,
+			&survey.Slug,
+			&survey.Title,
+			&survey.Description,
+			&defJSON,
+			&survey.Version,
+			&survey.StartsAt,
+			&survey.EndsAt,
+			&survey.ResultsURI,
+			&survey.ResultsCID,
+			&survey.CreatedAt,
+			&survey.UpdatedAt,
+			&survey.HiddenAt,
+			&survey.OrgID,
+			&survey.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan survey: %w", err)
+		}
+
+		if err := json.Unmarshal(defJSON, &survey.Definition); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal survey definition: %w", err)
+		}
+
+		surveys = append(surveys, survey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted surveys: %w", err)
+	}
+
+	return surveys, nil
+}
+
+// RestoreSurvey implements the trash.Store interface
+// Takes a survey out of the trash and removes its tombstone
+func (q *Queries) RestoreSurvey(ctx context.Context, id uuid.UUID, cid *string) error {
+	return q.InTx(ctx, func(tx *Queries) error {
+		var slug string
+		err := tx.db.QueryRowContext(ctx, `
+			UPDATE surveys SET deleted_at = NULL, cid = COALESCE($2, cid), updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NOT NULL
+			RETURNING slug
+		`, id, cid).Scan(&slug)
+		if err != nil {
+			return fmt.Errorf("failed to restore survey: %w", err)
+		}
+
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM survey_tombstones WHERE slug = $1`, slug); err != nil {
+			return fmt.Errorf("failed to delete survey tombstone: %w", err)
+		}
+		return nil
+	})
+}
+
+// PurgeDeletedSurveys implements the trash.Store interface
+// Deletes surveys moved to the trash before a time; their responses, versions,
+// and other rows cascade, and their tombstones stay
+func (q *Queries) PurgeDeletedSurveys(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM surveys WHERE deleted_at < $1`
+
+	result, err := q.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted surveys: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,88 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSurveyTrash(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	author := "did:plc:author"
+	uri := "at://did:plc:author/net.openmeet.survey/abc"
+	create := func(slug string, uri *string) *models.Survey {
+		survey := &models.Survey{
+			ID:        uuid.New(),
+			URI:       uri,
+			AuthorDID: &author,
+			Slug:      slug,
+			Title:     slug,
+			Definition: models.SurveyDefinition{
+				Questions: []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}},
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		require.NoError(t, queries.CreateSurvey(ctx, survey))
+		return survey
+	}
+	local := create("local", nil)
+	create("published", &uri)
+	create("kept", nil)
+
+	require.NoError(t, queries.DeleteSurvey(ctx, local.ID))
+	require.NoError(t, queries.DeleteSurveyByURI(ctx, uri))
+
+	// Surveys in the trash are gone from lookups and listings, with tombstones
+	_, err := queries.GetSurveyBySlug(ctx, "local")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = queries.GetSurveyByURI(ctx, uri)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	surveys, err := queries.ListSurveysByAuthor(ctx, author)
+	require.NoError(t, err)
+	require.Len(t, surveys, 1)
+	assert.Equal(t, "kept", surveys[0].Slug)
+	_, err = queries.GetSurveyTombstoneBySlug(ctx, "local")
+	assert.NoError(t, err)
+	exists, err := queries.SlugExists(ctx, "local")
+	require.NoError(t, err)
+	assert.True(t, exists, "slugs of surveys in the trash are not reused")
+
+	trashed, err := queries.ListDeletedSurveys(ctx, author)
+	require.NoError(t, err)
+	require.Len(t, trashed, 2)
+	assert.Equal(t, "published", trashed[0].Slug, "most recently deleted first")
+	assert.NotNil(t, trashed[0].DeletedAt)
+
+	cid := "bafyrestored"
+	require.NoError(t, queries.RestoreSurvey(ctx, trashed[0].ID, &cid))
+	assert.ErrorIs(t, queries.RestoreSurvey(ctx, trashed[0].ID, nil), sql.ErrNoRows)
+	restored, err := queries.GetSurveyByURI(ctx, uri)
+	require.NoError(t, err)
+	assert.Equal(t, cid, *restored.CID)
+	_, err = queries.GetSurveyTombstoneBySlug(ctx, "published")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	purged, err := queries.PurgeDeletedSurveys(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purged, "surveys are kept until they expire")
+	purged, err = queries.PurgeDeletedSurveys(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	trashed, err = queries.ListDeletedSurveys(ctx, author)
+	require.NoError(t, err)
+	assert.Empty(t, trashed)
+	_, err = queries.GetSurveyTombstoneBySlug(ctx, "local")
+	assert.NoError(t, err, "purged surveys keep their tombstone")
+}
@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/trending"
+)
+
+// listedSurvey is the condition of surveys (as s) shown in listings: public,
+// not hidden by moderation, and not in the trash
+const listedSurvey = `s.hidden_at IS NULL AND s.deleted_at IS NULL
+			AND COALESCE(s.definition->>'visibility', '') IN ('', 'public')`
+
+// RefreshTrendingSurveys implements the trending.Store interface
+// Recomputes the trending_surveys materialized view without blocking readers
+func (q *Queries) RefreshTrendingSurveys(ctx context.Context) error {
+	if q.dialect == dialectSQLite {
+		return q.refreshTrendingSurveysSQLite(ctx)
+	}
+
+	if _, err := q.db.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY trending_surveys`); err != nil {
+		return fmt.Errorf("failed to refresh trending surveys: %w", err)
+	}
+	return nil
+}
+
+// refreshTrendingSurveysSQLite recomputes the trending_surveys table, which
+// stands in for the materialized view on SQLite, scoring as the view does
+func (q *Queries) refreshTrendingSurveysSQLite(ctx context.Context) error {
+	return q.InTx(ctx, func(tx *Queries) error {
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM trending_surveys`); err != nil {
+			return fmt.Errorf("failed to refresh trending surveys: %w", err)
+		}
+
+		now := time.Now()
+		query := `
+			INSERT INTO trending_surveys (survey_id, responses, score)
+			SELECT
+				survey_id,
+				COUNT(*),
+				SUM(power(0.5, max((julianday($1) - julianday(created_at)) * 86400, 0) / 21600.0))
+			FROM responses
+			WHERE created_at > $2
+			GROUP BY survey_id
+		`
+		if _, err := tx.db.ExecContext(ctx, query, now, now.Add(-24*time.Hour)); err != nil {
+			return fmt.Errorf("failed to refresh trending surveys: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetTrendingSurveys implements the trending.Store interface
+// Returns the open, listed surveys with the highest scores, leaving out
+// featured surveys
+func (q *Queries) GetTrendingSurveys(ctx context.Context, limit int) ([]*trending.Entry, error) {
+	if q.replicas != nil {
+		return readFromReplica(ctx, q, func(r *Queries) ([]*trending.Entry, error) { return r.GetTrendingSurveys(ctx, limit) })
+	}
+
+	query := `
+		SELECT s.id, s.uri, s.cid, s.author_did, s.slug, s.title, s.description, s.definition, s.version, s.starts_at, s.ends_at, s.results_uri, s.results_cid, s.created_at, s.updated_at, s.hidden_at, s.org_id,
+			t.responses, t.score
+		FROM trending_surveys t
+		JOIN surveys s ON s.id = t.survey_id
+		WHERE ` + listedSurvey + `
+			AND (s.ends_at IS NULL OR s.ends_at > NOW())
+			AND NOT EXISTS (SELECT 1 FROM featured_surveys f WHERE f.survey_id = s.id)
+		ORDER BY t.score DESC, s.id
+		LIMIT $1
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trending surveys: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*trending.Entry
+	for rows.Next() {
+		entry := &trending.Entry{Survey: &models.Survey{}}
+		survey := entry.Survey
+		var defJSON []byte
+
+		err := rows.Scan(
+			&survey.ID,
+			&survey.URI,
+			&survey.CID,
+			&survey.AuthorDID,
+			&survey.Slug,
+			&survey.Title,
+			&survey.Description,
+			&defJSON,
+			&survey.Version,
+			&survey.StartsAt,
+			&survey.EndsAt,
+			&survey.ResultsURI,
+			&survey.ResultsCID,
+			&survey.CreatedAt,
+			&survey.UpdatedAt,
+			&survey.HiddenAt,
+			&survey.OrgID,
+			&entry.Responses,
+			&entry.Score,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trending survey: %w", err)
+		}
+
+		if err := json.Unmarshal(defJSON, &survey.Definition); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal survey definition: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trending surveys: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ListFeaturedSurveys implements the trending.Store interface
+// Returns the featured surveys not in the trash, most recently featured first
+func (q *Queries) ListFeaturedSurveys(ctx context.Context) ([]*models.Survey, error) {
+	if q.replicas != nil {
+		return readFromReplica(ctx, q, func(r *Queries) ([]*models.Survey, error) { return r.ListFeaturedSurveys(ctx) })
+	}
+
+	query := `
+		SELECT s.id, s.uri, s.cid, s.author_did, s.slug, s.title, s.description, s.definition, s.version, s.starts_at, s.ends_at, s.results_uri, s.results_cid, s.created_at, s.updated_at, s.hidden_at, s.org_id
+		FROM featured_surveys f
+		JOIN surveys s ON s.id = f.survey_id
+		WHERE s.deleted_at IS NULL
+		ORDER BY f.created_at DESC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query featured surveys: %w", err)
+	}
+	defer rows.Close()
+
+	var surveys []*models.Survey
+	for rows.Next() {
+		survey := &models.Survey{}
+		var defJSON []byte
+
+		err := rows.Scan(
+			&survey.ID,
+			&survey.URI,
+			&survey.CID,
+			&survey.AuthorDID,
+			&survey.Slug,
+			&survey.Title,
+			&survey.Description,
+			&defJSON,
+			&survey.Version,
+			&survey.StartsAt,
+			&survey.EndsAt,
+			&survey.ResultsURI,
+			&survey.ResultsCID,
+			&survey.CreatedAt,
+			&survey.UpdatedAt,
+			&survey.HiddenAt,
+			&survey.OrgID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan featured survey: %w", err)
+		}
+
+		if err := json.Unmarshal(defJSON, &survey.Definition); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal survey definition: %w", err)
+		}
+
+		surveys = append(surveys, survey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating featured surveys: %w", err)
+	}
+
+	return surveys, nil
+}
+
+// FeatureSurvey implements the trending.Store interface
+// Features a survey unless trending.MaxFeatured surveys outside the trash
+// already are
+func (q *Queries) FeatureSurvey(ctx context.Context, surveyID uuid.UUID, by string) error {
+	query := `
+		INSERT INTO featured_surveys (survey_id, featured_by)
+		SELECT $1, $2
+		WHERE (SELECT COUNT(*) FROM featured_surveys f JOIN surveys s ON s.id = f.survey_id WHERE s.deleted_at IS NULL) < $3
+		ON CONFLICT (survey_id) DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, surveyID, by, trending.MaxFeatured)
+	if err != nil {
+		return fmt.Errorf("failed to feature survey: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	// Nothing was inserted: the survey is featured already, or the list is full
+	var featured bool
+	err = q.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM featured_surveys WHERE survey_id = $1)`, surveyID).Scan(&featured)
+	if err != nil {
+		return fmt.Errorf("failed to check featured survey: %w", err)
+	}
+	if !featured {
+		return trending.ErrTooManyFeatured
+	}
+	return nil
+}
+
+// UnfeatureSurvey implements the trending.Store interface
+// Returns sql.ErrNoRows if the survey is not featured
+func (q *Queries) UnfeatureSurvey(ctx context.Context, surveyID uuid.UUID) error {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM featured_surveys WHERE survey_id = $1`, surveyID)
+	if err != nil {
+		return fmt.Errorf("failed to unfeature survey: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
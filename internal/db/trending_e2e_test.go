@@ -0,0 +1,88 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/trending"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrendingSurveys(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	newSurvey := func(slug string, visibility string, ages ...time.Duration) *models.Survey {
+		survey := &models.Survey{
+			ID:    uuid.New(),
+			Slug:  slug,
+			Title: slug,
+			Definition: models.SurveyDefinition{
+				Visibility: visibility,
+				Questions:  []models.Question{{ID: "q1", Text: "Why?", Type: models.QuestionTypeText}},
+			},
+			CreatedAt: now.Add(-48 * time.Hour),
+			UpdatedAt: now.Add(-48 * time.Hour),
+		}
+		require.NoError(t, queries.CreateSurvey(ctx, survey))
+		for i, age := range ages {
+			session := fmt.Sprintf("%s-%d", slug, i)
+			require.NoError(t, queries.CreateResponse(ctx, &models.Response{
+				ID:           uuid.New(),
+				SurveyID:     survey.ID,
+				VoterSession: &session,
+				Answers:      map[string]models.Answer{"q1": {Text: "Because"}},
+				CreatedAt:    now.Add(-age),
+			}))
+		}
+		return survey
+	}
+
+	newSurvey("fresh", "", time.Minute, 2*time.Minute)
+	newSurvey("older", "", 20*time.Hour, 21*time.Hour, 22*time.Hour)
+	newSurvey("stale", "", 30*time.Hour, 31*time.Hour)
+	newSurvey("unlisted", models.VisibilityUnlisted, time.Minute, time.Minute, time.Minute)
+	picked := newSurvey("picked", "", time.Minute, time.Minute, time.Minute, time.Minute)
+
+	slugs := func() []string {
+		entries, err := queries.GetTrendingSurveys(ctx, trending.Limit)
+		require.NoError(t, err)
+		var slugs []string
+		for _, entry := range entries {
+			slugs = append(slugs, entry.Survey.Slug)
+		}
+		return slugs
+	}
+
+	assert.Empty(t, slugs(), "scores are computed on refresh")
+	require.NoError(t, queries.RefreshTrendingSurveys(ctx))
+	assert.Equal(t, []string{"picked", "fresh", "older"}, slugs(), "recent responses count most, and old ones not at all")
+
+	require.NoError(t, queries.FeatureSurvey(ctx, picked.ID, "did:plc:admin"))
+	require.NoError(t, queries.FeatureSurvey(ctx, picked.ID, "did:plc:admin"), "featuring twice does nothing")
+	assert.Equal(t, []string{"fresh", "older"}, slugs(), "featured surveys are not trending")
+
+	featured, err := queries.ListFeaturedSurveys(ctx)
+	require.NoError(t, err)
+	require.Len(t, featured, 1)
+	assert.Equal(t, picked.ID, featured[0].ID)
+
+	for i := 1; i < trending.MaxFeatured; i++ {
+		require.NoError(t, queries.FeatureSurvey(ctx, newSurvey(fmt.Sprintf("extra-%d", i), "").ID, "did:plc:admin"))
+	}
+	err = queries.FeatureSurvey(ctx, newSurvey("one-more", "").ID, "did:plc:admin")
+	assert.ErrorIs(t, err, trending.ErrTooManyFeatured)
+
+	require.NoError(t, queries.UnfeatureSurvey(ctx, picked.ID))
+	assert.ErrorIs(t, queries.UnfeatureSurvey(ctx, picked.ID), sql.ErrNoRows)
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/openmeet-team/survey/internal/vanity"
+)
+
+// ListReservedSlugs implements the vanity.Store interface
+// Returns the reservations sorted by pattern
+func (q *Queries) ListReservedSlugs(ctx context.Context) ([]*vanity.Reservation, error) {
+	query := `SELECT pattern, reason, created_by, created_at FROM reserved_slugs ORDER BY pattern`
+
+	rows, err := q.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reserved slugs: %w", err)
+	}
+	defer rows.Close()
+
+	var reservations []*vanity.Reservation
+	for rows.Next() {
+		r := &vanity.Reservation{}
+		if err := rows.Scan(&r.Pattern, &r.Reason, &r.CreatedBy, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reserved slug: %w", err)
+		}
+		reservations = append(reservations, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reserved slugs: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// ReserveSlug implements the vanity.Store interface
+// Returns vanity.ErrAlreadyReserved if the pattern is reserved
+func (q *Queries) ReserveSlug(ctx context.Context, r *vanity.Reservation) error {
+	query := `
+		INSERT INTO reserved_slugs (pattern, reason, created_by, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (pattern) DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, r.Pattern, r.Reason, r.CreatedBy, r.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to reserve slug: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return vanity.ErrAlreadyReserved
+	}
+
+	return nil
+}
+
+// UnreserveSlug implements the vanity.Store interface
+// Returns sql.ErrNoRows if the pattern is not reserved
+func (q *Queries) UnreserveSlug(ctx context.Context, pattern string) error {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM reserved_slugs WHERE pattern = $1`, pattern)
+	if err != nil {
+		return fmt.Errorf("failed to unreserve slug: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
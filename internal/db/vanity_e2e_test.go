@@ -0,0 +1,43 @@
+//go:build e2e
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/openmeet-team/survey/internal/vanity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservedSlugs(t *testing.T) {
+	database := startMigratedDB(t)
+	queries := NewQueries(database)
+	ctx := context.Background()
+
+	for _, pattern := range []string{"official-*", "news"} {
+		r, err := vanity.New(pattern, "Kept for the team", "did:plc:admin")
+		require.NoError(t, err)
+		require.NoError(t, queries.ReserveSlug(ctx, r))
+	}
+	again, err := vanity.New("news", "", "did:plc:admin")
+	require.NoError(t, err)
+	assert.ErrorIs(t, queries.ReserveSlug(ctx, again), vanity.ErrAlreadyReserved)
+
+	list, err := queries.ListReservedSlugs(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "news", list[0].Pattern, "sorted by pattern")
+	assert.Equal(t, "Kept for the team", list[0].Reason)
+	assert.Equal(t, "did:plc:admin", list[0].CreatedBy)
+	assert.False(t, list[0].CreatedAt.IsZero())
+
+	require.NoError(t, queries.UnreserveSlug(ctx, "news"))
+	assert.ErrorIs(t, queries.UnreserveSlug(ctx, "news"), sql.ErrNoRows)
+	list, err = queries.ListReservedSlugs(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "official-*", list[0].Pattern)
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// insertSurveyVersion stores the definition of a survey version
+func (q *Queries) insertSurveyVersion(ctx context.Context, surveyID uuid.UUID, version int, cid *string, defJSON []byte) error {
+	query := `
+		INSERT INTO survey_versions (survey_id, version, cid, definition)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (survey_id, version) DO UPDATE SET cid = EXCLUDED.cid, definition = EXCLUDED.definition
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, surveyID, version, cid, defJSON); err != nil {
+		return fmt.Errorf("failed to insert survey version: %w", err)
+	}
+
+	return nil
+}
+
+// GetSurveyVersionByCID returns the newest version of a survey defined by a
+// survey record CID, or nil if no version has that CID
+func (q *Queries) GetSurveyVersionByCID(ctx context.Context, surveyID uuid.UUID, cid string) (*models.SurveyVersion, error) {
+	query := `
+		SELECT survey_id, version, cid, definition, created_at
+		FROM survey_versions
+		WHERE survey_id = $1 AND cid = $2
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	v, err := scanSurveyVersion(q.db.QueryRowContext(ctx, query, surveyID, cid))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // No version with this CID is not an error
+		}
+		return nil, fmt.Errorf("failed to query survey version: %w", err)
+	}
+	return v, nil
+}
+
+// ListSurveyVersions returns the stored definitions of a survey, oldest first
+func (q *Queries) ListSurveyVersions(ctx context.Context, surveyID uuid.UUID) ([]*models.SurveyVersion, error) {
+	query := `
+		SELECT survey_id, version, cid, definition, created_at
+		FROM survey_versions
+		WHERE survey_id = $1
+		ORDER BY version ASC
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list survey versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*models.SurveyVersion
+	for rows.Next() {
+		v, err := scanSurveyVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan survey version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating survey versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// scanSurveyVersion scans a survey_versions row and decodes its definition
+func scanSurveyVersion(row rowScanner) (*models.SurveyVersion, error) {
+	v := &models.SurveyVersion{}
+	var defJSON []byte
+	if err := row.Scan(&v.SurveyID, &v.Version, &v.CID, &defJSON, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(defJSON, &v.Definition); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal survey version definition: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+func TestLabelRemovedOptions(t *testing.T) {
+	question := func(options ...models.Option) models.SurveyDefinition {
+		return models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Type: models.QuestionTypeSingle, Options: options}}}
+	}
+	versions := []*models.SurveyVersion{
+		{Version: 1, Definition: question(models.Option{ID: "a", Text: "Apple"}, models.Option{ID: "b", Text: "Banana"})},
+		{Version: 2, Definition: question(models.Option{ID: "a", Text: "Apple"}, models.Option{ID: "b", Text: "Blueberry"})},
+		{Version: 3, Definition: question(models.Option{ID: "a", Text: "Apple"})},
+	}
+	survey := &models.Survey{Version: 3, Definition: versions[2].Definition}
+	results := &models.SurveyResults{
+		QuestionResults: map[string]*models.QuestionResult{
+			"q1": {QuestionID: "q1", OptionCounts: map[string]int{"a": 2, "b": 1, "ghost": 1}},
+		},
+	}
+
+	labelRemovedOptions(results, survey, versions)
+
+	removed := results.QuestionResults["q1"].RemovedOptions
+	if len(removed) != 1 {
+		t.Fatalf("RemovedOptions = %v, want only option b", removed)
+	}
+	if removed["b"] != "Blueberry" {
+		t.Errorf("RemovedOptions[b] = %q, want the text of the newest version that had it", removed["b"])
+	}
+}
@@ -0,0 +1,217 @@
+// Package dedup detects guests voting more than once. The voter session hash
+// of IP and user agent changes when a voter switches networks, so guest votes
+// also carry two stronger signals: a signed cookie identifying the browser,
+// which blocks a second vote from it, and optionally a fingerprint of request
+// headers, which only flags responses as suspected duplicates since unrelated
+// voters can share it. Authors review the suspected duplicates of a survey
+// and can exclude responses from its results.
+package dedup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CookieName is the cookie holding a voter's signed ID
+const CookieName = "survey_voter"
+
+// CookieMaxAge is how long the voter cookie is kept
+const CookieMaxAge = 365 * 24 * time.Hour
+
+// voterIDSize is the number of random bytes in a voter ID
+const voterIDSize = 16
+
+// Signals responses can share
+const (
+	SignalCookie      = "cookie"      // The same browser, by its voter cookie
+	SignalFingerprint = "fingerprint" // The same request headers
+)
+
+// fingerprintHeaders are the request headers fingerprints are made of. They
+// describe the browser, not the network, so they survive a network switch.
+var fingerprintHeaders = []string{
+	"User-Agent",
+	"Accept-Language",
+	"Accept-Encoding",
+	"Sec-CH-UA",
+	"Sec-CH-UA-Platform",
+	"Sec-CH-UA-Mobile",
+}
+
+// Config holds the voter cookie signing key and the fingerprint opt-in
+type Config struct {
+	Secret      string
+	Fingerprint bool
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - VOTER_SECRET: key used to sign voter cookies (a random per-process key is used if empty)
+//   - DEDUP_FINGERPRINT: set to "true" to also flag guest votes with the same request headers
+func ConfigFromEnv() Config {
+	return Config{
+		Secret:      os.Getenv("VOTER_SECRET"),
+		Fingerprint: os.Getenv("DEDUP_FINGERPRINT") == "true",
+	}
+}
+
+// Signals are the duplicate signals of a guest response. Both are hashed with
+// the survey ID, so they can't link a voter's responses across surveys.
+type Signals struct {
+	ResponseID  uuid.UUID
+	SurveyID    uuid.UUID
+	VoterToken  string // Hash of the voter cookie's ID; empty without a cookie
+	Fingerprint string // Hash of the request headers; empty unless enabled
+	Excluded    bool   // Excluded from results by the survey author
+	CreatedAt   time.Time
+}
+
+// Store persists the signals of guest responses
+type Store interface {
+	SaveResponseSignals(ctx context.Context, s *Signals) error
+	// HasVoterToken reports whether a response to the survey carries the token
+	HasVoterToken(ctx context.Context, surveyID uuid.UUID, token string) (bool, error)
+	// ListResponseSignals returns the signals of a survey's responses, oldest first
+	ListResponseSignals(ctx context.Context, surveyID uuid.UUID) ([]*Signals, error)
+	// SetResponsesExcluded excludes responses of a survey from its results,
+	// or counts them again, and returns the number of responses changed
+	SetResponsesExcluded(ctx context.Context, surveyID uuid.UUID, responseIDs []uuid.UUID, excluded bool) (int64, error)
+}
+
+// Voters issues and verifies the signed cookies identifying voters' browsers,
+// and fingerprints their requests if enabled
+type Voters struct {
+	secret      []byte
+	fingerprint bool
+}
+
+// NewVoters creates a voter signer. Without a secret it uses a random key, so
+// cookies issued before a restart no longer block second votes.
+func NewVoters(config Config) *Voters {
+	v := &Voters{secret: []byte(config.Secret), fingerprint: config.Fingerprint}
+	if config.Secret == "" {
+		v.secret = make([]byte, 32)
+		if _, err := rand.Read(v.secret); err != nil {
+			panic(fmt.Sprintf("failed to generate voter secret: %v", err))
+		}
+	}
+	return v
+}
+
+// New returns a new voter ID and its signed cookie value
+func (v *Voters) New() (string, string, error) {
+	raw := make([]byte, voterIDSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate voter ID: %w", err)
+	}
+	id := base64.RawURLEncoding.EncodeToString(raw)
+	return id, id + "." + v.sign(id), nil
+}
+
+// Verify returns the voter ID of a cookie value, if its signature is valid
+func (v *Voters) Verify(value string) (string, bool) {
+	id, sig, ok := strings.Cut(value, ".")
+	if !ok || id == "" || !hmac.Equal([]byte(sig), []byte(v.sign(id))) {
+		return "", false
+	}
+	return id, true
+}
+
+// sign returns the signature of a voter ID
+func (v *Voters) sign(id string) string {
+	h := hmac.New(sha256.New, v.secret)
+	h.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Token returns the token a voter's responses to a survey carry
+func Token(surveyID uuid.UUID, voterID string) string {
+	return hash(surveyID, SignalCookie, voterID)
+}
+
+// Fingerprint returns the fingerprint of a request's headers for a survey,
+// or "" if fingerprinting is disabled or the request has no user agent
+func (v *Voters) Fingerprint(surveyID uuid.UUID, header http.Header) string {
+	if !v.fingerprint || header.Get("User-Agent") == "" {
+		return ""
+	}
+	values := make([]string, len(fingerprintHeaders))
+	for i, name := range fingerprintHeaders {
+		values[i] = header.Get(name)
+	}
+	return hash(surveyID, SignalFingerprint, values...)
+}
+
+// hash returns the hex SHA-256 of a survey ID, a signal, and its values
+func hash(surveyID uuid.UUID, signal string, values ...string) string {
+	h := sha256.New()
+	h.Write(surveyID[:])
+	h.Write([]byte(signal))
+	for _, value := range values {
+		h.Write([]byte{0})
+		h.Write([]byte(value))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Suspect is a response in a group of suspected duplicates
+type Suspect struct {
+	ResponseID uuid.UUID `json:"responseId"`
+	Excluded   bool      `json:"excluded"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Group is responses sharing a signal, oldest first
+type Group struct {
+	Signal    string    `json:"signal"` // SignalCookie or SignalFingerprint
+	Responses []Suspect `json:"responses"`
+}
+
+// Groups returns the groups of two or more responses sharing a voter token or
+// a fingerprint, cookie groups first, each ordered by its oldest response.
+// A response can be in both a cookie group and a fingerprint group.
+func Groups(signals []*Signals) []Group {
+	var groups []Group
+	for _, signal := range []string{SignalCookie, SignalFingerprint} {
+		byValue := make(map[string][]Suspect)
+		var values []string
+		for _, s := range signals {
+			value := s.VoterToken
+			if signal == SignalFingerprint {
+				value = s.Fingerprint
+			}
+			if value == "" {
+				continue
+			}
+			if _, ok := byValue[value]; !ok {
+				values = append(values, value)
+			}
+			byValue[value] = append(byValue[value], Suspect{ResponseID: s.ResponseID, Excluded: s.Excluded, CreatedAt: s.CreatedAt})
+		}
+
+		var found []Group
+		for _, value := range values {
+			if suspects := byValue[value]; len(suspects) > 1 {
+				sort.SliceStable(suspects, func(i, j int) bool { return suspects[i].CreatedAt.Before(suspects[j].CreatedAt) })
+				found = append(found, Group{Signal: signal, Responses: suspects})
+			}
+		}
+		sort.SliceStable(found, func(i, j int) bool {
+			return found[i].Responses[0].CreatedAt.Before(found[j].Responses[0].CreatedAt)
+		})
+		groups = append(groups, found...)
+	}
+	return groups
+}
@@ -0,0 +1,80 @@
+package dedup
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoters(t *testing.T) {
+	voters := NewVoters(Config{Secret: "secret"})
+
+	id, value, err := voters.New()
+	require.NoError(t, err)
+	got, ok := voters.Verify(value)
+	assert.True(t, ok)
+	assert.Equal(t, id, got)
+
+	_, ok = NewVoters(Config{Secret: "other"}).Verify(value)
+	assert.False(t, ok, "signed with another key")
+	for _, forged := range []string{"", id, id + ".", "other." + value[len(id)+1:]} {
+		_, ok := voters.Verify(forged)
+		assert.False(t, ok, forged)
+	}
+
+	surveyA, surveyB := uuid.New(), uuid.New()
+	assert.Equal(t, Token(surveyA, id), Token(surveyA, id))
+	assert.NotEqual(t, Token(surveyA, id), Token(surveyB, id), "tokens don't link surveys")
+}
+
+func TestFingerprint(t *testing.T) {
+	surveyID := uuid.New()
+	header := http.Header{}
+	header.Set("User-Agent", "Mozilla/5.0")
+	header.Set("Accept-Language", "en-GB")
+
+	assert.Empty(t, NewVoters(Config{}).Fingerprint(surveyID, header), "disabled by default")
+
+	voters := NewVoters(Config{Fingerprint: true})
+	fingerprint := voters.Fingerprint(surveyID, header)
+	assert.NotEmpty(t, fingerprint)
+	assert.Equal(t, fingerprint, voters.Fingerprint(surveyID, header.Clone()))
+	assert.NotEqual(t, fingerprint, voters.Fingerprint(uuid.New(), header))
+
+	other := header.Clone()
+	other.Set("Accept-Language", "fr-FR")
+	assert.NotEqual(t, fingerprint, voters.Fingerprint(surveyID, other))
+
+	assert.Empty(t, voters.Fingerprint(surveyID, http.Header{}), "no user agent")
+}
+
+func TestGroups(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	signals := func(token, fingerprint string, minute int) *Signals {
+		return &Signals{ResponseID: uuid.New(), VoterToken: token, Fingerprint: fingerprint, CreatedAt: start.Add(time.Duration(minute) * time.Minute)}
+	}
+	a1 := signals("a", "x", 3)
+	a2 := signals("a", "y", 1)
+	b := signals("b", "x", 2)
+	c := signals("c", "z", 0)
+	none := signals("", "", 4)
+
+	groups := Groups([]*Signals{a1, a2, b, c, none})
+	require.Len(t, groups, 2)
+
+	assert.Equal(t, SignalCookie, groups[0].Signal)
+	require.Len(t, groups[0].Responses, 2)
+	assert.Equal(t, a2.ResponseID, groups[0].Responses[0].ResponseID, "oldest first")
+	assert.Equal(t, a1.ResponseID, groups[0].Responses[1].ResponseID)
+
+	assert.Equal(t, SignalFingerprint, groups[1].Signal)
+	require.Len(t, groups[1].Responses, 2)
+	assert.Equal(t, b.ResponseID, groups[1].Responses[0].ResponseID)
+	assert.Equal(t, a1.ResponseID, groups[1].Responses[1].ResponseID)
+
+	assert.Empty(t, Groups([]*Signals{c, none}))
+}
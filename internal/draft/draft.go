@@ -0,0 +1,161 @@
+// Package draft saves the partially-built survey definitions of the create
+// page, and the partial answers of the voting form, so creators and voters can
+// resume after navigating away. A draft belongs to the DID of a logged-in
+// user, or to a guest identified by a signed cookie.
+package draft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Limits
+const (
+	MaxDrafts       = 20                  // Drafts an owner can hold
+	MaxContentBytes = 100 * 1024          // Same as a survey definition
+	TTL             = 30 * 24 * time.Hour // Drafts not saved for this long are deleted
+	maxTitleLength  = 100
+)
+
+// Editor formats of a draft
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// guestPrefix starts the owner of a guest's drafts
+const guestPrefix = "guest:"
+
+// Errors returned by New and Update
+var (
+	ErrTooLarge      = fmt.Errorf("draft exceeds the maximum of %d bytes", MaxContentBytes)
+	ErrInvalidFormat = errors.New("format must be 'json' or 'yaml'")
+)
+
+// Draft is the editor content of a survey being created
+type Draft struct {
+	ID        uuid.UUID `json:"id"`
+	Owner     string    `json:"-"`     // DID, or GuestOwner of the cookie's guest ID
+	Title     string    `json:"title"` // First question of the content, if it parses
+	Slug      string    `json:"slug,omitempty"`
+	Format    string    `json:"format"`  // FormatJSON or FormatYAML
+	Content   string    `json:"content"` // Editor text; need not be a valid definition yet
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Store persists drafts
+type Store interface {
+	CreateDraft(ctx context.Context, d *Draft) error
+	// GetDraft returns sql.ErrNoRows if the owner has no such draft
+	GetDraft(ctx context.Context, id uuid.UUID, owner string) (*Draft, error)
+	// ListDrafts returns an owner's drafts, most recently saved first
+	ListDrafts(ctx context.Context, owner string) ([]*Draft, error)
+	CountDrafts(ctx context.Context, owner string) (int, error)
+	// UpdateDraft saves the content of a draft; it returns sql.ErrNoRows if
+	// the owner has no such draft
+	UpdateDraft(ctx context.Context, d *Draft) error
+	// DeleteDraft returns sql.ErrNoRows if the owner has no such draft
+	DeleteDraft(ctx context.Context, id uuid.UUID, owner string) error
+	// DeleteExpiredDrafts deletes drafts last saved before a time
+	DeleteExpiredDrafts(ctx context.Context, before time.Time) (int64, error)
+}
+
+// GuestOwner returns the owner of a guest's drafts
+func GuestOwner(guestID string) string {
+	return guestPrefix + guestID
+}
+
+// New creates a draft of content in an editor format ("" for JSON)
+func New(owner, format, content, slug string, now time.Time) (*Draft, error) {
+	d := &Draft{
+		ID:        uuid.New(),
+		Owner:     owner,
+		CreatedAt: now,
+	}
+	if err := d.Update(format, content, slug, now); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Update replaces the content of a draft
+func (d *Draft) Update(format, content, slug string, now time.Time) error {
+	if len(content) > MaxContentBytes {
+		return ErrTooLarge
+	}
+	switch format {
+	case "":
+		format = FormatJSON
+	case FormatJSON, FormatYAML:
+	default:
+		return ErrInvalidFormat
+	}
+
+	d.Format = format
+	d.Content = content
+	d.Slug = strings.TrimSpace(slug)
+	d.Title = Title(content)
+	d.UpdatedAt = now
+	return nil
+}
+
+// Title returns the text of the first question of a draft's content, which
+// becomes the survey title, or "" if the content does not parse that far
+func Title(content string) string {
+	// YAML is a superset of JSON, so this reads both editor formats
+	var def struct {
+		Questions []struct {
+			Text string `yaml:"text"`
+		} `yaml:"questions"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &def); err != nil || len(def.Questions) == 0 {
+		return ""
+	}
+
+	title := strings.TrimSpace(def.Questions[0].Text)
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		title = string([]rune(title)[:maxTitleLength-1]) + "…"
+	}
+	return title
+}
+
+// StartCleanupWorker deletes expired survey drafts, and response drafts if
+// responses is not nil, every interval until ctx is cancelled
+func StartCleanupWorker(ctx context.Context, store Store, responses ResponseStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		before := time.Now().Add(-TTL)
+		deleted, err := store.DeleteExpiredDrafts(ctx, before)
+		if err != nil {
+			log.Printf("Error deleting expired drafts: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d expired drafts", deleted)
+		}
+
+		if responses != nil {
+			deleted, err := responses.DeleteExpiredResponseDrafts(ctx, before)
+			if err != nil {
+				log.Printf("Error deleting expired response drafts: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Deleted %d expired response drafts", deleted)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package draft
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	d, err := New("did:plc:abc", "", `{"questions": [{"id": "q1", "text": " Where to? "`, " my-slug ", now)
+	require.NoError(t, err)
+
+	assert.Equal(t, "did:plc:abc", d.Owner)
+	assert.Equal(t, FormatJSON, d.Format)
+	assert.Equal(t, "my-slug", d.Slug)
+	assert.Equal(t, "", d.Title, "unfinished JSON has no title yet")
+	assert.Equal(t, now, d.CreatedAt)
+	assert.Equal(t, now, d.UpdatedAt)
+
+	later := now.Add(time.Minute)
+	require.NoError(t, d.Update(FormatYAML, "questions:\n  - id: q1\n    text: Where to?\n", "", later))
+	assert.Equal(t, FormatYAML, d.Format)
+	assert.Equal(t, "Where to?", d.Title)
+	assert.Equal(t, now, d.CreatedAt)
+	assert.Equal(t, later, d.UpdatedAt)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, err := New("did:plc:abc", "xml", "", "", time.Now())
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	_, err = New("did:plc:abc", "", strings.Repeat("x", MaxContentBytes+1), "", time.Now())
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"json", `{"questions": [{"text": "Lunch?"}, {"text": "Dinner?"}]}`, "Lunch?"},
+		{"yaml", "questions:\n  - text: Lunch?\n", "Lunch?"},
+		{"no questions", `{"anonymous": true}`, ""},
+		{"empty", "", ""},
+		{"invalid", "questions: [", ""},
+		{"long", `{"questions": [{"text": "` + strings.Repeat("é", 150) + `"}]}`, strings.Repeat("é", 99) + "…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Title(tt.content))
+		})
+	}
+}
+
+func TestGuests(t *testing.T) {
+	guests := NewGuests(Config{Secret: "secret"})
+
+	id, value, err := guests.New()
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	got, ok := guests.Verify(value)
+	assert.True(t, ok)
+	assert.Equal(t, id, got)
+
+	_, ok = NewGuests(Config{Secret: "other"}).Verify(value)
+	assert.False(t, ok, "signed with another key")
+
+	for _, forged := range []string{"", id, id + ".", "other." + value[len(id)+1:]} {
+		_, ok := guests.Verify(forged)
+		assert.False(t, ok, forged)
+	}
+
+	assert.Equal(t, "guest:"+id, GuestOwner(id))
+}
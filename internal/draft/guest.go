@@ -0,0 +1,74 @@
+package draft
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CookieName is the cookie holding a guest's signed ID
+const CookieName = "survey_drafts"
+
+// guestIDSize is the number of random bytes in a guest ID
+const guestIDSize = 16
+
+// Config holds the guest cookie signing key
+type Config struct {
+	Secret string
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - DRAFT_SECRET: key used to sign guest draft cookies (a random per-process key is used if empty)
+func ConfigFromEnv() Config {
+	return Config{Secret: os.Getenv("DRAFT_SECRET")}
+}
+
+// Guests issues and verifies the signed cookies identifying guests' drafts
+type Guests struct {
+	secret []byte
+}
+
+// NewGuests creates a guest signer. Without a secret it uses a random key, so
+// guests lose access to their drafts when the process restarts.
+func NewGuests(config Config) *Guests {
+	if config.Secret != "" {
+		return &Guests{secret: []byte(config.Secret)}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to generate draft secret: %v", err))
+	}
+	return &Guests{secret: secret}
+}
+
+// New returns a new guest ID and its signed cookie value
+func (g *Guests) New() (string, string, error) {
+	raw := make([]byte, guestIDSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate guest ID: %w", err)
+	}
+	id := base64.RawURLEncoding.EncodeToString(raw)
+	return id, id + "." + g.sign(id), nil
+}
+
+// Verify returns the guest ID of a cookie value, if its signature is valid
+func (g *Guests) Verify(value string) (string, bool) {
+	id, sig, ok := strings.Cut(value, ".")
+	if !ok || id == "" || !hmac.Equal([]byte(sig), []byte(g.sign(id))) {
+		return "", false
+	}
+	return id, true
+}
+
+// sign returns the signature of a guest ID
+func (g *Guests) sign(id string) string {
+	h := hmac.New(sha256.New, g.secret)
+	h.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
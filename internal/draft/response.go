@@ -0,0 +1,31 @@
+package draft
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// AutosaveInterval is how often the voting form saves its answers
+const AutosaveInterval = 15 * time.Second
+
+// ResponseDraft holds the answers of a voter who has not submitted yet
+type ResponseDraft struct {
+	SurveyID  uuid.UUID
+	Owner     string                   // DID, or GuestOwner of the cookie's guest ID
+	Answers   map[string]models.Answer // Partial; validated only on submission
+	UpdatedAt time.Time
+}
+
+// ResponseStore persists response drafts
+type ResponseStore interface {
+	// SaveResponseDraft creates or replaces the owner's draft for a survey
+	SaveResponseDraft(ctx context.Context, d *ResponseDraft) error
+	// GetResponseDraft returns sql.ErrNoRows if the owner has no draft for the survey
+	GetResponseDraft(ctx context.Context, surveyID uuid.UUID, owner string) (*ResponseDraft, error)
+	DeleteResponseDraft(ctx context.Context, surveyID uuid.UUID, owner string) error
+	// DeleteExpiredResponseDrafts deletes drafts last saved before a time
+	DeleteExpiredResponseDrafts(ctx context.Context, before time.Time) (int64, error)
+}
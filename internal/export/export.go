@@ -0,0 +1,194 @@
+// Package export generates response exports in the background: a request
+// queues an export job, the job writes its file to blob storage, and the
+// requester downloads it through a signed URL, so large exports are not
+// streamed from request handlers.
+package export
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/jobs"
+	"github.com/openmeet-team/survey/internal/storage"
+)
+
+const (
+	// TTL is how long finished exports and their files are kept
+	TTL = 24 * time.Hour
+	// URLExpiry is how long a download URL of an export is valid
+	URLExpiry = 15 * time.Minute
+	// CleanupInterval is how often expired exports are deleted
+	CleanupInterval = time.Hour
+	// JobType is the job type of exports
+	JobType = "export"
+)
+
+// Statuses
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// ValidFormat reports whether responses can be exported in a format
+func ValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatJSON
+}
+
+// ContentType returns the content type of a format's files
+func ContentType(format string) string {
+	if format == FormatJSON {
+		return "application/json"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Export is a queued or finished export of a survey's responses
+type Export struct {
+	ID          uuid.UUID
+	SurveyID    uuid.UUID
+	RequestedBy string // DID of the requester
+	Format      string // FormatCSV or FormatJSON
+	Query       string // URL-encoded response filters
+	Status      string
+	BlobKey     *string // Key of the file, once done
+	Error       *string // Why the export failed
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time // When the export and its file are deleted, once done or failed
+}
+
+// Store persists exports
+type Store interface {
+	// CreateExport queues an export
+	CreateExport(ctx context.Context, export *Export) error
+	// GetExport returns an export, or sql.ErrNoRows if it does not exist
+	GetExport(ctx context.Context, id uuid.UUID) (*Export, error)
+	// StartExport marks an export as running
+	StartExport(ctx context.Context, id uuid.UUID) error
+	// CompleteExport marks an export as done with its file
+	CompleteExport(ctx context.Context, id uuid.UUID, blobKey string, expiresAt time.Time) error
+	// FailExport marks an export as failed
+	FailExport(ctx context.Context, id uuid.UUID, reason string, expiresAt time.Time) error
+	// DeleteExpiredExports deletes the exports that expired before a time,
+	// returning them so their files can be deleted
+	DeleteExpiredExports(ctx context.Context, now time.Time) ([]*Export, error)
+}
+
+// BuildFunc builds the file of an export, returning its file name
+type BuildFunc func(ctx context.Context, export *Export) (filename string, body []byte, err error)
+
+// Key returns the blob key of an export's file. File names that can't be
+// keys are replaced by "responses.<format>".
+func Key(export *Export, filename string) string {
+	key := "exports/" + export.ID.String() + "/" + filename
+	if !storage.ValidKey(key) {
+		key = "exports/" + export.ID.String() + "/responses." + export.Format
+	}
+	return key
+}
+
+// Job is the payload of an export job
+type Job struct {
+	ExportID uuid.UUID `json:"exportId"`
+}
+
+// Run builds an export and stores its file. Errors are returned so the job
+// is retried; on the job's final attempt the export is marked as failed.
+func Run(ctx context.Context, store Store, blobs storage.Store, build BuildFunc, id uuid.UUID, final bool, now time.Time) error {
+	export, err := store.GetExport(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // Expired before it ran
+	}
+	if err != nil {
+		return err
+	}
+	if export.Status == StatusDone || export.Status == StatusFailed {
+		return nil
+	}
+	if err := store.StartExport(ctx, id); err != nil {
+		return err
+	}
+
+	fail := func(reason string, err error) error {
+		if final {
+			if err := store.FailExport(ctx, id, reason, now.Add(TTL)); err != nil {
+				log.Printf("Error marking export %s as failed: %v", id, err)
+			}
+		}
+		return fmt.Errorf("%s: %w", reason, err)
+	}
+
+	filename, body, err := build(ctx, export)
+	if err != nil {
+		return fail("Failed to build the export", err)
+	}
+	key := Key(export, filename)
+	if err := blobs.Put(ctx, key, ContentType(export.Format), body); err != nil {
+		return fail("Failed to store the export", err)
+	}
+	return store.CompleteExport(ctx, id, key, now.Add(TTL))
+}
+
+// Handler returns the handler of export jobs
+func Handler(store Store, blobs storage.Store, build BuildFunc) jobs.Handler {
+	return func(ctx context.Context, job *jobs.Job) error {
+		var payload Job
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return jobs.Permanent(fmt.Errorf("invalid export job: %w", err))
+		}
+		return Run(ctx, store, blobs, build, payload.ExportID, job.FinalAttempt(), time.Now())
+	}
+}
+
+// Cleanup deletes expired exports and their files, returning how many were deleted
+func Cleanup(ctx context.Context, store Store, blobs storage.Store, now time.Time) (int, error) {
+	expired, err := store.DeleteExpiredExports(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, export := range expired {
+		if export.BlobKey == nil {
+			continue
+		}
+		if err := blobs.Delete(ctx, *export.BlobKey); err != nil {
+			log.Printf("Error deleting file of expired export %s: %v", export.ID, err)
+		}
+	}
+	return len(expired), nil
+}
+
+// StartCleanup deletes expired exports and their files every interval until
+// ctx is cancelled
+func StartCleanup(ctx context.Context, store Store, blobs storage.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := Cleanup(ctx, store, blobs, time.Now()); err != nil {
+			log.Printf("Error deleting expired exports: %v", err)
+		} else if n > 0 {
+			log.Printf("Deleted %d expired exports", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/jobs"
+	"github.com/openmeet-team/survey/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore keeps exports in memory
+type memoryStore struct {
+	exports []*Export
+}
+
+func (s *memoryStore) CreateExport(ctx context.Context, export *Export) error {
+	s.exports = append(s.exports, export)
+	return nil
+}
+
+func (s *memoryStore) GetExport(ctx context.Context, id uuid.UUID) (*Export, error) {
+	for _, e := range s.exports {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *memoryStore) StartExport(ctx context.Context, id uuid.UUID) error {
+	e, _ := s.GetExport(ctx, id)
+	now := time.Now()
+	e.Status, e.StartedAt = StatusRunning, &now
+	return nil
+}
+
+func (s *memoryStore) CompleteExport(ctx context.Context, id uuid.UUID, blobKey string, expiresAt time.Time) error {
+	e, _ := s.GetExport(ctx, id)
+	e.Status, e.BlobKey, e.ExpiresAt = StatusDone, &blobKey, &expiresAt
+	return nil
+}
+
+func (s *memoryStore) FailExport(ctx context.Context, id uuid.UUID, reason string, expiresAt time.Time) error {
+	e, _ := s.GetExport(ctx, id)
+	e.Status, e.Error, e.ExpiresAt = StatusFailed, &reason, &expiresAt
+	return nil
+}
+
+func (s *memoryStore) DeleteExpiredExports(ctx context.Context, now time.Time) ([]*Export, error) {
+	var expired, kept []*Export
+	for _, e := range s.exports {
+		if e.ExpiresAt != nil && e.ExpiresAt.Before(now) {
+			expired = append(expired, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	s.exports = kept
+	return expired, nil
+}
+
+func TestKey(t *testing.T) {
+	export := &Export{ID: uuid.MustParse("2d1c0f4e-8a57-4c1e-9a3b-5f1c2a7e9d10"), Format: FormatCSV}
+	assert.Equal(t, "exports/2d1c0f4e-8a57-4c1e-9a3b-5f1c2a7e9d10/poll-responses.csv", Key(export, "poll-responses.csv"))
+	assert.Equal(t, "exports/2d1c0f4e-8a57-4c1e-9a3b-5f1c2a7e9d10/responses.csv", Key(export, "Ünïcode poll.csv"))
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	blobs, err := storage.NewDisk(t.TempDir(), []byte("secret"), "")
+	require.NoError(t, err)
+
+	ok := &Export{ID: uuid.New(), Format: FormatCSV, Status: StatusPending}
+	broken := &Export{ID: uuid.New(), Format: FormatJSON, Status: StatusPending}
+	store := &memoryStore{exports: []*Export{ok, broken}}
+
+	build := func(ctx context.Context, export *Export) (string, []byte, error) {
+		if export == broken {
+			return "", nil, errors.New("boom")
+		}
+		return "poll-responses.csv", []byte("id\n"), nil
+	}
+
+	require.NoError(t, Run(ctx, store, blobs, build, ok.ID, false, now))
+	assert.Equal(t, StatusDone, ok.Status)
+	require.NotNil(t, ok.BlobKey)
+	r, err := blobs.Open(ctx, *ok.BlobKey)
+	require.NoError(t, err)
+	r.Close()
+
+	assert.Error(t, Run(ctx, store, blobs, build, broken.ID, false, now))
+	assert.Equal(t, StatusRunning, broken.Status, "the export stays running while its job is retried")
+
+	assert.Error(t, Run(ctx, store, blobs, build, broken.ID, true, now))
+	assert.Equal(t, StatusFailed, broken.Status, "the export fails with its job's final attempt")
+	assert.NotNil(t, broken.ExpiresAt, "failed exports expire too")
+
+	assert.NoError(t, Run(ctx, store, blobs, build, uuid.New(), false, now), "exports that expired before their job ran are skipped")
+}
+
+func TestHandler(t *testing.T) {
+	blobs, err := storage.NewDisk(t.TempDir(), []byte("secret"), "")
+	require.NoError(t, err)
+	export := &Export{ID: uuid.New(), Format: FormatCSV, Status: StatusPending}
+	store := &memoryStore{exports: []*Export{export}}
+	build := func(ctx context.Context, export *Export) (string, []byte, error) {
+		return "responses.csv", []byte("id\n"), nil
+	}
+	handler := Handler(store, blobs, build)
+
+	err = handler(context.Background(), &jobs.Job{Payload: []byte("nope"), Attempts: 1, MaxAttempts: 5})
+	assert.True(t, jobs.IsPermanent(err), "invalid payloads are not retried")
+
+	require.NoError(t, handler(context.Background(), &jobs.Job{Payload: []byte(`{"exportId":"` + export.ID.String() + `"}`), Attempts: 1, MaxAttempts: 5}))
+	assert.Equal(t, StatusDone, export.Status)
+}
+
+func TestCleanup(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	blobs, err := storage.NewDisk(t.TempDir(), []byte("secret"), "")
+	require.NoError(t, err)
+
+	key := "exports/old/responses.csv"
+	require.NoError(t, blobs.Put(ctx, key, "text/csv", []byte("id\n")))
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	store := &memoryStore{exports: []*Export{
+		{ID: uuid.New(), Status: StatusDone, BlobKey: &key, ExpiresAt: &past},
+		{ID: uuid.New(), Status: StatusFailed, ExpiresAt: &past},
+		{ID: uuid.New(), Status: StatusDone, ExpiresAt: &future},
+		{ID: uuid.New(), Status: StatusPending},
+	}}
+
+	n, err := Cleanup(ctx, store, blobs, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, store.exports, 2)
+	_, err = blobs.Open(ctx, key)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
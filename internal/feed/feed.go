@@ -0,0 +1,129 @@
+// Package feed renders Atom feeds of newly indexed surveys, site-wide and per
+// author, so people can follow survey creators from feed readers. Only
+// listed surveys (public visibility, not hidden or in the trash) are included.
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Limit is how many surveys a feed lists, newest first
+const Limit = 50
+
+// ContentType is the media type of Atom feeds
+const ContentType = "application/atom+xml; charset=utf-8"
+
+// Store lists surveys for feeds
+type Store interface {
+	// ListFeedSurveys returns the newest listed surveys indexed from the
+	// network, of one author or of everyone if authorDID is empty
+	ListFeedSurveys(ctx context.Context, authorDID string, limit int) ([]*models.Survey, error)
+}
+
+// Feed is an Atom feed (RFC 4287)
+type Feed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Links   []Link   `xml:"link"`
+	Author  *Person  `xml:"author,omitempty"`
+	Entries []Entry  `xml:"entry"`
+}
+
+// Entry is a survey in a feed
+type Entry struct {
+	ID        string  `xml:"id"`
+	Title     string  `xml:"title"`
+	Updated   string  `xml:"updated"`
+	Published string  `xml:"published"`
+	Links     []Link  `xml:"link"`
+	Author    *Person `xml:"author,omitempty"`
+	Summary   string  `xml:"summary,omitempty"`
+}
+
+// Link is a link of a feed or entry
+type Link struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// Person is the author of a feed or entry
+type Person struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+// Meta describes a feed: its absolute URL and that of the page it follows
+type Meta struct {
+	Title   string
+	SelfURL string
+	PageURL string
+	Author  *Person // Only set for feeds of one author
+}
+
+// New builds the feed of surveys, newest first. surveyURL returns the
+// absolute URL of a survey page; author returns its author, or nil. A feed
+// without surveys is dated by now.
+func New(meta Meta, surveys []*models.Survey, surveyURL func(*models.Survey) string, author func(*models.Survey) *Person, now time.Time) *Feed {
+	feed := &Feed{
+		ID:    meta.SelfURL,
+		Title: meta.Title,
+		Links: []Link{
+			{Href: meta.SelfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: meta.PageURL, Rel: "alternate", Type: "text/html"},
+		},
+		Author:  meta.Author,
+		Entries: make([]Entry, 0, len(surveys)),
+	}
+
+	updated := now
+	if len(surveys) > 0 {
+		updated = surveys[0].UpdatedAt
+	}
+	for _, s := range surveys {
+		if s.UpdatedAt.After(updated) {
+			updated = s.UpdatedAt
+		}
+
+		link := surveyURL(s)
+		id := link
+		if s.URI != nil {
+			id = *s.URI
+		}
+		entry := Entry{
+			ID:        id,
+			Title:     s.Title,
+			Updated:   formatTime(s.UpdatedAt),
+			Published: formatTime(s.CreatedAt),
+			Links:     []Link{{Href: link, Rel: "alternate", Type: "text/html"}},
+			Author:    author(s),
+		}
+		if s.Description != nil {
+			entry.Summary = *s.Description
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	feed.Updated = formatTime(updated)
+
+	return feed
+}
+
+// Marshal encodes a feed as an XML document
+func Marshal(feed *Feed) ([]byte, error) {
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// formatTime formats a time as an RFC 3339 date in UTC
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,75 @@
+package feed
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	uri := "at://did:plc:alice/net.openmeet.survey/3k2"
+	description := "Where should we eat?"
+	alice := "did:plc:alice"
+	surveys := []*models.Survey{
+		{ID: uuid.New(), URI: &uri, AuthorDID: &alice, Slug: "lunch", Title: "Lunch?", Description: &description, CreatedAt: created, UpdatedAt: created.Add(time.Hour)},
+		{ID: uuid.New(), Slug: "dinner", Title: "Dinner?", CreatedAt: created.Add(-time.Hour), UpdatedAt: created.Add(2 * time.Hour)},
+	}
+
+	feed := New(
+		Meta{Title: "New surveys", SelfURL: "https://example.com/feeds/surveys.atom", PageURL: "https://example.com/"},
+		surveys,
+		func(s *models.Survey) string { return "https://example.com/surveys/" + s.Slug },
+		func(s *models.Survey) *Person {
+			if s.AuthorDID == nil {
+				return nil
+			}
+			return &Person{Name: "@alice.test"}
+		},
+		time.Now(),
+	)
+
+	assert.Equal(t, "https://example.com/feeds/surveys.atom", feed.ID)
+	assert.Equal(t, "2026-03-01T14:00:00Z", feed.Updated, "feeds are dated by their newest update")
+	require.Len(t, feed.Entries, 2)
+	assert.Equal(t, uri, feed.Entries[0].ID, "entries are identified by their AT URI")
+	assert.Equal(t, "https://example.com/surveys/dinner", feed.Entries[1].ID, "local surveys are identified by their link")
+	assert.Equal(t, "2026-03-01T12:00:00Z", feed.Entries[0].Published)
+	assert.Equal(t, description, feed.Entries[0].Summary)
+	assert.Equal(t, "@alice.test", feed.Entries[0].Author.Name)
+	assert.Nil(t, feed.Entries[1].Author)
+}
+
+func TestNew_Empty(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	feed := New(Meta{Title: "New surveys"}, nil, nil, nil, now)
+	assert.Equal(t, "2026-03-01T12:00:00Z", feed.Updated)
+	assert.Empty(t, feed.Entries)
+}
+
+func TestMarshal(t *testing.T) {
+	feed := New(
+		Meta{Title: "Surveys <by> Alice", SelfURL: "https://example.com/feeds/authors/did:plc:alice.atom", PageURL: "https://example.com/", Author: &Person{Name: "Alice"}},
+		[]*models.Survey{{Slug: "lunch", Title: "Fish & chips?"}},
+		func(s *models.Survey) string { return "https://example.com/surveys/" + s.Slug },
+		func(s *models.Survey) *Person { return nil },
+		time.Now(),
+	)
+
+	body, err := Marshal(feed)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `<?xml version="1.0" encoding="UTF-8"?>`)
+	assert.Contains(t, string(body), `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, string(body), `<title>Fish &amp; chips?</title>`)
+	assert.Contains(t, string(body), `<link href="https://example.com/feeds/authors/did:plc:alice.atom" rel="self" type="application/atom+xml"></link>`)
+
+	var decoded Feed
+	require.NoError(t, xml.Unmarshal(body, &decoded))
+	assert.Equal(t, "Surveys <by> Alice", decoded.Title)
+	assert.Equal(t, "Alice", decoded.Author.Name)
+}
@@ -0,0 +1,129 @@
+package firehose
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// errMissingBlock means a block is not in the CAR slice of a commit
+var errMissingBlock = errors.New("block not found")
+
+// Blocks holds the blocks of a CAR file by CID
+type Blocks struct {
+	Roots  []CID
+	blocks map[CID][]byte
+}
+
+// ReadCAR reads a CARv1 file: a DAG-CBOR header with the root CIDs followed by
+// length-prefixed (CID, block) sections. Each block is checked against the hash
+// in its CID, so a block can't be swapped for other content.
+func ReadCAR(data []byte) (*Blocks, error) {
+	headerLen, n := binary.Uvarint(data)
+	if n <= 0 || headerLen > uint64(len(data)-n) {
+		return nil, fmt.Errorf("car: malformed header length")
+	}
+	pos := n
+	header, _, err := decodeCBOR(data[pos : pos+int(headerLen)])
+	if err != nil {
+		return nil, fmt.Errorf("car: malformed header: %w", err)
+	}
+	pos += int(headerLen)
+
+	fields, _ := header.(map[string]any)
+	if version, _ := fields["version"].(int64); version != 1 {
+		return nil, fmt.Errorf("car: unsupported version %v", fields["version"])
+	}
+
+	b := &Blocks{blocks: make(map[CID][]byte)}
+	roots, _ := fields["roots"].([]any)
+	for _, root := range roots {
+		if cid, ok := root.(CID); ok {
+			b.Roots = append(b.Roots, cid)
+		}
+	}
+
+	for pos < len(data) {
+		sectionLen, n := binary.Uvarint(data[pos:])
+		if n <= 0 || sectionLen > uint64(len(data)-pos-n) {
+			return nil, fmt.Errorf("car: malformed section at byte %d", pos)
+		}
+		pos += n
+		section := data[pos : pos+int(sectionLen)]
+		pos += int(sectionLen)
+
+		cid, cidLen, err := ParseCID(section)
+		if err != nil {
+			return nil, fmt.Errorf("car: %w", err)
+		}
+		if err := cid.Verify(section[cidLen:]); err != nil {
+			return nil, fmt.Errorf("car: %w", err)
+		}
+		b.blocks[cid] = section[cidLen:]
+	}
+
+	return b, nil
+}
+
+// Decode decodes the DAG-CBOR block with the given CID
+func (b *Blocks) Decode(cid CID) (any, error) {
+	data, ok := b.blocks[cid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errMissingBlock, cid)
+	}
+	v, n, err := decodeCBOR(data)
+	if err != nil {
+		return nil, fmt.Errorf("block %s: %w", cid, err)
+	}
+	if n != len(data) {
+		return nil, fmt.Errorf("block %s: trailing bytes", cid)
+	}
+	return v, nil
+}
+
+// Block is a DAG-CBOR block and its CID
+type Block struct {
+	CID  CID
+	Data []byte
+}
+
+// WriteCAR writes a CARv1 file of blocks with the given roots, in the format
+// ReadCAR reads
+func WriteCAR(w io.Writer, roots []CID, blocks []Block) error {
+	rootLinks := make([]any, len(roots))
+	for i, root := range roots {
+		rootLinks[i] = root
+	}
+	header, err := EncodeCBOR(map[string]any{"version": 1, "roots": rootLinks})
+	if err != nil {
+		return fmt.Errorf("car: %w", err)
+	}
+	if err := writeSection(w, header); err != nil {
+		return err
+	}
+
+	for _, block := range blocks {
+		if err := writeSection(w, block.CID.Bytes(), block.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSection writes parts as one length-prefixed section
+func writeSection(w io.Writer, parts ...[]byte) error {
+	var n int
+	for _, part := range parts {
+		n += len(part)
+	}
+	if _, err := w.Write(binary.AppendUvarint(nil, uint64(n))); err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Package firehose decodes the ATProto relay firehose (com.atproto.sync.subscribeRepos):
+// DAG-CBOR event frames, the CAR block slices of commits, and the Merkle Search
+// Tree (MST) nodes that map record paths to record CIDs. It also encodes records
+// back to their blocks and writes CAR files, so exported records can be checked
+// against the CIDs their repositories list.
+package firehose
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxNesting bounds the depth of decoded CBOR values
+const maxNesting = 64
+
+// cborTagCID is the CBOR tag of IPLD links in DAG-CBOR
+const cborTagCID = 42
+
+var errTruncated = errors.New("cbor: unexpected end of data")
+
+// decodeCBOR decodes one DAG-CBOR value from the start of data and returns it
+// with the number of bytes read. Values decode to int64, []byte, string,
+// []any, map[string]any, bool, float64, nil, and CID for links.
+func decodeCBOR(data []byte) (any, int, error) {
+	d := &cborDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return v, d.pos, nil
+}
+
+// cborDecoder reads DAG-CBOR values from a byte slice
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// head reads the initial byte and argument of an item
+func (d *cborDecoder) head() (major byte, info byte, arg uint64, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, 0, errTruncated
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info = b>>5, b&0x1f
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		n := 1 << (info - 24)
+		if len(d.data)-d.pos < n {
+			return 0, 0, 0, errTruncated
+		}
+		buf := d.data[d.pos : d.pos+n]
+		d.pos += n
+		switch n {
+		case 1:
+			arg = uint64(buf[0])
+		case 2:
+			arg = uint64(binary.BigEndian.Uint16(buf))
+		case 4:
+			arg = uint64(binary.BigEndian.Uint32(buf))
+		default:
+			arg = binary.BigEndian.Uint64(buf)
+		}
+		return major, info, arg, nil
+	default:
+		// DAG-CBOR forbids indefinite lengths
+		return 0, 0, 0, fmt.Errorf("cbor: unsupported additional info %d", info)
+	}
+}
+
+// bytes reads n bytes of a string item
+func (d *cborDecoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errTruncated
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// value decodes the next item
+func (d *cborDecoder) value(depth int) (any, error) {
+	if depth > maxNesting {
+		return nil, fmt.Errorf("cbor: nesting deeper than %d", maxNesting)
+	}
+	major, info, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: integer %d out of range", arg)
+		}
+		return int64(arg), nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor: integer -1-%d out of range", arg)
+		}
+		return -1 - int64(arg), nil
+	case 2:
+		b, err := d.bytes(arg)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 3:
+		b, err := d.bytes(arg)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case 4:
+		// Each element takes at least one byte
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errTruncated
+		}
+		list := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case 5:
+		if arg > uint64(len(d.data)-d.pos)/2 {
+			return nil, errTruncated
+		}
+		m := make(map[string]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			k, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("cbor: map key is %T, not a string", k)
+			}
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		}
+		return m, nil
+	case 6:
+		if arg != cborTagCID {
+			return nil, fmt.Errorf("cbor: unsupported tag %d", arg)
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := v.([]byte)
+		if !ok || len(b) == 0 || b[0] != 0 {
+			return nil, fmt.Errorf("cbor: malformed link")
+		}
+		cid, n, err := ParseCID(b[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n != len(b)-1 {
+			return nil, fmt.Errorf("cbor: trailing bytes after link")
+		}
+		return cid, nil
+	default:
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		case 27:
+			return math.Float64frombits(arg), nil
+		default:
+			return nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package firehose
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// CID is a content identifier in its binary form
+type CID struct {
+	raw string
+}
+
+var errBadCID = errors.New("malformed CID")
+
+// base32Lower is the multibase "b" encoding used for CIDv1 strings
+var base32Lower = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// ParseCID reads a binary CID from the start of b and returns it with its length
+func ParseCID(b []byte) (CID, int, error) {
+	// CIDv0 is a bare sha2-256 multihash
+	if len(b) >= 34 && b[0] == 0x12 && b[1] == 0x20 {
+		return CID{raw: string(b[:34])}, 34, nil
+	}
+
+	pos := 0
+	next := func() (uint64, bool) {
+		v, n := binary.Uvarint(b[pos:])
+		if n <= 0 {
+			return 0, false
+		}
+		pos += n
+		return v, true
+	}
+
+	version, ok := next()
+	if !ok || version != 1 {
+		return CID{}, 0, fmt.Errorf("%w: unsupported version", errBadCID)
+	}
+	if _, ok := next(); !ok { // Codec
+		return CID{}, 0, errBadCID
+	}
+	if _, ok := next(); !ok { // Multihash function
+		return CID{}, 0, errBadCID
+	}
+	size, ok := next()
+	if !ok || size > uint64(len(b)-pos) {
+		return CID{}, 0, errBadCID
+	}
+	pos += int(size)
+
+	return CID{raw: string(b[:pos])}, pos, nil
+}
+
+// Bytes returns the binary form of the CID
+func (c CID) Bytes() []byte {
+	return []byte(c.raw)
+}
+
+// Defined reports whether the CID is set
+func (c CID) Defined() bool {
+	return c.raw != ""
+}
+
+// String returns the CID in its usual text form: base32 for CIDv1 and
+// base58btc for CIDv0
+func (c CID) String() string {
+	if c.raw == "" {
+		return ""
+	}
+	if len(c.raw) == 34 && c.raw[0] == 0x12 {
+		return base58(c.Bytes())
+	}
+	return "b" + base32Lower.EncodeToString(c.Bytes())
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58 encodes b in the bitcoin base58 alphabet
+func base58(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix, mod := big.NewInt(58), new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// decodeBase58 decodes a bitcoin base58 string
+func decodeBase58(s string) ([]byte, error) {
+	n, radix := new(big.Int), big.NewInt(58)
+	for _, c := range []byte(s) {
+		i := strings.IndexByte(base58Alphabet, c)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, radix).Add(n, big.NewInt(int64(i)))
+	}
+
+	var zeros int
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// Verify checks that block is the content the CID addresses. Repositories
+// only use sha2-256 multihashes; other hash functions are rejected.
+func (c CID) Verify(block []byte) error {
+	digest := c.Bytes()
+	if len(c.raw) != 34 || c.raw[0] != 0x12 {
+		// Skip the CIDv1 version and codec to the multihash
+		for i := 0; i < 2; i++ {
+			_, n := binary.Uvarint(digest)
+			if n <= 0 {
+				return errBadCID
+			}
+			digest = digest[n:]
+		}
+	}
+	if len(digest) != 2+sha256.Size || digest[0] != 0x12 || digest[1] != sha256.Size {
+		return fmt.Errorf("%w: unsupported hash function", errBadCID)
+	}
+
+	sum := sha256.Sum256(block)
+	if !bytes.Equal(sum[:], digest[2:]) {
+		return fmt.Errorf("block does not match CID %s", c)
+	}
+	return nil
+}
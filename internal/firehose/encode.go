@@ -0,0 +1,195 @@
+package firehose
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// cidV1Prefix is the CIDv1 prefix of DAG-CBOR blocks hashed with sha2-256:
+// version 1, codec dag-cbor (0x71), multihash sha2-256 of 32 bytes
+var cidV1Prefix = []byte{0x01, 0x71, 0x12, sha256.Size}
+
+// NewCID returns the CIDv1 (dag-cbor, sha2-256) of a block, as repositories
+// address records
+func NewCID(block []byte) CID {
+	sum := sha256.Sum256(block)
+	return CID{raw: string(append(append([]byte(nil), cidV1Prefix...), sum[:]...))}
+}
+
+// ParseCIDString parses a CID in its text form: base32 ("b" multibase) for
+// CIDv1, or base58btc for CIDv0
+func ParseCIDString(s string) (CID, error) {
+	var b []byte
+	var err error
+	switch {
+	case strings.HasPrefix(s, "Qm") && len(s) == 46:
+		b, err = decodeBase58(s)
+	case strings.HasPrefix(s, "b"):
+		b, err = base32Lower.DecodeString(s[1:])
+	default:
+		return CID{}, fmt.Errorf("%w: unsupported multibase %q", errBadCID, s)
+	}
+	if err != nil {
+		return CID{}, fmt.Errorf("%w: %v", errBadCID, err)
+	}
+
+	cid, n, err := ParseCID(b)
+	if err != nil {
+		return CID{}, err
+	}
+	if n != len(b) {
+		return CID{}, fmt.Errorf("%w: trailing bytes", errBadCID)
+	}
+	return cid, nil
+}
+
+// EncodeRecord encodes a record in the ATProto JSON data model, as Jetstream
+// and Commit.Record give them, back to its DAG-CBOR block. The block of an
+// unchanged record hashes to the CID its repository lists for it.
+func EncodeRecord(record map[string]any) ([]byte, CID, error) {
+	v, err := cborValue(record)
+	if err != nil {
+		return nil, CID{}, err
+	}
+	block, err := EncodeCBOR(v)
+	if err != nil {
+		return nil, CID{}, err
+	}
+	return block, NewCID(block), nil
+}
+
+// cborValue converts a value of the ATProto JSON data model to the DAG-CBOR
+// data model: {"$link": cid} becomes a CID, {"$bytes": base64} becomes bytes,
+// and numbers become integers, the only numbers records may hold.
+func cborValue(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		if link, ok := v["$link"].(string); ok && len(v) == 1 {
+			return ParseCIDString(link)
+		}
+		if encoded, ok := v["$bytes"].(string); ok && len(v) == 1 {
+			b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+			if err != nil {
+				return nil, fmt.Errorf("cbor: malformed $bytes: %w", err)
+			}
+			return b, nil
+		}
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			converted, err := cborValue(e)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = converted
+		}
+		return m, nil
+	case []any:
+		list := make([]any, len(v))
+		for i, e := range v {
+			converted, err := cborValue(e)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = converted
+		}
+		return list, nil
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+			return nil, fmt.Errorf("cbor: %v is not an integer", v)
+		}
+		return int64(v), nil
+	default:
+		return v, nil
+	}
+}
+
+// EncodeCBOR encodes a value as canonical DAG-CBOR. Values are those
+// decodeCBOR returns, except floats: int64 or int, []byte, string, []any,
+// map[string]any, bool, nil, and CID for links. Map keys are sorted by
+// length, then bytewise, so equal values always encode to the same block.
+func EncodeCBOR(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeValue(&buf, v, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeValue appends the encoding of v to buf
+func writeValue(buf *bytes.Buffer, v any, depth int) error {
+	if depth > maxNesting {
+		return fmt.Errorf("cbor: nesting deeper than %d", maxNesting)
+	}
+
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case int:
+		writeInt(buf, int64(v))
+	case int64:
+		writeInt(buf, v)
+	case string:
+		buf.Write(cborHead(3, uint64(len(v))))
+		buf.WriteString(v)
+	case []byte:
+		buf.Write(cborHead(2, uint64(len(v))))
+		buf.Write(v)
+	case []any:
+		buf.Write(cborHead(4, uint64(len(v))))
+		for _, e := range v {
+			if err := writeValue(buf, e, depth+1); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) < len(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+		buf.Write(cborHead(5, uint64(len(v))))
+		for _, k := range keys {
+			buf.Write(cborHead(3, uint64(len(k))))
+			buf.WriteString(k)
+			if err := writeValue(buf, v[k], depth+1); err != nil {
+				return err
+			}
+		}
+	case CID:
+		if !v.Defined() {
+			return fmt.Errorf("cbor: undefined link")
+		}
+		buf.Write(cborHead(6, cborTagCID))
+		buf.Write(cborHead(2, uint64(len(v.raw)+1)))
+		buf.WriteByte(0) // Identity multibase prefix
+		buf.WriteString(v.raw)
+	default:
+		return fmt.Errorf("cbor: cannot encode %T", v)
+	}
+	return nil
+}
+
+// writeInt appends the encoding of an integer to buf
+func writeInt(buf *bytes.Buffer, n int64) {
+	if n < 0 {
+		buf.Write(cborHead(1, uint64(-1-n)))
+	} else {
+		buf.Write(cborHead(0, uint64(n)))
+	}
+}
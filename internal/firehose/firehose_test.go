@@ -0,0 +1,585 @@
+package firehose
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeCBOR encodes test values as DAG-CBOR
+func encodeCBOR(v any) []byte {
+	var buf bytes.Buffer
+	writeCBOR(&buf, v)
+	return buf.Bytes()
+}
+
+func writeHead(buf *bytes.Buffer, major byte, arg uint64) {
+	switch {
+	case arg < 24:
+		buf.WriteByte(major<<5 | byte(arg))
+	case arg <= math.MaxUint8:
+		buf.WriteByte(major<<5 | 24)
+		buf.WriteByte(byte(arg))
+	case arg <= math.MaxUint16:
+		buf.WriteByte(major<<5 | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(arg)))
+	case arg <= math.MaxUint32:
+		buf.WriteByte(major<<5 | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(arg)))
+	default:
+		buf.WriteByte(major<<5 | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, arg))
+	}
+}
+
+func writeCBOR(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case int:
+		if v < 0 {
+			writeHead(buf, 1, uint64(-1-v))
+		} else {
+			writeHead(buf, 0, uint64(v))
+		}
+	case string:
+		writeHead(buf, 3, uint64(len(v)))
+		buf.WriteString(v)
+	case []byte:
+		writeHead(buf, 2, uint64(len(v)))
+		buf.Write(v)
+	case []any:
+		writeHead(buf, 4, uint64(len(v)))
+		for _, e := range v {
+			writeCBOR(buf, e)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		writeHead(buf, 5, uint64(len(v)))
+		for _, k := range keys {
+			writeCBOR(buf, k)
+			writeCBOR(buf, v[k])
+		}
+	case CID:
+		writeHead(buf, 6, cborTagCID)
+		writeCBOR(buf, append([]byte{0}, v.Bytes()...))
+	default:
+		panic("unsupported test value")
+	}
+}
+
+// cidOf returns the CIDv1 (dag-cbor, sha2-256) of a block
+func cidOf(block []byte) CID {
+	sum := sha256.Sum256(block)
+	return CID{raw: string(append([]byte{0x01, 0x71, 0x12, 0x20}, sum[:]...))}
+}
+
+// testRepo collects blocks for a CAR slice
+type testRepo struct {
+	blocks [][]byte
+}
+
+func (r *testRepo) put(v any) CID {
+	block := encodeCBOR(v)
+	r.blocks = append(r.blocks, block)
+	return cidOf(block)
+}
+
+func (r *testRepo) car(root CID) []byte {
+	var buf bytes.Buffer
+	header := encodeCBOR(map[string]any{"version": 1, "roots": []any{root}})
+	buf.Write(binary.AppendUvarint(nil, uint64(len(header))))
+	buf.Write(header)
+	for _, block := range r.blocks {
+		cid := cidOf(block).Bytes()
+		buf.Write(binary.AppendUvarint(nil, uint64(len(cid)+len(block))))
+		buf.Write(cid)
+		buf.Write(block)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want any
+	}{
+		{"00", int64(0)},
+		{"1818", int64(24)},
+		{"1903e8", int64(1000)},
+		{"3903e7", int64(-1000)},
+		{"6161", "a"},
+		{"43010203", []byte{1, 2, 3}},
+		{"83010203", []any{int64(1), int64(2), int64(3)}},
+		{"a1616101", map[string]any{"a": int64(1)}},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"fb3ff199999999999a", 1.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hex, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.hex)
+			require.NoError(t, err)
+			v, n, err := decodeCBOR(data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, v)
+			assert.Equal(t, len(data), n)
+		})
+	}
+}
+
+func TestDecodeCBOR_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"truncated string":   "6261",
+		"indefinite length":  "5f",
+		"unsupported tag":    "c11a514b67b0",
+		"non-string map key": "a10101",
+		"huge array":         "9bffffffffffffffff",
+		"integer overflow":   "1bffffffffffffffff",
+		"malformed link":     "d82a4101",
+		"half precision":     "f93c00",
+		"missing map value":  "a16161",
+		"empty input":        "",
+	}
+
+	for name, h := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := hex.DecodeString(h)
+			require.NoError(t, err)
+			_, _, err = decodeCBOR(data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCID(t *testing.T) {
+	cid := cidOf([]byte("record"))
+
+	decoded, n, err := decodeCBOR(encodeCBOR(cid))
+	require.NoError(t, err)
+	assert.Equal(t, cid, decoded)
+	assert.Equal(t, 2+2+1+36, n, "tag, byte string head, multibase prefix, and 36 CID bytes")
+
+	assert.True(t, strings.HasPrefix(cid.String(), "bafyrei"), cid.String())
+	assert.Len(t, cid.String(), 59)
+
+	// CIDv0 is a bare sha2-256 multihash shown in base58btc
+	v0, _, err := ParseCID(append([]byte{0x12, 0x20}, make([]byte, 32)...))
+	require.NoError(t, err)
+	assert.Equal(t, "QmNLei78zWmzUdbeRB3CiUfAizWUrbeeZh5K1rhAQKCh51", v0.String())
+
+	_, _, err = ParseCID([]byte{0x02, 0x71})
+	assert.Error(t, err)
+}
+
+func TestLookup(t *testing.T) {
+	repo := &testRepo{}
+	post1 := repo.put(map[string]any{"text": "1"})
+	post2 := repo.put(map[string]any{"text": "2"})
+	survey := repo.put(map[string]any{"name": "Lunch"})
+	vote := repo.put(map[string]any{"answers": []any{}})
+
+	left := repo.put(map[string]any{"l": nil, "e": []any{
+		map[string]any{"p": 0, "k": []byte("app.bsky.feed.post/1"), "v": post1, "t": nil},
+		map[string]any{"p": 19, "k": []byte("2"), "v": post2, "t": nil},
+	}})
+	right := repo.put(map[string]any{"l": nil, "e": []any{
+		map[string]any{"p": 0, "k": []byte("net.openmeet.survey/s2"), "v": vote, "t": nil},
+		map[string]any{"p": 21, "k": []byte("3"), "v": post1, "t": nil},
+	}})
+	root := repo.put(map[string]any{"l": left, "e": []any{
+		map[string]any{"p": 0, "k": []byte("net.openmeet.survey/s1"), "v": survey, "t": right},
+	}})
+
+	blocks, err := ReadCAR(repo.car(root))
+	require.NoError(t, err)
+	assert.Equal(t, []CID{root}, blocks.Roots)
+
+	tests := []struct {
+		key   string
+		want  CID
+		found bool
+	}{
+		{"app.bsky.feed.post/1", post1, true},
+		{"app.bsky.feed.post/2", post2, true},
+		{"net.openmeet.survey/s1", survey, true},
+		{"net.openmeet.survey/s2", vote, true},
+		{"net.openmeet.survey/s3", post1, true},
+		{"net.openmeet.survey.response/v1", CID{}, false},
+		{"app.bsky.feed.post/3", CID{}, false},
+		{"zzz/1", CID{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			cid, found, err := blocks.Lookup(root, tt.key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.want, cid)
+		})
+	}
+
+	// A subtree missing from the slice is reported, not treated as absent
+	partial := &testRepo{blocks: repo.blocks[len(repo.blocks)-1:]}
+	blocks, err = ReadCAR(partial.car(root))
+	require.NoError(t, err)
+	_, _, err = blocks.Lookup(root, "app.bsky.feed.post/1")
+	assert.True(t, errors.Is(err, errMissingBlock), err)
+}
+
+// commitFrame builds a #commit frame writing a survey record
+func commitFrame(t *testing.T, opCID func(record CID) CID, withTree bool) ([]byte, CID) {
+	t.Helper()
+	repo := &testRepo{}
+	record := repo.put(map[string]any{
+		"$type":   "net.openmeet.survey",
+		"name":    "Lunch",
+		"count":   3,
+		"subject": map[string]any{"uri": "at://did:plc:a/net.openmeet.survey/1", "cid": cidOf([]byte("x"))},
+		"blob":    []byte{0xff},
+	})
+	node := repo.put(map[string]any{"l": nil, "e": []any{
+		map[string]any{"p": 0, "k": []byte("net.openmeet.survey/s1"), "v": record, "t": nil},
+	}})
+	commit := repo.put(map[string]any{"did": "did:plc:author", "version": 3, "data": node, "rev": "3l2", "prev": nil})
+	if !withTree {
+		repo.blocks = repo.blocks[:1]
+	}
+
+	body := map[string]any{
+		"seq":    42,
+		"repo":   "did:plc:author",
+		"rev":    "3l2",
+		"time":   "2026-03-02T12:00:00.5Z",
+		"tooBig": false,
+		"commit": commit,
+		"blocks": repo.car(commit),
+		"ops": []any{
+			map[string]any{"action": "create", "path": "net.openmeet.survey/s1", "cid": opCID(record)},
+			map[string]any{"action": "delete", "path": "app.bsky.feed.post/1", "cid": nil},
+		},
+	}
+	frame := append(encodeCBOR(map[string]any{"op": 1, "t": "#commit"}), encodeCBOR(body)...)
+	return frame, record
+}
+
+func TestDecodeFrame_Commit(t *testing.T) {
+	data, recordCID := commitFrame(t, func(record CID) CID { return record }, true)
+
+	frame, err := DecodeFrame(data)
+	require.NoError(t, err)
+	require.NotNil(t, frame.Commit)
+	assert.Equal(t, TypeCommit, frame.Type)
+	assert.Equal(t, int64(42), frame.Seq)
+
+	commit := frame.Commit
+	assert.Equal(t, "did:plc:author", commit.Repo)
+	assert.Equal(t, "3l2", commit.Rev)
+	assert.Equal(t, int64(1772452800500000), commit.Time.UnixMicro())
+	require.Len(t, commit.Ops, 2)
+	assert.Equal(t, "net.openmeet.survey", commit.Ops[0].Collection())
+	assert.Equal(t, "s1", commit.Ops[0].RKey())
+	assert.Equal(t, recordCID, commit.Ops[0].CID)
+	assert.False(t, commit.Ops[1].CID.Defined())
+
+	record, cid, err := commit.Record(commit.Ops[0])
+	require.NoError(t, err)
+	assert.Equal(t, recordCID, cid)
+	assert.Equal(t, map[string]any{
+		"$type":   "net.openmeet.survey",
+		"name":    "Lunch",
+		"count":   float64(3),
+		"subject": map[string]any{"uri": "at://did:plc:a/net.openmeet.survey/1", "cid": map[string]any{"$link": cidOf([]byte("x")).String()}},
+		"blob":    map[string]any{"$bytes": "/w"},
+	}, record)
+}
+
+func TestDecodeFrame_CommitRecordCID(t *testing.T) {
+	// The op must agree with the tree
+	data, _ := commitFrame(t, func(CID) CID { return cidOf([]byte("other")) }, true)
+	frame, err := DecodeFrame(data)
+	require.NoError(t, err)
+	_, _, err = frame.Commit.Record(frame.Commit.Ops[0])
+	assert.ErrorContains(t, err, "tree has CID")
+
+	// Without the tree nodes, the op CID locates the record
+	data, _ = commitFrame(t, func(record CID) CID { return record }, false)
+	frame, err = DecodeFrame(data)
+	require.NoError(t, err)
+	record, _, err := frame.Commit.Record(frame.Commit.Ops[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Lunch", record["name"])
+}
+
+func TestDecodeFrame_ErrorAndInfo(t *testing.T) {
+	data := append(encodeCBOR(map[string]any{"op": -1}), encodeCBOR(map[string]any{"error": "FutureCursor", "message": "cursor in the future"})...)
+	frame, err := DecodeFrame(data)
+	require.NoError(t, err)
+	assert.EqualError(t, frame.Err, "firehose error FutureCursor: cursor in the future")
+
+	data = append(encodeCBOR(map[string]any{"op": 1, "t": "#info"}), encodeCBOR(map[string]any{"name": "OutdatedCursor"})...)
+	frame, err = DecodeFrame(data)
+	require.NoError(t, err)
+	assert.Equal(t, "OutdatedCursor", frame.Info)
+
+	data = append(encodeCBOR(map[string]any{"op": 1, "t": "#identity"}), encodeCBOR(map[string]any{"seq": 7, "did": "did:plc:a"})...)
+	frame, err = DecodeFrame(data)
+	require.NoError(t, err)
+	assert.Equal(t, "#identity", frame.Type)
+	assert.Equal(t, int64(7), frame.Seq)
+
+	_, err = DecodeFrame([]byte{0xa1})
+	assert.Error(t, err)
+}
+
+func TestReadCAR_RejectsTamperedBlock(t *testing.T) {
+	repo := &testRepo{}
+	root := repo.put(map[string]any{"text": "original"})
+	car := repo.car(root)
+
+	// Same length, different content
+	tampered := bytes.Replace(car, []byte("original"), []byte("replaced"), 1)
+	_, err := ReadCAR(tampered)
+	assert.ErrorContains(t, err, "does not match CID")
+
+	_, err = ReadCAR(car)
+	assert.NoError(t, err)
+}
+
+func TestSecp256k1(t *testing.T) {
+	double := k1Double(k1G)
+	assert.Equal(t, hexInt("c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"), double.x)
+	assert.Equal(t, hexInt("1ae168fea63dc339a3c58419466ceaeef7f632653266d0e1236431a950cfe52a"), double.y)
+
+	assert.Equal(t, double, k1Add(k1G, k1G))
+	assert.Nil(t, k1Combine(k1N, big.NewInt(0), k1G).x, "n·G is the point at infinity")
+
+	point, err := k1Decompress(k1Compress(double))
+	require.NoError(t, err)
+	assert.Equal(t, double, point)
+}
+
+// k1Compress encodes a point as a SEC 1 compressed key
+func k1Compress(p k1Point) []byte {
+	return append([]byte{2 | byte(p.y.Bit(0))}, p.x.FillBytes(make([]byte, 32))...)
+}
+
+// lowS returns a 64-byte signature, with s normalized to the lower half of the group order
+func lowS(r, s, n *big.Int) []byte {
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s = new(big.Int).Sub(n, s)
+	}
+	return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+}
+
+// testSigner signs commits with a fresh key and returns the key in multibase form
+type testSigner func(data []byte) []byte
+
+func newK256Signer(t *testing.T) (testSigner, string) {
+	t.Helper()
+	d, err := rand.Int(rand.Reader, k1N)
+	require.NoError(t, err)
+	pub := k1Combine(d, big.NewInt(0), k1G)
+
+	sign := func(data []byte) []byte {
+		hash := sha256.Sum256(data)
+		e := new(big.Int).SetBytes(hash[:])
+		k, err := rand.Int(rand.Reader, k1N)
+		require.NoError(t, err)
+		r := new(big.Int).Mod(k1Combine(k, big.NewInt(0), k1G).x, k1N)
+		s := new(big.Int).Mul(r, d)
+		s.Add(s, e).Mul(s, new(big.Int).ModInverse(k, k1N)).Mod(s, k1N)
+		return lowS(r, s, k1N)
+	}
+	return sign, "z" + base58(append(append([]byte{}, multicodecSecp256k1...), k1Compress(pub)...))
+}
+
+func newP256Signer(t *testing.T) (testSigner, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	sign := func(data []byte) []byte {
+		hash := sha256.Sum256(data)
+		r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+		require.NoError(t, err)
+		return lowS(r, s, elliptic.P256().Params().N)
+	}
+	compressed := elliptic.MarshalCompressed(elliptic.P256(), key.X, key.Y)
+	return sign, "z" + base58(append(append([]byte{}, multicodecP256...), compressed...))
+}
+
+// signedCommit decodes a #commit event whose commit block is signed with sign
+func signedCommit(t *testing.T, did string, sign testSigner) *Commit {
+	t.Helper()
+	repo := &testRepo{}
+	node := repo.put(map[string]any{"l": nil, "e": []any{}})
+	commit := map[string]any{"did": did, "version": 3, "data": node, "rev": "3l2", "prev": nil}
+	commit["sig"] = sign(encodeCBOR(commit))
+	root := repo.put(commit)
+
+	body := map[string]any{"seq": 1, "repo": "did:plc:author", "rev": "3l2", "commit": root, "blocks": repo.car(root), "ops": []any{}}
+	frame, err := DecodeFrame(append(encodeCBOR(map[string]any{"op": 1, "t": "#commit"}), encodeCBOR(body)...))
+	require.NoError(t, err)
+	return frame.Commit
+}
+
+func TestVerifySignature(t *testing.T) {
+	for name, newSigner := range map[string]func(*testing.T) (testSigner, string){
+		"k256": newK256Signer,
+		"p256": newP256Signer,
+	} {
+		t.Run(name, func(t *testing.T) {
+			sign, multibase := newSigner(t)
+			key, err := ParsePublicKey(multibase)
+			require.NoError(t, err)
+
+			commit := signedCommit(t, "did:plc:author", sign)
+			assert.NoError(t, commit.VerifySignature(key))
+
+			// Signed by another key
+			other, _ := newSigner(t)
+			commit = signedCommit(t, "did:plc:author", other)
+			assert.ErrorIs(t, commit.VerifySignature(key), ErrBadSignature)
+
+			// Signed by the key, but for another repository
+			commit = signedCommit(t, "did:plc:other", sign)
+			assert.ErrorContains(t, commit.VerifySignature(key), "not did:plc:author")
+		})
+	}
+}
+
+func TestVerifyMalleable(t *testing.T) {
+	for name, newSigner := range map[string]func(*testing.T) (testSigner, string){
+		"k256": newK256Signer,
+		"p256": newP256Signer,
+	} {
+		t.Run(name, func(t *testing.T) {
+			sign, multibase := newSigner(t)
+			key, err := ParsePublicKey(multibase)
+			require.NoError(t, err)
+
+			data := []byte("header.payload")
+			sig := sign(data)
+			assert.True(t, key.VerifyMalleable(data, sig))
+			assert.False(t, key.VerifyMalleable([]byte("header.other"), sig))
+
+			// The high-S form of the signature is only accepted here
+			n := k1N
+			if name == "p256" {
+				n = elliptic.P256().Params().N
+			}
+			s := new(big.Int).Sub(n, new(big.Int).SetBytes(sig[32:]))
+			high := append(append([]byte{}, sig[:32]...), s.FillBytes(make([]byte, 32))...)
+			assert.False(t, key.verify(data, high))
+			assert.True(t, key.VerifyMalleable(data, high))
+		})
+	}
+}
+
+func TestParsePublicKey_Invalid(t *testing.T) {
+	for _, multibase := range []string{"", "uABC", "z0OIl", "z" + base58([]byte{0xed, 0x01, 1, 2, 3}), "z" + base58(append([]byte{0xe7, 0x01}, make([]byte, 33)...))} {
+		_, err := ParsePublicKey(multibase)
+		assert.Error(t, err, multibase)
+	}
+}
+
+func TestEncodeCBOR_Canonical(t *testing.T) {
+	// Keys sort by length first, then bytewise
+	block, err := EncodeCBOR(map[string]any{"$type": "x", "bb": []any{true, nil}, "a": 1})
+	require.NoError(t, err)
+	assert.Equal(t, "a361610162626282f5f66524747970656178", hex.EncodeToString(block))
+
+	_, err = EncodeCBOR(map[string]any{"n": 1.5})
+	assert.Error(t, err)
+}
+
+func TestEncodeRecord(t *testing.T) {
+	repo := &testRepo{}
+	blob := repo.put(map[string]any{"data": "image"})
+	v := map[string]any{
+		"$type":     "net.openmeet.survey.response",
+		"subject":   map[string]any{"uri": "at://did:plc:author/net.openmeet.survey/1", "cid": blob},
+		"answers":   []any{map[string]any{"questionId": "q1", "selected": []any{"a"}}},
+		"count":     int64(-3),
+		"signature": []byte{1, 2, 3},
+		"createdAt": "2026-03-01T12:00:00Z",
+	}
+	want, err := EncodeCBOR(v)
+	require.NoError(t, err)
+
+	// The JSON form Jetstream and Commit.Record give encodes back to the same block
+	record := jsonValue(v).(map[string]any)
+	block, cid, err := EncodeRecord(record)
+	require.NoError(t, err)
+	assert.Equal(t, want, block)
+	assert.Equal(t, NewCID(want), cid)
+	assert.NoError(t, cid.Verify(block))
+
+	decoded, _, err := decodeCBOR(block)
+	require.NoError(t, err)
+	assert.Equal(t, blob, decoded.(map[string]any)["subject"].(map[string]any)["cid"])
+
+	_, _, err = EncodeRecord(map[string]any{"score": 0.5})
+	assert.ErrorContains(t, err, "not an integer")
+	_, _, err = EncodeRecord(map[string]any{"subject": map[string]any{"$link": "not-a-cid"}})
+	assert.Error(t, err)
+}
+
+func TestParseCIDString(t *testing.T) {
+	cid := NewCID([]byte("record"))
+	parsed, err := ParseCIDString(cid.String())
+	require.NoError(t, err)
+	assert.Equal(t, cid, parsed)
+
+	v0, err := ParseCIDString("QmNLei78zWmzUdbeRB3CiUfAizWUrbeeZh5K1rhAQKCh51")
+	require.NoError(t, err)
+	assert.Equal(t, "QmNLei78zWmzUdbeRB3CiUfAizWUrbeeZh5K1rhAQKCh51", v0.String())
+
+	for _, s := range []string{"", "zabc", "b!!", cid.String() + "aa"} {
+		_, err := ParseCIDString(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestWriteCAR(t *testing.T) {
+	root, err := EncodeCBOR(map[string]any{"text": "root"})
+	require.NoError(t, err)
+	leaf, err := EncodeCBOR(map[string]any{"text": "leaf"})
+	require.NoError(t, err)
+	blocks := []Block{{NewCID(root), root}, {NewCID(leaf), leaf}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCAR(&buf, []CID{blocks[0].CID}, blocks))
+
+	car, err := ReadCAR(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []CID{blocks[0].CID}, car.Roots)
+	v, err := car.Decode(blocks[1].CID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"text": "leaf"}, v)
+}
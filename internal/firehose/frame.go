@@ -0,0 +1,224 @@
+package firehose
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Firehose event types
+const (
+	TypeCommit = "#commit"
+	TypeInfo   = "#info"
+)
+
+// Frame is a decoded firehose message: a header naming the event type followed
+// by the event body, or an error
+type Frame struct {
+	Type   string  // Event type, e.g. "#commit"; empty for error frames
+	Seq    int64   // Sequence number of the event, 0 if it has none
+	Commit *Commit // Set for #commit events
+	Info   string  // Set for #info events, e.g. "OutdatedCursor: ..."
+	Err    error   // Set for error frames; the relay closes the stream after them
+}
+
+// Commit is a #commit event: record operations in one repository and the
+// blocks (commit, changed MST nodes, and records) they touched
+type Commit struct {
+	Seq    int64
+	Repo   string // DID of the repository
+	Rev    string
+	Time   time.Time // When the relay received the commit
+	TooBig bool      // Blocks were omitted; records must be fetched from the PDS
+	Root   CID       // Commit block, whose "data" is the MST root
+	Ops    []Op
+	Blocks *Blocks
+}
+
+// Op is a create, update, or delete of the record at Path
+type Op struct {
+	Action string // create, update, or delete
+	Path   string // collection/rkey
+	CID    CID    // Record CID; undefined for deletes
+}
+
+// Collection returns the collection NSID of the operation's record
+func (op Op) Collection() string {
+	collection, _, _ := strings.Cut(op.Path, "/")
+	return collection
+}
+
+// RKey returns the record key of the operation's record
+func (op Op) RKey() string {
+	_, rkey, _ := strings.Cut(op.Path, "/")
+	return rkey
+}
+
+// DecodeFrame decodes a binary WebSocket message of the firehose.
+// Events other than #commit and #info only carry their type and sequence number.
+func DecodeFrame(data []byte) (*Frame, error) {
+	h, n, err := decodeCBOR(data)
+	if err != nil {
+		return nil, fmt.Errorf("malformed frame header: %w", err)
+	}
+	header, _ := h.(map[string]any)
+	b, _, err := decodeCBOR(data[n:])
+	if err != nil {
+		return nil, fmt.Errorf("malformed frame body: %w", err)
+	}
+	body, _ := b.(map[string]any)
+
+	op, _ := header["op"].(int64)
+	switch op {
+	case -1:
+		name, _ := body["error"].(string)
+		message, _ := body["message"].(string)
+		return &Frame{Err: fmt.Errorf("firehose error %s: %s", name, message)}, nil
+	case 1:
+	default:
+		return nil, fmt.Errorf("unknown frame op %d", op)
+	}
+
+	frame := &Frame{}
+	frame.Type, _ = header["t"].(string)
+	frame.Seq, _ = body["seq"].(int64)
+
+	switch frame.Type {
+	case TypeCommit:
+		commit, err := decodeCommit(body)
+		if err != nil {
+			return nil, err
+		}
+		frame.Commit = commit
+	case TypeInfo:
+		name, _ := body["name"].(string)
+		message, _ := body["message"].(string)
+		frame.Info = strings.TrimSuffix(name+": "+message, ": ")
+	}
+
+	return frame, nil
+}
+
+// decodeCommit reads the fields of a #commit event body
+func decodeCommit(body map[string]any) (*Commit, error) {
+	c := &Commit{}
+	c.Seq, _ = body["seq"].(int64)
+	c.Repo, _ = body["repo"].(string)
+	c.Rev, _ = body["rev"].(string)
+	c.TooBig, _ = body["tooBig"].(bool)
+	c.Root, _ = body["commit"].(CID)
+	if s, ok := body["time"].(string); ok {
+		c.Time, _ = time.Parse(time.RFC3339Nano, s)
+	}
+	if c.Repo == "" {
+		return nil, fmt.Errorf("commit %d has no repo", c.Seq)
+	}
+
+	ops, _ := body["ops"].([]any)
+	for _, o := range ops {
+		fields, ok := o.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("commit %d has a malformed op", c.Seq)
+		}
+		op := Op{}
+		op.Action, _ = fields["action"].(string)
+		op.Path, _ = fields["path"].(string)
+		op.CID, _ = fields["cid"].(CID)
+		c.Ops = append(c.Ops, op)
+	}
+
+	if blocks, ok := body["blocks"].([]byte); ok && len(blocks) > 0 {
+		car, err := ReadCAR(blocks)
+		if err != nil {
+			return nil, fmt.Errorf("commit %d: %w", c.Seq, err)
+		}
+		c.Blocks = car
+	}
+
+	return c, nil
+}
+
+// Record returns the record written by a create or update operation, in the
+// JSON data model Jetstream uses: links become {"$link": cid}, bytes become
+// {"$bytes": base64}, and numbers are float64.
+//
+// The record CID is looked up in the commit's MST and returned with the record;
+// the CID listed in the operation is only used when the relay omitted the tree
+// nodes on the path.
+func (c *Commit) Record(op Op) (map[string]any, CID, error) {
+	if c.Blocks == nil {
+		return nil, CID{}, fmt.Errorf("commit %d has no blocks", c.Seq)
+	}
+
+	cid, err := c.recordCID(op)
+	if err != nil {
+		return nil, CID{}, err
+	}
+
+	v, err := c.Blocks.Decode(cid)
+	if err != nil {
+		return nil, CID{}, err
+	}
+	record, ok := jsonValue(v).(map[string]any)
+	if !ok {
+		return nil, CID{}, fmt.Errorf("record %s is not an object", op.Path)
+	}
+	return record, cid, nil
+}
+
+// recordCID finds the CID of an operation's record in the commit's tree
+func (c *Commit) recordCID(op Op) (CID, error) {
+	commit, err := c.Blocks.Decode(c.Root)
+	if errors.Is(err, errMissingBlock) && op.CID.Defined() {
+		return op.CID, nil
+	}
+	if err != nil {
+		return CID{}, fmt.Errorf("commit block: %w", err)
+	}
+	fields, _ := commit.(map[string]any)
+	root, ok := fields["data"].(CID)
+	if !ok {
+		return CID{}, fmt.Errorf("commit block has no MST root")
+	}
+
+	cid, found, err := c.Blocks.Lookup(root, op.Path)
+	switch {
+	case errors.Is(err, errMissingBlock) && op.CID.Defined():
+		return op.CID, nil
+	case err != nil:
+		return CID{}, err
+	case !found:
+		return CID{}, fmt.Errorf("record %s is not in the repository tree", op.Path)
+	case op.CID.Defined() && cid != op.CID:
+		return CID{}, fmt.Errorf("record %s: tree has CID %s but the op lists %s", op.Path, cid, op.CID)
+	}
+	return cid, nil
+}
+
+// jsonValue converts a decoded DAG-CBOR value to the ATProto JSON data model
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = jsonValue(e)
+		}
+		return m
+	case []any:
+		list := make([]any, len(v))
+		for i, e := range v {
+			list[i] = jsonValue(e)
+		}
+		return list
+	case CID:
+		return map[string]any{"$link": v.String()}
+	case []byte:
+		return map[string]any{"$bytes": base64.RawStdEncoding.EncodeToString(v)}
+	case int64:
+		return float64(v)
+	default:
+		return v
+	}
+}
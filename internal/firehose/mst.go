@@ -0,0 +1,63 @@
+package firehose
+
+import (
+	"fmt"
+)
+
+// maxTreeDepth bounds MST lookups in malformed trees
+const maxTreeDepth = 64
+
+// Lookup finds the record CID stored under key (collection/rkey) in the Merkle
+// Search Tree with the given root. Each node has an optional left subtree "l"
+// and entries "e" sorted by key; an entry stores its key as the length "p" of
+// the prefix shared with the previous key plus the remaining suffix "k", the
+// record CID "v", and the subtree "t" of keys between it and the next entry.
+// It returns false if the tree does not contain the key, and an error wrapping
+// errMissingBlock if a node on the path is not in b.
+func (b *Blocks) Lookup(root CID, key string) (CID, bool, error) {
+	node := root
+	for depth := 0; depth < maxTreeDepth; depth++ {
+		v, err := b.Decode(node)
+		if err != nil {
+			return CID{}, false, err
+		}
+		fields, ok := v.(map[string]any)
+		if !ok {
+			return CID{}, false, fmt.Errorf("mst: node %s is not a map", node)
+		}
+		entries, _ := fields["e"].([]any)
+
+		subtree, _ := fields["l"].(CID)
+		prev := ""
+		for _, e := range entries {
+			entry, ok := e.(map[string]any)
+			if !ok {
+				return CID{}, false, fmt.Errorf("mst: malformed entry in node %s", node)
+			}
+			prefix, _ := entry["p"].(int64)
+			suffix, _ := entry["k"].([]byte)
+			if prefix < 0 || int(prefix) > len(prev) {
+				return CID{}, false, fmt.Errorf("mst: malformed key prefix in node %s", node)
+			}
+			entryKey := prev[:prefix] + string(suffix)
+
+			if key == entryKey {
+				value, ok := entry["v"].(CID)
+				if !ok {
+					return CID{}, false, fmt.Errorf("mst: malformed value in node %s", node)
+				}
+				return value, true, nil
+			}
+			if key < entryKey {
+				break // Descend left of this entry
+			}
+			subtree, _ = entry["t"].(CID)
+			prev = entryKey
+		}
+		if !subtree.Defined() {
+			return CID{}, false, nil
+		}
+		node = subtree
+	}
+	return CID{}, false, fmt.Errorf("mst: tree deeper than %d", maxTreeDepth)
+}
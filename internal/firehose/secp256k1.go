@@ -0,0 +1,143 @@
+package firehose
+
+import (
+	"errors"
+	"math/big"
+)
+
+// secp256k1 curve parameters (SEC 2): y² = x³ + 7 over the field of order k1P,
+// with base point (k1Gx, k1Gy) of order k1N
+var (
+	k1P  = hexInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	k1N  = hexInt("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+	k1Gx = hexInt("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	k1Gy = hexInt("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8")
+
+	k1G = k1Point{x: k1Gx, y: k1Gy}
+
+	// k1SqrtExp is (p+1)/4; p ≡ 3 mod 4, so a^((p+1)/4) is a square root of a
+	k1SqrtExp = new(big.Int).Rsh(new(big.Int).Add(k1P, big.NewInt(1)), 2)
+)
+
+// hexInt parses a hexadecimal constant
+func hexInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("bad constant " + s)
+	}
+	return n
+}
+
+// k1Point is an affine point of the curve; a nil x is the point at infinity
+type k1Point struct {
+	x, y *big.Int
+}
+
+// k1Decompress reads a 33-byte SEC 1 compressed public key
+func k1Decompress(b []byte) (k1Point, error) {
+	if len(b) != 33 || (b[0] != 2 && b[0] != 3) {
+		return k1Point{}, errors.New("secp256k1: malformed compressed key")
+	}
+	x := new(big.Int).SetBytes(b[1:])
+	if x.Cmp(k1P) >= 0 {
+		return k1Point{}, errors.New("secp256k1: key out of range")
+	}
+
+	// y² = x³ + 7
+	y2 := new(big.Int).Exp(x, big.NewInt(3), k1P)
+	y2.Add(y2, big.NewInt(7)).Mod(y2, k1P)
+	y := new(big.Int).Exp(y2, k1SqrtExp, k1P)
+	if new(big.Int).Exp(y, big.NewInt(2), k1P).Cmp(y2) != 0 {
+		return k1Point{}, errors.New("secp256k1: key is not on the curve")
+	}
+	if y.Bit(0) != uint(b[0]&1) {
+		y.Sub(k1P, y)
+	}
+	return k1Point{x: x, y: y}, nil
+}
+
+// k1Add returns a + b
+func k1Add(a, b k1Point) k1Point {
+	switch {
+	case a.x == nil:
+		return b
+	case b.x == nil:
+		return a
+	case a.x.Cmp(b.x) == 0:
+		if a.y.Cmp(b.y) == 0 {
+			return k1Double(a)
+		}
+		return k1Point{} // a = -b
+	}
+
+	// λ = (y2 - y1) / (x2 - x1)
+	num := new(big.Int).Sub(b.y, a.y)
+	den := new(big.Int).Sub(b.x, a.x)
+	den.Mod(den, k1P).ModInverse(den, k1P)
+	lambda := num.Mul(num, den)
+	lambda.Mod(lambda, k1P)
+	return k1Line(a, b.x, lambda)
+}
+
+// k1Double returns 2a
+func k1Double(a k1Point) k1Point {
+	if a.x == nil || a.y.Sign() == 0 {
+		return k1Point{}
+	}
+
+	// λ = 3x² / 2y
+	num := new(big.Int).Mul(a.x, a.x)
+	num.Mul(num, big.NewInt(3))
+	den := new(big.Int).Lsh(a.y, 1)
+	den.Mod(den, k1P).ModInverse(den, k1P)
+	lambda := num.Mul(num, den)
+	lambda.Mod(lambda, k1P)
+	return k1Line(a, a.x, lambda)
+}
+
+// k1Line returns the third intersection of the line through a with slope
+// lambda, whose second intersection has x coordinate bx, mirrored across the x axis
+func k1Line(a k1Point, bx, lambda *big.Int) k1Point {
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, a.x).Sub(x, bx).Mod(x, k1P)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, lambda).Sub(y, a.y).Mod(y, k1P)
+	return k1Point{x: x, y: y}
+}
+
+// k1Combine returns u1·G + u2·q, doubling once for both scalars (Shamir's trick)
+func k1Combine(u1 *big.Int, u2 *big.Int, q k1Point) k1Point {
+	both := k1Add(k1G, q)
+	var r k1Point
+	for i := max(u1.BitLen(), u2.BitLen()) - 1; i >= 0; i-- {
+		r = k1Double(r)
+		switch {
+		case u1.Bit(i) == 1 && u2.Bit(i) == 1:
+			r = k1Add(r, both)
+		case u1.Bit(i) == 1:
+			r = k1Add(r, k1G)
+		case u2.Bit(i) == 1:
+			r = k1Add(r, q)
+		}
+	}
+	return r
+}
+
+// k1Verify verifies an ECDSA signature (r, s) of a 32-byte hash by the public key q
+func k1Verify(q k1Point, hash []byte, r, s *big.Int) bool {
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(k1N) >= 0 || s.Cmp(k1N) >= 0 {
+		return false
+	}
+	e := new(big.Int).SetBytes(hash)
+	w := new(big.Int).ModInverse(s, k1N)
+	u1 := new(big.Int).Mul(e, w)
+	u1.Mod(u1, k1N)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, k1N)
+
+	p := k1Combine(u1, u2, q)
+	if p.x == nil {
+		return false
+	}
+	return new(big.Int).Mod(p.x, k1N).Cmp(r) == 0
+}
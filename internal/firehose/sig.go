@@ -0,0 +1,194 @@
+package firehose
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrBadSignature means a commit is not signed by its repository's key
+var ErrBadSignature = errors.New("commit signature does not verify")
+
+// Multicodec prefixes of the public keys in DID documents (varint encoded)
+var (
+	multicodecSecp256k1 = []byte{0xe7, 0x01}
+	multicodecP256      = []byte{0x80, 0x24}
+)
+
+// PublicKey is a repository signing key: secp256k1 ("k256") or NIST P-256
+type PublicKey struct {
+	k256 *k1Point
+	p256 *ecdsa.PublicKey
+}
+
+// ParsePublicKey reads a key in the multibase form of DID documents'
+// publicKeyMultibase: "z", then base58btc of a multicodec prefix and the
+// compressed point
+func ParsePublicKey(multibase string) (*PublicKey, error) {
+	encoded, ok := strings.CutPrefix(multibase, "z")
+	if !ok {
+		return nil, fmt.Errorf("unsupported multibase encoding of key %q", multibase)
+	}
+	b, err := decodeBase58(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("malformed key: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(b, multicodecSecp256k1):
+		point, err := k1Decompress(b[len(multicodecSecp256k1):])
+		if err != nil {
+			return nil, err
+		}
+		return &PublicKey{k256: &point}, nil
+	case bytes.HasPrefix(b, multicodecP256):
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), b[len(multicodecP256):])
+		if x == nil {
+			return nil, errors.New("p256: malformed compressed key")
+		}
+		return &PublicKey{p256: &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}}, nil
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+}
+
+// verify checks a 64-byte (r, s) signature of data. Like the ATProto
+// reference implementation, it only accepts low-S signatures, so a valid
+// signature can't be rewritten into a second valid one.
+func (k *PublicKey) verify(data, sig []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	hash := sha256.Sum256(data)
+
+	switch {
+	case k.k256 != nil:
+		return s.Cmp(new(big.Int).Rsh(k1N, 1)) <= 0 && k1Verify(*k.k256, hash[:], r, s)
+	case k.p256 != nil:
+		return s.Cmp(new(big.Int).Rsh(elliptic.P256().Params().N, 1)) <= 0 && ecdsa.Verify(k.p256, hash[:], r, s)
+	default:
+		return false
+	}
+}
+
+// VerifyMalleable checks a 64-byte (r, s) signature of data, also accepting
+// high-S signatures. JWT libraries produce them, and service auth tokens are
+// not deduplicated by signature, so the reference implementation accepts them.
+func (k *PublicKey) VerifyMalleable(data, sig []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
+	var n *big.Int
+	switch {
+	case k.k256 != nil:
+		n = k1N
+	case k.p256 != nil:
+		n = elliptic.P256().Params().N
+	default:
+		return false
+	}
+	s := new(big.Int).SetBytes(sig[32:])
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s.Sub(n, s)
+	}
+	return k.verify(data, append(sig[:32:32], s.FillBytes(make([]byte, 32))...))
+}
+
+// VerifySignature checks that the commit block is for the event's repository
+// and signed with key. The signature covers the DAG-CBOR commit block
+// without its "sig" field.
+func (c *Commit) VerifySignature(key *PublicKey) error {
+	if c.Blocks == nil {
+		return fmt.Errorf("commit %d has no blocks", c.Seq)
+	}
+	block, ok := c.Blocks.blocks[c.Root]
+	if !ok {
+		return fmt.Errorf("commit block: %w: %s", errMissingBlock, c.Root)
+	}
+
+	v, err := c.Blocks.Decode(c.Root)
+	if err != nil {
+		return fmt.Errorf("commit block: %w", err)
+	}
+	fields, _ := v.(map[string]any)
+	if did, _ := fields["did"].(string); did != c.Repo {
+		return fmt.Errorf("commit block is for %q, not %s", did, c.Repo)
+	}
+
+	unsigned, sig, err := unsignedCommit(block)
+	if err != nil {
+		return fmt.Errorf("commit block: %w", err)
+	}
+	if !key.verify(unsigned, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// unsignedCommit returns a commit block without its "sig" entry, and the
+// signature. DAG-CBOR map entries are in canonical order, so removing one
+// entry's bytes leaves the canonical encoding of the unsigned commit.
+func unsignedCommit(block []byte) ([]byte, []byte, error) {
+	d := &cborDecoder{data: block}
+	major, _, count, err := d.head()
+	if err != nil {
+		return nil, nil, err
+	}
+	if major != 5 {
+		return nil, nil, errors.New("not a map")
+	}
+	entries := d.pos
+
+	var sig []byte
+	sigStart, sigEnd := -1, -1
+	for i := uint64(0); i < count; i++ {
+		start := d.pos
+		k, err := d.value(1)
+		if err != nil {
+			return nil, nil, err
+		}
+		v, err := d.value(1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if k == "sig" {
+			sig, _ = v.([]byte)
+			sigStart, sigEnd = start, d.pos
+		}
+	}
+	if sigStart < 0 || sig == nil {
+		return nil, nil, errors.New("commit is not signed")
+	}
+
+	unsigned := cborHead(5, count-1)
+	unsigned = append(unsigned, block[entries:sigStart]...)
+	unsigned = append(unsigned, block[sigEnd:d.pos]...)
+	return unsigned, sig, nil
+}
+
+// cborHead encodes the shortest initial byte and argument of an item
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return []byte{major<<5 | 25, byte(arg >> 8), byte(arg)}
+	case arg <= 0xffffffff:
+		return []byte{major<<5 | 26, byte(arg >> 24), byte(arg >> 16), byte(arg >> 8), byte(arg)}
+	default:
+		b := []byte{major<<5 | 27}
+		for shift := 56; shift >= 0; shift -= 8 {
+			b = append(b, byte(arg>>shift))
+		}
+		return b
+	}
+}
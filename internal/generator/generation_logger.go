@@ -27,6 +27,7 @@ type AIGenerationLog struct {
 	OutputTokens int
 	CostUSD      float64
 	DurationMS   int
+	APIKeyID     *uuid.UUID // API key that made the request, if any
 	CreatedAt    time.Time
 }
 
@@ -53,6 +54,40 @@ func (l *AIGenerationLog) Validate() error {
 	return nil
 }
 
+// apiKeyIDKey is the context key of the requesting API key
+type apiKeyIDKey struct{}
+
+// WithAPIKeyID attributes generations logged with the returned context to an API key
+func WithAPIKeyID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, apiKeyIDKey{}, id)
+}
+
+// APIKeyIDFromContext returns the API key set by WithAPIKeyID, or nil
+func APIKeyIDFromContext(ctx context.Context) *uuid.UUID {
+	if id, ok := ctx.Value(apiKeyIDKey{}).(uuid.UUID); ok {
+		return &id
+	}
+	return nil
+}
+
+// DailyUsage aggregates a user's generations on a day (UTC), per API key
+type DailyUsage struct {
+	Day          time.Time  `json:"day"`
+	APIKeyID     *uuid.UUID `json:"apiKeyId,omitempty"` // nil for generations made on the web
+	Generations  int        `json:"generations"`
+	Succeeded    int        `json:"succeeded"`
+	RateLimited  int        `json:"rateLimited"`
+	InputTokens  int        `json:"inputTokens"`
+	OutputTokens int        `json:"outputTokens"`
+	CostUSD      float64    `json:"costUsd"`
+}
+
+// UsageStore aggregates the generation log
+type UsageStore interface {
+	// GetGenerationUsage returns a user's daily generation usage since a day, oldest first
+	GetGenerationUsage(ctx context.Context, userID string, since time.Time) ([]*DailyUsage, error)
+}
+
 // GenerationLogDB defines the interface for logging to database
 type GenerationLogDB interface {
 	LogGeneration(ctx context.Context, log *AIGenerationLog) error
@@ -97,6 +132,7 @@ func (l *GenerationLogger) LogSuccess(
 		OutputTokens: result.OutputTokens,
 		CostUSD:      result.EstimatedCost,
 		DurationMS:   durationMS,
+		APIKeyID:     APIKeyIDFromContext(ctx),
 		CreatedAt:    time.Now(),
 	}
 
@@ -140,6 +176,7 @@ func (l *GenerationLogger) LogError(
 		OutputTokens: outputTokens,
 		CostUSD:      costUSD,
 		DurationMS:   durationMS,
+		APIKeyID:     APIKeyIDFromContext(ctx),
 		CreatedAt:    time.Now(),
 	}
 
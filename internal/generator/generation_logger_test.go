@@ -311,3 +311,28 @@ func TestAIGenerationLog_Validation(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerationLogger_APIKeyAttribution(t *testing.T) {
+	mockDB := &MockLogDB{}
+	logger := NewGenerationLogger(mockDB)
+
+	// Without an API key
+	err := logger.LogError(context.Background(), "did:plc:test123", "authenticated", "prompt", "", "", "rate_limited", "Rate limit exceeded", 0, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if mockDB.lastLog.APIKeyID != nil {
+		t.Errorf("Expected no API key, got %v", mockDB.lastLog.APIKeyID)
+	}
+
+	// With an API key in the context
+	keyID := uuid.New()
+	ctx := WithAPIKeyID(context.Background(), keyID)
+	err = logger.LogError(ctx, "did:plc:test123", "authenticated", "prompt", "", "", "rate_limited", "Rate limit exceeded", 0, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if mockDB.lastLog.APIKeyID == nil || *mockDB.lastLog.APIKeyID != keyID {
+		t.Errorf("Expected API key %s, got %v", keyID, mockDB.lastLog.APIKeyID)
+	}
+}
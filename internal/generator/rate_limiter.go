@@ -123,6 +123,41 @@ func (rl *RateLimiter) AllowAuthenticated(did string) bool {
 	return rl.checkLimit(did, rl.authTracking, rl.authLimit, rl.authWindow)
 }
 
+// Quota is what a caller has left of their generation limit
+type Quota struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time // When the current window ends, or now if none started
+}
+
+// AnonymousQuota returns the quota of an IP without counting a request
+func (rl *RateLimiter) AnonymousQuota(ip string) Quota {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	return quota(rl.anonTracking[ip], rl.anonLimit, rl.anonWindow, time.Now())
+}
+
+// AuthenticatedQuota returns the quota of a DID without counting a request
+func (rl *RateLimiter) AuthenticatedQuota(did string) Quota {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	return quota(rl.authTracking[did], rl.authLimit, rl.authWindow, time.Now())
+}
+
+// quota returns the quota left by a tracking entry (nil if none) at now
+func quota(entry *rateLimitEntry, limit int, window time.Duration, now time.Time) Quota {
+	if entry == nil || now.Sub(entry.windowStart) > window {
+		return Quota{Limit: limit, Remaining: limit, Reset: now}
+	}
+	return Quota{
+		Limit:     limit,
+		Remaining: max(limit-entry.count, 0),
+		Reset:     entry.windowStart.Add(window),
+	}
+}
+
 // checkLimit is the internal logic for checking and updating rate limits
 func (rl *RateLimiter) checkLimit(key string, tracking map[string]*rateLimitEntry, limit int, window time.Duration) bool {
 	now := time.Now()
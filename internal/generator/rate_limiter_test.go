@@ -72,6 +72,36 @@ func TestRateLimiter_Anonymous(t *testing.T) {
 		// Should be able to make requests again
 		assert.True(t, limiter.AllowAnonymous(ip))
 	})
+
+	t.Run("remaining does not count a request", func(t *testing.T) {
+		ip := "192.168.1.30"
+
+		q := limiter.AnonymousQuota(ip)
+		assert.Equal(t, 2, q.Remaining)
+		assert.Equal(t, 2, q.Limit)
+
+		limiter.AllowAnonymous(ip)
+		assert.Equal(t, 1, limiter.AnonymousQuota(ip).Remaining)
+		assert.Equal(t, 1, limiter.AnonymousQuota(ip).Remaining)
+
+		limiter.AllowAnonymous(ip)
+		limiter.AllowAnonymous(ip)
+		assert.Equal(t, 0, limiter.AnonymousQuota(ip).Remaining)
+	})
+
+	t.Run("quota resets when the window ends", func(t *testing.T) {
+		ip := "192.168.1.40"
+
+		before := time.Now()
+		q := limiter.AnonymousQuota(ip)
+		assert.Equal(t, Quota{Limit: 2, Remaining: 2, Reset: q.Reset}, q)
+		assert.False(t, q.Reset.Before(before), "a full quota resets now")
+
+		limiter.AllowAnonymous(ip)
+		q = limiter.AnonymousQuota(ip)
+		assert.Equal(t, 1, q.Remaining)
+		assert.WithinDuration(t, before.Add(time.Hour), q.Reset, time.Second)
+	})
 }
 
 func TestRateLimiter_Authenticated(t *testing.T) {
@@ -0,0 +1,141 @@
+package heatmap
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Config holds the sources of responses' countries
+type Config struct {
+	// DB is the path of a CSV file of IP address ranges and their countries,
+	// one "first,last,country" range per line, as in the DB-IP IP to Country Lite database
+	DB string
+	// Header is a request header holding the voter's country, set by a CDN
+	// in front of the service, such as CF-IPCountry. It is preferred to DB.
+	Header string
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - GEOIP_DB: path of a CSV file of IP address ranges and their countries
+//   - GEOIP_HEADER: request header holding the voter's country, set by a CDN (e.g. CF-IPCountry)
+func ConfigFromEnv() Config {
+	return Config{
+		DB:     os.Getenv("GEOIP_DB"),
+		Header: os.Getenv("GEOIP_HEADER"),
+	}
+}
+
+// ipRange is a range of IP addresses in a country
+type ipRange struct {
+	first, last netip.Addr
+	country     string
+}
+
+// Locator looks up the countries of voters
+type Locator struct {
+	ranges []ipRange // Sorted by first address
+	header string
+}
+
+// NewLocator creates a locator, loading the ranges of config.DB. Without a
+// DB or a header, every response is from Unknown.
+func NewLocator(config Config) (*Locator, error) {
+	l := &Locator{header: config.Header}
+	if config.DB == "" {
+		return l, nil
+	}
+
+	f, err := os.Open(config.DB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	defer f.Close()
+
+	if l.ranges, err = readRanges(f); err != nil {
+		return nil, fmt.Errorf("failed to read GeoIP database %s: %w", config.DB, err)
+	}
+	return l, nil
+}
+
+// readRanges reads "first,last,country" lines, sorted by first address
+func readRanges(r io.Reader) ([]ipRange, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.ReuseRecord = true
+
+	var ranges []ipRange
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		first, err := netip.ParseAddr(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		last, err := netip.ParseAddr(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if first.Is4() != last.Is4() || last.Less(first) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", line, first, last)
+		}
+		country, ok := countryCode(record[2])
+		if !ok {
+			continue // Ranges without a country, like ZZ in some databases
+		}
+		ranges = append(ranges, ipRange{first: first, last: last, country: country})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].first.Less(ranges[j].first) })
+	return ranges, nil
+}
+
+// Country returns the country of a voter by their IP address and request
+// headers, or Unknown
+func (l *Locator) Country(ip string, header http.Header) string {
+	if l.header != "" {
+		if country, ok := countryCode(header.Get(l.header)); ok {
+			return country
+		}
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return Unknown
+	}
+	addr = addr.Unmap()
+
+	// The last range starting at or before the address
+	i := sort.Search(len(l.ranges), func(i int) bool { return addr.Less(l.ranges[i].first) }) - 1
+	if i >= 0 && !l.ranges[i].last.Less(addr) {
+		return l.ranges[i].country
+	}
+	return Unknown
+}
+
+// countryCode returns the uppercase ISO 3166-1 alpha-2 code of a country. CDNs'
+// codes for unknown countries and Tor (XX, T1) and reserved ranges (ZZ) are not countries.
+func countryCode(s string) (string, bool) {
+	code := strings.ToUpper(strings.TrimSpace(s))
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return "", false
+	}
+	switch code {
+	case "XX", "ZZ":
+		return "", false
+	}
+	return code, true
+}
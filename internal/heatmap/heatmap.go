@@ -0,0 +1,113 @@
+// Package heatmap counts when and where a survey's responses come from, for
+// surveys whose definition opts in with responseMetadata. Each response adds
+// one to the count of its country, looked up from the voter's IP address, and
+// hour of day (UTC). Counts are kept apart from responses, so they can't be
+// tied to answers or voters, and IP addresses are not stored. Anonymous
+// surveys never count them.
+package heatmap
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Unknown is the country of responses from IP addresses without one
+const Unknown = "unknown"
+
+// Other is the row of the countries beyond a report's first maxCountries
+const Other = "other"
+
+// maxCountries is the number of countries a report shows by themselves
+const maxCountries = 20
+
+// Hours is the number of columns of a heatmap
+const Hours = 24
+
+// Count is the number of a survey's responses from a country in an hour of day (UTC)
+type Count struct {
+	Country   string `json:"country"` // ISO 3166-1 alpha-2 code, or Unknown
+	Hour      int    `json:"hour"`    // 0-23
+	Responses int    `json:"responses"`
+}
+
+// Store persists the counts of surveys' responses by country and hour of day
+type Store interface {
+	// RecordResponseMetadata adds a response to the count of its country and hour of day
+	RecordResponseMetadata(ctx context.Context, surveyID uuid.UUID, country string, hour int) error
+	// ListResponseMetadata returns the counts of a survey's responses
+	ListResponseMetadata(ctx context.Context, surveyID uuid.UUID) ([]*Count, error)
+}
+
+// Hour returns the hour of day (UTC) of a time
+func Hour(t time.Time) int {
+	return t.UTC().Hour()
+}
+
+// Row is the responses from a country by hour of day (UTC)
+type Row struct {
+	Country   string     `json:"country"` // ISO 3166-1 alpha-2 code, Unknown, or Other
+	Responses int        `json:"responses"`
+	Hours     [Hours]int `json:"hours"`
+}
+
+// Report is the heatmap of a survey's responses by country and hour of day
+type Report struct {
+	Collecting bool       `json:"collecting"` // Whether the survey counts new responses
+	Total      int        `json:"total"`
+	Hours      [Hours]int `json:"hours"`     // Responses from all countries by hour of day
+	Countries  []Row      `json:"countries"` // Most responses first; the rest are in an Other row
+	Max        int        `json:"-"`         // Most responses in a cell, for shading
+}
+
+// Build builds the report of a survey's counts
+func Build(counts []*Count, collecting bool) *Report {
+	report := &Report{Collecting: collecting, Countries: []Row{}}
+	byCountry := make(map[string]*Row)
+	for _, c := range counts {
+		if c.Hour < 0 || c.Hour >= Hours {
+			continue
+		}
+		row, ok := byCountry[c.Country]
+		if !ok {
+			row = &Row{Country: c.Country}
+			byCountry[c.Country] = row
+		}
+		row.Responses += c.Responses
+		row.Hours[c.Hour] += c.Responses
+		report.Hours[c.Hour] += c.Responses
+		report.Total += c.Responses
+	}
+
+	rows := make([]Row, 0, len(byCountry))
+	for _, row := range byCountry {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Responses != rows[j].Responses {
+			return rows[i].Responses > rows[j].Responses
+		}
+		return rows[i].Country < rows[j].Country
+	})
+
+	if len(rows) > maxCountries {
+		other := Row{Country: Other}
+		for _, row := range rows[maxCountries:] {
+			other.Responses += row.Responses
+			for hour, n := range row.Hours {
+				other.Hours[hour] += n
+			}
+		}
+		rows = append(rows[:maxCountries], other)
+	}
+	report.Countries = rows
+
+	for _, row := range rows {
+		for _, n := range row.Hours {
+			report.Max = max(report.Max, n)
+		}
+	}
+	return report
+}
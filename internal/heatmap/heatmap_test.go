@@ -0,0 +1,115 @@
+package heatmap
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	report := Build([]*Count{
+		{Country: "DE", Hour: 9, Responses: 3},
+		{Country: "DE", Hour: 10, Responses: 1},
+		{Country: "US", Hour: 15, Responses: 5},
+		{Country: Unknown, Hour: 9, Responses: 1},
+		{Country: "FR", Hour: 24, Responses: 7}, // Not an hour
+	}, true)
+
+	assert.True(t, report.Collecting)
+	assert.Equal(t, 10, report.Total)
+	assert.Equal(t, 4, report.Hours[9])
+	assert.Equal(t, 5, report.Max)
+	require.Len(t, report.Countries, 3)
+	assert.Equal(t, "US", report.Countries[0].Country)
+	assert.Equal(t, "DE", report.Countries[1].Country)
+	assert.Equal(t, 4, report.Countries[1].Responses)
+	assert.Equal(t, 3, report.Countries[1].Hours[9])
+	assert.Equal(t, Unknown, report.Countries[2].Country)
+
+	empty := Build(nil, false)
+	assert.Zero(t, empty.Total)
+	assert.NotNil(t, empty.Countries)
+}
+
+func TestBuild_Other(t *testing.T) {
+	var counts []*Count
+	for i := range maxCountries + 2 {
+		counts = append(counts, &Count{Country: fmt.Sprintf("A%c", 'A'+i), Hour: 12, Responses: 100 - i})
+	}
+
+	report := Build(counts, true)
+	require.Len(t, report.Countries, maxCountries+1)
+	other := report.Countries[maxCountries]
+	assert.Equal(t, Other, other.Country)
+	assert.Equal(t, (100-maxCountries)+(100-maxCountries-1), other.Responses)
+	assert.Equal(t, other.Responses, other.Hours[12])
+}
+
+func TestHour(t *testing.T) {
+	berlin := time.FixedZone("CEST", 2*60*60)
+	assert.Equal(t, 7, Hour(time.Date(2026, 6, 1, 9, 30, 0, 0, berlin)), "hours are UTC")
+}
+
+func TestLocator(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "countries.csv")
+	require.NoError(t, os.WriteFile(db, []byte(strings.Join([]string{
+		"2001:db8::,2001:db8:ffff:ffff:ffff:ffff:ffff:ffff,nl",
+		"203.0.113.0,203.0.113.255,DE",
+		"198.51.100.0,198.51.100.127,US",
+		"198.51.100.128,198.51.100.255,ZZ",
+	}, "\n")), 0o600))
+
+	l, err := NewLocator(Config{DB: db})
+	require.NoError(t, err)
+	tests := map[string]string{
+		"203.0.113.7":        "DE",
+		"::ffff:203.0.113.7": "DE",
+		"198.51.100.127":     "US",
+		"198.51.100.128":     Unknown, // Reserved
+		"192.0.2.1":          Unknown,
+		"2001:db8::1":        "NL",
+		"not an ip":          Unknown,
+	}
+	for ip, want := range tests {
+		assert.Equal(t, want, l.Country(ip, http.Header{}), ip)
+	}
+
+	// A CDN's header is preferred, unless its country is unknown
+	l, err = NewLocator(Config{DB: db, Header: "CF-IPCountry"})
+	require.NoError(t, err)
+	header := http.Header{}
+	header.Set("CF-IPCountry", "fr")
+	assert.Equal(t, "FR", l.Country("203.0.113.7", header))
+	header.Set("CF-IPCountry", "XX")
+	assert.Equal(t, "DE", l.Country("203.0.113.7", header))
+	header.Set("CF-IPCountry", "T1")
+	assert.Equal(t, Unknown, l.Country("192.0.2.1", header))
+
+	l, err = NewLocator(Config{})
+	require.NoError(t, err)
+	assert.Equal(t, Unknown, l.Country("203.0.113.7", http.Header{}))
+}
+
+func TestNewLocator_Invalid(t *testing.T) {
+	_, err := NewLocator(Config{DB: filepath.Join(t.TempDir(), "missing.csv")})
+	assert.Error(t, err)
+
+	for _, content := range []string{
+		"203.0.113.0,DE",
+		"203.0.113.0,not-an-ip,DE",
+		"203.0.113.255,203.0.113.0,DE",
+		"203.0.113.0,2001:db8::,DE",
+	} {
+		db := filepath.Join(t.TempDir(), "countries.csv")
+		require.NoError(t, os.WriteFile(db, []byte(content), 0o600))
+		_, err := NewLocator(Config{DB: db})
+		assert.Error(t, err, content)
+	}
+}
@@ -0,0 +1,194 @@
+// Package i18n provides locale-aware formatting for user-facing pages.
+//
+// The survey service does not translate UI strings yet; this package covers
+// the parts that must follow the survey's language to be readable: number
+// grouping, percentages, dates, vote counts, and text direction.
+package i18n
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Direction is the base text direction of a locale
+type Direction string
+
+const (
+	LTR Direction = "ltr"
+	RTL Direction = "rtl"
+)
+
+// Locale holds the formatting conventions for a language
+type Locale struct {
+	Tag           string    // BCP-47 language tag, e.g. "en", "ar"
+	Direction     Direction // Text direction
+	DecimalSep    string    // Decimal separator
+	GroupSep      string    // Thousands separator
+	PercentSuffix string    // Appended to percentages (some locales use a space before %)
+	DateLayout    string    // Go time layout for dates
+	Digits        []rune    // Native digits 0-9, nil for ASCII
+	Vote          string    // Noun for a single vote
+	Votes         string    // Noun for any other number of votes
+}
+
+// DefaultTag is used when no supported locale can be resolved
+const DefaultTag = "en"
+
+var arabicIndicDigits = []rune("٠١٢٣٤٥٦٧٨٩")
+
+var persianDigits = []rune("۰۱۲۳۴۵۶۷۸۹")
+
+// locales lists the supported locales keyed by base language
+var locales = map[string]Locale{
+	"en": {Tag: "en", Direction: LTR, DecimalSep: ".", GroupSep: ",", PercentSuffix: "%", DateLayout: "Jan 2, 2006", Vote: "vote", Votes: "votes"},
+	"de": {Tag: "de", Direction: LTR, DecimalSep: ",", GroupSep: ".", PercentSuffix: " %", DateLayout: "02.01.2006", Vote: "Stimme", Votes: "Stimmen"},
+	"es": {Tag: "es", Direction: LTR, DecimalSep: ",", GroupSep: ".", PercentSuffix: " %", DateLayout: "02/01/2006", Vote: "voto", Votes: "votos"},
+	"fr": {Tag: "fr", Direction: LTR, DecimalSep: ",", GroupSep: " ", PercentSuffix: " %", DateLayout: "02/01/2006", Vote: "vote", Votes: "votes"},
+	"it": {Tag: "it", Direction: LTR, DecimalSep: ",", GroupSep: ".", PercentSuffix: "%", DateLayout: "02/01/2006", Vote: "voto", Votes: "voti"},
+	"nl": {Tag: "nl", Direction: LTR, DecimalSep: ",", GroupSep: ".", PercentSuffix: "%", DateLayout: "02-01-2006", Vote: "stem", Votes: "stemmen"},
+	"pt": {Tag: "pt", Direction: LTR, DecimalSep: ",", GroupSep: ".", PercentSuffix: "%", DateLayout: "02/01/2006", Vote: "voto", Votes: "votos"},
+	"ja": {Tag: "ja", Direction: LTR, DecimalSep: ".", GroupSep: ",", PercentSuffix: "%", DateLayout: "2006/01/02", Vote: "票", Votes: "票"},
+	"ar": {Tag: "ar", Direction: RTL, DecimalSep: "٫", GroupSep: "٬", PercentSuffix: "٪", DateLayout: "02/01/2006", Digits: arabicIndicDigits, Vote: "صوت", Votes: "أصوات"},
+	"fa": {Tag: "fa", Direction: RTL, DecimalSep: "٫", GroupSep: "٬", PercentSuffix: "٪", DateLayout: "2006/01/02", Digits: persianDigits, Vote: "رأی", Votes: "رأی"},
+	"he": {Tag: "he", Direction: RTL, DecimalSep: ".", GroupSep: ",", PercentSuffix: "%", DateLayout: "02.01.2006", Vote: "קול", Votes: "קולות"},
+	"ur": {Tag: "ur", Direction: RTL, DecimalSep: ".", GroupSep: ",", PercentSuffix: "%", DateLayout: "02/01/2006", Vote: "ووٹ", Votes: "ووٹ"},
+}
+
+// Lookup returns the locale for a BCP-47 tag, matching on the base language.
+// The second return value is false if the language is not supported.
+func Lookup(tag string) (Locale, bool) {
+	base := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+	loc, ok := locales[base]
+	return loc, ok
+}
+
+// Default returns the default (English) locale
+func Default() Locale {
+	return locales[DefaultTag]
+}
+
+// Resolve picks the locale for a page: the survey's own language wins, then
+// the first supported language in the Accept-Language header, then English.
+func Resolve(surveyLanguage, acceptLanguage string) Locale {
+	if surveyLanguage != "" {
+		if loc, ok := Lookup(surveyLanguage); ok {
+			return loc
+		}
+	}
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		// Drop quality values ("de;q=0.8")
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		if loc, ok := Lookup(tag); ok {
+			return loc
+		}
+	}
+
+	return Default()
+}
+
+// IsRTL reports whether the locale is written right-to-left
+func (l Locale) IsRTL() bool {
+	return l.Direction == RTL
+}
+
+// Dir returns the value for an HTML dir attribute
+func (l Locale) Dir() string {
+	return string(l.Direction)
+}
+
+// FormatInt formats an integer with locale digit grouping
+func (l Locale) FormatInt(n int) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	return sign + l.localizeDigits(l.group(strconv.Itoa(n)))
+}
+
+// FormatDecimal formats a number with the given number of decimal places
+func (l Locale) FormatDecimal(f float64, decimals int) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		f = 0
+	}
+
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+
+	out := l.group(intPart)
+	if fracPart != "" {
+		out += l.DecimalSep + fracPart
+	}
+
+	sign := ""
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		sign = "-"
+	}
+	return sign + l.localizeDigits(out)
+}
+
+// FormatPercent formats a 0-100 percentage with one decimal place
+func (l Locale) FormatPercent(pct float64) string {
+	return l.FormatDecimal(pct, 1) + l.PercentSuffix
+}
+
+// FormatDate formats a date using the locale's date layout
+func (l Locale) FormatDate(t time.Time) string {
+	return l.localizeDigits(t.Format(l.DateLayout))
+}
+
+// FormatVotes formats a vote count with its share of the total, e.g. "3 votes (42.9%)"
+func (l Locale) FormatVotes(count, total int) string {
+	percentage := 0.0
+	if total > 0 {
+		percentage = float64(count) / float64(total) * 100
+	}
+	noun := l.Votes
+	if count == 1 {
+		noun = l.Vote
+	}
+	return fmt.Sprintf("%s %s (%s)", l.FormatInt(count), noun, l.FormatPercent(percentage))
+}
+
+// group inserts group separators into a string of ASCII digits
+func (l Locale) group(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		b.WriteString(digits[:lead])
+	}
+	for i := lead; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(l.GroupSep)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// localizeDigits replaces ASCII digits with the locale's native digits
+func (l Locale) localizeDigits(s string) string {
+	if l.Digits == nil {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return l.Digits[r-'0']
+		}
+		return r
+	}, s)
+}
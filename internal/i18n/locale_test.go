@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup_MatchesBaseLanguage(t *testing.T) {
+	loc, ok := Lookup("pt-BR")
+	assert.True(t, ok)
+	assert.Equal(t, "pt", loc.Tag)
+
+	loc, ok = Lookup("AR")
+	assert.True(t, ok)
+	assert.Equal(t, "ar", loc.Tag)
+
+	_, ok = Lookup("xx")
+	assert.False(t, ok)
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name           string
+		surveyLanguage string
+		acceptLanguage string
+		expectedTag    string
+	}{
+		{"survey language wins", "he", "de-DE,de;q=0.9", "he"},
+		{"falls back to accept-language", "", "fr-CA,fr;q=0.9,en;q=0.8", "fr"},
+		{"skips unsupported accept-language entries", "", "xx,de;q=0.5", "de"},
+		{"unsupported survey language uses accept-language", "zz", "es", "es"},
+		{"defaults to english", "", "", "en"},
+		{"wildcard ignored", "", "*", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedTag, Resolve(tt.surveyLanguage, tt.acceptLanguage).Tag)
+		})
+	}
+}
+
+func TestDirection(t *testing.T) {
+	for _, tag := range []string{"ar", "he", "fa", "ur"} {
+		loc, _ := Lookup(tag)
+		assert.True(t, loc.IsRTL(), tag)
+		assert.Equal(t, "rtl", loc.Dir(), tag)
+	}
+
+	assert.False(t, Default().IsRTL())
+	assert.Equal(t, "ltr", Default().Dir())
+}
+
+func TestFormatInt(t *testing.T) {
+	en := Default()
+	de, _ := Lookup("de")
+	fr, _ := Lookup("fr")
+	ar, _ := Lookup("ar")
+
+	assert.Equal(t, "0", en.FormatInt(0))
+	assert.Equal(t, "999", en.FormatInt(999))
+	assert.Equal(t, "1,234,567", en.FormatInt(1234567))
+	assert.Equal(t, "-12,345", en.FormatInt(-12345))
+	assert.Equal(t, "1.234.567", de.FormatInt(1234567))
+	assert.Equal(t, "1\u202f234", fr.FormatInt(1234))
+	assert.Equal(t, "١٬٢٣٤", ar.FormatInt(1234))
+}
+
+func TestFormatPercent(t *testing.T) {
+	en := Default()
+	de, _ := Lookup("de")
+	ar, _ := Lookup("ar")
+
+	assert.Equal(t, "42.9%", en.FormatPercent(42.857))
+	assert.Equal(t, "0.0%", en.FormatPercent(0))
+	assert.Equal(t, "42,9\u00a0%", de.FormatPercent(42.857))
+	assert.Equal(t, "٤٢٫٩٪", ar.FormatPercent(42.857))
+}
+
+func TestFormatVotes(t *testing.T) {
+	en := Default()
+	de, _ := Lookup("de")
+	ar, _ := Lookup("ar")
+
+	assert.Equal(t, "3 votes (42.9%)", en.FormatVotes(3, 7))
+	assert.Equal(t, "1 vote (100.0%)", en.FormatVotes(1, 1))
+	assert.Equal(t, "0 votes (0.0%)", en.FormatVotes(0, 0))
+	assert.Equal(t, "1.500 Stimmen (50,0\u00a0%)", de.FormatVotes(1500, 3000))
+	assert.Equal(t, "٣ أصوات (١٠٠٫٠٪)", ar.FormatVotes(3, 3))
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2025, time.March, 7, 15, 4, 0, 0, time.UTC)
+
+	en := Default()
+	de, _ := Lookup("de")
+	fa, _ := Lookup("fa")
+
+	assert.Equal(t, "Mar 7, 2025", en.FormatDate(date))
+	assert.Equal(t, "07.03.2025", de.FormatDate(date))
+	assert.Equal(t, "۲۰۲۵/۰۳/۰۷", fa.FormatDate(date))
+}
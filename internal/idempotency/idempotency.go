@@ -0,0 +1,156 @@
+// Package idempotency makes response submissions safe to retry. A client
+// sends a unique Idempotency-Key header with a submission; the first request
+// with a key claims it, and once it succeeds its response is stored, so a
+// retry of the request (after a timeout, say) gets the original response
+// back instead of submitting again. Keys are scoped to their caller and
+// survey and expire after TTL.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the idempotency key of a request
+const Header = "Idempotency-Key"
+
+const (
+	// TTL is how long a key's response is replayed
+	TTL = 24 * time.Hour
+	// ClaimTimeout is how long a claimed key waits for its request to
+	// finish; a key whose request died without finishing can then be
+	// claimed again
+	ClaimTimeout = time.Minute
+	// CleanupInterval is how often expired keys are deleted
+	CleanupInterval = time.Hour
+	// MaxKeyLength bounds the length of keys
+	MaxKeyLength = 255
+)
+
+// Errors of Claim
+var (
+	// ErrInvalidKey is returned for keys that are too long or not printable ASCII
+	ErrInvalidKey = errors.New("invalid idempotency key")
+	// ErrInProgress is returned while another request with the key runs
+	ErrInProgress = errors.New("a request with this idempotency key is in progress")
+	// ErrMismatch is returned when the key was used for a different request
+	ErrMismatch = errors.New("the idempotency key was used for a different request")
+)
+
+// Record is a claimed key and, once its request succeeded, the response to replay
+type Record struct {
+	Key         string // Hash of the scope and key
+	Fingerprint string // Hash of the request, to refuse a key reused for another one
+	ResponseID  *uuid.UUID
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	CompletedAt *time.Time // When the response was stored; nil while the request runs
+	ExpiresAt   time.Time
+}
+
+// Completed reports whether the record holds a response to replay
+func (r *Record) Completed() bool {
+	return r.CompletedAt != nil
+}
+
+// Store persists idempotency keys
+type Store interface {
+	// ClaimIdempotencyKey stores a record unless its key is held by a record
+	// that has not expired, or whose request is still running; that record
+	// is returned instead. Returns nil if the key was claimed.
+	ClaimIdempotencyKey(ctx context.Context, record *Record, now time.Time, claimTimeout time.Duration) (*Record, error)
+	// CompleteIdempotencyKey stores the response of a claimed key
+	CompleteIdempotencyKey(ctx context.Context, key string, responseID uuid.UUID, status int, contentType string, body []byte, now time.Time) error
+	// ReleaseIdempotencyKey deletes a claimed key whose request failed, so it can be retried
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+	// DeleteExpiredIdempotencyKeys deletes the records that expired before
+	// now, returning how many were deleted
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error)
+}
+
+// ValidKey reports whether a key is 1 to MaxKeyLength printable ASCII characters
+func ValidKey(key string) bool {
+	if key == "" || len(key) > MaxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// ScopedKey returns the stored form of a key: a hash of the key and its
+// scope, e.g. the caller and survey, so callers can't replay each other's keys
+func ScopedKey(scope, key string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Fingerprint returns the hash of a request's content, e.g. its answers
+func Fingerprint(request any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Claim claims a key for a request with a fingerprint. It returns the stored
+// record to replay if the request already succeeded, nil if the request
+// should run (and be completed or released afterwards), ErrInProgress if it
+// is running, or ErrMismatch if the key was used for another request.
+func Claim(ctx context.Context, store Store, scope, key, fingerprint string, now time.Time) (*Record, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	existing, err := store.ClaimIdempotencyKey(ctx, &Record{
+		Key:         ScopedKey(scope, key),
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(TTL),
+	}, now, ClaimTimeout)
+	switch {
+	case err != nil:
+		return nil, err
+	case existing == nil:
+		return nil, nil
+	case existing.Fingerprint != fingerprint:
+		return nil, ErrMismatch
+	case !existing.Completed():
+		return nil, ErrInProgress
+	}
+	return existing, nil
+}
+
+// StartCleanup deletes expired keys every interval until ctx is cancelled
+func StartCleanup(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := store.DeleteExpiredIdempotencyKeys(ctx, time.Now()); err != nil {
+			log.Printf("Error deleting expired idempotency keys: %v", err)
+		} else if n > 0 {
+			log.Printf("Deleted %d expired idempotency keys", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
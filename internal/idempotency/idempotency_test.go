@@ -0,0 +1,99 @@
+package idempotency
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore keeps records in memory
+type memoryStore struct {
+	records map[string]*Record
+}
+
+func (s *memoryStore) ClaimIdempotencyKey(ctx context.Context, record *Record, now time.Time, claimTimeout time.Duration) (*Record, error) {
+	if r, ok := s.records[record.Key]; ok && r.ExpiresAt.After(now) && (r.Completed() || r.CreatedAt.After(now.Add(-claimTimeout))) {
+		return r, nil
+	}
+	s.records[record.Key] = record
+	return nil, nil
+}
+
+func (s *memoryStore) CompleteIdempotencyKey(ctx context.Context, key string, responseID uuid.UUID, status int, contentType string, body []byte, now time.Time) error {
+	r := s.records[key]
+	r.ResponseID, r.Status, r.ContentType, r.Body, r.CompletedAt = &responseID, status, contentType, body, &now
+	return nil
+}
+
+func (s *memoryStore) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	delete(s.records, key)
+	return nil
+}
+
+func (s *memoryStore) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestValidKey(t *testing.T) {
+	assert.True(t, ValidKey("8e03978e-40d5-43e8-bc93-6894a57f9324"))
+	assert.True(t, ValidKey("retry 1"))
+	assert.False(t, ValidKey(""))
+	assert.False(t, ValidKey(strings.Repeat("k", MaxKeyLength+1)))
+	assert.False(t, ValidKey("line\nbreak"))
+	assert.False(t, ValidKey("ключ"))
+}
+
+func TestScopedKey(t *testing.T) {
+	assert.Equal(t, ScopedKey("did:plc:a", "k"), ScopedKey("did:plc:a", "k"))
+	assert.NotEqual(t, ScopedKey("did:plc:a", "k"), ScopedKey("did:plc:b", "k"), "callers can't replay each other's keys")
+}
+
+func TestFingerprint(t *testing.T) {
+	a, err := Fingerprint(map[string]string{"q1": "yes", "q2": "no"})
+	require.NoError(t, err)
+	b, err := Fingerprint(map[string]string{"q2": "no", "q1": "yes"})
+	require.NoError(t, err)
+	c, err := Fingerprint(map[string]string{"q1": "no"})
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+}
+
+func TestClaim(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{records: map[string]*Record{}}
+	now := time.Now()
+
+	_, err := Claim(ctx, store, "scope", "", "fp", now)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	record, err := Claim(ctx, store, "scope", "key", "fp", now)
+	require.NoError(t, err)
+	assert.Nil(t, record, "the first request runs")
+
+	_, err = Claim(ctx, store, "scope", "key", "fp", now)
+	assert.ErrorIs(t, err, ErrInProgress)
+	_, err = Claim(ctx, store, "scope", "key", "other", now)
+	assert.ErrorIs(t, err, ErrMismatch)
+
+	responseID := uuid.New()
+	require.NoError(t, store.CompleteIdempotencyKey(ctx, ScopedKey("scope", "key"), responseID, 201, "application/json", []byte("{}"), now))
+	record, err = Claim(ctx, store, "scope", "key", "fp", now.Add(time.Hour))
+	require.NoError(t, err)
+	require.NotNil(t, record, "retries replay the response")
+	assert.Equal(t, 201, record.Status)
+	assert.Equal(t, &responseID, record.ResponseID)
+
+	record, err = Claim(ctx, store, "other scope", "key", "fp", now)
+	require.NoError(t, err)
+	assert.Nil(t, record, "keys are scoped")
+
+	record, err = Claim(ctx, store, "scope", "key", "fp", now.Add(TTL+time.Second))
+	require.NoError(t, err)
+	assert.Nil(t, record, "expired keys are claimed anew")
+}
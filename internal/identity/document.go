@@ -0,0 +1,167 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxDocumentSize bounds the DID documents read
+const maxDocumentSize = 64 << 10
+
+// ErrDocumentNotFound means the DID has no document: it does not exist or was
+// deactivated
+var ErrDocumentNotFound = errors.New("DID document not found")
+
+// errNotPublic means a host chosen by a DID or handle resolves to an address
+// outside the public internet
+var errNotPublic = errors.New("address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which is not
+// routed on the internet but not reported as private either
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicTransport only connects to public addresses, checked after DNS
+// resolution, so hosts named in DIDs and handles cannot make the service
+// reach its own network
+var publicTransport = &http.Transport{
+	DialContext: (&net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !isPublic(ip) {
+				return fmt.Errorf("%w: %s", errNotPublic, ip)
+			}
+			return nil
+		},
+	}).DialContext,
+	TLSHandshakeTimeout: 10 * time.Second,
+}
+
+// isPublic reports whether an address is on the public internet: not
+// loopback, private, link-local, multicast, or unspecified
+func isPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// PublicClient returns a client like client that only connects to public
+// addresses, for fetching from hosts chosen by DIDs and handles
+func PublicClient(client *http.Client) *http.Client {
+	return &http.Client{
+		Transport:     publicTransport,
+		CheckRedirect: client.CheckRedirect,
+		Timeout:       client.Timeout,
+	}
+}
+
+// Document is the part of a DID document the service reads
+type Document struct {
+	ID                 string   `json:"id"`
+	AlsoKnownAs        []string `json:"alsoKnownAs"`
+	VerificationMethod []struct {
+		ID                 string `json:"id"`
+		PublicKeyMultibase string `json:"publicKeyMultibase"`
+	} `json:"verificationMethod"`
+	Service []struct {
+		ID              string `json:"id"`
+		Type            string `json:"type"`
+		ServiceEndpoint any    `json:"serviceEndpoint"` // A URL, or a map or list in other DID methods
+	} `json:"service"`
+}
+
+// FetchDocument fetches the document of a did:plc (from the directory at
+// plcURL) or did:web DID. did:web hosts are chosen by whoever creates the DID,
+// so they are only fetched from public addresses.
+func FetchDocument(ctx context.Context, client *http.Client, plcURL, did string) (*Document, error) {
+	var docURL string
+	switch {
+	case strings.HasPrefix(did, "did:plc:"):
+		docURL = fmt.Sprintf("%s/%s", plcURL, did)
+	case strings.HasPrefix(did, "did:web:"):
+		host := strings.ReplaceAll(strings.TrimPrefix(did, "did:web:"), "%3A", ":")
+		docURL = fmt.Sprintf("https://%s/.well-known/did.json", host)
+		client = PublicClient(client)
+	default:
+		return nil, fmt.Errorf("unsupported DID method: %s", did)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, docURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch DID document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("%w: %s", ErrDocumentNotFound, did)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var doc Document
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode DID document: %w", err)
+	}
+	if doc.ID != did {
+		return nil, fmt.Errorf("DID document is for %s", doc.ID)
+	}
+
+	return &doc, nil
+}
+
+// Handle returns the handle the document claims (the first at:// entry of
+// alsoKnownAs), or "" if it claims none
+func (d *Document) Handle() string {
+	for _, aka := range d.AlsoKnownAs {
+		if handle, ok := strings.CutPrefix(aka, "at://"); ok && handle != "" {
+			return strings.ToLower(handle)
+		}
+	}
+	return ""
+}
+
+// SigningKey returns the multibase public key that signs the repository's
+// commits (the "#atproto" verification method), or "" if there is none
+func (d *Document) SigningKey() string {
+	for _, method := range d.VerificationMethod {
+		if method.ID == "#atproto" || method.ID == d.ID+"#atproto" {
+			return method.PublicKeyMultibase
+		}
+	}
+	return ""
+}
+
+// PDSEndpoint returns the URL of the PDS hosting the repository (the
+// "#atproto_pds" service), or "" if there is none
+func (d *Document) PDSEndpoint() string {
+	for _, service := range d.Service {
+		if service.ID != "#atproto_pds" && service.ID != d.ID+"#atproto_pds" {
+			continue
+		}
+		if endpoint, ok := service.ServiceEndpoint.(string); ok && service.Type == "AtprotoPersonalDataServer" {
+			return endpoint
+		}
+	}
+	return ""
+}
@@ -0,0 +1,285 @@
+// Package identity resolves DIDs to Bluesky handles and profiles for display.
+// Resolved identities are cached in the database with a TTL, and cache misses
+// are fetched from the Bluesky public API in batches, while the caller waits
+// or in the background. Verifier checks that an author's handle and DID point
+// at each other.
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL is how long a resolved identity is used before it is refetched
+	DefaultTTL = 24 * time.Hour
+
+	// MaxBatchSize is the maximum number of actors per app.bsky.actor.getProfiles call
+	MaxBatchSize = 25
+
+	// DefaultAPIURL is the Bluesky public API endpoint
+	DefaultAPIURL = "https://public.api.bsky.app"
+
+	// backgroundTimeout limits the fetches of ResolveCached
+	backgroundTimeout = 30 * time.Second
+)
+
+// Identity is the display identity of a DID
+type Identity struct {
+	DID         string    `json:"did"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"displayName,omitempty"`
+	Avatar      string    `json:"avatar,omitempty"`
+	FetchedAt   time.Time `json:"-"`
+}
+
+// Name returns the display name, falling back to the handle and then the DID
+func (i *Identity) Name() string {
+	if i.DisplayName != "" {
+		return i.DisplayName
+	}
+	if i.Handle != "" {
+		return "@" + i.Handle
+	}
+	return i.DID
+}
+
+// Store persists resolved identities
+type Store interface {
+	GetIdentities(ctx context.Context, dids []string) ([]*Identity, error)
+	UpsertIdentities(ctx context.Context, identities []*Identity) error
+}
+
+// Resolver resolves DIDs using the store as a cache in front of the Bluesky public API
+type Resolver struct {
+	store  Store
+	ttl    time.Duration
+	apiURL string
+	client *http.Client
+	now    func() time.Time
+
+	mu         sync.Mutex
+	pending    map[string]bool // DIDs being fetched in the background
+	background sync.WaitGroup
+}
+
+// NewResolver creates a resolver caching identities in the store for ttl
+func NewResolver(store Store, ttl time.Duration) *Resolver {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Resolver{
+		store:  store,
+		ttl:    ttl,
+		apiURL: DefaultAPIURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// TTLFromEnv returns the identity cache TTL
+// Environment variables:
+//   - IDENTITY_CACHE_TTL: cache duration, e.g. "12h" (default: 24h)
+func TTLFromEnv() time.Duration {
+	if v := os.Getenv("IDENTITY_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("Warning: Invalid IDENTITY_CACHE_TTL %q, using default %s", v, DefaultTTL)
+	}
+	return DefaultTTL
+}
+
+// Resolve returns the identities of the given DIDs, keyed by DID.
+// Cached identities older than the TTL are refetched; if the API is unavailable
+// the stale identity is returned instead. DIDs that cannot be resolved are
+// omitted, so callers should fall back to showing the DID.
+// A nil resolver resolves nothing.
+func (r *Resolver) Resolve(ctx context.Context, dids []string) map[string]*Identity {
+	resolved := make(map[string]*Identity)
+	if r == nil {
+		return resolved
+	}
+
+	missing := r.cached(ctx, dids, resolved)
+	r.fetch(ctx, missing, resolved)
+	return resolved
+}
+
+// ResolveCached returns the cached identities of the given DIDs, keyed by DID,
+// without waiting for the API, for pages that must not block on it. DIDs
+// missing from the cache or older than the TTL are fetched in the background,
+// so they show from a later request; stale identities are returned meanwhile.
+// A nil resolver resolves nothing.
+func (r *Resolver) ResolveCached(ctx context.Context, dids []string) map[string]*Identity {
+	resolved := make(map[string]*Identity)
+	if r == nil {
+		return resolved
+	}
+
+	missing := r.claim(r.cached(ctx, dids, resolved))
+	if len(missing) == 0 {
+		return resolved
+	}
+
+	r.background.Add(1)
+	go func() {
+		defer r.background.Done()
+		defer r.release(missing)
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backgroundTimeout)
+		defer cancel()
+		r.fetch(ctx, missing, make(map[string]*Identity))
+	}()
+	return resolved
+}
+
+// cached adds the cached identities of the given DIDs to resolved, and returns
+// the DIDs to fetch: those missing from the cache or older than the TTL
+func (r *Resolver) cached(ctx context.Context, dids []string, resolved map[string]*Identity) []string {
+	seen := make(map[string]bool, len(dids))
+	var unique []string
+	for _, did := range dids {
+		if did != "" && !seen[did] {
+			seen[did] = true
+			unique = append(unique, did)
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+
+	cached, err := r.store.GetIdentities(ctx, unique)
+	if err != nil {
+		log.Printf("Failed to load cached identities: %v", err)
+	}
+
+	cutoff := r.now().Add(-r.ttl)
+	fresh := make(map[string]bool, len(cached))
+	for _, identity := range cached {
+		resolved[identity.DID] = identity
+		if identity.FetchedAt.After(cutoff) {
+			fresh[identity.DID] = true
+		}
+	}
+
+	var missing []string
+	for _, did := range unique {
+		if !fresh[did] {
+			missing = append(missing, did)
+		}
+	}
+	return missing
+}
+
+// fetch fetches the identities of the given DIDs in batches, adding them to
+// resolved and the cache
+func (r *Resolver) fetch(ctx context.Context, dids []string, resolved map[string]*Identity) {
+	for start := 0; start < len(dids); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(dids))
+
+		fetched, err := r.fetchProfiles(ctx, dids[start:end])
+		if err != nil {
+			log.Printf("Failed to fetch profiles: %v", err)
+			continue
+		}
+		if len(fetched) == 0 {
+			continue
+		}
+
+		for _, identity := range fetched {
+			resolved[identity.DID] = identity
+		}
+		if err := r.store.UpsertIdentities(ctx, fetched); err != nil {
+			log.Printf("Failed to cache identities: %v", err)
+		}
+	}
+}
+
+// claim returns the DIDs not already being fetched in the background, and
+// marks them pending until released
+func (r *Resolver) claim(dids []string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil {
+		r.pending = make(map[string]bool)
+	}
+	var claimed []string
+	for _, did := range dids {
+		if !r.pending[did] {
+			r.pending[did] = true
+			claimed = append(claimed, did)
+		}
+	}
+	return claimed
+}
+
+// release marks DIDs fetched in the background no longer pending
+func (r *Resolver) release(dids []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, did := range dids {
+		delete(r.pending, did)
+	}
+}
+
+// ResolveOne returns the identity of a single DID, or nil if it cannot be resolved
+func (r *Resolver) ResolveOne(ctx context.Context, did string) *Identity {
+	return r.Resolve(ctx, []string{did})[did]
+}
+
+// fetchProfiles fetches up to MaxBatchSize profiles with app.bsky.actor.getProfiles
+func (r *Resolver) fetchProfiles(ctx context.Context, dids []string) ([]*Identity, error) {
+	params := url.Values{}
+	for _, did := range dids {
+		params.Add("actors", did)
+	}
+	reqURL := fmt.Sprintf("%s/xrpc/app.bsky.actor.getProfiles?%s", r.apiURL, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch profiles: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var data struct {
+		Profiles []struct {
+			DID         string `json:"did"`
+			Handle      string `json:"handle"`
+			DisplayName string `json:"displayName,omitempty"`
+			Avatar      string `json:"avatar,omitempty"`
+		} `json:"profiles"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode profiles: %w", err)
+	}
+
+	now := r.now()
+	identities := make([]*Identity, 0, len(data.Profiles))
+	for _, p := range data.Profiles {
+		identities = append(identities, &Identity{
+			DID:         p.DID,
+			Handle:      p.Handle,
+			DisplayName: p.DisplayName,
+			Avatar:      p.Avatar,
+			FetchedAt:   now,
+		})
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,179 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	identities map[string]*Identity
+	upserts    int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{identities: make(map[string]*Identity)}
+}
+
+func (m *memoryStore) GetIdentities(ctx context.Context, dids []string) ([]*Identity, error) {
+	var found []*Identity
+	for _, did := range dids {
+		if identity, ok := m.identities[did]; ok {
+			found = append(found, identity)
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryStore) UpsertIdentities(ctx context.Context, identities []*Identity) error {
+	m.upserts++
+	for _, identity := range identities {
+		m.identities[identity.DID] = identity
+	}
+	return nil
+}
+
+// newProfilesServer serves getProfiles, returning a profile for every requested actor
+func newProfilesServer(t *testing.T, calls *[][]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/xrpc/app.bsky.actor.getProfiles", r.URL.Path)
+		actors := r.URL.Query()["actors"]
+		*calls = append(*calls, actors)
+
+		profiles := make([]map[string]string, 0, len(actors))
+		for _, did := range actors {
+			profiles = append(profiles, map[string]string{
+				"did":         did,
+				"handle":      did[len("did:plc:"):] + ".bsky.social",
+				"displayName": "Name " + did,
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"profiles": profiles})
+	}))
+}
+
+func TestResolver_BatchesAPICalls(t *testing.T) {
+	var calls [][]string
+	server := newProfilesServer(t, &calls)
+	defer server.Close()
+
+	store := newMemoryStore()
+	resolver := NewResolver(store, time.Hour)
+	resolver.apiURL = server.URL
+
+	dids := make([]string, 0, 30)
+	for i := range 30 {
+		dids = append(dids, fmt.Sprintf("did:plc:user%d", i))
+	}
+	dids = append(dids, "did:plc:user0", "") // duplicates and empty DIDs are ignored
+
+	resolved := resolver.Resolve(context.Background(), dids)
+	assert.Len(t, resolved, 30)
+	require.Len(t, calls, 2)
+	assert.Len(t, calls[0], MaxBatchSize)
+	assert.Len(t, calls[1], 5)
+	assert.Equal(t, "user3.bsky.social", resolved["did:plc:user3"].Handle)
+	assert.Len(t, store.identities, 30)
+
+	// Cached identities are not refetched within the TTL
+	resolver.Resolve(context.Background(), dids)
+	assert.Len(t, calls, 2)
+}
+
+func TestResolver_RefetchesStaleIdentities(t *testing.T) {
+	var calls [][]string
+	server := newProfilesServer(t, &calls)
+	defer server.Close()
+
+	store := newMemoryStore()
+	store.identities["did:plc:alice"] = &Identity{DID: "did:plc:alice", Handle: "old.handle", FetchedAt: time.Now().Add(-2 * time.Hour)}
+	store.identities["did:plc:bob"] = &Identity{DID: "did:plc:bob", Handle: "bob.handle", FetchedAt: time.Now()}
+
+	resolver := NewResolver(store, time.Hour)
+	resolver.apiURL = server.URL
+
+	resolved := resolver.Resolve(context.Background(), []string{"did:plc:alice", "did:plc:bob"})
+	require.Len(t, calls, 1)
+	assert.Equal(t, []string{"did:plc:alice"}, calls[0])
+	assert.Equal(t, "alice.bsky.social", resolved["did:plc:alice"].Handle)
+	assert.Equal(t, "bob.handle", resolved["did:plc:bob"].Handle)
+}
+
+func TestResolver_FallsBackToStaleOnAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	store := newMemoryStore()
+	store.identities["did:plc:alice"] = &Identity{DID: "did:plc:alice", Handle: "alice.old", FetchedAt: time.Now().Add(-48 * time.Hour)}
+
+	resolver := NewResolver(store, time.Hour)
+	resolver.apiURL = server.URL
+
+	resolved := resolver.Resolve(context.Background(), []string{"did:plc:alice", "did:plc:unknown"})
+	assert.Equal(t, "alice.old", resolved["did:plc:alice"].Handle)
+	assert.NotContains(t, resolved, "did:plc:unknown")
+	assert.Equal(t, 0, store.upserts)
+}
+
+func TestResolver_ResolveCachedFetchesInBackground(t *testing.T) {
+	var calls [][]string
+	server := newProfilesServer(t, &calls)
+	defer server.Close()
+
+	store := newMemoryStore()
+	store.identities["did:plc:alice"] = &Identity{DID: "did:plc:alice", Handle: "alice.old", FetchedAt: time.Now().Add(-2 * time.Hour)}
+	store.identities["did:plc:bob"] = &Identity{DID: "did:plc:bob", Handle: "bob.handle", FetchedAt: time.Now()}
+
+	resolver := NewResolver(store, time.Hour)
+	resolver.apiURL = server.URL
+
+	// Cached identities are returned at once, stale ones included
+	resolved := resolver.ResolveCached(context.Background(), []string{"did:plc:alice", "did:plc:bob", "did:plc:carol"})
+	assert.Equal(t, "alice.old", resolved["did:plc:alice"].Handle)
+	assert.Equal(t, "bob.handle", resolved["did:plc:bob"].Handle)
+	assert.NotContains(t, resolved, "did:plc:carol")
+
+	// The others are fetched in the background and cached
+	resolver.background.Wait()
+	require.Len(t, calls, 1)
+	assert.Equal(t, []string{"did:plc:alice", "did:plc:carol"}, calls[0])
+	resolved = resolver.ResolveCached(context.Background(), []string{"did:plc:alice", "did:plc:carol"})
+	assert.Equal(t, "alice.bsky.social", resolved["did:plc:alice"].Handle)
+	assert.Equal(t, "carol.bsky.social", resolved["did:plc:carol"].Handle)
+	resolver.background.Wait()
+	assert.Len(t, calls, 1)
+}
+
+func TestResolver_Nil(t *testing.T) {
+	var resolver *Resolver
+	assert.Empty(t, resolver.Resolve(context.Background(), []string{"did:plc:alice"}))
+	assert.Empty(t, resolver.ResolveCached(context.Background(), []string{"did:plc:alice"}))
+	assert.Nil(t, resolver.ResolveOne(context.Background(), "did:plc:alice"))
+}
+
+func TestIdentity_Name(t *testing.T) {
+	assert.Equal(t, "Alice", (&Identity{DID: "did:plc:a", Handle: "alice.bsky.social", DisplayName: "Alice"}).Name())
+	assert.Equal(t, "@alice.bsky.social", (&Identity{DID: "did:plc:a", Handle: "alice.bsky.social"}).Name())
+	assert.Equal(t, "did:plc:a", (&Identity{DID: "did:plc:a"}).Name())
+}
+
+func TestTTLFromEnv(t *testing.T) {
+	t.Setenv("IDENTITY_CACHE_TTL", "")
+	assert.Equal(t, DefaultTTL, TTLFromEnv())
+
+	t.Setenv("IDENTITY_CACHE_TTL", "2h")
+	assert.Equal(t, 2*time.Hour, TTLFromEnv())
+
+	t.Setenv("IDENTITY_CACHE_TTL", "soon")
+	assert.Equal(t, DefaultTTL, TTLFromEnv())
+}
@@ -0,0 +1,166 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPLCURL is the did:plc directory
+	DefaultPLCURL = "https://plc.directory"
+
+	// UnverifiedTTL is how long a failed verification is cached, so transient
+	// DNS or HTTP failures don't hide the badge for the full TTL
+	UnverifiedTTL = time.Hour
+)
+
+// Verification is the result of checking that a DID and its handle point at each other
+type Verification struct {
+	DID       string
+	Handle    string // Handle claimed in the DID document ("" if none)
+	Verified  bool   // Whether the handle's DNS or HTTP proof resolves back to the DID
+	CheckedAt time.Time
+}
+
+// VerificationStore persists verification results
+type VerificationStore interface {
+	GetVerification(ctx context.Context, did string) (*Verification, error)
+	UpsertVerification(ctx context.Context, v *Verification) error
+}
+
+// Verifier verifies DID handles, using the store as a cache
+type Verifier struct {
+	store     VerificationStore
+	ttl       time.Duration
+	plcURL    string
+	client    *http.Client
+	proofs    *http.Client // Fetches handle proofs, from public addresses only
+	lookupTXT func(ctx context.Context, name string) ([]string, error)
+	proofURL  func(handle string) string
+	now       func() time.Time
+}
+
+// NewVerifier creates a verifier caching results in the store for ttl
+func NewVerifier(store VerificationStore, ttl time.Duration) *Verifier {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Verifier{
+		store:     store,
+		ttl:       ttl,
+		plcURL:    DefaultPLCURL,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		proofs:    PublicClient(&http.Client{Timeout: 10 * time.Second}),
+		lookupTXT: net.DefaultResolver.LookupTXT,
+		proofURL: func(handle string) string {
+			return fmt.Sprintf("https://%s/.well-known/atproto-did", handle)
+		},
+		now: time.Now,
+	}
+}
+
+// Verify returns the verification of a DID. Cached results are reused until
+// they expire (UnverifiedTTL for failed verifications); if the DID document
+// cannot be fetched, the stale result is returned instead.
+// Returns nil if nothing is known. A nil verifier verifies nothing.
+func (v *Verifier) Verify(ctx context.Context, did string) *Verification {
+	if v == nil || did == "" {
+		return nil
+	}
+
+	cached, err := v.store.GetVerification(ctx, did)
+	if err != nil {
+		log.Printf("Failed to load cached verification for %s: %v", did, err)
+	}
+	if cached != nil {
+		ttl := v.ttl
+		if !cached.Verified {
+			ttl = min(ttl, UnverifiedTTL)
+		}
+		if cached.CheckedAt.After(v.now().Add(-ttl)) {
+			return cached
+		}
+	}
+
+	handle, err := v.documentHandle(ctx, did)
+	if err != nil {
+		log.Printf("Failed to resolve DID document for %s: %v", did, err)
+		return cached
+	}
+
+	verification := &Verification{DID: did, Handle: handle, CheckedAt: v.now()}
+	if handle != "" {
+		verification.Verified = v.handleResolvesTo(ctx, handle, did)
+	}
+
+	if err := v.store.UpsertVerification(ctx, verification); err != nil {
+		log.Printf("Failed to cache verification for %s: %v", did, err)
+	}
+
+	return verification
+}
+
+// documentHandle fetches the DID document and returns the handle it claims,
+// or "" if it claims none
+func (v *Verifier) documentHandle(ctx context.Context, did string) (string, error) {
+	doc, err := FetchDocument(ctx, v.client, v.plcURL, did)
+	if err != nil {
+		return "", err
+	}
+	return doc.Handle(), nil
+}
+
+// handleResolvesTo checks the handle's proof: a DNS TXT record "did=<did>" at
+// _atproto.<handle>, or the DID served at https://<handle>/.well-known/atproto-did
+func (v *Verifier) handleResolvesTo(ctx context.Context, handle, did string) bool {
+	records, err := v.lookupTXT(ctx, "_atproto."+handle)
+	if err == nil {
+		for _, record := range records {
+			if record == "did="+did {
+				return true
+			}
+		}
+	}
+
+	proofDID, err := v.fetchProof(ctx, handle)
+	if err != nil {
+		return false
+	}
+	return proofDID == did
+}
+
+// fetchProof fetches the DID served at the handle's well-known URL
+func (v *Verifier) fetchProof(ctx context.Context, handle string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.proofURL(handle), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := v.proofs.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch handle proof: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", fmt.Errorf("failed to read handle proof: %w", err)
+	}
+
+	did := strings.TrimSpace(string(body))
+	if !strings.HasPrefix(did, "did:") {
+		return "", errors.New("invalid DID in handle proof")
+	}
+	return did, nil
+}
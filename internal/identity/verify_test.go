@@ -0,0 +1,205 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryVerificationStore struct {
+	verifications map[string]*Verification
+}
+
+func (m *memoryVerificationStore) GetVerification(ctx context.Context, did string) (*Verification, error) {
+	return m.verifications[did], nil
+}
+
+func (m *memoryVerificationStore) UpsertVerification(ctx context.Context, v *Verification) error {
+	m.verifications[v.DID] = v
+	return nil
+}
+
+// newVerifierFixture serves DID documents from docs and handle proofs from proofs,
+// and answers DNS TXT lookups from txt
+func newVerifierFixture(t *testing.T, docs map[string]string, proofs map[string]string, txt map[string][]string) (*Verifier, *memoryVerificationStore, *int) {
+	docFetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handle, ok := strings.CutPrefix(r.URL.Path, "/proof/"); ok {
+			if did, ok := proofs[handle]; ok {
+				fmt.Fprintln(w, did)
+				return
+			}
+			http.NotFound(w, r)
+			return
+		}
+
+		docFetches++
+		did := strings.TrimPrefix(r.URL.Path, "/")
+		handle, ok := docs[did]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"id": %q, "alsoKnownAs": ["at://%s"]}`, did, handle)
+	}))
+	t.Cleanup(server.Close)
+
+	store := &memoryVerificationStore{verifications: make(map[string]*Verification)}
+	v := NewVerifier(store, 24*time.Hour)
+	v.plcURL = server.URL
+	v.proofURL = func(handle string) string { return server.URL + "/proof/" + handle }
+	v.proofs = server.Client()
+	v.lookupTXT = func(ctx context.Context, name string) ([]string, error) {
+		if records, ok := txt[name]; ok {
+			return records, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	return v, store, &docFetches
+}
+
+func TestVerifier_DNSProof(t *testing.T) {
+	v, _, _ := newVerifierFixture(t,
+		map[string]string{"did:plc:alice": "Alice.example.com"},
+		nil,
+		map[string][]string{"_atproto.alice.example.com": {"did=did:plc:alice"}},
+	)
+
+	verification := v.Verify(context.Background(), "did:plc:alice")
+	require.NotNil(t, verification)
+	assert.True(t, verification.Verified)
+	assert.Equal(t, "alice.example.com", verification.Handle)
+}
+
+func TestVerifier_HTTPProof(t *testing.T) {
+	v, _, _ := newVerifierFixture(t,
+		map[string]string{"did:plc:bob": "bob.example.com"},
+		map[string]string{"bob.example.com": "did:plc:bob"},
+		nil,
+	)
+
+	verification := v.Verify(context.Background(), "did:plc:bob")
+	require.NotNil(t, verification)
+	assert.True(t, verification.Verified)
+}
+
+func TestVerifier_ProofForOtherDID(t *testing.T) {
+	// The DID claims a handle whose proof points at someone else
+	v, _, _ := newVerifierFixture(t,
+		map[string]string{"did:plc:mallory": "alice.example.com"},
+		map[string]string{"alice.example.com": "did:plc:alice"},
+		map[string][]string{"_atproto.alice.example.com": {"did=did:plc:alice"}},
+	)
+
+	verification := v.Verify(context.Background(), "did:plc:mallory")
+	require.NotNil(t, verification)
+	assert.False(t, verification.Verified)
+	assert.Equal(t, "alice.example.com", verification.Handle)
+}
+
+func TestVerifier_CachesResults(t *testing.T) {
+	v, store, docFetches := newVerifierFixture(t,
+		map[string]string{"did:plc:alice": "alice.example.com", "did:plc:carol": "carol.example.com"},
+		map[string]string{"alice.example.com": "did:plc:alice"},
+		nil,
+	)
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	v.Verify(context.Background(), "did:plc:alice")
+	v.Verify(context.Background(), "did:plc:alice")
+	assert.Equal(t, 1, *docFetches)
+	assert.True(t, store.verifications["did:plc:alice"].Verified)
+
+	// Failed verifications are rechecked sooner than verified ones
+	v.Verify(context.Background(), "did:plc:carol")
+	now = now.Add(UnverifiedTTL + time.Minute)
+	v.Verify(context.Background(), "did:plc:alice")
+	v.Verify(context.Background(), "did:plc:carol")
+	assert.Equal(t, 3, *docFetches)
+}
+
+func TestVerifier_FallsBackToStale(t *testing.T) {
+	v, store, _ := newVerifierFixture(t, nil, nil, nil)
+	stale := &Verification{
+		DID:       "did:plc:gone",
+		Handle:    "gone.example.com",
+		Verified:  true,
+		CheckedAt: time.Now().Add(-48 * time.Hour),
+	}
+	store.verifications[stale.DID] = stale
+
+	assert.Equal(t, stale, v.Verify(context.Background(), "did:plc:gone"))
+	assert.Nil(t, v.Verify(context.Background(), "did:plc:unknown"))
+}
+
+func TestVerifier_Nil(t *testing.T) {
+	var v *Verifier
+	assert.Nil(t, v.Verify(context.Background(), "did:plc:alice"))
+}
+
+func TestDocument_HandleAndSigningKey(t *testing.T) {
+	var doc Document
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "did:plc:abc",
+		"alsoKnownAs": ["https://example.com", "at://Alice.example.com"],
+		"verificationMethod": [
+			{"id": "did:plc:abc#other", "publicKeyMultibase": "zOther"},
+			{"id": "did:plc:abc#atproto", "publicKeyMultibase": "zKey"}
+		]
+	}`), &doc))
+
+	assert.Equal(t, "alice.example.com", doc.Handle())
+	assert.Equal(t, "zKey", doc.SigningKey())
+	assert.Equal(t, "", (&Document{ID: "did:plc:abc"}).SigningKey())
+}
+
+func TestDocument_PDSEndpoint(t *testing.T) {
+	var doc Document
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "did:web:pds.example.com",
+		"service": [
+			{"id": "#atproto_labeler", "type": "AtprotoLabeler", "serviceEndpoint": "https://labeler.example.com"},
+			{"id": "#other", "type": "LinkedDomains", "serviceEndpoint": {"origins": ["https://example.com"]}},
+			{"id": "did:web:pds.example.com#atproto_pds", "type": "AtprotoPersonalDataServer", "serviceEndpoint": "https://pds.example.com"}
+		]
+	}`), &doc))
+
+	assert.Equal(t, "https://pds.example.com", doc.PDSEndpoint())
+	assert.Equal(t, "", (&Document{ID: "did:plc:abc"}).PDSEndpoint())
+}
+
+func TestFetchDocument_RejectsNonPublicHosts(t *testing.T) {
+	client := &http.Client{Timeout: time.Second}
+	for _, did := range []string{
+		"did:web:127.0.0.1",
+		"did:web:localhost%3A8443",
+		"did:web:169.254.169.254",
+		"did:web:10.0.0.5",
+		"did:web:[::1]",
+	} {
+		t.Run(did, func(t *testing.T) {
+			_, err := FetchDocument(context.Background(), client, DefaultPLCURL, did)
+			assert.ErrorIs(t, err, errNotPublic)
+		})
+	}
+}
+
+func TestFetchDocument_LimitsBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id": "did:plc:big", "alsoKnownAs": ["at://%s"]}`, strings.Repeat("a", maxDocumentSize))
+	}))
+	defer server.Close()
+
+	_, err := FetchDocument(context.Background(), server.Client(), server.URL, "did:plc:big")
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package interop
+
+import (
+	"errors"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PostCollection is the NSID of Bluesky posts. Opted-in authors run polls as
+// plain posts listing numbered options, and votes are replies naming an option:
+//
+//	Where should we meet?
+//	1. Online
+//	2) In person
+//
+// A poll is a top-level post with at least two options numbered from 1 in
+// order, one per line; the text before the first option is its question and
+// text after the options is ignored. A vote is a direct reply to a poll
+// starting with an option number, optionally after "#", e.g. "2" or "#2 for sure".
+const PostCollection = "app.bsky.feed.post"
+
+// ErrNotAPoll is returned for posts that are not polls, and replies that are not votes
+var ErrNotAPoll = errors.New("post is not a poll or vote")
+
+var (
+	// pollOptionLine matches a numbered option line of a poll post
+	pollOptionLine = regexp.MustCompile(`^\s*(\d{1,2})[.)]\s+(\S.*)$`)
+
+	// voteReply matches the option number a vote reply starts with
+	voteReply = regexp.MustCompile(`^#?(\d{1,2})\b`)
+)
+
+// BlueskyPollAuthorsFromEnv returns the DIDs whose Bluesky posts are indexed as polls
+// Environment variables:
+//   - BLUESKY_POLL_AUTHORS: comma-separated DIDs of opted-in authors (default: none, disabling the mode)
+func BlueskyPollAuthorsFromEnv() []string {
+	var dids []string
+	for _, did := range strings.Split(os.Getenv("BLUESKY_POLL_AUTHORS"), ",") {
+		did = strings.TrimSpace(did)
+		if did == "" {
+			continue
+		}
+		if !strings.HasPrefix(did, "did:") {
+			log.Printf("Warning: Ignoring invalid BLUESKY_POLL_AUTHORS entry %q", did)
+			continue
+		}
+		dids = append(dids, did)
+	}
+	return dids
+}
+
+// ReplyParentURI returns the URI of the post a post replies to, or "" if it is not a reply
+func ReplyParentURI(record map[string]interface{}) string {
+	reply, ok := record["reply"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	return refURI(reply["parent"])
+}
+
+// PostSurveyRecord converts a Bluesky poll post into a net.openmeet.survey record
+// with one required single choice question, or returns ErrNotAPoll
+func PostSurveyRecord(record map[string]interface{}) (map[string]interface{}, error) {
+	if ReplyParentURI(record) != "" {
+		return nil, ErrNotAPoll
+	}
+	text, _ := record["text"].(string)
+
+	var question []string
+	var options []string
+	for _, line := range strings.Split(text, "\n") {
+		match := pollOptionLine.FindStringSubmatch(line)
+		if match == nil {
+			if len(options) == 0 {
+				question = append(question, line)
+			}
+			continue
+		}
+		if n, _ := strconv.Atoi(match[1]); n != len(options)+1 {
+			return nil, ErrNotAPoll
+		}
+		options = append(options, strings.TrimSpace(match[2]))
+	}
+
+	name := strings.TrimSpace(strings.Join(question, "\n"))
+	if name == "" || len(options) < 2 {
+		return nil, ErrNotAPoll
+	}
+
+	survey := map[string]interface{}{
+		"question": name,
+		"options":  toInterfaces(options),
+	}
+	if langs, ok := record["langs"].([]interface{}); ok {
+		survey["langs"] = langs
+	}
+	return SurveyRecord(survey)
+}
+
+// ReplyResponseRecord converts a reply voting on a Bluesky poll into a
+// net.openmeet.survey.response record, or returns ErrNotAPoll
+func ReplyResponseRecord(record map[string]interface{}) (map[string]interface{}, error) {
+	pollURI := ReplyParentURI(record)
+	text, _ := record["text"].(string)
+	match := voteReply.FindStringSubmatch(strings.TrimSpace(text))
+	if pollURI == "" || match == nil {
+		return nil, ErrNotAPoll
+	}
+
+	n, _ := strconv.Atoi(match[1])
+	if n < 1 {
+		return nil, ErrNotAPoll
+	}
+	return ResponseRecord(map[string]interface{}{
+		"subject": pollURI,
+		"option":  float64(n - 1),
+	})
+}
+
+// toInterfaces converts strings to the []interface{} of decoded JSON
+func toInterfaces(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
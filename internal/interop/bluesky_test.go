@@ -0,0 +1,77 @@
+package interop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostSurveyRecord(t *testing.T) {
+	record, err := PostSurveyRecord(decode(t, `{
+		"text": "Where should we meet?\n\n1. Online\n2) In person\n\nReply with a number to vote",
+		"langs": ["en"]
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Where should we meet?", record["name"])
+	assert.Equal(t, []interface{}{"en"}, record["langs"])
+	q := record["questions"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "single", q["type"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "opt-0", "text": "Online"},
+		map[string]interface{}{"id": "opt-1", "text": "In person"},
+	}, q["options"])
+}
+
+func TestPostSurveyRecord_NotAPoll(t *testing.T) {
+	for name, record := range map[string]string{
+		"plain post":      `{"text": "Hello world"}`,
+		"one option":      `{"text": "Q\n1. A"}`,
+		"no question":     `{"text": "1. A\n2. B"}`,
+		"numbering gap":   `{"text": "Q\n1. A\n3. B"}`,
+		"numbered from 2": `{"text": "Q\n2. A\n3. B"}`,
+		"reply": `{
+			"text": "Q\n1. A\n2. B",
+			"reply": {"root": {"uri": "at://did:plc:a/app.bsky.feed.post/1"}, "parent": {"uri": "at://did:plc:a/app.bsky.feed.post/1"}}
+		}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := PostSurveyRecord(decode(t, record))
+			assert.ErrorIs(t, err, ErrNotAPoll)
+		})
+	}
+}
+
+func TestReplyResponseRecord(t *testing.T) {
+	reply := `"reply": {"root": {"uri": "at://did:plc:a/app.bsky.feed.post/poll"}, "parent": {"uri": "at://did:plc:a/app.bsky.feed.post/poll"}}`
+
+	for text, option := range map[string]string{
+		"2":               "opt-1",
+		" #1 for sure":    "opt-0",
+		"2. In person ok": "opt-1",
+	} {
+		t.Run(text, func(t *testing.T) {
+			record, err := ReplyResponseRecord(decode(t, `{"text": "`+text+`", `+reply+`}`))
+			require.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"uri": "at://did:plc:a/app.bsky.feed.post/poll"}, record["subject"])
+			answer := record["answers"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, []interface{}{option}, answer["selectedOptions"])
+		})
+	}
+
+	for _, text := range []string{"nice poll", "2nd option", "0", ""} {
+		t.Run("not a vote: "+text, func(t *testing.T) {
+			_, err := ReplyResponseRecord(decode(t, `{"text": "`+text+`", `+reply+`}`))
+			assert.ErrorIs(t, err, ErrNotAPoll)
+		})
+	}
+
+	_, err := ReplyResponseRecord(decode(t, `{"text": "2"}`))
+	assert.ErrorIs(t, err, ErrNotAPoll, "top-level posts are not votes")
+}
+
+func TestBlueskyPollAuthorsFromEnv(t *testing.T) {
+	t.Setenv("BLUESKY_POLL_AUTHORS", " did:plc:alice, alice.bsky.social ,,did:web:example.com")
+	assert.Equal(t, []string{"did:plc:alice", "did:web:example.com"}, BlueskyPollAuthorsFromEnv())
+}
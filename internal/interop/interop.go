@@ -0,0 +1,257 @@
+// Package interop maps records of other ATProto poll lexicons onto the survey
+// lexicon. Foreign polls are indexed read-only: votes are cast in the app that
+// created the poll and reach us as foreign vote records.
+//
+// Poll lexicons differ in naming but share a simple shape, so a single adapter
+// reads all configured collections:
+//
+//	poll: {"question": "...", "options": ["A", "B"] or [{"text": "A"}, ...], "multiple": false}
+//	vote: {"subject": {"uri": "at://..."} or "at://...", "option": 0} or {"options": [0, 2]}
+//
+// Common alternative field names ("text"/"title"/"name" for the question,
+// "answers"/"choices" for options, "poll" for the subject, "choice"/"answer"
+// for the selected option) are accepted. Option indexes are zero-based.
+package interop
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+const (
+	// SurveyCollection is the NSID of our survey records
+	SurveyCollection = "net.openmeet.survey"
+
+	// questionID is the ID of the single question of a foreign poll
+	questionID = "q1"
+)
+
+// ErrCrossPublished is returned for foreign polls that were cross-published from
+// one of our own surveys, so they are not indexed twice
+var ErrCrossPublished = errors.New("poll was cross-published from a survey")
+
+// Lexicon is a foreign poll lexicon to index
+type Lexicon struct {
+	Poll string // NSID of poll records
+	Vote string // NSID of vote records ("" if votes are not indexed)
+}
+
+// LexiconsFromEnv returns the foreign poll lexicons to index
+// Environment variables:
+//   - FOREIGN_POLL_LEXICONS: comma-separated poll NSIDs, each optionally followed
+//     by "=" and its vote NSID, e.g. "com.example.poll=com.example.poll.vote" (default: none)
+func LexiconsFromEnv() []Lexicon {
+	var lexicons []Lexicon
+	for _, entry := range strings.Split(os.Getenv("FOREIGN_POLL_LEXICONS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		poll, vote, _ := strings.Cut(entry, "=")
+		poll, vote = strings.TrimSpace(poll), strings.TrimSpace(vote)
+		if poll == "" || strings.HasPrefix(poll, SurveyCollection) {
+			log.Printf("Warning: Ignoring invalid FOREIGN_POLL_LEXICONS entry %q", entry)
+			continue
+		}
+		lexicons = append(lexicons, Lexicon{Poll: poll, Vote: vote})
+	}
+	return lexicons
+}
+
+// CrossPublishCollectionFromEnv returns the foreign poll lexicon new surveys are
+// cross-published to
+// Environment variables:
+//   - POLL_CROSS_PUBLISH_COLLECTION: poll NSID to also write new single-question surveys as (default: disabled)
+func CrossPublishCollectionFromEnv() string {
+	return strings.TrimSpace(os.Getenv("POLL_CROSS_PUBLISH_COLLECTION"))
+}
+
+// IsForeignURI reports whether an AT URI refers to a record outside the survey lexicon
+func IsForeignURI(uri string) bool {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+	if len(parts) != 3 {
+		return false
+	}
+	collection := parts[1]
+	return collection != SurveyCollection && !strings.HasPrefix(collection, SurveyCollection+".")
+}
+
+// SurveyRecord converts a foreign poll record into a net.openmeet.survey record
+// with one required choice question
+func SurveyRecord(record map[string]interface{}) (map[string]interface{}, error) {
+	if source := refURI(record["source"]); source != "" && !IsForeignURI(source) {
+		return nil, ErrCrossPublished
+	}
+
+	text := firstString(record, "question", "text", "title", "name")
+	if text == "" {
+		return nil, fmt.Errorf("poll question is required")
+	}
+
+	var rawOptions []interface{}
+	for _, key := range []string{"options", "answers", "choices"} {
+		if v, ok := record[key].([]interface{}); ok {
+			rawOptions = v
+			break
+		}
+	}
+	if len(rawOptions) < 2 {
+		return nil, fmt.Errorf("poll must have at least 2 options")
+	}
+
+	options := make([]interface{}, 0, len(rawOptions))
+	for i, raw := range rawOptions {
+		var optionText string
+		switch v := raw.(type) {
+		case string:
+			optionText = v
+		case map[string]interface{}:
+			optionText = firstString(v, "text", "label", "title", "name")
+		}
+		if optionText == "" {
+			return nil, fmt.Errorf("poll option %d has no text", i)
+		}
+		options = append(options, map[string]interface{}{
+			"id":   optionID(i),
+			"text": optionText,
+		})
+	}
+
+	questionType := string(models.QuestionTypeSingle)
+	for _, key := range []string{"multiple", "allowMultiple", "multipleChoice"} {
+		if multiple, ok := record[key].(bool); ok && multiple {
+			questionType = string(models.QuestionTypeMulti)
+		}
+	}
+
+	survey := map[string]interface{}{
+		"$type": SurveyCollection,
+		"name":  text,
+		"questions": []interface{}{
+			map[string]interface{}{
+				"id":       questionID,
+				"text":     text,
+				"type":     questionType,
+				"required": true,
+				"options":  options,
+			},
+		},
+	}
+	if description := firstString(record, "description"); description != "" {
+		survey["description"] = description
+	}
+	if langs, ok := record["langs"].([]interface{}); ok {
+		survey["langs"] = langs
+	}
+	return survey, nil
+}
+
+// ResponseRecord converts a foreign vote record into a net.openmeet.survey.response record
+func ResponseRecord(record map[string]interface{}) (map[string]interface{}, error) {
+	pollURI := refURI(record["subject"])
+	if pollURI == "" {
+		pollURI = refURI(record["poll"])
+	}
+	if pollURI == "" {
+		return nil, fmt.Errorf("vote subject is required")
+	}
+
+	var indexes []interface{}
+	for _, key := range []string{"option", "choice", "answer"} {
+		if v, ok := record[key]; ok {
+			indexes = []interface{}{v}
+			break
+		}
+	}
+	if indexes == nil {
+		for _, key := range []string{"options", "choices", "answers"} {
+			if v, ok := record[key].([]interface{}); ok {
+				indexes = v
+				break
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("vote has no selected option")
+	}
+
+	selected := make([]interface{}, 0, len(indexes))
+	for _, raw := range indexes {
+		// JSON numbers decode as float64
+		index, ok := raw.(float64)
+		if !ok || index < 0 || index != float64(int(index)) {
+			return nil, fmt.Errorf("invalid option index %v", raw)
+		}
+		selected = append(selected, optionID(int(index)))
+	}
+
+	return map[string]interface{}{
+		"$type":   SurveyCollection + ".response",
+		"subject": map[string]interface{}{"uri": pollURI},
+		"answers": []interface{}{
+			map[string]interface{}{
+				"questionId":      questionID,
+				"selectedOptions": selected,
+			},
+		},
+	}, nil
+}
+
+// PollRecord builds a simplified poll record in a foreign lexicon for a survey
+// published at sourceURI, linking back to it through "source". Only surveys with
+// a single choice question can be expressed as a poll; ok is false for all others.
+func PollRecord(collection, sourceURI, sourceCID string, def *models.SurveyDefinition, createdAt string) (map[string]interface{}, bool) {
+	if len(def.Questions) != 1 {
+		return nil, false
+	}
+	q := def.Questions[0]
+	if q.Type != models.QuestionTypeSingle && q.Type != models.QuestionTypeMulti {
+		return nil, false
+	}
+
+	options := make([]string, 0, len(q.Options))
+	for _, opt := range q.Options {
+		options = append(options, opt.Text)
+	}
+
+	return map[string]interface{}{
+		"$type":     collection,
+		"question":  q.Text,
+		"options":   options,
+		"multiple":  q.Type == models.QuestionTypeMulti,
+		"source":    map[string]string{"uri": sourceURI, "cid": sourceCID},
+		"createdAt": createdAt,
+	}, true
+}
+
+// optionID returns the option ID for a zero-based option index
+func optionID(index int) string {
+	return fmt.Sprintf("opt-%d", index)
+}
+
+// firstString returns the first non-empty string among the given keys
+func firstString(record map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := record[key].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// refURI returns the URI of a strong ref ({"uri": ...}) or a plain URI string
+func refURI(v interface{}) string {
+	switch ref := v.(type) {
+	case string:
+		return ref
+	case map[string]interface{}:
+		uri, _ := ref["uri"].(string)
+		return uri
+	}
+	return ""
+}
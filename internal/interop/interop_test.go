@@ -0,0 +1,182 @@
+package interop
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decode parses a JSON record the way Jetstream messages are decoded
+func decode(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(s), &record))
+	return record
+}
+
+func TestSurveyRecord(t *testing.T) {
+	record, err := SurveyRecord(decode(t, `{
+		"question": "Tabs or spaces?",
+		"options": ["Tabs", {"text": "Spaces"}],
+		"description": "The eternal question"
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Tabs or spaces?", record["name"])
+	assert.Equal(t, "The eternal question", record["description"])
+
+	questions := record["questions"].([]interface{})
+	require.Len(t, questions, 1)
+	q := questions[0].(map[string]interface{})
+	assert.Equal(t, "q1", q["id"])
+	assert.Equal(t, "single", q["type"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "opt-0", "text": "Tabs"},
+		map[string]interface{}{"id": "opt-1", "text": "Spaces"},
+	}, q["options"])
+}
+
+func TestSurveyRecord_AlternativeFields(t *testing.T) {
+	record, err := SurveyRecord(decode(t, `{
+		"title": "Lunch?",
+		"choices": [{"label": "Pizza"}, {"label": "Salad"}],
+		"allowMultiple": true
+	}`))
+	require.NoError(t, err)
+
+	q := record["questions"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Lunch?", q["text"])
+	assert.Equal(t, "multi", q["type"])
+}
+
+func TestSurveyRecord_Invalid(t *testing.T) {
+	for name, record := range map[string]string{
+		"no question":     `{"options": ["A", "B"]}`,
+		"one option":      `{"question": "Q", "options": ["A"]}`,
+		"empty option":    `{"question": "Q", "options": ["A", ""]}`,
+		"option not text": `{"question": "Q", "options": ["A", 2]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := SurveyRecord(decode(t, record))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSurveyRecord_SkipsCrossPublished(t *testing.T) {
+	_, err := SurveyRecord(decode(t, `{
+		"question": "Q",
+		"options": ["A", "B"],
+		"source": {"uri": "at://did:plc:abc/net.openmeet.survey/3k", "cid": "bafy"}
+	}`))
+	assert.ErrorIs(t, err, ErrCrossPublished)
+
+	// A source in another lexicon is not ours
+	_, err = SurveyRecord(decode(t, `{
+		"question": "Q",
+		"options": ["A", "B"],
+		"source": "at://did:plc:abc/com.example.poll/3k"
+	}`))
+	assert.NoError(t, err)
+}
+
+func TestResponseRecord(t *testing.T) {
+	tests := map[string]struct {
+		record   string
+		selected []interface{}
+	}{
+		"strong ref subject": {
+			`{"subject": {"uri": "at://did:plc:abc/com.example.poll/3k", "cid": "bafy"}, "option": 1}`,
+			[]interface{}{"opt-1"},
+		},
+		"poll URI and choice": {
+			`{"poll": "at://did:plc:abc/com.example.poll/3k", "choice": 0}`,
+			[]interface{}{"opt-0"},
+		},
+		"multiple options": {
+			`{"subject": "at://did:plc:abc/com.example.poll/3k", "options": [0, 2]}`,
+			[]interface{}{"opt-0", "opt-2"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			record, err := ResponseRecord(decode(t, tt.record))
+			require.NoError(t, err)
+
+			assert.Equal(t, "at://did:plc:abc/com.example.poll/3k", record["subject"].(map[string]interface{})["uri"])
+			answer := record["answers"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, "q1", answer["questionId"])
+			assert.Equal(t, tt.selected, answer["selectedOptions"])
+		})
+	}
+}
+
+func TestResponseRecord_Invalid(t *testing.T) {
+	for name, record := range map[string]string{
+		"no subject":         `{"option": 0}`,
+		"no option":          `{"subject": "at://did:plc:abc/com.example.poll/3k"}`,
+		"negative index":     `{"subject": "at://did:plc:abc/com.example.poll/3k", "option": -1}`,
+		"fractional index":   `{"subject": "at://did:plc:abc/com.example.poll/3k", "option": 0.5}`,
+		"option text":        `{"subject": "at://did:plc:abc/com.example.poll/3k", "option": "A"}`,
+		"empty options list": `{"subject": "at://did:plc:abc/com.example.poll/3k", "options": []}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ResponseRecord(decode(t, record))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPollRecord(t *testing.T) {
+	def := &models.SurveyDefinition{
+		Questions: []models.Question{{
+			ID:   "color",
+			Text: "Favorite color?",
+			Type: models.QuestionTypeSingle,
+			Options: []models.Option{
+				{ID: "red", Text: "Red"},
+				{ID: "blue", Text: "Blue"},
+			},
+		}},
+	}
+
+	record, ok := PollRecord("com.example.poll", "at://did:plc:abc/net.openmeet.survey/3k", "bafy", def, "2026-01-01T00:00:00Z")
+	require.True(t, ok)
+	assert.Equal(t, "com.example.poll", record["$type"])
+	assert.Equal(t, "Favorite color?", record["question"])
+	assert.Equal(t, []string{"Red", "Blue"}, record["options"])
+	assert.Equal(t, false, record["multiple"])
+
+	// The published record round-trips through the inbound adapter as cross-published
+	data, err := json.Marshal(record)
+	require.NoError(t, err)
+	_, err = SurveyRecord(decode(t, string(data)))
+	assert.ErrorIs(t, err, ErrCrossPublished)
+
+	def.Questions = append(def.Questions, models.Question{ID: "why", Text: "Why?", Type: models.QuestionTypeText})
+	_, ok = PollRecord("com.example.poll", "at://did:plc:abc/net.openmeet.survey/3k", "bafy", def, "2026-01-01T00:00:00Z")
+	assert.False(t, ok, "multi-question surveys cannot be expressed as a poll")
+}
+
+func TestLexiconsFromEnv(t *testing.T) {
+	t.Setenv("FOREIGN_POLL_LEXICONS", " com.example.poll=com.example.poll.vote, org.other.poll ,,net.openmeet.survey")
+
+	assert.Equal(t, []Lexicon{
+		{Poll: "com.example.poll", Vote: "com.example.poll.vote"},
+		{Poll: "org.other.poll"},
+	}, LexiconsFromEnv())
+
+	t.Setenv("FOREIGN_POLL_LEXICONS", "")
+	assert.Empty(t, LexiconsFromEnv())
+}
+
+func TestIsForeignURI(t *testing.T) {
+	assert.False(t, IsForeignURI("at://did:plc:abc/net.openmeet.survey/3k"))
+	assert.False(t, IsForeignURI("at://did:plc:abc/net.openmeet.survey.response/3k"))
+	assert.True(t, IsForeignURI("at://did:plc:abc/com.example.poll/3k"))
+	assert.False(t, IsForeignURI("not a uri"))
+}
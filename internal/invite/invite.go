@@ -0,0 +1,144 @@
+// Package invite gives the invitees of surveys with invite visibility their
+// own one-time links. The author uploads a list of email addresses and DIDs,
+// and each invitee gets a random "inv_" token, added to the survey's link,
+// that opens the survey and submits one response. Only the SHA-256 hash of a
+// token is stored, so links are shown once, when created; the service sends
+// no email, so the author passes them on.
+package invite
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenPrefix starts every invite token
+const TokenPrefix = "inv_"
+
+// Limits
+const (
+	MaxInvitesPerSurvey = 1000 // Invitees of a survey
+	maxInviteeLength    = 254  // Characters of an email address or DID
+	prefixLength        = 11   // Characters of the token kept for display, including "inv_"
+)
+
+// ErrUsed is returned when an invite's response was already submitted
+var ErrUsed = errors.New("invite link already used")
+
+// Invite is the one-time link of an invitee of a survey. The token itself is never stored.
+type Invite struct {
+	ID        uuid.UUID  `json:"id"`
+	SurveyID  uuid.UUID  `json:"surveyId"`
+	Invitee   string     `json:"invitee"` // Lowercase email address, or DID
+	Prefix    string     `json:"prefix"`  // Start of the token, to tell links apart
+	Hash      string     `json:"-"`       // Hex SHA-256 of the token
+	CreatedBy string     `json:"createdBy"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"` // When the invitee responded
+	// ResponseID is the invitee's response, kept unless the survey is anonymous
+	ResponseID *uuid.UUID `json:"responseId,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Store persists invites
+type Store interface {
+	// CreateInvites saves new invites of a survey
+	CreateInvites(ctx context.Context, invites []*Invite) error
+	// ListInvites returns a survey's invites, oldest first
+	ListInvites(ctx context.Context, surveyID uuid.UUID) ([]*Invite, error)
+	// GetInviteByHash returns sql.ErrNoRows if the survey has no invite with the hash
+	GetInviteByHash(ctx context.Context, surveyID uuid.UUID, hash string) (*Invite, error)
+	// UseInvite marks an unused invite used at now, or returns ErrUsed
+	UseInvite(ctx context.Context, id uuid.UUID, now time.Time) error
+	// SetInviteResponse records the response submitted with a used invite
+	SetInviteResponse(ctx context.Context, id, responseID uuid.UUID) error
+	// ReleaseInvite marks an invite unused again, after its response failed
+	ReleaseInvite(ctx context.Context, id uuid.UUID) error
+	// DeleteInvite returns sql.ErrNoRows if the survey has no such invite
+	DeleteInvite(ctx context.Context, id, surveyID uuid.UUID) error
+}
+
+// NormalizeInvitee returns the lowercase email address or the DID of an
+// invitee as entered
+func NormalizeInvitee(invitee string) (string, error) {
+	invitee = strings.TrimSpace(invitee)
+	if invitee == "" {
+		return "", errors.New("invitee is empty")
+	}
+	if len(invitee) > maxInviteeLength {
+		return "", fmt.Errorf("invitee %.20s... is longer than %d characters", invitee, maxInviteeLength)
+	}
+	if strings.HasPrefix(invitee, "did:") {
+		method, id, ok := strings.Cut(strings.TrimPrefix(invitee, "did:"), ":")
+		if !ok || method == "" || id == "" || strings.ContainsAny(invitee, " \t") {
+			return "", fmt.Errorf("invalid DID %q", invitee)
+		}
+		return invitee, nil
+	}
+
+	addr, err := mail.ParseAddress(invitee)
+	if err != nil || addr.Address != invitee {
+		return "", fmt.Errorf("%q is not an email address or DID", invitee)
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+// New creates the invite of an invitee of a survey and returns it with the
+// token itself, which must be shown to the author now as it cannot be recovered
+func New(surveyID uuid.UUID, invitee, createdBy string) (*Invite, string, error) {
+	invitee, err := NormalizeInvitee(invitee)
+	if err != nil {
+		return nil, "", err
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate invite token: %w", err)
+	}
+	token := TokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return &Invite{
+		ID:        uuid.New(),
+		SurveyID:  surveyID,
+		Invitee:   invitee,
+		Prefix:    token[:prefixLength],
+		Hash:      Hash(token),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}, token, nil
+}
+
+// Hash returns the hex SHA-256 of a token, as stored
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Rate summarizes how many of a survey's invitees responded
+type Rate struct {
+	Invited   int     `json:"invited"`
+	Responded int     `json:"responded"`
+	Rate      float64 `json:"rate"` // Responded over invited, from 0 to 1
+}
+
+// ResponseRate returns the response rate of a survey's invites
+func ResponseRate(invites []*Invite) Rate {
+	r := Rate{Invited: len(invites)}
+	for _, inv := range invites {
+		if inv.UsedAt != nil {
+			r.Responded++
+		}
+	}
+	if r.Invited > 0 {
+		r.Rate = float64(r.Responded) / float64(r.Invited)
+	}
+	return r
+}
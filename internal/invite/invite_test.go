@@ -0,0 +1,61 @@
+package invite
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeInvitee(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{" Alice@Example.com ", "alice@example.com"},
+		{"did:plc:abc123", "did:plc:abc123"},
+		{"did:web:example.com", "did:web:example.com"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeInvitee(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got)
+	}
+
+	for _, in := range []string{"", "alice", "Alice <alice@example.com>", "did:plc", "did::abc", "@alice.bsky.social", strings.Repeat("a", 250) + "@example.com"} {
+		_, err := NormalizeInvitee(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestNew(t *testing.T) {
+	surveyID := uuid.New()
+	inv, token, err := New(surveyID, "Bob@Example.com", "did:plc:alice")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(token, TokenPrefix))
+	assert.Equal(t, "bob@example.com", inv.Invitee)
+	assert.Equal(t, surveyID, inv.SurveyID)
+	assert.Equal(t, Hash(token), inv.Hash)
+	assert.True(t, strings.HasPrefix(token, inv.Prefix))
+	assert.Len(t, inv.Prefix, prefixLength)
+
+	_, other, err := New(surveyID, "carol@example.com", "did:plc:alice")
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+
+	_, _, err = New(surveyID, "not an invitee", "did:plc:alice")
+	assert.Error(t, err)
+}
+
+func TestResponseRate(t *testing.T) {
+	assert.Equal(t, Rate{}, ResponseRate(nil))
+
+	used := time.Now()
+	rate := ResponseRate([]*Invite{{UsedAt: &used}, {}, {}, {UsedAt: &used}})
+	assert.Equal(t, 4, rate.Invited)
+	assert.Equal(t, 2, rate.Responded)
+	assert.InDelta(t, 0.5, rate.Rate, 0.001)
+}
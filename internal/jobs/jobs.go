@@ -0,0 +1,321 @@
+// Package jobs runs long-running work, such as response exports, from a
+// queue in Postgres rather than in request handlers. Jobs are claimed with
+// SELECT ... FOR UPDATE SKIP LOCKED, so any number of instances can work the
+// queue; failed jobs are retried with exponential backoff, and jobs can be
+// scheduled to run later. Each job type runs at most Concurrency jobs at
+// once per instance.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+const (
+	// DefaultConcurrency is how many jobs of a type run at once per instance
+	DefaultConcurrency = 2
+	// DefaultMaxAttempts is how many times a job runs before it fails
+	DefaultMaxAttempts = 5
+	// DefaultTimeout is how long a job can run before it is cancelled
+	DefaultTimeout = 10 * time.Minute
+	// LeaseGrace is how long after its timeout a running job is considered
+	// abandoned by its instance, and claimed again
+	LeaseGrace = time.Minute
+	// PollInterval is how often each job type looks for due jobs
+	PollInterval = 2 * time.Second
+	// KeepFinished is how long done and failed jobs are kept
+	KeepFinished = 7 * 24 * time.Hour
+	// StatsInterval is how often the queue metrics are sampled and old jobs deleted
+	StatsInterval = time.Minute
+)
+
+// Statuses
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Job is a unit of queued work
+type Job struct {
+	ID          uuid.UUID
+	Type        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int // Runs so far, including the current one
+	MaxAttempts int
+	RunAt       time.Time  // When the job is due
+	LockedUntil *time.Time // When a running job is considered abandoned
+	LastError   *string
+	CreatedAt   time.Time
+	FinishedAt  *time.Time
+}
+
+// FinalAttempt reports whether the job fails if this run fails
+func (j *Job) FinalAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
+
+// Count is the number of jobs of a type in a status
+type Count struct {
+	Type   string
+	Status string
+	Jobs   int
+}
+
+// Store persists jobs
+type Store interface {
+	// EnqueueJob queues a job
+	EnqueueJob(ctx context.Context, job *Job) error
+	// ClaimJobs marks up to limit due jobs of a type, and running jobs whose
+	// lease expired, as running until now+lease, counting an attempt, and
+	// returns them. Concurrent claims never return the same job.
+	ClaimJobs(ctx context.Context, jobType string, now time.Time, lease time.Duration, limit int) ([]*Job, error)
+	// CompleteJob marks a job as done
+	CompleteJob(ctx context.Context, id uuid.UUID, now time.Time) error
+	// RetryJob makes a job pending again, due at runAt
+	RetryJob(ctx context.Context, id uuid.UUID, runAt time.Time, reason string) error
+	// FailJob marks a job as failed
+	FailJob(ctx context.Context, id uuid.UUID, reason string, now time.Time) error
+	// DeleteFinishedJobs deletes the jobs that finished before a time,
+	// returning how many were deleted
+	DeleteFinishedJobs(ctx context.Context, before time.Time) (int, error)
+	// CountJobs counts the pending and running jobs by type and status
+	CountJobs(ctx context.Context) ([]Count, error)
+}
+
+// Handler runs a job. Returning an error retries the job, unless the error
+// is Permanent or this was its final attempt.
+type Handler func(ctx context.Context, job *Job) error
+
+// Options configure a job type. Zero values use the defaults.
+type Options struct {
+	Concurrency int
+	MaxAttempts int
+	Timeout     time.Duration
+}
+
+// permanentError is an error that retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error of a handler as one that retrying won't fix, so the
+// job fails at once
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether an error was marked Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Backoff is how long a job waits before its next attempt after failing
+// attempt (starting at 1): 30 seconds, doubling up to an hour
+func Backoff(attempt int) time.Duration {
+	backoff := 30 * time.Second
+	for i := 1; i < attempt && backoff < time.Hour; i++ {
+		backoff *= 2
+	}
+	return min(backoff, time.Hour)
+}
+
+// jobType is a registered job type
+type jobType struct {
+	name    string
+	handler Handler
+	options Options
+}
+
+// Queue enqueues jobs and runs them with the handlers of their types
+type Queue struct {
+	store Store
+	types map[string]*jobType
+}
+
+// NewQueue creates a queue of a store
+func NewQueue(store Store) *Queue {
+	return &Queue{store: store, types: make(map[string]*jobType)}
+}
+
+// Register sets the handler of a job type. Types are registered before Start.
+func (q *Queue) Register(name string, handler Handler, options Options) {
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultConcurrency
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultMaxAttempts
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	q.types[name] = &jobType{name: name, handler: handler, options: options}
+}
+
+// Enqueue queues a job of a registered type with a JSON payload, due at
+// runAt, or now if runAt is zero
+func (q *Queue) Enqueue(ctx context.Context, name string, payload any, runAt time.Time) (*Job, error) {
+	t, ok := q.types[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown job type %q", name)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if runAt.IsZero() {
+		runAt = now
+	}
+	job := &Job{
+		ID:          uuid.New(),
+		Type:        name,
+		Payload:     data,
+		Status:      StatusPending,
+		MaxAttempts: t.options.MaxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   now,
+	}
+	if err := q.store.EnqueueJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Start runs due jobs of the registered types until ctx is cancelled, then
+// waits for running jobs to finish
+func (q *Queue) Start(ctx context.Context, interval time.Duration) {
+	var wg sync.WaitGroup
+	for _, t := range q.types {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, t, interval)
+		}()
+	}
+
+	ticker := time.NewTicker(StatsInterval)
+	defer ticker.Stop()
+	for {
+		q.sample(ctx)
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// work claims due jobs of a type every interval, running up to its
+// concurrency at once, until ctx is cancelled
+func (q *Queue) work(ctx context.Context, t *jobType, interval time.Duration) {
+	slots := make(chan struct{}, t.options.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if free := cap(slots) - len(slots); free > 0 {
+			claimed, err := q.store.ClaimJobs(ctx, t.name, time.Now(), t.options.Timeout+LeaseGrace, free)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Error claiming %s jobs: %v", t.name, err)
+			}
+			for _, job := range claimed {
+				slots <- struct{}{}
+				wg.Add(1)
+				go func() {
+					defer func() { <-slots; wg.Done() }()
+					// Jobs finish after ctx is cancelled, within their timeout
+					q.run(context.WithoutCancel(ctx), t, job)
+				}()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run runs a claimed job and records its outcome
+func (q *Queue) run(ctx context.Context, t *jobType, job *Job) {
+	started := time.Now()
+	err := q.call(ctx, t, job)
+	telemetry.JobDuration.WithLabelValues(t.name).Observe(time.Since(started).Seconds())
+
+	now := time.Now()
+	var result string
+	switch {
+	case err == nil:
+		result = "success"
+		err = q.store.CompleteJob(ctx, job.ID, now)
+	case IsPermanent(err) || job.FinalAttempt():
+		result = "failed"
+		log.Printf("Job %s (%s) failed after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+		err = q.store.FailJob(ctx, job.ID, err.Error(), now)
+	default:
+		result = "retry"
+		log.Printf("Job %s (%s) failed, retrying: %v", job.ID, job.Type, err)
+		err = q.store.RetryJob(ctx, job.ID, now.Add(Backoff(job.Attempts)), err.Error())
+	}
+	telemetry.JobsProcessedTotal.WithLabelValues(t.name, result).Inc()
+	if err != nil {
+		log.Printf("Error recording outcome of job %s: %v", job.ID, err)
+	}
+}
+
+// call calls the handler of a job with its timeout, turning panics into errors
+func (q *Queue) call(ctx context.Context, t *jobType, job *Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, t.options.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return t.handler(ctx, job)
+}
+
+// sample sets the queue metrics and deletes jobs that finished long ago
+func (q *Queue) sample(ctx context.Context) {
+	counts, err := q.store.CountJobs(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Error counting jobs: %v", err)
+		}
+		return
+	}
+	for name := range q.types {
+		for _, status := range []string{StatusPending, StatusRunning} {
+			telemetry.JobsQueued.WithLabelValues(name, status).Set(0)
+		}
+	}
+	for _, c := range counts {
+		telemetry.JobsQueued.WithLabelValues(c.Type, c.Status).Set(float64(c.Jobs))
+	}
+
+	if _, err := q.store.DeleteFinishedJobs(ctx, time.Now().Add(-KeepFinished)); err != nil {
+		log.Printf("Error deleting finished jobs: %v", err)
+	}
+}
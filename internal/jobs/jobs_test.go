@@ -0,0 +1,216 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore keeps jobs in memory
+type memoryStore struct {
+	mu   sync.Mutex
+	jobs []*Job
+}
+
+func (s *memoryStore) EnqueueJob(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+func (s *memoryStore) ClaimJobs(ctx context.Context, jobType string, now time.Time, lease time.Duration, limit int) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var claimed []*Job
+	for _, j := range s.jobs {
+		if len(claimed) == limit {
+			break
+		}
+		due := j.Status == StatusPending && !j.RunAt.After(now)
+		abandoned := j.Status == StatusRunning && j.LockedUntil.Before(now)
+		if j.Type == jobType && (due || abandoned) {
+			lockedUntil := now.Add(lease)
+			j.Status, j.Attempts, j.LockedUntil = StatusRunning, j.Attempts+1, &lockedUntil
+			copied := *j
+			claimed = append(claimed, &copied)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *memoryStore) job(id uuid.UUID) *Job {
+	for _, j := range s.jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) CompleteJob(ctx context.Context, id uuid.UUID, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := s.job(id)
+	j.Status, j.FinishedAt = StatusDone, &now
+	return nil
+}
+
+func (s *memoryStore) RetryJob(ctx context.Context, id uuid.UUID, runAt time.Time, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := s.job(id)
+	j.Status, j.RunAt, j.LastError = StatusPending, runAt, &reason
+	return nil
+}
+
+func (s *memoryStore) FailJob(ctx context.Context, id uuid.UUID, reason string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := s.job(id)
+	j.Status, j.LastError, j.FinishedAt = StatusFailed, &reason, &now
+	return nil
+}
+
+func (s *memoryStore) DeleteFinishedJobs(ctx context.Context, before time.Time) (int, error) {
+	return 0, nil
+}
+
+func (s *memoryStore) CountJobs(ctx context.Context) ([]Count, error) {
+	return nil, nil
+}
+
+func (s *memoryStore) status(id uuid.UUID) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.job(id).Status
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 2*time.Minute, Backoff(3))
+	assert.Equal(t, time.Hour, Backoff(20))
+}
+
+func TestPermanent(t *testing.T) {
+	cause := errors.New("bad payload")
+	err := Permanent(cause)
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, cause)
+	assert.False(t, IsPermanent(cause))
+}
+
+func TestEnqueue(t *testing.T) {
+	store := &memoryStore{}
+	queue := NewQueue(store)
+	queue.Register("export", func(ctx context.Context, job *Job) error { return nil }, Options{MaxAttempts: 3})
+
+	_, err := queue.Enqueue(context.Background(), "unknown", nil, time.Time{})
+	assert.Error(t, err)
+
+	later := time.Now().Add(time.Hour)
+	job, err := queue.Enqueue(context.Background(), "export", map[string]string{"exportId": "1"}, later)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, job.Status)
+	assert.Equal(t, 3, job.MaxAttempts)
+	assert.Equal(t, later, job.RunAt, "jobs can be scheduled")
+	assert.JSONEq(t, `{"exportId":"1"}`, string(job.Payload))
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{}
+	queue := NewQueue(store)
+
+	errFlaky := errors.New("flaky")
+	outcomes := map[string]error{
+		"ok":        nil,
+		"flaky":     errFlaky,
+		"permanent": Permanent(errors.New("invalid")),
+	}
+	queue.Register("test", func(ctx context.Context, job *Job) error {
+		if string(job.Payload) == `"panic"` {
+			panic("boom")
+		}
+		return outcomes[string(job.Payload[1:len(job.Payload)-1])]
+	}, Options{MaxAttempts: 2})
+
+	enqueue := func(payload string) *Job {
+		job, err := queue.Enqueue(ctx, "test", payload, time.Time{})
+		require.NoError(t, err)
+		return job
+	}
+	ok, flaky, permanent, panics := enqueue("ok"), enqueue("flaky"), enqueue("permanent"), enqueue("panic")
+
+	runDue := func(now time.Time) {
+		claimed, err := store.ClaimJobs(ctx, "test", now, time.Minute, 10)
+		require.NoError(t, err)
+		for _, job := range claimed {
+			queue.run(ctx, queue.types["test"], job)
+		}
+	}
+
+	runDue(time.Now())
+	assert.Equal(t, StatusDone, store.status(ok.ID))
+	assert.Equal(t, StatusPending, store.status(flaky.ID), "failed jobs are retried")
+	assert.True(t, store.job(flaky.ID).RunAt.After(time.Now().Add(20*time.Second)), "retries back off")
+	assert.Equal(t, StatusFailed, store.status(permanent.ID), "permanent errors are not retried")
+	assert.Equal(t, StatusPending, store.status(panics.ID), "panics are errors")
+	assert.Equal(t, "panic: boom", *store.job(panics.ID).LastError)
+
+	runDue(time.Now().Add(Backoff(1)))
+	assert.Equal(t, StatusFailed, store.status(flaky.ID), "jobs fail after their last attempt")
+	assert.Equal(t, 2, store.job(flaky.ID).Attempts)
+}
+
+func TestStart_Concurrency(t *testing.T) {
+	store := &memoryStore{}
+	queue := NewQueue(store)
+
+	var running, peak, done atomic.Int32
+	release := make(chan struct{})
+	queue.Register("slow", func(ctx context.Context, job *Job) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		running.Add(-1)
+		done.Add(1)
+		return nil
+	}, Options{Concurrency: 2})
+
+	for i := 0; i < 5; i++ {
+		_, err := queue.Enqueue(context.Background(), "slow", i, time.Time{})
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		queue.Start(ctx, 5*time.Millisecond)
+		close(stopped)
+	}()
+
+	require.Eventually(t, func() bool { return running.Load() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(2), running.Load(), "at most Concurrency jobs of a type run at once")
+
+	close(release)
+	require.Eventually(t, func() bool { return done.Load() == 5 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), peak.Load())
+
+	cancel()
+	<-stopped
+}
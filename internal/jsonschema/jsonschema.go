@@ -0,0 +1,354 @@
+// Package jsonschema generates JSON Schemas (draft 2020-12) from Go structs
+// and validates decoded JSON documents against them. It covers the subset of
+// the specification the generated schemas use: types, properties, required
+// properties, items, enums, lengths, patterns, bounds, and $defs references.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Draft is the $schema of generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *int64             `json:"minimum,omitempty"`
+	Maximum              *int64             `json:"maximum,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+
+	pattern *regexp.Regexp // Compiled Pattern, set by Compile
+}
+
+// Int returns a pointer to n, for the bounds of a schema
+func Int(n int) *int { return &n }
+
+// Int64 returns a pointer to n, for the bounds of a schema
+func Int64(n int64) *int64 { return &n }
+
+// Reflect generates the schema of a struct type. The struct is the root
+// schema and the structs it contains are $defs, named after their Go type.
+// Properties are named by their json tags; those without omitempty are
+// required, except booleans, which default to false. Unknown properties are
+// not allowed.
+func Reflect(t reflect.Type) *Schema {
+	root := &Schema{Schema: Draft, Defs: make(map[string]*Schema)}
+	r := reflector{defs: root.Defs}
+	r.object(indirect(t), root)
+	if len(root.Defs) == 0 {
+		root.Defs = nil
+	}
+	return root
+}
+
+type reflector struct {
+	defs map[string]*Schema
+}
+
+// object fills s with the properties of struct type t
+func (r reflector) object(t reflect.Type, s *Schema) {
+	s.Type = "object"
+	s.Properties = make(map[string]*Schema)
+	s.AdditionalProperties = new(bool)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = r.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Bool {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// schema returns the schema of a field type, adding the structs it uses to the $defs
+func (r reflector) schema(t reflect.Type) *Schema {
+	t = indirect(t)
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := r.defs[t.Name()]; !ok {
+			def := &Schema{}
+			r.defs[t.Name()] = def // Before the fields, for recursive types
+			r.object(t, def)
+		}
+		return &Schema{Ref: "#/$defs/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: r.schema(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		return &Schema{} // Anything
+	}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Compile checks the patterns of a schema and its subschemas, and prepares
+// them for Validate
+func (s *Schema) Compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, sub := range s.subschemas() {
+		if err := sub.Compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) subschemas() []*Schema {
+	var subs []*Schema
+	for _, key := range sortedKeys(s.Properties) {
+		subs = append(subs, s.Properties[key])
+	}
+	for _, key := range sortedKeys(s.Defs) {
+		subs = append(subs, s.Defs[key])
+	}
+	if s.Items != nil {
+		subs = append(subs, s.Items)
+	}
+	return subs
+}
+
+// ValidationError is a value that does not match its schema
+type ValidationError struct {
+	Path    string // JSON pointer of the value, "" for the document
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Validate validates a document decoded by encoding/json, returning all its
+// errors. Numbers may be float64 or json.Number. The schema must be compiled.
+func (s *Schema) Validate(doc interface{}) []*ValidationError {
+	v := validator{root: s}
+	v.validate(s, doc, "")
+	return v.errs
+}
+
+type validator struct {
+	root *Schema
+	errs []*ValidationError
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(s *Schema, value interface{}, path string) {
+	if s.Ref != "" {
+		def, ok := v.root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			v.fail(path, "unresolved reference %s", s.Ref)
+			return
+		}
+		s = def
+	}
+
+	if s.Type != "" && typeOf(value) != s.Type && !(s.Type == "number" && typeOf(value) == "integer") {
+		v.fail(path, "must be %s %s, not %s", article(s.Type), s.Type, typeOf(value))
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		v.object(s, value, path)
+	case []interface{}:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			v.fail(path, "must have at least %d %s", *s.MinItems, plural(*s.MinItems, "item"))
+		}
+		if s.MaxItems != nil && len(value) > *s.MaxItems {
+			v.fail(path, "must have at most %d %s", *s.MaxItems, plural(*s.MaxItems, "item"))
+		}
+		if s.Items != nil {
+			for i, item := range value {
+				v.validate(s.Items, item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	case string:
+		v.string(s, value, path)
+	default:
+		if n, ok := integer(value); ok {
+			if s.Minimum != nil && n < *s.Minimum {
+				v.fail(path, "must be at least %d", *s.Minimum)
+			}
+			if s.Maximum != nil && n > *s.Maximum {
+				v.fail(path, "must be at most %d", *s.Maximum)
+			}
+		}
+	}
+}
+
+func (v *validator) object(s *Schema, value map[string]interface{}, path string) {
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			v.fail(path, "missing required property %q", name)
+		}
+	}
+	for _, name := range sortedKeys(value) {
+		prop, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				v.fail(path+"/"+escapePointer(name), "unknown property %q", name)
+			}
+			continue
+		}
+		v.validate(prop, value[name], path+"/"+escapePointer(name))
+	}
+}
+
+func (v *validator) string(s *Schema, value, path string) {
+	if len(s.Enum) > 0 && !contains(s.Enum, value) {
+		v.fail(path, "must be one of %s", strings.Join(quoteAll(s.Enum), ", "))
+		return
+	}
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		if *s.MinLength == 1 {
+			v.fail(path, "must not be empty")
+		} else {
+			v.fail(path, "must be at least %d characters", *s.MinLength)
+		}
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.fail(path, "must be at most %d characters", *s.MaxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		v.fail(path, "must match %s", s.Pattern)
+	}
+}
+
+// typeOf returns the JSON Schema type of a decoded value
+func typeOf(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		if value == float64(int64(value)) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// integer returns the value of an integer of a decoded document
+func integer(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case json.Number:
+		n, err := value.Int64()
+		return n, err == nil
+	case float64:
+		return int64(value), value == float64(int64(value))
+	}
+	return 0, false
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return noun
+	}
+	return noun + "s"
+}
+
+func article(typ string) string {
+	if typ == "array" || typ == "object" || typ == "integer" {
+		return "an"
+	}
+	return "a"
+}
+
+// escapePointer escapes a property name for a JSON pointer (RFC 6901)
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return quoted
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
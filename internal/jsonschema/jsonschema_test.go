@@ -0,0 +1,90 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type poll struct {
+	Title   string   `json:"title"`
+	Closed  bool     `json:"closed"`
+	Note    string   `json:"note,omitempty"`
+	Choices []choice `json:"choices"`
+	Owner   *owner   `json:"owner,omitempty"`
+	secret  string
+}
+
+type choice struct {
+	ID    string `json:"id"`
+	Votes int    `json:"votes,omitempty"`
+}
+
+type owner struct {
+	Name string `json:"name"`
+}
+
+func TestReflect(t *testing.T) {
+	s := Reflect(reflect.TypeOf(poll{}))
+
+	assert.Equal(t, Draft, s.Schema)
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, []string{"title", "choices"}, s.Required, "booleans and omitempty fields are optional")
+	assert.False(t, *s.AdditionalProperties)
+	assert.NotContains(t, s.Properties, "secret")
+
+	assert.Equal(t, "array", s.Properties["choices"].Type)
+	assert.Equal(t, "#/$defs/choice", s.Properties["choices"].Items.Ref)
+	assert.Equal(t, "#/$defs/owner", s.Properties["owner"].Ref)
+	assert.Equal(t, "integer", s.Defs["choice"].Properties["votes"].Type)
+
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"$defs":{"choice":`)
+}
+
+func TestValidate(t *testing.T) {
+	s := Reflect(reflect.TypeOf(poll{}))
+	s.Properties["title"].MaxLength = Int(5)
+	s.Properties["choices"].MinItems = Int(1)
+	s.Defs["choice"].Properties["id"].Pattern = `^[a-z]+$`
+	s.Defs["choice"].Properties["votes"].Minimum = Int64(0)
+	s.Defs["owner"].Properties["name"].Enum = []string{"alice", "bob"}
+	require.NoError(t, s.Compile())
+
+	validate := func(doc string) []string {
+		var v interface{}
+		d := json.NewDecoder(strings.NewReader(doc))
+		d.UseNumber()
+		require.NoError(t, d.Decode(&v))
+		var errs []string
+		for _, e := range s.Validate(v) {
+			errs = append(errs, e.Error())
+		}
+		return errs
+	}
+
+	assert.Empty(t, validate(`{"title": "Lunch", "choices": [{"id": "a", "votes": 3}], "owner": {"name": "bob"}}`))
+	assert.Equal(t, []string{`missing required property "choices"`}, validate(`{"title": "Lunch"}`))
+	assert.Equal(t, []string{
+		"/choices/0/id: must match ^[a-z]+$",
+		"/choices/0/votes: must be at least 0",
+		"/choices/1: must be an object, not string",
+		`/extra: unknown property "extra"`,
+		`/owner/name: must be one of "alice", "bob"`,
+		"/title: must be at most 5 characters",
+	}, validate(`{"title": "Dinner", "extra": 1, "choices": [{"id": "A", "votes": -1}, "b"], "owner": {"name": "eve"}}`))
+	assert.Equal(t, []string{"/choices: must have at least 1 item", "/title: must be a string, not integer"},
+		validate(`{"title": 7, "choices": []}`))
+	assert.Equal(t, []string{"/choices/0/votes: must be an integer, not number"}, validate(`{"title": "", "choices": [{"id": "a", "votes": 1.5}]}`))
+}
+
+func TestCompileRejectsBadPatterns(t *testing.T) {
+	s := Reflect(reflect.TypeOf(choice{}))
+	s.Properties["id"].Pattern = `(`
+	assert.Error(t, s.Compile())
+}
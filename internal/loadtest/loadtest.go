@@ -0,0 +1,231 @@
+// Package loadtest drives the submit path of the survey API (creating
+// surveys, submitting responses, and reading results) at a steady rate, and
+// checks the p99 latency of each endpoint against a budget, so performance
+// regressions show up in CI-sized runs.
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/pkg/client"
+)
+
+// Config configures a load test run
+type Config struct {
+	Rate         int           // Submissions started per second
+	Duration     time.Duration // How long submissions are started
+	Workers      int           // Most requests in flight; the rate drops when all are busy
+	Surveys      int           // Surveys created, and voted on in turn
+	ResultsEvery int           // One results request after every this many submissions
+}
+
+// DefaultConfig is a run short enough for CI
+var DefaultConfig = Config{
+	Rate:         50,
+	Duration:     30 * time.Second,
+	Workers:      20,
+	Surveys:      3,
+	ResultsEvery: 5,
+}
+
+// Validate checks that a config describes a run
+func (c Config) Validate() error {
+	if c.Rate <= 0 {
+		return errors.New("rate must be positive")
+	}
+	if c.Duration <= 0 {
+		return errors.New("duration must be positive")
+	}
+	if c.Workers <= 0 {
+		return errors.New("workers must be positive")
+	}
+	if c.Surveys <= 0 {
+		return errors.New("surveys must be positive")
+	}
+	if c.ResultsEvery <= 0 {
+		return errors.New("results-every must be positive")
+	}
+	return nil
+}
+
+// Definition is the definition of the surveys of a run: a question of each
+// type that results aggregate differently
+const Definition = `anonymous: true
+questions:
+  - id: day
+    text: "Which day suits you?"
+    type: single
+    required: true
+    options:
+      - {id: sat, text: "Saturday"}
+      - {id: sun, text: "Sunday"}
+      - {id: any, text: "Either"}
+  - id: topics
+    text: "Which topics interest you?"
+    type: multi
+    options:
+      - {id: go, text: "Go"}
+      - {id: web, text: "Web"}
+      - {id: ops, text: "Ops"}
+  - id: notes
+    text: "Anything else?"
+    type: text
+`
+
+// Run creates cfg.Surveys surveys and then submits responses to them at
+// cfg.Rate for cfg.Duration, reading their results every cfg.ResultsEvery
+// submissions. The run fails early only if a survey cannot be created; if
+// ctx is done first, the report of the requests so far is returned with
+// ctx's error.
+//
+// Every request comes from a new voter (see voterTransport), so the service
+// must be reached from a private network or loopback, whose X-Forwarded-For
+// headers it trusts. Otherwise rate limits and the one vote per voter rule
+// reject most submissions.
+func Run(ctx context.Context, c *client.Client, cfg Config, budgets Budgets) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	c = withVoters(c)
+	recorder := NewRecorder()
+
+	slugs := make([]string, cfg.Surveys)
+	for i := range slugs {
+		slug := "loadtest-" + uuid.NewString()[:8]
+		start := time.Now()
+		_, err := c.CreateSurvey(ctx, slug, Definition)
+		recorder.Record(EndpointCreate, time.Since(start), err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create survey: %w", err)
+		}
+		slugs[i] = slug
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				vote(ctx, c, recorder, slugs[n%len(slugs)], n, cfg.ResultsEvery)
+			}
+		}()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(time.Second / time.Duration(cfg.Rate))
+	defer ticker.Stop()
+	deadline := time.NewTimer(cfg.Duration)
+	defer deadline.Stop()
+
+send:
+	for n := 0; ; n++ {
+		select {
+		case <-ctx.Done():
+			break send
+		case <-deadline.C:
+			break send
+		case <-ticker.C:
+		}
+		select {
+		case jobs <- n:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return recorder.Report(time.Since(start), budgets), ctx.Err()
+}
+
+// vote submits the nth response to a survey, and reads its results after
+// every resultsEvery submissions. Requests cut short by ctx are not recorded.
+func vote(ctx context.Context, c *client.Client, recorder *Recorder, slug string, n, resultsEvery int) {
+	start := time.Now()
+	_, err := c.SubmitResponse(ctx, slug, answers(n))
+	if ctx.Err() != nil {
+		return
+	}
+	recorder.Record(EndpointSubmit, time.Since(start), err)
+
+	if n%resultsEvery != resultsEvery-1 {
+		return
+	}
+	start = time.Now()
+	_, err = c.GetResults(ctx, slug)
+	if ctx.Err() != nil {
+		return
+	}
+	recorder.Record(EndpointResults, time.Since(start), err)
+}
+
+// answers returns the answers of the nth response, varied so that every
+// option is counted and some responses have text
+func answers(n int) map[string]client.Answer {
+	days := []string{"sat", "sun", "any"}
+	topics := [][]string{{"go"}, {"go", "web"}, {"ops"}, {"web", "ops"}, {}}
+
+	a := map[string]client.Answer{
+		"day": {SelectedOptions: []string{days[n%len(days)]}},
+	}
+	if t := topics[n%len(topics)]; len(t) > 0 {
+		a["topics"] = client.Answer{SelectedOptions: t}
+	}
+	if n%4 == 0 {
+		a["notes"] = client.Answer{Text: fmt.Sprintf("Load test response %d", n)}
+	}
+	return a
+}
+
+// withVoters returns a copy of the client whose requests each come from a
+// new voter. The copy does not retry, so that each recorded latency and
+// failure is of a single request.
+func withVoters(c *client.Client) *client.Client {
+	httpClient := http.Client{}
+	if c.HTTPClient != nil {
+		httpClient = *c.HTTPClient
+	}
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	httpClient.Transport = &voterTransport{base: base}
+
+	copied := *c
+	copied.HTTPClient = &httpClient
+	copied.MaxRetries = 0
+	return &copied
+}
+
+// voterTransport makes each request come from a new voter, with its own user
+// agent and an X-Forwarded-For address in 198.18.0.0/15, the range reserved
+// for benchmarks. Votes would otherwise count as repeat votes of one voter,
+// and run into the per-IP rate limits.
+type voterTransport struct {
+	base http.RoundTripper
+	next atomic.Uint32
+}
+
+func (t *voterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := t.next.Add(1)
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Forwarded-For", voterIP(n))
+	req.Header.Set("User-Agent", fmt.Sprintf("survey-loadtest/1.0 (voter %d)", n))
+	return t.base.RoundTrip(req)
+}
+
+// voterIP returns the nth address of 198.18.0.0/15, wrapping around after
+// its 131072 addresses
+func voterIP(n uint32) string {
+	n %= 1 << 17
+	return fmt.Sprintf("198.%d.%d.%d", 18+n>>16, n>>8&0xff, n&0xff)
+}
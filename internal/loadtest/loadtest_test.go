@@ -0,0 +1,133 @@
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	voters := make(map[string]bool)
+	counts := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/surveys":
+			counts[EndpointCreate]++
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"slug": "s"}`))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/responses"):
+			counts[EndpointSubmit]++
+			var body struct {
+				Answers map[string]models.Answer `json:"answers"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.NotEmpty(t, body.Answers["day"].SelectedOptions)
+			voter := r.Header.Get("X-Forwarded-For") + " " + r.Header.Get("User-Agent")
+			assert.False(t, voters[voter], "voter %s repeated", voter)
+			voters[voter] = true
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/results"):
+			counts[EndpointResults]++
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	// Budgets no loaded test runner misses, so that only failed requests fail the run
+	budgets := Budgets{EndpointCreate: time.Minute, EndpointSubmit: time.Minute, EndpointResults: time.Minute}
+	cfg := Config{Rate: 200, Duration: 200 * time.Millisecond, Workers: 4, Surveys: 2, ResultsEvery: 2}
+	report, err := Run(context.Background(), client.New(server.URL, ""), cfg, budgets)
+	require.NoError(t, err)
+
+	assert.True(t, report.Passed(), report.Violations)
+	assert.Equal(t, 2, counts[EndpointCreate])
+	assert.Greater(t, counts[EndpointSubmit], 10)
+	assert.Equal(t, counts[EndpointSubmit]/2, counts[EndpointResults])
+	require.Len(t, report.Endpoints, 3)
+	for i, endpoint := range []string{EndpointCreate, EndpointSubmit, EndpointResults} {
+		assert.Equal(t, counts[endpoint], report.Endpoints[i].Successes, endpoint)
+		assert.Zero(t, report.Endpoints[i].Failures, endpoint)
+	}
+}
+
+func TestRunFailsWhenSurveyCannotBeCreated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	report, err := Run(context.Background(), client.New(server.URL, ""), DefaultConfig, DefaultBudgets)
+	assert.Nil(t, report)
+	assert.ErrorContains(t, err, "failed to create survey")
+}
+
+func TestReport(t *testing.T) {
+	r := NewRecorder()
+	for i := 1; i <= 100; i++ {
+		r.Record(EndpointSubmit, time.Duration(i)*time.Millisecond, nil)
+	}
+	r.Record(EndpointResults, 10*time.Millisecond, nil)
+	r.Record(EndpointResults, 0, &client.Error{Status: 500, Title: "Internal Server Error"})
+
+	report := r.Report(10*time.Second, Budgets{EndpointSubmit: 50 * time.Millisecond})
+	require.Len(t, report.Endpoints, 2)
+
+	submit := report.Endpoints[0]
+	assert.Equal(t, 50*time.Millisecond, submit.P50)
+	assert.Equal(t, 95*time.Millisecond, submit.P95)
+	assert.Equal(t, 99*time.Millisecond, submit.P99)
+	assert.Equal(t, 100*time.Millisecond, submit.Max)
+	assert.InDelta(t, 10.0, submit.Throughput, 0.001)
+
+	results := report.Endpoints[1]
+	assert.Equal(t, 1, results.Successes)
+	assert.Equal(t, 1, results.Failures)
+	assert.Equal(t, []string{"Internal Server Error (HTTP 500) (1)"}, results.Errors)
+
+	assert.False(t, report.Passed())
+	assert.Equal(t, []string{
+		"submit p99 99ms is over its budget of 50ms",
+		"results had 1 failed requests",
+	}, report.Violations)
+
+	var text strings.Builder
+	report.WriteText(&text)
+	assert.Contains(t, text.String(), "FAIL: submit p99 99ms is over its budget of 50ms")
+}
+
+func TestParseBudgets(t *testing.T) {
+	budgets, err := ParseBudgets("submit=100ms, results = 1s")
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, budgets[EndpointSubmit])
+	assert.Equal(t, time.Second, budgets[EndpointResults])
+	assert.Equal(t, DefaultBudgets[EndpointCreate], budgets[EndpointCreate])
+
+	for _, s := range []string{"submit", "vote=1s", "submit=fast", "submit=-1s"} {
+		_, err := ParseBudgets(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestVoterIP(t *testing.T) {
+	assert.Equal(t, "198.18.0.1", voterIP(1))
+	assert.Equal(t, "198.18.1.0", voterIP(256))
+	assert.Equal(t, "198.19.0.0", voterIP(1<<16))
+	assert.Equal(t, "198.18.0.0", voterIP(1<<17))
+}
@@ -0,0 +1,214 @@
+package loadtest
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoints whose latencies are measured
+const (
+	EndpointCreate  = "create"  // POST /api/v1/surveys
+	EndpointSubmit  = "submit"  // POST /api/v1/surveys/:slug/responses
+	EndpointResults = "results" // GET /api/v1/surveys/:slug/results
+)
+
+// endpoints lists the endpoints in report order
+var endpoints = []string{EndpointCreate, EndpointSubmit, EndpointResults}
+
+// Budgets are the p99 latency budgets of endpoints; endpoints without one are
+// measured but never fail a run
+type Budgets map[string]time.Duration
+
+// DefaultBudgets are the p99 budgets of a run on a developer machine or a
+// CI runner, with the database in a local container
+var DefaultBudgets = Budgets{
+	EndpointCreate:  500 * time.Millisecond,
+	EndpointSubmit:  250 * time.Millisecond,
+	EndpointResults: 200 * time.Millisecond,
+}
+
+// ParseBudgets parses budgets like "submit=250ms,results=200ms". Endpoints
+// not listed keep their default budget.
+func ParseBudgets(s string) (Budgets, error) {
+	budgets := make(Budgets, len(DefaultBudgets))
+	for endpoint, budget := range DefaultBudgets {
+		budgets[endpoint] = budget
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		endpoint, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("budget %q must be endpoint=duration", part)
+		}
+		endpoint = strings.TrimSpace(endpoint)
+		if _, known := DefaultBudgets[endpoint]; !known {
+			return nil, fmt.Errorf("unknown endpoint %q (want create, submit, or results)", endpoint)
+		}
+		budget, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || budget <= 0 {
+			return nil, fmt.Errorf("budget of %s must be a positive duration like 250ms", endpoint)
+		}
+		budgets[endpoint] = budget
+	}
+	return budgets, nil
+}
+
+// Recorder collects the latencies and errors of requests. It is safe for
+// concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]map[string]int // Counts by endpoint and error message
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]map[string]int),
+	}
+}
+
+// Record records a request to an endpoint. Failed requests count as errors,
+// and their latencies are left out of the percentiles.
+func (r *Recorder) Record(endpoint string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		if r.errors[endpoint] == nil {
+			r.errors[endpoint] = make(map[string]int)
+		}
+		r.errors[endpoint][err.Error()]++
+		return
+	}
+	r.latencies[endpoint] = append(r.latencies[endpoint], latency)
+}
+
+// Report summarizes the recorded requests of a run that took elapsed, and
+// checks them against the budgets
+func (r *Recorder) Report(elapsed time.Duration, budgets Budgets) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{Duration: elapsed}
+	for _, endpoint := range endpoints {
+		latencies := append([]time.Duration(nil), r.latencies[endpoint]...)
+		if len(latencies) == 0 && len(r.errors[endpoint]) == 0 {
+			continue
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		stats := EndpointStats{
+			Endpoint:  endpoint,
+			Successes: len(latencies),
+			Budget:    budgets[endpoint],
+		}
+		for message, count := range r.errors[endpoint] {
+			stats.Failures += count
+			stats.Errors = append(stats.Errors, fmt.Sprintf("%s (%d)", message, count))
+		}
+		sort.Strings(stats.Errors)
+		if elapsed > 0 {
+			stats.Throughput = float64(stats.Successes+stats.Failures) / elapsed.Seconds()
+		}
+		if len(latencies) > 0 {
+			stats.P50 = percentile(latencies, 50)
+			stats.P95 = percentile(latencies, 95)
+			stats.P99 = percentile(latencies, 99)
+			stats.Max = latencies[len(latencies)-1]
+		}
+
+		if stats.Budget > 0 && stats.P99 > stats.Budget {
+			report.Violations = append(report.Violations, fmt.Sprintf("%s p99 %s is over its budget of %s", endpoint, stats.P99, stats.Budget))
+		}
+		if stats.Failures > 0 {
+			report.Violations = append(report.Violations, fmt.Sprintf("%s had %d failed requests", endpoint, stats.Failures))
+		}
+		report.Endpoints = append(report.Endpoints, stats)
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Report is the outcome of a load test run
+type Report struct {
+	Duration   time.Duration   `json:"duration"` // Nanoseconds
+	Endpoints  []EndpointStats `json:"endpoints"`
+	Violations []string        `json:"violations,omitempty"` // Budgets exceeded and failed requests
+}
+
+// EndpointStats are the latencies of an endpoint's successful requests, in
+// nanoseconds in JSON
+type EndpointStats struct {
+	Endpoint   string        `json:"endpoint"`
+	Successes  int           `json:"successes"`
+	Failures   int           `json:"failures"`
+	Errors     []string      `json:"errors,omitempty"` // Distinct errors with their counts
+	Throughput float64       `json:"throughput"`       // Requests per second
+	P50        time.Duration `json:"p50"`
+	P95        time.Duration `json:"p95"`
+	P99        time.Duration `json:"p99"`
+	Max        time.Duration `json:"max"`
+	Budget     time.Duration `json:"budget,omitempty"` // p99 budget
+}
+
+// Passed reports whether every endpoint kept within its budget without
+// failed requests
+func (r *Report) Passed() bool {
+	return len(r.Violations) == 0
+}
+
+// WriteText writes the report as a table followed by its violations
+func (r *Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Load test of %s\n\n", r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(w, "%-8s %8s %8s %8s %10s %10s %10s %10s %10s\n", "ENDPOINT", "OK", "FAILED", "REQ/S", "P50", "P95", "P99", "MAX", "BUDGET")
+	for _, s := range r.Endpoints {
+		budget := "-"
+		if s.Budget > 0 {
+			budget = s.Budget.String()
+		}
+		fmt.Fprintf(w, "%-8s %8d %8d %8.1f %10s %10s %10s %10s %10s\n", s.Endpoint, s.Successes, s.Failures, s.Throughput,
+			round(s.P50), round(s.P95), round(s.P99), round(s.Max), budget)
+	}
+	listed := false
+	for _, s := range r.Endpoints {
+		for _, e := range s.Errors {
+			if !listed {
+				fmt.Fprintln(w)
+				listed = true
+			}
+			fmt.Fprintf(w, "%s error: %s\n", s.Endpoint, e)
+		}
+	}
+
+	fmt.Fprintln(w)
+	if r.Passed() {
+		fmt.Fprintln(w, "PASS")
+		return
+	}
+	for _, v := range r.Violations {
+		fmt.Fprintf(w, "FAIL: %s\n", v)
+	}
+}
+
+// round rounds a latency for display
+func round(d time.Duration) string {
+	return d.Round(10 * time.Microsecond).String()
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Limits of comment records, as in the net.openmeet.survey.comment lexicon
+const (
+	MaxCommentLength     = 3000 // Bytes of a comment's text
+	MaxCommentCharacters = 1000 // Characters of a comment's text
+)
+
+// Comment is a net.openmeet.survey.comment record indexed from its author's repository
+type Comment struct {
+	ID        uuid.UUID `json:"id"`
+	SurveyID  uuid.UUID `json:"surveyId"`
+	AuthorDID string    `json:"authorDid"`
+	RecordURI string    `json:"recordUri"`
+	RecordCID string    `json:"recordCid"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"` // Declared by the record, or when indexed if later
+	IndexedAt time.Time `json:"indexedAt"`
+}
+
+// CommentCursor is the position of a comment in a survey's comments, oldest first
+type CommentCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// ValidateCommentText checks the text of a comment against the lexicon's limits
+func ValidateCommentText(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("comment text is empty")
+	}
+	if len(text) > MaxCommentLength {
+		return fmt.Errorf("comment text exceeds maximum length of %d bytes", MaxCommentLength)
+	}
+	if utf8.RuneCountInString(text) > MaxCommentCharacters {
+		return fmt.Errorf("comment text exceeds maximum length of %d characters", MaxCommentCharacters)
+	}
+	return nil
+}
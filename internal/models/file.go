@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxFileSize is the most bytes of a file answering a file question
+const MaxFileSize = 1000000
+
+// FileMimeTypes are the types of files that answer file questions
+var FileMimeTypes = append(append([]string{}, ImageMimeTypes...), "application/pdf")
+
+// AllowedFileType reports whether files of a MIME type can answer file questions
+func AllowedFileType(mimeType string) bool {
+	for _, t := range FileMimeTypes {
+		if t == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// FileFieldName returns the form field of the file uploaded for a file question
+func FileFieldName(questionID string) string {
+	return questionID + ".file"
+}
+
+// HasFileQuestions reports whether any question is answered with a file
+func (d *SurveyDefinition) HasFileQuestions() bool {
+	for _, q := range d.Questions {
+		if q.Type == QuestionTypeFile {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFileAnswers reports whether any answer is a file. Files are blobs on the
+// respondent's PDS, kept only while their response record references them.
+func HasFileAnswers(answers map[string]Answer) bool {
+	for _, answer := range answers {
+		if answer.File != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// CID returns the CID of the blob
+func (b *Blob) CID() string {
+	return b.Ref.Link
+}
+
+// ValidateFile checks a blob reference answering a file question
+func (b *Blob) ValidateFile() error {
+	if b.Type != "blob" {
+		return errors.New("file must be a blob reference")
+	}
+	if !cidRegex.MatchString(b.CID()) {
+		return fmt.Errorf("invalid file CID '%s'", b.CID())
+	}
+	if !AllowedFileType(b.MimeType) {
+		return fmt.Errorf("unsupported file type '%s': must be an image or a PDF", b.MimeType)
+	}
+	if b.Size <= 0 || b.Size > MaxFileSize {
+		return fmt.Errorf("file size must be between 1 and %d bytes", MaxFileSize)
+	}
+	return nil
+}
+
+func validateFileAnswer(answer *Answer) error {
+	if answer.File == nil {
+		return errors.New("file question must be answered with an uploaded file")
+	}
+	if len(answer.SelectedOptions) > 0 || answer.Text != "" || len(answer.Rows) > 0 {
+		return errors.New("file question can only be answered with a file")
+	}
+	return answer.File.ValidateFile()
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFile(mimeType string, size int64) *Blob {
+	return &Blob{Type: "blob", Ref: BlobRef{Link: testImageCID}, MimeType: mimeType, Size: size}
+}
+
+func TestValidateAnswers_File(t *testing.T) {
+	def := &SurveyDefinition{Questions: []Question{{ID: "receipt", Text: "Upload your receipt", Type: QuestionTypeFile, Required: true}}}
+	require.NoError(t, def.ValidateDefinition())
+	assert.True(t, def.HasFileQuestions())
+
+	tests := []struct {
+		name    string
+		answer  Answer
+		wantErr string
+	}{
+		{"pdf", Answer{File: testFile("application/pdf", 5120)}, ""},
+		{"image", Answer{File: testFile("image/jpeg", MaxFileSize)}, ""},
+		{"no file", Answer{Text: "receipt.pdf"}, "uploaded file"},
+		{"file and text", Answer{File: testFile("image/png", 10), Text: "receipt"}, "only be answered with a file"},
+		{"unsupported type", Answer{File: testFile("text/html", 10)}, "unsupported file type"},
+		{"too large", Answer{File: testFile("application/pdf", MaxFileSize+1)}, "file size"},
+		{"not a blob", Answer{File: &Blob{Ref: BlobRef{Link: testImageCID}, MimeType: "application/pdf", Size: 10}}, "blob reference"},
+		{"invalid CID", Answer{File: &Blob{Type: "blob", Ref: BlobRef{Link: "../etc/passwd"}, MimeType: "application/pdf", Size: 10}}, "invalid file CID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answers := map[string]Answer{"receipt": tt.answer}
+			err := ValidateAnswers(def, answers)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				assert.True(t, HasFileAnswers(answers))
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateDefinition_FileQuestionsNotAnonymous(t *testing.T) {
+	def := &SurveyDefinition{
+		Anonymous: true,
+		Questions: []Question{{ID: "photo", Text: "Share a photo", Type: QuestionTypeFile}},
+	}
+	err := def.ValidateDefinition()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "anonymous surveys cannot have file questions")
+}
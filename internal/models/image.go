@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Image limits
+const (
+	MaxImageSize      = 1000000 // Bytes, as accepted by the lexicon
+	MaxImageAltLength = 1000
+)
+
+// ImageMimeTypes are the image types that can be attached to questions and options
+var ImageMimeTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// Image is an image attached to a question or option, in the lexicon's
+// format: a blob uploaded to the survey author's PDS, with alt text
+type Image struct {
+	Image Blob   `json:"image" yaml:"image"`
+	Alt   string `json:"alt" yaml:"alt"`
+}
+
+// Blob is an ATProto blob reference
+type Blob struct {
+	Type     string  `json:"$type" yaml:"$type"` // Always "blob"
+	Ref      BlobRef `json:"ref" yaml:"ref"`
+	MimeType string  `json:"mimeType" yaml:"mimeType"`
+	Size     int64   `json:"size" yaml:"size"`
+}
+
+// BlobRef links a blob by CID
+type BlobRef struct {
+	Link string `json:"$link" yaml:"$link"`
+}
+
+// CID returns the CID of the image blob
+func (i *Image) CID() string {
+	return i.Image.Ref.Link
+}
+
+// cidRegex matches base32 CIDv1 strings, as PDSes return for blobs
+var cidRegex = regexp.MustCompile(`^b[a-z2-7]{20,100}$`)
+
+// AllowedImageType reports whether images of a MIME type can be attached
+func AllowedImageType(mimeType string) bool {
+	for _, t := range ImageMimeTypes {
+		if t == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the blob reference and sanitizes the alt text
+func (i *Image) Validate() error {
+	if i.Image.Type != "blob" {
+		return fmt.Errorf("image must be a blob reference")
+	}
+	if !cidRegex.MatchString(i.CID()) {
+		return fmt.Errorf("invalid image CID '%s'", i.CID())
+	}
+	if !AllowedImageType(i.Image.MimeType) {
+		return fmt.Errorf("unsupported image type '%s'", i.Image.MimeType)
+	}
+	if i.Image.Size <= 0 || i.Image.Size > MaxImageSize {
+		return fmt.Errorf("image size must be between 1 and %d bytes", MaxImageSize)
+	}
+
+	i.Alt = SanitizeText(i.Alt)
+	if len(i.Alt) > MaxImageAltLength {
+		return fmt.Errorf("image alt text too long: %d characters exceeds maximum of %d", len(i.Alt), MaxImageAltLength)
+	}
+	return nil
+}
+
+// HasImage reports whether a question or option of the definition shows the image with a CID
+func (d *SurveyDefinition) HasImage(cid string) bool {
+	for _, q := range d.Questions {
+		if q.Image != nil && q.Image.CID() == cid {
+			return true
+		}
+		for _, o := range q.Options {
+			if o.Image != nil && o.Image.CID() == cid {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StripImages removes all images, for surveys without a PDS record to hold
+// their blobs. Reports whether any image was removed.
+func (d *SurveyDefinition) StripImages() bool {
+	stripped := false
+	for i := range d.Questions {
+		if d.Questions[i].Image != nil {
+			d.Questions[i].Image = nil
+			stripped = true
+		}
+		for j := range d.Questions[i].Options {
+			if d.Questions[i].Options[j].Image != nil {
+				d.Questions[i].Options[j].Image = nil
+				stripped = true
+			}
+		}
+	}
+	return stripped
+}
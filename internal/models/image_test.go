@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testImageCID = "bafkreibme22gw2h7y2h7tg2fhqotaqjucnbc24deqo72b6mkl2egezxhvy"
+
+func testImage() *Image {
+	return &Image{
+		Image: Blob{Type: "blob", Ref: BlobRef{Link: testImageCID}, MimeType: "image/png", Size: 1234},
+		Alt:   "A red square",
+	}
+}
+
+func TestParseSurveyDefinition_YAMLImage(t *testing.T) {
+	yamlData := []byte(`
+questions:
+  - id: q1
+    text: "Which logo?"
+    type: single
+    image:
+      image:
+        $type: blob
+        ref:
+          $link: ` + testImageCID + `
+        mimeType: image/png
+        size: 1234
+      alt: Both logos
+    options:
+      - id: a
+        text: "A"
+        image: {"image": {"$type": "blob", "ref": {"$link": "` + testImageCID + `"}, "mimeType": "image/jpeg", "size": 99}, "alt": ""}
+      - id: b
+        text: "B"
+`)
+
+	def, err := ParseSurveyDefinition(yamlData)
+	require.NoError(t, err)
+	require.NoError(t, def.ValidateDefinition())
+	require.NotNil(t, def.Questions[0].Image)
+	assert.Equal(t, testImageCID, def.Questions[0].Image.CID())
+	assert.Equal(t, "Both logos", def.Questions[0].Image.Alt)
+	assert.Equal(t, "image/jpeg", def.Questions[0].Options[0].Image.Image.MimeType)
+	assert.Nil(t, def.Questions[0].Options[1].Image)
+}
+
+func TestImage_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Image)
+	}{
+		{"not a blob", func(i *Image) { i.Image.Type = "" }},
+		{"invalid CID", func(i *Image) { i.Image.Ref.Link = "../../etc/passwd" }},
+		{"unsupported type", func(i *Image) { i.Image.MimeType = "image/svg+xml" }},
+		{"empty", func(i *Image) { i.Image.Size = 0 }},
+		{"too large", func(i *Image) { i.Image.Size = MaxImageSize + 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image := testImage()
+			tt.modify(image)
+			assert.Error(t, image.Validate())
+		})
+	}
+
+	image := testImage()
+	image.Alt = "<script>alert(1)</script>Square"
+	require.NoError(t, image.Validate())
+	assert.Equal(t, "Square", image.Alt)
+}
+
+func TestSurveyDefinition_Images(t *testing.T) {
+	def := &SurveyDefinition{
+		Questions: []Question{
+			{ID: "q1", Text: "Pick", Type: QuestionTypeSingle, Options: []Option{
+				{ID: "a", Text: "A", Image: testImage()},
+				{ID: "b", Text: "B"},
+			}},
+		},
+	}
+
+	assert.True(t, def.HasImage(testImageCID))
+	assert.False(t, def.HasImage("bafkreiother"))
+
+	assert.True(t, def.StripImages())
+	assert.Nil(t, def.Questions[0].Options[0].Image)
+	assert.False(t, def.HasImage(testImageCID))
+	assert.False(t, def.StripImages())
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Thresholds of lint warnings
+const (
+	lintMaxQuestions     = 20 // Questions before long surveys lose respondents
+	lintMaxSingleOptions = 10 // Options of a single choice question before the list is hard to scan
+)
+
+// LintIssue is an error or warning of a survey definition
+type LintIssue struct {
+	Path       string `json:"path"`           // JSON pointer of the field, "" for the whole definition
+	Line       int    `json:"line,omitempty"` // 1-based line of the field in the source, 0 if unknown
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"` // How to fix it
+}
+
+// LintReport is the result of linting a survey definition. Errors prevent
+// creating the survey; warnings point out likely mistakes.
+type LintReport struct {
+	Valid    bool        `json:"valid"`
+	Errors   []LintIssue `json:"errors"`
+	Warnings []LintIssue `json:"warnings"`
+}
+
+// syntaxErrorLine matches the line number of YAML parse errors
+var syntaxErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// LintDefinition parses and validates a survey definition like creating a
+// survey does, against DefinitionSchema and ValidateDefinition, but returns
+// all errors instead of the first, warnings about likely mistakes, and the
+// source lines they concern
+func LintDefinition(data []byte) *LintReport {
+	report := &LintReport{Errors: []LintIssue{}, Warnings: []LintIssue{}}
+
+	def, err := ParseSurveyDefinition(data)
+	if err != nil {
+		issue := LintIssue{Message: err.Error()}
+		if m := syntaxErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			issue.Line, _ = strconv.Atoi(m[1])
+		}
+		report.Errors = append(report.Errors, issue)
+		return report
+	}
+
+	// YAML is a superset of JSON, so both sources parse into nodes with lines
+	var root yaml.Node
+	_ = yaml.Unmarshal(data, &root)
+
+	// Validation errors of the fields the schema rejected repeat them, but
+	// may suggest a fix
+	definitionErrs := def.DefinitionErrors()
+	suggestions := make(map[string]string)
+	for _, e := range definitionErrs {
+		suggestions[e.Path] = e.Suggestion
+	}
+	reported := make(map[string]bool)
+	for _, e := range SchemaErrors(data) {
+		reported[e.Path] = true
+		report.Errors = append(report.Errors, LintIssue{
+			Path:       e.Path,
+			Line:       sourceLine(&root, e.Path),
+			Message:    e.Error(),
+			Suggestion: suggestions[e.Path],
+		})
+	}
+	for _, e := range definitionErrs {
+		if reported[e.Path] {
+			continue
+		}
+		report.Errors = append(report.Errors, LintIssue{
+			Path:       e.Path,
+			Line:       sourceLine(&root, e.Path),
+			Message:    e.Error(),
+			Suggestion: e.Suggestion,
+		})
+	}
+	sort.SliceStable(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Line < report.Errors[j].Line
+	})
+	for _, w := range def.lintWarnings() {
+		w.Line = sourceLine(&root, w.Path)
+		report.Warnings = append(report.Warnings, w)
+	}
+
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+// lintWarnings returns the likely mistakes of a definition that validation accepts
+func (d *SurveyDefinition) lintWarnings() []LintIssue {
+	var warnings []LintIssue
+
+	if len(d.Questions) > lintMaxQuestions {
+		warnings = append(warnings, LintIssue{
+			Path:       "/questions",
+			Message:    fmt.Sprintf("the survey has %d questions; long surveys lose respondents", len(d.Questions)),
+			Suggestion: "Keep the questions that matter most, or split the survey",
+		})
+	}
+
+	required := false
+	texts := make(map[string]int)
+	for i, q := range d.Questions {
+		path := fmt.Sprintf("/questions/%d", i)
+		required = required || q.Required
+
+		if text := strings.ToLower(strings.TrimSpace(q.Text)); text != "" {
+			if first, ok := texts[text]; ok {
+				warnings = append(warnings, LintIssue{
+					Path:       path + "/text",
+					Message:    fmt.Sprintf("questions %d and %d ask the same question", first, i),
+					Suggestion: "Reword or remove one of them",
+				})
+			} else {
+				texts[text] = i
+			}
+		}
+
+		switch q.Type {
+		case QuestionTypeSingle, QuestionTypeMulti, QuestionTypeMatrix:
+			warnings = append(warnings, duplicateOptionTexts(i, q.Options)...)
+			if q.Type == QuestionTypeSingle && len(q.Options) > lintMaxSingleOptions {
+				warnings = append(warnings, LintIssue{
+					Path:       path + "/options",
+					Message:    fmt.Sprintf("question %d has %d options; long lists are hard to scan", i, len(q.Options)),
+					Suggestion: "Group or trim the options",
+				})
+			}
+		default:
+			if len(q.Options) > 0 {
+				warnings = append(warnings, LintIssue{
+					Path:       path + "/options",
+					Message:    fmt.Sprintf("question %d: %s questions ignore options", i, q.Type),
+					Suggestion: "Remove the options, or make it a single or multi choice question",
+				})
+			}
+		}
+	}
+
+	if len(d.Questions) > 0 && !required {
+		warnings = append(warnings, LintIssue{
+			Path:       "/questions",
+			Message:    "no question is required, so empty responses can be submitted",
+			Suggestion: `Set "required": true on the questions that must be answered`,
+		})
+	}
+
+	return warnings
+}
+
+// duplicateOptionTexts warns about options of question i that voters cannot tell apart
+func duplicateOptionTexts(i int, options []Option) []LintIssue {
+	var warnings []LintIssue
+	texts := make(map[string]int)
+	for j, opt := range options {
+		text := strings.ToLower(strings.TrimSpace(opt.Text))
+		if text == "" {
+			continue
+		}
+		if first, ok := texts[text]; ok {
+			warnings = append(warnings, LintIssue{
+				Path:       fmt.Sprintf("/questions/%d/options/%d/text", i, j),
+				Message:    fmt.Sprintf("question %d: options %d and %d have the same text '%s'", i, first, j, opt.Text),
+				Suggestion: "Voters cannot tell them apart; reword or remove one",
+			})
+			continue
+		}
+		texts[text] = j
+	}
+	return warnings
+}
+
+// sourceLine returns the line of the field at a JSON pointer in a parsed
+// source, or of its closest parent present; 0 if the source did not parse
+func sourceLine(root *yaml.Node, path string) int {
+	if len(root.Content) == 0 {
+		return 0
+	}
+	node := root.Content[0]
+	line := node.Line
+
+	for _, key := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		if key == "" {
+			continue
+		}
+		switch node.Kind {
+		case yaml.MappingNode:
+			var value *yaml.Node
+			for k := 0; k+1 < len(node.Content); k += 2 {
+				if node.Content[k].Value == key {
+					line, value = node.Content[k].Line, node.Content[k+1]
+					break
+				}
+			}
+			if value == nil {
+				return line
+			}
+			node = value
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return line
+			}
+			node = node.Content[i]
+			line = node.Line
+		default:
+			return line
+		}
+	}
+	return line
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintDefinition_Valid(t *testing.T) {
+	report := LintDefinition([]byte(`{
+	"questions": [
+		{
+			"id": "q1",
+			"text": "Favorite color?",
+			"type": "single",
+			"required": true,
+			"options": [
+				{"id": "red", "text": "Red"},
+				{"id": "blue", "text": "Blue"}
+			]
+		}
+	]
+}`))
+	assert.True(t, report.Valid)
+	assert.Empty(t, report.Errors)
+	assert.Empty(t, report.Warnings)
+}
+
+func TestLintDefinition_SyntaxError(t *testing.T) {
+	report := LintDefinition([]byte("questions:\n  - id: q1\n    text: [unclosed\n"))
+	assert.False(t, report.Valid)
+	require.Len(t, report.Errors, 1)
+	assert.Contains(t, report.Errors[0].Message, "failed to parse")
+	assert.NotZero(t, report.Errors[0].Line)
+}
+
+func TestLintDefinition_AllErrors(t *testing.T) {
+	report := LintDefinition([]byte(`{
+  "questions": [
+    {
+      "id": "q1",
+      "text": "Lunch?",
+      "type": "single",
+      "options": [
+        {"id": "pizza", "text": "Pizza"}
+      ]
+    },
+    {
+      "id": "q1",
+      "text": "Why?",
+      "type": "text"
+    },
+    {
+      "id": "q3",
+      "text": "Mood",
+      "type": "slider"
+    }
+  ],
+  "language": "not a tag"
+}`))
+	assert.False(t, report.Valid)
+	require.Len(t, report.Errors, 4)
+
+	// In source order
+	assert.Equal(t, "/questions/0/options", report.Errors[0].Path)
+	assert.Equal(t, 7, report.Errors[0].Line)
+	assert.Contains(t, report.Errors[0].Suggestion, "1 option")
+
+	assert.Equal(t, "/questions/1/id", report.Errors[1].Path)
+	assert.Equal(t, 12, report.Errors[1].Line)
+	assert.Contains(t, report.Errors[1].Message, "duplicate question ID 'q1'")
+
+	assert.Equal(t, "/questions/2/type", report.Errors[2].Path)
+	assert.Equal(t, 19, report.Errors[2].Line)
+	assert.Contains(t, report.Errors[2].Message, "must be one of", "reported by the schema")
+	assert.NotEmpty(t, report.Errors[2].Suggestion, "suggested by validation")
+
+	assert.Equal(t, "/language", report.Errors[3].Path)
+	assert.Equal(t, 22, report.Errors[3].Line)
+}
+
+func TestLintDefinition_YAMLLines(t *testing.T) {
+	report := LintDefinition([]byte(`questions:
+  - id: q1
+    text: Days?
+    type: multi
+    required: true
+    options:
+      - id: mon
+        text: Monday
+      - id: ""
+        text: Tuesday
+`))
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, "/questions/0/options/1/id", report.Errors[0].Path)
+	assert.Equal(t, 9, report.Errors[0].Line)
+}
+
+func TestLintDefinition_Warnings(t *testing.T) {
+	report := LintDefinition([]byte(`questions:
+  - id: q1
+    text: Lunch?
+    type: single
+    options:
+      - id: a
+        text: Pizza
+      - id: b
+        text: pizza
+  - id: q2
+    text: Comments
+    type: text
+    options:
+      - id: a
+        text: Unused
+  - id: q3
+    text: lunch?
+    type: text
+`))
+	assert.True(t, report.Valid, "warnings do not make a definition invalid")
+
+	paths := make([]string, len(report.Warnings))
+	for i, w := range report.Warnings {
+		paths[i] = w.Path
+		assert.NotZero(t, w.Line, w.Path)
+	}
+	assert.ElementsMatch(t, []string{
+		"/questions/0/options/1/text", // Same option text
+		"/questions/1/options",        // Options of a text question
+		"/questions/2/text",           // Same question text
+		"/questions",                  // Nothing required
+	}, paths)
+}
+
+func TestLintDefinition_LongSurvey(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("questions:\n")
+	for i := 0; i <= lintMaxQuestions; i++ {
+		b.WriteString("  - id: q" + string(rune('a'+i)) + "\n    text: Question " + string(rune('a'+i)) + "\n    type: text\n    required: true\n")
+	}
+	report := LintDefinition([]byte(b.String()))
+	assert.True(t, report.Valid)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0].Message, "21 questions")
+}
@@ -12,20 +12,50 @@ import (
 
 // Response represents a user's response to a survey
 type Response struct {
-	ID           uuid.UUID         `db:"id" json:"id"`
-	SurveyID     uuid.UUID         `db:"survey_id" json:"surveyId"`
-	VoterDID     *string           `db:"voter_did" json:"voterDid,omitempty"`
-	VoterSession *string           `db:"voter_session" json:"voterSession,omitempty"`
-	RecordURI    *string           `db:"record_uri" json:"recordUri,omitempty"`
-	RecordCID    *string           `db:"record_cid" json:"recordCid,omitempty"`
-	Answers      map[string]Answer `db:"answers" json:"answers"`
-	CreatedAt    time.Time         `db:"created_at" json:"createdAt"`
+	ID            uuid.UUID         `db:"id" json:"id"`
+	SurveyID      uuid.UUID         `db:"survey_id" json:"surveyId"`
+	VoterDID      *string           `db:"voter_did" json:"voterDid,omitempty"`
+	VoterSession  *string           `db:"voter_session" json:"voterSession,omitempty"`
+	RecordURI     *string           `db:"record_uri" json:"recordUri,omitempty"`
+	RecordCID     *string           `db:"record_cid" json:"recordCid,omitempty"`
+	Answers       map[string]Answer `db:"answers" json:"answers"`
+	SurveyVersion *int              `db:"survey_version" json:"surveyVersion,omitempty"` // Definition version answered, nil for responses recorded before versions were tracked
+	CreatedAt     time.Time         `db:"created_at" json:"createdAt"`
 }
 
 // Answer represents a response to a single question
 type Answer struct {
 	SelectedOptions []string `json:"selectedOptions,omitempty"`
-	Text            string   `json:"text,omitempty"`
+	Text            string   `json:"text,omitempty"` // Also the value of number, date, and datetime questions
+
+	// Rows holds the option chosen for each row of a matrix question, keyed by row ID
+	Rows map[string]string `json:"rows,omitempty"`
+
+	// File is the blob answering a file question, on the respondent's PDS
+	File *Blob `json:"file,omitempty"`
+}
+
+// MatrixFieldName returns the form field of a row of a matrix question
+func MatrixFieldName(questionID, rowID string) string {
+	return questionID + "." + rowID
+}
+
+// Voter types of responses
+const (
+	VoterTypeDID       = "did"       // Logged-in voter, identified by DID
+	VoterTypeAnonymous = "anonymous" // Anonymous voter, identified by a session hash
+)
+
+// ResponseFilter narrows the responses of a survey. Zero fields match everything.
+type ResponseFilter struct {
+	From        time.Time // Only responses submitted at or after From
+	To          time.Time // Only responses submitted before To
+	VoterType   string    // VoterTypeDID or VoterTypeAnonymous
+	QuestionIDs []string  // Only answers to these questions
+	Question    string    // With Option, only responses that selected Option for this question
+	Option      string
+	Limit       int // Maximum responses returned; 0 for all
+	Offset      int // Responses skipped, for pagination
 }
 
 // GenerateVoterSession creates a SHA256 hash for anonymous voter identification
@@ -81,6 +111,20 @@ func ValidateAnswers(def *SurveyDefinition, answers map[string]Answer) error {
 			}
 			// Write back the sanitized answer
 			answers[question.ID] = answer
+		case QuestionTypeMatrix:
+			if err := validateMatrixAnswer(&question, &answer); err != nil {
+				return fmt.Errorf("question '%s': %w", question.ID, err)
+			}
+		case QuestionTypeFile:
+			if err := validateFileAnswer(&answer); err != nil {
+				return fmt.Errorf("question '%s': %w", question.ID, err)
+			}
+		case QuestionTypeNumber, QuestionTypeDate, QuestionTypeDateTime:
+			if err := validateValueAnswer(&question, &answer); err != nil {
+				return fmt.Errorf("question '%s': %w", question.ID, err)
+			}
+			// Write back the normalized answer
+			answers[question.ID] = answer
 		}
 	}
 
@@ -124,6 +168,42 @@ func validateMultiChoice(question *Question, answer *Answer) error {
 	return nil
 }
 
+// validateMatrixAnswer checks that each rated row and its option exist. A
+// required matrix question must have every row rated.
+func validateMatrixAnswer(question *Question, answer *Answer) error {
+	if len(answer.Rows) == 0 {
+		return errors.New("matrix question must have at least one row rated")
+	}
+
+	validRows := make(map[string]bool)
+	for _, row := range question.Rows {
+		validRows[row.ID] = true
+	}
+	validOptions := make(map[string]bool)
+	for _, opt := range question.Options {
+		validOptions[opt.ID] = true
+	}
+
+	for rowID, optionID := range answer.Rows {
+		if !validRows[rowID] {
+			return fmt.Errorf("invalid row '%s'", rowID)
+		}
+		if !validOptions[optionID] {
+			return fmt.Errorf("row '%s': invalid option '%s'", rowID, optionID)
+		}
+	}
+
+	if question.Required {
+		for _, row := range question.Rows {
+			if _, ok := answer.Rows[row.ID]; !ok {
+				return fmt.Errorf("row '%s' is not rated", row.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
 func validateTextAnswer(question *Question, answer *Answer) error {
 	// Sanitize text answer
 	answer.Text = SanitizeText(answer.Text)
@@ -133,9 +213,13 @@ func validateTextAnswer(question *Question, answer *Answer) error {
 		return errors.New("text answer is required")
 	}
 
-	// Check length limit
-	if len(answer.Text) > MaxTextAnswerLength {
-		return fmt.Errorf("text answer exceeds maximum length of %d characters", MaxTextAnswerLength)
+	// Check length limits; optional questions may be left empty
+	length := TextLength(answer.Text)
+	if length > question.TextMaxLength() {
+		return fmt.Errorf("text answer exceeds maximum length of %d characters", question.TextMaxLength())
+	}
+	if length > 0 && length < question.MinLength {
+		return fmt.Errorf("text answer is shorter than the minimum length of %d characters", question.MinLength)
 	}
 
 	return nil
@@ -209,6 +209,47 @@ func TestValidateAnswers_MultiChoiceValid(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestValidateAnswers_Matrix(t *testing.T) {
+	def := &SurveyDefinition{
+		Questions: []Question{
+			{
+				ID:       "q1",
+				Text:     "Rate the event",
+				Type:     QuestionTypeMatrix,
+				Required: true,
+				Options:  []Option{{ID: "agree", Text: "Agree"}, {ID: "disagree", Text: "Disagree"}},
+				Rows:     []Option{{ID: "venue", Text: "Venue"}, {ID: "food", Text: "Food"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		rows    map[string]string
+		wantErr string
+	}{
+		{"all rows rated", map[string]string{"venue": "agree", "food": "disagree"}, ""},
+		{"row missing", map[string]string{"venue": "agree"}, "row 'food' is not rated"},
+		{"unknown row", map[string]string{"venue": "agree", "food": "agree", "music": "agree"}, "invalid row 'music'"},
+		{"unknown option", map[string]string{"venue": "agree", "food": "maybe"}, "invalid option 'maybe'"},
+		{"no rows", nil, "at least one row rated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAnswers(def, map[string]Answer{"q1": {Rows: tt.rows}})
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	// Optional matrix questions can be partly rated
+	def.Questions[0].Required = false
+	assert.NoError(t, ValidateAnswers(def, map[string]Answer{"q1": {Rows: map[string]string{"food": "agree"}}}))
+}
+
 func TestValidateAnswers_TextQuestionEmpty(t *testing.T) {
 	def := &SurveyDefinition{
 		Questions: []Question{
@@ -380,8 +421,9 @@ func TestValidateAnswers_RejectsTextAnswerExceedingMaxLength(t *testing.T) {
 			{
 				ID:       "q1",
 				Text:     "What are your thoughts?",
-				Type:     QuestionTypeText,
-				Required: true,
+				Type:      QuestionTypeText,
+				Required:  true,
+				MaxLength: MaxTextAnswerLength,
 			},
 		},
 		Anonymous: false,
@@ -408,8 +450,9 @@ func TestValidateAnswers_AcceptsTextAnswerAtMaxLength(t *testing.T) {
 			{
 				ID:       "q1",
 				Text:     "What are your thoughts?",
-				Type:     QuestionTypeText,
-				Required: true,
+				Type:      QuestionTypeText,
+				Required:  true,
+				MaxLength: MaxTextAnswerLength,
 			},
 		},
 		Anonymous: false,
@@ -0,0 +1,366 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of definition changes
+const (
+	ChangeQuestionAdded      = "question_added"
+	ChangeQuestionRemoved    = "question_removed"
+	ChangeQuestionText       = "question_text"
+	ChangeQuestionType       = "question_type"
+	ChangeQuestionRequired   = "question_required"
+	ChangeQuestionImage      = "question_image"
+	ChangeQuestionRange      = "question_range"
+	ChangeQuestionLength     = "question_length"
+	ChangeQuestionsReordered = "questions_reordered"
+	ChangeOptionAdded        = "option_added"
+	ChangeOptionRemoved      = "option_removed"
+	ChangeOptionText         = "option_text"
+	ChangeOptionImage        = "option_image"
+	ChangeOptionsReordered   = "options_reordered"
+	ChangeRowAdded           = "row_added"
+	ChangeRowRemoved         = "row_removed"
+	ChangeRowText            = "row_text"
+	ChangeAnonymous          = "anonymous"
+	ChangeLanguage           = "language"
+)
+
+// DefinitionChange is a single difference between two survey definitions
+type DefinitionChange struct {
+	Kind       string `json:"kind"`
+	QuestionID string `json:"questionId,omitempty"`
+	Question   string `json:"question,omitempty"` // Question text, for display
+	OptionID   string `json:"optionId,omitempty"`
+	RowID      string `json:"rowId,omitempty"` // Row of a matrix question
+	Old        string `json:"old,omitempty"`
+	New        string `json:"new,omitempty"`
+}
+
+// SurveyRevision records how an update indexed from the firehose changed a
+// survey's definition, so voters can see whether options changed after voting
+type SurveyRevision struct {
+	ID        uuid.UUID          `json:"id"`
+	SurveyID  uuid.UUID          `json:"surveyId"`
+	CID       *string            `json:"cid,omitempty"` // CID of the updated record
+	Changes   []DefinitionChange `json:"changes"`
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+// SurveyVersion is a stored revision of a survey's definition. Responses record
+// the version they answered, so they can be read against the options they saw.
+type SurveyVersion struct {
+	SurveyID   uuid.UUID        `json:"surveyId"`
+	Version    int              `json:"version"`
+	CID        *string          `json:"cid,omitempty"` // CID of the survey record defining this version
+	Definition SurveyDefinition `json:"definition"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+// DiffDefinitions returns the changes from old to new. Questions and options
+// are matched by ID, so renaming an option is reported as such rather than as
+// a removal and an addition.
+func DiffDefinitions(old, new *SurveyDefinition) []DefinitionChange {
+	var changes []DefinitionChange
+
+	oldQuestions := make(map[string]*Question, len(old.Questions))
+	for i := range old.Questions {
+		oldQuestions[old.Questions[i].ID] = &old.Questions[i]
+	}
+	newQuestions := make(map[string]*Question, len(new.Questions))
+	for i := range new.Questions {
+		newQuestions[new.Questions[i].ID] = &new.Questions[i]
+	}
+
+	for _, q := range old.Questions {
+		if _, ok := newQuestions[q.ID]; !ok {
+			changes = append(changes, DefinitionChange{Kind: ChangeQuestionRemoved, QuestionID: q.ID, Question: q.Text})
+		}
+	}
+
+	for i := range new.Questions {
+		q := &new.Questions[i]
+		prev, ok := oldQuestions[q.ID]
+		if !ok {
+			changes = append(changes, DefinitionChange{Kind: ChangeQuestionAdded, QuestionID: q.ID, Question: q.Text})
+			continue
+		}
+		changes = append(changes, diffQuestion(prev, q)...)
+	}
+
+	if !sameOrder(questionIDs(old.Questions), questionIDs(new.Questions)) {
+		changes = append(changes, DefinitionChange{Kind: ChangeQuestionsReordered})
+	}
+
+	if old.Anonymous != new.Anonymous {
+		changes = append(changes, DefinitionChange{
+			Kind: ChangeAnonymous,
+			Old:  fmt.Sprint(old.Anonymous),
+			New:  fmt.Sprint(new.Anonymous),
+		})
+	}
+	if old.Language != new.Language {
+		changes = append(changes, DefinitionChange{Kind: ChangeLanguage, Old: old.Language, New: new.Language})
+	}
+
+	return changes
+}
+
+// diffQuestion returns the changes to a question present in both definitions
+func diffQuestion(old, new *Question) []DefinitionChange {
+	var changes []DefinitionChange
+	change := func(kind, oldValue, newValue string) DefinitionChange {
+		return DefinitionChange{Kind: kind, QuestionID: new.ID, Question: new.Text, Old: oldValue, New: newValue}
+	}
+
+	if old.Text != new.Text {
+		changes = append(changes, change(ChangeQuestionText, old.Text, new.Text))
+	}
+	if old.Type != new.Type {
+		changes = append(changes, change(ChangeQuestionType, string(old.Type), string(new.Type)))
+	}
+	if old.Required != new.Required {
+		changes = append(changes, change(ChangeQuestionRequired, fmt.Sprint(old.Required), fmt.Sprint(new.Required)))
+	}
+	if imageCID(old.Image) != imageCID(new.Image) {
+		changes = append(changes, change(ChangeQuestionImage, imageCID(old.Image), imageCID(new.Image)))
+	}
+	if old.Min != new.Min || old.Max != new.Max {
+		changes = append(changes, change(ChangeQuestionRange, rangeText(old), rangeText(new)))
+	}
+	if old.MinLength != new.MinLength || old.MaxLength != new.MaxLength {
+		changes = append(changes, change(ChangeQuestionLength, lengthText(old), lengthText(new)))
+	}
+
+	oldOptions := make(map[string]string, len(old.Options))
+	oldImages := make(map[string]string, len(old.Options))
+	for _, o := range old.Options {
+		oldOptions[o.ID] = o.Text
+		oldImages[o.ID] = imageCID(o.Image)
+	}
+	newOptions := make(map[string]bool, len(new.Options))
+	for _, o := range new.Options {
+		newOptions[o.ID] = true
+	}
+
+	for _, o := range old.Options {
+		if !newOptions[o.ID] {
+			c := change(ChangeOptionRemoved, o.Text, "")
+			c.OptionID = o.ID
+			changes = append(changes, c)
+		}
+	}
+	for _, o := range new.Options {
+		prev, ok := oldOptions[o.ID]
+		switch {
+		case !ok:
+			c := change(ChangeOptionAdded, "", o.Text)
+			c.OptionID = o.ID
+			changes = append(changes, c)
+		case prev != o.Text:
+			c := change(ChangeOptionText, prev, o.Text)
+			c.OptionID = o.ID
+			changes = append(changes, c)
+		}
+		if ok && oldImages[o.ID] != imageCID(o.Image) {
+			c := change(ChangeOptionImage, oldImages[o.ID], imageCID(o.Image))
+			c.OptionID = o.ID
+			changes = append(changes, c)
+		}
+	}
+
+	if !sameOrder(optionIDs(old.Options), optionIDs(new.Options)) {
+		changes = append(changes, change(ChangeOptionsReordered, "", ""))
+	}
+
+	oldRows := make(map[string]string, len(old.Rows))
+	for _, r := range old.Rows {
+		oldRows[r.ID] = r.Text
+	}
+	newRows := make(map[string]bool, len(new.Rows))
+	for _, r := range new.Rows {
+		newRows[r.ID] = true
+	}
+	for _, r := range old.Rows {
+		if !newRows[r.ID] {
+			c := change(ChangeRowRemoved, r.Text, "")
+			c.RowID = r.ID
+			changes = append(changes, c)
+		}
+	}
+	for _, r := range new.Rows {
+		prev, ok := oldRows[r.ID]
+		switch {
+		case !ok:
+			c := change(ChangeRowAdded, "", r.Text)
+			c.RowID = r.ID
+			changes = append(changes, c)
+		case prev != r.Text:
+			c := change(ChangeRowText, prev, r.Text)
+			c.RowID = r.ID
+			changes = append(changes, c)
+		}
+	}
+
+	return changes
+}
+
+// Describe returns a human-readable summary of the change
+func (c DefinitionChange) Describe() string {
+	switch c.Kind {
+	case ChangeQuestionAdded:
+		return fmt.Sprintf("added question %q", c.Question)
+	case ChangeQuestionRemoved:
+		return fmt.Sprintf("removed question %q", c.Question)
+	case ChangeQuestionText:
+		return fmt.Sprintf("changed question %q to %q", c.Old, c.New)
+	case ChangeQuestionType:
+		return fmt.Sprintf("changed question %q from %s to %s", c.Question, questionTypeName(c.Old), questionTypeName(c.New))
+	case ChangeQuestionRequired:
+		if c.New == "true" {
+			return fmt.Sprintf("made question %q required", c.Question)
+		}
+		return fmt.Sprintf("made question %q optional", c.Question)
+	case ChangeQuestionRange:
+		return fmt.Sprintf("changed the range of %q from %s to %s", c.Question, c.Old, c.New)
+	case ChangeQuestionLength:
+		return fmt.Sprintf("changed the answer length of %q from %s to %s", c.Question, c.Old, c.New)
+	case ChangeQuestionsReordered:
+		return "reordered questions"
+	case ChangeOptionAdded:
+		return fmt.Sprintf("added option %q to %q", c.New, c.Question)
+	case ChangeOptionRemoved:
+		return fmt.Sprintf("removed option %q from %q", c.Old, c.Question)
+	case ChangeOptionText:
+		return fmt.Sprintf("renamed option %q to %q in %q", c.Old, c.New, c.Question)
+	case ChangeQuestionImage:
+		return fmt.Sprintf("changed the image of question %q", c.Question)
+	case ChangeOptionImage:
+		return fmt.Sprintf("changed the image of an option of %q", c.Question)
+	case ChangeOptionsReordered:
+		return fmt.Sprintf("reordered options of %q", c.Question)
+	case ChangeRowAdded:
+		return fmt.Sprintf("added row %q to %q", c.New, c.Question)
+	case ChangeRowRemoved:
+		return fmt.Sprintf("removed row %q from %q", c.Old, c.Question)
+	case ChangeRowText:
+		return fmt.Sprintf("renamed row %q to %q in %q", c.Old, c.New, c.Question)
+	case ChangeAnonymous:
+		if c.New == "true" {
+			return "made responses anonymous"
+		}
+		return "made responses non-anonymous"
+	case ChangeLanguage:
+		return fmt.Sprintf("changed language from %q to %q", c.Old, c.New)
+	default:
+		return c.Kind
+	}
+}
+
+// imageCID returns the CID of an image, or "" for no image
+func imageCID(image *Image) string {
+	if image == nil {
+		return ""
+	}
+	return image.CID()
+}
+
+// rangeText describes the bounds of a question, e.g. "1 to 10" or "any"
+func rangeText(q *Question) string {
+	switch {
+	case q.Min != "" && q.Max != "":
+		return q.Min + " to " + q.Max
+	case q.Min != "":
+		return "at least " + q.Min
+	case q.Max != "":
+		return "at most " + q.Max
+	default:
+		return "any"
+	}
+}
+
+// lengthText describes the answer length of a text question, e.g. "10 to 500 characters"
+func lengthText(q *Question) string {
+	if q.MinLength > 0 {
+		return fmt.Sprintf("%d to %d characters", q.MinLength, q.TextMaxLength())
+	}
+	return fmt.Sprintf("at most %d characters", q.TextMaxLength())
+}
+
+// questionTypeName returns the display name of a question type
+func questionTypeName(t string) string {
+	switch QuestionType(t) {
+	case QuestionTypeSingle:
+		return "single choice"
+	case QuestionTypeMulti:
+		return "multiple choice"
+	case QuestionTypeText:
+		return "free text"
+	case QuestionTypeMatrix:
+		return "rating matrix"
+	case QuestionTypeNumber:
+		return "number"
+	case QuestionTypeDate:
+		return "date"
+	case QuestionTypeDateTime:
+		return "date and time"
+	case QuestionTypeFile:
+		return "file upload"
+	default:
+		return t
+	}
+}
+
+// sameOrder reports whether the IDs present in both lists appear in the same
+// relative order. Additions and removals are reported separately.
+func sameOrder(old, new []string) bool {
+	inNew := make(map[string]bool, len(new))
+	for _, id := range new {
+		inNew[id] = true
+	}
+	inOld := make(map[string]bool, len(old))
+	for _, id := range old {
+		inOld[id] = true
+	}
+
+	var a, b []string
+	for _, id := range old {
+		if inNew[id] {
+			a = append(a, id)
+		}
+	}
+	for _, id := range new {
+		if inOld[id] {
+			b = append(b, id)
+		}
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// questionIDs returns the IDs of questions in order
+func questionIDs(questions []Question) []string {
+	ids := make([]string, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	return ids
+}
+
+// optionIDs returns the IDs of options in order
+func optionIDs(options []Option) []string {
+	ids := make([]string, len(options))
+	for i, o := range options {
+		ids[i] = o.ID
+	}
+	return ids
+}
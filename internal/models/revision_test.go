@@ -0,0 +1,130 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func revisionTestDefinition() *SurveyDefinition {
+	return &SurveyDefinition{
+		Questions: []Question{
+			{
+				ID:   "q1",
+				Text: "Favorite color?",
+				Type: QuestionTypeSingle,
+				Options: []Option{
+					{ID: "red", Text: "Red"},
+					{ID: "blue", Text: "Blue"},
+				},
+			},
+			{ID: "q2", Text: "Comments", Type: QuestionTypeText},
+		},
+	}
+}
+
+func TestDiffDefinitions_Unchanged(t *testing.T) {
+	assert.Empty(t, DiffDefinitions(revisionTestDefinition(), revisionTestDefinition()))
+}
+
+func TestDiffDefinitions_OptionChanges(t *testing.T) {
+	old := revisionTestDefinition()
+	updated := revisionTestDefinition()
+	updated.Questions[0].Options = []Option{
+		{ID: "red", Text: "Green"},
+		{ID: "yellow", Text: "Yellow"},
+	}
+
+	changes := DiffDefinitions(old, updated)
+
+	var described []string
+	for _, c := range changes {
+		described = append(described, c.Describe())
+	}
+	assert.Equal(t, []string{
+		`removed option "Blue" from "Favorite color?"`,
+		`renamed option "Red" to "Green" in "Favorite color?"`,
+		`added option "Yellow" to "Favorite color?"`,
+	}, described)
+	assert.Equal(t, "red", changes[1].OptionID)
+}
+
+func TestDiffDefinitions_QuestionChanges(t *testing.T) {
+	old := revisionTestDefinition()
+	updated := revisionTestDefinition()
+	updated.Questions[0].Text = "Favourite colour?"
+	updated.Questions[0].Required = true
+	updated.Questions[1] = Question{ID: "q3", Text: "Age?", Type: QuestionTypeText}
+	updated.Anonymous = true
+
+	var kinds []string
+	for _, c := range DiffDefinitions(old, updated) {
+		kinds = append(kinds, c.Kind)
+	}
+	assert.Equal(t, []string{
+		ChangeQuestionRemoved,
+		ChangeQuestionText,
+		ChangeQuestionRequired,
+		ChangeQuestionAdded,
+		ChangeAnonymous,
+	}, kinds)
+}
+
+func TestDiffDefinitions_Reordered(t *testing.T) {
+	old := revisionTestDefinition()
+	updated := revisionTestDefinition()
+	updated.Questions[0], updated.Questions[1] = updated.Questions[1], updated.Questions[0]
+	updated.Questions[1].Options[0], updated.Questions[1].Options[1] = updated.Questions[1].Options[1], updated.Questions[1].Options[0]
+
+	changes := DiffDefinitions(old, updated)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, `reordered options of "Favorite color?"`, changes[0].Describe())
+		assert.Equal(t, "reordered questions", changes[1].Describe())
+	}
+}
+
+func TestDiffDefinitions_AddedQuestionIsNotReorder(t *testing.T) {
+	old := revisionTestDefinition()
+	updated := revisionTestDefinition()
+	updated.Questions = append([]Question{{ID: "q0", Text: "Name?", Type: QuestionTypeText}}, updated.Questions...)
+
+	changes := DiffDefinitions(old, updated)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, ChangeQuestionAdded, changes[0].Kind)
+	}
+}
+
+func TestDiffDefinitions_ImageChanges(t *testing.T) {
+	old := revisionTestDefinition()
+	updated := revisionTestDefinition()
+	updated.Questions[0].Image = testImage()
+	updated.Questions[0].Options[1].Image = testImage()
+
+	var described []string
+	for _, c := range DiffDefinitions(old, updated) {
+		described = append(described, c.Describe())
+	}
+	assert.Equal(t, []string{
+		`changed the image of question "Favorite color?"`,
+		`changed the image of an option of "Favorite color?"`,
+	}, described)
+}
+
+func TestDiffDefinitions_RowChanges(t *testing.T) {
+	old := revisionTestDefinition()
+	old.Questions[0].Type = QuestionTypeMatrix
+	old.Questions[0].Rows = []Option{{ID: "venue", Text: "Venue"}, {ID: "food", Text: "Food"}}
+	updated := revisionTestDefinition()
+	updated.Questions[0].Type = QuestionTypeMatrix
+	updated.Questions[0].Rows = []Option{{ID: "venue", Text: "The venue"}, {ID: "music", Text: "Music"}}
+
+	var described []string
+	for _, c := range DiffDefinitions(old, updated) {
+		described = append(described, c.Describe())
+	}
+	assert.Equal(t, []string{
+		`removed row "Food" from "Favorite color?"`,
+		`renamed row "Venue" to "The venue" in "Favorite color?"`,
+		`added row "Music" to "Favorite color?"`,
+	}, described)
+}
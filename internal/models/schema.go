@@ -0,0 +1,114 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/openmeet-team/survey/internal/jsonschema"
+)
+
+// DefinitionSchemaID is the $id of the survey definition JSON Schema, the
+// path it is served at
+const DefinitionSchemaID = "/api/v1/schema/survey-definition.json"
+
+// definitionSchema is generated once from the definition structs
+var definitionSchema = newDefinitionSchema()
+
+// DefinitionSchema returns the JSON Schema of survey definitions, generated
+// from SurveyDefinition and its limits. It is shared and must not be modified.
+func DefinitionSchema() *jsonschema.Schema {
+	return definitionSchema
+}
+
+func newDefinitionSchema() *jsonschema.Schema {
+	s := jsonschema.Reflect(reflect.TypeOf(SurveyDefinition{}))
+	s.ID = DefinitionSchemaID
+	s.Title = "Survey definition"
+	s.Description = "The questions and settings of a survey, in JSON or YAML"
+
+	describe(s, "questions", "The questions, asked in order")
+	describe(s, "anonymous", "Whether responses are shown without who gave them")
+	describe(s, "language", `BCP-47 tag of the survey's language, e.g. "en" or "ar"; drives result formatting and text direction`)
+	describe(s, "confirmBeforeSubmit", "Show web voters their answers for review before submitting")
+	describe(s, "visibility", "public (listed), unlisted (open with the link), token (open with a share token), or invite (open with an invitee's one-time link)")
+	describe(s, "responseMetadata", "Count the country and hour of day of responses for the author; not allowed for anonymous surveys")
+	describe(s, "redaction", "What is masked in text answers shown in results; exports keep the original text")
+	s.Properties["questions"].MinItems = jsonschema.Int(1)
+	s.Properties["questions"].MaxItems = jsonschema.Int(MaxQuestions)
+	s.Properties["language"].Pattern = languageTagRegex.String()
+	s.Properties["visibility"].Enum = []string{VisibilityPublic, VisibilityUnlisted, VisibilityToken, VisibilityInvite}
+
+	q := s.Defs["Question"]
+	describe(q, "id", "Unique ID of the question, referenced by responses")
+	describe(q, "type", "single and multi choose from options, matrix rates rows on the options, file is answered with an image or PDF uploaded to the respondent's PDS, the others are answered in text")
+	describe(q, "rows", "Statements of a matrix question")
+	describe(q, "min", "Lowest number, date (YYYY-MM-DD), or datetime (YYYY-MM-DDTHH:MM) accepted")
+	describe(q, "max", "Highest number, date (YYYY-MM-DD), or datetime (YYYY-MM-DDTHH:MM) accepted")
+	describe(q, "minLength", "Fewest characters of an answer to a text question; empty answers to optional questions are accepted")
+	describe(q, "maxLength", fmt.Sprintf("Most characters of an answer to a text question (default %d)", DefaultTextAnswerLength))
+	q.Properties["id"].MinLength = jsonschema.Int(1)
+	q.Properties["text"].MinLength = jsonschema.Int(1)
+	q.Properties["text"].MaxLength = jsonschema.Int(MaxQuestionTextLength)
+	q.Properties["type"].Enum = []string{
+		string(QuestionTypeSingle), string(QuestionTypeMulti), string(QuestionTypeText), string(QuestionTypeMatrix),
+		string(QuestionTypeNumber), string(QuestionTypeDate), string(QuestionTypeDateTime), string(QuestionTypeFile),
+	}
+	q.Properties["options"].MaxItems = jsonschema.Int(MaxOptionsPerQuestion)
+	q.Properties["rows"].MaxItems = jsonschema.Int(MaxMatrixRows)
+	q.Properties["minLength"].Minimum = jsonschema.Int64(0)
+	q.Properties["minLength"].Maximum = jsonschema.Int64(MaxTextAnswerLength)
+	q.Properties["maxLength"].Minimum = jsonschema.Int64(0)
+	q.Properties["maxLength"].Maximum = jsonschema.Int64(MaxTextAnswerLength)
+
+	r := s.Defs["Redaction"]
+	describe(r, "emails", "Mask email addresses")
+	describe(r, "phones", "Mask phone numbers")
+	describe(r, "profanity", "Mask swear words of the survey's language and English")
+
+	o := s.Defs["Option"]
+	o.Properties["id"].MinLength = jsonschema.Int(1)
+	o.Properties["text"].MinLength = jsonschema.Int(1)
+	o.Properties["text"].MaxLength = jsonschema.Int(MaxOptionTextLength)
+
+	describe(s.Defs["Image"], "image", "Blob uploaded to the survey author's PDS")
+	s.Defs["Image"].Properties["alt"].MaxLength = jsonschema.Int(MaxImageAltLength)
+	blob := s.Defs["Blob"]
+	blob.Properties["$type"].Enum = []string{"blob"}
+	blob.Properties["mimeType"].Enum = ImageMimeTypes
+	blob.Properties["size"].Minimum = jsonschema.Int64(1)
+	blob.Properties["size"].Maximum = jsonschema.Int64(MaxImageSize)
+	s.Defs["BlobRef"].Properties["$link"].Pattern = cidRegex.String()
+
+	if err := s.Compile(); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// describe sets the description of a property of an object schema
+func describe(s *jsonschema.Schema, property, description string) {
+	s.Properties[property].Description = description
+}
+
+// SchemaErrors validates a JSON survey definition against DefinitionSchema,
+// catching unknown properties and values of the wrong type that parsing
+// accepts. YAML and malformed JSON are left to ParseSurveyDefinition.
+func SchemaErrors(data []byte) []*DefinitionError {
+	if !json.Valid(data) {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil
+	}
+
+	var errs []*DefinitionError
+	for _, e := range definitionSchema.Validate(doc) {
+		errs = append(errs, &DefinitionError{Path: e.Path, Err: e})
+	}
+	return errs
+}
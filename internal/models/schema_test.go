@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefinitionSchema(t *testing.T) {
+	s := DefinitionSchema()
+
+	assert.Equal(t, []string{"questions"}, s.Required)
+	assert.Equal(t, MaxQuestions, *s.Properties["questions"].MaxItems)
+	assert.Contains(t, s.Defs["Question"].Properties["type"].Enum, string(QuestionTypeMatrix))
+	assert.Equal(t, []string{"id", "text", "type"}, s.Defs["Question"].Required)
+	assert.Equal(t, MaxOptionTextLength, *s.Defs["Option"].Properties["text"].MaxLength)
+
+	_, err := json.Marshal(s)
+	require.NoError(t, err)
+}
+
+func TestSchemaErrors(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		assert.Empty(t, SchemaErrors([]byte(`{
+			"questions": [
+				{"id": "q1", "text": "Color?", "type": "single", "required": true, "options": [{"id": "red", "text": "Red"}, {"id": "blue", "text": "Blue"}]},
+				{"id": "q2", "text": "Age?", "type": "number", "min": "0", "max": "120"}
+			],
+			"visibility": "unlisted"
+		}`)))
+	})
+
+	t.Run("unknown properties and wrong types", func(t *testing.T) {
+		errs := SchemaErrors([]byte(`{
+			"questions": [{"id": 1, "text": "Color?", "type": "single", "colour": "red"}],
+			"anonymous": "yes"
+		}`))
+		var paths []string
+		for _, e := range errs {
+			paths = append(paths, e.Path)
+		}
+		assert.Equal(t, []string{"/anonymous", "/questions/0/colour", "/questions/0/id"}, paths)
+		assert.Contains(t, errs[0].Error(), "must be a boolean")
+	})
+
+	t.Run("YAML is left to parsing", func(t *testing.T) {
+		assert.Empty(t, SchemaErrors([]byte("questions:\n  - id: 1\n    text: Age?\n    type: number\n")))
+	})
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"errors"
+	"math"
+)
+
+// DefaultConfidence is the confidence level of result intervals, in percent
+const DefaultConfidence = 95
+
+// confidenceZ holds the z-scores of the supported confidence levels
+var confidenceZ = map[int]float64{
+	90: 1.6449,
+	95: 1.9600,
+	99: 2.5758,
+}
+
+// ValidateConfidence checks that a confidence level is supported
+func ValidateConfidence(confidence int) error {
+	if _, ok := confidenceZ[confidence]; !ok {
+		return errors.New("confidence must be 90, 95, or 99")
+	}
+	return nil
+}
+
+// QuestionStats are poll-style statistics of a choice or matrix question:
+// the share of the question's respondents choosing each option, with its
+// confidence interval
+type QuestionStats struct {
+	Confidence int `json:"confidence"` // Confidence level of the intervals, in percent
+
+	// MarginOfError is the largest margin of error of the percentages, in
+	// percentage points: that of a 50% share among the respondents, or among
+	// the respondents of the least answered row of a matrix question
+	MarginOfError float64 `json:"marginOfError"`
+
+	Options map[string]Proportion            `json:"options,omitempty"` // keyed by option ID
+	Rows    map[string]map[string]Proportion `json:"rows,omitempty"`    // keyed by row ID and then option ID
+}
+
+// Proportion is the share of respondents who chose an option, in percent,
+// with the bounds of its Wilson score interval
+type Proportion struct {
+	Percent float64 `json:"percent"`
+	Low     float64 `json:"low"`
+	High    float64 `json:"high"`
+}
+
+// WithStats returns a copy of the results in which the choice and matrix
+// questions of the definition have their statistics at the confidence level,
+// which must be supported. The results themselves may be shared with the
+// cache, so they are left as they are.
+func (r *SurveyResults) WithStats(def *SurveyDefinition, confidence int) *SurveyResults {
+	z := confidenceZ[confidence]
+	out := *r
+	out.QuestionResults = make(map[string]*QuestionResult, len(r.QuestionResults))
+	for id, qr := range r.QuestionResults {
+		copied := *qr
+		out.QuestionResults[id] = &copied
+	}
+
+	for i := range def.Questions {
+		question := &def.Questions[i]
+		qr, ok := out.QuestionResults[question.ID]
+		if !ok || !question.Type.countsOptions() {
+			continue
+		}
+		qr.Stats = qr.stats(question, r.TotalVotes, confidence, z)
+	}
+	return &out
+}
+
+// countsOptions reports whether results count the options chosen in answers
+func (t QuestionType) countsOptions() bool {
+	return t == QuestionTypeSingle || t == QuestionTypeMulti || t == QuestionTypeMatrix
+}
+
+// Answered returns how many responses answered the question. Results archived
+// before respondents were counted fall back to all responses.
+func (r *QuestionResult) Answered(totalVotes int) int {
+	if r.Respondents == 0 {
+		return totalVotes
+	}
+	return r.Respondents
+}
+
+// stats computes the statistics of the question's option counts, or of the
+// option counts of each row of a matrix question. Options nobody chose have
+// a share of 0; counted options no longer in the question are kept.
+func (r *QuestionResult) stats(question *Question, totalVotes, confidence int, z float64) *QuestionStats {
+	s := &QuestionStats{Confidence: confidence}
+
+	if question.Type == QuestionTypeMatrix {
+		s.Rows = make(map[string]map[string]Proportion, len(question.Rows))
+		fewest := -1
+		for _, row := range question.Rows {
+			counts := r.RowCounts[row.ID]
+			n := 0
+			for _, count := range counts {
+				n += count
+			}
+			if fewest < 0 || n < fewest {
+				fewest = n
+			}
+			s.Rows[row.ID] = proportions(question.Options, counts, n, z)
+		}
+		s.MarginOfError = marginOfError(fewest, z)
+		return s
+	}
+
+	n := r.Answered(totalVotes)
+	s.Options = proportions(question.Options, r.OptionCounts, n, z)
+	s.MarginOfError = marginOfError(n, z)
+	return s
+}
+
+// proportions computes the share of n respondents of each option and
+// counted option
+func proportions(options []Option, counts map[string]int, n int, z float64) map[string]Proportion {
+	shares := make(map[string]Proportion, len(options))
+	for _, option := range options {
+		shares[option.ID] = wilson(counts[option.ID], n, z)
+	}
+	for id, count := range counts {
+		shares[id] = wilson(count, n, z)
+	}
+	return shares
+}
+
+// wilson returns the share of k out of n with its Wilson score interval,
+// which unlike the normal approximation stays within 0-100% for small
+// samples and shares near 0% or 100%
+func wilson(k, n int, z float64) Proportion {
+	if n <= 0 {
+		return Proportion{}
+	}
+	p := float64(k) / float64(n)
+	nf := float64(n)
+	denominator := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / denominator
+	half := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / denominator
+	return Proportion{
+		Percent: roundPercent(p * 100),
+		Low:     roundPercent(math.Max(0, center-half) * 100),
+		High:    roundPercent(math.Min(1, center+half) * 100),
+	}
+}
+
+// marginOfError returns the margin of error of a 50% share of n respondents,
+// in percentage points, or 0 without respondents
+func marginOfError(n int, z float64) float64 {
+	if n <= 0 {
+		return 0
+	}
+	return roundPercent(z * math.Sqrt(0.25/float64(n)) * 100)
+}
+
+// roundPercent rounds percentages to two decimals, hiding floating-point noise
+func roundPercent(x float64) float64 {
+	return math.Round(x*100) / 100
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWilson(t *testing.T) {
+	z := confidenceZ[95]
+
+	// 40 of 100 is 40% with an interval of about 30.9% to 49.8%
+	assert.Equal(t, Proportion{Percent: 40, Low: 30.94, High: 49.8}, wilson(40, 100, z))
+
+	// Intervals stay within 0-100% at the extremes
+	none := wilson(0, 10, z)
+	assert.Equal(t, 0.0, none.Low)
+	assert.Greater(t, none.High, 0.0)
+	all := wilson(10, 10, z)
+	assert.Equal(t, 100.0, all.High)
+	assert.Less(t, all.Low, 100.0)
+
+	// Without respondents there is no share
+	assert.Equal(t, Proportion{}, wilson(0, 0, z))
+}
+
+func TestMarginOfError(t *testing.T) {
+	assert.Equal(t, 3.1, marginOfError(1000, confidenceZ[95]))
+	assert.Equal(t, 9.8, marginOfError(100, confidenceZ[95]))
+	assert.Equal(t, 12.88, marginOfError(100, confidenceZ[99]))
+	assert.Equal(t, 0.0, marginOfError(0, confidenceZ[95]))
+}
+
+func TestValidateConfidence(t *testing.T) {
+	for _, confidence := range []int{90, 95, 99} {
+		assert.NoError(t, ValidateConfidence(confidence))
+	}
+	assert.Error(t, ValidateConfidence(80))
+}
+
+func TestSurveyResults_WithStats(t *testing.T) {
+	def := &SurveyDefinition{Questions: []Question{
+		{ID: "q1", Type: QuestionTypeSingle, Options: []Option{{ID: "a", Text: "A"}, {ID: "b", Text: "B"}, {ID: "c", Text: "C"}}},
+		{ID: "q2", Type: QuestionTypeText},
+		{ID: "q3", Type: QuestionTypeMatrix, Options: []Option{{ID: "yes", Text: "Yes"}, {ID: "no", Text: "No"}}, Rows: []Option{{ID: "r1", Text: "R1"}, {ID: "r2", Text: "R2"}}},
+	}}
+	results := &SurveyResults{
+		TotalVotes: 10,
+		QuestionResults: map[string]*QuestionResult{
+			"q1": {QuestionID: "q1", Respondents: 4, OptionCounts: map[string]int{"a": 3, "b": 1}},
+			"q2": {QuestionID: "q2", Respondents: 2, OptionCounts: map[string]int{}, TextAnswers: []string{"x", "y"}},
+			"q3": {QuestionID: "q3", Respondents: 5, OptionCounts: map[string]int{}, RowCounts: map[string]map[string]int{
+				"r1": {"yes": 4, "no": 1},
+				"r2": {"yes": 1},
+			}},
+		},
+	}
+
+	withStats := results.WithStats(def, 95)
+
+	// Shares are of the question's respondents, not of all responses
+	q1 := withStats.QuestionResults["q1"].Stats
+	require.NotNil(t, q1)
+	assert.Equal(t, 95, q1.Confidence)
+	assert.Equal(t, 75.0, q1.Options["a"].Percent)
+	assert.Equal(t, 25.0, q1.Options["b"].Percent)
+	assert.Contains(t, q1.Options, "c", "options nobody chose are listed")
+	assert.Equal(t, 0.0, q1.Options["c"].Percent)
+	assert.Equal(t, marginOfError(4, confidenceZ[95]), q1.MarginOfError)
+
+	// Text questions have no statistics
+	assert.Nil(t, withStats.QuestionResults["q2"].Stats)
+
+	// Matrix rows have shares of their own respondents, and the margin of the least answered row
+	q3 := withStats.QuestionResults["q3"].Stats
+	require.NotNil(t, q3)
+	assert.Equal(t, 80.0, q3.Rows["r1"]["yes"].Percent)
+	assert.Equal(t, 100.0, q3.Rows["r2"]["yes"].Percent)
+	assert.Equal(t, 0.0, q3.Rows["r2"]["no"].Percent)
+	assert.Equal(t, marginOfError(1, confidenceZ[95]), q3.MarginOfError)
+
+	// The results themselves are left as they are
+	assert.Nil(t, results.QuestionResults["q1"].Stats)
+}
+
+func TestQuestionResult_Answered(t *testing.T) {
+	assert.Equal(t, 4, (&QuestionResult{Respondents: 4}).Answered(10))
+	assert.Equal(t, 10, (&QuestionResult{}).Answered(10), "results without respondents fall back to all responses")
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 	"unicode"
@@ -21,6 +22,21 @@ const (
 	QuestionTypeSingle QuestionType = "single"
 	QuestionTypeMulti  QuestionType = "multi"
 	QuestionTypeText   QuestionType = "text"
+	QuestionTypeMatrix QuestionType = "matrix" // Rows of statements, each rated on the shared options scale
+	QuestionTypeFile   QuestionType = "file"   // An image or PDF uploaded to the respondent's PDS
+
+	// Value questions, answered in Text and optionally bounded by Min and Max
+	QuestionTypeNumber   QuestionType = "number"
+	QuestionTypeDate     QuestionType = "date"     // YYYY-MM-DD
+	QuestionTypeDateTime QuestionType = "datetime" // YYYY-MM-DDTHH:MM, without a zone
+)
+
+// Visibilities of a survey, set in its definition
+const (
+	VisibilityPublic   = "public"   // Listed and open to anyone (the default)
+	VisibilityUnlisted = "unlisted" // Open to anyone with the link, but not listed
+	VisibilityToken    = "token"    // Open only with one of the author's share tokens, and not listed
+	VisibilityInvite   = "invite"   // Open only with an invitee's one-time link, and not listed
 )
 
 // Survey represents a survey definition stored in the database
@@ -33,33 +49,106 @@ type Survey struct {
 	Title       string            `db:"title" json:"title"`
 	Description *string           `db:"description" json:"description,omitempty"`
 	Definition  SurveyDefinition  `db:"definition" json:"definition"`
+	Version     int               `db:"version" json:"version"` // Bumped whenever the definition changes
 	StartsAt    *time.Time        `db:"starts_at" json:"startsAt,omitempty"`
 	EndsAt      *time.Time        `db:"ends_at" json:"endsAt,omitempty"`
 	ResultsURI  *string           `db:"results_uri" json:"resultsUri,omitempty"`
 	ResultsCID  *string           `db:"results_cid" json:"resultsCid,omitempty"`
 	CreatedAt   time.Time         `db:"created_at" json:"createdAt"`
 	UpdatedAt   time.Time         `db:"updated_at" json:"updatedAt"`
+	HiddenAt    *time.Time        `db:"hidden_at" json:"hiddenAt,omitempty"`   // Set while hidden pending review of abuse reports
+	OrgID       *uuid.UUID        `db:"org_id" json:"orgId,omitempty"`         // Organization owning the survey with its author
+	DeletedAt   *time.Time        `db:"deleted_at" json:"deletedAt,omitempty"` // Set while in the trash
+}
+
+// SurveyTombstone is what is kept of a deleted survey, from when it is moved
+// to the trash. Restoring the survey removes it.
+type SurveyTombstone struct {
+	Slug      string    `json:"slug"`
+	URI       *string   `json:"uri,omitempty"`
+	AuthorDID *string   `json:"authorDid,omitempty"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// IsForeign reports whether the survey was indexed from another app's poll
+// lexicon. Foreign surveys are read-only: votes are cast in the app that created them.
+func (s *Survey) IsForeign() bool {
+	if s.URI == nil {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(*s.URI, "at://"), "/")
+	return len(parts) == 3 && parts[1] != "net.openmeet.survey"
+}
+
+// IsClosed reports whether voting on the survey has ended by now
+func (s *Survey) IsClosed(now time.Time) bool {
+	return s.EndsAt != nil && !now.Before(*s.EndsAt)
 }
 
 // SurveyDefinition represents the survey structure stored as JSONB
 type SurveyDefinition struct {
 	Questions []Question `json:"questions"`
 	Anonymous bool       `json:"anonymous"`
+	Language  string     `json:"language,omitempty"` // BCP-47 tag, e.g. "en" or "ar"; drives result formatting and text direction
+	// ConfirmBeforeSubmit shows web voters their answers for review before the response is submitted
+	ConfirmBeforeSubmit bool `json:"confirmBeforeSubmit,omitempty" yaml:"confirmBeforeSubmit,omitempty"`
+	// Visibility is VisibilityPublic (if empty), VisibilityUnlisted, VisibilityToken, or VisibilityInvite
+	Visibility string `json:"visibility,omitempty" yaml:"visibility,omitempty"`
+	// ResponseMetadata counts the country and hour of responses for the author, never for anonymous surveys
+	ResponseMetadata bool `json:"responseMetadata,omitempty" yaml:"responseMetadata,omitempty"`
+	// Redaction masks personal data and profanity in the text answers of results
+	Redaction *Redaction `json:"redaction,omitempty" yaml:"redaction,omitempty"`
+}
+
+// Redaction selects what is masked in text answers before they are shown in
+// results. Exports keep the original text for the author.
+type Redaction struct {
+	Emails    bool `json:"emails,omitempty" yaml:"emails,omitempty"`       // Email addresses
+	Phones    bool `json:"phones,omitempty" yaml:"phones,omitempty"`       // Phone numbers
+	Profanity bool `json:"profanity,omitempty" yaml:"profanity,omitempty"` // Swear words of the survey's language and English
+}
+
+// Enabled reports whether anything is redacted
+func (r *Redaction) Enabled() bool {
+	return r != nil && (r.Emails || r.Phones || r.Profanity)
+}
+
+// CollectsResponseMetadata reports whether the country and hour of responses
+// are counted. Anonymous surveys never count them, whatever their record says.
+func (d *SurveyDefinition) CollectsResponseMetadata() bool {
+	return d.ResponseMetadata && !d.Anonymous
+}
+
+// IsListed reports whether the survey appears in survey listings
+func (d *SurveyDefinition) IsListed() bool {
+	return d.Visibility == "" || d.Visibility == VisibilityPublic
+}
+
+// IsPrivate reports whether the survey only opens with a share token or invite link
+func (d *SurveyDefinition) IsPrivate() bool {
+	return d.Visibility == VisibilityToken || d.Visibility == VisibilityInvite
 }
 
 // Question represents a survey question
 type Question struct {
-	ID       string       `json:"id"`
-	Text     string       `json:"text"`
-	Type     QuestionType `json:"type"`
-	Required bool         `json:"required"`
-	Options  []Option     `json:"options,omitempty"`
+	ID        string       `json:"id"`
+	Text      string       `json:"text"`
+	Type      QuestionType `json:"type"`
+	Required  bool         `json:"required"`
+	Options   []Option     `json:"options,omitempty"`
+	Rows      []Option     `json:"rows,omitempty" yaml:"rows,omitempty"`           // Statements of a matrix question
+	Min       string       `json:"min,omitempty" yaml:"min,omitempty"`             // Lowest number, date, or datetime accepted
+	Max       string       `json:"max,omitempty" yaml:"max,omitempty"`             // Highest number, date, or datetime accepted
+	MinLength int          `json:"minLength,omitempty" yaml:"minLength,omitempty"` // Fewest characters of a text answer
+	MaxLength int          `json:"maxLength,omitempty" yaml:"maxLength,omitempty"` // Most characters of a text answer (default DefaultTextAnswerLength)
+	Image     *Image       `json:"image,omitempty" yaml:"image,omitempty"`
 }
 
 // Option represents a choice option for a question
 type Option struct {
-	ID   string `json:"id"`
-	Text string `json:"text"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+	Image *Image `json:"image,omitempty" yaml:"image,omitempty"`
 }
 
 // Security limits for YAML bomb protection
@@ -67,9 +156,10 @@ const (
 	MaxSurveyDefinitionSize = 100 * 1024 // 100KB
 	MaxQuestions            = 50
 	MaxOptionsPerQuestion   = 20
+	MaxMatrixRows           = 20
 	MaxQuestionTextLength   = 1000
 	MaxOptionTextLength     = 500
-	MaxTextAnswerLength     = 5000 // Maximum length for free-form text answers
+	MaxTextAnswerLength     = 5000 // Highest maxLength of text questions
 )
 
 // Regex patterns for sanitization (compiled once for performance)
@@ -138,90 +228,246 @@ func ParseSurveyDefinition(data []byte) (*SurveyDefinition, error) {
 	return &def, nil
 }
 
-// ValidateDefinition validates the survey definition
+// QuestionOrdinals returns the 1-based position of each question, keyed by question ID
+func (d *SurveyDefinition) QuestionOrdinals() map[string]int {
+	ordinals := make(map[string]int, len(d.Questions))
+	for i, q := range d.Questions {
+		ordinals[q.ID] = i + 1
+	}
+	return ordinals
+}
+
+// OptionText returns the text of an option of a question, and whether the definition has it
+func (d *SurveyDefinition) OptionText(questionID, optionID string) (string, bool) {
+	for _, q := range d.Questions {
+		if q.ID != questionID {
+			continue
+		}
+		for _, opt := range q.Options {
+			if opt.ID == optionID {
+				return opt.Text, true
+			}
+		}
+	}
+	return "", false
+}
+
+// DefinitionError is a problem of a survey definition, with the JSON pointer
+// of the field it concerns, e.g. "/questions/0/options"
+type DefinitionError struct {
+	Path       string // Empty for the definition as a whole
+	Err        error  // Names the position, e.g. "question 0: question text is required"
+	Suggestion string // How to fix it, if there is an obvious way
+}
+
+func (e *DefinitionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DefinitionError) Unwrap() error {
+	return e.Err
+}
+
+// definitionError creates a DefinitionError at path
+func definitionError(path, format string, args ...any) *DefinitionError {
+	return &DefinitionError{Path: path, Err: fmt.Errorf(format, args...)}
+}
+
+// suggest sets the suggestion of an error
+func (e *DefinitionError) suggest(suggestion string) *DefinitionError {
+	e.Suggestion = suggestion
+	return e
+}
+
+// ValidateDefinition validates the survey definition, returning its first
+// error as a *DefinitionError
 func (d *SurveyDefinition) ValidateDefinition() error {
+	if errs := d.DefinitionErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// DefinitionErrors validates the survey definition and returns all its
+// errors. The checks of a question stop at its first error.
+func (d *SurveyDefinition) DefinitionErrors() []*DefinitionError {
+	var errs []*DefinitionError
+
 	if len(d.Questions) == 0 {
-		return errors.New("survey must have at least one question")
+		errs = append(errs, definitionError("/questions", "survey must have at least one question"))
 	}
 
 	// Check total question count
 	if len(d.Questions) > MaxQuestions {
-		return fmt.Errorf("too many questions: %d exceeds maximum of 50", len(d.Questions))
+		errs = append(errs, definitionError("/questions", "too many questions: %d exceeds maximum of 50", len(d.Questions)))
+	}
+
+	// Validate language tag if present
+	if d.Language != "" && !languageTagRegex.MatchString(d.Language) {
+		errs = append(errs, definitionError("/language", "invalid language tag '%s'", d.Language).
+			suggest(`Use a BCP-47 tag such as "en" or "pt-BR"`))
+	}
+
+	switch d.Visibility {
+	case "", VisibilityPublic, VisibilityUnlisted, VisibilityToken, VisibilityInvite:
+	default:
+		errs = append(errs, definitionError("/visibility", "invalid visibility '%s': must be public, unlisted, token, or invite", d.Visibility))
+	}
+
+	if d.ResponseMetadata && d.Anonymous {
+		errs = append(errs, definitionError("/responseMetadata", "anonymous surveys cannot collect response metadata").
+			suggest("Remove responseMetadata, or make the survey not anonymous"))
 	}
 
 	questionIDs := make(map[string]bool)
+	for i := range d.Questions {
+		if err := d.validateQuestion(i, questionIDs); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
-	for i, q := range d.Questions {
-		// Validate question ID
-		if q.ID == "" {
-			return fmt.Errorf("question %d: question ID is required", i)
+	return errs
+}
+
+// validateQuestion sanitizes and validates question i, recording its ID in questionIDs
+func (d *SurveyDefinition) validateQuestion(i int, questionIDs map[string]bool) *DefinitionError {
+	q := d.Questions[i]
+	path := fmt.Sprintf("/questions/%d", i)
+
+	// Validate question ID
+	if q.ID == "" {
+		return definitionError(path+"/id", "question %d: question ID is required", i).
+			suggest(fmt.Sprintf(`Give it a unique ID, e.g. "q%d"`, i+1))
+	}
+
+	// Check for duplicate question IDs
+	if questionIDs[q.ID] {
+		return definitionError(path+"/id", "question %d: duplicate question ID '%s'", i, q.ID).
+			suggest(fmt.Sprintf(`Give it a unique ID, e.g. "q%d"`, i+1))
+	}
+	questionIDs[q.ID] = true
+
+	// Sanitize question text
+	d.Questions[i].Text = SanitizeText(q.Text)
+
+	// Validate question text (after sanitization)
+	if d.Questions[i].Text == "" {
+		return definitionError(path+"/text", "question %d: question text is required", i)
+	}
+
+	// Check question text length
+	if len(d.Questions[i].Text) > MaxQuestionTextLength {
+		return definitionError(path+"/text", "question %d: question text too long: %d characters exceeds maximum of 1000", i, len(d.Questions[i].Text))
+	}
+
+	if q.Image != nil {
+		if err := d.Questions[i].Image.Validate(); err != nil {
+			return definitionError(path+"/image", "question %d: %w", i, err)
 		}
+	}
+
+	// Validate question type
+	if q.Type != QuestionTypeSingle && q.Type != QuestionTypeMulti && q.Type != QuestionTypeText && q.Type != QuestionTypeMatrix && q.Type != QuestionTypeFile && !q.Type.HasRange() {
+		return definitionError(path+"/type", "question %d: invalid question type '%s'", i, q.Type).
+			suggest("Use single, multi, text, matrix, number, date, datetime, or file")
+	}
 
-		// Check for duplicate question IDs
-		if questionIDs[q.ID] {
-			return fmt.Errorf("question %d: duplicate question ID '%s'", i, q.ID)
+	// Files are blobs on respondents' PDSes, which identify them
+	if q.Type == QuestionTypeFile && d.Anonymous {
+		return definitionError(path+"/type", "question %d: anonymous surveys cannot have file questions", i).
+			suggest("Remove the question, or make the survey not anonymous")
+	}
+
+	// Validate the bounds of number, date, and datetime questions
+	if err := validateRange(&d.Questions[i]); err != nil {
+		return definitionError(path, "question %d: %w", i, err)
+	}
+
+	// Validate the answer lengths of text questions
+	if err := validateTextLengths(&d.Questions[i]); err != nil {
+		return definitionError(path, "question %d: %w", i, err)
+	}
+
+	// Validate options for choice and matrix questions
+	if q.Type == QuestionTypeSingle || q.Type == QuestionTypeMulti || q.Type == QuestionTypeMatrix {
+		if len(q.Options) < 2 {
+			err := definitionError(path+"/options", "question %d: choice questions must have at least 2 options", i)
+			if len(q.Options) == 1 && q.Type != QuestionTypeMatrix {
+				return err.suggest(fmt.Sprintf("A %s choice question with 1 option has nothing to choose: add another option, or make it a text question", q.Type))
+			}
+			return err.suggest("Add at least 2 options, each with an id and text")
 		}
-		questionIDs[q.ID] = true
 
-		// Sanitize question text
-		d.Questions[i].Text = SanitizeText(q.Text)
+		// Check option count
+		if len(q.Options) > MaxOptionsPerQuestion {
+			return definitionError(path+"/options", "question %d: too many options: %d exceeds maximum of 20", i, len(q.Options))
+		}
 
-		// Validate question text (after sanitization)
-		if d.Questions[i].Text == "" {
-			return fmt.Errorf("question %d: question text is required", i)
+		if err := validateOptions(i, "option", d.Questions[i].Options); err != nil {
+			return err
 		}
+	}
 
-		// Check question text length
-		if len(d.Questions[i].Text) > MaxQuestionTextLength {
-			return fmt.Errorf("question %d: question text too long: %d characters exceeds maximum of 1000", i, len(d.Questions[i].Text))
+	// Validate the statements of matrix questions
+	if q.Type == QuestionTypeMatrix {
+		if len(q.Rows) == 0 {
+			return definitionError(path+"/rows", "question %d: matrix questions must have at least 1 row", i)
+		}
+		if len(q.Rows) > MaxMatrixRows {
+			return definitionError(path+"/rows", "question %d: too many rows: %d exceeds maximum of 20", i, len(q.Rows))
 		}
+		if err := validateOptions(i, "row", d.Questions[i].Rows); err != nil {
+			return err
+		}
+	} else if len(q.Rows) > 0 {
+		return definitionError(path+"/rows", "question %d: only matrix questions have rows", i).
+			suggest("Remove the rows, or make it a matrix question")
+	}
 
-		// Validate question type
-		if q.Type != QuestionTypeSingle && q.Type != QuestionTypeMulti && q.Type != QuestionTypeText {
-			return fmt.Errorf("question %d: invalid question type '%s'", i, q.Type)
+	return nil
+}
+
+// validateOptions sanitizes and validates the options of question i, or the
+// rows of a matrix question; kind names them in errors
+func validateOptions(i int, kind string, options []Option) *DefinitionError {
+	ids := make(map[string]bool)
+	for j, opt := range options {
+		path := fmt.Sprintf("/questions/%d/%ss/%d", i, kind, j)
+		if opt.ID == "" {
+			return definitionError(path+"/id", "question %d, %s %d: %s ID is required", i, kind, j, kind)
 		}
 
-		// Validate options for choice questions
-		if q.Type == QuestionTypeSingle || q.Type == QuestionTypeMulti {
-			if len(q.Options) < 2 {
-				return fmt.Errorf("question %d: choice questions must have at least 2 options", i)
-			}
+		// Sanitize option text
+		options[j].Text = SanitizeText(opt.Text)
 
-			// Check option count
-			if len(q.Options) > MaxOptionsPerQuestion {
-				return fmt.Errorf("question %d: too many options: %d exceeds maximum of 20", i, len(q.Options))
-			}
+		// Validate option text (after sanitization)
+		if options[j].Text == "" {
+			return definitionError(path+"/text", "question %d, %s %d: %s text is required", i, kind, j, kind)
+		}
+
+		// Check option text length
+		if len(options[j].Text) > MaxOptionTextLength {
+			return definitionError(path+"/text", "question %d, %s %d: %s text too long: %d characters exceeds maximum of 500", i, kind, j, kind, len(options[j].Text))
+		}
 
-			optionIDs := make(map[string]bool)
-			for j, opt := range q.Options {
-				if opt.ID == "" {
-					return fmt.Errorf("question %d, option %d: option ID is required", i, j)
-				}
-
-				// Sanitize option text
-				d.Questions[i].Options[j].Text = SanitizeText(opt.Text)
-
-				// Validate option text (after sanitization)
-				if d.Questions[i].Options[j].Text == "" {
-					return fmt.Errorf("question %d, option %d: option text is required", i, j)
-				}
-
-				// Check option text length
-				if len(d.Questions[i].Options[j].Text) > MaxOptionTextLength {
-					return fmt.Errorf("question %d, option %d: option text too long: %d characters exceeds maximum of 500", i, j, len(d.Questions[i].Options[j].Text))
-				}
-
-				if optionIDs[opt.ID] {
-					return fmt.Errorf("question %d: duplicate option ID '%s'", i, opt.ID)
-				}
-				optionIDs[opt.ID] = true
+		if opt.Image != nil {
+			if err := options[j].Image.Validate(); err != nil {
+				return definitionError(path+"/image", "question %d, %s %d: %w", i, kind, j, err)
 			}
 		}
-	}
 
+		if ids[opt.ID] {
+			return definitionError(path+"/id", "question %d: duplicate %s ID '%s'", i, kind, opt.ID).
+				suggest(fmt.Sprintf("Give each %s of the question a unique ID", kind))
+		}
+		ids[opt.ID] = true
+	}
 	return nil
 }
 
+// languageTagRegex matches simple BCP-47 tags like "en", "pt-BR", "zh-Hant"
+var languageTagRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
 var slugRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$|^[a-z0-9]{3}$`)
 
 // ValidateSlug validates a survey slug
@@ -240,13 +486,98 @@ func ValidateSlug(slug string) error {
 // SurveyResults represents aggregated results for a survey
 type SurveyResults struct {
 	SurveyID        uuid.UUID                  `json:"surveyId"`
-	TotalVotes      int                        `json:"totalVotes"`
-	QuestionResults map[string]*QuestionResult `json:"questionResults"` // keyed by question ID
+	TotalVotes         int                        `json:"totalVotes"`
+	ExcludedDuplicates int                        `json:"excludedDuplicates,omitempty"` // responses the author excluded as suspected duplicates
+	ExcludedSpam       int                        `json:"excludedSpam,omitempty"`       // responses at or above the survey's spam threshold
+	QuestionResults    map[string]*QuestionResult `json:"questionResults"`              // keyed by question ID
+	Version            int                        `json:"version"`                      // current definition version
+	VersionVotes       map[int]int                `json:"versionVotes,omitempty"`       // votes per definition version answered
+	MixedVersions      bool                       `json:"mixedVersions,omitempty"`      // votes answered more than one definition version
+}
+
+// OrderedQuestionResults returns the question results in display order.
+// Questions no longer in the definition (ordinal 0) come last; ties are ordered by question ID.
+func (r *SurveyResults) OrderedQuestionResults() []*QuestionResult {
+	ordered := make([]*QuestionResult, 0, len(r.QuestionResults))
+	for _, qr := range r.QuestionResults {
+		ordered = append(ordered, qr)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if a.Ordinal != b.Ordinal {
+			if a.Ordinal == 0 || b.Ordinal == 0 {
+				return b.Ordinal == 0
+			}
+			return a.Ordinal < b.Ordinal
+		}
+		return a.QuestionID < b.QuestionID
+	})
+	return ordered
+}
+
+// MarshalJSON writes questionResults keys in display order rather than the
+// alphabetical key order encoding/json uses for maps, so clients that keep
+// object key order see questions as voters did
+func (r SurveyResults) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"surveyId":`)
+	if err := writeJSON(&buf, r.SurveyID); err != nil {
+		return nil, err
+	}
+	buf.WriteString(`,"totalVotes":`)
+	if err := writeJSON(&buf, r.TotalVotes); err != nil {
+		return nil, err
+	}
+	buf.WriteString(`,"questionResults":`)
+	if r.QuestionResults == nil {
+		buf.WriteString("null}")
+		return buf.Bytes(), nil
+	}
+	buf.WriteByte('{')
+	for i, qr := range r.OrderedQuestionResults() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJSON(&buf, qr.QuestionID); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := writeJSON(&buf, qr); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteString("}}")
+	return buf.Bytes(), nil
+}
+
+// writeJSON appends the JSON encoding of v to buf
+func writeJSON(buf *bytes.Buffer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
 }
 
 // QuestionResult represents aggregated results for a single question
 type QuestionResult struct {
 	QuestionID   string         `json:"questionId"`
+	Ordinal      int            `json:"ordinal"`      // 1-based position in the current definition, 0 if no longer present
+	Respondents  int            `json:"respondents"`  // responses that answered the question, fewer than all for optional questions
 	OptionCounts map[string]int `json:"optionCounts"` // keyed by option ID, value is count
 	TextAnswers  []string       `json:"textAnswers"`  // for text questions
+
+	// RowCounts holds the option counts of each row of a matrix question,
+	// keyed by row ID and then option ID
+	RowCounts map[string]map[string]int `json:"rowCounts,omitempty"`
+
+	Histogram []Bucket     `json:"histogram,omitempty"` // for number questions
+	Dates     *DateSummary `json:"dates,omitempty"`     // for date and datetime questions
+
+	// RemovedOptions holds the text of counted options no longer in the current
+	// definition, keyed by option ID, from the last version that had them
+	RemovedOptions map[string]string `json:"removedOptions,omitempty"`
+
+	Stats *QuestionStats `json:"stats,omitempty"` // with ?stats=true, for choice and matrix questions
 }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -224,6 +225,38 @@ func TestValidateDefinition_DuplicateOptionIDs(t *testing.T) {
 	assert.Contains(t, err.Error(), "duplicate option ID")
 }
 
+func TestValidateDefinition_Matrix(t *testing.T) {
+	matrix := func(rows ...Option) *SurveyDefinition {
+		return &SurveyDefinition{
+			Questions: []Question{
+				{
+					ID:      "q1",
+					Text:    "Rate the event",
+					Type:    QuestionTypeMatrix,
+					Options: []Option{{ID: "agree", Text: "Agree"}, {ID: "disagree", Text: "Disagree"}},
+					Rows:    rows,
+				},
+			},
+		}
+	}
+
+	assert.NoError(t, matrix(Option{ID: "venue", Text: "The venue was good"}, Option{ID: "food", Text: "The food was good"}).ValidateDefinition())
+
+	err := matrix().ValidateDefinition()
+	assert.ErrorContains(t, err, "at least 1 row")
+
+	err = matrix(Option{ID: "venue", Text: "Venue"}, Option{ID: "venue", Text: "Food"}).ValidateDefinition()
+	assert.ErrorContains(t, err, "duplicate row ID")
+
+	err = matrix(Option{ID: "venue", Text: "<script>x</script>"}).ValidateDefinition()
+	assert.ErrorContains(t, err, "row text is required")
+
+	// Rows only belong to matrix questions
+	def := matrix(Option{ID: "venue", Text: "Venue"})
+	def.Questions[0].Type = QuestionTypeSingle
+	assert.ErrorContains(t, def.ValidateDefinition(), "only matrix questions have rows")
+}
+
 func TestValidateDefinition_NoQuestions(t *testing.T) {
 	def := &SurveyDefinition{
 		Questions: []Question{},
@@ -235,6 +268,74 @@ func TestValidateDefinition_NoQuestions(t *testing.T) {
 	assert.Contains(t, err.Error(), "at least one question")
 }
 
+func TestValidateDefinition_Language(t *testing.T) {
+	questions := []Question{
+		{ID: "q1", Text: "Question 1", Type: QuestionTypeText},
+	}
+
+	for _, lang := range []string{"", "en", "ar", "pt-BR", "zh-Hant"} {
+		def := &SurveyDefinition{Questions: questions, Language: lang}
+		assert.NoError(t, def.ValidateDefinition(), lang)
+	}
+
+	for _, lang := range []string{"e", "english!", "en_US", "<script>"} {
+		def := &SurveyDefinition{Questions: questions, Language: lang}
+		err := def.ValidateDefinition()
+		assert.Error(t, err, lang)
+		if err != nil {
+			assert.Contains(t, err.Error(), "invalid language tag")
+		}
+	}
+}
+
+func TestValidateDefinition_Visibility(t *testing.T) {
+	questions := []Question{
+		{ID: "q1", Text: "Question 1", Type: QuestionTypeText},
+	}
+
+	for _, visibility := range []string{"", VisibilityPublic, VisibilityUnlisted, VisibilityToken, VisibilityInvite} {
+		def := &SurveyDefinition{Questions: questions, Visibility: visibility}
+		assert.NoError(t, def.ValidateDefinition(), visibility)
+		assert.Equal(t, visibility == "" || visibility == VisibilityPublic, def.IsListed(), visibility)
+		assert.Equal(t, visibility == VisibilityToken || visibility == VisibilityInvite, def.IsPrivate(), visibility)
+	}
+
+	def := &SurveyDefinition{Questions: questions, Visibility: "private"}
+	err := def.ValidateDefinition()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid visibility")
+}
+
+func TestValidateDefinition_ResponseMetadata(t *testing.T) {
+	questions := []Question{
+		{ID: "q1", Text: "Question 1", Type: QuestionTypeText},
+	}
+
+	def := &SurveyDefinition{Questions: questions, ResponseMetadata: true}
+	assert.NoError(t, def.ValidateDefinition())
+	assert.True(t, def.CollectsResponseMetadata())
+
+	def.Anonymous = true
+	var defErr *DefinitionError
+	require.ErrorAs(t, def.ValidateDefinition(), &defErr)
+	assert.Equal(t, "/responseMetadata", defErr.Path)
+	assert.False(t, def.CollectsResponseMetadata(), "anonymous surveys never collect it, even from records")
+}
+
+func TestParseSurveyDefinition_YAMLLanguage(t *testing.T) {
+	yamlData := []byte(`
+language: he
+questions:
+  - id: q1
+    text: "Question"
+    type: text
+`)
+
+	def, err := ParseSurveyDefinition(yamlData)
+	require.NoError(t, err)
+	assert.Equal(t, "he", def.Language)
+}
+
 func TestValidateSlug_Valid(t *testing.T) {
 	validSlugs := []string{
 		"my-survey",
@@ -875,3 +976,90 @@ func TestValidateDefinition_SanitizationPreservesValidation(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "question text is required")
 }
+
+func TestQuestionOrdinals(t *testing.T) {
+	def := &SurveyDefinition{
+		Questions: []Question{{ID: "b"}, {ID: "a"}, {ID: "c"}},
+	}
+
+	assert.Equal(t, map[string]int{"b": 1, "a": 2, "c": 3}, def.QuestionOrdinals())
+}
+
+func TestSurveyResults_OrderedQuestionResults(t *testing.T) {
+	results := &SurveyResults{
+		QuestionResults: map[string]*QuestionResult{
+			"a":       {QuestionID: "a", Ordinal: 2},
+			"z":       {QuestionID: "z", Ordinal: 1},
+			"removed": {QuestionID: "removed"},
+			"m":       {QuestionID: "m", Ordinal: 3},
+		},
+	}
+
+	var ids []string
+	for _, qr := range results.OrderedQuestionResults() {
+		ids = append(ids, qr.QuestionID)
+	}
+	assert.Equal(t, []string{"z", "a", "m", "removed"}, ids)
+}
+
+func TestSurveyResults_MarshalJSONKeepsOrdinalOrder(t *testing.T) {
+	surveyID := uuid.New()
+	results := SurveyResults{
+		SurveyID:   surveyID,
+		TotalVotes: 3,
+		QuestionResults: map[string]*QuestionResult{
+			"alpha": {QuestionID: "alpha", Ordinal: 2, OptionCounts: map[string]int{}, TextAnswers: []string{}},
+			"zulu":  {QuestionID: "zulu", Ordinal: 1, Respondents: 3, OptionCounts: map[string]int{"x": 3}, TextAnswers: []string{}},
+		},
+	}
+
+	data, err := json.Marshal(results)
+	require.NoError(t, err)
+
+	expected := `{"surveyId":"` + surveyID.String() + `","totalVotes":3,"questionResults":{` +
+		`"zulu":{"questionId":"zulu","ordinal":1,"respondents":3,"optionCounts":{"x":3},"textAnswers":[]},` +
+		`"alpha":{"questionId":"alpha","ordinal":2,"respondents":0,"optionCounts":{},"textAnswers":[]}}}`
+	assert.Equal(t, expected, string(data))
+
+	// Pointers marshal the same way and the output still decodes
+	data, err = json.Marshal(&results)
+	require.NoError(t, err)
+	var decoded SurveyResults
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, 1, decoded.QuestionResults["zulu"].Ordinal)
+}
+
+func TestSurvey_IsForeign(t *testing.T) {
+	uri := func(s string) *string { return &s }
+
+	assert.False(t, (&Survey{}).IsForeign())
+	assert.False(t, (&Survey{URI: uri("at://did:plc:abc/net.openmeet.survey/3k")}).IsForeign())
+	assert.True(t, (&Survey{URI: uri("at://did:plc:abc/com.example.poll/3k")}).IsForeign())
+}
+
+func TestSurvey_IsClosed(t *testing.T) {
+	now := time.Now()
+	endsAt := now.Add(time.Minute)
+	survey := &Survey{EndsAt: &endsAt}
+
+	assert.False(t, (&Survey{}).IsClosed(now), "surveys without an end stay open")
+	assert.False(t, survey.IsClosed(now))
+	assert.True(t, survey.IsClosed(endsAt), "voting ends at EndsAt")
+	assert.True(t, survey.IsClosed(endsAt.Add(time.Second)))
+}
+
+func TestSurveyDefinition_OptionText(t *testing.T) {
+	def := &SurveyDefinition{Questions: []Question{
+		{ID: "q1", Options: []Option{{ID: "a", Text: "Apple"}}},
+		{ID: "q2", Options: []Option{{ID: "a", Text: "Avocado"}}},
+	}}
+
+	text, ok := def.OptionText("q2", "a")
+	assert.True(t, ok)
+	assert.Equal(t, "Avocado", text)
+
+	_, ok = def.OptionText("q1", "b")
+	assert.False(t, ok)
+	_, ok = def.OptionText("q3", "a")
+	assert.False(t, ok)
+}
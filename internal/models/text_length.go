@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultTextAnswerLength is the maximum length of answers to text questions
+// without a maxLength
+const DefaultTextAnswerLength = 2000
+
+// TextLength counts the characters of a text answer. Line breaks count as
+// one character, as they do in browsers, although forms submit them as CRLF.
+func TextLength(text string) int {
+	return utf8.RuneCountInString(strings.ReplaceAll(text, "\r\n", "\n"))
+}
+
+// TextMaxLength returns the maximum length of answers to a text question
+func (q *Question) TextMaxLength() int {
+	if q.MaxLength > 0 {
+		return q.MaxLength
+	}
+	return DefaultTextAnswerLength
+}
+
+// TruncateAnswer shortens a text answer to the question's maximum length,
+// ending it with an ellipsis. Answers given before the limit was lowered
+// are shown this way; they are stored in full.
+func (q *Question) TruncateAnswer(text string) string {
+	limit := q.TextMaxLength()
+	if TextLength(text) <= limit {
+		return text
+	}
+	runes := []rune(strings.ReplaceAll(text, "\r\n", "\n"))
+	return strings.TrimRight(string(runes[:limit]), " \t\r\n") + "…"
+}
+
+// validateTextLengths checks the minLength and maxLength of a question
+func validateTextLengths(q *Question) error {
+	if q.Type != QuestionTypeText {
+		if q.MinLength != 0 || q.MaxLength != 0 {
+			return errors.New("only text questions have minLength and maxLength")
+		}
+		return nil
+	}
+
+	if q.MinLength < 0 || q.MaxLength < 0 {
+		return errors.New("minLength and maxLength must not be negative")
+	}
+	if q.MaxLength > MaxTextAnswerLength {
+		return fmt.Errorf("maxLength %d exceeds maximum of %d", q.MaxLength, MaxTextAnswerLength)
+	}
+	if q.MinLength > q.TextMaxLength() {
+		return fmt.Errorf("minLength %d is greater than maxLength %d", q.MinLength, q.TextMaxLength())
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextLength(t *testing.T) {
+	assert.Equal(t, 5, TextLength("hello"))
+	assert.Equal(t, 4, TextLength("café"), "characters, not bytes")
+	assert.Equal(t, 3, TextLength("a\r\nb"), "form line breaks count once")
+}
+
+func TestValidateDefinition_TextLengths(t *testing.T) {
+	question := func(t QuestionType, min, max int) *SurveyDefinition {
+		q := Question{ID: "q1", Text: "Why?", Type: t, MinLength: min, MaxLength: max}
+		if t == QuestionTypeSingle {
+			q.Options = []Option{{ID: "a", Text: "A"}, {ID: "b", Text: "B"}}
+		}
+		return &SurveyDefinition{Questions: []Question{q}}
+	}
+
+	assert.NoError(t, question(QuestionTypeText, 0, 0).ValidateDefinition())
+	assert.NoError(t, question(QuestionTypeText, 10, 500).ValidateDefinition())
+	assert.NoError(t, question(QuestionTypeText, 2000, 0).ValidateDefinition())
+	assert.NoError(t, question(QuestionTypeText, 0, MaxTextAnswerLength).ValidateDefinition())
+
+	assert.ErrorContains(t, question(QuestionTypeText, 0, MaxTextAnswerLength+1).ValidateDefinition(), "maxLength 5001 exceeds maximum of 5000")
+	assert.ErrorContains(t, question(QuestionTypeText, 100, 50).ValidateDefinition(), "minLength 100 is greater than maxLength 50")
+	assert.ErrorContains(t, question(QuestionTypeText, 2001, 0).ValidateDefinition(), "minLength 2001 is greater than maxLength 2000")
+	assert.ErrorContains(t, question(QuestionTypeText, -1, 0).ValidateDefinition(), "must not be negative")
+	assert.ErrorContains(t, question(QuestionTypeSingle, 0, 100).ValidateDefinition(), "only text questions have minLength and maxLength")
+}
+
+func TestValidateAnswers_TextLengths(t *testing.T) {
+	def := &SurveyDefinition{
+		Questions: []Question{
+			{ID: "why", Text: "Why?", Type: QuestionTypeText, MinLength: 10, MaxLength: 20},
+			{ID: "notes", Text: "Notes?", Type: QuestionTypeText},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		answers map[string]Answer
+		wantErr string
+	}{
+		{"within limits", map[string]Answer{"why": {Text: "because it is"}}, ""},
+		{"at the maximum", map[string]Answer{"why": {Text: strings.Repeat("é", 20)}}, ""},
+		{"too long", map[string]Answer{"why": {Text: strings.Repeat("a", 21)}}, "exceeds maximum length of 20 characters"},
+		{"too short", map[string]Answer{"why": {Text: "short"}}, "shorter than the minimum length of 10 characters"},
+		{"optional and empty", map[string]Answer{"why": {Text: "  "}}, ""},
+		{"default maximum", map[string]Answer{"notes": {Text: strings.Repeat("a", DefaultTextAnswerLength)}}, ""},
+		{"over the default maximum", map[string]Answer{"notes": {Text: strings.Repeat("a", DefaultTextAnswerLength+1)}}, "exceeds maximum length of 2000 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAnswers(def, tt.answers)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuestion_TruncateAnswer(t *testing.T) {
+	q := &Question{ID: "why", Type: QuestionTypeText, MaxLength: 5}
+	assert.Equal(t, "short", q.TruncateAnswer("short"))
+	assert.Equal(t, "longe…", q.TruncateAnswer("longer"))
+	assert.Equal(t, "ab…", q.TruncateAnswer("ab   cd"), "trailing space is dropped")
+
+	q.MaxLength = 0
+	answer := strings.Repeat("a", DefaultTextAnswerLength+10)
+	require.Equal(t, DefaultTextAnswerLength+1, TextLength(q.TruncateAnswer(answer)))
+}
+
+func TestDiffDefinitions_TextLength(t *testing.T) {
+	old := &SurveyDefinition{Questions: []Question{{ID: "why", Text: "Why?", Type: QuestionTypeText}}}
+	updated := &SurveyDefinition{Questions: []Question{{ID: "why", Text: "Why?", Type: QuestionTypeText, MinLength: 10, MaxLength: 500}}}
+
+	changes := DiffDefinitions(old, updated)
+	require.Len(t, changes, 1)
+	assert.Equal(t, ChangeQuestionLength, changes[0].Kind)
+	assert.Equal(t, `changed the answer length of "Why?" from at most 2000 characters to 10 to 500 characters`, changes[0].Describe())
+}
@@ -0,0 +1,228 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts of date and datetime answers and bounds. Datetimes are wall-clock
+// times without a zone, as entered in the voter's datetime-local input.
+const (
+	DateLayout     = time.DateOnly
+	DateTimeLayout = "2006-01-02T15:04"
+)
+
+// maxHistogramBuckets bounds the buckets of a number question's histogram
+const maxHistogramBuckets = 10
+
+// Bucket counts the answers of a number question from Min to Max. Buckets
+// include Min and exclude Max, except the last, which includes both.
+type Bucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// DateSummary summarizes the answers of a date or datetime question
+type DateSummary struct {
+	Earliest        string `json:"earliest"`
+	Latest          string `json:"latest"`
+	MostCommon      string `json:"mostCommon"` // earliest of the most common answers on a tie
+	MostCommonCount int    `json:"mostCommonCount"`
+}
+
+// HasRange reports whether a question type takes a value with min and max bounds
+func (t QuestionType) HasRange() bool {
+	return t == QuestionTypeNumber || t == QuestionTypeDate || t == QuestionTypeDateTime
+}
+
+// ParseValue parses the answer or bound of a number, date, or datetime
+// question. It returns the value in its canonical form and as a number that
+// orders values: the number itself, or the Unix time of dates.
+// Datetimes may also be given in RFC 3339, whose zone is dropped.
+func ParseValue(t QuestionType, s string) (string, float64, error) {
+	s = strings.TrimSpace(s)
+	switch t {
+	case QuestionTypeNumber:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return "", 0, fmt.Errorf("'%s' is not a number", s)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), f, nil
+	case QuestionTypeDate:
+		d, err := time.Parse(DateLayout, s)
+		if err != nil {
+			return "", 0, fmt.Errorf("'%s' is not a date (YYYY-MM-DD)", s)
+		}
+		return d.Format(DateLayout), float64(d.Unix()), nil
+	case QuestionTypeDateTime:
+		d, err := time.Parse(DateTimeLayout, s)
+		if err != nil {
+			zoned, zerr := time.Parse(time.RFC3339, s)
+			if zerr != nil {
+				return "", 0, fmt.Errorf("'%s' is not a date and time (YYYY-MM-DDTHH:MM)", s)
+			}
+			d, _ = time.Parse(DateTimeLayout, zoned.Format(DateTimeLayout))
+		}
+		return d.Format(DateTimeLayout), float64(d.Unix()), nil
+	default:
+		return "", 0, fmt.Errorf("question type '%s' has no values", t)
+	}
+}
+
+// validateRange checks the min and max bounds of a question, normalizing them
+func validateRange(q *Question) error {
+	if !q.Type.HasRange() {
+		if q.Min != "" || q.Max != "" {
+			return errors.New("only number, date, and datetime questions have min and max")
+		}
+		return nil
+	}
+
+	var lo, hi float64
+	var err error
+	if q.Min != "" {
+		if q.Min, lo, err = ParseValue(q.Type, q.Min); err != nil {
+			return fmt.Errorf("min: %w", err)
+		}
+	}
+	if q.Max != "" {
+		if q.Max, hi, err = ParseValue(q.Type, q.Max); err != nil {
+			return fmt.Errorf("max: %w", err)
+		}
+	}
+	if q.Min != "" && q.Max != "" && lo > hi {
+		return errors.New("min is greater than max")
+	}
+	return nil
+}
+
+// validateValueAnswer checks that the answer to a number, date, or datetime
+// question is a value within the question's bounds, normalizing it
+func validateValueAnswer(question *Question, answer *Answer) error {
+	if strings.TrimSpace(answer.Text) == "" {
+		if question.Required {
+			return errors.New("answer is required")
+		}
+		answer.Text = ""
+		return nil
+	}
+
+	value, v, err := ParseValue(question.Type, answer.Text)
+	if err != nil {
+		return err
+	}
+	if question.Min != "" {
+		if _, lo, err := ParseValue(question.Type, question.Min); err == nil && v < lo {
+			return fmt.Errorf("answer is below the minimum of %s", question.Min)
+		}
+	}
+	if question.Max != "" {
+		if _, hi, err := ParseValue(question.Type, question.Max); err == nil && v > hi {
+			return fmt.Errorf("answer is above the maximum of %s", question.Max)
+		}
+	}
+	answer.Text = value
+	return nil
+}
+
+// SummarizeValues aggregates the answers of a number, date, or datetime
+// question: a histogram for numbers, and the earliest, latest, and most common
+// answer for dates. Answers that do not parse are skipped.
+func (r *QuestionResult) SummarizeValues(question *Question, answers []string) {
+	switch question.Type {
+	case QuestionTypeNumber:
+		values := make([]float64, 0, len(answers))
+		for _, answer := range answers {
+			if _, v, err := ParseValue(question.Type, answer); err == nil {
+				values = append(values, v)
+			}
+		}
+		r.Histogram = histogram(question, values)
+	case QuestionTypeDate, QuestionTypeDateTime:
+		r.Dates = summarizeDates(question.Type, answers)
+	}
+}
+
+// histogram buckets the answers of a number question over its bounds, or the
+// range of the answers where it has none. Whole numbers over a small range get
+// a bucket per number.
+func histogram(question *Question, values []float64) []Bucket {
+	if len(values) == 0 {
+		return nil
+	}
+
+	lo, hi := values[0], values[0]
+	whole := true
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+		whole = whole && v == math.Trunc(v)
+	}
+	if _, bound, err := ParseValue(question.Type, question.Min); err == nil {
+		lo = math.Min(lo, bound)
+	}
+	if _, bound, err := ParseValue(question.Type, question.Max); err == nil {
+		hi = math.Max(hi, bound)
+	}
+
+	if lo == hi {
+		return []Bucket{{Min: lo, Max: hi, Count: len(values)}}
+	}
+	if whole && lo == math.Trunc(lo) && hi == math.Trunc(hi) && hi-lo < maxHistogramBuckets {
+		buckets := make([]Bucket, 0, int(hi-lo)+1)
+		for n := lo; n <= hi; n++ {
+			buckets = append(buckets, Bucket{Min: n, Max: n})
+		}
+		for _, v := range values {
+			buckets[int(v-lo)].Count++
+		}
+		return buckets
+	}
+
+	width := (hi - lo) / maxHistogramBuckets
+	buckets := make([]Bucket, maxHistogramBuckets)
+	for i := range buckets {
+		buckets[i].Min = lo + float64(i)*width
+		buckets[i].Max = lo + float64(i+1)*width
+	}
+	buckets[len(buckets)-1].Max = hi
+	for _, v := range values {
+		i := int((v - lo) / width)
+		buckets[min(max(i, 0), len(buckets)-1)].Count++
+	}
+	return buckets
+}
+
+// summarizeDates returns the earliest, latest, and most common of the answers
+// to a date or datetime question, or nil if none parse
+func summarizeDates(t QuestionType, answers []string) *DateSummary {
+	counts := make(map[string]int)
+	for _, answer := range answers {
+		if value, _, err := ParseValue(t, answer); err == nil {
+			counts[value]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	// Canonical dates and datetimes sort chronologically as strings
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	summary := &DateSummary{Earliest: values[0], Latest: values[len(values)-1]}
+	for _, value := range values {
+		if counts[value] > summary.MostCommonCount {
+			summary.MostCommon, summary.MostCommonCount = value, counts[value]
+		}
+	}
+	return summary
+}
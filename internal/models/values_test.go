@@ -0,0 +1,137 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		t         QuestionType
+		input     string
+		canonical string
+		wantErr   bool
+	}{
+		{"integer", QuestionTypeNumber, " 42 ", "42", false},
+		{"decimal", QuestionTypeNumber, "2.50", "2.5", false},
+		{"not a number", QuestionTypeNumber, "ten", "", true},
+		{"infinity", QuestionTypeNumber, "Inf", "", true},
+		{"date", QuestionTypeDate, "2026-03-01", "2026-03-01", false},
+		{"bad date", QuestionTypeDate, "01/03/2026", "", true},
+		{"datetime", QuestionTypeDateTime, "2026-03-01T09:30", "2026-03-01T09:30", false},
+		{"RFC 3339 keeps the wall clock", QuestionTypeDateTime, "2026-03-01T09:30:00+02:00", "2026-03-01T09:30", false},
+		{"date for datetime", QuestionTypeDateTime, "2026-03-01", "", true},
+		{"text has no values", QuestionTypeText, "hello", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonical, _, err := ParseValue(tt.t, tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.canonical, canonical)
+		})
+	}
+}
+
+func TestValidateDefinition_Ranges(t *testing.T) {
+	question := func(t QuestionType, min, max string) *SurveyDefinition {
+		return &SurveyDefinition{Questions: []Question{{ID: "q1", Text: "When?", Type: t, Min: min, Max: max}}}
+	}
+
+	assert.NoError(t, question(QuestionTypeNumber, "", "").ValidateDefinition())
+	assert.NoError(t, question(QuestionTypeNumber, "0", "10").ValidateDefinition())
+	assert.NoError(t, question(QuestionTypeDate, "2026-01-01", "").ValidateDefinition())
+	assert.NoError(t, question(QuestionTypeDateTime, "", "2026-01-01T18:00").ValidateDefinition())
+
+	assert.ErrorContains(t, question(QuestionTypeNumber, "10", "1").ValidateDefinition(), "min is greater than max")
+	assert.ErrorContains(t, question(QuestionTypeDate, "soon", "").ValidateDefinition(), "min:")
+	assert.ErrorContains(t, question(QuestionTypeText, "1", "").ValidateDefinition(), "only number, date, and datetime questions")
+
+	// Bounds are normalized
+	def := question(QuestionTypeNumber, "1.0", " 5 ")
+	require.NoError(t, def.ValidateDefinition())
+	assert.Equal(t, "1", def.Questions[0].Min)
+	assert.Equal(t, "5", def.Questions[0].Max)
+}
+
+func TestValidateAnswers_Values(t *testing.T) {
+	def := &SurveyDefinition{
+		Questions: []Question{
+			{ID: "age", Text: "Age?", Type: QuestionTypeNumber, Required: true, Min: "18", Max: "120"},
+			{ID: "day", Text: "Day?", Type: QuestionTypeDate, Min: "2026-01-01"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		answers map[string]Answer
+		wantErr string
+	}{
+		{"in range", map[string]Answer{"age": {Text: "30"}, "day": {Text: "2026-02-01"}}, ""},
+		{"optional left empty", map[string]Answer{"age": {Text: "30"}, "day": {Text: ""}}, ""},
+		{"required empty", map[string]Answer{"age": {Text: " "}}, "answer is required"},
+		{"below minimum", map[string]Answer{"age": {Text: "12"}}, "below the minimum of 18"},
+		{"above maximum", map[string]Answer{"age": {Text: "500"}}, "above the maximum of 120"},
+		{"date before minimum", map[string]Answer{"age": {Text: "30"}, "day": {Text: "2025-12-31"}}, "below the minimum of 2026-01-01"},
+		{"not a number", map[string]Answer{"age": {Text: "old"}}, "is not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAnswers(def, tt.answers)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	// Answers are normalized
+	answers := map[string]Answer{"age": {Text: " 30.0 "}}
+	require.NoError(t, ValidateAnswers(def, answers))
+	assert.Equal(t, "30", answers["age"].Text)
+}
+
+func TestQuestionResult_SummarizeValues_Numbers(t *testing.T) {
+	// Whole numbers over a small range get a bucket per number
+	var r QuestionResult
+	r.SummarizeValues(&Question{Type: QuestionTypeNumber, Min: "1", Max: "5"}, []string{"2", "2", "5", "bad"})
+	assert.Equal(t, []Bucket{
+		{Min: 1, Max: 1}, {Min: 2, Max: 2, Count: 2}, {Min: 3, Max: 3}, {Min: 4, Max: 4}, {Min: 5, Max: 5, Count: 1},
+	}, r.Histogram)
+
+	// Wider ranges are split into equal buckets, the last including its maximum
+	r = QuestionResult{}
+	r.SummarizeValues(&Question{Type: QuestionTypeNumber}, []string{"0", "49.5", "50", "100"})
+	require.Len(t, r.Histogram, maxHistogramBuckets)
+	assert.Equal(t, 1, r.Histogram[0].Count)
+	assert.Equal(t, 1, r.Histogram[4].Count)
+	assert.Equal(t, 1, r.Histogram[5].Count)
+	assert.Equal(t, Bucket{Min: 90, Max: 100, Count: 1}, r.Histogram[9])
+
+	// A single value gets a single bucket
+	r = QuestionResult{}
+	r.SummarizeValues(&Question{Type: QuestionTypeNumber}, []string{"2.5", "2.5"})
+	assert.Equal(t, []Bucket{{Min: 2.5, Max: 2.5, Count: 2}}, r.Histogram)
+}
+
+func TestQuestionResult_SummarizeValues_Dates(t *testing.T) {
+	var r QuestionResult
+	r.SummarizeValues(&Question{Type: QuestionTypeDate}, []string{"2026-03-02", "2026-01-15", "2026-03-02", "2026-05-01", "2026-01-15"})
+	assert.Equal(t, &DateSummary{
+		Earliest:        "2026-01-15",
+		Latest:          "2026-05-01",
+		MostCommon:      "2026-01-15", // ties go to the earliest
+		MostCommonCount: 2,
+	}, r.Dates)
+
+	r = QuestionResult{}
+	r.SummarizeValues(&Question{Type: QuestionTypeDateTime}, nil)
+	assert.Nil(t, r.Dates)
+}
@@ -0,0 +1,297 @@
+// Package moderation checks free-text answers before they are shown in
+// public results. Flagged answers are stored for review by the survey author
+// or an admin and excluded from results until approved.
+package moderation
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/telemetry"
+)
+
+// Review statuses of a flagged answer
+const (
+	StatusPending  = "pending"  // Awaiting review, hidden from results
+	StatusApproved = "approved" // Reviewed and allowed, shown in results
+	StatusRejected = "rejected" // Reviewed and hidden from results
+)
+
+// Verdict is the outcome of checking a piece of text
+type Verdict struct {
+	Flagged bool
+	Source  string // Checker that flagged the text ("blocklist", "openai")
+	Reason  string // Matched term or flagged categories
+}
+
+// Checker checks text for content that should not be shown publicly
+type Checker interface {
+	Name() string
+	Check(ctx context.Context, text string) (*Verdict, error)
+}
+
+// FlaggedResponse represents a flagged text answer stored for review
+type FlaggedResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	ResponseID uuid.UUID  `json:"responseId"`
+	SurveyID   uuid.UUID  `json:"surveyId"`
+	QuestionID string     `json:"questionId"`
+	Text       string     `json:"text"`
+	Source     string     `json:"source"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	ReviewedBy *string    `json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Moderator runs text through its checkers in order
+type Moderator struct {
+	checkers []Checker
+}
+
+// NewModerator creates a moderator from the given checkers
+func NewModerator(checkers ...Checker) *Moderator {
+	return &Moderator{checkers: checkers}
+}
+
+// Enabled reports whether the moderator has any checkers
+func (m *Moderator) Enabled() bool {
+	return m != nil && len(m.checkers) > 0
+}
+
+// Check returns the first flagging verdict, or an unflagged verdict.
+// Checker errors are logged and skipped so an outage of an external
+// moderation API does not block submissions.
+func (m *Moderator) Check(ctx context.Context, text string) *Verdict {
+	if !m.Enabled() || strings.TrimSpace(text) == "" {
+		return &Verdict{}
+	}
+
+	for _, checker := range m.checkers {
+		verdict, err := checker.Check(ctx, text)
+		if err != nil {
+			log.Printf("Moderation check %s failed: %v", checker.Name(), err)
+			telemetry.ModerationChecksTotal.WithLabelValues(checker.Name(), "error").Inc()
+			continue
+		}
+		if verdict.Flagged {
+			telemetry.ModerationChecksTotal.WithLabelValues(checker.Name(), "flagged").Inc()
+			return verdict
+		}
+		telemetry.ModerationChecksTotal.WithLabelValues(checker.Name(), "clean").Inc()
+	}
+
+	return &Verdict{}
+}
+
+// Store persists flagged answers
+type Store interface {
+	CreateFlaggedResponse(ctx context.Context, f *FlaggedResponse) error
+	DeleteFlaggedResponse(ctx context.Context, responseID uuid.UUID, questionID string) error
+}
+
+// Verdicts are the verdicts on the text answers of a response, by question ID
+type Verdicts map[string]*Verdict
+
+// CheckAnswers checks the text answers of a response. Checkers may call
+// external APIs, so answers are checked before the transaction that stores
+// the response, not inside it. Returns nil if the moderator is disabled.
+func (m *Moderator) CheckAnswers(ctx context.Context, answers map[string]models.Answer) Verdicts {
+	if !m.Enabled() {
+		return nil
+	}
+
+	verdicts := make(Verdicts)
+	for questionID, answer := range answers {
+		if answer.Text != "" {
+			verdicts[questionID] = m.Check(ctx, answer.Text)
+		}
+	}
+	return verdicts
+}
+
+// Flags returns a pending flag for each flagged answer, in question order
+func (v Verdicts) Flags(surveyID, responseID uuid.UUID, answers map[string]models.Answer) []*FlaggedResponse {
+	var flags []*FlaggedResponse
+	for _, questionID := range v.questionIDs() {
+		verdict := v[questionID]
+		if !verdict.Flagged {
+			continue
+		}
+		flags = append(flags, &FlaggedResponse{
+			ID:         uuid.New(),
+			ResponseID: responseID,
+			SurveyID:   surveyID,
+			QuestionID: questionID,
+			Text:       answers[questionID].Text,
+			Source:     verdict.Source,
+			Reason:     verdict.Reason,
+			Status:     StatusPending,
+			CreatedAt:  time.Now(),
+		})
+	}
+	return flags
+}
+
+// Save stores a flag for each flagged answer. Answers that are no longer
+// flagged lose their flag, so it can be called again when a response is
+// updated. Returns the number of flagged answers.
+func (v Verdicts) Save(ctx context.Context, store Store, surveyID, responseID uuid.UUID, answers map[string]models.Answer) (int, error) {
+	flags := v.Flags(surveyID, responseID, answers)
+	for _, questionID := range v.questionIDs() {
+		if v[questionID].Flagged {
+			continue
+		}
+		if err := store.DeleteFlaggedResponse(ctx, responseID, questionID); err != nil {
+			return 0, err
+		}
+	}
+	for i, flag := range flags {
+		if err := store.CreateFlaggedResponse(ctx, flag); err != nil {
+			return i, err
+		}
+	}
+	return len(flags), nil
+}
+
+// questionIDs returns the checked question IDs in order, so flags are stored
+// deterministically
+func (v Verdicts) questionIDs() []string {
+	questionIDs := make([]string, 0, len(v))
+	for questionID := range v {
+		questionIDs = append(questionIDs, questionID)
+	}
+	sort.Strings(questionIDs)
+	return questionIDs
+}
+
+// Blocklist flags text containing any of its terms as a whole word (case-insensitive)
+type Blocklist struct {
+	pattern *regexp.Regexp
+}
+
+// NewBlocklist creates a blocklist from the given terms. Empty terms are ignored.
+func NewBlocklist(terms []string) *Blocklist {
+	var quoted []string
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		quoted = append(quoted, regexp.QuoteMeta(strings.ToLower(term)))
+	}
+	if len(quoted) == 0 {
+		return &Blocklist{}
+	}
+
+	return &Blocklist{
+		pattern: regexp.MustCompile(`(?i)(?:^|\W)(` + strings.Join(quoted, "|") + `)(?:\W|$)`),
+	}
+}
+
+// Name implements Checker
+func (b *Blocklist) Name() string {
+	return "blocklist"
+}
+
+// Check implements Checker
+func (b *Blocklist) Check(ctx context.Context, text string) (*Verdict, error) {
+	if b.pattern == nil {
+		return &Verdict{}, nil
+	}
+
+	match := b.pattern.FindStringSubmatch(text)
+	if match == nil {
+		return &Verdict{}, nil
+	}
+
+	return &Verdict{
+		Flagged: true,
+		Source:  b.Name(),
+		Reason:  "matched blocked term \"" + strings.ToLower(match[1]) + "\"",
+	}, nil
+}
+
+// Config holds moderation configuration
+type Config struct {
+	Blocklist     []string
+	OpenAIEnabled bool
+	OpenAIKey     string
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - MODERATION_BLOCKLIST: comma-separated blocked terms
+//   - MODERATION_BLOCKLIST_FILE: file with one blocked term per line (# starts a comment)
+//   - MODERATION_OPENAI: set to "true" to also check answers with the OpenAI moderation API
+//   - OPENAI_API_KEY: API key for the OpenAI moderation API
+func ConfigFromEnv() Config {
+	config := Config{
+		OpenAIEnabled: os.Getenv("MODERATION_OPENAI") == "true",
+		OpenAIKey:     os.Getenv("OPENAI_API_KEY"),
+	}
+
+	if v := os.Getenv("MODERATION_BLOCKLIST"); v != "" {
+		config.Blocklist = append(config.Blocklist, strings.Split(v, ",")...)
+	}
+
+	if path := os.Getenv("MODERATION_BLOCKLIST_FILE"); path != "" {
+		terms, err := readBlocklistFile(path)
+		if err != nil {
+			log.Printf("Warning: Failed to read moderation blocklist %s: %v", path, err)
+		} else {
+			config.Blocklist = append(config.Blocklist, terms...)
+		}
+	}
+
+	return config
+}
+
+// NewFromConfig creates a moderator with the checkers enabled by the config
+func NewFromConfig(config Config) *Moderator {
+	var checkers []Checker
+
+	blocklist := NewBlocklist(config.Blocklist)
+	if blocklist.pattern != nil {
+		checkers = append(checkers, blocklist)
+	}
+
+	if config.OpenAIEnabled {
+		if config.OpenAIKey == "" {
+			log.Println("Warning: MODERATION_OPENAI is set but OPENAI_API_KEY is not configured")
+		} else {
+			checkers = append(checkers, NewOpenAIChecker(config.OpenAIKey))
+		}
+	}
+
+	return NewModerator(checkers...)
+}
+
+// readBlocklistFile reads one term per line, skipping blank lines and comments
+func readBlocklistFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var terms []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	return terms, scanner.Err()
+}
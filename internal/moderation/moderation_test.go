@@ -0,0 +1,174 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocklist_Check(t *testing.T) {
+	blocklist := NewBlocklist([]string{"spam", " Bad Word ", ""})
+	ctx := context.Background()
+
+	tests := []struct {
+		text    string
+		flagged bool
+	}{
+		{"this is SPAM!", true},
+		{"a bad word here", true},
+		{"spammer is not a whole word match", false},
+		{"perfectly fine answer", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			verdict, err := blocklist.Check(ctx, tt.text)
+			require.NoError(t, err)
+			assert.Equal(t, tt.flagged, verdict.Flagged)
+			if tt.flagged {
+				assert.Equal(t, "blocklist", verdict.Source)
+			}
+		})
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	assert.False(t, NewFromConfig(Config{}).Enabled())
+	assert.False(t, NewFromConfig(Config{OpenAIEnabled: true}).Enabled(), "OpenAI without key is skipped")
+	assert.True(t, NewFromConfig(Config{Blocklist: []string{"spam"}}).Enabled())
+
+	var nilModerator *Moderator
+	assert.False(t, nilModerator.Enabled())
+}
+
+type stubChecker struct {
+	verdict *Verdict
+	err     error
+}
+
+func (s stubChecker) Name() string { return "stub" }
+
+func (s stubChecker) Check(ctx context.Context, text string) (*Verdict, error) {
+	return s.verdict, s.err
+}
+
+func TestModerator_CheckerErrorsFailOpen(t *testing.T) {
+	m := NewModerator(stubChecker{err: errors.New("API down")})
+	assert.False(t, m.Check(context.Background(), "anything").Flagged)
+
+	m = NewModerator(
+		stubChecker{err: errors.New("API down")},
+		NewBlocklist([]string{"spam"}),
+	)
+	assert.True(t, m.Check(context.Background(), "spam").Flagged)
+}
+
+type fakeStore struct {
+	created map[string]*FlaggedResponse
+	deleted []string
+}
+
+func (f *fakeStore) CreateFlaggedResponse(ctx context.Context, fr *FlaggedResponse) error {
+	f.created[fr.QuestionID] = fr
+	return nil
+}
+
+func (f *fakeStore) DeleteFlaggedResponse(ctx context.Context, responseID uuid.UUID, questionID string) error {
+	f.deleted = append(f.deleted, questionID)
+	return nil
+}
+
+func TestVerdicts_Save(t *testing.T) {
+	m := NewModerator(NewBlocklist([]string{"spam"}))
+	store := &fakeStore{created: make(map[string]*FlaggedResponse)}
+	surveyID, responseID := uuid.New(), uuid.New()
+
+	answers := map[string]models.Answer{
+		"q1": {Text: "buy spam now"},
+		"q2": {Text: "a thoughtful answer"},
+		"q3": {SelectedOptions: []string{"a"}},
+	}
+
+	verdicts := m.CheckAnswers(context.Background(), answers)
+	assert.Len(t, verdicts, 2, "choice answers are not checked")
+
+	flagged, err := verdicts.Save(context.Background(), store, surveyID, responseID, answers)
+	require.NoError(t, err)
+	assert.Equal(t, 1, flagged)
+
+	require.Contains(t, store.created, "q1")
+	f := store.created["q1"]
+	assert.Equal(t, responseID, f.ResponseID)
+	assert.Equal(t, surveyID, f.SurveyID)
+	assert.Equal(t, StatusPending, f.Status)
+	assert.Equal(t, "blocklist", f.Source)
+
+	// Clean answers clear any previous flag
+	assert.Equal(t, []string{"q2"}, store.deleted)
+}
+
+func TestVerdicts_Flags(t *testing.T) {
+	m := NewModerator(NewBlocklist([]string{"spam"}))
+	surveyID, responseID := uuid.New(), uuid.New()
+	answers := map[string]models.Answer{
+		"q2": {Text: "more spam"},
+		"q1": {Text: "spam"},
+		"q3": {Text: "fine"},
+	}
+
+	flags := m.CheckAnswers(context.Background(), answers).Flags(surveyID, responseID, answers)
+	require.Len(t, flags, 2)
+	assert.Equal(t, "q1", flags[0].QuestionID)
+	assert.Equal(t, "q2", flags[1].QuestionID)
+	assert.Equal(t, "more spam", flags[1].Text)
+	assert.Equal(t, responseID, flags[1].ResponseID)
+}
+
+func TestModerator_CheckAnswersDisabled(t *testing.T) {
+	var m *Moderator
+	verdicts := m.CheckAnswers(context.Background(), map[string]models.Answer{"q1": {Text: "spam"}})
+	assert.Nil(t, verdicts)
+
+	flagged, err := verdicts.Save(context.Background(), nil, uuid.New(), uuid.New(), map[string]models.Answer{"q1": {Text: "spam"}})
+	require.NoError(t, err)
+	assert.Equal(t, 0, flagged)
+	assert.Empty(t, verdicts.Flags(uuid.New(), uuid.New(), nil))
+}
+
+func TestOpenAIChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"flagged":true,"categories":{"violence":true,"hate":true,"sexual":false}}]}`))
+	}))
+	defer server.Close()
+
+	checker := NewOpenAIChecker("test-key")
+	checker.endpoint = server.URL
+
+	verdict, err := checker.Check(context.Background(), "some text")
+	require.NoError(t, err)
+	assert.True(t, verdict.Flagged)
+	assert.Equal(t, "openai", verdict.Source)
+	assert.Equal(t, "flagged categories: hate, violence", verdict.Reason)
+}
+
+func TestOpenAIChecker_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	checker := NewOpenAIChecker("test-key")
+	checker.endpoint = server.URL
+
+	_, err := checker.Check(context.Background(), "some text")
+	assert.Error(t, err)
+}
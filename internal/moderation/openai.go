@@ -0,0 +1,102 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultOpenAIEndpoint is the OpenAI moderation API endpoint
+const DefaultOpenAIEndpoint = "https://api.openai.com/v1/moderations"
+
+// OpenAIChecker flags text using the OpenAI moderation API
+type OpenAIChecker struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewOpenAIChecker creates a checker for the OpenAI moderation API
+func NewOpenAIChecker(apiKey string) *OpenAIChecker {
+	return &OpenAIChecker{
+		apiKey:   apiKey,
+		endpoint: DefaultOpenAIEndpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// openAIModerationResponse is the subset of the moderation API response we use
+type openAIModerationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// Name implements Checker
+func (o *OpenAIChecker) Name() string {
+	return "openai"
+}
+
+// Check implements Checker
+func (o *OpenAIChecker) Check(ctx context.Context, text string) (*Verdict, error) {
+	body, err := json.Marshal(map[string]string{"input": text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal moderation request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result openAIModerationResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse moderation response: %w", err)
+	}
+
+	for _, r := range result.Results {
+		if !r.Flagged {
+			continue
+		}
+
+		var categories []string
+		for category, flagged := range r.Categories {
+			if flagged {
+				categories = append(categories, category)
+			}
+		}
+		sort.Strings(categories)
+
+		return &Verdict{
+			Flagged: true,
+			Source:  o.Name(),
+			Reason:  "flagged categories: " + strings.Join(categories, ", "),
+		}, nil
+	}
+
+	return &Verdict{}, nil
+}
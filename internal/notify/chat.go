@@ -0,0 +1,184 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// chatProxy routes XRPC calls through the PDS to the Bluesky chat service
+const chatProxy = "did:web:api.bsky.chat#bsky_chat"
+
+// maxXRPCResponseSize bounds the XRPC responses read
+const maxXRPCResponseSize = 1 << 20
+
+// ChatConfig holds the Bluesky account direct messages are sent from
+type ChatConfig struct {
+	Identifier  string // Handle or DID of the account
+	AppPassword string
+	Service     string // URL of the account's PDS
+}
+
+// ChatConfigFromEnv creates a ChatConfig from environment variables
+// Environment variables:
+//   - NOTIFY_BSKY_IDENTIFIER: handle or DID of the account sending direct messages
+//   - NOTIFY_BSKY_APP_PASSWORD: an app password of the account allowed to use chats
+//     (direct messages are disabled if either is empty)
+//   - NOTIFY_BSKY_SERVICE: the account's PDS (default: https://bsky.social)
+func ChatConfigFromEnv() ChatConfig {
+	config := ChatConfig{
+		Identifier:  os.Getenv("NOTIFY_BSKY_IDENTIFIER"),
+		AppPassword: os.Getenv("NOTIFY_BSKY_APP_PASSWORD"),
+		Service:     os.Getenv("NOTIFY_BSKY_SERVICE"),
+	}
+	if config.Service == "" {
+		config.Service = "https://bsky.social"
+	}
+	return config
+}
+
+// Enabled reports whether an account to send direct messages from is configured
+func (c ChatConfig) Enabled() bool {
+	return c.Identifier != "" && c.AppPassword != ""
+}
+
+// Messenger sends direct messages with the chat.bsky.convo lexicon from the
+// configured account, logging in with its app password when needed
+type Messenger struct {
+	config ChatConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+}
+
+// NewMessenger creates a messenger, failing if no account is configured
+func NewMessenger(config ChatConfig) (*Messenger, error) {
+	if !config.Enabled() {
+		return nil, errors.New("notification account identifier and app password are required")
+	}
+	config.Service = strings.TrimSuffix(config.Service, "/")
+	return &Messenger{config: config, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// errUnauthorized is returned by xrpc when the access token is rejected
+var errUnauthorized = errors.New("unauthorized")
+
+// Send sends a direct message to did, starting a conversation if there is
+// none. Recipients must allow messages from the account.
+func (m *Messenger) Send(ctx context.Context, did, text string) error {
+	var convo struct {
+		Convo struct {
+			ID string `json:"id"`
+		} `json:"convo"`
+	}
+	query := url.Values{"members": {did}}
+	if err := m.call(ctx, http.MethodGet, "chat.bsky.convo.getConvoForMembers?"+query.Encode(), nil, &convo); err != nil {
+		return fmt.Errorf("failed to open conversation: %w", err)
+	}
+
+	message := map[string]interface{}{
+		"convoId": convo.Convo.ID,
+		"message": map[string]string{"text": text},
+	}
+	if err := m.call(ctx, http.MethodPost, "chat.bsky.convo.sendMessage", message, nil); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// call makes a chat XRPC call, logging in first if there is no access token
+// and again once if the token is rejected
+func (m *Messenger) call(ctx context.Context, method, path string, body, out interface{}) error {
+	token, err := m.token(ctx, false)
+	if err != nil {
+		return err
+	}
+	err = m.xrpc(ctx, method, path, token, body, out)
+	if errors.Is(err, errUnauthorized) {
+		if token, err = m.token(ctx, true); err != nil {
+			return err
+		}
+		err = m.xrpc(ctx, method, path, token, body, out)
+	}
+	return err
+}
+
+// token returns the account's access token, logging in if there is none or
+// refresh is set
+func (m *Messenger) token(ctx context.Context, refresh bool) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.accessToken != "" && !refresh {
+		return m.accessToken, nil
+	}
+
+	var session struct {
+		AccessJwt string `json:"accessJwt"`
+	}
+	credentials := map[string]string{"identifier": m.config.Identifier, "password": m.config.AppPassword}
+	if err := m.xrpc(ctx, http.MethodPost, "com.atproto.server.createSession", "", credentials, &session); err != nil {
+		return "", fmt.Errorf("failed to log in as %s: %w", m.config.Identifier, err)
+	}
+	m.accessToken = session.AccessJwt
+	return m.accessToken, nil
+}
+
+// xrpc makes an XRPC call to the account's PDS, proxied to the chat service
+// when authenticated
+func (m *Messenger) xrpc(ctx context.Context, method, path, token string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, m.config.Service+"/xrpc/"+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("atproto-proxy", chatProxy)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxXRPCResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	// PDSes reject expired access tokens with 400 ExpiredToken
+	if token != "" && (resp.StatusCode == http.StatusUnauthorized || bytes.Contains(data, []byte(`"ExpiredToken"`))) {
+		return errUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
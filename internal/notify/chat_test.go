@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessenger_Send(t *testing.T) {
+	logins := 0
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.createSession":
+			logins++
+			var credentials map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&credentials))
+			assert.Equal(t, "bot.example.com", credentials["identifier"])
+			assert.Empty(t, r.Header.Get("atproto-proxy"))
+			json.NewEncoder(w).Encode(map[string]string{"accessJwt": "token-" + string(rune('0'+logins))})
+		case "/xrpc/chat.bsky.convo.getConvoForMembers":
+			assert.Equal(t, chatProxy, r.Header.Get("atproto-proxy"))
+			// The first token has expired
+			if r.Header.Get("Authorization") == "Bearer token-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": "ExpiredToken"}`))
+				return
+			}
+			assert.Equal(t, "did:plc:alice", r.URL.Query().Get("members"))
+			json.NewEncoder(w).Encode(map[string]interface{}{"convo": map[string]string{"id": "convo1"}})
+		case "/xrpc/chat.bsky.convo.sendMessage":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	m, err := NewMessenger(ChatConfig{Identifier: "bot.example.com", AppPassword: "app-password", Service: server.URL + "/"})
+	require.NoError(t, err)
+
+	require.NoError(t, m.Send(context.Background(), "did:plc:alice", "Hello"))
+	assert.Equal(t, 2, logins, "rejected tokens are renewed")
+	assert.Equal(t, "convo1", sent["convoId"])
+	assert.Equal(t, map[string]interface{}{"text": "Hello"}, sent["message"])
+
+	_, err = NewMessenger(ChatConfig{Identifier: "bot.example.com"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,216 @@
+// Package notify tells survey authors about milestones of their surveys: the
+// first response, the 100th response, and the survey closing. Authors opt in
+// with preferences choosing the milestones and a channel: a Bluesky post on
+// their own account, written with their login session, or a direct message
+// from the service's Bluesky account. A worker queues milestones reached
+// since the preferences were saved and delivers them, retrying failures.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Milestone is an event of a survey authors can be notified of
+type Milestone string
+
+// Milestones
+const (
+	FirstResponse Milestone = "first_response"
+	Responses100  Milestone = "responses_100"
+	Closed        Milestone = "closed"
+)
+
+// Milestones lists every milestone, in the order they usually happen
+var Milestones = []Milestone{FirstResponse, Responses100, Closed}
+
+// Channel is how notifications are delivered
+type Channel string
+
+// Channels
+const (
+	ChannelPost Channel = "post" // A post on the author's account
+	ChannelDM   Channel = "dm"   // A chat.bsky.convo message from the service's account
+)
+
+// Notification statuses
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed" // Gave up after MaxAttempts
+)
+
+// Limits
+const (
+	MaxAttempts  = 5                // Deliveries tried before a notification fails
+	RetryDelay   = 10 * time.Minute // Multiplied by the attempts made
+	ClaimLease   = 5 * time.Minute  // Claimed notifications are not claimed again for this long
+	HistoryLimit = 50               // Latest notifications listed
+	batchSize    = 50               // Due notifications a replica claims per run
+	maxTextRunes = 300              // Bluesky's limit for posts and messages
+)
+
+// Preferences are an author's opt-in to notifications
+type Preferences struct {
+	DID        string      `json:"-"`
+	Channel    Channel     `json:"channel"`
+	Milestones []Milestone `json:"milestones"`
+	// UpdatedAt is when the preferences were saved; milestones reached before
+	// are not notified
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NewPreferences validates an opt-in and returns it ready to save
+func NewPreferences(did string, channel Channel, milestones []Milestone, now time.Time) (*Preferences, error) {
+	if channel != ChannelPost && channel != ChannelDM {
+		return nil, fmt.Errorf("channel must be %q or %q", ChannelPost, ChannelDM)
+	}
+	if len(milestones) == 0 {
+		return nil, errors.New("choose at least one milestone")
+	}
+
+	var chosen []Milestone
+	for _, m := range milestones {
+		if !slices.Contains(Milestones, m) {
+			return nil, fmt.Errorf("unknown milestone %q", m)
+		}
+		if !slices.Contains(chosen, m) {
+			chosen = append(chosen, m)
+		}
+	}
+
+	return &Preferences{DID: did, Channel: channel, Milestones: chosen, UpdatedAt: now}, nil
+}
+
+// Notification is a milestone of a survey queued for, or delivered to, its author
+type Notification struct {
+	ID            uuid.UUID  `json:"id"`
+	SurveyID      uuid.UUID  `json:"surveyId"`
+	SurveySlug    string     `json:"surveySlug,omitempty"`  // Set by ListNotifications
+	SurveyTitle   string     `json:"surveyTitle,omitempty"` // Set by ListNotifications
+	DID           string     `json:"-"`
+	Milestone     Milestone  `json:"milestone"`
+	Channel       Channel    `json:"channel"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	URI           *string    `json:"uri,omitempty"`   // The post, for ChannelPost
+	Error         *string    `json:"error,omitempty"` // Of the last failed attempt
+	NextAttemptAt time.Time  `json:"-"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// Store persists preferences and notifications
+type Store interface {
+	// GetNotificationPreferences returns nil if the author has not opted in
+	GetNotificationPreferences(ctx context.Context, did string) (*Preferences, error)
+	// SaveNotificationPreferences creates or replaces the author's preferences
+	SaveNotificationPreferences(ctx context.Context, p *Preferences) error
+	DeleteNotificationPreferences(ctx context.Context, did string) error
+	// QueueReachedMilestones queues a pending notification of every milestone
+	// the surveys of opted-in authors reached since their preferences were
+	// saved, once per survey and milestone, and returns how many it queued
+	QueueReachedMilestones(ctx context.Context, now time.Time) (int64, error)
+	// ClaimDueNotifications returns up to limit pending notifications due at
+	// now and delays their next attempt by ClaimLease, so no two replicas
+	// deliver the same one
+	ClaimDueNotifications(ctx context.Context, now time.Time, limit int) ([]*Notification, error)
+	// UpdateNotification saves the outcome of a delivery attempt
+	UpdateNotification(ctx context.Context, n *Notification) error
+	// ListNotifications returns an author's latest notifications, newest first
+	ListNotifications(ctx context.Context, did string, limit int) ([]*Notification, error)
+}
+
+// Deliverer delivers a notification, returning the URI of the post if it
+// made one
+type Deliverer func(ctx context.Context, n *Notification) (uri string, err error)
+
+// Text returns the text of a milestone's notification about a survey, linking
+// to it, with the title shortened to fit
+func Text(milestone Milestone, title, link string) string {
+	var format string
+	switch milestone {
+	case FirstResponse:
+		format = "My survey “%s” got its first response: %s"
+	case Responses100:
+		format = "My survey “%s” reached 100 responses: %s"
+	default:
+		format = "My survey “%s” has closed. See the results: %s"
+	}
+
+	runes := []rune(title)
+	room := maxTextRunes - utf8.RuneCountInString(fmt.Sprintf(format, "", link))
+	if len(runes) > room {
+		runes = append(runes[:max(room-1, 0)], '…')
+	}
+	return fmt.Sprintf(format, string(runes), link)
+}
+
+// Attempted records the outcome of a delivery attempt at now: sent, retried
+// later, or failed after MaxAttempts
+func (n *Notification) Attempted(uri string, err error, now time.Time) {
+	n.Attempts++
+	if err == nil {
+		n.Status, n.Error, n.SentAt = StatusSent, nil, &now
+		if uri != "" {
+			n.URI = &uri
+		}
+		return
+	}
+
+	msg := err.Error()
+	n.Error = &msg
+	if n.Attempts >= MaxAttempts {
+		n.Status = StatusFailed
+		return
+	}
+	n.NextAttemptAt = now.Add(time.Duration(n.Attempts) * RetryDelay)
+}
+
+// Run queues the milestones reached by now and delivers the notifications due
+func Run(ctx context.Context, store Store, deliver Deliverer, now time.Time) {
+	if _, err := store.QueueReachedMilestones(ctx, now); err != nil {
+		log.Printf("Error queueing milestone notifications: %v", err)
+	}
+
+	notifications, err := store.ClaimDueNotifications(ctx, now, batchSize)
+	if err != nil {
+		log.Printf("Error claiming milestone notifications: %v", err)
+		return
+	}
+
+	for _, n := range notifications {
+		uri, err := deliver(ctx, n)
+		if err != nil {
+			log.Printf("Error delivering %s notification of survey %s: %v", n.Milestone, n.SurveyID, err)
+		}
+		n.Attempted(uri, err, now)
+		if err := store.UpdateNotification(ctx, n); err != nil {
+			log.Printf("Error saving %s notification of survey %s: %v", n.Milestone, n.SurveyID, err)
+		}
+	}
+}
+
+// StartWorker queues and delivers notifications every interval until ctx is
+// cancelled
+func StartWorker(ctx context.Context, store Store, deliver Deliverer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		Run(ctx, store, deliver, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
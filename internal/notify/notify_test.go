@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPreferences(t *testing.T) {
+	now := time.Now()
+	p, err := NewPreferences("did:plc:alice", ChannelDM, []Milestone{Closed, FirstResponse, Closed}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []Milestone{Closed, FirstResponse}, p.Milestones)
+	assert.Equal(t, now, p.UpdatedAt)
+
+	tests := []struct {
+		name       string
+		channel    Channel
+		milestones []Milestone
+	}{
+		{"unknown channel", "email", []Milestone{Closed}},
+		{"no milestones", ChannelPost, nil},
+		{"unknown milestone", ChannelPost, []Milestone{"responses_1000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPreferences("did:plc:alice", tt.channel, tt.milestones, now)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	link := "https://survey.example/s/lunch"
+	assert.Equal(t, "My survey “Lunch?” reached 100 responses: "+link, Text(Responses100, "Lunch?", link))
+
+	text := Text(FirstResponse, strings.Repeat("long ", 100), link)
+	assert.Equal(t, maxTextRunes, utf8.RuneCountInString(text))
+	assert.True(t, strings.HasSuffix(text, "…” got its first response: "+link))
+}
+
+func TestAttempted(t *testing.T) {
+	now := time.Now()
+	n := &Notification{Status: StatusPending}
+
+	n.Attempted("", errors.New("no login session"), now)
+	assert.Equal(t, StatusPending, n.Status)
+	assert.Equal(t, now.Add(RetryDelay), n.NextAttemptAt)
+	require.NotNil(t, n.Error)
+
+	n.Attempted("at://did:plc:alice/app.bsky.feed.post/1", nil, now)
+	assert.Equal(t, StatusSent, n.Status)
+	assert.Nil(t, n.Error)
+	require.NotNil(t, n.URI)
+	assert.Equal(t, 2, n.Attempts)
+
+	failing := &Notification{Status: StatusPending, Attempts: MaxAttempts - 1}
+	failing.Attempted("", errors.New("blocked"), now)
+	assert.Equal(t, StatusFailed, failing.Status)
+}
+
+// mockStore hands out its due notifications and keeps their outcomes
+type mockStore struct {
+	Store
+	due     []*Notification
+	queued  int
+	updated []*Notification
+}
+
+func (m *mockStore) QueueReachedMilestones(ctx context.Context, now time.Time) (int64, error) {
+	m.queued++
+	return 0, nil
+}
+
+func (m *mockStore) ClaimDueNotifications(ctx context.Context, now time.Time, limit int) ([]*Notification, error) {
+	due := m.due
+	m.due = nil
+	return due, nil
+}
+
+func (m *mockStore) UpdateNotification(ctx context.Context, n *Notification) error {
+	m.updated = append(m.updated, n)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	sent, failing := uuid.New(), uuid.New()
+	store := &mockStore{due: []*Notification{
+		{ID: sent, Milestone: FirstResponse, Channel: ChannelPost, Status: StatusPending},
+		{ID: failing, Milestone: Closed, Channel: ChannelDM, Status: StatusPending},
+	}}
+
+	Run(context.Background(), store, func(ctx context.Context, n *Notification) (string, error) {
+		if n.ID == failing {
+			return "", errors.New("recipient does not accept messages")
+		}
+		return "at://did:plc:alice/app.bsky.feed.post/1", nil
+	}, time.Now())
+
+	assert.Equal(t, 1, store.queued)
+	require.Len(t, store.updated, 2)
+	assert.Equal(t, StatusSent, store.updated[0].Status)
+	assert.Equal(t, StatusPending, store.updated[1].Status)
+	assert.Equal(t, 1, store.updated[1].Attempts)
+}
@@ -0,0 +1,179 @@
+package oauth
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// stateCookieName is the cookie holding the OAuth state during login
+const stateCookieName = "oauth_state"
+
+// CookieConfig controls the attributes of the session and OAuth state cookies,
+// so sessions work behind proxies that change the host, scheme, or path
+type CookieConfig struct {
+	Name     string // Session cookie name
+	Domain   string // Cookie domain ("" for host-only cookies)
+	BasePath string // Path prefix the app is served under ("" for the root)
+	SameSite http.SameSite
+	Secure   bool
+}
+
+// DefaultCookieConfig returns the cookie settings for an app served at the root over HTTPS
+func DefaultCookieConfig() CookieConfig {
+	return CookieConfig{
+		Name:     "session",
+		SameSite: http.SameSiteLaxMode,
+		Secure:   true,
+	}
+}
+
+// cookies is the active cookie configuration
+var cookies = DefaultCookieConfig()
+
+// SetCookieConfig sets the cookie configuration.
+// Call this at startup based on environment configuration.
+func SetCookieConfig(config CookieConfig) {
+	cookies = config
+}
+
+// PublicBaseURL returns the public URL of the app, including any path prefix
+// Environment variables:
+//   - PUBLIC_BASE_URL: public URL, e.g. https://example.com/survey (default: SERVER_HOST)
+func PublicBaseURL() string {
+	if v := os.Getenv("PUBLIC_BASE_URL"); v != "" {
+		return v
+	}
+	return os.Getenv("SERVER_HOST")
+}
+
+// BasePath returns the path prefix of a public URL, e.g. "/survey" for
+// https://example.com/survey/, or "" if the app is served at the root
+func BasePath(publicURL string) string {
+	if publicURL == "" {
+		return ""
+	}
+	if !strings.Contains(publicURL, "://") {
+		publicURL = "https://" + publicURL
+	}
+	u, err := url.Parse(publicURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// CookieConfigFromEnv creates a CookieConfig from environment variables
+// Environment variables:
+//   - SESSION_COOKIE_NAME: session cookie name (default: session)
+//   - SESSION_COOKIE_DOMAIN: cookie domain, e.g. .example.com (default: host-only)
+//   - SESSION_COOKIE_SAMESITE: "lax", "strict", or "none" (default: lax)
+//   - SESSION_COOKIE_SECURE: "true" or "false" (default: true). Always true when
+//     PUBLIC_BASE_URL is https or SameSite is none.
+//   - PUBLIC_BASE_URL: its path prefix scopes the cookies (default: SERVER_HOST)
+func CookieConfigFromEnv() CookieConfig {
+	config := DefaultCookieConfig()
+	publicURL := PublicBaseURL()
+
+	if v := os.Getenv("SESSION_COOKIE_NAME"); v != "" {
+		config.Name = v
+	}
+	config.Domain = os.Getenv("SESSION_COOKIE_DOMAIN")
+	config.BasePath = BasePath(publicURL)
+
+	switch v := strings.ToLower(os.Getenv("SESSION_COOKIE_SAMESITE")); v {
+	case "", "lax":
+		config.SameSite = http.SameSiteLaxMode
+	case "strict":
+		// Strict cookies are not sent on the redirect back from the authorization
+		// server, so the session is only visible after the next navigation
+		config.SameSite = http.SameSiteStrictMode
+	case "none":
+		config.SameSite = http.SameSiteNoneMode
+	default:
+		log.Printf("Warning: Unknown SESSION_COOKIE_SAMESITE %q, using lax", v)
+	}
+
+	if v := os.Getenv("SESSION_COOKIE_SECURE"); v != "" {
+		secure, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Printf("Warning: Invalid SESSION_COOKIE_SECURE %q, using true", v)
+			secure = true
+		}
+		config.Secure = secure
+	}
+
+	if !config.Secure && strings.HasPrefix(publicURL, "https://") {
+		log.Println("Warning: SESSION_COOKIE_SECURE=false ignored because PUBLIC_BASE_URL is https")
+		config.Secure = true
+	}
+	if !config.Secure && config.SameSite == http.SameSiteNoneMode {
+		log.Println("Warning: SESSION_COOKIE_SECURE=false ignored because SameSite=None requires Secure")
+		config.Secure = true
+	}
+
+	return config
+}
+
+// path returns an app path under the base path
+func (cc CookieConfig) path(p string) string {
+	return cc.BasePath + p
+}
+
+// cookie builds a cookie with the configured attributes
+func (cc CookieConfig) cookie(name, value, path string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   cc.Domain,
+		HttpOnly: true,
+		Secure:   cc.Secure,
+		SameSite: cc.SameSite,
+		MaxAge:   maxAge,
+	}
+}
+
+// appPath is the path of cookies sent for the whole app
+func (cc CookieConfig) appPath() string {
+	// The prefix itself (not prefix + "/") so the cookie is sent for the app root
+	if cc.BasePath == "" {
+		return "/"
+	}
+	return cc.BasePath
+}
+
+// sessionCookie returns the session cookie; a negative maxAge deletes it
+func (cc CookieConfig) sessionCookie(value string, maxAge int) *http.Cookie {
+	return cc.cookie(cc.Name, value, cc.appPath(), maxAge)
+}
+
+// stateCookie returns the OAuth state cookie, scoped to the OAuth routes
+func (cc CookieConfig) stateCookie(value string, maxAge int) *http.Cookie {
+	return cc.cookie(stateCookieName, value, cc.path("/oauth"), maxAge)
+}
+
+// SessionCookieValue returns the session ID from the request's session cookie
+func SessionCookieValue(c echo.Context) (string, bool) {
+	cookie, err := c.Cookie(cookies.Name)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// AppCookie returns a cookie sent for the whole app, with the attributes of
+// the session cookie; a negative maxAge deletes it
+func AppCookie(name, value string, maxAge int) *http.Cookie {
+	return cookies.cookie(name, value, cookies.appPath(), maxAge)
+}
+
+// ClearSessionCookie deletes the session cookie from the browser
+func ClearSessionCookie(c echo.Context) {
+	c.SetCookie(cookies.sessionCookie("", -1))
+}
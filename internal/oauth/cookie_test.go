@@ -0,0 +1,109 @@
+package oauth
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBasePath(t *testing.T) {
+	tests := map[string]string{
+		"":                              "",
+		"https://example.com":           "",
+		"https://example.com/":          "",
+		"https://example.com/survey":    "/survey",
+		"https://example.com/survey/":   "/survey",
+		"example.com/apps/survey":       "/apps/survey",
+		"http://localhost:8080/survey/": "/survey",
+	}
+	for publicURL, want := range tests {
+		if got := BasePath(publicURL); got != want {
+			t.Errorf("BasePath(%q) = %q, want %q", publicURL, got, want)
+		}
+	}
+}
+
+func TestCookieConfigFromEnv_Defaults(t *testing.T) {
+	t.Setenv("PUBLIC_BASE_URL", "")
+	t.Setenv("SERVER_HOST", "")
+	t.Setenv("SESSION_COOKIE_NAME", "")
+	t.Setenv("SESSION_COOKIE_DOMAIN", "")
+	t.Setenv("SESSION_COOKIE_SAMESITE", "")
+	t.Setenv("SESSION_COOKIE_SECURE", "")
+
+	config := CookieConfigFromEnv()
+	if config != DefaultCookieConfig() {
+		t.Errorf("expected default config, got %+v", config)
+	}
+}
+
+func TestCookieConfigFromEnv(t *testing.T) {
+	t.Setenv("PUBLIC_BASE_URL", "http://localhost:8080/survey")
+	t.Setenv("SESSION_COOKIE_NAME", "survey_session")
+	t.Setenv("SESSION_COOKIE_DOMAIN", ".example.com")
+	t.Setenv("SESSION_COOKIE_SAMESITE", "Strict")
+	t.Setenv("SESSION_COOKIE_SECURE", "false")
+
+	config := CookieConfigFromEnv()
+	if config.Name != "survey_session" {
+		t.Errorf("expected name survey_session, got %s", config.Name)
+	}
+	if config.Domain != ".example.com" {
+		t.Errorf("expected domain .example.com, got %s", config.Domain)
+	}
+	if config.BasePath != "/survey" {
+		t.Errorf("expected base path /survey, got %s", config.BasePath)
+	}
+	if config.SameSite != http.SameSiteStrictMode {
+		t.Errorf("expected SameSite Strict, got %v", config.SameSite)
+	}
+	if config.Secure {
+		t.Error("expected Secure to be false for an http base URL")
+	}
+
+	cookie := config.sessionCookie("abc", 3600)
+	if cookie.Path != "/survey" {
+		t.Errorf("expected session cookie path /survey, got %s", cookie.Path)
+	}
+	if state := config.stateCookie("xyz", 600); state.Path != "/survey/oauth" {
+		t.Errorf("expected state cookie path /survey/oauth, got %s", state.Path)
+	}
+}
+
+func TestCookieConfigFromEnv_EnforcesSecure(t *testing.T) {
+	t.Run("https base URL", func(t *testing.T) {
+		t.Setenv("PUBLIC_BASE_URL", "https://example.com")
+		t.Setenv("SESSION_COOKIE_SAMESITE", "")
+		t.Setenv("SESSION_COOKIE_SECURE", "false")
+
+		if !CookieConfigFromEnv().Secure {
+			t.Error("expected Secure when PUBLIC_BASE_URL is https")
+		}
+	})
+
+	t.Run("SameSite none", func(t *testing.T) {
+		t.Setenv("PUBLIC_BASE_URL", "http://localhost:8080")
+		t.Setenv("SESSION_COOKIE_SAMESITE", "none")
+		t.Setenv("SESSION_COOKIE_SECURE", "false")
+
+		config := CookieConfigFromEnv()
+		if config.SameSite != http.SameSiteNoneMode {
+			t.Errorf("expected SameSite None, got %v", config.SameSite)
+		}
+		if !config.Secure {
+			t.Error("expected Secure when SameSite is None")
+		}
+	})
+}
+
+func TestAppCookie(t *testing.T) {
+	defer SetCookieConfig(cookies)
+	SetCookieConfig(CookieConfig{Name: "session", BasePath: "/survey", SameSite: http.SameSiteLaxMode, Secure: true})
+
+	cookie := AppCookie("drafts", "abc", 60)
+	if cookie.Name != "drafts" || cookie.Value != "abc" || cookie.MaxAge != 60 {
+		t.Errorf("unexpected cookie %+v", cookie)
+	}
+	if cookie.Path != "/survey" || !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("expected the session cookie's attributes, got %+v", cookie)
+	}
+}
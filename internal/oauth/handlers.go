@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -108,11 +109,11 @@ func (h *Handlers) LoginPage(c echo.Context) error {
 </head>
 <body>
     <h1>Login with AT Protocol</h1>
-    <form action="/oauth/login" method="post">
+    <form action="` + cookies.path("/oauth/login") + `" method="post">
         <div class="form-group">
             <label for="handle">ATProto Handle:</label>
             <input type="text" id="handle" name="handle" placeholder="alice.bsky.social" required>
-            <div class="help-text">Enter your AT Protocol handle (e.g., alice.bsky.social)</div>
+            <div class="help-text">Enter your AT Protocol handle (e.g., alice.bsky.social) or DID, on any PDS</div>
         </div>
         <button type="submit">Continue</button>
     </form>
@@ -129,16 +130,20 @@ func (h *Handlers) Login(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusMethodNotAllowed, "method not allowed")
 	}
 
-	// Get handle from form
-	handle := c.FormValue("handle")
-	if handle == "" {
+	// Get handle (or DID) from form
+	input := c.FormValue("handle")
+	if input == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "handle is required")
 	}
+	handle, did, err := ParseIdentifier(input)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
 	// Get destination (where to redirect after auth)
 	destination := c.QueryParam("destination")
 	if destination == "" {
-		destination = "/"
+		destination = cookies.path("/")
 	}
 
 	// Generate state for CSRF protection EARLY (before any network calls that might fail)
@@ -149,25 +154,19 @@ func (h *Handlers) Login(c echo.Context) error {
 	// This prevents attackers from using their own state value with a victim's session
 	// Must be set BEFORE any network calls so tests can verify cookie is set even if resolution fails
 	// Use SameSiteLax (not Strict) because OAuth callbacks are cross-site navigations
-	stateCookie := &http.Cookie{
-		Name:     "oauth_state",
-		Value:    state,
-		Path:     "/oauth",
-		HttpOnly: true,
-		Secure:   true, // HTTPS only
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   600, // 10 minutes (same as OAuth request expiry)
-	}
-	c.SetCookie(stateCookie)
-
-	// Resolve handle → DID
-	did, err := HandleToDID(handle)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to resolve handle: %v", err))
+	c.SetCookie(cookies.stateCookie(state, 600)) // 10 minutes (same as OAuth request expiry)
+
+	// Resolve handle → DID → PDS. The DID document must claim the handle back.
+	var pds string
+	if handle != "" {
+		did, err = HandleToDID(handle)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to resolve handle: %v", err))
+		}
+		pds, err = HandleDIDToPDS(handle, did)
+	} else {
+		pds, err = DIDToPDS(did)
 	}
-
-	// Resolve DID → PDS
-	pds, err := DIDToPDS(did)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to resolve PDS: %v", err))
 	}
@@ -178,10 +177,13 @@ func (h *Handlers) Login(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to resolve auth server: %v", err))
 	}
 
-	// Resolve Auth Server → PAR endpoint
-	parEndpoint, err := AuthServerToPAREndpoint(authServer)
+	// Resolve Auth Server → PAR and authorization endpoints
+	metadata, err := FetchAuthServerMetadata(authServer)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to resolve PAR endpoint: %v", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to resolve auth server: %v", err))
+	}
+	if metadata.PushedAuthorizationRequestEndpoint == "" || metadata.AuthorizationEndpoint == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("auth server %s does not support pushed authorization requests", authServer))
 	}
 
 	// Generate PKCE verifier
@@ -204,7 +206,7 @@ func (h *Handlers) Login(c echo.Context) error {
 		CodeVerifier:  pkceVerifier,
 		DPoPKey:       dpopKeyJWK,
 		ClientKey:     h.config.SecretJWK,
-		PAREndpoint:   parEndpoint,
+		PAREndpoint:   metadata.PushedAuthorizationRequestEndpoint,
 		AuthServerURL: authServer,
 	}
 
@@ -227,14 +229,8 @@ func (h *Handlers) Login(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to save OAuth request")
 	}
 
-	// Get authorization endpoint
-	authEndpoint, err := getAuthorizationEndpoint(authServer)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get authorization endpoint")
-	}
-
 	// Redirect to authorization server
-	redirectURL := fmt.Sprintf("%s?client_id=%s&request_uri=%s", authEndpoint, clientID, requestURI)
+	redirectURL := fmt.Sprintf("%s?client_id=%s&request_uri=%s", metadata.AuthorizationEndpoint, url.QueryEscape(clientID), url.QueryEscape(requestURI))
 	return c.Redirect(http.StatusFound, redirectURL)
 }
 
@@ -251,7 +247,7 @@ func (h *Handlers) Callback(c echo.Context) error {
 
 	// CSRF Protection: Verify state matches the cookie value
 	// This prevents attackers from using their own authorization code with a victim's session
-	stateCookie, err := c.Cookie("oauth_state")
+	stateCookie, err := c.Cookie(stateCookieName)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "CSRF detected: missing state cookie")
 	}
@@ -261,16 +257,7 @@ func (h *Handlers) Callback(c echo.Context) error {
 	}
 
 	// Clear the state cookie immediately after validation (single-use)
-	clearStateCookie := &http.Cookie{
-		Name:     "oauth_state",
-		Value:    "",
-		Path:     "/oauth",
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   -1, // Delete cookie
-	}
-	c.SetCookie(clearStateCookie)
+	c.SetCookie(cookies.stateCookie("", -1))
 
 	// Look up OAuth request by state
 	oauthReq, err := h.storage.GetOAuthRequest(c.Request().Context(), state)
@@ -317,12 +304,15 @@ func (h *Handlers) Callback(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("token exchange failed: %v", err))
 	}
 
-	// Resolve PDS URL for the user's DID
+	// Resolve PDS URL for the user's DID, and check that its PDS uses the
+	// authorization server that issued the tokens, which may not otherwise
+	// speak for the DID
 	pdsURL, err := DIDToPDS(tokenResp.Sub)
 	if err != nil {
-		c.Logger().Errorf("Failed to resolve PDS URL for DID %s: %v", tokenResp.Sub, err)
-		// Continue without PDS URL - user can still use the service
-		pdsURL = ""
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to resolve PDS: %v", err))
+	}
+	if authServer, err := PDSToAuthServer(pdsURL); err != nil || authServer != iss {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("auth server %s does not speak for %s", iss, tokenResp.Sub))
 	}
 
 	// Calculate token expiration time
@@ -343,6 +333,7 @@ func (h *Handlers) Callback(c echo.Context) error {
 		PDSUrl:         pdsURL,
 		TokenExpiresAt: tokenExpiresAt,
 		Issuer:         iss, // Store issuer for token refresh
+		UserAgent:      c.Request().UserAgent(),
 		ExpiresAt:      time.Now().Add(24 * time.Hour), // Session cookie expiry
 	}
 
@@ -361,21 +352,12 @@ func (h *Handlers) Callback(c echo.Context) error {
 	}
 
 	// Set session cookie
-	cookie := &http.Cookie{
-		Name:     "session",
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true, // HTTPS only
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   86400, // 24 hours
-	}
-	c.SetCookie(cookie)
+	c.SetCookie(cookies.sessionCookie(sessionID, 86400)) // 24 hours
 
 	// Redirect to destination
 	destination := oauthReq.Destination
 	if destination == "" {
-		destination = "/"
+		destination = cookies.path("/")
 	}
 
 	return c.Redirect(http.StatusFound, destination)
@@ -425,47 +407,15 @@ func (h *Handlers) JWKS(c echo.Context) error {
 // Logout handles user logout
 func (h *Handlers) Logout(c echo.Context) error {
 	// Get session cookie
-	cookie, err := c.Cookie("session")
-	if err == nil && cookie.Value != "" {
+	if sessionID, ok := SessionCookieValue(c); ok {
 		// Delete session from database
-		if err := h.storage.DeleteSession(c.Request().Context(), cookie.Value); err != nil {
+		if err := h.storage.DeleteSession(c.Request().Context(), sessionID); err != nil {
 			c.Logger().Errorf("Failed to delete session: %v", err)
 		}
 	}
 
 	// Clear session cookie
-	c.SetCookie(&http.Cookie{
-		Name:     "session",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   -1, // Delete cookie
-	})
-
-	return c.Redirect(http.StatusFound, "/")
-}
-
-// Helper functions
-
-// getAuthorizationEndpoint fetches the authorization endpoint from the auth server
-func getAuthorizationEndpoint(authServer string) (string, error) {
-	resp, err := http.Get(authServer + "/.well-known/oauth-authorization-server")
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var metadata map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
-		return "", err
-	}
-
-	endpoint, ok := metadata["authorization_endpoint"].(string)
-	if !ok {
-		return "", fmt.Errorf("missing authorization_endpoint")
-	}
+	ClearSessionCookie(c)
 
-	return endpoint, nil
+	return c.Redirect(http.StatusFound, cookies.path("/"))
 }
@@ -3,7 +3,6 @@ package oauth
 import (
 	"context"
 	"database/sql"
-	"net/http"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -26,14 +25,14 @@ func SessionMiddleware(storage SessionStore) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Try to get session cookie
-			cookie, err := c.Cookie("session")
-			if err != nil {
+			sessionID, ok := SessionCookieValue(c)
+			if !ok {
 				// No session cookie - continue without user
 				return next(c)
 			}
 
 			// Look up session in database
-			session, err := storage.GetSessionByID(c.Request().Context(), cookie.Value)
+			session, err := storage.GetSessionByID(c.Request().Context(), sessionID)
 			if err != nil {
 				if err == sql.ErrNoRows {
 					// Invalid session - continue without user
@@ -47,17 +46,11 @@ func SessionMiddleware(storage SessionStore) echo.MiddlewareFunc {
 			// Check if session is expired
 			if session.ExpiresAt.Before(time.Now()) {
 				// Clean up expired session from database
-				if err := storage.DeleteSession(c.Request().Context(), cookie.Value); err != nil {
+				if err := storage.DeleteSession(c.Request().Context(), sessionID); err != nil {
 					c.Logger().Errorf("Failed to delete expired session: %v", err)
 				}
 				// Clear the session cookie from browser
-				c.SetCookie(&http.Cookie{
-					Name:     "session",
-					Value:    "",
-					Path:     "/",
-					MaxAge:   -1,
-					HttpOnly: true,
-				})
+				ClearSessionCookie(c)
 				return next(c)
 			}
 
@@ -72,6 +65,38 @@ func SessionMiddleware(storage SessionStore) echo.MiddlewareFunc {
 	}
 }
 
+// activityInterval is how stale a session's last-used time may get
+const activityInterval = 5 * time.Minute
+
+// ActivityStore records session activity
+type ActivityStore interface {
+	TouchSession(ctx context.Context, id string) error
+}
+
+// SessionActivityMiddleware records when the session of an authenticated
+// request was last used. It must run after SessionMiddleware.
+func SessionActivityMiddleware(storage ActivityStore) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if GetUser(c) == nil {
+				return next(c)
+			}
+
+			sessionID, ok := SessionCookieValue(c)
+			if !ok {
+				return next(c)
+			}
+
+			if err := storage.TouchSession(c.Request().Context(), sessionID); err != nil {
+				// Activity is informational - log but continue
+				c.Logger().Errorf("Failed to record session activity: %v", err)
+			}
+
+			return next(c)
+		}
+	}
+}
+
 // GetUser retrieves the authenticated user from the Echo context
 // Returns nil if no user is authenticated
 func GetUser(c echo.Context) *User {
@@ -93,13 +118,13 @@ func GetUser(c echo.Context) *User {
 // This requires the session ID to be stored in context by SessionMiddleware
 func GetSession(c echo.Context, storage *Storage) (*OAuthSession, error) {
 	// Get session cookie
-	cookie, err := c.Cookie("session")
-	if err != nil {
+	sessionID, ok := SessionCookieValue(c)
+	if !ok {
 		return nil, nil // No session cookie
 	}
 
 	// Look up full session in database
-	session, err := storage.GetSessionByID(c.Request().Context(), cookie.Value)
+	session, err := storage.GetSessionByID(c.Request().Context(), sessionID)
 	if err != nil {
 		return nil, err
 	}
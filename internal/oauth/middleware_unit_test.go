@@ -135,3 +135,34 @@ func TestSessionMiddlewareValidSessionDoesNotDelete(t *testing.T) {
 	assert.Equal(t, "did:plc:valid", capturedUser.DID)
 	assert.Empty(t, store.deleteCalls)
 }
+
+type stubActivityStore struct {
+	touched []string
+}
+
+func (s *stubActivityStore) TouchSession(ctx context.Context, id string) error {
+	s.touched = append(s.touched, id)
+	return nil
+}
+
+func TestSessionActivityMiddleware(t *testing.T) {
+	store := &stubActivityStore{}
+	handler := SessionActivityMiddleware(store)(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	e := echo.New()
+
+	// Anonymous requests are not tracked
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "stale-session"})
+	require.NoError(t, handler(e.NewContext(req, httptest.NewRecorder())))
+	assert.Empty(t, store.touched)
+
+	// Authenticated requests touch their session
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "valid-session"})
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.Set("user", &User{DID: "did:plc:user"})
+	require.NoError(t, handler(c))
+	assert.Equal(t, []string{"valid-session"}, store.touched)
+}
@@ -2,6 +2,7 @@ package oauth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,8 +10,20 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/openmeet-team/survey/internal/identity"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// publicPDSClient fetches public records and blobs without authentication.
+// Anyone can get a survey indexed whose author's DID document names any
+// host as its PDS, so it only connects to public addresses.
+var publicPDSClient = identity.PublicClient(&http.Client{Timeout: 10 * time.Second})
+
+// repoClient fetches repository exports, which can be large, so it allows
+// more time than publicPDSClient. It also only connects to public addresses.
+var repoClient = identity.PublicClient(&http.Client{Timeout: 2 * time.Minute})
+
 // PDSRecord represents a record from a PDS collection
 type PDSRecord struct {
 	URI       string                 `json:"uri"`
@@ -21,6 +34,21 @@ type PDSRecord struct {
 	Timestamp *time.Time             `json:"timestamp"` // parsed from TID if valid
 }
 
+// SubjectDID returns the repo DID of the record's subject URI (e.g. the author of
+// the survey a response answers), or "" if the record has no subject
+func (r PDSRecord) SubjectDID() string {
+	subject, ok := r.Value["subject"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	uri, ok := subject["uri"].(string)
+	if !ok || !strings.HasPrefix(uri, "at://") {
+		return ""
+	}
+	did, _, _ := strings.Cut(strings.TrimPrefix(uri, "at://"), "/")
+	return did
+}
+
 // ListRecordsResponse represents the response from listRecords
 type ListRecordsResponse struct {
 	Records []PDSRecord `json:"records"`
@@ -30,7 +58,10 @@ type ListRecordsResponse struct {
 // CreateRecord writes an ATProto record to the user's PDS
 // Returns the AT URI and CID of the created record
 // If rkey is empty, the PDS will generate one
-func CreateRecord(session *OAuthSession, collection string, rkey string, record interface{}) (string, string, error) {
+func CreateRecord(ctx context.Context, session *OAuthSession, collection string, rkey string, record interface{}) (uri, cid string, err error) {
+	ctx, span := startPDSSpan(ctx, "com.atproto.repo.createRecord", pdsURLOf(session), attribute.String("atproto.collection", collection))
+	defer func() { endPDSSpan(span, err) }()
+
 	if session == nil {
 		return "", "", fmt.Errorf("session cannot be nil")
 	}
@@ -83,7 +114,7 @@ func CreateRecord(session *OAuthSession, collection string, rkey string, record
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", pdsURL, bytes.NewReader(payloadBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", pdsURL, bytes.NewReader(payloadBytes))
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -115,7 +146,7 @@ func CreateRecord(session *OAuthSession, collection string, rkey string, record
 				return "", "", fmt.Errorf("failed to create DPoP proof with nonce: %w", err)
 			}
 
-			req, err = http.NewRequest("POST", pdsURL, bytes.NewReader(payloadBytes))
+			req, err = http.NewRequestWithContext(ctx, "POST", pdsURL, bytes.NewReader(payloadBytes))
 			if err != nil {
 				return "", "", fmt.Errorf("failed to create retry request: %w", err)
 			}
@@ -273,7 +304,10 @@ func RefreshAccessToken(session *OAuthSession, authServerURL, clientID, clientKe
 }
 
 // ListRecords fetches records from a collection (public endpoint, no auth required)
-func ListRecords(pdsURL, did, collection string, cursor string, limit int) (*ListRecordsResponse, error) {
+func ListRecords(ctx context.Context, pdsURL, did, collection string, cursor string, limit int) (records *ListRecordsResponse, err error) {
+	ctx, span := startPDSSpan(ctx, "com.atproto.repo.listRecords", pdsURL, attribute.String("atproto.collection", collection))
+	defer func() { endPDSSpan(span, err) }()
+
 	if pdsURL == "" {
 		return nil, fmt.Errorf("PDS URL cannot be empty")
 	}
@@ -301,7 +335,7 @@ func ListRecords(pdsURL, did, collection string, cursor string, limit int) (*Lis
 	fullURL := baseURL + "?" + params.Encode()
 
 	// Create HTTP request
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -344,8 +378,61 @@ func ListRecords(pdsURL, did, collection string, cursor string, limit int) (*Lis
 	return &result, nil
 }
 
+// GetRecord fetches a single record from a PDS (public endpoint, no auth required)
+func GetRecord(ctx context.Context, pdsURL, did, collection, rkey string) (record *PDSRecord, err error) {
+	ctx, span := startPDSSpan(ctx, "com.atproto.repo.getRecord", pdsURL, attribute.String("atproto.collection", collection))
+	defer func() { endPDSSpan(span, err) }()
+
+	if pdsURL == "" {
+		return nil, fmt.Errorf("PDS URL cannot be empty")
+	}
+
+	if did == "" || collection == "" || rkey == "" {
+		return nil, fmt.Errorf("DID, collection, and rkey cannot be empty")
+	}
+
+	// Build URL with query parameters
+	params := url.Values{}
+	params.Set("repo", did)
+	params.Set("collection", collection)
+	params.Set("rkey", rkey)
+	fullURL := strings.TrimSuffix(pdsURL, "/") + "/xrpc/com.atproto.repo.getRecord?" + params.Encode()
+
+	// Execute request (no auth required for public getRecord)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := publicPDSClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("PDS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("PDS returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Parse response
+	record = &PDSRecord{}
+	if err := json.Unmarshal(body, record); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	record.RKey = rkey
+
+	return record, nil
+}
+
 // DeleteRecord deletes a single record from the user's PDS (requires auth)
-func DeleteRecord(session *OAuthSession, collection, rkey string) error {
+func DeleteRecord(ctx context.Context, session *OAuthSession, collection, rkey string) (err error) {
+	ctx, span := startPDSSpan(ctx, "com.atproto.repo.deleteRecord", pdsURLOf(session), attribute.String("atproto.collection", collection))
+	defer func() { endPDSSpan(span, err) }()
+
 	if session == nil {
 		return fmt.Errorf("session cannot be nil")
 	}
@@ -389,7 +476,7 @@ func DeleteRecord(session *OAuthSession, collection, rkey string) error {
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", pdsURL, bytes.NewReader(payloadBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", pdsURL, bytes.NewReader(payloadBytes))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -421,7 +508,7 @@ func DeleteRecord(session *OAuthSession, collection, rkey string) error {
 				return fmt.Errorf("failed to create DPoP proof with nonce: %w", err)
 			}
 
-			req, err = http.NewRequest("POST", pdsURL, bytes.NewReader(payloadBytes))
+			req, err = http.NewRequestWithContext(ctx, "POST", pdsURL, bytes.NewReader(payloadBytes))
 			if err != nil {
 				return fmt.Errorf("failed to create retry request: %w", err)
 			}
@@ -451,7 +538,10 @@ func DeleteRecord(session *OAuthSession, collection, rkey string) error {
 }
 
 // UpdateRecord updates an existing record in the user's PDS (requires auth)
-func UpdateRecord(session *OAuthSession, collection, rkey string, record interface{}) (string, string, error) {
+func UpdateRecord(ctx context.Context, session *OAuthSession, collection, rkey string, record interface{}) (uri, cid string, err error) {
+	ctx, span := startPDSSpan(ctx, "com.atproto.repo.putRecord", pdsURLOf(session), attribute.String("atproto.collection", collection))
+	defer func() { endPDSSpan(span, err) }()
+
 	if session == nil {
 		return "", "", fmt.Errorf("session cannot be nil")
 	}
@@ -498,7 +588,7 @@ func UpdateRecord(session *OAuthSession, collection, rkey string, record interfa
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", pdsURL, bytes.NewReader(payloadBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", pdsURL, bytes.NewReader(payloadBytes))
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -530,7 +620,7 @@ func UpdateRecord(session *OAuthSession, collection, rkey string, record interfa
 				return "", "", fmt.Errorf("failed to create DPoP proof with nonce: %w", err)
 			}
 
-			req, err = http.NewRequest("POST", pdsURL, bytes.NewReader(payloadBytes))
+			req, err = http.NewRequestWithContext(ctx, "POST", pdsURL, bytes.NewReader(payloadBytes))
 			if err != nil {
 				return "", "", fmt.Errorf("failed to create retry request: %w", err)
 			}
@@ -567,3 +657,195 @@ func UpdateRecord(session *OAuthSession, collection, rkey string, record interfa
 
 	return result.URI, result.CID, nil
 }
+
+// UploadBlob uploads a blob, such as an image, to the user's PDS (requires auth)
+// Returns the blob reference to embed in a record, as JSON. The PDS deletes
+// blobs that no record references after a while.
+func UploadBlob(ctx context.Context, session *OAuthSession, data []byte, mimeType string) (blob json.RawMessage, err error) {
+	ctx, span := startPDSSpan(ctx, "com.atproto.repo.uploadBlob", pdsURLOf(session), attribute.Int("atproto.blob.size", len(data)))
+	defer func() { endPDSSpan(span, err) }()
+
+	if session == nil {
+		return nil, fmt.Errorf("session cannot be nil")
+	}
+
+	if session.AccessToken == "" {
+		return nil, fmt.Errorf("session missing access token")
+	}
+
+	if session.PDSUrl == "" {
+		return nil, fmt.Errorf("session missing PDS URL")
+	}
+
+	if session.DPoPKey == "" {
+		return nil, fmt.Errorf("session missing DPoP key")
+	}
+
+	// Check if token is expired
+	if session.TokenExpiresAt != nil && time.Now().After(*session.TokenExpiresAt) {
+		return nil, fmt.Errorf("access token expired")
+	}
+
+	// Build PDS URL
+	pdsURL := strings.TrimSuffix(session.PDSUrl, "/") + "/xrpc/com.atproto.repo.uploadBlob"
+
+	// Create DPoP proof
+	dpopProof, err := CreateDPoPProof(session.DPoPKey, "POST", pdsURL, "", session.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DPoP proof: %w", err)
+	}
+
+	// Create HTTP request with the raw blob as body
+	req, err := http.NewRequestWithContext(ctx, "POST", pdsURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", mimeType)
+	req.Header.Set("Authorization", "DPoP "+session.AccessToken)
+	req.Header.Set("DPoP", dpopProof)
+
+	// Execute request
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("PDS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Check for DPoP nonce requirement
+	if resp.StatusCode == http.StatusUnauthorized {
+		dpopNonce := resp.Header.Get("DPoP-Nonce")
+		if dpopNonce != "" {
+			// Retry with nonce
+			dpopProof, err = CreateDPoPProof(session.DPoPKey, "POST", pdsURL, dpopNonce, session.AccessToken)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create DPoP proof with nonce: %w", err)
+			}
+
+			req, err = http.NewRequestWithContext(ctx, "POST", pdsURL, bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create retry request: %w", err)
+			}
+
+			req.Header.Set("Content-Type", mimeType)
+			req.Header.Set("Authorization", "DPoP "+session.AccessToken)
+			req.Header.Set("DPoP", dpopProof)
+
+			resp, err = client.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("PDS retry request failed: %w", err)
+			}
+			defer resp.Body.Close()
+
+			body, err = io.ReadAll(resp.Body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read retry response: %w", err)
+			}
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("PDS returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Parse response
+	var result struct {
+		Blob json.RawMessage `json:"blob"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(result.Blob) == 0 {
+		return nil, fmt.Errorf("PDS response has no blob")
+	}
+
+	return result.Blob, nil
+}
+
+// GetBlob fetches a blob from a PDS (public endpoint, no auth required)
+// Returns the blob and its content type; blobs larger than maxSize are rejected
+func GetBlob(ctx context.Context, pdsURL, did, cid string, maxSize int64) (data []byte, contentType string, err error) {
+	ctx, span := startPDSSpan(ctx, "com.atproto.sync.getBlob", pdsURL)
+	defer func() { endPDSSpan(span, err) }()
+
+	if pdsURL == "" {
+		return nil, "", fmt.Errorf("PDS URL cannot be empty")
+	}
+
+	if did == "" || cid == "" {
+		return nil, "", fmt.Errorf("DID and CID cannot be empty")
+	}
+
+	// Build URL with query parameters
+	params := url.Values{}
+	params.Set("did", did)
+	params.Set("cid", cid)
+	fullURL := strings.TrimSuffix(pdsURL, "/") + "/xrpc/com.atproto.sync.getBlob?" + params.Encode()
+
+	// Execute request (no auth required for public getBlob)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := publicPDSClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("PDS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read one byte past the limit to detect oversized blobs
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("PDS returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if int64(len(body)) > maxSize {
+		return nil, "", fmt.Errorf("blob exceeds %d bytes", maxSize)
+	}
+
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// GetRepo fetches the CAR export of a repository from its PDS (public endpoint,
+// no auth required). The caller must close the returned body.
+func GetRepo(ctx context.Context, pdsURL, did string) (repo io.ReadCloser, err error) {
+	ctx, span := startPDSSpan(ctx, "com.atproto.sync.getRepo", pdsURL)
+	defer func() { endPDSSpan(span, err) }()
+
+	if pdsURL == "" {
+		return nil, fmt.Errorf("PDS URL cannot be empty")
+	}
+
+	if did == "" {
+		return nil, fmt.Errorf("DID cannot be empty")
+	}
+
+	fullURL := strings.TrimSuffix(pdsURL, "/") + "/xrpc/com.atproto.sync.getRepo?did=" + url.QueryEscape(did)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := repoClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("PDS request failed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("PDS returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return resp.Body, nil
+}
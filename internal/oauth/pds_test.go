@@ -1,10 +1,12 @@
 package oauth
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -54,7 +56,7 @@ func TestCreateRecord(t *testing.T) {
 			"createdAt": time.Now().Format(time.RFC3339),
 		}
 
-		uri, cid, err := CreateRecord(session, "net.openmeet.survey", "test123", record)
+		uri, cid, err := CreateRecord(context.Background(), session, "net.openmeet.survey", "test123", record)
 		if err != nil {
 			t.Fatalf("CreateRecord failed: %v", err)
 		}
@@ -123,7 +125,7 @@ func TestCreateRecord(t *testing.T) {
 			"question": "What's your favorite color?",
 		}
 
-		_, _, err := CreateRecord(session, "net.openmeet.survey", "", record)
+		_, _, err := CreateRecord(context.Background(), session, "net.openmeet.survey", "", record)
 		if err != nil {
 			t.Fatalf("CreateRecord failed: %v", err)
 		}
@@ -131,7 +133,7 @@ func TestCreateRecord(t *testing.T) {
 
 	t.Run("returns error for nil session", func(t *testing.T) {
 		record := map[string]interface{}{"test": "data"}
-		_, _, err := CreateRecord(nil, "net.openmeet.survey", "test123", record)
+		_, _, err := CreateRecord(context.Background(), nil, "net.openmeet.survey", "test123", record)
 		if err == nil {
 			t.Error("Expected error for nil session")
 		}
@@ -143,7 +145,7 @@ func TestCreateRecord(t *testing.T) {
 			PDSUrl: "https://pds.example.com",
 		}
 		record := map[string]interface{}{"test": "data"}
-		_, _, err := CreateRecord(session, "net.openmeet.survey", "test123", record)
+		_, _, err := CreateRecord(context.Background(), session, "net.openmeet.survey", "test123", record)
 		if err == nil {
 			t.Error("Expected error for missing access token")
 		}
@@ -156,7 +158,7 @@ func TestCreateRecord(t *testing.T) {
 			DPoPKey:     GenerateSecretJWK(),
 		}
 		record := map[string]interface{}{"test": "data"}
-		_, _, err := CreateRecord(session, "net.openmeet.survey", "test123", record)
+		_, _, err := CreateRecord(context.Background(), session, "net.openmeet.survey", "test123", record)
 		if err == nil {
 			t.Error("Expected error for missing PDS URL")
 		}
@@ -172,7 +174,7 @@ func TestCreateRecord(t *testing.T) {
 			TokenExpiresAt: &expiredTime,
 		}
 		record := map[string]interface{}{"test": "data"}
-		_, _, err := CreateRecord(session, "net.openmeet.survey", "test123", record)
+		_, _, err := CreateRecord(context.Background(), session, "net.openmeet.survey", "test123", record)
 		if err == nil {
 			t.Error("Expected error for expired token without refresh token")
 		}
@@ -187,7 +189,7 @@ func TestRefreshToken(t *testing.T) {
 		authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.URL.Path == "/.well-known/oauth-authorization-server" {
 				w.Header().Set("Content-Type", "application/json")
-				w.Write([]byte(`{"token_endpoint":"` + authServerURL + `/token"}`))
+				w.Write([]byte(`{"issuer":"` + authServerURL + `","token_endpoint":"` + authServerURL + `/token"}`))
 				return
 			}
 			if r.URL.Path == "/token" {
@@ -213,6 +215,7 @@ func TestRefreshToken(t *testing.T) {
 		}))
 		defer authServer.Close()
 		authServerURL = authServer.URL
+		allowLoopback(t, authServer.Client())
 
 		expiredTime := time.Now().Add(-1 * time.Hour)
 		session := &OAuthSession{
@@ -301,7 +304,7 @@ func TestListRecords(t *testing.T) {
 		}))
 		defer pdsServer.Close()
 
-		resp, err := ListRecords(pdsServer.URL, "did:plc:test123", "net.openmeet.survey", "", 50)
+		resp, err := ListRecords(context.Background(), pdsServer.URL, "did:plc:test123", "net.openmeet.survey", "", 50)
 		if err != nil {
 			t.Fatalf("ListRecords failed: %v", err)
 		}
@@ -324,7 +327,7 @@ func TestListRecords(t *testing.T) {
 	})
 
 	t.Run("returns error for invalid PDS URL", func(t *testing.T) {
-		_, err := ListRecords("", "did:plc:test", "net.openmeet.survey", "", 50)
+		_, err := ListRecords(context.Background(), "", "did:plc:test", "net.openmeet.survey", "", 50)
 		if err == nil {
 			t.Error("Expected error for empty PDS URL")
 		}
@@ -379,14 +382,14 @@ func TestDeleteRecord(t *testing.T) {
 			TokenExpiresAt: &tokenExpiresAt,
 		}
 
-		err := DeleteRecord(session, "net.openmeet.survey", "abc123")
+		err := DeleteRecord(context.Background(), session, "net.openmeet.survey", "abc123")
 		if err != nil {
 			t.Fatalf("DeleteRecord failed: %v", err)
 		}
 	})
 
 	t.Run("returns error for nil session", func(t *testing.T) {
-		err := DeleteRecord(nil, "net.openmeet.survey", "abc123")
+		err := DeleteRecord(context.Background(), nil, "net.openmeet.survey", "abc123")
 		if err == nil {
 			t.Error("Expected error for nil session")
 		}
@@ -447,7 +450,7 @@ func TestUpdateRecord(t *testing.T) {
 			"question": "Updated question?",
 		}
 
-		uri, cid, err := UpdateRecord(session, "net.openmeet.survey", "abc123", record)
+		uri, cid, err := UpdateRecord(context.Background(), session, "net.openmeet.survey", "abc123", record)
 		if err != nil {
 			t.Fatalf("UpdateRecord failed: %v", err)
 		}
@@ -462,9 +465,225 @@ func TestUpdateRecord(t *testing.T) {
 
 	t.Run("returns error for nil session", func(t *testing.T) {
 		record := map[string]interface{}{"test": "data"}
-		_, _, err := UpdateRecord(nil, "net.openmeet.survey", "abc123", record)
+		_, _, err := UpdateRecord(context.Background(), nil, "net.openmeet.survey", "abc123", record)
 		if err == nil {
 			t.Error("Expected error for nil session")
 		}
 	})
 }
+
+func TestPDSRecordSubjectDID(t *testing.T) {
+	response := PDSRecord{Value: map[string]interface{}{
+		"subject": map[string]interface{}{
+			"uri": "at://did:plc:author/net.openmeet.survey/abc123",
+			"cid": "bafyrei123",
+		},
+	}}
+	if got := response.SubjectDID(); got != "did:plc:author" {
+		t.Errorf("SubjectDID() = %q, want %q", got, "did:plc:author")
+	}
+
+	survey := PDSRecord{Value: map[string]interface{}{"name": "A survey"}}
+	if got := survey.SubjectDID(); got != "" {
+		t.Errorf("SubjectDID() = %q, want empty", got)
+	}
+}
+
+// TestUploadBlob tests uploading a blob to the user's PDS
+func TestUploadBlob(t *testing.T) {
+	t.Run("uploads blob with valid session", func(t *testing.T) {
+		pdsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/xrpc/com.atproto.repo.uploadBlob" {
+				t.Errorf("Expected path /xrpc/com.atproto.repo.uploadBlob, got %s", r.URL.Path)
+			}
+			if r.Header.Get("DPoP") == "" {
+				t.Error("Expected DPoP header")
+			}
+			if r.Header.Get("Content-Type") != "image/png" {
+				t.Errorf("Expected Content-Type: image/png, got %s", r.Header.Get("Content-Type"))
+			}
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != "png-bytes" {
+				t.Errorf("Expected raw blob body, got %q", body)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"blob":{"$type":"blob","ref":{"$link":"bafkreitest"},"mimeType":"image/png","size":9}}`))
+		}))
+		defer pdsServer.Close()
+
+		tokenExpiresAt := time.Now().Add(1 * time.Hour)
+		session := &OAuthSession{
+			ID:             "test-session",
+			DID:            "did:plc:test123",
+			AccessToken:    "test-access-token",
+			DPoPKey:        GenerateSecretJWK(),
+			PDSUrl:         pdsServer.URL,
+			TokenExpiresAt: &tokenExpiresAt,
+		}
+
+		blob, err := UploadBlob(context.Background(), session, []byte("png-bytes"), "image/png")
+		if err != nil {
+			t.Fatalf("UploadBlob failed: %v", err)
+		}
+
+		var ref map[string]interface{}
+		if err := json.Unmarshal(blob, &ref); err != nil {
+			t.Fatalf("Invalid blob JSON: %v", err)
+		}
+		if ref["$type"] != "blob" || ref["size"] != float64(9) {
+			t.Errorf("Unexpected blob: %s", blob)
+		}
+	})
+
+	t.Run("returns error for nil session", func(t *testing.T) {
+		if _, err := UploadBlob(context.Background(), nil, []byte("x"), "image/png"); err == nil {
+			t.Error("Expected error for nil session")
+		}
+	})
+}
+
+// TestGetRecord tests fetching a single public record from a PDS
+func TestGetRecord(t *testing.T) {
+	pdsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xrpc/com.atproto.repo.getRecord" {
+			t.Errorf("Expected path /xrpc/com.atproto.repo.getRecord, got %s", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("repo") != "did:plc:test123" || query.Get("collection") != "net.openmeet.survey.results" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		if query.Get("rkey") != "3kresults" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"RecordNotFound"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"uri":"at://did:plc:test123/net.openmeet.survey.results/3kresults","cid":"bafyresults","value":{"totalVotes":3}}`))
+	}))
+	defer pdsServer.Close()
+	allowLoopbackPDS(t)
+
+	record, err := GetRecord(context.Background(), pdsServer.URL, "did:plc:test123", "net.openmeet.survey.results", "3kresults")
+	if err != nil {
+		t.Fatalf("GetRecord failed: %v", err)
+	}
+	if record.CID != "bafyresults" || record.RKey != "3kresults" || record.Value["totalVotes"] != float64(3) {
+		t.Errorf("GetRecord = %+v", record)
+	}
+
+	if _, err := GetRecord(context.Background(), pdsServer.URL, "did:plc:test123", "net.openmeet.survey.results", "missing"); err == nil {
+		t.Error("Expected error for a missing record")
+	}
+}
+
+// TestGetRecord_RefusesLoopbackPDS tests that a DID whose document names a
+// loopback PDS cannot make the service fetch from its own network
+func TestGetRecord_RefusesLoopbackPDS(t *testing.T) {
+	var fetched bool
+	pdsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = true
+	}))
+	defer pdsServer.Close()
+
+	const did = "did:plc:ewvi7nxzyoun6zhxrhs64oiz"
+	plcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"id": did,
+			"service": []map[string]any{
+				{"id": "#atproto_pds", "type": "AtprotoPersonalDataServer", "serviceEndpoint": pdsServer.URL},
+			},
+		})
+	}))
+	defer plcServer.Close()
+	allowLoopback(t, plcServer.Client())
+	original := plcURL
+	plcURL = plcServer.URL
+	t.Cleanup(func() { plcURL = original })
+
+	pdsURL, err := DIDToPDS(did)
+	if err != nil {
+		t.Fatalf("DIDToPDS failed: %v", err)
+	}
+	if !strings.Contains(pdsURL, "127.0.0.1") {
+		t.Fatalf("Expected a loopback PDS, got %s", pdsURL)
+	}
+
+	_, err = GetRecord(context.Background(), pdsURL, did, "net.openmeet.survey", "3ksurvey")
+	if err == nil || !strings.Contains(err.Error(), "address is not public") {
+		t.Errorf("Expected loopback PDS to be refused, got %v", err)
+	}
+	if fetched {
+		t.Error("Expected no request to the loopback PDS")
+	}
+}
+
+// allowLoopbackPDS lets public PDS fetches reach test servers on loopback
+// addresses
+func allowLoopbackPDS(t *testing.T) {
+	t.Helper()
+	original, originalRepo := publicPDSClient, repoClient
+	publicPDSClient = &http.Client{Timeout: original.Timeout}
+	repoClient = &http.Client{Timeout: originalRepo.Timeout}
+	t.Cleanup(func() { publicPDSClient, repoClient = original, originalRepo })
+}
+
+// TestGetRepo tests fetching the CAR export of a repository from a PDS
+func TestGetRepo(t *testing.T) {
+	pdsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xrpc/com.atproto.sync.getRepo" || r.URL.Query().Get("did") != "did:plc:test123" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		w.Write([]byte("car bytes"))
+	}))
+	defer pdsServer.Close()
+
+	// PDS hosts are chosen by DID documents, so loopback is refused
+	if _, err := GetRepo(context.Background(), pdsServer.URL, "did:plc:test123"); err == nil || !strings.Contains(err.Error(), "address is not public") {
+		t.Errorf("Expected loopback PDS to be refused, got %v", err)
+	}
+
+	allowLoopbackPDS(t)
+	repo, err := GetRepo(context.Background(), pdsServer.URL, "did:plc:test123")
+	if err != nil {
+		t.Fatalf("GetRepo failed: %v", err)
+	}
+	defer repo.Close()
+	data, err := io.ReadAll(repo)
+	if err != nil || string(data) != "car bytes" {
+		t.Errorf("GetRepo = %q, %v", data, err)
+	}
+}
+
+// TestGetBlob tests fetching a public blob from a PDS
+func TestGetBlob(t *testing.T) {
+	pdsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xrpc/com.atproto.sync.getBlob" {
+			t.Errorf("Expected path /xrpc/com.atproto.sync.getBlob, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("did") != "did:plc:test123" || r.URL.Query().Get("cid") != "bafkreitest" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png-bytes"))
+	}))
+	defer pdsServer.Close()
+
+	// PDS hosts are chosen by DID documents, so loopback is refused
+	if _, _, err := GetBlob(context.Background(), pdsServer.URL, "did:plc:test123", "bafkreitest", 100); err == nil || !strings.Contains(err.Error(), "address is not public") {
+		t.Errorf("Expected loopback PDS to be refused, got %v", err)
+	}
+
+	allowLoopbackPDS(t)
+	data, contentType, err := GetBlob(context.Background(), pdsServer.URL, "did:plc:test123", "bafkreitest", 100)
+	if err != nil {
+		t.Fatalf("GetBlob failed: %v", err)
+	}
+	if string(data) != "png-bytes" || contentType != "image/png" {
+		t.Errorf("GetBlob = %q, %q", data, contentType)
+	}
+
+	if _, _, err := GetBlob(context.Background(), pdsServer.URL, "did:plc:test123", "bafkreitest", 4); err == nil {
+		t.Error("Expected error for blob larger than maxSize")
+	}
+}
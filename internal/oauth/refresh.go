@@ -52,7 +52,7 @@ func EnsureValidToken(ctx context.Context, session *OAuthSession, storage *Stora
 	}
 
 	// Build client ID from config
-	clientID := fmt.Sprintf("https://%s/oauth/client-metadata.json", config.Host)
+	clientID := fmt.Sprintf("https://%s/oauth/client-metadata.json", normalizeHost(config.Host))
 
 	// Attempt to refresh the token
 	newAccessToken, newRefreshToken, expiresIn, err := RefreshAccessToken(
@@ -1,13 +1,89 @@
 package oauth
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/identity"
+)
+
+// resolveTimeout bounds each step of resolving an account
+const resolveTimeout = 10 * time.Second
+
+// maxMetadataSize bounds the handle proofs and metadata documents read
+const maxMetadataSize = 64 << 10
+
+// Errors of resolving an account, for setups the service cannot log in with
+var (
+	ErrInvalidIdentifier = errors.New("not a valid handle or DID")
+	ErrUnsupportedDID    = errors.New("unsupported DID (only did:plc and did:web are supported)")
+	ErrNoPDS             = errors.New("DID document names no PDS")
+	ErrOAuthUnsupported  = errors.New("PDS does not support OAuth")
+)
+
+var (
+	// handlePattern is the ATProto handle syntax: a domain name of two or more
+	// labels whose last label does not start with a digit
+	handlePattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+	// didPattern is the syntax of the DID methods ATProto supports. did:web
+	// DIDs name a host (and port), never a path.
+	didPattern = regexp.MustCompile(`^did:(plc:[a-z2-7]{24}|web:[a-zA-Z0-9.-]+(%3A[0-9]+)?)$`)
 )
 
+// httpClient fetches handle proofs, DID documents, and PDS and authorization
+// server metadata. Their hosts are chosen by accounts, so it only connects to
+// public addresses.
+var httpClient = identity.PublicClient(&http.Client{Timeout: resolveTimeout})
+
+// plcURL is the did:plc directory
+var plcURL = identity.DefaultPLCURL
+
+// ParseIdentifier normalizes what a user entered to log in: a handle, with or
+// without a leading "@" or "at://", or a DID. It returns either the handle,
+// lowercased, or the DID.
+func ParseIdentifier(input string) (handle, did string, err error) {
+	input = strings.TrimSpace(input)
+	input = strings.TrimPrefix(input, "at://")
+	input = strings.TrimPrefix(input, "@")
+
+	if strings.HasPrefix(input, "did:") {
+		if err := validateDID(input); err != nil {
+			return "", "", err
+		}
+		return "", input, nil
+	}
+
+	handle = strings.ToLower(input)
+	if len(handle) > 253 || !handlePattern.MatchString(handle) {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidIdentifier, input)
+	}
+	return handle, "", nil
+}
+
+// validateDID checks that a DID is of a method ATProto supports
+func validateDID(did string) error {
+	if !strings.HasPrefix(did, "did:") {
+		return fmt.Errorf("invalid DID format: %s", did)
+	}
+	if !strings.HasPrefix(did, "did:plc:") && !strings.HasPrefix(did, "did:web:") {
+		return fmt.Errorf("%w: %s", ErrUnsupportedDID, did)
+	}
+	if !didPattern.MatchString(did) {
+		return fmt.Errorf("invalid DID format: %s", did)
+	}
+	return nil
+}
+
 // HandleToDID resolves a Bluesky handle to a DID
 // It tries multiple resolution methods in order:
 // 1. DNS TXT record at _atproto.<handle>
@@ -18,61 +94,61 @@ func HandleToDID(handle string) (string, error) {
 		return "", fmt.Errorf("handle cannot be empty")
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
 	// Try DNS TXT record first
-	if did, err := resolveHandleViaDNS(handle); err == nil {
+	if did, err := resolveHandleViaDNS(ctx, handle); err == nil {
 		return did, nil
 	}
 
 	// Try HTTP well-known
-	if did, err := resolveHandleViaHTTP(handle); err == nil {
+	if did, err := resolveHandleViaHTTP(ctx, handle); err == nil {
 		return did, nil
 	}
 
 	// Try Bluesky API as fallback (works for bsky.social handles)
-	if did, err := resolveHandleViaAPI(handle); err == nil {
+	if did, err := resolveHandleViaAPI(ctx, handle); err == nil {
 		return did, nil
 	}
 
 	return "", fmt.Errorf("failed to resolve handle: %s (tried DNS, HTTP, and API)", handle)
 }
 
-// resolveHandleViaDNS tries DNS TXT record resolution
-func resolveHandleViaDNS(handle string) (string, error) {
-	txtRecords, err := net.LookupTXT(fmt.Sprintf("_atproto.%s", handle))
+// resolveHandleViaDNS tries DNS TXT record resolution. A handle with records
+// naming different DIDs is ambiguous and does not resolve.
+func resolveHandleViaDNS(ctx context.Context, handle string) (string, error) {
+	txtRecords, err := net.DefaultResolver.LookupTXT(ctx, fmt.Sprintf("_atproto.%s", handle))
 	if err != nil {
 		return "", err
 	}
 
+	var found string
 	for _, record := range txtRecords {
-		if strings.HasPrefix(record, "did=") {
-			did := strings.TrimPrefix(record, "did=")
-			if strings.HasPrefix(did, "did:") {
-				return did, nil
-			}
+		did, ok := strings.CutPrefix(record, "did=")
+		if !ok || !strings.HasPrefix(did, "did:") {
+			continue
+		}
+		if found != "" && found != did {
+			return "", fmt.Errorf("DNS records of %s name more than one DID", handle)
 		}
+		found = did
 	}
 
-	return "", fmt.Errorf("no valid DID in DNS records")
+	if found == "" {
+		return "", fmt.Errorf("no valid DID in DNS records")
+	}
+	return found, nil
 }
 
 // resolveHandleViaHTTP tries HTTP well-known resolution
-func resolveHandleViaHTTP(handle string) (string, error) {
-	url := fmt.Sprintf("https://%s/.well-known/atproto-did", handle)
-	resp, err := http.Get(url)
+func resolveHandleViaHTTP(ctx context.Context, handle string) (string, error) {
+	body, err := fetch(ctx, fmt.Sprintf("https://%s/.well-known/atproto-did", handle))
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP status %d", resp.StatusCode)
-	}
-
-	// Read response body (should be just the DID)
-	buf := make([]byte, 256)
-	n, _ := resp.Body.Read(buf)
-	did := strings.TrimSpace(string(buf[:n]))
 
+	did := strings.TrimSpace(string(body))
 	if strings.HasPrefix(did, "did:") {
 		return did, nil
 	}
@@ -81,22 +157,16 @@ func resolveHandleViaHTTP(handle string) (string, error) {
 }
 
 // resolveHandleViaAPI tries the Bluesky API for handle resolution
-func resolveHandleViaAPI(handle string) (string, error) {
-	url := fmt.Sprintf("https://bsky.social/xrpc/com.atproto.identity.resolveHandle?handle=%s", handle)
-	resp, err := http.Get(url)
+func resolveHandleViaAPI(ctx context.Context, handle string) (string, error) {
+	body, err := fetch(ctx, "https://bsky.social/xrpc/com.atproto.identity.resolveHandle?handle="+url.QueryEscape(handle))
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API status %d", resp.StatusCode)
-	}
 
 	var result struct {
 		DID string `json:"did"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return "", err
 	}
 
@@ -109,74 +179,57 @@ func resolveHandleViaAPI(handle string) (string, error) {
 
 // DIDToPDS resolves a DID to its Personal Data Server endpoint
 func DIDToPDS(did string) (string, error) {
+	return resolvePDS(did, "")
+}
+
+// HandleDIDToPDS resolves the DID a handle resolved to to its PDS, checking
+// that the DID document claims the handle back, so a handle cannot log in as
+// an account that does not own it
+func HandleDIDToPDS(handle, did string) (string, error) {
+	return resolvePDS(did, handle)
+}
+
+// resolvePDS fetches the document of a DID and returns its PDS endpoint. If
+// handle is set, the document must claim it.
+func resolvePDS(did, handle string) (string, error) {
 	if did == "" {
 		return "", fmt.Errorf("DID cannot be empty")
 	}
-
-	if !strings.HasPrefix(did, "did:") {
-		return "", fmt.Errorf("invalid DID format: %s", did)
+	if err := validateDID(did); err != nil {
+		return "", err
 	}
 
-	var didDoc map[string]interface{}
-
-	if strings.HasPrefix(did, "did:plc:") {
-		// Resolve via PLC directory
-		resp, err := http.Get(fmt.Sprintf("https://plc.directory/%s", did))
-		if err != nil {
-			return "", fmt.Errorf("failed to resolve DID: %v", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("failed to resolve DID: status %d", resp.StatusCode)
-		}
-
-		if err := json.NewDecoder(resp.Body).Decode(&didDoc); err != nil {
-			return "", fmt.Errorf("failed to parse DID document: %v", err)
-		}
-	} else if strings.HasPrefix(did, "did:web:") {
-		// Resolve via did:web
-		domain := strings.TrimPrefix(did, "did:web:")
-		resp, err := http.Get(fmt.Sprintf("https://%s/.well-known/did.json", domain))
-		if err != nil {
-			return "", fmt.Errorf("failed to resolve DID: %v", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("failed to resolve DID: status %d", resp.StatusCode)
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
 
-		if err := json.NewDecoder(resp.Body).Decode(&didDoc); err != nil {
-			return "", fmt.Errorf("failed to parse DID document: %v", err)
-		}
-	} else {
-		return "", fmt.Errorf("unsupported DID method: %s", did)
+	doc, err := identity.FetchDocument(ctx, httpClient, plcURL, did)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve DID: %w", err)
 	}
 
-	// Extract PDS endpoint from service array
-	services, ok := didDoc["service"].([]interface{})
-	if !ok {
-		return "", fmt.Errorf("invalid or missing service in DID document")
+	if handle != "" && doc.Handle() != handle {
+		return "", fmt.Errorf("the DID document of %s does not confirm the handle %s", did, handle)
 	}
 
-	for _, service := range services {
-		svc, ok := service.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		if svc["type"] == "AtprotoPersonalDataServer" {
-			if endpoint, ok := svc["serviceEndpoint"].(string); ok {
-				return endpoint, nil
-			}
-		}
+	endpoint := doc.PDSEndpoint()
+	if endpoint == "" {
+		return "", fmt.Errorf("%w: %s", ErrNoPDS, did)
+	}
+	if err := validateHTTPS(endpoint); err != nil {
+		return "", fmt.Errorf("PDS of %s: %w", did, err)
 	}
 
-	return "", fmt.Errorf("no PDS endpoint found in DID document")
+	return strings.TrimSuffix(endpoint, "/"), nil
 }
 
-// PDSToAuthServer resolves a PDS URL to its authorization server
+// protectedResourceMetadata is the part of a PDS's OAuth metadata the service reads
+type protectedResourceMetadata struct {
+	AuthorizationServers []string `json:"authorization_servers"`
+}
+
+// PDSToAuthServer resolves a PDS URL to its authorization server, from its
+// OAuth protected resource metadata. The PDS may be its own authorization
+// server, or use another (like an entryway).
 func PDSToAuthServer(pdsURL string) (string, error) {
 	if pdsURL == "" {
 		return "", fmt.Errorf("PDS URL cannot be empty")
@@ -186,55 +239,118 @@ func PDSToAuthServer(pdsURL string) (string, error) {
 		return "", fmt.Errorf("invalid PDS URL format: %s", pdsURL)
 	}
 
-	resp, err := http.Get(pdsURL + "/.well-known/oauth-protected-resource")
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	body, err := fetch(ctx, strings.TrimSuffix(pdsURL, "/")+"/.well-known/oauth-protected-resource")
+	if errors.Is(err, errNotFound) {
+		return "", fmt.Errorf("%w: %s has no OAuth metadata (it may need upgrading)", ErrOAuthUnsupported, pdsURL)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch PDS metadata: %v", err)
 	}
-	defer resp.Body.Close()
 
-	var metadata map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+	var metadata protectedResourceMetadata
+	if err := json.Unmarshal(body, &metadata); err != nil {
 		return "", fmt.Errorf("failed to parse PDS metadata: %v", err)
 	}
 
-	authServers, ok := metadata["authorization_servers"].([]interface{})
-	if !ok || len(authServers) == 0 {
-		return "", fmt.Errorf("invalid or missing authorization_servers in PDS metadata")
+	if len(metadata.AuthorizationServers) == 0 {
+		return "", fmt.Errorf("%w: %s names no authorization server", ErrOAuthUnsupported, pdsURL)
 	}
 
-	authServer, ok := authServers[0].(string)
-	if !ok {
-		return "", fmt.Errorf("invalid authorization server format")
+	authServer := metadata.AuthorizationServers[0]
+	if err := validateHTTPS(authServer); err != nil {
+		return "", fmt.Errorf("authorization server of %s: %w", pdsURL, err)
 	}
 
 	return authServer, nil
 }
 
-// AuthServerToPAREndpoint resolves an authorization server to its PAR endpoint
-func AuthServerToPAREndpoint(authServer string) (string, error) {
+// AuthServerMetadata is the part of an authorization server's metadata the service uses
+type AuthServerMetadata struct {
+	Issuer                             string `json:"issuer"`
+	AuthorizationEndpoint              string `json:"authorization_endpoint"`
+	TokenEndpoint                      string `json:"token_endpoint"`
+	PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint"`
+}
+
+// FetchAuthServerMetadata fetches the metadata of an authorization server,
+// checking that it was issued by that server
+func FetchAuthServerMetadata(authServer string) (*AuthServerMetadata, error) {
 	if authServer == "" {
-		return "", fmt.Errorf("auth server URL cannot be empty")
+		return nil, fmt.Errorf("auth server URL cannot be empty")
 	}
 
 	if !strings.HasPrefix(authServer, "http://") && !strings.HasPrefix(authServer, "https://") {
-		return "", fmt.Errorf("invalid auth server URL format: %s", authServer)
+		return nil, fmt.Errorf("invalid auth server URL format: %s", authServer)
 	}
 
-	resp, err := http.Get(authServer + "/.well-known/oauth-authorization-server")
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	body, err := fetch(ctx, authServer+"/.well-known/oauth-authorization-server")
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch auth server metadata: %v", err)
+		return nil, fmt.Errorf("failed to fetch auth server metadata: %v", err)
+	}
+
+	var metadata AuthServerMetadata
+	if err := json.Unmarshal(body, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse auth server metadata: %v", err)
 	}
-	defer resp.Body.Close()
 
-	var metadata map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
-		return "", fmt.Errorf("failed to parse auth server metadata: %v", err)
+	if metadata.Issuer != authServer {
+		return nil, fmt.Errorf("auth server metadata of %s is issued by %q", authServer, metadata.Issuer)
 	}
 
-	parEndpoint, ok := metadata["pushed_authorization_request_endpoint"].(string)
-	if !ok {
+	return &metadata, nil
+}
+
+// AuthServerToPAREndpoint resolves an authorization server to its PAR endpoint
+func AuthServerToPAREndpoint(authServer string) (string, error) {
+	metadata, err := FetchAuthServerMetadata(authServer)
+	if err != nil {
+		return "", err
+	}
+
+	if metadata.PushedAuthorizationRequestEndpoint == "" {
 		return "", fmt.Errorf("invalid or missing PAR endpoint in auth server metadata")
 	}
 
-	return parEndpoint, nil
+	return metadata.PushedAuthorizationRequestEndpoint, nil
+}
+
+// errNotFound means a fetched document does not exist
+var errNotFound = errors.New("not found")
+
+// fetch gets a document of at most maxMetadataSize bytes
+func fetch(ctx context.Context, docURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, docURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+}
+
+// validateHTTPS checks that a PDS or authorization server URL is an https origin
+func validateHTTPS(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%q is not an https URL", rawURL)
+	}
+	return nil
 }
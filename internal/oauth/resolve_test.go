@@ -1,6 +1,9 @@
 package oauth
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -85,3 +88,123 @@ func TestAuthServerToPAREndpoint(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+// allowLoopback lets the resolver reach test servers on loopback addresses
+func allowLoopback(t *testing.T, client *http.Client) {
+	t.Helper()
+	original := httpClient
+	httpClient = client
+	t.Cleanup(func() { httpClient = original })
+}
+
+func TestParseIdentifier(t *testing.T) {
+	tests := []struct {
+		input  string
+		handle string
+		did    string
+	}{
+		{"alice.bsky.social", "alice.bsky.social", ""},
+		{"@Alice.Example.COM", "alice.example.com", ""},
+		{"at://alice.example.com", "alice.example.com", ""},
+		{" pds-user.self-hosted.dev ", "pds-user.self-hosted.dev", ""},
+		{"did:plc:z72i7hdynmk6r22z27h6tvur", "", "did:plc:z72i7hdynmk6r22z27h6tvur"},
+		{"did:web:example.com", "", "did:web:example.com"},
+		{"did:web:localhost%3A8080", "", "did:web:localhost%3A8080"},
+	}
+	for _, tt := range tests {
+		handle, did, err := ParseIdentifier(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.handle, handle, tt.input)
+		assert.Equal(t, tt.did, did, tt.input)
+	}
+
+	for _, input := range []string{"", "alice", "alice.123", "-alice.example.com", "alice..example.com", "did:plc:short", "did:web:example.com:user:alice"} {
+		_, _, err := ParseIdentifier(input)
+		assert.Error(t, err, input)
+	}
+
+	_, _, err := ParseIdentifier("did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK")
+	assert.ErrorIs(t, err, ErrUnsupportedDID)
+}
+
+func TestPDSToAuthServer_SelfHosted(t *testing.T) {
+	var authServer string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/entryway/.well-known/oauth-protected-resource":
+			json.NewEncoder(w).Encode(map[string]any{"authorization_servers": []string{authServer}})
+		case "/plain-http/.well-known/oauth-protected-resource":
+			json.NewEncoder(w).Encode(map[string]any{"authorization_servers": []string{"http://auth.example.com"}})
+		case "/no-servers/.well-known/oauth-protected-resource":
+			json.NewEncoder(w).Encode(map[string]any{"authorization_servers": []string{}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	authServer = server.URL
+	allowLoopback(t, server.Client())
+
+	got, err := PDSToAuthServer(server.URL + "/entryway/")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL, got)
+
+	_, err = PDSToAuthServer(server.URL + "/old-pds")
+	assert.ErrorIs(t, err, ErrOAuthUnsupported)
+
+	_, err = PDSToAuthServer(server.URL + "/no-servers")
+	assert.ErrorIs(t, err, ErrOAuthUnsupported)
+
+	_, err = PDSToAuthServer(server.URL + "/plain-http")
+	assert.ErrorContains(t, err, "not an https URL")
+}
+
+func TestFetchAuthServerMetadata(t *testing.T) {
+	var issuer string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                issuer,
+			"authorization_endpoint":                issuer + "/authorize",
+			"token_endpoint":                        issuer + "/token",
+			"pushed_authorization_request_endpoint": issuer + "/par",
+		})
+	}))
+	defer server.Close()
+	allowLoopback(t, server.Client())
+
+	issuer = server.URL
+	metadata, err := FetchAuthServerMetadata(server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/par", metadata.PushedAuthorizationRequestEndpoint)
+	assert.Equal(t, server.URL+"/authorize", metadata.AuthorizationEndpoint)
+
+	// Metadata of another issuer is rejected
+	issuer = "https://attacker.example.com"
+	_, err = FetchAuthServerMetadata(server.URL)
+	assert.ErrorContains(t, err, "issued by")
+}
+
+func TestHandleDIDToPDS(t *testing.T) {
+	const did = "did:plc:ewvi7nxzyoun6zhxrhs64oiz"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":          did,
+			"alsoKnownAs": []string{"at://alice.example.com"},
+			"service": []map[string]any{
+				{"id": "#atproto_pds", "type": "AtprotoPersonalDataServer", "serviceEndpoint": "https://pds.example.com/"},
+			},
+		})
+	}))
+	defer server.Close()
+	allowLoopback(t, server.Client())
+	original := plcURL
+	plcURL = server.URL
+	t.Cleanup(func() { plcURL = original })
+
+	pds, err := HandleDIDToPDS("alice.example.com", did)
+	require.NoError(t, err)
+	assert.Equal(t, "https://pds.example.com", pds)
+
+	_, err = HandleDIDToPDS("mallory.example.com", did)
+	assert.ErrorContains(t, err, "does not confirm the handle")
+}
@@ -2,7 +2,9 @@ package oauth
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"time"
@@ -29,10 +31,19 @@ type OAuthSession struct {
 	PDSUrl         string     // User's PDS URL for direct writes
 	TokenExpiresAt *time.Time // When the access token expires
 	Issuer         string     // Auth server URL (needed for token refresh)
+	UserAgent      string     // Browser the session was created in
+	LastUsedAt     *time.Time // When the session last made a request (within activityInterval)
 	CreatedAt      time.Time
 	ExpiresAt      time.Time
 }
 
+// SessionHandle identifies a session on pages listing it, without revealing
+// its ID, which is the value of the session cookie
+func SessionHandle(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
+
 // Storage provides database operations for OAuth
 type Storage struct {
 	db *sql.DB
@@ -109,8 +120,8 @@ func (s *Storage) DeleteOAuthRequest(ctx context.Context, state string) error {
 // CreateSession creates a new OAuth session
 func (s *Storage) CreateSession(ctx context.Context, session OAuthSession) error {
 	query := `
-		INSERT INTO oauth_sessions (id, did, access_token, refresh_token, dpop_key, pds_url, token_expires_at, issuer, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO oauth_sessions (id, did, access_token, refresh_token, dpop_key, pds_url, token_expires_at, issuer, user_agent, expires_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
 	`
 
 	_, err := s.db.ExecContext(
@@ -124,6 +135,7 @@ func (s *Storage) CreateSession(ctx context.Context, session OAuthSession) error
 		session.PDSUrl,
 		session.TokenExpiresAt,
 		session.Issuer,
+		session.UserAgent,
 		session.ExpiresAt,
 	)
 
@@ -200,6 +212,96 @@ func (s *Storage) DeleteSession(ctx context.Context, id string) error {
 	return nil
 }
 
+// ListSessionsByDID returns a user's unexpired sessions, most recently used first
+func (s *Storage) ListSessionsByDID(ctx context.Context, did string) ([]OAuthSession, error) {
+	query := `
+		SELECT id, did, user_agent, last_used_at, created_at, expires_at
+		FROM oauth_sessions
+		WHERE did = $1 AND expires_at > NOW()
+		ORDER BY COALESCE(last_used_at, created_at) DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, did)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []OAuthSession
+	for rows.Next() {
+		var session OAuthSession
+		if err := rows.Scan(
+			&session.ID,
+			&session.DID,
+			&session.UserAgent,
+			&session.LastUsedAt,
+			&session.CreatedAt,
+			&session.ExpiresAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// DeleteSessionByDID removes one of a user's sessions, returning
+// sql.ErrNoRows if the user has no such session
+func (s *Storage) DeleteSessionByDID(ctx context.Context, did, id string) error {
+	query := `DELETE FROM oauth_sessions WHERE id = $1 AND did = $2`
+
+	result, err := s.db.ExecContext(ctx, query, id, did)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteSessionsByDID removes all of a user's sessions, logging them out everywhere
+func (s *Storage) DeleteSessionsByDID(ctx context.Context, did string) (int64, error) {
+	query := `DELETE FROM oauth_sessions WHERE did = $1`
+
+	result, err := s.db.ExecContext(ctx, query, did)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return count, nil
+}
+
+// TouchSession records that a session made a request. It writes at most once
+// per activityInterval per session.
+func (s *Storage) TouchSession(ctx context.Context, id string) error {
+	query := `
+		UPDATE oauth_sessions
+		SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2)
+	`
+
+	_, err := s.db.ExecContext(ctx, query, id, time.Now().Add(-activityInterval))
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	return nil
+}
+
 // CleanupExpiredRequests removes expired OAuth requests
 func (s *Storage) CleanupExpiredRequests(ctx context.Context) (int64, error) {
 	query := `DELETE FROM oauth_requests WHERE expires_at < NOW()`
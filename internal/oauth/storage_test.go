@@ -253,6 +253,48 @@ func TestOAuthSessionStorage(t *testing.T) {
 			t.Error("Expected error after deletion, got nil")
 		}
 	})
+
+	t.Run("lists and revokes a user's sessions", func(t *testing.T) {
+		for _, id := range []string{"list-session-1", "list-session-2"} {
+			err := storage.CreateSession(ctx, OAuthSession{
+				ID:        id,
+				DID:       "did:plc:sessions",
+				UserAgent: "Test Browser",
+				ExpiresAt: time.Now().Add(24 * time.Hour),
+			})
+			if err != nil {
+				t.Fatalf("CreateSession failed: %v", err)
+			}
+		}
+
+		if err := storage.TouchSession(ctx, "list-session-2"); err != nil {
+			t.Fatalf("TouchSession failed: %v", err)
+		}
+
+		sessions, err := storage.ListSessionsByDID(ctx, "did:plc:sessions")
+		if err != nil {
+			t.Fatalf("ListSessionsByDID failed: %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+		}
+		if sessions[0].UserAgent != "Test Browser" || sessions[0].LastUsedAt == nil {
+			t.Errorf("Session activity not recorded: %+v", sessions[0])
+		}
+
+		// Sessions of other users cannot be revoked
+		if err := storage.DeleteSessionByDID(ctx, "did:plc:other", "list-session-1"); err != sql.ErrNoRows {
+			t.Errorf("Expected sql.ErrNoRows, got %v", err)
+		}
+
+		count, err := storage.DeleteSessionsByDID(ctx, "did:plc:sessions")
+		if err != nil {
+			t.Fatalf("DeleteSessionsByDID failed: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("Expected 2 sessions deleted, got %d", count)
+		}
+	})
 }
 
 // setupTestDB creates a test database connection
@@ -169,21 +169,14 @@ func makeTokenRequest(config TokenConfig, clientAssertion, dpopProof string) (*T
 
 // GetTokenEndpoint fetches the token endpoint from the auth server metadata
 func GetTokenEndpoint(authServer string) (string, error) {
-	resp, err := http.Get(authServer + "/.well-known/oauth-authorization-server")
+	metadata, err := FetchAuthServerMetadata(authServer)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	var metadata map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
-		return "", err
-	}
 
-	endpoint, ok := metadata["token_endpoint"].(string)
-	if !ok {
+	if metadata.TokenEndpoint == "" {
 		return "", fmt.Errorf("missing token_endpoint")
 	}
 
-	return endpoint, nil
+	return metadata.TokenEndpoint, nil
 }
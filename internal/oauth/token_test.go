@@ -305,6 +305,7 @@ func TestGetTokenEndpoint(t *testing.T) {
 	}))
 	defer server.Close()
 	serverURL = server.URL
+	allowLoopback(t, server.Client())
 
 	endpoint, err := GetTokenEndpoint(server.URL)
 	if err != nil {
@@ -330,6 +331,7 @@ func TestGetTokenEndpoint_MissingField(t *testing.T) {
 	}))
 	defer server.Close()
 	serverURL = server.URL
+	allowLoopback(t, server.Client())
 
 	_, err := GetTokenEndpoint(server.URL)
 	if err == nil {
@@ -0,0 +1,40 @@
+package oauth
+
+import (
+	"context"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer traces the service's calls to PDSes
+var tracer = otel.Tracer("github.com/openmeet-team/survey/internal/oauth")
+
+// startPDSSpan starts the client span of a call to the XRPC method of a PDS
+func startPDSSpan(ctx context.Context, method, pdsURL string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("rpc.system", "xrpc"), attribute.String("rpc.method", method))
+	if u, err := url.Parse(pdsURL); err == nil && u.Host != "" {
+		attrs = append(attrs, attribute.String("server.address", u.Hostname()))
+	}
+	return tracer.Start(ctx, "pds "+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endPDSSpan ends the span of a PDS call, marking it failed if err is not nil
+func endPDSSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// pdsURLOf returns the PDS URL of a session, or "" if there is no session
+func pdsURLOf(session *OAuthSession) string {
+	if session == nil {
+		return ""
+	}
+	return session.PDSUrl
+}
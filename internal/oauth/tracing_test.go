@@ -0,0 +1,56 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPDSSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	pdsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/xrpc/com.atproto.repo.listRecords" {
+			w.Write([]byte(`{"records": []}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "InvalidRequest"}`))
+	}))
+	defer pdsServer.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	_, err := ListRecords(ctx, pdsServer.URL, "did:plc:test123", "net.openmeet.survey", "", 10)
+	require.NoError(t, err)
+
+	session := &OAuthSession{DID: "did:plc:test123", AccessToken: "token", PDSUrl: pdsServer.URL, DPoPKey: GenerateSecretJWK()}
+	_, _, err = CreateRecord(ctx, session, "net.openmeet.survey", "abc", map[string]interface{}{"name": "x"})
+	require.Error(t, err)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	list := spans[0]
+	assert.Equal(t, "pds com.atproto.repo.listRecords", list.Name)
+	assert.Equal(t, trace.SpanKindClient, list.SpanKind)
+	assert.Equal(t, parent.SpanContext().SpanID(), list.Parent.SpanID())
+	assert.Contains(t, list.Attributes, attribute.String("atproto.collection", "net.openmeet.survey"))
+	assert.Contains(t, list.Attributes, attribute.String("server.address", "127.0.0.1"))
+	assert.Equal(t, codes.Unset, list.Status.Code)
+
+	create := spans[1]
+	assert.Equal(t, "pds com.atproto.repo.createRecord", create.Name)
+	assert.Equal(t, codes.Error, create.Status.Code)
+}
@@ -0,0 +1,197 @@
+// Package ogcard renders the Open Graph card image shown when a survey link is
+// shared on Bluesky and other social apps: a 1200x630 PNG with the survey's
+// title, question count, and vote count.
+package ogcard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Card dimensions, as recommended for og:image
+const (
+	Width  = 1200
+	Height = 630
+)
+
+// Layout of the card in pixels
+const (
+	margin        = 80
+	accentWidth   = 24
+	titleSize     = 64
+	titleLineSize = 80
+	maxTitleLines = 3
+	brandSize     = 32
+	statsSize     = 40
+	brandBaseline = 120
+	titleBaseline = 240
+	statsBaseline = Height - margin
+	textWidth     = Width - 2*margin
+	brandName     = "OpenMeet Survey"
+	ellipsis      = "…"
+)
+
+var (
+	background = color.RGBA{0xf5, 0xf7, 0xfa, 0xff}
+	accent     = color.RGBA{0x34, 0x98, 0xdb, 0xff}
+	dark       = color.RGBA{0x2c, 0x3e, 0x50, 0xff}
+	muted      = color.RGBA{0x7f, 0x8c, 0x8d, 0xff}
+)
+
+// Fonts are parsed once; faces are created per render since they are not safe for concurrent use
+var (
+	regularFont = mustParse(goregular.TTF)
+	boldFont    = mustParse(gobold.TTF)
+)
+
+func mustParse(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(fmt.Sprintf("ogcard: failed to parse font: %v", err))
+	}
+	return f
+}
+
+// Card is the content of a survey's card
+type Card struct {
+	Title     string
+	Questions int
+	Votes     int
+}
+
+// Key identifies the card's content, so cached images never need invalidation
+func (c *Card) Key() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", c.Title, c.Questions, c.Votes)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Stats returns the card's summary line, e.g. "3 questions · 42 votes"
+func (c *Card) Stats() string {
+	return plural(c.Questions, "question") + " · " + plural(c.Votes, "vote")
+}
+
+// plural formats a count with a singular or plural noun
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// Render draws the card as a PNG
+func Render(c *Card) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, accentWidth, Height), image.NewUniform(accent), image.Point{}, draw.Src)
+
+	brand, err := newFace(regularFont, brandSize)
+	if err != nil {
+		return nil, err
+	}
+	defer brand.Close()
+	title, err := newFace(boldFont, titleSize)
+	if err != nil {
+		return nil, err
+	}
+	defer title.Close()
+	stats, err := newFace(regularFont, statsSize)
+	if err != nil {
+		return nil, err
+	}
+	defer stats.Close()
+
+	drawText(img, brand, muted, margin, brandBaseline, brandName)
+	for i, line := range wrap(title, c.Title, textWidth, maxTitleLines) {
+		drawText(img, title, dark, margin, titleBaseline+i*titleLineSize, line)
+	}
+	drawText(img, stats, dark, margin, statsBaseline, c.Stats())
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode card: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// newFace creates a face of a font at a size in pixels
+func newFace(f *opentype.Font, size float64) (font.Face, error) {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %w", err)
+	}
+	return face, nil
+}
+
+// drawText draws a line of text with its baseline at y
+func drawText(img draw.Image, face font.Face, c color.Color, x, y int, text string) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+// wrap breaks text into at most maxLines lines fitting width pixels, breaking
+// words longer than a line and ending the last line with an ellipsis if the
+// text does not fit
+func wrap(face font.Face, text string, width, maxLines int) []string {
+	limit := fixed.I(width)
+	fits := func(s string) bool { return font.MeasureString(face, s) <= limit }
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if fits(candidate) {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+			line = ""
+		}
+		// Break words wider than a line
+		for !fits(word) {
+			runes := []rune(word)
+			n := len(runes) - 1
+			for n > 1 && !fits(string(runes[:n])) {
+				n--
+			}
+			lines = append(lines, string(runes[:n]))
+			word = string(runes[n:])
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	if len(lines) <= maxLines {
+		return lines
+	}
+	lines = lines[:maxLines]
+	last := []rune(lines[maxLines-1])
+	for len(last) > 0 && !fits(string(last)+ellipsis) {
+		last = last[:len(last)-1]
+	}
+	lines[maxLines-1] = strings.TrimRight(string(last), " ") + ellipsis
+	return lines
+}
@@ -0,0 +1,81 @@
+package ogcard
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestRender(t *testing.T) {
+	data, err := Render(&Card{Title: "Where should we have the team offsite?", Questions: 3, Votes: 42})
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, Width, img.Bounds().Dx())
+	assert.Equal(t, Height, img.Bounds().Dy())
+
+	// The accent bar and the background are drawn
+	assert.Equal(t, accent, img.At(0, 0))
+	assert.Equal(t, background, img.At(Width-1, Height-1))
+}
+
+func TestCard_Stats(t *testing.T) {
+	assert.Equal(t, "1 question · 0 votes", (&Card{Questions: 1}).Stats())
+	assert.Equal(t, "5 questions · 1 vote", (&Card{Questions: 5, Votes: 1}).Stats())
+}
+
+func TestCard_Key(t *testing.T) {
+	card := &Card{Title: "Lunch?", Questions: 1, Votes: 2}
+	assert.Equal(t, card.Key(), (&Card{Title: "Lunch?", Questions: 1, Votes: 2}).Key())
+	assert.NotEqual(t, card.Key(), (&Card{Title: "Lunch?", Questions: 1, Votes: 3}).Key())
+	assert.NotEqual(t, card.Key(), (&Card{Title: "Dinner?", Questions: 1, Votes: 2}).Key())
+}
+
+func TestWrap(t *testing.T) {
+	face, err := newFace(boldFont, titleSize)
+	require.NoError(t, err)
+	defer face.Close()
+
+	fits := func(line string) bool {
+		return font.MeasureString(face, line) <= fixed.I(textWidth)
+	}
+
+	t.Run("short title is one line", func(t *testing.T) {
+		assert.Equal(t, []string{"Lunch?"}, wrap(face, "Lunch?", textWidth, maxTitleLines))
+	})
+
+	t.Run("long title wraps at words", func(t *testing.T) {
+		lines := wrap(face, "Which of these venues should we book for the spring community meetup?", textWidth, maxTitleLines)
+		require.Greater(t, len(lines), 1)
+		for _, line := range lines {
+			assert.True(t, fits(line), "line too wide: %q", line)
+		}
+		assert.Equal(t, "Which of these venues should we book for the spring community meetup?", strings.Join(lines, " "))
+	})
+
+	t.Run("overflow ends with an ellipsis", func(t *testing.T) {
+		lines := wrap(face, strings.Repeat("survey ", 60), textWidth, maxTitleLines)
+		require.Len(t, lines, maxTitleLines)
+		assert.True(t, strings.HasSuffix(lines[maxTitleLines-1], ellipsis))
+		assert.True(t, fits(lines[maxTitleLines-1]))
+	})
+
+	t.Run("long words are broken", func(t *testing.T) {
+		lines := wrap(face, strings.Repeat("a", 100), textWidth, maxTitleLines)
+		require.Greater(t, len(lines), 1)
+		for _, line := range lines {
+			assert.True(t, fits(line), "line too wide: %q", line)
+		}
+	})
+
+	t.Run("empty title", func(t *testing.T) {
+		assert.Empty(t, wrap(face, "   ", textWidth, maxTitleLines))
+	})
+}
@@ -0,0 +1,148 @@
+// Package org groups users into organizations that own surveys together. A
+// survey record still lives in its author's PDS, but once the author moves it
+// to an organization, its members manage it according to their role: owners
+// and editors manage it like its author, and viewers see its responses,
+// exports, and analytics. Members join by accepting an invite from an owner.
+package org
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Roles of organization members
+const (
+	RoleOwner  = "owner"  // Manages members and the organization's surveys
+	RoleEditor = "editor" // Manages the organization's surveys
+	RoleViewer = "viewer" // Sees the responses, exports, and analytics of the organization's surveys
+)
+
+// Roles lists the valid roles, in the order forms show them
+var Roles = []string{RoleOwner, RoleEditor, RoleViewer}
+
+// Limits
+const (
+	MaxNameLength     = 100 // Characters of an organization's name
+	MaxMembers        = 100 // Members and pending invites of an organization
+	MaxOrgsPerCreator = 20  // Organizations a user can create
+)
+
+// Errors of Store methods caused by the request
+var (
+	ErrSlugTaken     = errors.New("an organization with this slug already exists")
+	ErrAlreadyMember = errors.New("already a member or invited")
+)
+
+// Org is an organization owning surveys
+type Org struct {
+	ID        uuid.UUID `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Member is a member of an organization, or an invitee until AcceptedAt is set
+type Member struct {
+	OrgID      uuid.UUID  `json:"orgId"`
+	DID        string     `json:"did"`
+	Role       string     `json:"role"`
+	InvitedBy  string     `json:"invitedBy"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Membership is an organization of a user, with the user's membership of it
+type Membership struct {
+	Org    *Org    `json:"org"`
+	Member *Member `json:"membership"`
+}
+
+// Store persists organizations and their members
+type Store interface {
+	// CreateOrg saves an organization with its creator as an accepted owner,
+	// or returns ErrSlugTaken
+	CreateOrg(ctx context.Context, o *Org) error
+	// GetOrgBySlug returns sql.ErrNoRows if there is no such organization
+	GetOrgBySlug(ctx context.Context, slug string) (*Org, error)
+	GetOrgByID(ctx context.Context, id uuid.UUID) (*Org, error)
+	CountOrgsByCreator(ctx context.Context, did string) (int, error)
+	// ListMemberships returns the organizations a user belongs to or is invited to, by name
+	ListMemberships(ctx context.Context, did string) ([]*Membership, error)
+	// ListMembers returns the members and invitees of an organization, owners first
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]*Member, error)
+	// GetMember returns sql.ErrNoRows if the DID is neither a member nor invited
+	GetMember(ctx context.Context, orgID uuid.UUID, did string) (*Member, error)
+	// InviteMember returns ErrAlreadyMember if the DID is a member or invited
+	InviteMember(ctx context.Context, m *Member) error
+	// AcceptInvite returns sql.ErrNoRows if the DID has no pending invite
+	AcceptInvite(ctx context.Context, orgID uuid.UUID, did string) error
+	// UpdateMemberRole returns sql.ErrNoRows if the DID is neither a member nor invited
+	UpdateMemberRole(ctx context.Context, orgID uuid.UUID, did, role string) error
+	// RemoveMember returns sql.ErrNoRows if the DID is neither a member nor invited
+	RemoveMember(ctx context.Context, orgID uuid.UUID, did string) error
+	// ListOrgSurveys returns the surveys owned by an organization, newest first
+	ListOrgSurveys(ctx context.Context, orgID uuid.UUID) ([]*models.Survey, error)
+	// SetSurveyOrg moves a survey to an organization, or back to its author with nil
+	SetSurveyOrg(ctx context.Context, surveyID uuid.UUID, orgID *uuid.UUID) error
+}
+
+// New creates an organization. Its slug is checked like a survey's.
+func New(slug, name, createdBy string) (*Org, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if err := models.ValidateSlug(slug); err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return nil, fmt.Errorf("name must be at most %d characters", MaxNameLength)
+	}
+
+	return &Org{
+		ID:        uuid.New(),
+		Slug:      slug,
+		Name:      name,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// ValidRole reports whether role is one of Roles
+func ValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Pending reports whether the member has not accepted the invite yet
+func (m *Member) Pending() bool {
+	return m.AcceptedAt == nil
+}
+
+// CanAdminister reports whether the member manages members of the organization
+func (m *Member) CanAdminister() bool {
+	return m != nil && !m.Pending() && m.Role == RoleOwner
+}
+
+// CanEdit reports whether the member manages the organization's surveys
+func (m *Member) CanEdit() bool {
+	return m != nil && !m.Pending() && (m.Role == RoleOwner || m.Role == RoleEditor)
+}
+
+// CanView reports whether the member sees the responses of the organization's surveys
+func (m *Member) CanView() bool {
+	return m != nil && !m.Pending() && ValidRole(m.Role)
+}
@@ -0,0 +1,56 @@
+package org
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	o, err := New(" Acme-Research ", "  Acme Research ", "did:plc:alice")
+	require.NoError(t, err)
+	assert.Equal(t, "acme-research", o.Slug)
+	assert.Equal(t, "Acme Research", o.Name)
+	assert.Equal(t, "did:plc:alice", o.CreatedBy)
+
+	_, err = New("a", "Acme", "did:plc:alice")
+	assert.Error(t, err, "slugs are checked like survey slugs")
+	_, err = New("acme", " ", "did:plc:alice")
+	assert.Error(t, err)
+	_, err = New("acme", strings.Repeat("a", MaxNameLength+1), "did:plc:alice")
+	assert.Error(t, err)
+}
+
+func TestMemberPermissions(t *testing.T) {
+	accepted := time.Now()
+	tests := []struct {
+		name                      string
+		member                    *Member
+		administer, edit, canView bool
+	}{
+		{"owner", &Member{Role: RoleOwner, AcceptedAt: &accepted}, true, true, true},
+		{"editor", &Member{Role: RoleEditor, AcceptedAt: &accepted}, false, true, true},
+		{"viewer", &Member{Role: RoleViewer, AcceptedAt: &accepted}, false, false, true},
+		{"pending owner", &Member{Role: RoleOwner}, false, false, false},
+		{"unknown role", &Member{Role: "admin", AcceptedAt: &accepted}, false, false, false},
+		{"not a member", nil, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.administer, tt.member.CanAdminister())
+			assert.Equal(t, tt.edit, tt.member.CanEdit())
+			assert.Equal(t, tt.canView, tt.member.CanView())
+		})
+	}
+}
+
+func TestValidRole(t *testing.T) {
+	for _, role := range Roles {
+		assert.True(t, ValidRole(role))
+	}
+	assert.False(t, ValidRole("admin"))
+	assert.False(t, ValidRole(""))
+}
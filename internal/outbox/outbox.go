@@ -0,0 +1,95 @@
+// Package outbox keeps ATProto records whose write to the user's PDS failed.
+// The survey or response is still saved locally, without a record; the entry
+// holds the record so the user can retry publishing it to their own PDS
+// instead of their data silently staying local-only.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of local rows an entry publishes
+const (
+	KindSurvey   = "survey"
+	KindResponse = "response"
+)
+
+// Statuses of an entry
+const (
+	StatusPending   = "pending"   // Saved locally, not yet on the user's PDS
+	StatusPublished = "published" // Written to the PDS and linked to the local row
+)
+
+// Entry is a record waiting to be written to a user's PDS
+type Entry struct {
+	ID         uuid.UUID       `json:"id"`
+	Kind       string          `json:"kind"`
+	SurveyID   uuid.UUID       `json:"surveyId"`
+	ResponseID *uuid.UUID      `json:"responseId,omitempty"` // Set for responses
+	DID        string          `json:"did"`                  // Repo the record is written to
+	Collection string          `json:"collection"`
+	RKey       string          `json:"rkey"`
+	Record     json.RawMessage `json:"record"`
+	Status     string          `json:"status"`
+	Attempts   int             `json:"attempts"`
+	LastError  *string         `json:"lastError,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
+}
+
+// Store persists outbox entries
+type Store interface {
+	CreateOutboxEntry(ctx context.Context, e *Entry) error
+	GetOutboxEntry(ctx context.Context, id uuid.UUID) (*Entry, error)
+	ListPendingOutboxEntries(ctx context.Context, surveyID uuid.UUID, did string) ([]*Entry, error)
+	RecordOutboxAttempt(ctx context.Context, id uuid.UUID, lastError string) error
+	// MarkOutboxPublished links the local survey or response to the written
+	// record and marks the entry published
+	MarkOutboxPublished(ctx context.Context, e *Entry, uri, cid string) error
+}
+
+// NewEntry creates a pending entry for a record that failed to be written.
+// The caller sets SurveyID and ResponseID once the local row is saved.
+func NewEntry(kind, did, collection, rkey string, record map[string]interface{}) (*Entry, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	now := time.Now()
+	return &Entry{
+		ID:         uuid.New(),
+		Kind:       kind,
+		DID:        did,
+		Collection: collection,
+		RKey:       rkey,
+		Record:     data,
+		Status:     StatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}, nil
+}
+
+// URI returns the AT URI the record is written to
+func (e *Entry) URI() string {
+	return fmt.Sprintf("at://%s/%s/%s", e.DID, e.Collection, e.RKey)
+}
+
+// Pending reports whether the record has not been written yet
+func (e *Entry) Pending() bool {
+	return e.Status == StatusPending
+}
+
+// RecordValue decodes the stored record for writing
+func (e *Entry) RecordValue() (map[string]interface{}, error) {
+	var record map[string]interface{}
+	if err := json.Unmarshal(e.Record, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", err)
+	}
+	return record, nil
+}
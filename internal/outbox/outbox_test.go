@@ -0,0 +1,41 @@
+package outbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEntry(t *testing.T) {
+	record := map[string]interface{}{
+		"$type": "net.openmeet.survey.response",
+		"subject": map[string]string{
+			"uri": "at://did:plc:author/net.openmeet.survey/abc",
+			"cid": "bafy",
+		},
+		"createdAt": "2025-01-01T00:00:00Z",
+	}
+
+	entry, err := NewEntry(KindResponse, "did:plc:voter", "net.openmeet.survey.response", "3kxyz", record)
+	require.NoError(t, err)
+
+	assert.True(t, entry.Pending())
+	assert.Equal(t, 0, entry.Attempts)
+	assert.Equal(t, "at://did:plc:voter/net.openmeet.survey.response/3kxyz", entry.URI())
+
+	// The stored record round-trips for the retry
+	value, err := entry.RecordValue()
+	require.NoError(t, err)
+	assert.Equal(t, "net.openmeet.survey.response", value["$type"])
+	assert.Equal(t, "2025-01-01T00:00:00Z", value["createdAt"])
+	assert.Equal(t, "at://did:plc:author/net.openmeet.survey/abc", value["subject"].(map[string]interface{})["uri"])
+}
+
+func TestEntry_Published(t *testing.T) {
+	entry, err := NewEntry(KindSurvey, "did:plc:author", "net.openmeet.survey", "3kxyz", map[string]interface{}{})
+	require.NoError(t, err)
+
+	entry.Status = StatusPublished
+	assert.False(t, entry.Pending())
+}
@@ -0,0 +1,101 @@
+// Package preview keeps survey definitions shown before publishing. A preview
+// is a short-lived random "pv_" token whose page renders the voting form as
+// respondents will see it; no survey is created and no answers are accepted.
+// Only the token's SHA-256 hash is stored.
+package preview
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// TokenPrefix starts every preview token
+const TokenPrefix = "pv_"
+
+// TTL is how long a preview can be viewed after it is created
+const TTL = time.Hour
+
+// Preview is a survey definition shown before publishing
+type Preview struct {
+	Hash       string                  // Hex SHA-256 of the token
+	Definition models.SurveyDefinition // Validated like the definitions of new surveys
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// Store persists previews
+type Store interface {
+	CreatePreview(ctx context.Context, p *Preview) error
+	// GetPreview returns sql.ErrNoRows if no preview has the hash or it
+	// expired before now
+	GetPreview(ctx context.Context, hash string, now time.Time) (*Preview, error)
+	// DeleteExpiredPreviews deletes previews that expired before a time
+	DeleteExpiredPreviews(ctx context.Context, before time.Time) (int64, error)
+}
+
+// New creates a preview of a validated definition and returns it with its
+// token, which cannot be recovered from the stored preview
+func New(def models.SurveyDefinition, now time.Time) (*Preview, string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate preview token: %w", err)
+	}
+	token := TokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return &Preview{
+		Hash:       Hash(token),
+		Definition: def,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(TTL),
+	}, token, nil
+}
+
+// Hash returns the hex SHA-256 of a token, as stored
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Survey returns the unsaved survey the preview shows, titled by its first
+// question like new surveys. It has no ID or slug.
+func (p *Preview) Survey() *models.Survey {
+	survey := &models.Survey{
+		Definition: p.Definition,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.CreatedAt,
+	}
+	if len(p.Definition.Questions) > 0 {
+		survey.Title = p.Definition.Questions[0].Text
+	}
+	return survey
+}
+
+// StartCleanupWorker deletes expired previews every interval until ctx is
+// cancelled
+func StartCleanupWorker(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := store.DeleteExpiredPreviews(ctx, time.Now())
+		if err != nil {
+			log.Printf("Error deleting expired previews: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d expired previews", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
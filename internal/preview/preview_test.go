@@ -0,0 +1,31 @@
+package preview
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	def := models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Lunch?", Type: models.QuestionTypeText}}}
+
+	p, token, err := New(def, now)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, TokenPrefix))
+	assert.Equal(t, Hash(token), p.Hash)
+	assert.Equal(t, now.Add(TTL), p.ExpiresAt)
+
+	_, other, err := New(def, now)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+
+	survey := p.Survey()
+	assert.Equal(t, "Lunch?", survey.Title)
+	assert.Empty(t, survey.Slug)
+	assert.Equal(t, def, survey.Definition)
+}
@@ -0,0 +1,212 @@
+// Package problem describes API errors as RFC 7807 problem details
+// (application/problem+json). Every error has a stable code that clients can
+// program against; its type URI resolves to the code's documentation.
+package problem
+
+import (
+	"net/http"
+	"sort"
+)
+
+// ContentType is the media type of problem details
+const ContentType = "application/problem+json"
+
+// Code is a stable, machine-readable error code. Codes never change meaning;
+// new conditions get new codes.
+type Code string
+
+// Error codes
+const (
+	BadRequest         Code = "bad_request"
+	InvalidRequestBody Code = "invalid_request_body"
+	ValidationFailed   Code = "validation_failed"
+	InvalidDefinition  Code = "invalid_definition"
+	InvalidAnswers     Code = "invalid_answers"
+
+	AuthenticationRequired Code = "authentication_required"
+	InvalidAPIKey          Code = "invalid_api_key"
+	Forbidden              Code = "forbidden"
+	InsufficientScope      Code = "insufficient_scope"
+	CaptchaRequired        Code = "captcha_required"
+	SurveyPrivate          Code = "survey_private"
+	SurveyClosed           Code = "survey_closed"
+	SurveyReadOnly         Code = "survey_read_only"
+	SurveyAnonymous        Code = "survey_anonymous"
+
+	NotFound       Code = "not_found"
+	SurveyNotFound Code = "survey_not_found"
+	SurveyHidden   Code = "survey_hidden"
+	SurveyDeleted  Code = "survey_deleted"
+
+	MethodNotAllowed Code = "method_not_allowed"
+	SlugTaken        Code = "slug_taken"
+	SlugReserved     Code = "slug_reserved"
+	AlreadyVoted     Code = "already_voted"
+	SurveyPublished  Code = "survey_published"
+	LimitReached     Code = "limit_reached"
+	PublishPending   Code = "publish_pending"
+
+	IdempotencyKeyInUse  Code = "idempotency_key_in_use"
+	IdempotencyKeyReused Code = "idempotency_key_reused"
+
+	PayloadTooLarge      Code = "payload_too_large"
+	UnsupportedMediaType Code = "unsupported_media_type"
+	RateLimited          Code = "rate_limited"
+
+	InternalError      Code = "internal_error"
+	GenerationFailed   Code = "generation_failed"
+	PDSUnavailable     Code = "pds_unavailable"
+	ServiceUnavailable Code = "service_unavailable"
+)
+
+// Definition is what a code means: the status and title of its problems
+type Definition struct {
+	Code        Code   `json:"code"`
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+var definitions = map[Code]Definition{
+	BadRequest:         {Status: http.StatusBadRequest, Title: "Bad request", Description: "The request is malformed."},
+	InvalidRequestBody: {Status: http.StatusBadRequest, Title: "Invalid request body", Description: "The request body is not valid JSON of the expected shape."},
+	ValidationFailed:   {Status: http.StatusBadRequest, Title: "Validation failed", Description: "A parameter or field of the request is invalid; the detail says which."},
+	InvalidDefinition:  {Status: http.StatusBadRequest, Title: "Invalid survey definition", Description: "The survey definition does not parse or does not validate."},
+	InvalidAnswers:     {Status: http.StatusBadRequest, Title: "Invalid answers", Description: "The answers of a response do not fit the survey's questions."},
+
+	AuthenticationRequired: {Status: http.StatusUnauthorized, Title: "Authentication required", Description: "Log in or send an API key."},
+	InvalidAPIKey:          {Status: http.StatusUnauthorized, Title: "Invalid API key", Description: "The API key is unknown, expired, or revoked."},
+	Forbidden:              {Status: http.StatusForbidden, Title: "Forbidden", Description: "The caller may not do this."},
+	InsufficientScope:      {Status: http.StatusForbidden, Title: "Insufficient scope", Description: "The API key lacks the scope this request needs."},
+	CaptchaRequired:        {Status: http.StatusForbidden, Title: "CAPTCHA required", Description: "Solve a CAPTCHA and retry with its token in the X-Captcha-Token header."},
+	SurveyPrivate:          {Status: http.StatusForbidden, Title: "Survey is private", Description: "The survey is only open to its share and invite links."},
+	SurveyClosed:           {Status: http.StatusForbidden, Title: "Survey closed", Description: "The survey is not accepting responses."},
+	SurveyReadOnly:         {Status: http.StatusForbidden, Title: "Survey is read-only", Description: "The survey accepts responses in the app that created it."},
+	SurveyAnonymous:        {Status: http.StatusForbidden, Title: "Survey is anonymous", Description: "Responses of anonymous surveys are only available as results."},
+
+	NotFound:       {Status: http.StatusNotFound, Title: "Not found", Description: "The resource does not exist."},
+	SurveyNotFound: {Status: http.StatusNotFound, Title: "Survey not found", Description: "No survey has this slug."},
+	SurveyHidden:   {Status: http.StatusNotFound, Title: "Survey hidden", Description: "The survey was hidden by a moderator."},
+	SurveyDeleted:  {Status: http.StatusGone, Title: "Survey deleted", Description: "The survey was deleted by its author."},
+
+	MethodNotAllowed: {Status: http.StatusMethodNotAllowed, Title: "Method not allowed", Description: "The resource does not support this method."},
+	SlugTaken:        {Status: http.StatusConflict, Title: "Survey slug already exists", Description: "Another survey has this slug."},
+	SlugReserved:     {Status: http.StatusConflict, Title: "Survey slug is reserved", Description: "The slug is reserved for allow-listed authors; choose another."},
+	AlreadyVoted:     {Status: http.StatusConflict, Title: "Already voted", Description: "The caller has already responded to this survey."},
+	SurveyPublished:  {Status: http.StatusConflict, Title: "Survey is published", Description: "The survey is published to its author's PDS and is changed through its record."},
+	LimitReached:     {Status: http.StatusConflict, Title: "Limit reached", Description: "The caller has as many of these resources as allowed."},
+	PublishPending:   {Status: http.StatusConflict, Title: "Publication already requested", Description: "The survey's author has yet to answer an earlier request to publish its results."},
+
+	IdempotencyKeyInUse:  {Status: http.StatusConflict, Title: "Idempotency key in use", Description: "A request with this Idempotency-Key is still being processed; retry after the Retry-After header's seconds."},
+	IdempotencyKeyReused: {Status: http.StatusUnprocessableEntity, Title: "Idempotency key reused", Description: "The Idempotency-Key was used for a different request; send a new key."},
+
+	PayloadTooLarge:      {Status: http.StatusRequestEntityTooLarge, Title: "Payload too large", Description: "The request body exceeds the limit of this endpoint."},
+	UnsupportedMediaType: {Status: http.StatusUnsupportedMediaType, Title: "Unsupported media type", Description: "The request body has a content type this endpoint does not accept."},
+	RateLimited:          {Status: http.StatusTooManyRequests, Title: "Rate limit exceeded", Description: "Too many requests; retry after the Retry-After header's seconds."},
+
+	InternalError:      {Status: http.StatusInternalServerError, Title: "Internal server error", Description: "The request failed on the server; report the trace ID."},
+	GenerationFailed:   {Status: http.StatusInternalServerError, Title: "AI generation failed", Description: "The AI model did not produce a valid survey; try rephrasing."},
+	PDSUnavailable:     {Status: http.StatusBadGateway, Title: "PDS unavailable", Description: "A record could not be read from the PDS holding it; try again later."},
+	ServiceUnavailable: {Status: http.StatusServiceUnavailable, Title: "Service unavailable", Description: "The feature is not configured or temporarily unavailable."},
+}
+
+// Lookup returns the definition of a code
+func Lookup(code Code) (Definition, bool) {
+	def, ok := definitions[code]
+	def.Code = code
+	return def, ok
+}
+
+// Definitions returns the definitions of all codes, sorted by status and code
+func Definitions() []Definition {
+	defs := make([]Definition, 0, len(definitions))
+	for code := range definitions {
+		def, _ := Lookup(code)
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		if defs[i].Status != defs[j].Status {
+			return defs[i].Status < defs[j].Status
+		}
+		return defs[i].Code < defs[j].Code
+	})
+	return defs
+}
+
+// ForStatus returns the generic code of an HTTP status, for errors that
+// carry no code of their own
+func ForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return BadRequest
+	case http.StatusUnauthorized:
+		return AuthenticationRequired
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusMethodNotAllowed:
+		return MethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return PayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return UnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return RateLimited
+	case http.StatusServiceUnavailable:
+		return ServiceUnavailable
+	}
+	if status < 500 {
+		return BadRequest
+	}
+	return InternalError
+}
+
+// Problem is an RFC 7807 problem details object. It is an error, so handlers
+// and middleware can return it for the API's error handler to write.
+type Problem struct {
+	Type     string `json:"type"` // URI of the code's documentation
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"` // Path of the request
+	Code     Code   `json:"code"`
+	TraceID  string `json:"traceId,omitempty"` // Reference to the server logs
+
+	// Extension members of some codes
+	NeedsCaptcha bool `json:"needs_captcha,omitempty"` // captcha_required: retry with a CAPTCHA token
+	Limit        int  `json:"limit,omitempty"`         // rate_limited: requests allowed per window
+	RetryAfter   int  `json:"retryAfter,omitempty"`    // rate_limited: seconds, as in the Retry-After header
+
+	Errors []ItemError `json:"errors,omitempty"` // Errors of the items of a batch request, e.g. bulk survey creation
+}
+
+// ItemError is why one item of a batch request was rejected
+type ItemError struct {
+	Index  int    `json:"index"` // Position of the item in the request
+	Code   Code   `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// New returns a problem of a code with a detail specific to this occurrence.
+// Unknown codes are internal errors.
+func New(code Code, detail string) *Problem {
+	def, ok := Lookup(code)
+	if !ok {
+		def, _ = Lookup(InternalError)
+	}
+	return &Problem{
+		Title:  def.Title,
+		Status: def.Status,
+		Detail: detail,
+		Code:   def.Code,
+	}
+}
+
+// Error returns the title and detail of the problem
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
@@ -0,0 +1,59 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	p := New(SlugTaken, "A survey with slug 'lunch' already exists")
+	assert.Equal(t, http.StatusConflict, p.Status)
+	assert.Equal(t, "Survey slug already exists", p.Title)
+	assert.Equal(t, "Survey slug already exists: A survey with slug 'lunch' already exists", p.Error())
+
+	unknown := New(Code("no_such_code"), "")
+	assert.Equal(t, InternalError, unknown.Code)
+	assert.Equal(t, http.StatusInternalServerError, unknown.Status)
+	assert.Equal(t, "Internal server error", unknown.Error())
+}
+
+func TestProblemJSON(t *testing.T) {
+	p := New(RateLimited, "Too many requests")
+	p.Type = "/problems/rate_limited"
+	p.RetryAfter = 30
+
+	data, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "/problems/rate_limited",
+		"title": "Rate limit exceeded",
+		"status": 429,
+		"detail": "Too many requests",
+		"code": "rate_limited",
+		"retryAfter": 30
+	}`, string(data))
+}
+
+func TestDefinitions(t *testing.T) {
+	defs := Definitions()
+	require.NotEmpty(t, defs)
+	for i, def := range defs {
+		assert.NotEmpty(t, def.Title, def.Code)
+		assert.NotEmpty(t, def.Description, def.Code)
+		assert.Equal(t, def.Status, New(def.Code, "").Status)
+		if i > 0 {
+			assert.LessOrEqual(t, defs[i-1].Status, def.Status)
+		}
+	}
+}
+
+func TestForStatus(t *testing.T) {
+	assert.Equal(t, NotFound, ForStatus(http.StatusNotFound))
+	assert.Equal(t, PayloadTooLarge, ForStatus(http.StatusRequestEntityTooLarge))
+	assert.Equal(t, BadRequest, ForStatus(http.StatusTeapot))
+	assert.Equal(t, InternalError, ForStatus(http.StatusBadGateway))
+}
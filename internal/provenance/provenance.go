@@ -0,0 +1,122 @@
+// Package provenance describes how published survey results were produced.
+// Several AppViews can aggregate the same lexicon, so published results records
+// carry the aggregating AppView, software version, and counting method.
+package provenance
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Version is the software version, set at build time with
+// -ldflags "-X github.com/openmeet-team/survey/internal/provenance.Version=<version>"
+var Version = "dev"
+
+const (
+	// Software identifies this AppView implementation
+	Software = "openmeet-survey"
+
+	// DedupePolicy is how repeat submissions are counted
+	DedupePolicy = "one-per-voter"
+
+	// baseMethodNotes describes how results of every survey are aggregated
+	baseMethodNotes = "One response per voter: logged-in voters are deduplicated by DID and anonymous voters " +
+		"by a per-survey hash of IP address and user agent; a changed vote replaces the earlier one. " +
+		"Text answers flagged by moderation are not counted until approved."
+)
+
+// Exclusions counts the responses a survey's settings left out of its results
+type Exclusions struct {
+	Duplicates int // excluded by the author as suspected duplicates
+	Spam       int // scored at or above the survey's spam threshold
+}
+
+// MethodNotes describes how results with the given exclusions are aggregated
+func MethodNotes(excluded Exclusions) string {
+	notes := baseMethodNotes
+	if excluded.Duplicates > 0 {
+		notes += fmt.Sprintf(" %s excluded by the author as suspected duplicates.", responses(excluded.Duplicates))
+	}
+	if excluded.Spam > 0 {
+		notes += fmt.Sprintf(" %s at or above the survey's spam threshold excluded.", responses(excluded.Spam))
+	}
+	return notes
+}
+
+// responses formats a count of responses
+func responses(n int) string {
+	if n == 1 {
+		return "1 response"
+	}
+	return fmt.Sprintf("%d responses", n)
+}
+
+// Config holds the identity of the AppView publishing results
+type Config struct {
+	AppViewDID string
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - APPVIEW_DID: DID of this AppView (default: did:web derived from SERVER_HOST)
+//   - SERVER_HOST: public URL of the service, e.g. https://survey.example.com
+func ConfigFromEnv() Config {
+	if did := os.Getenv("APPVIEW_DID"); did != "" {
+		return Config{AppViewDID: did}
+	}
+	return Config{AppViewDID: didWebFromURL(os.Getenv("SERVER_HOST"))}
+}
+
+// didWebFromURL returns the did:web for the host of a URL, or "" if it has none
+func didWebFromURL(serverURL string) string {
+	if serverURL == "" {
+		return ""
+	}
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	// Ports are percent-encoded in did:web identifiers
+	return "did:web:" + strings.ReplaceAll(u.Host, ":", "%3A")
+}
+
+// Provenance describes a single aggregation of survey results
+type Provenance struct {
+	AppViewDID      string    `json:"appView,omitempty"`
+	Software        string    `json:"software"`
+	SoftwareVersion string    `json:"softwareVersion"`
+	AggregatedAt    time.Time `json:"aggregatedAt"`
+	DedupePolicy    string    `json:"dedupePolicy"`
+	MethodNotes     string    `json:"methodNotes"`
+}
+
+// New describes results aggregated by this AppView at aggregatedAt, leaving
+// out the excluded responses
+func New(config Config, aggregatedAt time.Time, excluded Exclusions) *Provenance {
+	return &Provenance{
+		AppViewDID:      config.AppViewDID,
+		Software:        Software,
+		SoftwareVersion: Version,
+		AggregatedAt:    aggregatedAt.UTC(),
+		DedupePolicy:    DedupePolicy,
+		MethodNotes:     MethodNotes(excluded),
+	}
+}
+
+// Record returns the provenance in the net.openmeet.survey.results#provenance format
+func (p *Provenance) Record() map[string]interface{} {
+	record := map[string]interface{}{
+		"software":        p.Software,
+		"softwareVersion": p.SoftwareVersion,
+		"aggregatedAt":    p.AggregatedAt.Format(time.RFC3339),
+		"dedupePolicy":    p.DedupePolicy,
+		"methodNotes":     p.MethodNotes,
+	}
+	if p.AppViewDID != "" {
+		record["appView"] = p.AppViewDID
+	}
+	return record
+}
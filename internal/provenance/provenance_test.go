@@ -0,0 +1,51 @@
+package provenance
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("APPVIEW_DID", "")
+	t.Setenv("SERVER_HOST", "https://survey.example.com")
+	assert.Equal(t, "did:web:survey.example.com", ConfigFromEnv().AppViewDID)
+
+	t.Setenv("SERVER_HOST", "http://localhost:8080")
+	assert.Equal(t, "did:web:localhost%3A8080", ConfigFromEnv().AppViewDID)
+
+	t.Setenv("SERVER_HOST", "")
+	assert.Equal(t, "", ConfigFromEnv().AppViewDID)
+
+	t.Setenv("APPVIEW_DID", "did:plc:appview")
+	assert.Equal(t, "did:plc:appview", ConfigFromEnv().AppViewDID)
+}
+
+func TestRecord(t *testing.T) {
+	aggregatedAt := time.Date(2026, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+	p := New(Config{AppViewDID: "did:web:survey.example.com"}, aggregatedAt, Exclusions{})
+
+	record := p.Record()
+	assert.Equal(t, "did:web:survey.example.com", record["appView"])
+	assert.Equal(t, Software, record["software"])
+	assert.Equal(t, Version, record["softwareVersion"])
+	assert.Equal(t, "2026-03-01T11:30:00Z", record["aggregatedAt"])
+	assert.Equal(t, DedupePolicy, record["dedupePolicy"])
+	assert.Equal(t, baseMethodNotes, record["methodNotes"])
+
+	// AppView is omitted when unknown
+	assert.NotContains(t, New(Config{}, aggregatedAt, Exclusions{}).Record(), "appView")
+}
+
+func TestMethodNotes(t *testing.T) {
+	assert.Equal(t, baseMethodNotes, MethodNotes(Exclusions{}))
+
+	notes := MethodNotes(Exclusions{Duplicates: 1, Spam: 3})
+	assert.True(t, strings.HasPrefix(notes, baseMethodNotes))
+	assert.Contains(t, notes, "1 response excluded by the author as suspected duplicates.")
+	assert.Contains(t, notes, "3 responses at or above the survey's spam threshold excluded.")
+
+	assert.NotContains(t, MethodNotes(Exclusions{Spam: 2}), "duplicates")
+}
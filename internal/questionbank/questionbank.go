@@ -0,0 +1,59 @@
+// Package questionbank keeps the personal question banks of logged-in users.
+// A user saves a question, with its options, once and inserts it into the
+// definitions of new surveys from the create page or the API.
+package questionbank
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Limits
+const (
+	MaxQuestions    = 500 // Questions an owner can save
+	DefaultLimit    = 50  // Questions listed when no limit is given
+	MaxLimit        = 200 // Questions listed at most
+	MaxSearchLength = 100 // Length of a search, in bytes
+)
+
+// ErrFull is returned when an owner's bank has no room for another question
+var ErrFull = fmt.Errorf("question bank is full: at most %d questions can be saved", MaxQuestions)
+
+// Question is a question saved to a bank
+type Question struct {
+	ID        uuid.UUID       `json:"id"`
+	OwnerDID  string          `json:"-"`
+	Question  models.Question `json:"question"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// Store persists question banks
+type Store interface {
+	CreateBankQuestion(ctx context.Context, q *Question) error
+	// ListBankQuestions returns an owner's questions whose text or options
+	// contain search (all of them if it is empty), most recently saved first
+	ListBankQuestions(ctx context.Context, ownerDID, search string, limit int) ([]*Question, error)
+	CountBankQuestions(ctx context.Context, ownerDID string) (int, error)
+	// DeleteBankQuestion returns sql.ErrNoRows if the owner has no such question
+	DeleteBankQuestion(ctx context.Context, id uuid.UUID, ownerDID string) error
+}
+
+// New validates a question as a survey definition would and returns it ready
+// to save, sanitized like the questions of definitions
+func New(ownerDID string, q models.Question, now time.Time) (*Question, error) {
+	def := models.SurveyDefinition{Questions: []models.Question{q}}
+	if err := def.ValidateDefinition(); err != nil {
+		return nil, err
+	}
+
+	return &Question{
+		ID:        uuid.New(),
+		OwnerDID:  ownerDID,
+		Question:  def.Questions[0],
+		CreatedAt: now,
+	}, nil
+}
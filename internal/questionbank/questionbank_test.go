@@ -0,0 +1,40 @@
+package questionbank
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	now := time.Now()
+	q, err := New("did:plc:alice", models.Question{
+		ID:      "lunch",
+		Text:    "  Where should we eat?<script>alert(1)</script>  ",
+		Type:    models.QuestionTypeSingle,
+		Options: []models.Option{{ID: "pizza", Text: "Pizza"}, {ID: "salad", Text: "Salad"}},
+	}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "did:plc:alice", q.OwnerDID)
+	assert.Equal(t, now, q.CreatedAt)
+	assert.Equal(t, "Where should we eat?", q.Question.Text, "sanitized like definitions")
+	assert.Len(t, q.Question.Options, 2)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := map[string]models.Question{
+		"no ID":        {Text: "Why?", Type: models.QuestionTypeText},
+		"no text":      {ID: "q1", Type: models.QuestionTypeText},
+		"one option":   {ID: "q1", Text: "Pick", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "a", Text: "A"}}},
+		"unknown type": {ID: "q1", Text: "Why?", Type: "essay"},
+	}
+	for name, question := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New("did:plc:alice", question, time.Now())
+			assert.Error(t, err)
+		})
+	}
+}
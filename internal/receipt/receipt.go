@@ -0,0 +1,92 @@
+// Package receipt issues and verifies vote receipts. A receipt is a token
+// signed with HMAC-SHA256 over the response ID, survey ID, and submission time,
+// so voters can later prove that this server accepted their response.
+package receipt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// payloadSize is the size of the signed payload: two UUIDs and a Unix timestamp
+const payloadSize = 16 + 16 + 8
+
+// ErrInvalidReceipt is returned for receipts that are malformed or not signed by this server
+var ErrInvalidReceipt = errors.New("invalid receipt")
+
+// Receipt identifies a submitted response
+type Receipt struct {
+	ResponseID  uuid.UUID
+	SurveyID    uuid.UUID
+	SubmittedAt time.Time // Truncated to the second
+}
+
+// Config holds the receipt signing key
+type Config struct {
+	Secret string
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - RECEIPT_SECRET: key used to sign vote receipts (receipts are disabled if empty)
+func ConfigFromEnv() Config {
+	return Config{Secret: os.Getenv("RECEIPT_SECRET")}
+}
+
+// Signer signs and verifies receipts
+type Signer struct {
+	secret []byte
+}
+
+// NewFromConfig creates a signer, or returns nil if no secret is configured
+func NewFromConfig(config Config) *Signer {
+	if config.Secret == "" {
+		return nil
+	}
+	return &Signer{secret: []byte(config.Secret)}
+}
+
+// Enabled reports whether receipts are issued. A nil signer is disabled.
+func (s *Signer) Enabled() bool {
+	return s != nil
+}
+
+// Sign returns the URL-safe token for a receipt
+func (s *Signer) Sign(r Receipt) string {
+	payload := make([]byte, 0, payloadSize+sha256.Size)
+	payload = append(payload, r.ResponseID[:]...)
+	payload = append(payload, r.SurveyID[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(r.SubmittedAt.Unix()))
+	return base64.RawURLEncoding.EncodeToString(append(payload, s.mac(payload)...))
+}
+
+// Verify checks a token's signature and returns the receipt it encodes
+func (s *Signer) Verify(token string) (*Receipt, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) != payloadSize+sha256.Size {
+		return nil, ErrInvalidReceipt
+	}
+
+	payload, sig := data[:payloadSize], data[payloadSize:]
+	if !hmac.Equal(sig, s.mac(payload)) {
+		return nil, ErrInvalidReceipt
+	}
+
+	r := &Receipt{SubmittedAt: time.Unix(int64(binary.BigEndian.Uint64(payload[32:])), 0).UTC()}
+	copy(r.ResponseID[:], payload[:16])
+	copy(r.SurveyID[:], payload[16:32])
+	return r, nil
+}
+
+func (s *Signer) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
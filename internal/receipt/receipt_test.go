@@ -0,0 +1,69 @@
+package receipt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	s := NewFromConfig(Config{Secret: "test-secret"})
+	r := Receipt{
+		ResponseID:  uuid.New(),
+		SurveyID:    uuid.New(),
+		SubmittedAt: time.Date(2026, 3, 1, 12, 30, 15, 999, time.UTC),
+	}
+
+	token := s.Sign(r)
+	assert.NotContains(t, token, "/")
+	assert.NotContains(t, token, "+")
+
+	verified, err := s.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, r.ResponseID, verified.ResponseID)
+	assert.Equal(t, r.SurveyID, verified.SurveyID)
+	assert.Equal(t, r.SubmittedAt.Truncate(time.Second), verified.SubmittedAt)
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	s := NewFromConfig(Config{Secret: "test-secret"})
+	token := s.Sign(Receipt{ResponseID: uuid.New(), SurveyID: uuid.New(), SubmittedAt: time.Now()})
+
+	// Flip a character in the payload
+	tampered := []byte(token)
+	if tampered[0] == 'A' {
+		tampered[0] = 'B'
+	} else {
+		tampered[0] = 'A'
+	}
+
+	other := NewFromConfig(Config{Secret: "other-secret"})
+
+	for name, tc := range map[string]struct {
+		signer *Signer
+		token  string
+	}{
+		"tampered":     {s, string(tampered)},
+		"other secret": {other, token},
+		"truncated":    {s, token[:len(token)-4]},
+		"not base64":   {s, strings.Repeat("!", len(token))},
+		"empty":        {s, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := tc.signer.Verify(tc.token)
+			assert.ErrorIs(t, err, ErrInvalidReceipt)
+		})
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	assert.False(t, NewFromConfig(Config{}).Enabled())
+	assert.True(t, NewFromConfig(Config{Secret: "s"}).Enabled())
+
+	t.Setenv("RECEIPT_SECRET", "from-env")
+	assert.Equal(t, "from-env", ConfigFromEnv().Secret)
+}
@@ -0,0 +1,149 @@
+// Package redact masks email addresses, phone numbers, and profanity in free
+// text answers before they are shown in public results. Authors choose what
+// is masked per survey; stored responses, and so exports, keep the original text.
+package redact
+
+import (
+	"bufio"
+	"embed"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Masks replacing redacted email addresses and phone numbers
+const (
+	EmailMask = "[email]"
+	PhoneMask = "[phone]"
+)
+
+// DefaultLanguage is the language whose profanity is always masked
+const DefaultLanguage = "en"
+
+// Phone numbers have 9 to 15 digits, or 7 with a + country code, so dates
+// and year ranges (8 digits) are not taken for them
+const (
+	minPhoneDigits     = 9
+	minPlusPhoneDigits = 7
+	maxPhoneDigits     = 15
+)
+
+// profanityMask replaces all but the first letter of a masked word
+const profanityMask = "*"
+
+//go:embed words/*.txt
+var wordFiles embed.FS
+
+var (
+	emailRegex = regexp.MustCompile(`[\p{L}\p{N}._%+-]+@[\p{L}\p{N}-]+(?:\.[\p{L}\p{N}-]+)*\.\p{L}{2,}`)
+	phoneRegex = regexp.MustCompile(`\+?\(?\d[\d ().-]{5,}\d`)
+	wordRegex  = regexp.MustCompile(`[\p{L}\p{M}\p{N}]+`)
+)
+
+// Redactor masks what a survey's redaction settings select
+type Redactor struct {
+	emails bool
+	phones bool
+	words  map[string]bool // Lowercase profanity
+}
+
+// New creates a redactor for a survey's settings and language. Returns nil,
+// which redacts nothing, if nothing is selected.
+func New(r *models.Redaction, language string) *Redactor {
+	if !r.Enabled() {
+		return nil
+	}
+
+	redactor := &Redactor{emails: r.Emails, phones: r.Phones}
+	if r.Profanity {
+		redactor.words = Words(language)
+	}
+	return redactor
+}
+
+// Redact returns the text with the selected content masked
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+
+	if r.emails {
+		text = emailRegex.ReplaceAllString(text, EmailMask)
+	}
+	if r.phones {
+		text = phoneRegex.ReplaceAllStringFunc(text, maskPhone)
+	}
+	if len(r.words) > 0 {
+		text = wordRegex.ReplaceAllStringFunc(text, func(word string) string {
+			if !r.words[strings.ToLower(word)] {
+				return word
+			}
+			return maskWord(word)
+		})
+	}
+	return text
+}
+
+// maskPhone masks a digit sequence if it has as many digits as a phone number
+func maskPhone(match string) string {
+	digits := 0
+	for _, c := range match {
+		if unicode.IsDigit(c) {
+			digits++
+		}
+	}
+
+	least := minPhoneDigits
+	if strings.HasPrefix(match, "+") {
+		least = minPlusPhoneDigits
+	}
+	if digits < least || digits > maxPhoneDigits {
+		return match
+	}
+	return PhoneMask
+}
+
+// maskWord keeps the first letter of a word and masks the rest, so answers
+// stay readable
+func maskWord(word string) string {
+	_, size := utf8.DecodeRuneInString(word)
+	return word[:size] + strings.Repeat(profanityMask, utf8.RuneCountInString(word)-1)
+}
+
+// Words returns the profanity of a language, matched on its base language,
+// together with English profanity, which is common in answers in any language
+func Words(language string) map[string]bool {
+	words := make(map[string]bool)
+	readWords(words, DefaultLanguage)
+
+	base := strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+	if base != "" && base != DefaultLanguage {
+		readWords(words, base)
+	}
+	return words
+}
+
+// readWords adds the words of a language's list, one per line (# starts a
+// comment). Languages without a list add nothing.
+func readWords(words map[string]bool, language string) {
+	f, err := wordFiles.Open("words/" + language + ".txt")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words[strings.ToLower(word)] = true
+	}
+}
@@ -0,0 +1,77 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	all := New(&models.Redaction{Emails: true, Phones: true, Profanity: true}, "en")
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"email", "Write to jane.doe+survey@example.co.uk please", "Write to [email] please"},
+		{"phone", "Call me on (555) 123-4567 tonight", "Call me on [phone] tonight"},
+		{"international phone", "My number is +44 20 7946 0958.", "My number is [phone]."},
+		{"short number with country code", "+41 123 45", "[phone]"},
+		{"dates are kept", "Free on 2024-03-01 or 1990-2000", "Free on 2024-03-01 or 1990-2000"},
+		{"small numbers are kept", "We need 12 chairs and 150 cups", "We need 12 chairs and 150 cups"},
+		{"profanity", "This is SHIT, really shit.", "This is S***, really s***."},
+		{"whole words only", "Scunthorpe shitake assessment", "Scunthorpe shitake assessment"},
+		{"clean text", "Great session, thanks!", "Great session, thanks!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, all.Redact(tt.text))
+		})
+	}
+}
+
+func TestRedactor_Selection(t *testing.T) {
+	text := "Mail bob@example.com or call 0151 234 5678, damn crap"
+
+	emails := New(&models.Redaction{Emails: true}, "")
+	assert.Equal(t, "Mail [email] or call 0151 234 5678, damn crap", emails.Redact(text))
+
+	phones := New(&models.Redaction{Phones: true}, "")
+	assert.Equal(t, "Mail bob@example.com or call [phone], damn crap", phones.Redact(text))
+
+	profanity := New(&models.Redaction{Profanity: true}, "")
+	assert.Equal(t, "Mail bob@example.com or call 0151 234 5678, damn c***", profanity.Redact(text))
+
+	// Nothing selected redacts nothing
+	assert.Nil(t, New(nil, "en"))
+	assert.Nil(t, New(&models.Redaction{}, "en"))
+	var none *Redactor
+	assert.Equal(t, text, none.Redact(text))
+}
+
+func TestRedactor_Locale(t *testing.T) {
+	redaction := &models.Redaction{Profanity: true}
+
+	// The survey's language is matched on its base language, with English
+	german := New(redaction, "de-AT")
+	assert.Equal(t, "So eine S****** und s***", german.Redact("So eine Scheiße und shit"))
+
+	// Other languages' words are kept
+	english := New(redaction, "en")
+	assert.Equal(t, "So eine Scheiße und s***", english.Redact("So eine Scheiße und shit"))
+
+	// Languages without a list still mask English
+	assert.Equal(t, "s***", New(redaction, "ja").Redact("shit"))
+}
+
+func TestWords(t *testing.T) {
+	words := Words("es")
+	assert.True(t, words["mierda"])
+	assert.True(t, words["fuck"])
+	assert.False(t, words["scheiße"])
+	for word := range words {
+		assert.NotContains(t, word, "#")
+	}
+}
@@ -0,0 +1,173 @@
+// Package reminder reminds a survey's invitees to respond before it closes.
+// Authors of surveys shared with a known audience invite people by DID or
+// handle, or all the members of the survey's organization, and choose how
+// many hours before the survey ends to remind them. A worker marks the
+// invitations whose invitees responded, then sends the rest a direct message
+// from the service's Bluesky account once the reminder is due, retrying
+// failures.
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Invitation statuses
+const (
+	StatusInvited  = "invited"  // Not reminded yet
+	StatusReminded = "reminded" // The reminder was sent
+	StatusFailed   = "failed"   // Gave up after MaxAttempts
+)
+
+// Limits
+const (
+	MaxInvitations = 1000             // Invitees of a survey
+	MaxHoursBefore = 7 * 24           // Earliest reminder before a survey ends
+	MaxAttempts    = 5                // Deliveries tried before a reminder fails
+	RetryDelay     = 10 * time.Minute // Multiplied by the attempts made
+	ClaimLease     = 5 * time.Minute  // Claimed reminders are not claimed again for this long
+	batchSize      = 50               // Due reminders a replica claims per run
+	maxTextRunes   = 300              // Bluesky's limit for messages
+)
+
+// Invitation is an invitee of a survey and whether they responded or were reminded
+type Invitation struct {
+	SurveyID      uuid.UUID  `json:"-"`
+	DID           string     `json:"did"`
+	InvitedBy     string     `json:"invitedBy"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	Error         *string    `json:"error,omitempty"` // Of the last failed attempt
+	NextAttemptAt time.Time  `json:"-"`
+	RespondedAt   *time.Time `json:"respondedAt,omitempty"`
+	RemindedAt    *time.Time `json:"remindedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// Store persists invitations and the reminder schedules of surveys
+type Store interface {
+	// AddInvitations invites DIDs to a survey, skipping those already
+	// invited, and returns how many it added
+	AddInvitations(ctx context.Context, surveyID uuid.UUID, dids []string, by string) (int64, error)
+	// RemoveInvitation returns sql.ErrNoRows if the DID is not invited
+	RemoveInvitation(ctx context.Context, surveyID uuid.UUID, did string) error
+	// ListInvitations returns a survey's invitations, oldest first
+	ListInvitations(ctx context.Context, surveyID uuid.UUID) ([]*Invitation, error)
+	// GetReminderSchedule returns the hours before a survey ends its invitees
+	// are reminded, or 0 if they are not
+	GetReminderSchedule(ctx context.Context, surveyID uuid.UUID) (int, error)
+	// SetReminderSchedule sets the hours before a survey ends its invitees are
+	// reminded, or stops reminders if 0
+	SetReminderSchedule(ctx context.Context, surveyID uuid.UUID, hoursBefore int, by string) error
+	// MarkRespondedInvitations marks the invitations whose invitees responded
+	// and returns how many it marked
+	MarkRespondedInvitations(ctx context.Context) (int64, error)
+	// ClaimDueReminders returns up to limit invitations of open surveys whose
+	// reminder is due at now and whose invitees have not responded, and
+	// delays their next attempt by ClaimLease, so no two replicas send the
+	// same one
+	ClaimDueReminders(ctx context.Context, now time.Time, limit int) ([]*Invitation, error)
+	// UpdateInvitation saves the outcome of a reminder attempt
+	UpdateInvitation(ctx context.Context, inv *Invitation) error
+}
+
+// Deliverer sends an invitee the reminder of their invitation
+type Deliverer func(ctx context.Context, inv *Invitation) error
+
+// ValidateHoursBefore checks the hours of a reminder schedule, or 0 for none
+func ValidateHoursBefore(hours int) error {
+	if hours < 0 || hours > MaxHoursBefore {
+		return fmt.Errorf("hoursBefore must be between 1 and %d, or 0 to stop reminders", MaxHoursBefore)
+	}
+	return nil
+}
+
+// Text returns the text of a reminder to respond to a survey closing at
+// endsAt, linking to it, with the title shortened to fit
+func Text(title, link string, endsAt, now time.Time) string {
+	format := "Reminder: the survey “%s” closes in " + remaining(endsAt.Sub(now)) + ". Respond here: %s"
+
+	runes := []rune(title)
+	room := maxTextRunes - utf8.RuneCountInString(fmt.Sprintf(format, "", link))
+	if len(runes) > room {
+		runes = append(runes[:max(room-1, 0)], '…')
+	}
+	return fmt.Sprintf(format, string(runes), link)
+}
+
+// remaining describes the time left before a survey closes, rounded down
+func remaining(d time.Duration) string {
+	hours := int(d / time.Hour)
+	switch {
+	case hours < 1:
+		return "less than an hour"
+	case hours == 1:
+		return "1 hour"
+	case hours < 48:
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return fmt.Sprintf("%d days", hours/24)
+}
+
+// Attempted records the outcome of a reminder attempt at now: sent, retried
+// later, or failed after MaxAttempts
+func (inv *Invitation) Attempted(err error, now time.Time) {
+	inv.Attempts++
+	if err == nil {
+		inv.Status, inv.Error, inv.RemindedAt = StatusReminded, nil, &now
+		return
+	}
+
+	msg := err.Error()
+	inv.Error = &msg
+	if inv.Attempts >= MaxAttempts {
+		inv.Status = StatusFailed
+		return
+	}
+	inv.NextAttemptAt = now.Add(time.Duration(inv.Attempts) * RetryDelay)
+}
+
+// Run marks the invitations answered by now and sends the reminders due
+func Run(ctx context.Context, store Store, deliver Deliverer, now time.Time) {
+	if _, err := store.MarkRespondedInvitations(ctx); err != nil {
+		log.Printf("Error marking responded invitations: %v", err)
+	}
+
+	invitations, err := store.ClaimDueReminders(ctx, now, batchSize)
+	if err != nil {
+		log.Printf("Error claiming due reminders: %v", err)
+		return
+	}
+
+	for _, inv := range invitations {
+		err := deliver(ctx, inv)
+		if err != nil {
+			log.Printf("Error reminding %s of survey %s: %v", inv.DID, inv.SurveyID, err)
+		}
+		inv.Attempted(err, now)
+		if err := store.UpdateInvitation(ctx, inv); err != nil {
+			log.Printf("Error saving reminder of %s for survey %s: %v", inv.DID, inv.SurveyID, err)
+		}
+	}
+}
+
+// StartWorker sends due reminders every interval until ctx is cancelled
+func StartWorker(ctx context.Context, store Store, deliver Deliverer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		Run(ctx, store, deliver, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
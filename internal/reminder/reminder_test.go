@@ -0,0 +1,99 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateHoursBefore(t *testing.T) {
+	assert.NoError(t, ValidateHoursBefore(0))
+	assert.NoError(t, ValidateHoursBefore(24))
+	assert.NoError(t, ValidateHoursBefore(MaxHoursBefore))
+	assert.Error(t, ValidateHoursBefore(-1))
+	assert.Error(t, ValidateHoursBefore(MaxHoursBefore+1))
+}
+
+func TestText(t *testing.T) {
+	now := time.Now()
+	link := "https://survey.example/s/lunch"
+	assert.Equal(t, "Reminder: the survey “Lunch?” closes in 24 hours. Respond here: "+link,
+		Text("Lunch?", link, now.Add(24*time.Hour+time.Minute), now))
+	assert.Contains(t, Text("Lunch?", link, now.Add(30*time.Minute), now), "closes in less than an hour.")
+	assert.Contains(t, Text("Lunch?", link, now.Add(time.Hour), now), "closes in 1 hour.")
+	assert.Contains(t, Text("Lunch?", link, now.Add(72*time.Hour), now), "closes in 3 days.")
+
+	text := Text(strings.Repeat("long ", 100), link, now.Add(time.Hour), now)
+	assert.Equal(t, maxTextRunes, utf8.RuneCountInString(text))
+	assert.True(t, strings.HasSuffix(text, "…” closes in 1 hour. Respond here: "+link))
+}
+
+func TestAttempted(t *testing.T) {
+	now := time.Now()
+	inv := &Invitation{Status: StatusInvited}
+
+	inv.Attempted(errors.New("recipient does not accept messages"), now)
+	assert.Equal(t, StatusInvited, inv.Status)
+	assert.Equal(t, now.Add(RetryDelay), inv.NextAttemptAt)
+	require.NotNil(t, inv.Error)
+
+	inv.Attempted(nil, now)
+	assert.Equal(t, StatusReminded, inv.Status)
+	assert.Nil(t, inv.Error)
+	require.NotNil(t, inv.RemindedAt)
+	assert.Equal(t, 2, inv.Attempts)
+
+	failing := &Invitation{Status: StatusInvited, Attempts: MaxAttempts - 1}
+	failing.Attempted(errors.New("blocked"), now)
+	assert.Equal(t, StatusFailed, failing.Status)
+}
+
+// mockStore hands out its due reminders and keeps their outcomes
+type mockStore struct {
+	Store
+	due     []*Invitation
+	marked  int
+	updated []*Invitation
+}
+
+func (m *mockStore) MarkRespondedInvitations(ctx context.Context) (int64, error) {
+	m.marked++
+	return 0, nil
+}
+
+func (m *mockStore) ClaimDueReminders(ctx context.Context, now time.Time, limit int) ([]*Invitation, error) {
+	due := m.due
+	m.due = nil
+	return due, nil
+}
+
+func (m *mockStore) UpdateInvitation(ctx context.Context, inv *Invitation) error {
+	m.updated = append(m.updated, inv)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	store := &mockStore{due: []*Invitation{
+		{DID: "did:plc:alice", Status: StatusInvited},
+		{DID: "did:plc:bob", Status: StatusInvited},
+	}}
+
+	Run(context.Background(), store, func(ctx context.Context, inv *Invitation) error {
+		if inv.DID == "did:plc:bob" {
+			return errors.New("recipient does not accept messages")
+		}
+		return nil
+	}, time.Now())
+
+	assert.Equal(t, 1, store.marked)
+	require.Len(t, store.updated, 2)
+	assert.Equal(t, StatusReminded, store.updated[0].Status)
+	assert.Equal(t, StatusInvited, store.updated[1].Status)
+	assert.Equal(t, 1, store.updated[1].Attempts)
+}
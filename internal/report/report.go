@@ -0,0 +1,145 @@
+// Package report lets visitors flag surveys as abusive. A survey reported by
+// enough distinct reporters is hidden from the public until an admin reviews
+// its reports, and either dismisses them or upholds them to keep it hidden.
+package report
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Reasons a survey can be reported for
+const (
+	ReasonSpam       = "spam"
+	ReasonHarassment = "harassment"
+	ReasonIllegal    = "illegal"
+	ReasonOther      = "other"
+)
+
+// Reasons lists the valid reasons, in the order forms show them
+var Reasons = []string{ReasonSpam, ReasonHarassment, ReasonIllegal, ReasonOther}
+
+// Review statuses of a report
+const (
+	StatusPending   = "pending"   // Awaiting review
+	StatusDismissed = "dismissed" // Reviewed, the survey is shown again
+	StatusUpheld    = "upheld"    // Reviewed, the survey stays hidden
+)
+
+// DefaultHideThreshold is how many distinct reporters hide a survey
+const DefaultHideThreshold = 3
+
+// MaxDetailsLength bounds the free-text details of a report, in characters
+const MaxDetailsLength = 1000
+
+// Report is a visitor's report of a survey
+type Report struct {
+	ID         uuid.UUID  `json:"id"`
+	SurveyID   uuid.UUID  `json:"surveyId"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details,omitempty"`
+	Reporter   string     `json:"-"` // See ReporterKey
+	Status     string     `json:"status"`
+	ReviewedBy *string    `json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// ReportedSurvey is a survey with pending reports, in the review queue
+type ReportedSurvey struct {
+	SurveyID uuid.UUID
+	Slug     string
+	Title    string
+	HiddenAt *time.Time
+	Reports  []*Report // Pending reports, newest first
+}
+
+// Store persists reports
+type Store interface {
+	// CreateReport saves a report unless its reporter already reported the
+	// survey, and hides the survey once threshold distinct reporters have
+	// pending reports of it. It returns whether the report was saved and
+	// whether this hid the survey.
+	CreateReport(ctx context.Context, r *Report, threshold int) (created, hid bool, err error)
+	// ListReportedSurveys returns the surveys with pending reports, hidden
+	// ones first
+	ListReportedSurveys(ctx context.Context) ([]*ReportedSurvey, error)
+	// ResolveReports marks the pending reports of a survey dismissed, showing
+	// the survey again, or upheld, keeping it hidden. It returns the survey's
+	// slug, or sql.ErrNoRows if it has no pending reports.
+	ResolveReports(ctx context.Context, surveyID uuid.UUID, status, reviewerDID string) (string, error)
+}
+
+// New validates a report of a survey and creates it pending
+func New(surveyID uuid.UUID, reason, details, reporter string) (*Report, error) {
+	if !ValidReason(reason) {
+		return nil, fmt.Errorf("reason must be one of %s", strings.Join(Reasons, ", "))
+	}
+	details = strings.TrimSpace(details)
+	if utf8.RuneCountInString(details) > MaxDetailsLength {
+		return nil, fmt.Errorf("details must be at most %d characters", MaxDetailsLength)
+	}
+
+	return &Report{
+		ID:        uuid.New(),
+		SurveyID:  surveyID,
+		Reason:    reason,
+		Details:   details,
+		Reporter:  reporter,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// ValidReason reports whether reason is one of Reasons
+func ValidReason(reason string) bool {
+	for _, r := range Reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// ReporterKey identifies a reporter by their DID if logged in, otherwise by
+// their IP address. It is hashed so reports do not store IP addresses.
+func ReporterKey(did, ip string) string {
+	key := "ip:" + ip
+	if did != "" {
+		key = "did:" + did
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Config configures reports
+type Config struct {
+	HideThreshold int // Distinct reporters that hide a survey pending review
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - REPORT_HIDE_THRESHOLD: distinct reporters that hide a survey (default: 3)
+func ConfigFromEnv() Config {
+	config := Config{HideThreshold: DefaultHideThreshold}
+
+	if v := os.Getenv("REPORT_HIDE_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			config.HideThreshold = n
+		} else {
+			log.Printf("Warning: Invalid REPORT_HIDE_THRESHOLD %q, using %d", v, DefaultHideThreshold)
+		}
+	}
+
+	return config
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	surveyID := uuid.New()
+
+	r, err := New(surveyID, ReasonSpam, "  buy now  ", "reporter")
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, r.Status)
+	assert.Equal(t, "buy now", r.Details)
+
+	_, err = New(surveyID, "boring", "", "reporter")
+	assert.Error(t, err)
+
+	_, err = New(surveyID, ReasonOther, strings.Repeat("x", MaxDetailsLength+1), "reporter")
+	assert.Error(t, err)
+}
+
+func TestReporterKey(t *testing.T) {
+	// Logged-in reporters are one reporter from any address
+	assert.Equal(t, ReporterKey("did:plc:a", "1.2.3.4"), ReporterKey("did:plc:a", "5.6.7.8"))
+	assert.NotEqual(t, ReporterKey("", "1.2.3.4"), ReporterKey("", "5.6.7.8"))
+	assert.NotContains(t, ReporterKey("", "1.2.3.4"), "1.2.3.4")
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("REPORT_HIDE_THRESHOLD", "5")
+	assert.Equal(t, 5, ConfigFromEnv().HideThreshold)
+
+	t.Setenv("REPORT_HIDE_THRESHOLD", "zero")
+	assert.Equal(t, DefaultHideThreshold, ConfigFromEnv().HideThreshold)
+}
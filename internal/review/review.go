@@ -0,0 +1,115 @@
+// Package review signs the answers a voter confirmed on the review step of
+// surveys with confirmBeforeSubmit. The review page carries the answers and a
+// token signed with HMAC-SHA256 over the survey, its version, a hash of the
+// answers, and an expiry; the final submit is only accepted if the answers
+// still match the token, so nothing is submitted that the voter did not review.
+package review
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// TTL is how long a reviewed response can be submitted
+const TTL = time.Hour
+
+// payloadSize is the size of the token payload: a Unix expiry timestamp
+const payloadSize = 8
+
+// Errors returned by Verify
+var (
+	ErrInvalidToken = errors.New("invalid review token")
+	ErrExpiredToken = errors.New("review token expired")
+)
+
+// Config holds the review signing key
+type Config struct {
+	Secret string
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - REVIEW_SECRET: key used to sign reviewed answers (a random per-process key is used if empty)
+func ConfigFromEnv() Config {
+	return Config{Secret: os.Getenv("REVIEW_SECRET")}
+}
+
+// Signer signs and verifies reviewed answers
+type Signer struct {
+	secret []byte
+}
+
+// NewFromConfig creates a signer. Without a secret it uses a random key, which
+// only works while a voter's review and submit reach the same process.
+func NewFromConfig(config Config) *Signer {
+	if config.Secret != "" {
+		return &Signer{secret: []byte(config.Secret)}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to generate review secret: %v", err))
+	}
+	return &Signer{secret: secret}
+}
+
+// Sign returns the token for answers reviewed now
+func (s *Signer) Sign(survey *models.Survey, answers map[string]models.Answer, now time.Time) (string, error) {
+	payload := binary.BigEndian.AppendUint64(nil, uint64(now.Add(TTL).Unix()))
+	sig, err := s.mac(payload, survey.ID, survey.Version, answers)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(payload, sig...)), nil
+}
+
+// Verify checks that a token was issued for these answers to the current
+// version of the survey and has not expired
+func (s *Signer) Verify(token string, survey *models.Survey, answers map[string]models.Answer, now time.Time) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) != payloadSize+sha256.Size {
+		return ErrInvalidToken
+	}
+
+	payload, sig := data[:payloadSize], data[payloadSize:]
+	expected, err := s.mac(payload, survey.ID, survey.Version, answers)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(sig, expected) {
+		return ErrInvalidToken
+	}
+
+	if now.Unix() > int64(binary.BigEndian.Uint64(payload)) {
+		return ErrExpiredToken
+	}
+	return nil
+}
+
+// mac signs the payload together with the survey and a hash of the answers.
+// Answers are hashed as JSON, which orders map keys.
+func (s *Signer) mac(payload []byte, surveyID uuid.UUID, version int, answers map[string]models.Answer) ([]byte, error) {
+	answersJSON, err := json.Marshal(answers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode answers: %w", err)
+	}
+	answersHash := sha256.Sum256(answersJSON)
+
+	h := hmac.New(sha256.New, s.secret)
+	h.Write(payload)
+	h.Write(surveyID[:])
+	h.Write(binary.BigEndian.AppendUint64(nil, uint64(version)))
+	h.Write(answersHash[:])
+	return h.Sum(nil), nil
+}
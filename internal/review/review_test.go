@@ -0,0 +1,84 @@
+package review
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAnswers() map[string]models.Answer {
+	return map[string]models.Answer{
+		"q1": {SelectedOptions: []string{"a"}},
+		"q2": {SelectedOptions: []string{"x", "y"}},
+		"q3": {Text: "Line one\nline two"},
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	s := NewFromConfig(Config{Secret: "secret"})
+	survey := &models.Survey{ID: uuid.New(), Version: 1}
+	now := time.Now()
+
+	token, err := s.Sign(survey, testAnswers(), now)
+	require.NoError(t, err)
+
+	assert.NoError(t, s.Verify(token, survey, testAnswers(), now.Add(time.Minute)))
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	s := NewFromConfig(Config{Secret: "secret"})
+	survey := &models.Survey{ID: uuid.New(), Version: 1}
+	now := time.Now()
+
+	token, err := s.Sign(survey, testAnswers(), now)
+	require.NoError(t, err)
+
+	t.Run("changed answers", func(t *testing.T) {
+		answers := testAnswers()
+		answers["q1"] = models.Answer{SelectedOptions: []string{"b"}}
+		assert.ErrorIs(t, s.Verify(token, survey, answers, now), ErrInvalidToken)
+	})
+
+	t.Run("other survey", func(t *testing.T) {
+		other := &models.Survey{ID: uuid.New(), Version: 1}
+		assert.ErrorIs(t, s.Verify(token, other, testAnswers(), now), ErrInvalidToken)
+	})
+
+	t.Run("survey changed since review", func(t *testing.T) {
+		updated := &models.Survey{ID: survey.ID, Version: 2}
+		assert.ErrorIs(t, s.Verify(token, updated, testAnswers(), now), ErrInvalidToken)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		other := NewFromConfig(Config{Secret: "other"})
+		assert.ErrorIs(t, other.Verify(token, survey, testAnswers(), now), ErrInvalidToken)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		assert.ErrorIs(t, s.Verify("", survey, testAnswers(), now), ErrInvalidToken)
+		assert.ErrorIs(t, s.Verify("not-a-token!", survey, testAnswers(), now), ErrInvalidToken)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		assert.ErrorIs(t, s.Verify(token, survey, testAnswers(), now.Add(TTL+time.Minute)), ErrExpiredToken)
+	})
+}
+
+func TestNewFromConfig_RandomSecret(t *testing.T) {
+	a := NewFromConfig(Config{})
+	b := NewFromConfig(Config{})
+	survey := &models.Survey{ID: uuid.New()}
+	now := time.Now()
+
+	token, err := a.Sign(survey, testAnswers(), now)
+	require.NoError(t, err)
+	assert.NoError(t, a.Verify(token, survey, testAnswers(), now))
+	assert.ErrorIs(t, b.Verify(token, survey, testAnswers(), now), ErrInvalidToken)
+
+	t.Setenv("REVIEW_SECRET", "from-env")
+	assert.Equal(t, "from-env", ConfigFromEnv().Secret)
+}
@@ -0,0 +1,142 @@
+package seed
+
+import "github.com/openmeet-team/survey/internal/models"
+
+// topic is a survey template
+type topic struct {
+	title       string
+	description string
+	questions   []topicQuestion
+}
+
+// topicQuestion is a question template; options are empty for text questions
+type topicQuestion struct {
+	text    string
+	kind    models.QuestionType
+	options []string
+}
+
+var topics = []topic{
+	{
+		title:       "Team Offsite Planning",
+		description: "Help us pick the dates and activities for this year's offsite.",
+		questions: []topicQuestion{
+			{"Which month works best for you?", models.QuestionTypeSingle, []string{"April", "May", "June", "September"}},
+			{"Which activities would you join?", models.QuestionTypeMulti, []string{"Hiking", "Cooking class", "Escape room", "Board games", "Kayaking"}},
+			{"Any dietary requirements or accessibility needs?", models.QuestionTypeText, nil},
+		},
+	},
+	{
+		title:       "Weekly Meetup Day",
+		description: "We're moving the community meetup. Which day suits you?",
+		questions: []topicQuestion{
+			{"What's your preferred day?", models.QuestionTypeSingle, []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}},
+			{"What time of day?", models.QuestionTypeSingle, []string{"Morning", "Lunchtime", "Evening"}},
+		},
+	},
+	{
+		title:       "Favorite Programming Language",
+		description: "A totally scientific poll of the community's language preferences.",
+		questions: []topicQuestion{
+			{"Which language do you enjoy most?", models.QuestionTypeSingle, []string{"Go", "Rust", "TypeScript", "Python", "Elixir", "Kotlin"}},
+			{"Which have you used in production?", models.QuestionTypeMulti, []string{"Go", "Rust", "TypeScript", "Python", "Elixir", "Kotlin"}},
+			{"Why do you like it?", models.QuestionTypeText, nil},
+		},
+	},
+	{
+		title:       "Conference Talk Feedback",
+		description: "Tell the speakers what worked and what didn't.",
+		questions: []topicQuestion{
+			{"How would you rate the talk overall?", models.QuestionTypeSingle, []string{"Excellent", "Good", "Average", "Poor"}},
+			{"Was the pace right?", models.QuestionTypeSingle, []string{"Too slow", "Just right", "Too fast"}},
+			{"What should the speaker improve?", models.QuestionTypeText, nil},
+		},
+	},
+	{
+		title:       "Neighborhood Park Improvements",
+		description: "The council has budget for one improvement this year. Have your say.",
+		questions: []topicQuestion{
+			{"Which improvement matters most?", models.QuestionTypeSingle, []string{"Playground", "Lighting", "Dog park", "Benches and shade", "Sports court"}},
+			{"How often do you visit the park?", models.QuestionTypeSingle, []string{"Daily", "Weekly", "Monthly", "Rarely"}},
+			{"Anything else we should know?", models.QuestionTypeText, nil},
+		},
+	},
+	{
+		title:       "Book Club Next Read",
+		description: "Vote for the next book and tell us which genres you'd like more of.",
+		questions: []topicQuestion{
+			{"Which book should we read next?", models.QuestionTypeSingle, []string{"The Left Hand of Darkness", "Piranesi", "Project Hail Mary", "The Remains of the Day"}},
+			{"Which genres should we read more of?", models.QuestionTypeMulti, []string{"Science fiction", "Fantasy", "Literary fiction", "Non-fiction", "Mystery"}},
+		},
+	},
+	{
+		title:       "Remote Work Preferences",
+		description: "Help shape our hybrid work policy.",
+		questions: []topicQuestion{
+			{"How many days a week would you like in the office?", models.QuestionTypeSingle, []string{"0", "1", "2", "3", "4", "5"}},
+			{"What would make office days more useful?", models.QuestionTypeMulti, []string{"Team lunches", "Quiet rooms", "Better video rooms", "Fixed team days", "Parking"}},
+			{"What is the biggest challenge of remote work for you?", models.QuestionTypeText, nil},
+		},
+	},
+	{
+		title:       "Open Source Funding",
+		description: "How should the foundation allocate its grants this quarter?",
+		questions: []topicQuestion{
+			{"Which area should get the largest grant?", models.QuestionTypeSingle, []string{"Security audits", "Documentation", "Accessibility", "Maintainer stipends"}},
+			{"Would you donate monthly to a shared fund?", models.QuestionTypeSingle, []string{"Yes", "No", "Maybe"}},
+		},
+	},
+	{
+		title:       "Coffee Machine Replacement",
+		description: "The old machine has finally died. Pick its successor.",
+		questions: []topicQuestion{
+			{"Which machine should we buy?", models.QuestionTypeSingle, []string{"Espresso machine", "Filter brewer", "Pod machine", "French presses"}},
+			{"Which milks should we stock?", models.QuestionTypeMulti, []string{"Dairy", "Oat", "Soy", "Almond"}},
+		},
+	},
+	{
+		title:       "Community Garden Crops",
+		description: "Plan what we plant in the shared beds this spring.",
+		questions: []topicQuestion{
+			{"Which crops should we plant?", models.QuestionTypeMulti, []string{"Tomatoes", "Beans", "Squash", "Herbs", "Lettuce", "Strawberries"}},
+			{"Can you help with watering?", models.QuestionTypeSingle, []string{"Weekly", "Occasionally", "Not this season"}},
+			{"Suggestions for the garden?", models.QuestionTypeText, nil},
+		},
+	},
+}
+
+var textAnswers = []string{
+	"Looking forward to it!",
+	"No strong preference, happy with whatever the group decides.",
+	"Please keep it accessible for people using wheelchairs.",
+	"Vegetarian options would be great.",
+	"It would help to have the schedule a few weeks in advance.",
+	"More of this, please.",
+	"I think the last one went really well, let's do the same again.",
+	"Could we try something different this time?",
+	"Evenings are hard for me because of childcare.",
+	"Great initiative, thanks for organizing.",
+	"Not sure yet, I'll decide closer to the date.",
+	"Shorter sessions with more breaks would help.",
+	"Please share the slides afterwards.",
+	"The sound was hard to hear at the back.",
+	"Happy to volunteer if you need help.",
+}
+
+var firstNames = []string{
+	"Alex", "Sam", "Jordan", "Taylor", "Morgan", "Riley", "Casey", "Jamie", "Avery", "Quinn",
+	"Noor", "Kenji", "Amara", "Mateo", "Ingrid", "Priya", "Tomasz", "Leila", "Chidi", "Sofia",
+}
+
+var lastNames = []string{
+	"Rivera", "Chen", "Okafor", "Novak", "Haddad", "Lindqvist", "Tanaka", "Moreau", "Kowalski", "Mensah",
+	"Silva", "Patel", "Nguyen", "Schmidt", "Ivanova", "Brennan", "Costa", "Yilmaz", "Park", "Adeyemi",
+}
+
+var userAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+	"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36",
+}
@@ -0,0 +1,295 @@
+// Package seed generates realistic demo data for development databases:
+// surveys by a set of authors with federated-looking AT URIs, and responses
+// with varied answer distributions, free-text answers, and a mix of ATProto
+// and guest voters. Output is deterministic for a given seed.
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/identity"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Config controls the amount and shape of generated data
+type Config struct {
+	Surveys        int     // Number of surveys
+	MaxResponses   int     // Responses per survey are drawn between 0 and this number
+	Authors        int     // Number of survey authors
+	FederatedRatio float64 // Share of surveys published as ATProto records, and of their voters with DIDs
+	Seed           int64   // Random seed
+}
+
+// Scales are Config presets by name
+var Scales = map[string]Config{
+	"small":  {Surveys: 10, MaxResponses: 50, Authors: 3, FederatedRatio: 0.7},
+	"medium": {Surveys: 50, MaxResponses: 500, Authors: 10, FederatedRatio: 0.7},
+	"large":  {Surveys: 500, MaxResponses: 5000, Authors: 50, FederatedRatio: 0.7},
+}
+
+// Distribution shapes of choice answers
+const (
+	distUniform   = "uniform"   // Options chosen about equally
+	distSkewed    = "skewed"    // One clear favorite
+	distPolarized = "polarized" // Two favorites splitting the vote
+)
+
+// Generator generates seed data
+type Generator struct {
+	config  Config
+	rng     *rand.Rand
+	now     time.Time
+	authors []*identity.Identity
+	slugs   map[string]bool
+}
+
+// New creates a generator. Data is dated relative to now.
+func New(config Config, now time.Time) *Generator {
+	g := &Generator{
+		config: config,
+		rng:    rand.New(rand.NewSource(config.Seed)),
+		now:    now,
+		slugs:  make(map[string]bool),
+	}
+	if g.config.Authors <= 0 {
+		g.config.Authors = 1
+	}
+
+	for i := 0; i < g.config.Authors; i++ {
+		first := firstNames[g.rng.Intn(len(firstNames))]
+		last := lastNames[g.rng.Intn(len(lastNames))]
+		g.authors = append(g.authors, &identity.Identity{
+			DID:         g.did(),
+			Handle:      fmt.Sprintf("%s%s%d.bsky.social", strings.ToLower(first), strings.ToLower(last[:1]), i+1),
+			DisplayName: first + " " + last,
+			FetchedAt:   now,
+		})
+	}
+
+	return g
+}
+
+// Authors returns the survey authors, for storing their identities
+func (g *Generator) Authors() []*identity.Identity {
+	return g.authors
+}
+
+// Survey generates the next survey. Federated surveys have an author and an
+// AT URI; the others are local-only, as created by guests on the web.
+func (g *Generator) Survey() *models.Survey {
+	topic := topics[g.rng.Intn(len(topics))]
+	createdAt := g.now.Add(-time.Duration(1+g.rng.Intn(90*24)) * time.Hour)
+
+	def := models.SurveyDefinition{
+		Anonymous: g.rng.Float64() < 0.3,
+		Language:  "en",
+	}
+	for i, q := range topic.questions {
+		question := models.Question{
+			ID:       fmt.Sprintf("q%d", i+1),
+			Text:     q.text,
+			Type:     q.kind,
+			Required: q.kind != models.QuestionTypeText || g.rng.Float64() < 0.2,
+		}
+		for j, text := range q.options {
+			question.Options = append(question.Options, models.Option{ID: fmt.Sprintf("opt%d", j+1), Text: text})
+		}
+		def.Questions = append(def.Questions, question)
+	}
+
+	description := topic.description
+	survey := &models.Survey{
+		ID:          g.uuid(),
+		Slug:        g.slug(topic.title),
+		Title:       topic.title,
+		Description: &description,
+		Definition:  def,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+
+	if g.rng.Float64() < g.config.FederatedRatio {
+		author := g.authors[g.rng.Intn(len(g.authors))]
+		uri := fmt.Sprintf("at://%s/net.openmeet.survey/%s", author.DID, g.tid())
+		cid := g.cid()
+		survey.URI = &uri
+		survey.CID = &cid
+		survey.AuthorDID = &author.DID
+	}
+
+	return survey
+}
+
+// Responses generates the responses of a survey. Each choice question gets a
+// random distribution shape, and responses thin out after the survey is created.
+func (g *Generator) Responses(survey *models.Survey) []*models.Response {
+	count := 0
+	if g.config.MaxResponses > 0 {
+		count = g.rng.Intn(g.config.MaxResponses + 1)
+	}
+
+	weights := make(map[string][]float64, len(survey.Definition.Questions))
+	for _, q := range survey.Definition.Questions {
+		if len(q.Options) > 0 {
+			weights[q.ID] = g.weights(len(q.Options))
+		}
+	}
+
+	age := g.now.Sub(survey.CreatedAt)
+	responses := make([]*models.Response, 0, count)
+	for i := 0; i < count; i++ {
+		// Exponentially distributed delay: most votes arrive soon after publishing
+		delay := time.Duration(g.rng.ExpFloat64() * float64(age) / 5)
+		if delay > age {
+			delay = time.Duration(g.rng.Int63n(int64(age) + 1))
+		}
+
+		response := &models.Response{
+			ID:        g.uuid(),
+			SurveyID:  survey.ID,
+			Answers:   g.answers(&survey.Definition, weights),
+			CreatedAt: survey.CreatedAt.Add(delay),
+		}
+
+		// Only responses to ATProto surveys can be ATProto records
+		if survey.URI != nil && g.rng.Float64() < g.config.FederatedRatio {
+			did := g.did()
+			uri := fmt.Sprintf("at://%s/net.openmeet.survey.response/%s", did, g.tid())
+			cid := g.cid()
+			response.VoterDID = &did
+			response.RecordURI = &uri
+			response.RecordCID = &cid
+		} else {
+			ip := fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff)
+			session := models.GenerateVoterSession(survey.ID, ip, userAgents[g.rng.Intn(len(userAgents))])
+			response.VoterSession = &session
+		}
+
+		responses = append(responses, response)
+	}
+
+	return responses
+}
+
+// answers draws an answer for every required question and most optional ones
+func (g *Generator) answers(def *models.SurveyDefinition, weights map[string][]float64) map[string]models.Answer {
+	answers := make(map[string]models.Answer)
+	for _, q := range def.Questions {
+		if !q.Required && g.rng.Float64() < 0.4 {
+			continue
+		}
+
+		switch q.Type {
+		case models.QuestionTypeSingle:
+			option := q.Options[g.pick(weights[q.ID])]
+			answers[q.ID] = models.Answer{SelectedOptions: []string{option.ID}}
+		case models.QuestionTypeMulti:
+			// Each option is chosen with a probability proportional to its weight
+			var selected []string
+			for i, option := range q.Options {
+				if g.rng.Float64() < 0.15+0.6*weights[q.ID][i]*float64(len(q.Options))/2 {
+					selected = append(selected, option.ID)
+				}
+			}
+			if len(selected) == 0 {
+				selected = []string{q.Options[g.pick(weights[q.ID])].ID}
+			}
+			answers[q.ID] = models.Answer{SelectedOptions: selected}
+		case models.QuestionTypeText:
+			answers[q.ID] = models.Answer{Text: textAnswers[g.rng.Intn(len(textAnswers))]}
+		}
+	}
+	return answers
+}
+
+// weights returns normalized option weights of a random distribution shape
+func (g *Generator) weights(n int) []float64 {
+	w := make([]float64, n)
+	shape := []string{distUniform, distSkewed, distPolarized}[g.rng.Intn(3)]
+	for i := range w {
+		w[i] = 0.5 + g.rng.Float64()
+	}
+
+	switch shape {
+	case distSkewed:
+		w[g.rng.Intn(n)] *= 4
+	case distPolarized:
+		a, b := g.rng.Intn(n), g.rng.Intn(n)
+		w[a] *= 3
+		w[b] *= 3
+	}
+
+	var sum float64
+	for _, v := range w {
+		sum += v
+	}
+	for i := range w {
+		w[i] /= sum
+	}
+	return w
+}
+
+// pick returns an index drawn according to normalized weights
+func (g *Generator) pick(weights []float64) int {
+	r := g.rng.Float64()
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return len(weights) - 1
+}
+
+// slug returns a unique slug for a title
+func (g *Generator) slug(title string) string {
+	base := strings.Trim(slugReplacer.Replace(strings.ToLower(title)), "-")
+	for {
+		slug := fmt.Sprintf("%s-%s", base, g.randomString(4, "abcdefghijklmnopqrstuvwxyz0123456789"))
+		if !g.slugs[slug] {
+			g.slugs[slug] = true
+			return slug
+		}
+	}
+}
+
+// did returns a random did:plc identifier
+func (g *Generator) did() string {
+	return "did:plc:" + g.randomString(24, base32Alphabet)
+}
+
+// tid returns a random record key in TID format
+func (g *Generator) tid() string {
+	return "3" + g.randomString(12, "234567abcdefghijklmnopqrstuvwxyz")
+}
+
+// cid returns a random CIDv1 string
+func (g *Generator) cid() string {
+	return "bafyrei" + g.randomString(52, base32Alphabet)
+}
+
+// uuid returns a random UUID drawn from the seeded source
+func (g *Generator) uuid() uuid.UUID {
+	id, err := uuid.NewRandomFromReader(g.rng)
+	if err != nil {
+		panic(err) // Reading from math/rand cannot fail
+	}
+	return id
+}
+
+// randomString returns n random characters of the alphabet
+func (g *Generator) randomString(n int, alphabet string) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[g.rng.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+const base32Alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+var slugReplacer = strings.NewReplacer(" ", "-", "?", "", "'", "", ",", "", ".", "", "&", "and", "/", "-")
@@ -0,0 +1,64 @@
+package seed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_ValidData(t *testing.T) {
+	now := time.Now()
+	g := New(Config{Surveys: 20, MaxResponses: 100, Authors: 4, FederatedRatio: 0.5, Seed: 42}, now)
+	require.Len(t, g.Authors(), 4)
+
+	slugs := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		survey := g.Survey()
+		require.NoError(t, survey.Definition.ValidateDefinition())
+		require.NoError(t, models.ValidateSlug(survey.Slug))
+		assert.False(t, slugs[survey.Slug], "slugs must be unique")
+		slugs[survey.Slug] = true
+		assert.Equal(t, survey.URI != nil, survey.AuthorDID != nil)
+
+		sessions := make(map[string]bool)
+		for _, r := range g.Responses(survey) {
+			require.NoError(t, models.ValidateAnswers(&survey.Definition, r.Answers))
+			assert.True(t, (r.VoterDID == nil) != (r.VoterSession == nil), "exactly one voter identity")
+			if r.VoterDID != nil {
+				assert.NotNil(t, survey.URI, "DID voters only answer ATProto surveys")
+				assert.NotNil(t, r.RecordURI)
+			} else {
+				assert.False(t, sessions[*r.VoterSession], "guest sessions must be unique per survey")
+				sessions[*r.VoterSession] = true
+			}
+			assert.False(t, r.CreatedAt.Before(survey.CreatedAt))
+			assert.False(t, r.CreatedAt.After(now))
+		}
+	}
+}
+
+func TestGenerator_Deterministic(t *testing.T) {
+	now := time.Now()
+	config := Config{Surveys: 3, MaxResponses: 20, Authors: 2, FederatedRatio: 0.7, Seed: 7}
+	a, b := New(config, now), New(config, now)
+
+	for i := 0; i < 3; i++ {
+		sa, sb := a.Survey(), b.Survey()
+		assert.Equal(t, sa, sb)
+		assert.Equal(t, a.Responses(sa), b.Responses(sb))
+	}
+}
+
+func TestGenerator_LocalOnly(t *testing.T) {
+	g := New(Config{MaxResponses: 10, Authors: 1, FederatedRatio: 0, Seed: 1}, time.Now())
+
+	survey := g.Survey()
+	assert.Nil(t, survey.URI)
+	for _, r := range g.Responses(survey) {
+		assert.Nil(t, r.VoterDID)
+		assert.NotNil(t, r.VoterSession)
+	}
+}
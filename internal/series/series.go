@@ -0,0 +1,366 @@
+// Package series links surveys of one author into a series, e.g. the waves of
+// a survey repeated every quarter. The results of a series combine those of
+// its surveys question by question, matching questions by ID, and logged-in
+// voters are tracked across waves by their DID so their answers can be
+// compared before and after. Voters of anonymous surveys are never tracked,
+// and guests, who have no DID, are counted once per response.
+package series
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Limits
+const (
+	MaxTitleLength  = 100 // Characters of a series' title
+	MaxSurveys      = 20  // Surveys of a series
+	MaxSeriesPerDID = 50  // Series a user can create
+)
+
+// Errors of Store methods caused by the request
+var (
+	ErrSlugTaken       = errors.New("a series with this slug already exists")
+	ErrAlreadyInSeries = errors.New("the survey is already in a series")
+)
+
+// Series is an ordered group of surveys of one owner
+type Series struct {
+	ID        uuid.UUID `json:"id"`
+	Slug      string    `json:"slug"`
+	Title     string    `json:"title"`
+	OwnerDID  string    `json:"ownerDid"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Store persists series and their surveys
+type Store interface {
+	// CreateSeries returns ErrSlugTaken if a series has the slug
+	CreateSeries(ctx context.Context, s *Series) error
+	// GetSeriesBySlug returns sql.ErrNoRows if there is no such series
+	GetSeriesBySlug(ctx context.Context, slug string) (*Series, error)
+	// ListSeriesByOwner returns the series of a user, newest first
+	ListSeriesByOwner(ctx context.Context, did string) ([]*Series, error)
+	// DeleteSeries returns sql.ErrNoRows if there is no such series. Its
+	// surveys are kept.
+	DeleteSeries(ctx context.Context, id uuid.UUID) error
+	// AddSeriesSurvey makes a survey the last wave of a series, returning
+	// ErrAlreadyInSeries if it is in a series
+	AddSeriesSurvey(ctx context.Context, seriesID, surveyID uuid.UUID, now time.Time) error
+	// RemoveSeriesSurvey returns sql.ErrNoRows if the survey is not in the series
+	RemoveSeriesSurvey(ctx context.Context, seriesID, surveyID uuid.UUID) error
+	// ListSeriesSurveyIDs returns the surveys of a series in the order they were added
+	ListSeriesSurveyIDs(ctx context.Context, seriesID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// New creates a series. Its slug is checked like a survey's.
+func New(slug, title, ownerDID string) (*Series, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if err := models.ValidateSlug(slug); err != nil {
+		return nil, err
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		return nil, fmt.Errorf("title must be at most %d characters", MaxTitleLength)
+	}
+
+	return &Series{
+		ID:        uuid.New(),
+		Slug:      slug,
+		Title:     title,
+		OwnerDID:  ownerDID,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Wave is a survey of a series with what its results are combined from
+type Wave struct {
+	Survey  *models.Survey
+	Results *models.SurveyResults
+	// Responses are those of logged-in voters; left empty for anonymous surveys
+	Responses []*models.Response
+}
+
+// Tracked reports whether the voters of the wave are tracked across waves
+func (w *Wave) Tracked() bool {
+	return !w.Survey.Definition.Anonymous
+}
+
+// Results are the combined results of the waves of a series
+type Results struct {
+	Waves       []WaveSummary      `json:"waves"`
+	TotalVotes  int                `json:"totalVotes"`  // Responses to all waves
+	Respondents int                `json:"respondents"` // Logged-in voters counted once, plus the other responses
+	Returning   int                `json:"returning"`   // Logged-in voters who answered more than one wave
+	Questions   []*QuestionResults `json:"questions"`
+	Transitions []*Transition      `json:"transitions"`
+}
+
+// WaveSummary describes a wave of combined results
+type WaveSummary struct {
+	Slug       string `json:"slug"`
+	Title      string `json:"title"`
+	TotalVotes int    `json:"totalVotes"`
+	Tracked    int    `json:"tracked"` // Logged-in voters tracked across waves
+	Anonymous  bool   `json:"anonymous,omitempty"`
+}
+
+// QuestionResults are the combined option counts of a choice question
+type QuestionResults struct {
+	QuestionID   string              `json:"questionId"`
+	Text         string              `json:"text"` // As asked in the latest wave
+	Type         models.QuestionType `json:"type"`
+	Options      []models.Option     `json:"options"` // Those of the latest wave, then removed ones
+	Respondents  int                 `json:"respondents"`
+	OptionCounts map[string]int      `json:"optionCounts"`
+	// Waves holds the counts of each wave, nil for waves without the question
+	Waves []*WaveCounts `json:"waves"`
+}
+
+// WaveCounts are the option counts of a question in one wave
+type WaveCounts struct {
+	Respondents  int            `json:"respondents"`
+	OptionCounts map[string]int `json:"optionCounts"`
+}
+
+// Transition counts how the voters who answered a single-choice question in
+// two consecutive tracked waves changed their answer
+type Transition struct {
+	QuestionID string `json:"questionId"`
+	From       string `json:"from"` // Slug of the earlier wave
+	To         string `json:"to"`
+	Voters     int    `json:"voters"`  // Voters who answered in both waves
+	Changed    int    `json:"changed"` // Of whom picked another option
+	// Counts holds the voters by earlier and then later option
+	Counts map[string]map[string]int `json:"counts"`
+}
+
+// Respondent is a logged-in voter of a series
+type Respondent struct {
+	DID string `json:"did"`
+	// Waves holds the voter's response to each wave, nil for waves they did
+	// not answer and for anonymous waves
+	Waves []*RespondentWave `json:"waves"`
+}
+
+// RespondentWave is a respondent's response to a wave
+type RespondentWave struct {
+	ResponseID uuid.UUID                `json:"responseId"`
+	Answers    map[string]models.Answer `json:"answers"`
+	CreatedAt  time.Time                `json:"createdAt"`
+}
+
+// Answered returns how many waves the respondent answered
+func (r *Respondent) Answered() int {
+	n := 0
+	for _, w := range r.Waves {
+		if w != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Track returns the logged-in voters of tracked waves, those who answered
+// the most waves first
+func Track(waves []*Wave) []*Respondent {
+	byDID := make(map[string]*Respondent)
+	var respondents []*Respondent
+	for i, w := range waves {
+		if !w.Tracked() {
+			continue
+		}
+		for _, r := range w.Responses {
+			if r.VoterDID == nil {
+				continue
+			}
+			respondent, ok := byDID[*r.VoterDID]
+			if !ok {
+				respondent = &Respondent{DID: *r.VoterDID, Waves: make([]*RespondentWave, len(waves))}
+				byDID[respondent.DID] = respondent
+				respondents = append(respondents, respondent)
+			}
+			respondent.Waves[i] = &RespondentWave{ResponseID: r.ID, Answers: r.Answers, CreatedAt: r.CreatedAt}
+		}
+	}
+
+	sort.SliceStable(respondents, func(i, j int) bool {
+		a, b := respondents[i].Answered(), respondents[j].Answered()
+		if a != b {
+			return a > b
+		}
+		return respondents[i].DID < respondents[j].DID
+	})
+	return respondents
+}
+
+// Combine combines the results of the waves of a series, in order
+func Combine(waves []*Wave) *Results {
+	respondents := Track(waves)
+	results := &Results{
+		Waves:       make([]WaveSummary, len(waves)),
+		Questions:   combineQuestions(waves),
+		Transitions: transitions(waves, respondents),
+	}
+
+	tracked := make([]int, len(waves))
+	for _, r := range respondents {
+		for i, w := range r.Waves {
+			if w != nil {
+				tracked[i]++
+			}
+		}
+		if r.Answered() > 1 {
+			results.Returning++
+		}
+	}
+	results.Respondents = len(respondents)
+	for i, w := range waves {
+		results.Waves[i] = WaveSummary{
+			Slug:       w.Survey.Slug,
+			Title:      w.Survey.Title,
+			TotalVotes: w.Results.TotalVotes,
+			Tracked:    tracked[i],
+			Anonymous:  !w.Tracked(),
+		}
+		results.TotalVotes += w.Results.TotalVotes
+		// Responses of guests and anonymous waves cannot be matched across waves
+		results.Respondents += max(w.Results.TotalVotes-tracked[i], 0)
+	}
+	return results
+}
+
+// combineQuestions sums the option counts of the choice questions of the
+// waves, in the order of their latest wave
+func combineQuestions(waves []*Wave) []*QuestionResults {
+	byID := make(map[string]*QuestionResults)
+	var questions []*QuestionResults
+	for i := len(waves) - 1; i >= 0; i-- {
+		for _, q := range waves[i].Survey.Definition.Questions {
+			if q.Type != models.QuestionTypeSingle && q.Type != models.QuestionTypeMulti {
+				continue
+			}
+			combined, ok := byID[q.ID]
+			if !ok {
+				combined = &QuestionResults{
+					QuestionID:   q.ID,
+					Text:         q.Text,
+					Type:         q.Type,
+					OptionCounts: make(map[string]int),
+					Waves:        make([]*WaveCounts, len(waves)),
+				}
+				byID[q.ID] = combined
+				questions = append(questions, combined)
+			}
+			combined.addOptions(q.Options)
+
+			counts := &WaveCounts{OptionCounts: make(map[string]int)}
+			if qr, ok := waves[i].Results.QuestionResults[q.ID]; ok {
+				counts.Respondents = qr.Respondents
+				for id, n := range qr.OptionCounts {
+					counts.OptionCounts[id] = n
+					combined.OptionCounts[id] += n
+				}
+			}
+			combined.Waves[i] = counts
+			combined.Respondents += counts.Respondents
+		}
+	}
+	return questions
+}
+
+// addOptions appends the options of an earlier wave the question lacks
+func (q *QuestionResults) addOptions(options []models.Option) {
+	for _, opt := range options {
+		known := false
+		for _, o := range q.Options {
+			if o.ID == opt.ID {
+				known = true
+				break
+			}
+		}
+		if !known {
+			q.Options = append(q.Options, opt)
+		}
+	}
+}
+
+// transitions counts the answer changes of single-choice questions between
+// consecutive tracked waves
+func transitions(waves []*Wave, respondents []*Respondent) []*Transition {
+	var out []*Transition
+	previous := -1
+	for i, w := range waves {
+		if !w.Tracked() {
+			continue
+		}
+		if previous >= 0 {
+			out = append(out, waveTransitions(waves, previous, i, respondents)...)
+		}
+		previous = i
+	}
+	return out
+}
+
+func waveTransitions(waves []*Wave, from, to int, respondents []*Respondent) []*Transition {
+	earlier := make(map[string]bool)
+	for _, q := range waves[from].Survey.Definition.Questions {
+		if q.Type == models.QuestionTypeSingle {
+			earlier[q.ID] = true
+		}
+	}
+
+	var out []*Transition
+	for _, q := range waves[to].Survey.Definition.Questions {
+		if q.Type != models.QuestionTypeSingle || !earlier[q.ID] {
+			continue
+		}
+		t := &Transition{
+			QuestionID: q.ID,
+			From:       waves[from].Survey.Slug,
+			To:         waves[to].Survey.Slug,
+			Counts:     make(map[string]map[string]int),
+		}
+		for _, r := range respondents {
+			before, after := singleAnswer(r.Waves[from], q.ID), singleAnswer(r.Waves[to], q.ID)
+			if before == "" || after == "" {
+				continue
+			}
+			if t.Counts[before] == nil {
+				t.Counts[before] = make(map[string]int)
+			}
+			t.Counts[before][after]++
+			t.Voters++
+			if before != after {
+				t.Changed++
+			}
+		}
+		if t.Voters > 0 {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// singleAnswer returns the option a response picked for a single-choice
+// question, or "" if it did not answer it
+func singleAnswer(w *RespondentWave, questionID string) string {
+	if w == nil {
+		return ""
+	}
+	if a := w.Answers[questionID]; len(a.SelectedOptions) == 1 {
+		return a.SelectedOptions[0]
+	}
+	return ""
+}
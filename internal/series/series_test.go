@@ -0,0 +1,144 @@
+package series
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	s, err := New(" Team-Pulse ", " Team pulse ", "did:plc:alice")
+	require.NoError(t, err)
+	assert.Equal(t, "team-pulse", s.Slug)
+	assert.Equal(t, "Team pulse", s.Title)
+	assert.Equal(t, "did:plc:alice", s.OwnerDID)
+	assert.NotEqual(t, uuid.Nil, s.ID)
+
+	_, err = New("x", "Team pulse", "did:plc:alice")
+	assert.Error(t, err)
+	_, err = New("team-pulse", " ", "did:plc:alice")
+	assert.Error(t, err)
+	_, err = New("team-pulse", strings.Repeat("a", MaxTitleLength+1), "did:plc:alice")
+	assert.Error(t, err)
+}
+
+// wave builds a wave asking "mood" (single) and "topics" (multi), answered
+// by voters with their mood; DIDs prefixed with "guest" vote as guests
+func wave(slug string, anonymous bool, moods map[string]string) *Wave {
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  slug,
+		Title: "Pulse " + slug,
+		Definition: models.SurveyDefinition{
+			Anonymous: anonymous,
+			Questions: []models.Question{
+				{ID: "mood", Text: "How are you?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "good", Text: "Good"}, {ID: "bad", Text: "Bad"}}},
+				{ID: "topics", Text: "Topics", Type: models.QuestionTypeMulti, Options: []models.Option{{ID: "pay"}, {ID: "tools"}}},
+				{ID: "notes", Text: "Anything else?", Type: models.QuestionTypeText},
+			},
+		},
+	}
+	w := &Wave{Survey: survey, Results: &models.SurveyResults{SurveyID: survey.ID, QuestionResults: map[string]*models.QuestionResult{}}}
+	mood := &models.QuestionResult{QuestionID: "mood", OptionCounts: map[string]int{}}
+	w.Results.QuestionResults["mood"] = mood
+	for did, option := range moods {
+		w.Results.TotalVotes++
+		mood.Respondents++
+		mood.OptionCounts[option]++
+		if anonymous || strings.HasPrefix(did, "guest") {
+			continue
+		}
+		voter := did
+		w.Responses = append(w.Responses, &models.Response{
+			ID:        uuid.New(),
+			SurveyID:  survey.ID,
+			VoterDID:  &voter,
+			Answers:   map[string]models.Answer{"mood": {SelectedOptions: []string{option}}},
+			CreatedAt: time.Now(),
+		})
+	}
+	return w
+}
+
+func TestTrack(t *testing.T) {
+	waves := []*Wave{
+		wave("q1", false, map[string]string{"did:plc:a": "good", "did:plc:b": "bad"}),
+		wave("q2", true, map[string]string{"did:plc:a": "good", "did:plc:c": "good"}),
+		wave("q3", false, map[string]string{"did:plc:b": "good", "did:plc:c": "bad", "guest1": "bad"}),
+	}
+
+	respondents := Track(waves)
+	require.Len(t, respondents, 3)
+	assert.Equal(t, "did:plc:b", respondents[0].DID, "returning voters come first")
+	assert.Equal(t, 2, respondents[0].Answered())
+	assert.Equal(t, []string{"bad"}, respondents[0].Waves[0].Answers["mood"].SelectedOptions)
+	assert.Nil(t, respondents[0].Waves[1])
+	assert.Equal(t, []string{"good"}, respondents[0].Waves[2].Answers["mood"].SelectedOptions)
+
+	// Voters of anonymous waves are not tracked
+	assert.Equal(t, "did:plc:a", respondents[1].DID)
+	assert.Equal(t, 1, respondents[1].Answered())
+	assert.Nil(t, respondents[1].Waves[1])
+}
+
+func TestCombine(t *testing.T) {
+	waves := []*Wave{
+		wave("q1", false, map[string]string{"did:plc:a": "good", "did:plc:b": "bad", "guest1": "good"}),
+		wave("q2", true, map[string]string{"did:plc:a": "bad"}),
+		wave("q3", false, map[string]string{"did:plc:a": "bad", "did:plc:b": "bad", "did:plc:c": "good"}),
+	}
+	// The latest wave renamed the question and dropped an option
+	waves[2].Survey.Definition.Questions[0].Text = "How do you feel?"
+	waves[2].Survey.Definition.Questions[0].Options = waves[2].Survey.Definition.Questions[0].Options[1:]
+
+	results := Combine(waves)
+	assert.Equal(t, 7, results.TotalVotes)
+	assert.Equal(t, 5, results.Respondents, "a, b, c, the guest, and the anonymous response")
+	assert.Equal(t, 2, results.Returning)
+	require.Len(t, results.Waves, 3)
+	assert.Equal(t, WaveSummary{Slug: "q1", Title: "Pulse q1", TotalVotes: 3, Tracked: 2}, results.Waves[0])
+	assert.True(t, results.Waves[1].Anonymous)
+	assert.Zero(t, results.Waves[1].Tracked)
+
+	require.Len(t, results.Questions, 2, "text questions are not combined")
+	mood := results.Questions[0]
+	assert.Equal(t, "mood", mood.QuestionID)
+	assert.Equal(t, "How do you feel?", mood.Text)
+	assert.Equal(t, []string{"bad", "good"}, []string{mood.Options[0].ID, mood.Options[1].ID})
+	assert.Equal(t, 7, mood.Respondents)
+	assert.Equal(t, map[string]int{"good": 3, "bad": 4}, mood.OptionCounts)
+	assert.Equal(t, map[string]int{"good": 2, "bad": 1}, mood.Waves[0].OptionCounts)
+	assert.Equal(t, 1, mood.Waves[1].Respondents)
+
+	// Only tracked waves are compared: q1 with q3, skipping the anonymous q2
+	require.Len(t, results.Transitions, 1)
+	tr := results.Transitions[0]
+	assert.Equal(t, "mood", tr.QuestionID)
+	assert.Equal(t, "q1", tr.From)
+	assert.Equal(t, "q3", tr.To)
+	assert.Equal(t, 2, tr.Voters)
+	assert.Equal(t, 1, tr.Changed)
+	assert.Equal(t, map[string]map[string]int{"good": {"bad": 1}, "bad": {"bad": 1}}, tr.Counts)
+}
+
+func TestCombine_QuestionsOfSomeWaves(t *testing.T) {
+	waves := []*Wave{
+		wave("q1", false, map[string]string{"did:plc:a": "good"}),
+		wave("q2", false, map[string]string{"did:plc:a": "bad"}),
+	}
+	waves[1].Survey.Definition.Questions = waves[1].Survey.Definition.Questions[1:]
+
+	results := Combine(waves)
+	require.Len(t, results.Questions, 2)
+	assert.Equal(t, "topics", results.Questions[0].QuestionID, "questions of the latest wave come first")
+	mood := results.Questions[1]
+	assert.Equal(t, "mood", mood.QuestionID)
+	assert.NotNil(t, mood.Waves[0])
+	assert.Nil(t, mood.Waves[1], "the latest wave does not ask it")
+	assert.Empty(t, results.Transitions)
+}
@@ -0,0 +1,170 @@
+// Package serviceauth verifies ATProto inter-service auth tokens: short-lived
+// JWTs a user's PDS signs with their repository key
+// (com.atproto.server.getServiceAuth), which native clients send to call an
+// AppView's XRPC methods as that user.
+package serviceauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/cache"
+	"github.com/openmeet-team/survey/internal/firehose"
+	"github.com/openmeet-team/survey/internal/identity"
+)
+
+const (
+	// keyTTL is how long a caller's signing key is cached
+	keyTTL = time.Hour
+
+	// keyCacheSize bounds the number of cached signing keys
+	keyCacheSize = 10000
+)
+
+// Errors of Verify, named like the reference implementation's XRPC errors
+var (
+	ErrBadToken     = errors.New("malformed service auth token")
+	ErrBadSignature = errors.New("service auth token signature does not verify")
+	ErrExpired      = errors.New("service auth token has expired")
+	ErrBadAudience  = errors.New("service auth token is for another service")
+	ErrBadMethod    = errors.New("service auth token is for another method")
+)
+
+// header is the JOSE header of a token
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// claims are the claims of a token the service checks
+type claims struct {
+	Iss string `json:"iss"` // Caller DID, optionally with a service fragment
+	Aud string `json:"aud"` // Service DID, optionally with a service fragment
+	Exp int64  `json:"exp"`
+	Lxm string `json:"lxm"` // NSID of the method the token may call
+}
+
+// Verifier verifies the tokens addressed to a service, against the signing
+// keys of the callers' DID documents
+type Verifier struct {
+	serviceDID string
+	plcURL     string
+	client     *http.Client
+	keys       *cache.Memory
+	now        func() time.Time
+}
+
+// NewVerifier creates a verifier of the tokens addressed to serviceDID,
+// caching callers' keys in memory
+func NewVerifier(serviceDID string) *Verifier {
+	return &Verifier{
+		serviceDID: serviceDID,
+		plcURL:     identity.DefaultPLCURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		keys:       cache.NewMemory(keyCacheSize),
+		now:        time.Now,
+	}
+}
+
+// Verify checks a token from an "Authorization: Bearer" header of a call to
+// method (an NSID) and returns the caller's DID. Tokens must name the method
+// in lxm: a token a PDS issues for one method can't be replayed on another.
+func (v *Verifier) Verify(ctx context.Context, token, method string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("%w: not a JWT", ErrBadToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return "", fmt.Errorf("%w: header: %v", ErrBadToken, err)
+	}
+	if h.Alg != "ES256K" && h.Alg != "ES256" {
+		return "", fmt.Errorf("%w: unsupported algorithm %q", ErrBadToken, h.Alg)
+	}
+	switch h.Typ {
+	case "at+jwt", "refresh+jwt", "dpop+jwt":
+		// Session tokens of a PDS are not for other services
+		return "", fmt.Errorf("%w: %s is not a service auth token", ErrBadToken, h.Typ)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return "", fmt.Errorf("%w: claims: %v", ErrBadToken, err)
+	}
+	if c.Iss == "" || c.Aud == "" || c.Exp == 0 {
+		return "", fmt.Errorf("%w: missing iss, aud, or exp", ErrBadToken)
+	}
+	if v.now().Unix() > c.Exp {
+		return "", ErrExpired
+	}
+	if aud, _, _ := strings.Cut(c.Aud, "#"); aud != v.serviceDID {
+		return "", ErrBadAudience
+	}
+	if c.Lxm != method {
+		return "", ErrBadMethod
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("%w: signature: %v", ErrBadToken, err)
+	}
+	did, _, _ := strings.Cut(c.Iss, "#")
+	signed := []byte(parts[0] + "." + parts[1])
+
+	// A cached key that does not verify the token is fetched again, as the
+	// caller may have rotated its key
+	key, cached, err := v.key(ctx, did, false)
+	if err != nil {
+		return "", err
+	}
+	if !key.VerifyMalleable(signed, sig) && cached {
+		if key, _, err = v.key(ctx, did, true); err != nil {
+			return "", err
+		}
+	}
+	if !key.VerifyMalleable(signed, sig) {
+		return "", ErrBadSignature
+	}
+	return did, nil
+}
+
+// key returns the signing key of a DID and whether it came from the cache
+func (v *Verifier) key(ctx context.Context, did string, refresh bool) (*firehose.PublicKey, bool, error) {
+	if !refresh {
+		if multibase, ok, _ := v.keys.Get(ctx, did); ok {
+			key, err := firehose.ParsePublicKey(string(multibase))
+			return key, true, err
+		}
+	}
+
+	doc, err := identity.FetchDocument(ctx, v.client, v.plcURL, did)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: resolving issuer: %v", ErrBadToken, err)
+	}
+	multibase := doc.SigningKey()
+	if multibase == "" {
+		return nil, false, fmt.Errorf("%w: DID document of %s has no signing key", ErrBadToken, did)
+	}
+	key, err := firehose.ParsePublicKey(multibase)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: signing key of %s: %v", ErrBadToken, did, err)
+	}
+	v.keys.Set(ctx, did, []byte(multibase), keyTTL)
+	return key, false, nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,162 @@
+package serviceauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testService = "did:web:survey.example.com"
+	testCaller  = "did:plc:caller"
+	testMethod  = "net.openmeet.survey.getResponses"
+)
+
+// base58 encodes b with the bitcoin alphabet
+func base58(b []byte) string {
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	n := new(big.Int).SetBytes(b)
+	var out []byte
+	for mod := new(big.Int); n.Sign() > 0; {
+		n.DivMod(n, big.NewInt(58), mod)
+		out = append([]byte{alphabet[mod.Int64()]}, out...)
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append([]byte{'1'}, out...)
+	}
+	return string(out)
+}
+
+// newKey generates a P-256 key and returns it with its multibase form
+func newKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	compressed := elliptic.MarshalCompressed(elliptic.P256(), key.X, key.Y)
+	return key, "z" + base58(append([]byte{0x80, 0x24}, compressed...))
+}
+
+// signToken signs a token with the header and claims
+func signToken(t *testing.T, key *ecdsa.PrivateKey, header, claims map[string]any) string {
+	t.Helper()
+	segment := func(v any) string {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := segment(header) + "." + segment(claims)
+	hash := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	require.NoError(t, err)
+	sig := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// testDirectory serves the DID document of testCaller with the current key,
+// counting fetches
+type testDirectory struct {
+	key     atomic.Value
+	fetches atomic.Int32
+}
+
+func (d *testDirectory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/"+testCaller {
+		http.NotFound(w, r)
+		return
+	}
+	d.fetches.Add(1)
+	json.NewEncoder(w).Encode(map[string]any{
+		"id": testCaller,
+		"verificationMethod": []map[string]string{
+			{"id": testCaller + "#atproto", "publicKeyMultibase": d.key.Load().(string)},
+		},
+	})
+}
+
+func TestVerify(t *testing.T) {
+	key, multibase := newKey(t)
+	directory := &testDirectory{}
+	directory.key.Store(multibase)
+	server := httptest.NewServer(directory)
+	defer server.Close()
+
+	now := time.Unix(1_700_000_000, 0)
+	v := NewVerifier(testService)
+	v.plcURL = server.URL
+	v.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	header := map[string]any{"alg": "ES256", "typ": "JWT"}
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{"iss": testCaller, "aud": testService, "exp": now.Add(time.Minute).Unix(), "iat": now.Unix(), "lxm": testMethod}
+		for k, value := range overrides {
+			if value == nil {
+				delete(c, k)
+			} else {
+				c[k] = value
+			}
+		}
+		return c
+	}
+
+	did, err := v.Verify(ctx, signToken(t, key, header, claims(nil)), testMethod)
+	require.NoError(t, err)
+	assert.Equal(t, testCaller, did)
+
+	did, err = v.Verify(ctx, signToken(t, key, header, claims(map[string]any{"aud": testService + "#survey_appview", "iss": testCaller + "#atproto"})), testMethod)
+	require.NoError(t, err, "fragments name services of the DIDs")
+	assert.Equal(t, testCaller, did)
+	assert.EqualValues(t, 1, directory.fetches.Load(), "the key is cached")
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"not a JWT", "abc.def", ErrBadToken},
+		{"unsupported algorithm", signToken(t, key, map[string]any{"alg": "HS256"}, claims(nil)), ErrBadToken},
+		{"PDS access token", signToken(t, key, map[string]any{"alg": "ES256", "typ": "at+jwt"}, claims(nil)), ErrBadToken},
+		{"no expiry", signToken(t, key, header, claims(map[string]any{"exp": nil})), ErrBadToken},
+		{"expired", signToken(t, key, header, claims(map[string]any{"exp": now.Add(-time.Second).Unix()})), ErrExpired},
+		{"another service", signToken(t, key, header, claims(map[string]any{"aud": "did:web:other.example.com"})), ErrBadAudience},
+		{"another method", signToken(t, key, header, claims(map[string]any{"lxm": "net.openmeet.survey.getComments"})), ErrBadMethod},
+		{"no method", signToken(t, key, header, claims(map[string]any{"lxm": nil})), ErrBadMethod},
+		{"unknown issuer", signToken(t, key, header, claims(map[string]any{"iss": "did:plc:unknown"})), ErrBadToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(ctx, tt.token, testMethod)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	t.Run("signed by another key", func(t *testing.T) {
+		other, _ := newKey(t)
+		_, err := v.Verify(ctx, signToken(t, other, header, claims(nil)), testMethod)
+		assert.ErrorIs(t, err, ErrBadSignature)
+	})
+
+	t.Run("a rotated key is fetched again", func(t *testing.T) {
+		rotated, multibase := newKey(t)
+		directory.key.Store(multibase)
+		did, err := v.Verify(ctx, signToken(t, rotated, header, claims(nil)), testMethod)
+		require.NoError(t, err)
+		assert.Equal(t, testCaller, did)
+	})
+}
@@ -0,0 +1,81 @@
+// Package sharetoken grants access to surveys with token visibility. A share
+// token is a random "st_" string the author adds to the survey's link; only
+// its SHA-256 hash is stored, so the link is shown once at creation.
+package sharetoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenPrefix starts every share token
+const TokenPrefix = "st_"
+
+// Limits
+const (
+	MaxTokensPerSurvey = 50  // Active tokens a survey can have
+	MaxLabelLength     = 100 // Characters of a token's label
+	prefixLength       = 10  // Characters of the token kept for display, including "st_"
+)
+
+// Token is a share token of a survey. The token itself is never stored.
+type Token struct {
+	ID        uuid.UUID  `json:"id"`
+	SurveyID  uuid.UUID  `json:"surveyId"`
+	Label     string     `json:"label"`  // Who the link was given to, e.g. "Board members"
+	Prefix    string     `json:"prefix"` // Start of the token, to tell tokens apart
+	Hash      string     `json:"-"`      // Hex SHA-256 of the token
+	CreatedBy string     `json:"createdBy"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Store persists share tokens
+type Store interface {
+	CreateShareToken(ctx context.Context, t *Token) error
+	ListShareTokens(ctx context.Context, surveyID uuid.UUID) ([]*Token, error)
+	CountActiveShareTokens(ctx context.Context, surveyID uuid.UUID) (int, error)
+	// ValidShareToken reports whether the hash is of an active token of the survey
+	ValidShareToken(ctx context.Context, surveyID uuid.UUID, hash string) (bool, error)
+	// RevokeShareToken returns sql.ErrNoRows if the survey has no such active token
+	RevokeShareToken(ctx context.Context, id, surveyID uuid.UUID) error
+}
+
+// New creates a token for a survey and returns it with the token itself,
+// which must be shown to the author now as it cannot be recovered
+func New(surveyID uuid.UUID, label, createdBy string) (*Token, string, error) {
+	label = strings.TrimSpace(label)
+	if len([]rune(label)) > MaxLabelLength {
+		return nil, "", fmt.Errorf("label must be at most %d characters", MaxLabelLength)
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	token := TokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return &Token{
+		ID:        uuid.New(),
+		SurveyID:  surveyID,
+		Label:     label,
+		Prefix:    token[:prefixLength],
+		Hash:      Hash(token),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}, token, nil
+}
+
+// Hash returns the hex SHA-256 of a token, as stored
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,33 @@
+package sharetoken
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	surveyID := uuid.New()
+	st, token, err := New(surveyID, "  Board members ", "did:plc:alice")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(token, TokenPrefix))
+	assert.Equal(t, "Board members", st.Label)
+	assert.Equal(t, surveyID, st.SurveyID)
+	assert.Equal(t, "did:plc:alice", st.CreatedBy)
+	assert.Equal(t, Hash(token), st.Hash)
+	assert.True(t, strings.HasPrefix(token, st.Prefix))
+	assert.Len(t, st.Prefix, prefixLength)
+
+	_, other, err := New(surveyID, "", "did:plc:alice")
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestNew_LabelTooLong(t *testing.T) {
+	_, _, err := New(uuid.New(), strings.Repeat("a", MaxLabelLength+1), "did:plc:alice")
+	assert.Error(t, err)
+}
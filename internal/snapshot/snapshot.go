@@ -0,0 +1,138 @@
+// Package snapshot keeps scheduled snapshots of survey results. Authors opt a
+// survey into hourly or daily snapshots; each one is kept locally for the
+// results-over-time chart and published to the author's PDS as a
+// net.openmeet.survey.results record while they have a login session.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Frequency is how often a survey's results are snapshotted
+type Frequency string
+
+// Frequencies
+const (
+	Hourly Frequency = "hourly" // At the start of every hour
+	Daily  Frequency = "daily"  // At midnight UTC
+)
+
+// Limits
+const (
+	HistoryLimit = 60 // Latest snapshots shown and charted
+	batchSize    = 50 // Due schedules a replica claims per run
+)
+
+// ParseFrequency parses a frequency, returning false if it is unknown
+func ParseFrequency(s string) (Frequency, bool) {
+	switch f := Frequency(s); f {
+	case Hourly, Daily:
+		return f, true
+	}
+	return "", false
+}
+
+// Interval returns the time between snapshots
+func (f Frequency) Interval() time.Duration {
+	if f == Hourly {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Next returns the first snapshot time after t: the start of the next hour,
+// or the next midnight UTC
+func (f Frequency) Next(t time.Time) time.Time {
+	return t.UTC().Truncate(f.Interval()).Add(f.Interval())
+}
+
+// Schedule is a survey's opt-in to results snapshots
+type Schedule struct {
+	SurveyID  uuid.UUID `json:"surveyId"`
+	Frequency Frequency `json:"frequency"`
+	DID       string    `json:"did"` // Who opted in; snapshots are published to their repo
+	NextRunAt time.Time `json:"nextRunAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewSchedule creates a schedule whose first snapshot is at the next
+// frequency boundary after now
+func NewSchedule(surveyID uuid.UUID, frequency Frequency, did string, now time.Time) *Schedule {
+	return &Schedule{
+		SurveyID:  surveyID,
+		Frequency: frequency,
+		DID:       did,
+		NextRunAt: frequency.Next(now),
+		CreatedAt: now,
+	}
+}
+
+// Snapshot is the results of a survey at one point in time
+type Snapshot struct {
+	ID         uuid.UUID       `json:"id"`
+	SurveyID   uuid.UUID       `json:"surveyId"`
+	TotalVotes int             `json:"totalVotes"`
+	Record     json.RawMessage `json:"record"`        // The net.openmeet.survey.results record
+	URI        *string         `json:"uri,omitempty"` // Set once published to the PDS
+	CID        *string         `json:"cid,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// Published reports whether the snapshot was written to the PDS
+func (s *Snapshot) Published() bool {
+	return s.URI != nil
+}
+
+// Store persists schedules and snapshots
+type Store interface {
+	// GetSnapshotSchedule returns nil if the survey is not opted in
+	GetSnapshotSchedule(ctx context.Context, surveyID uuid.UUID) (*Schedule, error)
+	// SaveSnapshotSchedule creates or replaces the schedule of its survey
+	SaveSnapshotSchedule(ctx context.Context, s *Schedule) error
+	DeleteSnapshotSchedule(ctx context.Context, surveyID uuid.UUID) error
+	// ClaimDueSnapshotSchedules returns up to limit schedules due at now and
+	// moves them to their next run, so no two replicas take the same snapshot
+	ClaimDueSnapshotSchedules(ctx context.Context, now time.Time, limit int) ([]*Schedule, error)
+	CreateSnapshot(ctx context.Context, s *Snapshot) error
+	// ListSnapshots returns the latest snapshots of a survey, oldest first
+	ListSnapshots(ctx context.Context, surveyID uuid.UUID, limit int) ([]*Snapshot, error)
+}
+
+// Taker takes the snapshot of a schedule's survey at now
+type Taker func(ctx context.Context, schedule *Schedule, now time.Time) error
+
+// Run claims the schedules due at now and takes their snapshots
+func Run(ctx context.Context, store Store, take Taker, now time.Time) {
+	schedules, err := store.ClaimDueSnapshotSchedules(ctx, now, batchSize)
+	if err != nil {
+		log.Printf("Error claiming results snapshot schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		if err := take(ctx, schedule, now); err != nil {
+			log.Printf("Error taking results snapshot of survey %s: %v", schedule.SurveyID, err)
+		}
+	}
+}
+
+// StartWorker takes due snapshots every interval until ctx is cancelled
+func StartWorker(ctx context.Context, store Store, take Taker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		Run(ctx, store, take, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFrequency(t *testing.T) {
+	for _, s := range []string{"hourly", "daily"} {
+		f, ok := ParseFrequency(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, Frequency(s), f)
+	}
+	for _, s := range []string{"", "weekly", "Hourly"} {
+		_, ok := ParseFrequency(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestFrequencyNext(t *testing.T) {
+	at := time.Date(2026, 3, 14, 15, 9, 26, 0, time.FixedZone("EST", -5*3600))
+
+	assert.Equal(t, time.Date(2026, 3, 14, 21, 0, 0, 0, time.UTC), Hourly.Next(at))
+	assert.Equal(t, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), Daily.Next(at))
+
+	midnight := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, midnight.Add(24*time.Hour), Daily.Next(midnight), "a boundary schedules the next one")
+}
+
+func TestNewSchedule(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	s := NewSchedule(uuid.New(), Hourly, "did:plc:author", now)
+	assert.Equal(t, time.Date(2026, 3, 14, 16, 0, 0, 0, time.UTC), s.NextRunAt)
+	assert.Equal(t, "did:plc:author", s.DID)
+}
@@ -0,0 +1,280 @@
+// Package spam scores guest responses for spam. A response's score, from 0 to
+// 100, adds up signals of automated voting: a filled honeypot form field that
+// people never see, many votes from one IP address in a short time, a missing
+// or scripted user agent, and IP ranges the operator lists as disposable, such
+// as hosting providers and VPN exits. Authors set a threshold per survey, and
+// responses scoring at or above it are left out of the survey's results.
+package spam
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HoneypotField is the name of the voting form field hidden from people.
+// Only bots fill it in.
+const HoneypotField = "_website"
+
+// MaxScore is the highest score, given to responses that filled the honeypot
+const MaxScore = 100
+
+// Reasons a response scores
+const (
+	ReasonHoneypot  = "honeypot"   // The honeypot field was filled in
+	ReasonRate      = "rate"       // Many votes from the IP address recently
+	ReasonUserAgent = "user_agent" // A missing, scripted, or low entropy user agent
+	ReasonIPRange   = "ip_range"   // An IP address in a disposable range
+)
+
+// Points of each signal
+const (
+	ipRangePoints      = 40
+	scriptedUAPoints   = 40 // No user agent, or an HTTP library's
+	lowEntropyUAPoints = 20 // A short or repetitive user agent
+	ratePoints         = 15 // Per vote from the IP address beyond RateFree
+	maxRatePoints      = 45
+)
+
+// Recent votes per IP address are counted over RateWindow. The first RateFree
+// votes in the window score nothing.
+const (
+	RateWindow = 10 * time.Minute
+	RateFree   = 3
+)
+
+// maxTracked bounds the IP addresses whose recent votes are kept
+const maxTracked = 10000
+
+// minUserAgentEntropy is the Shannon entropy, in bits per character, below
+// which a user agent is considered made up. Browsers' are above 4.
+const minUserAgentEntropy = 3.5
+
+// minUserAgentLength is the length below which a user agent is considered made up
+const minUserAgentLength = 20
+
+// scriptedAgents are lowercase substrings of the user agents of HTTP
+// libraries and headless browsers
+var scriptedAgents = []string{
+	"curl", "wget", "python", "go-http-client", "java/", "okhttp", "axios",
+	"node-fetch", "libwww", "httpclient", "headless", "phantomjs", "scrapy", "bot/", "spider", "crawler",
+}
+
+// Config holds the disposable IP ranges
+type Config struct {
+	IPRanges []string // CIDRs
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - SPAM_IP_RANGES: comma-separated CIDRs of disposable IP ranges, such as hosting providers and VPN exits
+func ConfigFromEnv() Config {
+	var config Config
+	for _, cidr := range strings.Split(os.Getenv("SPAM_IP_RANGES"), ",") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			config.IPRanges = append(config.IPRanges, cidr)
+		}
+	}
+	return config
+}
+
+// Score is the spam score of a guest response
+type Score struct {
+	ResponseID uuid.UUID
+	SurveyID   uuid.UUID
+	Score      int
+	Reasons    []string
+	CreatedAt  time.Time
+}
+
+// Store persists the spam scores of guest responses and the thresholds of surveys
+type Store interface {
+	SaveSpamScore(ctx context.Context, s *Score) error
+	// ListSpamScores returns the scores of a survey's responses
+	ListSpamScores(ctx context.Context, surveyID uuid.UUID) ([]*Score, error)
+	// GetSpamThreshold returns a survey's threshold, or 0 if it has none
+	GetSpamThreshold(ctx context.Context, surveyID uuid.UUID) (int, error)
+	// SetSpamThreshold sets a survey's threshold, or removes it if 0
+	SetSpamThreshold(ctx context.Context, surveyID uuid.UUID, threshold int, by string) error
+}
+
+// ValidateThreshold checks a threshold is a score, or 0 for none
+func ValidateThreshold(threshold int) error {
+	if threshold < 0 || threshold > MaxScore {
+		return fmt.Errorf("threshold must be between 1 and %d, or 0 to count all responses", MaxScore)
+	}
+	return nil
+}
+
+// Scorer scores guest responses, counting the recent votes of each IP address
+// in memory. Each API replica counts its own.
+type Scorer struct {
+	ranges []netip.Prefix
+	now    func() time.Time
+
+	mu     sync.Mutex
+	recent map[string][]time.Time // Times of the votes of each IP address within RateWindow
+}
+
+// NewScorer creates a scorer. It returns an error if an IP range is not a CIDR.
+func NewScorer(config Config) (*Scorer, error) {
+	s := &Scorer{now: time.Now, recent: make(map[string][]time.Time)}
+	for _, cidr := range config.IPRanges {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spam IP range %q: %w", cidr, err)
+		}
+		s.ranges = append(s.ranges, prefix.Masked())
+	}
+	return s, nil
+}
+
+// Score scores a guest vote on a survey and counts it against its IP address
+func (s *Scorer) Score(surveyID uuid.UUID, ip, userAgent, honeypot string) *Score {
+	score := &Score{SurveyID: surveyID, Reasons: []string{}}
+	add := func(points int, reason string) {
+		score.Score += points
+		score.Reasons = append(score.Reasons, reason)
+	}
+
+	if strings.TrimSpace(honeypot) != "" {
+		add(MaxScore, ReasonHoneypot)
+	}
+	if votes := s.count(ip); votes > RateFree {
+		add(min((votes-RateFree)*ratePoints, maxRatePoints), ReasonRate)
+	}
+	if points := userAgentPoints(userAgent); points > 0 {
+		add(points, ReasonUserAgent)
+	}
+	if s.inRanges(ip) {
+		add(ipRangePoints, ReasonIPRange)
+	}
+
+	score.Score = min(score.Score, MaxScore)
+	return score
+}
+
+// count records a vote from an IP address and returns its votes within RateWindow
+func (s *Scorer) count(ip string) int {
+	if ip == "" {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	cutoff := now.Add(-RateWindow)
+	if _, ok := s.recent[ip]; !ok && len(s.recent) >= maxTracked {
+		for other, times := range s.recent {
+			if times[len(times)-1].Before(cutoff) {
+				delete(s.recent, other)
+			}
+		}
+		// Still full of busy addresses: start counting again
+		if len(s.recent) >= maxTracked {
+			s.recent = make(map[string][]time.Time)
+		}
+	}
+
+	times := s.recent[ip]
+	kept := times[:0]
+	for _, t := range times {
+		if !t.Before(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	s.recent[ip] = append(kept, now)
+	return len(s.recent[ip])
+}
+
+// inRanges reports whether an IP address is in a disposable range
+func (s *Scorer) inRanges(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range s.ranges {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// userAgentPoints scores a user agent: most if it is missing or an HTTP
+// library's, fewer if it is too short or repetitive to be a browser's
+func userAgentPoints(userAgent string) int {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return scriptedUAPoints
+	}
+	lower := strings.ToLower(userAgent)
+	for _, agent := range scriptedAgents {
+		if strings.Contains(lower, agent) {
+			return scriptedUAPoints
+		}
+	}
+	if len(userAgent) < minUserAgentLength || entropy(userAgent) < minUserAgentEntropy {
+		return lowEntropyUAPoints
+	}
+	return 0
+}
+
+// entropy returns the Shannon entropy of a string in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var bits float64
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// Report summarizes the spam scores of a survey's responses
+type Report struct {
+	Threshold int            `json:"threshold"` // 0 if the survey counts all responses
+	Scored    int            `json:"scored"`    // Guest responses with a score
+	Excluded  int            `json:"excluded"`  // Responses left out of results by the threshold
+	Reasons   map[string]int `json:"reasons"`   // Responses scoring for each reason
+	Histogram []Bucket       `json:"histogram"` // Responses by score, in tens
+}
+
+// Bucket counts the responses scoring from Min to Min+9 (100 is in the last)
+type Bucket struct {
+	Min       int `json:"min"`
+	Responses int `json:"responses"`
+}
+
+// NewReport summarizes the scores of a survey's responses under a threshold
+func NewReport(scores []*Score, threshold int) Report {
+	report := Report{Threshold: threshold, Scored: len(scores), Reasons: make(map[string]int)}
+	buckets := make([]int, MaxScore/10)
+	for _, s := range scores {
+		if threshold > 0 && s.Score >= threshold {
+			report.Excluded++
+		}
+		for _, reason := range s.Reasons {
+			report.Reasons[reason]++
+		}
+		buckets[min(s.Score/10, len(buckets)-1)]++
+	}
+	for i, n := range buckets {
+		report.Histogram = append(report.Histogram, Bucket{Min: i * 10, Responses: n})
+	}
+	return report
+}
@@ -0,0 +1,123 @@
+package spam
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const browser = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+
+func TestUserAgentPoints(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      int
+	}{
+		{browser, 0},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", 0},
+		{"", scriptedUAPoints},
+		{"curl/8.5.0", scriptedUAPoints},
+		{"python-requests/2.32.3", scriptedUAPoints},
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/126.0.0.0 Safari/537.36", scriptedUAPoints},
+		{"Mozilla/5.0", lowEntropyUAPoints},
+		{strings.Repeat("ab", 40), lowEntropyUAPoints},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, userAgentPoints(tt.userAgent), tt.userAgent)
+	}
+}
+
+func TestScorer(t *testing.T) {
+	_, err := NewScorer(Config{IPRanges: []string{"203.0.113.0"}})
+	assert.Error(t, err, "ranges are CIDRs")
+
+	s, err := NewScorer(Config{IPRanges: []string{"203.0.113.0/24", "2001:db8::/32"}})
+	require.NoError(t, err)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	surveyID := uuid.New()
+
+	score := s.Score(surveyID, "198.51.100.1", browser, "")
+	assert.Equal(t, surveyID, score.SurveyID)
+	assert.Zero(t, score.Score)
+	assert.Empty(t, score.Reasons)
+
+	score = s.Score(surveyID, "198.51.100.2", browser, " https://spam.example ")
+	assert.Equal(t, MaxScore, score.Score)
+	assert.Equal(t, []string{ReasonHoneypot}, score.Reasons)
+
+	score = s.Score(surveyID, "203.0.113.9", browser, "")
+	assert.Equal(t, ipRangePoints, score.Score)
+	assert.Equal(t, []string{ReasonIPRange}, score.Reasons)
+	score = s.Score(surveyID, "::ffff:203.0.113.9", "", "")
+	assert.Equal(t, ipRangePoints+scriptedUAPoints, score.Score, "IPv4-mapped addresses are in IPv4 ranges")
+	assert.Equal(t, []string{ReasonUserAgent, ReasonIPRange}, score.Reasons)
+	score = s.Score(surveyID, "2001:db8::1", browser, "")
+	assert.Equal(t, []string{ReasonIPRange}, score.Reasons)
+
+	// Votes from an IP address beyond RateFree in RateWindow score more and more
+	ip := "192.0.2.1"
+	var scores []int
+	for range RateFree + 4 {
+		scores = append(scores, s.Score(uuid.New(), ip, browser, "").Score)
+	}
+	assert.Equal(t, []int{0, 0, 0, 15, 30, 45, 45}, scores)
+	now = now.Add(RateWindow + time.Second)
+	assert.Zero(t, s.Score(surveyID, ip, browser, "").Score, "old votes are forgotten")
+
+	// Scores never exceed MaxScore
+	assert.Equal(t, MaxScore, s.Score(surveyID, "203.0.113.9", "", "filled").Score)
+}
+
+func TestScorer_Tracked(t *testing.T) {
+	s, err := NewScorer(Config{})
+	require.NoError(t, err)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	for i := range maxTracked {
+		s.count(fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	assert.LessOrEqual(t, len(s.recent), maxTracked)
+
+	// Addresses idle for RateWindow make room
+	now = now.Add(RateWindow + time.Second)
+	s.count("192.0.2.1")
+	assert.Len(t, s.recent, 1)
+}
+
+func TestValidateThreshold(t *testing.T) {
+	for _, threshold := range []int{0, 1, 50, MaxScore} {
+		assert.NoError(t, ValidateThreshold(threshold), threshold)
+	}
+	for _, threshold := range []int{-1, MaxScore + 1} {
+		assert.Error(t, ValidateThreshold(threshold), threshold)
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	scores := []*Score{
+		{Score: 0, Reasons: []string{}},
+		{Score: 15, Reasons: []string{ReasonRate}},
+		{Score: 40, Reasons: []string{ReasonUserAgent}},
+		{Score: 100, Reasons: []string{ReasonHoneypot, ReasonUserAgent}},
+	}
+
+	report := NewReport(scores, 0)
+	assert.Equal(t, 4, report.Scored)
+	assert.Zero(t, report.Excluded, "no threshold excludes nothing")
+	assert.Equal(t, map[string]int{ReasonRate: 1, ReasonUserAgent: 2, ReasonHoneypot: 1}, report.Reasons)
+	require.Len(t, report.Histogram, 10)
+	assert.Equal(t, Bucket{Min: 0, Responses: 1}, report.Histogram[0])
+	assert.Equal(t, Bucket{Min: 10, Responses: 1}, report.Histogram[1])
+	assert.Equal(t, Bucket{Min: 40, Responses: 1}, report.Histogram[4])
+	assert.Equal(t, Bucket{Min: 90, Responses: 1}, report.Histogram[9], "100 is in the last bucket")
+
+	report = NewReport(scores, 40)
+	assert.Equal(t, 40, report.Threshold)
+	assert.Equal(t, 2, report.Excluded, "responses at the threshold are excluded")
+}
@@ -0,0 +1,276 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Health thresholds applied to the metrics observed during one sampling interval
+const (
+	// MaxAPIErrorRate is the highest share of 5xx responses for the API to count as up
+	MaxAPIErrorRate = 0.05
+	// MinPDSSuccessRate is the lowest PDS write success rate for writes to count as up
+	MinPDSSuccessRate = 0.9
+	// MinAISuccessRate is the lowest AI generation success rate for the generator to count as up
+	MinAISuccessRate = 0.5
+	// DefaultConsumerStaleAfter is how far the consumer cursor may lag before indexing counts as down
+	DefaultConsumerStaleAfter = 1 * time.Hour
+)
+
+// Metric names read from the process's Prometheus registry
+const (
+	metricHTTPRequests  = "http_request_duration_seconds"
+	metricPDSWrites     = "survey_pds_writes_total"
+	metricAIGenerations = "survey_ai_generations_total"
+)
+
+// Store persists status samples
+type Store interface {
+	InsertStatusSample(ctx context.Context, s *Sample) error
+	DeleteStatusSamplesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// DBChecker checks database connectivity
+type DBChecker interface {
+	PingContext(ctx context.Context) error
+}
+
+// CursorFunc returns the time of the newest event the consumer indexed (microseconds since epoch)
+type CursorFunc func(ctx context.Context) (int64, error)
+
+// SamplerConfig configures which components are sampled and how
+type SamplerConfig struct {
+	AIEnabled          bool          // Sample the AI generator (skipped when not configured)
+	ConsumerStaleAfter time.Duration // Cursor lag before indexing counts as down
+	Instance           string        // Name of this process in its samples (default: host name)
+}
+
+// Sampler turns the process's metrics into periodic status samples.
+// Counter-based components are judged on the change since the previous sample.
+// Each API instance runs a sampler over its own metrics and names itself in
+// its samples.
+type Sampler struct {
+	store    Store
+	db       DBChecker
+	cursor   CursorFunc
+	gatherer prometheus.Gatherer
+	config   SamplerConfig
+	previous map[string]float64
+	now      func() time.Time
+}
+
+// NewSampler creates a sampler reading from the default Prometheus registry
+func NewSampler(store Store, db DBChecker, cursor CursorFunc, config SamplerConfig) *Sampler {
+	if config.ConsumerStaleAfter <= 0 {
+		config.ConsumerStaleAfter = DefaultConsumerStaleAfter
+	}
+	if config.Instance == "" {
+		config.Instance, _ = os.Hostname()
+	}
+	return &Sampler{
+		store:    store,
+		db:       db,
+		cursor:   cursor,
+		gatherer: prometheus.DefaultGatherer,
+		config:   config,
+		previous: make(map[string]float64),
+		now:      time.Now,
+	}
+}
+
+// Collect observes every component once and returns the samples without storing them
+func (s *Sampler) Collect(ctx context.Context) ([]*Sample, error) {
+	families, err := s.gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	now := s.now()
+	samples := []*Sample{
+		s.sampleAPI(ctx, families, now),
+		s.sampleConsumer(ctx, now),
+		s.samplePDSWrites(families, now),
+	}
+	if s.config.AIEnabled {
+		samples = append(samples, s.sampleAIGenerator(families, now))
+	}
+	for _, sample := range samples {
+		sample.Instance = s.config.Instance
+	}
+
+	return samples, nil
+}
+
+// sampleAPI checks the database and the share of 5xx responses
+func (s *Sampler) sampleAPI(ctx context.Context, families []*dto.MetricFamily, now time.Time) *Sample {
+	if err := s.db.PingContext(ctx); err != nil {
+		return newSample(ComponentAPI, false, nil, "Database unreachable", now)
+	}
+
+	totals := s.deltas(metricHTTPRequests, "status", families)
+	var requests, errors float64
+	for code, n := range totals {
+		requests += n
+		if strings.HasPrefix(code, "5") {
+			errors += n
+		}
+	}
+	if requests == 0 {
+		return newSample(ComponentAPI, true, nil, "No requests in interval", now)
+	}
+
+	successRate := 1 - errors/requests
+	healthy := errors/requests <= MaxAPIErrorRate
+	return newSample(ComponentAPI, healthy, &successRate,
+		fmt.Sprintf("%.1f%% of requests succeeded", successRate*100), now)
+}
+
+// sampleConsumer checks how far the consumer cursor lags behind real time
+func (s *Sampler) sampleConsumer(ctx context.Context, now time.Time) *Sample {
+	timeUs, err := s.cursor(ctx)
+	if err != nil {
+		return newSample(ComponentConsumer, false, nil, "Cursor unavailable", now)
+	}
+	if timeUs == 0 {
+		return newSample(ComponentConsumer, false, nil, "No events processed yet", now)
+	}
+
+	lag := now.Sub(time.UnixMicro(timeUs))
+	if lag < 0 {
+		lag = 0
+	}
+	lagSeconds := lag.Seconds()
+	healthy := lag <= s.config.ConsumerStaleAfter
+	return newSample(ComponentConsumer, healthy, &lagSeconds,
+		fmt.Sprintf("Last event indexed %s ago", lag.Round(time.Second)), now)
+}
+
+// samplePDSWrites checks the success rate of record writes to user PDSes
+func (s *Sampler) samplePDSWrites(families []*dto.MetricFamily, now time.Time) *Sample {
+	return s.sampleSuccessRate(ComponentPDSWrites, metricPDSWrites, "writes", MinPDSSuccessRate, families, now)
+}
+
+// sampleAIGenerator checks the success rate of AI generations.
+// Rate-limited and over-budget requests are not failures of the generator.
+func (s *Sampler) sampleAIGenerator(families []*dto.MetricFamily, now time.Time) *Sample {
+	return s.sampleSuccessRate(ComponentAIGenerator, metricAIGenerations, "generations", MinAISuccessRate, families, now)
+}
+
+// sampleSuccessRate judges a component on a counter with success/error status labels
+func (s *Sampler) sampleSuccessRate(c Component, metric, noun string, minRate float64, families []*dto.MetricFamily, now time.Time) *Sample {
+	totals := s.deltas(metric, "status", families)
+	attempts := totals["success"] + totals["error"]
+	if attempts == 0 {
+		return newSample(c, true, nil, "No "+noun+" in interval", now)
+	}
+
+	successRate := totals["success"] / attempts
+	return newSample(c, successRate >= minRate, &successRate,
+		fmt.Sprintf("%.1f%% of %s succeeded", successRate*100, noun), now)
+}
+
+// deltas returns the change of a counter (or histogram count) per label value since the previous call
+func (s *Sampler) deltas(metric, label string, families []*dto.MetricFamily) map[string]float64 {
+	result := make(map[string]float64)
+	for value, total := range totals(families, metric, label) {
+		key := metric + "/" + value
+		delta := total - s.previous[key]
+		if delta < 0 {
+			// Counter was reset
+			delta = total
+		}
+		s.previous[key] = total
+		result[value] = delta
+	}
+	return result
+}
+
+// Run collects samples, stores them, and prunes samples past the retention period
+func (s *Sampler) Run(ctx context.Context) {
+	samples, err := s.Collect(ctx)
+	if err != nil {
+		log.Printf("Error collecting status samples: %v", err)
+		return
+	}
+
+	for _, sample := range samples {
+		if err := s.store.InsertStatusSample(ctx, sample); err != nil {
+			log.Printf("Error storing %s status sample: %v", sample.Component, err)
+		}
+	}
+
+	count, err := s.store.DeleteStatusSamplesBefore(ctx, s.now().Add(-RetentionPeriod))
+	if err != nil {
+		log.Printf("Error pruning status samples: %v", err)
+	} else if count > 0 {
+		log.Printf("Pruned %d status samples older than %v", count, RetentionPeriod)
+	}
+}
+
+// StartSampler starts a background goroutine that periodically records status
+// samples. It runs until the context is cancelled.
+func StartSampler(ctx context.Context, sampler *Sampler, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Status sampler started (interval: %v)", interval)
+
+	// Sample immediately on start
+	sampler.Run(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Status sampler stopped")
+			return
+		case <-ticker.C:
+			sampler.Run(ctx)
+		}
+	}
+}
+
+// totals sums a counter or histogram family per value of the given label
+func totals(families []*dto.MetricFamily, metric, label string) map[string]float64 {
+	result := make(map[string]float64)
+	for _, mf := range families {
+		if mf.GetName() != metric {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			var value float64
+			switch {
+			case m.GetCounter() != nil:
+				value = m.GetCounter().GetValue()
+			case m.GetHistogram() != nil:
+				value = float64(m.GetHistogram().GetSampleCount())
+			}
+
+			key := ""
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == label {
+					key = lp.GetValue()
+				}
+			}
+			result[key] += value
+		}
+	}
+	return result
+}
+
+// newSample builds a sample with a detail message
+func newSample(c Component, healthy bool, value *float64, detail string, now time.Time) *Sample {
+	return &Sample{
+		Component: c,
+		Healthy:   healthy,
+		Value:     value,
+		Detail:    &detail,
+		SampledAt: now,
+	}
+}
@@ -0,0 +1,244 @@
+// Package status records periodic availability samples for the service's
+// components and summarizes them for the public /status page.
+package status
+
+import (
+	"context"
+	"time"
+)
+
+// Component identifies a monitored part of the service
+type Component string
+
+const (
+	ComponentAPI         Component = "api"
+	ComponentConsumer    Component = "consumer"
+	ComponentPDSWrites   Component = "pds_writes"
+	ComponentAIGenerator Component = "ai_generator"
+)
+
+// Components lists the monitored components in display order
+var Components = []Component{
+	ComponentAPI,
+	ComponentConsumer,
+	ComponentPDSWrites,
+	ComponentAIGenerator,
+}
+
+// Label returns the human-readable name of a component
+func (c Component) Label() string {
+	switch c {
+	case ComponentAPI:
+		return "Website & API"
+	case ComponentConsumer:
+		return "ATProto indexing"
+	case ComponentPDSWrites:
+		return "PDS writes"
+	case ComponentAIGenerator:
+		return "AI survey generator"
+	default:
+		return string(c)
+	}
+}
+
+// RetentionPeriod is how long samples are kept for the status history
+const RetentionPeriod = 90 * 24 * time.Hour
+
+// StaleAfter is how old the latest sample may be before a component's
+// current state is reported as unknown
+const StaleAfter = 15 * time.Minute
+
+// Sample is a single availability observation of a component
+type Sample struct {
+	ID        int64
+	Component Component
+	Instance  string // Process that took the sample, as each samples its own metrics
+	Healthy   bool
+	Value     *float64 // Component-specific measurement (success rate, cursor age in seconds)
+	Detail    *string  // Short explanation shown on the status page
+	SampledAt time.Time
+}
+
+// State is the current state of a component or of the whole service
+type State string
+
+const (
+	StateOperational State = "operational"
+	StateDegraded    State = "degraded" // Overall only: some components are down
+	StateDown        State = "down"
+	StateUnknown     State = "unknown" // No recent samples
+)
+
+// Report is the summarized status served by /status and /api/v1/status
+type Report struct {
+	Status      State             `json:"status"`
+	GeneratedAt time.Time         `json:"generatedAt"`
+	Components  []ComponentReport `json:"components"`
+}
+
+// ComponentReport summarizes the history of a single component
+type ComponentReport struct {
+	Name          Component   `json:"name"`
+	Label         string      `json:"label"`
+	Status        State       `json:"status"`
+	Detail        string      `json:"detail,omitempty"`
+	LastCheckedAt *time.Time  `json:"lastCheckedAt,omitempty"`
+	Uptime        Uptime      `json:"uptime"`
+	Days          []DayUptime `json:"days"`
+}
+
+// Uptime holds the share of healthy samples (0-100) over rolling windows.
+// A nil value means there were no samples in the window.
+type Uptime struct {
+	Day     *float64 `json:"24h"`
+	Week    *float64 `json:"7d"`
+	Month   *float64 `json:"30d"`
+	Quarter *float64 `json:"90d"`
+}
+
+// DayUptime is the uptime of a single UTC day
+type DayUptime struct {
+	Date   string   `json:"date"` // YYYY-MM-DD
+	Uptime *float64 `json:"uptime"`
+}
+
+// HistoryDays is the number of days shown in the per-component history
+const HistoryDays = 90
+
+// Windows are the rolling windows uptime is reported over, shortest first
+var Windows = [...]time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour, RetentionPeriod}
+
+// Counts is the number of samples taken in a period and how many were healthy
+type Counts struct {
+	Total   int
+	Healthy int
+}
+
+// History is the sample history of a component, aggregated by the store
+type History struct {
+	Latest  []*Sample            // Latest sample of each instance
+	Windows [len(Windows)]Counts // Samples within each of Windows
+	Days    map[string]Counts    // Samples per UTC day, by YYYY-MM-DD
+}
+
+// HistoryReader reads the sample history of every component
+type HistoryReader interface {
+	StatusHistory(ctx context.Context, now time.Time) (map[Component]*History, error)
+}
+
+// HistorySince returns the start of the first UTC day of the history
+func HistorySince(now time.Time) time.Time {
+	return now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -(HistoryDays - 1))
+}
+
+// Summarize builds a report from the history of each component
+func Summarize(histories map[Component]*History, now time.Time) *Report {
+	report := &Report{
+		GeneratedAt: now.UTC(),
+		Components:  make([]ComponentReport, 0, len(Components)),
+	}
+
+	known, down := 0, 0
+	for _, c := range Components {
+		cr := summarizeComponent(c, histories[c], now)
+		switch cr.Status {
+		case StateOperational:
+			known++
+		case StateDown:
+			known++
+			down++
+		}
+		report.Components = append(report.Components, cr)
+	}
+
+	switch {
+	case known == 0:
+		report.Status = StateUnknown
+	case down == 0:
+		report.Status = StateOperational
+	default:
+		report.Status = StateDegraded
+	}
+
+	return report
+}
+
+// summarizeComponent computes the current state, window uptimes and daily
+// history of a component. The component is down if the latest recent sample
+// of any instance is unhealthy.
+func summarizeComponent(c Component, h *History, now time.Time) ComponentReport {
+	if h == nil {
+		h = &History{}
+	}
+
+	cr := ComponentReport{
+		Name:   c,
+		Label:  c.Label(),
+		Status: StateUnknown,
+		Uptime: Uptime{
+			Day:     h.Windows[0].uptime(),
+			Week:    h.Windows[1].uptime(),
+			Month:   h.Windows[2].uptime(),
+			Quarter: h.Windows[3].uptime(),
+		},
+		Days: dailyUptime(h.Days, now),
+	}
+
+	var newest, failing *Sample
+	recent := false
+	for _, s := range h.Latest {
+		if newest == nil || s.SampledAt.After(newest.SampledAt) {
+			newest = s
+		}
+		if now.Sub(s.SampledAt) > StaleAfter {
+			continue
+		}
+		recent = true
+		if !s.Healthy && (failing == nil || s.SampledAt.After(failing.SampledAt)) {
+			failing = s
+		}
+	}
+	if newest == nil {
+		return cr
+	}
+
+	checkedAt := newest.SampledAt.UTC()
+	cr.LastCheckedAt = &checkedAt
+	shown := newest
+	if failing != nil {
+		shown = failing
+	}
+	if shown.Detail != nil {
+		cr.Detail = *shown.Detail
+	}
+
+	switch {
+	case failing != nil:
+		cr.Status = StateDown
+	case recent:
+		cr.Status = StateOperational
+	}
+
+	return cr
+}
+
+// dailyUptime returns the uptime of each of the last HistoryDays UTC days, oldest first
+func dailyUptime(perDay map[string]Counts, now time.Time) []DayUptime {
+	first := HistorySince(now)
+	days := make([]DayUptime, 0, HistoryDays)
+	for i := 0; i < HistoryDays; i++ {
+		key := first.AddDate(0, 0, i).Format(time.DateOnly)
+		days = append(days, DayUptime{Date: key, Uptime: perDay[key].uptime()})
+	}
+	return days
+}
+
+// uptime returns the share of healthy samples as a 0-100 value, or nil if
+// there were none
+func (c Counts) uptime() *float64 {
+	if c.Total == 0 {
+		return nil
+	}
+	pct := float64(c.Healthy) / float64(c.Total) * 100
+	return &pct
+}
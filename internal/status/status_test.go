@@ -0,0 +1,264 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleAt(c Component, healthy bool, at time.Time) *Sample {
+	return &Sample{Component: c, Healthy: healthy, SampledAt: at}
+}
+
+// historyOf aggregates samples the way the store does in SQL
+func historyOf(samples []*Sample, now time.Time) map[Component]*History {
+	histories := make(map[Component]*History)
+	for _, s := range samples {
+		h := histories[s.Component]
+		if h == nil {
+			h = &History{Days: make(map[string]Counts)}
+			histories[s.Component] = h
+		}
+
+		healthy := 0
+		if s.Healthy {
+			healthy = 1
+		}
+		for i, window := range Windows {
+			if !s.SampledAt.Before(now.Add(-window)) {
+				h.Windows[i].Total++
+				h.Windows[i].Healthy += healthy
+			}
+		}
+		if !s.SampledAt.Before(HistorySince(now)) {
+			day := s.SampledAt.UTC().Format(time.DateOnly)
+			h.Days[day] = Counts{Total: h.Days[day].Total + 1, Healthy: h.Days[day].Healthy + healthy}
+		}
+
+		latest := -1
+		for i, l := range h.Latest {
+			if l.Instance == s.Instance {
+				latest = i
+			}
+		}
+		switch {
+		case latest < 0:
+			h.Latest = append(h.Latest, s)
+		case s.SampledAt.After(h.Latest[latest].SampledAt):
+			h.Latest[latest] = s
+		}
+	}
+	return histories
+}
+
+func TestSummarize_NoSamples(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	report := Summarize(nil, now)
+
+	assert.Equal(t, StateUnknown, report.Status)
+	require.Len(t, report.Components, len(Components))
+	for _, cr := range report.Components {
+		assert.Equal(t, StateUnknown, cr.Status)
+		assert.Nil(t, cr.Uptime.Day)
+		assert.Len(t, cr.Days, HistoryDays)
+	}
+}
+
+func TestSummarize_UptimeAndState(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	samples := []*Sample{
+		sampleAt(ComponentAPI, true, now.Add(-10*24*time.Hour)),
+		sampleAt(ComponentAPI, false, now.Add(-2*time.Hour)),
+		sampleAt(ComponentAPI, true, now.Add(-1*time.Hour)),
+		sampleAt(ComponentAPI, true, now.Add(-5*time.Minute)),
+		sampleAt(ComponentConsumer, false, now.Add(-5*time.Minute)),
+	}
+
+	report := Summarize(historyOf(samples, now), now)
+	assert.Equal(t, StateDegraded, report.Status)
+
+	api := report.Components[0]
+	assert.Equal(t, ComponentAPI, api.Name)
+	assert.Equal(t, StateOperational, api.Status)
+	require.NotNil(t, api.Uptime.Day)
+	assert.InDelta(t, 66.67, *api.Uptime.Day, 0.01)
+	require.NotNil(t, api.Uptime.Month)
+	assert.InDelta(t, 75.0, *api.Uptime.Month, 0.01)
+
+	today := api.Days[len(api.Days)-1]
+	assert.Equal(t, "2025-06-01", today.Date)
+	require.NotNil(t, today.Uptime)
+	assert.InDelta(t, 66.67, *today.Uptime, 0.01)
+
+	assert.Equal(t, StateDown, report.Components[1].Status)
+}
+
+func TestSummarize_StaleSampleIsUnknown(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	samples := []*Sample{sampleAt(ComponentAPI, true, now.Add(-time.Hour))}
+
+	report := Summarize(historyOf(samples, now), now)
+	assert.Equal(t, StateUnknown, report.Components[0].Status)
+	assert.NotNil(t, report.Components[0].LastCheckedAt)
+}
+
+func TestSummarize_AnyInstanceDown(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	detail := "Database unreachable"
+	down := sampleAt(ComponentAPI, false, now.Add(-4*time.Minute))
+	down.Instance, down.Detail = "api-2", &detail
+	up := sampleAt(ComponentAPI, true, now.Add(-time.Minute))
+	up.Instance = "api-1"
+	gone := sampleAt(ComponentAPI, false, now.Add(-time.Hour))
+	gone.Instance = "api-3"
+
+	report := Summarize(historyOf([]*Sample{up, down, gone}, now), now)
+	api := report.Components[0]
+	assert.Equal(t, StateDown, api.Status, "a recent unhealthy sample of any instance is down")
+	assert.Equal(t, detail, api.Detail)
+	assert.Equal(t, now.Add(-time.Minute), *api.LastCheckedAt)
+
+	report = Summarize(historyOf([]*Sample{up, gone}, now), now)
+	assert.Equal(t, StateOperational, report.Components[0].Status, "instances without recent samples are ignored")
+}
+
+type fakeStore struct {
+	samples []*Sample
+	before  time.Time
+}
+
+func (f *fakeStore) InsertStatusSample(ctx context.Context, s *Sample) error {
+	f.samples = append(f.samples, s)
+	return nil
+}
+
+func (f *fakeStore) DeleteStatusSamplesBefore(ctx context.Context, before time.Time) (int64, error) {
+	f.before = before
+	return 0, nil
+}
+
+type fakeDB struct{ err error }
+
+func (f fakeDB) PingContext(ctx context.Context) error { return f.err }
+
+func newTestSampler(t *testing.T, db DBChecker, cursor CursorFunc, config SamplerConfig) (*Sampler, *prometheus.CounterVec, *prometheus.CounterVec) {
+	t.Helper()
+
+	registry := prometheus.NewRegistry()
+	pdsWrites := prometheus.NewCounterVec(prometheus.CounterOpts{Name: metricPDSWrites}, []string{"operation", "status"})
+	aiGenerations := prometheus.NewCounterVec(prometheus.CounterOpts{Name: metricAIGenerations}, []string{"status"})
+	registry.MustRegister(pdsWrites, aiGenerations)
+
+	sampler := NewSampler(&fakeStore{}, db, cursor, config)
+	sampler.gatherer = registry
+	return sampler, pdsWrites, aiGenerations
+}
+
+func findSample(samples []*Sample, c Component) *Sample {
+	for _, s := range samples {
+		if s.Component == c {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestSampler_PDSWriteSuccessRateUsesDeltas(t *testing.T) {
+	now := time.Now()
+	cursor := func(ctx context.Context) (int64, error) { return now.UnixMicro(), nil }
+	sampler, pdsWrites, _ := newTestSampler(t, fakeDB{}, cursor, SamplerConfig{})
+
+	pdsWrites.WithLabelValues("create", "success").Add(10)
+	samples, err := sampler.Collect(context.Background())
+	require.NoError(t, err)
+	pds := findSample(samples, ComponentPDSWrites)
+	require.NotNil(t, pds)
+	assert.True(t, pds.Healthy)
+
+	// Only the writes since the previous sample count
+	pdsWrites.WithLabelValues("create", "success").Add(1)
+	pdsWrites.WithLabelValues("delete", "error").Add(3)
+	samples, err = sampler.Collect(context.Background())
+	require.NoError(t, err)
+	pds = findSample(samples, ComponentPDSWrites)
+	require.NotNil(t, pds.Value)
+	assert.InDelta(t, 0.25, *pds.Value, 0.001)
+	assert.False(t, pds.Healthy)
+
+	// No writes in the interval is not an outage
+	samples, err = sampler.Collect(context.Background())
+	require.NoError(t, err)
+	assert.True(t, findSample(samples, ComponentPDSWrites).Healthy)
+}
+
+func TestSampler_AIGeneratorOnlyWhenEnabled(t *testing.T) {
+	cursor := func(ctx context.Context) (int64, error) { return time.Now().UnixMicro(), nil }
+
+	sampler, _, _ := newTestSampler(t, fakeDB{}, cursor, SamplerConfig{})
+	samples, err := sampler.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, findSample(samples, ComponentAIGenerator))
+
+	sampler, _, aiGenerations := newTestSampler(t, fakeDB{}, cursor, SamplerConfig{AIEnabled: true})
+	aiGenerations.WithLabelValues("success").Add(1)
+	aiGenerations.WithLabelValues("rate_limited").Add(5)
+	samples, err = sampler.Collect(context.Background())
+	require.NoError(t, err)
+	ai := findSample(samples, ComponentAIGenerator)
+	require.NotNil(t, ai)
+	assert.True(t, ai.Healthy)
+}
+
+func TestSampler_ConsumerLag(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		cursor  CursorFunc
+		healthy bool
+	}{
+		{"recent cursor", func(ctx context.Context) (int64, error) { return now.Add(-time.Minute).UnixMicro(), nil }, true},
+		{"stale cursor", func(ctx context.Context) (int64, error) { return now.Add(-2 * time.Hour).UnixMicro(), nil }, false},
+		{"no events yet", func(ctx context.Context) (int64, error) { return 0, nil }, false},
+		{"cursor error", func(ctx context.Context) (int64, error) { return 0, errors.New("boom") }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sampler, _, _ := newTestSampler(t, fakeDB{}, tt.cursor, SamplerConfig{})
+			sampler.now = func() time.Time { return now }
+
+			samples, err := sampler.Collect(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.healthy, findSample(samples, ComponentConsumer).Healthy)
+		})
+	}
+}
+
+func TestSampler_APIDownWhenDatabaseUnreachable(t *testing.T) {
+	cursor := func(ctx context.Context) (int64, error) { return time.Now().UnixMicro(), nil }
+	sampler, _, _ := newTestSampler(t, fakeDB{err: errors.New("connection refused")}, cursor, SamplerConfig{})
+
+	samples, err := sampler.Collect(context.Background())
+	require.NoError(t, err)
+	assert.False(t, findSample(samples, ComponentAPI).Healthy)
+}
+
+func TestSampler_RunStoresAndPrunes(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	cursor := func(ctx context.Context) (int64, error) { return now.UnixMicro(), nil }
+	sampler, _, _ := newTestSampler(t, fakeDB{}, cursor, SamplerConfig{Instance: "api-1"})
+	sampler.now = func() time.Time { return now }
+
+	sampler.Run(context.Background())
+
+	store := sampler.store.(*fakeStore)
+	assert.Len(t, store.samples, 3)
+	assert.Equal(t, "api-1", store.samples[0].Instance)
+	assert.Equal(t, now.Add(-RetentionPeriod), store.before)
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Disk keeps blobs as files in a directory. Its signed URLs point at the
+// app, which checks them with Verify and serves the blob.
+type Disk struct {
+	dir        string
+	signingKey []byte
+	urlPrefix  string
+}
+
+// NewDisk creates a disk store in dir, creating the directory if needed.
+// Without a signing key, a random one is used, so URLs stop working on
+// restart and only work on the replica that signed them.
+func NewDisk(dir string, signingKey []byte, urlPrefix string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	if len(signingKey) == 0 {
+		log.Printf("Warning: STORAGE_SIGNING_KEY is not set, download URLs only work until restart")
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+	}
+	return &Disk{dir: dir, signingKey: signingKey, urlPrefix: urlPrefix}, nil
+}
+
+// path returns the file of a key
+func (d *Disk) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(d.dir, filepath.FromSlash(key)), nil
+}
+
+// Put implements Store. The blob is written to a temporary file first, so
+// readers never see part of it.
+func (d *Disk) Put(ctx context.Context, key, contentType string, body []byte) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+// Open implements Store
+func (d *Disk) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return f, nil
+}
+
+// Delete implements Store
+func (d *Disk) Delete(ctx context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+// SignedURL implements Store. The URL is the key under the URL prefix, with
+// its expiry time and an HMAC of both.
+func (d *Disk) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	query := url.Values{"expires": {expiresAt}, "signature": {d.sign(key, expiresAt)}}
+	return d.urlPrefix + key + "?" + query.Encode(), nil
+}
+
+// Verify reports whether a signed URL's expiry time and signature are valid
+// for a key at now
+func (d *Disk) Verify(key, expires, signature string, now time.Time) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(d.sign(key, expires)))
+}
+
+// sign returns the signature of a key and expiry time
+func (d *Disk) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, d.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,113 @@
+// Package storage keeps generated artifacts, such as response exports, as
+// blobs on local disk or in an S3-compatible bucket (AWS S3, MinIO), and
+// hands out signed URLs to download them without going through the API.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ErrNotFound is returned when a blob does not exist
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidKey is returned for keys that are not made of lowercase letters,
+// digits, and ".-_" segments separated by "/"
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// keyPattern matches valid keys, e.g. "exports/2d1c…/responses.csv"
+var keyPattern = regexp.MustCompile(`^[a-z0-9_-][a-z0-9._-]*(/[a-z0-9_-][a-z0-9._-]*)*$`)
+
+// ValidKey reports whether a key can name a blob. Keys can't escape the
+// storage directory or need escaping in URLs.
+func ValidKey(key string) bool {
+	return len(key) <= 512 && keyPattern.MatchString(key)
+}
+
+// Store keeps blobs by key
+type Store interface {
+	// Put stores a blob, replacing any blob with the same key
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	// Open reads a blob, returning ErrNotFound if it does not exist
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete deletes a blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads a blob until it expires
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// Backends
+const (
+	BackendDisk = "disk"
+	BackendS3   = "s3"
+)
+
+// Config configures the blob store
+type Config struct {
+	Backend string // BackendDisk or BackendS3
+
+	Dir        string // Directory of the disk backend
+	SigningKey string // Secret signing the disk backend's URLs
+	URLPrefix  string // Absolute URL the disk backend's blobs are served under
+
+	S3 S3Config
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - STORAGE_BACKEND: "disk" (default) or "s3"
+//   - STORAGE_DIR: directory of the disk backend (default: ./data/blobs)
+//   - STORAGE_SIGNING_KEY: secret signing disk download URLs (default: random per process)
+//   - S3_ENDPOINT: endpoint of the S3 API (default: https://s3.<region>.amazonaws.com)
+//   - S3_REGION: bucket region (default: us-east-1)
+//   - S3_BUCKET, S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY: bucket and credentials
+//   - S3_PATH_STYLE: "true" to address the bucket in the path, as MinIO needs
+func ConfigFromEnv() Config {
+	config := Config{
+		Backend:    os.Getenv("STORAGE_BACKEND"),
+		Dir:        os.Getenv("STORAGE_DIR"),
+		SigningKey: os.Getenv("STORAGE_SIGNING_KEY"),
+		S3: S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		},
+	}
+	if config.Backend == "" {
+		config.Backend = BackendDisk
+	}
+	if config.Dir == "" {
+		config.Dir = "./data/blobs"
+	}
+	if config.S3.Region == "" {
+		config.S3.Region = "us-east-1"
+	}
+	if v := os.Getenv("S3_PATH_STYLE"); v != "" {
+		pathStyle, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Printf("Warning: Invalid S3_PATH_STYLE %q, using virtual-hosted buckets", v)
+		}
+		config.S3.PathStyle = pathStyle
+	}
+	return config
+}
+
+// New creates the store of a config
+func New(config Config) (Store, error) {
+	switch config.Backend {
+	case BackendDisk:
+		return NewDisk(config.Dir, []byte(config.SigningKey), config.URLPrefix)
+	case BackendS3:
+		return NewS3(config.S3)
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", config.Backend)
+}
@@ -0,0 +1,156 @@
+package surveyctl
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/pkg/client"
+)
+
+// Results converts results returned by the API, whose types mirror its JSON,
+// to the service's results model
+func Results(r *client.Results) (*models.SurveyResults, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var results models.SurveyResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode results: %w", err)
+	}
+	return &results, nil
+}
+
+// WriteResultsCSV writes the counts of survey results as CSV, one row per
+// option of each choice question (per row and option for matrix questions)
+// and one per text, number, or date question with its number of answers.
+// Question and option texts come from the survey definition.
+func WriteResultsCSV(w io.Writer, def *models.SurveyDefinition, results *models.SurveyResults) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"question_id", "question", "row_id", "row", "option_id", "option", "count"}); err != nil {
+		return err
+	}
+
+	questions := make(map[string]*models.Question, len(def.Questions))
+	for i := range def.Questions {
+		questions[def.Questions[i].ID] = &def.Questions[i]
+	}
+
+	for _, qr := range results.OrderedQuestionResults() {
+		q := questions[qr.QuestionID]
+		questionText := ""
+		if q != nil {
+			questionText = q.Text
+		}
+		record := func(rowID, optionID string, count int) error {
+			return cw.Write([]string{
+				qr.QuestionID, questionText,
+				rowID, optionText(q, qr, rowID, true),
+				optionID, optionText(q, qr, optionID, false),
+				strconv.Itoa(count),
+			})
+		}
+
+		switch {
+		case qr.RowCounts != nil:
+			for _, rowID := range sortedIDs(q, qr.RowCounts, true) {
+				counts := qr.RowCounts[rowID]
+				for _, optionID := range sortedIDs(q, counts, false) {
+					if err := record(rowID, optionID, counts[optionID]); err != nil {
+						return err
+					}
+				}
+			}
+		case len(qr.OptionCounts) > 0:
+			for _, optionID := range sortedIDs(q, qr.OptionCounts, false) {
+				if err := record("", optionID, qr.OptionCounts[optionID]); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := record("", "", len(qr.TextAnswers)); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// optionText returns the text of an option or matrix row of question q, from
+// its removed options if the definition no longer has it
+func optionText(q *models.Question, qr *models.QuestionResult, id string, row bool) string {
+	if id == "" {
+		return ""
+	}
+	if q != nil {
+		options := q.Options
+		if row {
+			options = q.Rows
+		}
+		for _, opt := range options {
+			if opt.ID == id {
+				return opt.Text
+			}
+		}
+	}
+	if text, ok := qr.RemovedOptions[id]; ok {
+		return text
+	}
+	return id
+}
+
+// sortedIDs returns the keys of counts in the order of the options (or rows)
+// of question q, followed by those it no longer has in alphabetical order
+func sortedIDs[V any](q *models.Question, counts map[string]V, row bool) []string {
+	position := make(map[string]int)
+	if q != nil {
+		options := q.Options
+		if row {
+			options = q.Rows
+		}
+		for i, opt := range options {
+			position[opt.ID] = i + 1
+		}
+	}
+
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		pi, pj := position[ids[i]], position[ids[j]]
+		if pi != pj {
+			if pi == 0 || pj == 0 {
+				return pj == 0
+			}
+			return pi < pj
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// Summary is a one-line summary of survey results, for watching them
+func Summary(results *models.SurveyResults) string {
+	summary := fmt.Sprintf("%d responses", results.TotalVotes)
+	for _, qr := range results.OrderedQuestionResults() {
+		if len(qr.OptionCounts) == 0 {
+			continue
+		}
+		leader, most := "", -1
+		for _, id := range sortedIDs(nil, qr.OptionCounts, false) {
+			if qr.OptionCounts[id] > most {
+				leader, most = id, qr.OptionCounts[id]
+			}
+		}
+		summary += fmt.Sprintf(" · %s: %s (%d)", qr.QuestionID, leader, most)
+	}
+	return summary
+}
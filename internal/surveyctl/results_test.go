@@ -0,0 +1,48 @@
+package surveyctl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteResultsCSV(t *testing.T) {
+	def := &models.SurveyDefinition{Questions: []models.Question{
+		{ID: "lunch", Text: "Lunch?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "pizza", Text: "Pizza"}, {ID: "salad", Text: "Salad"}}},
+		{ID: "rate", Text: "Rate", Type: models.QuestionTypeMatrix, Options: []models.Option{{ID: "good", Text: "Good"}}, Rows: []models.Option{{ID: "food", Text: "Food"}}},
+		{ID: "why", Text: "Why?", Type: models.QuestionTypeText},
+	}}
+	results := &models.SurveyResults{TotalVotes: 3, QuestionResults: map[string]*models.QuestionResult{
+		"lunch": {QuestionID: "lunch", Ordinal: 1, OptionCounts: map[string]int{"salad": 1, "pizza": 1, "soup": 1}, RemovedOptions: map[string]string{"soup": "Soup"}},
+		"rate":  {QuestionID: "rate", Ordinal: 2, RowCounts: map[string]map[string]int{"food": {"good": 2}}},
+		"why":   {QuestionID: "why", Ordinal: 3, TextAnswers: []string{"hungry", "tasty"}},
+	}}
+
+	var b strings.Builder
+	require.NoError(t, WriteResultsCSV(&b, def, results))
+	assert.Equal(t, `question_id,question,row_id,row,option_id,option,count
+lunch,Lunch?,,,pizza,Pizza,1
+lunch,Lunch?,,,salad,Salad,1
+lunch,Lunch?,,,soup,Soup,1
+rate,Rate,food,Food,good,Good,2
+why,Why?,,,,,2
+`, b.String())
+
+	assert.Equal(t, "3 responses · lunch: pizza (1)", Summary(results))
+}
+
+func TestResults(t *testing.T) {
+	results, err := Results(&client.Results{TotalVotes: 2, QuestionResults: map[string]*client.QuestionResult{
+		"lunch": {QuestionID: "lunch", Ordinal: 1, OptionCounts: map[string]int{"soup": 2}, RemovedOptions: map[string]string{"soup": "Soup"}},
+		"age":   {QuestionID: "age", Ordinal: 2, Histogram: []byte(`[{"min": 20, "max": 30, "count": 2}]`)},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.TotalVotes)
+	assert.Equal(t, "Soup", results.QuestionResults["lunch"].RemovedOptions["soup"])
+	require.Len(t, results.QuestionResults["age"].Histogram, 1)
+	assert.Equal(t, 2, results.QuestionResults["age"].Histogram[0].Count)
+}
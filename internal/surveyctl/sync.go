@@ -0,0 +1,204 @@
+// Package surveyctl implements the commands of surveyctl on top of the API
+// client in pkg/client: syncing a directory of survey definitions, and
+// printing results.
+package surveyctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/pkg/client"
+)
+
+// Kinds of planned changes
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionArchive = "archive"
+)
+
+// Action is a change that brings a survey in line with its definition file
+type Action struct {
+	Kind       string
+	Slug       string
+	File       string                    // Definition file; empty when archiving
+	Definition string                    // Contents of File
+	Changes    []models.DefinitionChange // Changes of an update
+}
+
+// SyncPlan is the set of changes that makes the surveys of the API key's
+// owner match a directory of definition files
+type SyncPlan struct {
+	Actions []Action
+	Skipped []string // Notes on surveys left alone, e.g. published ones
+}
+
+// Empty reports whether the surveys already match the directory
+func (p *SyncPlan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+// Plan reads the YAML and JSON survey definitions in dir, one survey per file
+// named after its slug, and compares them with the surveys of the API key's
+// owner. Surveys without a file are archived unless already closed. Surveys
+// published to a PDS are edited through their record and left alone.
+func Plan(ctx context.Context, c *client.Client, dir string) (*SyncPlan, error) {
+	files, err := definitionFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	surveys, err := c.ListSurveys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remote := make(map[string]*client.Survey, len(surveys))
+	for i := range surveys {
+		remote[surveys[i].Slug] = &surveys[i]
+	}
+
+	plan := &SyncPlan{}
+	for _, slug := range sortedKeys(files) {
+		path := files[slug]
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		def, err := models.ParseSurveyDefinition(data)
+		if err == nil {
+			err = def.ValidateDefinition()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		def.StripImages()
+
+		existing, ok := remote[slug]
+		switch {
+		case !ok:
+			plan.Actions = append(plan.Actions, Action{Kind: ActionCreate, Slug: slug, File: path, Definition: string(data)})
+		case existing.URI != nil:
+			plan.Skipped = append(plan.Skipped, fmt.Sprintf("%s is published; edit its record instead", slug))
+		default:
+			// Lists leave out definitions
+			current, err := c.GetSurvey(ctx, slug)
+			if err != nil {
+				return nil, err
+			}
+			currentDef, err := Definition(current)
+			if err != nil {
+				return nil, err
+			}
+			if changes := models.DiffDefinitions(currentDef, def); len(changes) > 0 {
+				plan.Actions = append(plan.Actions, Action{Kind: ActionUpdate, Slug: slug, File: path, Definition: string(data), Changes: changes})
+			}
+		}
+	}
+
+	now := time.Now()
+	for _, s := range surveys {
+		if _, ok := files[s.Slug]; ok || s.URI != nil {
+			continue
+		}
+		if s.EndsAt != nil && !s.EndsAt.After(now) {
+			continue
+		}
+		plan.Actions = append(plan.Actions, Action{Kind: ActionArchive, Slug: s.Slug})
+	}
+	return plan, nil
+}
+
+// Apply carries out a plan in order, stopping at the first failure
+func Apply(ctx context.Context, c *client.Client, plan *SyncPlan) error {
+	for _, a := range plan.Actions {
+		var err error
+		switch a.Kind {
+		case ActionCreate:
+			_, err = c.CreateSurvey(ctx, a.Slug, a.Definition)
+		case ActionUpdate:
+			_, err = c.UpdateSurvey(ctx, a.Slug, a.Definition)
+		case ActionArchive:
+			_, err = c.ArchiveSurvey(ctx, a.Slug)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", a.Kind, a.Slug, err)
+		}
+	}
+	return nil
+}
+
+// WritePlan writes a plan for review before applying it
+func WritePlan(w io.Writer, plan *SyncPlan) {
+	counts := make(map[string]int)
+	for _, a := range plan.Actions {
+		counts[a.Kind]++
+		switch a.Kind {
+		case ActionCreate:
+			fmt.Fprintf(w, "+ create %s (%s)\n", a.Slug, a.File)
+		case ActionUpdate:
+			fmt.Fprintf(w, "~ update %s (%s)\n", a.Slug, a.File)
+			for _, change := range a.Changes {
+				fmt.Fprintf(w, "    %s\n", change.Describe())
+			}
+		case ActionArchive:
+			fmt.Fprintf(w, "- archive %s\n", a.Slug)
+		}
+	}
+	for _, note := range plan.Skipped {
+		fmt.Fprintf(w, "! %s\n", note)
+	}
+
+	if plan.Empty() {
+		fmt.Fprintln(w, "No changes. Surveys match the definitions.")
+		return
+	}
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to archive.\n",
+		counts[ActionCreate], counts[ActionUpdate], counts[ActionArchive])
+}
+
+// Definition decodes the definition of a survey returned by the API
+func Definition(s *client.Survey) (*models.SurveyDefinition, error) {
+	var def models.SurveyDefinition
+	if err := json.Unmarshal(s.Definition, &def); err != nil {
+		return nil, fmt.Errorf("failed to decode the definition of %s: %w", s.Slug, err)
+	}
+	return &def, nil
+}
+
+// definitionFiles returns the definition files directly in dir by slug
+func definitionFiles(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		slug := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if other, ok := files[slug]; ok {
+			return nil, fmt.Errorf("%s and %s define the same survey", filepath.Base(other), entry.Name())
+		}
+		files[slug] = filepath.Join(dir, entry.Name())
+	}
+	return files, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
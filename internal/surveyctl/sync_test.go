@@ -0,0 +1,94 @@
+package surveyctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanAndApply(t *testing.T) {
+	lunch := &models.SurveyDefinition{Questions: []models.Question{{ID: "q1", Text: "Where for lunch?", Type: models.QuestionTypeText}}}
+	uri := "at://did:plc:alice/net.openmeet.survey/published"
+	yesterday := time.Now().Add(-24 * time.Hour)
+	remote := []client.Survey{
+		{Slug: "lunch", Title: "Where for lunch?"},
+		{Slug: "unchanged", Title: "Where for lunch?"},
+		{Slug: "published", URI: &uri},
+		{Slug: "old"},
+		{Slug: "closed", EndsAt: &yesterday},
+	}
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/surveys":
+			json.NewEncoder(w).Encode(remote)
+		case "GET /api/v1/surveys/lunch", "GET /api/v1/surveys/unchanged":
+			json.NewEncoder(w).Encode(map[string]interface{}{"definition": lunch})
+		default:
+			json.NewEncoder(w).Encode(client.Survey{})
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("lunch.yaml", "questions:\n  - id: q1\n    text: Where for dinner?\n    type: text\n")
+	write("unchanged.yml", "questions:\n  - id: q1\n    text: Where for lunch?\n    type: text\n")
+	write("published.json", `{"questions": [{"id": "q1", "text": "New?", "type": "text"}]}`)
+	write("new.json", `{"questions": [{"id": "q1", "text": "New?", "type": "text"}]}`)
+	write("README.md", "not a survey")
+
+	c := client.New(server.URL, "sk_test")
+	plan, err := Plan(context.Background(), c, dir)
+	require.NoError(t, err)
+
+	var kinds []string
+	for _, a := range plan.Actions {
+		kinds = append(kinds, a.Kind+" "+a.Slug)
+	}
+	assert.Equal(t, []string{"update lunch", "create new", "archive old"}, kinds)
+	require.Len(t, plan.Actions[0].Changes, 1)
+	assert.Equal(t, models.ChangeQuestionText, plan.Actions[0].Changes[0].Kind)
+	assert.Len(t, plan.Skipped, 1, "published surveys are left alone")
+
+	var out bytes.Buffer
+	WritePlan(&out, plan)
+	assert.Contains(t, out.String(), "~ update lunch")
+	assert.Contains(t, out.String(), "Plan: 1 to create, 1 to update, 1 to archive.")
+
+	requests = nil
+	require.NoError(t, Apply(context.Background(), c, plan))
+	assert.Equal(t, []string{
+		"PUT /api/v1/surveys/lunch",
+		"POST /api/v1/surveys",
+		"POST /api/v1/surveys/old/archive",
+	}, requests)
+}
+
+func TestPlan_InvalidDefinition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.yaml"), []byte("questions: []\n"), 0o644))
+
+	_, err := Plan(context.Background(), client.New(server.URL, ""), dir)
+	assert.ErrorContains(t, err, "empty.yaml")
+}
@@ -0,0 +1,153 @@
+// Package surveylist sorts and filters an author's surveys, for the survey
+// list API and the browse page. Listings are always of one author, so
+// surveys still can't be discovered without a link.
+package surveylist
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Sort orders of survey listings
+const (
+	SortCreatedAt     = "created_at"
+	SortResponseCount = "response_count"
+	SortTitle         = "title"
+)
+
+// Statuses surveys can be filtered by
+const (
+	StatusOpen     = "open"     // Started, and not ended
+	StatusClosed   = "closed"   // Ended
+	StatusUpcoming = "upcoming" // Not started yet
+)
+
+// Errors of Parse
+var (
+	ErrInvalidSort   = errors.New("sort must be created_at, response_count, or title")
+	ErrInvalidOrder  = errors.New("order must be asc or desc")
+	ErrInvalidStatus = errors.New("status must be open, closed, or upcoming")
+	ErrInvalidFilter = errors.New("has_results must be true or false")
+)
+
+// Filter selects and orders the surveys of an author
+type Filter struct {
+	AuthorDID  string
+	Sort       string // SortCreatedAt, SortResponseCount, or SortTitle
+	Descending bool
+	Status     string // StatusOpen, StatusClosed, StatusUpcoming, or "" for any
+	HasResults *bool  // Whether the results were published, nil for any
+}
+
+// Item is a listed survey with its response count
+type Item struct {
+	Survey    *models.Survey
+	Responses int
+}
+
+// Facets counts an author's surveys by status and published results
+type Facets struct {
+	Total       int `json:"total"`
+	Open        int `json:"open"`
+	Closed      int `json:"closed"`
+	Upcoming    int `json:"upcoming"`
+	WithResults int `json:"withResults"`
+}
+
+// Store lists surveys
+type Store interface {
+	// ListAuthorSurveys lists the surveys of filter.AuthorDID that are not in the
+	// trash, with statuses as of now
+	ListAuthorSurveys(ctx context.Context, filter Filter, now time.Time) ([]*Item, error)
+	// GetSurveyFacets counts the surveys of an author that are not in the trash
+	GetSurveyFacets(ctx context.Context, authorDID string, now time.Time) (*Facets, error)
+}
+
+// Parse reads a filter from the author, sort, order, status, and has_results
+// query parameters. Surveys are sorted oldest first by default; response
+// counts are sorted highest first and titles alphabetically unless order is
+// given. The caller decides whose surveys an empty author lists.
+func Parse(query url.Values) (Filter, error) {
+	filter := Filter{AuthorDID: query.Get("author"), Sort: SortCreatedAt}
+
+	switch sort := query.Get("sort"); sort {
+	case "":
+	case SortCreatedAt, SortTitle:
+		filter.Sort = sort
+	case SortResponseCount:
+		filter.Sort = sort
+		filter.Descending = true
+	default:
+		return Filter{}, ErrInvalidSort
+	}
+
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		filter.Descending = false
+	case "desc":
+		filter.Descending = true
+	default:
+		return Filter{}, ErrInvalidOrder
+	}
+
+	switch status := query.Get("status"); status {
+	case "", StatusOpen, StatusClosed, StatusUpcoming:
+		filter.Status = status
+	default:
+		return Filter{}, ErrInvalidStatus
+	}
+
+	if v := query.Get("has_results"); v != "" {
+		hasResults, err := strconv.ParseBool(v)
+		if err != nil {
+			return Filter{}, ErrInvalidFilter
+		}
+		filter.HasResults = &hasResults
+	}
+
+	return filter, nil
+}
+
+// Query returns the query parameters of a filter, leaving out defaults, so
+// links keep the other facets
+func (f Filter) Query() url.Values {
+	query := url.Values{}
+	if f.AuthorDID != "" {
+		query.Set("author", f.AuthorDID)
+	}
+	if f.Sort != SortCreatedAt {
+		query.Set("sort", f.Sort)
+	}
+	if f.Descending != (f.Sort == SortResponseCount) {
+		if f.Descending {
+			query.Set("order", "desc")
+		} else {
+			query.Set("order", "asc")
+		}
+	}
+	if f.Status != "" {
+		query.Set("status", f.Status)
+	}
+	if f.HasResults != nil {
+		query.Set("has_results", strconv.FormatBool(*f.HasResults))
+	}
+	return query
+}
+
+// Status returns the status of a survey at a time
+func Status(survey *models.Survey, now time.Time) string {
+	switch {
+	case survey.IsClosed(now):
+		return StatusClosed
+	case survey.StartsAt != nil && now.Before(*survey.StartsAt):
+		return StatusUpcoming
+	default:
+		return StatusOpen
+	}
+}
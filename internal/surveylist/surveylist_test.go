@@ -0,0 +1,69 @@
+package surveylist
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	parse := func(query string) Filter {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		filter, err := Parse(values)
+		require.NoError(t, err, query)
+		return filter
+	}
+
+	assert.Equal(t, Filter{Sort: SortCreatedAt}, parse(""), "oldest first by default")
+	assert.Equal(t, Filter{Sort: SortResponseCount, Descending: true}, parse("sort=response_count"))
+	assert.Equal(t, Filter{Sort: SortResponseCount}, parse("sort=response_count&order=asc"))
+	assert.Equal(t, Filter{Sort: SortTitle, Descending: true}, parse("sort=title&order=desc"))
+
+	filter := parse("author=did:plc:alice&status=closed&has_results=false")
+	assert.Equal(t, "did:plc:alice", filter.AuthorDID)
+	assert.Equal(t, StatusClosed, filter.Status)
+	require.NotNil(t, filter.HasResults)
+	assert.False(t, *filter.HasResults)
+
+	for query, want := range map[string]error{
+		"sort=slug":          ErrInvalidSort,
+		"order=up":           ErrInvalidOrder,
+		"status=archived":    ErrInvalidStatus,
+		"has_results=maybe":  ErrInvalidFilter,
+		"sort=title&order=1": ErrInvalidOrder,
+	} {
+		values, _ := url.ParseQuery(query)
+		_, err := Parse(values)
+		assert.ErrorIs(t, err, want, query)
+	}
+}
+
+func TestFilterQuery(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"sort=response_count",
+		"order=asc&sort=response_count&status=open",
+		"author=did%3Aplc%3Aalice&has_results=true&sort=title",
+	} {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		filter, err := Parse(values)
+		require.NoError(t, err)
+		assert.Equal(t, query, filter.Query().Encode(), "defaults are left out")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	assert.Equal(t, StatusOpen, Status(&models.Survey{}, now))
+	assert.Equal(t, StatusOpen, Status(&models.Survey{StartsAt: &past, EndsAt: &future}, now))
+	assert.Equal(t, StatusUpcoming, Status(&models.Survey{StartsAt: &future}, now))
+	assert.Equal(t, StatusClosed, Status(&models.Survey{EndsAt: &past}, now))
+}
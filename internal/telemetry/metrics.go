@@ -35,7 +35,7 @@ var (
 			Name: "survey_jetstream_records_processed_total",
 			Help: "Total number of ATProto records processed from Jetstream",
 		},
-		[]string{"collection", "operation", "status"}, // status: "success" or "error"
+		[]string{"collection", "operation", "status"}, // status: "success", "error", or "rejected"
 	)
 
 	// JetstreamCursorLag tracks time since last processed event
@@ -65,11 +65,76 @@ var (
 	)
 
 	// JetstreamReconnects tracks reconnection attempts
-	JetstreamReconnects = promauto.NewCounter(
+	// Labels: reason (dial_error, stale, server_closed, read_error, processing_error)
+	JetstreamReconnects = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "survey_jetstream_reconnects_total",
 			Help: "Total number of Jetstream reconnection attempts",
 		},
+		[]string{"reason"},
+	)
+
+	// JetstreamQueueDepth tracks messages waiting in each consumer priority tier
+	JetstreamQueueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "survey_jetstream_queue_depth",
+			Help: "Number of Jetstream messages waiting to be processed, per priority tier",
+		},
+		[]string{"tier"}, // tier: "high" (locally known survey) or "low"
+	)
+
+	// JetstreamQueueWait tracks how long messages wait before processing starts
+	JetstreamQueueWait = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "survey_jetstream_queue_wait_seconds",
+			Help:    "Time a Jetstream message spends queued before processing, per priority tier",
+			Buckets: []float64{.001, .01, .1, .5, 1, 5, 15, 60, 300},
+		},
+		[]string{"tier"},
+	)
+
+	// JetstreamTierRecordsProcessed tracks processed records per priority tier
+	JetstreamTierRecordsProcessed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_jetstream_tier_records_processed_total",
+			Help: "Total number of Jetstream records processed, per priority tier",
+		},
+		[]string{"tier", "status"}, // status: "success", "error", or "rejected"
+	)
+
+	// ConsumerLeader reports whether this consumer instance holds leadership and reads Jetstream
+	ConsumerLeader = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "survey_consumer_leader",
+			Help: "Whether this consumer instance is the leader consuming Jetstream (1=leader, 0=standby)",
+		},
+	)
+
+	// ConsumerLeadershipChanges tracks leadership transitions of this consumer instance
+	ConsumerLeadershipChanges = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_consumer_leadership_changes_total",
+			Help: "Total number of consumer leadership transitions of this instance",
+		},
+		[]string{"event"}, // event: "acquired", "lost", or "released"
+	)
+
+	// ConsumerRetries tracks messages queued for a retry after their processing failed
+	ConsumerRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_consumer_retries_total",
+			Help: "Total number of consumer messages queued for, retried from, or dropped from the retry queue",
+		},
+		[]string{"event"}, // event: "queued", "succeeded", "failed", or "abandoned"
+	)
+
+	// JetstreamSchemaViolations tracks records that failed lexicon validation
+	JetstreamSchemaViolations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_jetstream_schema_violations_total",
+			Help: "Total number of Jetstream records that failed lexicon validation",
+		},
+		[]string{"collection", "action"}, // action: "rejected" (strict) or "logged" (permissive)
 	)
 
 	// Business metrics for ATProto records
@@ -125,6 +190,170 @@ var (
 		},
 	)
 
+	// PDS write metrics
+
+	// PDSWritesTotal tracks record writes and blob uploads to user PDSes
+	// Labels: operation (create, update, delete, upload_blob), status (success, error)
+	PDSWritesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_pds_writes_total",
+			Help: "Total number of record writes to user PDSes",
+		},
+		[]string{"operation", "status"},
+	)
+
+	// PDSWriteFallbacksTotal tracks surveys and responses saved locally only because their PDS write failed
+	// Labels: kind (survey, response), reason (write_error, token_refresh)
+	PDSWriteFallbacksTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_pds_write_fallbacks_total",
+			Help: "Total number of surveys and responses saved locally only because their PDS write failed",
+		},
+		[]string{"kind", "reason"},
+	)
+
+	// PDSOutboxRetriesTotal tracks user retries of failed PDS writes
+	// Labels: kind (survey, response), status (success, error)
+	PDSOutboxRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_pds_outbox_retries_total",
+			Help: "Total number of user retries of failed PDS writes",
+		},
+		[]string{"kind", "status"},
+	)
+
+	// API key metrics
+
+	// APIKeyRequestsTotal tracks API key authentication results
+	// Labels: result (allowed, rate_limited, unauthorized)
+	// Per-key counts are stored in api_key_usage for the usage dashboard
+	APIKeyRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_api_key_requests_total",
+			Help: "Total number of API requests by API key authentication result",
+		},
+		[]string{"result"},
+	)
+
+	// Moderation metrics
+
+	// ModerationChecksTotal tracks text answer moderation checks
+	// Labels: checker (blocklist, openai), result (clean, flagged, error)
+	ModerationChecksTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_moderation_checks_total",
+			Help: "Total number of text answer moderation checks",
+		},
+		[]string{"checker", "result"},
+	)
+
+	// Database replica metrics
+
+	// DBReadsTotal tracks where read-only queries routed to replicas were served
+	// Labels: target (replica, primary, fallback)
+	// primary means no replica was healthy; fallback means the replica failed or had not replicated the row yet
+	DBReadsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_db_reads_total",
+			Help: "Total number of read-only queries by the database that served them",
+		},
+		[]string{"target"},
+	)
+
+	// DBReplicaHealthy reports whether each read replica is healthy (1) or not (0)
+	// Labels: replica (index in DATABASE_REPLICAS)
+	DBReplicaHealthy = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "survey_db_replica_healthy",
+			Help: "Whether a database read replica is healthy",
+		},
+		[]string{"replica"},
+	)
+
+	// Database connection pool metrics, sampled from sql.DBStats
+	// Labels: pool (primary, or replica0, replica1, ... in DATABASE_REPLICAS order)
+
+	// DBPoolConnections reports the connections of each pool
+	// Labels: state (in_use, idle, max_open)
+	DBPoolConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "survey_db_pool_connections",
+			Help: "Connections of a database connection pool, by state",
+		},
+		[]string{"pool", "state"},
+	)
+
+	// DBPoolWaitCount reports how many connections callers have waited for
+	DBPoolWaitCount = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "survey_db_pool_wait_count",
+			Help: "Total number of connections waited for since the pool was opened",
+		},
+		[]string{"pool"},
+	)
+
+	// DBPoolWaitDuration reports how long callers have waited for connections
+	DBPoolWaitDuration = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "survey_db_pool_wait_duration_seconds",
+			Help: "Total time spent waiting for connections since the pool was opened",
+		},
+		[]string{"pool"},
+	)
+
+	// DBPoolSaturation reports the share of the pool's connections in use
+	DBPoolSaturation = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "survey_db_pool_saturation",
+			Help: "Connections in use per maximum open connections (1 = saturated)",
+		},
+		[]string{"pool"},
+	)
+
+	// Cache metrics
+
+	// CacheRequestsTotal tracks lookups in the survey, results, and social card caches
+	// Labels: kind (survey, results, card), result (hit, miss, error)
+	CacheRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_cache_requests_total",
+			Help: "Total number of survey, results, and social card cache lookups",
+		},
+		[]string{"kind", "result"},
+	)
+
+	// Job queue metrics
+
+	// JobsProcessedTotal tracks runs of queued jobs
+	// Labels: type (e.g. export), result (success, retry, failed)
+	JobsProcessedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "survey_jobs_processed_total",
+			Help: "Total number of queued job runs by result",
+		},
+		[]string{"type", "result"},
+	)
+
+	// JobDuration tracks how long queued jobs run
+	JobDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "survey_job_duration_seconds",
+			Help:    "Time to run a queued job",
+			Buckets: []float64{.1, .5, 1, 5, 15, 30, 60, 300, 600},
+		},
+		[]string{"type"},
+	)
+
+	// JobsQueued reports the pending and running jobs, sampled every minute
+	// Labels: type, status (pending, running)
+	JobsQueued = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "survey_jobs_queued",
+			Help: "Number of pending and running jobs",
+		},
+		[]string{"type", "status"},
+	)
+
 	// Note: Removed UniqueVoters and UniqueSurveyAuthors gauges
 	// These require periodic DB queries to populate - use SQL queries in dashboards instead
 
@@ -1,5 +1,11 @@
 package templates
 
+import (
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
 // NoIndex controls whether search engines should index pages.
 // Default is true (block indexing). Set to false in production to allow indexing.
 var NoIndex = true
@@ -9,3 +15,134 @@ var NoIndex = true
 func SetNoIndex(val bool) {
 	NoIndex = val
 }
+
+// BasePath is the path prefix the app is served under ("" for the root).
+var BasePath = ""
+
+// SetBasePath sets the path prefix prepended to links.
+// Call this at startup based on environment configuration.
+func SetBasePath(path string) {
+	BasePath = path
+}
+
+// PublicURL is the public URL of the app including the base path, e.g.
+// https://example.com/survey ("" if unknown). Open Graph tags need absolute URLs.
+var PublicURL = ""
+
+// SetPublicURL sets the public URL used for absolute links.
+// Call this at startup based on environment configuration.
+func SetPublicURL(url string) {
+	if url != "" && !strings.Contains(url, "://") {
+		url = "https://" + url
+	}
+	PublicURL = strings.TrimSuffix(url, "/")
+}
+
+// AbsoluteURL returns the absolute URL of an app path, or the path under the
+// base path if the public URL is unknown
+func AbsoluteURL(path string) string {
+	if PublicURL == "" {
+		return AppPath(path)
+	}
+	return PublicURL + path
+}
+
+// AppPath returns an app path (e.g. "/surveys/new") under the base path
+func AppPath(path string) string {
+	return BasePath + path
+}
+
+// appURL returns a sanitized link to an app path under the base path
+func appURL(path string) templ.SafeURL {
+	return templ.URL(AppPath(path))
+}
+
+// SnapshotsEnabled controls whether links to results snapshots are shown.
+var SnapshotsEnabled = false
+
+// SetSnapshotsEnabled sets whether results snapshots are enabled.
+// Call this at startup when the snapshot routes are registered.
+func SetSnapshotsEnabled(val bool) {
+	SnapshotsEnabled = val
+}
+
+// OrgsEnabled controls whether links to organizations are shown.
+var OrgsEnabled = false
+
+// SetOrgsEnabled sets whether organizations are enabled.
+// Call this at startup when the organization routes are registered.
+func SetOrgsEnabled(val bool) {
+	OrgsEnabled = val
+}
+
+// CoAuthorsEnabled controls whether links to the co-authors of surveys are shown.
+var CoAuthorsEnabled = false
+
+// SetCoAuthorsEnabled sets whether survey co-authors are enabled.
+// Call this at startup when the co-author routes are registered.
+func SetCoAuthorsEnabled(val bool) {
+	CoAuthorsEnabled = val
+}
+
+// HeatmapEnabled controls whether links to the response heatmap of surveys are shown.
+var HeatmapEnabled = false
+
+// SetHeatmapEnabled sets whether the response heatmap is enabled.
+// Call this at startup when the heatmap routes are registered.
+func SetHeatmapEnabled(val bool) {
+	HeatmapEnabled = val
+}
+
+// TrashEnabled controls whether links to the trash of deleted surveys are shown.
+var TrashEnabled = false
+
+// SetTrashEnabled sets whether the trash is enabled.
+// Call this at startup when the trash routes are registered.
+func SetTrashEnabled(val bool) {
+	TrashEnabled = val
+}
+
+// QuestionBankEnabled controls whether the create page offers the question bank.
+var QuestionBankEnabled = false
+
+// SetQuestionBankEnabled sets whether the question bank is enabled.
+// Call this at startup when the question bank routes are registered.
+func SetQuestionBankEnabled(val bool) {
+	QuestionBankEnabled = val
+}
+
+// PreviewsEnabled controls whether the create page opens previews of the survey page.
+var PreviewsEnabled = false
+
+// SetPreviewsEnabled sets whether survey previews are enabled.
+// Call this at startup when the preview routes are registered.
+func SetPreviewsEnabled(val bool) {
+	PreviewsEnabled = val
+}
+
+// NotificationsEnabled controls whether links to notification settings are shown.
+var NotificationsEnabled = false
+
+// SetNotificationsEnabled sets whether milestone notifications are enabled.
+// Call this at startup when the notification routes are registered.
+func SetNotificationsEnabled(val bool) {
+	NotificationsEnabled = val
+}
+
+// SurveyListEnabled controls whether links to the browse page of a user's surveys are shown.
+var SurveyListEnabled = false
+
+// SetSurveyListEnabled sets whether the survey list is enabled.
+// Call this at startup when the survey list routes are registered.
+func SetSurveyListEnabled(val bool) {
+	SurveyListEnabled = val
+}
+
+// FeedsEnabled controls whether links to the Atom feeds of new surveys are shown.
+var FeedsEnabled = false
+
+// SetFeedsEnabled sets whether the Atom feeds are enabled.
+// Call this at startup when the feed routes are registered.
+func SetFeedsEnabled(val bool) {
+	FeedsEnabled = val
+}
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"context"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/draft"
 	"github.com/openmeet-team/survey/internal/oauth"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -43,7 +46,7 @@ func TestCreateSurvey_RendersWithoutError(t *testing.T) {
 			var buf bytes.Buffer
 			ctx := context.Background()
 
-			err := CreateSurvey(tt.user, tt.profile, tt.posthogKey, "").Render(ctx, &buf)
+			err := CreateSurvey(tt.user, tt.profile, tt.posthogKey, "", DraftState{}, nil).Render(ctx, &buf)
 			require.NoError(t, err, "Template should render without errors")
 
 			html := buf.String()
@@ -57,7 +60,7 @@ func TestCreateSurvey_ContainsAIGenerationUI(t *testing.T) {
 	var buf bytes.Buffer
 	ctx := context.Background()
 
-	err := CreateSurvey(nil, nil, "", "").Render(ctx, &buf)
+	err := CreateSurvey(nil, nil, "", "", DraftState{}, nil).Render(ctx, &buf)
 	require.NoError(t, err)
 
 	html := buf.String()
@@ -85,7 +88,7 @@ func TestCreateSurvey_ContainsGenerateHandlerScript(t *testing.T) {
 	var buf bytes.Buffer
 	ctx := context.Background()
 
-	err := CreateSurvey(nil, nil, "", "").Render(ctx, &buf)
+	err := CreateSurvey(nil, nil, "", "", DraftState{}, nil).Render(ctx, &buf)
 	require.NoError(t, err)
 
 	html := buf.String()
@@ -107,7 +110,7 @@ func TestCreateSurvey_TemplateMode(t *testing.T) {
 	ctx := context.Background()
 
 	templateJSON := `{"title":"Test Survey","questions":[{"id":"q1","text":"Test?","type":"single"}]}`
-	err := CreateSurvey(nil, nil, "", templateJSON).Render(ctx, &buf)
+	err := CreateSurvey(nil, nil, "", templateJSON, DraftState{}, nil).Render(ctx, &buf)
 	require.NoError(t, err)
 
 	html := buf.String()
@@ -133,7 +136,7 @@ func TestCreateSurvey_NormalMode(t *testing.T) {
 	var buf bytes.Buffer
 	ctx := context.Background()
 
-	err := CreateSurvey(nil, nil, "", "").Render(ctx, &buf)
+	err := CreateSurvey(nil, nil, "", "", DraftState{}, nil).Render(ctx, &buf)
 	require.NoError(t, err)
 
 	html := buf.String()
@@ -150,3 +153,72 @@ func TestCreateSurvey_NormalMode(t *testing.T) {
 	assert.NotContains(t, html, "Modify with AI", "Should NOT have modify heading")
 	assert.NotContains(t, html, "id=\"template-data\"", "Should NOT have template data script")
 }
+
+// TestCreateSurvey_DraftBanner ensures saved drafts are offered for resuming
+func TestCreateSurvey_DraftBanner(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := context.Background()
+
+	saved := []*draft.Draft{
+		{ID: uuid.New(), Title: "Where to ride?", UpdatedAt: time.Date(2026, 3, 2, 12, 30, 0, 0, time.UTC)},
+		{ID: uuid.New()},
+	}
+	err := CreateSurvey(nil, nil, "", "", DraftState{Enabled: true, Saved: saved}, nil).Render(ctx, &buf)
+	require.NoError(t, err)
+
+	html := buf.String()
+	assert.Contains(t, html, "Resume a draft?", "Should have resume banner")
+	assert.Contains(t, html, "/surveys/new?draft="+saved[0].ID.String(), "Should link to resume the draft")
+	assert.Contains(t, html, "Where to ride?", "Should name drafts by title")
+	assert.Contains(t, html, "Mar 2 12:30", "Should show when the draft was saved")
+	assert.Contains(t, html, "Untitled draft", "Should name drafts without a title")
+	assert.Contains(t, html, "id=\"draft-state\"", "Should enable autosave")
+	assert.NotContains(t, html, "data-content=", "Should not load a draft into the editor")
+}
+
+// TestCreateSurvey_ResumedDraft ensures a resumed draft is loaded into the editor
+func TestCreateSurvey_ResumedDraft(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := context.Background()
+
+	resumed := &draft.Draft{ID: uuid.New(), Format: draft.FormatYAML, Content: "questions:\n  - text: Lunch?\n", Slug: "lunch"}
+	err := CreateSurvey(nil, nil, "", "", DraftState{Enabled: true, Resumed: resumed}, nil).Render(ctx, &buf)
+	require.NoError(t, err)
+
+	html := buf.String()
+	assert.Contains(t, html, "data-format=\"yaml\"", "Should embed the draft format")
+	assert.Contains(t, html, "data-slug=\"lunch\"", "Should embed the draft slug")
+	assert.Contains(t, html, "Lunch?", "Should embed the draft content")
+	assert.Contains(t, html, "value=\""+resumed.ID.String()+"\"", "Should submit the draft ID with the form")
+	assert.NotContains(t, html, "Resume a draft?", "Should not offer other drafts")
+}
+
+// TestCreateSurvey_DraftsDisabled ensures no autosave without a draft store
+func TestCreateSurvey_DraftsDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := context.Background()
+
+	err := CreateSurvey(nil, nil, "", "", DraftState{}, nil).Render(ctx, &buf)
+	require.NoError(t, err)
+
+	html := buf.String()
+	assert.NotContains(t, html, "id=\"draft-state\"", "Should not autosave")
+	assert.NotContains(t, html, "Resume a draft?", "Should not have resume banner")
+}
+
+func TestCreateSurvey_QuestionBank(t *testing.T) {
+	SetQuestionBankEnabled(true)
+	defer SetQuestionBankEnabled(false)
+
+	render := func(user *oauth.User) string {
+		var buf bytes.Buffer
+		require.NoError(t, CreateSurvey(user, nil, "", "", DraftState{}, nil).Render(context.Background(), &buf))
+		return buf.String()
+	}
+
+	html := render(&oauth.User{DID: "did:plc:abc"})
+	assert.Contains(t, html, `id="question-bank"`)
+	assert.Contains(t, html, "/api/v1/me/questions")
+
+	assert.NotContains(t, render(nil), `id="question-bank"`, "guests have no bank")
+}
@@ -0,0 +1,29 @@
+package templates
+
+import "github.com/openmeet-team/survey/internal/draft"
+
+// maxBannerDrafts is the number of drafts offered in the resume banner
+const maxBannerDrafts = 5
+
+// DraftState holds the drafts of the create survey page
+type DraftState struct {
+	Enabled bool           // Autosave the editor content as a draft
+	Resumed *draft.Draft   // Draft loaded into the editor with ?draft=
+	Saved   []*draft.Draft // The creator's drafts, offered in the resume banner
+}
+
+// bannerDrafts returns the drafts offered in the resume banner, most recent first
+func (s DraftState) bannerDrafts() []*draft.Draft {
+	if len(s.Saved) > maxBannerDrafts {
+		return s.Saved[:maxBannerDrafts]
+	}
+	return s.Saved
+}
+
+// draftTitle names a draft in the resume banner
+func draftTitle(d *draft.Draft) string {
+	if d.Title == "" {
+		return "Untitled draft"
+	}
+	return d.Title
+}
@@ -0,0 +1,194 @@
+package templates
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/i18n"
+	"github.com/openmeet-team/survey/internal/identity"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/oauth"
+	"github.com/openmeet-team/survey/internal/provenance"
+	"github.com/openmeet-team/survey/internal/trending"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the golden files with the current output:
+//
+//	go test ./internal/templates -run TestGolden -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+var (
+	spaceRegex          = regexp.MustCompile(`\s+`)
+	tagSpaceRegex       = regexp.MustCompile(`\s*(<|>)\s*`)
+	idempotencyKeyRegex = regexp.MustCompile(`(Idempotency-Key&#34;: &#34;)[0-9a-f-]{36}`)
+)
+
+// normalizeHTML puts each tag on its own line, so golden diffs point at the
+// markup that changed. Whitespace around tags is dropped and other runs of
+// whitespace collapse to a space, as the templ generator's formatting of
+// whitespace is not what these tests are about. Generated idempotency keys
+// are zeroed.
+func normalizeHTML(html string) string {
+	html = idempotencyKeyRegex.ReplaceAllString(html, "${1}00000000-0000-0000-0000-000000000000")
+	html = spaceRegex.ReplaceAllString(html, " ")
+	html = tagSpaceRegex.ReplaceAllString(html, "$1")
+	return strings.TrimSpace(strings.ReplaceAll(html, "><", ">\n<")) + "\n"
+}
+
+// assertGolden compares a component's normalized HTML with the golden file
+// testdata/golden/<name>.html, or rewrites the file with -update
+func assertGolden(t *testing.T, name string, component templ.Component) {
+	t.Helper()
+	html, err := Render(context.Background(), component)
+	require.NoError(t, err)
+	got := normalizeHTML(html)
+
+	path := filepath.Join("testdata", "golden", name+".html")
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+		return
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "missing golden file; run go test ./internal/templates -run TestGolden -update")
+	assert.Equal(t, string(want), got, "%s differs from its golden file; if the change is intended, run go test ./internal/templates -run TestGolden -update and review the diff", path)
+}
+
+// goldenTime is when every fixture happened, so pages render the same each run
+var goldenTime = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// goldenSurvey returns a survey with a question of every type
+func goldenSurvey() *models.Survey {
+	author := "did:plc:author"
+	description := "Help us plan the spring meetup."
+	return &models.Survey{
+		ID:          uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+		Slug:        "spring-meetup",
+		Title:       "Spring Meetup",
+		Description: &description,
+		AuthorDID:   &author,
+		Version:     1,
+		Definition: models.SurveyDefinition{
+			Questions: []models.Question{
+				{ID: "day", Text: "Which day suits you?", Type: models.QuestionTypeSingle, Required: true, Options: []models.Option{{ID: "sat", Text: "Saturday"}, {ID: "sun", Text: "Sunday"}}},
+				{ID: "topics", Text: "Which topics interest you?", Type: models.QuestionTypeMulti, Options: []models.Option{{ID: "go", Text: "Go"}, {ID: "web", Text: "Web"}, {ID: "ops", Text: "Ops"}}},
+				{ID: "rate", Text: "How did the last meetups go?", Type: models.QuestionTypeMatrix, Options: []models.Option{{ID: "good", Text: "Good"}, {ID: "bad", Text: "Bad"}}, Rows: []models.Option{{ID: "talks", Text: "Talks"}, {ID: "food", Text: "Food"}}},
+				{ID: "people", Text: "How many people will you bring?", Type: models.QuestionTypeNumber, Min: "0", Max: "5"},
+				{ID: "arrive", Text: "When will you arrive?", Type: models.QuestionTypeDate},
+				{ID: "leave", Text: "When will you leave?", Type: models.QuestionTypeDateTime},
+				{ID: "notes", Text: "Anything else?", Type: models.QuestionTypeText, MinLength: 5, MaxLength: 500},
+				{ID: "talk", Text: "Slides of your lightning talk", Type: models.QuestionTypeFile},
+			},
+		},
+		CreatedAt: goldenTime,
+		UpdatedAt: goldenTime,
+	}
+}
+
+// goldenResults returns results of the golden survey
+func goldenResults(survey *models.Survey) *models.SurveyResults {
+	return &models.SurveyResults{
+		SurveyID:   survey.ID,
+		TotalVotes: 4,
+		Version:    1,
+		QuestionResults: map[string]*models.QuestionResult{
+			"day":    {QuestionID: "day", Ordinal: 1, Respondents: 4, OptionCounts: map[string]int{"sat": 3, "sun": 1}, TextAnswers: []string{}},
+			"topics": {QuestionID: "topics", Ordinal: 2, Respondents: 3, OptionCounts: map[string]int{"go": 3, "web": 1}, TextAnswers: []string{}},
+			"rate": {QuestionID: "rate", Ordinal: 3, Respondents: 2, OptionCounts: map[string]int{}, TextAnswers: []string{}, RowCounts: map[string]map[string]int{
+				"talks": {"good": 2},
+				"food":  {"good": 1, "bad": 1},
+			}},
+			"people": {QuestionID: "people", Ordinal: 4, Respondents: 3, OptionCounts: map[string]int{}, TextAnswers: []string{}, Histogram: []models.Bucket{{Min: 0, Max: 1, Count: 1}, {Min: 1, Max: 2, Count: 2}}},
+			"arrive": {QuestionID: "arrive", Ordinal: 5, Respondents: 2, OptionCounts: map[string]int{}, TextAnswers: []string{}, Dates: &models.DateSummary{Earliest: "2026-04-01", Latest: "2026-04-02", MostCommon: "2026-04-01", MostCommonCount: 1}},
+			"leave":  {QuestionID: "leave", Ordinal: 6, OptionCounts: map[string]int{}, TextAnswers: []string{}},
+			"notes":  {QuestionID: "notes", Ordinal: 7, Respondents: 2, OptionCounts: map[string]int{}, TextAnswers: []string{"See you there!", "Vegetarian food, please <3"}},
+			"talk":   {QuestionID: "talk", Ordinal: 8, Respondents: 1, OptionCounts: map[string]int{}, TextAnswers: []string{}},
+		},
+	}
+}
+
+func goldenUser() (*oauth.User, *oauth.Profile) {
+	return &oauth.User{DID: "did:plc:voter"}, &oauth.Profile{DID: "did:plc:voter", Handle: "voter.example.com", DisplayName: "Vera Voter"}
+}
+
+func goldenAuthor() (*identity.Identity, *identity.Verification) {
+	return &identity.Identity{DID: "did:plc:author", Handle: "author.example.com", DisplayName: "Ada Author"},
+		&identity.Verification{DID: "did:plc:author", Handle: "author.example.com", Verified: true, CheckedAt: goldenTime}
+}
+
+// TestGolden renders the main pages from fixtures and compares their markup
+// with golden files, so template changes show up as reviewed diffs
+func TestGolden(t *testing.T) {
+	survey := goldenSurvey()
+	results := goldenResults(survey)
+	user, profile := goldenUser()
+	author, verification := goldenAuthor()
+	attribution := &provenance.Provenance{AppViewDID: "did:web:survey.example.com", Software: "openmeet-survey", SoftwareVersion: "v1.0.0", AggregatedAt: goldenTime}
+	respondents := []*identity.Identity{{DID: "did:plc:voter", Handle: "voter.example.com", DisplayName: "Vera Voter"}}
+
+	arabic := goldenSurvey()
+	arabic.Definition.Language = "ar"
+	arabicLocale, _ := i18n.Lookup("ar")
+
+	drafted := map[string]models.Answer{
+		"day":    {SelectedOptions: []string{"sun"}},
+		"topics": {SelectedOptions: []string{"go", "ops"}},
+		"rate":   {Rows: map[string]string{"talks": "good"}},
+		"people": {Text: "2"},
+		"notes":  {Text: "Looking forward to it"},
+	}
+
+	timestamp := goldenTime
+	records := []oauth.PDSRecord{{
+		URI:       "at://did:plc:voter/net.openmeet.survey.response/3kabc",
+		CID:       "bafyreib2rxk3rh6kzwq",
+		Value:     map[string]interface{}{"subject": map[string]interface{}{"uri": "at://did:plc:author/net.openmeet.survey/3kxyz"}},
+		ValueJSON: "{\n  \"subject\": {\"uri\": \"at://did:plc:author/net.openmeet.survey/3kxyz\"}\n}",
+		RKey:      "3kabc",
+		Timestamp: &timestamp,
+	}}
+
+	tests := []struct {
+		name      string
+		component templ.Component
+	}{
+		{"survey_form", SurveyForm(survey, author, verification, nil, nil, "", nil, nil, nil, false, nil, true)},
+		{"survey_form_draft", SurveyForm(survey, author, verification, user, profile, "", nil, nil, drafted, true, nil, false)},
+		{"review_answers", ReviewAnswers(survey, drafted, "token", nil)},
+		{"survey_results", SurveyResults(survey, results, i18n.Default(), author, verification, respondents, 2, attribution, false, false, nil, nil, "")},
+		{"survey_results_manage", SurveyResults(survey, results, i18n.Default(), author, verification, nil, 0, attribution, true, false, user, profile, "")},
+		{"survey_results_rtl", SurveyResults(arabic, results, arabicLocale, author, verification, nil, 0, attribution, false, false, nil, nil, "")},
+		{"results_partial", ResultsPartial(survey, results, i18n.Default())},
+		{"landing", LandingPage(&models.Stats{SurveyCount: 12, ResponseCount: 345, UniqueUserCount: 67}, []*models.Survey{survey}, []*trending.Entry{{Survey: survey, Responses: 9, Score: 4.5}}, nil, nil, "https://example.com/support", "")},
+		{"landing_logged_in", LandingPage(&models.Stats{}, nil, nil, user, profile, "", "")},
+		{"my_data", MyDataPage(user, profile, "")},
+		{"my_data_collection", MyDataCollectionPage(user, profile, "net.openmeet.survey.response", records, "3kabc", map[string]*identity.Identity{"did:plc:author": author}, "")},
+		{"my_data_record", MyDataRecordPage(user, profile, "net.openmeet.survey.response", &records[0], "")},
+		{"thank_you", ThankYou(survey.Slug, "", nil)},
+		{"survey_deleted", SurveyDeleted(&models.SurveyTombstone{Slug: survey.Slug, DeletedAt: goldenTime}, nil, nil, "")},
+		{"error", Error("Survey not found")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertGolden(t, tt.name, tt.component)
+		})
+	}
+}
+
+func TestNormalizeHTML(t *testing.T) {
+	html := "<div>\n\t<p class=\"a\">  Hello,\n  world </p>\n\n<br>\n</div>"
+	assert.Equal(t, "<div>\n<p class=\"a\">Hello, world</p>\n<br>\n</div>\n", normalizeHTML(html))
+
+	key := `hx-headers="{&#34;Idempotency-Key&#34;: &#34;` + uuid.NewString() + `&#34;}"`
+	assert.Equal(t, `hx-headers="{&#34;Idempotency-Key&#34;: &#34;00000000-0000-0000-0000-000000000000&#34;}"`+"\n", normalizeHTML(key))
+}
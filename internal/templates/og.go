@@ -7,6 +7,7 @@ type OGMeta struct {
 	URL         string // og:url - canonical URL
 	Image       string // og:image - defaults to /static/og-image.png if empty
 	Type        string // og:type - defaults to "website" if empty
+	NoIndex     bool   // Keep search engines off the page even where indexing is allowed
 }
 
 // DefaultOGImage is the default Open Graph image path
@@ -0,0 +1,18 @@
+package templates
+
+import (
+	"context"
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
+// Render renders a component to a string. Handlers render to the response
+// instead; tests use it to inspect pages and compare them with golden files.
+func Render(ctx context.Context, component templ.Component) (string, error) {
+	var b strings.Builder
+	if err := component.Render(ctx, &b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -108,6 +108,26 @@ func TestSurveyOGMeta_TypeIsWebsite(t *testing.T) {
 	assert.Equal(t, "website", og.Type, "OG type should be website")
 }
 
+// TestSurveyOGMeta_CardImage tests that the survey URL and its card image are absolute when the public URL is known
+func TestSurveyOGMeta_CardImage(t *testing.T) {
+	survey := &models.Survey{
+		Title: "Test Survey",
+		Slug:  "test-survey",
+	}
+
+	SetPublicURL("https://example.com/survey/")
+	defer SetPublicURL("")
+
+	og := surveyOGMeta(survey)
+
+	assert.Equal(t, "https://example.com/survey/surveys/test-survey", og.URL)
+	assert.Equal(t, "https://example.com/survey/surveys/test-survey/card.png", og.Image)
+
+	SetPublicURL("")
+	og = surveyOGMeta(survey)
+	assert.Equal(t, "/surveys/test-survey/card.png", og.Image, "falls back to a relative path")
+}
+
 // Helper function to create string pointer
 func stringPtr(s string) *string {
 	return &s
@@ -0,0 +1,268 @@
+// Package tenant serves surveys on partners' own domains (white-label). A
+// tenant has hostnames that map to it, branding shown on every page served
+// on them, and a landing page listing its surveys: those of its default
+// author, and public surveys cross-posted to it. Requests to other hosts are
+// served as usual.
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// Limits
+const (
+	MaxNameLength    = 100 // Characters of a tenant's name
+	MaxTaglineLength = 200 // Characters of a tenant's tagline
+	MaxDomains       = 10  // Hostnames of a tenant
+	// LandingLimit is how many surveys a tenant's landing page lists
+	LandingLimit = 20
+	// CacheTTL is how long a resolved hostname is used before it is looked up again
+	CacheTTL = time.Minute
+	// maxCached bounds the hostnames cached, since clients choose the Host header
+	maxCached = 1000
+)
+
+// Errors of Store methods caused by the request
+var (
+	ErrSlugTaken   = errors.New("a tenant with this slug already exists")
+	ErrDomainTaken = errors.New("the hostname belongs to a tenant")
+)
+
+// colorPattern matches the accent colors tenants can choose
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// hostnameLabel matches a label of a hostname
+var hostnameLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Tenant is a partner serving surveys on its own domains
+type Tenant struct {
+	ID          uuid.UUID `json:"id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`                  // Shown instead of "OpenMeet Survey"
+	Tagline     string    `json:"tagline,omitempty"`     // Shown on the landing page
+	LogoURL     string    `json:"logoUrl,omitempty"`     // https image shown next to the name
+	AccentColor string    `json:"accentColor,omitempty"` // #rrggbb of the navigation bar
+	// DefaultAuthorDID is the author whose surveys the tenant lists without
+	// cross-posting them
+	DefaultAuthorDID *string   `json:"defaultAuthor,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// Domain is a hostname serving a tenant
+type Domain struct {
+	Hostname  string    `json:"hostname"`
+	TenantID  uuid.UUID `json:"tenantId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Store persists tenants, their domains, and the surveys cross-posted to them
+type Store interface {
+	// CreateTenant returns ErrSlugTaken if a tenant has the slug
+	CreateTenant(ctx context.Context, t *Tenant) error
+	// UpdateTenant saves the branding and default author of a tenant,
+	// returning sql.ErrNoRows if there is no such tenant
+	UpdateTenant(ctx context.Context, t *Tenant) error
+	// GetTenantBySlug returns sql.ErrNoRows if there is no such tenant
+	GetTenantBySlug(ctx context.Context, slug string) (*Tenant, error)
+	// GetTenantByHostname returns sql.ErrNoRows if no tenant has the hostname
+	GetTenantByHostname(ctx context.Context, hostname string) (*Tenant, error)
+	// ListTenants returns the tenants by name
+	ListTenants(ctx context.Context) ([]*Tenant, error)
+	// ListDomains returns the hostnames of a tenant, alphabetically
+	ListDomains(ctx context.Context, tenantID uuid.UUID) ([]*Domain, error)
+	// AddDomain returns ErrDomainTaken if a tenant has the hostname
+	AddDomain(ctx context.Context, d *Domain) error
+	// RemoveDomain returns sql.ErrNoRows if the tenant does not have the hostname
+	RemoveDomain(ctx context.Context, tenantID uuid.UUID, hostname string) error
+	// CrossPostSurvey lists a survey on a tenant. Cross-posting it again does nothing.
+	CrossPostSurvey(ctx context.Context, tenantID, surveyID uuid.UUID, by string) error
+	// RemoveCrossPost returns sql.ErrNoRows if the survey is not cross-posted to the tenant
+	RemoveCrossPost(ctx context.Context, tenantID, surveyID uuid.UUID) error
+	// ListTenantSurveys returns the listed surveys of a tenant, newest first:
+	// those of its default author and those cross-posted to it
+	ListTenantSurveys(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*models.Survey, error)
+}
+
+// New creates a tenant. Its slug is checked like a survey's.
+func New(slug, name string) (*Tenant, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if err := models.ValidateSlug(slug); err != nil {
+		return nil, err
+	}
+
+	t := &Tenant{
+		ID:        uuid.New(),
+		Slug:      slug,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Validate trims and checks the branding and default author of a tenant
+func (t *Tenant) Validate() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(t.Name) > MaxNameLength {
+		return fmt.Errorf("name must be at most %d characters", MaxNameLength)
+	}
+
+	t.Tagline = strings.TrimSpace(t.Tagline)
+	if utf8.RuneCountInString(t.Tagline) > MaxTaglineLength {
+		return fmt.Errorf("tagline must be at most %d characters", MaxTaglineLength)
+	}
+
+	t.LogoURL = strings.TrimSpace(t.LogoURL)
+	if t.LogoURL != "" {
+		u, err := url.Parse(t.LogoURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("logo URL must be an https URL")
+		}
+	}
+
+	t.AccentColor = strings.TrimSpace(t.AccentColor)
+	if t.AccentColor != "" && !colorPattern.MatchString(t.AccentColor) {
+		return fmt.Errorf("accent color must be #rrggbb")
+	}
+
+	if t.DefaultAuthorDID != nil {
+		did := strings.TrimSpace(*t.DefaultAuthorDID)
+		switch {
+		case did == "":
+			t.DefaultAuthorDID = nil
+		case !strings.HasPrefix(did, "did:"):
+			return fmt.Errorf("default author must be a DID")
+		default:
+			t.DefaultAuthorDID = &did
+		}
+	}
+	return nil
+}
+
+// Hostname returns the lowercased hostname of a Host header, without its
+// port or trailing dot
+func Hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// NormalizeHostname returns the hostname of a domain to add to a tenant, or
+// an error if it is not a fully qualified DNS name
+func NormalizeHostname(host string) (string, error) {
+	host = Hostname(host)
+	if len(host) > 253 {
+		return "", fmt.Errorf("hostname must be at most 253 characters")
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("hostname must be a domain name such as surveys.example.com")
+	}
+	for _, label := range labels {
+		if !hostnameLabel.MatchString(label) {
+			return "", fmt.Errorf("hostname must be a domain name such as surveys.example.com")
+		}
+	}
+	return host, nil
+}
+
+// contextKey is the key of the tenant in request contexts
+type contextKey struct{}
+
+// NewContext returns a context serving a tenant
+func NewContext(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tenant a request is served for, or nil for the
+// app's own hosts
+func FromContext(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(contextKey{}).(*Tenant)
+	return t
+}
+
+// cached is a resolved hostname, with a nil tenant if it has none
+type cached struct {
+	tenant  *Tenant
+	expires time.Time
+}
+
+// Resolver finds the tenants of hostnames, caching them for a TTL so that
+// requests don't each query the store
+type Resolver struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+// NewResolver creates a resolver caching hostnames for ttl
+func NewResolver(store Store, ttl time.Duration) *Resolver {
+	if ttl <= 0 {
+		ttl = CacheTTL
+	}
+	return &Resolver{
+		store: store,
+		ttl:   ttl,
+		now:   time.Now,
+		cache: make(map[string]cached),
+	}
+}
+
+// Resolve returns the tenant of a Host header, or nil if it has none
+func (r *Resolver) Resolve(ctx context.Context, host string) (*Tenant, error) {
+	hostname := Hostname(host)
+	if hostname == "" {
+		return nil, nil
+	}
+
+	r.mu.Lock()
+	entry, ok := r.cache[hostname]
+	r.mu.Unlock()
+	if ok && r.now().Before(entry.expires) {
+		return entry.tenant, nil
+	}
+
+	t, err := r.store.GetTenantByHostname(ctx, hostname)
+	if errors.Is(err, sql.ErrNoRows) {
+		t = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if len(r.cache) >= maxCached {
+		r.cache = make(map[string]cached)
+	}
+	r.cache[hostname] = cached{tenant: t, expires: r.now().Add(r.ttl)}
+	r.mu.Unlock()
+	return t, nil
+}
+
+// Invalidate forgets the resolved hostnames, after tenants or their domains change
+func (r *Resolver) Invalidate() {
+	r.mu.Lock()
+	r.cache = make(map[string]cached)
+	r.mu.Unlock()
+}
@@ -0,0 +1,151 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tn, err := New(" Acme-Polls ", "  Acme Polls ")
+	require.NoError(t, err)
+	assert.Equal(t, "acme-polls", tn.Slug)
+	assert.Equal(t, "Acme Polls", tn.Name)
+
+	_, err = New("a", "Acme")
+	assert.Error(t, err, "slugs are checked like survey slugs")
+	_, err = New("acme", " ")
+	assert.Error(t, err)
+	_, err = New("acme", strings.Repeat("a", MaxNameLength+1))
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	blank := " "
+	did := " did:plc:acme "
+	handle := "acme.example.com"
+
+	tests := []struct {
+		name   string
+		tenant Tenant
+		ok     bool
+	}{
+		{"name only", Tenant{Name: "Acme"}, true},
+		{"branding", Tenant{Name: "Acme", Tagline: "Polls by Acme", LogoURL: "https://acme.example.com/logo.png", AccentColor: "#1A2b3C", DefaultAuthorDID: &did}, true},
+		{"blank default author", Tenant{Name: "Acme", DefaultAuthorDID: &blank}, true},
+		{"long tagline", Tenant{Name: "Acme", Tagline: strings.Repeat("a", MaxTaglineLength+1)}, false},
+		{"plain http logo", Tenant{Name: "Acme", LogoURL: "http://acme.example.com/logo.png"}, false},
+		{"script logo", Tenant{Name: "Acme", LogoURL: "javascript:alert(1)"}, false},
+		{"named color", Tenant{Name: "Acme", AccentColor: "red"}, false},
+		{"css in color", Tenant{Name: "Acme", AccentColor: "#123456; }"}, false},
+		{"handle as default author", Tenant{Name: "Acme", DefaultAuthorDID: &handle}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tenant.Validate()
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	tn := Tenant{Name: "Acme", DefaultAuthorDID: &did}
+	require.NoError(t, tn.Validate())
+	assert.Equal(t, "did:plc:acme", *tn.DefaultAuthorDID)
+	tn = Tenant{Name: "Acme", DefaultAuthorDID: &blank}
+	require.NoError(t, tn.Validate())
+	assert.Nil(t, tn.DefaultAuthorDID)
+}
+
+func TestNormalizeHostname(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+		ok   bool
+	}{
+		{"surveys.example.com", "surveys.example.com", true},
+		{" Surveys.Example.COM. ", "surveys.example.com", true},
+		{"surveys.example.com:8443", "surveys.example.com", true},
+		{"localhost", "", false},
+		{"*.example.com", "", false},
+		{"surveys..example.com", "", false},
+		{"-surveys.example.com", "", false},
+		{"surveys.example.com/path", "", false},
+		{strings.Repeat("a.", 127) + "com", "", false},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeHostname(tt.host)
+		if tt.ok {
+			require.NoError(t, err, tt.host)
+			assert.Equal(t, tt.want, got)
+		} else {
+			assert.Error(t, err, tt.host)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, FromContext(ctx))
+
+	tn := &Tenant{Name: "Acme"}
+	assert.Same(t, tn, FromContext(NewContext(ctx, tn)))
+}
+
+// hostStore answers hostname lookups from a map, counting them
+type hostStore struct {
+	Store
+	tenants map[string]*Tenant
+	lookups int
+}
+
+func (s *hostStore) GetTenantByHostname(ctx context.Context, hostname string) (*Tenant, error) {
+	s.lookups++
+	if t, ok := s.tenants[hostname]; ok {
+		return t, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+	acme := &Tenant{Name: "Acme"}
+	store := &hostStore{tenants: map[string]*Tenant{"surveys.acme.example": acme}}
+	r := NewResolver(store, time.Minute)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	tn, err := r.Resolve(ctx, "Surveys.Acme.Example:443")
+	require.NoError(t, err)
+	assert.Same(t, acme, tn)
+	tn, err = r.Resolve(ctx, "surveys.acme.example")
+	require.NoError(t, err)
+	assert.Same(t, acme, tn)
+	assert.Equal(t, 1, store.lookups, "resolved hostnames are cached")
+
+	tn, err = r.Resolve(ctx, "survey.openmeet.example")
+	require.NoError(t, err)
+	assert.Nil(t, tn)
+	_, _ = r.Resolve(ctx, "survey.openmeet.example")
+	assert.Equal(t, 2, store.lookups, "hostnames without a tenant are cached too")
+
+	now = now.Add(2 * time.Minute)
+	_, _ = r.Resolve(ctx, "surveys.acme.example")
+	assert.Equal(t, 3, store.lookups, "cached hostnames expire")
+
+	r.Invalidate()
+	_, _ = r.Resolve(ctx, "surveys.acme.example")
+	assert.Equal(t, 4, store.lookups)
+
+	tn, err = r.Resolve(ctx, "")
+	require.NoError(t, err)
+	assert.Nil(t, tn)
+	assert.Equal(t, 4, store.lookups)
+}
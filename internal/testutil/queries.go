@@ -0,0 +1,559 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/openmeet-team/survey/internal/redact"
+)
+
+// MemoryQueries keeps surveys, responses, and comments in memory and
+// implements the queries of api.QueriesInterface the way db.Queries does:
+// one response per voter and survey, results aggregated from the responses,
+// and stats counted over them. It is safe for concurrent use, but tests that
+// set up or inspect its fields directly must not do so during calls.
+//
+// It serves the API tests only. The consumer processes each message in a
+// transaction of *db.Queries, so its tests run against a database.
+type MemoryQueries struct {
+	mu sync.Mutex
+
+	Surveys           map[string]*models.Survey                 // slug -> survey
+	SurveysByURI      map[string]*models.Survey                 // URI -> survey
+	Slugs             map[string]bool                           // Slugs taken, including those of deleted surveys
+	Responses         map[uuid.UUID]*models.Response            // ID -> response
+	ResponsesBySurvey map[uuid.UUID]map[string]*models.Response // surveyID -> voter DID or session -> response
+	QuestionOrdinals  map[uuid.UUID]map[int]map[string]int      // surveyID -> version -> questionID -> ordinal
+	Tombstones        map[string]*models.SurveyTombstone        // slug -> tombstone
+	Revisions         []*models.SurveyRevision
+	Comments          []*models.Comment
+	ResultsQueries    int // Number of GetSurveyResults calls
+}
+
+// NewMemoryQueries creates empty in-memory queries
+func NewMemoryQueries() *MemoryQueries {
+	return &MemoryQueries{
+		Surveys:           make(map[string]*models.Survey),
+		SurveysByURI:      make(map[string]*models.Survey),
+		Slugs:             make(map[string]bool),
+		Responses:         make(map[uuid.UUID]*models.Response),
+		ResponsesBySurvey: make(map[uuid.UUID]map[string]*models.Response),
+		QuestionOrdinals:  make(map[uuid.UUID]map[int]map[string]int),
+		Tombstones:        make(map[string]*models.SurveyTombstone),
+	}
+}
+
+func (m *MemoryQueries) CreateSurvey(ctx context.Context, s *models.Survey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.createSurvey(s)
+	return nil
+}
+
+// CreateSurveys creates all the surveys, or none if a slug is taken, like the
+// transaction of db.Queries.CreateSurveys
+func (m *MemoryQueries) CreateSurveys(ctx context.Context, surveys []*models.Survey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	slugs := make(map[string]bool, len(surveys))
+	for i, s := range surveys {
+		if m.Slugs[s.Slug] || slugs[s.Slug] {
+			return fmt.Errorf("survey %d (%s): slug already exists", i, s.Slug)
+		}
+		slugs[s.Slug] = true
+	}
+	for _, s := range surveys {
+		m.createSurvey(s)
+	}
+	return nil
+}
+
+func (m *MemoryQueries) createSurvey(s *models.Survey) {
+	if s.Version == 0 {
+		s.Version = 1
+	}
+	m.Surveys[s.Slug] = s
+	m.Slugs[s.Slug] = true
+	if s.URI != nil {
+		m.SurveysByURI[*s.URI] = s
+	}
+	m.ResponsesBySurvey[s.ID] = make(map[string]*models.Response)
+}
+
+func (m *MemoryQueries) GetSurveyBySlug(ctx context.Context, slug string) (*models.Survey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.Surveys[slug]; ok {
+		return s, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryQueries) GetSurveyByURI(ctx context.Context, uri string) (*models.Survey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.SurveysByURI[uri]; ok {
+		return s, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryQueries) GetSurveyByID(ctx context.Context, id uuid.UUID) (*models.Survey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.surveyByID(id)
+}
+
+// surveyByID finds a survey by ID; m.mu must be held
+func (m *MemoryQueries) surveyByID(id uuid.UUID) (*models.Survey, error) {
+	for _, s := range m.Surveys {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// ListSurveys lists surveys newest first, all of them if limit is 0
+func (m *MemoryQueries) ListSurveys(ctx context.Context, limit, offset int) ([]*models.Survey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var surveys []*models.Survey
+	for _, s := range m.Surveys {
+		surveys = append(surveys, s)
+	}
+	sort.Slice(surveys, func(i, j int) bool {
+		return surveys[i].CreatedAt.After(surveys[j].CreatedAt)
+	})
+	if limit > 0 {
+		surveys = surveys[min(offset, len(surveys)):min(offset+limit, len(surveys))]
+	}
+	return surveys, nil
+}
+
+func (m *MemoryQueries) SlugExists(ctx context.Context, slug string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, deleted := m.Tombstones[slug]
+	return m.Slugs[slug] || deleted, nil
+}
+
+// CreateResponse stores a response, failing like the unique indexes of the
+// responses table if its voter already responded to the survey
+func (m *MemoryQueries) CreateResponse(ctx context.Context, r *models.Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byVoter := m.ResponsesBySurvey[r.SurveyID]
+	if byVoter == nil {
+		byVoter = make(map[string]*models.Response)
+		m.ResponsesBySurvey[r.SurveyID] = byVoter
+	}
+	key := voterKey(r)
+	if key != "" {
+		if _, exists := byVoter[key]; exists {
+			return fmt.Errorf("failed to insert response: duplicate voter %s for survey %s", key, r.SurveyID)
+		}
+	}
+
+	// Responses without a pinned version answer the current version
+	if r.SurveyVersion == nil {
+		if s, err := m.surveyByID(r.SurveyID); err == nil {
+			version := s.Version
+			r.SurveyVersion = &version
+		}
+	}
+
+	m.Responses[r.ID] = r
+	if key != "" {
+		byVoter[key] = r
+	}
+	return nil
+}
+
+// voterKey returns the key of a response's voter in ResponsesBySurvey: their
+// DID, or their session if they are anonymous
+func voterKey(r *models.Response) string {
+	if r.VoterDID != nil && *r.VoterDID != "" {
+		return *r.VoterDID
+	}
+	if r.VoterSession != nil {
+		return *r.VoterSession
+	}
+	return ""
+}
+
+func (m *MemoryQueries) GetResponseBySurveyAndVoter(ctx context.Context, surveyID uuid.UUID, voterDID, voterSession string) (*models.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := voterDID
+	if key == "" {
+		key = voterSession
+	}
+	if key == "" {
+		return nil, fmt.Errorf("either voterDID or voterSession must be provided")
+	}
+	return m.ResponsesBySurvey[surveyID][key], nil // No existing response is not an error
+}
+
+func (m *MemoryQueries) GetResponseByID(ctx context.Context, id uuid.UUID) (*models.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if resp, ok := m.Responses[id]; ok {
+		return resp, nil
+	}
+	return nil, fmt.Errorf("response not found: %w", sql.ErrNoRows)
+}
+
+// GetSurveyResults aggregates the responses to a survey: option, matrix row,
+// and respondent counts, summaries of number and date answers, and redacted
+// text answers. Unlike db.Queries, nothing is excluded or hidden.
+func (m *MemoryQueries) GetSurveyResults(ctx context.Context, surveyID uuid.UUID) (*models.SurveyResults, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ResultsQueries++
+	survey, err := m.surveyByID(surveyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get survey: %w", err)
+	}
+	responses := m.responsesBySurvey(surveyID)
+
+	results := &models.SurveyResults{
+		SurveyID:        surveyID,
+		TotalVotes:      len(responses),
+		QuestionResults: make(map[string]*models.QuestionResult),
+		Version:         survey.Version,
+		VersionVotes:    make(map[int]int),
+	}
+	redactor := redact.New(survey.Definition.Redaction, survey.Definition.Language)
+
+	valueQuestions := make(map[string]*models.Question) // number, date, and datetime questions
+	values := make(map[string][]string)
+	for i, question := range survey.Definition.Questions {
+		results.QuestionResults[question.ID] = &models.QuestionResult{
+			QuestionID:   question.ID,
+			Ordinal:      i + 1,
+			OptionCounts: make(map[string]int),
+			TextAnswers:  []string{},
+		}
+		if question.Type == models.QuestionTypeMatrix {
+			results.QuestionResults[question.ID].RowCounts = make(map[string]map[string]int)
+		}
+		if question.Type.HasRange() {
+			valueQuestions[question.ID] = &survey.Definition.Questions[i]
+		}
+	}
+
+	for _, response := range responses {
+		version := 1
+		if response.SurveyVersion != nil {
+			version = *response.SurveyVersion
+		}
+		results.VersionVotes[version]++
+
+		for questionID, answer := range response.Answers {
+			qResult, exists := results.QuestionResults[questionID]
+			if !exists {
+				continue
+			}
+			if len(answer.SelectedOptions) > 0 || len(answer.Rows) > 0 || answer.Text != "" || answer.File != nil {
+				qResult.Respondents++
+			}
+			for _, optionID := range answer.SelectedOptions {
+				qResult.OptionCounts[optionID]++
+			}
+			if qResult.RowCounts != nil {
+				for rowID, optionID := range answer.Rows {
+					if qResult.RowCounts[rowID] == nil {
+						qResult.RowCounts[rowID] = make(map[string]int)
+					}
+					qResult.RowCounts[rowID][optionID]++
+				}
+			}
+			if _, ok := valueQuestions[questionID]; ok {
+				if answer.Text != "" {
+					values[questionID] = append(values[questionID], answer.Text)
+				}
+				continue
+			}
+			if answer.Text != "" {
+				qResult.TextAnswers = append(qResult.TextAnswers, redactor.Redact(answer.Text))
+			}
+		}
+	}
+	for questionID, question := range valueQuestions {
+		results.QuestionResults[questionID].SummarizeValues(question, values[questionID])
+	}
+	results.MixedVersions = len(results.VersionVotes) > 1
+
+	return results, nil
+}
+
+func (m *MemoryQueries) UpdateSurveyResults(ctx context.Context, surveyID uuid.UUID, resultsURI, resultsCID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	survey, err := m.surveyByID(surveyID)
+	if err != nil {
+		return fmt.Errorf("survey not found")
+	}
+	survey.ResultsURI = &resultsURI
+	survey.ResultsCID = &resultsCID
+	return nil
+}
+
+// GetStats counts surveys, responses, and unique voters: DIDs, plus the
+// sessions of anonymous voters
+func (m *MemoryQueries) GetStats(ctx context.Context) (*models.Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	uniqueDIDs := make(map[string]bool)
+	uniqueSessions := make(map[string]bool)
+	for _, response := range m.Responses {
+		if response.VoterDID != nil && *response.VoterDID != "" {
+			uniqueDIDs[*response.VoterDID] = true
+		} else if response.VoterSession != nil && *response.VoterSession != "" {
+			uniqueSessions[*response.VoterSession] = true
+		}
+	}
+
+	return &models.Stats{
+		SurveyCount:     len(m.Surveys),
+		ResponseCount:   len(m.Responses),
+		UniqueUserCount: len(uniqueDIDs) + len(uniqueSessions),
+	}, nil
+}
+
+func (m *MemoryQueries) ListResponsesBySurvey(ctx context.Context, surveyID uuid.UUID) ([]*models.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.responsesBySurvey(surveyID), nil
+}
+
+func (m *MemoryQueries) ListRespondentDIDs(ctx context.Context, surveyID uuid.UUID, limit int) ([]string, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var dids []string
+	for _, r := range m.responsesBySurvey(surveyID) {
+		if r.VoterDID != nil && !seen[*r.VoterDID] {
+			seen[*r.VoterDID] = true
+			dids = append(dids, *r.VoterDID)
+		}
+	}
+	total := len(dids)
+	if len(dids) > limit {
+		dids = dids[:limit]
+	}
+	return dids, total, nil
+}
+
+// responsesBySurvey lists the responses to a survey, oldest first; m.mu must
+// be held
+func (m *MemoryQueries) responsesBySurvey(surveyID uuid.UUID) []*models.Response {
+	var responses []*models.Response
+	for _, r := range m.Responses {
+		if r.SurveyID == surveyID {
+			responses = append(responses, r)
+		}
+	}
+	sort.Slice(responses, func(i, j int) bool {
+		return responses[i].CreatedAt.Before(responses[j].CreatedAt)
+	})
+	return responses
+}
+
+func (m *MemoryQueries) ListResponsesBySurveyStream(ctx context.Context, surveyID uuid.UUID, filter models.ResponseFilter, batchSize int, fn func([]*models.Response) error) error {
+	responses, err := m.ListFilteredResponses(ctx, surveyID, filter)
+	if err != nil {
+		return err
+	}
+	for batch := range slices.Chunk(responses, batchSize) {
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryQueries) ListFilteredResponses(ctx context.Context, surveyID uuid.UUID, filter models.ResponseFilter) ([]*models.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var responses []*models.Response
+	for _, r := range m.responsesBySurvey(surveyID) {
+		if !filter.From.IsZero() && r.CreatedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !r.CreatedAt.Before(filter.To) {
+			continue
+		}
+		if (filter.VoterType == models.VoterTypeDID && r.VoterDID == nil) ||
+			(filter.VoterType == models.VoterTypeAnonymous && r.VoterDID != nil) {
+			continue
+		}
+		if filter.Option != "" && !slices.Contains(r.Answers[filter.Question].SelectedOptions, filter.Option) {
+			continue
+		}
+		if len(filter.QuestionIDs) > 0 {
+			filtered := *r
+			filtered.Answers = make(map[string]models.Answer)
+			for _, questionID := range filter.QuestionIDs {
+				if answer, ok := r.Answers[questionID]; ok {
+					filtered.Answers[questionID] = answer
+				}
+			}
+			r = &filtered
+		}
+		responses = append(responses, r)
+	}
+	if filter.Limit > 0 {
+		responses = responses[min(filter.Offset, len(responses)):min(filter.Offset+filter.Limit, len(responses))]
+	}
+	return responses, nil
+}
+
+func (m *MemoryQueries) CountFilteredResponses(ctx context.Context, surveyID uuid.UUID, filter models.ResponseFilter) (int, error) {
+	filter.Limit, filter.Offset = 0, 0
+	responses, err := m.ListFilteredResponses(ctx, surveyID, filter)
+	return len(responses), err
+}
+
+func (m *MemoryQueries) UpdateSurvey(ctx context.Context, s *models.Survey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.Surveys[s.Slug]; !ok {
+		return fmt.Errorf("survey not found")
+	}
+	m.Surveys[s.Slug] = s
+	if s.URI != nil {
+		m.SurveysByURI[*s.URI] = s
+	}
+	return nil
+}
+
+func (m *MemoryQueries) CreateSurveyRevision(ctx context.Context, r *models.SurveyRevision) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Revisions = append(m.Revisions, r)
+	return nil
+}
+
+// ListSurveyRevisions lists the revisions of a survey, newest first
+func (m *MemoryQueries) ListSurveyRevisions(ctx context.Context, surveyID uuid.UUID, limit int) ([]*models.SurveyRevision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var revisions []*models.SurveyRevision
+	for i := len(m.Revisions) - 1; i >= 0 && len(revisions) < limit; i-- {
+		if m.Revisions[i].SurveyID == surveyID {
+			revisions = append(revisions, m.Revisions[i])
+		}
+	}
+	return revisions, nil
+}
+
+func (m *MemoryQueries) ListSurveyComments(ctx context.Context, surveyID uuid.UUID, limit, offset int) ([]*models.Comment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var comments []*models.Comment
+	for _, c := range m.Comments {
+		if c.SurveyID == surveyID {
+			comments = append(comments, c)
+		}
+	}
+	if offset >= len(comments) {
+		return nil, nil
+	}
+	return comments[offset:min(offset+limit, len(comments))], nil
+}
+
+func (m *MemoryQueries) ListSurveyCommentsAfter(ctx context.Context, surveyID uuid.UUID, after *models.CommentCursor, limit int) ([]*models.Comment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var comments []*models.Comment
+	for _, c := range m.Comments {
+		if c.SurveyID != surveyID {
+			continue
+		}
+		if after != nil && (c.CreatedAt.Before(after.CreatedAt) || c.CreatedAt.Equal(after.CreatedAt) && c.ID.String() <= after.ID.String()) {
+			continue
+		}
+		comments = append(comments, c)
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID.String() < comments[j].ID.String()
+	})
+	return comments[:min(limit, len(comments))], nil
+}
+
+func (m *MemoryQueries) CountSurveyComments(ctx context.Context, surveyID uuid.UUID) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, c := range m.Comments {
+		if c.SurveyID == surveyID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryQueries) GetSurveyTombstoneBySlug(ctx context.Context, slug string) (*models.SurveyTombstone, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t, ok := m.Tombstones[slug]; ok {
+		return t, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryQueries) GetSurveyTombstoneByURI(ctx context.Context, uri string) (*models.SurveyTombstone, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range m.Tombstones {
+		if t.URI != nil && *t.URI == uri {
+			return t, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryQueries) ListQuestionOrdinals(ctx context.Context, surveyID uuid.UUID) (map[int]map[string]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ordinals, ok := m.QuestionOrdinals[surveyID]; ok {
+		return ordinals, nil
+	}
+	return map[int]map[string]int{}, nil
+}
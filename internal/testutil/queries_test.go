@@ -0,0 +1,112 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createLunchSurvey(t *testing.T, q *MemoryQueries) *models.Survey {
+	survey := &models.Survey{
+		ID:    uuid.New(),
+		Slug:  "lunch",
+		Title: "Lunch",
+		Definition: models.SurveyDefinition{Questions: []models.Question{
+			{ID: "food", Text: "Food?", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "pizza", Text: "Pizza"}, {ID: "salad", Text: "Salad"}}},
+			{ID: "notes", Text: "Notes?", Type: models.QuestionTypeText},
+		}},
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, q.CreateSurvey(context.Background(), survey))
+	return survey
+}
+
+func TestMemoryQueries_OneResponsePerVoter(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueries()
+	survey := createLunchSurvey(t, q)
+	did, session := "did:plc:alice", "session-1"
+
+	require.NoError(t, q.CreateResponse(ctx, &models.Response{ID: uuid.New(), SurveyID: survey.ID, VoterDID: &did}))
+	require.NoError(t, q.CreateResponse(ctx, &models.Response{ID: uuid.New(), SurveyID: survey.ID, VoterSession: &session}))
+	assert.Error(t, q.CreateResponse(ctx, &models.Response{ID: uuid.New(), SurveyID: survey.ID, VoterDID: &did}))
+	assert.Error(t, q.CreateResponse(ctx, &models.Response{ID: uuid.New(), SurveyID: survey.ID, VoterSession: &session}))
+	assert.Len(t, q.Responses, 2)
+
+	existing, err := q.GetResponseBySurveyAndVoter(ctx, survey.ID, did, "")
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, 1, *existing.SurveyVersion, "responses answer the current version")
+
+	existing, err = q.GetResponseBySurveyAndVoter(ctx, survey.ID, "", "session-2")
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+}
+
+func TestMemoryQueries_GetSurveyResults(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueries()
+	survey := createLunchSurvey(t, q)
+
+	for i, food := range []string{"pizza", "pizza", "salad"} {
+		session := uuid.NewString()
+		answers := map[string]models.Answer{"food": {SelectedOptions: []string{food}}}
+		if i == 0 {
+			answers["notes"] = models.Answer{Text: "Extra cheese"}
+		}
+		require.NoError(t, q.CreateResponse(ctx, &models.Response{ID: uuid.New(), SurveyID: survey.ID, VoterSession: &session, Answers: answers}))
+	}
+
+	results, err := q.GetSurveyResults(ctx, survey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, results.TotalVotes)
+	assert.Equal(t, map[string]int{"pizza": 2, "salad": 1}, results.QuestionResults["food"].OptionCounts)
+	assert.Equal(t, 3, results.QuestionResults["food"].Respondents)
+	assert.Equal(t, []string{"Extra cheese"}, results.QuestionResults["notes"].TextAnswers)
+	assert.Equal(t, 1, q.ResultsQueries)
+
+	_, err = q.GetSurveyResults(ctx, uuid.New())
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestMemoryQueries_GetStats(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueries()
+	lunch := createLunchSurvey(t, q)
+	dinner := &models.Survey{ID: uuid.New(), Slug: "dinner"}
+	require.NoError(t, q.CreateSurvey(ctx, dinner))
+
+	did, session := "did:plc:alice", "session-1"
+	for _, survey := range []*models.Survey{lunch, dinner} {
+		require.NoError(t, q.CreateResponse(ctx, &models.Response{ID: uuid.New(), SurveyID: survey.ID, VoterDID: &did, VoterSession: &session}))
+		otherSession := uuid.NewString()
+		require.NoError(t, q.CreateResponse(ctx, &models.Response{ID: uuid.New(), SurveyID: survey.ID, VoterSession: &otherSession}))
+	}
+
+	stats, err := q.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.SurveyCount)
+	assert.Equal(t, 4, stats.ResponseCount)
+	assert.Equal(t, 3, stats.UniqueUserCount, "one DID and two anonymous sessions")
+}
+
+func TestMemoryQueries_CreateSurveys(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueries()
+	createLunchSurvey(t, q)
+
+	require.NoError(t, q.CreateSurveys(ctx, []*models.Survey{{ID: uuid.New(), Slug: "dinner"}, {ID: uuid.New(), Slug: "breakfast"}}))
+	assert.Len(t, q.Surveys, 3)
+
+	// A taken slug leaves the whole batch uncreated
+	assert.Error(t, q.CreateSurveys(ctx, []*models.Survey{{ID: uuid.New(), Slug: "brunch"}, {ID: uuid.New(), Slug: "lunch"}}))
+	assert.Error(t, q.CreateSurveys(ctx, []*models.Survey{{ID: uuid.New(), Slug: "supper"}, {ID: uuid.New(), Slug: "supper"}}))
+	assert.Len(t, q.Surveys, 3)
+	assert.False(t, q.Slugs["brunch"])
+}
@@ -0,0 +1,60 @@
+// Package trash keeps deleted surveys for a while, so their authors can
+// restore them, before a cleanup worker deletes them for good with their
+// responses. Surveys in the trash are left out of listings and cannot be
+// answered; their links show that they were deleted.
+package trash
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// TTL is how long a survey stays in the trash
+const TTL = 30 * 24 * time.Hour
+
+// Store persists the trash
+type Store interface {
+	// DeleteSurvey moves a survey to the trash, leaving a tombstone in its place
+	DeleteSurvey(ctx context.Context, id uuid.UUID) error
+	// ListDeletedSurveys lists an author's surveys in the trash, most recently deleted first
+	ListDeletedSurveys(ctx context.Context, authorDID string) ([]*models.Survey, error)
+	// RestoreSurvey takes a survey out of the trash, setting its CID if cid
+	// is not nil, and returns sql.ErrNoRows if it is not in the trash
+	RestoreSurvey(ctx context.Context, id uuid.UUID, cid *string) error
+	// PurgeDeletedSurveys deletes surveys moved to the trash before a time
+	PurgeDeletedSurveys(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ExpiresAt returns when a survey in the trash is deleted for good
+func ExpiresAt(survey *models.Survey) time.Time {
+	if survey.DeletedAt == nil {
+		return time.Time{}
+	}
+	return survey.DeletedAt.Add(TTL)
+}
+
+// StartPurgeWorker deletes surveys that have been in the trash for longer
+// than TTL every interval until ctx is cancelled
+func StartPurgeWorker(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := store.PurgeDeletedSurveys(ctx, time.Now().Add(-TTL))
+		if err != nil {
+			log.Printf("Error purging deleted surveys: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted surveys", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package trash
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// purgeStore records the cutoff of purges
+type purgeStore struct {
+	Store
+	before chan time.Time
+}
+
+func (s *purgeStore) PurgeDeletedSurveys(ctx context.Context, before time.Time) (int64, error) {
+	s.before <- before
+	return 1, nil
+}
+
+func TestExpiresAt(t *testing.T) {
+	deletedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC), ExpiresAt(&models.Survey{ID: uuid.New(), DeletedAt: &deletedAt}))
+	assert.True(t, ExpiresAt(&models.Survey{}).IsZero(), "surveys not in the trash do not expire")
+}
+
+func TestStartPurgeWorker(t *testing.T) {
+	store := &purgeStore{before: make(chan time.Time, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go StartPurgeWorker(ctx, store, time.Hour)
+
+	select {
+	case before := <-store.before:
+		assert.WithinDuration(t, time.Now().Add(-TTL), before, time.Minute, "purges surveys deleted more than TTL ago")
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not purge on start")
+	}
+}
@@ -0,0 +1,110 @@
+// Package trending ranks surveys by their recent responses for the landing
+// page, next to surveys that admins feature. Only listed surveys (public
+// visibility, not hidden, closed, or in the trash) are shown.
+package trending
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+const (
+	// Window is how far back responses count towards a survey's score
+	Window = 24 * time.Hour
+	// HalfLife is the age at which a response counts half as much as a new one
+	HalfLife = 6 * time.Hour
+	// Limit is how many trending surveys the landing page shows
+	Limit = 6
+	// MaxFeatured is how many surveys can be featured at once
+	MaxFeatured = 6
+	// RefreshInterval is how often the scores are recomputed
+	RefreshInterval = 5 * time.Minute
+)
+
+// ErrTooManyFeatured is returned when featuring a survey while MaxFeatured
+// surveys are featured
+var ErrTooManyFeatured = errors.New("too many featured surveys")
+
+// Entry is a trending survey
+type Entry struct {
+	Survey    *models.Survey
+	Responses int     // In the last Window
+	Score     float64 // Responses weighted by recency
+}
+
+// Store ranks and features surveys
+type Store interface {
+	// RefreshTrendingSurveys recomputes the scores of surveys with responses
+	// in the last Window
+	RefreshTrendingSurveys(ctx context.Context) error
+	// GetTrendingSurveys returns the listed surveys with the highest scores as
+	// of the last refresh, leaving out featured surveys
+	GetTrendingSurveys(ctx context.Context, limit int) ([]*Entry, error)
+	// ListFeaturedSurveys lists the featured surveys not in the trash, most
+	// recently featured first. Some may no longer be listed (see Listed).
+	ListFeaturedSurveys(ctx context.Context) ([]*models.Survey, error)
+	// FeatureSurvey features a survey, returning ErrTooManyFeatured if
+	// MaxFeatured surveys already are. Featuring a featured survey again does nothing.
+	FeatureSurvey(ctx context.Context, surveyID uuid.UUID, by string) error
+	// UnfeatureSurvey stops featuring a survey, returning sql.ErrNoRows if it
+	// is not featured
+	UnfeatureSurvey(ctx context.Context, surveyID uuid.UUID) error
+}
+
+// Listed returns the surveys that can be shown in listings: public, and not
+// hidden by moderation
+func Listed(surveys []*models.Survey) []*models.Survey {
+	var listed []*models.Survey
+	for _, s := range surveys {
+		if s.Definition.IsListed() && s.HiddenAt == nil {
+			listed = append(listed, s)
+		}
+	}
+	return listed
+}
+
+// Weight is how much a response of an age counts towards its survey's score:
+// 1 for a new response, halving every HalfLife, and 0 outside the Window
+func Weight(age time.Duration) float64 {
+	if age >= Window {
+		return 0
+	}
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, age.Seconds()/HalfLife.Seconds())
+}
+
+// Score is the score of a survey with responses of the given ages
+func Score(ages []time.Duration) float64 {
+	var score float64
+	for _, age := range ages {
+		score += Weight(age)
+	}
+	return score
+}
+
+// StartRefreshWorker recomputes the trending scores every interval until ctx
+// is cancelled
+func StartRefreshWorker(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := store.RefreshTrendingSurveys(ctx); err != nil {
+			log.Printf("Error refreshing trending surveys: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
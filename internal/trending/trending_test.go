@@ -0,0 +1,55 @@
+package trending
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeight(t *testing.T) {
+	assert.Equal(t, 1.0, Weight(0))
+	assert.Equal(t, 1.0, Weight(-time.Minute), "clock skew counts as new")
+	assert.InDelta(t, 0.5, Weight(HalfLife), 1e-9)
+	assert.InDelta(t, 0.25, Weight(2*HalfLife), 1e-9)
+	assert.Zero(t, Weight(Window))
+}
+
+func TestScore(t *testing.T) {
+	// Fresh responses outrank more, older ones
+	recent := Score([]time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute})
+	older := Score([]time.Duration{20 * time.Hour, 20 * time.Hour, 21 * time.Hour, 22 * time.Hour, 23 * time.Hour})
+	assert.Greater(t, recent, older)
+	assert.Zero(t, Score(nil))
+}
+
+// countingStore counts refreshes
+type countingStore struct {
+	Store
+	refreshes int
+}
+
+func (s *countingStore) RefreshTrendingSurveys(ctx context.Context) error {
+	s.refreshes++
+	return nil
+}
+
+func TestStartRefreshWorker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	store := &countingStore{}
+	StartRefreshWorker(ctx, store, time.Hour)
+	assert.Equal(t, 1, store.refreshes, "scores are computed on start")
+}
+
+func TestListed(t *testing.T) {
+	now := time.Now()
+	public := &models.Survey{Slug: "public"}
+	unlisted := &models.Survey{Slug: "unlisted", Definition: models.SurveyDefinition{Visibility: models.VisibilityUnlisted}}
+	hidden := &models.Survey{Slug: "hidden", HiddenAt: &now}
+
+	assert.Equal(t, []*models.Survey{public}, Listed([]*models.Survey{unlisted, public, hidden}))
+}
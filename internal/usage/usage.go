@@ -0,0 +1,148 @@
+// Package usage summarizes an author's API usage for the usage dashboard:
+// requests and rate-limit hits per API key and day, and the AI generations
+// made with each key or on the web, with their cost.
+package usage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/generator"
+)
+
+// Report periods in days
+const (
+	DefaultDays = 30
+	MaxDays     = 90
+)
+
+// Totals are the counts of a key, a day, or the whole report
+type Totals struct {
+	Requests              int     `json:"requests"`              // API requests, including rate-limited ones
+	RateLimited           int     `json:"rateLimited"`           // API requests rejected by the key's rate limit
+	Generations           int     `json:"generations"`           // AI generation attempts
+	GenerationsSucceeded  int     `json:"generationsSucceeded"`  // AI generations that produced a survey
+	GenerationRateLimited int     `json:"generationRateLimited"` // AI generations rejected by the generation quota
+	InputTokens           int     `json:"inputTokens"`
+	OutputTokens          int     `json:"outputTokens"`
+	CostUSD               float64 `json:"costUsd"`
+}
+
+// Day is the usage on a day (UTC)
+type Day struct {
+	Day time.Time `json:"day"`
+	Totals
+}
+
+// Key is the usage of one API key
+type Key struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Prefix  string    `json:"prefix"`
+	Revoked bool      `json:"revoked"`
+	Totals
+	Days []*Day `json:"days"` // Days with usage, oldest first
+}
+
+// Report is an author's usage since a day
+type Report struct {
+	Since  time.Time `json:"since"`
+	Days   int       `json:"days"`
+	Keys   []*Key    `json:"keys"` // All of the author's keys, newest first
+	Web    Totals    `json:"web"`  // AI generations made on the web, without a key
+	Totals Totals    `json:"totals"`
+	Daily  []*Day    `json:"daily"` // Days with usage across keys and the web, oldest first
+}
+
+// Since returns the first day of a report covering days days up to now
+func Since(now time.Time, days int) time.Time {
+	return apikey.Day(now).AddDate(0, 0, 1-days)
+}
+
+// Build aggregates key request counts and generation usage into a report.
+// Generations by a key not in keys are counted as web generations.
+func Build(since time.Time, days int, keys []*apikey.Key, requests []*apikey.Usage, generations []*generator.DailyUsage) *Report {
+	report := &Report{
+		Since: since,
+		Days:  days,
+		Keys:  make([]*Key, 0, len(keys)),
+		Daily: []*Day{},
+	}
+
+	byID := make(map[uuid.UUID]*Key, len(keys))
+	keyDays := make(map[uuid.UUID]map[time.Time]*Day, len(keys))
+	for _, k := range keys {
+		key := &Key{
+			ID:      k.ID,
+			Name:    k.Name,
+			Prefix:  k.Prefix,
+			Revoked: !k.Active(),
+			Days:    []*Day{},
+		}
+		report.Keys = append(report.Keys, key)
+		byID[k.ID] = key
+		keyDays[k.ID] = make(map[time.Time]*Day)
+	}
+
+	daily := make(map[time.Time]*Day)
+	dayOf := func(days map[time.Time]*Day, day time.Time) *Day {
+		d, ok := days[day]
+		if !ok {
+			d = &Day{Day: day}
+			days[day] = d
+		}
+		return d
+	}
+
+	for _, r := range requests {
+		key, ok := byID[r.KeyID]
+		if !ok {
+			continue
+		}
+		day := apikey.Day(r.Day)
+		for _, t := range []*Totals{&key.Totals, &dayOf(keyDays[r.KeyID], day).Totals, &dayOf(daily, day).Totals, &report.Totals} {
+			t.Requests += r.Requests
+			t.RateLimited += r.RateLimited
+		}
+	}
+
+	for _, g := range generations {
+		day := apikey.Day(g.Day)
+		targets := []*Totals{&dayOf(daily, day).Totals, &report.Totals}
+		if g.APIKeyID != nil && byID[*g.APIKeyID] != nil {
+			key := byID[*g.APIKeyID]
+			targets = append(targets, &key.Totals, &dayOf(keyDays[key.ID], day).Totals)
+		} else {
+			targets = append(targets, &report.Web)
+		}
+		for _, t := range targets {
+			t.Generations += g.Generations
+			t.GenerationsSucceeded += g.Succeeded
+			t.GenerationRateLimited += g.RateLimited
+			t.InputTokens += g.InputTokens
+			t.OutputTokens += g.OutputTokens
+			t.CostUSD += g.CostUSD
+		}
+	}
+
+	for _, key := range report.Keys {
+		key.Days = sortedDays(keyDays[key.ID])
+	}
+	report.Daily = sortedDays(daily)
+
+	return report
+}
+
+// sortedDays returns days oldest first
+func sortedDays(days map[time.Time]*Day) []*Day {
+	sorted := make([]*Day, 0, len(days))
+	for _, d := range days {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Day.Before(sorted[j].Day)
+	})
+	return sorted
+}
@@ -0,0 +1,79 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openmeet-team/survey/internal/apikey"
+	"github.com/openmeet-team/survey/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSince(t *testing.T) {
+	now := time.Date(2026, 3, 31, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Since(now, 30))
+	assert.Equal(t, time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), Since(now, 1))
+}
+
+func TestBuild(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	now := time.Now()
+	ci := &apikey.Key{ID: uuid.New(), Name: "CI", Prefix: "sk_ci"}
+	old := &apikey.Key{ID: uuid.New(), Name: "Old", Prefix: "sk_old", RevokedAt: &now}
+	unknown := uuid.New()
+
+	requests := []*apikey.Usage{
+		{KeyID: ci.ID, Day: day1, Requests: 10, RateLimited: 2},
+		{KeyID: ci.ID, Day: day2, Requests: 5},
+		{KeyID: old.ID, Day: day1, Requests: 1},
+		{KeyID: unknown, Day: day1, Requests: 100},
+	}
+	generations := []*generator.DailyUsage{
+		{Day: day2, APIKeyID: &ci.ID, Generations: 3, Succeeded: 2, RateLimited: 1, InputTokens: 300, OutputTokens: 600, CostUSD: 0.25},
+		{Day: day1, Generations: 1, Succeeded: 1, InputTokens: 100, OutputTokens: 200, CostUSD: 0.1},
+		{Day: day1, APIKeyID: &unknown, Generations: 1, Succeeded: 1, CostUSD: 0.05},
+	}
+
+	report := Build(day1, 2, []*apikey.Key{ci, old}, requests, generations)
+
+	require.Len(t, report.Keys, 2)
+	key := report.Keys[0]
+	assert.Equal(t, "CI", key.Name)
+	assert.False(t, key.Revoked)
+	assert.Equal(t, 15, key.Requests)
+	assert.Equal(t, 2, key.RateLimited)
+	assert.Equal(t, 3, key.Generations)
+	assert.Equal(t, 2, key.GenerationsSucceeded)
+	assert.InDelta(t, 0.25, key.CostUSD, 1e-9)
+	require.Len(t, key.Days, 2)
+	assert.Equal(t, day1, key.Days[0].Day)
+	assert.Equal(t, 10, key.Days[0].Requests)
+	assert.Equal(t, 3, key.Days[1].Generations)
+
+	assert.True(t, report.Keys[1].Revoked)
+	assert.Equal(t, 1, report.Keys[1].Requests)
+
+	// Requests of keys that are not the author's are ignored; their generations count as web
+	assert.Equal(t, 2, report.Web.Generations)
+	assert.InDelta(t, 0.15, report.Web.CostUSD, 1e-9)
+
+	assert.Equal(t, 16, report.Totals.Requests)
+	assert.Equal(t, 5, report.Totals.Generations)
+	assert.InDelta(t, 0.4, report.Totals.CostUSD, 1e-9)
+
+	require.Len(t, report.Daily, 2)
+	assert.Equal(t, 11, report.Daily[0].Requests)
+	assert.Equal(t, 2, report.Daily[0].Generations)
+	assert.Equal(t, 5, report.Daily[1].Requests)
+}
+
+func TestBuild_Empty(t *testing.T) {
+	report := Build(time.Now(), DefaultDays, nil, nil, nil)
+	assert.NotNil(t, report.Keys)
+	assert.NotNil(t, report.Daily)
+	assert.Zero(t, report.Totals)
+}
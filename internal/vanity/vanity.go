@@ -0,0 +1,217 @@
+// Package vanity reserves survey slugs for vanity use. Admins reserve single
+// slugs ("news") or namespaces ("official-*"); only admins, allow-listed
+// authors, and optionally authors with a verified handle, can claim them, as
+// decided by a Policy shared by the API and the consumer. Others are refused
+// by the API, and the consumer picks another slug for their indexed surveys.
+package vanity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/identity"
+)
+
+const (
+	// MaxPatternLength is the longest pattern, as long as the longest slug
+	MaxPatternLength = 50
+	// MaxReasonLength is the longest reason of a reservation
+	MaxReasonLength = 200
+)
+
+// ErrAlreadyReserved is returned when reserving a pattern that is reserved
+var ErrAlreadyReserved = errors.New("pattern already reserved")
+
+// patternRegex matches a slug, or a slug prefix ending in "-" followed by "*"
+var patternRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*(-\*)?$`)
+
+// Reservation reserves the slugs matching a pattern: a slug, or a namespace
+// of the slugs starting with a prefix, written "prefix-*"
+type Reservation struct {
+	Pattern   string    `json:"pattern"`
+	Reason    string    `json:"reason,omitempty"` // Shown to authors refused the slug
+	CreatedBy string    `json:"createdBy"`        // DID of the admin who reserved it
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Namespace reports whether the reservation is of a namespace rather than a
+// single slug
+func (r *Reservation) Namespace() bool {
+	return strings.HasSuffix(r.Pattern, "*")
+}
+
+// Matches reports whether a slug is reserved
+func (r *Reservation) Matches(slug string) bool {
+	if r.Namespace() {
+		return strings.HasPrefix(slug, strings.TrimSuffix(r.Pattern, "*"))
+	}
+	return slug == r.Pattern
+}
+
+// Store persists reservations
+type Store interface {
+	// ListReservedSlugs lists the reservations, sorted by pattern
+	ListReservedSlugs(ctx context.Context) ([]*Reservation, error)
+	// ReserveSlug adds a reservation, returning ErrAlreadyReserved if its
+	// pattern is reserved
+	ReserveSlug(ctx context.Context, r *Reservation) error
+	// UnreserveSlug removes the reservation of a pattern, returning
+	// sql.ErrNoRows if it is not reserved
+	UnreserveSlug(ctx context.Context, pattern string) error
+}
+
+// New validates a reservation of a pattern by an admin
+func New(pattern, reason, by string) (*Reservation, error) {
+	pattern, err := NormalizePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if len(reason) > MaxReasonLength {
+		return nil, fmt.Errorf("reason must be at most %d characters", MaxReasonLength)
+	}
+	return &Reservation{
+		Pattern:   pattern,
+		Reason:    reason,
+		CreatedBy: by,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// NormalizePattern lowercases and validates a pattern
+func NormalizePattern(pattern string) (string, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" || len(pattern) > MaxPatternLength {
+		return "", fmt.Errorf("pattern must be between 1 and %d characters", MaxPatternLength)
+	}
+	if !patternRegex.MatchString(pattern) {
+		return "", errors.New(`pattern must be a slug, or a prefix ending in "-*" to reserve a namespace`)
+	}
+	return pattern, nil
+}
+
+// Match returns the reservation of a slug, preferring a single slug over a
+// namespace and longer namespaces over shorter ones, or nil if it is not reserved
+func Match(reservations []*Reservation, slug string) *Reservation {
+	var match *Reservation
+	for _, r := range reservations {
+		if !r.Matches(slug) {
+			continue
+		}
+		if !r.Namespace() {
+			return r
+		}
+		if match == nil || len(r.Pattern) > len(match.Pattern) {
+			match = r
+		}
+	}
+	return match
+}
+
+// Available returns a slug to number on collisions: slug itself, unless it is
+// in a reserved namespace, which numbered suffixes cannot leave. Those get a
+// "survey-" prefix instead, cut to maxLength.
+func Available(reservations []*Reservation, slug string, maxLength int) string {
+	r := Match(reservations, slug)
+	if r == nil || !r.Namespace() {
+		return slug
+	}
+	slug = "survey-" + slug
+	if len(slug) > maxLength {
+		slug = strings.TrimRight(slug[:maxLength], "-")
+	}
+	return slug
+}
+
+// Config configures who may claim reserved slugs
+type Config struct {
+	Admins   []string // DIDs of admins, who always may
+	Authors  []string // DIDs of allow-listed authors
+	Verified bool     // Whether authors with a verified handle may claim them too
+}
+
+// ConfigFromEnv creates a Config from environment variables
+// Environment variables:
+//   - ADMIN_DIDS: comma-separated DIDs of admins (default: none)
+//   - VANITY_SLUG_AUTHORS: comma-separated DIDs of allow-listed authors (default: none; admins always may)
+//   - VANITY_SLUG_VERIFIED: let authors with a verified handle claim reserved slugs (default: false)
+func ConfigFromEnv() Config {
+	var config Config
+
+	for _, did := range strings.Split(os.Getenv("ADMIN_DIDS"), ",") {
+		if did = strings.TrimSpace(did); did != "" {
+			config.Admins = append(config.Admins, did)
+		}
+	}
+
+	for _, did := range strings.Split(os.Getenv("VANITY_SLUG_AUTHORS"), ",") {
+		did = strings.TrimSpace(did)
+		if did == "" {
+			continue
+		}
+		if !strings.HasPrefix(did, "did:") {
+			log.Printf("Warning: Ignoring invalid VANITY_SLUG_AUTHORS entry %q", did)
+			continue
+		}
+		config.Authors = append(config.Authors, did)
+	}
+
+	if v := os.Getenv("VANITY_SLUG_VERIFIED"); v != "" {
+		verified, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Printf("Warning: Invalid VANITY_SLUG_VERIFIED %q, using false", v)
+		}
+		config.Verified = verified
+	}
+
+	return config
+}
+
+// Policy decides who may claim reserved slugs. The API checks it when surveys
+// are created, and the consumer when surveys from other apps are indexed, so
+// both give an author the same slugs.
+type Policy struct {
+	claimants map[string]bool // Admins and allow-listed authors
+	verified  bool
+	verifier  *identity.Verifier
+}
+
+// NewPolicy creates the policy of config. Authors with a verified handle are
+// checked with verifier, if config lets them claim reserved slugs.
+func NewPolicy(config Config, verifier *identity.Verifier) *Policy {
+	p := &Policy{
+		claimants: make(map[string]bool, len(config.Admins)+len(config.Authors)),
+		verified:  config.Verified,
+		verifier:  verifier,
+	}
+	for _, did := range config.Admins {
+		p.claimants[did] = true
+	}
+	for _, did := range config.Authors {
+		p.claimants[did] = true
+	}
+	return p
+}
+
+// MayClaim reports whether an author may claim reserved slugs. Nobody ("")
+// may not, nor anyone under a nil policy.
+func (p *Policy) MayClaim(ctx context.Context, did string) bool {
+	if p == nil || did == "" {
+		return false
+	}
+	if p.claimants[did] {
+		return true
+	}
+	if !p.verified {
+		return false
+	}
+	v := p.verifier.Verify(ctx, did)
+	return v != nil && v.Verified
+}
@@ -0,0 +1,94 @@
+package vanity
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openmeet-team/survey/internal/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	r, err := New(" Official-* ", " Kept for the team ", "did:plc:admin")
+	require.NoError(t, err)
+	assert.Equal(t, "official-*", r.Pattern)
+	assert.Equal(t, "Kept for the team", r.Reason)
+	assert.Equal(t, "did:plc:admin", r.CreatedBy)
+	assert.True(t, r.Namespace())
+
+	for _, pattern := range []string{"", "official*", "official-", "-news", "*", "news-*-x", "no spaces", strings.Repeat("a", MaxPatternLength+1)} {
+		_, err := New(pattern, "", "did:plc:admin")
+		assert.Error(t, err, pattern)
+	}
+	_, err = New("news", strings.Repeat("a", MaxReasonLength+1), "did:plc:admin")
+	assert.Error(t, err)
+}
+
+func TestMatch(t *testing.T) {
+	reservations := []*Reservation{{Pattern: "official-*"}, {Pattern: "official-news-*"}, {Pattern: "news"}, {Pattern: "official-poll"}}
+
+	assert.Equal(t, "news", Match(reservations, "news").Pattern)
+	assert.Nil(t, Match(reservations, "news-2"), "single slugs only reserve themselves")
+	assert.Nil(t, Match(reservations, "officials"))
+	assert.Equal(t, "official-*", Match(reservations, "official-lunch").Pattern)
+	assert.Equal(t, "official-news-*", Match(reservations, "official-news-daily").Pattern, "the longest namespace wins")
+	assert.Equal(t, "official-poll", Match(reservations, "official-poll").Pattern, "single slugs win over namespaces")
+	assert.Nil(t, Match(nil, "news"))
+}
+
+func TestAvailable(t *testing.T) {
+	reservations := []*Reservation{{Pattern: "official-*"}, {Pattern: "news"}}
+
+	assert.Equal(t, "lunch", Available(reservations, "lunch", 50))
+	assert.Equal(t, "news", Available(reservations, "news", 50), "numbered suffixes free single slugs")
+	assert.Equal(t, "survey-official-lunch", Available(reservations, "official-lunch", 50))
+	assert.Equal(t, "survey-official-a", Available(reservations, "official-a-b", 17))
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("VANITY_SLUG_AUTHORS", " did:plc:alice, alice.bsky.social ,,did:web:example.com")
+	t.Setenv("VANITY_SLUG_VERIFIED", "true")
+	t.Setenv("ADMIN_DIDS", "did:plc:admin, ")
+	config := ConfigFromEnv()
+	assert.Equal(t, []string{"did:plc:admin"}, config.Admins)
+	assert.Equal(t, []string{"did:plc:alice", "did:web:example.com"}, config.Authors)
+	assert.True(t, config.Verified)
+
+	t.Setenv("VANITY_SLUG_VERIFIED", "maybe")
+	assert.False(t, ConfigFromEnv().Verified)
+}
+
+// verificationStore serves cached verifications
+type verificationStore map[string]*identity.Verification
+
+func (m verificationStore) GetVerification(ctx context.Context, did string) (*identity.Verification, error) {
+	return m[did], nil
+}
+
+func (m verificationStore) UpsertVerification(ctx context.Context, v *identity.Verification) error {
+	m[v.DID] = v
+	return nil
+}
+
+func TestPolicy(t *testing.T) {
+	ctx := context.Background()
+	verifier := identity.NewVerifier(verificationStore{
+		"did:plc:verified": {DID: "did:plc:verified", Handle: "verified.example.com", Verified: true, CheckedAt: time.Now()},
+	}, time.Hour)
+	config := Config{Admins: []string{"did:plc:admin"}, Authors: []string{"did:plc:brand"}}
+
+	policy := NewPolicy(config, verifier)
+	assert.True(t, policy.MayClaim(ctx, "did:plc:admin"))
+	assert.True(t, policy.MayClaim(ctx, "did:plc:brand"))
+	assert.False(t, policy.MayClaim(ctx, "did:plc:verified"), "verified authors only if configured")
+	assert.False(t, policy.MayClaim(ctx, ""))
+
+	config.Verified = true
+	assert.True(t, NewPolicy(config, verifier).MayClaim(ctx, "did:plc:verified"))
+
+	var none *Policy
+	assert.False(t, none.MayClaim(ctx, "did:plc:admin"))
+}
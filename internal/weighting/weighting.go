@@ -0,0 +1,223 @@
+// Package weighting computes weighted survey results: responses are weighted
+// by their answer to a single-choice question (e.g. a demographic one) so each
+// answer group makes up a target share of the weighted sample, correcting for
+// groups that responded more or less than their share of the population.
+package weighting
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openmeet-team/survey/internal/models"
+)
+
+// ErrInvalidSpec is returned for weighting specs that do not fit the survey
+var ErrInvalidSpec = errors.New("invalid weighting")
+
+// Spec says how to weight responses: by their answer to the single-choice
+// question WeightBy, so the group of each option makes up its share of Targets
+type Spec struct {
+	WeightBy string
+	Targets  map[string]float64 // keyed by option ID; relative shares, normalized to sum to 1
+}
+
+// ParseTargets parses the targets of a ?targets= query parameter: comma-separated
+// option:share pairs, e.g. "a:50,b:30,c:20". Shares are relative, so percentages
+// and fractions both work.
+func ParseTargets(s string) (map[string]float64, error) {
+	targets := make(map[string]float64)
+	for _, pair := range strings.Split(s, ",") {
+		option, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || option == "" {
+			return nil, fmt.Errorf("%w: target %q is not option:share", ErrInvalidSpec, pair)
+		}
+		share, err := strconv.ParseFloat(value, 64)
+		if err != nil || share < 0 || math.IsInf(share, 0) || math.IsNaN(share) {
+			return nil, fmt.Errorf("%w: share of %q must be a non-negative number", ErrInvalidSpec, option)
+		}
+		if _, dup := targets[option]; dup {
+			return nil, fmt.Errorf("%w: option %q has two targets", ErrInvalidSpec, option)
+		}
+		targets[option] = share
+	}
+	return targets, nil
+}
+
+// Validate checks the spec against the survey definition and normalizes its
+// targets to sum to 1
+func (s *Spec) Validate(def *models.SurveyDefinition) error {
+	var question *models.Question
+	for i := range def.Questions {
+		if def.Questions[i].ID == s.WeightBy {
+			question = &def.Questions[i]
+		}
+	}
+	if question == nil {
+		return fmt.Errorf("%w: no question %q", ErrInvalidSpec, s.WeightBy)
+	}
+	if question.Type != models.QuestionTypeSingle {
+		return fmt.Errorf("%w: question %q is not single-choice", ErrInvalidSpec, s.WeightBy)
+	}
+
+	total := 0.0
+	for option, share := range s.Targets {
+		if !hasOption(question, option) {
+			return fmt.Errorf("%w: question %q has no option %q", ErrInvalidSpec, s.WeightBy, option)
+		}
+		total += share
+	}
+	if total <= 0 {
+		return fmt.Errorf("%w: targets must have a positive share", ErrInvalidSpec)
+	}
+
+	normalized := make(map[string]float64, len(s.Targets))
+	for option, share := range s.Targets {
+		normalized[option] = share / total
+	}
+	s.Targets = normalized
+	return nil
+}
+
+// Results are the weighted counts of a survey's choice questions
+type Results struct {
+	WeightBy string             `json:"weightBy"`
+	Targets  map[string]float64 `json:"targets"` // normalized shares, keyed by option ID
+	Shares   map[string]float64 `json:"shares"`  // unweighted share of each group, to compare with its quota
+	Weights  map[string]float64 `json:"weights"` // weight of each response in a group, keyed by option ID
+
+	// WeightedResponses is the number of responses weighted; their weights
+	// sum to it. Excluded responses did not answer WeightBy with a targeted option.
+	WeightedResponses int `json:"weightedResponses"`
+	Excluded          int `json:"excluded"`
+
+	// MissingGroups lists targeted options no response chose. Their share
+	// cannot be reached, so the other targets are scaled up to fill it.
+	MissingGroups []string `json:"missingGroups,omitempty"`
+
+	QuestionResults map[string]*QuestionResult `json:"questionResults"` // keyed by question ID
+}
+
+// QuestionResult is the weighted counts of a choice question
+type QuestionResult struct {
+	QuestionID string `json:"questionId"`
+
+	// OptionCounts is the summed weight of the responses choosing each option,
+	// and OptionPercentages its share of the weight of the responses answering
+	// the question. Shares of multiple-choice questions can add up to over 100.
+	OptionCounts      map[string]float64 `json:"optionCounts"`
+	OptionPercentages map[string]float64 `json:"optionPercentages"`
+}
+
+// Compute weights the responses by spec, which must have been validated
+// against def, and returns the weighted counts of def's choice questions
+func Compute(def *models.SurveyDefinition, responses []*models.Response, spec Spec) *Results {
+	groups := make(map[string]int)
+	excluded := 0
+	for _, r := range responses {
+		if option, ok := group(r, spec); ok {
+			groups[option]++
+		} else {
+			excluded++
+		}
+	}
+	weighted := len(responses) - excluded
+
+	// Targets of groups without responses go to the others, in proportion
+	reachable := 0.0
+	var missing []string
+	for option, share := range spec.Targets {
+		if groups[option] > 0 {
+			reachable += share
+		} else if share > 0 {
+			missing = append(missing, option)
+		}
+	}
+	sort.Strings(missing)
+
+	// Each group's weights sum to its share of the weighted responses
+	weights := make(map[string]float64, len(groups))
+	shares := make(map[string]float64, len(groups))
+	for option, n := range groups {
+		weights[option] = spec.Targets[option] / reachable * float64(weighted) / float64(n)
+		shares[option] = float64(n) / float64(weighted)
+	}
+
+	results := &Results{
+		WeightBy:          spec.WeightBy,
+		Targets:           spec.Targets,
+		Shares:            shares,
+		Weights:           weights,
+		WeightedResponses: weighted,
+		Excluded:          excluded,
+		MissingGroups:     missing,
+		QuestionResults:   make(map[string]*QuestionResult),
+	}
+
+	for _, question := range def.Questions {
+		if question.Type != models.QuestionTypeSingle && question.Type != models.QuestionTypeMulti {
+			continue
+		}
+		qr := &QuestionResult{
+			QuestionID:        question.ID,
+			OptionCounts:      make(map[string]float64, len(question.Options)),
+			OptionPercentages: make(map[string]float64, len(question.Options)),
+		}
+		for _, option := range question.Options {
+			qr.OptionCounts[option.ID] = 0
+		}
+
+		answered := 0.0
+		for _, r := range responses {
+			option, ok := group(r, spec)
+			if !ok {
+				continue
+			}
+			selected := r.Answers[question.ID].SelectedOptions
+			if len(selected) == 0 {
+				continue
+			}
+			answered += weights[option]
+			for _, id := range selected {
+				qr.OptionCounts[id] += weights[option]
+			}
+		}
+		for id, count := range qr.OptionCounts {
+			qr.OptionPercentages[id] = 0
+			if answered > 0 {
+				qr.OptionPercentages[id] = round(count / answered * 100)
+			}
+			qr.OptionCounts[id] = round(count)
+		}
+		results.QuestionResults[question.ID] = qr
+	}
+
+	return results
+}
+
+// hasOption reports whether the question has an option with the ID
+func hasOption(question *models.Question, id string) bool {
+	for _, option := range question.Options {
+		if option.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// group returns the targeted option a response chose for the WeightBy question
+func group(r *models.Response, spec Spec) (string, bool) {
+	selected := r.Answers[spec.WeightBy].SelectedOptions
+	if len(selected) != 1 || spec.Targets[selected[0]] <= 0 {
+		return "", false
+	}
+	return selected[0], true
+}
+
+// round rounds weighted figures to two decimals, hiding floating-point noise
+func round(x float64) float64 {
+	return math.Round(x*100) / 100
+}
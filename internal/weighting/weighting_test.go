@@ -0,0 +1,116 @@
+package weighting
+
+import (
+	"testing"
+
+	"github.com/openmeet-team/survey/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDefinition() *models.SurveyDefinition {
+	return &models.SurveyDefinition{Questions: []models.Question{
+		{ID: "age", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "young"}, {ID: "old"}, {ID: "other"}}},
+		{ID: "tea", Type: models.QuestionTypeSingle, Options: []models.Option{{ID: "yes"}, {ID: "no"}}},
+		{ID: "snacks", Type: models.QuestionTypeMulti, Options: []models.Option{{ID: "cake"}, {ID: "fruit"}}},
+		{ID: "why", Type: models.QuestionTypeText},
+	}}
+}
+
+func response(age, tea string, snacks ...string) *models.Response {
+	answers := map[string]models.Answer{"tea": {SelectedOptions: []string{tea}}}
+	if age != "" {
+		answers["age"] = models.Answer{SelectedOptions: []string{age}}
+	}
+	if len(snacks) > 0 {
+		answers["snacks"] = models.Answer{SelectedOptions: snacks}
+	}
+	return &models.Response{Answers: answers}
+}
+
+func TestParseTargets(t *testing.T) {
+	targets, err := ParseTargets("young:60, old:40")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"young": 60, "old": 40}, targets)
+
+	for _, bad := range []string{"", "young", "young:", ":5", "young:-1", "young:x", "young:1,young:2", "young:Inf"} {
+		_, err := ParseTargets(bad)
+		assert.ErrorIs(t, err, ErrInvalidSpec, bad)
+	}
+}
+
+func TestSpec_Validate(t *testing.T) {
+	spec := Spec{WeightBy: "age", Targets: map[string]float64{"young": 60, "old": 40}}
+	require.NoError(t, spec.Validate(testDefinition()))
+	assert.InDelta(t, 0.6, spec.Targets["young"], 1e-9)
+	assert.InDelta(t, 0.4, spec.Targets["old"], 1e-9)
+
+	invalid := map[string]Spec{
+		"unknown question":    {WeightBy: "nope", Targets: map[string]float64{"young": 1}},
+		"not single-choice":   {WeightBy: "snacks", Targets: map[string]float64{"cake": 1}},
+		"unknown option":      {WeightBy: "age", Targets: map[string]float64{"teen": 1}},
+		"no positive targets": {WeightBy: "age", Targets: map[string]float64{"young": 0}},
+	}
+	for name, spec := range invalid {
+		assert.ErrorIs(t, spec.Validate(testDefinition()), ErrInvalidSpec, name)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	def := testDefinition()
+	// Three young voters who all drink tea, one old voter who does not:
+	// with a 50/50 target, tea drinkers are half of the weighted sample
+	responses := []*models.Response{
+		response("young", "yes", "cake"),
+		response("young", "yes", "cake"),
+		response("young", "yes"),
+		response("old", "no", "cake", "fruit"),
+		response("", "no"),      // did not answer the weighting question
+		response("other", "no"), // chose an option without a target
+	}
+	spec := Spec{WeightBy: "age", Targets: map[string]float64{"young": 1, "old": 1}}
+	require.NoError(t, spec.Validate(def))
+
+	results := Compute(def, responses, spec)
+	assert.Equal(t, 4, results.WeightedResponses)
+	assert.Equal(t, 2, results.Excluded)
+	assert.Empty(t, results.MissingGroups)
+	assert.InDelta(t, 0.75, results.Shares["young"], 1e-9)
+	assert.InDelta(t, 2.0/3, results.Weights["young"], 1e-9)
+	assert.InDelta(t, 2.0, results.Weights["old"], 1e-9)
+
+	tea := results.QuestionResults["tea"]
+	assert.Equal(t, 2.0, tea.OptionCounts["yes"])
+	assert.Equal(t, 2.0, tea.OptionCounts["no"])
+	assert.Equal(t, 50.0, tea.OptionPercentages["yes"])
+
+	// Multiple-choice shares are of the weight of the voters who answered
+	snacks := results.QuestionResults["snacks"]
+	assert.Equal(t, 3.33, snacks.OptionCounts["cake"])
+	assert.Equal(t, 2.0, snacks.OptionCounts["fruit"])
+	assert.Equal(t, 100.0, snacks.OptionPercentages["cake"])
+	assert.Equal(t, 60.0, snacks.OptionPercentages["fruit"])
+
+	assert.NotContains(t, results.QuestionResults, "why", "text questions are not weighted")
+}
+
+func TestCompute_MissingGroup(t *testing.T) {
+	def := testDefinition()
+	spec := Spec{WeightBy: "age", Targets: map[string]float64{"young": 1, "old": 1, "other": 2}}
+	require.NoError(t, spec.Validate(def))
+
+	results := Compute(def, []*models.Response{response("young", "yes"), response("old", "no")}, spec)
+	assert.Equal(t, []string{"other"}, results.MissingGroups)
+	assert.InDelta(t, 1.0, results.Weights["young"], 1e-9, "the missing share is spread over the other groups")
+	assert.Equal(t, 50.0, results.QuestionResults["tea"].OptionPercentages["yes"])
+}
+
+func TestCompute_NoResponses(t *testing.T) {
+	def := testDefinition()
+	spec := Spec{WeightBy: "age", Targets: map[string]float64{"young": 1}}
+	require.NoError(t, spec.Validate(def))
+
+	results := Compute(def, nil, spec)
+	assert.Zero(t, results.WeightedResponses)
+	assert.Equal(t, 0.0, results.QuestionResults["tea"].OptionPercentages["yes"])
+}
@@ -0,0 +1,386 @@
+// Package lexicon embeds the published net.openmeet.survey lexicon schemas and
+// validates records against them.
+//
+// The validator covers the subset of the Lexicon language used by these schemas:
+// objects, strings (length, grapheme, format and enum constraints), integers,
+// booleans, arrays, blobs (accept and maxSize), and refs (local defs and
+// com.atproto.repo.strongRef).
+// Unknown fields are allowed, as lexicons may gain optional fields over time.
+package lexicon
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+//go:embed *.json
+var schemaFiles embed.FS
+
+// Def is a single lexicon definition (or a nested schema inside one)
+type Def struct {
+	Type         string          `json:"type"`
+	Record       *Def            `json:"record,omitempty"`
+	Required     []string        `json:"required,omitempty"`
+	Properties   map[string]*Def `json:"properties,omitempty"`
+	Items        *Def            `json:"items,omitempty"`
+	Ref          string          `json:"ref,omitempty"`
+	Format       string          `json:"format,omitempty"`
+	MinLength    *int            `json:"minLength,omitempty"`
+	MaxLength    *int            `json:"maxLength,omitempty"`
+	MaxGraphemes *int            `json:"maxGraphemes,omitempty"`
+	Minimum      *int64          `json:"minimum,omitempty"`
+	Maximum      *int64          `json:"maximum,omitempty"`
+	Enum         []string        `json:"enum,omitempty"`
+	Accept       []string        `json:"accept,omitempty"`  // MIME types of a blob, e.g. "image/*"
+	MaxSize      *int64          `json:"maxSize,omitempty"` // Bytes of a blob
+}
+
+// Schema is a lexicon document
+type Schema struct {
+	Lexicon int             `json:"lexicon"`
+	ID      string          `json:"id"`
+	Defs    map[string]*Def `json:"defs"`
+}
+
+// ValidationError describes the first schema violation found in a record
+type ValidationError struct {
+	Path    string // Location of the invalid value, e.g. "questions[2].text"
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Validator validates records against a set of lexicon schemas
+type Validator struct {
+	schemas map[string]*Schema
+}
+
+// NewValidator loads the embedded lexicon schemas
+func NewValidator() (*Validator, error) {
+	v := &Validator{schemas: make(map[string]*Schema)}
+
+	err := fs.WalkDir(schemaFiles, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := schemaFiles.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var schema Schema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return fmt.Errorf("failed to parse lexicon %s: %w", path, err)
+		}
+		v.schemas[schema.ID] = &schema
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lexicons: %w", err)
+	}
+
+	return v, nil
+}
+
+// IDs returns the NSIDs of the embedded lexicon schemas, sorted
+func IDs() []string {
+	files, _ := fs.Glob(schemaFiles, "*.json")
+	ids := make([]string, len(files))
+	for i, file := range files {
+		ids[i] = strings.TrimSuffix(file, ".json")
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Document returns the embedded lexicon schema of an NSID as published, or
+// false if there is none
+func Document(id string) ([]byte, bool) {
+	if !fs.ValidPath(id) || strings.Contains(id, "/") {
+		return nil, false
+	}
+	data, err := schemaFiles.ReadFile(id + ".json")
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// HasSchema reports whether a schema is known for the collection
+func (v *Validator) HasSchema(collection string) bool {
+	_, ok := v.schemas[collection]
+	return ok
+}
+
+// ValidateRecord validates a record of a collection against its lexicon.
+// Returns a *ValidationError for schema violations.
+func (v *Validator) ValidateRecord(collection string, record map[string]interface{}) error {
+	schema, ok := v.schemas[collection]
+	if !ok {
+		return fmt.Errorf("no lexicon for collection %s", collection)
+	}
+	main, ok := schema.Defs["main"]
+	if !ok || main.Type != "record" || main.Record == nil {
+		return fmt.Errorf("lexicon %s has no record definition", collection)
+	}
+
+	if t, ok := record["$type"]; ok && t != collection {
+		return &ValidationError{Path: "$type", Message: fmt.Sprintf("expected %q, got %v", collection, t)}
+	}
+
+	return v.validate(schema, main.Record, record, "")
+}
+
+// validate checks a value against a definition within the given schema
+func (v *Validator) validate(schema *Schema, def *Def, value interface{}, path string) error {
+	switch def.Type {
+	case "object":
+		return v.validateObject(schema, def, value, path)
+	case "array":
+		return v.validateArray(schema, def, value, path)
+	case "string":
+		return validateString(def, value, path)
+	case "integer":
+		return validateInteger(def, value, path)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return &ValidationError{Path: path, Message: "expected boolean"}
+		}
+		return nil
+	case "blob":
+		return validateBlob(def, value, path)
+	case "ref":
+		return v.validateRef(schema, def.Ref, value, path)
+	case "unknown":
+		return nil
+	default:
+		return &ValidationError{Path: path, Message: fmt.Sprintf("unsupported lexicon type %q", def.Type)}
+	}
+}
+
+func (v *Validator) validateObject(schema *Schema, def *Def, value interface{}, path string) error {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return &ValidationError{Path: path, Message: "expected object"}
+	}
+
+	for _, name := range def.Required {
+		if val, ok := obj[name]; !ok || val == nil {
+			return &ValidationError{Path: joinPath(path, name), Message: "required field missing"}
+		}
+	}
+
+	// Check properties in name order so the reported violation is deterministic
+	names := make([]string, 0, len(def.Properties))
+	for name := range def.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		val, ok := obj[name]
+		if !ok || val == nil {
+			continue
+		}
+		if err := v.validate(schema, def.Properties[name], val, joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) validateArray(schema *Schema, def *Def, value interface{}, path string) error {
+	arr, ok := value.([]interface{})
+	if !ok {
+		return &ValidationError{Path: path, Message: "expected array"}
+	}
+	if def.MinLength != nil && len(arr) < *def.MinLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must have at least %d items", *def.MinLength)}
+	}
+	if def.MaxLength != nil && len(arr) > *def.MaxLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must have at most %d items", *def.MaxLength)}
+	}
+
+	if def.Items == nil {
+		return nil
+	}
+	for i, item := range arr {
+		if err := v.validate(schema, def.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRef resolves a ref ("#def", "nsid#def" or "nsid") and validates against it
+func (v *Validator) validateRef(schema *Schema, ref string, value interface{}, path string) error {
+	if ref == "com.atproto.repo.strongRef" {
+		return v.validate(schema, strongRef, value, path)
+	}
+
+	nsid, name, _ := strings.Cut(ref, "#")
+	if name == "" {
+		name = "main"
+	}
+	target := schema
+	if nsid != "" {
+		var ok bool
+		if target, ok = v.schemas[nsid]; !ok {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("unknown lexicon ref %q", ref)}
+		}
+	}
+
+	def, ok := target.Defs[name]
+	if !ok {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("unknown lexicon ref %q", ref)}
+	}
+	return v.validate(target, def, value, path)
+}
+
+// strongRef is com.atproto.repo.strongRef, referenced by the survey lexicons
+var strongRef = &Def{
+	Type:     "object",
+	Required: []string{"uri", "cid"},
+	Properties: map[string]*Def{
+		"uri": {Type: "string", Format: "at-uri"},
+		"cid": {Type: "string", Format: "cid"},
+	},
+}
+
+func validateString(def *Def, value interface{}, path string) error {
+	s, ok := value.(string)
+	if !ok {
+		return &ValidationError{Path: path, Message: "expected string"}
+	}
+
+	// maxLength counts UTF-8 bytes; maxGraphemes is approximated by code points
+	if def.MinLength != nil && len(s) < *def.MinLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at least %d bytes", *def.MinLength)}
+	}
+	if def.MaxLength != nil && len(s) > *def.MaxLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %d bytes", *def.MaxLength)}
+	}
+	if def.MaxGraphemes != nil && utf8.RuneCountInString(s) > *def.MaxGraphemes {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %d characters", *def.MaxGraphemes)}
+	}
+
+	if len(def.Enum) > 0 {
+		found := false
+		for _, e := range def.Enum {
+			if s == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must be one of %s", strings.Join(def.Enum, ", "))}
+		}
+	}
+
+	if def.Format != "" && !validFormat(def.Format, s) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("invalid %s", def.Format)}
+	}
+
+	return nil
+}
+
+func validateInteger(def *Def, value interface{}, path string) error {
+	// JSON numbers decode as float64
+	f, ok := value.(float64)
+	if !ok || f != float64(int64(f)) {
+		return &ValidationError{Path: path, Message: "expected integer"}
+	}
+	n := int64(f)
+	if def.Minimum != nil && n < *def.Minimum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at least %d", *def.Minimum)}
+	}
+	if def.Maximum != nil && n > *def.Maximum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %d", *def.Maximum)}
+	}
+	return nil
+}
+
+// validateBlob checks a blob reference: {"$type": "blob", "ref": {"$link": cid}, "mimeType", "size"}
+func validateBlob(def *Def, value interface{}, path string) error {
+	blob, ok := value.(map[string]interface{})
+	if !ok || blob["$type"] != "blob" {
+		return &ValidationError{Path: path, Message: "expected blob"}
+	}
+
+	ref, _ := blob["ref"].(map[string]interface{})
+	if link, _ := ref["$link"].(string); link == "" {
+		return &ValidationError{Path: joinPath(path, "ref"), Message: "expected blob CID link"}
+	}
+
+	mimeType, _ := blob["mimeType"].(string)
+	if len(def.Accept) > 0 && !acceptsMimeType(def.Accept, mimeType) {
+		return &ValidationError{Path: joinPath(path, "mimeType"), Message: fmt.Sprintf("must be one of %s", strings.Join(def.Accept, ", "))}
+	}
+
+	size, ok := blob["size"].(float64)
+	if !ok || size < 0 || size != float64(int64(size)) {
+		return &ValidationError{Path: joinPath(path, "size"), Message: "expected integer"}
+	}
+	if def.MaxSize != nil && int64(size) > *def.MaxSize {
+		return &ValidationError{Path: joinPath(path, "size"), Message: fmt.Sprintf("must be at most %d bytes", *def.MaxSize)}
+	}
+
+	return nil
+}
+
+// acceptsMimeType matches a MIME type against accept patterns like "image/png" or "image/*"
+func acceptsMimeType(accept []string, mimeType string) bool {
+	for _, pattern := range accept {
+		if pattern == "*/*" || pattern == mimeType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(mimeType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	didRegex      = regexp.MustCompile(`^did:[a-z]+:[a-zA-Z0-9._:%-]*[a-zA-Z0-9._-]$`)
+	languageRegex = regexp.MustCompile(`^[a-zA-Z]{2,8}(-[a-zA-Z0-9]{1,8})*$`)
+)
+
+// validFormat checks the string formats used by the survey lexicons
+func validFormat(format, s string) bool {
+	switch format {
+	case "datetime":
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	case "did":
+		return didRegex.MatchString(s)
+	case "at-uri":
+		return strings.HasPrefix(s, "at://") && len(s) > len("at://")
+	case "cid":
+		return s != ""
+	case "language":
+		return languageRegex.MatchString(s)
+	default:
+		return true // Unknown formats are not enforced
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,226 @@
+package lexicon
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(s), &record))
+	return record
+}
+
+const validSurvey = `{
+	"$type": "net.openmeet.survey",
+	"name": "Lunch",
+	"questions": [
+		{"id": "q1", "text": "Where?", "type": "net.openmeet.survey#single", "options": [{"id": "a", "text": "Pizza"}]}
+	],
+	"langs": ["en-US"],
+	"createdAt": "2026-01-02T10:00:00.000Z"
+}`
+
+const validResponse = `{
+	"$type": "net.openmeet.survey.response",
+	"subject": {"uri": "at://did:plc:author/net.openmeet.survey/3k2a", "cid": "bafyreia"},
+	"answers": [{"questionId": "q1", "selectedOptions": ["a"]}],
+	"createdAt": "2026-01-02T10:00:00Z"
+}`
+
+func TestNewValidator(t *testing.T) {
+	v, err := NewValidator()
+	require.NoError(t, err)
+
+	assert.True(t, v.HasSchema("net.openmeet.survey"))
+	assert.True(t, v.HasSchema("net.openmeet.survey.response"))
+	assert.True(t, v.HasSchema("net.openmeet.survey.results"))
+	assert.True(t, v.HasSchema("net.openmeet.survey.comment"))
+	assert.False(t, v.HasSchema("app.bsky.feed.post"))
+}
+
+func TestValidateRecord_Valid(t *testing.T) {
+	v, err := NewValidator()
+	require.NoError(t, err)
+
+	assert.NoError(t, v.ValidateRecord("net.openmeet.survey", decode(t, validSurvey)))
+	assert.NoError(t, v.ValidateRecord("net.openmeet.survey.response", decode(t, validResponse)))
+
+	// Images are blob references
+	record := decode(t, validSurvey)
+	option := record["questions"].([]interface{})[0].(map[string]interface{})["options"].([]interface{})[0].(map[string]interface{})
+	option["image"] = decode(t, `{"image": {"$type": "blob", "ref": {"$link": "bafkreia"}, "mimeType": "image/png", "size": 1234}, "alt": "Pizza"}`)
+	assert.NoError(t, v.ValidateRecord("net.openmeet.survey", record))
+
+	// Unknown fields are allowed
+	record = decode(t, validSurvey)
+	record["futureField"] = true
+	assert.NoError(t, v.ValidateRecord("net.openmeet.survey", record))
+}
+
+func TestValidateRecord_Violations(t *testing.T) {
+	v, err := NewValidator()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		collection string
+		base       string
+		modify     func(map[string]interface{})
+		path       string
+	}{
+		{
+			name:       "missing required field",
+			collection: "net.openmeet.survey",
+			base:       validSurvey,
+			modify:     func(r map[string]interface{}) { delete(r, "name") },
+			path:       "name",
+		},
+		{
+			name:       "string too long",
+			collection: "net.openmeet.survey",
+			base:       validSurvey,
+			modify:     func(r map[string]interface{}) { r["name"] = strings.Repeat("x", 301) },
+			path:       "name",
+		},
+		{
+			name:       "too many graphemes",
+			collection: "net.openmeet.survey",
+			base:       validSurvey,
+			modify:     func(r map[string]interface{}) { r["name"] = strings.Repeat("é", 101) },
+			path:       "name",
+		},
+		{
+			name:       "empty questions",
+			collection: "net.openmeet.survey",
+			base:       validSurvey,
+			modify:     func(r map[string]interface{}) { r["questions"] = []interface{}{} },
+			path:       "questions",
+		},
+		{
+			name:       "nested option missing text",
+			collection: "net.openmeet.survey",
+			base:       validSurvey,
+			modify: func(r map[string]interface{}) {
+				q := r["questions"].([]interface{})[0].(map[string]interface{})
+				q["options"] = []interface{}{map[string]interface{}{"id": "a"}}
+			},
+			path: "questions[0].options[0].text",
+		},
+		{
+			name:       "wrong type",
+			collection: "net.openmeet.survey",
+			base:       validSurvey,
+			modify:     func(r map[string]interface{}) { r["anonymous"] = "yes" },
+			path:       "anonymous",
+		},
+		{
+			name:       "invalid datetime",
+			collection: "net.openmeet.survey",
+			base:       validSurvey,
+			modify:     func(r map[string]interface{}) { r["createdAt"] = "yesterday" },
+			path:       "createdAt",
+		},
+		{
+			name:       "invalid strongRef uri",
+			collection: "net.openmeet.survey.response",
+			base:       validResponse,
+			modify: func(r map[string]interface{}) {
+				r["subject"] = map[string]interface{}{"uri": "https://example.com", "cid": "bafyreia"}
+			},
+			path: "subject.uri",
+		},
+		{
+			name:       "image blob of a disallowed type",
+			collection: "net.openmeet.survey",
+			base:       validSurvey,
+			modify: func(r map[string]interface{}) {
+				q := r["questions"].([]interface{})[0].(map[string]interface{})
+				q["image"] = decode(t, `{"image": {"$type": "blob", "ref": {"$link": "bafkreia"}, "mimeType": "image/svg+xml", "size": 10}, "alt": ""}`)
+			},
+			path: "questions[0].image.image.mimeType",
+		},
+		{
+			name:       "image blob too large",
+			collection: "net.openmeet.survey",
+			base:       validSurvey,
+			modify: func(r map[string]interface{}) {
+				q := r["questions"].([]interface{})[0].(map[string]interface{})
+				q["image"] = decode(t, `{"image": {"$type": "blob", "ref": {"$link": "bafkreia"}, "mimeType": "image/png", "size": 1000001}, "alt": ""}`)
+			},
+			path: "questions[0].image.image.size",
+		},
+		{
+			name:       "image without a blob",
+			collection: "net.openmeet.survey",
+			base:       validSurvey,
+			modify: func(r map[string]interface{}) {
+				q := r["questions"].([]interface{})[0].(map[string]interface{})
+				q["image"] = decode(t, `{"image": "https://example.com/cat.png", "alt": ""}`)
+			},
+			path: "questions[0].image.image",
+		},
+		{
+			name:       "mismatched $type",
+			collection: "net.openmeet.survey.response",
+			base:       validResponse,
+			modify:     func(r map[string]interface{}) { r["$type"] = "net.openmeet.survey" },
+			path:       "$type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := decode(t, tt.base)
+			tt.modify(record)
+
+			err := v.ValidateRecord(tt.collection, record)
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.path, validationErr.Path)
+		})
+	}
+}
+
+func TestValidateRecord_UnknownCollection(t *testing.T) {
+	v, err := NewValidator()
+	require.NoError(t, err)
+
+	err = v.ValidateRecord("app.bsky.feed.post", map[string]interface{}{})
+	assert.Error(t, err)
+	var validationErr *ValidationError
+	assert.False(t, errors.As(err, &validationErr))
+}
+
+func TestDocuments(t *testing.T) {
+	ids := IDs()
+	assert.Equal(t, []string{
+		"net.openmeet.survey",
+		"net.openmeet.survey.comment",
+		"net.openmeet.survey.getComments",
+		"net.openmeet.survey.getResponses",
+		"net.openmeet.survey.getResults",
+		"net.openmeet.survey.getSurvey",
+		"net.openmeet.survey.response",
+		"net.openmeet.survey.results",
+	}, ids)
+
+	for _, id := range ids {
+		data, ok := Document(id)
+		require.True(t, ok, id)
+		var schema Schema
+		require.NoError(t, json.Unmarshal(data, &schema))
+		assert.Equal(t, id, schema.ID)
+	}
+
+	for _, id := range []string{"", "app.bsky.feed.post", "../lexicon", "net.openmeet.survey.json", "./net.openmeet.survey"} {
+		_, ok := Document(id)
+		assert.False(t, ok, id)
+	}
+}
@@ -0,0 +1,414 @@
+// Package client is a Go client of the survey service's JSON API, for other
+// services, surveyctl, and the load test to create surveys, submit responses,
+// and read results. It depends on no internal package: its types mirror the
+// JSON of the API.
+//
+// Requests take a context, and are retried when the service is rate limiting
+// or briefly unavailable. Response submissions carry an Idempotency-Key, so a
+// retried submission is counted once.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client calls the JSON API of a survey service. Its fields must not change
+// while requests are in flight.
+type Client struct {
+	BaseURL    string // e.g. "https://survey.openmeet.net", without /api/v1
+	APIKey     string // "sk_..." key; surveys are created for its owner
+	HTTPClient *http.Client
+
+	MaxRetries int           // Retries of a failed request; 0 disables retrying
+	MinBackoff time.Duration // Wait before the first retry, doubled for each next one
+	MaxBackoff time.Duration // Longest wait between retries, including Retry-After
+}
+
+// New creates a client of the service at baseURL that retries failed
+// requests up to 3 times
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: 3,
+		MinBackoff: 500 * time.Millisecond,
+		MaxBackoff: 10 * time.Second,
+	}
+}
+
+// Error codes of the API that callers commonly handle. Codes never change
+// meaning; GET /problems on the service lists all of them.
+const (
+	CodeInvalidDefinition = "invalid_definition"
+	CodeInvalidAnswers    = "invalid_answers"
+	CodeSurveyNotFound    = "survey_not_found"
+	CodeSurveyClosed      = "survey_closed"
+	CodeSlugTaken         = "slug_taken"
+	CodeAlreadyVoted      = "already_voted"
+	CodeRateLimited       = "rate_limited"
+)
+
+// Error is an error response of the API, a problem details object
+type Error struct {
+	Status  int    `json:"status"` // HTTP status code
+	Code    string `json:"code"`   // Empty for errors not from the API, e.g. of a proxy
+	Title   string `json:"title"`
+	Detail  string `json:"detail"`
+	TraceID string `json:"traceId"`
+
+	retryAfter time.Duration // From the Retry-After header, if any
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%s: %s (HTTP %d)", e.Title, e.Detail, e.Status)
+	}
+	return fmt.Sprintf("%s (HTTP %d)", e.Title, e.Status)
+}
+
+// IsCode reports whether err is an API error with the given code
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// Survey is a survey as the API returns it. Definition is empty in lists.
+type Survey struct {
+	ID          uuid.UUID       `json:"id"`
+	URI         *string         `json:"uri,omitempty"`       // AT URI, once published to the author's PDS
+	AuthorDID   *string         `json:"authorDid,omitempty"` // Empty for guest surveys
+	Slug        string          `json:"slug"`
+	Title       string          `json:"title"`
+	Description *string         `json:"description,omitempty"`
+	Definition  json.RawMessage `json:"definition,omitempty"` // Questions and settings, as JSON
+	Version     int             `json:"version,omitempty"`    // Bumped whenever the definition changes
+	StartsAt    *time.Time      `json:"startsAt,omitempty"`
+	EndsAt      *time.Time      `json:"endsAt,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+// Answer is the answer to one question
+type Answer struct {
+	SelectedOptions []string          `json:"selectedOptions,omitempty"` // Option IDs of single and multi questions
+	Text            string            `json:"text,omitempty"`            // Also the value of number, date, and datetime questions
+	Rows            map[string]string `json:"rows,omitempty"`            // Option ID chosen for each row of a matrix question
+}
+
+// Submission is a response submitted to a survey
+type Submission struct {
+	ID        uuid.UUID `json:"id"`
+	SurveyID  uuid.UUID `json:"surveyId"`
+	CreatedAt time.Time `json:"createdAt"`
+	Receipt   string    `json:"receipt,omitempty"` // Signed receipt token, if the service signs receipts
+}
+
+// Results are the counted responses of a survey
+type Results struct {
+	SurveyID           uuid.UUID                  `json:"surveyId"`
+	TotalVotes         int                        `json:"totalVotes"`
+	ExcludedDuplicates int                        `json:"excludedDuplicates,omitempty"` // Responses the author excluded as suspected duplicates
+	ExcludedSpam       int                        `json:"excludedSpam,omitempty"`       // Responses at or above the survey's spam threshold
+	QuestionResults    map[string]*QuestionResult `json:"questionResults"`              // Keyed by question ID
+	Version            int                        `json:"version"`                      // Current definition version
+	VersionVotes       map[int]int                `json:"versionVotes,omitempty"`       // Votes per definition version answered
+	MixedVersions      bool                       `json:"mixedVersions,omitempty"`      // Votes answered more than one definition version
+}
+
+// QuestionResult are the counted answers to one question
+type QuestionResult struct {
+	QuestionID     string                    `json:"questionId"`
+	Ordinal        int                       `json:"ordinal"`     // 1-based position in the definition, 0 if no longer present
+	Respondents    int                       `json:"respondents"` // Responses that answered the question
+	OptionCounts   map[string]int            `json:"optionCounts"`
+	TextAnswers    []string                  `json:"textAnswers"`
+	RowCounts      map[string]map[string]int `json:"rowCounts,omitempty"`      // Option counts of each matrix row
+	Histogram      json.RawMessage           `json:"histogram,omitempty"`      // Buckets of number questions, as JSON
+	Dates          json.RawMessage           `json:"dates,omitempty"`          // Summary of date and datetime questions, as JSON
+	RemovedOptions map[string]string         `json:"removedOptions,omitempty"` // Text of counted options no longer in the definition
+}
+
+// Generated is a survey definition generated from a description
+type Generated struct {
+	Definition   json.RawMessage `json:"definition"` // JSON that CreateSurvey accepts as a definition
+	TokensUsed   int             `json:"tokens_used"`
+	Cost         float64         `json:"cost"`                    // In US dollars
+	NeedsCaptcha bool            `json:"needs_captcha,omitempty"` // The next generation requires a CAPTCHA token
+}
+
+// CreateSurvey creates a survey from a JSON or YAML definition. An empty slug
+// is generated from the first question. A failed creation is retried only if
+// the service rejected it before creating anything, e.g. when rate limiting.
+func (c *Client) CreateSurvey(ctx context.Context, slug, definition string) (*Survey, error) {
+	body := map[string]string{"slug": slug, "definition": definition}
+	var survey Survey
+	if _, err := c.do(ctx, http.MethodPost, "/surveys", body, nil, &survey); err != nil {
+		return nil, err
+	}
+	return &survey, nil
+}
+
+// UpdateSurvey replaces the definition of a survey that is not published to
+// a PDS
+func (c *Client) UpdateSurvey(ctx context.Context, slug, definition string) (*Survey, error) {
+	body := map[string]string{"definition": definition}
+	var survey Survey
+	if _, err := c.do(ctx, http.MethodPut, surveyPath(slug), body, nil, &survey); err != nil {
+		return nil, err
+	}
+	return &survey, nil
+}
+
+// ArchiveSurvey closes voting on a survey, keeping its results
+func (c *Client) ArchiveSurvey(ctx context.Context, slug string) (*Survey, error) {
+	var survey Survey
+	if _, err := c.do(ctx, http.MethodPost, surveyPath(slug)+"/archive", nil, nil, &survey); err != nil {
+		return nil, err
+	}
+	return &survey, nil
+}
+
+// ListSurveys lists the surveys of the API key's owner, oldest first
+func (c *Client) ListSurveys(ctx context.Context) ([]Survey, error) {
+	var surveys []Survey
+	if _, err := c.do(ctx, http.MethodGet, "/surveys", nil, nil, &surveys); err != nil {
+		return nil, err
+	}
+	return surveys, nil
+}
+
+// GetSurvey gets a survey with its definition
+func (c *Client) GetSurvey(ctx context.Context, slug string) (*Survey, error) {
+	var survey Survey
+	if _, err := c.do(ctx, http.MethodGet, surveyPath(slug), nil, nil, &survey); err != nil {
+		return nil, err
+	}
+	return &survey, nil
+}
+
+// SubmitResponse submits answers to a survey, keyed by question ID, with a
+// new idempotency key that its retries reuse
+func (c *Client) SubmitResponse(ctx context.Context, slug string, answers map[string]Answer) (*Submission, error) {
+	return c.SubmitResponseWithKey(ctx, slug, answers, uuid.NewString())
+}
+
+// SubmitResponseWithKey submits answers with an idempotency key of the
+// caller's, e.g. one stored with a queued submission to survive restarts.
+// Submitting the same answers with the same key again returns the original
+// submission instead of voting twice.
+func (c *Client) SubmitResponseWithKey(ctx context.Context, slug string, answers map[string]Answer, idempotencyKey string) (*Submission, error) {
+	body := map[string]interface{}{"answers": answers}
+	header := http.Header{}
+	if idempotencyKey != "" {
+		header.Set("Idempotency-Key", idempotencyKey)
+	}
+	var submission Submission
+	if _, err := c.do(ctx, http.MethodPost, surveyPath(slug)+"/responses", body, header, &submission); err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// GetResults gets the results of a survey
+func (c *Client) GetResults(ctx context.Context, slug string) (*Results, error) {
+	results, _, err := c.GetResultsIfChanged(ctx, slug, "")
+	return results, err
+}
+
+// GetResultsIfChanged gets the results of a survey and their ETag. Given the
+// ETag of earlier results that are unchanged, it returns nil results and the
+// same ETag.
+func (c *Client) GetResultsIfChanged(ctx context.Context, slug, etag string) (*Results, string, error) {
+	header := http.Header{}
+	if etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	var results Results
+	respHeader, err := c.do(ctx, http.MethodGet, surveyPath(slug)+"/results", nil, header, &results)
+	if err != nil {
+		return nil, "", err
+	}
+	if respHeader == nil {
+		return nil, etag, nil
+	}
+	return &results, respHeader.Get("ETag"), nil
+}
+
+// WatchResults polls the results of a survey every interval until ctx is
+// done, calling changed with the first results and whenever they change
+func (c *Client) WatchResults(ctx context.Context, slug string, interval time.Duration, changed func(*Results)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	etag := ""
+	for {
+		results, newETag, err := c.GetResultsIfChanged(ctx, slug, etag)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if results != nil {
+			etag = newETag
+			changed(results)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// GenerateSurvey generates a survey definition from a description with the
+// service's AI provider. Calling it gives the consent the service requires to
+// send the description to that provider.
+func (c *Client) GenerateSurvey(ctx context.Context, description string) (*Generated, error) {
+	body := map[string]interface{}{"description": description, "consent": true}
+	var generated Generated
+	if _, err := c.do(ctx, http.MethodPost, "/surveys/generate", body, nil, &generated); err != nil {
+		return nil, err
+	}
+	return &generated, nil
+}
+
+// surveyPath returns the API path of a survey
+func surveyPath(slug string) string {
+	return "/surveys/" + url.PathEscape(slug)
+}
+
+// do sends a request with the given extra header to an API path under
+// /api/v1, retrying it while it fails in a way worth retrying, and decodes
+// the JSON response into out. It returns the response header, or nil if the
+// server answered 304 Not Modified to an If-None-Match header.
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, header http.Header, out interface{}) (http.Header, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	// Only reads and keyed submissions are safe to send again after a failure
+	// whose outcome is unknown
+	replayable := method == http.MethodGet || header.Get("Idempotency-Key") != ""
+
+	backoff := c.MinBackoff
+	for attempt := 0; ; attempt++ {
+		respHeader, err := c.send(ctx, method, path, data, header, out)
+		if err == nil || attempt >= c.MaxRetries || !retryable(err, replayable) {
+			return respHeader, err
+		}
+
+		wait := backoff
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.retryAfter > 0 {
+			wait = apiErr.retryAfter
+		}
+		if c.MaxBackoff > 0 && wait > c.MaxBackoff {
+			wait = c.MaxBackoff
+		}
+		backoff *= 2
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether a failed request is worth sending again. Requests
+// rejected by rate limits or an in-flight submission with the same key were
+// not processed, so they are always retried; other transient failures only if
+// the request is replayable.
+func retryable(err error, replayable bool) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusTooManyRequests:
+			return true
+		case http.StatusConflict:
+			return apiErr.Code == "idempotency_key_in_use"
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return replayable
+		}
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return replayable && (errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+// send sends a request once
+func (c *Client) send(ctx context.Context, method, path string, data []byte, header http.Header, out interface{}) (http.Header, error) {
+	var reader io.Reader
+	if data != nil {
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+"/api/v1"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if resp.StatusCode >= 400 {
+		apiErr := &Error{}
+		if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil || apiErr.Title == "" {
+			apiErr.Title = http.StatusText(resp.StatusCode)
+		}
+		apiErr.Status = resp.StatusCode
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			apiErr.retryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, apiErr
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp.Header, nil
+}
@@ -0,0 +1,253 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient creates a client of server that retries without waiting long
+func newTestClient(server *httptest.Server) *Client {
+	c := New(server.URL, "sk_test")
+	c.MinBackoff = time.Millisecond
+	c.MaxBackoff = 5 * time.Millisecond
+	return c
+}
+
+func TestCreateAndGetSurvey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sk_test", r.Header.Get("Authorization"))
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v1/surveys":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "lunch", body["slug"])
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"slug": "lunch", "title": "Lunch?"}`))
+		case "GET /api/v1/surveys/lunch":
+			w.Write([]byte(`{"slug": "lunch", "title": "Lunch?", "definition": {"questions": []}, "version": 2}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	c := newTestClient(server)
+
+	survey, err := c.CreateSurvey(context.Background(), "lunch", "questions: []")
+	require.NoError(t, err)
+	assert.Equal(t, "Lunch?", survey.Title)
+
+	survey, err = c.GetSurvey(context.Background(), "lunch")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"questions": []}`, string(survey.Definition))
+	assert.Equal(t, 2, survey.Version)
+}
+
+func TestSubmitResponse_RetriesWithSameIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/surveys/lunch/responses", r.URL.Path)
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body struct {
+			Answers map[string]Answer `json:"answers"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"pizza"}, body.Answers["food"].SelectedOptions)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "7f6c1c1e-3b4d-4f63-9a52-0d6f7e1b2a3c"}`))
+	}))
+	defer server.Close()
+
+	submission, err := newTestClient(server).SubmitResponse(context.Background(), "lunch", map[string]Answer{"food": {SelectedOptions: []string{"pizza"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "7f6c1c1e-3b4d-4f63-9a52-0d6f7e1b2a3c", submission.ID.String())
+	require.Len(t, keys, 3)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1])
+	assert.Equal(t, keys[0], keys[2])
+
+	keys = nil
+	_, err = newTestClient(server).SubmitResponseWithKey(context.Background(), "lunch", map[string]Answer{"food": {SelectedOptions: []string{"pizza"}}}, "queued-42")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"queued-42", "queued-42", "queued-42"}, keys)
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		code     string
+		call     func(*Client) error
+		attempts int32
+	}{
+		{"rate limited creation", http.StatusTooManyRequests, "rate_limited", createLunch, 4},
+		{"unavailable creation", http.StatusServiceUnavailable, "service_unavailable", createLunch, 1},
+		{"unavailable read", http.StatusServiceUnavailable, "service_unavailable", getResults, 4},
+		{"bad gateway read", http.StatusBadGateway, "", getResults, 4},
+		{"server error", http.StatusInternalServerError, "internal_error", getResults, 1},
+		{"not found", http.StatusNotFound, "survey_not_found", getResults, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(tt.status)
+				if tt.code != "" {
+					json.NewEncoder(w).Encode(map[string]interface{}{"status": tt.status, "code": tt.code, "title": "Failed"})
+				}
+			}))
+			defer server.Close()
+
+			err := tt.call(newTestClient(server))
+			var apiErr *Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.status, apiErr.Status)
+			assert.True(t, IsCode(err, tt.code))
+			assert.Equal(t, tt.attempts, attempts.Load())
+		})
+	}
+}
+
+func createLunch(c *Client) error {
+	_, err := c.CreateSurvey(context.Background(), "lunch", "questions: []")
+	return err
+}
+
+func getResults(c *Client) error {
+	_, err := c.GetResults(context.Background(), "lunch")
+	return err
+}
+
+func TestRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"totalVotes": 3, "questionResults": {"food": {"questionId": "food", "optionCounts": {"pizza": 3}}}}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	c.MaxBackoff = 50 * time.Millisecond // Caps the second of Retry-After
+	start := time.Now()
+	results, err := c.GetResults(context.Background(), "lunch")
+	require.NoError(t, err)
+	assert.Equal(t, 3, results.QuestionResults["food"].OptionCounts["pizza"])
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryStopsWhenContextIsDone(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	c.MinBackoff, c.MaxBackoff = time.Hour, time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.GetResults(ctx, "lunch")
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr, "the last failure is returned")
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.Status)
+	assert.Equal(t, int32(1), attempts.Load())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestGenerateSurvey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/surveys/generate", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "Team lunch preferences", body["description"])
+		assert.Equal(t, true, body["consent"])
+		w.Write([]byte(`{"definition": {"questions": [{"id": "food"}]}, "tokens_used": 120, "cost": 0.001}`))
+	}))
+	defer server.Close()
+
+	generated, err := newTestClient(server).GenerateSurvey(context.Background(), "Team lunch preferences")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"questions": [{"id": "food"}]}`, string(generated.Definition))
+	assert.Equal(t, 120, generated.TokensUsed)
+}
+
+func TestManageSurveys(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		if r.URL.Path == "/api/v1/surveys" {
+			w.Write([]byte(`[{"slug": "lunch"}, {"slug": "dinner"}]`))
+			return
+		}
+		w.Write([]byte(`{"slug": "lunch"}`))
+	}))
+	defer server.Close()
+	c := newTestClient(server)
+
+	surveys, err := c.ListSurveys(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, surveys, 2)
+	_, err = c.UpdateSurvey(context.Background(), "lunch", "questions: []")
+	require.NoError(t, err)
+	_, err = c.ArchiveSurvey(context.Background(), "lunch")
+	require.NoError(t, err)
+
+	// Slugs are escaped in paths
+	_, err = c.GetSurvey(context.Background(), "lunch/../admin?x")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"GET /api/v1/surveys",
+		"PUT /api/v1/surveys/lunch",
+		"POST /api/v1/surveys/lunch/archive",
+		"GET /api/v1/surveys/lunch%2F..%2Fadmin%3Fx",
+	}, requests)
+}
+
+func TestWatchResults(t *testing.T) {
+	var votes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + string(rune('0'+votes.Load())) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		json.NewEncoder(w).Encode(Results{TotalVotes: int(votes.Load())})
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var seen []int
+	err := newTestClient(server).WatchResults(ctx, "lunch", 10*time.Millisecond, func(r *Results) {
+		seen = append(seen, r.TotalVotes)
+		if r.TotalVotes == 2 {
+			cancel()
+			return
+		}
+		votes.Add(1)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, seen, "called once per change")
+}
@@ -5,7 +5,8 @@
 
 import yaml from 'js-yaml'
 
-// Survey definition JSON Schema - matches internal/models/survey.go
+// Survey definition JSON Schema - matches internal/models/survey.go; used
+// until the one served at /api/v1/schema/survey-definition.json loads
 const surveySchema = {
   $schema: 'http://json-schema.org/draft-07/schema#',
   title: 'Survey Definition',
@@ -34,8 +35,8 @@ const surveySchema = {
           },
           type: {
             type: 'string',
-            enum: ['single', 'multi', 'text'],
-            description: 'Question type: "single" (radio buttons), "multi" (checkboxes), or "text" (free-form input)'
+            enum: ['single', 'multi', 'text', 'matrix', 'number', 'date', 'datetime'],
+            description: 'Question type: "single" (radio buttons), "multi" (checkboxes), "text" (free-form input), "matrix" (rows rated on the options scale), "number", "date", or "datetime"'
           },
           required: {
             type: 'boolean',
@@ -44,7 +45,7 @@ const surveySchema = {
           },
           options: {
             type: 'array',
-            description: 'Available choices for single/multi questions (2-20 options, required for single/multi types)',
+            description: 'Available choices for single/multi questions, or the rating scale of matrix questions (2-20 options)',
             minItems: 2,
             maxItems: 20,
             items: {
@@ -63,6 +64,36 @@ const surveySchema = {
                 }
               }
             }
+          },
+          min: {
+            type: 'string',
+            description: 'Lowest answer of number, date (YYYY-MM-DD), and datetime (YYYY-MM-DDTHH:MM) questions'
+          },
+          max: {
+            type: 'string',
+            description: 'Highest answer of number, date (YYYY-MM-DD), and datetime (YYYY-MM-DDTHH:MM) questions'
+          },
+          rows: {
+            type: 'array',
+            description: 'Statements of matrix questions, each rated on the options scale (1-20 rows)',
+            minItems: 1,
+            maxItems: 20,
+            items: {
+              type: 'object',
+              required: ['id', 'text'],
+              properties: {
+                id: {
+                  type: 'string',
+                  description: 'Unique row identifier within this question',
+                  maxLength: 64
+                },
+                text: {
+                  type: 'string',
+                  description: 'The statement displayed to users',
+                  maxLength: 500
+                }
+              }
+            }
           }
         }
       }
@@ -71,16 +102,6 @@ const surveySchema = {
       type: 'boolean',
       description: 'If true, voter identities are hidden in results (default: false)',
       default: false
-    },
-    startsAt: {
-      type: 'string',
-      format: 'date-time',
-      description: 'When the survey opens for responses (ISO 8601 format, e.g., "2025-01-01T00:00:00Z")'
-    },
-    endsAt: {
-      type: 'string',
-      format: 'date-time',
-      description: 'When the survey closes for new responses (ISO 8601 format)'
     }
   },
   additionalProperties: false
@@ -529,20 +550,17 @@ class SurveyEditor {
     this.currentFormat = options.format || 'json'
     this.hiddenInput = options.hiddenInput ? document.getElementById(options.hiddenInput) : null
     this.onValidationChange = options.onValidationChange || null
-
-    // Configure JSON Schema validation
-    monaco.languages.json.jsonDefaults.setDiagnosticsOptions({
-      validate: true,
-      allowComments: false,
-      schemaValidation: 'error',
-      schemas: [
-        {
-          uri: 'https://survey.openmeet.net/schema/survey.json',
-          fileMatch: ['*'],
-          schema: surveySchema
-        }
-      ]
-    })
+    this.onChange = options.onChange || null
+    // Endpoint linting definitions on the server, for the errors and
+    // warnings the JSON schema cannot express
+    this.lintURL = options.lintURL || null
+    this.lintTimer = null
+    this.lintSeq = 0
+
+    // Configure JSON Schema validation, with the schema the server publishes
+    // once it loads
+    this.setSchema(surveySchema)
+    if (options.schemaURL) this.loadSchema(options.schemaURL)
 
     // Create format toggle buttons
     this.createFormatToggle()
@@ -577,6 +595,8 @@ class SurveyEditor {
     this.editor.onDidChangeModelContent(() => {
       this.syncToHiddenInput()
       this.updateValidationStatus()
+      this.scheduleLint()
+      if (this.onChange) this.onChange()
     })
 
     // Initial sync
@@ -584,6 +604,81 @@ class SurveyEditor {
 
     // Delay initial validation check to let Monaco process
     setTimeout(() => this.updateValidationStatus(), 500)
+    this.scheduleLint()
+  }
+
+  setSchema(schema) {
+    monaco.languages.json.jsonDefaults.setDiagnosticsOptions({
+      validate: true,
+      allowComments: false,
+      schemaValidation: 'error',
+      schemas: [
+        {
+          uri: 'https://survey.openmeet.net/schema/survey.json',
+          fileMatch: ['*'],
+          schema
+        }
+      ]
+    })
+  }
+
+  async loadSchema(url) {
+    try {
+      const res = await fetch(url)
+      if (res.ok) this.setSchema(await res.json())
+    } catch (e) {
+      // Keep the bundled schema
+    }
+  }
+
+  // Lint the definition once typing pauses, dropping the markers of the
+  // previous content meanwhile
+  scheduleLint() {
+    if (!this.lintURL) return
+    clearTimeout(this.lintTimer)
+    this.lintSeq++
+    const model = this.editor.getModel()
+    if (model) monaco.editor.setModelMarkers(model, 'survey-lint', [])
+    this.lintTimer = setTimeout(() => this.lint(), 600)
+  }
+
+  async lint() {
+    const model = this.editor.getModel()
+    if (!model) return
+    const seq = this.lintSeq
+
+    let report
+    try {
+      const res = await fetch(this.lintURL, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        credentials: 'same-origin',
+        body: JSON.stringify({ definition: this.editor.getValue() })
+      })
+      if (!res.ok) return
+      report = await res.json()
+    } catch (e) {
+      return // Linting is best effort; the server validates on submit
+    }
+    // Ignore the report of content that has changed since
+    if (seq !== this.lintSeq || model.isDisposed()) return
+
+    const toMarker = (issue, severity) => {
+      const line = Math.min(Math.max(issue.line || 1, 1), model.getLineCount())
+      return {
+        severity,
+        message: issue.suggestion ? `${issue.message}\n${issue.suggestion}` : issue.message,
+        startLineNumber: line,
+        startColumn: model.getLineFirstNonWhitespaceColumn(line) || 1,
+        endLineNumber: line,
+        endColumn: model.getLineMaxColumn(line)
+      }
+    }
+    monaco.editor.setModelMarkers(model, 'survey-lint', [
+      ...(report.errors || []).map(i => toMarker(i, monaco.MarkerSeverity.Error)),
+      ...(report.warnings || []).map(i => toMarker(i, monaco.MarkerSeverity.Warning))
+    ])
+    this.updateValidationStatus()
   }
 
   createFormatToggle() {
@@ -714,6 +809,42 @@ class SurveyEditor {
     this.editor.setValue(content)
   }
 
+  // Load content as is in a format, e.g. a saved draft that may not parse yet
+  loadContent(content, format) {
+    format = format === 'yaml' ? 'yaml' : 'json'
+    this.currentFormat = format
+    monaco.editor.setModelLanguage(this.editor.getModel(), format)
+    this.jsonBtn.className = 'btn btn-sm' + (format === 'json' ? ' btn-primary' : ' btn-secondary')
+    this.yamlBtn.className = 'btn btn-sm' + (format === 'yaml' ? ' btn-primary' : ' btn-secondary')
+    this.editor.setValue(content)
+  }
+
+  // Parse the content in its format, or return null if it doesn't parse
+  parseContent() {
+    try {
+      return this.currentFormat === 'yaml' ? this.parseYaml(this.getValue()) : JSON.parse(this.getValue())
+    } catch {
+      return null
+    }
+  }
+
+  // Append a question, e.g. one saved to the question bank, renaming its ID
+  // if the definition already has a question with it
+  insertQuestion(question) {
+    const def = this.parseContent()
+    if (!def || typeof def !== 'object' || Array.isArray(def)) {
+      throw new Error('Fix syntax errors before inserting a question')
+    }
+    if (!Array.isArray(def.questions)) def.questions = []
+
+    const ids = new Set(def.questions.map(q => q && q.id))
+    let id = question.id
+    for (let n = 2; ids.has(id); n++) id = question.id + '-' + n
+    def.questions.push({ ...question, id })
+
+    this.editor.setValue(this.currentFormat === 'yaml' ? this.toYaml(def) : JSON.stringify(def, null, 2))
+  }
+
   loadExample(exampleName) {
     const example = examples[exampleName]
     if (!example) {
@@ -763,6 +894,7 @@ class SurveyEditor {
   }
 
   dispose() {
+    clearTimeout(this.lintTimer)
     this.editor.dispose()
   }
 }